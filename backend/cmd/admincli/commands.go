@@ -0,0 +1,435 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/handlers_new/admin"
+	"github.com/geoo115/charity-management-system/internal/jobs"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// parseFlags parses a command's flags, reporting bad usage as errUsage
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+// required reports a missing flag as bad usage
+func required(fs *flag.FlagSet, values map[string]string) error {
+	for name, value := range values {
+		if strings.TrimSpace(value) == "" {
+			fmt.Fprintf(os.Stderr, "-%s is required\n", name)
+			fs.Usage()
+			return errUsage
+		}
+	}
+	return nil
+}
+
+// audit records a CLI action in the audit log
+func audit(tx *gorm.DB, action, entityType string, entityID uint, description string) error {
+	_, err := writeAudit(tx, action, entityType, entityID, description, "")
+	return err
+}
+
+// Status of a long-running command's audit log entry
+const (
+	auditStarted   = "started"
+	auditCompleted = "completed"
+	auditFailed    = "failed"
+)
+
+// startAudit records a command that can't run in a single transaction before it
+// starts, so a run that is interrupted part way still shows in the audit log
+func startAudit(action, entityType string, entityID uint, description string) (*models.AuditLog, error) {
+	return writeAudit(db.DB, action, entityType, entityID, description, auditStarted)
+}
+
+// finishAudit records how a started command ended
+func finishAudit(entry *models.AuditLog, entityID uint, description string, runErr error) error {
+	status := auditCompleted
+	details := map[string]interface{}{"command": commandLine(), "status": status}
+	if runErr != nil {
+		details["status"] = auditFailed
+		details["error"] = runErr.Error()
+		description += ": " + runErr.Error()
+	}
+	detailsJSON, _ := json.Marshal(details)
+	return db.DB.Model(entry).Updates(map[string]interface{}{
+		"entity_id":    entityID,
+		"description":  description,
+		"details_json": string(detailsJSON),
+	}).Error
+}
+
+// writeAudit creates an audit log entry, with a status for long-running commands
+func writeAudit(tx *gorm.DB, action, entityType string, entityID uint, description, status string) (*models.AuditLog, error) {
+	details := map[string]interface{}{"command": commandLine()}
+	if status != "" {
+		details["status"] = status
+	}
+	detailsJSON, _ := json.Marshal(details)
+	entry := &models.AuditLog{
+		Action:      action,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Description: description,
+		DetailsJSON: string(detailsJSON),
+		PerformedBy: operator(),
+		UserAgent:   "admincli",
+		CreatedAt:   time.Now(),
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// randomToken returns n random bytes as hex
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// findUser looks a user up by email
+func findUser(tx *gorm.DB, email string) (*models.User, error) {
+	var user models.User
+	if err := tx.Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(email))).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("no user with email %s", email)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// createAdmin creates an active admin with a temporary password they must change
+func createAdmin(args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the new admin")
+	first := fs.String("first", "", "first name")
+	last := fs.String("last", "", "last name")
+	scope := fs.String("scope", "", "limit the admin to a scoped role such as volunteer_coordinator (default full admin)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, map[string]string{"email": *email, "first": *first, "last": *last}); err != nil {
+		return err
+	}
+	if *scope != "" {
+		if _, ok := models.FindAdminScope(*scope); !ok {
+			return fmt.Errorf("unknown admin scope %q", *scope)
+		}
+	}
+
+	if err := connect(); err != nil {
+		return err
+	}
+
+	token, err := randomToken(9)
+	if err != nil {
+		return err
+	}
+	// Meets the password rules: upper and lower case, a digit and a symbol
+	password := "Tmp-" + token + "7!"
+
+	user := models.User{
+		FirstName:     strings.TrimSpace(*first),
+		LastName:      strings.TrimSpace(*last),
+		Email:         strings.ToLower(strings.TrimSpace(*email)),
+		Role:          models.RoleAdmin,
+		AdminScope:    *scope,
+		Status:        models.StatusActive,
+		FirstLogin:    true,
+		EmailVerified: true,
+	}
+	if err := user.HashPasswordWithValue(password); err != nil {
+		return err
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&models.User{}).Where("LOWER(email) = ?", user.Email).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return fmt.Errorf("a user with email %s already exists", user.Email)
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		role := "admin"
+		if user.AdminScope != "" {
+			role = "admin (" + user.AdminScope + ")"
+		}
+		return audit(tx, "CreateUser", "User", user.ID, fmt.Sprintf("Created %s %s from the admin CLI", role, user.Email))
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created admin %d (%s)\nTemporary password: %s\nAsk them to sign in and change it straight away.\n",
+		user.ID, user.Email, password)
+	return nil
+}
+
+// resetPassword ends a user's sessions, locks their current password and emails a
+// reset link
+func resetPassword(args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the user")
+	hours := fs.Int("expires-hours", 24, "hours the reset link stays valid")
+	printLink := fs.Bool("print-link", false, "also print the reset link, for when email is unavailable")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, map[string]string{"email": *email}); err != nil {
+		return err
+	}
+	if *hours <= 0 {
+		return errors.New("-expires-hours must be positive")
+	}
+
+	if err := connect(); err != nil {
+		return err
+	}
+
+	resetToken, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	hashedToken, err := bcrypt.GenerateFromPassword([]byte(resetToken), 6)
+	if err != nil {
+		return err
+	}
+	locked, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	lockedHash, err := bcrypt.GenerateFromPassword([]byte(locked), 8)
+	if err != nil {
+		return err
+	}
+
+	var user *models.User
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		found, err := findUser(tx, *email)
+		if err != nil {
+			return err
+		}
+		user = found
+
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.PasswordReset{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.PasswordReset{
+			UserID:    user.ID,
+			Token:     string(hashedToken),
+			ExpiresAt: time.Now().Add(time.Duration(*hours) * time.Hour),
+			CreatedAt: time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		// A random password nobody knows, and a new token version to sign out every session
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"password":      string(lockedHash),
+			"token_version": gorm.Expr("token_version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		return audit(tx, "ForcePasswordReset", "User", user.ID,
+			fmt.Sprintf("Forced a password reset for %s from the admin CLI", user.Email))
+	})
+	if err != nil {
+		return err
+	}
+
+	baseURL := os.Getenv("FRONTEND_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+	resetURL := fmt.Sprintf("%s/reset-password?token=%s", baseURL, resetToken)
+	if service := notifications.GetService(); service != nil {
+		if err := service.SendPasswordResetEmail(*user, resetToken, resetURL); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to email the reset link: %v\n", err)
+		}
+	}
+
+	fmt.Printf("Signed out %s and sent a password reset link valid for %d hours\n", user.Email, *hours)
+	if *printLink {
+		fmt.Println(resetURL)
+	}
+	return nil
+}
+
+// runJob runs one round of a scheduled job. Jobs commit their own work and send
+// emails and notifications as they go, so a run can't be rolled back; the audit entry
+// is written before the job starts and completed or marked failed afterwards.
+func runJob(args []string) error {
+	fs := flag.NewFlagSet("run-job", flag.ContinueOnError)
+	name := fs.String("name", "", "job to run: "+strings.Join(jobs.JobNames(), ", "))
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, map[string]string{"name": *name}); err != nil {
+		return err
+	}
+
+	if err := connect(); err != nil {
+		return err
+	}
+
+	entry, err := startAudit("RunJob", "Job", 0, fmt.Sprintf("Started the %s job from the admin CLI", *name))
+	if err != nil {
+		return fmt.Errorf("job not run, the audit log failed: %w", err)
+	}
+
+	started := time.Now()
+	runErr := jobs.RunJob(*name)
+	took := time.Since(started).Round(time.Millisecond)
+
+	if err := finishAudit(entry, 0, fmt.Sprintf("Ran the %s job from the admin CLI in %s", *name, took), runErr); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: audit log %d left as started: %v\n", entry.ID, err)
+	}
+	if runErr != nil {
+		return runErr
+	}
+	fmt.Printf("Ran %s in %s; see the log above for what it did\n", *name, took)
+	return nil
+}
+
+// releaseTickets issues tickets for a visit day, as the admin ticket release does.
+// Each ticket is saved and the visitor notified as it is issued, so a release can't be
+// rolled back as a whole; the audit entry is written before the release starts and
+// completed with the outcome, and the release run keeps the full allocation.
+func releaseTickets(args []string) error {
+	fs := flag.NewFlagSet("release-tickets", flag.ContinueOnError)
+	date := fs.String("date", "", "visit day to release tickets for (YYYY-MM-DD)")
+	category := fs.String("category", "", "only release this category (default food and general)")
+	max := fs.Int("max", 0, "most tickets to release per category (default remaining capacity)")
+	dryRun := fs.Bool("dry-run", false, "show who would receive tickets without issuing them")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, map[string]string{"date": *date}); err != nil {
+		return err
+	}
+	releaseDate, err := time.Parse("2006-01-02", *date)
+	if err != nil {
+		return errors.New("-date must be YYYY-MM-DD")
+	}
+	if !admin.IsValidReleaseDay(releaseDate) {
+		return errors.New("tickets can only be released on Tuesday, Wednesday or Thursday")
+	}
+
+	if err := connect(); err != nil {
+		return err
+	}
+
+	var categories []string
+	maxTickets := map[string]int{}
+	if *category != "" {
+		categories = []string{*category}
+		if *max > 0 {
+			maxTickets[*category] = *max
+		}
+	} else if *max > 0 {
+		maxTickets[models.CategoryFood] = *max
+		maxTickets[models.CategoryGeneral] = *max
+	}
+
+	if *dryRun {
+		plan := admin.PlanTicketRelease(*date, categories, maxTickets)
+		for _, categoryPlan := range plan.Categories {
+			fmt.Printf("%s: %d of %d approved requests would get tickets (capacity %d)\n",
+				categoryPlan.Category, categoryPlan.Released, categoryPlan.Eligible, categoryPlan.Capacity)
+			for _, recipient := range categoryPlan.Recipients {
+				fmt.Printf("  %3d  %-12s %s\n", recipient.Position, recipient.Reference, recipient.VisitorName)
+			}
+		}
+		fmt.Printf("Dry run: no tickets were issued. %d requests still pending.\n", plan.RemainingInQueue)
+		return nil
+	}
+
+	entry, err := startAudit("TicketRelease", "ReleaseRun", 0, fmt.Sprintf("Started a ticket release for %s from the admin CLI", *date))
+	if err != nil {
+		return fmt.Errorf("tickets not released, the audit log failed: %w", err)
+	}
+
+	result := admin.ProcessTicketRelease(*date, categories, maxTickets, models.ReleaseTriggerManual, nil)
+	var releaseErr error
+	if len(result.FailedReleases) > 0 {
+		releaseErr = fmt.Errorf("%d tickets could not be saved", len(result.FailedReleases))
+	}
+	if err := finishAudit(entry, result.ReleaseRunID,
+		fmt.Sprintf("Released %d tickets for %s from the admin CLI", result.TotalReleased, *date), releaseErr); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: audit log %d left as started: %v\n", entry.ID, err)
+	}
+	fmt.Printf("Released %d tickets for %s (food %d, general %d); %d requests still pending\n",
+		result.TotalReleased, *date, result.FoodTickets, result.GeneralTickets, result.RemainingInQueue)
+	if len(result.FailedReleases) > 0 {
+		fmt.Printf("%d tickets could not be saved; see the log above\n", len(result.FailedReleases))
+	}
+	if result.ReleaseRunID != 0 {
+		fmt.Printf("Recorded as release run %d (%s)\n", result.ReleaseRunID, result.ReleaseRunHash)
+	}
+	return nil
+}
+
+// recomputeHours rebuilds a volunteer's total hours from their completed shifts
+func recomputeHours(args []string) error {
+	fs := flag.NewFlagSet("recompute-hours", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the volunteer")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, map[string]string{"email": *email}); err != nil {
+		return err
+	}
+
+	if err := connect(); err != nil {
+		return err
+	}
+
+	var user *models.User
+	var before, after float64
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		found, err := findUser(tx, *email)
+		if err != nil {
+			return err
+		}
+		user = found
+
+		before, after, err = services.NewVolunteerStatementService().RecomputeTotalHoursTx(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		return audit(tx, "RecomputeHours", "User", user.ID,
+			fmt.Sprintf("Recomputed %s's total hours from %.1f to %.1f from the admin CLI", user.Email, before, after))
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: total hours %.1f -> %.1f\n", user.Email, before, after)
+	return nil
+}
@@ -0,0 +1,123 @@
+// Command admincli runs operational tasks directly against the database, for when the
+// API is down or a task has no endpoint. Every command is recorded in the audit log.
+// create-admin, reset-password and recompute-hours make their change and the audit
+// entry in one transaction. run-job and release-tickets send emails and notifications
+// as they go and can't be rolled back, so their audit entry is written as "started"
+// first and updated to "completed" or "failed" when they finish.
+//
+//	admincli create-admin -email ops@example.org -first Sam -last Lee [-scope volunteer_coordinator]
+//	admincli reset-password -email user@example.org [-print-link]
+//	admincli run-job -name reminder_emails
+//	admincli release-tickets -date 2026-03-10 [-category food] [-max 40] [-dry-run]
+//	admincli recompute-hours -email volunteer@example.org
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"github.com/joho/godotenv"
+)
+
+// command is one admincli subcommand
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"create-admin":    {"Create an admin account and print its temporary password", createAdmin},
+	"reset-password":  {"End a user's sessions and email them a password reset link", resetPassword},
+	"run-job":         {"Run a background job once, such as after a failed run", runJob},
+	"release-tickets": {"Release tickets for a visit day", releaseTickets},
+	"recompute-hours": {"Rebuild a volunteer's total hours from their completed shifts", recomputeHours},
+}
+
+// errUsage means the command line was wrong; the message has already been printed
+var errUsage = errors.New("usage")
+
+func main() {
+	log.SetFlags(log.LstdFlags)
+	log.SetOutput(os.Stderr)
+
+	if len(os.Args) < 2 || os.Args[1] == "help" || os.Args[1] == "-h" || os.Args[1] == "--help" {
+		printUsage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		printUsage()
+		os.Exit(2)
+	}
+
+	loadEnvironment()
+	if err := cmd.run(os.Args[2:]); err != nil {
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// printUsage lists the commands
+func printUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "Usage: admincli <command> [flags]\n\nCommands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun admincli <command> -h for a command's flags.")
+}
+
+// loadEnvironment loads the first .env file found, as the API server does
+func loadEnvironment() {
+	for _, path := range []string{".env", filepath.Join("backend", ".env")} {
+		if err := godotenv.Load(path); err == nil {
+			return
+		}
+	}
+}
+
+// connect opens the database and notifications once a command's flags are valid
+func connect() error {
+	if _, err := db.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to the database: %w", err)
+	}
+	if err := notifications.Initialize(); err != nil {
+		log.Printf("Warning: notifications unavailable, emails will not be sent: %v", err)
+	}
+	return nil
+}
+
+// operator names the person running the CLI in audit logs
+func operator() string {
+	name := "unknown"
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		name = current.Username
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		name += "@" + host
+	}
+	return "admincli (" + name + ")"
+}
+
+// commandLine is the command as run, for audit details
+func commandLine() string {
+	return strings.Join(os.Args[1:], " ")
+}
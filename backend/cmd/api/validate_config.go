@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/auth"
+	"github.com/geoo115/charity-management-system/internal/config"
+	"github.com/geoo115/charity-management-system/internal/db"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// configCheckTimeout bounds each connectivity check so a hung dependency cannot stall
+// a deploy hook
+const configCheckTimeout = 5 * time.Second
+
+// Check results
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// configCheck is the result of one check in the validation report
+type configCheck struct {
+	Name       string      `json:"name"`
+	Status     string      `json:"status"`
+	Detail     string      `json:"detail"`
+	DurationMS int64       `json:"duration_ms"`
+	Data       interface{} `json:"data,omitempty"`
+}
+
+// configReport is printed by --validate-config
+type configReport struct {
+	Valid       bool          `json:"valid"`
+	Environment string        `json:"environment"`
+	CheckedAt   time.Time     `json:"checked_at"`
+	Checks      []configCheck `json:"checks"`
+}
+
+// validateConfig checks the configuration and the services it points at without
+// starting the server or changing the database, prints a JSON report and returns the
+// exit code: 0 when nothing failed, 1 otherwise. Warnings do not fail the run. When
+// the configuration failed to load, loadErr is reported and nothing else is checked.
+func validateConfig(cfg *config.Config, loadErr error) int {
+	report := configReport{
+		Valid:       true,
+		Environment: os.Getenv("APP_ENV"),
+		CheckedAt:   time.Now().UTC(),
+	}
+	if cfg != nil {
+		report.Environment = cfg.Environment
+	}
+
+	run := func(name string, check func(ctx context.Context) (string, string, interface{})) {
+		ctx, cancel := context.WithTimeout(context.Background(), configCheckTimeout)
+		defer cancel()
+		start := time.Now()
+		status, detail, data := check(ctx)
+		report.Checks = append(report.Checks, configCheck{
+			Name:       name,
+			Status:     status,
+			Detail:     detail,
+			DurationMS: time.Since(start).Milliseconds(),
+			Data:       data,
+		})
+		if status == checkFail {
+			report.Valid = false
+		}
+	}
+
+	run("config", func(ctx context.Context) (string, string, interface{}) {
+		if loadErr != nil || cfg == nil {
+			return checkFail, fmt.Sprintf("configuration did not load: %v", loadErr), nil
+		}
+		return checkOK, "loaded", nil
+	})
+	if !report.Valid {
+		return printConfigReport(report)
+	}
+
+	run("secrets", checkSecrets)
+
+	var database *gorm.DB
+	run("postgres", func(ctx context.Context) (string, string, interface{}) {
+		conn, err := db.Open(ctx)
+		if err != nil {
+			return checkFail, err.Error(), nil
+		}
+		database = conn
+		return checkOK, "connected", nil
+	})
+	run("migrations", func(ctx context.Context) (string, string, interface{}) {
+		if database == nil {
+			return checkFail, "skipped: no database connection", nil
+		}
+		return checkMigrations(ctx, database)
+	})
+	if database != nil {
+		if sqlDB, err := database.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+
+	run("redis", func(ctx context.Context) (string, string, interface{}) {
+		return checkRedis(ctx, cfg)
+	})
+	run("email", checkEmail)
+	run("storage", func(ctx context.Context) (string, string, interface{}) {
+		return checkStorage()
+	})
+
+	return printConfigReport(report)
+}
+
+// printConfigReport prints the report as JSON and returns the exit code for it
+func printConfigReport(report configReport) int {
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+	if !report.Valid {
+		return 1
+	}
+	return 0
+}
+
+// checkSecrets verifies the secrets the server cannot run without
+func checkSecrets(ctx context.Context) (string, string, interface{}) {
+	if err := auth.CheckSigningConfig(); err != nil {
+		return checkFail, err.Error(), nil
+	}
+	if os.Getenv("DB_PASSWORD") == "" {
+		return checkFail, "DB_PASSWORD environment variable is required", nil
+	}
+	if id := auth.ActiveKeyID(); id != "" {
+		return checkOK, "tokens are signed with key " + id, nil
+	}
+	return checkOK, "tokens are signed with JWT_SECRET", nil
+}
+
+// checkMigrations fails when a migration's last attempt failed and warns about
+// migrations that will run on start
+func checkMigrations(ctx context.Context, database *gorm.DB) (string, string, interface{}) {
+	state, err := db.GetMigrationState(database.WithContext(ctx))
+	if err != nil {
+		return checkFail, err.Error(), nil
+	}
+	switch {
+	case len(state.Failed) > 0:
+		return checkFail, "failed migrations: " + strings.Join(state.Failed, ", "), state
+	case len(state.Unknown) > 0:
+		return checkWarn, "database has migrations this build does not know: " + strings.Join(state.Unknown, ", "), state
+	case len(state.Pending) > 0:
+		return checkWarn, fmt.Sprintf("%d migrations will run on start", len(state.Pending)), state
+	}
+	return checkOK, fmt.Sprintf("%d migrations applied", state.Applied), state
+}
+
+// checkRedis pings Redis. Redis is optional, so it only fails when REDIS_ADDR was set
+// explicitly.
+func checkRedis(ctx context.Context, cfg *config.Config) (string, string, interface{}) {
+	_, explicit := os.LookupEnv("REDIS_ADDR")
+	if cfg.RedisAddr == "" {
+		return checkWarn, "not configured; background jobs and caching are disabled", nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:        cfg.RedisAddr,
+		Password:    cfg.RedisPassword,
+		DialTimeout: configCheckTimeout,
+	})
+	defer client.Close()
+	if err := client.Ping(ctx).Err(); err != nil {
+		if !explicit {
+			return checkWarn, fmt.Sprintf("default address %s unreachable; background jobs and caching are disabled", cfg.RedisAddr), nil
+		}
+		return checkFail, fmt.Sprintf("%s: %v", cfg.RedisAddr, err), nil
+	}
+	return checkOK, "connected to " + cfg.RedisAddr, nil
+}
+
+// checkEmail checks the mail provider the notification service will use is reachable
+func checkEmail(ctx context.Context) (string, string, interface{}) {
+	if os.Getenv("SENDGRID_API_KEY") != "" {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", "api.sendgrid.com:443")
+		if err != nil {
+			return checkFail, "SendGrid unreachable: " + err.Error(), nil
+		}
+		conn.Close()
+		return checkOK, "SendGrid reachable", nil
+	}
+
+	// Same defaults as notifications.Initialize
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		host = "smtp.gmail.com"
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, "587"))
+	if err != nil {
+		return checkFail, "SMTP unreachable: " + err.Error(), nil
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return checkFail, "SMTP greeting failed: " + err.Error(), nil
+	}
+	client.Quit()
+
+	if os.Getenv("SMTP_USERNAME") == "" || os.Getenv("SMTP_PASSWORD") == "" {
+		return checkWarn, host + " reachable but SMTP_USERNAME or SMTP_PASSWORD is not set", nil
+	}
+	return checkOK, host + " reachable", nil
+}
+
+// checkStorage checks the upload and document directories can be written. A missing
+// directory is created on start, so its nearest existing parent is checked instead.
+func checkStorage() (string, string, interface{}) {
+	dirs := map[string]string{
+		"UPLOAD_DIR":            "./uploads",
+		"DOCUMENT_STORAGE_PATH": "uploads/documents",
+	}
+	var problems []string
+	for _, env := range []string{"UPLOAD_DIR", "DOCUMENT_STORAGE_PATH"} {
+		dir := os.Getenv(env)
+		if dir == "" {
+			dir = dirs[env]
+		}
+		if err := checkWritable(dir); err != nil {
+			problems = append(problems, fmt.Sprintf("%s (%s): %v", env, dir, err))
+		}
+	}
+	if len(problems) > 0 {
+		return checkFail, strings.Join(problems, "; "), nil
+	}
+	return checkOK, "upload and document directories are writable", nil
+}
+
+// checkWritable writes and removes a temporary file in dir, or in its nearest
+// existing parent when dir does not exist yet
+func checkWritable(dir string) error {
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return errors.New("not a directory")
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return err
+		}
+		dir = parent
+	}
+
+	f, err := os.CreateTemp(dir, ".validate-config-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestValidateConfigLoadFailure(t *testing.T) {
+	t.Setenv("APP_ENV", "staging")
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	code := validateConfig(nil, errors.New("bad RATE_LIMIT_API"))
+	os.Stdout = stdout
+	w.Close()
+	out, _ := io.ReadAll(r)
+
+	if code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+	var report configReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		t.Fatalf("report is not JSON: %v\n%s", err, out)
+	}
+	if report.Valid || report.Environment != "staging" || len(report.Checks) != 1 {
+		t.Fatalf("got %+v", report)
+	}
+	if check := report.Checks[0]; check.Name != "config" || check.Status != checkFail || check.Detail != "configuration did not load: bad RATE_LIMIT_API" {
+		t.Errorf("got %+v", check)
+	}
+}
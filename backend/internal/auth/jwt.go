@@ -5,80 +5,169 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// ErrTokenVersion is returned when a token was issued before the user's sessions were
+// ended, for example by an admin forcing a logout
+var ErrTokenVersion = errors.New("token has been revoked")
+
+// ErrTokenType is returned when a refresh token is used as an access token or the
+// other way round
+var ErrTokenType = errors.New("token is not valid for this use")
+
+// Token types, carried in the typ claim
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 // TokenClaims defines the claims in the JWT
 type TokenClaims struct {
 	UserID uint   `json:"user_id"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+	// TokenVersion must match the user's token version for the token to be accepted
+	TokenVersion int `json:"tv"`
+	// TokenType is always TokenTypeAccess
+	TokenType string `json:"typ"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken creates a new JWT token for a user
-func GenerateToken(userID uint, email string, role string) (string, error) {
-	// Get JWT secret from environment
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return "", errors.New("JWT_SECRET environment variable is required")
+// jwtSecret returns JWT_SECRET, which signs tokens when no key pairs are configured
+func jwtSecret() ([]byte, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, errors.New("JWT_SECRET environment variable is required")
 	}
 
 	// Ensure minimum security for JWT secret
-	if len(jwtSecret) < 32 {
-		return "", errors.New("JWT_SECRET must be at least 32 characters for security")
-	}
-
-	// Create token with claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, TokenClaims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenExpiry)), // Token valid for 15 minutes
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
-	})
-
-	// Sign and get the complete encoded token as a string
-	tokenString, err := token.SignedString([]byte(jwtSecret))
-	if err != nil {
-		return "", err
+	if len(secret) < 32 {
+		return nil, errors.New("JWT_SECRET must be at least 32 characters for security")
 	}
 
-	return tokenString, nil
+	return []byte(secret), nil
 }
 
-// ValidateToken verifies a JWT token and returns the claims
-func ValidateToken(tokenString string) (*TokenClaims, error) {
-	// Get JWT secret from environment
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return nil, errors.New("JWT_SECRET environment variable is required")
+// roleEnvSuffix turns a role into the suffix of its lifetime settings, e.g. SUPER_ADMIN
+func roleEnvSuffix(role string) string {
+	role = strings.ToLower(role)
+	if role == "superadmin" {
+		role = "super_admin"
 	}
+	return strings.ToUpper(role)
+}
 
-	// Ensure minimum security for JWT secret
-	if len(jwtSecret) < 32 {
-		return nil, errors.New("JWT_SECRET must be at least 32 characters for security")
+// defaultAccessTokenTTL keeps sessions with admin rights short; they are renewed
+// with the refresh token
+var defaultAccessTokenTTL = map[string]time.Duration{
+	"ADMIN":       15 * time.Minute,
+	"SUPER_ADMIN": 15 * time.Minute,
+	"STAFF":       30 * time.Minute,
+}
+
+// tokenTTL reads <prefix>_<ROLE> as a duration such as 15m, then the role's default,
+// then <prefix>
+func tokenTTL(prefix, role string, roleDefaults map[string]time.Duration, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(prefix + "_" + roleEnvSuffix(role))); err == nil && d > 0 {
+		return d
 	}
+	if d, ok := roleDefaults[roleEnvSuffix(role)]; ok {
+		return d
+	}
+	if d, err := time.ParseDuration(os.Getenv(prefix)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
 
-	// Parse the token
-	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+// AccessTokenTTL returns how long an access token issued to the role is valid
+func AccessTokenTTL(role string) time.Duration {
+	return tokenTTL("JWT_ACCESS_TTL", role, defaultAccessTokenTTL, TokenExpiry)
+}
+
+// RefreshTokenTTL returns how long a refresh token issued to the role is valid
+func RefreshTokenTTL(role string) time.Duration {
+	return tokenTTL("JWT_REFRESH_TTL", role, nil, RefreshTokenExpiry)
+}
+
+// registeredClaims fills the standard claims, including the issuer and audience from
+// JWT_ISSUER and JWT_AUDIENCE so other services can check tokens were meant for them
+func registeredClaims(userID uint, ttl time.Duration) jwt.RegisteredClaims {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		Issuer:    os.Getenv("JWT_ISSUER"),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+	if id, err := GenerateSecureTokenID(); err == nil {
+		claims.ID = id
+	}
+	for _, aud := range strings.Split(os.Getenv("JWT_AUDIENCE"), ",") {
+		if aud = strings.TrimSpace(aud); aud != "" {
+			claims.Audience = append(claims.Audience, aud)
 		}
-		return []byte(jwtSecret), nil
-	})
+	}
+	return claims
+}
+
+// verifyRegisteredClaims checks the issuer and audience when they are configured. The
+// first JWT_AUDIENCE entry names this API.
+func verifyRegisteredClaims(claims *jwt.RegisteredClaims) error {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" && !claims.VerifyIssuer(issuer, true) {
+		return errors.New("token issuer is not accepted")
+	}
+	if aud := strings.TrimSpace(strings.Split(os.Getenv("JWT_AUDIENCE"), ",")[0]); aud != "" && !claims.VerifyAudience(aud, true) {
+		return errors.New("token audience is not accepted")
+	}
+	return nil
+}
+
+// GenerateToken creates a new JWT token for a user. tokenVersion is the user's current
+// token version; bumping it invalidates every token issued before.
+func GenerateToken(userID uint, email string, role string, tokenVersion int) (string, error) {
+	secret, err := jwtSecret()
+	if err != nil && ActiveKeyID() == "" {
+		return "", err
+	}
+
+	return signToken(TokenClaims{
+		UserID:           userID,
+		Email:            email,
+		Role:             role,
+		TokenVersion:     tokenVersion,
+		TokenType:        TokenTypeAccess,
+		RegisteredClaims: registeredClaims(userID, AccessTokenTTL(role)),
+	}, secret)
+}
 
+// ValidateToken verifies a JWT token and returns the claims
+func ValidateToken(tokenString string) (*TokenClaims, error) {
+	secret, err := jwtSecret()
+	if err != nil && ActiveKeyID() == "" {
+		return nil, err
+	}
+
+	// Parse the token
+	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, keyFunc(secret))
 	if err != nil {
 		return nil, err
 	}
 
 	// Validate claims
 	if claims, ok := token.Claims.(*TokenClaims); ok && token.Valid {
+		if claims.TokenType != TokenTypeAccess {
+			return nil, ErrTokenType
+		}
+		if err := verifyRegisteredClaims(&claims.RegisteredClaims); err != nil {
+			return nil, err
+		}
+
 		// Check blacklist (Redis). If Redis is not configured this is a no-op.
 		if blacklisted, err := IsTokenBlacklisted(context.Background(), tokenString); err != nil {
 			return nil, fmt.Errorf("failed to check token blacklist: %w", err)
@@ -91,3 +180,11 @@ func ValidateToken(tokenString string) (*TokenClaims, error) {
 
 	return nil, errors.New("invalid token")
 }
+
+// CheckTokenVersion rejects a token issued before the user's sessions were ended
+func CheckTokenVersion(claims *TokenClaims, userTokenVersion int) error {
+	if claims.TokenVersion != userTokenVersion {
+		return ErrTokenVersion
+	}
+	return nil
+}
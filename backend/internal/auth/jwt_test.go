@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTokenTypesAreNotInterchangeable(t *testing.T) {
+	t.Setenv("JWT_SECRET", "0123456789abcdef0123456789abcdef")
+
+	access, err := GenerateToken(7, "user@example.com", "visitor", 3)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	refresh, err := GenerateRefreshToken(7, "user@example.com", "visitor", 3)
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken: %v", err)
+	}
+
+	claims, err := ValidateToken(access)
+	if err != nil {
+		t.Fatalf("access token rejected: %v", err)
+	}
+	if claims.TokenType != TokenTypeAccess || claims.TokenVersion != 3 {
+		t.Fatalf("got type %q, version %d", claims.TokenType, claims.TokenVersion)
+	}
+	if _, err := ValidateToken(refresh); !errors.Is(err, ErrTokenType) {
+		t.Fatalf("refresh token used as access token: got %v, want ErrTokenType", err)
+	}
+
+	refreshClaims, err := ValidateRefreshToken(refresh)
+	if err != nil {
+		t.Fatalf("refresh token rejected: %v", err)
+	}
+	if refreshClaims.TokenType != TokenTypeRefresh || refreshClaims.TokenVersion != 3 {
+		t.Fatalf("got type %q, version %d", refreshClaims.TokenType, refreshClaims.TokenVersion)
+	}
+	if _, err := ValidateRefreshToken(access); !errors.Is(err, ErrTokenType) {
+		t.Fatalf("access token used as refresh token: got %v, want ErrTokenType", err)
+	}
+}
+
+func TestRefreshTokenHandlerEmbedsTokenVersion(t *testing.T) {
+	t.Setenv("JWT_SECRET", "0123456789abcdef0123456789abcdef")
+
+	token, err := RefreshTokenHandler(7, "user@example.com", "admin", 5)
+	if err != nil {
+		t.Fatalf("RefreshTokenHandler: %v", err)
+	}
+	claims, err := ValidateRefreshToken(token)
+	if err != nil {
+		t.Fatalf("ValidateRefreshToken: %v", err)
+	}
+	if claims.TokenVersion != 5 || claims.Role != "admin" {
+		t.Fatalf("got version %d, role %q", claims.TokenVersion, claims.Role)
+	}
+	if err := CheckTokenVersion(&TokenClaims{TokenVersion: 4}, 5); !errors.Is(err, ErrTokenVersion) {
+		t.Fatalf("stale token version accepted: %v", err)
+	}
+}
+
+func TestTokensNeedASecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+	if ActiveKeyID() != "" {
+		t.Skip("signing keys are configured")
+	}
+
+	if _, err := GenerateRefreshToken(7, "user@example.com", "visitor", 0); err == nil {
+		t.Fatal("refresh token issued without a secret")
+	}
+	if _, err := ValidateRefreshToken("x.y.z"); err == nil {
+		t.Fatal("refresh token validated without a secret")
+	}
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Signing keys are RSA key pairs listed in JWT_SIGNING_KEYS as comma separated
+// kid=/path/to/private.pem entries. Tokens are signed with JWT_ACTIVE_KID and verified
+// with any listed key, so a key is rotated by adding the new one, making it active and
+// removing the old one once the tokens it signed have expired. Without any keys tokens
+// are signed with JWT_SECRET as before.
+
+// JWK is the public half of a signing key in JSON Web Key format
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Modulus   string `json:"n"`
+	Exponent  string `json:"e"`
+}
+
+// keyring holds the loaded signing keys
+type keyring struct {
+	keys   map[string]*rsa.PrivateKey
+	active string
+}
+
+var (
+	signingKeys     *keyring
+	signingKeysErr  error
+	signingKeysOnce sync.Once
+)
+
+// loadKeyring reads the signing keys once; later calls return the same keys
+func loadKeyring() (*keyring, error) {
+	signingKeysOnce.Do(func() {
+		signingKeys, signingKeysErr = readKeyring(os.Getenv("JWT_SIGNING_KEYS"), os.Getenv("JWT_ACTIVE_KID"))
+		if signingKeysErr != nil {
+			log.Printf("Failed to load JWT signing keys: %v", signingKeysErr)
+		}
+	})
+	return signingKeys, signingKeysErr
+}
+
+// readKeyring parses a JWT_SIGNING_KEYS list and checks the active kid is in it
+func readKeyring(spec, active string) (*keyring, error) {
+	ring := &keyring{keys: map[string]*rsa.PrivateKey{}}
+	var last string
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, path, ok := strings.Cut(entry, "=")
+		kid, path = strings.TrimSpace(kid), strings.TrimSpace(path)
+		if !ok || kid == "" || path == "" {
+			return nil, fmt.Errorf("signing key %q must be kid=path", entry)
+		}
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %w", kid, err)
+		}
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %w", kid, err)
+		}
+		ring.keys[kid] = key
+		last = kid
+	}
+	if len(ring.keys) == 0 {
+		return ring, nil
+	}
+
+	// The last listed key signs unless another is named
+	ring.active = last
+	if active != "" {
+		if _, ok := ring.keys[active]; !ok {
+			return nil, fmt.Errorf("JWT_ACTIVE_KID %s is not in JWT_SIGNING_KEYS", active)
+		}
+		ring.active = active
+	}
+	return ring, nil
+}
+
+// signToken signs claims with the active key pair, or with the HMAC secret when no
+// key pairs are configured
+func signToken(claims jwt.Claims, hmacSecret []byte) (string, error) {
+	ring, err := loadKeyring()
+	if err != nil {
+		return "", err
+	}
+	if ring.active == "" {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(hmacSecret)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = ring.active
+	return token.SignedString(ring.keys[ring.active])
+}
+
+// keyFunc finds the key a token was signed with. HMAC tokens are accepted while no
+// key pairs are configured, and afterwards until JWT_ACCEPT_LEGACY_HS256=false.
+func keyFunc(hmacSecret []byte) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		ring, err := loadKeyring()
+		if err != nil {
+			return nil, err
+		}
+
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA:
+			kid, _ := token.Header["kid"].(string)
+			key, ok := ring.keys[kid]
+			if !ok {
+				return nil, fmt.Errorf("unknown signing key %q", kid)
+			}
+			return &key.PublicKey, nil
+		case *jwt.SigningMethodHMAC:
+			if ring.active != "" && os.Getenv("JWT_ACCEPT_LEGACY_HS256") == "false" {
+				return nil, errors.New("HMAC signed tokens are no longer accepted")
+			}
+			if hmacSecret == nil {
+				return nil, errors.New("JWT_SECRET environment variable is required")
+			}
+			return hmacSecret, nil
+		default:
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+	}
+}
+
+// PublicKeys returns the public signing keys so other services can verify tokens.
+// Keys being rotated out stay listed until they are removed from JWT_SIGNING_KEYS.
+func PublicKeys() ([]JWK, error) {
+	ring, err := loadKeyring()
+	if err != nil {
+		return nil, err
+	}
+
+	kids := make([]string, 0, len(ring.keys))
+	for kid := range ring.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	keys := make([]JWK, 0, len(kids))
+	for _, kid := range kids {
+		public := ring.keys[kid].PublicKey
+		keys = append(keys, JWK{
+			KeyType:   "RSA",
+			Use:       "sig",
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			KeyID:     kid,
+			Modulus:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			Exponent:  base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		})
+	}
+	return keys, nil
+}
+
+// ActiveKeyID returns the kid new tokens are signed with, empty when signing with JWT_SECRET
+func ActiveKeyID() string {
+	ring, err := loadKeyring()
+	if err != nil {
+		return ""
+	}
+	return ring.active
+}
+
+// CheckSigningConfig reports whether tokens can be signed: the listed key pairs must
+// load, and without any JWT_SECRET must be set and long enough
+func CheckSigningConfig() error {
+	ring, err := loadKeyring()
+	if err != nil {
+		return err
+	}
+	if ring.active != "" {
+		return nil
+	}
+	_, err = jwtSecret()
+	return err
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// writeSigningKeys writes a new RSA key for each kid and returns the keys and a
+// JWT_SIGNING_KEYS list naming them
+func writeSigningKeys(t *testing.T, kids ...string) (map[string]*rsa.PrivateKey, string) {
+	t.Helper()
+	dir := t.TempDir()
+	keys := map[string]*rsa.PrivateKey{}
+	var entries []string
+	for _, kid := range kids {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, kid+".pem")
+		block := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+		if err := os.WriteFile(path, block, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		keys[kid] = key
+		entries = append(entries, kid+"="+path)
+	}
+	return keys, strings.Join(entries, ",")
+}
+
+// useKeyring loads a keyring as if it were configured, restoring the real one after
+// the test
+func useKeyring(t *testing.T, spec, active string) {
+	t.Helper()
+	ring, err := readKeyring(spec, active)
+	if err != nil {
+		t.Fatalf("readKeyring: %v", err)
+	}
+	signingKeysOnce = sync.Once{}
+	signingKeysOnce.Do(func() { signingKeys, signingKeysErr = ring, nil })
+	t.Cleanup(func() {
+		signingKeysOnce = sync.Once{}
+		signingKeys, signingKeysErr = nil, nil
+	})
+}
+
+func TestKeyRotation(t *testing.T) {
+	t.Setenv("JWT_SECRET", "0123456789abcdef0123456789abcdef")
+	_, both := writeSigningKeys(t, "2024-01", "2025-01")
+	oldOnly := strings.Split(both, ",")[0]
+	newOnly := strings.Split(both, ",")[1]
+
+	// Tokens issued before the rotation carry the old kid
+	useKeyring(t, oldOnly, "")
+	old, err := GenerateToken(7, "user@example.com", "visitor", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := tokenKeyID(t, old); kid != "2024-01" {
+		t.Fatalf("signed with %q, want 2024-01", kid)
+	}
+
+	// After rotating, the retired key still verifies while it is listed
+	useKeyring(t, both, "2025-01")
+	if ActiveKeyID() != "2025-01" {
+		t.Fatalf("active key %q", ActiveKeyID())
+	}
+	if _, err := ValidateToken(old); err != nil {
+		t.Fatalf("token from the retired key rejected: %v", err)
+	}
+	current, err := GenerateToken(7, "user@example.com", "visitor", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := tokenKeyID(t, current); kid != "2025-01" {
+		t.Fatalf("signed with %q, want 2025-01", kid)
+	}
+
+	// Once it is removed, its tokens are refused
+	useKeyring(t, newOnly, "")
+	if _, err := ValidateToken(old); err == nil {
+		t.Fatal("token from a removed key accepted")
+	}
+	if _, err := ValidateToken(current); err != nil {
+		t.Fatalf("token from the active key rejected: %v", err)
+	}
+}
+
+func TestUnknownKeyIDRejected(t *testing.T) {
+	t.Setenv("JWT_SECRET", "0123456789abcdef0123456789abcdef")
+	keys, spec := writeSigningKeys(t, "listed")
+	useKeyring(t, spec, "")
+
+	claims := TokenClaims{UserID: 7, Email: "user@example.com", Role: "visitor", TokenType: TokenTypeAccess,
+		RegisteredClaims: registeredClaims(7, AccessTokenTTL("visitor"))}
+	for name, kid := range map[string]interface{}{"unknown kid": "stranger", "no kid": nil} {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		if kid != nil {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(keys["listed"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ValidateToken(signed); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+
+	// A listed kid on a token signed by another key fails the signature check
+	forger, _ := writeSigningKeys(t, "forger")
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "listed"
+	signed, err := token.SignedString(forger["forger"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateToken(signed); err == nil {
+		t.Error("token signed by an unlisted key accepted")
+	}
+}
+
+func TestPublicKeysMatchKeyring(t *testing.T) {
+	keys, spec := writeSigningKeys(t, "b-key", "a-key")
+	useKeyring(t, spec, "b-key")
+
+	jwks, err := PublicKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jwks) != 2 || jwks[0].KeyID != "a-key" || jwks[1].KeyID != "b-key" {
+		t.Fatalf("got %+v, want a-key then b-key", jwks)
+	}
+	for _, jwk := range jwks {
+		if jwk.KeyType != "RSA" || jwk.Use != "sig" || jwk.Algorithm != "RS256" {
+			t.Errorf("%s: got %+v", jwk.KeyID, jwk)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.Modulus)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.Exponent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		public := keys[jwk.KeyID].PublicKey
+		if new(big.Int).SetBytes(n).Cmp(public.N) != 0 || int(new(big.Int).SetBytes(e).Int64()) != public.E {
+			t.Errorf("%s: published key does not match the loaded key", jwk.KeyID)
+		}
+	}
+}
+
+func TestReadKeyring(t *testing.T) {
+	_, spec := writeSigningKeys(t, "one", "two")
+
+	ring, err := readKeyring(spec, "")
+	if err != nil || ring.active != "two" {
+		t.Fatalf("got %v, %v; want the last key active", ring, err)
+	}
+	if _, err := readKeyring(spec, "three"); err == nil {
+		t.Error("active kid missing from the list accepted")
+	}
+	if _, err := readKeyring("no-path", ""); err == nil {
+		t.Error("entry without a path accepted")
+	}
+	if _, err := readKeyring("missing="+filepath.Join(t.TempDir(), "none.pem"), ""); err == nil {
+		t.Error("missing key file accepted")
+	}
+	if ring, err := readKeyring("", ""); err != nil || ring.active != "" {
+		t.Errorf("empty list: got %v, %v", ring, err)
+	}
+}
+
+// tokenKeyID reads a token's kid header without verifying it
+func tokenKeyID(t *testing.T, signed string) string {
+	t.Helper()
+	token, _, err := new(jwt.Parser).ParseUnverified(signed, &TokenClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kid, _ := token.Header["kid"].(string)
+	return kid
+}
@@ -4,9 +4,6 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
-	"fmt"
-	"os"
-	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
@@ -16,6 +13,11 @@ type RefreshTokenClaims struct {
 	UserID uint   `json:"user_id"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+	// TokenVersion must match the user's token version for the token to be refreshed
+	TokenVersion int `json:"tv"`
+	// TokenType is always TokenTypeRefresh, so a refresh token is never accepted as
+	// an access token
+	TokenType string `json:"typ"`
 	jwt.RegisteredClaims
 }
 
@@ -24,68 +26,53 @@ const (
 )
 
 // GenerateRefreshToken creates a new refresh token
-func GenerateRefreshToken(userID uint, email string, role string) (string, error) {
-	// Get JWT secret from environment
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "default_secret_for_development"
-	}
-
-	// Create token with claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, RefreshTokenClaims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenExpiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
-	})
-
-	// Sign and get the complete encoded token as a string
-	tokenString, err := token.SignedString([]byte(jwtSecret))
-	if err != nil {
+func GenerateRefreshToken(userID uint, email string, role string, tokenVersion int) (string, error) {
+	secret, err := jwtSecret()
+	if err != nil && ActiveKeyID() == "" {
 		return "", err
 	}
 
-	return tokenString, nil
+	return signToken(RefreshTokenClaims{
+		UserID:           userID,
+		Email:            email,
+		Role:             role,
+		TokenVersion:     tokenVersion,
+		TokenType:        TokenTypeRefresh,
+		RegisteredClaims: registeredClaims(userID, RefreshTokenTTL(role)),
+	}, secret)
 }
 
 // ValidateRefreshToken validates a refresh token and returns claims
 func ValidateRefreshToken(tokenString string) (*RefreshTokenClaims, error) {
-	// Get JWT secret from environment
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "default_secret_for_development"
+	secret, err := jwtSecret()
+	if err != nil && ActiveKeyID() == "" {
+		return nil, err
 	}
 
 	// Parse the token
-	token, err := jwt.ParseWithClaims(tokenString, &RefreshTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(jwtSecret), nil
-	})
-
+	token, err := jwt.ParseWithClaims(tokenString, &RefreshTokenClaims{}, keyFunc(secret))
 	if err != nil {
 		return nil, err
 	}
 
 	// Validate claims
 	if claims, ok := token.Claims.(*RefreshTokenClaims); ok && token.Valid {
+		if claims.TokenType != TokenTypeRefresh {
+			return nil, ErrTokenType
+		}
+		if err := verifyRegisteredClaims(&claims.RegisteredClaims); err != nil {
+			return nil, err
+		}
 		return claims, nil
 	}
 
 	return nil, errors.New("invalid refresh token")
 }
 
-// RefreshTokenHandler handles refresh token requests
-func RefreshTokenHandler(userID uint, email string) (string, error) {
-	// Get user role - this would normally come from database
-	role := "Visitor" // Default role, should be fetched from database
-
-	refreshToken, err := GenerateRefreshToken(userID, email, role)
+// RefreshTokenHandler issues a refresh token for a user. tokenVersion is the user's
+// current token version, as for GenerateToken.
+func RefreshTokenHandler(userID uint, email, role string, tokenVersion int) (string, error) {
+	refreshToken, err := GenerateRefreshToken(userID, email, role, tokenVersion)
 	if err != nil {
 		return "", err
 	}
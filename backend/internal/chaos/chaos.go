@@ -0,0 +1,255 @@
+// Package chaos injects failures on demand so retries, fallbacks and alerting can be
+// exercised before a real incident. It is only active when CHAOS_ENABLED=true and
+// APP_ENV is not production. Faults are held in memory, so they only affect the
+// instance that received them and are cleared on restart.
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Fault kinds
+const (
+	KindLatency      = "latency"      // Delay HTTP requests whose path starts with the target
+	KindDBError      = "db_error"     // Fail database operations on the target table
+	KindNotification = "notification" // Fail email or SMS sends; target is "email", "sms" or empty for both
+)
+
+// Limits on how long a fault may stay active
+const (
+	DefaultDuration = 10 * time.Minute
+	MaxDuration     = time.Hour
+	MaxLatency      = 30 * time.Second
+)
+
+var (
+	ErrInjected     = errors.New("chaos: injected failure")
+	ErrInvalidFault = errors.New("invalid fault")
+)
+
+// Fault is a failure to inject into matching calls until it expires
+type Fault struct {
+	ID          int       `json:"id"`
+	Kind        string    `json:"kind"`
+	Target      string    `json:"target"`      // Path prefix, table name or notification channel
+	Probability float64   `json:"probability"` // Share of matching calls affected, from 0 to 1
+	LatencyMS   int       `json:"latency_ms,omitempty"`
+	Hits        int64     `json:"hits"`
+	CreatedBy   uint      `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+var (
+	enabledOnce sync.Once
+	enabled     bool
+
+	mu     sync.RWMutex
+	faults []*Fault
+	nextID int
+	active int32 // Number of faults, read without the lock on hot paths
+)
+
+// Enabled reports whether failure injection is switched on for this process
+func Enabled() bool {
+	enabledOnce.Do(func() {
+		enabled = os.Getenv("CHAOS_ENABLED") == "true" && os.Getenv("APP_ENV") != "production"
+	})
+	return enabled
+}
+
+// Add validates and activates a fault for duration, or DefaultDuration when zero
+func Add(fault Fault, duration time.Duration, now time.Time) (Fault, error) {
+	fault.Target = strings.TrimSpace(fault.Target)
+	if fault.Probability == 0 {
+		fault.Probability = 1
+	}
+	if fault.Probability < 0 || fault.Probability > 1 {
+		return Fault{}, fmt.Errorf("%w: probability must be between 0 and 1", ErrInvalidFault)
+	}
+	if duration == 0 {
+		duration = DefaultDuration
+	}
+	if duration < 0 || duration > MaxDuration {
+		return Fault{}, fmt.Errorf("%w: duration must be at most %s", ErrInvalidFault, MaxDuration)
+	}
+
+	switch fault.Kind {
+	case KindLatency:
+		if fault.LatencyMS <= 0 || time.Duration(fault.LatencyMS)*time.Millisecond > MaxLatency {
+			return Fault{}, fmt.Errorf("%w: latency_ms must be between 1 and %d", ErrInvalidFault, MaxLatency.Milliseconds())
+		}
+	case KindDBError:
+		// Failing every table would also lock admins out of clearing the fault
+		if fault.Target == "" {
+			return Fault{}, fmt.Errorf("%w: database faults need a table name as target", ErrInvalidFault)
+		}
+		fault.LatencyMS = 0
+	case KindNotification:
+		if fault.Target != "" && fault.Target != "email" && fault.Target != "sms" {
+			return Fault{}, fmt.Errorf("%w: notification target must be email, sms or empty", ErrInvalidFault)
+		}
+		fault.LatencyMS = 0
+	default:
+		return Fault{}, fmt.Errorf("%w: unknown kind %q", ErrInvalidFault, fault.Kind)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	removeExpired(now)
+	nextID++
+	fault.ID = nextID
+	fault.Hits = 0
+	fault.CreatedAt = now
+	fault.ExpiresAt = now.Add(duration)
+	faults = append(faults, &fault)
+	atomic.StoreInt32(&active, int32(len(faults)))
+	return fault, nil
+}
+
+// List returns the active faults, oldest first
+func List(now time.Time) []Fault {
+	mu.Lock()
+	defer mu.Unlock()
+	removeExpired(now)
+
+	list := make([]Fault, 0, len(faults))
+	for _, fault := range faults {
+		copied := *fault
+		copied.Hits = atomic.LoadInt64(&fault.Hits)
+		list = append(list, copied)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Remove deactivates a fault, reporting whether it was active
+func Remove(id int) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	for i, fault := range faults {
+		if fault.ID == id {
+			faults = append(faults[:i], faults[i+1:]...)
+			atomic.StoreInt32(&active, int32(len(faults)))
+			return true
+		}
+	}
+	return false
+}
+
+// Clear deactivates every fault and returns how many were active
+func Clear() int {
+	mu.Lock()
+	defer mu.Unlock()
+	n := len(faults)
+	faults = nil
+	atomic.StoreInt32(&active, 0)
+	return n
+}
+
+// removeExpired drops faults past their expiry. The caller holds mu.
+func removeExpired(now time.Time) {
+	kept := faults[:0]
+	for _, fault := range faults {
+		if now.Before(fault.ExpiresAt) {
+			kept = append(kept, fault)
+		}
+	}
+	faults = kept
+	atomic.StoreInt32(&active, int32(len(faults)))
+}
+
+// match returns the first active fault of a kind that applies to target and fires
+// this time
+func match(kind, target string, matches func(faultTarget, target string) bool) *Fault {
+	if !Enabled() || atomic.LoadInt32(&active) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, fault := range faults {
+		if fault.Kind != kind || !now.Before(fault.ExpiresAt) || !matches(fault.Target, target) {
+			continue
+		}
+		if fault.Probability < 1 && rand.Float64() >= fault.Probability {
+			continue
+		}
+		atomic.AddInt64(&fault.Hits, 1)
+		return fault
+	}
+	return nil
+}
+
+// Fail returns ErrInjected when a database or notification fault applies to target
+func Fail(kind, target string) error {
+	fault := match(kind, target, func(faultTarget, target string) bool {
+		return faultTarget == "" || faultTarget == target
+	})
+	if fault == nil {
+		return nil
+	}
+	return fmt.Errorf("%w (fault %d: %s on %s)", ErrInjected, fault.ID, kind, target)
+}
+
+// Delay returns the latency to add to a request for path
+func Delay(path string) time.Duration {
+	fault := match(KindLatency, path, func(faultTarget, path string) bool {
+		return strings.HasPrefix(path, faultTarget)
+	})
+	if fault == nil {
+		return 0
+	}
+	return time.Duration(fault.LatencyMS) * time.Millisecond
+}
+
+// Middleware delays requests matched by latency faults
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if delay := Delay(c.Request.URL.Path); delay > 0 {
+			c.Header("X-Chaos-Latency", delay.String())
+			select {
+			case <-time.After(delay):
+			case <-c.Request.Context().Done():
+			}
+		}
+		c.Next()
+	}
+}
+
+// RegisterDBCallbacks makes database operations fail while a database fault targets
+// their table
+func RegisterDBCallbacks(db *gorm.DB) error {
+	inject := func(tx *gorm.DB) {
+		if err := Fail(KindDBError, tx.Statement.Table); err != nil {
+			tx.AddError(err)
+		}
+	}
+
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("chaos:create", inject); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("chaos:query", inject); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("chaos:update", inject); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("chaos:delete", inject); err != nil {
+		return err
+	}
+	return callbacks.Row().Before("gorm:row").Register("chaos:row", inject)
+}
@@ -0,0 +1,94 @@
+package chaos
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func enableForTest(t *testing.T) {
+	enabledOnce.Do(func() {})
+	enabled = true
+	t.Cleanup(func() {
+		Clear()
+		enabled = false
+	})
+}
+
+func TestAddValidatesFaults(t *testing.T) {
+	now := time.Now()
+	cases := []Fault{
+		{Kind: "unknown"},
+		{Kind: KindLatency},
+		{Kind: KindLatency, LatencyMS: int(MaxLatency.Milliseconds()) + 1},
+		{Kind: KindDBError},
+		{Kind: KindNotification, Target: "fax"},
+		{Kind: KindNotification, Probability: 1.5},
+	}
+	for _, fault := range cases {
+		if _, err := Add(fault, 0, now); !errors.Is(err, ErrInvalidFault) {
+			t.Errorf("%+v: got %v, want ErrInvalidFault", fault, err)
+		}
+	}
+	if _, err := Add(Fault{Kind: KindNotification}, 2*MaxDuration, now); !errors.Is(err, ErrInvalidFault) {
+		t.Errorf("over-long fault: got %v", err)
+	}
+}
+
+func TestFaultsMatchTargetsAndExpire(t *testing.T) {
+	enableForTest(t)
+	now := time.Now()
+
+	if _, err := Add(Fault{Kind: KindDBError, Target: "donations"}, time.Minute, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Add(Fault{Kind: KindLatency, Target: "/api/v1/queue", LatencyMS: 250}, time.Minute, now); err != nil {
+		t.Fatal(err)
+	}
+	email, err := Add(Fault{Kind: KindNotification, Target: "email"}, time.Minute, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Fail(KindDBError, "donations"); !errors.Is(err, ErrInjected) {
+		t.Errorf("donations: got %v", err)
+	}
+	if err := Fail(KindDBError, "users"); err != nil {
+		t.Errorf("users: got %v", err)
+	}
+	if err := Fail(KindNotification, "sms"); err != nil {
+		t.Errorf("sms: got %v", err)
+	}
+	if err := Fail(KindNotification, "email"); !errors.Is(err, ErrInjected) {
+		t.Errorf("email: got %v", err)
+	}
+	if got := Delay("/api/v1/queue/status"); got != 250*time.Millisecond {
+		t.Errorf("queue delay: got %v", got)
+	}
+	if got := Delay("/api/v1/donations"); got != 0 {
+		t.Errorf("donations delay: got %v", got)
+	}
+
+	if !Remove(email.ID) || Remove(email.ID) {
+		t.Fatal("fault should be removed exactly once")
+	}
+	if err := Fail(KindNotification, "email"); err != nil {
+		t.Errorf("removed fault still fires: %v", err)
+	}
+
+	faults := List(now.Add(2 * time.Minute))
+	if len(faults) != 0 {
+		t.Fatalf("expired faults still listed: %+v", faults)
+	}
+}
+
+func TestDisabledInjectsNothing(t *testing.T) {
+	enableForTest(t)
+	if _, err := Add(Fault{Kind: KindNotification}, time.Minute, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	enabled = false
+	if err := Fail(KindNotification, "email"); err != nil {
+		t.Fatalf("disabled: got %v", err)
+	}
+}
@@ -72,6 +72,10 @@ type RateLimitConfig struct {
 	APIWindow       time.Duration
 	AuthLimit       int
 	AuthWindow      time.Duration
+	RegisterLimit   int
+	RegisterWindow  time.Duration
+	FeedbackLimit   int
+	FeedbackWindow  time.Duration
 	StrictLimit     int
 	StrictWindow    time.Duration
 	WebSocketLimit  int
@@ -165,12 +169,16 @@ func Load() (*Config, error) {
 		},
 		RateLimit: RateLimitConfig{
 			EnabledInDev:    getEnvAsBool("RATE_LIMIT_ENABLED_IN_DEV", false),
-			LoginLimit:      getEnvAsInt("RATE_LIMIT_LOGIN", 5),
-			LoginWindow:     getEnvAsDuration("RATE_LIMIT_LOGIN_WINDOW", "1m"),
+			LoginLimit:      getEnvAsInt("RATE_LIMIT_LOGIN", 10),
+			LoginWindow:     getEnvAsDuration("RATE_LIMIT_LOGIN_WINDOW", "15m"),
 			APILimit:        getEnvAsInt("RATE_LIMIT_API", 100),
 			APIWindow:       getEnvAsDuration("RATE_LIMIT_API_WINDOW", "1m"),
 			AuthLimit:       getEnvAsInt("RATE_LIMIT_AUTH", 10),
 			AuthWindow:      getEnvAsDuration("RATE_LIMIT_AUTH_WINDOW", "1m"),
+			RegisterLimit:   getEnvAsInt("RATE_LIMIT_REGISTER", 5),
+			RegisterWindow:  getEnvAsDuration("RATE_LIMIT_REGISTER_WINDOW", "1h"),
+			FeedbackLimit:   getEnvAsInt("RATE_LIMIT_FEEDBACK", 10),
+			FeedbackWindow:  getEnvAsDuration("RATE_LIMIT_FEEDBACK_WINDOW", "1h"),
 			StrictLimit:     getEnvAsInt("RATE_LIMIT_STRICT", 3),
 			StrictWindow:    getEnvAsDuration("RATE_LIMIT_STRICT_WINDOW", "5m"),
 			WebSocketLimit:  getEnvAsInt("RATE_LIMIT_WEBSOCKET", 50),
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	changeHistoryBeforeKey = "change_history:before"
+	changeHistoryMaxRows   = 200 // Bulk updates touching more rows are not recorded
+)
+
+// registerChangeHistory records field-level changes to the tracked tables. The rows an
+// update touches are read before and after it runs and the differences saved as an
+// EntityChange, so Save, Update and Updates are all covered. It is registered after
+// migrations so the history table always exists.
+func registerChangeHistory(db *gorm.DB) error {
+	if err := db.Callback().Update().Before("gorm:update").Register("change_history:before", captureChangesBefore); err != nil {
+		return err
+	}
+	return db.Callback().Update().After("gorm:update").Register("change_history:after", recordChangesAfter)
+}
+
+// captureChangesBefore snapshots the rows an update is about to change
+func captureChangesBefore(tx *gorm.DB) {
+	if tx.Error != nil || tx.Statement.Schema == nil || tx.Statement.Schema.PrioritizedPrimaryField == nil {
+		return
+	}
+	if _, tracked := models.ChangeTrackedTables[tx.Statement.Schema.Table]; !tracked {
+		return
+	}
+
+	query := changeSnapshotQuery(tx)
+	if query == nil {
+		return
+	}
+	var rows []map[string]interface{}
+	if err := query.Limit(changeHistoryMaxRows + 1).Find(&rows).Error; err != nil {
+		log.Printf("Change history: failed to read %s before update: %v", tx.Statement.Schema.Table, err)
+		return
+	}
+	if len(rows) == 0 || len(rows) > changeHistoryMaxRows {
+		return
+	}
+	tx.InstanceSet(changeHistoryBeforeKey, rows)
+}
+
+// recordChangesAfter compares the updated rows with their snapshot and saves what changed
+func recordChangesAfter(tx *gorm.DB) {
+	value, ok := tx.InstanceGet(changeHistoryBeforeKey)
+	if !ok || tx.Error != nil {
+		return
+	}
+	before := value.([]map[string]interface{})
+	table := tx.Statement.Schema.Table
+	primaryKey := tx.Statement.Schema.PrioritizedPrimaryField.DBName
+
+	ids := make([]interface{}, 0, len(before))
+	for _, row := range before {
+		ids = append(ids, row[primaryKey])
+	}
+	var after []map[string]interface{}
+	if err := tx.Session(&gorm.Session{NewDB: true}).Table(table).
+		Where(primaryKey+" IN ?", ids).Find(&after).Error; err != nil {
+		log.Printf("Change history: failed to read %s after update: %v", table, err)
+		return
+	}
+	updated := make(map[string]map[string]interface{}, len(after))
+	for _, row := range after {
+		updated[fmt.Sprint(row[primaryKey])] = row
+	}
+
+	now := time.Now()
+	var entries []models.EntityChange
+	for _, old := range before {
+		id := fmt.Sprint(old[primaryKey])
+		changes := diffChangeRows(old, updated[id])
+		if len(changes) == 0 {
+			continue
+		}
+		entityID, _ := strconv.ParseUint(id, 10, 64)
+		entries = append(entries, models.EntityChange{
+			EntityType: models.ChangeTrackedTables[table],
+			EntityID:   uint(entityID),
+			Changes:    changes,
+			CreatedAt:  now,
+		})
+	}
+	if len(entries) == 0 {
+		return
+	}
+	if err := tx.Session(&gorm.Session{NewDB: true}).Create(&entries).Error; err != nil {
+		log.Printf("Change history: failed to record %s changes: %v", table, err)
+	}
+}
+
+// changeSnapshotQuery selects the rows an update will change, from the model's primary
+// key and the update's conditions. Updates with neither are not recorded.
+func changeSnapshotQuery(tx *gorm.DB) *gorm.DB {
+	stmt := tx.Statement
+	query := tx.Session(&gorm.Session{NewDB: true}).Table(stmt.Schema.Table)
+	scoped := false
+
+	if stmt.ReflectValue.Kind() == reflect.Struct {
+		field := stmt.Schema.PrioritizedPrimaryField
+		if id, zero := field.ValueOf(stmt.Context, stmt.ReflectValue); !zero {
+			query = query.Where(field.DBName+" = ?", id)
+			scoped = true
+		}
+	}
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			query = query.Clauses(where)
+			scoped = true
+		}
+	}
+
+	if !scoped {
+		return nil
+	}
+	return query
+}
+
+// diffChangeRows lists the fields that differ between two versions of a row, with
+// sensitive values redacted
+func diffChangeRows(before, after map[string]interface{}) models.FieldChanges {
+	if after == nil {
+		return nil
+	}
+
+	columns := make([]string, 0, len(after))
+	for column := range after {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	changes := models.FieldChanges{}
+	for _, column := range columns {
+		if models.IsChangeIgnoredField(column) {
+			continue
+		}
+		old, updated := normalizeChangeValue(before[column]), normalizeChangeValue(after[column])
+		if reflect.DeepEqual(old, updated) {
+			continue
+		}
+
+		change := models.FieldChange{Field: column, Before: old, After: updated}
+		if models.IsChangeRedactedField(column) {
+			change = models.FieldChange{Field: column, Before: models.RedactedValue, After: models.RedactedValue, Redacted: true}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// normalizeChangeValue makes driver values comparable and readable in JSON
+func normalizeChangeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC()
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return v.UTC()
+	}
+	return value
+}
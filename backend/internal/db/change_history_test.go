@@ -0,0 +1,80 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestDiffChangeRows(t *testing.T) {
+	local := time.FixedZone("BST", 3600)
+	visit := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	before := map[string]interface{}{
+		"id":          int64(7),
+		"status":      "pending",
+		"notes":       []byte("first"),
+		"visit_time":  visit,
+		"password":    "old-hash",
+		"reset_token": "abc",
+		"updated_at":  visit,
+	}
+	after := map[string]interface{}{
+		"id":          int64(7),
+		"status":      "approved",
+		"notes":       []byte("first"),
+		"visit_time":  visit.In(local), // The same instant read back in another zone
+		"password":    "new-hash",
+		"reset_token": "abc",
+		"updated_at":  visit.Add(time.Hour),
+	}
+
+	want := models.FieldChanges{
+		{Field: "password", Before: models.RedactedValue, After: models.RedactedValue, Redacted: true},
+		{Field: "status", Before: "pending", After: "approved"},
+	}
+	if got := diffChangeRows(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// A row that disappeared has no diff
+	if got := diffChangeRows(before, nil); got != nil {
+		t.Errorf("deleted row: got %+v", got)
+	}
+}
+
+func TestNormalizeChangeValue(t *testing.T) {
+	at := time.Date(2026, 5, 1, 11, 0, 0, 0, time.FixedZone("BST", 3600))
+	var none *time.Time
+	tests := []struct {
+		in, want interface{}
+	}{
+		{[]byte("text"), "text"},
+		{at, at.UTC()},
+		{&at, at.UTC()},
+		{none, nil},
+		{int64(3), int64(3)},
+	}
+	for _, tt := range tests {
+		if got := normalizeChangeValue(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("normalizeChangeValue(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestChangeFieldRules(t *testing.T) {
+	for _, column := range []string{"password", "payment_id", "refresh_token", "totp_secret", "otp_code"} {
+		if !models.IsChangeRedactedField(column) {
+			t.Errorf("%s not redacted", column)
+		}
+	}
+	for _, column := range []string{"status", "email", "notes"} {
+		if models.IsChangeRedactedField(column) {
+			t.Errorf("%s redacted", column)
+		}
+	}
+	if !models.IsChangeIgnoredField("updated_at") || models.IsChangeIgnoredField("status") {
+		t.Error("only bookkeeping columns are ignored")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -94,6 +95,11 @@ func (cm *ConnectionManager) Connect() (*gorm.DB, error) {
 		return nil, fmt.Errorf("migrations failed: %w", err)
 	}
 
+	// Record field-level changes to tracked entities
+	if err := registerChangeHistory(db); err != nil {
+		return nil, fmt.Errorf("change history setup failed: %w", err)
+	}
+
 	// Start health monitoring
 	go cm.startHealthMonitoring(db)
 
@@ -196,9 +202,9 @@ func (cm *ConnectionManager) ensureDatabaseExists() error {
 
 // connectToDatabase establishes connection to the application database
 func (cm *ConnectionManager) connectToDatabase() (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s connect_timeout=%d",
 		cm.config.Host, cm.config.Port, cm.config.User, cm.config.Password,
-		cm.config.DBName, cm.config.SSLMode)
+		cm.config.DBName, cm.config.SSLMode, int(math.Ceil(cm.config.ConnectTimeout.Seconds())))
 
 	// Configure GORM with optimized performance settings
 	gormConfig := &gorm.Config{
@@ -326,6 +332,37 @@ func Connect() (*gorm.DB, error) {
 	return cm.Connect()
 }
 
+// Open connects to the application database without creating it or running
+// migrations, for checks that must not change anything
+func Open(ctx context.Context) (*gorm.DB, error) {
+	config, err := loadAndValidateConfig()
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		config.ConnectTimeout = time.Until(deadline)
+	}
+	// Log to stderr so callers can print reports on stdout
+	cm := &ConnectionManager{
+		config: config,
+		logger: log.New(os.Stderr, "[DB] ", log.LstdFlags|log.Lshortfile),
+	}
+
+	db, err := cm.connectToDatabase()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to ping database %s: %w", config.DBName, err)
+	}
+	return db, nil
+}
+
 func GetDB() *gorm.DB {
 	return DB
 }
@@ -229,3 +229,148 @@ func getMaxVisitsByDay(dayOfWeek time.Weekday, visitType string) int {
 	}
 	return 20
 }
+
+// createDefaultServiceTypes creates service types for the built-in food and general
+// categories, plus any other categories already used by help requests
+func createDefaultServiceTypes(db *gorm.DB) error {
+	defaults := []models.ServiceType{
+		{
+			Code:                models.CategoryFood,
+			Name:                "Food Support",
+			Description:         "Food bank parcels and fresh produce",
+			IsActive:            true,
+			SortOrder:           1,
+			DailyCapacity:       getMaxVisitsByDay(time.Tuesday, models.CategoryFood),
+			VisitIntervalDays:   7,
+			OperatingDays:       "Tuesday,Wednesday,Thursday",
+			OpeningTime:         "11:30",
+			ClosingTime:         "14:30",
+			SlotIntervalMinutes: 10,
+			MaxVisitorsPerSlot:  2,
+			AutoApprove:         true,
+			TicketPrefix:        "FD",
+			TicketValidityHours: 24,
+			WhatToBring:         "Valid ID\nProof of address\nReusable bags for food",
+		},
+		{
+			Code:                models.CategoryGeneral,
+			Name:                "General Support",
+			Description:         "Advice, signposting and general assistance",
+			IsActive:            true,
+			SortOrder:           2,
+			DailyCapacity:       getMaxVisitsByDay(time.Tuesday, models.CategoryGeneral),
+			VisitIntervalDays:   7,
+			OperatingDays:       "Tuesday,Wednesday,Thursday",
+			OpeningTime:         "10:30",
+			ClosingTime:         "14:30",
+			SlotIntervalMinutes: 10,
+			MaxVisitorsPerSlot:  2,
+			AutoApprove:         true,
+			TicketPrefix:        "GN",
+			TicketValidityHours: 24,
+			WhatToBring:         "Valid ID\nProof of address",
+		},
+	}
+
+	for _, serviceType := range defaults {
+		if err := db.Where("code = ?", serviceType.Code).FirstOrCreate(&serviceType).Error; err != nil {
+			return fmt.Errorf("failed to create service type %s: %w", serviceType.Code, err)
+		}
+	}
+
+	// Carry over any other categories visitors have already requested
+	var categories []string
+	if err := db.Model(&models.HelpRequest{}).
+		Distinct("LOWER(category)").
+		Where("category <> '' AND LOWER(category) NOT IN ?", []string{models.CategoryFood, models.CategoryGeneral}).
+		Pluck("LOWER(category)", &categories).Error; err != nil {
+		return fmt.Errorf("failed to read existing help request categories: %w", err)
+	}
+
+	for i, code := range categories {
+		serviceType := models.ServiceType{
+			Code:                code,
+			Name:                strings.ToUpper(code[:1]) + code[1:],
+			IsActive:            true,
+			SortOrder:           len(defaults) + i + 1,
+			DailyCapacity:       10,
+			VisitIntervalDays:   7,
+			OperatingDays:       "Tuesday,Wednesday,Thursday",
+			OpeningTime:         "10:30",
+			ClosingTime:         "14:30",
+			SlotIntervalMinutes: 10,
+			MaxVisitorsPerSlot:  2,
+			TicketPrefix:        "TKT",
+			TicketValidityHours: 24,
+			WhatToBring:         "Valid ID\nProof of address",
+		}
+		if err := db.Where("code = ?", code).FirstOrCreate(&serviceType).Error; err != nil {
+			return fmt.Errorf("failed to create service type %s: %w", code, err)
+		}
+	}
+
+	log.Printf("Service types initialised (%d existing categories migrated)", len(categories))
+	return nil
+}
+
+// createDefaultRejectionReasons creates the standard reasons for rejecting a visitor's
+// documents. Existing reasons are left as staff have edited them.
+func createDefaultRejectionReasons(db *gorm.DB) error {
+	idAndAddress := models.DocumentTypeID + "," + models.DocumentTypeProofAddress
+	defaults := []models.DocumentRejectionReason{
+		{
+			Code:        "unreadable",
+			Label:       "Blurry or unreadable",
+			Explanation: "We couldn't read your document. Please take a new photo in good light, holding the camera steady so all the writing is sharp.",
+			SortOrder:   1,
+		},
+		{
+			Code:        "incomplete",
+			Label:       "Part of the document is missing",
+			Explanation: "Part of your document was cut off. Please upload a photo that shows the whole page, including all four corners.",
+			SortOrder:   2,
+		},
+		{
+			Code:          "expired",
+			Label:         "Expired",
+			Explanation:   "Your document has expired. Please upload one that is still in date.",
+			DocumentTypes: models.DocumentTypeID,
+			SortOrder:     3,
+		},
+		{
+			Code:          "address_too_old",
+			Label:         "Older than 3 months",
+			Explanation:   "Your proof of address needs to be dated within the last 3 months. A recent bill, bank statement or letter from the council or benefits office is fine.",
+			DocumentTypes: models.DocumentTypeProofAddress,
+			SortOrder:     4,
+		},
+		{
+			Code:          "name_mismatch",
+			Label:         "Name doesn't match account",
+			Explanation:   "The name on your document doesn't match the name on your account. Please upload a document in your name, or contact us if your name has changed.",
+			DocumentTypes: idAndAddress,
+			SortOrder:     5,
+		},
+		{
+			Code:          "address_mismatch",
+			Label:         "Address doesn't match account",
+			Explanation:   "The address on your document doesn't match the address on your account. Please update your address or upload a document showing your current address.",
+			DocumentTypes: models.DocumentTypeProofAddress,
+			SortOrder:     6,
+		},
+		{
+			Code:        "wrong_document",
+			Label:       "Wrong kind of document",
+			Explanation: "This isn't a document we can accept for this step. Please check the list of accepted documents and upload one of those.",
+			SortOrder:   7,
+		},
+	}
+
+	for _, reason := range defaults {
+		reason.IsActive = true
+		if err := db.Where("code = ?", reason.Code).FirstOrCreate(&reason).Error; err != nil {
+			return fmt.Errorf("failed to create rejection reason %s: %w", reason.Code, err)
+		}
+	}
+	return nil
+}
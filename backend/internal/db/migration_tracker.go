@@ -155,3 +155,53 @@ func GetMigrationHistory(db *gorm.DB) (string, error) {
 
 	return history, nil
 }
+
+// MigrationState compares the migrations this build defines with those recorded in
+// the database
+type MigrationState struct {
+	Applied int      `json:"applied"`
+	Pending []string `json:"pending"` // Defined but not yet applied, in run order
+	Failed  []string `json:"failed"`  // Last attempt failed and none has succeeded
+	Unknown []string `json:"unknown"` // Applied but not defined, e.g. after a rollback
+}
+
+// GetMigrationState reads the tracking table without creating it, so a database that
+// has never been migrated reports every migration as pending
+func GetMigrationState(db *gorm.DB) (*MigrationState, error) {
+	state := &MigrationState{Pending: []string{}, Failed: []string{}, Unknown: []string{}}
+
+	var records []MigrationRecord
+	if db.Migrator().HasTable(&MigrationRecord{}) {
+		if err := db.Order("applied_at asc").Find(&records).Error; err != nil {
+			return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+		}
+	}
+	applied := map[string]bool{}
+	failed := map[string]bool{}
+	for _, record := range records {
+		if record.Success {
+			applied[record.Version] = true
+		} else {
+			failed[record.Version] = true
+		}
+	}
+
+	defined := map[string]bool{}
+	for _, migration := range NewMigrationManager(db).defineMigrations() {
+		defined[migration.Version] = true
+		switch {
+		case applied[migration.Version]:
+			state.Applied++
+		case failed[migration.Version]:
+			state.Failed = append(state.Failed, migration.Version)
+		default:
+			state.Pending = append(state.Pending, migration.Version)
+		}
+	}
+	for _, record := range records {
+		if record.Success && !defined[record.Version] {
+			state.Unknown = append(state.Unknown, record.Version)
+		}
+	}
+	return state, nil
+}
@@ -121,6 +121,731 @@ func (mm *MigrationManager) defineMigrations() []Migration {
 			Up:          initializeDefaultData,
 			Down:        mm.cleanupDefaultData,
 		},
+		{
+			Version:     "006_donation_pledges",
+			Description: "Create donation pledge and pledge payment tables",
+			Up:          autoMigrate(&models.DonationPledge{}, &models.PledgePayment{}),
+			Down:        dropTables("pledge_payments", "donation_pledges"),
+		},
+		{
+			Version:     "007_bank_reconciliation",
+			Description: "Create bank statement import and transaction tables",
+			Up:          autoMigrate(&models.BankStatementImport{}, &models.BankTransaction{}),
+			Down:        dropTables("bank_transactions", "bank_statement_imports"),
+		},
+		{
+			Version:     "008_volunteer_kudos",
+			Description: "Create volunteer kudos and kudos report tables",
+			Up:          autoMigrate(&models.Kudos{}, &models.KudosReport{}),
+			Down:        dropTables("volunteer_kudos_reports", "volunteer_kudos"),
+		},
+		{
+			Version:     "009_emergency_callouts",
+			Description: "Create emergency call-out and response tables",
+			Up:          autoMigrate(&models.EmergencyCallout{}, &models.EmergencyCalloutResponse{}),
+			Down:        dropTables("emergency_callout_responses", "emergency_callouts"),
+		},
+		{
+			Version:     "010_service_types",
+			Description: "Create configurable visitor service types and migrate existing categories",
+			Up: func(db *gorm.DB) error {
+				if err := db.AutoMigrate(&models.ServiceType{}); err != nil {
+					return err
+				}
+				return createDefaultServiceTypes(db)
+			},
+			Down: dropTables("service_types"),
+		},
+		{
+			Version:     "011_phone_identity",
+			Description: "Normalise user phone numbers, enforce unique phones and create SMS one-time code table",
+			Up: func(db *gorm.DB) error {
+				if err := db.AutoMigrate(&models.PhoneOTP{}); err != nil {
+					return err
+				}
+				return normalizeUserPhones(db)
+			},
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("DROP INDEX IF EXISTS idx_users_phone_unique").Error; err != nil {
+					return err
+				}
+				return dropTables("phone_otps")(db)
+			},
+		},
+		{
+			Version:     "012_walk_ins",
+			Description: "Create walk-in registration and standby list tables",
+			Up:          autoMigrate(&models.StandbyEntry{}, &models.WalkInRegistration{}),
+			Down:        dropTables("walk_in_registrations", "standby_entries"),
+		},
+		{
+			Version:     "013_capacity_releases",
+			Description: "Create capacity release log for same-day standby offers",
+			Up:          autoMigrate(&models.CapacityRelease{}),
+			Down:        dropTables("capacity_releases"),
+		},
+		{
+			Version:     "014_donation_refunds",
+			Description: "Track donation refunds, chargebacks and Gift Aid adjustments",
+			Up:          autoMigrate(&models.Donation{}, &models.DonationRefund{}, &models.GiftAidAdjustment{}),
+			Down:        dropTables("gift_aid_adjustments", "donation_refunds"),
+		},
+		{
+			Version:     "015_notification_costs",
+			Description: "Track per-message notification costs and SMS budget alerts",
+			Up:          autoMigrate(&models.NotificationCost{}, &models.SMSBudgetAlert{}),
+			Down:        dropTables("sms_budget_alerts", "notification_costs"),
+		},
+		{
+			Version:     "016_campaigns",
+			Description: "Add bulk email and SMS campaigns and the notification outbox",
+			Up:          autoMigrate(&models.Campaign{}, &models.NotificationOutbox{}),
+			Down:        dropTables("notification_outbox", "campaigns"),
+		},
+		{
+			Version:     "017_status_incidents",
+			Description: "Add admin-controlled incidents for the public status page",
+			Up:          autoMigrate(&models.StatusIncident{}),
+			Down:        dropTables("status_incidents"),
+		},
+		{
+			Version:     "018_kiosk_feedback",
+			Description: "Add kiosk devices and anonymous quick ratings",
+			Up:          autoMigrate(&models.KioskDevice{}, &models.KioskRating{}),
+			Down:        dropTables("kiosk_ratings", "kiosk_devices"),
+		},
+		{
+			Version:     "019_volunteer_documents",
+			Description: "Track volunteer document expiry and shift role document requirements",
+			Up:          autoMigrate(&models.Document{}, &models.ShiftRoleDocumentRequirement{}),
+			Down:        dropTables("shift_role_document_requirements"),
+		},
+		{
+			Version:     "020_service_time_targets",
+			Description: "Add service time targets, queue completion times and service time alerts",
+			Up:          autoMigrate(&models.ServiceType{}, &models.QueueEntry{}, &models.ServiceTimeAlert{}),
+			Down:        dropTables("service_time_alerts"),
+		},
+		{
+			Version:     "021_analytics_export",
+			Description: "Add the analytics event log, capture cursors and export batches",
+			Up:          autoMigrate(&models.AnalyticsEvent{}, &models.AnalyticsCaptureCursor{}, &models.AnalyticsExportBatch{}),
+			Down:        dropTables("analytics_export_batches", "analytics_capture_cursors", "analytics_events"),
+		},
+		{
+			Version:     "022_supplier_orders",
+			Description: "Add suppliers, supplier orders and urgent need reorder settings",
+			Up:          autoMigrate(&models.UrgentNeed{}, &models.Supplier{}, &models.SupplierOrder{}, &models.SupplierOrderLine{}),
+			Down:        dropTables("supplier_order_lines", "supplier_orders", "suppliers"),
+		},
+		{
+			Version:     "023_volunteer_carpool",
+			Description: "Add opt-in volunteer lift sharing posts and matches",
+			Up:          autoMigrate(&models.CarpoolPost{}, &models.CarpoolMatch{}),
+			Down:        dropTables("carpool_matches", "carpool_posts"),
+		},
+		{
+			Version:     "024_form_definitions",
+			Description: "Add custom help request questions per service type and stored answers",
+			Up:          autoMigrate(&models.FormDefinition{}, &models.HelpRequest{}),
+			Down:        dropTables("form_definitions"),
+		},
+		{
+			Version:     "025_document_inboxes",
+			Description: "Add visitor document upload addresses and the inbound message log",
+			Up:          autoMigrate(&models.Document{}, &models.DocumentInbox{}, &models.InboundDocumentMessage{}),
+			Down:        dropTables("inbound_document_messages", "document_inboxes"),
+		},
+		{
+			Version:     "026_service_unit_costs",
+			Description: "Add dated unit costs for estimating the value of services delivered",
+			Up:          autoMigrate(&models.ServiceUnitCost{}),
+			Down:        dropTables("service_unit_costs"),
+		},
+		{
+			Version:     "027_location_travel_times",
+			Description: "Add travel times between shift locations for clash detection",
+			Up:          autoMigrate(&models.LocationTravelTime{}),
+			Down:        dropTables("location_travel_times"),
+		},
+		{
+			Version:     "028_entity_changes",
+			Description: "Add field-level change history for users, help requests, capacities and donations",
+			Up:          autoMigrate(&models.EntityChange{}),
+			Down:        dropTables("entity_changes"),
+		},
+		{
+			Version:     "029_announcement_banners",
+			Description: "Add severity, start time and public flag to announcements for banners",
+			Up:          autoMigrate(&models.Announcement{}),
+			Down: func(db *gorm.DB) error {
+				return db.Exec("ALTER TABLE announcements DROP COLUMN IF EXISTS severity, DROP COLUMN IF EXISTS public, DROP COLUMN IF EXISTS starts_at").Error
+			},
+		},
+		{
+			Version:     "030_donation_drives",
+			Description: "Add team donation drives, drop-offs and team attribution on donations",
+			Up:          autoMigrate(&models.DonationDrive{}, &models.DriveTeam{}, &models.DriveDropoff{}, &models.Donation{}),
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("ALTER TABLE donations DROP COLUMN IF EXISTS drive_team_id").Error; err != nil {
+					return err
+				}
+				return dropTables("drive_dropoffs", "drive_teams", "donation_drives")(db)
+			},
+		},
+		{
+			Version:     "031_volunteer_application_retention",
+			Description: "Add source, closed and anonymized dates to volunteer applications for retention",
+			Up:          autoMigrate(&models.VolunteerApplication{}),
+			Down: func(db *gorm.DB) error {
+				return db.Exec("ALTER TABLE volunteer_applications DROP COLUMN IF EXISTS source, DROP COLUMN IF EXISTS closed_at, DROP COLUMN IF EXISTS anonymized_at").Error
+			},
+		},
+		{
+			Version:     "032_volunteer_away_periods",
+			Description: "Add volunteer away periods, swap flags and reminder tracking on shift assignments",
+			Up:          autoMigrate(&models.VolunteerAwayPeriod{}, &models.ShiftAssignment{}),
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("ALTER TABLE shift_assignments DROP COLUMN IF EXISTS swap_flagged_at, DROP COLUMN IF EXISTS away_period_id, DROP COLUMN IF EXISTS reminder_sent_at").Error; err != nil {
+					return err
+				}
+				return dropTables("volunteer_away_periods")(db)
+			},
+		},
+		{
+			Version:     "033_sla_compliance_alerts",
+			Description: "Add help request SLA compliance alerts",
+			Up:          autoMigrate(&models.SLAComplianceAlert{}),
+			Down:        dropTables("sla_compliance_alerts"),
+		},
+		{
+			Version:     "034_auth_providers",
+			Description: "Add OpenID Connect sign-in providers, linked identities and login state",
+			Up:          autoMigrate(&models.AuthProvider{}, &models.UserIdentity{}, &models.OIDCLoginState{}),
+			Down:        dropTables("oidc_login_states", "user_identities", "auth_providers"),
+		},
+		{
+			Version:     "035_admin_scopes",
+			Description: "Add scoped admin roles to users",
+			Up:          autoMigrate(&models.User{}),
+			Down: func(db *gorm.DB) error {
+				return db.Exec("ALTER TABLE users DROP COLUMN IF EXISTS admin_scope").Error
+			},
+		},
+		{
+			Version:     "036_user_invitations",
+			Description: "Add invitations for staff and admin accounts",
+			Up:          autoMigrate(&models.UserInvitation{}),
+			Down:        dropTables("user_invitations"),
+		},
+		{
+			Version:     "037_day_operations",
+			Description: "Add day open/close runs, their steps and daily service summaries",
+			Up:          autoMigrate(&models.DayOperationRun{}, &models.DayOperationStep{}, &models.DailyServiceSummary{}),
+			Down:        dropTables("day_operation_steps", "day_operation_runs", "daily_service_summaries"),
+		},
+		{
+			Version:     "038_ticket_fraud",
+			Description: "Add ticket scans and suspected ticket fraud events",
+			Up:          autoMigrate(&models.TicketScan{}, &models.TicketFraudEvent{}),
+			Down:        dropTables("ticket_fraud_events", "ticket_scans"),
+		},
+		{
+			Version:     "039_user_token_versions",
+			Description: "Add token versions so admins can end all of a user's sessions",
+			Up:          autoMigrate(&models.User{}),
+			Down: func(db *gorm.DB) error {
+				return db.Exec("ALTER TABLE users DROP COLUMN IF EXISTS token_version").Error
+			},
+		},
+		{
+			Version:     "040_queue_fairness",
+			Description: "Add queue wait anomalies and weekly queue fairness reports",
+			Up:          autoMigrate(&models.QueueWaitAnomaly{}, &models.QueueFairnessReport{}),
+			Down:        dropTables("queue_wait_anomalies", "queue_fairness_reports"),
+		},
+		{
+			Version:     "041_volunteer_certificates",
+			Description: "Add volunteer hour certificates with approval and verification codes",
+			Up:          autoMigrate(&models.VolunteerCertificate{}),
+			Down:        dropTables("volunteer_certificates"),
+		},
+		{
+			Version:     "042_shift_cancellations",
+			Description: "Record when and why shifts were cancelled",
+			Up:          autoMigrate(&models.Shift{}),
+			Down: func(db *gorm.DB) error {
+				return db.Exec(`ALTER TABLE shifts
+					DROP COLUMN IF EXISTS cancelled_at,
+					DROP COLUMN IF EXISTS cancelled_by,
+					DROP COLUMN IF EXISTS cancellation_reason`).Error
+			},
+		},
+		{
+			Version:     "043_branding_profiles",
+			Description: "Add branding profiles for emails, PDFs, tickets and certificates",
+			Up:          autoMigrate(&models.BrandingProfile{}),
+			Down:        dropTables("branding_profiles"),
+		},
+		{
+			Version:     "044_pledge_payment_bank_keys",
+			Description: "Key bank feed pledge payments on date, amount and reference so lines are only recorded once",
+			Up:          autoMigrate(&models.PledgePayment{}),
+			Down: func(db *gorm.DB) error {
+				return db.Exec("ALTER TABLE pledge_payments DROP COLUMN IF EXISTS bank_line_key").Error
+			},
+		},
+		{
+			Version:     "045_unique_bank_fingerprints",
+			Description: "Make bank transaction fingerprints unique so concurrent imports cannot store a line twice",
+			Up: func(db *gorm.DB) error {
+				var duplicates int64
+				if err := db.Raw(`SELECT COUNT(*) FROM (SELECT fingerprint FROM bank_transactions
+					GROUP BY fingerprint HAVING COUNT(*) > 1) d`).Scan(&duplicates).Error; err != nil {
+					return err
+				}
+				if duplicates > 0 {
+					return fmt.Errorf("%d bank transaction fingerprints are duplicated; remove the repeated rows before migrating", duplicates)
+				}
+				if err := db.Exec("DROP INDEX IF EXISTS idx_bank_transactions_fingerprint").Error; err != nil {
+					return err
+				}
+				return db.AutoMigrate(&models.BankTransaction{})
+			},
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("DROP INDEX IF EXISTS idx_bank_transactions_fingerprint").Error; err != nil {
+					return err
+				}
+				return db.Exec("CREATE INDEX IF NOT EXISTS idx_bank_transactions_fingerprint ON bank_transactions (fingerprint)").Error
+			},
+		},
+		{
+			Version:     "046_inbound_replies",
+			Description: "Log email replies to help request and feedback messages",
+			Up:          autoMigrate(&models.InboundReply{}),
+			Down:        dropTables("inbound_replies"),
+		},
+		{
+			Version:     "047_partner_api_keys",
+			Description: "Add partner API keys and daily usage counts",
+			Up:          autoMigrate(&models.APIKey{}, &models.APIKeyUsage{}),
+			Down:        dropTables("api_key_usage", "api_keys"),
+		},
+		{
+			Version:     "048_volunteer_statements",
+			Description: "Add monthly volunteer hour statements",
+			Up:          autoMigrate(&models.VolunteerStatement{}),
+			Down:        dropTables("volunteer_statements"),
+		},
+		{
+			Version:     "049_appointments",
+			Description: "Add advice appointment slots and bookings",
+			Up:          autoMigrate(&models.AppointmentSlot{}, &models.Appointment{}),
+			Down:        dropTables("appointments", "appointment_slots"),
+		},
+		{
+			Version:     "050_shift_visibility_rules",
+			Description: "Add shift role levels and training required for shift roles",
+			Up:          autoMigrate(&models.Shift{}, &models.ShiftRoleTrainingRequirement{}),
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("ALTER TABLE shifts DROP COLUMN IF EXISTS role_level").Error; err != nil {
+					return err
+				}
+				return dropTables("shift_role_training_requirements")(db)
+			},
+		},
+		{
+			Version:     "051_feedback_invitations",
+			Description: "Add feedback survey invitations sent after visits",
+			Up:          autoMigrate(&models.FeedbackInvitation{}),
+			Down:        dropTables("feedback_invitations"),
+		},
+		{
+			Version:     "052_admin_change_reports",
+			Description: "Add weekly reports of administrative changes for trustees",
+			Up:          autoMigrate(&models.AdminChangeReport{}),
+			Down:        dropTables("admin_change_reports"),
+		},
+		{
+			Version:     "053_capacity_overrides",
+			Description: "Add capacity override requests and the override share of daily capacity",
+			Up:          autoMigrate(&models.CapacityOverride{}, &models.VisitCapacity{}),
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("ALTER TABLE visit_capacities DROP COLUMN IF EXISTS override_food_visits, DROP COLUMN IF EXISTS override_general_visits").Error; err != nil {
+					return err
+				}
+				return dropTables("capacity_overrides")(db)
+			},
+		},
+		{
+			Version:     "054_queue_missed_calls",
+			Description: "Add missed call count and re-call pool time to queue entries",
+			Up:          autoMigrate(&models.QueueEntry{}),
+			Down: func(db *gorm.DB) error {
+				return db.Exec("ALTER TABLE queue_entries DROP COLUMN IF EXISTS missed_calls, DROP COLUMN IF EXISTS recall_pool_at").Error
+			},
+		},
+		{
+			Version:     "055_document_rejection_reasons",
+			Description: "Add managed document rejection reasons and record them on decisions",
+			Up: func(db *gorm.DB) error {
+				if err := db.AutoMigrate(&models.DocumentRejectionReason{}, &models.Document{}, &models.DocumentVerificationResult{}); err != nil {
+					return err
+				}
+				return createDefaultRejectionReasons(db)
+			},
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("ALTER TABLE documents DROP COLUMN IF EXISTS rejection_code").Error; err != nil {
+					return err
+				}
+				if err := db.Exec("ALTER TABLE document_verification_results DROP COLUMN IF EXISTS rejection_code").Error; err != nil {
+					return err
+				}
+				return dropTables("document_rejection_reasons")(db)
+			},
+		},
+		{
+			Version:     "056_shift_feedback",
+			Description: "Add volunteer shift feedback and track feedback prompts on assignments",
+			Up:          autoMigrate(&models.ShiftFeedback{}, &models.ShiftAssignment{}),
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("ALTER TABLE shift_assignments DROP COLUMN IF EXISTS feedback_requested_at").Error; err != nil {
+					return err
+				}
+				return dropTables("shift_feedback")(db)
+			},
+		},
+		{
+			Version:     "057_account_deletion_grace_period",
+			Description: "Add grace period scheduling and cancel links to account deletion requests",
+			Up:          autoMigrate(&models.AccountDeletionRequest{}),
+			Down: func(db *gorm.DB) error {
+				return db.Exec("ALTER TABLE account_deletion_requests DROP COLUMN IF EXISTS scheduled_for, DROP COLUMN IF EXISTS cancelled_at, DROP COLUMN IF EXISTS cancel_token, DROP COLUMN IF EXISTS previous_status").Error
+			},
+		},
+		{
+			Version:     "058_booking_windows",
+			Description: "Add per-category and per-day booking windows for help requests",
+			Up:          autoMigrate(&models.BookingWindow{}),
+			Down:        dropTables("booking_windows"),
+		},
+		{
+			Version:     "059_interpreter_bookings",
+			Description: "Track interpreter bookings for help requests and show their status on tickets",
+			Up:          autoMigrate(&models.InterpreterBooking{}, &models.HelpRequest{}, &models.Ticket{}),
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("ALTER TABLE tickets DROP COLUMN IF EXISTS interpreter_status").Error; err != nil {
+					return err
+				}
+				if err := db.Exec("ALTER TABLE help_requests DROP COLUMN IF EXISTS interpreter_language").Error; err != nil {
+					return err
+				}
+				return dropTables("interpreter_bookings")(db)
+			},
+		},
+		{
+			Version:     "060_shift_code_check_in",
+			Description: "Record how volunteers checked in and wrong shift codes entered",
+			Up:          autoMigrate(&models.ShiftAssignment{}),
+			Down: func(db *gorm.DB) error {
+				return db.Exec("ALTER TABLE shift_assignments DROP COLUMN IF EXISTS check_in_method, DROP COLUMN IF EXISTS check_in_failures").Error
+			},
+		},
+		{
+			Version:     "061_historic_imports",
+			Description: "Stage historic spreadsheet imports for review and rollback",
+			Up:          autoMigrate(&models.HistoricImportBatch{}, &models.HistoricImportRow{}),
+			Down:        dropTables("historic_import_rows", "historic_import_batches"),
+		},
+		{
+			Version:     "062_visitor_communication_formats",
+			Description: "Let visitors ask for plain-language and large-print communications",
+			Up:          autoMigrate(&models.VisitorProfile{}),
+			Down: func(db *gorm.DB) error {
+				return db.Exec("ALTER TABLE visitor_profiles DROP COLUMN IF EXISTS plain_language, DROP COLUMN IF EXISTS large_print").Error
+			},
+		},
+		{
+			Version:     "063_contact_restrictions",
+			Description: "Add do-not-contact and vulnerable adult flags with a log of suppressed contact attempts",
+			Up:          autoMigrate(&models.ContactRestriction{}, &models.ContactSuppressionLog{}),
+			Down:        dropTables("contact_suppression_logs", "contact_restrictions"),
+		},
+		{
+			Version:     "064_system_alerts",
+			Description: "Persist system alerts with acknowledgement, snooze and auto-resolve",
+			Up:          autoMigrate(&models.Alert{}),
+			Down:        dropTables("system_alerts"),
+		},
+		{
+			Version:     "065_tags",
+			Description: "Add tags for visitors, volunteers, help requests and donations, and campaign audiences by tag",
+			Up:          autoMigrate(&models.Tag{}, &models.TagAssignment{}, &models.Campaign{}),
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("ALTER TABLE campaigns DROP COLUMN IF EXISTS audience_tag").Error; err != nil {
+					return err
+				}
+				return dropTables("tag_assignments", "tags")(db)
+			},
+		},
+		{
+			Version:     "066_shift_series",
+			Description: "Group recurring shifts into series and ask volunteers to re-confirm changed shifts",
+			Up:          autoMigrate(&models.ShiftSeries{}, &models.Shift{}, &models.ShiftAssignment{}),
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("ALTER TABLE shift_assignments DROP COLUMN IF EXISTS reconfirm_requested_at, DROP COLUMN IF EXISTS reconfirmed_at").Error; err != nil {
+					return err
+				}
+				if err := db.Exec("ALTER TABLE shifts DROP COLUMN IF EXISTS series_id").Error; err != nil {
+					return err
+				}
+				return dropTables("shift_series")(db)
+			},
+		},
+		{
+			Version:     "067_gift_aid_declarations",
+			Description: "Store dated Gift Aid declarations and link donations made with one",
+			Up:          autoMigrate(&models.GiftAidDeclaration{}, &models.Donation{}),
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("ALTER TABLE donations DROP COLUMN IF EXISTS gift_aid_id").Error; err != nil {
+					return err
+				}
+				return dropTables("gift_aid_declarations")(db)
+			},
+		},
+		{
+			Version:     "068_release_runs",
+			Description: "Record each ticket release as a hash-chained release run",
+			Up:          autoMigrate(&models.ReleaseRun{}),
+			Down:        dropTables("release_runs"),
+		},
+		{
+			Version:     "069_donation_receipts",
+			Description: "Number donation receipts in a register",
+			Up:          autoMigrate(&models.DonationReceipt{}),
+			Down:        dropTables("donation_receipts"),
+		},
+		{
+			Version:     "070_auto_approval",
+			Description: "Add auto-approval rules for help requests and the record of what they approved",
+			Up:          autoMigrate(&models.AutoApprovalRule{}, &models.AutoApproval{}),
+			Down:        dropTables("auto_approvals", "auto_approval_rules"),
+		},
+		{
+			Version:     "071_inventory",
+			Description: "Add inventory categories, stock items and the stock movement ledger",
+			Up:          autoMigrate(&models.InventoryCategory{}, &models.InventoryItem{}, &models.StockMovement{}),
+			Down:        dropTables("stock_movements", "inventory_items", "inventory_categories"),
+		},
+		{
+			Version:     "072_volunteer_teams",
+			Description: "Move volunteer team members into their own table and add team lead sign-off of attendance and hours",
+			Up: func(db *gorm.DB) error {
+				if err := db.AutoMigrate(&models.VolunteerTeam{}, &models.VolunteerTeamMember{}, &models.ShiftAssignment{}); err != nil {
+					return err
+				}
+				if !db.Migrator().HasColumn("volunteer_teams", "members") {
+					return nil
+				}
+				// Leads and the IDs in the old "1,2" or "[1,2]" member lists join their
+				// team; a volunteer listed in several teams stays in the oldest one
+				if err := db.Exec(`INSERT INTO volunteer_team_members (team_id, user_id, joined_at, added_by)
+					SELECT DISTINCT ON (m.user_id) t.id, m.user_id, t.created_at, t.lead_id
+					FROM volunteer_teams t
+					CROSS JOIN LATERAL (
+						SELECT t.lead_id AS user_id
+						UNION
+						SELECT TRIM(v)::bigint FROM regexp_split_to_table(TRIM(BOTH '[]' FROM COALESCE(t.members, '')), ',') v
+						WHERE TRIM(v) ~ '^[0-9]+$'
+					) m
+					JOIN users ON users.id = m.user_id AND users.role = ?
+					WHERE t.deleted_at IS NULL
+					ORDER BY m.user_id, t.id
+					ON CONFLICT (user_id) DO NOTHING`, models.RoleVolunteer).Error; err != nil {
+					return err
+				}
+				return db.Exec("ALTER TABLE volunteer_teams DROP COLUMN IF EXISTS members").Error
+			},
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("ALTER TABLE shift_assignments DROP COLUMN IF EXISTS attendance_confirmed_by, DROP COLUMN IF EXISTS attendance_confirmed_at, DROP COLUMN IF EXISTS hours_approved_by, DROP COLUMN IF EXISTS hours_approved_at").Error; err != nil {
+					return err
+				}
+				if err := db.Exec("ALTER TABLE volunteer_teams ADD COLUMN IF NOT EXISTS members text").Error; err != nil {
+					return err
+				}
+				if err := db.Exec(`UPDATE volunteer_teams SET members = (
+					SELECT '[' || string_agg(user_id::text, ',' ORDER BY user_id) || ']'
+					FROM volunteer_team_members WHERE team_id = volunteer_teams.id)`).Error; err != nil {
+					return err
+				}
+				if err := db.Exec("ALTER TABLE volunteer_teams DROP COLUMN IF EXISTS can_confirm_attendance, DROP COLUMN IF EXISTS can_approve_hours").Error; err != nil {
+					return err
+				}
+				return dropTables("volunteer_team_members")(db)
+			},
+		},
+		{
+			Version:     "073_goods_intake",
+			Description: "Add declared and received donation items, and batch and expiry on stock received",
+			Up:          autoMigrate(&models.InventoryCategory{}, &models.StockMovement{}, &models.DonationItem{}),
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("ALTER TABLE stock_movements DROP COLUMN IF EXISTS batch_code, DROP COLUMN IF EXISTS expires_on, DROP COLUMN IF EXISTS donation_id").Error; err != nil {
+					return err
+				}
+				if err := db.Exec("ALTER TABLE inventory_categories DROP COLUMN IF EXISTS tracks_expiry").Error; err != nil {
+					return err
+				}
+				return dropTables("donation_items")(db)
+			},
+		},
+		{
+			Version:     "074_visitor_reverification",
+			Description: "Add visitor re-verification cycles",
+			Up:          autoMigrate(&models.VisitorReverification{}),
+			Down:        dropTables("visitor_reverifications"),
+		},
+		{
+			Version:     "075_notification_languages",
+			Description: "Add preferred and location languages, template and campaign translations and the message language log",
+			Up: autoMigrate(&models.User{}, &models.BrandingProfile{}, &models.NotificationTemplateTranslation{},
+				&models.CampaignTranslation{}, &models.NotificationLanguageLog{}),
+			Down: func(db *gorm.DB) error {
+				if err := db.Exec("ALTER TABLE users DROP COLUMN IF EXISTS language").Error; err != nil {
+					return err
+				}
+				if err := db.Exec("ALTER TABLE branding_profiles DROP COLUMN IF EXISTS language").Error; err != nil {
+					return err
+				}
+				return dropTables("notification_language_logs", "campaign_translations", "notification_template_translations")(db)
+			},
+		},
+		{
+			Version:     "076_notification_category_preferences",
+			Description: "Add per-category notification channel preferences",
+			Up:          autoMigrate(&models.NotificationPreference{}),
+			Down:        dropTables("notification_category_preferences"),
+		},
+		{
+			Version:     "077_environmental_impact",
+			Description: "Add visit goods weights and environmental impact factors",
+			Up:          autoMigrate(&models.VisitGoodsWeight{}, &models.EnvironmentalFactor{}),
+			Down:        dropTables("visit_goods_weights", "environmental_factors"),
+		},
+		{
+			Version:     "078_emergency_broadcasts",
+			Description: "Add emergency broadcasts to today's ticket holders and their delivery outcomes",
+			Up:          autoMigrate(&models.EmergencyBroadcast{}, &models.EmergencyBroadcastDelivery{}),
+			Down:        dropTables("emergency_broadcast_deliveries", "emergency_broadcasts"),
+		},
+		{
+			Version:     "079_donor_onboarding",
+			Description: "Add donor onboarding with acquisition source and mark outbox messages by kind",
+			Up:          autoMigrate(&models.DonorOnboarding{}, &models.NotificationOutbox{}),
+			Down: func(db *gorm.DB) error {
+				if err := dropTables("donor_onboardings")(db); err != nil {
+					return err
+				}
+				return db.Migrator().DropColumn(&models.NotificationOutbox{}, "kind")
+			},
+		},
+		{
+			Version:     "080_notification_digests",
+			Description: "Add daily and weekly notification digests",
+			Up:          autoMigrate(&models.NotificationPreference{}, &models.NotificationDigestItem{}),
+			Down: func(db *gorm.DB) error {
+				if err := dropTables("notification_digest_items")(db); err != nil {
+					return err
+				}
+				return db.Migrator().DropColumn(&models.NotificationPreference{}, "digest")
+			},
+		},
+		{
+			Version:     "081_kpi_anomalies",
+			Description: "Add daily KPI anomalies found against learned normal ranges",
+			Up:          autoMigrate(&models.KPIAnomaly{}),
+			Down:        dropTables("kpi_anomalies"),
+		},
+		{
+			Version:     "082_transactional_email_templates",
+			Description: "Let notification templates replace built-in transactional emails, with plain-text bodies and variables",
+			Up:          autoMigrate(&models.NotificationTemplate{}),
+			Down: func(db *gorm.DB) error {
+				for _, column := range []string{"text_body", "variables", "template_type", "updated_by"} {
+					if err := db.Migrator().DropColumn(&models.NotificationTemplate{}, column); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Version:     "083_help_request_drafts",
+			Description: "Add help request wizard drafts for save-and-resume and drop-off analytics",
+			Up:          autoMigrate(&models.HelpRequestDraft{}),
+			Down:        dropTables("help_request_drafts"),
+		},
+		{
+			Version:     "084_ticket_release_schedule",
+			Description: "Add the automatic ticket release schedule and a record of each scheduled slot",
+			Up:          autoMigrate(&models.TicketReleaseSchedule{}, &models.ScheduledReleaseRun{}),
+			Down:        dropTables("scheduled_release_runs", "ticket_release_schedules"),
+		},
+		{
+			Version:     "085_product_barcodes",
+			Description: "Cache scanned product barcodes and the stock items they are booked into",
+			Up:          autoMigrate(&models.ProductBarcode{}),
+			Down:        dropTables("product_barcodes"),
+		},
+		{
+			Version:     "086_research_datasets",
+			Description: "Add anonymised research dataset exports and postcode to ward mappings",
+			Up:          autoMigrate(&models.ResearchDatasetExport{}, &models.PostcodeWard{}),
+			Down:        dropTables("postcode_wards", "research_dataset_exports"),
+		},
+		{
+			Version:     "087_service_closures",
+			Description: "Add service closure periods and the queue of what arrives while closed",
+			Up:          autoMigrate(&models.ServiceClosure{}, &models.ClosureQueueItem{}),
+			Down:        dropTables("closure_queue_items", "service_closures"),
+		},
+		{
+			Version:     "088_approve_imported_volunteer_hours",
+			Description: "Mark volunteer hours recorded by historic imports as approved, as only approved hours count towards totals",
+			Up: func(db *gorm.DB) error {
+				return db.Exec(`UPDATE shift_assignments SET hours_approved_by = b.created_by, hours_approved_at = b.committed_at
+					FROM historic_import_rows r JOIN historic_import_batches b ON b.id = r.batch_id
+					WHERE r.created_type = 'shift_assignment' AND r.created_id = shift_assignments.id
+					AND r.status = ? AND shift_assignments.hours_approved_at IS NULL`, models.HistoricRowImported).Error
+			},
+			Down: func(db *gorm.DB) error { return nil },
+		},
+		{
+			Version:     "089_ticket_link_tokens",
+			Description: "Give each ticket a random token for its public short link",
+			Up: func(db *gorm.DB) error {
+				if err := db.AutoMigrate(&models.Ticket{}); err != nil {
+					return err
+				}
+				var ids []uint
+				if err := db.Unscoped().Model(&models.Ticket{}).Where("link_token IS NULL OR link_token = ''").
+					Pluck("id", &ids).Error; err != nil {
+					return err
+				}
+				for _, id := range ids {
+					token, err := models.NewTicketLinkToken()
+					if err != nil {
+						return err
+					}
+					if err := db.Exec("UPDATE tickets SET link_token = ? WHERE id = ?", token, id).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			Down: func(db *gorm.DB) error {
+				return db.Migrator().DropColumn(&models.Ticket{}, "link_token")
+			},
+		},
 	}
 }
 
@@ -726,7 +1451,6 @@ func createSampleVolunteerRoleData(db *gorm.DB) error {
 				Name:        "Community Outreach Team",
 				Description: "Handles community outreach and special events",
 				LeadID:      lead.UserID,
-				Members:     fmt.Sprintf("[%d]", lead.UserID),
 				Active:      true,
 				CreatedAt:   time.Now(),
 				UpdatedAt:   time.Now(),
@@ -734,6 +1458,8 @@ func createSampleVolunteerRoleData(db *gorm.DB) error {
 
 			if err := db.Create(&team).Error; err != nil {
 				log.Printf("Failed to create sample team: %v", err)
+			} else if err := db.Create(&models.VolunteerTeamMember{TeamID: team.ID, UserID: lead.UserID, JoinedAt: time.Now(), AddedBy: lead.UserID}).Error; err != nil {
+				log.Printf("Failed to add lead to sample team: %v", err)
 			} else {
 				log.Printf("Created sample team: %s", team.Name)
 			}
@@ -803,6 +1529,63 @@ func createSampleVolunteerRoleData(db *gorm.DB) error {
 	return nil
 }
 
+// normalizeUserPhones converts existing phone numbers to E.164 format and adds a
+// unique index so a phone number can identify a user in place of an email.
+// Numbers that cannot be parsed are left unchanged.
+func normalizeUserPhones(db *gorm.DB) error {
+	var users []models.User
+	if err := db.Select("id, phone").Where("phone <> ''").Find(&users).Error; err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		normalized, err := models.NormalizePhone(user.Phone)
+		if err != nil || normalized == user.Phone {
+			continue
+		}
+		if err := db.Model(&models.User{}).Where("id = ?", user.ID).
+			Update("phone", normalized).Error; err != nil {
+			return fmt.Errorf("failed to normalise phone for user %d: %w", user.ID, err)
+		}
+	}
+
+	// Existing duplicates must be resolved by an admin before the index can be created;
+	// until then registration rejects phone numbers that are already in use, shared
+	// numbers cannot sign in by SMS, and the index is created once the admin duplicate
+	// list comes back empty.
+	var duplicates int64
+	if err := db.Raw("SELECT COUNT(*) FROM (SELECT phone FROM users WHERE phone <> '' AND deleted_at IS NULL " +
+		"GROUP BY phone HAVING COUNT(*) > 1) d").Scan(&duplicates).Error; err != nil {
+		return err
+	}
+	if duplicates > 0 {
+		log.Printf("Warning: %d phone numbers are shared by more than one user, skipping unique phone index", duplicates)
+		return nil
+	}
+
+	return db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_phone_unique ON users (phone) " +
+		"WHERE phone <> '' AND deleted_at IS NULL").Error
+}
+
+// autoMigrate returns a migration step that creates or updates the tables for the given models
+func autoMigrate(tables ...interface{}) func(*gorm.DB) error {
+	return func(db *gorm.DB) error {
+		return db.AutoMigrate(tables...)
+	}
+}
+
+// dropTables returns a rollback step that drops the given tables
+func dropTables(tables ...string) func(*gorm.DB) error {
+	return func(db *gorm.DB) error {
+		for _, table := range tables {
+			if err := db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE", table)).Error; err != nil {
+				return fmt.Errorf("failed to drop table %s: %w", table, err)
+			}
+		}
+		return nil
+	}
+}
+
 // timePtr returns a pointer to a time.Time value
 func timePtr(t time.Time) *time.Time {
 	return &t
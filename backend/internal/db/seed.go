@@ -272,6 +272,7 @@ func seedShiftAssignments(db *gorm.DB) error {
 			assignment.CheckedInAt = &checkInTime
 			assignment.CheckedOutAt = &checkOutTime
 			assignment.HoursLogged = checkOutDuration.Hours()
+			assignment.HoursApprovedAt = &checkOutTime
 		}
 
 		assignments = append(assignments, assignment)
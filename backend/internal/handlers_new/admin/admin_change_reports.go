@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminChangeReportRequest generates the weekly change report for a week
+type AdminChangeReportRequest struct {
+	WeekStart string `json:"week_start" binding:"required"` // Any day in the week, YYYY-MM-DD
+	Send      bool   `json:"send"`                          // Email it to trustees
+}
+
+// AdminListChangeReports returns the weekly administrative change reports, newest first
+func AdminListChangeReports(c *gin.Context) {
+	reports, err := services.NewAdminChangeReportService().Reports(52)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch change reports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports})
+}
+
+// AdminGetChangeReport returns one weekly change report by its Monday, with each
+// member of staff's changes
+func AdminGetChangeReport(c *gin.Context) {
+	record, content, err := services.NewAdminChangeReportService().Report(c.Param("week"))
+	if err != nil {
+		if errors.Is(err, services.ErrAdminChangeReportNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch change report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"report":  record,
+		"content": content,
+	})
+}
+
+// AdminGenerateChangeReport builds, or rebuilds, the change report for a finished week
+// and optionally emails it to trustees
+func AdminGenerateChangeReport(c *gin.Context) {
+	var req AdminChangeReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	weekStart, err := time.ParseInLocation("2006-01-02", req.WeekStart, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "week_start must be in YYYY-MM-DD format"})
+		return
+	}
+
+	report, err := services.NewAdminChangeReportService().GenerateWeeklyReport(weekStart, req.Send)
+	if err != nil {
+		if errors.Is(err, services.ErrAdminChangeReportFuture) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate change report"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Generate", "AdminChangeReport", report.ID,
+		fmt.Sprintf("Change report for week of %s generated, emailed to %d", report.WeekStart, report.EmailedTo))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Change report generated",
+		"report":  report,
+	})
+}
@@ -0,0 +1,184 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A manual export gives up after this long; the scheduled job picks up the rest
+const manualAnalyticsExportTimeout = 2 * time.Minute
+
+// newAnalyticsExportService creates the export service, replying with the config error
+// if the settings are invalid
+func newAnalyticsExportService(c *gin.Context) (*services.AnalyticsExportService, bool) {
+	exporter, err := services.NewAnalyticsExportService()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Analytics export is misconfigured: " + err.Error()})
+		return nil, false
+	}
+	return exporter, true
+}
+
+// GetAnalyticsExportStatus returns the export settings and capture and export progress
+// for each stream
+func GetAnalyticsExportStatus(c *gin.Context) {
+	exporter, err := services.NewAnalyticsExportService()
+	if err != nil {
+		// Still show progress so a bad setting can be diagnosed
+		cfg, _ := services.LoadAnalyticsExportConfig()
+		c.JSON(http.StatusOK, gin.H{"config": cfg, "config_error": err.Error()})
+		return
+	}
+
+	status, err := exporter.Status()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch analytics export status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// ListAnalyticsExportBatches returns recent export batches, optionally by status
+func ListAnalyticsExportBatches(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	query := db.DB.Model(&models.AnalyticsExportBatch{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if sink := c.Query("sink"); sink != "" {
+		query = query.Where("sink = ?", sink)
+	}
+
+	var batches []models.AnalyticsExportBatch
+	if err := query.Order("id DESC").Limit(limit).Find(&batches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch export batches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"batches": batches,
+		"total":   len(batches),
+	})
+}
+
+// RunAnalyticsExport captures new events and exports them to the sink now rather than
+// waiting for the scheduled job
+func RunAnalyticsExport(c *gin.Context) {
+	exporter, ok := newAnalyticsExportService(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), manualAnalyticsExportTimeout)
+	defer cancel()
+
+	result, err := exporter.Run(ctx)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAnalyticsExportRunning):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAnalyticsNoSalt):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Analytics export failed: " + err.Error(), "result": result})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "Export", "AnalyticsEvent", 0,
+		fmt.Sprintf("Ran analytics export to %s: %d events in %d batches", exporter.Config().Sink, result.Exported, len(result.Batches)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Analytics export complete",
+		"result":  result,
+	})
+}
+
+// BackfillAnalyticsEventsRequest selects the source rows to backfill by creation date
+type BackfillAnalyticsEventsRequest struct {
+	Stream    string `json:"stream" binding:"required"`
+	StartDate string `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate   string `json:"end_date" binding:"required"`   // YYYY-MM-DD, inclusive
+}
+
+// BackfillAnalyticsEvents captures events for older source rows, e.g. after a new
+// stream is added or the log was cleared. Backfilled events go out with the next export.
+func BackfillAnalyticsEvents(c *gin.Context) {
+	var req BackfillAnalyticsEventsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+	if !models.IsAnalyticsStream(req.Stream) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown stream", "valid_streams": models.AnalyticsStreams})
+		return
+	}
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD"})
+		return
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	exporter, ok := newAnalyticsExportService(c)
+	if !ok {
+		return
+	}
+
+	captured, err := exporter.Backfill(req.Stream, start, end.AddDate(0, 0, 1))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAnalyticsInvalidRange):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be on or after start_date"})
+		case errors.Is(err, services.ErrAnalyticsNoSalt):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Backfill failed", "captured": captured})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "Backfill", "AnalyticsEvent", 0,
+		fmt.Sprintf("Backfilled %d %s analytics events from %s to %s", captured, req.Stream, req.StartDate, req.EndDate))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Backfill complete",
+		"stream":   req.Stream,
+		"captured": captured,
+	})
+}
+
+// GetAnalyticsExportSchemas describes the exported row and each stream's payload at its
+// current schema version
+func GetAnalyticsExportSchemas(c *gin.Context) {
+	exporter, ok := newAnalyticsExportService(c)
+	if !ok {
+		return
+	}
+
+	envelope, streams := exporter.Schemas()
+	c.JSON(http.StatusOK, gin.H{
+		"envelope": envelope,
+		"streams":  streams,
+	})
+}
@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminListAnnouncements returns announcements, newest first. Pass live=true for only
+// those showing now.
+func AdminListAnnouncements(c *gin.Context) {
+	var announcements []models.Announcement
+	if err := db.DB.Order("created_at DESC").Find(&announcements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch announcements"})
+		return
+	}
+
+	now := time.Now()
+	results := make([]gin.H, 0, len(announcements))
+	for i := range announcements {
+		live := announcements[i].IsLive(now)
+		if c.Query("live") == "true" && !live {
+			continue
+		}
+		results = append(results, gin.H{
+			"announcement": announcements[i],
+			"live":         live,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"announcements": results})
+}
+
+// AdminCreateAnnouncement adds a banner and pushes it to connected users in its audience
+func AdminCreateAnnouncement(c *gin.Context) {
+	announcement := models.Announcement{Active: true, Priority: "medium"}
+	if err := c.ShouldBindJSON(&announcement); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	announcement.ID = 0
+
+	announcementService := services.NewAnnouncementService()
+	if err := announcementService.Validate(&announcement); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	announcement.CreatedByID = utils.GetUserIDFromContext(c)
+
+	if err := db.DB.Omit("CreatedBy").Create(&announcement).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create announcement"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "Announcement", announcement.ID,
+		fmt.Sprintf("%s announcement %q created for %s", announcement.Severity, announcement.Title, announcement.TargetRole))
+
+	go announcementService.Publish(&announcement, time.Now())
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Announcement created successfully",
+		"announcement": announcement,
+	})
+}
+
+// AdminUpdateAnnouncement edits a banner, for example to end it early
+func AdminUpdateAnnouncement(c *gin.Context) {
+	announcement, ok := loadAnnouncement(c)
+	if !ok {
+		return
+	}
+
+	id, createdBy := announcement.ID, announcement.CreatedByID
+	if err := c.ShouldBindJSON(announcement); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	announcement.ID, announcement.CreatedByID = id, createdBy
+
+	announcementService := services.NewAnnouncementService()
+	if err := announcementService.Validate(announcement); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.DB.Omit("CreatedBy").Save(announcement).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update announcement"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "Announcement", announcement.ID,
+		fmt.Sprintf("Announcement %q updated", announcement.Title))
+
+	go announcementService.Publish(announcement, time.Now())
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Announcement updated successfully",
+		"announcement": announcement,
+	})
+}
+
+// AdminDeleteAnnouncement removes a banner and clears it from connected clients
+func AdminDeleteAnnouncement(c *gin.Context) {
+	announcement, ok := loadAnnouncement(c)
+	if !ok {
+		return
+	}
+
+	if err := db.DB.Delete(announcement).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete announcement"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "Announcement", announcement.ID,
+		fmt.Sprintf("Announcement %q deleted", announcement.Title))
+
+	announcement.Active = false
+	go services.NewAnnouncementService().Publish(announcement, time.Now())
+
+	c.JSON(http.StatusOK, gin.H{"message": "Announcement deleted"})
+}
+
+// loadAnnouncement loads the announcement identified by the :id path parameter
+func loadAnnouncement(c *gin.Context) (*models.Announcement, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid announcement ID"})
+		return nil, false
+	}
+
+	var announcement models.Announcement
+	if err := db.DB.First(&announcement, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Announcement not found"})
+		return nil, false
+	}
+
+	return &announcement, true
+}
@@ -0,0 +1,156 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// APIKeyRequest issues a partner API key
+type APIKeyRequest struct {
+	Name    string `json:"name" binding:"required"`
+	Partner string `json:"partner" binding:"required"`
+	OwnerID *uint  `json:"owner_id"` // Partner contact who can see the key's usage
+}
+
+// ListAPIKeys returns every partner API key with its usage over the period, defaulting
+// to the last 30 days, flagging dormant keys
+func ListAPIKeys(c *gin.Context) {
+	from, to, ok := apiKeyUsageRange(c)
+	if !ok {
+		return
+	}
+
+	reports, err := services.NewAPIKeyService().Overview(from, to, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys":  reports,
+		"total": len(reports),
+		"from":  from,
+		"to":    to,
+	})
+}
+
+// CreateAPIKey issues a partner API key and returns it once
+func CreateAPIKey(c *gin.Context) {
+	var req APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.OwnerID != nil {
+		var owner models.User
+		if err := db.DB.Select("id").First(&owner, *req.OwnerID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Owner not found"})
+			return
+		}
+	}
+
+	key, secret, err := services.NewAPIKeyService().CreateKey(req.Name, req.Partner, req.OwnerID, utils.GetUserIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "APIKey", key.ID,
+		fmt.Sprintf("API key issued to %s: %s (%s)", key.Partner, key.Name, key.KeyPrefix))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "API key created. Copy the key now, it will not be shown again",
+		"key":     key,
+		"secret":  secret,
+	})
+}
+
+// GetAPIKeyUsage returns one key's usage by endpoint and by day
+func GetAPIKeyUsage(c *gin.Context) {
+	key, ok := loadAPIKey(c)
+	if !ok {
+		return
+	}
+	from, to, ok := apiKeyUsageRange(c)
+	if !ok {
+		return
+	}
+
+	report, err := services.NewAPIKeyService().Report(*key, from, to, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API key usage"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// RevokeAPIKey stops a partner API key from being accepted
+func RevokeAPIKey(c *gin.Context) {
+	key, ok := loadAPIKey(c)
+	if !ok {
+		return
+	}
+
+	if err := services.NewAPIKeyService().Revoke(key, utils.GetUserIDFromContext(c)); err != nil {
+		if errors.Is(err, services.ErrAPIKeyRevoked) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Revoke", "APIKey", key.ID, fmt.Sprintf("API key revoked: %s (%s)", key.Name, key.Partner))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API key revoked",
+		"key":     key,
+	})
+}
+
+// loadAPIKey finds the key named in the path, writing the error response if it cannot
+func loadAPIKey(c *gin.Context) (*models.APIKey, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return nil, false
+	}
+
+	var key models.APIKey
+	if err := db.DB.First(&key, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API key"})
+		}
+		return nil, false
+	}
+	return &key, true
+}
+
+// apiKeyUsageRange reads from_date and to_date, defaulting to the last 30 days
+func apiKeyUsageRange(c *gin.Context) (string, string, bool) {
+	now := time.Now()
+	from := c.DefaultQuery("from_date", now.AddDate(0, 0, -29).Format("2006-01-02"))
+	to := c.DefaultQuery("to_date", now.Format("2006-01-02"))
+	if _, err := time.Parse("2006-01-02", from); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from_date must be in YYYY-MM-DD format"})
+		return "", "", false
+	}
+	if _, err := time.Parse("2006-01-02", to); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to_date must be in YYYY-MM-DD format"})
+		return "", "", false
+	}
+	return from, to, true
+}
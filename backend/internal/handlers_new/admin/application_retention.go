@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminGetApplicationRetention lists rejected and withdrawn volunteer applications due
+// to be anonymized within the next `days` days (default 30), with counts by outcome
+// and source for review
+func AdminGetApplicationRetention(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 0 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 0 and 365"})
+		return
+	}
+
+	retentionService := services.NewApplicationRetentionService()
+	upcoming, err := retentionService.Upcoming(time.Now(), time.Duration(days)*24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch upcoming anonymizations"})
+		return
+	}
+	counts, err := retentionService.OutcomeCounts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch application statistics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"retention_days": retentionService.RetentionDays(),
+		"window_days":    days,
+		"upcoming":       upcoming,
+		"outcomes":       counts,
+	})
+}
@@ -0,0 +1,254 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AppointmentCancelRequest gives the reason a slot or appointment was cancelled
+type AppointmentCancelRequest struct {
+	Reason string `json:"reason"`
+}
+
+// appointmentRange reads from_date and to_date (YYYY-MM-DD, inclusive) as local times,
+// defaulting to `days` days starting `offset` days from today
+func appointmentRange(c *gin.Context, offset, days int) (time.Time, time.Time, bool) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from := today.AddDate(0, 0, offset)
+	to := from.AddDate(0, 0, days)
+	if value := c.Query("from_date"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from_date must be in YYYY-MM-DD format"})
+			return from, to, false
+		}
+		from = parsed
+	}
+	if value := c.Query("to_date"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to_date must be in YYYY-MM-DD format"})
+			return from, to, false
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to_date must not be before from_date"})
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// CreateAppointmentSlots opens appointment slots for a member of staff
+func CreateAppointmentSlots(c *gin.Context) {
+	var req services.AppointmentSlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slots, err := services.NewAppointmentService().CreateSlots(req, utils.GetUserIDFromContext(c))
+	if err != nil {
+		writeAppointmentError(c, err, "Failed to create slots")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "AppointmentSlot", slots[0].ID,
+		fmt.Sprintf("%d %s appointment slots opened for staff %d from %s", len(slots), slots[0].Category,
+			req.StaffID, slots[0].StartsAt.Format("2006-01-02 15:04")))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": fmt.Sprintf("%d slots opened", len(slots)),
+		"slots":   slots,
+	})
+}
+
+// CancelAppointmentSlot withdraws a slot and cancels any booking in it
+func CancelAppointmentSlot(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid slot ID"})
+		return
+	}
+	var req AppointmentCancelRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	slot, err := services.NewAppointmentService().CancelSlot(uint(id), utils.GetUserIDFromContext(c), req.Reason, time.Now())
+	if err != nil {
+		writeAppointmentError(c, err, "Failed to cancel slot")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Cancel", "AppointmentSlot", slot.ID, "Appointment slot cancelled: "+req.Reason)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Slot cancelled",
+		"slot":    slot,
+	})
+}
+
+// GetAppointmentCalendar returns slots and bookings for a member of staff, defaulting
+// to the signed-in user and the next 7 days. Pass staff_id=all for everyone.
+func GetAppointmentCalendar(c *gin.Context) {
+	from, to, ok := appointmentRange(c, 0, 7)
+	if !ok {
+		return
+	}
+
+	staffID := utils.GetUserIDFromContext(c)
+	switch value := c.Query("staff_id"); value {
+	case "":
+	case "all":
+		staffID = 0
+	default:
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid staff ID"})
+			return
+		}
+		staffID = uint(parsed)
+	}
+
+	calendar, err := services.NewAppointmentService().Calendar(staffID, c.Query("category"), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calendar"})
+		return
+	}
+
+	days := map[string][]services.CalendarSlot{}
+	for _, slot := range calendar {
+		day := slot.StartsAt.In(time.Local).Format("2006-01-02")
+		days[day] = append(days[day], slot)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"staff_id": staffID,
+		"from":     from.Format("2006-01-02"),
+		"to":       to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"slots":    calendar,
+		"days":     days,
+	})
+}
+
+// CancelAppointmentForVisitor cancels a visitor's booking on their behalf
+func CancelAppointmentForVisitor(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+	var req AppointmentCancelRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	appointment, err := services.NewAppointmentService().Cancel(uint(id), 0, utils.GetUserIDFromContext(c), req.Reason, time.Now())
+	if err != nil {
+		writeAppointmentError(c, err, "Failed to cancel appointment")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Cancel", "Appointment", appointment.ID,
+		fmt.Sprintf("Appointment for visitor %d cancelled by staff: %s", appointment.VisitorID, req.Reason))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Appointment cancelled and the visitor notified",
+		"appointment": appointment,
+	})
+}
+
+// MarkAppointmentAttended records that the visitor came to their appointment
+func MarkAppointmentAttended(c *gin.Context) {
+	recordAppointmentOutcome(c, models.AppointmentAttended)
+}
+
+// MarkAppointmentNoShow records that the visitor missed their appointment
+func MarkAppointmentNoShow(c *gin.Context) {
+	recordAppointmentOutcome(c, models.AppointmentNoShow)
+}
+
+// recordAppointmentOutcome sets an appointment's attendance
+func recordAppointmentOutcome(c *gin.Context, status string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	appointment, err := services.NewAppointmentService().RecordOutcome(uint(id), status, utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		writeAppointmentError(c, err, "Failed to record attendance")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "Appointment", appointment.ID,
+		fmt.Sprintf("Appointment for visitor %d marked %s", appointment.VisitorID, status))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Attendance recorded",
+		"appointment": appointment,
+	})
+}
+
+// GetAppointmentNoShows reports visitors' appointment attendance, defaulting to the
+// last 90 days, and which visitors cannot currently book online
+func GetAppointmentNoShows(c *gin.Context) {
+	from, to, ok := appointmentRange(c, -89, 90)
+	if !ok {
+		return
+	}
+
+	rows, err := services.NewAppointmentService().NoShowReport(from, to, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch no-shows"})
+		return
+	}
+
+	var appointments, noShows int64
+	for _, row := range rows {
+		appointments += row.Appointments
+		noShows += row.NoShows
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":         from.Format("2006-01-02"),
+		"to":           to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"appointments": appointments,
+		"no_shows":     noShows,
+		"visitors":     rows,
+	})
+}
+
+// writeAppointmentError maps appointment service errors to responses
+func writeAppointmentError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrAppointmentSlotNotFound), errors.Is(err, services.ErrAppointmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAppointmentSlotClash), errors.Is(err, services.ErrAppointmentSlotTaken),
+		errors.Is(err, services.ErrAppointmentNotBooked), errors.Is(err, services.ErrAppointmentNotStarted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAppointmentSlotInvalid), errors.Is(err, services.ErrAppointmentSlotTooMany),
+		errors.Is(err, services.ErrAppointmentStaffInvalid), errors.Is(err, services.ErrAppointmentServiceInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AuthProviderRequest creates or updates an OpenID Connect sign-in provider
+type AuthProviderRequest struct {
+	Key            string `json:"key" binding:"required"`
+	Name           string `json:"name" binding:"required"`
+	IssuerURL      string `json:"issuer_url" binding:"required"`
+	ClientID       string `json:"client_id" binding:"required"`
+	ClientSecret   string `json:"client_secret"` // Left unchanged on update when empty
+	Scopes         string `json:"scopes"`
+	AllowedDomains string `json:"allowed_domains"`
+	Enabled        bool   `json:"enabled"`
+}
+
+// apply copies the request onto a provider
+func (req *AuthProviderRequest) apply(provider *models.AuthProvider) {
+	provider.Key = req.Key
+	provider.Name = req.Name
+	provider.IssuerURL = req.IssuerURL
+	provider.ClientID = req.ClientID
+	if req.ClientSecret != "" {
+		provider.ClientSecret = req.ClientSecret
+	}
+	provider.Scopes = req.Scopes
+	if provider.Scopes == "" {
+		provider.Scopes = "openid email profile"
+	}
+	provider.AllowedDomains = req.AllowedDomains
+	provider.Enabled = req.Enabled
+}
+
+// loadAuthProvider loads the provider named by the :id parameter
+func loadAuthProvider(c *gin.Context) (*models.AuthProvider, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid provider ID"})
+		return nil, false
+	}
+
+	var provider models.AuthProvider
+	if err := db.DB.First(&provider, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Provider not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch provider"})
+		}
+		return nil, false
+	}
+	return &provider, true
+}
+
+// ListAuthProviders returns the configured sign-in providers with how many accounts
+// are linked to each
+func ListAuthProviders(c *gin.Context) {
+	var providers []models.AuthProvider
+	if err := db.DB.Order("name ASC").Find(&providers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch providers"})
+		return
+	}
+
+	var counts []struct {
+		ProviderID uint
+		Total      int64
+	}
+	db.DB.Model(&models.UserIdentity{}).
+		Select("provider_id, COUNT(*) AS total").
+		Group("provider_id").
+		Scan(&counts)
+	linked := map[uint]int64{}
+	for _, count := range counts {
+		linked[count.ProviderID] = count.Total
+	}
+
+	results := make([]gin.H, 0, len(providers))
+	for _, provider := range providers {
+		results = append(results, gin.H{
+			"provider":          provider,
+			"has_client_secret": provider.ClientSecret != "",
+			"linked_accounts":   linked[provider.ID],
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"providers": results})
+}
+
+// CreateAuthProvider adds a sign-in provider after checking its discovery document
+func CreateAuthProvider(c *gin.Context) {
+	var req AuthProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	provider := models.AuthProvider{CreatedBy: utils.GetUserIDFromContext(c)}
+	req.apply(&provider)
+	if err := services.NewOIDCService().ValidateProvider(&provider); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing int64
+	db.DB.Model(&models.AuthProvider{}).Where("key = ?", provider.Key).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A provider with this key already exists"})
+		return
+	}
+
+	if err := db.DB.Create(&provider).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create provider"})
+		return
+	}
+	// Enabled defaults to false in the database, so set it explicitly
+	if err := db.DB.Model(&provider).Update("enabled", req.Enabled).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create provider"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "AuthProvider", provider.ID,
+		fmt.Sprintf("Added sign-in provider %s (%s)", provider.Name, provider.IssuerURL))
+
+	c.JSON(http.StatusCreated, gin.H{"provider": provider})
+}
+
+// UpdateAuthProvider changes a sign-in provider's settings
+func UpdateAuthProvider(c *gin.Context) {
+	provider, ok := loadAuthProvider(c)
+	if !ok {
+		return
+	}
+
+	var req AuthProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.apply(provider)
+	if err := services.NewOIDCService().ValidateProvider(provider); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing int64
+	db.DB.Model(&models.AuthProvider{}).Where("key = ? AND id <> ?", provider.Key, provider.ID).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A provider with this key already exists"})
+		return
+	}
+
+	if err := db.DB.Save(provider).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update provider"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "AuthProvider", provider.ID,
+		fmt.Sprintf("Updated sign-in provider %s (enabled: %t)", provider.Name, provider.Enabled))
+
+	c.JSON(http.StatusOK, gin.H{"provider": provider})
+}
+
+// DeleteAuthProvider removes a sign-in provider and the account links made with it.
+// Affected users can still sign in with their password.
+func DeleteAuthProvider(c *gin.Context) {
+	provider, ok := loadAuthProvider(c)
+	if !ok {
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("provider_id = ?", provider.ID).Delete(&models.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("provider_id = ?", provider.ID).Delete(&models.OIDCLoginState{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(provider).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete provider"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "AuthProvider", provider.ID,
+		fmt.Sprintf("Removed sign-in provider %s", provider.Name))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Provider removed"})
+}
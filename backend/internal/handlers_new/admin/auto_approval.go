@@ -0,0 +1,206 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AutoApprovalRuleRequest is the body for creating or replacing an auto-approval rule
+type AutoApprovalRuleRequest struct {
+	Name                    string   `json:"name" binding:"required"`
+	Enabled                 *bool    `json:"enabled"`
+	Priority                int      `json:"priority"`
+	Categories              []string `json:"categories" binding:"required"`
+	RequireVerifiedID       bool     `json:"require_verified_id"`
+	MaxRequests             int      `json:"max_requests"`
+	FrequencyDays           int      `json:"frequency_days"`
+	MaxHouseholdSize        int      `json:"max_household_size"`
+	MinPreviousVisits       int      `json:"min_previous_visits"`
+	RequireEligiblePostcode bool     `json:"require_eligible_postcode"`
+	AllowSpecialNeeds       bool     `json:"allow_special_needs"`
+	AllowUrgent             bool     `json:"allow_urgent"`
+	ReviewSamplePercent     int      `json:"review_sample_percent"`
+}
+
+// rule converts the request into a rule, enabled unless it says otherwise
+func (req AutoApprovalRuleRequest) rule() *models.AutoApprovalRule {
+	enabled := req.Enabled == nil || *req.Enabled
+	priority := req.Priority
+	if priority == 0 {
+		priority = 100
+	}
+	return &models.AutoApprovalRule{
+		Name:                    req.Name,
+		Enabled:                 enabled,
+		Priority:                priority,
+		Categories:              req.Categories,
+		RequireVerifiedID:       req.RequireVerifiedID,
+		MaxRequests:             req.MaxRequests,
+		FrequencyDays:           req.FrequencyDays,
+		MaxHouseholdSize:        req.MaxHouseholdSize,
+		MinPreviousVisits:       req.MinPreviousVisits,
+		RequireEligiblePostcode: req.RequireEligiblePostcode,
+		AllowSpecialNeeds:       req.AllowSpecialNeeds,
+		AllowUrgent:             req.AllowUrgent,
+		ReviewSamplePercent:     req.ReviewSamplePercent,
+	}
+}
+
+// ListAutoApprovalRules returns the auto-approval rules in the order they are tried
+func ListAutoApprovalRules(c *gin.Context) {
+	rules, err := services.NewAutoApprovalService().Rules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch auto-approval rules"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// CreateAutoApprovalRule adds an auto-approval rule
+func CreateAutoApprovalRule(c *gin.Context) {
+	var req AutoApprovalRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := req.rule()
+	if err := services.NewAutoApprovalService().CreateRule(rule, utils.GetUserIDFromContext(c)); err != nil {
+		autoApprovalError(c, err, "Failed to create auto-approval rule")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "AutoApprovalRule", rule.ID,
+		fmt.Sprintf("Auto-approval rule %q created for %v", rule.Name, rule.Categories))
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateAutoApprovalRule replaces an auto-approval rule's conditions
+func UpdateAutoApprovalRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+	var req AutoApprovalRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := services.NewAutoApprovalService().UpdateRule(uint(id), req.rule(), utils.GetUserIDFromContext(c))
+	if err != nil {
+		autoApprovalError(c, err, "Failed to update auto-approval rule")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "AutoApprovalRule", rule.ID,
+		fmt.Sprintf("Auto-approval rule %q updated (enabled %t)", rule.Name, rule.Enabled))
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteAutoApprovalRule removes an auto-approval rule
+func DeleteAutoApprovalRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	if err := services.NewAutoApprovalService().DeleteRule(uint(id)); err != nil {
+		autoApprovalError(c, err, "Failed to delete auto-approval rule")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "AutoApprovalRule", uint(id), "Auto-approval rule deleted")
+	c.JSON(http.StatusOK, gin.H{"message": "Auto-approval rule deleted"})
+}
+
+// ListAutoApprovals returns auto-approved help requests, optionally with one review
+// status (review_status=pending for the review queue), and a summary of the last
+// 30 days
+func ListAutoApprovals(c *gin.Context) {
+	reviewStatus := c.Query("review_status")
+	switch reviewStatus {
+	case "", models.AutoApprovalReviewNotSampled, models.AutoApprovalReviewPending,
+		models.AutoApprovalReviewUpheld, models.AutoApprovalReviewOverturned:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review status"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	autoApprovalService := services.NewAutoApprovalService()
+	approvals, total, err := autoApprovalService.Approvals(reviewStatus, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch auto-approvals"})
+		return
+	}
+	summary, err := autoApprovalService.Summary(time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to summarise auto-approvals"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"auto_approvals": approvals,
+		"total":          total,
+		"summary":        summary,
+	})
+}
+
+// ReviewAutoApproval records a reviewer's check of an auto-approved request.
+// Overturning it sends the request back to staff if no ticket has been issued.
+func ReviewAutoApproval(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid auto-approval ID"})
+		return
+	}
+	var req struct {
+		Upheld *bool  `json:"upheld" binding:"required"`
+		Notes  string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !*req.Upheld && req.Notes == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Notes are required when overturning an auto-approval"})
+		return
+	}
+
+	approval, err := services.NewAutoApprovalService().Review(uint(id), *req.Upheld, req.Notes, utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		autoApprovalError(c, err, "Failed to record review")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Review", "AutoApproval", approval.ID,
+		fmt.Sprintf("Auto-approval of help request %d %s", approval.HelpRequestID, approval.ReviewStatus))
+	c.JSON(http.StatusOK, approval)
+}
+
+// autoApprovalError writes the response for an auto-approval error
+func autoApprovalError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrAutoApprovalRuleNotFound), errors.Is(err, services.ErrAutoApprovalNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAutoApprovalRuleInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAutoApprovalReviewed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/geoo115/charity-management-system/internal/jobs"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminListDeadLetters returns background job runs that failed every retry, newest
+// first
+func AdminListDeadLetters(c *gin.Context) {
+	letters, err := jobs.DeadLetters()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dead-lettered jobs"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"dead_letters": letters,
+		"total":        len(letters),
+	})
+}
+
+// AdminRetryDeadLetter runs a dead-lettered job again now. A run that fails again
+// stays dead-lettered with the new error.
+func AdminRetryDeadLetter(c *gin.Context) {
+	letter, err := jobs.RetryDeadLetter(c.Param("id"))
+	switch {
+	case errors.Is(err, jobs.ErrDeadLetterNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead-lettered job not found"})
+		return
+	case errors.Is(err, jobs.ErrJobBusy):
+		c.JSON(http.StatusConflict, gin.H{"error": "The job is already running, try again shortly"})
+		return
+	case errors.Is(err, jobs.ErrJobFailed):
+		utils.CreateAuditLog(c, "Retry", "BackgroundJob", 0, fmt.Sprintf("Retried dead-lettered %s job; it failed again", letter.Job))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "The job failed again", "dead_letter": letter})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry the job"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Retry", "BackgroundJob", 0, fmt.Sprintf("Retried dead-lettered %s job", letter.Job))
+	c.JSON(http.StatusOK, gin.H{"message": "Job ran successfully", "job": letter.Job})
+}
+
+// AdminDiscardDeadLetter removes a dead-lettered job without running it
+func AdminDiscardDeadLetter(c *gin.Context) {
+	letter, err := jobs.DiscardDeadLetter(c.Param("id"))
+	if errors.Is(err, jobs.ErrDeadLetterNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead-lettered job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to discard the job"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "BackgroundJob", 0, fmt.Sprintf("Discarded dead-lettered %s job: %s", letter.Job, letter.Error))
+	c.JSON(http.StatusOK, gin.H{"message": "Dead-lettered job discarded"})
+}
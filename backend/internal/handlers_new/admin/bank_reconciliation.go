@@ -0,0 +1,316 @@
+package admin
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ResolveBankMatchRequest represents a manual match of a bank transaction
+type ResolveBankMatchRequest struct {
+	MatchType string `json:"match_type" binding:"required,oneof=pledge donation"`
+	MatchID   uint   `json:"match_id" binding:"required"`
+}
+
+// CreateDonationFromBankRequest represents creating a donation from an unmatched bank credit
+type CreateDonationFromBankRequest struct {
+	Name         string `json:"name"`
+	ContactEmail string `json:"contact_email" binding:"omitempty,email"`
+	Notes        string `json:"notes"`
+}
+
+// AdminImportBankStatement imports a bank statement CSV and auto-matches credits.
+// Expected columns: Date, Amount and optionally Reference, Description and Name.
+func AdminImportBankStatement(c *gin.Context) {
+	file, fileHeader, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to get file",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to read CSV header",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	columnIndices := make(map[string]int)
+	for i, h := range header {
+		columnIndices[strings.TrimSpace(h)] = i
+	}
+	for _, col := range []string{"Date", "Amount"} {
+		if _, exists := columnIndices[col]; !exists {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Required column '%s' not found in CSV", col),
+			})
+			return
+		}
+	}
+
+	var rows []services.BankStatementRow
+	rowErrors := []gin.H{}
+	rowNum := 1
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		rowNum++
+		if err != nil {
+			rowErrors = append(rowErrors, gin.H{"row": rowNum, "reason": "Failed to read row"})
+			continue
+		}
+
+		amountValue := csvValue(record, columnIndices, "Amount")
+		amount, err := strconv.ParseFloat(strings.NewReplacer(",", "", "£", "").Replace(amountValue), 64)
+		if err != nil {
+			rowErrors = append(rowErrors, gin.H{"row": rowNum, "field": "Amount", "value": amountValue, "reason": "Invalid amount"})
+			continue
+		}
+
+		dateValue := csvValue(record, columnIndices, "Date")
+		date, err := parseBankDate(dateValue)
+		if err != nil {
+			rowErrors = append(rowErrors, gin.H{"row": rowNum, "field": "Date", "value": dateValue, "reason": "Invalid date"})
+			continue
+		}
+
+		rows = append(rows, services.BankStatementRow{
+			RowNumber:   rowNum,
+			Date:        date,
+			Amount:      amount,
+			Reference:   csvValue(record, columnIndices, "Reference"),
+			Description: csvValue(record, columnIndices, "Description"),
+			PayerName:   csvValue(record, columnIndices, "Name"),
+		})
+	}
+
+	statement, err := services.NewBankReconciliationService().ImportStatement(
+		fileHeader.Filename, utils.GetUserIDFromContext(c), rows)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import bank statement"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Import", "BankStatement", statement.ID,
+		fmt.Sprintf("Imported %s: %d credits, %d auto-matched, %d suggested, %d unmatched",
+			statement.FileName, statement.CreditCount, statement.AutoMatched, statement.Suggested, statement.Unmatched))
+
+	c.JSON(http.StatusOK, gin.H{
+		"import": statement,
+		"errors": rowErrors,
+	})
+}
+
+// AdminListBankImports returns previously imported bank statements
+func AdminListBankImports(c *gin.Context) {
+	var imports []models.BankStatementImport
+	if err := db.DB.Order("created_at DESC").Limit(100).Find(&imports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bank imports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"imports": imports})
+}
+
+// AdminGetBankImport returns a bank statement import with its transactions
+func AdminGetBankImport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import ID"})
+		return
+	}
+
+	var statement models.BankStatementImport
+	if err := db.DB.Preload("Transactions", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("row_number ASC")
+	}).First(&statement, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bank import not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"import": statement})
+}
+
+// AdminListBankTransactions returns bank transactions, by default those still needing review
+func AdminListBankTransactions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "50"))
+	status := c.DefaultQuery("status", "pending")
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 200 {
+		perPage = 50
+	}
+
+	query := db.DB.Model(&models.BankTransaction{})
+	switch status {
+	case "all":
+	case "pending":
+		query = query.Where("status IN ?", []string{models.BankTransactionStatusUnmatched, models.BankTransactionStatusSuggested})
+	default:
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var transactions []models.BankTransaction
+	if err := query.Order("transaction_date DESC, id DESC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bank transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": transactions,
+		"total":        total,
+		"page":         page,
+		"per_page":     perPage,
+	})
+}
+
+// AdminGetBankTransactionCandidates returns scored match candidates for a bank transaction
+func AdminGetBankTransactionCandidates(c *gin.Context) {
+	txn, ok := loadBankTransaction(c)
+	if !ok {
+		return
+	}
+
+	candidates, err := services.NewBankReconciliationService().FindCandidates(txn)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find match candidates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transaction": txn,
+		"candidates":  candidates,
+	})
+}
+
+// AdminMatchBankTransaction manually matches a bank transaction to a pledge or donation
+func AdminMatchBankTransaction(c *gin.Context) {
+	txn, ok := loadBankTransaction(c)
+	if !ok {
+		return
+	}
+
+	var req ResolveBankMatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adminID := utils.GetUserIDFromContext(c)
+	if err := services.NewBankReconciliationService().ApplyMatch(txn, req.MatchType, req.MatchID, &adminID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Match", "BankTransaction", txn.ID,
+		fmt.Sprintf("Bank credit of %.2f matched to %s #%d", txn.Amount, req.MatchType, req.MatchID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Transaction matched successfully",
+		"transaction": txn,
+	})
+}
+
+// AdminCreateDonationFromBankTransaction records an unmatched bank credit as a new donation
+func AdminCreateDonationFromBankTransaction(c *gin.Context) {
+	txn, ok := loadBankTransaction(c)
+	if !ok {
+		return
+	}
+
+	var req CreateDonationFromBankRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	donation, err := services.NewBankReconciliationService().CreateDonationFromTransaction(
+		txn, req.Name, req.ContactEmail, req.Notes, utils.GetUserIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "Donation", donation.ID,
+		fmt.Sprintf("Donation created from bank transaction #%d", txn.ID))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Donation created successfully",
+		"donation":    donation,
+		"transaction": txn,
+	})
+}
+
+// AdminIgnoreBankTransaction marks a bank transaction as not requiring reconciliation
+func AdminIgnoreBankTransaction(c *gin.Context) {
+	txn, ok := loadBankTransaction(c)
+	if !ok {
+		return
+	}
+
+	if txn.IsResolved() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Transaction is already %s", txn.Status)})
+		return
+	}
+
+	txn.Status = models.BankTransactionStatusIgnored
+	if err := db.DB.Save(txn).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Ignore", "BankTransaction", txn.ID, "Bank transaction ignored")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Transaction ignored",
+		"transaction": txn,
+	})
+}
+
+// loadBankTransaction loads the bank transaction identified by the :id path parameter
+func loadBankTransaction(c *gin.Context) (*models.BankTransaction, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return nil, false
+	}
+
+	var txn models.BankTransaction
+	if err := db.DB.First(&txn, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bank transaction not found"})
+		return nil, false
+	}
+
+	return &txn, true
+}
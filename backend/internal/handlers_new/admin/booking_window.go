@@ -0,0 +1,134 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListBookingWindows returns the configured booking windows, optionally for one category
+func ListBookingWindows(c *gin.Context) {
+	windows, err := services.NewBookingWindowService().List(c.Query("category"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch booking windows"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"booking_windows": windows})
+}
+
+// CreateBookingWindow adds a booking window for a category, or for one weekday of it
+func CreateBookingWindow(c *gin.Context) {
+	window := defaultBookingWindow()
+	if err := c.ShouldBindJSON(&window); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.NewBookingWindowService().Create(&window, utils.GetUserIDFromContext(c)); err != nil {
+		bookingWindowError(c, err, "Failed to create booking window")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "BookingWindow", window.ID,
+		fmt.Sprintf("Booking window for %s created: %s", window.Category, describeBookingWindow(&window)))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":        "Booking window created successfully",
+		"booking_window": window,
+	})
+}
+
+// UpdateBookingWindow replaces a booking window's settings; fields left out go back
+// to their defaults
+func UpdateBookingWindow(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking window ID"})
+		return
+	}
+	changes := defaultBookingWindow()
+	if err := c.ShouldBindJSON(&changes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	window, err := services.NewBookingWindowService().Update(uint(id), changes, utils.GetUserIDFromContext(c))
+	if err != nil {
+		bookingWindowError(c, err, "Failed to update booking window")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "BookingWindow", window.ID,
+		fmt.Sprintf("Booking window for %s updated: %s", window.Category, describeBookingWindow(window)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Booking window updated successfully",
+		"booking_window": window,
+	})
+}
+
+// DeleteBookingWindow removes a booking window. A category without windows is always
+// open for booking.
+func DeleteBookingWindow(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking window ID"})
+		return
+	}
+
+	window, err := services.NewBookingWindowService().Delete(uint(id))
+	if err != nil {
+		bookingWindowError(c, err, "Failed to delete booking window")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "BookingWindow", window.ID,
+		fmt.Sprintf("Booking window for %s deleted", window.Category))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Booking window deleted successfully"})
+}
+
+// bookingWindowError maps booking window errors to responses
+func bookingWindowError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrBookingWindowNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrBookingWindowExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrBookingWindowInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// defaultBookingWindow opens bookings at 09:00 a week ahead and closes them at 18:00
+// the day before
+func defaultBookingWindow() models.BookingWindow {
+	return models.BookingWindow{
+		OpensDaysBefore:  7,
+		OpensAt:          "09:00",
+		ClosesDaysBefore: 1,
+		ClosesAt:         "18:00",
+		IsActive:         true,
+	}
+}
+
+// describeBookingWindow summarises a window for the audit log
+func describeBookingWindow(window *models.BookingWindow) string {
+	day := "every day"
+	if window.Weekday != nil {
+		day = "visits on " + time.Weekday(*window.Weekday).String()
+	}
+	return fmt.Sprintf("%s open %d days before at %s and close %d days before at %s",
+		day, window.OpensDaysBefore, window.OpensAt, window.ClosesDaysBefore, window.ClosesAt)
+}
@@ -0,0 +1,159 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BrandingRequest saves the branding for a location. Leave location empty for the
+// organisation default.
+type BrandingRequest struct {
+	Location string `json:"location"`
+	services.BrandingUpdate
+}
+
+// brandingLocationName describes a location in messages and audit logs
+func brandingLocationName(location string) string {
+	if location == "" {
+		return "organisation default"
+	}
+	return location
+}
+
+// AdminListBranding returns the saved branding profiles and the branding currently
+// in effect for the organisation
+func AdminListBranding(c *gin.Context) {
+	branding := services.NewBrandingService()
+	profiles, err := branding.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch branding"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"profiles":  profiles,
+		"effective": branding.Resolve(c.Query("location")),
+	})
+}
+
+// AdminSaveBranding creates or updates the branding for a location
+func AdminSaveBranding(c *gin.Context) {
+	var req BrandingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := services.NewBrandingService().Save(req.Location, req.BrandingUpdate, utils.GetUserIDFromContext(c))
+	if err != nil {
+		if errors.Is(err, services.ErrBrandingColor) || errors.Is(err, services.ErrBrandingLanguage) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save branding"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "BrandingProfile", profile.ID,
+		fmt.Sprintf("Branding updated for %s", brandingLocationName(profile.Location)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Branding saved",
+		"profile": profile,
+	})
+}
+
+// AdminDeleteBranding removes a location's branding so it falls back to the
+// organisation default
+func AdminDeleteBranding(c *gin.Context) {
+	location := c.Query("location")
+	if err := services.NewBrandingService().Delete(location); err != nil {
+		if errors.Is(err, services.ErrBrandingNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete branding"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "BrandingProfile", 0,
+		fmt.Sprintf("Branding removed for %s", brandingLocationName(location)))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Branding removed"})
+}
+
+// AdminUploadBrandingLogo uploads the logo for a location as the "logo" form field
+func AdminUploadBrandingLogo(c *gin.Context) {
+	file, err := c.FormFile("logo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A logo file is required"})
+		return
+	}
+	reader, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read logo"})
+		return
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.LimitReader(reader, 1<<20))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read logo"})
+		return
+	}
+
+	location := c.PostForm("location")
+	profile, err := services.NewBrandingService().SetLogo(location, data, utils.GetUserIDFromContext(c))
+	if err != nil {
+		if errors.Is(err, services.ErrBrandingLogo) || errors.Is(err, services.ErrBrandingLogoSize) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save logo"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "BrandingProfile", profile.ID,
+		fmt.Sprintf("Logo uploaded for %s", brandingLocationName(profile.Location)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logo uploaded",
+		"profile": profile,
+	})
+}
+
+// AdminDeleteBrandingLogo removes the uploaded logo for a location
+func AdminDeleteBrandingLogo(c *gin.Context) {
+	profile, err := services.NewBrandingService().RemoveLogo(c.Query("location"), utils.GetUserIDFromContext(c))
+	if err != nil {
+		if errors.Is(err, services.ErrBrandingNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove logo"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "BrandingProfile", profile.ID,
+		fmt.Sprintf("Logo removed for %s", brandingLocationName(profile.Location)))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logo removed"})
+}
+
+// AdminPreviewBrandingEmail renders a sample email with a location's branding
+func AdminPreviewBrandingEmail(c *gin.Context) {
+	html := services.NewBrandingService().PreviewEmail(c.Query("location"))
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+}
+
+// AdminPreviewBrandingPDF renders a sample PDF with a location's branding
+func AdminPreviewBrandingPDF(c *gin.Context) {
+	c.Header("Content-Disposition", "inline; filename=branding-preview.pdf")
+	c.Data(http.StatusOK, "application/pdf", services.NewBrandingService().PreviewPDF(c.Query("location")))
+}
@@ -0,0 +1,133 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CachePurgeRequest removes cache entries by key, pattern or tag
+type CachePurgeRequest struct {
+	Keys    []string `json:"keys"`
+	Pattern string   `json:"pattern"`
+	Tags    []string `json:"tags"`
+}
+
+// AdminGetCacheStatus returns cache statistics, health and the keys held per tag
+func AdminGetCacheStatus(c *gin.Context) {
+	cache := services.GetCacheService()
+	tags, err := cache.Tags()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read cache tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stats":  cache.GetStats(),
+		"health": cache.HealthCheck(),
+		"tags":   tags,
+	})
+}
+
+// AdminListCacheKeys returns a page of cache keys matching a pattern. Pass the
+// returned next_cursor to fetch the following page.
+func AdminListCacheKeys(c *gin.Context) {
+	cursor, _ := strconv.ParseUint(c.DefaultQuery("cursor", "0"), 10, 64)
+	count, _ := strconv.ParseInt(c.DefaultQuery("count", "100"), 10, 64)
+
+	keys, next, err := services.GetCacheService().ListKeys(c.Query("pattern"), cursor, count)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list cache keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys":        keys,
+		"next_cursor": next,
+	})
+}
+
+// AdminGetCacheKey returns one cache key with its value
+func AdminGetCacheKey(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		return
+	}
+
+	cache := services.GetCacheService()
+	info, err := cache.KeyInfo(key)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cache key not found"})
+		return
+	}
+
+	response := gin.H{"key": info}
+	if info.Type == "string" {
+		if value, err := cache.Peek(key); err == nil {
+			response["value"] = value
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// AdminPurgeCache removes cache entries by key, pattern or tag
+func AdminPurgeCache(c *gin.Context) {
+	var req CachePurgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Keys) == 0 && req.Pattern == "" && len(req.Tags) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide keys, a pattern or tags to purge"})
+		return
+	}
+
+	cache := services.GetCacheService()
+	var removed int64
+	if len(req.Keys) > 0 {
+		deleted, err := cache.Delete(req.Keys...)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge cache keys"})
+			return
+		}
+		removed += deleted
+	}
+	if len(req.Tags) > 0 {
+		deleted, err := cache.InvalidateTags(req.Tags...)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge cache tags"})
+			return
+		}
+		removed += deleted
+	}
+	if req.Pattern != "" {
+		if err := cache.DeletePattern(req.Pattern); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge cache pattern"})
+			return
+		}
+	}
+
+	var targets []string
+	if len(req.Keys) > 0 {
+		targets = append(targets, fmt.Sprintf("%d keys", len(req.Keys)))
+	}
+	if req.Pattern != "" {
+		targets = append(targets, "pattern "+req.Pattern)
+	}
+	if len(req.Tags) > 0 {
+		targets = append(targets, "tags "+strings.Join(req.Tags, ", "))
+	}
+	utils.CreateAuditLog(c, "PurgeCache", "Cache", 0, "Purged "+strings.Join(targets, "; "))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cache purged",
+		"removed": removed, // Keys removed by key or tag; pattern purges are not counted
+	})
+}
@@ -0,0 +1,302 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CampaignRequest holds the message and audience for a campaign
+type CampaignRequest struct {
+	Name              string   `json:"name" binding:"required"`
+	Channel           string   `json:"channel" binding:"required"`
+	Subject           string   `json:"subject"`
+	Body              string   `json:"body" binding:"required"`
+	Roles             []string `json:"roles"`
+	ActiveWithinDays  int      `json:"active_within_days"`
+	ConsentType       string   `json:"consent_type"`
+	AudienceTag       string   `json:"audience_tag"` // Tag slug or ID; only that tag's cohort
+	ThrottlePerMinute int      `json:"throttle_per_minute"`
+}
+
+// ScheduleCampaignRequest sets when a campaign starts sending. Empty means now.
+type ScheduleCampaignRequest struct {
+	SendAt *time.Time `json:"send_at"`
+}
+
+// apply copies the request onto a campaign
+func (req *CampaignRequest) apply(campaign *models.Campaign) {
+	campaign.Name = req.Name
+	campaign.Channel = strings.ToLower(req.Channel)
+	campaign.Subject = req.Subject
+	campaign.Body = req.Body
+	campaign.AudienceRoles = strings.Join(req.Roles, ",")
+	campaign.ActiveWithinDays = req.ActiveWithinDays
+	campaign.ConsentType = req.ConsentType
+	campaign.AudienceTag = req.AudienceTag
+	campaign.ThrottlePerMinute = req.ThrottlePerMinute
+}
+
+// CreateCampaign saves a draft campaign
+func CreateCampaign(c *gin.Context) {
+	var req CampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	campaign := models.Campaign{
+		Status:    models.CampaignStatusDraft,
+		CreatedBy: utils.GetUserIDFromContext(c),
+	}
+	req.apply(&campaign)
+	if err := services.NewCampaignService().ValidateCampaign(&campaign); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.DB.Create(&campaign).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create campaign"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "Campaign", campaign.ID,
+		fmt.Sprintf("Campaign created: %s (%s)", campaign.Name, campaign.Channel))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Campaign created",
+		"campaign": campaign,
+	})
+}
+
+// ListCampaigns returns campaigns, newest first
+func ListCampaigns(c *gin.Context) {
+	query := db.DB.Model(&models.Campaign{})
+	if status := c.Query("status"); status != "" && status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if channel := c.Query("channel"); channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+
+	var campaigns []models.Campaign
+	if err := query.Preload("Creator", selectUserSummary).
+		Order("created_at DESC").
+		Limit(100).
+		Find(&campaigns).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch campaigns"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"campaigns": campaigns,
+		"total":     len(campaigns),
+	})
+}
+
+// GetCampaign returns a campaign with its delivery statistics
+func GetCampaign(c *gin.Context) {
+	campaign, ok := loadCampaign(c)
+	if !ok {
+		return
+	}
+
+	stats, err := services.NewCampaignService().Stats(campaign)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate campaign statistics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"campaign": campaign,
+		"stats":    stats,
+	})
+}
+
+// UpdateCampaign changes a draft campaign
+func UpdateCampaign(c *gin.Context) {
+	campaign, ok := loadCampaign(c)
+	if !ok {
+		return
+	}
+	if campaign.Status != models.CampaignStatusDraft {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrCampaignNotEditable.Error()})
+		return
+	}
+
+	var req CampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.apply(campaign)
+	if err := services.NewCampaignService().ValidateCampaign(campaign); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.DB.Save(campaign).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update campaign"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "Campaign", campaign.ID, fmt.Sprintf("Campaign updated: %s", campaign.Name))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Campaign updated",
+		"campaign": campaign,
+	})
+}
+
+// PreviewCampaignAudience previews the audience and message for an unsaved campaign
+func PreviewCampaignAudience(c *gin.Context) {
+	var req CampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var campaign models.Campaign
+	req.apply(&campaign)
+	respondWithPreview(c, &campaign)
+}
+
+// PreviewCampaign previews the current audience and message for a saved campaign
+func PreviewCampaign(c *gin.Context) {
+	campaign, ok := loadCampaign(c)
+	if !ok {
+		return
+	}
+	respondWithPreview(c, campaign)
+}
+
+// ScheduleCampaign queues a draft campaign's messages in the outbox
+func ScheduleCampaign(c *gin.Context) {
+	campaign, ok := loadCampaign(c)
+	if !ok {
+		return
+	}
+
+	var req ScheduleCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sendAt := time.Now()
+	if req.SendAt != nil {
+		if req.SendAt.Before(sendAt.Add(-time.Minute)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "send_at cannot be in the past"})
+			return
+		}
+		sendAt = *req.SendAt
+	}
+
+	scheduled, err := services.NewCampaignService().Schedule(campaign.ID, sendAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrCampaignNotSchedulable):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrCampaignNoRecipients), errors.Is(err, services.ErrCampaignChannel), errors.Is(err, services.ErrTagNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule campaign"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "Schedule", "Campaign", scheduled.ID,
+		fmt.Sprintf("Campaign %s scheduled for %s to %d recipients", scheduled.Name, sendAt.Format(time.RFC3339), scheduled.RecipientCount))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  fmt.Sprintf("Campaign scheduled for %d recipients", scheduled.RecipientCount),
+		"campaign": scheduled,
+	})
+}
+
+// CancelCampaign stops a campaign and withdraws its unsent messages
+func CancelCampaign(c *gin.Context) {
+	campaign, ok := loadCampaign(c)
+	if !ok {
+		return
+	}
+
+	cancelled, withdrawn, err := services.NewCampaignService().Cancel(campaign.ID)
+	if err != nil {
+		if errors.Is(err, services.ErrCampaignNotCancellable) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel campaign"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "Cancel", "Campaign", cancelled.ID,
+		fmt.Sprintf("Campaign %s cancelled, %d unsent messages withdrawn", cancelled.Name, withdrawn))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Campaign cancelled",
+		"campaign":  cancelled,
+		"withdrawn": withdrawn,
+	})
+}
+
+// GetCampaignStats returns delivery and open statistics for a campaign
+func GetCampaignStats(c *gin.Context) {
+	campaign, ok := loadCampaign(c)
+	if !ok {
+		return
+	}
+
+	stats, err := services.NewCampaignService().Stats(campaign)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate campaign statistics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stats": stats})
+}
+
+// respondWithPreview writes the audience preview for a campaign
+func respondWithPreview(c *gin.Context, campaign *models.Campaign) {
+	preview, err := services.NewCampaignService().Preview(campaign)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preview": preview})
+}
+
+// loadCampaign loads the campaign identified by the :id path parameter
+func loadCampaign(c *gin.Context) (*models.Campaign, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid campaign ID"})
+		return nil, false
+	}
+
+	var campaign models.Campaign
+	if err := db.DB.First(&campaign, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Campaign not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch campaign"})
+		}
+		return nil, false
+	}
+
+	return &campaign, true
+}
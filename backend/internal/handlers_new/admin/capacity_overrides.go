@@ -0,0 +1,174 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CapacityOverrideCreateRequest asks for extra visits above a day's capacity
+type CapacityOverrideCreateRequest struct {
+	Date       string     `json:"date" binding:"required"` // YYYY-MM-DD
+	Category   string     `json:"category" binding:"required,oneof=food general"`
+	ExtraSlots int        `json:"extra_slots" binding:"required,min=1"`
+	Reason     string     `json:"reason" binding:"required"`
+	ExpiresAt  *time.Time `json:"expires_at"` // Defaults to the end of the day
+}
+
+// CapacityOverrideDecisionRequest records a decision on an override
+type CapacityOverrideDecisionRequest struct {
+	Notes string `json:"notes"`
+}
+
+// ListCapacityOverrides returns capacity override requests, newest first, with each
+// upcoming day's base capacity and the slots overrides add. Pass status=pending for
+// requests waiting for a decision.
+func ListCapacityOverrides(c *gin.Context) {
+	limit := 100
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+	var from *time.Time
+	if v := c.Query("from"); v != "" {
+		date, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		from = &date
+	}
+
+	service := services.NewCapacityOverrideService()
+	overrides, err := service.List(c.Query("status"), from, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch capacity overrides"})
+		return
+	}
+	today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	days, err := service.Days(today, 14)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch capacity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"overrides": overrides,
+		"days":      days,
+		"total":     len(overrides),
+	})
+}
+
+// RequestCapacityOverride asks for extra visits on a day. A manager other than the
+// requester has to approve it before the slots are added.
+func RequestCapacityOverride(c *gin.Context) {
+	var req CapacityOverrideCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	override, err := services.NewCapacityOverrideService().Request(utils.GetUserIDFromContext(c), services.CapacityOverrideRequest{
+		Date:       date,
+		Category:   req.Category,
+		ExtraSlots: req.ExtraSlots,
+		Reason:     req.Reason,
+		ExpiresAt:  req.ExpiresAt,
+	})
+	if err != nil {
+		capacityOverrideError(c, err, "Failed to request capacity override")
+		return
+	}
+
+	utils.CreateAuditLog(c, "RequestOverride", "CapacityOverride", override.ID,
+		fmt.Sprintf("Requested %d extra %s visits for %s: %s", override.ExtraSlots, override.Category, req.Date, override.Reason))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Capacity override requested",
+		"override": override,
+	})
+}
+
+// ApproveCapacityOverride approves an override and adds its slots to the day until
+// it expires
+func ApproveCapacityOverride(c *gin.Context) {
+	decideCapacityOverride(c, "ApproveOverride")
+}
+
+// RejectCapacityOverride turns down an override request
+func RejectCapacityOverride(c *gin.Context) {
+	decideCapacityOverride(c, "RejectOverride")
+}
+
+// CancelCapacityOverride withdraws a pending override or revokes an approved one,
+// taking its slots back
+func CancelCapacityOverride(c *gin.Context) {
+	decideCapacityOverride(c, "CancelOverride")
+}
+
+// decideCapacityOverride applies a decision to an override and logs it
+func decideCapacityOverride(c *gin.Context, action string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid override ID"})
+		return
+	}
+	var req CapacityOverrideDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewCapacityOverrideService()
+	userID := utils.GetUserIDFromContext(c)
+	decide := service.Cancel
+	switch action {
+	case "ApproveOverride":
+		decide = service.Approve
+	case "RejectOverride":
+		decide = service.Reject
+	}
+
+	override, err := decide(uint(id), userID, req.Notes)
+	if err != nil {
+		capacityOverrideError(c, err, "Failed to update capacity override")
+		return
+	}
+
+	utils.CreateAuditLog(c, action, "CapacityOverride", override.ID,
+		fmt.Sprintf("Capacity override for %d extra %s visits on %s %s",
+			override.ExtraSlots, override.Category, override.Date.Format("2006-01-02"), override.Status))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Capacity override " + override.Status,
+		"override": override,
+	})
+}
+
+// capacityOverrideError maps capacity override errors to responses
+func capacityOverrideError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrCapacityOverrideNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCapacityOverrideApprover), errors.Is(err, services.ErrCapacityOverrideCancel):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCapacityOverrideDecided), errors.Is(err, services.ErrCapacityOverrideNotLive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCapacityOverrideInvalid), errors.Is(err, services.ErrCapacityOverrideClosed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,298 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errCapacityChanged         = errors.New("capacity has changed since the suggestion was made")
+	errInvalidCapacityTransfer = errors.New("invalid capacity transfer")
+)
+
+// ApplyCapacityTransferRequest confirms a capacity transfer between categories
+type ApplyCapacityTransferRequest struct {
+	Date         string `json:"date" binding:"required"`
+	FromCategory string `json:"from_category" binding:"required,oneof=food general"`
+	ToCategory   string `json:"to_category" binding:"required,oneof=food general"`
+	Slots        int    `json:"slots" binding:"required,min=1"`
+	// Max visits the suggestion was based on; if set and capacity has changed since,
+	// the transfer is refused so it can be reviewed again
+	ExpectedFromMax *int   `json:"expected_from_max"`
+	ExpectedToMax   *int   `json:"expected_to_max"`
+	Notes           string `json:"notes"`
+}
+
+// CategoryCapacityLoad is how a category's capacity compares with its queue on a day
+type CategoryCapacityLoad struct {
+	Category  string `json:"category"`
+	MaxVisits int    `json:"max_visits"`
+	Base      int    `json:"base_visits"`     // Max visits without overrides
+	Override  int    `json:"override_visits"` // Slots added by approved overrides
+	Used      int    `json:"used"`
+	Available int    `json:"available"`
+	Demand    int64  `json:"demand"`    // Pending and approved requests
+	Shortfall int64  `json:"shortfall"` // Requests capacity cannot reach
+	Spare     int64  `json:"spare"`     // Slots no request is waiting for
+}
+
+// CapacityTransfer is a suggested move of slots from one category to another
+type CapacityTransfer struct {
+	FromCategory string `json:"from_category"`
+	ToCategory   string `json:"to_category"`
+	Slots        int    `json:"slots"`
+	FromMax      int    `json:"from_max"`     // Max visits now
+	ToMax        int    `json:"to_max"`       // Max visits now
+	NewFromMax   int    `json:"new_from_max"` // Max visits after the transfer
+	NewToMax     int    `json:"new_to_max"`   // Max visits after the transfer
+	Reason       string `json:"reason"`
+}
+
+// GetCapacityRebalanceSuggestion suggests moving slots from an underused category to
+// an oversubscribed one on the same day
+func GetCapacityRebalanceSuggestion(c *gin.Context) {
+	dateStr := c.DefaultQuery("date", time.Now().Format("2006-01-02"))
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	capacity, err := capacityForDate(db.DB, date, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch capacity"})
+		return
+	}
+
+	loads := []CategoryCapacityLoad{
+		categoryCapacityLoad(capacity, models.CategoryFood),
+		categoryCapacityLoad(capacity, models.CategoryGeneral),
+	}
+
+	response := gin.H{
+		"date":        dateStr,
+		"operating":   capacity.IsOperatingDay,
+		"categories":  loads,
+		"suggestions": []CapacityTransfer{},
+		"impact":      calculateCapacityImpact(*capacity),
+	}
+	if capacity.IsOperatingDay {
+		if transfer := suggestCapacityTransfer(capacity, loads); transfer != nil {
+			response["suggestions"] = []CapacityTransfer{*transfer}
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ApplyCapacityTransfer moves slots between categories in one transaction. A category
+// cannot drop below the visits it has already used.
+func ApplyCapacityTransfer(c *gin.Context) {
+	var req ApplyCapacityTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.FromCategory == req.ToCategory {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from_category and to_category must differ"})
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	var capacity *models.VisitCapacity
+	var before []CategoryCapacityLoad
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if capacity, err = capacityForDate(tx, date, true); err != nil {
+			return err
+		}
+		if !capacity.IsOperatingDay {
+			return fmt.Errorf("%w: %s is not an operating day", errInvalidCapacityTransfer, req.Date)
+		}
+
+		if err := checkCapacityTransfer(capacity, req); err != nil {
+			return err
+		}
+		fromMax, _ := categoryMaxAndUsed(capacity, req.FromCategory)
+		toMax, _ := categoryMaxAndUsed(capacity, req.ToCategory)
+
+		before = []CategoryCapacityLoad{
+			categoryCapacityLoad(capacity, models.CategoryFood),
+			categoryCapacityLoad(capacity, models.CategoryGeneral),
+		}
+
+		setCategoryMax(capacity, req.FromCategory, fromMax-req.Slots)
+		setCategoryMax(capacity, req.ToCategory, toMax+req.Slots)
+		capacity.TemporaryAdjustment = true
+		note := fmt.Sprintf("Moved %d slots from %s to %s", req.Slots, req.FromCategory, req.ToCategory)
+		if req.Notes != "" {
+			note += ": " + req.Notes
+		}
+		capacity.Notes = strings.TrimSpace(capacity.Notes + "\n" + note)
+
+		return tx.Model(capacity).Updates(map[string]interface{}{
+			"max_food_visits":      capacity.MaxFoodVisits,
+			"max_general_visits":   capacity.MaxGeneralVisits,
+			"temporary_adjustment": capacity.TemporaryAdjustment,
+			"notes":                capacity.Notes,
+		}).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errCapacityChanged):
+			c.JSON(http.StatusConflict, gin.H{"error": "Capacity has changed since the suggestion was made; review the new suggestion"})
+		case errors.Is(err, errInvalidCapacityTransfer):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update capacity"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "RebalanceCapacity", "VisitCapacity", capacity.ID,
+		fmt.Sprintf("Moved %d slots from %s to %s for %s: Food=%d, General=%d",
+			req.Slots, req.FromCategory, req.ToCategory, req.Date, capacity.MaxFoodVisits, capacity.MaxGeneralVisits))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Capacity rebalanced",
+		"capacity": capacity,
+		"before":   before,
+		"after": []CategoryCapacityLoad{
+			categoryCapacityLoad(capacity, models.CategoryFood),
+			categoryCapacityLoad(capacity, models.CategoryGeneral),
+		},
+		"impact": calculateCapacityImpact(*capacity),
+	})
+}
+
+// capacityForDate returns the capacity record for a date, creating one with the
+// default limits if there is none yet. Pass lock to hold the row for an update.
+func capacityForDate(tx *gorm.DB, date time.Time, lock bool) (*models.VisitCapacity, error) {
+	query := tx
+	if lock {
+		query = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var capacity models.VisitCapacity
+	err := query.Where("date = ?", date).First(&capacity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		capacity = models.VisitCapacity{
+			Date:             date,
+			DayOfWeek:        date.Format("Monday"),
+			MaxFoodVisits:    50,
+			MaxGeneralVisits: 20,
+			IsOperatingDay:   isValidVisitDay(date.Format("2006-01-02"), ""),
+		}
+		err = tx.Create(&capacity).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &capacity, nil
+}
+
+// checkCapacityTransfer checks a transfer still matches the capacity it was suggested
+// for and leaves the source category enough slots for the visits it has used
+func checkCapacityTransfer(capacity *models.VisitCapacity, req ApplyCapacityTransferRequest) error {
+	fromMax, fromUsed := categoryMaxAndUsed(capacity, req.FromCategory)
+	toMax, _ := categoryMaxAndUsed(capacity, req.ToCategory)
+	if (req.ExpectedFromMax != nil && *req.ExpectedFromMax != fromMax) ||
+		(req.ExpectedToMax != nil && *req.ExpectedToMax != toMax) {
+		return errCapacityChanged
+	}
+	if fromMax-req.Slots < fromUsed {
+		return fmt.Errorf("%w: only %d %s slots are unused and can be moved", errInvalidCapacityTransfer, fromMax-fromUsed, req.FromCategory)
+	}
+	// Override slots end on their own, so only base capacity can be moved
+	if base := capacity.BaseCapacity(req.FromCategory); req.Slots > base {
+		return fmt.Errorf("%w: only %d %s slots are base capacity; the rest come from overrides", errInvalidCapacityTransfer, base, req.FromCategory)
+	}
+	return nil
+}
+
+// categoryMaxAndUsed returns a category's max and used visits
+func categoryMaxAndUsed(capacity *models.VisitCapacity, category string) (int, int) {
+	if category == models.CategoryFood {
+		return capacity.MaxFoodVisits, capacity.CurrentFoodVisits
+	}
+	return capacity.MaxGeneralVisits, capacity.CurrentGeneralVisits
+}
+
+// setCategoryMax sets a category's max visits
+func setCategoryMax(capacity *models.VisitCapacity, category string, max int) {
+	if category == models.CategoryFood {
+		capacity.MaxFoodVisits = max
+	} else {
+		capacity.MaxGeneralVisits = max
+	}
+}
+
+// categoryCapacityLoad compares a category's remaining capacity with its queue
+func categoryCapacityLoad(capacity *models.VisitCapacity, category string) CategoryCapacityLoad {
+	max, used := categoryMaxAndUsed(capacity, category)
+	load := CategoryCapacityLoad{
+		Category:  category,
+		MaxVisits: max,
+		Base:      capacity.BaseCapacity(category),
+		Override:  capacity.OverrideCapacity(category),
+		Used:      used,
+		Available: capacity.GetAvailableCapacity(category),
+	}
+
+	db.DB.Model(&models.HelpRequest{}).
+		Where("visit_day = ? AND category = ? AND status IN ?",
+			capacity.Date.Format("2006-01-02"), category,
+			[]string{models.HelpRequestStatusPending, models.HelpRequestStatusApproved}).
+		Count(&load.Demand)
+
+	if gap := load.Demand - int64(load.Available); gap > 0 {
+		load.Shortfall = gap
+	} else {
+		load.Spare = -gap
+	}
+	return load
+}
+
+// suggestCapacityTransfer moves as many spare slots as the oversubscribed category
+// needs, without leaving the other category short
+func suggestCapacityTransfer(capacity *models.VisitCapacity, loads []CategoryCapacityLoad) *CapacityTransfer {
+	for _, to := range loads {
+		if to.Shortfall == 0 {
+			continue
+		}
+		for _, from := range loads {
+			if from.Category == to.Category || from.Spare == 0 || from.Base <= 0 {
+				continue
+			}
+
+			slots := min(int(min(to.Shortfall, from.Spare)), from.Base)
+			return &CapacityTransfer{
+				FromCategory: from.Category,
+				ToCategory:   to.Category,
+				Slots:        slots,
+				FromMax:      from.MaxVisits,
+				ToMax:        to.MaxVisits,
+				NewFromMax:   from.MaxVisits - slots,
+				NewToMax:     to.MaxVisits + slots,
+				Reason: fmt.Sprintf("%s has %d more requests than slots on %s while %s has %d unused slots",
+					to.Category, to.Shortfall, capacity.Date.Format("2006-01-02"), from.Category, from.Spare),
+			}
+		}
+	}
+	return nil
+}
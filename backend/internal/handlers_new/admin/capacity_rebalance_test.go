@@ -0,0 +1,93 @@
+package admin
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestSuggestCapacityTransfer(t *testing.T) {
+	capacity := &models.VisitCapacity{Date: time.Date(2026, 5, 5, 0, 0, 0, 0, time.UTC)}
+	food := CategoryCapacityLoad{Category: models.CategoryFood, MaxVisits: 50, Base: 50}
+	general := CategoryCapacityLoad{Category: models.CategoryGeneral, MaxVisits: 20, Base: 20}
+
+	tests := []struct {
+		name                  string
+		foodLoad, generalLoad func(*CategoryCapacityLoad)
+		want                  *CapacityTransfer
+	}{
+		{"balanced", func(l *CategoryCapacityLoad) {}, func(l *CategoryCapacityLoad) {}, nil},
+		{"moves only the shortfall",
+			func(l *CategoryCapacityLoad) { l.Shortfall = 6 },
+			func(l *CategoryCapacityLoad) { l.Spare = 10 },
+			&CapacityTransfer{FromCategory: models.CategoryGeneral, ToCategory: models.CategoryFood, Slots: 6, FromMax: 20, ToMax: 50, NewFromMax: 14, NewToMax: 56}},
+		{"never more than the spare slots",
+			func(l *CategoryCapacityLoad) { l.Spare = 4 },
+			func(l *CategoryCapacityLoad) { l.Shortfall = 9 },
+			&CapacityTransfer{FromCategory: models.CategoryFood, ToCategory: models.CategoryGeneral, Slots: 4, FromMax: 50, ToMax: 20, NewFromMax: 46, NewToMax: 24}},
+		{"override slots are not moved",
+			func(l *CategoryCapacityLoad) { l.Shortfall = 9 },
+			func(l *CategoryCapacityLoad) { l.Spare = 8; l.Base = 3 },
+			&CapacityTransfer{FromCategory: models.CategoryGeneral, ToCategory: models.CategoryFood, Slots: 3, FromMax: 20, ToMax: 50, NewFromMax: 17, NewToMax: 53}},
+		{"both short",
+			func(l *CategoryCapacityLoad) { l.Shortfall = 2 },
+			func(l *CategoryCapacityLoad) { l.Shortfall = 1 }, nil},
+	}
+	for _, tt := range tests {
+		f, g := food, general
+		tt.foodLoad(&f)
+		tt.generalLoad(&g)
+		got := suggestCapacityTransfer(capacity, []CategoryCapacityLoad{f, g})
+		switch {
+		case tt.want == nil && got != nil:
+			t.Errorf("%s: suggested %+v", tt.name, *got)
+		case tt.want != nil && got == nil:
+			t.Errorf("%s: no suggestion", tt.name)
+		case tt.want != nil:
+			got.Reason = ""
+			if *got != *tt.want {
+				t.Errorf("%s: got %+v, want %+v", tt.name, *got, *tt.want)
+			}
+		}
+	}
+}
+
+func TestCheckCapacityTransfer(t *testing.T) {
+	capacity := &models.VisitCapacity{
+		MaxFoodVisits: 50, CurrentFoodVisits: 40,
+		MaxGeneralVisits: 20, CurrentGeneralVisits: 5, OverrideGeneralVisits: 12,
+	}
+	ints := func(n int) *int { return &n }
+	transfer := func(from, to string, slots int) ApplyCapacityTransferRequest {
+		return ApplyCapacityTransferRequest{FromCategory: from, ToCategory: to, Slots: slots}
+	}
+
+	tests := []struct {
+		name string
+		req  ApplyCapacityTransferRequest
+		want error
+	}{
+		{"unused slots", transfer(models.CategoryFood, models.CategoryGeneral, 10), nil},
+		{"below used visits", transfer(models.CategoryFood, models.CategoryGeneral, 11), errInvalidCapacityTransfer},
+		{"override slots", transfer(models.CategoryGeneral, models.CategoryFood, 9), errInvalidCapacityTransfer},
+		{"base slots", transfer(models.CategoryGeneral, models.CategoryFood, 8), nil},
+	}
+	for _, tt := range tests {
+		if err := checkCapacityTransfer(capacity, tt.req); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// A suggestion made against different limits is refused for review
+	req := transfer(models.CategoryFood, models.CategoryGeneral, 1)
+	req.ExpectedFromMax, req.ExpectedToMax = ints(50), ints(18)
+	if err := checkCapacityTransfer(capacity, req); !errors.Is(err, errCapacityChanged) {
+		t.Errorf("stale suggestion: got %v", err)
+	}
+	req.ExpectedToMax = ints(20)
+	if err := checkCapacityTransfer(capacity, req); err != nil {
+		t.Errorf("current suggestion: got %v", err)
+	}
+}
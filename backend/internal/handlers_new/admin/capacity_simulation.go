@@ -0,0 +1,44 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CapacitySimulationRequest describes the "what if" scenarios to compare
+type CapacitySimulationRequest struct {
+	Category  string                      `json:"category" binding:"required"`
+	Weeks     int                         `json:"weeks"` // History to replay, defaults to 8
+	Scenarios []services.CapacityScenario `json:"scenarios" binding:"required"`
+}
+
+// AdminSimulateCapacity replays recent queue history for a service under planning
+// scenarios (more demand, fewer volunteers, longer visits) and returns projected
+// wait times and unmet demand against the baseline. Nothing is changed.
+func AdminSimulateCapacity(c *gin.Context) {
+	var req CapacitySimulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	simulation, err := services.NewCapacitySimulationService().Simulate(services.CapacitySimulationRequest{
+		Category:  req.Category,
+		Weeks:     req.Weeks,
+		Scenarios: req.Scenarios,
+	}, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrCapacitySimulationInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to run capacity simulation"})
+		return
+	}
+	c.JSON(http.StatusOK, simulation)
+}
@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CancelCarpoolMatchRequest records why a coordinator stopped a lift share
+type CancelCarpoolMatchRequest struct {
+	Note string `json:"note" binding:"required"`
+}
+
+// GetShiftCarpoolOverview returns every lift offer, request and match on a shift so
+// coordinators can keep an eye on lift sharing
+func GetShiftCarpoolOverview(c *gin.Context) {
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+
+	posts, matches, err := services.NewCarpoolService().ShiftOverview(uint(shiftID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lift sharing"})
+		return
+	}
+
+	offers, needs := 0, 0
+	for _, post := range posts {
+		if post.Status == models.CarpoolPostWithdrawn {
+			continue
+		}
+		if post.Kind == models.CarpoolOffer {
+			offers++
+		} else {
+			needs++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts":              posts,
+		"matches":            matches,
+		"offers":             offers,
+		"needs":              needs,
+		"disclaimer_version": models.CarpoolDisclaimerVersion,
+	})
+}
+
+// CancelCarpoolMatch stops a lift share, e.g. after a safety concern is raised
+func CancelCarpoolMatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	var req CancelCarpoolMatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var match models.CarpoolMatch
+	if err := db.DB.First(&match, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Lift share not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lift share"})
+		}
+		return
+	}
+
+	if err := services.NewCarpoolService().CoordinatorCancel(&match, utils.GetUserIDFromContext(c), req.Note); err != nil {
+		if errors.Is(err, services.ErrCarpoolMatchClosed) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel lift share"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "Cancel", "CarpoolMatch", match.ID,
+		fmt.Sprintf("Lift share on shift %d cancelled by coordinator: %s", match.ShiftID, req.Note))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Lift share cancelled",
+		"match":   match,
+	})
+}
@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetEntityChangeHistory returns the field-level changes made to an entity, newest
+// first, with the audit log entries describing the actions behind them. The entity
+// can be given by type (User, HelpRequest) or table (users, help-requests).
+func GetEntityChangeHistory(c *gin.Context) {
+	entityType := changeHistoryEntityType(c.Param("entity"))
+	if entityType == "" {
+		names := make([]string, 0, len(models.ChangeTrackedTables))
+		for _, name := range models.ChangeTrackedTables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Change history is not recorded for this entity",
+			"tracked": names,
+		})
+		return
+	}
+
+	entityID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	var changes []models.EntityChange
+	if err := db.DB.Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch change history"})
+		return
+	}
+
+	var auditLogs []models.AuditLog
+	db.DB.Select("id", "action", "entity_type", "entity_id", "description", "performed_by", "created_at").
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&auditLogs)
+
+	c.JSON(http.StatusOK, gin.H{
+		"entity_type": entityType,
+		"entity_id":   entityID,
+		"changes":     changes,
+		"audit_logs":  auditLogs,
+	})
+}
+
+// changeHistoryEntityType matches a path parameter to a tracked entity type
+func changeHistoryEntityType(param string) string {
+	key := strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(param))
+	for table, entityType := range models.ChangeTrackedTables {
+		if key == strings.ReplaceAll(table, "_", "") || key == strings.ToLower(entityType) {
+			return entityType
+		}
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/chaos"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChaosFaultRequest describes a failure to inject
+type ChaosFaultRequest struct {
+	Kind            string  `json:"kind" binding:"required"` // latency, db_error or notification
+	Target          string  `json:"target"`                  // Path prefix, table name, or email/sms
+	Probability     float64 `json:"probability"`             // Defaults to 1, every matching call
+	LatencyMS       int     `json:"latency_ms"`
+	DurationMinutes int     `json:"duration_minutes"` // Defaults to 10, at most 60
+}
+
+// ListChaosFaults returns the faults active on this instance
+func ListChaosFaults(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"faults": chaos.List(time.Now()),
+	})
+}
+
+// CreateChaosFault starts injecting a failure
+func CreateChaosFault(c *gin.Context) {
+	var req ChaosFaultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fault, err := chaos.Add(chaos.Fault{
+		Kind:        req.Kind,
+		Target:      req.Target,
+		Probability: req.Probability,
+		LatencyMS:   req.LatencyMS,
+		CreatedBy:   utils.GetUserIDFromContext(c),
+	}, time.Duration(req.DurationMinutes)*time.Minute, time.Now())
+	if err != nil {
+		if errors.Is(err, chaos.ErrInvalidFault) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add fault"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "ChaosFault", uint(fault.ID),
+		fmt.Sprintf("Injecting %s on %q at %.0f%% until %s", fault.Kind, fault.Target, fault.Probability*100, fault.ExpiresAt.Format(time.RFC3339)))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Fault active",
+		"fault":   fault,
+	})
+}
+
+// DeleteChaosFault stops injecting a failure
+func DeleteChaosFault(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fault ID"})
+		return
+	}
+	if !chaos.Remove(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fault not found or already expired"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "ChaosFault", uint(id), "Fault removed")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fault removed"})
+}
+
+// ClearChaosFaults stops every injected failure
+func ClearChaosFaults(c *gin.Context) {
+	removed := chaos.Clear()
+
+	utils.CreateAuditLog(c, "Delete", "ChaosFault", 0, fmt.Sprintf("%d faults cleared", removed))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("%d faults cleared", removed),
+		"removed": removed,
+	})
+}
@@ -0,0 +1,212 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContactRestrictionRequest sets the do-not-contact and vulnerable adult flags on an
+// account
+type ContactRestrictionRequest struct {
+	DoNotCall       bool   `json:"do_not_call"`
+	DoNotSMS        bool   `json:"do_not_sms"`
+	VulnerableAdult bool   `json:"vulnerable_adult"`
+	Reason          string `json:"reason" binding:"required"`
+	ReviewDate      string `json:"review_date" binding:"required"` // YYYY-MM-DD
+}
+
+// ContactCheckRequest asks whether staff may contact someone on a channel
+type ContactCheckRequest struct {
+	Channel string `json:"channel" binding:"required"` // call, sms, email
+}
+
+// ListContactRestrictions returns every flagged account, soonest review first. Pass
+// due=true for accounts whose review date has passed.
+func ListContactRestrictions(c *gin.Context) {
+	restrictions, err := services.NewContactRestrictionService().List(c.Query("due") == "true", time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact restrictions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"restrictions": restrictions,
+		"total":        len(restrictions),
+	})
+}
+
+// GetContactRestriction returns the flags on a user's account
+func GetContactRestriction(c *gin.Context) {
+	userID, ok := contactUserID(c)
+	if !ok {
+		return
+	}
+	restriction, err := services.NewContactRestrictionService().Get(userID)
+	if err != nil {
+		contactRestrictionError(c, err, "Failed to fetch contact restrictions")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"restriction": restriction})
+}
+
+// SetContactRestriction sets the do-not-call, do-not-SMS and vulnerable adult flags on
+// a user's account. Messages and campaigns are suppressed from the moment they are set.
+func SetContactRestriction(c *gin.Context) {
+	userID, ok := contactUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ContactRestrictionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	reviewDate, err := time.ParseInLocation("2006-01-02", req.ReviewDate, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "review_date must be in YYYY-MM-DD format"})
+		return
+	}
+
+	restriction, err := services.NewContactRestrictionService().Set(userID, services.ContactRestrictionInput{
+		DoNotCall:       req.DoNotCall,
+		DoNotSMS:        req.DoNotSMS,
+		VulnerableAdult: req.VulnerableAdult,
+		Reason:          req.Reason,
+		ReviewDate:      &reviewDate,
+	}, utils.GetUserIDFromContext(c))
+	if err != nil {
+		contactRestrictionError(c, err, "Failed to set contact restrictions")
+		return
+	}
+
+	var flags []string
+	if restriction.DoNotCall {
+		flags = append(flags, "do-not-call")
+	}
+	if restriction.DoNotSMS {
+		flags = append(flags, "do-not-SMS")
+	}
+	if restriction.VulnerableAdult {
+		flags = append(flags, "vulnerable adult")
+	}
+	utils.CreateAuditLog(c, "SetContactRestriction", "User", userID,
+		fmt.Sprintf("Flagged %s until review on %s: %s", strings.Join(flags, ", "), req.ReviewDate, restriction.Reason))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Contact restrictions updated",
+		"restriction": restriction,
+	})
+}
+
+// ClearContactRestriction removes every flag from a user's account
+func ClearContactRestriction(c *gin.Context) {
+	userID, ok := contactUserID(c)
+	if !ok {
+		return
+	}
+	if _, err := services.NewContactRestrictionService().Clear(userID); err != nil {
+		contactRestrictionError(c, err, "Failed to clear contact restrictions")
+		return
+	}
+
+	utils.CreateAuditLog(c, "ClearContactRestriction", "User", userID, "Cleared contact restrictions")
+	c.JSON(http.StatusOK, gin.H{"message": "Contact restrictions cleared"})
+}
+
+// CheckContactAllowed tells staff whether they may call, text or email someone before
+// they do. Blocked attempts are logged.
+func CheckContactAllowed(c *gin.Context) {
+	userID, ok := contactUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ContactCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check, err := services.NewContactRestrictionService().CheckContact(userID, req.Channel, utils.GetUserIDFromContext(c))
+	if err != nil {
+		contactRestrictionError(c, err, "Failed to check contact restrictions")
+		return
+	}
+	c.JSON(http.StatusOK, check)
+}
+
+// ListContactSuppressions returns the log of contact attempts stopped by contact
+// restrictions. Filter by user_id, channel, source and from/to dates (YYYY-MM-DD).
+func ListContactSuppressions(c *gin.Context) {
+	filter := services.ContactSuppressionFilter{
+		Channel: c.Query("channel"),
+		Source:  c.Query("source"),
+	}
+	if value := c.Query("user_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		filter.UserID = uint(id)
+	}
+	if value := c.Query("from"); value != "" {
+		from, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be in YYYY-MM-DD format"})
+			return
+		}
+		filter.From = &from
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be in YYYY-MM-DD format"})
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+
+	entries, err := services.NewContactRestrictionService().Suppressions(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suppressed contacts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"suppressions": entries,
+		"total":        len(entries),
+	})
+}
+
+// contactUserID reads the user ID from the path, responding with an error if invalid
+func contactUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// contactRestrictionError maps contact restriction errors to responses
+func contactRestrictionError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrContactUserNotFound), errors.Is(err, services.ErrContactRestrictionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrContactRestrictionInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -377,10 +377,10 @@ func (h *DashboardHandler) getDashboardAlerts() []DashboardAlert {
 		})
 	}
 
-	// Check for low inventory (if applicable)
+	// Check for low inventory
 	var lowInventoryItems int64
-	h.DB.Model(&models.DonationItem{}).
-		Where("quantity <= minimum_quantity").
+	h.DB.Model(&models.InventoryItem{}).
+		Where("active = ? AND minimum_level > 0 AND quantity <= minimum_level", true).
 		Count(&lowInventoryItems)
 
 	if lowInventoryItems > 0 {
@@ -0,0 +1,115 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DayOperationRequest picks the day to open or close; today when empty
+type DayOperationRequest struct {
+	Day string `json:"day"` // YYYY-MM-DD
+}
+
+// parseOperationDay parses a YYYY-MM-DD day, defaulting to today
+func parseOperationDay(value string) (time.Time, error) {
+	if value == "" {
+		value = time.Now().Format("2006-01-02")
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// startDayOperation runs the open or close runbook and returns the run straight away;
+// clients poll the run for step status
+func startDayOperation(c *gin.Context, operation string) {
+	var req DayOperationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	day, err := parseOperationDay(req.Day)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid day format. Use YYYY-MM-DD"})
+		return
+	}
+
+	run, err := services.NewDayOperationsService().Start(operation, day, utils.GetUserIDFromContext(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDayOperationRunning), errors.Is(err, services.ErrDayOperationCompleted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrDayOperationFuture):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start day operation"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "DayOperation", "DayOperationRun", run.ID,
+		fmt.Sprintf("Started day %s for %s (attempt %d)", operation, day.Format("2006-01-02"), run.Attempts))
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Day " + operation + " started", "run": run})
+}
+
+// AdminOpenDay activates the queue, publishes the day's capacity and notifies the
+// volunteers on the rota
+func AdminOpenDay(c *gin.Context) {
+	startDayOperation(c, models.DayOperationOpen)
+}
+
+// AdminCloseDay closes the queue, records no-shows, finalises visits, rolls up the
+// day's statistics and emails the summary to managers
+func AdminCloseDay(c *gin.Context) {
+	startDayOperation(c, models.DayOperationClose)
+}
+
+// AdminListDayOperations returns the open and close runs for a day, or the last week
+func AdminListDayOperations(c *gin.Context) {
+	to, err := parseOperationDay(c.Query("day"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid day format. Use YYYY-MM-DD"})
+		return
+	}
+	from := to
+	if c.Query("day") == "" {
+		from = to.AddDate(0, 0, -6)
+	}
+
+	runs, err := services.NewDayOperationsService().List(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch day operations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+// AdminGetDayOperation returns a run with the status of each step
+func AdminGetDayOperation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid run ID"})
+		return
+	}
+
+	run, err := services.NewDayOperationsService().Get(uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrDayOperationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch day operation"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"run": run})
+}
@@ -0,0 +1,232 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BulkDocumentDecisionRequest applies one decision to several documents
+type BulkDocumentDecisionRequest struct {
+	DocumentIDs []uint `json:"document_ids" binding:"required,min=1"`
+	Decision    string `json:"decision" binding:"required,oneof=approved rejected"`
+	ReasonCode  string `json:"reason_code"` // Required when rejecting
+	Note        string `json:"note"`        // Added to the reason's explanation for the visitor
+}
+
+// RejectionReasonRequest creates or updates a managed rejection reason
+type RejectionReasonRequest struct {
+	Code          string `json:"code"` // Set on create only
+	Label         string `json:"label" binding:"required"`
+	Explanation   string `json:"explanation" binding:"required"`
+	DocumentTypes string `json:"document_types"` // Comma separated; blank for all types
+	SortOrder     int    `json:"sort_order"`
+	IsActive      *bool  `json:"is_active"`
+}
+
+// AdminBulkDocumentDecision approves or rejects a batch of pending documents. Each
+// document succeeds or fails on its own and each visitor gets one notification.
+func AdminBulkDocumentDecision(c *gin.Context) {
+	var req BulkDocumentDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, err := services.NewDocumentDecisionService().Decide(services.BulkDocumentDecision{
+		DocumentIDs: req.DocumentIDs,
+		Decision:    req.Decision,
+		ReasonCode:  req.ReasonCode,
+		Note:        req.Note,
+	}, utils.GetUserIDFromContext(c))
+	if err != nil {
+		rejectionReasonError(c, err, "Failed to apply document decisions")
+		return
+	}
+
+	action := "Verify"
+	if req.Decision == models.DocumentStatusRejected {
+		action = "Reject"
+	}
+	succeeded := 0
+	for _, result := range results {
+		if result.Error != "" {
+			continue
+		}
+		succeeded++
+		description := fmt.Sprintf("Document %s in bulk decision", result.Status)
+		if req.ReasonCode != "" && action == "Reject" {
+			description += " (" + req.ReasonCode + ")"
+		}
+		utils.CreateAuditLog(c, action, "Document", result.DocumentID, description)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   fmt.Sprintf("%d of %d documents %s", succeeded, len(results), req.Decision),
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	})
+}
+
+// AdminListRejectionReasons returns the managed rejection reasons. Pass
+// include_inactive=true to see retired ones.
+func AdminListRejectionReasons(c *gin.Context) {
+	reasons, err := services.NewDocumentDecisionService().Reasons(c.Query("include_inactive") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rejection reasons"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"reasons": reasons, "total": len(reasons)})
+}
+
+// AdminCreateRejectionReason adds a rejection reason
+func AdminCreateRejectionReason(c *gin.Context) {
+	var req RejectionReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reason := models.DocumentRejectionReason{IsActive: true}
+	applyRejectionReasonRequest(&reason, req)
+	reason.Code = req.Code
+	if err := services.NewDocumentDecisionService().SaveReason(&reason); err != nil {
+		rejectionReasonError(c, err, "Failed to create rejection reason")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "DocumentRejectionReason", reason.ID, "Created rejection reason "+reason.Code)
+	c.JSON(http.StatusCreated, gin.H{"message": "Rejection reason created", "reason": reason})
+}
+
+// AdminUpdateRejectionReason changes a rejection reason's wording or the document
+// types it applies to
+func AdminUpdateRejectionReason(c *gin.Context) {
+	reason, ok := loadRejectionReason(c)
+	if !ok {
+		return
+	}
+	var req RejectionReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	applyRejectionReasonRequest(reason, req)
+	if err := services.NewDocumentDecisionService().SaveReason(reason); err != nil {
+		rejectionReasonError(c, err, "Failed to update rejection reason")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "DocumentRejectionReason", reason.ID, "Updated rejection reason "+reason.Code)
+	c.JSON(http.StatusOK, gin.H{"message": "Rejection reason updated", "reason": reason})
+}
+
+// AdminDeactivateRejectionReason retires a rejection reason. Past decisions keep it.
+func AdminDeactivateRejectionReason(c *gin.Context) {
+	reason, ok := loadRejectionReason(c)
+	if !ok {
+		return
+	}
+
+	reason.IsActive = false
+	if err := db.DB.Save(reason).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate rejection reason"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Deactivate", "DocumentRejectionReason", reason.ID, "Deactivated rejection reason "+reason.Code)
+	c.JSON(http.StatusOK, gin.H{"message": "Rejection reason deactivated", "reason": reason})
+}
+
+// AdminGetRejectionReasonAnalytics reports how often each rejection reason was used
+// and how many visitors went on to get an approved document. Defaults to the last
+// 90 days.
+func AdminGetRejectionReasonAnalytics(c *gin.Context) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -90)
+	if v := c.Query("from"); v != "" {
+		date, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		from = date
+	}
+	if v := c.Query("to"); v != "" {
+		date, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		to = date.AddDate(0, 0, 1)
+	}
+
+	stats, err := services.NewDocumentDecisionService().ReasonAnalytics(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rejection analytics"})
+		return
+	}
+	var total int64
+	for _, s := range stats {
+		total += s.Rejections
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":       from.Format("2006-01-02"),
+		"to":         to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"rejections": total,
+		"reasons":    stats,
+	})
+}
+
+// applyRejectionReasonRequest copies the editable fields onto a reason
+func applyRejectionReasonRequest(reason *models.DocumentRejectionReason, req RejectionReasonRequest) {
+	reason.Label = req.Label
+	reason.Explanation = req.Explanation
+	reason.DocumentTypes = req.DocumentTypes
+	reason.SortOrder = req.SortOrder
+	if req.IsActive != nil {
+		reason.IsActive = *req.IsActive
+	}
+}
+
+// loadRejectionReason fetches the rejection reason named in the path
+func loadRejectionReason(c *gin.Context) (*models.DocumentRejectionReason, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rejection reason ID"})
+		return nil, false
+	}
+	var reason models.DocumentRejectionReason
+	if err := db.DB.First(&reason, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rejection reason not found"})
+		return nil, false
+	}
+	return &reason, true
+}
+
+// rejectionReasonError maps document decision errors to responses
+func rejectionReasonError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrRejectionReasonNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrRejectionReasonExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrRejectionReasonInactive), errors.Is(err, services.ErrRejectionReasonRequired),
+		errors.Is(err, services.ErrRejectionReasonInvalid), errors.Is(err, services.ErrBulkDecisionInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UpdateDocumentInboxRequest turns a visitor's upload address on or off
+type UpdateDocumentInboxRequest struct {
+	IsActive bool `json:"is_active"`
+}
+
+// ListInboundDocumentMessages returns messages received by the document ingestion
+// webhook, newest first
+func ListInboundDocumentMessages(c *gin.Context) {
+	query := db.DB.Model(&models.InboundDocumentMessage{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var messages []models.InboundDocumentMessage
+	if err := query.Order("received_at DESC").Limit(200).Find(&messages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inbound messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"messages": messages,
+		"total":    len(messages),
+	})
+}
+
+// GetVisitorDocumentInbox returns a visitor's upload address so staff can read it out
+// to visitors who cannot use the website
+func GetVisitorDocumentInbox(c *gin.Context) {
+	userID, ok := parseInboxUserID(c)
+	if !ok {
+		return
+	}
+
+	service := services.NewDocumentInboxService()
+	inbox, err := service.InboxFor(userID)
+	if err != nil {
+		documentInboxError(c, err)
+		return
+	}
+
+	utils.CreateAuditLog(c, "View", "DocumentInbox", inbox.ID, fmt.Sprintf("Viewed document upload address for user %d", userID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"inbox":      inbox,
+		"address":    service.Address(inbox),
+		"configured": service.Configured(),
+	})
+}
+
+// RotateVisitorDocumentInbox gives a visitor a new upload address
+func RotateVisitorDocumentInbox(c *gin.Context) {
+	userID, ok := parseInboxUserID(c)
+	if !ok {
+		return
+	}
+
+	service := services.NewDocumentInboxService()
+	inbox, err := service.Rotate(userID)
+	if err != nil {
+		documentInboxError(c, err)
+		return
+	}
+
+	utils.CreateAuditLog(c, "Rotate", "DocumentInbox", inbox.ID, fmt.Sprintf("Document upload address changed for user %d", userID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Upload address changed",
+		"inbox":   inbox,
+		"address": service.Address(inbox),
+	})
+}
+
+// UpdateVisitorDocumentInbox turns a visitor's upload address on or off
+func UpdateVisitorDocumentInbox(c *gin.Context) {
+	userID, ok := parseInboxUserID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateDocumentInboxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewDocumentInboxService()
+	inbox, err := service.InboxFor(userID)
+	if err != nil {
+		documentInboxError(c, err)
+		return
+	}
+	if err := service.SetActive(inbox, req.IsActive); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update upload address"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "DocumentInbox", inbox.ID,
+		fmt.Sprintf("Document upload address for user %d set active=%t", userID, req.IsActive))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Upload address updated",
+		"inbox":   inbox,
+	})
+}
+
+// parseInboxUserID reads the visitor ID from the route
+func parseInboxUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// documentInboxError maps document inbox errors to a response
+func documentInboxError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, services.ErrInboundNotVisitor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch upload address"})
+	}
+}
@@ -0,0 +1,393 @@
+package admin
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DonationDriveRequest represents the request body for creating or updating a drive
+type DonationDriveRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	StartsOn    string `json:"starts_on" binding:"required"` // YYYY-MM-DD
+	EndsOn      string `json:"ends_on" binding:"required"`   // YYYY-MM-DD, inclusive
+	Status      string `json:"status"`
+}
+
+// DriveTeamRequest represents the request body for registering a team
+type DriveTeamRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Kind         string `json:"kind"`
+	ContactName  string `json:"contact_name"`
+	ContactEmail string `json:"contact_email" binding:"omitempty,email"`
+	Participants int    `json:"participants"`
+}
+
+// DriveDropoffRequest represents goods weighed in for a team at intake
+type DriveDropoffRequest struct {
+	TeamCode   string  `json:"team_code"`
+	DonationID *uint   `json:"donation_id"` // Online donation being dropped off; its team is used when no code is given
+	WeightKg   float64 `json:"weight_kg"`
+	Items      int     `json:"items"`
+	Notes      string  `json:"notes"`
+	ReceivedAt string  `json:"received_at"` // RFC3339, defaults to now
+}
+
+// AdminListDonationDrives returns donation drives, newest first
+func AdminListDonationDrives(c *gin.Context) {
+	query := db.DB.Order("starts_at DESC")
+	if status := c.Query("status"); status != "" && status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	var drives []models.DonationDrive
+	if err := query.Find(&drives).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch donation drives"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"drives": drives})
+}
+
+// AdminCreateDonationDrive creates a team donation drive
+func AdminCreateDonationDrive(c *gin.Context) {
+	var drive models.DonationDrive
+	if !bindDonationDrive(c, &drive) {
+		return
+	}
+	drive.CreatedBy = utils.GetUserIDFromContext(c)
+
+	if err := db.DB.Create(&drive).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Failed to create donation drive; the slug may already be in use"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "DonationDrive", drive.ID,
+		fmt.Sprintf("Donation drive %q created", drive.Name))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Donation drive created successfully",
+		"drive":   drive,
+	})
+}
+
+// AdminUpdateDonationDrive edits a drive, for example to open or close it
+func AdminUpdateDonationDrive(c *gin.Context) {
+	drive, ok := loadDonationDrive(c)
+	if !ok {
+		return
+	}
+	if !bindDonationDrive(c, drive) {
+		return
+	}
+
+	if err := db.DB.Save(drive).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Failed to update donation drive; the slug may already be in use"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "DonationDrive", drive.ID,
+		fmt.Sprintf("Donation drive %q updated (%s)", drive.Name, drive.Status))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Donation drive updated successfully",
+		"drive":   drive,
+	})
+}
+
+// AdminListDriveTeams returns a drive's teams with their codes and contacts
+func AdminListDriveTeams(c *gin.Context) {
+	drive, ok := loadDonationDrive(c)
+	if !ok {
+		return
+	}
+
+	var teams []models.DriveTeam
+	if err := db.DB.Where("drive_id = ?", drive.ID).Order("name").Find(&teams).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch teams"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"teams": teams})
+}
+
+// AdminCreateDriveTeam registers a school or group on a drive and issues its team code
+func AdminCreateDriveTeam(c *gin.Context) {
+	drive, ok := loadDonationDrive(c)
+	if !ok {
+		return
+	}
+	if drive.Status == models.DonationDriveClosed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Teams cannot join a closed drive"})
+		return
+	}
+
+	var req DriveTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	team := models.DriveTeam{
+		DriveID:      drive.ID,
+		Name:         req.Name,
+		Kind:         req.Kind,
+		ContactName:  req.ContactName,
+		ContactEmail: req.ContactEmail,
+		Participants: req.Participants,
+	}
+	if err := services.NewDonationDriveService().CreateTeam(&team); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "DriveTeam", team.ID,
+		fmt.Sprintf("Team %q (%s) registered for drive %q", team.Name, team.Code, drive.Name))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Team registered successfully",
+		"team":    team,
+	})
+}
+
+// AdminListDriveDropoffs returns the drop-offs recorded on a drive, newest first
+func AdminListDriveDropoffs(c *gin.Context) {
+	drive, ok := loadDonationDrive(c)
+	if !ok {
+		return
+	}
+
+	query := db.DB.Where("drive_id = ?", drive.ID).Order("received_at DESC")
+	if teamID := c.Query("team_id"); teamID != "" {
+		query = query.Where("team_id = ?", teamID)
+	}
+
+	var dropoffs []models.DriveDropoff
+	if err := query.Find(&dropoffs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch drop-offs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"dropoffs": dropoffs})
+}
+
+// AdminRecordDriveDropoff records goods weighed in at intake against a team code
+func AdminRecordDriveDropoff(c *gin.Context) {
+	drive, ok := loadDonationDrive(c)
+	if !ok {
+		return
+	}
+
+	var req DriveDropoffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dropoff := models.DriveDropoff{
+		DonationID: req.DonationID,
+		WeightKg:   req.WeightKg,
+		Items:      req.Items,
+		Notes:      req.Notes,
+		RecordedBy: utils.GetUserIDFromContext(c),
+	}
+	if req.ReceivedAt != "" {
+		receivedAt, err := time.Parse(time.RFC3339, req.ReceivedAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid received_at format. Use RFC3339"})
+			return
+		}
+		dropoff.ReceivedAt = receivedAt
+	}
+
+	driveService := services.NewDonationDriveService()
+	team, err := dropoffTeam(driveService, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := driveService.RecordDropoff(drive, team, &dropoff); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "DriveDropoff", dropoff.ID,
+		fmt.Sprintf("%.1f kg / %d items recorded for team %q", dropoff.WeightKg, dropoff.Items, team.Name))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Drop-off recorded successfully",
+		"dropoff": dropoff,
+		"team":    team,
+	})
+}
+
+// AdminGetDonationDriveSummary returns the end-of-drive report. Pass format=csv for
+// the team results as a spreadsheet.
+func AdminGetDonationDriveSummary(c *gin.Context) {
+	drive, ok := loadDonationDrive(c)
+	if !ok {
+		return
+	}
+
+	summary, err := services.NewDonationDriveService().Summary(drive)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build drive summary"})
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, gin.H{"summary": summary})
+		return
+	}
+
+	filename := fmt.Sprintf("drive_%s_summary_%s.csv", drive.Slug, time.Now().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Header("Content-Type", "text/csv")
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"Rank", "Team", "Kind", "Weight (kg)", "Items", "Drop-offs"})
+	for _, standing := range summary.Leaderboard {
+		writer.Write([]string{
+			strconv.Itoa(standing.Rank),
+			standing.TeamName,
+			standing.Kind,
+			fmt.Sprintf("%.1f", standing.WeightKg),
+			strconv.Itoa(standing.Items),
+			strconv.Itoa(standing.Dropoffs),
+		})
+	}
+	writer.Write([]string{"", "Total", "", fmt.Sprintf("%.1f", summary.TotalWeightKg),
+		strconv.Itoa(summary.TotalItems), strconv.Itoa(summary.Dropoffs)})
+	writer.Flush()
+}
+
+// AdminDownloadDriveCertificates renders certificates for a team. Pass participant
+// once per name for individual certificates, otherwise one team certificate is made.
+func AdminDownloadDriveCertificates(c *gin.Context) {
+	drive, ok := loadDonationDrive(c)
+	if !ok {
+		return
+	}
+
+	teamID, err := strconv.ParseUint(c.Param("teamId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+	var team models.DriveTeam
+	if err := db.DB.Where("drive_id = ?", drive.ID).First(&team, teamID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return
+	}
+
+	leaderboard, err := services.NewDonationDriveService().Leaderboard(drive.ID, services.DriveRankByWeight)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load team results"})
+		return
+	}
+	standing := services.DriveStanding{TeamID: team.ID, TeamName: team.Name}
+	for _, s := range leaderboard {
+		if s.TeamID == team.ID {
+			standing = s
+		}
+	}
+
+	var participants []string
+	for _, name := range c.QueryArray("participant") {
+		if name = strings.TrimSpace(name); name != "" {
+			participants = append(participants, name)
+		}
+	}
+
+	filename := fmt.Sprintf("%s-%s-certificates.pdf", drive.Slug, strings.ToLower(team.Code))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, "application/pdf", services.RenderDriveCertificates(*drive, team, standing, participants))
+}
+
+// bindDonationDrive reads a drive request into a drive and validates it
+func bindDonationDrive(c *gin.Context, drive *models.DonationDrive) bool {
+	var req DonationDriveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	startsOn, err := time.Parse("2006-01-02", req.StartsOn)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid starts_on format. Use YYYY-MM-DD"})
+		return false
+	}
+	endsOn, err := time.Parse("2006-01-02", req.EndsOn)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ends_on format. Use YYYY-MM-DD"})
+		return false
+	}
+
+	drive.Name = req.Name
+	drive.Slug = req.Slug
+	drive.Description = req.Description
+	drive.StartsAt = startsOn
+	drive.EndsAt = endsOn.AddDate(0, 0, 1).Add(-time.Second) // Inclusive of the last day
+	drive.Status = req.Status
+
+	if err := services.NewDonationDriveService().ValidateDrive(drive); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// dropoffTeam finds the team a drop-off is for, from its code or its online donation
+func dropoffTeam(driveService *services.DonationDriveService, req DriveDropoffRequest) (*models.DriveTeam, error) {
+	if req.TeamCode != "" {
+		team, _, err := driveService.FindTeamByCode(req.TeamCode)
+		return team, err
+	}
+	if req.DonationID == nil {
+		return nil, errors.New("team_code or donation_id is required")
+	}
+
+	var donation models.Donation
+	if err := db.DB.First(&donation, *req.DonationID).Error; err != nil {
+		return nil, errors.New("donation not found")
+	}
+	if donation.DriveTeamID == nil {
+		return nil, errors.New("donation has no team code; give team_code")
+	}
+	var team models.DriveTeam
+	if err := db.DB.First(&team, *donation.DriveTeamID).Error; err != nil {
+		return nil, services.ErrDriveTeamNotFound
+	}
+	return &team, nil
+}
+
+// loadDonationDrive loads the drive identified by the :id path parameter
+func loadDonationDrive(c *gin.Context) (*models.DonationDrive, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid drive ID"})
+		return nil, false
+	}
+
+	var drive models.DonationDrive
+	if err := db.DB.First(&drive, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Donation drive not found"})
+		return nil, false
+	}
+
+	return &drive, true
+}
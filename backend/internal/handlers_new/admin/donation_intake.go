@@ -0,0 +1,134 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DonationIntakeRequest records the goods counted when a donation is received
+type DonationIntakeRequest struct {
+	Lines []DonationIntakeLine `json:"lines"`
+}
+
+// DonationIntakeLine is one counted line. Leave donation_item_id out for goods the
+// donor did not declare; a quantity of 0 records a declared item that did not arrive.
+type DonationIntakeLine struct {
+	DonationItemID  *uint  `json:"donation_item_id"`
+	InventoryItemID uint   `json:"inventory_item_id"`
+	Quantity        int    `json:"quantity"`
+	BatchCode       string `json:"batch_code"`
+	ExpiresOn       string `json:"expires_on"` // YYYY-MM-DD, required for food
+	Notes           string `json:"notes"`
+}
+
+// donationIntakeError maps goods intake errors to responses
+func donationIntakeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrIntakeDonationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrIntakeInvalid), errors.Is(err, services.ErrInventoryInvalid),
+		errors.Is(err, services.ErrInventoryItemNotFound), errors.Is(err, services.ErrIntakeNotGoods):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrIntakeAlreadyReceived):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// AdminGetDonationIntake returns a goods donation's declared items, what was
+// received and any discrepancies
+func AdminGetDonationIntake(c *gin.Context) {
+	donationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid donation ID"})
+		return
+	}
+
+	intake, err := services.NewDonationIntakeService().Intake(uint(donationID))
+	if err != nil {
+		donationIntakeError(c, err, "Failed to fetch donation intake")
+		return
+	}
+	c.JSON(http.StatusOK, intake)
+}
+
+// AdminReceiveDonationGoods counts a goods donation into stock and marks it received
+func AdminReceiveDonationGoods(c *gin.Context) {
+	donationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid donation ID"})
+		return
+	}
+	var req DonationIntakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lines := make([]services.IntakeLine, 0, len(req.Lines))
+	for i, line := range req.Lines {
+		intakeLine := services.IntakeLine{
+			DonationItemID:  line.DonationItemID,
+			InventoryItemID: line.InventoryItemID,
+			Quantity:        line.Quantity,
+			BatchCode:       line.BatchCode,
+			Notes:           line.Notes,
+		}
+		if line.ExpiresOn != "" {
+			date, err := time.Parse("2006-01-02", line.ExpiresOn)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %d has an invalid expiry date, use YYYY-MM-DD", i+1)})
+				return
+			}
+			intakeLine.ExpiresOn = &date
+		}
+		lines = append(lines, intakeLine)
+	}
+
+	intake, markedReceived, err := services.NewDonationIntakeService().Receive(
+		uint(donationID), lines, utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		donationIntakeError(c, err, "Failed to receive donation")
+		return
+	}
+	if markedReceived {
+		go services.SendReceiptWhenReceived(intake.Donation.ID)
+	}
+
+	utils.CreateAuditLog(c, "Receive", "Donation", intake.Donation.ID,
+		fmt.Sprintf("Goods donation received into stock: %d units, %d discrepancies", intake.Donation.Quantity, len(intake.Discrepancies)))
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Donation received into stock",
+		"intake":         intake,
+		"receipt_queued": markedReceived,
+	})
+}
+
+// AdminListIntakeDiscrepancies lists goods counted in the last days (30 by default)
+// whose received quantity differs from what the donor declared
+func AdminListIntakeDiscrepancies(c *gin.Context) {
+	days := 30
+	if v, err := strconv.Atoi(c.Query("days")); err == nil && v > 0 && v <= 365 {
+		days = v
+	}
+
+	discrepancies, err := services.NewDonationIntakeService().Discrepancies(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch intake discrepancies"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"discrepancies": discrepancies,
+		"total":         len(discrepancies),
+		"days":          days,
+	})
+}
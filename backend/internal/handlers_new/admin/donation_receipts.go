@@ -0,0 +1,107 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminListDonationReceipts returns a page of the receipt register, optionally for
+// one calendar year (year) or donor (donor_id)
+func AdminListDonationReceipts(c *gin.Context) {
+	var filter services.DonationReceiptFilter
+	if value := c.Query("year"); value != "" {
+		year, err := strconv.Atoi(value)
+		if err != nil || year < 2000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+		filter.Year = year
+	}
+	if value := c.Query("donor_id"); value != "" {
+		donorID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid donor ID"})
+			return
+		}
+		filter.DonorID = uint(donorID)
+	}
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	receipts, total, err := services.NewDonationReceiptService().Register(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch receipts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"receipts": receipts,
+		"total":    total,
+	})
+}
+
+// AdminDownloadDonationReceipt returns the PDF receipt for a donation, issuing it
+// if the donation has been paid but not yet receipted
+func AdminDownloadDonationReceipt(c *gin.Context) {
+	donationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid donation ID"})
+		return
+	}
+
+	receiptService := services.NewDonationReceiptService()
+	receipt, err := receiptService.Issue(uint(donationID), time.Now())
+	if err != nil {
+		donationReceiptError(c, err, "Failed to issue receipt")
+		return
+	}
+	pdf, err := receiptService.Render(receipt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build receipt"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=receipt-%s.pdf", receipt.Number))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// AdminSendDonationReceipt emails a donation's receipt to the donor again
+func AdminSendDonationReceipt(c *gin.Context) {
+	donationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid donation ID"})
+		return
+	}
+
+	receipt, err := services.NewDonationReceiptService().Send(uint(donationID), time.Now())
+	if err != nil {
+		donationReceiptError(c, err, "Failed to send receipt")
+		return
+	}
+
+	utils.CreateAuditLog(c, "SendReceipt", "Donation", receipt.DonationID,
+		fmt.Sprintf("Receipt %s emailed to the donor", receipt.Number))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Receipt sent successfully",
+		"receipt": receipt,
+	})
+}
+
+// donationReceiptError writes the response for a receipt error
+func donationReceiptError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrDonorDonationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrDonationReceiptUnavailable), errors.Is(err, services.ErrDonationReceiptNoEmail):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,217 @@
+package admin
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DonationRefundRequest holds the amount and reason for refunding a donation
+type DonationRefundRequest struct {
+	Amount float64 `json:"amount"` // Leave empty to refund the full remaining amount
+	Reason string  `json:"reason"`
+}
+
+// ApplyGiftAidAdjustmentsRequest marks adjustments as included in a Gift Aid claim
+type ApplyGiftAidAdjustmentsRequest struct {
+	IDs            []uint `json:"ids" binding:"required,min=1"`
+	ClaimReference string `json:"claim_reference" binding:"required"`
+}
+
+// AdminRefundDonation refunds a card donation through the payment provider
+func AdminRefundDonation(c *gin.Context) {
+	donationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid donation ID"})
+		return
+	}
+
+	var req DonationRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	refund, err := services.NewDonationRefundService().RefundDonation(uint(donationID), req.Amount, req.Reason, utils.GetUserIDFromContext(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Donation not found"})
+		case errors.Is(err, services.ErrRefundNotMonetary), errors.Is(err, services.ErrRefundNoPayment),
+			errors.Is(err, services.ErrRefundExceedsBalance):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrRefundDisputed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to process refund"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "Refund", "Donation", uint(donationID),
+		fmt.Sprintf("Refunded £%.2f (%s)", refund.Amount, refund.ProviderReference))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Refund processed",
+		"refund":  refund,
+	})
+}
+
+// AdminListDonationRefunds returns refunds and chargebacks, newest first
+func AdminListDonationRefunds(c *gin.Context) {
+	query := db.DB.Model(&models.DonationRefund{})
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if status := c.Query("status"); status != "" && status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if start := c.Query("start_date"); start != "" {
+		query = query.Where("created_at >= ?", start)
+	}
+	if end := c.Query("end_date"); end != "" {
+		query = query.Where("created_at <= ?", end)
+	}
+
+	var refunds []models.DonationRefund
+	if err := query.Preload("Donation").Order("created_at DESC").Find(&refunds).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refunds"})
+		return
+	}
+
+	var refunded, chargedBack, disputed float64
+	for _, refund := range refunds {
+		switch {
+		case refund.Kind == models.RefundKindRefund && refund.Status == models.RefundStatusSucceeded:
+			refunded += refund.Amount
+		case refund.Kind == models.RefundKindChargeback && refund.Status == models.RefundStatusLost:
+			chargedBack += refund.Amount
+		case refund.Kind == models.RefundKindChargeback && refund.Status == models.RefundStatusNeedsResponse:
+			disputed += refund.Amount
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"refunds": refunds,
+		"total":   len(refunds),
+		"summary": gin.H{
+			"refunded":     refunded,
+			"charged_back": chargedBack,
+			"in_dispute":   disputed,
+		},
+	})
+}
+
+// AdminExportDonationRefunds exports refunds and chargebacks to CSV for the finance team
+func AdminExportDonationRefunds(c *gin.Context) {
+	query := db.DB.Model(&models.DonationRefund{})
+	if start := c.Query("start_date"); start != "" {
+		query = query.Where("created_at >= ?", start)
+	}
+	if end := c.Query("end_date"); end != "" {
+		query = query.Where("created_at <= ?", end)
+	}
+
+	var refunds []models.DonationRefund
+	if err := query.Preload("Donation").Order("created_at ASC").Find(&refunds).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refunds"})
+		return
+	}
+
+	filename := fmt.Sprintf("donation_refunds_%s.csv", time.Now().Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Type", "text/csv")
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"Refund ID", "Date", "Kind", "Status", "Donation ID", "Donor Name", "Donation Amount", "Amount Returned", "Currency", "Reason", "Provider Reference", "Resolved At"})
+	for _, refund := range refunds {
+		donationID, donorName, donationAmount := "", "", ""
+		if refund.Donation != nil {
+			donationID = strconv.FormatUint(uint64(refund.Donation.ID), 10)
+			donorName = refund.Donation.Name
+			donationAmount = fmt.Sprintf("%.2f", refund.Donation.Amount)
+		}
+		resolvedAt := ""
+		if refund.ResolvedAt != nil {
+			resolvedAt = refund.ResolvedAt.Format("2006-01-02 15:04:05")
+		}
+		writer.Write([]string{
+			strconv.FormatUint(uint64(refund.ID), 10),
+			refund.CreatedAt.Format("2006-01-02 15:04:05"),
+			refund.Kind,
+			refund.Status,
+			donationID,
+			donorName,
+			donationAmount,
+			fmt.Sprintf("%.2f", refund.Amount),
+			refund.Currency,
+			refund.Reason,
+			refund.ProviderReference,
+			resolvedAt,
+		})
+	}
+	writer.Flush()
+}
+
+// AdminListGiftAidAdjustments returns Gift Aid adjustments raised by refunds and chargebacks
+func AdminListGiftAidAdjustments(c *gin.Context) {
+	status := c.DefaultQuery("status", models.GiftAidAdjustmentPending)
+
+	query := db.DB.Model(&models.GiftAidAdjustment{})
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	var adjustments []models.GiftAidAdjustment
+	if err := query.Preload("Donation").Order("created_at ASC").Find(&adjustments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch Gift Aid adjustments"})
+		return
+	}
+
+	var donationTotal, taxTotal float64
+	for _, adjustment := range adjustments {
+		donationTotal += adjustment.DonationAmount
+		taxTotal += adjustment.TaxAmount
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"adjustments":    adjustments,
+		"total":          len(adjustments),
+		"donation_total": donationTotal,
+		"tax_total":      taxTotal,
+	})
+}
+
+// AdminApplyGiftAidAdjustments records that adjustments were included in a Gift Aid claim
+func AdminApplyGiftAidAdjustments(c *gin.Context) {
+	var req ApplyGiftAidAdjustmentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	applied, err := services.NewDonationRefundService().ApplyGiftAidAdjustments(req.IDs, req.ClaimReference, utils.GetUserIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply Gift Aid adjustments"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "GiftAidAdjustment", 0,
+		fmt.Sprintf("%d Gift Aid adjustments included in claim %s", applied, req.ClaimReference))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Gift Aid adjustments applied",
+		"applied": applied,
+	})
+}
@@ -1,18 +1,28 @@
 package admin
 
 import (
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AdminListDonations returns a paginated list of donations for admin
+// AdminListDonations returns a paginated list of donations for admin, filtered by
+// status, type and tag
 func AdminListDonations(c *gin.Context) {
 	// Get query parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
 	status := c.Query("status")
+	donationType := c.Query("type")
 
 	// Ensure page is at least 1
 	if page < 1 {
@@ -22,67 +32,36 @@ func AdminListDonations(c *gin.Context) {
 		perPage = 20
 	}
 
-	// For now, return mock data that matches the expected format
-	mockDonations := []gin.H{
-		{
-			"id":             "don_001",
-			"donor_name":     "John Smith",
-			"donor_email":    "john.smith@example.com",
-			"amount":         50.00,
-			"currency":       "GBP",
-			"status":         "completed",
-			"donation_date":  "2024-06-28",
-			"payment_method": "card",
-			"is_anonymous":   false,
-			"receipt_sent":   true,
-			"type":           "monetary",
-			"created_at":     "2024-06-28T10:30:00Z",
-			"updated_at":     "2024-06-28T10:30:00Z",
-		},
-		{
-			"id":             "don_002",
-			"donor_name":     "Anonymous",
-			"donor_email":    "",
-			"amount":         25.00,
-			"currency":       "GBP",
-			"status":         "pending",
-			"donation_date":  "2024-06-28",
-			"payment_method": "paypal",
-			"is_anonymous":   true,
-			"receipt_sent":   false,
-			"type":           "monetary",
-			"created_at":     "2024-06-28T09:15:00Z",
-			"updated_at":     "2024-06-28T09:15:00Z",
-		},
-	}
-
-	// Filter by status if provided
-	filteredDonations := mockDonations
+	query := db.DB.Model(&models.Donation{})
 	if status != "" && status != "all" {
-		var filtered []gin.H
-		for _, donation := range mockDonations {
-			if donation["status"] == status {
-				filtered = append(filtered, donation)
-			}
+		query = query.Where("status = ?", status)
+	}
+	if donationType != "" && donationType != "all" {
+		query = query.Where("type = ?", donationType)
+	}
+	if tag := c.Query("tag"); tag != "" {
+		tagged, err := services.NewTagService().Filter(query, "donations.id", tag, models.TagEntityDonation)
+		if err != nil {
+			tagError(c, err, "Failed to filter donations by tag")
+			return
 		}
-		filteredDonations = filtered
+		query = tagged
 	}
 
-	// Calculate pagination
-	total := len(filteredDonations)
-	start := (page - 1) * perPage
-	end := start + perPage
-	if end > total {
-		end = total
-	}
-	if start > total {
-		start = total
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count donations"})
+		return
 	}
 
-	paginatedDonations := filteredDonations[start:end]
+	var donations []models.Donation
+	if err := query.Order("created_at DESC").Offset((page - 1) * perPage).Limit(perPage).Find(&donations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch donations"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"donations": paginatedDonations,
+		"donations": donations,
 		"total":     total,
 		"page":      page,
 		"per_page":  perPage,
@@ -118,24 +97,73 @@ func AdminGetDonationAnalytics(c *gin.Context) {
 	c.JSON(http.StatusOK, analytics)
 }
 
-// AdminUpdateDonationStatus updates the status of a donation
+// AdminUpdateDonationStatus moves a donation between pending, received, processed
+// and cancelled. Marking a donation received emails the donor their receipt.
 func AdminUpdateDonationStatus(c *gin.Context) {
-	donationID := c.Param("id")
+	donationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid donation ID"})
+		return
+	}
 
 	var request struct {
-		Status string `json:"status" binding:"required,oneof=pending completed failed refunded"`
+		Status string `json:"status" binding:"required,oneof=pending received processed cancelled"`
 	}
-
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
 		return
 	}
 
-	// In a real implementation, this would update the database
-	// For now, just return success
+	var donation models.Donation
+	if err := db.DB.First(&donation, donationID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Donation not found"})
+		return
+	}
+	// Payment statuses are set by the payment provider and refunds
+	if !slices.Contains(adminDonationStatuses, donation.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("A %s donation cannot be changed here", donation.Status)})
+		return
+	}
+
+	previous := donation.Status
+	now := time.Now()
+	adminID := utils.GetUserIDFromContext(c)
+	donation.Status = request.Status
+	switch request.Status {
+	case models.DonationStatusReceived:
+		if donation.ReceivedAt == nil {
+			donation.ReceivedAt = &now
+			donation.ReceivedBy = &adminID
+		}
+	case models.DonationStatusProcessed:
+		donation.ProcessedAt = &now
+		donation.ProcessedBy = &adminID
+	}
+
+	if err := db.DB.Save(&donation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update donation status"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "UpdateStatus", "Donation", donation.ID,
+		fmt.Sprintf("Donation status changed from %s to %s", previous, donation.Status))
+
+	receiptQueued := previous == models.DonationStatusPending && donation.Status == models.DonationStatusReceived && !donation.ReceiptSent
+	if receiptQueued {
+		go services.SendReceiptWhenReceived(donation.ID)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message":     "Donation status updated successfully",
-		"donation_id": donationID,
-		"new_status":  request.Status,
+		"message":        "Donation status updated successfully",
+		"donation":       donation,
+		"receipt_queued": receiptQueued,
 	})
 }
+
+// adminDonationStatuses are the statuses staff can move a donation between
+var adminDonationStatuses = []string{
+	models.DonationStatusPending,
+	models.DonationStatusReceived,
+	models.DonationStatusProcessed,
+	models.DonationStatusCancelled,
+}
@@ -0,0 +1,26 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminGetDonorAcquisitionReport attributes new donors over a date range (default the
+// last 12 months) to the campaign, referral or other source that brought them in,
+// with what they gave first and have given since
+func AdminGetDonorAcquisitionReport(c *gin.Context) {
+	start, end, ok := slaDateRange(c, 365)
+	if !ok {
+		return
+	}
+
+	report, err := services.NewDonorOnboardingService().AcquisitionReport(start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate acquisition report"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AdminSendEmergencyBroadcast messages everyone holding a ticket for today, for
+// example when closing early or moving venue. The message is also shown on the queue
+// display and visitor dashboards. Delivery happens in the background; fetch the
+// broadcast to see the outcome for each visitor.
+func AdminSendEmergencyBroadcast(c *gin.Context) {
+	var req services.EmergencyBroadcastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	broadcast, err := services.NewEmergencyBroadcastService().Send(req, utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidBroadcast):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrNoTicketHolders):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send broadcast"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "EmergencyBroadcast", broadcast.ID,
+		fmt.Sprintf("Broadcast %q (%s) to %d ticket holders", broadcast.Title, broadcast.Reason, broadcast.Recipients))
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":   fmt.Sprintf("Sending to %d ticket holders", broadcast.Recipients),
+		"broadcast": broadcast,
+	})
+}
+
+// AdminListEmergencyBroadcasts returns the broadcasts for a day, or the last week
+func AdminListEmergencyBroadcasts(c *gin.Context) {
+	to, err := parseOperationDay(c.Query("day"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid day format. Use YYYY-MM-DD"})
+		return
+	}
+	from := to
+	if c.Query("day") == "" {
+		from = to.AddDate(0, 0, -6)
+	}
+
+	broadcasts, err := services.NewEmergencyBroadcastService().List(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch broadcasts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"broadcasts": broadcasts})
+}
+
+// AdminGetEmergencyBroadcast returns a broadcast with the delivery outcome for each
+// visitor and channel
+func AdminGetEmergencyBroadcast(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid broadcast ID"})
+		return
+	}
+
+	broadcast, err := services.NewEmergencyBroadcastService().Get(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Broadcast not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch broadcast"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"broadcast": broadcast})
+}
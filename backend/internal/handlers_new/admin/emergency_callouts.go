@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateCalloutRequest represents an emergency call-out for a shift
+type CreateCalloutRequest struct {
+	ShiftID          uint   `json:"shift_id" binding:"required"`
+	Reason           string `json:"reason"`
+	RequiredSkills   string `json:"required_skills"`    // Comma-separated, defaults to the shift's required skills
+	ExpiresInMinutes int    `json:"expires_in_minutes"` // Defaults to the shift start time
+}
+
+// AdminCreateEmergencyCallout broadcasts an urgent call-out for a shift to matching volunteers
+func AdminCreateEmergencyCallout(c *gin.Context) {
+	var req CreateCalloutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var expiresAt time.Time
+	if req.ExpiresInMinutes > 0 {
+		expiresAt = time.Now().Add(time.Duration(req.ExpiresInMinutes) * time.Minute)
+	}
+
+	callout, err := services.NewEmergencyCalloutService().CreateCallout(
+		req.ShiftID, utils.GetUserIDFromContext(c), req.Reason, req.RequiredSkills, expiresAt)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Shift not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "EmergencyCallout", callout.ID,
+		fmt.Sprintf("Emergency call-out for shift %d sent to %d volunteers", callout.ShiftID, callout.NotifiedCount))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": fmt.Sprintf("Call-out sent to %d matching volunteers", callout.NotifiedCount),
+		"callout": callout,
+	})
+}
+
+// AdminListEmergencyCallouts returns recent emergency call-outs
+func AdminListEmergencyCallouts(c *gin.Context) {
+	status := c.Query("status")
+
+	query := db.DB.Model(&models.EmergencyCallout{})
+	if status != "" && status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	var callouts []models.EmergencyCallout
+	if err := query.Preload("Shift").
+		Preload("FilledByUser", selectUserSummary).
+		Order("created_at DESC").
+		Limit(100).
+		Find(&callouts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch call-outs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"callouts": callouts})
+}
+
+// AdminGetEmergencyCallout returns a call-out with each volunteer's response
+func AdminGetEmergencyCallout(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid call-out ID"})
+		return
+	}
+
+	var callout models.EmergencyCallout
+	if err := db.DB.Preload("Shift").
+		Preload("FilledByUser", selectUserSummary).
+		Preload("Responses", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("responded_at DESC NULLS LAST, viewed_at DESC NULLS LAST")
+		}).
+		Preload("Responses.User", selectUserSummary).
+		First(&callout, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Call-out not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"callout": callout,
+		"summary": services.NewEmergencyCalloutService().ResponseSummary(callout.ID),
+	})
+}
+
+// AdminCancelEmergencyCallout closes an open call-out without filling it
+func AdminCancelEmergencyCallout(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid call-out ID"})
+		return
+	}
+
+	callout, err := services.NewEmergencyCalloutService().Cancel(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Call-out not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Cancel", "EmergencyCallout", callout.ID, "Emergency call-out cancelled")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Call-out cancelled",
+		"callout": callout,
+	})
+}
@@ -0,0 +1,188 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// VisitGoodsRequest is the weight in kg of each type of goods handed out at a visit
+type VisitGoodsRequest struct {
+	Weights map[string]float64 `json:"weights" binding:"required"` // e.g. {"food": 12.5, "clothing": 2}
+}
+
+// environmentalFactorRequest is the body for adding an environmental factor
+type environmentalFactorRequest struct {
+	GoodsType     string  `json:"goods_type" binding:"required"`
+	DiversionRate float64 `json:"diversion_rate"`
+	CO2ePerKg     float64 `json:"co2e_per_kg"`
+	EffectiveFrom string  `json:"effective_from"` // YYYY-MM-DD, defaults to today
+	Source        string  `json:"source"`
+}
+
+// AdminRecordVisitGoods records the weight of goods a visitor took home, replacing
+// earlier figures for the same goods types. A weight of 0 removes a goods type.
+func AdminRecordVisitGoods(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid help request ID"})
+		return
+	}
+
+	var req VisitGoodsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	weights, err := services.NewEnvironmentalImpactService().RecordVisitGoods(uint(id), req.Weights, utils.GetUserIDFromContext(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Help request not found"})
+		case errors.Is(err, services.ErrInvalidGoodsWeight):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrVisitNotDelivered):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record goods weights"})
+		}
+		return
+	}
+
+	total := 0.0
+	for _, weight := range weights {
+		total += weight.WeightKg
+	}
+	utils.CreateAuditLog(c, "Update", "HelpRequest", uint(id),
+		fmt.Sprintf("Recorded %.1f kg of goods handed out", total))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Goods weights recorded",
+		"weights":   weights,
+		"weight_kg": total,
+	})
+}
+
+// AdminGetEnvironmentalImpact returns the estimated waste diverted and CO2e avoided by
+// redistributing goods, by goods type and month, over a date range (default the last
+// 12 months)
+func AdminGetEnvironmentalImpact(c *gin.Context) {
+	start, end, ok := slaDateRange(c, 365)
+	if !ok {
+		return
+	}
+
+	report, err := services.NewEnvironmentalImpactService().Report(start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate environmental impact"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"environmental_impact": report})
+}
+
+// AdminListEnvironmentalFactors returns the configured factors, newest first within
+// each goods type, and the built-in defaults used where none is configured
+func AdminListEnvironmentalFactors(c *gin.Context) {
+	var factors []models.EnvironmentalFactor
+	if err := db.DB.Order("goods_type ASC, effective_from DESC").Find(&factors).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch environmental factors"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"factors":  factors,
+		"defaults": services.DefaultEnvironmentalFactors(),
+	})
+}
+
+// AdminCreateEnvironmentalFactor adds a factor for a goods type from a date. Earlier
+// factors are kept so past reports do not change.
+func AdminCreateEnvironmentalFactor(c *gin.Context) {
+	var req environmentalFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.EffectiveFrom == "" {
+		req.EffectiveFrom = time.Now().Format("2006-01-02")
+	}
+	effectiveFrom, err := time.Parse("2006-01-02", req.EffectiveFrom)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "effective_from must be in YYYY-MM-DD format"})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	factor := models.EnvironmentalFactor{
+		GoodsType:     req.GoodsType,
+		DiversionRate: req.DiversionRate,
+		CO2ePerKg:     req.CO2ePerKg,
+		EffectiveFrom: effectiveFrom,
+		Source:        strings.TrimSpace(req.Source),
+		CreatedBy:     &userID,
+	}
+	if err := services.ValidateEnvironmentalFactor(&factor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing int64
+	db.DB.Model(&models.EnvironmentalFactor{}).
+		Where("goods_type = ? AND effective_from = ?", factor.GoodsType, factor.EffectiveFrom).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A factor for this goods type already starts on that date"})
+		return
+	}
+
+	if err := db.DB.Create(&factor).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create environmental factor"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "EnvironmentalFactor", factor.ID,
+		fmt.Sprintf("Environmental factor for %s set to %.0f%% diverted, %.2f kg CO2e/kg from %s",
+			factor.GoodsType, factor.DiversionRate*100, factor.CO2ePerKg, factor.EffectiveFrom.Format("2006-01-02")))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Environmental factor created successfully",
+		"factor":  factor,
+	})
+}
+
+// AdminDeleteEnvironmentalFactor removes a factor entered by mistake
+func AdminDeleteEnvironmentalFactor(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid factor ID"})
+		return
+	}
+
+	var factor models.EnvironmentalFactor
+	if err := db.DB.First(&factor, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environmental factor not found"})
+		return
+	}
+	if err := db.DB.Delete(&factor).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete environmental factor"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "EnvironmentalFactor", factor.ID,
+		fmt.Sprintf("Environmental factor for %s from %s deleted", factor.GoodsType, factor.EffectiveFrom.Format("2006-01-02")))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Environmental factor deleted"})
+}
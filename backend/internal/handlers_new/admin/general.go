@@ -10,6 +10,7 @@ import (
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/handlers_new/shared"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -135,12 +136,16 @@ func AdminTicketRelease(c *gin.Context) {
 		Categories  []string       `json:"categories"`
 		MaxTickets  map[string]int `json:"max_tickets"`
 		AutoRelease bool           `json:"auto_release"`
+		DryRun      bool           `json:"dry_run"` // Preview who would receive tickets without issuing them
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if c.Query("dry_run") == "true" {
+		req.DryRun = true
+	}
 
 	// Validate release time (should be Tuesday, Wednesday, or Thursday at 9 AM)
 	releaseDate, err := time.Parse("2006-01-02", req.ReleaseDate)
@@ -149,7 +154,7 @@ func AdminTicketRelease(c *gin.Context) {
 		return
 	}
 
-	if !isValidReleaseDay(releaseDate) {
+	if !IsValidReleaseDay(releaseDate) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":        "Tickets can only be released on Tuesday, Wednesday, or Thursday",
 			"allowed_days": []string{"Tuesday", "Wednesday", "Thursday"},
@@ -157,11 +162,36 @@ func AdminTicketRelease(c *gin.Context) {
 		return
 	}
 
+	// A dry run returns the plan without issuing tickets or notifying anyone
+	if req.DryRun {
+		plan := PlanTicketRelease(req.ReleaseDate, req.Categories, req.MaxTickets)
+		byCategory := gin.H{}
+		for _, categoryPlan := range plan.Categories {
+			byCategory[categoryPlan.Category] = categoryPlan.Released
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Dry run: no tickets were issued",
+			"dry_run": true,
+			"plan":    plan,
+			"summary": gin.H{
+				"total_released":  plan.TotalReleased,
+				"by_category":     byCategory,
+				"remaining_queue": plan.RemainingInQueue,
+			},
+		})
+		return
+	}
+
 	// Process ticket release
-	results := processTicketRelease(req.ReleaseDate, req.Categories, req.MaxTickets)
+	var triggeredBy *uint
+	if adminID := utils.GetUserIDFromContext(c); adminID != 0 {
+		triggeredBy = &adminID
+	}
+	results := ProcessTicketRelease(req.ReleaseDate, req.Categories, req.MaxTickets, models.ReleaseTriggerManual, triggeredBy)
 
 	// Create audit log
-	utils.CreateAuditLog(c, "TicketRelease", "HelpRequest", 0,
+	utils.CreateAuditLog(c, "TicketRelease", "ReleaseRun", results.ReleaseRunID,
 		fmt.Sprintf("Released %d tickets for %s", results.TotalReleased, req.ReleaseDate))
 
 	c.JSON(http.StatusOK, gin.H{
@@ -219,9 +249,10 @@ func AdminManageCapacity(c *gin.Context) {
 			return
 		}
 	} else {
-		// Update existing capacity
-		capacity.MaxFoodVisits = req.MaxFoodVisits
-		capacity.MaxGeneralVisits = req.MaxGeneralVisits
+		// Update existing capacity. The requested limits are the base capacity; slots
+		// from approved overrides stay on top until they expire.
+		capacity.MaxFoodVisits = req.MaxFoodVisits + capacity.OverrideFoodVisits
+		capacity.MaxGeneralVisits = req.MaxGeneralVisits + capacity.OverrideGeneralVisits
 		capacity.IsOperatingDay = req.IsOperatingDay
 		capacity.Notes = req.Notes
 		capacity.TemporaryAdjustment = req.TemporaryAdjustment
@@ -314,97 +345,6 @@ func AdminSystemHealth(c *gin.Context) {
 	c.JSON(http.StatusOK, health)
 }
 
-// AdminGetSystemAlerts returns system alerts for the admin dashboard
-func AdminGetSystemAlerts(c *gin.Context) {
-	today := time.Now()
-	todayStr := today.Format("2006-01-02")
-
-	var alerts []gin.H
-
-	// Check high volume of requests
-	var todayRequests int64
-	db.DB.Model(&models.HelpRequest{}).Where("DATE(created_at) = ?", todayStr).Count(&todayRequests)
-
-	if todayRequests > 50 {
-		alerts = append(alerts, gin.H{
-			"id":           fmt.Sprintf("high_volume_%s", todayStr),
-			"type":         "warning",
-			"severity":     "medium",
-			"title":        "High Request Volume",
-			"message":      fmt.Sprintf("High volume of requests today: %d", todayRequests),
-			"timestamp":    today.Format(time.RFC3339),
-			"acknowledged": false,
-		})
-	}
-
-	// Check volunteer coverage
-	var todayShifts, assignedShifts int64
-	db.DB.Model(&models.Shift{}).Where("DATE(date) = ?", todayStr).Count(&todayShifts)
-	db.DB.Model(&models.Shift{}).Where("DATE(date) = ? AND assigned_volunteer_id IS NOT NULL", todayStr).Count(&assignedShifts)
-
-	coveragePercent := 0
-	if todayShifts > 0 {
-		coveragePercent = int(float64(assignedShifts) / float64(todayShifts) * 100)
-	}
-
-	if coveragePercent < 80 && todayShifts > 0 {
-		alerts = append(alerts, gin.H{
-			"id":           fmt.Sprintf("low_coverage_%s", todayStr),
-			"type":         "error",
-			"severity":     "high",
-			"title":        "Low Volunteer Coverage",
-			"message":      fmt.Sprintf("Low volunteer coverage: %d%% (%d/%d shifts covered)", coveragePercent, assignedShifts, todayShifts),
-			"timestamp":    today.Format(time.RFC3339),
-			"acknowledged": false,
-			"action": gin.H{
-				"label": "View Shifts",
-				"url":   "/admin/shifts",
-			},
-		})
-	}
-
-	// Check urgent needs - using hardcoded value instead of inventory query
-	urgentNeeds := int64(3) // Hardcoded value replacing inventory system
-
-	if urgentNeeds > 3 {
-		alerts = append(alerts, gin.H{
-			"id":           fmt.Sprintf("urgent_needs_%s", todayStr),
-			"type":         "warning",
-			"severity":     "medium",
-			"title":        "Urgent Needs",
-			"message":      fmt.Sprintf("%d urgent needs require attention", urgentNeeds),
-			"timestamp":    today.Format(time.RFC3339),
-			"acknowledged": false,
-			"action": gin.H{
-				"label": "View Needs",
-				"url":   "/admin/needs",
-			},
-		})
-	}
-
-	// Check pending document verifications
-	var pendingVerifications int64
-	db.DB.Model(&models.Document{}).Where("status = ?", "pending_verification").Count(&pendingVerifications)
-
-	if pendingVerifications > 10 {
-		alerts = append(alerts, gin.H{
-			"id":           fmt.Sprintf("pending_docs_%s", todayStr),
-			"type":         "info",
-			"severity":     "low",
-			"title":        "Pending Verifications",
-			"message":      fmt.Sprintf("%d document verifications pending", pendingVerifications),
-			"timestamp":    today.Format(time.RFC3339),
-			"acknowledged": false,
-			"action": gin.H{
-				"label": "Review Documents",
-				"url":   "/admin/documents",
-			},
-		})
-	}
-
-	c.JSON(http.StatusOK, alerts)
-}
-
 // AdminGetVolunteerCoverageGaps returns volunteer coverage gaps for upcoming shifts
 func AdminGetVolunteerCoverageGaps(c *gin.Context) {
 	gaps := getVolunteerCoverageGaps()
@@ -463,29 +403,6 @@ func AdminGetPerformanceMetrics(c *gin.Context) {
 	})
 }
 
-// AdminAcknowledgeAlert marks a system alert as acknowledged
-func AdminAcknowledgeAlert(c *gin.Context) {
-	alertID := c.Param("id")
-
-	if alertID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Alert ID is required"})
-		return
-	}
-
-	// In a real implementation, you would store alert acknowledgments in the database
-	// For now, we'll just return a success response
-
-	// Create audit log
-	utils.CreateAuditLog(c, "Acknowledge", "System Alert", 0,
-		fmt.Sprintf("Alert %s acknowledged by admin", alertID))
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":   "Alert acknowledged successfully",
-		"alert_id":  alertID,
-		"timestamp": time.Now().Format(time.RFC3339),
-	})
-}
-
 // Helper function to determine priority based on coverage percentage
 func determinePriority(coveragePercent interface{}) string {
 	if percent, ok := coveragePercent.(float64); ok {
@@ -533,7 +450,9 @@ func calculateAdminKPIs(todayStr string) AdminKPIs {
 		Count(&kpis.AssignedShifts)
 
 	// General metrics
-	kpis.UrgentNeeds = 3 // Placeholder for removed inventory system
+	if urgent, err := services.NewInventoryService().UrgentCount(); err == nil {
+		kpis.UrgentNeeds = urgent
+	}
 	db.DB.Model(&models.User{}).Where("role = ? AND status = ?", models.RoleVolunteer, "active").Count(&kpis.ActiveVolunteers)
 	db.DB.Model(&models.User{}).Where("role = ?", models.RoleVisitor).Count(&kpis.TotalVisitors)
 
@@ -551,10 +470,46 @@ type TicketReleaseResult struct {
 	GeneralTickets   int     `json:"general_tickets"`
 	RemainingInQueue int     `json:"remaining_in_queue"`
 	FailedReleases   []gin.H `json:"failed_releases"`
+	ReleaseRunID     uint    `json:"release_run_id,omitempty"`   // Permanent record of this release
+	ReleaseRunHash   string  `json:"release_run_hash,omitempty"` // Hash chaining the record to earlier releases
+}
+
+// TicketReleasePlan is who a ticket release would issue tickets to. A dry run
+// returns it without changing anything.
+type TicketReleasePlan struct {
+	ReleaseDate      string                `json:"release_date"`
+	Categories       []CategoryReleasePlan `json:"categories"`
+	TotalReleased    int                   `json:"total_released"`
+	RemainingInQueue int                   `json:"remaining_in_queue"`
+}
+
+// CategoryReleasePlan is the planned release for one category
+type CategoryReleasePlan struct {
+	Category       string             `json:"category"`
+	Capacity       int                `json:"capacity"`
+	Eligible       int                `json:"eligible"` // Approved requests waiting for a ticket
+	Released       int                `json:"released"`
+	Recipients     []ReleaseRecipient `json:"recipients"`
+	RemainingQueue []ReleaseRecipient `json:"remaining_queue"` // Approved requests capacity does not reach
+
+	defaultCapacity int
+	requests        []models.HelpRequest
+}
+
+// ReleaseRecipient is a help request in a planned release, in allocation order
+type ReleaseRecipient struct {
+	Position      int       `json:"position"`
+	HelpRequestID uint      `json:"help_request_id"`
+	VisitorID     uint      `json:"visitor_id"`
+	VisitorName   string    `json:"visitor_name"`
+	Reference     string    `json:"reference"`
+	RequestedAt   time.Time `json:"requested_at"`
 }
 
-func processTicketRelease(releaseDate string, categories []string, maxTickets map[string]int) TicketReleaseResult {
-	var result TicketReleaseResult
+// PlanTicketRelease works out who would receive tickets under current capacity.
+// Approved requests are allocated first come, first served.
+func PlanTicketRelease(releaseDate string, categories []string, maxTickets map[string]int) TicketReleasePlan {
+	plan := TicketReleasePlan{ReleaseDate: releaseDate}
 
 	// If no categories specified, use both
 	if len(categories) == 0 {
@@ -562,42 +517,167 @@ func processTicketRelease(releaseDate string, categories []string, maxTickets ma
 	}
 
 	for _, category := range categories {
-		max := maxTickets[category]
-		if max == 0 {
-			max = getDailyCapacity(releaseDate, category)
+		// Get approved requests in order
+		var approvedRequests []models.HelpRequest
+		db.DB.Where("status = ? AND visit_day = ? AND category = ?",
+			models.HelpRequestStatusApproved, releaseDate, category).
+			Order("created_at ASC").
+			Find(&approvedRequests)
+
+		categoryPlan := planCategoryRelease(category, maxTickets[category], getDailyCapacity(releaseDate, category), approvedRequests)
+		plan.TotalReleased += categoryPlan.Released
+		plan.Categories = append(plan.Categories, categoryPlan)
+	}
+
+	// Count remaining in queue
+	var remaining int64
+	db.DB.Model(&models.HelpRequest{}).
+		Where("visit_day = ? AND status = ?", releaseDate, models.HelpRequestStatusPending).
+		Count(&remaining)
+	plan.RemainingInQueue = int(remaining)
+
+	return plan
+}
+
+// planCategoryRelease allocates a category's capacity to approved requests in the
+// order given. An override of 0 uses the day's default capacity.
+func planCategoryRelease(category string, override, defaultCapacity int, approvedRequests []models.HelpRequest) CategoryReleasePlan {
+	max := override
+	if max == 0 {
+		max = defaultCapacity
+	}
+	if max < 0 {
+		max = 0
+	}
+
+	categoryPlan := CategoryReleasePlan{
+		Category:        category,
+		Capacity:        max,
+		Eligible:        len(approvedRequests),
+		Recipients:      []ReleaseRecipient{},
+		RemainingQueue:  []ReleaseRecipient{},
+		defaultCapacity: defaultCapacity,
+	}
+	for i, request := range approvedRequests {
+		recipient := ReleaseRecipient{
+			Position:      i + 1,
+			HelpRequestID: request.ID,
+			VisitorID:     request.VisitorID,
+			VisitorName:   request.VisitorName,
+			Reference:     request.Reference,
+			RequestedAt:   request.CreatedAt,
+		}
+		if i < max {
+			categoryPlan.Recipients = append(categoryPlan.Recipients, recipient)
+			categoryPlan.requests = append(categoryPlan.requests, request)
+		} else {
+			categoryPlan.RemainingQueue = append(categoryPlan.RemainingQueue, recipient)
 		}
+	}
+	categoryPlan.Released = len(categoryPlan.Recipients)
+	return categoryPlan
+}
+
+// ProcessTicketRelease issues tickets to the requests PlanTicketRelease picks and
+// notifies the visitors. Every release is recorded as a ReleaseRun with its
+// capacities, overrides and allocation order; triggeredBy is the admin who ran it.
+func ProcessTicketRelease(releaseDate string, categories []string, maxTickets map[string]int, trigger string, triggeredBy *uint) TicketReleaseResult {
+	result := TicketReleaseResult{FailedReleases: []gin.H{}}
+	plan := PlanTicketRelease(releaseDate, categories, maxTickets)
+	run := &models.ReleaseRun{
+		ReleaseDate:      releaseDate,
+		RunAt:            time.Now(),
+		Trigger:          trigger,
+		TriggeredBy:      triggeredBy,
+		Overrides:        models.ReleaseOverrides{},
+		RemainingInQueue: plan.RemainingInQueue,
+	}
 
-		released := releaseTicketsForCategory(releaseDate, category, max)
+	for _, categoryPlan := range plan.Categories {
+		issued, failed := releaseTickets(categoryPlan.requests)
+		released := len(issued)
 		result.TotalReleased += released
 
-		switch category {
+		switch categoryPlan.Category {
 		case models.CategoryFood:
 			result.FoodTickets = released
 		case models.CategoryGeneral:
 			result.GeneralTickets = released
 		}
+		for id, err := range failed {
+			result.FailedReleases = append(result.FailedReleases, gin.H{
+				"help_request_id": id,
+				"category":        categoryPlan.Category,
+				"error":           err.Error(),
+			})
+		}
+
+		if override, ok := maxTickets[categoryPlan.Category]; ok && override != 0 {
+			run.Overrides[categoryPlan.Category] = override
+		}
+		categoryRun := buildReleaseRunCategory(categoryPlan, failed)
+		run.Categories = append(run.Categories, categoryRun)
+		run.TotalEligible += categoryRun.QueueSize
+		run.TotalReleased += categoryRun.Issued
+		run.TotalFailed += categoryRun.Failed
 	}
+	result.RemainingInQueue = plan.RemainingInQueue
 
-	// Count remaining in queue
-	var remaining int64
-	db.DB.Model(&models.HelpRequest{}).
-		Where("visit_day = ? AND status = ?", releaseDate, models.HelpRequestStatusPending).
-		Count(&remaining)
-	result.RemainingInQueue = int(remaining)
+	if err := services.NewReleaseRunService().Record(run); err != nil {
+		log.Printf("Failed to record ticket release for %s: %v", releaseDate, err)
+	} else {
+		result.ReleaseRunID = run.ID
+		result.ReleaseRunHash = run.Hash
+	}
 
 	return result
 }
 
-func releaseTicketsForCategory(releaseDate, category string, maxTickets int) int {
-	// Get approved requests in order
-	var approvedRequests []models.HelpRequest
-	db.DB.Where("status = ? AND visit_day = ? AND category = ?",
-		models.HelpRequestStatusApproved, releaseDate, category).
-		Order("created_at ASC").
-		Limit(maxTickets).
-		Find(&approvedRequests)
+// buildReleaseRunCategory records a category's queue in allocation order and what
+// happened to each request in it
+func buildReleaseRunCategory(categoryPlan CategoryReleasePlan, failed map[uint]error) models.ReleaseRunCategory {
+	categoryRun := models.ReleaseRunCategory{
+		Category:        categoryPlan.Category,
+		DefaultCapacity: categoryPlan.defaultCapacity,
+		Capacity:        categoryPlan.Capacity,
+		Overridden:      categoryPlan.Capacity != categoryPlan.defaultCapacity,
+		QueueSize:       categoryPlan.Eligible,
+		Allocation:      make([]models.ReleaseAllocation, 0, categoryPlan.Eligible),
+	}
+
+	for _, recipient := range categoryPlan.Recipients {
+		outcome := models.ReleaseOutcomeIssued
+		if _, ok := failed[recipient.HelpRequestID]; ok {
+			outcome = models.ReleaseOutcomeFailed
+			categoryRun.Failed++
+		} else {
+			categoryRun.Issued++
+		}
+		categoryRun.Allocation = append(categoryRun.Allocation, models.ReleaseAllocation{
+			Position:      recipient.Position,
+			HelpRequestID: recipient.HelpRequestID,
+			RequestedAt:   recipient.RequestedAt,
+			Outcome:       outcome,
+		})
+	}
+	for _, waiting := range categoryPlan.RemainingQueue {
+		categoryRun.NotReached++
+		categoryRun.Allocation = append(categoryRun.Allocation, models.ReleaseAllocation{
+			Position:      waiting.Position,
+			HelpRequestID: waiting.HelpRequestID,
+			RequestedAt:   waiting.RequestedAt,
+			Outcome:       models.ReleaseOutcomeNotReached,
+		})
+	}
 
-	released := 0
+	return categoryRun
+}
+
+// releaseTickets issues tickets for planned requests. It returns the requests that
+// were issued a ticket and, for the rest, why saving the ticket failed.
+func releaseTickets(approvedRequests []models.HelpRequest) ([]uint, map[uint]error) {
+	var issued []uint
+	failed := map[uint]error{}
 	for _, request := range approvedRequests {
 		ticketNumber := shared.GenerateTicketNumber()
 		qrCode, _ := shared.GenerateQRCode(ticketNumber)
@@ -609,18 +689,20 @@ func releaseTicketsForCategory(releaseDate, category string, maxTickets int) int
 
 		if err := db.DB.Save(&request).Error; err != nil {
 			log.Printf("Failed to issue ticket for request %d: %v", request.ID, err)
+			failed[request.ID] = err
 			continue
 		}
 
 		// Send notification
 		go sendTicketIssuedNotification(request)
-		released++
+		issued = append(issued, request.ID)
 	}
 
-	return released
+	return issued, failed
 }
 
-func isValidReleaseDay(date time.Time) bool {
+// IsValidReleaseDay reports whether tickets can be released on a date
+func IsValidReleaseDay(date time.Time) bool {
 	dayOfWeek := date.Weekday()
 	return dayOfWeek >= time.Tuesday && dayOfWeek <= time.Thursday
 }
@@ -942,6 +1024,30 @@ func isValidVisitDay(dateStr, _ string) bool {
 // @Failure 401 {object} gin.H
 // @Router /admin/dashboard/stats [get]
 func AdminDashboardStats(c *gin.Context) {
+	// Dashboards poll this, so concurrent misses share one build of the aggregates
+	var response gin.H
+	hit, err := services.GetCacheService().GetOrLoad("dashboard:admin:stats", time.Minute, &response,
+		func() (interface{}, error) { return buildAdminDashboardStats(), nil },
+		services.CacheTagDashboard)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load dashboard statistics"})
+		return
+	}
+
+	c.Header("X-Cache", cacheHeader(hit))
+	c.JSON(http.StatusOK, response)
+}
+
+// cacheHeader returns the X-Cache value for a cache lookup
+func cacheHeader(hit bool) string {
+	if hit {
+		return "HIT"
+	}
+	return "MISS"
+}
+
+// buildAdminDashboardStats gathers the admin dashboard statistics
+func buildAdminDashboardStats() gin.H {
 	today := time.Now()
 
 	// Get system alerts
@@ -1060,7 +1166,7 @@ func AdminDashboardStats(c *gin.Context) {
 		"lastUpdated":          time.Now(),
 	}
 
-	c.JSON(http.StatusOK, response)
+	return response
 }
 
 // AdminDashboardCharts returns chart data for admin dashboard
@@ -1073,6 +1179,22 @@ func AdminDashboardStats(c *gin.Context) {
 // @Router /admin/dashboard/charts [get]
 func AdminDashboardCharts(c *gin.Context) {
 	timeRange := c.DefaultQuery("timeRange", "month")
+
+	var response gin.H
+	hit, err := services.GetCacheService().GetOrLoad("dashboard:admin:charts:"+timeRange, 5*time.Minute, &response,
+		func() (interface{}, error) { return buildAdminDashboardCharts(timeRange), nil },
+		services.CacheTagDashboard)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load dashboard charts"})
+		return
+	}
+
+	c.Header("X-Cache", cacheHeader(hit))
+	c.JSON(http.StatusOK, response)
+}
+
+// buildAdminDashboardCharts gathers the admin dashboard chart series for a time range
+func buildAdminDashboardCharts(timeRange string) gin.H {
 	startDate := calculateChartStartDate(timeRange)
 
 	// Get chart data
@@ -1095,7 +1217,7 @@ func AdminDashboardCharts(c *gin.Context) {
 		"timeRange": timeRange,
 	}
 
-	c.JSON(http.StatusOK, response)
+	return response
 }
 
 // AdminAnalytics returns analytics data for admin dashboard
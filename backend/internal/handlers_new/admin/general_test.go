@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestPlanCategoryRelease(t *testing.T) {
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	var approved []models.HelpRequest
+	for i := 1; i <= 5; i++ {
+		request := models.HelpRequest{ID: uint(i), VisitorID: uint(100 + i)}
+		request.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		approved = append(approved, request)
+	}
+
+	tests := []struct {
+		name              string
+		override, daily   int
+		capacity, release int
+	}{
+		{"default capacity", 0, 3, 3, 3},
+		{"override", 4, 3, 4, 4},
+		{"more capacity than requests", 10, 3, 10, 5},
+		{"negative override releases nothing", -1, 3, 0, 0},
+	}
+	for _, tt := range tests {
+		plan := planCategoryRelease(models.CategoryFood, tt.override, tt.daily, approved)
+		if plan.Capacity != tt.capacity || plan.Released != tt.release || plan.Eligible != 5 ||
+			len(plan.Recipients) != tt.release || len(plan.RemainingQueue) != 5-tt.release || len(plan.requests) != tt.release {
+			t.Errorf("%s: got capacity %d, released %d, %d left", tt.name, plan.Capacity, plan.Released, len(plan.RemainingQueue))
+			continue
+		}
+		if tt.release > 0 && tt.release < 5 {
+			// First come, first served, with positions running on into the queue
+			if plan.Recipients[0].HelpRequestID != 1 || plan.RemainingQueue[0].HelpRequestID != uint(tt.release+1) ||
+				plan.RemainingQueue[0].Position != tt.release+1 {
+				t.Errorf("%s: order %+v / %+v", tt.name, plan.Recipients, plan.RemainingQueue)
+			}
+		}
+	}
+
+	empty := planCategoryRelease(models.CategoryGeneral, 0, 20, nil)
+	if empty.Recipients == nil || empty.RemainingQueue == nil || empty.Released != 0 || empty.defaultCapacity != 20 {
+		t.Errorf("no requests: got %+v", empty)
+	}
+}
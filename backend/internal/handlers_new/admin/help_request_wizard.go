@@ -0,0 +1,43 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminGetHelpRequestWizardFunnel returns how far visitors get through the help
+// request wizard and the steps where they give up, for drafts started between from
+// and to (YYYY-MM-DD, inclusive; default the last 30 days)
+func AdminGetHelpRequestWizardFunnel(c *gin.Context) {
+	today := time.Now()
+	to := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	from := to.AddDate(0, 0, -29)
+	for param, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " date, use YYYY-MM-DD"})
+			return
+		}
+		*target = parsed
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+
+	funnel, err := services.NewHelpRequestDraftService().Funnel(from, to.AddDate(0, 0, 1))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to measure the help request wizard"})
+		return
+	}
+	funnel.To = to.Format("2006-01-02")
+	c.JSON(http.StatusOK, funnel)
+}
@@ -0,0 +1,191 @@
+package admin
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RollbackHistoricImportRequest gives the reason a committed import is undone
+type RollbackHistoricImportRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// DownloadHistoricImportTemplate returns the CSV template for visitors, donations
+// or volunteer_hours, with one example row
+func DownloadHistoricImportTemplate(c *gin.Context) {
+	template, err := services.NewHistoricImportService().Template(c.Param("kind"))
+	if err != nil {
+		historicImportError(c, err, "Failed to build the template")
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"historic-%s-template.csv\"", template.Kind))
+	writer := csv.NewWriter(c.Writer)
+	writer.Write(template.Columns)
+	writer.Write(template.Example)
+	writer.Flush()
+}
+
+// StageHistoricImport validates a spreadsheet of past records and stages it for
+// review. Send the CSV as "file" and the kind of records as "kind".
+func StageHistoricImport(c *gin.Context) {
+	file, fileHeader, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to get file",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	service := services.NewHistoricImportService()
+	batch, err := service.Stage(c.PostForm("kind"), fileHeader.Filename, file, utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		historicImportError(c, err, "Failed to stage the import")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Stage", "HistoricImportBatch", batch.ID,
+		fmt.Sprintf("Staged %s import %s: %d valid, %d invalid, %d duplicate rows",
+			batch.Kind, batch.FileName, batch.ValidRows, batch.InvalidRows, batch.DuplicateRows))
+
+	report, err := service.Report(batch.ID, "", 1, 100)
+	if err != nil {
+		historicImportError(c, err, "Import staged but the report failed")
+		return
+	}
+	c.JSON(http.StatusCreated, report)
+}
+
+// ListHistoricImports returns import batches, optionally of one kind or status
+func ListHistoricImports(c *gin.Context) {
+	batches, err := services.NewHistoricImportService().List(c.Query("kind"), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch imports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"batches": batches,
+		"total":   len(batches),
+	})
+}
+
+// GetHistoricImport returns a batch's validation report and a page of its rows.
+// Filter with status=valid, invalid, duplicate or imported.
+func GetHistoricImport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import ID"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	report, err := services.NewHistoricImportService().Report(uint(id), c.Query("status"), page, limit)
+	if err != nil {
+		historicImportError(c, err, "Failed to fetch import")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// CommitHistoricImport writes a staged batch's valid rows to the live records
+func CommitHistoricImport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import ID"})
+		return
+	}
+
+	batch, err := services.NewHistoricImportService().Commit(uint(id), utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		historicImportError(c, err, "Failed to commit import")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Commit", "HistoricImportBatch", batch.ID,
+		fmt.Sprintf("Committed %s import %s: %d rows imported", batch.Kind, batch.FileName, batch.ImportedRows))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Imported %d rows", batch.ImportedRows),
+		"batch":   batch,
+	})
+}
+
+// RollbackHistoricImport removes the records a committed batch created
+func RollbackHistoricImport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import ID"})
+		return
+	}
+	var req RollbackHistoricImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	batch, err := services.NewHistoricImportService().Rollback(uint(id), utils.GetUserIDFromContext(c), req.Reason, time.Now())
+	if err != nil {
+		historicImportError(c, err, "Failed to roll back import")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Rollback", "HistoricImportBatch", batch.ID,
+		fmt.Sprintf("Rolled back %s import %s (%d rows): %s", batch.Kind, batch.FileName, batch.ImportedRows, batch.RollbackReason))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Import rolled back",
+		"batch":   batch,
+	})
+}
+
+// DiscardHistoricImport abandons a staged batch without importing it
+func DiscardHistoricImport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import ID"})
+		return
+	}
+
+	batch, err := services.NewHistoricImportService().Discard(uint(id))
+	if err != nil {
+		historicImportError(c, err, "Failed to discard import")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Discard", "HistoricImportBatch", batch.ID,
+		fmt.Sprintf("Discarded staged %s import %s", batch.Kind, batch.FileName))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Import discarded",
+		"batch":   batch,
+	})
+}
+
+// historicImportError maps historic import errors to responses
+func historicImportError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrHistoricImportNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrHistoricImportKind), errors.Is(err, services.ErrHistoricImportFile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrHistoricImportNotStaged), errors.Is(err, services.ErrHistoricImportNotCommitted),
+		errors.Is(err, services.ErrHistoricImportNothingValid), errors.Is(err, services.ErrHistoricImportInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
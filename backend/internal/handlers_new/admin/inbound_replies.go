@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListInboundReplies returns email replies received by the inbound reply webhook,
+// newest first. Filter with entity_type, entity_id and status, e.g. status=unmatched
+// to find replies that could not be threaded.
+func ListInboundReplies(c *gin.Context) {
+	var entityID uint
+	if raw := c.Query("entity_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+			return
+		}
+		entityID = uint(id)
+	}
+	respondWithReplies(c, c.Query("entity_type"), entityID, c.Query("status"))
+}
+
+// ListHelpRequestReplies returns the email replies threaded onto a help request
+func ListHelpRequestReplies(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid help request ID"})
+		return
+	}
+	respondWithReplies(c, models.InboundReplyHelpRequest, uint(id), models.InboundReplyMatched)
+}
+
+// ListFeedbackReplies returns the email replies threaded onto visit feedback
+func ListFeedbackReplies(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("feedback_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid feedback ID"})
+		return
+	}
+	respondWithReplies(c, models.InboundReplyFeedback, uint(id), models.InboundReplyMatched)
+}
+
+// respondWithReplies writes the matching replies
+func respondWithReplies(c *gin.Context, entityType string, entityID uint, status string) {
+	replies, err := services.NewInboundReplyService().List(entityType, entityID, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch replies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"replies": replies,
+		"total":   len(replies),
+	})
+}
@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InterpreterBookingUpdateRequest records what staff agreed with an interpreter
+type InterpreterBookingUpdateRequest struct {
+	Status          string `json:"status" binding:"required,oneof=requested confirmed declined cancelled"`
+	InterpreterName string `json:"interpreter_name"`
+	Notes           string `json:"notes"`
+}
+
+// ListInterpreterBookings returns interpreter bookings, optionally for one visit day.
+// Pass status=open for bookings that still need an interpreter.
+func ListInterpreterBookings(c *gin.Context) {
+	service := services.NewInterpreterBookingService()
+	bookings, err := service.List(c.Query("date"), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch interpreter bookings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bookings":            bookings,
+		"total":               len(bookings),
+		"provider_configured": service.ProviderConfigured(),
+	})
+}
+
+// UpdateInterpreterBooking records a booking made or lost outside the interpreter
+// service, such as by phone
+func UpdateInterpreterBooking(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+	var req InterpreterBookingUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	booking, err := services.NewInterpreterBookingService().Record(uint(id), req.Status, req.InterpreterName, req.Notes, utils.GetUserIDFromContext(c))
+	if err != nil {
+		interpreterBookingError(c, err, "Failed to update interpreter booking")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "InterpreterBooking", booking.ID,
+		fmt.Sprintf("%s interpreter for %s marked %s", booking.Language, booking.Reference, booking.Status))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Interpreter booking updated",
+		"booking": booking,
+	})
+}
+
+// SendInterpreterBooking sends a booking to the interpreter service, such as after
+// the service was unavailable or declined
+func SendInterpreterBooking(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	service := services.NewInterpreterBookingService()
+	booking, err := service.Get(uint(id))
+	if err != nil {
+		interpreterBookingError(c, err, "Failed to fetch interpreter booking")
+		return
+	}
+	if err := service.Send(c.Request.Context(), booking); err != nil {
+		interpreterBookingError(c, err, "Failed to send interpreter booking")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Send", "InterpreterBooking", booking.ID,
+		fmt.Sprintf("%s interpreter for %s sent to the interpreter service", booking.Language, booking.Reference))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Interpreter booking sent",
+		"booking": booking,
+	})
+}
+
+// interpreterBookingError maps interpreter booking errors to responses
+func interpreterBookingError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInterpreterBookingNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInterpreterNotSendable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInterpreterNoProvider), errors.Is(err, services.ErrInterpreterInvalidStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInterpreterProviderFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,149 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// KioskDeviceRequest registers a feedback kiosk
+type KioskDeviceRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Location string `json:"location"`
+}
+
+// ListKioskDevices returns registered feedback kiosks
+func ListKioskDevices(c *gin.Context) {
+	var devices []models.KioskDevice
+	if err := db.DB.Order("is_active DESC, created_at DESC").Find(&devices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch kiosk devices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"devices": devices,
+		"total":   len(devices),
+	})
+}
+
+// CreateKioskDevice registers a kiosk and returns its device token once
+func CreateKioskDevice(c *gin.Context) {
+	var req KioskDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	device, token, err := services.NewKioskService().CreateDevice(req.Name, req.Location, utils.GetUserIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register kiosk device"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "KioskDevice", device.ID,
+		fmt.Sprintf("Feedback kiosk registered: %s (%s)", device.Name, device.TokenPrefix))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Kiosk registered. Copy the token now, it will not be shown again",
+		"device":  device,
+		"token":   token,
+	})
+}
+
+// RevokeKioskDevice stops a kiosk's token from being accepted
+func RevokeKioskDevice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kiosk ID"})
+		return
+	}
+
+	var device models.KioskDevice
+	if err := db.DB.First(&device, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Kiosk device not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch kiosk device"})
+		}
+		return
+	}
+	if !device.IsActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "Kiosk device is already revoked"})
+		return
+	}
+
+	if err := services.NewKioskService().RevokeDevice(&device); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke kiosk device"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Revoke", "KioskDevice", device.ID, fmt.Sprintf("Feedback kiosk revoked: %s", device.Name))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Kiosk device revoked",
+		"device":  device,
+	})
+}
+
+// GetKioskSatisfaction returns daily satisfaction metrics from kiosk ratings,
+// defaulting to the last 30 days
+func GetKioskSatisfaction(c *gin.Context) {
+	now := time.Now()
+	from := c.DefaultQuery("from_date", now.AddDate(0, 0, -29).Format("2006-01-02"))
+	to := c.DefaultQuery("to_date", now.Format("2006-01-02"))
+	if _, err := time.Parse("2006-01-02", from); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from_date must be in YYYY-MM-DD format"})
+		return
+	}
+	if _, err := time.Parse("2006-01-02", to); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to_date must be in YYYY-MM-DD format"})
+		return
+	}
+
+	var deviceID uint
+	if value := c.Query("device_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+			return
+		}
+		deviceID = uint(id)
+	}
+
+	days, err := services.NewKioskService().DailyMetrics(from, to, deviceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate kiosk satisfaction"})
+		return
+	}
+
+	var responses, satisfied int64
+	var ratingSum float64
+	for _, day := range days {
+		responses += day.Responses
+		satisfied += day.Distribution[4] + day.Distribution[5]
+		ratingSum += day.AverageRating * float64(day.Responses)
+	}
+	summary := gin.H{"responses": responses, "average_rating": 0.0, "satisfaction": 0.0}
+	if responses > 0 {
+		summary["average_rating"] = math.Round(ratingSum/float64(responses)*100) / 100
+		summary["satisfaction"] = math.Round(float64(satisfied)/float64(responses)*1000) / 10
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from_date": from,
+		"to_date":   to,
+		"summary":   summary,
+		"daily":     days,
+	})
+}
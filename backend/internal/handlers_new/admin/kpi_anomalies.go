@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminGetKPIRanges returns each daily KPI on a day (default yesterday) against its
+// normal range for that weekday
+func AdminGetKPIRanges(c *gin.Context) {
+	day := time.Now().AddDate(0, 0, -1)
+	if value := c.Query("date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date, use YYYY-MM-DD"})
+			return
+		}
+		day = parsed
+	}
+
+	ranges, err := services.NewKPIAnomalyService().Ranges(day)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to measure KPIs"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"day": day.Format("2006-01-02"), "kpis": ranges})
+}
+
+// AdminListKPIAnomalies returns days whose KPIs fell outside their normal range,
+// newest first. Pass kpi to see one KPI.
+func AdminListKPIAnomalies(c *gin.Context) {
+	kpi := c.Query("kpi")
+	if kpi != "" && !models.IsKPI(kpi) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown KPI", "kpis": models.KPIs})
+		return
+	}
+	limit := 100
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+
+	anomalies, err := services.NewKPIAnomalyService().Anomalies(kpi, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch KPI anomalies"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"anomalies": anomalies,
+		"total":     len(anomalies),
+	})
+}
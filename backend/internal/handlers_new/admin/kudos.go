@@ -0,0 +1,249 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StaffKudosRequest represents kudos sent by a staff member
+type StaffKudosRequest struct {
+	RecipientID uint   `json:"recipient_id" binding:"required"`
+	ShiftID     uint   `json:"shift_id" binding:"required"`
+	Category    string `json:"category" binding:"omitempty,oneof=teamwork kindness leadership reliability above_and_beyond"`
+	Message     string `json:"message" binding:"required"`
+}
+
+// ModerateKudosRequest represents a moderation decision
+type ModerateKudosRequest struct {
+	Note string `json:"note"`
+}
+
+// ResolveKudosReportRequest represents the outcome of an abuse report review
+type ResolveKudosReportRequest struct {
+	Uphold bool `json:"uphold"`
+}
+
+// AdminSendKudos sends kudos from a staff member to a volunteer on a shift.
+// Staff kudos skip moderation.
+func AdminSendKudos(c *gin.Context) {
+	adminID := utils.GetUserIDFromContext(c)
+
+	var req StaffKudosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kudosService := services.NewKudosService()
+	kudos, err := kudosService.SendKudos(adminID, true, req.RecipientID, req.ShiftID, req.Category, req.Message)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrKudosDailyLimit) {
+			status = http.StatusTooManyRequests
+		} else if errors.Is(err, services.ErrKudosDuplicate) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if kudos, err = kudosService.ModerateKudos(kudos.ID, adminID, true, "Sent by staff"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve kudos"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "Kudos", kudos.ID,
+		fmt.Sprintf("Staff kudos sent to user %d for shift %d", kudos.RecipientID, kudos.ShiftID))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Kudos sent successfully",
+		"kudos":   kudos,
+	})
+}
+
+// AdminListKudos returns kudos for moderation, pending by default
+func AdminListKudos(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	status := c.DefaultQuery("status", models.KudosStatusPending)
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	query := db.DB.Model(&models.Kudos{})
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var kudos []models.Kudos
+	if err := query.
+		Preload("Sender", selectUserSummary).
+		Preload("Recipient", selectUserSummary).
+		Preload("Shift").
+		Order("created_at ASC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&kudos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch kudos"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"kudos":    kudos,
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+	})
+}
+
+// AdminApproveKudos approves a kudos so it appears on the recipient's dashboard
+func AdminApproveKudos(c *gin.Context) {
+	moderateKudos(c, true)
+}
+
+// AdminRejectKudos rejects a kudos
+func AdminRejectKudos(c *gin.Context) {
+	moderateKudos(c, false)
+}
+
+// moderateKudos applies a moderation decision to the kudos in the :id path parameter
+func moderateKudos(c *gin.Context, approve bool) {
+	kudosID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kudos ID"})
+		return
+	}
+
+	var req ModerateKudosRequest
+	_ = c.ShouldBindJSON(&req)
+
+	kudos, err := services.NewKudosService().ModerateKudos(uint(kudosID), utils.GetUserIDFromContext(c), approve, req.Note)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Kudos not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Moderate", "Kudos", kudos.ID, fmt.Sprintf("Kudos %s", kudos.Status))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Kudos %s", kudos.Status),
+		"kudos":   kudos,
+	})
+}
+
+// AdminListKudosReports returns abuse reports raised against kudos
+func AdminListKudosReports(c *gin.Context) {
+	status := c.DefaultQuery("status", models.KudosReportStatusOpen)
+
+	query := db.DB.Model(&models.KudosReport{})
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	var reports []models.KudosReport
+	if err := query.
+		Preload("Kudos").
+		Preload("Reporter", selectUserSummary).
+		Order("created_at ASC").
+		Limit(100).
+		Find(&reports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch kudos reports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports})
+}
+
+// AdminResolveKudosReport upholds or dismisses an abuse report
+func AdminResolveKudosReport(c *gin.Context) {
+	reportID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+		return
+	}
+
+	var req ResolveKudosReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := services.NewKudosService().ResolveReport(uint(reportID), utils.GetUserIDFromContext(c), req.Uphold)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Resolve", "KudosReport", report.ID, fmt.Sprintf("Kudos report %s", report.Status))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Report resolved",
+		"report":  report,
+	})
+}
+
+// AdminGetVolunteerOfTheMonth returns the volunteers who received the most kudos in a month
+func AdminGetVolunteerOfTheMonth(c *gin.Context) {
+	month := time.Now().AddDate(0, -1, 0)
+	if monthParam := c.Query("month"); monthParam != "" {
+		parsed, err := time.Parse("2006-01", monthParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid month format. Use YYYY-MM"})
+			return
+		}
+		month = parsed
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	ranking, err := services.NewKudosService().VolunteerOfTheMonth(month, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate report"})
+		return
+	}
+
+	var winner *services.VolunteerOfTheMonthEntry
+	if len(ranking) > 0 {
+		winner = &ranking[0]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"month":   month.Format("2006-01"),
+		"winner":  winner,
+		"ranking": ranking,
+	})
+}
+
+// selectUserSummary limits preloaded users to their public name fields
+func selectUserSummary(tx *gorm.DB) *gorm.DB {
+	return tx.Select("id", "first_name", "last_name")
+}
@@ -0,0 +1,133 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SMSBudgetRequest sets the monthly SMS budget
+type SMSBudgetRequest struct {
+	Budget *float64 `json:"budget" binding:"required"`
+}
+
+// notificationSpendRow is one channel and provider's spend for the month
+type notificationSpendRow struct {
+	Channel  string  `json:"channel"`
+	Provider string  `json:"provider"`
+	Messages int64   `json:"messages"`
+	Segments int64   `json:"segments"`
+	Cost     float64 `json:"cost"`
+}
+
+// dailySpendRow is one day's SMS and email spend
+type dailySpendRow struct {
+	Day      string  `json:"day"`
+	Channel  string  `json:"channel"`
+	Messages int64   `json:"messages"`
+	Cost     float64 `json:"cost"`
+}
+
+// GetNotificationSpend returns the spend dashboard for a month: budget status, spend
+// by channel and provider, a daily trend and how many SMS were blocked or redirected
+func GetNotificationSpend(c *gin.Context) {
+	month := time.Now()
+	if value := c.Query("month"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "month must be in YYYY-MM format"})
+			return
+		}
+		month = parsed
+	}
+
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	end := start.AddDate(0, 1, 0)
+	inMonth := db.DB.Model(&models.NotificationCost{}).Where("created_at >= ? AND created_at < ?", start, end)
+
+	var byProvider []notificationSpendRow
+	if err := inMonth.Session(&gorm.Session{}).
+		Select("channel, provider, COUNT(*) AS messages, COALESCE(SUM(segments), 0) AS segments, COALESCE(SUM(cost), 0) AS cost").
+		Where("status = ?", models.NotificationCostSent).
+		Group("channel, provider").
+		Order("cost DESC").
+		Scan(&byProvider).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate notification spend"})
+		return
+	}
+
+	var daily []dailySpendRow
+	if err := inMonth.Session(&gorm.Session{}).
+		Select("TO_CHAR(created_at, 'YYYY-MM-DD') AS day, channel, COUNT(*) AS messages, COALESCE(SUM(cost), 0) AS cost").
+		Where("status = ?", models.NotificationCostSent).
+		Group("day, channel").
+		Order("day ASC").
+		Scan(&daily).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate daily spend"})
+		return
+	}
+
+	var blocked, redirected, urgent, failed int64
+	inMonth.Session(&gorm.Session{}).Where("status = ?", models.NotificationCostBlocked).Count(&blocked)
+	inMonth.Session(&gorm.Session{}).Where("status = ? AND fallback_channel = ?",
+		models.NotificationCostBlocked, notifications.EmailNotification.String()).Count(&redirected)
+	inMonth.Session(&gorm.Session{}).Where("channel = ? AND status = ? AND urgent = ?",
+		notifications.SMSNotification.String(), models.NotificationCostSent, true).Count(&urgent)
+	inMonth.Session(&gorm.Session{}).Where("status = ?", models.NotificationCostFailed).Count(&failed)
+
+	var alerts []models.SMSBudgetAlert
+	db.DB.Where("month = ?", start.Format("2006-01")).Order("threshold ASC").Find(&alerts)
+
+	c.JSON(http.StatusOK, gin.H{
+		"budget":      notifications.GetSMSBudgetStatus(start),
+		"by_provider": byProvider,
+		"daily":       daily,
+		"sms": gin.H{
+			"blocked":             blocked,
+			"redirected_to_email": redirected,
+			"urgent_sent":         urgent,
+		},
+		"failed": failed,
+		"alerts": alerts,
+		"rates": gin.H{
+			"sms_per_segment": notifications.SMSUnitCost(),
+			"email":           notifications.EmailUnitCost(),
+			"currency":        notifications.CostCurrency(),
+		},
+	})
+}
+
+// UpdateSMSBudget sets the monthly SMS budget. Zero removes the cap.
+func UpdateSMSBudget(c *gin.Context) {
+	var req SMSBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if *req.Budget < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "budget cannot be negative"})
+		return
+	}
+
+	previous := notifications.MonthlySMSBudget()
+	if err := notifications.SetMonthlySMSBudget(*req.Budget, utils.GetUserIDFromContext(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update SMS budget"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "SystemConfig", 0,
+		fmt.Sprintf("Monthly SMS budget changed from %.2f to %.2f", previous, *req.Budget))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "SMS budget updated",
+		"budget":  notifications.GetSMSBudgetStatus(time.Now()),
+	})
+}
@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TemplatePreviewRequest previews a transactional template. Leave html_body blank to
+// preview the template as it is sent now; sample_data replaces the sample values.
+type TemplatePreviewRequest struct {
+	Subject    string                 `json:"subject"`
+	HTMLBody   string                 `json:"html_body"`
+	TextBody   string                 `json:"text_body"`
+	SampleData map[string]interface{} `json:"sample_data"`
+}
+
+// respondTemplateError maps a transactional template error to a response
+func respondTemplateError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, services.ErrTemplateNotFound), errors.Is(err, services.ErrTemplateNotEdited):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidTemplate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action})
+	}
+}
+
+// AdminListEmailTemplates returns every transactional email template, edited or
+// built-in
+func AdminListEmailTemplates(c *gin.Context) {
+	templates, err := services.NewNotificationTemplateService().List()
+	if err != nil {
+		respondTemplateError(c, err, "fetch templates")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"templates": templates,
+		"total":     len(templates),
+	})
+}
+
+// AdminGetEmailTemplate returns one transactional email template
+func AdminGetEmailTemplate(c *gin.Context) {
+	template, err := services.NewNotificationTemplateService().Get(c.Param("template"))
+	if err != nil {
+		respondTemplateError(c, err, "fetch template")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"template": template})
+}
+
+// AdminSaveEmailTemplate replaces a transactional email template with an edit
+func AdminSaveEmailTemplate(c *gin.Context) {
+	var req services.TransactionalTemplateInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := services.NewNotificationTemplateService().Save(c.Param("template"), req, utils.GetUserIDFromContext(c))
+	if err != nil {
+		respondTemplateError(c, err, "save template")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "NotificationTemplate", 0, fmt.Sprintf("Edited the %s email template", template.TemplateType))
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Template saved",
+		"template": template,
+	})
+}
+
+// AdminResetEmailTemplate removes the edit of a transactional email template, so
+// the built-in default is sent again
+func AdminResetEmailTemplate(c *gin.Context) {
+	templateType := c.Param("template")
+	if err := services.NewNotificationTemplateService().Reset(templateType); err != nil {
+		respondTemplateError(c, err, "reset template")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "NotificationTemplate", 0, fmt.Sprintf("Reset the %s email template to the default", templateType))
+	c.JSON(http.StatusOK, gin.H{"message": "Template reset to the default"})
+}
+
+// AdminPreviewEmailTemplate renders a transactional email template, or a draft of
+// it, with sample data
+func AdminPreviewEmailTemplate(c *gin.Context) {
+	var req TemplatePreviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var draft *services.TransactionalTemplateInput
+	if req.HTMLBody != "" {
+		draft = &services.TransactionalTemplateInput{Subject: req.Subject, HTMLBody: req.HTMLBody, TextBody: req.TextBody}
+	}
+	preview, err := services.NewNotificationTemplateService().Preview(c.Param("template"), draft, req.SampleData)
+	if err != nil {
+		respondTemplateError(c, err, "preview template")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preview": preview})
+}
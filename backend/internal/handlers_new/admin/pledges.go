@@ -0,0 +1,421 @@
+package admin
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreatePledgeRequest represents the request body for creating a business pledge
+type CreatePledgeRequest struct {
+	BusinessName   string  `json:"business_name" binding:"required"`
+	ContactName    string  `json:"contact_name"`
+	ContactEmail   string  `json:"contact_email" binding:"omitempty,email"`
+	ContactPhone   string  `json:"contact_phone"`
+	BillingAddress string  `json:"billing_address"`
+	PurchaseOrder  string  `json:"purchase_order"`
+	Amount         float64 `json:"amount" binding:"required,gt=0"`
+	Currency       string  `json:"currency"`
+	DueDate        string  `json:"due_date" binding:"required"` // YYYY-MM-DD
+	Notes          string  `json:"notes"`
+}
+
+// RecordPledgePaymentRequest represents a manually recorded pledge payment
+type RecordPledgePaymentRequest struct {
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+	PaidOn        string  `json:"paid_on"` // YYYY-MM-DD, defaults to today
+	BankReference string  `json:"bank_reference"`
+	Notes         string  `json:"notes"`
+}
+
+// AdminCreatePledge creates a new business pledge
+func AdminCreatePledge(c *gin.Context) {
+	var req CreatePledgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dueDate, err := time.Parse("2006-01-02", req.DueDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" {
+		currency = "GBP"
+	}
+
+	pledge := models.DonationPledge{
+		BusinessName:     req.BusinessName,
+		ContactName:      req.ContactName,
+		ContactEmail:     req.ContactEmail,
+		ContactPhone:     req.ContactPhone,
+		BillingAddress:   req.BillingAddress,
+		PurchaseOrder:    req.PurchaseOrder,
+		Amount:           req.Amount,
+		Currency:         currency,
+		PaymentReference: services.GeneratePledgeReference(),
+		Status:           models.PledgeStatusPledged,
+		PledgedAt:        time.Now(),
+		DueDate:          dueDate,
+		CreatedBy:        utils.GetUserIDFromContext(c),
+		Notes:            req.Notes,
+	}
+
+	if err := db.DB.Create(&pledge).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create pledge"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "DonationPledge", pledge.ID,
+		fmt.Sprintf("Pledge of %.2f %s created for %s", pledge.Amount, pledge.Currency, pledge.BusinessName))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Pledge created successfully",
+		"pledge":  pledge,
+	})
+}
+
+// AdminListPledges returns a paginated list of business pledges
+func AdminListPledges(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	status := c.Query("status")
+	search := c.Query("search")
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	query := db.DB.Model(&models.DonationPledge{})
+	if status != "" && status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if search != "" {
+		like := "%" + search + "%"
+		query = query.Where("business_name ILIKE ? OR payment_reference ILIKE ? OR invoice_number ILIKE ?", like, like, like)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var pledges []models.DonationPledge
+	if err := query.Order("created_at DESC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&pledges).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pledges"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pledges":  pledges,
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+	})
+}
+
+// AdminGetPledge returns a single pledge with its payments
+func AdminGetPledge(c *gin.Context) {
+	pledge, ok := loadPledge(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pledge":       pledge,
+		"outstanding":  pledge.OutstandingAmount(),
+		"days_overdue": pledge.DaysOverdue(time.Now()),
+	})
+}
+
+// AdminIssuePledgeInvoice assigns an invoice number to a pledge and marks it as invoiced
+func AdminIssuePledgeInvoice(c *gin.Context) {
+	pledge, ok := loadPledge(c)
+	if !ok {
+		return
+	}
+
+	if err := services.NewPledgeService().IssueInvoice(pledge); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Invoice", "DonationPledge", pledge.ID,
+		fmt.Sprintf("Invoice %s issued to %s", pledge.InvoiceNumber, pledge.BusinessName))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invoice issued successfully",
+		"pledge":  pledge,
+	})
+}
+
+// AdminDownloadPledgeInvoice returns the invoice PDF for a pledge
+func AdminDownloadPledgeInvoice(c *gin.Context) {
+	pledge, ok := loadPledge(c)
+	if !ok {
+		return
+	}
+
+	if pledge.InvoiceNumber == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No invoice has been issued for this pledge"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.pdf\"", pledge.InvoiceNumber))
+	c.Data(http.StatusOK, "application/pdf", services.RenderPledgeInvoice(*pledge))
+}
+
+// AdminRecordPledgePayment manually records a payment received against a pledge
+func AdminRecordPledgePayment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pledge ID"})
+		return
+	}
+
+	var req RecordPledgePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	paidOn := time.Now()
+	if req.PaidOn != "" {
+		if paidOn, err = time.Parse("2006-01-02", req.PaidOn); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid paid_on date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	recordedBy := utils.GetUserIDFromContext(c)
+	pledge, payment, err := services.NewPledgeService().RecordPayment(
+		uint(id), req.Amount, paidOn, models.PledgePaymentSourceManual, req.BankReference, &recordedBy, req.Notes)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pledge not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.CreateAuditLog(c, "RecordPayment", "DonationPledge", pledge.ID,
+		fmt.Sprintf("Payment of %.2f recorded, pledge now %s", payment.Amount, pledge.Status))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Payment recorded successfully",
+		"pledge":      pledge,
+		"payment":     payment,
+		"outstanding": pledge.OutstandingAmount(),
+	})
+}
+
+// AdminImportPledgePayments reconciles pledge payments from a bank feed CSV export.
+// Expected columns: Date, Amount, Reference and optionally Description. Lines are
+// stored with the bank statement imports, so the same line is never recorded twice.
+func AdminImportPledgePayments(c *gin.Context) {
+	file, fileHeader, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to get file",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to read CSV header",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	columnIndices := make(map[string]int)
+	for i, h := range header {
+		columnIndices[strings.TrimSpace(h)] = i
+	}
+	for _, col := range []string{"Date", "Amount", "Reference"} {
+		if _, exists := columnIndices[col]; !exists {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Required column '%s' not found in CSV", col),
+			})
+			return
+		}
+	}
+
+	var rows []services.BankStatementRow
+	unmatched := []gin.H{}
+	rowNum := 1
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		rowNum++
+		if err != nil {
+			unmatched = append(unmatched, gin.H{"row": rowNum, "reason": "Failed to read row"})
+			continue
+		}
+
+		reference := csvValue(row, columnIndices, "Reference")
+		amount, err := strconv.ParseFloat(strings.ReplaceAll(csvValue(row, columnIndices, "Amount"), ",", ""), 64)
+		if err != nil || amount <= 0 {
+			// Debits and malformed amounts are not pledge payments
+			continue
+		}
+
+		paidOn, err := parseBankDate(csvValue(row, columnIndices, "Date"))
+		if err != nil {
+			unmatched = append(unmatched, gin.H{"row": rowNum, "reference": reference, "amount": amount, "reason": "Invalid date"})
+			continue
+		}
+
+		rows = append(rows, services.BankStatementRow{
+			RowNumber:   rowNum,
+			Date:        paidOn,
+			Amount:      amount,
+			Reference:   reference,
+			Description: csvValue(row, columnIndices, "Description"),
+		})
+	}
+
+	statement, lines, err := services.NewBankReconciliationService().ImportPledgePayments(
+		fileHeader.Filename, utils.GetUserIDFromContext(c), rows)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import pledge payments"})
+		return
+	}
+
+	matched := []gin.H{}
+	duplicates := []gin.H{} // Lines already recorded by an earlier import
+	for _, line := range lines {
+		switch line.Status {
+		case services.PledgeImportMatched:
+			matched = append(matched, gin.H{
+				"row":               line.RowNumber,
+				"transaction_id":    line.TransactionID,
+				"pledge_id":         line.Pledge.ID,
+				"business_name":     line.Pledge.BusinessName,
+				"amount":            line.Amount,
+				"pledge_status":     line.Pledge.Status,
+				"outstanding":       line.Pledge.OutstandingAmount(),
+				"payment_reference": line.Pledge.PaymentReference,
+			})
+		case services.PledgeImportDuplicate:
+			duplicates = append(duplicates, gin.H{"row": line.RowNumber, "reference": line.Reference, "amount": line.Amount})
+		default:
+			unmatched = append(unmatched, gin.H{"row": line.RowNumber, "reference": line.Reference, "amount": line.Amount, "reason": line.Reason})
+		}
+	}
+
+	utils.CreateAuditLog(c, "Import", "PledgePayment", statement.ID,
+		fmt.Sprintf("Bank feed import matched %d payments, %d unmatched, %d already recorded", len(matched), len(unmatched), len(duplicates)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"import":          statement,
+		"matched":         matched,
+		"unmatched":       unmatched,
+		"duplicates":      duplicates,
+		"matched_count":   len(matched),
+		"unmatched_count": len(unmatched),
+		"duplicate_count": len(duplicates),
+	})
+}
+
+// AdminCancelPledge cancels an unpaid pledge
+func AdminCancelPledge(c *gin.Context) {
+	pledge, ok := loadPledge(c)
+	if !ok {
+		return
+	}
+
+	if pledge.Status == models.PledgeStatusPaid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Paid pledges cannot be cancelled"})
+		return
+	}
+
+	pledge.Status = models.PledgeStatusCancelled
+	if err := db.DB.Save(pledge).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel pledge"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Cancel", "DonationPledge", pledge.ID, "Pledge cancelled")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pledge cancelled successfully",
+		"pledge":  pledge,
+	})
+}
+
+// AdminGetPledgeAgingReport returns unpaid pledges grouped by days overdue
+func AdminGetPledgeAgingReport(c *gin.Context) {
+	report, err := services.NewPledgeService().AgingReport(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate aging report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// loadPledge loads the pledge identified by the :id path parameter, writing an error response on failure
+func loadPledge(c *gin.Context) (*models.DonationPledge, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pledge ID"})
+		return nil, false
+	}
+
+	var pledge models.DonationPledge
+	if err := db.DB.Preload("Payments").First(&pledge, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pledge not found"})
+		return nil, false
+	}
+
+	return &pledge, true
+}
+
+// csvValue returns a trimmed column value or an empty string if the column is missing
+func csvValue(row []string, columnIndices map[string]int, column string) string {
+	idx, exists := columnIndices[column]
+	if !exists || idx >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[idx])
+}
+
+// parseBankDate parses the date formats commonly found in UK bank exports
+func parseBankDate(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "02/01/2006", "02-01-2006", "02 Jan 2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date: %s", value)
+}
@@ -0,0 +1,177 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueueWaitAnomalyAckRequest records what was found when looking into a wait spike
+type QueueWaitAnomalyAckRequest struct {
+	Notes string `json:"notes"`
+}
+
+// QueueFairnessReportRequest generates the weekly fairness report for a week
+type QueueFairnessReportRequest struct {
+	WeekStart string `json:"week_start" binding:"required"` // Any day in the week, YYYY-MM-DD
+	Send      bool   `json:"send"`                          // Email it to trustees
+}
+
+// AdminGetQueueFairness compares queue waits by category, postcode district, hour of
+// day and weekday over a date range (default the last 12 weeks). Pass dimension to
+// get just one breakdown.
+func AdminGetQueueFairness(c *gin.Context) {
+	start, end, ok := slaDateRange(c, 84)
+	if !ok {
+		return
+	}
+
+	dimensions := services.QueueFairnessDimensions
+	if dimension := c.Query("dimension"); dimension != "" {
+		dimensions = []string{dimension}
+	}
+
+	fairness := services.NewQueueFairnessService()
+	breakdowns := make([]*services.QueueFairnessBreakdown, 0, len(dimensions))
+	for _, dimension := range dimensions {
+		breakdown, err := fairness.Breakdown(dimension, start, end)
+		if err != nil {
+			if errors.Is(err, services.ErrQueueFairnessDimension) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to analyse queue waits"})
+			return
+		}
+		breakdowns = append(breakdowns, breakdown)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"breakdowns": breakdowns})
+}
+
+// AdminListQueueWaitAnomalies returns detected wait spikes. Pass status=open for the
+// ones nobody has looked into.
+func AdminListQueueWaitAnomalies(c *gin.Context) {
+	limit := 100
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+
+	anomalies, err := services.NewQueueFairnessService().Anomalies(c.Query("status"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wait anomalies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"anomalies": anomalies,
+		"total":     len(anomalies),
+	})
+}
+
+// AdminAcknowledgeQueueWaitAnomaly records that a wait spike has been looked into
+func AdminAcknowledgeQueueWaitAnomaly(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid anomaly ID"})
+		return
+	}
+
+	var req QueueWaitAnomalyAckRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	anomaly, err := services.NewQueueFairnessService().AcknowledgeAnomaly(uint(id), utils.GetUserIDFromContext(c), req.Notes)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrQueueWaitAnomalyNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrQueueWaitAnomalyAcked):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acknowledge wait anomaly"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "Acknowledge", "QueueWaitAnomaly", anomaly.ID,
+		fmt.Sprintf("Wait spike for %s at %s acknowledged", anomaly.Category, anomaly.WindowStart.Format("2006-01-02 15:04")))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Wait anomaly acknowledged",
+		"anomaly": anomaly,
+	})
+}
+
+// AdminListQueueFairnessReports returns the weekly fairness reports, newest first
+func AdminListQueueFairnessReports(c *gin.Context) {
+	reports, err := services.NewQueueFairnessService().Reports(52)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch fairness reports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports})
+}
+
+// AdminGetQueueFairnessReport returns one weekly fairness report by its Monday
+func AdminGetQueueFairnessReport(c *gin.Context) {
+	record, content, err := services.NewQueueFairnessService().Report(c.Param("week"))
+	if err != nil {
+		if errors.Is(err, services.ErrQueueFairnessReportNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch fairness report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"report":  record,
+		"content": content,
+	})
+}
+
+// AdminGenerateQueueFairnessReport builds, or rebuilds, the fairness report for a
+// finished week and optionally emails it to trustees
+func AdminGenerateQueueFairnessReport(c *gin.Context) {
+	var req QueueFairnessReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	weekStart, err := time.Parse("2006-01-02", req.WeekStart)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "week_start must be in YYYY-MM-DD format"})
+		return
+	}
+
+	report, err := services.NewQueueFairnessService().GenerateWeeklyReport(weekStart, req.Send)
+	if err != nil {
+		if errors.Is(err, services.ErrQueueFairnessReportFuture) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate fairness report"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Generate", "QueueFairnessReport", report.ID,
+		fmt.Sprintf("Queue fairness report for week of %s generated, emailed to %d", report.WeekStart, report.EmailedTo))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Fairness report generated",
+		"report":  report,
+	})
+}
@@ -0,0 +1,56 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListRecallPool returns visitors who missed their call and are waiting to be called
+// again, longest waiting first. Pass category to see one queue.
+func ListRecallPool(c *gin.Context) {
+	entries, err := services.NewQueueRecallService().Pool(c.Query("category"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch the re-call pool"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   len(entries),
+	})
+}
+
+// RecallQueueEntry calls a visitor in the re-call pool again, for example when they
+// come back to the desk. A new grace period starts.
+func RecallQueueEntry(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid queue entry ID"})
+		return
+	}
+
+	entry, err := services.NewQueueRecallService().Recall(uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrRecallEntryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to call visitor again"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Recall", "QueueEntry", entry.ID,
+		fmt.Sprintf("Called %s again after %d missed calls", entry.Reference, entry.MissedCalls))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Visitor called again",
+		"entry":   entry,
+	})
+}
@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminListReleaseRuns lists recorded ticket releases, newest first, optionally
+// for one visit day (release_date)
+func AdminListReleaseRuns(c *gin.Context) {
+	releaseDate := c.Query("release_date")
+	if releaseDate != "" {
+		if _, err := time.Parse("2006-01-02", releaseDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release date format"})
+			return
+		}
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+		return
+	}
+
+	runs, err := services.NewReleaseRunService().List(releaseDate, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ticket releases"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"release_runs": runs,
+		"total":        len(runs),
+	})
+}
+
+// AdminGetReleaseRun returns one recorded ticket release with its full allocation
+func AdminGetReleaseRun(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release run ID"})
+		return
+	}
+
+	run, err := services.NewReleaseRunService().Get(uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrReleaseRunNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ticket release"})
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// AdminVerifyReleaseRuns checks that no recorded ticket release has been altered
+// since it was recorded
+func AdminVerifyReleaseRuns(c *gin.Context) {
+	report, err := services.NewReleaseRunService().Verify()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify ticket releases"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -1,11 +1,13 @@
 package admin
 
 import (
+	"math"
 	"net/http"
 	"time"
 
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,6 +23,8 @@ func AdminGetMetrics(c *gin.Context) {
 	helpRequestMetrics := getDashboardHelpRequestMetrics(startDate)
 	volunteerMetrics := getDashboardVolunteerMetrics(startDate)
 	userMetrics := getDashboardUserMetrics()
+	impact, _ := services.NewServiceValueService().Report(startDate, time.Now())
+	feedbackMetrics := getDashboardFeedbackMetrics(startDate)
 
 	// Construct response matching frontend expectations
 	response := gin.H{
@@ -52,6 +56,10 @@ func AdminGetMetrics(c *gin.Context) {
 			"recent":               []gin.H{},
 		},
 
+		"impact": impact,
+
+		"feedback": feedbackMetrics,
+
 		"response_times": gin.H{
 			"average":  "24h",
 			"urgent":   "4h",
@@ -81,6 +89,10 @@ func AdminGetDonationReports(c *gin.Context) {
 	db.Model(&models.Donation{}).Where("deleted_at IS NULL").Count(&totalDonations)
 	db.Model(&models.Donation{}).Where("deleted_at IS NULL").Select("COALESCE(SUM(amount), 0)").Scan(&totalAmount)
 
+	// Refunds and lost chargebacks
+	var totalRefunded float64
+	db.Model(&models.Donation{}).Where("deleted_at IS NULL").Select("COALESCE(SUM(refunded_amount), 0)").Scan(&totalRefunded)
+
 	// This month's donations
 	db.Model(&models.Donation{}).Where("deleted_at IS NULL AND created_at >= ?", startOfMonth).Count(&monthlyDonations)
 	db.Model(&models.Donation{}).Where("deleted_at IS NULL AND created_at >= ?", startOfMonth).Select("COALESCE(SUM(amount), 0)").Scan(&monthlyAmount)
@@ -139,6 +151,8 @@ func AdminGetDonationReports(c *gin.Context) {
 		"summary": gin.H{
 			"totalDonations":   totalDonations,
 			"totalAmount":      totalAmount,
+			"totalRefunded":    totalRefunded,
+			"netAmount":        totalAmount - totalRefunded,
 			"monthlyDonations": monthlyDonations,
 			"monthlyAmount":    monthlyAmount,
 			"donationGrowth":   donationGrowth,
@@ -225,6 +239,9 @@ func AdminGetHelpRequestReports(c *gin.Context) {
 		})
 	}
 
+	// Estimated value of visits delivered this month
+	monthlyImpact, _ := services.NewServiceValueService().Report(startOfMonth, now)
+
 	// Calculate growth rate
 	requestGrowth := 0.0
 	if lastMonthRequests > 0 {
@@ -246,9 +263,10 @@ func AdminGetHelpRequestReports(c *gin.Context) {
 			"requestGrowth":     requestGrowth,
 			"completionRate":    completionRate,
 		},
-		"byCategory": requestsByCategory,
-		"byStatus":   requestsByStatus,
-		"trends":     monthlyTrends,
+		"byCategory":    requestsByCategory,
+		"byStatus":      requestsByStatus,
+		"trends":        monthlyTrends,
+		"monthlyImpact": monthlyImpact,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -332,13 +350,16 @@ func AdminGenerateCustomReport(c *gin.Context) {
 		// Service efficiency report
 		var totalRequests int64
 		var completedRequests int64
-		var avgProcessingTime float64
 
 		db.Model(&models.HelpRequest{}).Where("deleted_at IS NULL AND created_at >= ? AND created_at <= ?", request.DateFrom, request.DateTo).Count(&totalRequests)
 		db.Model(&models.HelpRequest{}).Where("deleted_at IS NULL AND status = ? AND created_at >= ? AND created_at <= ?", "Approved", request.DateFrom, request.DateTo).Count(&completedRequests)
 
-		// Calculate average processing time (simplified)
-		avgProcessingTime = 24.0 // Default 24 hours
+		// Average hours from submission to approval for requests approved in the range
+		approval, err := services.NewRequestSLAService().Approval(request.DateFrom, request.DateTo)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate processing times"})
+			return
+		}
 
 		reportData = gin.H{
 			"totalRequests":     totalRequests,
@@ -349,7 +370,30 @@ func AdminGenerateCustomReport(c *gin.Context) {
 				}
 				return 0
 			}(),
-			"avgProcessingTime": avgProcessingTime,
+			"avgProcessingTime": approval.AverageHours,
+			"slaCompliance":     approval.Compliance,
+			"dateRange": gin.H{
+				"from": request.DateFrom.Format("2006-01-02"),
+				"to":   request.DateTo.Format("2006-01-02"),
+			},
+		}
+
+	case "impact":
+		// Estimated value of services delivered, from the configured unit costs
+		impact, err := services.NewServiceValueService().Report(request.DateFrom, request.DateTo)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate impact report"})
+			return
+		}
+
+		reportData = gin.H{
+			"totalValue":    impact.TotalValue,
+			"currency":      impact.Currency,
+			"deliveries":    impact.Deliveries,
+			"peopleReached": impact.PeopleReached,
+			"unpriced":      impact.Unpriced,
+			"byCategory":    impact.ByCategory,
+			"monthly":       impact.Monthly,
 			"dateRange": gin.H{
 				"from": request.DateFrom.Format("2006-01-02"),
 				"to":   request.DateTo.Format("2006-01-02"),
@@ -453,13 +497,21 @@ func AdminGetFeedbackReports(c *gin.Context) {
 		responseRate = float64(respondedCount) / float64(totalFeedback) * 100
 	}
 
+	// Share of visitors invited after a visit who gave feedback this month
+	surveys, err := services.NewFeedbackSurveyService().ResponseStats(startOfMonth, now)
+	if err != nil {
+		surveys = &services.SurveyResponseStats{}
+	}
+
 	response := gin.H{
 		"summary": gin.H{
-			"totalFeedback":   totalFeedback,
-			"avgRating":       avgRating,
-			"monthlyFeedback": monthlyFeedback,
-			"feedbackGrowth":  feedbackGrowth,
-			"responseRate":    responseRate,
+			"totalFeedback":      totalFeedback,
+			"avgRating":          avgRating,
+			"monthlyFeedback":    monthlyFeedback,
+			"feedbackGrowth":     feedbackGrowth,
+			"responseRate":       responseRate,
+			"surveysSent":        surveys.Invited,
+			"surveyResponseRate": surveys.ResponseRate,
 		},
 		"byCategory": feedbackByCategory,
 		"byRating":   feedbackByRating,
@@ -761,6 +813,33 @@ func getDashboardVolunteerMetrics(startDate time.Time) map[string]interface{} {
 	}
 }
 
+// getDashboardFeedbackMetrics returns visit feedback totals and the share of visitors
+// invited after checking out who gave feedback
+func getDashboardFeedbackMetrics(startDate time.Time) map[string]interface{} {
+	var totalFeedback, priorityItems int64
+	var averageRating float64
+	db.GetDB().Model(&models.VisitFeedback{}).Where("created_at >= ?", startDate).Count(&totalFeedback)
+	db.GetDB().Model(&models.VisitFeedback{}).Where("created_at >= ?", startDate).
+		Select("COALESCE(AVG(overall_rating), 0)").Scan(&averageRating)
+	// Low ratings are the feedback flagged for follow-up
+	db.GetDB().Model(&models.VisitFeedback{}).Where("created_at >= ? AND overall_rating <= ?", startDate, 2).
+		Count(&priorityItems)
+
+	surveys, err := services.NewFeedbackSurveyService().ResponseStats(startDate, time.Now())
+	if err != nil {
+		surveys = &services.SurveyResponseStats{}
+	}
+
+	return map[string]interface{}{
+		"totalFeedback":   totalFeedback,
+		"averageRating":   math.Round(averageRating*10) / 10,
+		"responseRate":    surveys.ResponseRate,
+		"surveysSent":     surveys.Invited,
+		"surveyResponses": surveys.Responded,
+		"priorityItems":   priorityItems,
+	}
+}
+
 // getDashboardUserMetrics retrieves user metrics for dashboard
 func getDashboardUserMetrics() map[string]interface{} {
 	var totalUsers int64
@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SLATargetsRequest sets the help request SLA targets
+type SLATargetsRequest struct {
+	ApprovalHours float64 `json:"approval_hours" binding:"required"`
+	TicketHours   float64 `json:"ticket_hours" binding:"required"`
+	AlertPercent  float64 `json:"alert_percent" binding:"required"`
+}
+
+// slaDateRange reads start_date and end_date (YYYY-MM-DD), defaulting to the last
+// `defaultDays` days
+func slaDateRange(c *gin.Context, defaultDays int) (time.Time, time.Time, bool) {
+	end := time.Now().Truncate(24 * time.Hour)
+	start := end.AddDate(0, 0, -defaultDays+1)
+	if value := c.Query("start_date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be in YYYY-MM-DD format"})
+			return start, end, false
+		}
+		start = parsed
+	}
+	if value := c.Query("end_date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be in YYYY-MM-DD format"})
+			return start, end, false
+		}
+		end = parsed
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return start, end, false
+	}
+	return start, end, true
+}
+
+// AdminGetRequestSLA returns time to approval and time to ticket for help requests
+// over a date range (default the last 30 days) against the SLA targets
+func AdminGetRequestSLA(c *gin.Context) {
+	start, end, ok := slaDateRange(c, 30)
+	if !ok {
+		return
+	}
+
+	summary, err := services.NewRequestSLAService().Summary(start, end, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate SLA metrics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sla": summary})
+}
+
+// AdminGetRequestSLATrend returns SLA metrics per day, week (default) or month over a
+// date range (default the last 12 weeks)
+func AdminGetRequestSLATrend(c *gin.Context) {
+	start, end, ok := slaDateRange(c, 84)
+	if !ok {
+		return
+	}
+	interval := c.DefaultQuery("interval", "week")
+	if interval != "day" && interval != "week" && interval != "month" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be day, week or month"})
+		return
+	}
+	if interval == "day" && end.Sub(start) > 366*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Daily trends are limited to one year"})
+		return
+	}
+
+	slaService := services.NewRequestSLAService()
+	trend, err := slaService.Trend(start, end, interval)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate SLA trend"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"interval": interval,
+		"targets":  slaService.Targets(),
+		"trend":    trend,
+	})
+}
+
+// AdminUpdateRequestSLATargets sets the help request SLA targets and alert threshold
+func AdminUpdateRequestSLATargets(c *gin.Context) {
+	var req SLATargetsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slaService := services.NewRequestSLAService()
+	previous := slaService.Targets()
+	if err := slaService.SetTargets(req.ApprovalHours, req.TicketHours, req.AlertPercent, utils.GetUserIDFromContext(c)); err != nil {
+		if errors.Is(err, services.ErrInvalidSLATargets) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update SLA targets"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "SystemConfig", 0,
+		fmt.Sprintf("Help request SLA targets changed from %.0fh approval, %.0fh ticket, %.0f%% alert to %.0fh, %.0fh, %.0f%%",
+			previous.ApprovalHours, previous.TicketHours, previous.AlertPercent, req.ApprovalHours, req.TicketHours, req.AlertPercent))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "SLA targets updated",
+		"targets": slaService.Targets(),
+	})
+}
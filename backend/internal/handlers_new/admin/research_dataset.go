@@ -0,0 +1,130 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminListResearchDatasets returns the most recent research datasets
+func AdminListResearchDatasets(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	exports, err := services.NewResearchDatasetService().List(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch research datasets"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"datasets": exports,
+		"total":    len(exports),
+	})
+}
+
+// AdminGenerateResearchDataset builds a research dataset now rather than waiting for
+// the weekly job. weeks defaults to the configured period.
+func AdminGenerateResearchDataset(c *gin.Context) {
+	var req struct {
+		Weeks int `json:"weeks"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	export, err := services.NewResearchDatasetService().Generate(req.Weeks, &userID, time.Now())
+	if errors.Is(err, services.ErrInvalidResearchRange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate the research dataset"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Generate", "ResearchDatasetExport", export.ID,
+		fmt.Sprintf("Generated a research dataset for %s to %s: %d rows, %d requests suppressed",
+			export.PeriodStart.Format("2006-01-02"), export.PeriodEnd.Format("2006-01-02"), export.Rows, export.SuppressedRecords))
+	c.JSON(http.StatusCreated, export)
+}
+
+// AdminGetResearchDatasetSchema documents the research dataset's columns and how it
+// is anonymised, for sharing alongside the file
+func AdminGetResearchDatasetSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, services.NewResearchDatasetService().Schema())
+}
+
+// AdminDownloadResearchDataset downloads a research dataset as CSV
+func AdminDownloadResearchDataset(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dataset ID"})
+		return
+	}
+
+	content, name, err := services.NewResearchDatasetService().Download(uint(id))
+	switch {
+	case errors.Is(err, services.ErrResearchDatasetNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrResearchDatasetNotReady):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch the research dataset"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Download", "ResearchDatasetExport", uint(id), "Research dataset downloaded")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", content)
+}
+
+// AdminListPostcodeWards returns the postcode to ward mappings research datasets use
+func AdminListPostcodeWards(c *gin.Context) {
+	wards, err := services.NewResearchDatasetService().Wards()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch postcode wards"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"wards": wards})
+}
+
+// AdminSetPostcodeWards replaces the postcode to ward mappings. Postcodes no mapping
+// covers are reported under the "Unmapped" ward.
+func AdminSetPostcodeWards(c *gin.Context) {
+	var req struct {
+		Wards []services.PostcodeWardInput `json:"wards" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	wards, err := services.NewResearchDatasetService().SetWards(req.Wards)
+	if errors.Is(err, services.ErrInvalidPostcodeWard) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save postcode wards"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "PostcodeWard", 0, fmt.Sprintf("Set %d postcode to ward mappings", len(wards)))
+	c.JSON(http.StatusOK, gin.H{"wards": wards})
+}
@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminListServiceClosures returns closure periods, latest first, and the one in force
+func AdminListServiceClosures(c *gin.Context) {
+	closureService := services.NewServiceClosureService()
+	closures, err := closureService.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch service closures"})
+		return
+	}
+	active, err := closureService.Active(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch service closures"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"closures": closures,
+		"active":   active,
+		"total":    len(closures),
+	})
+}
+
+// AdminCreateServiceClosure schedules a closure. While it is in force help requests,
+// messages to staff and emails get an automatic reply with the reopening date and the
+// alternatives, and are queued for when the service reopens.
+func AdminCreateServiceClosure(c *gin.Context) {
+	var req services.ServiceClosureInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	closure, err := services.NewServiceClosureService().Create(req, utils.GetUserIDFromContext(c), time.Now())
+	if serviceClosureError(c, err) {
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "ServiceClosure", closure.ID,
+		fmt.Sprintf("Scheduled closure %q from %s to %s", closure.Title,
+			closure.StartsAt.Format(time.RFC3339), closure.ReopensAt.Format(time.RFC3339)))
+	c.JSON(http.StatusCreated, closure)
+}
+
+// AdminUpdateServiceClosure changes a closure that has not ended, such as to extend it
+func AdminUpdateServiceClosure(c *gin.Context) {
+	id, ok := serviceClosureID(c)
+	if !ok {
+		return
+	}
+	var req services.ServiceClosureInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	closure, err := services.NewServiceClosureService().Update(id, req, time.Now())
+	if serviceClosureError(c, err) {
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "ServiceClosure", closure.ID,
+		fmt.Sprintf("Updated closure %q: %s to %s", closure.Title,
+			closure.StartsAt.Format(time.RFC3339), closure.ReopensAt.Format(time.RFC3339)))
+	c.JSON(http.StatusOK, closure)
+}
+
+// AdminCancelServiceClosure calls off a closure, or reopens the service early if it
+// has already started
+func AdminCancelServiceClosure(c *gin.Context) {
+	id, ok := serviceClosureID(c)
+	if !ok {
+		return
+	}
+
+	closure, err := services.NewServiceClosureService().Cancel(id, time.Now())
+	if serviceClosureError(c, err) {
+		return
+	}
+
+	message := fmt.Sprintf("Cancelled closure %q", closure.Title)
+	if closure.CancelledAt == nil {
+		message = fmt.Sprintf("Reopened early from closure %q", closure.Title)
+	}
+	utils.CreateAuditLog(c, "Cancel", "ServiceClosure", closure.ID, message)
+	c.JSON(http.StatusOK, closure)
+}
+
+// AdminGetServiceClosureQueue lists what was received during a closure
+func AdminGetServiceClosureQueue(c *gin.Context) {
+	id, ok := serviceClosureID(c)
+	if !ok {
+		return
+	}
+
+	items, err := services.NewServiceClosureService().Queue(id)
+	if serviceClosureError(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+		"total": len(items),
+	})
+}
+
+// serviceClosureID reads the closure ID from the path
+func serviceClosureID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid closure ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// serviceClosureError writes the response for a closure error. It reports whether
+// there was one.
+func serviceClosureError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, services.ErrServiceClosureNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidServiceClosure):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrServiceClosureOverlaps), errors.Is(err, services.ErrServiceClosureEnded):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save the service closure"})
+	}
+	return true
+}
@@ -0,0 +1,279 @@
+package admin
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serviceUnitCostRequest is the body for creating or updating a unit cost
+type serviceUnitCostRequest struct {
+	Category      string  `json:"category" binding:"required"`
+	UnitName      string  `json:"unit_name" binding:"required"`
+	UnitCost      float64 `json:"unit_cost"`
+	PerPersonCost float64 `json:"per_person_cost"`
+	EffectiveFrom string  `json:"effective_from"` // YYYY-MM-DD, defaults to today
+	Source        string  `json:"source"`
+}
+
+// AdminListServiceUnitCosts returns the configured unit costs, newest first within each service
+func AdminListServiceUnitCosts(c *gin.Context) {
+	query := db.DB.Model(&models.ServiceUnitCost{})
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", strings.ToLower(category))
+	}
+
+	var costs []models.ServiceUnitCost
+	if err := query.Order("category ASC, effective_from DESC").Find(&costs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch unit costs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unit_costs": costs, "currency": "GBP"})
+}
+
+// AdminCreateServiceUnitCost adds a unit cost for a service from a date. Earlier
+// figures are kept so past reports do not change.
+func AdminCreateServiceUnitCost(c *gin.Context) {
+	var req serviceUnitCostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cost := models.ServiceUnitCost{}
+	if err := applyServiceUnitCost(&cost, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID := utils.GetUserIDFromContext(c)
+	cost.CreatedBy = &userID
+
+	var existing int64
+	db.DB.Model(&models.ServiceUnitCost{}).
+		Where("category = ? AND effective_from = ?", cost.Category, cost.EffectiveFrom).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A unit cost for this service already starts on that date"})
+		return
+	}
+
+	if err := db.DB.Create(&cost).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create unit cost"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "ServiceUnitCost", cost.ID,
+		fmt.Sprintf("Unit cost for %s set to £%.2f per %s from %s", cost.Category, cost.UnitCost, cost.UnitName, cost.EffectiveFrom.Format("2006-01-02")))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Unit cost created successfully",
+		"unit_cost": cost,
+	})
+}
+
+// AdminUpdateServiceUnitCost corrects a unit cost
+func AdminUpdateServiceUnitCost(c *gin.Context) {
+	cost, ok := loadServiceUnitCost(c)
+	if !ok {
+		return
+	}
+
+	var req serviceUnitCostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applyServiceUnitCost(cost, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing int64
+	db.DB.Model(&models.ServiceUnitCost{}).
+		Where("category = ? AND effective_from = ? AND id <> ?", cost.Category, cost.EffectiveFrom, cost.ID).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A unit cost for this service already starts on that date"})
+		return
+	}
+
+	if err := db.DB.Save(cost).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update unit cost"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "ServiceUnitCost", cost.ID,
+		fmt.Sprintf("Unit cost for %s from %s updated to £%.2f", cost.Category, cost.EffectiveFrom.Format("2006-01-02"), cost.UnitCost))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Unit cost updated successfully",
+		"unit_cost": cost,
+	})
+}
+
+// AdminDeleteServiceUnitCost removes a unit cost entered by mistake
+func AdminDeleteServiceUnitCost(c *gin.Context) {
+	cost, ok := loadServiceUnitCost(c)
+	if !ok {
+		return
+	}
+
+	if err := db.DB.Delete(cost).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete unit cost"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "ServiceUnitCost", cost.ID,
+		fmt.Sprintf("Unit cost for %s from %s deleted", cost.Category, cost.EffectiveFrom.Format("2006-01-02")))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unit cost deleted"})
+}
+
+// AdminGetImpactReport returns the estimated value of services delivered in a period,
+// and the environmental impact of goods handed out, for funder reports. Pass
+// format=csv to download it.
+func AdminGetImpactReport(c *gin.Context) {
+	now := time.Now()
+	start := now.AddDate(0, -12, 0)
+	end := now
+	if value := c.Query("start_date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be in YYYY-MM-DD format"})
+			return
+		}
+		start = parsed
+	}
+	if value := c.Query("end_date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be in YYYY-MM-DD format"})
+			return
+		}
+		end = parsed
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return
+	}
+
+	report, err := services.NewServiceValueService().Report(start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate impact report"})
+		return
+	}
+
+	environmental, err := services.NewEnvironmentalImpactService().Report(start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate impact report"})
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, gin.H{"report": report, "environmental": environmental})
+		return
+	}
+
+	filename := fmt.Sprintf("impact_report_%s_%s.csv", report.StartDate, report.EndDate)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Type", "text/csv")
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"Service", "Unit", "Visits Delivered", "People Reached", "Estimated Value (GBP)"})
+	for _, entry := range report.ByCategory {
+		value := fmt.Sprintf("%.2f", entry.Value)
+		if !entry.Priced {
+			value = "No unit cost set"
+		}
+		writer.Write([]string{
+			entry.Category,
+			entry.UnitName,
+			strconv.FormatInt(entry.Deliveries, 10),
+			strconv.FormatInt(entry.People, 10),
+			value,
+		})
+	}
+	writer.Write([]string{
+		"Total",
+		"",
+		strconv.FormatInt(report.Deliveries, 10),
+		strconv.FormatInt(report.PeopleReached, 10),
+		fmt.Sprintf("%.2f", report.TotalValue),
+	})
+
+	writer.Write([]string{})
+	writer.Write([]string{"Goods Type", "Weight Handed Out (kg)", "Waste Diverted (kg)", "CO2e Avoided (kg)"})
+	for _, entry := range environmental.ByGoodsType {
+		writer.Write([]string{
+			entry.GoodsType,
+			fmt.Sprintf("%.1f", entry.WeightKg),
+			fmt.Sprintf("%.1f", entry.DivertedKg),
+			fmt.Sprintf("%.1f", entry.CO2eKg),
+		})
+	}
+	writer.Write([]string{
+		"Total",
+		fmt.Sprintf("%.1f", environmental.Total.WeightKg),
+		fmt.Sprintf("%.1f", environmental.Total.DivertedKg),
+		fmt.Sprintf("%.1f", environmental.Total.CO2eKg),
+	})
+	writer.Flush()
+}
+
+// applyServiceUnitCost validates a request and copies it onto a unit cost
+func applyServiceUnitCost(cost *models.ServiceUnitCost, req serviceUnitCostRequest) error {
+	category := strings.ToLower(strings.TrimSpace(req.Category))
+	if _, err := services.NewServiceTypeService().GetByCode(category); err != nil {
+		return fmt.Errorf("unknown service type %q", req.Category)
+	}
+	if req.UnitCost < 0 || req.PerPersonCost < 0 {
+		return fmt.Errorf("costs cannot be negative")
+	}
+	if req.UnitCost == 0 && req.PerPersonCost == 0 {
+		return fmt.Errorf("unit_cost or per_person_cost is required")
+	}
+
+	if req.EffectiveFrom == "" {
+		req.EffectiveFrom = time.Now().Format("2006-01-02")
+	}
+	effectiveFrom, err := time.Parse("2006-01-02", req.EffectiveFrom)
+	if err != nil {
+		return fmt.Errorf("effective_from must be in YYYY-MM-DD format")
+	}
+
+	cost.Category = category
+	cost.UnitName = strings.TrimSpace(req.UnitName)
+	cost.UnitCost = req.UnitCost
+	cost.PerPersonCost = req.PerPersonCost
+	cost.EffectiveFrom = effectiveFrom
+	cost.Source = strings.TrimSpace(req.Source)
+	return nil
+}
+
+// loadServiceUnitCost loads the unit cost identified by the :id path parameter
+func loadServiceUnitCost(c *gin.Context) (*models.ServiceUnitCost, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unit cost ID"})
+		return nil, false
+	}
+
+	var cost models.ServiceUnitCost
+	if err := db.DB.First(&cost, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unit cost not found"})
+		return nil, false
+	}
+
+	return &cost, true
+}
@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceInterruptionRequest cancels a service day and rebooks its visitors
+type ServiceInterruptionRequest struct {
+	Date       string `json:"date" binding:"required"` // YYYY-MM-DD
+	Reason     string `json:"reason" binding:"required"`
+	SearchDays int    `json:"search_days"` // Days ahead to look for new dates, default 14
+	Notify     *bool  `json:"notify"`      // Defaults to true
+	DryRun     bool   `json:"dry_run"`     // Preview the rebooking without changing anything
+}
+
+// AdminRebookServiceInterruption cancels every ticket for a day that has to close,
+// rebooks the affected visitors on the next days with space in priority order,
+// notifies them and reports anyone who could not be rebooked
+func AdminRebookServiceInterruption(c *gin.Context) {
+	var req ServiceInterruptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("dry_run") == "true" {
+		req.DryRun = true
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+	if req.SearchDays < 0 || req.SearchDays > 60 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "search_days must be between 1 and 60"})
+		return
+	}
+
+	result, err := services.NewServiceInterruptionService().Rebook(services.ServiceInterruption{
+		Date:       date,
+		Reason:     req.Reason,
+		SearchDays: req.SearchDays,
+		Notify:     req.Notify == nil || *req.Notify,
+		DryRun:     req.DryRun,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rebook visitors"})
+		return
+	}
+
+	if req.DryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Dry run: no bookings were changed",
+			"result":  result,
+		})
+		return
+	}
+
+	utils.CreateAuditLog(c, "ServiceInterruption", "VisitCapacity", 0,
+		fmt.Sprintf("%s closed (%s): %d rebooked, %d could not be rebooked",
+			result.Date, req.Reason, len(result.Rebooked), len(result.NotRebooked)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("%d of %d visitors rebooked", len(result.Rebooked), result.Affected),
+		"result":  result,
+	})
+}
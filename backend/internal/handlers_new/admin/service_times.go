@@ -0,0 +1,141 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetLiveServiceTimes returns visitors currently waiting or being served with their
+// time against the service type's targets
+func GetLiveServiceTimes(c *gin.Context) {
+	live, err := services.NewServiceTimeService().LiveServiceTimes(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch live service times"})
+		return
+	}
+
+	overTarget := 0
+	for _, visit := range live {
+		if visit.Level != "ok" {
+			overTarget++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"visits":      live,
+		"total":       len(live),
+		"over_target": overTarget,
+	})
+}
+
+// GetServiceTimeStats returns average wait and service times per service type for
+// completed visits. Defaults to the last 30 days.
+func GetServiceTimeStats(c *gin.Context) {
+	end := time.Now()
+	start := end.AddDate(0, 0, -30)
+	if v := c.Query("start_date"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD"})
+			return
+		}
+		start = parsed
+	}
+	if v := c.Query("end_date"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD"})
+			return
+		}
+		end = parsed.AddDate(0, 0, 1)
+	}
+	if !end.After(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be on or after start_date"})
+		return
+	}
+
+	stats, err := services.NewServiceTimeService().Stats(start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate service times"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stats":      stats,
+		"start_date": start.Format("2006-01-02"),
+		"end_date":   end.AddDate(0, 0, -1).Format("2006-01-02"),
+	})
+}
+
+// ListServiceTimeAlerts returns recent service time alerts. Pass status=open for
+// alerts nobody has acknowledged.
+func ListServiceTimeAlerts(c *gin.Context) {
+	query := db.DB.Model(&models.ServiceTimeAlert{})
+	if c.Query("status") == "open" {
+		query = query.Where("acknowledged_at IS NULL")
+	}
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+
+	var alerts []models.ServiceTimeAlert
+	if err := query.Where("created_at >= ?", time.Now().AddDate(0, 0, -7)).
+		Order("created_at DESC").
+		Limit(200).
+		Find(&alerts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch service time alerts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alerts": alerts,
+		"total":  len(alerts),
+	})
+}
+
+// AcknowledgeServiceTimeAlert marks a service time alert as dealt with
+func AcknowledgeServiceTimeAlert(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	var alert models.ServiceTimeAlert
+	if err := db.DB.First(&alert, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alert"})
+		}
+		return
+	}
+	if alert.AcknowledgedAt != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Alert already acknowledged", "alert": alert})
+		return
+	}
+
+	if err := services.NewServiceTimeService().AcknowledgeAlert(&alert, utils.GetUserIDFromContext(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acknowledge alert"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Acknowledge", "ServiceTimeAlert", alert.ID,
+		fmt.Sprintf("Service time alert for %s acknowledged", alert.Reference))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alert acknowledged",
+		"alert":   alert,
+	})
+}
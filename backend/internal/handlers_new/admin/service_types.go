@@ -0,0 +1,253 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AdminListServiceTypes returns all service types, including inactive ones
+func AdminListServiceTypes(c *gin.Context) {
+	var serviceTypes []models.ServiceType
+	if err := db.DB.Order("sort_order ASC, name ASC").Find(&serviceTypes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch service types"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"service_types": serviceTypes})
+}
+
+// AdminCreateServiceType defines a new visitor service
+func AdminCreateServiceType(c *gin.Context) {
+	serviceType := models.ServiceType{
+		IsActive:             true,
+		DailyCapacity:        20,
+		VisitIntervalDays:    7,
+		OperatingDays:        "Tuesday,Wednesday,Thursday",
+		OpeningTime:          "10:30",
+		ClosingTime:          "14:30",
+		SlotIntervalMinutes:  10,
+		MaxVisitorsPerSlot:   2,
+		TicketPrefix:         "TKT",
+		TicketValidityHours:  24,
+		TargetWaitMinutes:    20,
+		TargetServiceMinutes: 15,
+	}
+	if err := c.ShouldBindJSON(&serviceType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	serviceType.ID = 0
+
+	serviceTypeService := services.NewServiceTypeService()
+	if err := serviceTypeService.Validate(&serviceType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if serviceType.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	if _, err := serviceTypeService.GetByCode(serviceType.Code); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "A service type with this code already exists"})
+		return
+	}
+
+	if err := db.DB.Create(&serviceType).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create service type"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "ServiceType", serviceType.ID,
+		fmt.Sprintf("Service type %s (%s) created", serviceType.Name, serviceType.Code))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Service type created successfully",
+		"service_type": serviceType,
+	})
+}
+
+// AdminUpdateServiceType updates a service type's capacity rules and ticket format.
+// The code cannot be changed because existing help requests refer to it.
+func AdminUpdateServiceType(c *gin.Context) {
+	serviceType, ok := loadServiceType(c)
+	if !ok {
+		return
+	}
+
+	id, code := serviceType.ID, serviceType.Code
+	if err := c.ShouldBindJSON(serviceType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	serviceType.ID, serviceType.Code = id, code
+
+	if err := services.NewServiceTypeService().Validate(serviceType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.DB.Save(serviceType).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update service type"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "ServiceType", serviceType.ID,
+		fmt.Sprintf("Service type %s updated", serviceType.Code))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Service type updated successfully",
+		"service_type": serviceType,
+	})
+}
+
+// AdminDeactivateServiceType stops visitors requesting a service without deleting its history
+func AdminDeactivateServiceType(c *gin.Context) {
+	serviceType, ok := loadServiceType(c)
+	if !ok {
+		return
+	}
+
+	if err := db.DB.Model(serviceType).Update("is_active", false).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate service type"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Deactivate", "ServiceType", serviceType.ID,
+		fmt.Sprintf("Service type %s deactivated", serviceType.Code))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Service type deactivated"})
+}
+
+// AdminGetServiceTypeAnalytics returns demand and ticket statistics for a service type
+func AdminGetServiceTypeAnalytics(c *gin.Context) {
+	serviceType, ok := loadServiceType(c)
+	if !ok {
+		return
+	}
+
+	end := time.Now()
+	start := end.AddDate(0, 0, -30)
+	if v := c.Query("start_date"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD"})
+			return
+		}
+		start = parsed
+	}
+	if v := c.Query("end_date"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD"})
+			return
+		}
+		end = parsed
+	}
+
+	analytics, err := services.NewServiceTypeService().Analytics(serviceType, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate analytics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+// ServiceTypeFormRequest represents the extra questions asked for a service type
+type ServiceTypeFormRequest struct {
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Fields      models.FormFields `json:"fields"`
+	IsActive    *bool             `json:"is_active"`
+}
+
+// AdminGetServiceTypeForm returns the extra questions asked when visitors request
+// a service type
+func AdminGetServiceTypeForm(c *gin.Context) {
+	serviceType, ok := loadServiceType(c)
+	if !ok {
+		return
+	}
+
+	form, err := services.NewFormDefinitionService().ForServiceType(serviceType.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusOK, gin.H{"form": nil, "field_types": models.FormFieldTypes})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch form"})
+		}
+		return
+	}
+
+	var answered int64
+	db.DB.Model(&models.HelpRequest{}).
+		Where("LOWER(category) = ? AND form_version > 0", serviceType.Code).
+		Count(&answered)
+
+	c.JSON(http.StatusOK, gin.H{
+		"form":           form,
+		"field_types":    models.FormFieldTypes,
+		"requests_using": answered,
+	})
+}
+
+// AdminSaveServiceTypeForm sets the extra questions for a service type. Existing
+// answers keep the labels they were given under, so questions can be changed freely.
+func AdminSaveServiceTypeForm(c *gin.Context) {
+	serviceType, ok := loadServiceType(c)
+	if !ok {
+		return
+	}
+
+	var req ServiceTypeFormRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	form, err := services.NewFormDefinitionService().Save(serviceType.ID, req.Title, req.Description, req.Fields, isActive, utils.GetUserIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.CreateAuditLog(c, "UpdateForm", "ServiceType", serviceType.ID,
+		fmt.Sprintf("Form for service type %s saved as version %d with %d questions", serviceType.Code, form.Version, len(form.Fields)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Form saved",
+		"form":    form,
+	})
+}
+
+// loadServiceType loads the service type identified by the :id path parameter
+func loadServiceType(c *gin.Context) (*models.ServiceType, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service type ID"})
+		return nil, false
+	}
+
+	var serviceType models.ServiceType
+	if err := db.DB.First(&serviceType, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service type not found"})
+		return nil, false
+	}
+
+	return &serviceType, true
+}
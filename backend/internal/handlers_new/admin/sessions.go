@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForceLogoutRequest records why an admin ended a user's sessions
+type ForceLogoutRequest struct {
+	Reason string `json:"reason"`
+}
+
+// AdminForceLogout signs a user out everywhere. Every token they hold stops working
+// straight away rather than when it expires.
+func AdminForceLogout(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req ForceLogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	adminID := utils.GetUserIDFromContext(c)
+	ended, err := services.NewSessionService().EndAllSessions(uint(id), fmt.Sprintf("admin:%d", adminID))
+	if err != nil {
+		if errors.Is(err, services.ErrSessionUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end user sessions"})
+		return
+	}
+
+	description := "Forced logout of all sessions"
+	if req.Reason != "" {
+		description += ". Reason: " + req.Reason
+	}
+	utils.CreateAuditLog(c, "ForceLogout", "User", ended.UserID, description)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "User has been signed out everywhere",
+		"sessions": ended,
+	})
+}
@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BulkShiftCancelRequest selects the shifts to call off
+type BulkShiftCancelRequest struct {
+	From     string `json:"from" binding:"required"` // YYYY-MM-DD
+	To       string `json:"to" binding:"required"`   // YYYY-MM-DD, inclusive
+	Location string `json:"location"`
+	Reason   string `json:"reason" binding:"required"`
+	DryRun   bool   `json:"dry_run"` // Preview who would be affected without cancelling
+}
+
+// AdminBulkCancelShifts cancels every upcoming shift in a date range, optionally at
+// one location. Volunteers booked on them are released and told, with a link to
+// book another shift. Use dry_run to preview the shifts and volunteers affected.
+func AdminBulkCancelShifts(c *gin.Context) {
+	var req BulkShiftCancelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	from, err := time.ParseInLocation("2006-01-02", req.From, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+		return
+	}
+	to, err := time.ParseInLocation("2006-01-02", req.To, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+		return
+	}
+
+	filter := services.BulkShiftCancelFilter{From: from, To: to, Location: req.Location}
+	result, err := services.NewShiftCancellationService().CancelShifts(filter, req.Reason, utils.GetUserIDFromContext(c), req.DryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrBulkCancelRange):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrBulkCancelNoShifts):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel shifts"})
+		}
+		return
+	}
+
+	if req.DryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"message":      fmt.Sprintf("%d shifts would be cancelled", len(result.Shifts)),
+			"cancellation": result,
+		})
+		return
+	}
+
+	location := req.Location
+	if location == "" {
+		location = "all locations"
+	}
+	utils.CreateAuditLog(c, "BulkCancel", "Shift", 0,
+		fmt.Sprintf("Cancelled %d shifts at %s from %s to %s, releasing %d volunteers (%d notified). Reason: %s",
+			len(result.Shifts), location, req.From, req.To, len(result.Volunteers), result.VolunteersNotified, req.Reason))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      fmt.Sprintf("%d shifts cancelled and %d volunteers notified", len(result.Shifts), result.VolunteersNotified),
+		"cancellation": result,
+	})
+}
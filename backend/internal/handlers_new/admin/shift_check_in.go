@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetShiftCheckInCode returns the current check-in code for the coordinator's screen,
+// which should refresh it when it expires
+func GetShiftCheckInCode(c *gin.Context) {
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+
+	code, err := services.NewShiftCodeService().Current(uint(shiftID), time.Now())
+	if err != nil {
+		shiftCheckInError(c, err, "Failed to get check-in code")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, code)
+}
+
+// ManualShiftCheckInRequest names the volunteer a coordinator is checking in
+type ManualShiftCheckInRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// CheckInVolunteerToShift lets a coordinator check in a volunteer who cannot use the
+// code, clearing any lockout from wrong codes
+func CheckInVolunteerToShift(c *gin.Context) {
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+	var req ManualShiftCheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assignment, err := services.NewShiftCodeService().ManualCheckIn(uint(shiftID), req.UserID, time.Now())
+	if err != nil {
+		shiftCheckInError(c, err, "Failed to check in volunteer")
+		return
+	}
+
+	utils.CreateAuditLog(c, "CheckIn", "ShiftAssignment", assignment.ID,
+		fmt.Sprintf("Checked in volunteer %d to shift %d by hand", req.UserID, assignment.ShiftID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Volunteer checked in",
+		"assignment": assignment,
+	})
+}
+
+// shiftCheckInError maps coordinator check-in errors to responses
+func shiftCheckInError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrShiftCodeShiftNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrShiftCodeNotAssigned):
+		c.JSON(http.StatusNotFound, gin.H{"error": "That volunteer is not signed up for this shift"})
+	case errors.Is(err, services.ErrShiftCodeCheckedIn):
+		c.JSON(http.StatusConflict, gin.H{"error": "That volunteer has already checked in to this shift"})
+	case errors.Is(err, services.ErrShiftCodeCancelled):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrShiftCodeKey):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Shift codes are not available"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
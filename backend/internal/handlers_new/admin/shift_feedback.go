@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AdminListShiftFeedback returns volunteer feedback on shifts, newest first. Filter by
+// shift_id, location, role or issue.
+func AdminListShiftFeedback(c *gin.Context) {
+	limit := 100
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+
+	query := db.DB.Model(&models.ShiftFeedback{}).
+		Joins("JOIN shifts ON shifts.id = shift_feedback.shift_id").
+		Preload("Shift").
+		Preload("User", func(tx *gorm.DB) *gorm.DB {
+			return tx.Select("id", "first_name", "last_name")
+		})
+	if v := c.Query("shift_id"); v != "" {
+		query = query.Where("shift_feedback.shift_id = ?", v)
+	}
+	if v := c.Query("location"); v != "" {
+		query = query.Where("shifts.location = ?", v)
+	}
+	if v := c.Query("role"); v != "" {
+		query = query.Where("shifts.role = ?", v)
+	}
+	if v := c.Query("issue"); v != "" {
+		query = query.Where("(',' || shift_feedback.issues || ',') LIKE ?", "%,"+v+",%")
+	}
+
+	var feedback []models.ShiftFeedback
+	if err := query.Order("shift_feedback.created_at DESC").Limit(limit).Find(&feedback).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shift feedback"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"feedback": feedback,
+		"total":    len(feedback),
+	})
+}
+
+// AdminGetShiftQualityReport summarises volunteer feedback by location and role and
+// lists problems reported on several shifts. Defaults to the last 30 days.
+func AdminGetShiftQualityReport(c *gin.Context) {
+	today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	from, to := today.AddDate(0, 0, -29), today.AddDate(0, 0, 1)
+	if v := c.Query("from"); v != "" {
+		date, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		from = date
+	}
+	if v := c.Query("to"); v != "" {
+		date, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		to = date.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be on or before to"})
+		return
+	}
+
+	report, err := services.NewShiftFeedbackService().Report(from, to, c.Query("location"), c.Query("role"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build shift quality report"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,216 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShiftSeriesRequest creates a recurring shift. Give until or occurrences to say
+// when the series ends.
+type ShiftSeriesRequest struct {
+	Name           string `json:"name"`
+	Frequency      string `json:"frequency" binding:"required,oneof=daily weekly fortnightly"`
+	FirstDate      string `json:"first_date" binding:"required"` // YYYY-MM-DD
+	Until          string `json:"until"`                         // YYYY-MM-DD, inclusive
+	Occurrences    int    `json:"occurrences"`
+	StartTime      string `json:"start_time" binding:"required"` // HH:MM
+	EndTime        string `json:"end_time" binding:"required"`   // HH:MM
+	Location       string `json:"location" binding:"required"`
+	Description    string `json:"description" binding:"required"`
+	Role           string `json:"role"`
+	RequiredSkills string `json:"required_skills"`
+	RoleLevel      string `json:"role_level"`
+	MaxVolunteers  int    `json:"max_volunteers"`
+	Type           string `json:"type"`
+}
+
+// ShiftEditRequest changes a shift, the rest of its series or the whole series.
+// Fields left out are not changed.
+type ShiftEditRequest struct {
+	Scope          string  `json:"scope" binding:"required,oneof=this future all"`
+	StartTime      *string `json:"start_time"` // HH:MM
+	EndTime        *string `json:"end_time"`   // HH:MM
+	Location       *string `json:"location"`
+	Description    *string `json:"description"`
+	Role           *string `json:"role"`
+	RequiredSkills *string `json:"required_skills"`
+	MaxVolunteers  *int    `json:"max_volunteers"`
+	Reason         string  `json:"reason"`
+}
+
+// AdminCreateShiftSeries creates a recurring shift and all of its shifts
+func AdminCreateShiftSeries(c *gin.Context) {
+	var req ShiftSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	firstDate, err := time.Parse("2006-01-02", req.FirstDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid first_date, expected YYYY-MM-DD"})
+		return
+	}
+	if firstDate.Before(time.Now().Truncate(24 * time.Hour)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot create shifts for past dates"})
+		return
+	}
+	var until *time.Time
+	if req.Until != "" {
+		parsed, err := time.Parse("2006-01-02", req.Until)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid until, expected YYYY-MM-DD"})
+			return
+		}
+		until = &parsed
+	}
+	startTime, err := time.Parse("15:04", req.StartTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time, expected HH:MM"})
+		return
+	}
+	endTime, err := time.Parse("15:04", req.EndTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time, expected HH:MM"})
+		return
+	}
+
+	maxVolunteers := req.MaxVolunteers
+	if maxVolunteers <= 0 {
+		maxVolunteers = 1
+	}
+	shiftType := req.Type
+	if shiftType != "fixed" && shiftType != "flexible" && shiftType != "open" {
+		shiftType = "fixed"
+	}
+
+	series, err := services.NewShiftSeriesService().Create(services.ShiftSeriesInput{
+		Name:        req.Name,
+		Frequency:   req.Frequency,
+		FirstDate:   firstDate,
+		Until:       until,
+		Occurrences: req.Occurrences,
+		Template: models.Shift{
+			StartTime:      startTime,
+			EndTime:        endTime,
+			Location:       strings.TrimSpace(req.Location),
+			Description:    strings.TrimSpace(req.Description),
+			Role:           strings.TrimSpace(req.Role),
+			RequiredSkills: strings.TrimSpace(req.RequiredSkills),
+			RoleLevel:      req.RoleLevel,
+			MaxVolunteers:  maxVolunteers,
+			Type:           shiftType,
+		},
+	}, utils.GetUserIDFromContext(c))
+	if err != nil {
+		shiftSeriesError(c, err, "Failed to create shift series")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "ShiftSeries", series.ID,
+		fmt.Sprintf("Created %s shift series %q with %d shifts", series.Frequency, series.Name, len(series.Shifts)))
+	c.JSON(http.StatusCreated, series)
+}
+
+// AdminGetShiftSeries returns a shift series and its shifts
+func AdminGetShiftSeries(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+	series, err := services.NewShiftSeriesService().Get(uint(id))
+	if err != nil {
+		shiftSeriesError(c, err, "Failed to fetch shift series")
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+// AdminEditShiftInSeries changes this shift only, this and later shifts in its
+// series, or every upcoming shift in the series. Volunteers booked on changed shifts
+// are notified, and asked to re-confirm when the time or place changed.
+func AdminEditShiftInSeries(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+	var req ShiftEditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	edit := services.ShiftEdit{
+		Scope:          req.Scope,
+		Location:       req.Location,
+		Description:    req.Description,
+		Role:           req.Role,
+		RequiredSkills: req.RequiredSkills,
+		MaxVolunteers:  req.MaxVolunteers,
+		Reason:         strings.TrimSpace(req.Reason),
+	}
+	if edit.StartTime, err = parseShiftClock(req.StartTime); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time, expected HH:MM"})
+		return
+	}
+	if edit.EndTime, err = parseShiftClock(req.EndTime); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time, expected HH:MM"})
+		return
+	}
+
+	result, err := services.NewShiftSeriesService().Edit(uint(id), edit, utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		shiftSeriesError(c, err, "Failed to update shifts")
+		return
+	}
+	if len(result.ShiftIDs) == 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "Nothing changed", "result": result})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "Shift", uint(id),
+		fmt.Sprintf("Changed %s on %d shifts (scope %s); %d volunteers asked to re-confirm",
+			strings.Join(result.Changed, ", "), len(result.ShiftIDs), result.Scope, result.ReconfirmRequested))
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("%d shifts updated and %d volunteers notified", len(result.ShiftIDs), result.VolunteersNotified),
+		"result":  result,
+	})
+}
+
+// parseShiftClock parses an optional HH:MM time of day
+func parseShiftClock(value *string) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	parsed, err := time.Parse("15:04", *value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// shiftSeriesError maps shift series errors to responses
+func shiftSeriesError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrShiftSeriesNotFound), errors.Is(err, services.ErrShiftEditNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrShiftSeriesInvalid), errors.Is(err, services.ErrShiftEditInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrShiftNotInSeries):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
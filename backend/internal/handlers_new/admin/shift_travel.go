@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListShiftTravelTimes returns the configured travel times between shift locations,
+// the default used for other pairs and the locations shifts use
+func ListShiftTravelTimes(c *gin.Context) {
+	var travelTimes []models.LocationTravelTime
+	if err := db.DB.Order("from_location ASC, to_location ASC").Find(&travelTimes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch travel times"})
+		return
+	}
+
+	var locations []string
+	db.DB.Model(&models.Shift{}).
+		Where("location <> ''").
+		Distinct("location").
+		Order("location ASC").
+		Pluck("location", &locations)
+
+	c.JSON(http.StatusOK, gin.H{
+		"travel_times":    travelTimes,
+		"default_minutes": services.NewShiftClashService().DefaultBuffer(),
+		"locations":       locations,
+	})
+}
+
+// SetShiftTravelTime sets the travel time between two shift locations. The time
+// applies in both directions.
+func SetShiftTravelTime(c *gin.Context) {
+	var req struct {
+		FromLocation string `json:"from_location" binding:"required"`
+		ToLocation   string `json:"to_location" binding:"required"`
+		Minutes      int    `json:"minutes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from, to := models.LocationPair(req.FromLocation, req.ToLocation)
+	if from == "" || from == to {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Give two different locations"})
+		return
+	}
+	if req.Minutes < 0 || req.Minutes > 24*60 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "minutes must be between 0 and 1440"})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	var travel models.LocationTravelTime
+	err := db.DB.Where("from_location = ? AND to_location = ?", from, to).First(&travel).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch travel time"})
+		return
+	}
+	travel.FromLocation = from
+	travel.ToLocation = to
+	travel.Minutes = req.Minutes
+	travel.UpdatedBy = &userID
+	if err := db.DB.Save(&travel).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save travel time"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "LocationTravelTime", travel.ID,
+		fmt.Sprintf("Travel time between %s and %s set to %d minutes", from, to, req.Minutes))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Travel time saved",
+		"travel_time": travel,
+	})
+}
+
+// DeleteShiftTravelTime removes a travel time so the pair uses the default again
+func DeleteShiftTravelTime(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid travel time ID"})
+		return
+	}
+
+	var travel models.LocationTravelTime
+	if err := db.DB.First(&travel, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Travel time not found"})
+		return
+	}
+	if err := db.DB.Delete(&travel).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete travel time"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "LocationTravelTime", travel.ID,
+		fmt.Sprintf("Travel time between %s and %s removed", travel.FromLocation, travel.ToLocation))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Travel time removed"})
+}
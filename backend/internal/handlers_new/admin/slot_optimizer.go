@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SlotPlanRequest chooses which approved requests and days the optimizer plans
+type SlotPlanRequest struct {
+	Category string `json:"category" binding:"required"`
+	From     string `json:"from"` // YYYY-MM-DD, defaults to today
+	Days     int    `json:"days"` // Defaults to 7
+	PlanID   string `json:"plan_id"`
+}
+
+// parseSlotPlanRequest binds the request body into the optimizer's request
+func parseSlotPlanRequest(c *gin.Context) (SlotPlanRequest, services.SlotPlanRequest, bool) {
+	var req SlotPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, services.SlotPlanRequest{}, false
+	}
+
+	from := time.Now()
+	if req.From != "" {
+		date, err := time.Parse("2006-01-02", req.From)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return req, services.SlotPlanRequest{}, false
+		}
+		from = date
+	}
+	if req.Days == 0 {
+		req.Days = 7
+	}
+	return req, services.SlotPlanRequest{Category: req.Category, From: from, Days: req.Days}, true
+}
+
+// AdminPreviewSlotPlan proposes visit days and times for approved help requests
+// without changing anything. Apply the returned plan_id to save it.
+func AdminPreviewSlotPlan(c *gin.Context) {
+	_, planReq, ok := parseSlotPlanRequest(c)
+	if !ok {
+		return
+	}
+
+	plan, err := services.NewSlotOptimizerService().Preview(planReq)
+	if err != nil {
+		if errors.Is(err, services.ErrSlotPlanInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build slot plan"})
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// AdminApplySlotPlan saves a previewed slot plan and tells visitors whose visit moved.
+// The plan is rejected when requests or capacity changed since the preview.
+func AdminApplySlotPlan(c *gin.Context) {
+	req, planReq, ok := parseSlotPlanRequest(c)
+	if !ok {
+		return
+	}
+	if req.PlanID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "plan_id from the preview is required"})
+		return
+	}
+
+	plan, err := services.NewSlotOptimizerService().Apply(planReq, req.PlanID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSlotPlanInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrSlotPlanChanged):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "plan": plan})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply slot plan"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "AssignSlots", "HelpRequest", 0,
+		fmt.Sprintf("Assigned %d %s requests to slots from %s to %s (plan %s)",
+			len(plan.Assignments), plan.Category, plan.From, plan.To, plan.PlanID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Assigned %d requests to visit slots", len(plan.Assignments)),
+		"plan":    plan,
+	})
+}
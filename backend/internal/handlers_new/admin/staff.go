@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/handlers_new/shared"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,9 +27,20 @@ func CheckInVisitor(c *gin.Context) {
 		return
 	}
 
-	// Verify ticket exists and is valid
+	// Verify ticket exists
 	var ticket models.Ticket
-	if err := db.DB.Where("ticket_number = ? AND status = ?", req.TicketNumber, "issued").First(&ticket).Error; err != nil {
+	if err := db.DB.Where("ticket_number = ?", req.TicketNumber).First(&ticket).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid or expired ticket"})
+		return
+	}
+
+	// Hold reused or shared tickets for staff rather than turning them away silently
+	scanStaffID := uint(req.StaffID)
+	if !shared.HoldSuspectTicketScan(c, &ticket, "ticket_number", &scanStaffID, false) {
+		return
+	}
+
+	if ticket.Status != models.TicketStatusActive && ticket.Status != "issued" {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid or expired ticket"})
 		return
 	}
@@ -102,8 +115,12 @@ func CheckInVisitor(c *gin.Context) {
 	// Replace visitor.Name
 	visitorName := visitor.FirstName + " " + visitor.LastName
 
+	// Warn the desk when a visitor who asked for an interpreter does not have one
+	interpreter := services.NewInterpreterBookingService().CheckIn(&ticket)
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Visitor checked in successfully",
+		"message":     "Visitor checked in successfully",
+		"interpreter": interpreter,
 		"visitor": gin.H{
 			"id":       visitor.ID,
 			"name":     visitorName,
@@ -140,6 +157,11 @@ func ScanTicket(c *gin.Context) {
 		return
 	}
 
+	scanStaffID := uint(req.StaffID)
+	if !shared.HoldSuspectTicketScan(c, &ticket, "qr", &scanStaffID, false) {
+		return
+	}
+
 	// Check ticket validity
 	today := time.Now().Format("2006-01-02")
 	visitDate := ticket.VisitDate.Format("2006-01-02")
@@ -247,6 +269,21 @@ func CompleteVisit(c *gin.Context) {
 		db.DB.Save(&ticket)
 	}
 
+	// Close the visitor's queue entry so their service time is recorded
+	var queueEntry models.QueueEntry
+	if err := db.DB.Where("visitor_id = ? AND status IN ? AND joined_at >= ?",
+		visit.VisitorID, []string{"waiting", "called", "being_served", "served"}, visit.CheckInTime.Add(-time.Minute)).
+		Order("joined_at DESC").
+		First(&queueEntry).Error; err == nil {
+		if queueEntry.ServedAt == nil {
+			queueEntry.ServedAt = queueEntry.CalledAt
+		}
+		queueEntry.MarkCompleted()
+		db.DB.Save(&queueEntry)
+	}
+
+	services.NewFeedbackSurveyService().QueueInvitation(visit.ID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Visit completed successfully",
 		"visit_id":     visitID,
@@ -415,10 +452,11 @@ func CreateStaff(c *gin.Context) {
 		return
 	}
 
-	// Verify user exists and has appropriate role (admin, volunteer, or existing staff)
+	// Verify user exists and already holds a staff or admin account. Other users
+	// have to be invited, as staff accounts are invitation-only.
 	var user models.User
-	if err := db.DB.Where("id = ? AND role IN ?", req.UserID, []string{models.RoleAdmin, models.RoleVolunteer, models.RoleStaff}).First(&user).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found or not eligible for staff role"})
+	if err := db.DB.Where("id = ? AND role IN ?", req.UserID, []string{models.RoleAdmin, models.RoleStaff}).First(&user).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found or not a staff account; invite new staff instead"})
 		return
 	}
 
@@ -448,11 +486,9 @@ func CreateStaff(c *gin.Context) {
 		UpdatedAt:        time.Now(),
 	}
 
-	// Update user role to staff
-	user.Role = models.RoleStaff
 	user.Status = "active"
 	if err := db.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user role"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 		return
 	}
 
@@ -0,0 +1,219 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StatusIncidentRequest creates or updates a status page incident
+type StatusIncidentRequest struct {
+	Title      string   `json:"title" binding:"required"`
+	Message    string   `json:"message"`
+	Severity   string   `json:"severity"`
+	Status     string   `json:"status"`
+	Components []string `json:"components"`
+}
+
+// validate checks the severity, status and components, filling in defaults
+func (req *StatusIncidentRequest) validate() error {
+	switch req.Severity {
+	case "":
+		req.Severity = models.IncidentSeverityMinor
+	case models.IncidentSeverityMinor, models.IncidentSeverityMajor, models.IncidentSeverityCritical:
+	default:
+		return fmt.Errorf("severity must be minor, major or critical")
+	}
+
+	switch req.Status {
+	case "":
+		req.Status = models.IncidentStatusInvestigating
+	case models.IncidentStatusInvestigating, models.IncidentStatusIdentified, models.IncidentStatusMonitoring, models.IncidentStatusResolved:
+	default:
+		return fmt.Errorf("status must be investigating, identified, monitoring or resolved")
+	}
+
+	for _, component := range req.Components {
+		switch component {
+		case models.StatusComponentAPI, models.StatusComponentBookings, models.StatusComponentNotifications:
+		default:
+			return fmt.Errorf("unknown component: %s", component)
+		}
+	}
+	return nil
+}
+
+// ListStatusIncidents returns status page incidents, open ones first
+func ListStatusIncidents(c *gin.Context) {
+	query := db.DB.Model(&models.StatusIncident{})
+	if c.Query("open") == "true" {
+		query = query.Where("status <> ?", models.IncidentStatusResolved)
+	}
+
+	var incidents []models.StatusIncident
+	if err := query.Order("resolved_at IS NOT NULL, started_at DESC").
+		Limit(50).
+		Find(&incidents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incidents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"incidents": incidents,
+		"total":     len(incidents),
+	})
+}
+
+// CreateStatusIncident publishes an incident on the status page
+func CreateStatusIncident(c *gin.Context) {
+	var req StatusIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	incident := models.StatusIncident{
+		Title:      req.Title,
+		Message:    req.Message,
+		Severity:   req.Severity,
+		Status:     req.Status,
+		Components: strings.Join(req.Components, ","),
+		StartedAt:  now,
+		CreatedBy:  utils.GetUserIDFromContext(c),
+	}
+	if incident.Status == models.IncidentStatusResolved {
+		incident.ResolvedAt = &now
+	}
+
+	if err := db.DB.Create(&incident).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create incident"})
+		return
+	}
+	services.InvalidateStatus()
+
+	utils.CreateAuditLog(c, "Create", "StatusIncident", incident.ID,
+		fmt.Sprintf("Status incident published: %s (%s)", incident.Title, incident.Severity))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Incident published",
+		"incident": incident,
+	})
+}
+
+// UpdateStatusIncident changes an incident's details or progress
+func UpdateStatusIncident(c *gin.Context) {
+	incident, ok := loadStatusIncident(c)
+	if !ok {
+		return
+	}
+
+	var req StatusIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	incident.Title = req.Title
+	incident.Message = req.Message
+	incident.Severity = req.Severity
+	incident.Components = strings.Join(req.Components, ",")
+	incident.UpdatedBy = &userID
+	setIncidentStatus(incident, req.Status)
+
+	if err := db.DB.Save(incident).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update incident"})
+		return
+	}
+	services.InvalidateStatus()
+
+	utils.CreateAuditLog(c, "Update", "StatusIncident", incident.ID,
+		fmt.Sprintf("Status incident updated: %s (%s)", incident.Title, incident.Status))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Incident updated",
+		"incident": incident,
+	})
+}
+
+// ResolveStatusIncident marks an incident resolved, removing it from the status page
+func ResolveStatusIncident(c *gin.Context) {
+	incident, ok := loadStatusIncident(c)
+	if !ok {
+		return
+	}
+	if incident.Status == models.IncidentStatusResolved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Incident is already resolved"})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	incident.UpdatedBy = &userID
+	setIncidentStatus(incident, models.IncidentStatusResolved)
+
+	if err := db.DB.Save(incident).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve incident"})
+		return
+	}
+	services.InvalidateStatus()
+
+	utils.CreateAuditLog(c, "Resolve", "StatusIncident", incident.ID,
+		fmt.Sprintf("Status incident resolved: %s", incident.Title))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Incident resolved",
+		"incident": incident,
+	})
+}
+
+// setIncidentStatus updates the status, keeping the resolved time in step
+func setIncidentStatus(incident *models.StatusIncident, status string) {
+	if status == models.IncidentStatusResolved && incident.ResolvedAt == nil {
+		now := time.Now()
+		incident.ResolvedAt = &now
+	} else if status != models.IncidentStatusResolved {
+		incident.ResolvedAt = nil
+	}
+	incident.Status = status
+}
+
+// loadStatusIncident loads the incident identified by the :id path parameter
+func loadStatusIncident(c *gin.Context) (*models.StatusIncident, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid incident ID"})
+		return nil, false
+	}
+
+	var incident models.StatusIncident
+	if err := db.DB.First(&incident, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incident"})
+		}
+		return nil, false
+	}
+
+	return &incident, true
+}
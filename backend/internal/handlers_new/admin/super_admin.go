@@ -11,6 +11,7 @@ import (
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
 	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 
 	"github.com/geoo115/charity-management-system/internal/handlers_new/shared"
@@ -305,8 +306,10 @@ func SuperAdminUpdateUser(c *gin.Context) {
 
 	// Force logout if requested
 	if req.ForceLogout {
-		// Invalidate all refresh tokens for this user
-		db.DB.Model(&models.RefreshToken{}).Where("user_id = ?", user.ID).Update("revoked", true)
+		// Invalidate every token issued to this user
+		if _, err := services.NewSessionService().EndAllSessions(user.ID, fmt.Sprintf("admin:%d", utils.GetUserIDFromContext(c))); err != nil {
+			log.Printf("Failed to end sessions for user %d: %v", user.ID, err)
+		}
 	}
 
 	// Create audit log
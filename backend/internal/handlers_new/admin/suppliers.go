@@ -0,0 +1,622 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SupplierRequest represents the request body for creating or updating a supplier
+type SupplierRequest struct {
+	Name         string `json:"name" binding:"required"`
+	ContactName  string `json:"contact_name"`
+	ContactEmail string `json:"contact_email" binding:"omitempty,email"`
+	ContactPhone string `json:"contact_phone"`
+	OrderMethod  string `json:"order_method"` // webhook or email, defaults to email
+	WebhookURL   string `json:"webhook_url"`
+	Categories   string `json:"categories"` // Comma separated urgent need categories
+	LeadTimeDays *int   `json:"lead_time_days"`
+	AutoSubmit   bool   `json:"auto_submit"`
+	IsActive     *bool  `json:"is_active"`
+	Notes        string `json:"notes"`
+}
+
+// CreateSupplierOrderRequest represents a manually raised supplier order
+type CreateSupplierOrderRequest struct {
+	SupplierID uint `json:"supplier_id" binding:"required"`
+	Lines      []struct {
+		UrgentNeedID uint `json:"urgent_need_id" binding:"required"`
+		Quantity     int  `json:"quantity"` // Defaults to the quantity needed to reach target
+	} `json:"lines" binding:"required,min=1"`
+	Notes string `json:"notes"`
+}
+
+// UpdateSupplierOrderStatusRequest represents a status change entered by staff
+type UpdateSupplierOrderStatusRequest struct {
+	Status      string `json:"status" binding:"required"`
+	ExternalRef string `json:"external_ref"`
+	ExpectedAt  string `json:"expected_at"` // YYYY-MM-DD
+	Notes       string `json:"notes"`
+}
+
+// ReceiveSupplierDeliveryRequest lists what arrived for each order line
+type ReceiveSupplierDeliveryRequest struct {
+	Lines []struct {
+		LineID   uint `json:"line_id" binding:"required"`
+		Quantity int  `json:"quantity" binding:"min=0"`
+	} `json:"lines" binding:"required,min=1"`
+	Notes string `json:"notes"`
+}
+
+// UpdateReorderSettingsRequest sets how an urgent need is reordered
+type UpdateReorderSettingsRequest struct {
+	SupplierID   *uint `json:"supplier_id"`   // 0 clears the supplier
+	ReorderLevel *int  `json:"reorder_level"` // 0 uses the low stock level
+}
+
+// applySupplierRequest copies request fields onto a supplier and validates them
+func applySupplierRequest(supplier *models.Supplier, req *SupplierRequest) error {
+	supplier.Name = req.Name
+	supplier.ContactName = req.ContactName
+	supplier.ContactEmail = req.ContactEmail
+	supplier.ContactPhone = req.ContactPhone
+	supplier.WebhookURL = strings.TrimSpace(req.WebhookURL)
+	supplier.Categories = req.Categories
+	supplier.AutoSubmit = req.AutoSubmit
+	supplier.Notes = req.Notes
+	if req.OrderMethod != "" {
+		supplier.OrderMethod = req.OrderMethod
+	}
+	if supplier.OrderMethod == "" {
+		supplier.OrderMethod = models.SupplierOrderMethodEmail
+	}
+	if req.LeadTimeDays != nil {
+		supplier.LeadTimeDays = *req.LeadTimeDays
+	}
+	if req.IsActive != nil {
+		supplier.IsActive = *req.IsActive
+	}
+
+	switch supplier.OrderMethod {
+	case models.SupplierOrderMethodWebhook:
+		parsed, err := url.Parse(supplier.WebhookURL)
+		if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+			return errors.New("a valid webhook_url is required for webhook suppliers")
+		}
+	case models.SupplierOrderMethodEmail:
+		if supplier.ContactEmail == "" {
+			return errors.New("contact_email is required for email suppliers")
+		}
+	default:
+		return errors.New("order_method must be webhook or email")
+	}
+	if supplier.LeadTimeDays < 0 {
+		return errors.New("lead_time_days cannot be negative")
+	}
+	return nil
+}
+
+// ListSuppliers returns suppliers, active ones first
+func ListSuppliers(c *gin.Context) {
+	query := db.DB.Model(&models.Supplier{})
+	if c.Query("active") == "true" {
+		query = query.Where("is_active = ?", true)
+	}
+	if category := c.Query("category"); category != "" {
+		query = query.Where("categories ILIKE ?", "%"+category+"%")
+	}
+
+	var suppliers []models.Supplier
+	if err := query.Order("is_active DESC, name ASC").Find(&suppliers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suppliers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"suppliers": suppliers,
+		"total":     len(suppliers),
+	})
+}
+
+// CreateSupplier adds a supplier. Webhook suppliers are given a signing secret, which
+// is only returned here and when rotated.
+func CreateSupplier(c *gin.Context) {
+	var req SupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	supplier := models.Supplier{IsActive: true, LeadTimeDays: 3}
+	if err := applySupplierRequest(&supplier, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	secret, err := services.GenerateSupplierSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate webhook secret"})
+		return
+	}
+	supplier.WebhookSecret = secret
+
+	if err := db.DB.Create(&supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create supplier"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "Supplier", supplier.ID, fmt.Sprintf("Supplier %s added", supplier.Name))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":        "Supplier created",
+		"supplier":       supplier,
+		"webhook_secret": secret,
+	})
+}
+
+// GetSupplier returns a supplier with its recent orders
+func GetSupplier(c *gin.Context) {
+	supplier, ok := loadSupplier(c)
+	if !ok {
+		return
+	}
+
+	var orders []models.SupplierOrder
+	db.DB.Preload("Lines").Where("supplier_id = ?", supplier.ID).Order("created_at DESC").Limit(20).Find(&orders)
+
+	c.JSON(http.StatusOK, gin.H{
+		"supplier": supplier,
+		"orders":   orders,
+	})
+}
+
+// UpdateSupplier updates a supplier's details
+func UpdateSupplier(c *gin.Context) {
+	supplier, ok := loadSupplier(c)
+	if !ok {
+		return
+	}
+
+	var req SupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applySupplierRequest(supplier, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.DB.Save(supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update supplier"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "Supplier", supplier.ID, fmt.Sprintf("Supplier %s updated", supplier.Name))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Supplier updated",
+		"supplier": supplier,
+	})
+}
+
+// RotateSupplierSecret replaces a supplier's webhook signing secret
+func RotateSupplierSecret(c *gin.Context) {
+	supplier, ok := loadSupplier(c)
+	if !ok {
+		return
+	}
+
+	secret, err := services.GenerateSupplierSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate webhook secret"})
+		return
+	}
+	if err := db.DB.Model(supplier).Update("webhook_secret", secret).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate webhook secret"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "RotateSecret", "Supplier", supplier.ID, fmt.Sprintf("Webhook secret rotated for supplier %s", supplier.Name))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Webhook secret rotated",
+		"webhook_secret": secret,
+	})
+}
+
+// DeleteSupplier removes a supplier with no open orders
+func DeleteSupplier(c *gin.Context) {
+	supplier, ok := loadSupplier(c)
+	if !ok {
+		return
+	}
+
+	var open int64
+	db.DB.Model(&models.SupplierOrder{}).
+		Where("supplier_id = ? AND status IN ?", supplier.ID, models.SupplierOrderOpenStatuses).
+		Count(&open)
+	if open > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Supplier has open orders; cancel or receive them first, or mark the supplier inactive"})
+		return
+	}
+
+	if err := db.DB.Delete(supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete supplier"})
+		return
+	}
+	db.DB.Model(&models.UrgentNeed{}).Where("supplier_id = ?", supplier.ID).Update("supplier_id", nil)
+
+	utils.CreateAuditLog(c, "Delete", "Supplier", supplier.ID, fmt.Sprintf("Supplier %s deleted", supplier.Name))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Supplier deleted"})
+}
+
+// UpdateReorderSettings sets the supplier and reorder level for an urgent need
+func UpdateReorderSettings(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid urgent need ID"})
+		return
+	}
+
+	var req UpdateReorderSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var need models.UrgentNeed
+	if err := db.DB.First(&need, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Urgent need not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch urgent need"})
+		}
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.SupplierID != nil {
+		if *req.SupplierID == 0 {
+			updates["supplier_id"] = nil
+		} else {
+			var supplier models.Supplier
+			if err := db.DB.First(&supplier, *req.SupplierID).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Supplier not found"})
+				return
+			}
+			updates["supplier_id"] = supplier.ID
+		}
+	}
+	if req.ReorderLevel != nil {
+		if *req.ReorderLevel < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reorder_level cannot be negative"})
+			return
+		}
+		updates["reorder_level"] = *req.ReorderLevel
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+
+	if err := db.DB.Model(&need).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reorder settings"})
+		return
+	}
+	db.DB.First(&need, need.ID)
+
+	utils.CreateAuditLog(c, "Update", "UrgentNeed", need.ID, fmt.Sprintf("Reorder settings updated for %s", need.Name))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Reorder settings updated",
+		"urgent_need":   need,
+		"needs_reorder": need.NeedsReorder(),
+	})
+}
+
+// ListSupplierOrders returns supplier orders, optionally by status or supplier
+func ListSupplierOrders(c *gin.Context) {
+	query := db.DB.Model(&models.SupplierOrder{}).Preload("Supplier").Preload("Lines")
+	switch status := c.Query("status"); status {
+	case "":
+	case "open":
+		query = query.Where("status IN ?", models.SupplierOrderOpenStatuses)
+	default:
+		query = query.Where("status = ?", status)
+	}
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+
+	var orders []models.SupplierOrder
+	if err := query.Order("created_at DESC").Limit(200).Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch supplier orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orders": orders,
+		"total":  len(orders),
+	})
+}
+
+// CreateSupplierOrder raises a draft order by hand
+func CreateSupplierOrder(c *gin.Context) {
+	var req CreateSupplierOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lines := make([]models.SupplierOrderLine, 0, len(req.Lines))
+	for _, reqLine := range req.Lines {
+		var need models.UrgentNeed
+		if err := db.DB.First(&need, reqLine.UrgentNeedID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Urgent need %d not found", reqLine.UrgentNeedID)})
+			return
+		}
+		quantity := reqLine.Quantity
+		if quantity <= 0 {
+			quantity = need.GetQuantityNeeded()
+		}
+		if quantity <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s is already at target stock; give a quantity to order", need.Name)})
+			return
+		}
+		lines = append(lines, models.SupplierOrderLine{
+			UrgentNeedID:    need.ID,
+			ItemName:        need.Name,
+			QuantityOrdered: quantity,
+		})
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	order, err := services.NewSupplierOrderService().CreateOrder(req.SupplierID, lines, models.SupplierOrderTriggerManual, &userID, req.Notes)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Supplier not found"})
+		case errors.Is(err, services.ErrSupplierInactive):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create supplier order"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "SupplierOrder", order.ID,
+		fmt.Sprintf("Draft order %s raised with %s", order.Reference, order.Supplier.Name))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Draft order created",
+		"order":   order,
+	})
+}
+
+// RunReorderCheck raises low stock orders now rather than waiting for the scheduled
+// inventory check
+func RunReorderCheck(c *gin.Context) {
+	orders, err := services.NewSupplierOrderService().CheckReorders(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check stock levels"})
+		return
+	}
+
+	if len(orders) > 0 {
+		utils.CreateAuditLog(c, "ReorderCheck", "SupplierOrder", 0, fmt.Sprintf("Low stock check raised %d supplier orders", len(orders)))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("%d orders raised", len(orders)),
+		"orders":  orders,
+	})
+}
+
+// GetSupplierOrder returns an order with its lines and delivery progress
+func GetSupplierOrder(c *gin.Context) {
+	order, ok := loadSupplierOrder(c)
+	if !ok {
+		return
+	}
+
+	ordered, received := 0, 0
+	for _, line := range order.Lines {
+		ordered += line.QuantityOrdered
+		received += line.QuantityReceived
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order":          order,
+		"total_ordered":  ordered,
+		"total_received": received,
+	})
+}
+
+// SubmitSupplierOrder sends a draft or failed order to the supplier
+func SubmitSupplierOrder(c *gin.Context) {
+	order, ok := loadSupplierOrder(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	if err := services.NewSupplierOrderService().Submit(ctx, order); err != nil {
+		switch {
+		case errors.Is(err, services.ErrSupplierOrderNotSubmittable),
+			errors.Is(err, services.ErrSupplierOrderNoLines),
+			errors.Is(err, services.ErrSupplierInactive):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			utils.CreateAuditLog(c, "Submit", "SupplierOrder", order.ID, fmt.Sprintf("Order %s failed to send: %v", order.Reference, err))
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send order to supplier: " + err.Error(), "order": order})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "Submit", "SupplierOrder", order.ID,
+		fmt.Sprintf("Order %s sent to %s by %s", order.Reference, order.Supplier.Name, order.Supplier.OrderMethod))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Order sent to supplier",
+		"order":   order,
+	})
+}
+
+// UpdateSupplierOrderStatus records a status change, e.g. when a supplier confirms by
+// phone or the order is cancelled
+func UpdateSupplierOrderStatus(c *gin.Context) {
+	order, ok := loadSupplierOrder(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateSupplierOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	update := services.SupplierStatusUpdate{
+		Status:      req.Status,
+		ExternalRef: req.ExternalRef,
+		Notes:       req.Notes,
+	}
+	if req.ExpectedAt != "" {
+		expected, err := time.Parse("2006-01-02", req.ExpectedAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expected_at format. Use YYYY-MM-DD"})
+			return
+		}
+		update.ExpectedAt = &expected
+	}
+
+	previous := order.Status
+	if err := services.NewSupplierOrderService().UpdateStatus(order, update); err != nil {
+		if errors.Is(err, services.ErrSupplierInvalidTransition) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Order cannot move from %s to %s", previous, req.Status)})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "UpdateStatus", "SupplierOrder", order.ID,
+		fmt.Sprintf("Order %s moved from %s to %s", order.Reference, previous, order.Status))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Order updated",
+		"order":   order,
+	})
+}
+
+// ReceiveSupplierDelivery records a delivery or collection against an order, adds the
+// items to stock and reports any shortfall against what was ordered
+func ReceiveSupplierDelivery(c *gin.Context) {
+	order, ok := loadSupplierOrder(c)
+	if !ok {
+		return
+	}
+
+	var req ReceiveSupplierDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lineIDs := make(map[uint]bool, len(order.Lines))
+	for _, line := range order.Lines {
+		lineIDs[line.ID] = true
+	}
+	received := make(map[uint]int, len(req.Lines))
+	for _, line := range req.Lines {
+		if !lineIDs[line.LineID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Line %d is not on this order", line.LineID)})
+			return
+		}
+		received[line.LineID] += line.Quantity
+	}
+
+	service := services.NewSupplierOrderService()
+	reconciliation, err := service.ReceiveDelivery(order, received)
+	if err != nil {
+		if errors.Is(err, services.ErrSupplierOrderClosed) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Deliveries can only be recorded against sent, open orders"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record delivery"})
+		}
+		return
+	}
+	if req.Notes != "" {
+		service.UpdateStatus(order, services.SupplierStatusUpdate{Notes: req.Notes})
+	}
+
+	shortfall := 0
+	for _, line := range reconciliation.Lines {
+		shortfall += line.Shortfall
+	}
+	utils.CreateAuditLog(c, "ReceiveDelivery", "SupplierOrder", order.ID,
+		fmt.Sprintf("Delivery recorded for order %s; %d items outstanding", order.Reference, shortfall))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Delivery recorded",
+		"reconciliation": reconciliation,
+	})
+}
+
+// loadSupplier fetches the supplier named in the route
+func loadSupplier(c *gin.Context) (*models.Supplier, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid supplier ID"})
+		return nil, false
+	}
+
+	var supplier models.Supplier
+	if err := db.DB.First(&supplier, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch supplier"})
+		}
+		return nil, false
+	}
+
+	return &supplier, true
+}
+
+// loadSupplierOrder fetches the supplier order named in the route
+func loadSupplierOrder(c *gin.Context) (*models.SupplierOrder, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return nil, false
+	}
+
+	order, err := services.NewSupplierOrderService().LoadOrder(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Supplier order not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch supplier order"})
+		}
+		return nil, false
+	}
+
+	return order, true
+}
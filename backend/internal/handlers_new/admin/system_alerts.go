@@ -0,0 +1,202 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SystemAlertRequest raises an alert by hand
+type SystemAlertRequest struct {
+	Title       string `json:"title" binding:"required"`
+	Message     string `json:"message"`
+	Severity    string `json:"severity"` // low, medium (default), high, critical
+	ActionLabel string `json:"action_label"`
+	ActionURL   string `json:"action_url"`
+}
+
+// SnoozeAlertRequest hides an alert for a number of minutes
+type SnoozeAlertRequest struct {
+	Minutes int `json:"minutes" binding:"required,min=1"`
+}
+
+// AdminGetSystemAlerts returns system alerts for the admin dashboard. By default only
+// open alerts that are not snoozed are listed; pass status=active, acknowledged,
+// snoozed, resolved or all, and filter by severity and source.
+func AdminGetSystemAlerts(c *gin.Context) {
+	alerts, err := services.NewSystemAlertService().List(services.SystemAlertFilter{
+		Status:   c.Query("status"),
+		Severity: c.Query("severity"),
+		Source:   c.Query("source"),
+	}, time.Now())
+	if err != nil {
+		systemAlertError(c, err, "Failed to fetch alerts")
+		return
+	}
+	c.JSON(http.StatusOK, alerts)
+}
+
+// AdminGetSystemAlert returns one system alert
+func AdminGetSystemAlert(c *gin.Context) {
+	id, ok := alertID(c)
+	if !ok {
+		return
+	}
+	alert, err := services.NewSystemAlertService().Get(id)
+	if err != nil {
+		systemAlertError(c, err, "Failed to fetch alert")
+		return
+	}
+	c.JSON(http.StatusOK, alert)
+}
+
+// AdminCreateSystemAlert raises an alert by hand, such as a known outage
+func AdminCreateSystemAlert(c *gin.Context) {
+	var req SystemAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alert, err := services.NewSystemAlertService().Create(services.SystemAlertInput{
+		Title:       req.Title,
+		Message:     req.Message,
+		Severity:    req.Severity,
+		ActionLabel: req.ActionLabel,
+		ActionURL:   req.ActionURL,
+	}, utils.GetUserIDFromContext(c))
+	if err != nil {
+		systemAlertError(c, err, "Failed to create alert")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "SystemAlert", alert.ID,
+		fmt.Sprintf("Raised %s alert: %s", alert.Severity, alert.Title))
+	c.JSON(http.StatusCreated, alert)
+}
+
+// AdminAcknowledgeAlert marks a system alert as acknowledged
+func AdminAcknowledgeAlert(c *gin.Context) {
+	id, ok := alertID(c)
+	if !ok {
+		return
+	}
+	alert, err := services.NewSystemAlertService().Acknowledge(id, utils.GetUserIDFromContext(c))
+	if err != nil {
+		systemAlertError(c, err, "Failed to acknowledge alert")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Acknowledge", "SystemAlert", alert.ID,
+		fmt.Sprintf("Alert acknowledged: %s", alert.Title))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alert acknowledged successfully",
+		"alert":   alert,
+	})
+}
+
+// AdminSnoozeAlert hides a system alert from the dashboard for a while
+func AdminSnoozeAlert(c *gin.Context) {
+	id, ok := alertID(c)
+	if !ok {
+		return
+	}
+	var req SnoozeAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	until := time.Now().Add(time.Duration(req.Minutes) * time.Minute)
+	alert, err := services.NewSystemAlertService().Snooze(id, until, utils.GetUserIDFromContext(c))
+	if err != nil {
+		systemAlertError(c, err, "Failed to snooze alert")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Snooze", "SystemAlert", alert.ID,
+		fmt.Sprintf("Alert snoozed until %s: %s", until.Format(time.RFC3339), alert.Title))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alert snoozed",
+		"alert":   alert,
+	})
+}
+
+// AdminResolveAlert closes a system alert
+func AdminResolveAlert(c *gin.Context) {
+	id, ok := alertID(c)
+	if !ok {
+		return
+	}
+	alert, err := services.NewSystemAlertService().Resolve(id, utils.GetUserIDFromContext(c))
+	if err != nil {
+		systemAlertError(c, err, "Failed to resolve alert")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Resolve", "SystemAlert", alert.ID,
+		fmt.Sprintf("Alert resolved: %s", alert.Title))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alert resolved",
+		"alert":   alert,
+	})
+}
+
+// AdminDeleteAlert removes a system alert
+func AdminDeleteAlert(c *gin.Context) {
+	id, ok := alertID(c)
+	if !ok {
+		return
+	}
+	if err := services.NewSystemAlertService().Delete(id); err != nil {
+		systemAlertError(c, err, "Failed to delete alert")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "SystemAlert", id, "Alert deleted")
+	c.JSON(http.StatusOK, gin.H{"message": "Alert deleted"})
+}
+
+// AdminEvaluateAlerts runs the alert rules now instead of waiting for the job
+func AdminEvaluateAlerts(c *gin.Context) {
+	raised, resolved, err := services.NewSystemAlertService().Evaluate(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to evaluate alerts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"raised":   raised,
+		"resolved": resolved,
+	})
+}
+
+// alertID reads the alert ID from the path, responding with an error if invalid
+func alertID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// systemAlertError maps system alert errors to responses
+func systemAlertError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrAlertNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAlertInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAlertResolved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,220 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TagRequest creates or renames a tag
+type TagRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Colour      string `json:"colour"` // Hex colour, e.g. #2E7D32
+}
+
+// TagAssignmentRequest attaches a tag to a visitor, volunteer, help request or donation
+type TagAssignmentRequest struct {
+	EntityType string `json:"entity_type" binding:"required"` // visitor, volunteer, help_request, donation
+	EntityID   uint   `json:"entity_id" binding:"required"`
+}
+
+// AdminListTags returns every tag with how many visitors, volunteers, help requests
+// and donations carry it
+func AdminListTags(c *gin.Context) {
+	tags, err := services.NewTagService().List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"tags":  tags,
+		"total": len(tags),
+	})
+}
+
+// AdminCreateTag adds a tag
+func AdminCreateTag(c *gin.Context) {
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := services.NewTagService().Create(services.TagInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Colour:      req.Colour,
+	}, utils.GetUserIDFromContext(c))
+	if err != nil {
+		tagError(c, err, "Failed to create tag")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "Tag", tag.ID, fmt.Sprintf("Created tag %s", tag.Name))
+	c.JSON(http.StatusCreated, tag)
+}
+
+// AdminUpdateTag renames or recolours a tag
+func AdminUpdateTag(c *gin.Context) {
+	id, ok := tagID(c)
+	if !ok {
+		return
+	}
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := services.NewTagService().Update(id, services.TagInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Colour:      req.Colour,
+	})
+	if err != nil {
+		tagError(c, err, "Failed to update tag")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "Tag", tag.ID, fmt.Sprintf("Updated tag %s", tag.Name))
+	c.JSON(http.StatusOK, tag)
+}
+
+// AdminDeleteTag removes a tag from everything it is attached to
+func AdminDeleteTag(c *gin.Context) {
+	id, ok := tagID(c)
+	if !ok {
+		return
+	}
+	tag, err := services.NewTagService().Delete(id)
+	if err != nil {
+		tagError(c, err, "Failed to delete tag")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "Tag", tag.ID, fmt.Sprintf("Deleted tag %s", tag.Name))
+	c.JSON(http.StatusOK, gin.H{"message": "Tag deleted"})
+}
+
+// AdminListTagAssignments returns what a tag is attached to. Pass type to list one
+// entity type.
+func AdminListTagAssignments(c *gin.Context) {
+	id, ok := tagID(c)
+	if !ok {
+		return
+	}
+	assignments, err := services.NewTagService().Assignments(id, c.Query("type"))
+	if err != nil {
+		tagError(c, err, "Failed to fetch tagged records")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"assignments": assignments,
+		"total":       len(assignments),
+	})
+}
+
+// AdminAssignTag attaches a tag to a visitor, volunteer, help request or donation
+func AdminAssignTag(c *gin.Context) {
+	id, ok := tagID(c)
+	if !ok {
+		return
+	}
+	var req TagAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assignment, err := services.NewTagService().Assign(id, req.EntityType, req.EntityID, utils.GetUserIDFromContext(c))
+	if err != nil {
+		tagError(c, err, "Failed to tag record")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Tag", "Tag", id, fmt.Sprintf("Tagged %s %d", req.EntityType, req.EntityID))
+	c.JSON(http.StatusOK, assignment)
+}
+
+// AdminUnassignTag detaches a tag from a visitor, volunteer, help request or donation
+func AdminUnassignTag(c *gin.Context) {
+	id, ok := tagID(c)
+	if !ok {
+		return
+	}
+	entityID, err := strconv.ParseUint(c.Param("entityId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return
+	}
+
+	entityType := c.Param("type")
+	if err := services.NewTagService().Unassign(id, entityType, uint(entityID)); err != nil {
+		tagError(c, err, "Failed to untag record")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Untag", "Tag", id, fmt.Sprintf("Untagged %s %d", entityType, entityID))
+	c.JSON(http.StatusOK, gin.H{"message": "Tag removed"})
+}
+
+// AdminGetEntityTags returns the tags on a visitor, volunteer, help request or donation
+func AdminGetEntityTags(c *gin.Context) {
+	entityID, err := strconv.ParseUint(c.Param("entityId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return
+	}
+	tags, err := services.NewTagService().TagsFor(c.Param("type"), uint(entityID))
+	if err != nil {
+		tagError(c, err, "Failed to fetch tags")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// AdminGetTagReport summarises a tag's cohort: who carries it, the people reached
+// through it, its donations and its help requests
+func AdminGetTagReport(c *gin.Context) {
+	id, ok := tagID(c)
+	if !ok {
+		return
+	}
+	report, err := services.NewTagService().Report(id)
+	if err != nil {
+		tagError(c, err, "Failed to build tag report")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// tagID reads the tag ID from the path, responding with an error if invalid
+func tagID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// tagError maps tag errors to responses
+func tagError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrTagNotFound), errors.Is(err, services.ErrTagEntityNotFound), errors.Is(err, services.ErrTagNotAssigned):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTagInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTagExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TicketFraudReviewRequest records the outcome of looking into a held check-in
+type TicketFraudReviewRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=confirmed dismissed"`
+	Notes    string `json:"notes"`
+}
+
+// ListTicketFraudEvents returns suspected ticket misuse for review. Pass status=open
+// for events nobody has reviewed.
+func ListTicketFraudEvents(c *gin.Context) {
+	limit := 200
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+
+	events, err := services.NewTicketFraudService().List(c.Query("status"), c.Query("reason"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch fraud events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"total":  len(events),
+	})
+}
+
+// ReviewTicketFraudEvent confirms or dismisses a suspected ticket misuse. Dismissing it
+// lets the ticket be checked in.
+func ReviewTicketFraudEvent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req TicketFraudReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := services.NewTicketFraudService().Review(uint(id), utils.GetUserIDFromContext(c), req.Decision, req.Notes)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTicketFraudEventNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTicketFraudReviewed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTicketFraudDecision):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review fraud event"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "ReviewFraud", "TicketFraudEvent", event.ID,
+		fmt.Sprintf("%s %s on ticket %s", event.Status, event.Reason, event.TicketNumber))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Fraud event " + event.Status,
+		"event":   event,
+	})
+}
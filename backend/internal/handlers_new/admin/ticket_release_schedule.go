@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	// The scheduled release issues tickets the same way as a manual one, under the
+	// stored daily capacity
+	services.TicketReleaser = func(releaseDate string) (uint, int) {
+		result := ProcessTicketRelease(releaseDate, nil, nil, models.ReleaseTriggerScheduled, nil)
+		return result.ReleaseRunID, result.TotalReleased
+	}
+}
+
+// AdminGetTicketReleaseSchedule returns when tickets are released automatically and
+// when the next release is due
+func AdminGetTicketReleaseSchedule(c *gin.Context) {
+	status, err := services.NewTicketReleaseScheduleService().Status(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch the ticket release schedule"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// AdminUpdateTicketReleaseSchedule pauses, resumes or moves the automatic ticket
+// release. Days are limited to Tuesday, Wednesday and Thursday.
+func AdminUpdateTicketReleaseSchedule(c *gin.Context) {
+	var input services.TicketReleaseScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := services.NewTicketReleaseScheduleService().Update(input, utils.GetUserIDFromContext(c), time.Now())
+	if errors.Is(err, services.ErrInvalidReleaseSchedule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update the ticket release schedule"})
+		return
+	}
+
+	state := "enabled"
+	if !status.Enabled {
+		state = "disabled"
+	}
+	utils.CreateAuditLog(c, "Update", "TicketReleaseSchedule", 0,
+		fmt.Sprintf("Set the automatic ticket release %s, at %s on %s", state, status.ReleaseAt, strings.Join(status.Weekdays, ", ")))
+
+	c.JSON(http.StatusOK, status)
+}
+
+// AdminListScheduledReleaseRuns returns what the scheduler did at each release slot,
+// newest first
+func AdminListScheduledReleaseRuns(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+		return
+	}
+
+	runs, err := services.NewTicketReleaseScheduleService().Runs(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scheduled ticket releases"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"runs":  runs,
+		"total": len(runs),
+	})
+}
@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TranslationRequest is a template's subject and body in another language
+type TranslationRequest struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body" binding:"required"`
+}
+
+// AdminListTemplateTranslations returns notification template translations. Pass
+// template_type or language to filter.
+func AdminListTemplateTranslations(c *gin.Context) {
+	translations, err := services.NewTranslationService().List(c.Query("template_type"), c.Query("language"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch translations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"translations": translations,
+		"total":        len(translations),
+	})
+}
+
+// AdminSaveTemplateTranslation adds or replaces a notification template's
+// translation into a language
+func AdminSaveTemplateTranslation(c *gin.Context) {
+	var req TranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	translation, err := services.NewTranslationService().Save(c.Param("template"), c.Param("language"),
+		req.Subject, req.Body, utils.GetUserIDFromContext(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTranslation) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save translation"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "NotificationTemplateTranslation", translation.ID,
+		fmt.Sprintf("Saved %s translation of %s", translation.Language, translation.TemplateType))
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Translation saved",
+		"translation": translation,
+	})
+}
+
+// AdminDeleteTemplateTranslation removes a notification template's translation
+func AdminDeleteTemplateTranslation(c *gin.Context) {
+	templateType, language := c.Param("template"), c.Param("language")
+	if err := services.NewTranslationService().Delete(templateType, language); err != nil {
+		if errors.Is(err, services.ErrTranslationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete translation"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "NotificationTemplateTranslation", 0,
+		fmt.Sprintf("Removed %s translation of %s", language, templateType))
+	c.JSON(http.StatusOK, gin.H{"message": "Translation removed"})
+}
+
+// AdminGetTranslationCoverage reports, for each language users and locations ask
+// for, which templates are untranslated and how many messages fell back to another
+// language in the last days (30 by default)
+func AdminGetTranslationCoverage(c *gin.Context) {
+	days := 30
+	if v, err := strconv.Atoi(c.Query("days")); err == nil && v > 0 && v <= 365 {
+		days = v
+	}
+
+	coverage, err := services.NewTranslationService().Coverage(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate translation coverage"})
+		return
+	}
+	c.JSON(http.StatusOK, coverage)
+}
+
+// GetCampaignTranslations returns a campaign's translations
+func GetCampaignTranslations(c *gin.Context) {
+	campaign, ok := loadCampaign(c)
+	if !ok {
+		return
+	}
+
+	translations, err := services.NewCampaignService().Translations(campaign.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch campaign translations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"translations": translations})
+}
+
+// SaveCampaignTranslation adds or replaces a draft campaign's subject and body in a
+// language
+func SaveCampaignTranslation(c *gin.Context) {
+	campaign, ok := loadCampaign(c)
+	if !ok {
+		return
+	}
+	var req TranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	translation, err := services.NewCampaignService().SaveTranslation(campaign.ID, c.Param("language"),
+		req.Subject, req.Body, utils.GetUserIDFromContext(c))
+	if err != nil {
+		campaignTranslationError(c, err, "Failed to save campaign translation")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "Campaign", campaign.ID,
+		fmt.Sprintf("Saved %s translation of campaign %s", translation.Language, campaign.Name))
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Campaign translation saved",
+		"translation": translation,
+	})
+}
+
+// DeleteCampaignTranslation removes a draft campaign's translation
+func DeleteCampaignTranslation(c *gin.Context) {
+	campaign, ok := loadCampaign(c)
+	if !ok {
+		return
+	}
+
+	language := c.Param("language")
+	if err := services.NewCampaignService().DeleteTranslation(campaign.ID, language); err != nil {
+		campaignTranslationError(c, err, "Failed to delete campaign translation")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "Campaign", campaign.ID,
+		fmt.Sprintf("Removed %s translation of campaign %s", language, campaign.Name))
+	c.JSON(http.StatusOK, gin.H{"message": "Campaign translation removed"})
+}
+
+// campaignTranslationError maps campaign translation errors to responses
+func campaignTranslationError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, services.ErrCampaignTranslationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Campaign translation not found"})
+	case errors.Is(err, services.ErrCampaignTranslation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCampaignNotEditable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
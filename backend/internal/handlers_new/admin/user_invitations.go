@@ -0,0 +1,137 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserInvitationRequest invites someone to create an admin or staff account
+type UserInvitationRequest struct {
+	Email      string `json:"email" binding:"required,email"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Role       string `json:"role" binding:"required"`
+	AdminScope string `json:"admin_scope"` // Optional scoped admin role; empty for a full admin
+	Department string `json:"department"`
+	Position   string `json:"position"`
+}
+
+// invitationID parses the :id parameter
+func invitationID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// invitationError writes the response for an invitation service error
+func invitationError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvitationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvitationClosed), errors.Is(err, services.ErrInvitationPending),
+		errors.Is(err, services.ErrInvitationEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvitationRole), errors.Is(err, services.ErrInvitationScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// AdminListInvitations returns account invitations, optionally filtered by status
+func AdminListInvitations(c *gin.Context) {
+	invitations, err := services.NewUserInvitationService().List(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invitations"})
+		return
+	}
+
+	now := time.Now()
+	results := make([]gin.H, 0, len(invitations))
+	for i := range invitations {
+		results = append(results, gin.H{
+			"invitation": invitations[i],
+			"status":     invitations[i].Status(now),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"invitations": results})
+}
+
+// AdminCreateInvitation emails an invitation to create an admin or staff account
+func AdminCreateInvitation(c *gin.Context) {
+	var req UserInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation, err := services.NewUserInvitationService().Create(services.InvitationInput{
+		Email:      req.Email,
+		FirstName:  req.FirstName,
+		LastName:   req.LastName,
+		Role:       req.Role,
+		AdminScope: req.AdminScope,
+		Department: req.Department,
+		Position:   req.Position,
+	}, utils.GetUserIDFromContext(c))
+	if err != nil {
+		invitationError(c, err, "Failed to create invitation")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Invite", "UserInvitation", invitation.ID,
+		fmt.Sprintf("Invited %s as %s (scope %q), expires %s", invitation.Email, invitation.Role,
+			invitation.AdminScope, invitation.ExpiresAt.Format(time.RFC3339)))
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Invitation sent", "invitation": invitation})
+}
+
+// AdminResendInvitation sends a fresh link for an invitation and extends its expiry
+func AdminResendInvitation(c *gin.Context) {
+	id, ok := invitationID(c)
+	if !ok {
+		return
+	}
+
+	invitation, err := services.NewUserInvitationService().Resend(id)
+	if err != nil {
+		invitationError(c, err, "Failed to resend invitation")
+		return
+	}
+
+	utils.CreateAuditLog(c, "ResendInvitation", "UserInvitation", invitation.ID,
+		fmt.Sprintf("Resent invitation to %s (send %d), expires %s", invitation.Email,
+			invitation.SentCount, invitation.ExpiresAt.Format(time.RFC3339)))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation resent", "invitation": invitation})
+}
+
+// AdminRevokeInvitation cancels an invitation so its link no longer works
+func AdminRevokeInvitation(c *gin.Context) {
+	id, ok := invitationID(c)
+	if !ok {
+		return
+	}
+
+	invitation, err := services.NewUserInvitationService().Revoke(id, utils.GetUserIDFromContext(c))
+	if err != nil {
+		invitationError(c, err, "Failed to revoke invitation")
+		return
+	}
+
+	utils.CreateAuditLog(c, "RevokeInvitation", "UserInvitation", invitation.ID,
+		fmt.Sprintf("Revoked invitation to %s", invitation.Email))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation revoked", "invitation": invitation})
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
 	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 
 	"github.com/geoo115/charity-management-system/internal/handlers_new/shared"
@@ -59,17 +60,10 @@ func AdminGetDashboard(c *gin.Context) {
 		Where("DATE(shifts.date) = ? AND shift_assignments.status = ?", todayStr, "Confirmed").
 		Count(&assignedShifts)
 
-	// Urgent inventory needs - using hardcoded data instead of inventory queries
-	var urgentNeeds = []struct {
-		ID           uint
-		Category     string
-		Name         string
-		Description  string
-		UrgencyLevel string
-	}{
-		{1, "Food", "Canned Goods", "Non-perishable food items", "Critical"},
-		{2, "Clothing", "Winter Coats", "Warm winter coats", "High"},
-		{3, "Toiletries", "Hygiene Products", "Soap, shampoo, etc.", "Medium"},
+	// Stock items at or below their minimum level
+	urgentNeeds, err := services.NewInventoryService().Shortages()
+	if err != nil {
+		urgentNeeds = []services.InventoryItemView{}
 	}
 
 	// Recent activity
@@ -623,10 +617,10 @@ func generateSystemAlerts(todayRequests, assignedShifts, todayShifts int64, urge
 		})
 	}
 
-	if urgentNeeds > 3 {
+	if urgentNeeds > 0 {
 		alerts = append(alerts, gin.H{
 			"type":    "warning",
-			"message": fmt.Sprintf("%d urgent inventory needs require attention", urgentNeeds),
+			"message": fmt.Sprintf("%d stock items are at or below their minimum level", urgentNeeds),
 		})
 	}
 
@@ -735,35 +729,23 @@ func sendRejectionNotification(helpRequest models.HelpRequest, user models.User)
 }
 
 func sendTicketIssuedNotification(helpRequest models.HelpRequest) {
-	notificationService := shared.GetNotificationService() // Get the shared service
 	var user models.User
 	if err := db.DB.First(&user, helpRequest.VisitorID).Error; err != nil {
 		log.Printf("Failed to find user for ticket notification: %v", err)
 		return
 	}
 
-	if notificationService != nil {
-		data := notifications.NotificationData{
-			To:               user.Email,
-			Subject:          "Your Visit Ticket is Ready - " + helpRequest.TicketNumber,
-			TemplateType:     "ticket_issued", // Use string instead of enum type
-			NotificationType: notifications.EmailNotification,
-			TemplateData: map[string]interface{}{
-				"Name":         user.FirstName + " " + user.LastName,
-				"TicketNumber": helpRequest.TicketNumber,
-				"Reference":    helpRequest.Reference,
-				"Category":     helpRequest.Category,
-				"VisitDay":     helpRequest.VisitDay,
-				"TimeSlot":     helpRequest.TimeSlot,
-				"QRCode":       helpRequest.QRCode,
-				"Instructions": shared.GetVisitInstructions(),
-				"Requirements": shared.GetVisitRequirements(),
-			},
+	// Visitors without email receive their ticket by SMS instead
+	if user.Email == "" {
+		if err := services.NewPhoneAuthService().SendTicketSMS(user, helpRequest); err != nil {
+			log.Printf("Failed to send ticket SMS: %v", err)
 		}
+		return
+	}
 
-		if err := notificationService.SendNotification(data, user); err != nil {
-			log.Printf("Failed to send ticket issued notification: %v", err)
-		}
+	// The email follows the visitor's plain-language and large-print choices
+	if err := services.SendTicketIssuedEmail(user, helpRequest); err != nil {
+		log.Printf("Failed to send ticket issued notification: %v", err)
 	}
 }
 
@@ -812,7 +794,7 @@ func getDailyCapacity(visitDay, category string) int {
 		case models.CategoryGeneral:
 			return 20
 		default:
-			return 10
+			return serviceTypeCapacity(visitDay, category, 10)
 		}
 	}
 
@@ -823,6 +805,17 @@ func getDailyCapacity(visitDay, category string) int {
 	case models.CategoryGeneral:
 		return capacity.MaxGeneralVisits - capacity.CurrentGeneralVisits
 	default:
-		return 0
+		return serviceTypeCapacity(visitDay, category, 0)
+	}
+}
+
+// serviceTypeCapacity returns the remaining capacity for a configured service type,
+// or fallback if the category has no service type
+func serviceTypeCapacity(visitDay, category string, fallback int) int {
+	serviceTypeService := services.NewServiceTypeService()
+	serviceType, err := serviceTypeService.GetByCode(category)
+	if err != nil {
+		return fallback
 	}
+	return serviceTypeService.RemainingCapacity(serviceType, visitDay)
 }
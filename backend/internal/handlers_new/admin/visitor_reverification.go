@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReverificationPolicyRequest sets how often visitors re-verify their documents
+type ReverificationPolicyRequest struct {
+	Months    *int `json:"months" binding:"required"` // 0 turns re-verification off
+	GraceDays *int `json:"grace_days" binding:"required"`
+}
+
+// AdminGetReverificationPolicy returns the visitor re-verification policy
+func AdminGetReverificationPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"policy": services.NewVisitorReverificationService().Policy()})
+}
+
+// AdminUpdateReverificationPolicy sets how many months visitor verification lasts
+// and the grace period before new bookings are restricted
+func AdminUpdateReverificationPolicy(c *gin.Context) {
+	var req ReverificationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reverification := services.NewVisitorReverificationService()
+	previous := reverification.Policy()
+	policy := services.ReverificationPolicy{Months: *req.Months, GraceDays: *req.GraceDays}
+	if err := reverification.SetPolicy(policy, utils.GetUserIDFromContext(c)); err != nil {
+		if errors.Is(err, services.ErrInvalidReverificationPolicy) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update re-verification policy"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "SystemConfig", 0,
+		fmt.Sprintf("Visitor re-verification changed from every %d months with %d days grace to every %d months with %d days grace",
+			previous.Months, previous.GraceDays, policy.Months, policy.GraceDays))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Re-verification policy updated",
+		"policy":  reverification.Policy(),
+	})
+}
+
+// AdminListReverifications lists visitor re-verification cycles, most recently
+// flagged first. Pass status=due, restricted or completed to filter.
+func AdminListReverifications(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.ReverificationDue, models.ReverificationRestricted, models.ReverificationCompleted:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be due, restricted or completed"})
+		return
+	}
+	limit := 100
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+
+	cycles, err := services.NewVisitorReverificationService().Cycles(status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch re-verifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"reverifications": cycles,
+		"total":           len(cycles),
+	})
+}
+
+// AdminGetReverificationAnalytics reports how many flagged visitors re-verified, how
+// many did so within the grace period and how long it took, over a date range
+// (default the last 12 months)
+func AdminGetReverificationAnalytics(c *gin.Context) {
+	start, end, ok := slaDateRange(c, 365)
+	if !ok {
+		return
+	}
+
+	analytics, err := services.NewVisitorReverificationService().Analytics(start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate re-verification analytics"})
+		return
+	}
+	c.JSON(http.StatusOK, analytics)
+}
@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminGetVolunteerAvailabilityHeatmap returns, for each day from `start` (default
+// today) over `days` days (default 28), how many volunteers are away against the
+// shifts that still need filling
+func AdminGetVolunteerAvailabilityHeatmap(c *gin.Context) {
+	start := time.Now().Truncate(24 * time.Hour)
+	if value := c.Query("start"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start, expected YYYY-MM-DD"})
+			return
+		}
+		start = parsed
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", "28"))
+	if err != nil || days < 1 || days > 92 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 92"})
+		return
+	}
+
+	heatmap, err := services.NewVolunteerAwayService().Heatmap(start, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build availability heat map"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"start": start.Format("2006-01-02"),
+		"days":  heatmap,
+	})
+}
+
+// AdminGetAwayConflicts lists upcoming shift assignments flagged for a swap because
+// the volunteer has marked themselves away
+func AdminGetAwayConflicts(c *gin.Context) {
+	flagged, err := services.NewVolunteerAwayService().FlaggedAssignments()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch flagged assignments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"flagged": flagged,
+		"total":   len(flagged),
+	})
+}
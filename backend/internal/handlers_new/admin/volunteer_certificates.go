@@ -0,0 +1,112 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CertificateReviewRequest records a coordinator's decision on a certificate
+type CertificateReviewRequest struct {
+	Notes string `json:"notes"`
+}
+
+// AdminListVolunteerCertificates lists certificate requests, oldest first. Defaults
+// to the ones waiting for approval; pass status=all for every certificate.
+func AdminListVolunteerCertificates(c *gin.Context) {
+	status := c.DefaultQuery("status", models.VolunteerCertificatePending)
+	if status == "all" {
+		status = ""
+	}
+	limit := 100
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+
+	certificates, err := services.NewVolunteerCertificateService().List(status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch certificates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"certificates": certificates,
+		"total":        len(certificates),
+	})
+}
+
+// AdminApproveVolunteerCertificate signs a certificate so the volunteer can download it
+func AdminApproveVolunteerCertificate(c *gin.Context) {
+	reviewVolunteerCertificate(c, "Approve")
+}
+
+// AdminRejectVolunteerCertificate turns down a certificate request
+func AdminRejectVolunteerCertificate(c *gin.Context) {
+	reviewVolunteerCertificate(c, "Reject")
+}
+
+// AdminRevokeVolunteerCertificate withdraws an issued certificate, for example one
+// issued in error. Verifying it afterwards reports it as withdrawn.
+func AdminRevokeVolunteerCertificate(c *gin.Context) {
+	reviewVolunteerCertificate(c, "Revoke")
+}
+
+// reviewVolunteerCertificate applies a coordinator decision to a certificate
+func reviewVolunteerCertificate(c *gin.Context, action string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid certificate ID"})
+		return
+	}
+
+	var req CertificateReviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	certificates := services.NewVolunteerCertificateService()
+	reviewerID := utils.GetUserIDFromContext(c)
+	var certificate *models.VolunteerCertificate
+	switch action {
+	case "Approve":
+		certificate, err = certificates.Approve(uint(id), reviewerID, req.Notes)
+	case "Reject":
+		certificate, err = certificates.Reject(uint(id), reviewerID, req.Notes)
+	default:
+		certificate, err = certificates.Revoke(uint(id), reviewerID, req.Notes)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrCertificateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrCertificateNotPending), errors.Is(err, services.ErrCertificateNotIssued),
+			errors.Is(err, services.ErrCertificateNoHours):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update certificate"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, action, "VolunteerCertificate", certificate.ID,
+		fmt.Sprintf("Certificate for %s (%.1f hours) %s", certificate.VolunteerName, certificate.TotalHours, certificate.Status))
+
+	response := gin.H{
+		"message":     fmt.Sprintf("Certificate %s", certificate.Status),
+		"certificate": certificate,
+	}
+	if certificate.VerificationCode != nil && certificate.Status == models.VolunteerCertificateApproved {
+		response["verify_url"] = services.CertificateVerifyURL(*certificate.VerificationCode)
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
 	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 
 	"github.com/geoo115/charity-management-system/internal/handlers_new/shared"
@@ -19,11 +20,12 @@ import (
 
 // AdminVolunteerShiftAssignment represents the data needed for admin shift assignment
 type AdminVolunteerShiftAssignment struct {
-	VolunteerID    uint   `json:"volunteerId" binding:"required"`
-	ShiftIDs       []uint `json:"shiftIds" binding:"required"`
-	Notes          string `json:"notes"`
-	OverrideSkills bool   `json:"overrideSkills"`
-	SendEmail      bool   `json:"sendEmail"`
+	VolunteerID       uint   `json:"volunteerId" binding:"required"`
+	ShiftIDs          []uint `json:"shiftIds" binding:"required"`
+	Notes             string `json:"notes"`
+	OverrideSkills    bool   `json:"overrideSkills"`
+	OverrideDocuments bool   `json:"overrideDocuments"`
+	SendEmail         bool   `json:"sendEmail"`
 }
 
 // AdminShiftReassignment represents data for reassigning shifts
@@ -152,6 +154,22 @@ func AdminAssignShifts(c *gin.Context) {
 			}
 		}
 
+		// Check required documents unless overridden
+		if !req.OverrideDocuments {
+			missing, err := services.NewVolunteerDocumentService().MissingDocuments(req.VolunteerID, shift.Role, shift.Date)
+			if err != nil {
+				log.Printf("Failed to check documents for volunteer %d: %v", req.VolunteerID, err)
+			} else if len(missing) > 0 {
+				failedAssignments = append(failedAssignments, gin.H{
+					"shiftId":          shiftID,
+					"reason":           "volunteer is missing valid documents required for this role",
+					"role":             shift.Role,
+					"missingDocuments": missing,
+				})
+				continue
+			}
+		}
+
 		// All checks passed - assign the shift
 		volunteerID := req.VolunteerID
 		shift.AssignedVolunteerID = &volunteerID
@@ -1047,6 +1065,19 @@ func AdminAssignVolunteerToShifts(c *gin.Context) {
 			continue
 		}
 
+		// Check required documents unless overridden
+		if !req.OverrideDocuments {
+			missing, err := services.NewVolunteerDocumentService().MissingDocuments(req.VolunteerID, shift.Role, shift.Date)
+			if err == nil && len(missing) > 0 {
+				failed = append(failed, gin.H{
+					"shift_id":          shiftID,
+					"reason":            "Volunteer is missing required documents",
+					"missing_documents": missing,
+				})
+				continue
+			}
+		}
+
 		// Assign the volunteer to the shift
 		if err := db.DB.Model(&shift).Update("assigned_volunteer_id", req.VolunteerID).Error; err != nil {
 			failed = append(failed, gin.H{
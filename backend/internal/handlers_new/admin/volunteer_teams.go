@@ -0,0 +1,217 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VolunteerTeamRequest creates or updates a volunteer team
+type VolunteerTeamRequest struct {
+	Name                 string `json:"name" binding:"required"`
+	Description          string `json:"description"`
+	LeadID               uint   `json:"lead_id" binding:"required"`
+	MemberIDs            []uint `json:"member_ids"` // Only used when creating
+	CanConfirmAttendance bool   `json:"can_confirm_attendance"`
+	CanApproveHours      bool   `json:"can_approve_hours"`
+	Active               *bool  `json:"active"`
+}
+
+// VolunteerTeamMemberRequest adds a volunteer to a team
+type VolunteerTeamMemberRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// volunteerTeamError maps volunteer team errors to responses
+func volunteerTeamError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrVolunteerTeamNotFound), errors.Is(err, services.ErrVolunteerNotInTeam):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrVolunteerTeamInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrVolunteerInAnotherTeam), errors.Is(err, services.ErrTeamLeadCannotBeRemoved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// volunteerTeamID parses the team ID route parameter
+func volunteerTeamID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// AdminListVolunteerTeams lists every volunteer team with its lead and members
+func AdminListVolunteerTeams(c *gin.Context) {
+	teams, err := services.NewVolunteerTeamService().Teams()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch volunteer teams"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"teams": teams,
+		"total": len(teams),
+	})
+}
+
+// AdminGetVolunteerTeam returns a volunteer team with this month's statistics
+func AdminGetVolunteerTeam(c *gin.Context) {
+	id, ok := volunteerTeamID(c)
+	if !ok {
+		return
+	}
+
+	service := services.NewVolunteerTeamService()
+	team, err := service.Team(id)
+	if err != nil {
+		volunteerTeamError(c, err, "Failed to fetch volunteer team")
+		return
+	}
+	stats, err := service.Stats(team, time.Now())
+	if err != nil {
+		volunteerTeamError(c, err, "Failed to fetch volunteer team")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"team":  team,
+		"stats": stats,
+	})
+}
+
+// AdminCreateVolunteerTeam creates a team under a lead volunteer
+func AdminCreateVolunteerTeam(c *gin.Context) {
+	var req VolunteerTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	team, err := services.NewVolunteerTeamService().CreateTeam(
+		volunteerTeamFromRequest(req), req.MemberIDs, utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		volunteerTeamError(c, err, "Failed to create volunteer team")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "VolunteerTeam", team.ID,
+		fmt.Sprintf("Created volunteer team %s with %d members", team.Name, len(team.Members)))
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Volunteer team created",
+		"team":    team,
+	})
+}
+
+// AdminUpdateVolunteerTeam changes a team's details, lead and permissions
+func AdminUpdateVolunteerTeam(c *gin.Context) {
+	id, ok := volunteerTeamID(c)
+	if !ok {
+		return
+	}
+	var req VolunteerTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	team, err := services.NewVolunteerTeamService().UpdateTeam(
+		id, volunteerTeamFromRequest(req), utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		volunteerTeamError(c, err, "Failed to update volunteer team")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "VolunteerTeam", team.ID,
+		fmt.Sprintf("Updated volunteer team %s (confirm attendance: %t, approve hours: %t)",
+			team.Name, team.CanConfirmAttendance, team.CanApproveHours))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Volunteer team updated",
+		"team":    team,
+	})
+}
+
+// AdminDeleteVolunteerTeam removes a team and releases its members
+func AdminDeleteVolunteerTeam(c *gin.Context) {
+	id, ok := volunteerTeamID(c)
+	if !ok {
+		return
+	}
+	if err := services.NewVolunteerTeamService().DeleteTeam(id); err != nil {
+		volunteerTeamError(c, err, "Failed to delete volunteer team")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "VolunteerTeam", id, "Deleted volunteer team")
+	c.JSON(http.StatusOK, gin.H{"message": "Volunteer team deleted"})
+}
+
+// AdminAddVolunteerTeamMember puts a volunteer in a team
+func AdminAddVolunteerTeamMember(c *gin.Context) {
+	id, ok := volunteerTeamID(c)
+	if !ok {
+		return
+	}
+	var req VolunteerTeamMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.NewVolunteerTeamService().AddMember(id, req.UserID, utils.GetUserIDFromContext(c), time.Now()); err != nil {
+		volunteerTeamError(c, err, "Failed to add team member")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "VolunteerTeam", id, fmt.Sprintf("Added volunteer %d to the team", req.UserID))
+	c.JSON(http.StatusOK, gin.H{"message": "Volunteer added to the team"})
+}
+
+// AdminRemoveVolunteerTeamMember takes a volunteer out of a team
+func AdminRemoveVolunteerTeamMember(c *gin.Context) {
+	id, ok := volunteerTeamID(c)
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := services.NewVolunteerTeamService().RemoveMember(id, uint(userID)); err != nil {
+		volunteerTeamError(c, err, "Failed to remove team member")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "VolunteerTeam", id, fmt.Sprintf("Removed volunteer %d from the team", userID))
+	c.JSON(http.StatusOK, gin.H{"message": "Volunteer removed from the team"})
+}
+
+// volunteerTeamFromRequest builds the team a request describes. Teams are active
+// unless the request says otherwise.
+func volunteerTeamFromRequest(req VolunteerTeamRequest) *models.VolunteerTeam {
+	team := &models.VolunteerTeam{
+		Name:                 req.Name,
+		Description:          req.Description,
+		LeadID:               req.LeadID,
+		CanConfirmAttendance: req.CanConfirmAttendance,
+		CanApproveHours:      req.CanApproveHours,
+		Active:               true,
+	}
+	if req.Active != nil {
+		team.Active = *req.Active
+	}
+	return team
+}
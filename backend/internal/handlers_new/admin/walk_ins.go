@@ -0,0 +1,323 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WalkInRequest holds the minimal details collected from a walk-in visitor
+type WalkInRequest struct {
+	FirstName           string `json:"first_name" binding:"required"`
+	LastName            string `json:"last_name" binding:"required"`
+	Phone               string `json:"phone"`
+	Postcode            string `json:"postcode"`
+	Category            string `json:"category" binding:"required"`
+	HouseholdSize       int    `json:"household_size"`
+	Notes               string `json:"notes"`
+	OverrideEligibility bool   `json:"override_eligibility"`
+}
+
+// MergeWalkInRequest identifies the existing account a walk-in belongs to
+type MergeWalkInRequest struct {
+	TargetUserID uint `json:"target_user_id" binding:"required"`
+}
+
+// RegisterWalkIn registers a visitor at the front desk and issues a same-day ticket
+// or adds them to the standby list
+func RegisterWalkIn(c *gin.Context) {
+	var req WalkInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := services.NewWalkInService().RegisterWalkIn(services.WalkInInput{
+		FirstName:           req.FirstName,
+		LastName:            req.LastName,
+		Phone:               req.Phone,
+		Postcode:            req.Postcode,
+		Category:            req.Category,
+		HouseholdSize:       req.HouseholdSize,
+		Notes:               req.Notes,
+		OverrideEligibility: req.OverrideEligibility,
+	}, utils.GetUserIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "WalkInRegistration", result.Registration.ID,
+		fmt.Sprintf("Walk-in registered for visitor %d: %s", result.Visitor.ID, result.Registration.Outcome))
+
+	message := "Visitor is not eligible for a visit today"
+	switch result.Registration.Outcome {
+	case models.WalkInOutcomeTicketIssued:
+		message = "Same-day ticket issued"
+	case models.WalkInOutcomeStandby:
+		message = fmt.Sprintf("Today's capacity is full. Visitor added to the standby list at position %d", result.StandbyPosition)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": message,
+		"result":  result,
+	})
+}
+
+// CheckWalkInEligibility looks up a visitor by phone and runs the quick eligibility
+// check before anything is registered
+func CheckWalkInEligibility(c *gin.Context) {
+	phone := c.Query("phone")
+	category := c.Query("category")
+	if phone == "" || category == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "phone and category are required"})
+		return
+	}
+
+	visitor, err := services.NewPhoneAuthService().FindUserByPhone(phone)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"found":   false,
+			"message": "No visitor is registered with this phone number",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"found": true,
+		"visitor": gin.H{
+			"id":         visitor.ID,
+			"first_name": visitor.FirstName,
+			"last_name":  visitor.LastName,
+			"postcode":   visitor.Postcode,
+		},
+		"eligibility": services.NewWalkInService().CheckEligibility(visitor, category),
+	})
+}
+
+// ListWalkIns returns walk-in registrations for a day, defaulting to today
+func ListWalkIns(c *gin.Context) {
+	day := c.DefaultQuery("date", time.Now().Format("2006-01-02"))
+
+	query := db.DB.Where("visit_day = ?", day)
+	if outcome := c.Query("outcome"); outcome != "" {
+		query = query.Where("outcome = ?", outcome)
+	}
+
+	var registrations []models.WalkInRegistration
+	if err := query.Preload("Visitor", selectUserSummary).
+		Preload("Ticket").
+		Preload("StandbyEntry").
+		Order("created_at DESC").
+		Find(&registrations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch walk-ins"})
+		return
+	}
+
+	summary := map[string]int{}
+	for _, registration := range registrations {
+		summary[registration.Outcome]++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":          day,
+		"registrations": registrations,
+		"summary":       summary,
+		"total":         len(registrations),
+	})
+}
+
+// GetWalkInDuplicates returns existing visitor accounts that may belong to a walk-in
+func GetWalkInDuplicates(c *gin.Context) {
+	registration, ok := loadWalkIn(c)
+	if !ok {
+		return
+	}
+
+	var visitor models.User
+	if err := db.DB.First(&visitor, registration.VisitorID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Visitor not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"registration": registration,
+		"duplicates":   services.NewWalkInService().FindPossibleDuplicates(&visitor),
+	})
+}
+
+// MergeWalkIn merges the account created for a walk-in into the visitor's existing account
+func MergeWalkIn(c *gin.Context) {
+	registration, ok := loadWalkIn(c)
+	if !ok {
+		return
+	}
+
+	var req MergeWalkInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sourceID := registration.VisitorID
+	merged, err := services.NewWalkInService().MergeWalkIn(registration.ID, req.TargetUserID, utils.GetUserIDFromContext(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrWalkInAlreadyMerged), errors.Is(err, services.ErrWalkInNotNewVisitor):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrMergeSameVisitor), errors.Is(err, services.ErrMergeTargetInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge visitor records"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "Merge", "User", req.TargetUserID,
+		fmt.Sprintf("Walk-in account %d merged into visitor %d", sourceID, req.TargetUserID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Walk-in merged into existing visitor record",
+		"registration": merged,
+	})
+}
+
+// ListStandby returns the standby list for a day and service, defaulting to today
+func ListStandby(c *gin.Context) {
+	day := c.DefaultQuery("date", time.Now().Format("2006-01-02"))
+
+	query := db.DB.Where("visit_day = ?", day)
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if status := c.Query("status"); status != "" && status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	var entries []models.StandbyEntry
+	if err := query.Preload("Visitor", selectUserSummary).
+		Order("priority DESC, created_at ASC").
+		Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch standby list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":    day,
+		"standby": entries,
+		"total":   len(entries),
+	})
+}
+
+// ClaimStandbyForVisitor claims an offered place on behalf of a visitor at the front desk
+func ClaimStandbyForVisitor(c *gin.Context) {
+	entryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid standby ID"})
+		return
+	}
+
+	staffID := utils.GetUserIDFromContext(c)
+	entry, ticket, err := services.NewStandbyService().ClaimOffer(uint(entryID), 0, staffID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Standby entry not found"})
+		case errors.Is(err, services.ErrStandbyNotOffered):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrStandbyOfferExpired):
+			c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to claim standby place"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "Claim", "StandbyEntry", entry.ID,
+		fmt.Sprintf("Standby place claimed for visitor %d at front desk, ticket %s issued", entry.VisitorID, ticket.TicketNumber))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Same-day ticket issued",
+		"standby": entry,
+		"ticket":  ticket,
+	})
+}
+
+// ReleaseStandbyCapacity runs the same-day capacity release now instead of waiting for
+// the scheduled job. Nothing is released before the daily cutoff.
+func ReleaseStandbyCapacity(c *gin.Context) {
+	standbyService := services.NewStandbyService()
+	now := time.Now()
+	if !standbyService.PastCutoff(now) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Unused capacity cannot be released before the daily cutoff"})
+		return
+	}
+
+	releases, err := standbyService.RunRelease(now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to release capacity"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Release", "CapacityRelease", 0,
+		fmt.Sprintf("Same-day capacity released manually for %d services", len(releases)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Unused capacity released",
+		"releases": releases,
+	})
+}
+
+// GetStandbyConversion returns release history and offer conversion rates for a day
+func GetStandbyConversion(c *gin.Context) {
+	day := c.DefaultQuery("date", time.Now().Format("2006-01-02"))
+
+	conversions, err := services.NewStandbyService().ConversionStats(day)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate standby conversion"})
+		return
+	}
+
+	var releases []models.CapacityRelease
+	if err := db.DB.Where("visit_day = ?", day).Order("created_at ASC").Find(&releases).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch capacity releases"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":        day,
+		"conversions": conversions,
+		"releases":    releases,
+	})
+}
+
+// loadWalkIn loads the walk-in registration identified by the :id path parameter
+func loadWalkIn(c *gin.Context) (*models.WalkInRegistration, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid walk-in ID"})
+		return nil, false
+	}
+
+	var registration models.WalkInRegistration
+	if err := db.DB.First(&registration, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Walk-in not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch walk-in"})
+		}
+		return nil, false
+	}
+
+	return &registration, true
+}
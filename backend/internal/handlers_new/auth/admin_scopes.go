@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validateAdminScope checks an admin scope can be given to a user with the role
+func validateAdminScope(role, scope string) error {
+	if scope == "" {
+		return nil
+	}
+	if _, ok := models.FindAdminScope(scope); !ok {
+		return fmt.Errorf("unknown admin scope: %s", scope)
+	}
+	if role != models.RoleAdmin && role != models.RoleAdminLegacy {
+		return fmt.Errorf("admin scopes can only be given to admin users")
+	}
+	return nil
+}
+
+// ListAdminScopes returns the scoped admin roles that can be chosen when inviting an admin
+func ListAdminScopes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"scopes": models.AdminScopes})
+}
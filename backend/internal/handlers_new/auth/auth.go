@@ -17,6 +17,7 @@ import (
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
 	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 
 	"github.com/geoo115/charity-management-system/internal/handlers_new/shared"
@@ -133,6 +134,18 @@ func Register(c *gin.Context) {
 	} else if req.Phone != "" {
 		user.Phone = req.Phone
 	}
+	if user.Phone != "" {
+		phone, err := models.NormalizePhone(user.Phone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if services.NewPhoneAuthService().PhoneInUse(phone, 0) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Phone number already registered"})
+			return
+		}
+		user.Phone = phone
+	}
 	user.Address = req.Address
 	user.City = req.City
 	user.Postcode = req.Postcode
@@ -286,7 +299,7 @@ func Register(c *gin.Context) {
 	}
 
 	// Generate JWT token
-	token, err := auth.GenerateToken(user.ID, user.Email, user.Role)
+	token, err := auth.GenerateToken(user.ID, user.Email, user.Role, user.TokenVersion)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
@@ -399,6 +412,11 @@ login_success:
 		return
 	}
 
+	if user.Status == models.UserStatusPendingDeletion {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This account is scheduled for deletion. Use the link in your email to cancel and restore it."})
+		return
+	}
+
 	// Check if user is active for all other cases
 	if user.Status != "active" {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Account is not active"})
@@ -415,14 +433,14 @@ login_success:
 	}
 
 	// Generate JWT token
-	token, err := auth.GenerateToken(user.ID, user.Email, user.Role)
+	token, err := auth.GenerateToken(user.ID, user.Email, user.Role, user.TokenVersion)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
 	// Generate refresh token
-	refreshToken, err := auth.GenerateRefreshToken(user.ID, user.Email, user.Role)
+	refreshToken, err := auth.GenerateRefreshToken(user.ID, user.Email, user.Role, user.TokenVersion)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
 		return
@@ -530,7 +548,7 @@ func RefreshTokenHandler(c *gin.Context) {
 	}
 
 	// Validate refresh token using auth service instead of utils
-	claims, err := auth.ValidateToken(req.RefreshToken)
+	claims, err := auth.ValidateRefreshToken(req.RefreshToken)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
 		return
@@ -543,8 +561,14 @@ func RefreshTokenHandler(c *gin.Context) {
 		return
 	}
 
+	// Refresh tokens issued before a forced logout cannot be used
+	if claims.TokenVersion != user.TokenVersion {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has ended, please sign in again"})
+		return
+	}
+
 	// Generate new access token using auth service
-	newToken, err := auth.GenerateToken(user.ID, user.Email, user.Role)
+	newToken, err := auth.GenerateToken(user.ID, user.Email, user.Role, user.TokenVersion)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
@@ -553,7 +577,7 @@ func RefreshTokenHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"access_token": newToken,
 		"token_type":   "Bearer",
-		"expires_in":   3600, // 1 hour
+		"expires_in":   int(auth.AccessTokenTTL(user.Role).Seconds()),
 	})
 }
 
@@ -984,20 +1008,6 @@ func generateSecureToken(length int) (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
-// GetJWTSecret returns the JWT secret from environment or a default value
-func GetJWTSecret() string {
-	secret := getEnv("JWT_SECRET")
-	if secret == "" {
-		// Fallback for quick local development only - log a clear warning so it is not overlooked
-		log.Printf("WARNING: JWT_SECRET is not set. Using insecure default secret for development only. Set JWT_SECRET in your environment for production.")
-		return "default_secret_for_development_only"
-	}
-	if len(secret) < 32 {
-		log.Printf("WARNING: JWT_SECRET is set but appears short (<32 characters). Use a strong secret of at least 32 characters in production.")
-	}
-	return secret
-}
-
 // Helper function to get an environment variable with fallback
 func getenv(key, fallback string) string {
 	value := getEnv(key)
@@ -1119,6 +1129,13 @@ func GetCurrentUser(c *gin.Context) {
 		"postcode":       user.Postcode,
 	}
 
+	if user.AdminScope != "" {
+		if definition, ok := models.FindAdminScope(user.AdminScope); ok {
+			response["admin_scope"] = user.AdminScope
+			response["admin_modules"] = definition.Modules
+		}
+	}
+
 	// Add role-specific information
 	switch user.Role {
 	case models.RoleVisitor:
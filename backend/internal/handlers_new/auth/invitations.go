@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AcceptInvitationRequest sets the password and profile for an invited account
+type AcceptInvitationRequest struct {
+	Token     string `json:"token" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Phone     string `json:"phone"`
+	Address   string `json:"address"`
+	City      string `json:"city"`
+	Postcode  string `json:"postcode"`
+}
+
+// invitationOnlyRole reports whether accounts with the role can only be created by
+// invitation
+func invitationOnlyRole(role string) bool {
+	switch role {
+	case models.RoleAdmin, models.RoleAdminLegacy, models.RoleSuperAdmin, models.RoleSuperAdminLegacy,
+		models.RoleStaff, models.RoleStaffLegacy:
+		return true
+	}
+	return false
+}
+
+// GetInvitation returns the details of an open invitation so the invitee can confirm them
+func GetInvitation(c *gin.Context) {
+	invitation, err := services.NewUserInvitationService().Lookup(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvitationInvalid) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invitation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"email":       invitation.Email,
+		"first_name":  invitation.FirstName,
+		"last_name":   invitation.LastName,
+		"role":        normalizeRoleForFrontend(invitation.Role),
+		"admin_scope": invitation.AdminScope,
+		"expires_at":  invitation.ExpiresAt,
+	})
+}
+
+// AcceptInvitation creates the invited account once the invitee has chosen a password
+func AcceptInvitation(c *gin.Context) {
+	var req AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validatePasswordStrength(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Phone != "" {
+		if _, err := models.NormalizePhone(req.Phone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	user, invitation, err := services.NewUserInvitationService().Accept(req.Token, services.AcceptInvitationInput{
+		Password:  req.Password,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Phone:     req.Phone,
+		Address:   req.Address,
+		City:      req.City,
+		Postcode:  req.Postcode,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvitationInvalid):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvitationEmailTaken), errors.Is(err, services.ErrInvitationPhoneTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Printf("Failed to accept invitation: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "AcceptInvitation", "UserInvitation", invitation.ID,
+		fmt.Sprintf("%s accepted their %s invitation and created user %d", user.Email, user.Role, user.ID))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Account created. You can now sign in.",
+		"user": gin.H{
+			"id":         user.ID,
+			"first_name": user.FirstName,
+			"last_name":  user.LastName,
+			"email":      user.Email,
+			"role":       normalizeRoleForFrontend(user.Role),
+		},
+	})
+}
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/geoo115/charity-management-system/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS publishes the public keys tokens are signed with so other services can verify
+// them. The list is empty while tokens are signed with JWT_SECRET.
+func JWKS(c *gin.Context) {
+	keys, err := auth.PublicKeys()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Signing keys are not available"})
+		return
+	}
+
+	// Verifiers cache the keys; a short max-age lets them pick up a rotation quickly
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
@@ -99,6 +99,17 @@ func (h *MiddlewareHandler) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Tokens issued before a forced logout are no longer accepted
+		if err := auth.CheckTokenVersion(claims, user.TokenVersion); err != nil {
+			c.JSON(401, shared.StandardResponse{
+				Success:   false,
+				Error:     "Session has ended, please sign in again",
+				Timestamp: time.Now(),
+			})
+			c.Abort()
+			return
+		}
+
 		// Set user information in context
 		c.Set("userID", user.ID)
 		c.Set("userEmail", user.Email)
@@ -210,8 +221,8 @@ func (h *MiddlewareHandler) OptionalAuth() gin.HandlerFunc {
 			return
 		}
 
-		// Check if user is active
-		if user.Status != "active" {
+		// Check if user is active and the token predates no forced logout
+		if user.Status != "active" || auth.CheckTokenVersion(claims, user.TokenVersion) != nil {
 			c.Next()
 			return
 		}
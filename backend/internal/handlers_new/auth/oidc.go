@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/auth"
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OIDCCallbackRequest carries the code and state the provider redirected back with
+type OIDCCallbackRequest struct {
+	State string `json:"state" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// ListOIDCProviders returns the external sign-in options for the login page
+func ListOIDCProviders(c *gin.Context) {
+	providers, err := services.NewOIDCService().EnabledProviders()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load sign-in providers"})
+		return
+	}
+
+	options := make([]gin.H, 0, len(providers))
+	for _, provider := range providers {
+		options = append(options, gin.H{"key": provider.Key, "name": provider.Name})
+	}
+	c.JSON(http.StatusOK, gin.H{"providers": options})
+}
+
+// StartOIDCLogin returns the provider URL to send the user to for sign-in
+func StartOIDCLogin(c *gin.Context) {
+	authorizationURL, err := services.NewOIDCService().StartLogin(c.Param("provider"))
+	if err != nil {
+		if errors.Is(err, services.ErrOIDCProviderNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Failed to start sign-in with %s: %v", c.Param("provider"), err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Sign-in with this provider is unavailable"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authorizationURL})
+}
+
+// CompleteOIDCLogin finishes an external sign-in and returns tokens like Login
+func CompleteOIDCLogin(c *gin.Context) {
+	var req OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := services.NewOIDCService().CompleteLogin(req.State, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOIDCStateInvalid), errors.Is(err, services.ErrOIDCTokenInvalid),
+			errors.Is(err, services.ErrOIDCEmailUnverified), errors.Is(err, services.ErrOIDCNoAccount):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrOIDCPasswordOnly):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "password_login": true})
+		case errors.Is(err, services.ErrOIDCProviderNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			log.Printf("External sign-in failed: %v", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Sign-in with the provider failed"})
+		}
+		return
+	}
+	user := result.User
+
+	if user.Status != "active" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is not active"})
+		return
+	}
+
+	now := time.Now()
+	if err := db.DB.Model(user).Updates(map[string]interface{}{
+		"last_login":  &now,
+		"first_login": false,
+	}).Error; err != nil {
+		log.Printf("Failed to update last login: %v", err)
+	}
+
+	token, err := auth.GenerateToken(user.ID, user.Email, user.Role, user.TokenVersion)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	refreshToken, err := auth.GenerateRefreshToken(user.ID, user.Email, user.Role, user.TokenVersion)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
+		return
+	}
+
+	description := fmt.Sprintf("User logged in with external provider: %s", user.Email)
+	switch {
+	case result.Provisioned:
+		description = fmt.Sprintf("Staff account created on first external sign-in: %s", user.Email)
+	case result.Linked:
+		description = fmt.Sprintf("External sign-in linked to existing account: %s", user.Email)
+	}
+	utils.CreateAuditLog(c, "Login", "User", user.ID, description)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Login successful",
+		"token":         token,
+		"refresh_token": refreshToken,
+		"user": gin.H{
+			"id":         user.ID,
+			"first_name": user.FirstName,
+			"last_name":  user.LastName,
+			"email":      user.Email,
+			"role":       normalizeRoleForFrontend(user.Role),
+			"status":     user.Status,
+		},
+		"linked":      result.Linked,
+		"provisioned": result.Provisioned,
+		"success":     true,
+	})
+}
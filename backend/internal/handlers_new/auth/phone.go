@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/auth"
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PhoneRegisterRequest holds the data needed to register a visitor by phone number
+type PhoneRegisterRequest struct {
+	FirstName     string `json:"first_name" binding:"required"`
+	LastName      string `json:"last_name" binding:"required"`
+	Phone         string `json:"phone" binding:"required"`
+	Email         string `json:"email" binding:"omitempty,email"`
+	Address       string `json:"address"`
+	City          string `json:"city"`
+	Postcode      string `json:"postcode"`
+	HouseholdSize int    `json:"household_size"`
+}
+
+// PhoneOTPRequest asks for a one-time code to be sent to a phone number
+type PhoneOTPRequest struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+// PhoneOTPVerifyRequest holds a one-time code entered by the user
+type PhoneOTPVerifyRequest struct {
+	Phone string `json:"phone" binding:"required"`
+	Code  string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// RegisterWithPhone creates a visitor account identified by phone number, for
+// visitors who have no email address, and sends a code to verify the number
+func RegisterWithPhone(c *gin.Context) {
+	var req PhoneRegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	phone, err := models.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	phoneService := services.NewPhoneAuthService()
+	if phoneService.PhoneInUse(phone, 0) {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrPhoneInUse.Error()})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email != "" {
+		var existing models.User
+		if err := db.DB.Where("email = ?", email).First(&existing).Error; err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+			return
+		}
+	}
+
+	// Phone-only visitors sign in with one-time codes, so the password is random and never shown
+	token, err := generateSecureToken(16)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
+		return
+	}
+
+	user := models.User{
+		FirstName:  req.FirstName,
+		LastName:   req.LastName,
+		Email:      email,
+		Phone:      phone,
+		Password:   token + "Aa1!",
+		Role:       models.RoleVisitor,
+		Status:     "active",
+		FirstLogin: true,
+		Address:    req.Address,
+		City:       req.City,
+		Postcode:   req.Postcode,
+	}
+	if err := user.HashPassword(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
+		return
+	}
+
+	if err := db.DB.Create(&user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
+
+	householdSize := req.HouseholdSize
+	if householdSize < 1 {
+		householdSize = 1
+	}
+	if err := db.DB.Create(&models.VisitorProfile{UserID: user.ID, HouseholdSize: householdSize}).Error; err != nil {
+		log.Printf("Failed to create visitor profile for user %d: %v", user.ID, err)
+	}
+
+	// SMS is the only channel for visitors without email
+	preferences := models.NotificationPreferences{
+		UserID:         user.ID,
+		EmailEnabled:   email != "",
+		SMSEnabled:     true,
+		PushEnabled:    true,
+		UpcomingShifts: true,
+		ShiftReminders: true,
+		ShiftUpdates:   true,
+		SystemUpdates:  true,
+	}
+	if err := db.DB.Create(&preferences).Error; err != nil {
+		log.Printf("Failed to create notification preferences: %v", err)
+	}
+
+	utils.CreateAuditLog(c, "Register", "User", user.ID, "Visitor registered by phone number")
+
+	if err := phoneService.RequestOTP(phone, models.OTPPurposeVerifyPhone, c.ClientIP()); err != nil {
+		log.Printf("Failed to send verification code to user %d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":              "Account created. We have sent a 6-digit code to your phone.",
+		"verificationRequired": true,
+		"phone":                models.MaskPhone(phone),
+		"user": gin.H{
+			"id":         user.ID,
+			"first_name": user.FirstName,
+			"last_name":  user.LastName,
+			"role":       normalizeRoleForFrontend(user.Role),
+			"status":     user.Status,
+		},
+	})
+}
+
+// RequestPhoneOTP sends a one-time login code by SMS to visitor accounts. The response
+// is the same whether or not the number is registered so it cannot be used to
+// discover accounts.
+func RequestPhoneOTP(c *gin.Context) {
+	var req PhoneOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	phone, err := models.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	phoneService := services.NewPhoneAuthService()
+	if user, err := phoneService.FindUserByPhone(phone); err == nil && services.CanSignInByPhone(user) {
+		if err := phoneService.RequestOTP(phone, models.OTPPurposeLogin, c.ClientIP()); err != nil {
+			if errors.Is(err, services.ErrOTPRateLimited) {
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Failed to send login code: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If this number is registered, a 6-digit code has been sent by SMS",
+		"phone":   models.MaskPhone(phone),
+	})
+}
+
+// VerifyPhoneOTP signs a visitor in with a one-time code and marks their phone as
+// verified. It accepts codes sent at registration as well as login codes.
+func VerifyPhoneOTP(c *gin.Context) {
+	var req PhoneOTPVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	phone, err := models.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	phoneService := services.NewPhoneAuthService()
+	user, err := phoneService.FindUserByPhone(phone)
+	if err != nil || !services.CanSignInByPhone(user) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": services.ErrOTPInvalid.Error()})
+		return
+	}
+
+	if err := phoneService.VerifyOTP(phone, models.OTPPurposeLogin, req.Code); err != nil {
+		if err := phoneService.VerifyOTP(phone, models.OTPPurposeVerifyPhone, req.Code); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": services.ErrOTPInvalid.Error()})
+			return
+		}
+	}
+
+	if user.Status != "active" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is not active"})
+		return
+	}
+
+	if err := phoneService.MarkPhoneVerified(user); err != nil {
+		log.Printf("Failed to mark phone verified for user %d: %v", user.ID, err)
+	}
+
+	now := time.Now()
+	if err := db.DB.Model(user).Updates(map[string]interface{}{
+		"last_login":  &now,
+		"first_login": false,
+	}).Error; err != nil {
+		log.Printf("Failed to update last login: %v", err)
+	}
+
+	token, err := auth.GenerateToken(user.ID, user.Email, user.Role, user.TokenVersion)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	refreshToken, err := auth.GenerateRefreshToken(user.ID, user.Email, user.Role, user.TokenVersion)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Login", "User", user.ID, fmt.Sprintf("User logged in by SMS code: %s", models.MaskPhone(phone)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Login successful",
+		"token":         token,
+		"refresh_token": refreshToken,
+		"user": gin.H{
+			"id":             user.ID,
+			"first_name":     user.FirstName,
+			"last_name":      user.LastName,
+			"email":          user.Email,
+			"phone":          user.Phone,
+			"phone_verified": user.PhoneVerified,
+			"role":           normalizeRoleForFrontend(user.Role),
+			"status":         user.Status,
+		},
+		"success": true,
+	})
+}
+
+// ListDuplicatePhones returns phone numbers shared by more than one account so
+// admins can merge or correct them before phone numbers are enforced as unique
+func ListDuplicatePhones(c *gin.Context) {
+	duplicates, err := services.NewPhoneAuthService().DuplicatePhones()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find duplicate phone numbers"})
+		return
+	}
+
+	// Once every duplicate is resolved, phone numbers can be enforced as unique
+	if len(duplicates) == 0 {
+		if err := services.NewPhoneAuthService().EnsureUniquePhoneIndex(); err != nil {
+			log.Printf("Failed to create unique phone index: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"duplicates": duplicates,
+		"total":      len(duplicates),
+	})
+}
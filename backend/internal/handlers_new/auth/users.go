@@ -12,6 +12,7 @@ import (
 
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
 	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 
@@ -227,8 +228,21 @@ func UpdateUserProfile(c *gin.Context) {
 		hasChanges = true
 	}
 	if updates.Phone != "" && updates.Phone != user.Phone {
-		user.Phone = strings.TrimSpace(updates.Phone)
-		hasChanges = true
+		phone, err := models.NormalizePhone(updates.Phone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if phone != user.Phone {
+			if services.NewPhoneAuthService().PhoneInUse(phone, user.ID) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Phone number already in use"})
+				return
+			}
+			user.Phone = phone
+			user.PhoneVerified = false
+			user.PhoneVerifiedAt = nil
+			hasChanges = true
+		}
 	}
 	if updates.Address != "" && updates.Address != user.Address {
 		user.Address = strings.TrimSpace(updates.Address)
@@ -356,6 +370,12 @@ func CreateUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if invitationOnlyRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Admin and staff accounts must be created by invitation",
+		})
+		return
+	}
 
 	// Check for existing user
 	var existing models.User
@@ -407,6 +427,8 @@ func AdminUpdateUser(c *gin.Context) {
 		Role      string `json:"role"`
 		Status    string `json:"status"`
 		Password  string `json:"password"`
+		// AdminScope is only changed when sent; an empty string makes the user a full admin
+		AdminScope *string `json:"admin_scope"`
 	}
 	if err := c.ShouldBindJSON(&updates); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -427,14 +449,40 @@ func AdminUpdateUser(c *gin.Context) {
 		user.Email = updates.Email
 	}
 	if updates.Phone != "" {
-		user.Phone = updates.Phone
+		phone, err := models.NormalizePhone(updates.Phone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if services.NewPhoneAuthService().PhoneInUse(phone, user.ID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Phone number already in use"})
+			return
+		}
+		user.Phone = phone
 	}
-	if updates.Role != "" {
+	if updates.Role != "" && updates.Role != user.Role {
+		if invitationOnlyRole(updates.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Admin and staff roles can only be given by invitation",
+			})
+			return
+		}
 		user.Role = updates.Role
 	}
 	if updates.Status != "" {
 		user.Status = updates.Status
 	}
+	if updates.AdminScope != nil {
+		user.AdminScope = *updates.AdminScope
+	}
+	if user.Role != models.RoleAdmin && user.Role != models.RoleAdminLegacy {
+		// Scopes only apply to admins, so drop it when the role changes
+		user.AdminScope = ""
+	}
+	if err := validateAdminScope(user.Role, user.AdminScope); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if updates.Password != "" {
 		if err := user.HashPasswordWithValue(updates.Password); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
@@ -501,6 +549,19 @@ func ListUsers(c *gin.Context) {
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
+	if tag := c.Query("tag"); tag != "" {
+		tagged, err := services.NewTagService().Filter(query, "users.id", tag,
+			models.TagEntityVisitor, models.TagEntityVolunteer)
+		if err != nil {
+			if errors.Is(err, services.ErrTagNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to filter users by tag"})
+			return
+		}
+		query = tagged
+	}
 
 	// Count total records for pagination
 	var total int64
@@ -821,6 +882,7 @@ func GetProfile(c *gin.Context) {
 			"address":    user.Address,
 			"city":       user.City,
 			"postcode":   user.Postcode,
+			"language":   user.Language,
 		},
 	}
 
@@ -872,6 +934,14 @@ func UpdateProfile(c *gin.Context) {
 	if postcode, ok := req["postcode"]; ok {
 		user.Postcode = postcode.(string)
 	}
+	if value, ok := req["language"].(string); ok {
+		language, valid := notifications.NormalizeLanguage(value)
+		if !valid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "language must be a language tag such as en or pl"})
+			return
+		}
+		user.Language = language
+	}
 
 	// Save basic user updates
 	if err := db.DB.Save(&user).Error; err != nil {
@@ -0,0 +1,55 @@
+package donor
+
+import (
+	"net/http"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDonationDriveLeaderboard returns the public team leaderboard for a drive (public
+// endpoint). Teams are ranked by weight unless by=items is given.
+func GetDonationDriveLeaderboard(c *gin.Context) {
+	var drive models.DonationDrive
+	if err := db.DB.Where("slug = ? AND status <> ?", c.Param("slug"), models.DonationDriveDraft).
+		First(&drive).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Donation drive not found"})
+		return
+	}
+
+	by := services.DriveRankByWeight
+	if c.Query("by") == services.DriveRankByItems {
+		by = services.DriveRankByItems
+	}
+
+	leaderboard, err := services.NewDonationDriveService().Leaderboard(drive.ID, by)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load leaderboard"})
+		return
+	}
+
+	var totalWeight float64
+	totalItems := 0
+	for _, standing := range leaderboard {
+		totalWeight += standing.WeightKg
+		totalItems += standing.Items
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"drive": gin.H{
+			"name":        drive.Name,
+			"slug":        drive.Slug,
+			"description": drive.Description,
+			"starts_at":   drive.StartsAt,
+			"ends_at":     drive.EndsAt,
+			"status":      drive.Status,
+		},
+		"ranked_by":       by,
+		"leaderboard":     leaderboard,
+		"total_weight_kg": totalWeight,
+		"total_items":     totalItems,
+	})
+}
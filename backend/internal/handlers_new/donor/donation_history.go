@@ -0,0 +1,147 @@
+package donor
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListDonorDonations returns a page of the donor's own donations with their receipts,
+// Gift Aid status and the appeal or drive they went to. Filter with status (comma
+// separated), type=money or goods, and from/to dates (YYYY-MM-DD, inclusive). Pass
+// format=csv to download every matching donation.
+func ListDonorDonations(c *gin.Context) {
+	filter, err := donorDonationFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewDonorDonationService()
+	userID := utils.GetUserIDFromContext(c)
+	if c.Query("format") == "csv" {
+		donations, err := service.Export(userID, filter)
+		if err != nil {
+			donorDonationError(c, err, "Failed to export donations")
+			return
+		}
+		writeDonorDonationsCSV(c, donations)
+		return
+	}
+
+	page, err := service.List(userID, filter)
+	if err != nil {
+		donorDonationError(c, err, "Failed to fetch donations")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// DownloadDonorDonationReceipt returns a PDF receipt for one of the donor's received
+// donations
+func DownloadDonorDonationReceipt(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid donation ID"})
+		return
+	}
+
+	donation, pdf, err := services.NewDonorDonationService().Receipt(utils.GetUserIDFromContext(c), uint(id))
+	if err != nil {
+		donorDonationError(c, err, "Failed to build receipt")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=receipt-%s.pdf", donation.ReceiptNumber))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// donorDonationFilter reads the history filters from the query string
+func donorDonationFilter(c *gin.Context) (services.DonorDonationFilter, error) {
+	statuses, err := services.ParseDonorDonationStatuses(c.Query("status"))
+	if err != nil {
+		return services.DonorDonationFilter{}, err
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	filter := services.DonorDonationFilter{
+		Statuses: statuses,
+		Type:     c.Query("type"),
+		Page:     page,
+		Limit:    limit,
+	}
+
+	if value := c.Query("from"); value != "" {
+		from, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return filter, errors.New("from must be a date like 2025-04-06")
+		}
+		filter.From = &from
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return filter, errors.New("to must be a date like 2026-04-05")
+		}
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+	return filter, nil
+}
+
+// writeDonorDonationsCSV writes the donor's donations as a CSV download
+func writeDonorDonationsCSV(c *gin.Context, donations []services.DonorDonation) {
+	filename := fmt.Sprintf("my_donations_%s.csv", time.Now().Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Type", "text/csv")
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"Date", "Receipt", "Type", "Status", "Description", "Currency", "Amount", "Refunded", "Net", "Goods Value", "Gift Aid Status", "Gift Aid", "Campaign"})
+	for _, donation := range donations {
+		campaign := ""
+		if donation.Campaign != nil {
+			campaign = donation.Campaign.Name
+			if donation.Campaign.Team != "" {
+				campaign += " (" + donation.Campaign.Team + ")"
+			}
+		}
+		writer.Write([]string{
+			donation.Date.In(time.Local).Format("2006-01-02"),
+			donation.ReceiptNumber,
+			donation.Type,
+			donation.Status,
+			donation.Description,
+			donation.Currency,
+			fmt.Sprintf("%.2f", donation.Amount),
+			fmt.Sprintf("%.2f", donation.Refunded),
+			fmt.Sprintf("%.2f", donation.Net),
+			fmt.Sprintf("%.2f", donation.GoodsValue),
+			donation.GiftAidStatus,
+			fmt.Sprintf("%.2f", donation.GiftAid),
+			campaign,
+		})
+	}
+	writer.Flush()
+}
+
+// donorDonationError maps donor donation errors to responses
+func donorDonationError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrDonorDonationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrDonorDonationFilter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrDonationReceiptUnavailable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
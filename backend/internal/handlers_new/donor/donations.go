@@ -10,6 +10,7 @@ import (
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
 	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,6 +24,12 @@ type CreateDonationRequest struct {
 	Amount       float64 `json:"amount"`
 	Goods        string  `json:"goods"` // Changed from 'Items' to 'Goods'
 	Notes        string  `json:"notes"`
+	TeamCode     string  `json:"teamCode"`     // Credits a goods donation to a donation drive team
+	CampaignCode string  `json:"campaignCode"` // Campaign that brought the donor to us, for attribution
+	ReferralCode string  `json:"referralCode"` // Donor or partner who referred them
+
+	// Gift Aid declaration made with a monetary donation
+	GiftAid *GiftAidDeclarationRequest `json:"giftAid"`
 }
 
 // CreateDonation handles donation creation
@@ -43,6 +50,41 @@ func CreateDonation(c *gin.Context) {
 		return
 	}
 
+	// Attribute goods to a donation drive team when a team code is given
+	var driveTeamID *uint
+	if req.TeamCode != "" {
+		if req.Type != "goods" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Team codes can only be used for goods donations"})
+			return
+		}
+		team, drive, err := services.NewDonationDriveService().FindTeamByCode(req.TeamCode)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Team code not recognised"})
+			return
+		}
+		if drive.Status != models.DonationDriveActive || time.Now().After(drive.EndsAt) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This donation drive is not currently running"})
+			return
+		}
+		driveTeamID = &team.ID
+	}
+
+	giftAid := services.NewGiftAidService()
+	if req.GiftAid != nil {
+		if req.Type != "monetary" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Gift Aid can only be claimed on monetary donations"})
+			return
+		}
+		if !req.GiftAid.ConfirmTaxpayer {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Please confirm you are a UK taxpayer to add Gift Aid"})
+			return
+		}
+		if err := giftAid.Validate(req.GiftAid.input(), time.Now()); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// Create donation record
 	donation := models.Donation{
 		Name:         req.Name,
@@ -53,6 +95,7 @@ func CreateDonation(c *gin.Context) {
 		Goods:        req.Goods, // Changed from 'Items'
 		Status:       "pending",
 		Notes:        req.Notes,
+		DriveTeamID:  driveTeamID,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
@@ -67,6 +110,30 @@ func CreateDonation(c *gin.Context) {
 	var user models.User
 	db.DB.Where("email = ?", req.ContactEmail).First(&user)
 
+	if req.GiftAid != nil {
+		input := req.GiftAid.input()
+		input.DonationID = &donation.ID
+		if user.ID != 0 {
+			input.UserID = &user.ID
+		}
+		declaration, err := giftAid.Declare(input, time.Now())
+		if err != nil {
+			// The donation is kept; the donor can make the declaration from their profile
+			log.Printf("Failed to save Gift Aid declaration for donation %d: %v", donation.ID, err)
+		} else {
+			donation.GiftAidID = &declaration.ID
+		}
+	}
+
+	// A first donation starts the donor's welcome series
+	onboarding, err := services.NewDonorOnboardingService().Start(&donation, &user, services.DonorAcquisition{
+		CampaignCode: req.CampaignCode,
+		ReferralCode: req.ReferralCode,
+	}, time.Now())
+	if err != nil {
+		log.Printf("Failed to start onboarding for donation %d: %v", donation.ID, err)
+	}
+
 	// Send confirmation email
 	config := notifications.NotificationConfig{
 		Enabled: true,
@@ -82,8 +149,9 @@ func CreateDonation(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":  "Donation received successfully",
-		"donation": donation,
+		"message":        "Donation received successfully",
+		"donation":       donation,
+		"first_donation": onboarding != nil, // The welcome email asks new donors for their preferences
 	})
 }
 
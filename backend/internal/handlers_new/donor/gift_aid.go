@@ -0,0 +1,119 @@
+package donor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GiftAidDeclarationRequest is a donor's Gift Aid declaration. The donor confirms
+// they are a UK taxpayer and understand that if they pay less Income Tax or Capital
+// Gains Tax than the Gift Aid claimed on their donations, they must pay the difference.
+type GiftAidDeclarationRequest struct {
+	Title             string `json:"title"`
+	FirstName         string `json:"first_name" binding:"required"`
+	LastName          string `json:"last_name" binding:"required"`
+	HouseNameOrNumber string `json:"house_name_or_number" binding:"required"`
+	Postcode          string `json:"postcode"`
+	Overseas          bool   `json:"overseas"`
+	CoversPast        bool   `json:"covers_past"` // Also claim on donations from the last four years
+	ConfirmTaxpayer   bool   `json:"confirm_taxpayer"`
+}
+
+// input converts the request to a declaration for the service
+func (r GiftAidDeclarationRequest) input() services.GiftAidDeclarationInput {
+	return services.GiftAidDeclarationInput{
+		Title:             r.Title,
+		FirstName:         r.FirstName,
+		LastName:          r.LastName,
+		HouseNameOrNumber: r.HouseNameOrNumber,
+		Postcode:          r.Postcode,
+		Overseas:          r.Overseas,
+		CoversPast:        r.CoversPast,
+		Method:            models.GiftAidMethodOnline,
+	}
+}
+
+// GetDonorGiftAid returns the donor's current Gift Aid declaration and past ones
+func GetDonorGiftAid(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	giftAid := services.NewGiftAidService()
+
+	history, err := giftAid.History(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch Gift Aid declarations"})
+		return
+	}
+	current, err := giftAid.Current(userID)
+	if err != nil && !errors.Is(err, services.ErrGiftAidNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch Gift Aid declarations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"declared":     current != nil,
+		"current":      current,
+		"declarations": history,
+	})
+}
+
+// DeclareGiftAid records a Gift Aid declaration on the donor's profile
+func DeclareGiftAid(c *gin.Context) {
+	var req GiftAidDeclarationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.ConfirmTaxpayer {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please confirm you are a UK taxpayer to make a Gift Aid declaration"})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	input := req.input()
+	input.UserID = &userID
+	declaration, err := services.NewGiftAidService().Declare(input, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrGiftAidInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save Gift Aid declaration"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "GiftAidDeclaration", declaration.ID,
+		fmt.Sprintf("Donor made a Gift Aid declaration effective from %s", declaration.EffectiveFrom.Format("2006-01-02")))
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Thank you, we can now claim Gift Aid on your donations",
+		"declaration": declaration,
+	})
+}
+
+// CancelGiftAid withdraws the donor's Gift Aid declaration for future donations
+func CancelGiftAid(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	userID := utils.GetUserIDFromContext(c)
+	if _, err := services.NewGiftAidService().Cancel(userID, req.Reason, userID, time.Now()); err != nil {
+		if errors.Is(err, services.ErrGiftAidNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel Gift Aid declaration"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Cancel", "GiftAidDeclaration", userID, "Donor cancelled their Gift Aid declaration")
+	c.JSON(http.StatusOK, gin.H{"message": "Your Gift Aid declaration has been cancelled for future donations"})
+}
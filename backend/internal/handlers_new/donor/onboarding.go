@@ -0,0 +1,110 @@
+package donor
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// onboardingResponse is what the welcome page shows a new donor
+func onboardingResponse(onboarding *models.DonorOnboarding) gin.H {
+	return gin.H{
+		"onboarding":         onboarding,
+		"causes":             onboarding.CauseList(),
+		"available_causes":   models.DonorCauses,
+		"preferences_prompt": onboarding.PreferencesSetAt == nil, // Show the prompt until they choose
+	}
+}
+
+// saveOnboardingPreferences binds and saves a donor's welcome choices
+func saveOnboardingPreferences(c *gin.Context, onboarding *models.DonorOnboarding) {
+	var req services.DonorPreferencesInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.NewDonorOnboardingService().SavePreferences(onboarding, req, time.Now()); err != nil {
+		if errors.Is(err, services.ErrInvalidDonorPreferences) || errors.Is(err, services.ErrInvalidNotificationPreference) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save preferences"})
+		return
+	}
+
+	response := onboardingResponse(onboarding)
+	response["message"] = "Thank you, your preferences have been saved"
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDonorOnboarding returns a signed-in donor's welcome status, causes of interest and
+// whether they still need to be asked for their preferences
+func GetDonorOnboarding(c *gin.Context) {
+	var user models.User
+	if err := db.DB.First(&user, utils.GetUserIDFromContext(c)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	onboarding, err := services.NewDonorOnboardingService().ForUser(&user)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusOK, gin.H{"onboarding": nil, "preferences_prompt": false, "available_causes": models.DonorCauses})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch onboarding"})
+		return
+	}
+	c.JSON(http.StatusOK, onboardingResponse(onboarding))
+}
+
+// UpdateDonorOnboarding saves a signed-in donor's causes of interest and communication
+// preferences
+func UpdateDonorOnboarding(c *gin.Context) {
+	var user models.User
+	if err := db.DB.First(&user, utils.GetUserIDFromContext(c)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	onboarding, err := services.NewDonorOnboardingService().ForUser(&user)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No donations found for this account"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch onboarding"})
+		return
+	}
+	saveOnboardingPreferences(c, onboarding)
+}
+
+// GetDonorOnboardingByToken returns the welcome page for the link in a welcome email,
+// for donors who gave without an account
+func GetDonorOnboardingByToken(c *gin.Context) {
+	onboarding, err := services.NewDonorOnboardingService().ForToken(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "This link is not valid"})
+		return
+	}
+	c.JSON(http.StatusOK, onboardingResponse(onboarding))
+}
+
+// UpdateDonorOnboardingByToken saves preferences from the link in a welcome email
+func UpdateDonorOnboardingByToken(c *gin.Context) {
+	onboarding, err := services.NewDonorOnboardingService().ForToken(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "This link is not valid"})
+		return
+	}
+	saveOnboardingPreferences(c, onboarding)
+}
@@ -0,0 +1,80 @@
+package donor
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDonorTaxSummary totals the donor's money donations for a UK tax year (6 April to
+// 5 April), defaulting to the current one, with the amounts covered by Gift Aid.
+// Pass format=csv to download it for a self-assessment return.
+func GetDonorTaxSummary(c *gin.Context) {
+	taxYear := services.TaxYearFor(time.Now())
+	if value := c.Query("taxYear"); value != "" {
+		parsed, err := services.ParseTaxYear(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		taxYear = parsed
+	}
+
+	summary, err := services.NewDonationTaxService().Summary(utils.GetUserIDFromContext(c), taxYear)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build tax summary"})
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, gin.H{"summary": summary})
+		return
+	}
+
+	filename := fmt.Sprintf("donations_tax_year_%s.csv", summary.TaxYear)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Type", "text/csv")
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"Date", "Receipt", "Status", "Currency", "Amount", "Refunded", "Net", "Gift Aid Eligible", "Gift Aid Claimed", "Refunded After Year End"})
+	for _, donation := range summary.Donations {
+		eligible, afterYearEnd := "No", ""
+		if donation.GiftAidEligible {
+			eligible = "Yes"
+		}
+		if donation.RefundedAfterYearEnd {
+			afterYearEnd = donation.RefundedAt.Format("2006-01-02")
+		}
+		writer.Write([]string{
+			donation.Date.In(time.Local).Format("2006-01-02"),
+			donation.Reference,
+			donation.Status,
+			donation.Currency,
+			fmt.Sprintf("%.2f", donation.Amount),
+			fmt.Sprintf("%.2f", donation.Refunded),
+			fmt.Sprintf("%.2f", donation.Net),
+			eligible,
+			fmt.Sprintf("%.2f", donation.GiftAid),
+			afterYearEnd,
+		})
+	}
+	writer.Write([]string{
+		fmt.Sprintf("Total %s (%s to %s)", summary.TaxYear, summary.StartDate, summary.EndDate),
+		"",
+		"",
+		summary.Currency,
+		fmt.Sprintf("%.2f", summary.TotalDonated),
+		fmt.Sprintf("%.2f", summary.TotalRefunded),
+		fmt.Sprintf("%.2f", summary.NetDonated),
+		fmt.Sprintf("%.2f", summary.GiftAidEligible),
+		fmt.Sprintf("%.2f", summary.GiftAidClaimed),
+		"",
+	})
+	writer.Flush()
+}
@@ -0,0 +1,105 @@
+package inventory
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScanRequest is the body for booking a scanned product into stock. Item and category
+// are only needed when the product is not recognised or cannot be categorised; giving
+// an item links the barcode to it for later scans.
+type ScanRequest struct {
+	Barcode    string `json:"barcode" binding:"required"`
+	Quantity   int    `json:"quantity"`
+	ItemID     *uint  `json:"item_id"`
+	CategoryID *uint  `json:"category_id"`
+	Reference  string `json:"reference"`
+	BatchCode  string `json:"batch_code"`
+	ExpiresOn  string `json:"expires_on"`
+	DonationID *uint  `json:"donation_id"`
+}
+
+// LinkBarcodeRequest is the body for linking a barcode to a stock item
+type LinkBarcodeRequest struct {
+	ItemID uint `json:"item_id" binding:"required"`
+}
+
+// ResolveBarcode shows the product behind a barcode and the item a scan of it would
+// be booked into, without changing stock
+func ResolveBarcode(c *gin.Context) {
+	resolution, err := services.NewBarcodeIntakeService().Resolve(c.Request.Context(), c.Param("code"), time.Now())
+	if err != nil {
+		inventoryError(c, err, "Failed to look up barcode")
+		return
+	}
+	c.JSON(http.StatusOK, resolution)
+}
+
+// LinkBarcode books later scans of a barcode into a stock item
+func LinkBarcode(c *gin.Context) {
+	var req LinkBarcodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	barcode, err := services.NewBarcodeIntakeService().Link(c.Param("code"), req.ItemID, utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		inventoryError(c, err, "Failed to link barcode")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "ProductBarcode", barcode.ID,
+		fmt.Sprintf("Barcode %s linked to inventory item %d", barcode.Barcode, req.ItemID))
+	c.JSON(http.StatusOK, barcode)
+}
+
+// ScanIntake books a scanned product into stock, adding a stock item for products
+// seen for the first time
+func ScanIntake(c *gin.Context) {
+	var req ScanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var expiresOn *time.Time
+	if req.ExpiresOn != "" {
+		date, err := time.Parse("2006-01-02", req.ExpiresOn)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expiry date, use YYYY-MM-DD"})
+			return
+		}
+		expiresOn = &date
+	}
+
+	result, err := services.NewBarcodeIntakeService().Scan(c.Request.Context(), services.BarcodeScanInput{
+		Barcode:    req.Barcode,
+		Quantity:   req.Quantity,
+		ItemID:     req.ItemID,
+		CategoryID: req.CategoryID,
+		Reference:  req.Reference,
+		BatchCode:  req.BatchCode,
+		ExpiresOn:  expiresOn,
+		DonationID: req.DonationID,
+	}, utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		inventoryError(c, err, "Failed to book scanned stock")
+		return
+	}
+
+	if result.ItemCreated {
+		utils.CreateAuditLog(c, "Create", "InventoryItem", result.Item.ID,
+			fmt.Sprintf("Inventory item %q added from barcode %s", result.Item.Name, result.Barcode.Barcode))
+	}
+	utils.CreateAuditLog(c, "StockMovement", "InventoryItem", result.Item.ID,
+		fmt.Sprintf("Scanned %d of %q in from barcode %s, %d now in stock",
+			result.Movement.Change, result.Item.Name, result.Barcode.Barcode, result.Movement.QuantityAfter))
+	c.JSON(http.StatusCreated, result)
+}
@@ -0,0 +1,315 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CategoryRequest is the body for creating or updating a stock category
+type CategoryRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Description  string `json:"description"`
+	TracksExpiry bool   `json:"tracks_expiry"`
+}
+
+// ItemRequest is the body for creating or updating a stock item. Quantity is only
+// used as the opening stock of a new item.
+type ItemRequest struct {
+	Name         string `json:"name" binding:"required"`
+	CategoryID   uint   `json:"category_id" binding:"required"`
+	Description  string `json:"description"`
+	Unit         string `json:"unit"`
+	Quantity     int    `json:"quantity"`
+	MinimumLevel int    `json:"minimum_level"`
+	TargetLevel  int    `json:"target_level"`
+	Location     string `json:"location"`
+	Active       *bool  `json:"active"`
+}
+
+// item converts the request into a stock item, active unless it says otherwise
+func (req ItemRequest) item() *models.InventoryItem {
+	return &models.InventoryItem{
+		Name:         req.Name,
+		CategoryID:   req.CategoryID,
+		Description:  req.Description,
+		Unit:         req.Unit,
+		Quantity:     req.Quantity,
+		MinimumLevel: req.MinimumLevel,
+		TargetLevel:  req.TargetLevel,
+		Location:     req.Location,
+		Active:       req.Active == nil || *req.Active,
+	}
+}
+
+// MovementRequest is the body for recording a stock movement. Batch code and
+// expiry date (YYYY-MM-DD) are kept for stock coming in.
+type MovementRequest struct {
+	Type      string `json:"type" binding:"required"`
+	Quantity  int    `json:"quantity"`
+	Reason    string `json:"reason"`
+	Reference string `json:"reference"`
+	BatchCode string `json:"batch_code"`
+	ExpiresOn string `json:"expires_on"`
+}
+
+// ListCategories returns the stock categories
+func ListCategories(c *gin.Context) {
+	categories, err := services.NewInventoryService().Categories()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inventory categories"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
+}
+
+// CreateCategory adds a stock category
+func CreateCategory(c *gin.Context) {
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category := &models.InventoryCategory{Name: req.Name, Description: req.Description, TracksExpiry: req.TracksExpiry}
+	if err := services.NewInventoryService().CreateCategory(category); err != nil {
+		inventoryError(c, err, "Failed to create inventory category")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "InventoryCategory", category.ID, fmt.Sprintf("Inventory category %q created", category.Name))
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory renames or redescribes a stock category
+func UpdateCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := services.NewInventoryService().UpdateCategory(uint(id), req.Name, req.Description, req.TracksExpiry)
+	if err != nil {
+		inventoryError(c, err, "Failed to update inventory category")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "InventoryCategory", category.ID, fmt.Sprintf("Inventory category %q updated", category.Name))
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory removes an empty stock category
+func DeleteCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	if err := services.NewInventoryService().DeleteCategory(uint(id)); err != nil {
+		inventoryError(c, err, "Failed to delete inventory category")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "InventoryCategory", uint(id), "Inventory category deleted")
+	c.JSON(http.StatusOK, gin.H{"message": "Inventory category deleted"})
+}
+
+// ListItems returns stock items with their urgency. Filters: category_id, search,
+// urgent=true for items at or below their minimum level, include_inactive=true.
+func ListItems(c *gin.Context) {
+	categoryID, _ := strconv.ParseUint(c.Query("category_id"), 10, 32)
+	items, err := services.NewInventoryService().Items(services.InventoryItemFilter{
+		CategoryID:      uint(categoryID),
+		Search:          c.Query("search"),
+		UrgentOnly:      c.Query("urgent") == "true",
+		IncludeInactive: c.Query("include_inactive") == "true",
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inventory items"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "count": len(items)})
+}
+
+// GetItem returns one stock item
+func GetItem(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	item, err := services.NewInventoryService().Item(uint(id))
+	if err != nil {
+		inventoryError(c, err, "Failed to fetch inventory item")
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+// CreateItem adds a stock item, booking any opening quantity as a stocktake
+func CreateItem(c *gin.Context) {
+	var req ItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := services.NewInventoryService().CreateItem(req.item(), utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		inventoryError(c, err, "Failed to create inventory item")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "InventoryItem", item.ID,
+		fmt.Sprintf("Inventory item %q created with %d in stock", item.Name, item.Quantity))
+	c.JSON(http.StatusCreated, item)
+}
+
+// UpdateItem changes a stock item's details and levels. Stock is changed by
+// recording a movement.
+func UpdateItem(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+	var req ItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := services.NewInventoryService().UpdateItem(uint(id), req.item(), utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		inventoryError(c, err, "Failed to update inventory item")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "InventoryItem", item.ID,
+		fmt.Sprintf("Inventory item %q updated (minimum %d, target %d)", item.Name, item.MinimumLevel, item.TargetLevel))
+	c.JSON(http.StatusOK, item)
+}
+
+// DeleteItem removes a stock item and withdraws its urgent need
+func DeleteItem(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	if err := services.NewInventoryService().DeleteItem(uint(id)); err != nil {
+		inventoryError(c, err, "Failed to delete inventory item")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "InventoryItem", uint(id), "Inventory item deleted")
+	c.JSON(http.StatusOK, gin.H{"message": "Inventory item deleted"})
+}
+
+// RecordMovement books stock in or out of an item, or corrects it to a stocktake count
+func RecordMovement(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+	var req MovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var expiresOn *time.Time
+	if req.ExpiresOn != "" {
+		date, err := time.Parse("2006-01-02", req.ExpiresOn)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expiry date, use YYYY-MM-DD"})
+			return
+		}
+		expiresOn = &date
+	}
+
+	inventoryService := services.NewInventoryService()
+	movement, err := inventoryService.RecordMovement(uint(id), services.StockMovementInput{
+		Type:      req.Type,
+		Quantity:  req.Quantity,
+		Reason:    req.Reason,
+		Reference: req.Reference,
+		BatchCode: req.BatchCode,
+		ExpiresOn: expiresOn,
+	}, utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		inventoryError(c, err, "Failed to record stock movement")
+		return
+	}
+	item, err := inventoryService.Item(uint(id))
+	if err != nil {
+		inventoryError(c, err, "Failed to fetch inventory item")
+		return
+	}
+
+	utils.CreateAuditLog(c, "StockMovement", "InventoryItem", item.ID,
+		fmt.Sprintf("Stock %s of %d for %q, %d now in stock", movement.Type, movement.Change, item.Name, movement.QuantityAfter))
+	c.JSON(http.StatusCreated, gin.H{"movement": movement, "item": item})
+}
+
+// ListMovements returns a page of an item's stock movements, newest first
+func ListMovements(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	movements, total, err := services.NewInventoryService().Movements(uint(id), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock movements"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"movements": movements, "total": total})
+}
+
+// ListShortages returns the items at or below their minimum level, most urgent first
+func ListShortages(c *gin.Context) {
+	items, err := services.NewInventoryService().Shortages()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock shortages"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "count": len(items)})
+}
+
+// inventoryError writes the response for an inventory error
+func inventoryError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInventoryItemNotFound), errors.Is(err, services.ErrInventoryCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInventoryInvalid), errors.Is(err, services.ErrInvalidBarcode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrBarcodeUnknown), errors.Is(err, services.ErrBarcodeUncategorised):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInsufficientStock), errors.Is(err, services.ErrInventoryCategoryInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
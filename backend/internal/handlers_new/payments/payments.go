@@ -5,17 +5,19 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stripe/stripe-go/v74"
 	"github.com/stripe/stripe-go/v74/customer"
 	"github.com/stripe/stripe-go/v74/paymentmethod"
-	"github.com/stripe/stripe-go/v74/refund"
 	"github.com/stripe/stripe-go/v74/webhook"
 
 	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/httpclient"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 )
 
@@ -56,6 +58,15 @@ type RefundRequest struct {
 func init() {
 	// Set Stripe API key from environment variable
 	stripe.Key = "sk_test_..." // This should come from environment variables
+	if key := os.Getenv("STRIPE_SECRET_KEY"); key != "" {
+		stripe.Key = key
+	}
+
+	// Stripe retries with its own idempotency keys, so the shared client only adds the
+	// timeout, circuit breaker, metrics and tracing
+	stripe.SetBackend(stripe.APIBackend, stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{
+		HTTPClient: httpclient.New("stripe", httpclient.Options{Timeout: 80 * time.Second, MaxRetries: -1}),
+	}))
 }
 
 // CreatePaymentIntent creates a new payment intent for donations
@@ -269,42 +280,19 @@ func ProcessRefund(c *gin.Context) {
 		return
 	}
 
-	// Create refund parameters
-	params := &stripe.RefundParams{
-		PaymentIntent: stripe.String(req.PaymentIntentID),
-	}
-
-	if req.Amount > 0 {
-		params.Amount = stripe.Int64(req.Amount)
-	}
-
-	if req.Reason != "" {
-		params.Reason = stripe.String(req.Reason)
-	}
-
-	// Process refund
-	r, err := refund.New(params)
+	// Process refund and update the payment, donation and Gift Aid records
+	r, err := services.NewDonationRefundService().RefundPayment(req.PaymentIntentID, req.Amount, req.Reason, utils.GetUserIDFromContext(c))
 	if err != nil {
-		utils.CreateAuditLog(c, "ProcessRefund", "Refund", 0, fmt.Sprintf("Stripe error: %v", err))
+		utils.CreateAuditLog(c, "ProcessRefund", "Refund", 0, fmt.Sprintf("Refund failed: %v", err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process refund"})
 		return
 	}
 
-	// Update payment record
-	var payment models.Payment
-	if err := db.GetDB().Where("stripe_payment_id = ?", req.PaymentIntentID).First(&payment).Error; err == nil {
-		payment.Status = "refunded"
-		payment.RefundAmount = float64(r.Amount) / 100
-		payment.RefundedAt = &time.Time{}
-		*payment.RefundedAt = time.Now()
-		db.GetDB().Save(&payment)
-	}
-
-	utils.CreateAuditLog(c, "ProcessRefund", "Refund", 0, fmt.Sprintf("Refund processed: %s", r.ID))
+	utils.CreateAuditLog(c, "ProcessRefund", "Refund", r.ID, fmt.Sprintf("Refund processed: %s", r.ProviderReference))
 
 	c.JSON(http.StatusOK, gin.H{
-		"id":     r.ID,
-		"amount": r.Amount,
+		"id":     r.ProviderReference,
+		"amount": int64(r.Amount * 100),
 		"status": r.Status,
 	})
 }
@@ -355,6 +343,9 @@ func WebhookHandler(c *gin.Context) {
 
 	// Verify webhook signature
 	endpointSecret := "whsec_..." // This should come from environment variables
+	if secret := os.Getenv("STRIPE_WEBHOOK_SECRET"); secret != "" {
+		endpointSecret = secret
+	}
 	event, err := webhook.ConstructEvent(payload, c.GetHeader("Stripe-Signature"), endpointSecret)
 	if err != nil {
 		log.Printf("Webhook signature verification failed: %v", err)
@@ -391,6 +382,33 @@ func WebhookHandler(c *gin.Context) {
 		}
 		handleInvoicePaymentSucceeded(invoice)
 
+	case "charge.refunded":
+		var charge stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+			log.Printf("Failed to parse charge: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event data"})
+			return
+		}
+		handleChargeRefunded(charge)
+
+	case "charge.dispute.created":
+		var dispute stripe.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+			log.Printf("Failed to parse dispute: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event data"})
+			return
+		}
+		handleDisputeCreated(dispute)
+
+	case "charge.dispute.closed":
+		var dispute stripe.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+			log.Printf("Failed to parse dispute: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event data"})
+			return
+		}
+		handleDisputeClosed(dispute)
+
 	default:
 		log.Printf("Unhandled event type: %s", event.Type)
 	}
@@ -427,6 +445,10 @@ func handlePaymentIntentSucceeded(pi stripe.PaymentIntent) {
 	// Update payment record
 	var payment models.Payment
 	if err := db.GetDB().Where("stripe_payment_id = ?", pi.ID).First(&payment).Error; err == nil {
+		// Wallet payments can succeed immediately as well as through the webhook
+		if payment.Status == "succeeded" {
+			return
+		}
 		payment.Status = "succeeded"
 		payment.CompletedAt = &time.Time{}
 		*payment.CompletedAt = time.Now()
@@ -435,13 +457,31 @@ func handlePaymentIntentSucceeded(pi stripe.PaymentIntent) {
 		// Create donation record
 		userID := payment.UserID
 		donation := models.Donation{
-			UserID:    &userID,
-			Amount:    payment.Amount,
-			Type:      "monetary",
-			Status:    "completed",
-			CreatedAt: time.Now(),
+			UserID:        &userID,
+			Amount:        payment.Amount,
+			Currency:      payment.Currency,
+			Type:          "monetary",
+			Status:        models.DonationStatusCompleted,
+			PaymentMethod: "card",
+			PaymentID:     pi.ID,
+			CreatedAt:     time.Now(),
+		}
+		if err := db.GetDB().Create(&donation).Error; err == nil {
+			db.GetDB().Model(&payment).Update("donation_id", donation.ID)
+
+			// A first donation starts the donor's welcome series; the checkout passes any
+			// campaign or referral code through the payment intent's metadata
+			var user models.User
+			if err := db.GetDB().First(&user, userID).Error; err == nil {
+				acquisition := services.DonorAcquisition{
+					CampaignCode: pi.Metadata["campaign_code"],
+					ReferralCode: pi.Metadata["referral_code"],
+				}
+				if _, err := services.NewDonorOnboardingService().Start(&donation, &user, acquisition, time.Now()); err != nil {
+					log.Printf("Failed to start onboarding for donation %d: %v", donation.ID, err)
+				}
+			}
 		}
-		db.GetDB().Create(&donation)
 	}
 }
 
@@ -479,3 +519,42 @@ func handleInvoicePaymentSucceeded(invoice stripe.Invoice) {
 		}
 	}
 }
+
+func handleChargeRefunded(charge stripe.Charge) {
+	if charge.PaymentIntent == nil {
+		return
+	}
+
+	reference := charge.ID
+	if charge.Refunds != nil && len(charge.Refunds.Data) > 0 {
+		reference = charge.Refunds.Data[0].ID
+	}
+
+	if _, err := services.NewDonationRefundService().SyncChargeRefunds(charge.PaymentIntent.ID, reference, float64(charge.AmountRefunded)/100); err != nil {
+		log.Printf("Failed to record refund for charge %s: %v", charge.ID, err)
+	}
+}
+
+func handleDisputeCreated(dispute stripe.Dispute) {
+	if dispute.PaymentIntent == nil {
+		return
+	}
+
+	var dueBy *time.Time
+	if dispute.EvidenceDetails != nil && dispute.EvidenceDetails.DueBy > 0 {
+		due := time.Unix(dispute.EvidenceDetails.DueBy, 0)
+		dueBy = &due
+	}
+
+	if _, err := services.NewDonationRefundService().OpenChargeback(dispute.PaymentIntent.ID, dispute.ID,
+		float64(dispute.Amount)/100, string(dispute.Reason), dueBy); err != nil {
+		log.Printf("Failed to record chargeback %s: %v", dispute.ID, err)
+	}
+}
+
+func handleDisputeClosed(dispute stripe.Dispute) {
+	won := dispute.Status == stripe.DisputeStatusWon
+	if _, err := services.NewDonationRefundService().CloseChargeback(dispute.ID, won); err != nil {
+		log.Printf("Failed to close chargeback %s: %v", dispute.ID, err)
+	}
+}
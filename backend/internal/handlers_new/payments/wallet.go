@@ -0,0 +1,401 @@
+package payments
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stripe/stripe-go/v74"
+	"github.com/stripe/stripe-go/v74/applepaydomain"
+	"github.com/stripe/stripe-go/v74/paymentintent"
+	"github.com/stripe/stripe-go/v74/paymentmethod"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/httpclient"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/utils"
+)
+
+// Supported wallets
+const (
+	WalletApplePay  = "apple_pay"
+	WalletGooglePay = "google_pay"
+)
+
+// WalletPaymentRequest is a donation paid with an Apple Pay or Google Pay token.
+// The frontend sends either the Stripe PaymentMethod created from the wallet sheet
+// or the raw card token the wallet returned.
+type WalletPaymentRequest struct {
+	Amount          int64             `json:"amount" binding:"required,min=100"` // Amount in pence
+	Currency        string            `json:"currency"`
+	Wallet          string            `json:"wallet" binding:"required,oneof=apple_pay google_pay"`
+	PaymentMethodID string            `json:"paymentMethodId,omitempty"`
+	Token           string            `json:"token,omitempty"`
+	ReturnURL       string            `json:"returnUrl,omitempty"` // Used if the card requires 3D Secure
+	Metadata        map[string]string `json:"metadata,omitempty"`
+}
+
+// ApplePaySessionRequest holds the validation URL Safari passes to onvalidatemerchant
+type ApplePaySessionRequest struct {
+	ValidationURL string `json:"validationUrl" binding:"required"`
+}
+
+// ApplePayDomainRequest registers a web domain for Apple Pay with Stripe
+type ApplePayDomainRequest struct {
+	Domain string `json:"domain" binding:"required"`
+}
+
+// GetWalletConfig returns the settings the frontend needs to show Apple Pay and
+// Google Pay buttons
+func GetWalletConfig(c *gin.Context) {
+	merchantID := os.Getenv("APPLE_PAY_MERCHANT_ID")
+	googleEnvironment := os.Getenv("GOOGLE_PAY_ENVIRONMENT")
+	if googleEnvironment == "" {
+		googleEnvironment = "TEST"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"publishableKey":    os.Getenv("STRIPE_PUBLISHABLE_KEY"),
+		"country":           "GB",
+		"currency":          "gbp",
+		"merchantName":      walletDisplayName(),
+		"supportedNetworks": []string{"visa", "masterCard", "amex", "maestro"},
+		"applePay": gin.H{
+			"enabled":            merchantID != "",
+			"merchantIdentifier": merchantID,
+		},
+		"googlePay": gin.H{
+			"enabled":     true,
+			"environment": googleEnvironment,
+			"merchantId":  os.Getenv("GOOGLE_PAY_MERCHANT_ID"),
+			"gateway": gin.H{
+				"gateway":               "stripe",
+				"stripe:version":        stripe.APIVersion,
+				"stripe:publishableKey": os.Getenv("STRIPE_PUBLISHABLE_KEY"),
+			},
+		},
+	})
+}
+
+// CreateApplePaySession performs Apple Pay merchant validation. Safari gives the page a
+// validation URL on Apple's servers; the backend calls it with the merchant identity
+// certificate and returns the opaque merchant session to pass to completeMerchantValidation.
+func CreateApplePaySession(c *gin.Context) {
+	var req ApplePaySessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	validationURL, ok := applePayValidationURL(req.ValidationURL)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Apple Pay validation URL"})
+		return
+	}
+
+	merchantID := os.Getenv("APPLE_PAY_MERCHANT_ID")
+	certFile := os.Getenv("APPLE_PAY_MERCHANT_CERT_FILE")
+	keyFile := os.Getenv("APPLE_PAY_MERCHANT_KEY_FILE")
+	if merchantID == "" || certFile == "" || keyFile == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Apple Pay is not configured"})
+		return
+	}
+
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		log.Printf("Failed to load Apple Pay merchant identity certificate: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Apple Pay is not configured"})
+		return
+	}
+
+	body, _ := json.Marshal(map[string]string{
+		"merchantIdentifier": merchantID,
+		"displayName":        walletDisplayName(),
+		"initiative":         "web",
+		"initiativeContext":  applePayDomain(c),
+	})
+
+	client := httpclient.New("apple_pay", httpclient.Options{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				Certificates: []tls.Certificate{certificate},
+				MinVersion:   tls.VersionTLS12,
+			},
+		},
+	})
+
+	resp, err := client.Post(validationURL.String(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Apple Pay merchant validation failed: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Apple Pay merchant validation failed"})
+		return
+	}
+	defer resp.Body.Close()
+
+	session, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		log.Printf("Apple Pay merchant validation returned %d", resp.StatusCode)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Apple Pay merchant validation failed"})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", session)
+}
+
+// ServeApplePayDomainAssociation serves the domain association file Apple uses to
+// verify that the site may use Apple Pay
+func ServeApplePayDomainAssociation(c *gin.Context) {
+	path := os.Getenv("APPLE_PAY_DOMAIN_ASSOCIATION_FILE")
+	if path == "" {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.File(path)
+}
+
+// CreateWalletPayment creates and confirms a PaymentIntent from an Apple Pay or Google
+// Pay token in a single call. If the card issuer asks for 3D Secure the client secret
+// is returned so the frontend can complete it and call ConfirmWalletPayment.
+func CreateWalletPayment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req WalletPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.PaymentMethodID == "" && req.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "paymentMethodId or token is required"})
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = "gbp"
+	}
+
+	var user models.User
+	if err := db.GetDB().First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	pm, err := walletPaymentMethod(req)
+	if err != nil {
+		utils.CreateAuditLog(c, "WalletPayment", "Payment", 0, fmt.Sprintf("Stripe error: %v", err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet payment token"})
+		return
+	}
+	if pm.Card == nil || pm.Card.Wallet == nil || string(pm.Card.Wallet.Type) != req.Wallet {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payment method was not created by " + walletName(req.Wallet)})
+		return
+	}
+
+	customerID, err := getOrCreateStripeCustomer(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process payment"})
+		return
+	}
+
+	params := &stripe.PaymentIntentParams{
+		Amount:             stripe.Int64(req.Amount),
+		Currency:           stripe.String(strings.ToLower(req.Currency)),
+		Customer:           stripe.String(customerID),
+		PaymentMethod:      stripe.String(pm.ID),
+		PaymentMethodTypes: []*string{stripe.String("card")},
+		Confirm:            stripe.Bool(true),
+		Description:        stripe.String("Donation via " + walletName(req.Wallet)),
+	}
+	if user.Email != "" {
+		params.ReceiptEmail = stripe.String(user.Email)
+	}
+	if req.ReturnURL != "" {
+		params.ReturnURL = stripe.String(req.ReturnURL)
+	}
+	for key, value := range req.Metadata {
+		params.AddMetadata(key, value)
+	}
+	params.AddMetadata("wallet", req.Wallet)
+	params.AddMetadata("user_id", fmt.Sprintf("%d", user.ID))
+
+	pi, err := paymentintent.New(params)
+	if err != nil {
+		utils.CreateAuditLog(c, "WalletPayment", "Payment", 0, fmt.Sprintf("Stripe error: %v", err))
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": "Payment was declined"})
+		return
+	}
+
+	payment := models.Payment{
+		UserID:          user.ID,
+		StripePaymentID: pi.ID,
+		Amount:          float64(req.Amount) / 100,
+		Currency:        strings.ToUpper(req.Currency),
+		Status:          "pending",
+		Type:            "donation",
+		PaymentMethod:   req.Wallet,
+		PaymentMethodID: pm.ID,
+		Description:     "Donation via " + walletName(req.Wallet),
+	}
+	if err := db.GetDB().Create(&payment).Error; err != nil {
+		log.Printf("Failed to record wallet payment %s: %v", pi.ID, err)
+	}
+
+	syncWalletPayment(pi)
+
+	utils.CreateAuditLog(c, "WalletPayment", "Payment", payment.ID,
+		fmt.Sprintf("%s payment %s: %s", walletName(req.Wallet), pi.ID, pi.Status))
+
+	c.JSON(http.StatusOK, walletPaymentResponse(pi))
+}
+
+// ConfirmWalletPayment refreshes a wallet payment after the donor has completed 3D
+// Secure in the browser, and records the donation once the payment has succeeded
+func ConfirmWalletPayment(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	intentID := c.Param("id")
+	var payment models.Payment
+	if err := db.GetDB().Where("stripe_payment_id = ? AND user_id = ?", intentID, userID).First(&payment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+
+	pi, err := paymentintent.Get(intentID, nil)
+	if err != nil {
+		utils.CreateAuditLog(c, "WalletPayment", "Payment", payment.ID, fmt.Sprintf("Stripe error: %v", err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to confirm payment"})
+		return
+	}
+
+	// Confirm again if the payment was left waiting after authentication
+	if pi.Status == stripe.PaymentIntentStatusRequiresConfirmation {
+		if pi, err = paymentintent.Confirm(intentID, nil); err != nil {
+			utils.CreateAuditLog(c, "WalletPayment", "Payment", payment.ID, fmt.Sprintf("Stripe error: %v", err))
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": "Payment was declined"})
+			return
+		}
+	}
+
+	syncWalletPayment(pi)
+
+	c.JSON(http.StatusOK, walletPaymentResponse(pi))
+}
+
+// RegisterApplePayDomain registers a web domain with Stripe so Apple Pay can be offered on it
+func RegisterApplePayDomain(c *gin.Context) {
+	var req ApplePayDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	domain, err := applepaydomain.New(&stripe.ApplePayDomainParams{
+		DomainName: stripe.String(strings.ToLower(strings.TrimSpace(req.Domain))),
+	})
+	if err != nil {
+		utils.CreateAuditLog(c, "RegisterApplePayDomain", "ApplePayDomain", 0, fmt.Sprintf("Stripe error: %v", err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to register Apple Pay domain"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "RegisterApplePayDomain", "ApplePayDomain", 0, fmt.Sprintf("Apple Pay domain registered: %s", domain.DomainName))
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":     domain.ID,
+		"domain": domain.DomainName,
+	})
+}
+
+// walletPaymentMethod returns the Stripe PaymentMethod for a wallet payment, creating
+// one from the wallet's card token if needed
+func walletPaymentMethod(req WalletPaymentRequest) (*stripe.PaymentMethod, error) {
+	if req.PaymentMethodID != "" {
+		return paymentmethod.Get(req.PaymentMethodID, nil)
+	}
+	return paymentmethod.New(&stripe.PaymentMethodParams{
+		Type: stripe.String(string(stripe.PaymentMethodTypeCard)),
+		Card: &stripe.PaymentMethodCardParams{Token: stripe.String(req.Token)},
+	})
+}
+
+// syncWalletPayment updates the local payment record from the PaymentIntent status
+func syncWalletPayment(pi *stripe.PaymentIntent) {
+	switch pi.Status {
+	case stripe.PaymentIntentStatusSucceeded:
+		handlePaymentIntentSucceeded(*pi)
+	case stripe.PaymentIntentStatusRequiresPaymentMethod, stripe.PaymentIntentStatusCanceled:
+		handlePaymentIntentFailed(*pi)
+	}
+}
+
+// walletPaymentResponse builds the response the frontend uses to finish the payment sheet
+func walletPaymentResponse(pi *stripe.PaymentIntent) gin.H {
+	response := gin.H{
+		"id":       pi.ID,
+		"status":   pi.Status,
+		"amount":   pi.Amount,
+		"currency": pi.Currency,
+	}
+	if pi.Status == stripe.PaymentIntentStatusRequiresAction {
+		response["clientSecret"] = pi.ClientSecret
+		response["requiresAction"] = true
+	}
+	if pi.LastPaymentError != nil {
+		response["error"] = pi.LastPaymentError.Msg
+	}
+	return response
+}
+
+// applePayValidationURL parses a merchant validation URL, accepting only Apple's own
+// servers so the session endpoint cannot be used to reach other hosts
+func applePayValidationURL(raw string) (*url.URL, bool) {
+	validationURL, err := url.Parse(raw)
+	if err != nil || validationURL.Scheme != "https" || validationURL.User != nil ||
+		!strings.HasSuffix(validationURL.Hostname(), ".apple.com") {
+		return nil, false
+	}
+	return validationURL, true
+}
+
+// applePayDomain returns the domain Apple Pay is shown on
+func applePayDomain(c *gin.Context) string {
+	if domain := os.Getenv("APPLE_PAY_DOMAIN"); domain != "" {
+		return domain
+	}
+	if origin, err := url.Parse(c.GetHeader("Origin")); err == nil && origin.Hostname() != "" {
+		return origin.Hostname()
+	}
+	return c.Request.Host
+}
+
+// walletDisplayName returns the merchant name shown on the payment sheet
+func walletDisplayName() string {
+	if name := os.Getenv("WALLET_DISPLAY_NAME"); name != "" {
+		return name
+	}
+	return "Lewisham Charity"
+}
+
+// walletName returns the display name of a wallet
+func walletName(wallet string) string {
+	if wallet == WalletApplePay {
+		return "Apple Pay"
+	}
+	return "Google Pay"
+}
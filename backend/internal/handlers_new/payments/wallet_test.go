@@ -0,0 +1,75 @@
+package payments
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stripe/stripe-go/v74"
+)
+
+func TestApplePayValidationURL(t *testing.T) {
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://apple-pay-gateway.apple.com/paymentservices/startSession", true},
+		{"https://apple-pay-gateway-cert.apple.com/paymentservices/paymentSession", true},
+		{"http://apple-pay-gateway.apple.com/paymentservices/startSession", false},
+		{"https://apple.com.attacker.example/startSession", false},
+		{"https://attackerapple.com/startSession", false},
+		{"https://attacker.example/.apple.com", false},
+		{"https://user@attacker.example#.apple.com", false},
+		{"https://gateway.apple.com@attacker.example/", false},
+		{"https://169.254.169.254/latest/meta-data", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if _, ok := applePayValidationURL(tt.url); ok != tt.ok {
+			t.Errorf("%q: accepted %v, want %v", tt.url, ok, tt.ok)
+		}
+	}
+}
+
+func TestApplePayDomain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	context := func(origin string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("POST", "http://api.example.org/api/v1/payments/apple-pay/session", nil)
+		if origin != "" {
+			c.Request.Header.Set("Origin", origin)
+		}
+		return c
+	}
+
+	t.Setenv("APPLE_PAY_DOMAIN", "")
+	if got := applePayDomain(context("https://donate.example.org:8443")); got != "donate.example.org" {
+		t.Errorf("from Origin: got %q", got)
+	}
+	if got := applePayDomain(context("")); got != "api.example.org" {
+		t.Errorf("from Host: got %q", got)
+	}
+	t.Setenv("APPLE_PAY_DOMAIN", "charity.example.org")
+	if got := applePayDomain(context("https://donate.example.org")); got != "charity.example.org" {
+		t.Errorf("configured: got %q", got)
+	}
+}
+
+func TestWalletPaymentResponse(t *testing.T) {
+	pi := &stripe.PaymentIntent{ID: "pi_1", Status: stripe.PaymentIntentStatusRequiresAction, Amount: 2500, Currency: "gbp", ClientSecret: "pi_1_secret"}
+	response := walletPaymentResponse(pi)
+	if response["clientSecret"] != "pi_1_secret" || response["requiresAction"] != true {
+		t.Errorf("3D Secure: got %v", response)
+	}
+
+	pi = &stripe.PaymentIntent{ID: "pi_2", Status: stripe.PaymentIntentStatusSucceeded, Amount: 2500, Currency: "gbp", ClientSecret: "pi_2_secret"}
+	if response := walletPaymentResponse(pi); response["clientSecret"] != nil || response["requiresAction"] != nil {
+		t.Errorf("client secret returned for a finished payment: %v", response)
+	}
+
+	pi = &stripe.PaymentIntent{ID: "pi_3", Status: stripe.PaymentIntentStatusRequiresPaymentMethod,
+		LastPaymentError: &stripe.Error{Msg: "Your card was declined."}}
+	if response := walletPaymentResponse(pi); response["error"] != "Your card was declined." {
+		t.Errorf("declined: got %v", response)
+	}
+}
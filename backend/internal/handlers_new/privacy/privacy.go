@@ -2,6 +2,7 @@ package privacy
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -236,3 +239,74 @@ func UpdateConsent(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Consent saved"})
 }
+
+// GetAccountDeletionStatus returns the visitor's deletion request, if any, and any
+// tickets or open cases that would stop their account being deleted
+func GetAccountDeletionStatus(c *gin.Context) {
+	status, err := services.NewAccountDeletionService().Status(utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account deletion status"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// DeleteAccount disables the visitor's account and schedules it for erasure after the
+// grace period. The visitor is emailed a link to cancel and is signed out.
+func DeleteAccount(c *gin.Context) {
+	var body struct {
+		Reason string `json:"reason" binding:"max=1000"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+	}
+
+	request, blockers, err := services.NewAccountDeletionService().Request(utils.GetUserIDFromContext(c), body.Reason, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAccountDeletionBlocked):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    "Your account can't be deleted yet",
+				"blockers": blockers,
+			})
+		case errors.Is(err, services.ErrAccountDeletionNotAllowed):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAccountDeletionScheduled):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":       "Your account has been disabled and will be deleted. Check your email for a link to cancel.",
+		"request_id":    request.ID,
+		"scheduled_for": request.ScheduledFor,
+	})
+}
+
+// CancelAccountDeletion restores an account during its grace period using the token
+// from the emailed link
+func CancelAccountDeletion(c *gin.Context) {
+	var body struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token is required"})
+		return
+	}
+
+	if _, err := services.NewAccountDeletionService().Cancel(body.Token, time.Now()); err != nil {
+		if errors.Is(err, services.ErrAccountDeletionLinkInvalid) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel account deletion"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled. You can sign in again."})
+}
@@ -0,0 +1,62 @@
+package shared
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SelectFields applies the comma-separated fields query parameter to a response so
+// clients can ask for only the data they show. Nested keys are selected with dot
+// notation, e.g. fields=next_shift.start_time,unread_notifications. Unknown fields
+// are ignored and the full response is returned when no fields are given.
+func SelectFields(c *gin.Context, data gin.H) gin.H {
+	param := strings.TrimSpace(c.Query("fields"))
+	if param == "" {
+		return data
+	}
+
+	selected := gin.H{}
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		selectPath(data, selected, strings.Split(field, "."))
+	}
+	return selected
+}
+
+// RespondWithFields writes a JSON response after applying the fields projection
+func RespondWithFields(c *gin.Context, status int, data gin.H) {
+	c.JSON(status, SelectFields(c, data))
+}
+
+// selectPath copies the value at path from src into dst, creating nested maps as needed
+func selectPath(src, dst gin.H, path []string) {
+	value, ok := src[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = value
+		return
+	}
+
+	var nested gin.H
+	switch v := value.(type) {
+	case gin.H:
+		nested = v
+	case map[string]interface{}:
+		nested = v
+	default:
+		return
+	}
+
+	child, ok := dst[path[0]].(gin.H)
+	if !ok {
+		child = gin.H{}
+		dst[path[0]] = child
+	}
+	selectPath(nested, child, path[1:])
+}
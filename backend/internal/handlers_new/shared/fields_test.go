@@ -0,0 +1,49 @@
+package shared
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSelectFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	summary := func() gin.H {
+		return gin.H{
+			"unread_notifications": 3,
+			"hours_this_month":     12.5,
+			"next_shift": gin.H{
+				"id":         7,
+				"start_time": "2026-05-05T09:00:00Z",
+				"location":   map[string]interface{}{"name": "Main hall", "postcode": "SE13 5AB"},
+			},
+		}
+	}
+
+	tests := []struct {
+		fields string
+		want   gin.H
+	}{
+		{"", summary()},
+		{"unread_notifications", gin.H{"unread_notifications": 3}},
+		{" next_shift.start_time , hours_this_month ,", gin.H{
+			"hours_this_month": 12.5,
+			"next_shift":       gin.H{"start_time": "2026-05-05T09:00:00Z"},
+		}},
+		{"next_shift.id,next_shift.location.name", gin.H{
+			"next_shift": gin.H{"id": 7, "location": gin.H{"name": "Main hall"}},
+		}},
+		{"missing,unread_notifications.count,next_shift.missing", gin.H{"next_shift": gin.H{}}},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/volunteer/mobile/summary", nil)
+		c.Request.URL.RawQuery = "fields=" + tt.fields
+
+		if got := SelectFields(c, summary()); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("fields=%q: got %v, want %v", tt.fields, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package shared
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceIDHeader lets scanners and the visitor app identify the device a ticket was
+// scanned on
+const DeviceIDHeader = "X-Device-ID"
+
+// HoldSuspectTicketScan records a ticket scan and checks it for misuse. When the scan
+// is suspect it writes a 409 with the fraud events for the desk and returns false;
+// the caller should stop without checking the ticket in.
+func HoldSuspectTicketScan(c *gin.Context, ticket *models.Ticket, method string, staffID *uint, selfService bool) bool {
+	events, err := services.NewTicketFraudService().Inspect(ticket, services.TicketScanContext{
+		Method:      method,
+		DeviceID:    services.TicketScanDevice(c.GetHeader(DeviceIDHeader), c.ClientIP(), c.Request.UserAgent()),
+		IPAddress:   c.ClientIP(),
+		StaffID:     staffID,
+		SelfService: selfService,
+	})
+	if err != nil {
+		// Fraud checks should not stop the desk from working
+		log.Printf("Failed to check ticket %s for misuse: %v", ticket.TicketNumber, err)
+		return true
+	}
+	if len(events) == 0 {
+		return true
+	}
+
+	c.JSON(http.StatusConflict, gin.H{
+		"error":        "Check-in held: this ticket needs checking by staff",
+		"valid":        false,
+		"fraud_alert":  true,
+		"fraud_events": events,
+	})
+	return false
+}
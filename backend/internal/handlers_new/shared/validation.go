@@ -11,6 +11,7 @@ import (
 
 	"github.com/geoo115/charity-management-system/internal/models"
 	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
@@ -263,11 +264,11 @@ func GenerateQRCode(data string) (string, error) {
 	return fmt.Sprintf("QR_%s", data), nil
 }
 
-// checkVisitEligibility checks if a visitor is eligible for a visit
+// CheckVisitEligibility checks if a visitor is eligible for a visit. Visitors whose
+// re-verification grace period has ended cannot book until their documents are
+// re-approved.
 func CheckVisitEligibility(userID uint) error {
-	// Placeholder implementation
-	// In production, this would check various eligibility criteria
-	return nil
+	return services.NewVisitorReverificationService().CheckBooking(userID, time.Now())
 }
 
 // checkDailyCapacity checks if daily capacity allows new visits
@@ -0,0 +1,51 @@
+package system
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLiveAnnouncements returns the banners showing now for the signed-in user's role.
+// Clients poll this, and also receive "announcement" messages over the WebSocket.
+func GetLiveAnnouncements(c *gin.Context) {
+	role, _ := c.Get("userRole")
+	roleName, _ := role.(string)
+	if roleName == "" {
+		roleName = models.RoleUser
+	}
+	respondLiveAnnouncements(c, roleName)
+}
+
+// GetPublicAnnouncements returns the banners marked public, for signed-out visitors
+func GetPublicAnnouncements(c *gin.Context) {
+	respondLiveAnnouncements(c, "")
+}
+
+// respondLiveAnnouncements writes the live announcements for a role
+func respondLiveAnnouncements(c *gin.Context, role string) {
+	announcements, err := services.NewAnnouncementService().Live(role, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch announcements"})
+		return
+	}
+
+	banners := make([]gin.H, 0, len(announcements))
+	for _, announcement := range announcements {
+		banners = append(banners, gin.H{
+			"id":         announcement.ID,
+			"title":      announcement.Title,
+			"content":    announcement.Content,
+			"severity":   announcement.Severity,
+			"starts_at":  announcement.StartsAt,
+			"expires_at": announcement.ExpiresAt,
+		})
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"announcements": banners})
+}
@@ -0,0 +1,44 @@
+package system
+
+import (
+	"net/http"
+
+	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPublicBranding returns the branding in effect for a location so the web app
+// can match emails and documents
+func GetPublicBranding(c *gin.Context) {
+	profile := services.NewBrandingService().Resolve(c.Query("location"))
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"display_name":   profile.DisplayName,
+		"primary_color":  profile.PrimaryColor,
+		"accent_color":   profile.AccentColor,
+		"footer_text":    profile.FooterText,
+		"charity_number": profile.CharityNumber,
+		"website":        profile.Website,
+		"contact_email":  profile.ContactEmail,
+		"contact_phone":  profile.ContactPhone,
+		"address":        profile.Address,
+		"logo_url":       notifications.BrandingLogoURL(profile),
+	})
+}
+
+// GetBrandingLogo serves the uploaded logo for a location. Emails link here, so it
+// needs no login.
+func GetBrandingLogo(c *gin.Context) {
+	data, contentType, _, ok := services.NewBrandingService().Logo(c.Query("location"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No logo has been uploaded"})
+		return
+	}
+
+	// The logo URL carries a version, so it can be cached for a long time
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,27 @@
+package system
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// transparentGIF is a 1x1 transparent GIF returned by the open-tracking pixel
+var transparentGIF = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// TrackCampaignOpen records that a campaign email was opened. It always returns the
+// pixel so unknown tokens reveal nothing.
+func TrackCampaignOpen(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".gif")
+	_ = services.NewCampaignService().RecordOpen(token)
+
+	c.Header("Cache-Control", "no-store, no-cache, must-revalidate")
+	c.Data(http.StatusOK, "image/gif", transparentGIF)
+}
@@ -284,7 +284,8 @@ func GetMessageTemplates(c *gin.Context) {
 	// Implement template retrieval logic
 	var templates []models.NotificationTemplate
 
-	query := db.DB
+	// Edits of transactional emails are managed under email templates
+	query := db.DB.Where("template_type = '' OR template_type IS NULL")
 	if category != "" {
 		query = query.Where("category = ?", category)
 	}
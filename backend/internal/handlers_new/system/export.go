@@ -10,6 +10,7 @@ import (
 
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -264,7 +265,7 @@ func ExportDonationsToCSV(c *gin.Context) {
 	writer := csv.NewWriter(c.Writer)
 
 	// Write header row
-	header := []string{"ID", "Donor Name", "Email", "Phone", "Type", "Amount", "Currency", "Goods", "Status", "Date", "Receipt Sent", "Notes"}
+	header := []string{"ID", "Donor Name", "Email", "Phone", "Type", "Amount", "Refunded", "Net Amount", "Currency", "Goods", "Status", "Date", "Refunded At", "Receipt Sent", "Notes"}
 	if err := writer.Write(header); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "failed to write CSV header",
@@ -275,6 +276,11 @@ func ExportDonationsToCSV(c *gin.Context) {
 
 	// Write data rows
 	for _, donation := range donations {
+		refundedAt := ""
+		if donation.RefundedAt != nil {
+			refundedAt = donation.RefundedAt.Format("2006-01-02 15:04:05")
+		}
+
 		// For each donation, add a row with their details
 		record := []string{
 			fmt.Sprintf("%d", donation.ID),
@@ -283,10 +289,13 @@ func ExportDonationsToCSV(c *gin.Context) {
 			donation.ContactPhone,
 			donation.Type,
 			fmt.Sprintf("%.2f", donation.Amount),
+			fmt.Sprintf("%.2f", donation.RefundedAmount),
+			fmt.Sprintf("%.2f", donation.Amount-donation.RefundedAmount),
 			donation.Currency,
 			donation.Goods, // Use consistent field name
 			donation.Status,
 			donation.CreatedAt.Format("2006-01-02 15:04:05"),
+			refundedAt,
 			fmt.Sprintf("%t", donation.ReceiptSent),
 			donation.Notes,
 		}
@@ -354,8 +363,24 @@ func ExportHelpRequestsToCSV(c *gin.Context) {
 	// Create CSV writer
 	writer := csv.NewWriter(c.Writer)
 
+	// Exports for a single category get a column per extra question; otherwise the
+	// answers are summarised in one column
+	var formFields models.FormFields
+	if category != "" {
+		if form, err := services.NewFormDefinitionService().ActiveForCategory(category); err == nil && form != nil {
+			formFields = form.Fields
+		}
+	}
+
 	// Write header row
 	header := []string{"ID", "Reference", "Visitor Name", "Email", "Phone", "Postcode", "Category", "Status", "Visit Day", "Time Slot", "Created At", "Details"}
+	if len(formFields) > 0 {
+		for _, field := range formFields {
+			header = append(header, field.Label)
+		}
+	} else {
+		header = append(header, "Additional Answers")
+	}
 	if err := writer.Write(header); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "failed to write CSV header",
@@ -380,6 +405,14 @@ func ExportHelpRequestsToCSV(c *gin.Context) {
 			request.CreatedAt.Format("2006-01-02 15:04:05"),
 			request.Details,
 		}
+		if len(formFields) > 0 {
+			for _, field := range formFields {
+				answer, _ := request.FormAnswers.Find(field.Key)
+				row = append(row, answer.DisplayValue())
+			}
+		} else {
+			row = append(row, request.FormAnswers.Summary())
+		}
 
 		if err := writer.Write(row); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -3,12 +3,16 @@ package system
 import (
 	"database/sql"
 	"fmt"
+	"html"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -139,6 +143,9 @@ func SubmitVisitFeedback(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save feedback"})
 		return
 	}
+	if err := services.NewFeedbackSurveyService().RecordResponse(visit.ID, feedback.ID, time.Now()); err != nil {
+		log.Printf("Failed to record feedback survey response for visit %d: %v", visit.ID, err)
+	}
 
 	// Send notification to admin team for review (async)
 	go func() {
@@ -392,6 +399,19 @@ func UpdateFeedbackReviewStatus(c *gin.Context) {
 				CreatedAt: time.Now(),
 			}
 			db.DB.Create(&notification)
+
+			// Email the response too, quoting the feedback reference so a reply is
+			// threaded back onto this feedback
+			var visitor models.User
+			if !feedback.AllowFollowUp || db.DB.Select("id", "email").First(&visitor, feedback.VisitorID).Error != nil || visitor.Email == "" {
+				return
+			}
+			subject := fmt.Sprintf("Response to your feedback (%s)", services.FeedbackReference(feedback.ID))
+			body := fmt.Sprintf("<p>Thank you for your feedback. Our response:</p><p>%s</p><p>You can reply to this email if you would like to tell us more.</p>",
+				html.EscapeString(req.AdminResponse))
+			if err := notifications.GetService().SendEmail(visitor.Email, subject, body); err != nil {
+				log.Printf("Failed to email feedback response to user %d: %v", visitor.ID, err)
+			}
 		}()
 	}
 
@@ -0,0 +1,88 @@
+package system
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inboundMaxBodySize caps webhook bodies: five 5MB attachments plus the message
+const inboundMaxBodySize = 32 << 20
+
+// InboundDocumentWebhook receives emails forwarded by the provider's inbound parse
+// webhook as multipart form data. The provider is configured with the shared key in
+// the URL. Messages are always acknowledged once logged so the provider does not
+// retry ones that cannot be filed.
+func InboundDocumentWebhook(c *gin.Context) {
+	service := services.NewDocumentInboxService()
+	key := c.Query("key")
+	if key == "" {
+		key = c.GetHeader("X-Inbound-Key")
+	}
+	if err := service.VerifyWebhookKey(key); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid key"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, inboundMaxBodySize)
+	if err := c.Request.ParseMultipartForm(inboundMaxBodySize); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message"})
+		return
+	}
+
+	msg := services.InboundMessage{
+		Channel:    "email",
+		Recipients: []string{c.PostForm("to")},
+		Sender:     c.PostForm("from"),
+		Subject:    c.PostForm("subject"),
+	}
+
+	// The envelope holds the address the message was actually delivered to, which
+	// may differ from the To header for BCC or forwarded mail
+	var envelope struct {
+		To   []string `json:"to"`
+		From string   `json:"from"`
+	}
+	if raw := c.PostForm("envelope"); raw != "" && json.Unmarshal([]byte(raw), &envelope) == nil {
+		msg.Recipients = append(envelope.To, msg.Recipients...)
+		if envelope.From != "" {
+			msg.Sender = envelope.From
+		}
+	}
+
+	for _, headers := range c.Request.MultipartForm.File {
+		for _, header := range headers {
+			file, err := header.Open()
+			if err != nil {
+				continue
+			}
+			data, err := io.ReadAll(io.LimitReader(file, services.InboundMaxAttachmentSize+1))
+			file.Close()
+			if err != nil {
+				continue
+			}
+			msg.Attachments = append(msg.Attachments, services.InboundAttachment{
+				Filename: header.Filename,
+				Data:     data,
+			})
+		}
+	}
+
+	result, err := service.Ingest(msg, time.Now())
+	if err != nil {
+		log.Printf("Failed to ingest inbound documents: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process message"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": result.Message.Status,
+		"filed":  result.Message.Filed,
+	})
+}
@@ -0,0 +1,76 @@
+package system
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inboundReplyMaxBodySize caps reply webhook bodies; attachments are not kept
+const inboundReplyMaxBodySize = 10 << 20
+
+// messageIDHeader finds the Message-ID in the raw headers SendGrid forwards
+var messageIDHeader = regexp.MustCompile(`(?im)^Message-ID:\s*(\S+)`)
+
+// InboundReplyWebhook receives replies to help request and feedback emails from the
+// provider's inbound parse webhook. SendGrid posts multipart form data and Mailgun
+// posts a URL-encoded form; both are read here. Replies are always acknowledged once
+// logged so the provider does not retry ones that match nothing.
+func InboundReplyWebhook(c *gin.Context) {
+	service := services.NewInboundReplyService()
+	key := c.Query("key")
+	if key == "" {
+		key = c.GetHeader("X-Inbound-Key")
+	}
+	if err := service.VerifyWebhookKey(key); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid key"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, inboundReplyMaxBodySize)
+	if err := c.Request.ParseMultipartForm(inboundReplyMaxBodySize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message"})
+		return
+	}
+
+	msg := services.InboundReplyMessage{
+		Provider: "sendgrid",
+		Sender:   c.PostForm("from"),
+		Subject:  c.PostForm("subject"),
+		Text:     c.PostForm("text"),
+	}
+	if match := messageIDHeader.FindStringSubmatch(c.PostForm("headers")); match != nil {
+		msg.MessageID = match[1]
+	}
+
+	// Mailgun names its fields differently and strips the quoted history itself
+	if c.PostForm("recipient") != "" || c.PostForm("body-plain") != "" {
+		msg.Provider = "mailgun"
+		if msg.Sender == "" {
+			msg.Sender = c.PostForm("sender")
+		}
+		msg.Text = c.PostForm("stripped-text")
+		if msg.Text == "" {
+			msg.Text = c.PostForm("body-plain")
+		}
+		msg.MessageID = c.PostForm("Message-Id")
+	}
+
+	reply, err := service.Receive(msg, time.Now())
+	if err != nil {
+		log.Printf("Failed to record inbound reply: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process message"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":      reply.Status,
+		"entity_type": reply.EntityType,
+	})
+}
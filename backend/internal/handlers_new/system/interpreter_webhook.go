@@ -0,0 +1,53 @@
+package system
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InterpreterWebhook receives booking confirmations from the interpreter service. The
+// body must be signed with INTERPRETER_WEBHOOK_SECRET.
+func InterpreterWebhook(c *gin.Context) {
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+		return
+	}
+
+	service := services.NewInterpreterBookingService()
+	if err := service.VerifySignature(payload, c.GetHeader(services.InterpreterSignatureHeader)); err != nil {
+		log.Printf("Interpreter webhook signature verification failed")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+		return
+	}
+
+	var update services.InterpreterProviderUpdate
+	if err := json.Unmarshal(payload, &update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event data"})
+		return
+	}
+
+	booking, err := service.ApplyProviderUpdate(update)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInterpreterInvalidStatus):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be confirmed, declined or cancelled"})
+		case errors.Is(err, services.ErrInterpreterBookingNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update booking"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"booking_id": booking.ID,
+		"status":     booking.Status,
+	})
+}
@@ -0,0 +1,60 @@
+package system
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KioskRatingRequest is a single tap on the exit kiosk
+type KioskRatingRequest struct {
+	Rating   int    `json:"rating" binding:"required"`
+	Category string `json:"category"`
+}
+
+// GetKioskConfig returns what the kiosk tablet needs to draw its screen
+func GetKioskConfig(c *gin.Context) {
+	device := c.MustGet("kioskDevice").(*models.KioskDevice)
+
+	c.JSON(http.StatusOK, gin.H{
+		"device": gin.H{
+			"id":       device.ID,
+			"name":     device.Name,
+			"location": device.Location,
+		},
+		"ratings":    []int{1, 2, 3, 4, 5},
+		"categories": models.KioskCategories,
+	})
+}
+
+// SubmitKioskRating stores an anonymous quick rating from a feedback kiosk
+func SubmitKioskRating(c *gin.Context) {
+	var req KioskRatingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rating, err := services.NewKioskService().RecordRating(c.GetUint("kioskDeviceID"), req.Rating, req.Category, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrKioskInvalidRating), errors.Is(err, services.ErrKioskInvalidCategory):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrKioskDuplicateTap):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record rating"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Thank you for your feedback",
+		"id":      rating.ID,
+	})
+}
@@ -0,0 +1,39 @@
+package system
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetEnumCatalog returns the canonical lists of statuses, categories, roles and
+// priorities with their labels and allowed transitions. Pass names=a,b to fetch only
+// some enums.
+func GetEnumCatalog(c *gin.Context) {
+	catalog := models.EnumCatalog()
+
+	if names := c.Query("names"); names != "" {
+		wanted := map[string]bool{}
+		for _, name := range strings.Split(names, ",") {
+			wanted[strings.TrimSpace(name)] = true
+		}
+		filtered := make([]models.Enum, 0, len(wanted))
+		for _, enum := range catalog {
+			if wanted[enum.Name] {
+				filtered = append(filtered, enum)
+			}
+		}
+		catalog = filtered
+	}
+
+	enums := make(map[string]models.Enum, len(catalog))
+	for _, enum := range catalog {
+		enums[enum.Name] = enum
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, gin.H{"enums": enums})
+}
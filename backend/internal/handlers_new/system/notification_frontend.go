@@ -8,6 +8,7 @@ import (
 
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -203,11 +204,7 @@ func GetInAppNotifications(c *gin.Context) {
 	}
 
 	// Get unread count
-	var unreadCount int64
-	db.DB.Model(&models.InAppNotification{}).
-		Where("user_id = ? AND is_read = ? AND (expires_at IS NULL OR expires_at > ?)",
-			userID, false, time.Now()).
-		Count(&unreadCount)
+	unreadCount, _ := services.NewNotificationCenterService().UnreadCount(userID.(uint))
 
 	c.JSON(http.StatusOK, gin.H{
 		"notifications": formattedNotifications,
@@ -225,7 +222,7 @@ func MarkNotificationAsRead(c *gin.Context) {
 		return
 	}
 
-	notificationID := c.Param("id")
+	notificationID := c.Param("notificationId")
 
 	var notification models.InAppNotification
 	err := db.DB.Where("id = ? AND user_id = ?", notificationID, userID).
@@ -236,20 +233,44 @@ func MarkNotificationAsRead(c *gin.Context) {
 		return
 	}
 
-	if !notification.IsRead {
-		now := time.Now()
-		notification.IsRead = true
-		notification.ReadAt = &now
-
-		if err := db.DB.Save(&notification).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notification as read"})
-			return
-		}
+	// Marking read through the notification center updates the user's other devices
+	center := services.NewNotificationCenterService()
+	if _, err := center.MarkRead(notification.UserID, []uint{notification.ID}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notification as read"})
+		return
 	}
+	unreadCount, _ := center.UnreadCount(notification.UserID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":         "Notification marked as read",
 		"notification_id": notification.ID,
+		"unread_count":    unreadCount,
+	})
+}
+
+// MarkNotificationsAsRead marks several of the user's notifications as read at once
+func MarkNotificationsAsRead(c *gin.Context) {
+	var req struct {
+		IDs []uint `json:"ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	center := services.NewNotificationCenterService()
+	updated, err := center.MarkRead(userID, req.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notifications as read"})
+		return
+	}
+	unreadCount, _ := center.UnreadCount(userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Notifications marked as read",
+		"updated_count": updated,
+		"unread_count":  unreadCount,
 	})
 }
 
@@ -261,22 +282,16 @@ func MarkAllNotificationsAsRead(c *gin.Context) {
 		return
 	}
 
-	now := time.Now()
-	result := db.DB.Model(&models.InAppNotification{}).
-		Where("user_id = ? AND is_read = ?", userID, false).
-		Updates(map[string]interface{}{
-			"is_read": true,
-			"read_at": now,
-		})
-
-	if result.Error != nil {
+	updated, err := services.NewNotificationCenterService().MarkAllRead(userID.(uint))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notifications as read"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":       "All notifications marked as read",
-		"updated_count": result.RowsAffected,
+		"updated_count": updated,
+		"unread_count":  0,
 	})
 }
 
@@ -439,7 +454,8 @@ func SendTestNotification(c *gin.Context) {
 	})
 }
 
-// GetNotificationCount returns just the notification count for the user
+// GetNotificationCount returns the badge count for the user, counted the same way as
+// the counts pushed over the notification WebSocket
 func GetNotificationCount(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -447,12 +463,11 @@ func GetNotificationCount(c *gin.Context) {
 		return
 	}
 
-	// Get unread count
-	var unreadCount int64
-	db.DB.Model(&models.InAppNotification{}).
-		Where("user_id = ? AND is_read = ? AND (expires_at IS NULL OR expires_at > ?)",
-			userID, false, time.Now()).
-		Count(&unreadCount)
+	unreadCount, err := services.NewNotificationCenterService().UnreadCount(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notifications"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"unread_count": unreadCount,
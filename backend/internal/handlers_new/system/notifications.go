@@ -1,399 +1,91 @@
 package system
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-// GetUnifiedNotificationPreferences gets preferences for the current user
-func GetUnifiedNotificationPreferences(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
-	}
+// CategoryPreferencesRequest changes channels for one or more notification categories
+type CategoryPreferencesRequest struct {
+	Preferences []services.NotificationPreferenceUpdate `json:"preferences" binding:"required,dive"`
+}
 
-	// Get user and determine role
+// notificationChannels lists the channels the preference center offers and whether
+// each can currently reach the user
+func notificationChannels(userID uint) []gin.H {
 	var user models.User
-	if err := db.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
-		return
-	}
-
-	// Get user's notification preferences
-	var preferences models.NotificationPreferences
-	result := db.DB.Where("user_id = ?", userID).First(&preferences)
-
-	// If no preferences found, create default ones
-	if result.Error != nil {
-		preferences = models.NotificationPreferences{
-			UserID:         userID.(uint),
-			Email:          true,
-			SMS:            user.Phone != "",
-			Push:           true,
-			EmailEnabled:   true,
-			SMSEnabled:     user.Phone != "",
-			PushEnabled:    true,
-			ShiftReminders: true,
-			ShiftUpdates:   true,
-			UpcomingShifts: true,
-			SystemUpdates:  true,
-			CreatedAt:      time.Now(),
-			UpdatedAt:      time.Now(),
-		}
-
-		if err := db.DB.Create(&preferences).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create default preferences"})
-			return
-		}
-	}
+	db.DB.Select("id", "phone").First(&user, userID)
+	var pushDevices int64
+	db.DB.Model(&models.PushSubscription{}).Where("user_id = ? AND active = ?", userID, true).Count(&pushDevices)
 
-	// Define available channels
-	channels := []map[string]interface{}{
-		{
-			"id":      "email",
-			"name":    "Email",
-			"enabled": true,
-		},
-		{
-			"id":      "sms",
-			"name":    "SMS",
-			"enabled": user.Phone != "",
-		},
-		{
-			"id":      "push",
-			"name":    "Push Notifications",
-			"enabled": true,
-		},
+	return []gin.H{
+		{"id": models.NotificationChannelEmail, "name": "Email", "available": true},
+		{"id": models.NotificationChannelSMS, "name": "SMS", "available": user.Phone != ""},
+		{"id": models.NotificationChannelPush, "name": "Push notifications", "available": pushDevices > 0},
+		{"id": models.NotificationChannelInApp, "name": "In-app", "available": true},
 	}
+}
 
-	// Get type-specific preferences
-	var typePrefs []models.NotificationTypePreference
-	db.DB.Where("user_id = ?", userID).Find(&typePrefs)
-
-	// Map for quick lookup
-	prefMap := make(map[string]models.NotificationTypePreference)
-	for _, pref := range typePrefs {
-		prefMap[pref.NotificationType] = pref
+// GetUnifiedNotificationPreferences returns which channels the current user accepts
+// for each notification category (help requests, shifts, donations and marketing)
+func GetUnifiedNotificationPreferences(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
 	}
 
-	// Define relevant notification types based on user role
-	var notificationTypes []map[string]interface{}
-
-	// Common notifications for all users
-	notificationTypes = append(notificationTypes, map[string]interface{}{
-		"id":          "account_updates",
-		"name":        "Account Updates",
-		"description": "Password changes, profile updates, and security alerts",
-		"channels": map[string]bool{
-			"email": preferences.Email,
-			"sms":   preferences.SMS && getTypePrefValue(prefMap, "account_updates", "sms"),
-			"push":  preferences.Push && getTypePrefValue(prefMap, "account_updates", "push"),
-		},
-	})
-
-	notificationTypes = append(notificationTypes, map[string]interface{}{
-		"id":          "general_announcements",
-		"name":        "General Announcements",
-		"description": "Community updates and important news",
-		"channels": map[string]bool{
-			"email": preferences.Email && getTypePrefValue(prefMap, "general_announcements", "email"),
-			"sms":   preferences.SMS && getTypePrefValue(prefMap, "general_announcements", "sms"),
-			"push":  preferences.Push && getTypePrefValue(prefMap, "general_announcements", "push"),
-		},
-	})
-
-	// Role-specific notifications
-	switch user.Role {
-	case "visitor", "Visitor":
-		notificationTypes = append(notificationTypes, map[string]interface{}{
-			"id":          "visitor_appointments",
-			"name":        "Visit Appointments",
-			"description": "Reminders about your scheduled visits and updates",
-			"channels": map[string]bool{
-				"email": preferences.Email && getTypePrefValue(prefMap, "visitor_appointments", "email"),
-				"sms":   preferences.SMS && getTypePrefValue(prefMap, "visitor_appointments", "sms"),
-				"push":  preferences.Push && getTypePrefValue(prefMap, "visitor_appointments", "push"),
-			},
-		})
-
-	case "donor", "Donor":
-		notificationTypes = append(notificationTypes, map[string]interface{}{
-			"id":          "donor_receipts",
-			"name":        "Donation Receipts",
-			"description": "Receipts and confirmation of your donations",
-			"channels": map[string]bool{
-				"email": preferences.Email && getTypePrefValue(prefMap, "donor_receipts", "email"),
-				"sms":   preferences.SMS && getTypePrefValue(prefMap, "donor_receipts", "sms"),
-				"push":  preferences.Push && getTypePrefValue(prefMap, "donor_receipts", "push"),
-			},
-		})
-
-		notificationTypes = append(notificationTypes, map[string]interface{}{
-			"id":          "donor_impact",
-			"name":        "Impact Updates",
-			"description": "Information about how your donations are making a difference",
-			"channels": map[string]bool{
-				"email": preferences.Email && getTypePrefValue(prefMap, "donor_impact", "email"),
-				"sms":   preferences.SMS && getTypePrefValue(prefMap, "donor_impact", "sms"),
-				"push":  preferences.Push && getTypePrefValue(prefMap, "donor_impact", "push"),
-			},
-		})
-
-	case "volunteer", "Volunteer":
-		notificationTypes = append(notificationTypes, map[string]interface{}{
-			"id":          "volunteer_shifts",
-			"name":        "Shift Updates",
-			"description": "Shift assignments, reminders, and changes",
-			"channels": map[string]bool{
-				"email": preferences.Email && getTypePrefValue(prefMap, "volunteer_shifts", "email"),
-				"sms":   preferences.SMS && getTypePrefValue(prefMap, "volunteer_shifts", "sms"),
-				"push":  preferences.Push && getTypePrefValue(prefMap, "volunteer_shifts", "push"),
-			},
-		})
+	preferences, err := services.NewNotificationPreferenceService().Preferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notification preferences"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"channels": channels,
-		"types":    notificationTypes,
+		"channels":    notificationChannels(userID),
+		"preferences": preferences,
 	})
 }
 
-// Helper function to get preference values with defaults
-func getTypePrefValue(prefMap map[string]models.NotificationTypePreference, notifType, channel string) bool {
-	if pref, exists := prefMap[notifType]; exists {
-		switch channel {
-		case "email":
-			return pref.Email
-		case "sms":
-			return pref.SMS
-		case "push":
-			return pref.Push
-		}
-	}
-	return true // Default to true if not specified
-}
-
-// UpdateUnifiedNotificationPreferences updates notification preferences for the current user
+// UpdateUnifiedNotificationPreferences turns channels on or off for one or more
+// notification categories for the current user
 func UpdateUnifiedNotificationPreferences(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
+	userID := utils.GetUserIDFromContext(c)
+	if userID == 0 {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	var req struct {
-		Types []struct {
-			ID       string          `json:"id" validate:"required"`
-			Channels map[string]bool `json:"channels" validate:"required"`
-		} `json:"types" validate:"required,dive"`
-	}
-
+	var req CategoryPreferencesRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
 		return
 	}
 
-	// Validate input
-	if len(req.Types) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No notification types provided"})
-		return
-	}
-
-	// Valid notification types
-	validTypes := map[string]bool{
-		"shift_reminders":    true,
-		"shift_updates":      true,
-		"application_status": true,
-		"system_updates":     true,
-		"emergency_alerts":   true,
-	}
-
-	// Valid channels
-	validChannels := map[string]bool{
-		"email": true,
-		"sms":   true,
-		"push":  true,
-	}
-
-	// Validate all types and channels
-	for _, typePref := range req.Types {
-		if !validTypes[typePref.ID] {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Invalid notification type: " + typePref.ID,
-			})
-			return
-		}
-
-		if len(typePref.Channels) == 0 {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "No channels provided for type: " + typePref.ID,
-			})
-			return
-		}
-
-		for channel := range typePref.Channels {
-			if !validChannels[channel] {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error": "Invalid channel: " + channel,
-				})
-				return
-			}
-		}
-	}
-
-	// Convert userID to uint
-	var uid uint
-	switch v := userID.(type) {
-	case uint:
-		uid = v
-	case float64:
-		uid = uint(v)
-	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format"})
-		return
-	}
-
-	// Begin transaction
-	tx := db.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
-		return
-	}
-
-	// Update main preferences first
-	mainPrefs := struct {
-		Email bool
-		SMS   bool
-		Push  bool
-	}{
-		Email: false,
-		SMS:   false,
-		Push:  false,
-	}
-
-	// Check if any channel is enabled for any notification
-	for _, typePref := range req.Types {
-		for channel, enabled := range typePref.Channels {
-			if enabled {
-				switch channel {
-				case "email":
-					mainPrefs.Email = true
-				case "sms":
-					mainPrefs.SMS = true
-				case "push":
-					mainPrefs.Push = true
-				}
-			}
-		}
-	}
-
-	// Update or create main preferences record
-	var existingMainPrefs models.NotificationPreferences
-	result := tx.Where("user_id = ?", uid).First(&existingMainPrefs)
-
-	if result.Error != nil {
-		// Create new main preferences
-		newMainPrefs := models.NotificationPreferences{
-			UserID:       uid,
-			Email:        mainPrefs.Email,
-			SMS:          mainPrefs.SMS,
-			Push:         mainPrefs.Push,
-			EmailEnabled: mainPrefs.Email,
-			SMSEnabled:   mainPrefs.SMS,
-			PushEnabled:  mainPrefs.Push,
-			CreatedAt:    time.Now(),
-			UpdatedAt:    time.Now(),
-		}
-
-		if err := tx.Create(&newMainPrefs).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create main preferences"})
-			return
-		}
-	} else {
-		// Update existing preferences
-		if err := tx.Model(&existingMainPrefs).Updates(map[string]interface{}{
-			"email":         mainPrefs.Email,
-			"sms":           mainPrefs.SMS,
-			"push":          mainPrefs.Push,
-			"email_enabled": mainPrefs.Email,
-			"sms_enabled":   mainPrefs.SMS,
-			"push_enabled":  mainPrefs.Push,
-			"updated_at":    time.Now(),
-		}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update main preferences"})
+	preferences, err := services.NewNotificationPreferenceService().Update(userID, req.Preferences)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidNotificationPreference) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-	}
-
-	// Now process individual type preferences
-	for _, typePref := range req.Types {
-		// Check if type preference exists
-		var existingPref models.NotificationTypePreference
-		result := tx.Where("user_id = ? AND notification_type = ?", uid, typePref.ID).First(&existingPref)
-
-		emailEnabled := typePref.Channels["email"]
-		smsEnabled := typePref.Channels["sms"]
-		pushEnabled := typePref.Channels["push"]
-
-		if result.Error != nil {
-			// Create new preference
-			newPref := models.NotificationTypePreference{
-				UserID:           uid,
-				NotificationType: typePref.ID,
-				Email:            emailEnabled,
-				SMS:              smsEnabled,
-				Push:             pushEnabled,
-				CreatedAt:        time.Now(),
-				UpdatedAt:        time.Now(),
-			}
-
-			if err := tx.Create(&newPref).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create type preference"})
-				return
-			}
-		} else {
-			// Update existing preference
-			if err := tx.Model(&existingPref).Updates(map[string]interface{}{
-				"email":      emailEnabled,
-				"sms":        smsEnabled,
-				"push":       pushEnabled,
-				"updated_at": time.Now(),
-			}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update type preference"})
-				return
-			}
-		}
-	}
-
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit changes"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification preferences"})
 		return
 	}
 
-	// Create audit log
-	utils.CreateAuditLog(c, "Update", "NotificationPreferences", uid, "Notification preferences updated")
+	utils.CreateAuditLog(c, "Update", "NotificationPreferences", userID, "Notification preferences updated")
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Notification preferences updated successfully",
+		"message":     "Notification preferences updated successfully",
+		"preferences": preferences,
 	})
 }
 
@@ -414,6 +106,11 @@ func ResetNotificationPreferencesToDefaults(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset preferences"})
 		return
 	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.NotificationPreference{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset preferences"})
+		return
+	}
 
 	// Reset main preferences to default (all enabled)
 	if err := tx.Model(&models.NotificationPreferences{}).
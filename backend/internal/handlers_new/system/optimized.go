@@ -1,6 +1,7 @@
 package system
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -45,6 +46,18 @@ func OptimizedListVolunteers(c *gin.Context) {
 	if status != "" && status != "all" {
 		query = query.Where("status = ?", status)
 	}
+	if tag := c.Query("tag"); tag != "" {
+		tagged, err := services.NewTagService().Filter(query, "users.id", tag, models.TagEntityVolunteer)
+		if err != nil {
+			if errors.Is(err, services.ErrTagNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to filter volunteers by tag"})
+			return
+		}
+		query = tagged
+	}
 
 	// Get total count
 	var total int64
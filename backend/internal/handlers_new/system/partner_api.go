@@ -0,0 +1,62 @@
+package system
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPartnerAPIUsage returns the calling API key's own usage over the last 30 days,
+// so partners can monitor their integration
+func GetPartnerAPIUsage(c *gin.Context) {
+	value, ok := c.Get("apiKey")
+	key, isKey := value.(*models.APIKey)
+	if !ok || !isKey {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "API key required"})
+		return
+	}
+
+	now := time.Now()
+	report, err := services.NewAPIKeyService().Report(*key, now.AddDate(0, 0, -29).Format("2006-01-02"), now.Format("2006-01-02"), now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch usage"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetMyAPIKeys returns the API keys a signed-in partner contact owns, with each
+// key's usage over the last 30 days
+func GetMyAPIKeys(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	service := services.NewAPIKeyService()
+	keys, err := service.ListKeys(&userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+
+	now := time.Now()
+	from, to := now.AddDate(0, 0, -29).Format("2006-01-02"), now.Format("2006-01-02")
+	reports := make([]*services.APIKeyReport, 0, len(keys))
+	for _, key := range keys {
+		report, err := service.Report(key, from, to, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch usage"})
+			return
+		}
+		reports = append(reports, report)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys":  reports,
+		"total": len(reports),
+		"from":  from,
+		"to":    to,
+	})
+}
@@ -0,0 +1,86 @@
+package system
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+	"github.com/geoo115/charity-management-system/internal/websocket"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleQueueDisplayWebSocket streams the "now serving" board to a display screen in
+// the waiting area. Boards show ticket references and wait estimates, never names.
+func HandleQueueDisplayWebSocket(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	userRole, _ := c.Get("userRole")
+	role, _ := userRole.(string)
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Queue display WebSocket upgrade failed for user %d: %v", userID, err)
+		return
+	}
+
+	metadata := map[string]interface{}{
+		"connection_type": "queue_display",
+		"ip":              c.ClientIP(),
+		"user_agent":      c.GetHeader("User-Agent"),
+	}
+	managedConn, err := websocket.GetGlobalManager().AddConnection(conn, userID, role,
+		[]string{services.QueueDisplayTopic, "queue_updates"}, metadata)
+	if err != nil {
+		log.Printf("Failed to add queue display connection to manager: %v", err)
+		conn.Close()
+		return
+	}
+
+	sendQueueBoard(managedConn.ID)
+	<-managedConn.Context.Done()
+}
+
+// GetQueueDisplayBoard returns the "now serving" boards for displays that poll
+// instead of holding a WebSocket open
+func GetQueueDisplayBoard(c *gin.Context) {
+	boards, err := services.GetGlobalQueueService().QueueBoards()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load queue"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"boards":        boards,
+		"announcements": displayAnnouncements(),
+		"timestamp":     time.Now(),
+	})
+}
+
+// displayAnnouncements returns the public announcements showing now, such as an
+// early closure broadcast to today's ticket holders
+func displayAnnouncements() []models.Announcement {
+	announcements, err := services.NewAnnouncementService().Live("", time.Now())
+	if err != nil {
+		log.Printf("Failed to load announcements for queue display: %v", err)
+		return []models.Announcement{}
+	}
+	return announcements
+}
+
+// sendQueueBoard sends the current boards to a newly connected display
+func sendQueueBoard(connID string) {
+	boards, err := services.GetGlobalQueueService().QueueBoards()
+	if err != nil {
+		log.Printf("Failed to load queue board for display %s: %v", connID, err)
+		return
+	}
+	if err := websocket.GetGlobalManager().SendToConnection(connID, gin.H{
+		"type":          "queue_board",
+		"boards":        boards,
+		"announcements": displayAnnouncements(),
+	}); err != nil {
+		log.Printf("Failed to send queue board to display %s: %v", connID, err)
+	}
+}
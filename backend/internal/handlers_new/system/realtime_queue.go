@@ -10,6 +10,7 @@ import (
 
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 	"github.com/geoo115/charity-management-system/internal/websocket"
 
@@ -132,10 +133,13 @@ func RealTimeQueueWebSocket(c *gin.Context) {
 
 	log.Printf("Real-time queue WebSocket connection established for user %v", userID)
 
-	// Send initial queue status
-	initialStatus := getCurrentQueueStatus("system")
-	if err := websocket.GetGlobalManager().BroadcastToUser(userID.(uint), initialStatus); err != nil {
-		log.Printf("Failed to send initial status: %v", err)
+	// Send the current queue: staff see the boards, visitors their own place in it
+	if userRole == models.RoleAdmin || userRole == models.RoleSuperAdmin || userRole == models.RoleStaff {
+		sendQueueBoard(managedConn.ID)
+	} else if status, err := services.GetGlobalQueueService().VisitorQueueStatus(userID.(uint)); err == nil {
+		if err := websocket.GetGlobalManager().SendToConnection(managedConn.ID, status); err != nil {
+			log.Printf("Failed to send initial status: %v", err)
+		}
 	}
 
 	// Wait for connection to close (handled by manager)
@@ -608,6 +612,7 @@ func completeVisitorService(visitorID, staffID uint, notes string) gin.H {
 	// Create audit log
 	utils.CreateAuditLog(nil, "CompleteVisit", "Visit", visit.ID,
 		fmt.Sprintf("Visit completed for visitor %d by staff %d", visitorID, staffID))
+	services.NewFeedbackSurveyService().QueueInvitation(visit.ID)
 	// Broadcast queue update
 	BroadcastQueueUpdate("visit_completed", "", nil)
 	return gin.H{
@@ -0,0 +1,18 @@
+package system
+
+import (
+	"net/http"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetServiceStatus returns component health and active incidents for the public
+// status page and the frontend's degradation banner
+func GetServiceStatus(c *gin.Context) {
+	status := services.NewStatusService().CurrentStatus()
+
+	c.Header("Cache-Control", "public, max-age=30")
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,90 @@
+package system
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SupplierWebhookEvent is an order status update posted by a supplier
+type SupplierWebhookEvent struct {
+	Reference   string     `json:"reference"`
+	Status      string     `json:"status"` // acknowledged, dispatched or cancelled
+	ExternalRef string     `json:"external_ref"`
+	ExpectedAt  *time.Time `json:"expected_at"`
+	Notes       string     `json:"notes"`
+}
+
+// SupplierWebhook receives order status updates from a supplier. The body must be
+// signed with the supplier's webhook secret. Deliveries are recorded by staff when
+// the stock arrives, not from the supplier's word.
+func SupplierWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid supplier ID"})
+		return
+	}
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+		return
+	}
+
+	var supplier models.Supplier
+	if err := db.DB.Where("is_active = ?", true).First(&supplier, id).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+		return
+	}
+	if err := services.VerifySupplierSignature(&supplier, payload, c.GetHeader(services.SupplierSignatureHeader)); err != nil {
+		log.Printf("Supplier %d webhook signature verification failed", supplier.ID)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+		return
+	}
+
+	var event SupplierWebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil || event.Reference == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event data"})
+		return
+	}
+	switch event.Status {
+	case "", models.SupplierOrderAcknowledged, models.SupplierOrderDispatched, models.SupplierOrderCancelled:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be acknowledged, dispatched or cancelled"})
+		return
+	}
+
+	var order models.SupplierOrder
+	if err := db.DB.Preload("Lines").Where("reference = ? AND supplier_id = ?", event.Reference, supplier.ID).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	err = services.NewSupplierOrderService().UpdateStatus(&order, services.SupplierStatusUpdate{
+		Status:      event.Status,
+		ExternalRef: event.ExternalRef,
+		ExpectedAt:  event.ExpectedAt,
+		Notes:       event.Notes,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrSupplierInvalidTransition) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Order cannot move to that status", "status": order.Status})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reference": order.Reference,
+		"status":    order.Status,
+	})
+}
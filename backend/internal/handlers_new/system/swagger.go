@@ -88,6 +88,10 @@ var SwaggerSpec = `{
       "name": "Admin - User Management",
       "description": "User account administration"
     },
+    {
+      "name": "Admin - Inventory",
+      "description": "Stock items, stock movements and shortages"
+    },
     {
       "name": "Document Management",
       "description": "Document upload and verification"
@@ -289,6 +293,39 @@ var SwaggerSpec = `{
           "createdAt": {"type": "string", "format": "date-time"}
         }
       },
+      "InventoryItem": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer", "example": 1},
+          "name": {"type": "string", "example": "Tinned Vegetables"},
+          "category_id": {"type": "integer", "example": 2},
+          "unit": {"type": "string", "example": "tins"},
+          "quantity": {"type": "integer", "example": 8},
+          "minimum_level": {"type": "integer", "example": 20},
+          "target_level": {"type": "integer", "example": 100},
+          "location": {"type": "string", "example": "Store room, shelf B"},
+          "active": {"type": "boolean", "example": true},
+          "urgent_need_id": {"type": "integer", "nullable": true, "example": 4, "description": "Urgent need published while the item is at or below its minimum level"},
+          "urgency": {"type": "string", "enum": ["", "Low", "Medium", "High", "Critical"], "example": "Critical"},
+          "urgent": {"type": "boolean", "example": true},
+          "shortfall": {"type": "integer", "example": 92},
+          "last_moved_at": {"type": "string", "format": "date-time", "nullable": true}
+        }
+      },
+      "StockMovement": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer", "example": 1},
+          "item_id": {"type": "integer", "example": 1},
+          "type": {"type": "string", "enum": ["in", "out", "waste", "adjust"], "example": "out"},
+          "change": {"type": "integer", "example": -12},
+          "quantity_after": {"type": "integer", "example": 8},
+          "reason": {"type": "string", "example": "Thursday food bank session"},
+          "reference": {"type": "string", "example": "Supplier order 12"},
+          "recorded_by": {"type": "integer", "nullable": true, "example": 3},
+          "created_at": {"type": "string", "format": "date-time"}
+        }
+      },
       "Visit": {
         "type": "object",
         "properties": {
@@ -406,7 +443,8 @@ var SwaggerSpec = `{
             "properties": {
               "totalFeedback": {"type": "integer", "example": 145},
               "averageRating": {"type": "number", "example": 4.2},
-              "responseRate": {"type": "number", "example": 67.3, "description": "Percentage of visitors who provide feedback"},
+              "responseRate": {"type": "number", "example": 67.3, "description": "Percentage of visitors invited after checking out who gave feedback"},
+              "surveysSent": {"type": "integer", "example": 214},
               "priorityItems": {"type": "integer", "example": 8}
             }
           }
@@ -1286,6 +1324,112 @@ var SwaggerSpec = `{
         }
       }
     },
+    "/admin/inventory/items": {
+      "get": {
+        "tags": ["Admin - Inventory"],
+        "summary": "List stock items",
+        "description": "Stock items with their urgency against their minimum and target levels",
+        "security": [{"bearerAuth": []}],
+        "parameters": [
+          {"name": "category_id", "in": "query", "schema": {"type": "integer"}},
+          {"name": "search", "in": "query", "schema": {"type": "string"}},
+          {"name": "urgent", "in": "query", "schema": {"type": "boolean"}},
+          {"name": "include_inactive", "in": "query", "schema": {"type": "boolean"}}
+        ],
+        "responses": {
+          "200": {
+            "description": "Stock items retrieved successfully",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "properties": {
+                    "items": {"type": "array", "items": {"$ref": "#/components/schemas/InventoryItem"}},
+                    "count": {"type": "integer", "example": 24}
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/admin/inventory/items/{id}/movements": {
+      "post": {
+        "tags": ["Admin - Inventory"],
+        "summary": "Record a stock movement",
+        "description": "Book stock in, out or as waste, or correct it to a stocktake count with an adjustment",
+        "security": [{"bearerAuth": []}],
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "type": "object",
+                "required": ["type", "quantity"],
+                "properties": {
+                  "type": {"type": "string", "enum": ["in", "out", "waste", "adjust"]},
+                  "quantity": {"type": "integer", "example": 12},
+                  "reason": {"type": "string"},
+                  "reference": {"type": "string"}
+                }
+              }
+            }
+          }
+        },
+        "responses": {
+          "201": {
+            "description": "Movement recorded",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "properties": {
+                    "movement": {"$ref": "#/components/schemas/StockMovement"},
+                    "item": {"$ref": "#/components/schemas/InventoryItem"}
+                  }
+                }
+              }
+            }
+          },
+          "409": {
+            "description": "Not enough stock",
+            "content": {
+              "application/json": {
+                "schema": {"$ref": "#/components/schemas/Error"}
+              }
+            }
+          }
+        }
+      }
+    },
+    "/admin/inventory/shortages": {
+      "get": {
+        "tags": ["Admin - Inventory"],
+        "summary": "List stock shortages",
+        "description": "Items at or below their minimum level, most urgent first. Each is published as an urgent need for donors.",
+        "security": [{"bearerAuth": []}],
+        "responses": {
+          "200": {
+            "description": "Shortages retrieved successfully",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "properties": {
+                    "items": {"type": "array", "items": {"$ref": "#/components/schemas/InventoryItem"}},
+                    "count": {"type": "integer", "example": 3}
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    },
     "/queue": {
       "get": {
         "tags": ["Queue Management"],
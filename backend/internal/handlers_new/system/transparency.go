@@ -0,0 +1,36 @@
+package system
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTicketTransparency publishes weekly demand against tickets issued so the
+// community can see how tickets are allocated. Counts are anonymised and small
+// numbers are suppressed.
+func GetTicketTransparency(c *gin.Context) {
+	weeks, err := strconv.Atoi(c.DefaultQuery("weeks", "12"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid number of weeks"})
+		return
+	}
+
+	transparency, err := services.NewReleaseRunService().Transparency(weeks, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrTransparencyRange) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ticket statistics"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, transparency)
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/websocket"
 
 	"github.com/gin-gonic/gin"
@@ -57,10 +58,11 @@ type NotificationUpdate struct {
 
 // WebSocketStatusResponse represents the status of the WebSocket service
 type WebSocketStatusResponse struct {
-	Available       bool      `json:"available"`
-	ActiveSessions  int       `json:"active_sessions"`
-	Endpoints       []string  `json:"endpoints"`
-	ServerTimestamp time.Time `json:"server_timestamp"`
+	Available       bool               `json:"available"`
+	ActiveSessions  int                `json:"active_sessions"`
+	Endpoints       []string           `json:"endpoints"`
+	Hub             websocket.HubStats `json:"hub"`
+	ServerTimestamp time.Time          `json:"server_timestamp"`
 }
 
 // HandleWebSocketStatus returns information about the WebSocket server status
@@ -79,6 +81,7 @@ func HandleWebSocketStatus(c *gin.Context) {
 			"/ws/volunteer/notifications",
 			"/ws/volunteer/queue",
 		},
+		Hub:             websocket.GetGlobalManager().GetHubStats(),
 		ServerTimestamp: time.Now(),
 	}
 
@@ -221,6 +224,11 @@ func HandleNotificationUpdates(c *gin.Context) {
 		log.Printf("Sending unread notifications to user %v", userID)
 		sendUnreadNotifications(userID.(uint))
 		log.Printf("Finished sending unread notifications to user %v", userID)
+
+		// Bring the badge and read state on this device in line with the others
+		if err := services.NewNotificationCenterService().Sync(userID.(uint)); err != nil {
+			log.Printf("Failed to sync notification state for user %v: %v", userID, err)
+		}
 	}()
 
 	log.Printf("Waiting for connection context to close for user %v", userID)
@@ -232,7 +240,8 @@ func HandleNotificationUpdates(c *gin.Context) {
 // sendUnreadNotifications sends existing unread notifications to a user
 func sendUnreadNotifications(userID uint) {
 	var unreadNotifications []models.InAppNotification
-	if err := db.DB.Where("user_id = ? AND read = false", userID).Find(&unreadNotifications).Error; err != nil {
+	if err := db.DB.Where("user_id = ? AND is_read = ? AND (expires_at IS NULL OR expires_at > ?)", userID, false, time.Now()).
+		Order("created_at ASC").Find(&unreadNotifications).Error; err != nil {
 		log.Printf("Error fetching unread notifications: %v", err)
 		return
 	}
@@ -0,0 +1,128 @@
+package visitor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// appointmentBookingHorizon is how far ahead visitors can see and book slots
+const appointmentBookingHorizon = 28 * 24 * time.Hour
+
+// AppointmentBookingRequest books an advice appointment
+type AppointmentBookingRequest struct {
+	SlotID uint   `json:"slot_id" binding:"required"`
+	Notes  string `json:"notes"` // What the visitor would like help with
+}
+
+// AppointmentCancellation gives the visitor's reason for cancelling
+type AppointmentCancellation struct {
+	Reason string `json:"reason"`
+}
+
+// GetAvailableAppointmentSlots lists open advice slots over the next four weeks,
+// optionally for one service
+func GetAvailableAppointmentSlots(c *gin.Context) {
+	now := time.Now()
+	slots, err := services.NewAppointmentService().AvailableSlots(c.Query("category"), now, now.Add(appointmentBookingHorizon), now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch appointment slots"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"slots": slots})
+}
+
+// GetMyAppointments lists the visitor's appointments, most recent first
+func GetMyAppointments(c *gin.Context) {
+	appointments, err := services.NewAppointmentService().ForVisitor(utils.GetUserIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch appointments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"appointments": appointments})
+}
+
+// BookAppointment books an open slot for the visitor
+func BookAppointment(c *gin.Context) {
+	var req AppointmentBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	visitorID := utils.GetUserIDFromContext(c)
+	if err := services.NewVisitorReverificationService().CheckBooking(visitorID, time.Now()); err != nil {
+		writeAppointmentError(c, err)
+		return
+	}
+	appointment, err := services.NewAppointmentService().Book(visitorID, req.SlotID, req.Notes, time.Now())
+	if err != nil {
+		writeAppointmentError(c, err)
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "Appointment", appointment.ID,
+		fmt.Sprintf("Visitor %d booked %s appointment slot %d", visitorID, appointment.Slot.Category, appointment.SlotID))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Appointment booked. We'll send you a reminder the day before.",
+		"appointment": appointment,
+	})
+}
+
+// CancelMyAppointment cancels one of the visitor's appointments before it starts
+func CancelMyAppointment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+	var req AppointmentCancellation
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	visitorID := utils.GetUserIDFromContext(c)
+	appointment, err := services.NewAppointmentService().Cancel(uint(id), visitorID, visitorID, req.Reason, time.Now())
+	if err != nil {
+		writeAppointmentError(c, err)
+		return
+	}
+
+	utils.CreateAuditLog(c, "Cancel", "Appointment", appointment.ID,
+		fmt.Sprintf("Visitor %d cancelled their appointment", visitorID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Appointment cancelled",
+		"appointment": appointment,
+	})
+}
+
+// writeAppointmentError maps appointment service errors to responses
+func writeAppointmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAppointmentSlotNotFound), errors.Is(err, services.ErrAppointmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAppointmentSlotTaken), errors.Is(err, services.ErrAppointmentDuplicate),
+		errors.Is(err, services.ErrAppointmentNotBooked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAppointmentSlotPast):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAppointmentNoShowLimit), errors.Is(err, services.ErrReverificationRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update appointment"})
+	}
+}
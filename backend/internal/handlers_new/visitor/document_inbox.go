@@ -0,0 +1,55 @@
+package visitor
+
+import (
+	"net/http"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDocumentInbox returns the visitor's private address for emailing document photos
+func GetDocumentInbox(c *gin.Context) {
+	service := services.NewDocumentInboxService()
+	if !service.Configured() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Sending documents by email is not available"})
+		return
+	}
+
+	inbox, err := service.InboxFor(utils.GetUserIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch upload address"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"address":          service.Address(inbox),
+		"is_active":        inbox.IsActive,
+		"last_received_at": inbox.LastReceivedAt,
+		"instructions":     "Email photos or scans of your documents to this address. Only PDF, JPG and PNG files up to 5MB are accepted. Keep this address private.",
+	})
+}
+
+// RotateDocumentInbox replaces the visitor's upload address, e.g. if it was shared by mistake
+func RotateDocumentInbox(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	service := services.NewDocumentInboxService()
+	if !service.Configured() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Sending documents by email is not available"})
+		return
+	}
+
+	inbox, err := service.Rotate(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change upload address"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Rotate", "DocumentInbox", inbox.ID, "Visitor changed their document upload address")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Upload address changed; the old address no longer works",
+		"address": service.Address(inbox),
+	})
+}
@@ -12,7 +12,9 @@ import (
 	"time"
 
 	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/handlers_new/shared"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -100,7 +102,13 @@ func UploadVisitorDocument(c *gin.Context) {
 	// Check if document already exists for this user and type
 	var existingDoc models.Document
 	if err := db.DB.Where("user_id = ? AND type = ?", userID, documentType).First(&existingDoc).Error; err == nil {
-		if existingDoc.Status == models.DocumentStatusApproved {
+		// Approved documents can only be replaced when they are due for re-verification
+		reverifying, err := services.NewVisitorReverificationService().HasOpenCycle(userID.(uint))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check document re-verification"})
+			return
+		}
+		if existingDoc.Status == models.DocumentStatusApproved && !reverifying {
 			c.JSON(http.StatusConflict, gin.H{
 				"error":             "Document of this type already approved",
 				"existing_document": existingDoc,
@@ -126,6 +134,7 @@ func UploadVisitorDocument(c *gin.Context) {
 		FilePath:    filePath,
 		Status:      models.DocumentStatusPending,
 		Description: description,
+		Source:      models.DocumentSourceUpload,
 		UploadedAt:  now,
 		CreatedAt:   now,
 		UpdatedAt:   now,
@@ -202,6 +211,16 @@ func VisitorCheckIn(c *gin.Context) {
 		return
 	}
 
+	// Tickets checked in from the visitor's own device are checked for shared screenshots
+	// and devices as well as reuse
+	var scanStaffID *uint
+	if req.StaffMemberID > 0 {
+		scanStaffID = &req.StaffMemberID
+	}
+	if !shared.HoldSuspectTicketScan(c, &ticket, req.CheckInMethod, scanStaffID, req.StaffMemberID == 0) {
+		return
+	}
+
 	// Validate ticket
 	if !ticket.CanBeUsed() {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -327,8 +346,12 @@ func VisitorCheckIn(c *gin.Context) {
 	utils.CreateAuditLog(c, "CheckIn", "Visit", visit.ID,
 		fmt.Sprintf("Visitor checked in: %s (Position: %d)", req.TicketNumber, queuePosition))
 
+	// Warn the desk when a visitor who asked for an interpreter does not have one
+	interpreter := services.NewInterpreterBookingService().CheckIn(&ticket)
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Check-in successful",
+		"message":     "Check-in successful",
+		"interpreter": interpreter,
 		"visit": gin.H{
 			"id":            visit.ID,
 			"ticket_number": ticket.TicketNumber,
@@ -0,0 +1,92 @@
+package visitor
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetHelpRequestDraft returns the visitor's unfinished help request so they can
+// resume it at the step they reached
+func GetHelpRequestDraft(c *gin.Context) {
+	visitorID := utils.GetUserIDFromContext(c)
+	if visitorID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		return
+	}
+
+	draft, err := services.NewHelpRequestDraftService().Current(visitorID, time.Now())
+	if errors.Is(err, services.ErrDraftNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No help request draft to resume"})
+		return
+	}
+	if err != nil {
+		log.Printf("GetHelpRequestDraft error: failed to load draft for visitor %d: %v", visitorID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load your draft"})
+		return
+	}
+	c.JSON(http.StatusOK, draft)
+}
+
+// SaveHelpRequestDraftStep saves the answers for one step of the help request wizard.
+// The answers are kept even when the step has problems; the problems come back by
+// field and the draft stays at that step.
+func SaveHelpRequestDraftStep(c *gin.Context) {
+	visitorID := utils.GetUserIDFromContext(c)
+	if visitorID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		return
+	}
+
+	var answers services.HelpRequestDraftAnswers
+	if err := c.ShouldBindJSON(&answers); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	draft, fieldErrors, err := services.NewHelpRequestDraftService().SaveStep(visitorID, c.Param("step"), answers, time.Now())
+	switch {
+	case errors.Is(err, services.ErrUnknownWizardStep):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrWizardStepIncomplete):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":        "Please check your answers",
+			"field_errors": fieldErrors,
+			"draft":        draft,
+		})
+		return
+	case err != nil:
+		log.Printf("SaveHelpRequestDraftStep error: failed to save draft for visitor %d: %v", visitorID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save your draft"})
+		return
+	}
+	c.JSON(http.StatusOK, draft)
+}
+
+// DiscardHelpRequestDraft throws away the visitor's unfinished help request
+func DiscardHelpRequestDraft(c *gin.Context) {
+	visitorID := utils.GetUserIDFromContext(c)
+	if visitorID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		return
+	}
+
+	err := services.NewHelpRequestDraftService().Discard(visitorID, time.Now())
+	if errors.Is(err, services.ErrDraftNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No help request draft to discard"})
+		return
+	}
+	if err != nil {
+		log.Printf("DiscardHelpRequestDraft error: failed to discard draft for visitor %d: %v", visitorID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to discard your draft"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Draft discarded"})
+}
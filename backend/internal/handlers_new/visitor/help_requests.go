@@ -2,6 +2,8 @@ package visitor
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/geoo115/charity-management-system/internal/db" // Add this import
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils" // Add this import
 
 	"github.com/geoo115/charity-management-system/internal/notifications"
@@ -41,6 +44,12 @@ type HelpRequestRequest struct {
 	UrgencyLevel  string `json:"urgency_level"`
 	HouseholdSize int    `json:"household_size"`
 	SpecialNeeds  string `json:"special_needs"`
+
+	// Answers to the service type's extra questions, keyed by question key
+	FormAnswers map[string]interface{} `json:"form_answers"`
+
+	// Language to book an interpreter for; empty when none is needed
+	InterpreterLanguage string `json:"interpreter_language" binding:"max=100"`
 }
 
 type UpdateHelpRequestRequest struct {
@@ -147,6 +156,18 @@ func ListHelpRequests(c *gin.Context) {
 		query = query.Where("reference LIKE ? OR visitor_name LIKE ? OR email LIKE ?",
 			"%"+search+"%", "%"+search+"%", "%"+search+"%")
 	}
+	if tag := c.Query("tag"); tag != "" {
+		tagged, err := services.NewTagService().Filter(query, "help_requests.id", tag, models.TagEntityHelpRequest)
+		if err != nil {
+			if errors.Is(err, services.ErrTagNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to filter help requests by tag"})
+			return
+		}
+		query = tagged
+	}
 
 	// Get total count
 	var total int64
@@ -188,6 +209,7 @@ func ListHelpRequests(c *gin.Context) {
 			"approved_at":          req.ApprovedAt,
 			"ticket_number":        req.TicketNumber,
 			"qr_code":              req.QRCode,
+			"form_answers":         req.FormAnswers,
 			"visitor": gin.H{
 				"id":         visitor.ID,
 				"first_name": visitor.FirstName,
@@ -309,8 +331,19 @@ func GetTimeSlots(c *gin.Context) {
 	// Get day of week
 	dayOfWeek := parsedDate.Weekday()
 
-	// Validate allowed days (Tue, Wed, Thu)
-	if dayOfWeek != time.Tuesday && dayOfWeek != time.Wednesday && dayOfWeek != time.Thursday {
+	// Use the configured service type's hours and slot rules when one exists
+	serviceType, stErr := services.NewServiceTypeService().GetByCode(category)
+	if stErr == nil {
+		if !serviceType.IsActive {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "this service is not currently available"})
+			return
+		}
+		if !serviceType.IsOperatingDay(dayOfWeek) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("appointments are only available on %s", strings.ReplaceAll(serviceType.OperatingDays, ",", ", "))})
+			return
+		}
+	} else if dayOfWeek != time.Tuesday && dayOfWeek != time.Wednesday && dayOfWeek != time.Thursday {
+		// Validate allowed days (Tue, Wed, Thu)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "appointments are only available on Tuesday, Wednesday, and Thursday"})
 		return
 	}
@@ -336,6 +369,19 @@ func GetTimeSlots(c *gin.Context) {
 	slotInterval := 10 // minutes
 	maxVisitorsPerSlot := 2
 
+	if stErr == nil {
+		if open, closing, err := serviceType.SlotWindow(); err == nil {
+			startHour, startMinute = open/60, open%60
+			endHour, endMinute = closing/60, closing%60
+		}
+		if serviceType.SlotIntervalMinutes > 0 {
+			slotInterval = serviceType.SlotIntervalMinutes
+		}
+		if serviceType.MaxVisitorsPerSlot > 0 {
+			maxVisitorsPerSlot = serviceType.MaxVisitorsPerSlot
+		}
+	}
+
 	// Query database for existing bookings
 	var bookings []models.HelpRequest
 	if err := db.DB.Where("visit_day = ? AND category = ?", date, category).Find(&bookings).Error; err != nil {
@@ -356,7 +402,7 @@ func GetTimeSlots(c *gin.Context) {
 	startTotalMinutes := startHour*60 + startMinute
 	endTotalMinutes := endHour*60 + endMinute
 
-	// Generate slots at 10-minute intervals
+	// Generate slots at the configured interval
 	for totalMinutes := startTotalMinutes; totalMinutes < endTotalMinutes; totalMinutes += slotInterval {
 		hour := totalMinutes / 60
 		minute := totalMinutes % 60
@@ -488,7 +534,76 @@ func CreateHelpRequest(c *gin.Context) {
 		return
 	}
 
+	// Apply the service type's own capacity rules and ticket format
+	serviceTypeService := services.NewServiceTypeService()
+	serviceType, stErr := serviceTypeService.GetByCode(request.Category)
+	if stErr == nil && !serviceType.IsActive {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("%s is not currently available", serviceType.Name),
+		})
+		return
+	}
+
+	// Only accept bookings while the service's booking window for the day is open
+	if window, err := services.NewBookingWindowService().Check(request.Category, request.VisitDay, time.Now()); err != nil {
+		switch {
+		case errors.Is(err, services.ErrBookingNotOpen), errors.Is(err, services.ErrBookingClosed):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success":   false,
+				"error":     window.Message,
+				"opens_at":  window.OpensAt,
+				"closes_at": window.ClosesAt,
+			})
+		case errors.Is(err, services.ErrInvalidVisitDay):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		default:
+			log.Printf("CreateHelpRequest error: failed to check booking window for %s: %v", request.Category, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Internal server error",
+			})
+		}
+		return
+	}
+
+	// Check answers to the service type's extra questions
+	form, err := services.NewFormDefinitionService().ActiveForCategory(request.Category)
+	if err != nil {
+		log.Printf("CreateHelpRequest error: failed to load form for %s: %v", request.Category, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+	var formAnswers models.FormAnswers
+	if form != nil {
+		answers, fieldErrors, err := services.ValidateAnswers(form, request.FormAnswers)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success":      false,
+				"error":        err.Error(),
+				"field_errors": fieldErrors,
+			})
+			return
+		}
+		formAnswers = answers
+	}
+
+	if stErr == nil && serviceTypeService.RemainingCapacity(serviceType, request.VisitDay) <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("%s is fully booked on %s", serviceType.Name, request.VisitDay),
+		})
+		return
+	}
+
 	ticketNumber := shared.GenerateTicketNumber()
+	if stErr == nil {
+		ticketNumber = serviceTypeService.GenerateTicketNumber(serviceType)
+	}
 	qrCode, err := shared.GenerateQRCode(ticketNumber)
 	if err != nil {
 		log.Printf("Failed to generate QR code: %v", err)
@@ -510,31 +625,51 @@ func CreateHelpRequest(c *gin.Context) {
 
 	// Create help request record
 	helpRequest := models.HelpRequest{
-		VisitorID:     visitorID,
-		VisitorName:   user.FirstName + " " + user.LastName,
-		Email:         user.Email,
-		Phone:         user.Phone,
-		Postcode:      user.Postcode,
-		Category:      request.Category,
-		Details:       request.Details,
-		VisitDay:      request.VisitDay,
-		TimeSlot:      request.TimeSlot,
-		HouseholdSize: request.HouseholdSize,
-		SpecialNeeds:  request.SpecialNeeds,
-		Priority:      request.UrgencyLevel,
-		Reference:     reference,
-		Status:        models.HelpRequestStatusPending,
-		RequestDate:   time.Now(),
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		VisitorID:           visitorID,
+		VisitorName:         user.FirstName + " " + user.LastName,
+		Email:               user.Email,
+		Phone:               user.Phone,
+		Postcode:            user.Postcode,
+		Category:            request.Category,
+		Details:             request.Details,
+		VisitDay:            request.VisitDay,
+		TimeSlot:            request.TimeSlot,
+		HouseholdSize:       request.HouseholdSize,
+		SpecialNeeds:        request.SpecialNeeds,
+		Priority:            request.UrgencyLevel,
+		FormAnswers:         formAnswers,
+		Reference:           reference,
+		Status:              models.HelpRequestStatusPending,
+		InterpreterLanguage: strings.TrimSpace(request.InterpreterLanguage),
+		RequestDate:         time.Now(),
+		CreatedAt:           time.Now(),
+		UpdatedAt:           time.Now(),
+	}
+
+	if form != nil {
+		helpRequest.FormVersion = form.Version
 	}
 
 	// Set ticket details
 	helpRequest.TicketNumber = ticketNumber
 	helpRequest.QRCode = qrCode
 
-	// If visitor is eligible for Food or General help and daily capacity allows, auto-approve and issue ticket
-	if request.Category == "Food" || request.Category == "General" {
+	// While the service is closed requests are held for staff to review on reopening
+	closureService := services.NewServiceClosureService()
+	closure, err := closureService.Active(time.Now())
+	if err != nil {
+		log.Printf("Failed to check for a service closure: %v", err)
+	}
+
+	// If the service type allows it and daily capacity allows, auto-approve and issue ticket
+	autoApprove := request.Category == "Food" || request.Category == "General"
+	if stErr == nil {
+		autoApprove = serviceType.AutoApprove
+	}
+	if closure != nil {
+		autoApprove = false
+	}
+	if autoApprove {
 		// Auto-approve and issue ticket
 		helpRequest.Status = models.HelpRequestStatusTicketIssued
 
@@ -578,6 +713,38 @@ func CreateHelpRequest(c *gin.Context) {
 	// Set reference code in struct for email notification
 	helpRequest.Reference = referenceCode
 
+	// The visitor's saved draft, if they used the wizard, is finished with
+	if err := services.NewHelpRequestDraftService().Complete(visitorID, helpRequest.ID, time.Now()); err != nil {
+		log.Printf("Failed to close help request draft for visitor %d: %v", visitorID, err)
+	}
+
+	// Approve low-risk requests straight away when an auto-approval rule covers them.
+	// During a closure the visitor is sent the closure notice instead and the rules are
+	// checked again on reopening.
+	var autoApproval *models.AutoApproval
+	if closure != nil {
+		if err := closureService.HoldHelpRequest(closure, &helpRequest, time.Now()); err != nil {
+			log.Printf("Failed to queue help request %d during closure %d: %v", helpRequest.ID, closure.ID, err)
+		}
+	} else if autoApproval, err = services.NewAutoApprovalService().Apply(&helpRequest, time.Now()); err != nil {
+		log.Printf("Failed to check auto-approval rules for help request %d: %v", helpRequest.ID, err)
+	}
+
+	// Raise a task for staff to book an interpreter, and book one straight away when an
+	// interpreter service is configured
+	interpreterService := services.NewInterpreterBookingService()
+	if booking, err := interpreterService.Request(&helpRequest); err != nil {
+		log.Printf("Failed to raise interpreter booking for help request %d: %v", helpRequest.ID, err)
+	} else if booking != nil && interpreterService.ProviderConfigured() {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := interpreterService.Send(ctx, booking); err != nil {
+				log.Printf("Failed to send interpreter booking %d: %v", booking.ID, err)
+			}
+		}()
+	}
+
 	// If ticket was auto-issued, update daily capacity
 	if helpRequest.Status == models.HelpRequestStatusTicketIssued {
 		visitDay, _ := time.Parse("2006-01-02", helpRequest.VisitDay)
@@ -593,8 +760,8 @@ func CreateHelpRequest(c *gin.Context) {
 			if err := sendTicketIssuedNotificationDirect(helpRequest); err != nil {
 				log.Printf("Failed to send ticket issued notification: %v", err)
 			}
-		} else {
-			// Send regular confirmation email
+		} else if closure == nil {
+			// Send regular confirmation email; during a closure the notice stands in for it
 			if err := sendHelpRequestConfirmationEmail(helpRequest); err != nil {
 				log.Printf("Failed to send help request confirmation email: %v", err)
 			}
@@ -614,6 +781,13 @@ func CreateHelpRequest(c *gin.Context) {
 		response["qr_code"] = helpRequest.QRCode
 		response["message"] = "Help request created and ticket issued automatically"
 		response["auto_approved"] = true
+	} else if autoApproval != nil {
+		response["message"] = "Help request created and approved automatically"
+		response["auto_approved"] = true
+	}
+	if closure != nil {
+		response["message"] = "Help request received while the service is closed"
+		response["closure"] = closureService.Notice(closure)
 	}
 
 	c.JSON(http.StatusCreated, response)
@@ -653,12 +827,20 @@ func GetAvailableDays(c *gin.Context) {
 		log.Printf("No category specified, defaulting to: %s", category)
 	}
 
+	serviceType, stErr := services.NewServiceTypeService().GetByCode(category)
+
 	// Get next 14 days that are operating days
 	var availableDays []string
 	today := time.Now()
 
 	for i := 0; i < 14; i++ {
 		checkDate := today.AddDate(0, 0, i)
+		if stErr == nil {
+			if serviceType.IsActive && serviceType.IsOperatingDay(checkDate.Weekday()) {
+				availableDays = append(availableDays, checkDate.Format("2006-01-02"))
+			}
+			continue
+		}
 		// Operating days are Tuesday, Wednesday, Thursday
 		if checkDate.Weekday() >= time.Tuesday && checkDate.Weekday() <= time.Thursday {
 			availableDays = append(availableDays, checkDate.Format("2006-01-02"))
@@ -970,40 +1152,19 @@ func AssignVolunteerToRequest(c *gin.Context) {
 
 // sendTicketIssuedNotificationDirect sends a direct notification when a ticket is auto-issued during help request creation
 func sendTicketIssuedNotificationDirect(helpRequest models.HelpRequest) error {
-	// Get the notification service
-	notificationService := notifications.GetService()
-	if notificationService == nil {
-		return fmt.Errorf("notification service is not initialized")
-	}
-
 	// Get user details for the visitor
 	var user models.User
 	if err := db.DB.First(&user, helpRequest.VisitorID).Error; err != nil {
 		return fmt.Errorf("failed to find user for ticket notification: %v", err)
 	}
 
-	// Prepare notification data for ticket issued email
-	data := notifications.NotificationData{
-		To:               user.Email,
-		Subject:          "Your Visit Ticket is Ready - " + helpRequest.TicketNumber,
-		TemplateType:     "ticket_issued",
-		NotificationType: notifications.EmailNotification,
-		TemplateData: map[string]interface{}{
-			"Name":             user.FirstName + " " + user.LastName,
-			"TicketNumber":     helpRequest.TicketNumber,
-			"Reference":        helpRequest.Reference,
-			"Category":         helpRequest.Category,
-			"VisitDay":         helpRequest.VisitDay,
-			"TimeSlot":         helpRequest.TimeSlot,
-			"QRCode":           helpRequest.QRCode,
-			"Instructions":     shared.GetVisitInstructions(),
-			"Requirements":     shared.GetVisitRequirements(),
-			"OrganizationName": "Lewisham Donation Hub",
-		},
+	// Visitors without email receive their ticket by SMS instead
+	if user.Email == "" {
+		return services.NewPhoneAuthService().SendTicketSMS(user, helpRequest)
 	}
 
-	// Send the notification
-	if err := notificationService.SendNotification(data, user); err != nil {
+	// The email follows the visitor's plain-language and large-print choices
+	if err := services.SendTicketIssuedEmail(user, helpRequest); err != nil {
 		return fmt.Errorf("failed to send ticket issued notification: %v", err)
 	}
 
@@ -0,0 +1,22 @@
+package visitor
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMyReverification returns when the visitor's documents were last verified, when
+// they are next due and whether bookings are restricted until they re-verify
+func GetMyReverification(c *gin.Context) {
+	status, err := services.NewVisitorReverificationService().Status(utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch re-verification status"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
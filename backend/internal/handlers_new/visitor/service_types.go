@@ -0,0 +1,54 @@
+package visitor
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListServiceTypes returns the services visitors can currently request
+func ListServiceTypes(c *gin.Context) {
+	serviceTypes, err := services.NewServiceTypeService().ListActive()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch service types"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"service_types": serviceTypes})
+}
+
+// GetServiceTypeForm returns the extra questions to ask when requesting a service.
+// Services without extra questions return an empty form.
+func GetServiceTypeForm(c *gin.Context) {
+	form, err := services.NewFormDefinitionService().ActiveForCategory(c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch form"})
+		return
+	}
+	if form == nil {
+		c.JSON(http.StatusOK, gin.H{"form": nil, "fields": []interface{}{}})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"form":   form,
+		"fields": form.Fields,
+	})
+}
+
+// ListUpcomingBookingWindows returns when bookings open and close for the coming visit
+// days, optionally for one category
+func ListUpcomingBookingWindows(c *gin.Context) {
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "14"))
+	windows, err := services.NewBookingWindowService().Upcoming(c.Query("category"), days, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch booking windows"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"booking_windows": windows})
+}
@@ -0,0 +1,91 @@
+package visitor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetMyStandby returns the current visitor's standby entries for today, including open offers
+func GetMyStandby(c *gin.Context) {
+	visitorID := utils.GetUserIDFromContext(c)
+
+	var entries []models.StandbyEntry
+	if err := db.DB.Where("visitor_id = ? AND visit_day = ?", visitorID, time.Now().Format("2006-01-02")).
+		Order("created_at DESC").
+		Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch standby entries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"standby": entries})
+}
+
+// ClaimStandbyOffer claims an offered same-day place and issues a ticket
+func ClaimStandbyOffer(c *gin.Context) {
+	entryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid standby ID"})
+		return
+	}
+
+	visitorID := utils.GetUserIDFromContext(c)
+	entry, ticket, err := services.NewStandbyService().ClaimOffer(uint(entryID), visitorID, 0)
+	if err != nil {
+		writeStandbyError(c, err)
+		return
+	}
+
+	utils.CreateAuditLog(c, "Claim", "StandbyEntry", entry.ID,
+		fmt.Sprintf("Standby place claimed by visitor %d, ticket %s issued", visitorID, ticket.TicketNumber))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Place claimed. Your ticket has been issued.",
+		"standby": entry,
+		"ticket":  ticket,
+	})
+}
+
+// DeclineStandbyOffer turns down an offered place so it can go to the next visitor
+func DeclineStandbyOffer(c *gin.Context) {
+	entryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid standby ID"})
+		return
+	}
+
+	entry, err := services.NewStandbyService().DeclineOffer(uint(entryID), utils.GetUserIDFromContext(c))
+	if err != nil {
+		writeStandbyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Offer declined",
+		"standby": entry,
+	})
+}
+
+// writeStandbyError maps standby service errors to responses
+func writeStandbyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Standby entry not found"})
+	case errors.Is(err, services.ErrStandbyNotOffered):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrStandbyOfferExpired):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update standby entry"})
+	}
+}
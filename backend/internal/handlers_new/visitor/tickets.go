@@ -10,6 +10,7 @@ import (
 	"github.com/geoo115/charity-management-system/internal/handlers_new/shared"
 	"github.com/geoo115/charity-management-system/internal/models"
 	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -66,6 +67,16 @@ func GetTicketDetails(c *gin.Context) {
 		instructions["whatToBring"] = []string{"Valid ID", "Proof of address"}
 	}
 
+	// Configured service types override the default instructions
+	if serviceType, err := services.NewServiceTypeService().GetByCode(ticket.Category); err == nil {
+		if items := serviceType.WhatToBringList(); len(items) > 0 {
+			instructions["whatToBring"] = items
+		}
+		if serviceType.Instructions != "" {
+			instructions["notes"] = serviceType.Instructions
+		}
+	}
+
 	// Replace all occurrences of ticket.Visitor.Name
 	visitorName := ticket.Visitor.FirstName + " " + ticket.Visitor.LastName
 
@@ -310,11 +321,15 @@ func UseTicket(c *gin.Context) {
 		return
 	}
 
+	// Warn the desk when a visitor who asked for an interpreter does not have one
+	interpreter := services.NewInterpreterBookingService().CheckIn(&ticket)
+
 	// Update ticket use response
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Ticket used successfully",
 		"data": gin.H{
+			"interpreter":   interpreter,
 			"ticketNumber":  ticket.TicketNumber,
 			"usedAt":        now.Format(time.RFC3339),
 			"usedBy":        usage.StaffID,
@@ -560,17 +575,17 @@ func AdminGetTicketAnalytics(c *gin.Context) {
 
 	// Query statistics by category
 	categoryStats := make(map[string]gin.H)
-	categories := []string{models.CategoryFood, models.CategoryGeneral}
+	categories := services.NewServiceTypeService().ActiveCodes()
 
 	for _, category := range categories {
 		var issued, used int64
 
 		db.DB.Model(&models.Ticket{}).
-			Where("issued_at BETWEEN ? AND ? AND category = ?", start, end.AddDate(0, 0, 1), category).
+			Where("issued_at BETWEEN ? AND ? AND LOWER(category) = ?", start, end.AddDate(0, 0, 1), category).
 			Count(&issued)
 
 		db.DB.Model(&models.Ticket{}).
-			Where("used_at BETWEEN ? AND ? AND status = ? AND category = ?",
+			Where("used_at BETWEEN ? AND ? AND status = ? AND LOWER(category) = ?",
 				start, end.AddDate(0, 0, 1), models.TicketStatusUsed, category).
 			Count(&used)
 
@@ -817,30 +832,19 @@ func sendTicketNotification(helpRequest models.HelpRequest, ticket models.Ticket
 		return
 	}
 
-	// No need to concatenate name here, pass FirstName and LastName separately
-	notificationService := shared.GetNotificationService()
-	if notificationService != nil {
-		data := notifications.NotificationData{
-			To:               user.Email,
-			Subject:          "Your Visit Ticket is Ready - " + ticket.TicketNumber,
-			TemplateType:     notifications.TemplateType("ticket_issued"),
-			NotificationType: notifications.EmailNotification,
-			TemplateData: map[string]interface{}{
-				"FirstName":        user.FirstName,
-				"LastName":         user.LastName,
-				"TicketNumber":     ticket.TicketNumber,
-				"Reference":        helpRequest.Reference,
-				"Category":         ticket.Category,
-				"VisitDay":         ticket.VisitDate, // Already a string
-				"TimeSlot":         ticket.TimeSlot,
-				"QRCode":           ticket.QRCode,
-				"OrganizationName": "Lewisham Charity",
-			},
+	// Visitors without email receive their ticket by SMS instead
+	if user.Email == "" {
+		helpRequest.TicketNumber = ticket.TicketNumber
+		if err := services.NewPhoneAuthService().SendTicketSMS(user, helpRequest); err != nil {
+			fmt.Printf("Failed to send ticket SMS: %v\n", err)
 		}
+		return
+	}
 
-		if err := notificationService.SendNotification(data, user); err != nil {
-			fmt.Printf("Failed to send ticket notification: %v\n", err)
-		}
+	// The email follows the visitor's plain-language and large-print choices
+	helpRequest.TicketNumber = ticket.TicketNumber
+	if err := services.SendTicketIssuedEmail(user, helpRequest); err != nil {
+		fmt.Printf("Failed to send ticket notification: %v\n", err)
 	}
 }
 
@@ -950,3 +954,46 @@ func GetVisitorTicketHistory(c *gin.Context) {
 		"data":    ticketHistory,
 	})
 }
+
+// GetPublicTicket returns a ticket's visit details from the short link sent by SMS.
+// Tickets are found by their random link token, and no personal details or QR code
+// are included.
+func GetPublicTicket(c *gin.Context) {
+	var ticket models.Ticket
+	if err := db.DB.Where("link_token = ?", c.Param("token")).First(&ticket).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ticket_number": ticket.TicketNumber,
+		"category":      ticket.Category,
+		"visit_date":    ticket.VisitDate.Format("2006-01-02"),
+		"time_slot":     ticket.TimeSlot,
+		"status":        ticket.Status,
+		"valid_until":   ticket.ValidUntil,
+	})
+}
+
+// DownloadTicketPDF returns a printable, branded copy of a ticket. Visitors can only
+// download their own tickets.
+func DownloadTicketPDF(c *gin.Context) {
+	var ticket models.Ticket
+	if err := db.DB.Where("ticket_number = ?", c.Param("ticket")).First(&ticket).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	}
+
+	role := c.GetString("userRole")
+	switch role {
+	case models.RoleAdmin, models.RoleSuperAdmin, models.RoleStaff, models.RoleAdminLegacy, models.RoleStaffLegacy:
+	default:
+		if ticket.VisitorID != utils.GetUserIDFromContext(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied - not your ticket"})
+			return
+		}
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=ticket-%s.pdf", ticket.TicketNumber))
+	c.Data(http.StatusOK, "application/pdf", services.RenderTicketFor(ticket, notifications.VisitorCommsFormat(ticket.VisitorID)))
+}
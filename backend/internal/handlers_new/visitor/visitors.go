@@ -391,6 +391,8 @@ func GetVisitorProfile(c *gin.Context) {
 		"household_size":       visitorProfile.HouseholdSize,
 		"dietary_requirements": visitorProfile.DietaryRequirements,
 		"accessibility_needs":  visitorProfile.AccessibilityNeeds,
+		"plain_language":       visitorProfile.PlainLanguage,
+		"large_print":          visitorProfile.LargePrint,
 		"emergency_contact":    visitorProfile.EmergencyContact,
 		"verification_status":  verificationStatus,
 		"registration_date":    user.CreatedAt.Format("2006-01-02"),
@@ -427,6 +429,9 @@ func UpdateVisitorProfile(c *gin.Context) {
 		DietaryRequirements string `json:"dietary_requirements"`
 		AccessibilityNeeds  string `json:"accessibility_needs"`
 		EmergencyContact    string `json:"emergency_contact"`
+		// Formats for tickets, confirmations and reminders; left alone when omitted
+		PlainLanguage *bool `json:"plain_language"`
+		LargePrint    *bool `json:"large_print"`
 	}
 
 	if err := c.ShouldBindJSON(&updates); err != nil {
@@ -489,6 +494,12 @@ func UpdateVisitorProfile(c *gin.Context) {
 	if updates.EmergencyContact != "" {
 		visitorProfile.EmergencyContact = updates.EmergencyContact
 	}
+	if updates.PlainLanguage != nil {
+		visitorProfile.PlainLanguage = *updates.PlainLanguage
+	}
+	if updates.LargePrint != nil {
+		visitorProfile.LargePrint = *updates.LargePrint
+	}
 
 	if err := db.DB.Save(&visitorProfile).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update visitor profile"})
@@ -1245,7 +1256,7 @@ func GetCurrentUserQueuePosition(c *gin.Context) {
 
 	// Find active queue entry for the current user
 	var queueEntry models.QueueEntry
-	if err := db.DB.Where("visitor_id = ? AND status IN ?", userID, []string{"waiting", "called"}).
+	if err := db.DB.Where("visitor_id = ? AND status IN ?", userID, []string{"waiting", "called", "recall"}).
 		First(&queueEntry).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
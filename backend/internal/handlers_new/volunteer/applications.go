@@ -100,6 +100,7 @@ func (h *ApplicationsHandler) SubmitApplication(c *gin.Context) {
 		Password:      req.Password,
 		TermsAccepted: req.TermsAccepted,
 		Status:        "pending",
+		Source:        models.VolunteerApplicationSourceForm,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
@@ -317,6 +318,7 @@ func (h *ApplicationsHandler) RejectApplication(c *gin.Context) {
 	application.Status = "rejected"
 	application.RejectionReason = req.Reason
 	application.UpdatedAt = time.Now()
+	application.ClosedAt = &application.UpdatedAt
 	if err := h.DB.Save(&application).Error; err != nil {
 		h.InternalError(c, "Failed to update application status")
 		return
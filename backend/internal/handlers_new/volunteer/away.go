@@ -0,0 +1,102 @@
+package volunteer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AwayPeriodRequest represents a volunteer marking themselves away
+type AwayPeriodRequest struct {
+	StartsOn string `json:"starts_on" binding:"required"` // YYYY-MM-DD
+	EndsOn   string `json:"ends_on" binding:"required"`   // YYYY-MM-DD, inclusive
+	Reason   string `json:"reason"`
+}
+
+// GetMyAwayPeriods lists the volunteer's current and upcoming away periods
+func GetMyAwayPeriods(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	periods, err := services.NewVolunteerAwayService().List(userID, c.Query("include_past") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch away periods"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"away_periods": periods})
+}
+
+// CreateAwayPeriod marks the volunteer away between two dates. Upcoming shifts in the
+// period are flagged so a coordinator can arrange a swap.
+func CreateAwayPeriod(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req AwayPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	startsOn, err := time.Parse("2006-01-02", req.StartsOn)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid starts_on, expected YYYY-MM-DD"})
+		return
+	}
+	endsOn, err := time.Parse("2006-01-02", req.EndsOn)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ends_on, expected YYYY-MM-DD"})
+		return
+	}
+
+	period, flagged, err := services.NewVolunteerAwayService().Create(userID, startsOn, endsOn, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAwayPeriodInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAwayPeriodOverlap):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save away period"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "VolunteerAwayPeriod", period.ID,
+		fmt.Sprintf("Marked away %s to %s, %d shifts flagged for swap", req.StartsOn, req.EndsOn, len(flagged)))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"away_period":       period,
+		"flagged_for_swap":  flagged,
+		"reminders_paused":  true,
+		"callouts_excluded": true,
+	})
+}
+
+// DeleteAwayPeriod ends away mode for a period and clears its swap flags
+func DeleteAwayPeriod(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	periodID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid away period ID"})
+		return
+	}
+
+	if err := services.NewVolunteerAwayService().Delete(userID, uint(periodID)); err != nil {
+		if errors.Is(err, services.ErrAwayPeriodNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove away period"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Delete", "VolunteerAwayPeriod", uint(periodID), "Removed away period")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Away period removed"})
+}
@@ -193,6 +193,7 @@ func rejectVolunteers(db *gorm.DB, action BulkVolunteerAction, adminID uint) (gi
 		application.Status = "rejected"
 		application.RejectionReason = action.Reason
 		application.UpdatedAt = time.Now()
+		application.ClosedAt = &application.UpdatedAt
 		if err := tx.Save(&application).Error; err != nil {
 			failed = append(failed, gin.H{
 				"volunteer_id": volunteerID,
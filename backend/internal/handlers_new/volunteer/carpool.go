@@ -0,0 +1,268 @@
+package volunteer
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CarpoolPostRequest represents a volunteer offering or asking for a lift
+type CarpoolPostRequest struct {
+	Kind             string `json:"kind" binding:"required,oneof=offer need"`
+	Postcode         string `json:"postcode"` // Defaults to the profile postcode
+	Seats            int    `json:"seats"`    // Seats offered, or needed; defaults to 1
+	Notes            string `json:"notes"`
+	AcceptDisclaimer bool   `json:"accept_disclaimer"`
+}
+
+// ProposeCarpoolMatchRequest names the post to share a lift with
+type ProposeCarpoolMatchRequest struct {
+	PostID uint `json:"post_id" binding:"required"`
+}
+
+// carpoolError maps carpool service errors to a response
+func carpoolError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrCarpoolNotOnShift), errors.Is(err, services.ErrCarpoolNotParty):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCarpoolPostClosed), errors.Is(err, services.ErrCarpoolAlreadyProposed),
+		errors.Is(err, services.ErrCarpoolNoSeats), errors.Is(err, services.ErrCarpoolMatchClosed),
+		errors.Is(err, services.ErrCarpoolHasMatches):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCarpoolShiftStarted), errors.Is(err, services.ErrCarpoolDisclaimer),
+		errors.Is(err, services.ErrCarpoolNoPostcode), errors.Is(err, services.ErrCarpoolInvalidKind),
+		errors.Is(err, services.ErrCarpoolInvalidSeats), errors.Is(err, services.ErrCarpoolNotesTooLong),
+		errors.Is(err, services.ErrCarpoolNoPost), errors.Is(err, services.ErrCarpoolSameKind):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// GetShiftCarpool returns the volunteer's lift post for a shift, possible matches
+// and their current matches
+func GetShiftCarpool(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+
+	var assigned int64
+	db.DB.Model(&models.ShiftAssignment{}).
+		Where("shift_id = ? AND user_id = ? AND status NOT IN ?", shiftID, userID, []string{"Cancelled", "NoShow"}).
+		Count(&assigned)
+	if assigned == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not signed up for this shift"})
+		return
+	}
+
+	service := services.NewCarpoolService()
+	response := gin.H{
+		"disclaimer":         models.CarpoolDisclaimer,
+		"disclaimer_version": models.CarpoolDisclaimerVersion,
+		"post":               nil,
+		"candidates":         []services.CarpoolCandidate{},
+		"matches":            []services.CarpoolMatchView{},
+	}
+
+	post, err := service.MyPost(uint(shiftID), userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lift sharing"})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	response["post"] = gin.H{
+		"post":     post,
+		"postcode": post.Postcode,
+	}
+	if post.Status == models.CarpoolPostOpen {
+		candidates, err := service.Candidates(post)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find lift matches"})
+			return
+		}
+		response["candidates"] = candidates
+	}
+	matches, err := service.MatchesFor(uint(shiftID), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lift matches"})
+		return
+	}
+	response["matches"] = matches
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SaveShiftCarpool opts the volunteer in to lift sharing for a shift, or updates
+// their offer or request
+func SaveShiftCarpool(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+
+	var req CarpoolPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	post, err := services.NewCarpoolService().SavePost(uint(shiftID), userID, req.Kind, req.Postcode, req.Seats, req.Notes, req.AcceptDisclaimer, time.Now())
+	if err != nil {
+		carpoolError(c, err, "Failed to save lift sharing")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Lift sharing saved",
+		"post":    post,
+	})
+}
+
+// WithdrawShiftCarpool opts the volunteer out of lift sharing for a shift
+func WithdrawShiftCarpool(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+
+	service := services.NewCarpoolService()
+	post, err := service.MyPost(uint(shiftID), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "You have not joined lift sharing for this shift"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lift sharing"})
+		}
+		return
+	}
+
+	if err := service.Withdraw(post); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to withdraw from lift sharing"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Withdrawn from lift sharing"})
+}
+
+// ProposeCarpoolMatch asks another volunteer on the shift to share a lift
+func ProposeCarpoolMatch(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+
+	var req ProposeCarpoolMatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewCarpoolService()
+	post, err := service.MyPost(uint(shiftID), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = services.ErrCarpoolNoPost
+		}
+		carpoolError(c, err, "Failed to fetch lift sharing")
+		return
+	}
+
+	match, err := service.Propose(post, req.PostID, time.Now())
+	if err != nil {
+		carpoolError(c, err, "Failed to propose lift share")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Lift share proposed; contact details are shared once the other volunteer accepts",
+		"match":   match,
+	})
+}
+
+// AcceptCarpoolMatch accepts a proposed lift share
+func AcceptCarpoolMatch(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	match, ok := loadCarpoolMatch(c)
+	if !ok {
+		return
+	}
+
+	service := services.NewCarpoolService()
+	if err := service.Accept(match, userID, time.Now()); err != nil {
+		carpoolError(c, err, "Failed to accept lift share")
+		return
+	}
+
+	message := "Lift share accepted; waiting for the other volunteer"
+	if match.Status == models.CarpoolMatchAccepted {
+		message = "Lift share confirmed"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"match":   match,
+	})
+}
+
+// DeclineCarpoolMatch declines a proposed lift share, or cancels a confirmed one
+func DeclineCarpoolMatch(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	match, ok := loadCarpoolMatch(c)
+	if !ok {
+		return
+	}
+
+	if err := services.NewCarpoolService().Decline(match, userID); err != nil {
+		carpoolError(c, err, "Failed to decline lift share")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Lift share " + match.Status,
+		"match":   match,
+	})
+}
+
+// loadCarpoolMatch fetches the carpool match named in the route
+func loadCarpoolMatch(c *gin.Context) (*models.CarpoolMatch, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return nil, false
+	}
+
+	var match models.CarpoolMatch
+	if err := db.DB.First(&match, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Lift share not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lift share"})
+		}
+		return nil, false
+	}
+
+	return &match, true
+}
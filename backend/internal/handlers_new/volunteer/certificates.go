@@ -0,0 +1,116 @@
+package volunteer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CertificateRequest represents a volunteer asking for a certificate of their hours
+type CertificateRequest struct {
+	AddressedTo string `json:"addressed_to"` // e.g. the employer the letter is for
+	Purpose     string `json:"purpose"`
+}
+
+// RequestCertificate asks a coordinator to approve a certificate of the volunteer's
+// hours and roles so far
+func RequestCertificate(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req CertificateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	certificate, err := services.NewVolunteerCertificateService().Request(userID, req.AddressedTo, req.Purpose)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrCertificateNoHours):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrCertificatePending):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request certificate"})
+		}
+		return
+	}
+
+	utils.CreateAuditLog(c, "Create", "VolunteerCertificate", certificate.ID,
+		fmt.Sprintf("Certificate requested for %.1f hours", certificate.TotalHours))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Certificate requested. A coordinator will review it shortly.",
+		"certificate": certificate,
+		"roles":       services.CertificateRoles(certificate),
+	})
+}
+
+// GetMyCertificates lists the volunteer's certificate requests and issued certificates
+func GetMyCertificates(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	certificates, err := services.NewVolunteerCertificateService().ListForUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch certificates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"certificates": certificates})
+}
+
+// DownloadCertificate returns an approved certificate as a signed PDF
+func DownloadCertificate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid certificate ID"})
+		return
+	}
+
+	certificate, err := services.NewVolunteerCertificateService().Get(uint(id), utils.GetUserIDFromContext(c))
+	if err != nil {
+		if errors.Is(err, services.ErrCertificateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch certificate"})
+		return
+	}
+
+	pdf, err := services.RenderVolunteerCertificate(certificate)
+	if err != nil {
+		if errors.Is(err, services.ErrCertificateNotIssued) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render certificate"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=volunteering-certificate-%d.pdf", certificate.ID))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// VerifyCertificate lets an employer confirm a certificate is genuine using the code
+// printed on it. No login is needed.
+func VerifyCertificate(c *gin.Context) {
+	result, err := services.NewVolunteerCertificateService().Verify(c.Param("code"))
+	if err != nil {
+		if errors.Is(err, services.ErrCertificateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"valid": false, "error": "No certificate has this verification code"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify certificate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -235,17 +235,15 @@ func ValidateShiftAvailability(c *gin.Context) {
 		return
 	}
 
-	// Check for conflicts
-	var conflictingShifts []models.Shift
-	db.DB.Where("assigned_volunteer_id = ? AND date = ? AND ((start_time <= ? AND end_time > ?) OR (start_time < ? AND end_time >= ?))",
-		userID, shift.Date, shift.EndTime, shift.StartTime, shift.EndTime, shift.StartTime).
-		Find(&conflictingShifts)
-
-	if len(conflictingShifts) > 0 {
+	// Check for conflicts, including time to travel from shifts at other locations
+	if result := checkShiftClashes(userID.(uint), shift, shift.StartTime, shift.EndTime); result != nil {
 		c.JSON(http.StatusConflict, gin.H{
-			"available": false,
-			"reason":    "Time conflict with existing shift",
-			"conflicts": conflictingShifts,
+			"available":    false,
+			"reason":       result.Reason,
+			"code":         result.ErrorCode,
+			"conflicts":    result.Conflicts,
+			"clashes":      result.Clashes,
+			"alternatives": result.Alternatives,
 		})
 		return
 	}
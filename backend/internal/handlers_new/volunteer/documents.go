@@ -1,20 +1,39 @@
 package volunteer
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// VolunteerDocumentDecision is a coordinator's verification decision
+type VolunteerDocumentDecision struct {
+	ExpiresAt *time.Time `json:"expires_at"` // Overrides the expiry date given by the volunteer
+	Notes     string     `json:"notes"`
+	Reason    string     `json:"reason"` // Required when rejecting
+}
+
+// ShiftRoleRequirementsRequest sets the documents required for a shift role
+type ShiftRoleRequirementsRequest struct {
+	Role          string   `json:"role" binding:"required"`
+	DocumentTypes []string `json:"document_types"`
+}
+
 // UploadVolunteerDocument handles volunteer document uploads
 func UploadVolunteerDocument(c *gin.Context) {
 	userID, exists := c.Get("userID")
@@ -35,6 +54,13 @@ func UploadVolunteerDocument(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Document type is required"})
 		return
 	}
+	if !models.IsVolunteerDocumentType(documentType) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "Unknown document type",
+			"allowed_types": models.VolunteerDocumentTypes,
+		})
+		return
+	}
 
 	// Validate file type
 	if !isValidDocumentFile(header.Filename) {
@@ -42,6 +68,30 @@ func UploadVolunteerDocument(c *gin.Context) {
 		return
 	}
 
+	// Check file size (max 5MB)
+	if header.Size > 5*1024*1024 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    "File size must be less than 5MB",
+			"max_size": "5MB",
+		})
+		return
+	}
+
+	// Expiry date printed on the document, e.g. a certificate's renewal date
+	var expiresAt *time.Time
+	if value := c.PostForm("expires_at"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be a date in YYYY-MM-DD format"})
+			return
+		}
+		if !parsed.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrDocumentAlreadyExpired.Error()})
+			return
+		}
+		expiresAt = &parsed
+	}
+
 	// Save file
 	filePath, err := saveUploadedFile(file, header, userID.(uint), documentType)
 	if err != nil {
@@ -49,13 +99,22 @@ func UploadVolunteerDocument(c *gin.Context) {
 		return
 	}
 
-	// Create document record
+	// Create document record. Earlier documents of the same type are kept so an
+	// approved document stays valid while its renewal is checked.
+	now := time.Now()
 	document := models.Document{
-		UserID:   userID.(uint),
-		Type:     documentType,
-		Name:     header.Filename,
-		FilePath: filePath,
-		Status:   "pending_verification",
+		UserID:      userID.(uint),
+		Type:        documentType,
+		Name:        header.Filename,
+		Title:       c.PostForm("title"),
+		Description: c.PostForm("description"),
+		FilePath:    filePath,
+		FileType:    header.Header.Get("Content-Type"),
+		FileSize:    header.Size,
+		Status:      models.DocumentStatusPending,
+		ExpiresAt:   expiresAt,
+		IsPrivate:   true,
+		UploadedAt:  now,
 	}
 
 	if err := db.DB.Create(&document).Error; err != nil {
@@ -63,6 +122,14 @@ func UploadVolunteerDocument(c *gin.Context) {
 		return
 	}
 
+	// A pending upload of the same type is replaced by the new one
+	db.DB.Where("user_id = ? AND type = ? AND status = ? AND id <> ?",
+		document.UserID, documentType, models.DocumentStatusPending, document.ID).
+		Delete(&models.Document{})
+
+	utils.CreateAuditLog(c, "Upload", "Document", document.ID,
+		fmt.Sprintf("Volunteer uploaded %s document", documentType))
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message":  "Document uploaded successfully",
 		"document": document,
@@ -78,7 +145,7 @@ func GetVolunteerDocuments(c *gin.Context) {
 	}
 
 	var documents []models.Document
-	if err := db.DB.Where("user_id = ?", userID).Find(&documents).Error; err != nil {
+	if err := db.DB.Where("user_id = ?", userID).Order("uploaded_at DESC").Find(&documents).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve documents"})
 		return
 	}
@@ -88,107 +155,240 @@ func GetVolunteerDocuments(c *gin.Context) {
 	})
 }
 
+// GetVolunteerDocumentRequirements returns the documents shift roles need and the
+// volunteer's standing for each
+func GetVolunteerDocumentRequirements(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	requirements, err := services.NewVolunteerDocumentService().RequirementStatus(userID.(uint), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve document requirements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"requirements":   requirements,
+		"document_types": models.VolunteerDocumentTypes,
+	})
+}
+
 // GetAllVolunteerDocuments retrieves all volunteer documents for admin
 func GetAllVolunteerDocuments(c *gin.Context) {
 	var documents []models.Document
-	query := db.DB.Where("type IN (?)", []string{"identity", "right_to_work", "references", "dbs_check"})
+	query := db.DB.Where("type IN ?", models.VolunteerDocumentTypes).
+		Where("user_id IN (?)", db.DB.Model(&models.User{}).Select("id").Where("role = ?", models.RoleVolunteer))
 
 	// Apply filters
 	if volunteerID := c.Query("volunteer_id"); volunteerID != "" {
-		query = query.Where("volunteer_id = ?", volunteerID)
+		query = query.Where("user_id = ?", volunteerID)
 	}
 
 	if status := c.Query("status"); status != "" {
 		query = query.Where("status = ?", status)
 	}
 
-	if err := query.Preload("Volunteer").Preload("Uploader").Preload("Verifier").Find(&documents).Error; err != nil {
+	if documentType := c.Query("type"); documentType != "" {
+		query = query.Where("type = ?", documentType)
+	}
+
+	if err := query.Order("uploaded_at DESC").Limit(500).Find(&documents).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve documents"})
 		return
 	}
 
-	c.JSON(http.StatusOK, documents)
+	c.JSON(http.StatusOK, gin.H{
+		"documents": documents,
+		"total":     len(documents),
+	})
 }
 
-// VerifyVolunteerDocument verifies a volunteer document
-func VerifyVolunteerDocument(c *gin.Context) {
-	documentID := c.Param("documentId")
-	var document models.Document
+// GetVolunteerDocumentQueue returns volunteer documents waiting for verification
+func GetVolunteerDocumentQueue(c *gin.Context) {
+	documentType := c.Query("type")
+	if documentType != "" && !models.IsVolunteerDocumentType(documentType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrUnknownDocumentType.Error()})
+		return
+	}
 
-	if err := db.DB.First(&document, documentID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+	queue, err := services.NewVolunteerDocumentService().VerificationQueue(documentType, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve verification queue"})
 		return
 	}
 
-	// Get user ID from context
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	c.JSON(http.StatusOK, gin.H{
+		"queue": queue,
+		"total": len(queue),
+	})
+}
+
+// GetExpiringVolunteerDocuments returns approved volunteer documents expiring within
+// the given number of days (default 30)
+func GetExpiringVolunteerDocuments(c *gin.Context) {
+	days := 30
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 365 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+			return
+		}
+		days = parsed
+	}
+
+	documents, err := services.NewVolunteerDocumentService().ExpiringDocuments(time.Now().AddDate(0, 0, days))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve expiring documents"})
 		return
 	}
 
-	// Check if user is a verifier
-	if !isVerifier(userID.(uint)) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to verify documents"})
+	c.JSON(http.StatusOK, gin.H{
+		"documents": documents,
+		"total":     len(documents),
+		"days":      days,
+	})
+}
+
+// VerifyVolunteerDocument verifies a volunteer document
+func VerifyVolunteerDocument(c *gin.Context) {
+	document, ok := loadVolunteerDocument(c)
+	if !ok {
 		return
 	}
 
-	// Update document status and verifier ID
-	document.Status = "verified"
-	now := time.Now()
-	verifierID := userID.(uint)
-	document.VerifiedBy = &verifierID
-	document.VerifiedAt = &now
+	var req VolunteerDocumentDecision
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	if err := db.DB.Save(&document).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify document"})
+	verifierID := utils.GetUserIDFromContext(c)
+	if err := services.NewVolunteerDocumentService().Approve(document, verifierID, req.ExpiresAt, req.Notes); err != nil {
+		respondWithDocumentError(c, err, "Failed to verify document")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Document verified successfully"})
+	utils.CreateAuditLog(c, "Verify", "Document", document.ID,
+		fmt.Sprintf("Volunteer %s document approved for user %d", document.Type, document.UserID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Document verified successfully",
+		"document": document,
+	})
 }
 
 // RejectVolunteerDocument rejects a volunteer document
 func RejectVolunteerDocument(c *gin.Context) {
-	documentID := c.Param("documentId")
-	var document models.Document
+	document, ok := loadVolunteerDocument(c)
+	if !ok {
+		return
+	}
 
-	if err := db.DB.First(&document, documentID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+	var req VolunteerDocumentDecision
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get user ID from context
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	verifierID := utils.GetUserIDFromContext(c)
+	if err := services.NewVolunteerDocumentService().Reject(document, verifierID, req.Reason); err != nil {
+		respondWithDocumentError(c, err, "Failed to reject document")
 		return
 	}
 
-	// Check if user is a verifier
-	if !isVerifier(userID.(uint)) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to reject documents"})
+	utils.CreateAuditLog(c, "Reject", "Document", document.ID,
+		fmt.Sprintf("Volunteer %s document rejected for user %d: %s", document.Type, document.UserID, req.Reason))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Document rejected successfully",
+		"document": document,
+	})
+}
+
+// GetShiftRoleRequirements lists the documents required for each shift role
+func GetShiftRoleRequirements(c *gin.Context) {
+	requirements, err := services.NewVolunteerDocumentService().Requirements(c.Query("role"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve shift role requirements"})
 		return
 	}
 
-	// Update document status and verifier ID
-	document.Status = "rejected"
-	now := time.Now()
-	verifierID := userID.(uint)
-	document.VerifiedBy = &verifierID
-	document.VerifiedAt = &now
+	c.JSON(http.StatusOK, gin.H{
+		"requirements":   requirements,
+		"document_types": models.VolunteerDocumentTypes,
+	})
+}
 
-	if err := db.DB.Save(&document).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject document"})
+// SetShiftRoleRequirements replaces the documents required for a shift role. An
+// empty list removes the role's requirements.
+func SetShiftRoleRequirements(c *gin.Context) {
+	var req ShiftRoleRequirementsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if services.NormalizeShiftRole(req.Role) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role is required"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Document rejected successfully"})
+	requirements, err := services.NewVolunteerDocumentService().SetRequirements(req.Role, req.DocumentTypes, utils.GetUserIDFromContext(c))
+	if err != nil {
+		respondWithDocumentError(c, err, "Failed to update shift role requirements")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Update", "ShiftRoleDocumentRequirement", 0,
+		fmt.Sprintf("Documents required for shift role %q set to [%s]", services.NormalizeShiftRole(req.Role), strings.Join(req.DocumentTypes, ", ")))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Shift role requirements updated",
+		"requirements": requirements,
+	})
+}
+
+// respondWithDocumentError maps volunteer document service errors to responses
+func respondWithDocumentError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrDocumentNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrDocumentAlreadyExpired),
+		errors.Is(err, services.ErrDocumentRejectionReason),
+		errors.Is(err, services.ErrUnknownDocumentType):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// loadVolunteerDocument loads the volunteer document identified by the :documentId
+// path parameter
+func loadVolunteerDocument(c *gin.Context) (*models.Document, bool) {
+	id, err := strconv.ParseUint(c.Param("documentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
+		return nil, false
+	}
+
+	var document models.Document
+	if err := db.DB.Where("type IN ?", models.VolunteerDocumentTypes).First(&document, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch document"})
+		}
+		return nil, false
+	}
+
+	return &document, true
 }
 
 // Helper functions
 func isValidDocumentFile(filename string) bool {
-	ext := filepath.Ext(filename)
+	ext := strings.ToLower(filepath.Ext(filename))
 	allowedExts := map[string]bool{
 		".pdf":  true,
 		".doc":  true,
@@ -201,10 +401,10 @@ func isValidDocumentFile(filename string) bool {
 	return allowedExts[ext]
 }
 
-func saveUploadedFile(file multipart.File, header *multipart.FileHeader, userID uint, _ string) (string, error) {
+func saveUploadedFile(file multipart.File, header *multipart.FileHeader, userID uint, documentType string) (string, error) {
 	// Generate a unique file name
-	filename := fmt.Sprintf("%d_%s", userID, header.Filename)
-	filePath := fmt.Sprintf("uploads/documents/%s", filename)
+	filename := fmt.Sprintf("%d_%s_%d%s", userID, documentType, time.Now().UnixNano(), strings.ToLower(filepath.Ext(header.Filename)))
+	filePath := fmt.Sprintf("uploads/documents/volunteers/%s", filename)
 
 	// Create the uploads directory if it doesn't exist
 	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
@@ -224,13 +424,3 @@ func saveUploadedFile(file multipart.File, header *multipart.FileHeader, userID
 
 	return filePath, nil
 }
-
-// isVerifier checks if a user has permission to verify documents
-func isVerifier(userID uint) bool {
-	var user models.User
-	if err := db.DB.First(&user, userID).Error; err != nil {
-		return false
-	}
-	// Check if user has admin role or specific verifier permission
-	return user.Role == models.RoleAdmin || user.Role == models.RoleSuperAdmin
-}
@@ -0,0 +1,128 @@
+package volunteer
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DeclineCalloutRequest represents a volunteer declining a call-out
+type DeclineCalloutRequest struct {
+	Reason string `json:"reason"`
+}
+
+// GetMyEmergencyCallouts returns open call-outs the volunteer has been invited to
+func GetMyEmergencyCallouts(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var responses []models.EmergencyCalloutResponse
+	if err := db.DB.
+		Joins("JOIN emergency_callouts ON emergency_callouts.id = emergency_callout_responses.callout_id").
+		Where("emergency_callout_responses.user_id = ? AND emergency_callouts.status = ? AND emergency_callouts.expires_at > ?",
+			userID, models.CalloutStatusOpen, time.Now()).
+		Preload("Callout").
+		Preload("Callout.Shift").
+		Order("emergency_callout_responses.notified_at DESC").
+		Find(&responses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch call-outs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"callouts": responses})
+}
+
+// ViewEmergencyCallout returns a call-out and records that the volunteer has seen it
+func ViewEmergencyCallout(c *gin.Context) {
+	calloutID, ok := parseCalloutID(c)
+	if !ok {
+		return
+	}
+
+	response, err := services.NewEmergencyCalloutService().MarkViewed(calloutID, utils.GetUserIDFromContext(c))
+	if err != nil {
+		respondCalloutError(c, err)
+		return
+	}
+
+	var callout models.EmergencyCallout
+	db.DB.Preload("Shift").First(&callout, calloutID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"callout":  callout,
+		"response": response,
+	})
+}
+
+// AcceptEmergencyCallout accepts a call-out; the first volunteer to accept is assigned the shift
+func AcceptEmergencyCallout(c *gin.Context) {
+	calloutID, ok := parseCalloutID(c)
+	if !ok {
+		return
+	}
+
+	callout, assignment, err := services.NewEmergencyCalloutService().Accept(calloutID, utils.GetUserIDFromContext(c))
+	if err != nil {
+		respondCalloutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Thank you! You have been assigned to this shift.",
+		"callout":    callout,
+		"assignment": assignment,
+	})
+}
+
+// DeclineEmergencyCallout records that the volunteer cannot cover the shift
+func DeclineEmergencyCallout(c *gin.Context) {
+	calloutID, ok := parseCalloutID(c)
+	if !ok {
+		return
+	}
+
+	var req DeclineCalloutRequest
+	_ = c.ShouldBindJSON(&req)
+
+	response, err := services.NewEmergencyCalloutService().Decline(calloutID, utils.GetUserIDFromContext(c), req.Reason)
+	if err != nil {
+		respondCalloutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Response recorded",
+		"response": response,
+	})
+}
+
+// parseCalloutID reads the :id path parameter
+func parseCalloutID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid call-out ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondCalloutError maps call-out service errors to HTTP responses
+func respondCalloutError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrCalloutNotNotified), errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Call-out not found"})
+	case errors.Is(err, services.ErrCalloutClosed), errors.Is(err, services.ErrCalloutShiftFilled),
+		errors.Is(err, services.ErrCalloutAssigned):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update call-out"})
+	}
+}
@@ -254,17 +254,17 @@ func GetVolunteerAnnouncements(c *gin.Context) {
 	var announcements []models.Announcement
 	var total int64
 
-	query := db.DB.Where("target_role IN (?, ?)", "Volunteer", "All")
-	query.Model(&models.Announcement{}).Count(&total)
-	query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&announcements)
+	forVolunteers := models.AnnouncementsForRole(models.RoleVolunteer)
+	db.DB.Model(&models.Announcement{}).Scopes(forVolunteers).Count(&total)
+	db.DB.Scopes(forVolunteers).Order("created_at DESC").Limit(limit).Offset(offset).Find(&announcements)
 
 	// Count unread announcements
 	userID, _ := c.Get("userID")
 	var unreadCount int64
 	if userID != nil {
 		db.DB.Model(&models.Announcement{}).
-			Where("target_role IN (?, ?) AND id NOT IN (SELECT announcement_id FROM announcement_reads WHERE user_id = ?)",
-				"Volunteer", "All", userID).
+			Scopes(forVolunteers).
+			Where("id NOT IN (SELECT announcement_id FROM announcement_reads WHERE user_id = ?)", userID).
 			Count(&unreadCount)
 	}
 
@@ -0,0 +1,213 @@
+package volunteer
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SendKudosRequest represents a kudos sent to another volunteer
+type SendKudosRequest struct {
+	RecipientID uint   `json:"recipient_id" binding:"required"`
+	ShiftID     uint   `json:"shift_id" binding:"required"`
+	Category    string `json:"category" binding:"omitempty,oneof=teamwork kindness leadership reliability above_and_beyond"`
+	Message     string `json:"message" binding:"required"`
+}
+
+// ReportKudosRequest represents an abuse report for a kudos
+type ReportKudosRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// SendKudos sends kudos to a volunteer who worked the same shift
+func SendKudos(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req SendKudosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kudos, err := services.NewKudosService().SendKudos(userID, false, req.RecipientID, req.ShiftID, req.Category, req.Message)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, services.ErrKudosDailyLimit):
+			status = http.StatusTooManyRequests
+		case errors.Is(err, services.ErrKudosDuplicate):
+			status = http.StatusConflict
+		case errors.Is(err, services.ErrKudosNotOnShift):
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Kudos sent and awaiting approval",
+		"kudos":   kudos,
+	})
+}
+
+// GetReceivedKudos returns approved kudos the volunteer has received
+func GetReceivedKudos(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var kudos []models.Kudos
+	if err := db.DB.Where("recipient_id = ? AND status = ?", userID, models.KudosStatusApproved).
+		Preload("Sender", func(tx *gorm.DB) *gorm.DB {
+			return tx.Select("id", "first_name", "last_name")
+		}).
+		Preload("Shift").
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&kudos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch kudos"})
+		return
+	}
+
+	var total int64
+	db.DB.Model(&models.Kudos{}).
+		Where("recipient_id = ? AND status = ?", userID, models.KudosStatusApproved).
+		Count(&total)
+
+	c.JSON(http.StatusOK, gin.H{
+		"kudos": kudos,
+		"total": total,
+	})
+}
+
+// GetSentKudos returns kudos the volunteer has sent, including moderation status
+func GetSentKudos(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var kudos []models.Kudos
+	if err := db.DB.Where("sender_id = ?", userID).
+		Preload("Recipient", func(tx *gorm.DB) *gorm.DB {
+			return tx.Select("id", "first_name", "last_name")
+		}).
+		Order("created_at DESC").
+		Limit(50).
+		Find(&kudos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch kudos"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"kudos": kudos})
+}
+
+// GetShiftTeammates returns the other volunteers on a shift the volunteer worked, for sending kudos
+func GetShiftTeammates(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+
+	var own int64
+	db.DB.Model(&models.ShiftAssignment{}).
+		Where("shift_id = ? AND user_id = ? AND status NOT IN ?", shiftID, userID, []string{"Cancelled", "NoShow"}).
+		Count(&own)
+	if own == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You were not assigned to this shift"})
+		return
+	}
+
+	type teammate struct {
+		ID        uint   `json:"id"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+	}
+	teammates := []teammate{}
+	db.DB.Model(&models.ShiftAssignment{}).
+		Select("users.id, users.first_name, users.last_name").
+		Joins("JOIN users ON users.id = shift_assignments.user_id").
+		Where("shift_assignments.shift_id = ? AND shift_assignments.user_id <> ? AND shift_assignments.status NOT IN ?",
+			shiftID, userID, []string{"Cancelled", "NoShow"}).
+		Scan(&teammates)
+
+	c.JSON(http.StatusOK, gin.H{"teammates": teammates})
+}
+
+// ReportKudos flags a kudos message as abusive or inappropriate
+func ReportKudos(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	kudosID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kudos ID"})
+		return
+	}
+
+	var req ReportKudosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Only the sender and recipient can see a kudos, so only they may report it
+	var kudos models.Kudos
+	if err := db.DB.Where("id = ? AND (sender_id = ? OR recipient_id = ?)", kudosID, userID, userID).
+		First(&kudos).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Kudos not found"})
+		return
+	}
+
+	report, err := services.NewKudosService().ReportKudos(kudos.ID, userID, req.Reason)
+	if err != nil {
+		if errors.Is(err, services.ErrKudosAlreadyFlagged) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report kudos"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Report submitted. A coordinator will review it.",
+		"report":  report,
+	})
+}
+
+// getKudosSummary returns the kudos summary shown on the volunteer dashboard
+func getKudosSummary(userID uint) gin.H {
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	var total, thisMonth int64
+	db.DB.Model(&models.Kudos{}).
+		Where("recipient_id = ? AND status = ?", userID, models.KudosStatusApproved).
+		Count(&total)
+	db.DB.Model(&models.Kudos{}).
+		Where("recipient_id = ? AND status = ? AND created_at >= ?", userID, models.KudosStatusApproved, monthStart).
+		Count(&thisMonth)
+
+	var recent []models.Kudos
+	db.DB.Where("recipient_id = ? AND status = ?", userID, models.KudosStatusApproved).
+		Preload("Sender", func(tx *gorm.DB) *gorm.DB {
+			return tx.Select("id", "first_name", "last_name")
+		}).
+		Order("created_at DESC").
+		Limit(3).
+		Find(&recent)
+
+	return gin.H{
+		"total":      total,
+		"this_month": thisMonth,
+		"recent":     recent,
+	}
+}
@@ -0,0 +1,165 @@
+package volunteer
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/handlers_new/shared"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Mobile responses are kept under 2KB, so lists are capped and free text is shortened
+const (
+	mobilePendingActionLimit = 5
+	mobileTextLimit          = 60
+)
+
+// GetMobileSummary returns the volunteer home screen data for mobile clients in one
+// small payload: next shift, unread counts and pending actions. Supports ?fields=.
+func GetMobileSummary(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	now := time.Now()
+
+	notifications, messages := unreadCounts(userID, now)
+	actions := pendingActions(userID, now)
+
+	shared.RespondWithFields(c, http.StatusOK, gin.H{
+		"next_shift":           nextShiftSummary(userID, now),
+		"unread_notifications": notifications,
+		"unread_messages":      messages,
+		"pending_actions": gin.H{
+			"total": len(actions),
+			"items": limitActions(actions),
+		},
+		"generated_at": now.Format(time.RFC3339),
+	})
+}
+
+// GetMobileNextShift returns the volunteer's next upcoming shift
+func GetMobileNextShift(c *gin.Context) {
+	shared.RespondWithFields(c, http.StatusOK, gin.H{
+		"next_shift": nextShiftSummary(utils.GetUserIDFromContext(c), time.Now()),
+	})
+}
+
+// GetMobileUnreadCounts returns the number of unread notifications and messages
+func GetMobileUnreadCounts(c *gin.Context) {
+	notifications, messages := unreadCounts(utils.GetUserIDFromContext(c), time.Now())
+	shared.RespondWithFields(c, http.StatusOK, gin.H{
+		"unread_notifications": notifications,
+		"unread_messages":      messages,
+	})
+}
+
+// GetMobilePendingActions returns things the volunteer needs to respond to
+func GetMobilePendingActions(c *gin.Context) {
+	actions := pendingActions(utils.GetUserIDFromContext(c), time.Now())
+	shared.RespondWithFields(c, http.StatusOK, gin.H{
+		"total": len(actions),
+		"items": limitActions(actions),
+	})
+}
+
+// nextShiftSummary finds the earliest upcoming shift the volunteer is assigned to,
+// either directly or through a confirmed shift assignment
+func nextShiftSummary(userID uint, now time.Time) gin.H {
+	var shift models.Shift
+	found := db.DB.Where("assigned_volunteer_id = ? AND start_time > ?", userID, now).
+		Order("start_time ASC").
+		First(&shift).Error == nil
+
+	var assigned models.Shift
+	if err := db.DB.Joins("JOIN shift_assignments ON shift_assignments.shift_id = shifts.id").
+		Where("shift_assignments.user_id = ? AND shift_assignments.status = ? AND shifts.start_time > ?", userID, "Confirmed", now).
+		Order("shifts.start_time ASC").
+		First(&assigned).Error; err == nil && (!found || assigned.StartTime.Before(shift.StartTime)) {
+		shift = assigned
+		found = true
+	}
+
+	if !found {
+		return nil
+	}
+
+	return gin.H{
+		"id":         shift.ID,
+		"date":       shift.Date.Format("2006-01-02"),
+		"start_time": shift.StartTime.Format(time.RFC3339),
+		"end_time":   shift.EndTime.Format(time.RFC3339),
+		"role":       truncateText(shift.Role),
+		"location":   truncateText(shift.Location),
+		"starts_in":  int(shift.StartTime.Sub(now).Minutes()),
+	}
+}
+
+// unreadCounts counts unexpired unread notifications and unread messages
+func unreadCounts(userID uint, now time.Time) (int64, int64) {
+	var notifications, messages int64
+	db.DB.Model(&models.InAppNotification{}).
+		Where("user_id = ? AND is_read = ? AND (expires_at IS NULL OR expires_at > ?)", userID, false, now).
+		Count(&notifications)
+	db.DB.Model(&models.Message{}).
+		Where("recipient_id = ? AND is_read = ?", userID, false).
+		Count(&messages)
+	return notifications, messages
+}
+
+// pendingActions lists open emergency call-outs and outstanding tasks, most urgent first
+func pendingActions(userID uint, now time.Time) []gin.H {
+	actions := []gin.H{}
+
+	var callouts []models.EmergencyCalloutResponse
+	db.DB.Joins("JOIN emergency_callouts ON emergency_callouts.id = emergency_callout_responses.callout_id").
+		Where("emergency_callout_responses.user_id = ? AND emergency_callout_responses.status IN ? AND emergency_callouts.status = ? AND emergency_callouts.expires_at > ?",
+			userID, []string{models.CalloutResponseNotified, models.CalloutResponseViewed}, models.CalloutStatusOpen, now).
+		Preload("Callout").
+		Order("emergency_callouts.expires_at ASC").
+		Find(&callouts)
+	for _, response := range callouts {
+		actions = append(actions, gin.H{
+			"type":  "emergency_callout",
+			"id":    response.CalloutID,
+			"title": truncateText(response.Callout.Reason),
+			"due":   response.Callout.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+
+	var tasks []models.VolunteerTask
+	db.DB.Where("assigned_to = ? AND status IN ?", userID, []string{"pending", "in_progress"}).
+		Order("due_date ASC NULLS LAST, created_at ASC").
+		Find(&tasks)
+	for _, task := range tasks {
+		action := gin.H{
+			"type":  "task",
+			"id":    task.ID,
+			"title": truncateText(task.Title),
+		}
+		if task.DueDate != nil {
+			action["due"] = task.DueDate.Format(time.RFC3339)
+		}
+		actions = append(actions, action)
+	}
+
+	return actions
+}
+
+// limitActions caps the number of pending actions returned to mobile clients
+func limitActions(actions []gin.H) []gin.H {
+	if len(actions) > mobilePendingActionLimit {
+		return actions[:mobilePendingActionLimit]
+	}
+	return actions
+}
+
+// truncateText shortens free text for mobile payloads
+func truncateText(text string) string {
+	runes := []rune(text)
+	if len(runes) <= mobileTextLimit {
+		return text
+	}
+	return string(runes[:mobileTextLimit-3]) + "..."
+}
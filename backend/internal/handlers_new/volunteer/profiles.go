@@ -6,11 +6,13 @@ import (
 	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
 	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 
 	"github.com/geoo115/charity-management-system/internal/handlers_new/shared"
@@ -181,6 +183,7 @@ func RejectVolunteer(c *gin.Context) {
 	application.Status = "rejected"
 	application.RejectionReason = req.Reason
 	application.UpdatedAt = time.Now()
+	application.ClosedAt = &application.UpdatedAt
 	if err := db.DB.Save(&application).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update application status"})
 		return
@@ -195,6 +198,40 @@ func RejectVolunteer(c *gin.Context) {
 	})
 }
 
+// WithdrawVolunteerApplication records that an applicant has withdrawn their
+// application, which starts its retention period
+func WithdrawVolunteerApplication(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid volunteer ID"})
+		return
+	}
+
+	var application models.VolunteerApplication
+	if err := db.DB.First(&application, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Volunteer application not found"})
+		return
+	}
+	if application.Status != "pending" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only pending applications can be withdrawn"})
+		return
+	}
+
+	application.Status = models.VolunteerApplicationWithdrawn
+	application.UpdatedAt = time.Now()
+	application.ClosedAt = &application.UpdatedAt
+	if err := db.DB.Save(&application).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update application status"})
+		return
+	}
+
+	utils.CreateAuditLog(c, "Withdraw", "VolunteerApplication", application.ID, "Volunteer application withdrawn")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Volunteer application withdrawn successfully",
+	})
+}
+
 // ListPendingVolunteers returns volunteers awaiting approval
 func ListPendingVolunteers(c *gin.Context) {
 	var pendingApplications []models.VolunteerApplication
@@ -243,7 +280,8 @@ func ListActiveVolunteers(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// ListAvailableShifts returns all available shifts
+// ListAvailableShifts returns the unassigned shifts the volunteer is qualified for.
+// Shifts they are nearly qualified for are listed by GetAlmostEligibleShifts.
 func ListAvailableShifts(c *gin.Context) {
 	var shifts []models.Shift
 
@@ -252,7 +290,13 @@ func ListAvailableShifts(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, shifts)
+	eligible, _, err := services.NewShiftVisibilityService().Partition(utils.GetUserIDFromContext(c), shifts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check shift requirements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, eligible)
 }
 
 // SignupForShift assigns a volunteer to a shift
@@ -294,12 +338,7 @@ func SignupForShift(c *gin.Context) {
 	// Enhanced eligibility check with better error messages
 	eligibilityResult := checkShiftEligibilityEnhanced(volunteerID, shift)
 	if !eligibilityResult.Eligible {
-		c.JSON(http.StatusConflict, gin.H{
-			"error":       eligibilityResult.Reason,
-			"conflicts":   eligibilityResult.Conflicts,
-			"suggestions": eligibilityResult.Suggestions,
-			"code":        eligibilityResult.ErrorCode,
-		})
+		respondShiftIneligible(c, eligibilityResult)
 		return
 	}
 
@@ -363,6 +402,18 @@ func SignupForShift(c *gin.Context) {
 		}
 	}
 
+	// Flexible shifts are checked for clashes with the times the volunteer chose
+	if shift.Type == "flexible" {
+		start, end := shift.StartTime, shift.EndTime
+		if customStartTime != nil && customEndTime != nil {
+			start, end = *customStartTime, *customEndTime
+		}
+		if result := checkShiftClashes(volunteerID, shift, start, end); result != nil {
+			respondShiftIneligible(c, *result)
+			return
+		}
+	}
+
 	// Begin transaction for atomic operation
 	tx := db.DB.Begin()
 
@@ -458,6 +509,18 @@ func SignupForShift(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// respondShiftIneligible explains why the volunteer cannot take a shift
+func respondShiftIneligible(c *gin.Context, result ShiftEligibilityResult) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error":        result.Reason,
+		"conflicts":    result.Conflicts,
+		"suggestions":  result.Suggestions,
+		"code":         result.ErrorCode,
+		"clashes":      result.Clashes,
+		"alternatives": result.Alternatives,
+	})
+}
+
 // timeRangesOverlap checks if two time ranges overlap
 func timeRangesOverlap(start1, end1, start2, end2 time.Time) bool {
 	return start1.Before(end2) && start2.Before(end1)
@@ -484,23 +547,43 @@ func checkShiftEligibilityEnhanced(volunteerID uint, shift models.Shift) ShiftEl
 		}
 	}
 
-	// Check for time conflicts with other assigned shifts
-	var conflicts []models.Shift
-	db.DB.Where("assigned_volunteer_id = ? AND date::date = ?::date", volunteerID, shift.Date).Find(&conflicts)
-
-	for _, existingShift := range conflicts {
-		if timeRangesOverlapSameDay(shift.StartTime, shift.EndTime, existingShift.StartTime, existingShift.EndTime) {
-			return ShiftEligibilityResult{
-				Eligible:  false,
-				Reason:    fmt.Sprintf("Time conflict with existing shift from %s to %s", existingShift.StartTime.Format("15:04"), existingShift.EndTime.Format("15:04")),
-				ErrorCode: "TIME_CONFLICT",
-				Conflicts: []models.Shift{existingShift},
-				Suggestions: []string{
-					"Choose a different time slot",
-					"Cancel your existing shift if this one is more important",
-					"Contact coordinator about overlapping assignments",
-				},
-			}
+	// Check for clashes with other shifts that day, including travel between locations.
+	// Flexible shifts are checked once the volunteer has chosen their times.
+	if shift.Type != "flexible" {
+		if result := checkShiftClashes(volunteerID, shift, shift.StartTime, shift.EndTime); result != nil {
+			return *result
+		}
+	}
+
+	// Check the volunteer has the role level, skills, training and documents the shift needs
+	gaps, err := services.NewShiftVisibilityService().Check(volunteerID, shift)
+	if err != nil {
+		log.Printf("Failed to check shift requirements for volunteer %d: %v", volunteerID, err)
+	}
+	var missingDocuments, missing []string
+	for _, gap := range gaps {
+		if gap.Kind == services.ShiftGapDocument {
+			missingDocuments = append(missingDocuments, gap.Requirement)
+		}
+		missing = append(missing, gap.Message)
+	}
+	if len(missingDocuments) == len(gaps) && len(gaps) > 0 {
+		return ShiftEligibilityResult{
+			Eligible:  false,
+			Reason:    fmt.Sprintf("This %s shift needs valid documents you have not provided: %s", shift.Role, strings.Join(missingDocuments, ", ")),
+			ErrorCode: "MISSING_DOCUMENTS",
+			Suggestions: []string{
+				"Upload the missing documents from your documents page",
+				"Documents must be verified by a coordinator and valid on the shift date",
+			},
+		}
+	}
+	if len(gaps) > 0 {
+		return ShiftEligibilityResult{
+			Eligible:    false,
+			Reason:      "You are not yet qualified for this shift",
+			ErrorCode:   "NOT_QUALIFIED",
+			Suggestions: missing,
 		}
 	}
 
@@ -768,6 +851,7 @@ func VolunteerDashboardStats(c *gin.Context) {
 		"streak":            stats.CurrentStreak,
 		"nextMilestone":     nextMilestone,
 		"milestonePorgress": milestoneProgress,
+		"kudos":             getKudosSummary(userID.(uint)),
 	})
 }
 
@@ -858,8 +942,15 @@ func GetPerformanceMetrics(c *gin.Context) {
 		punctualityScore = float64(onTimeShifts) / float64(totalShifts) * 100
 	}
 
-	// Calculate quality score based on feedback (placeholder - you may need to implement feedback system)
-	qualityScore := float64(85) // Default score
+	// Quality is how volunteers rated the shifts this volunteer worked over the last
+	// six months, with a default until there is feedback to go on
+	qualityScore := float64(85)
+	if score, responses, err := services.NewShiftFeedbackService().VolunteerQualityScore(userID, time.Now().AddDate(0, -6, 0)); err == nil && responses > 0 {
+		qualityScore = score
+	}
+
+	var recentFeedback []models.ShiftFeedback
+	db.DB.Where("user_id = ?", userID).Preload("Shift").Order("created_at DESC").Limit(5).Find(&recentFeedback)
 
 	overallRating := (reliabilityScore + punctualityScore + qualityScore) / 3
 
@@ -869,7 +960,7 @@ func GetPerformanceMetrics(c *gin.Context) {
 		"quality_score":     qualityScore,
 		"overall_rating":    overallRating,
 		"total_evaluations": totalShifts,
-		"recent_feedback":   []gin.H{},                        // Placeholder for future feedback system
+		"recent_feedback":   recentFeedback,
 		"improvement_areas": []string{},                       // Placeholder
 		"strengths":         []string{"Reliable", "Punctual"}, // Default strengths
 	}
@@ -940,8 +1031,8 @@ func GetEmergencyShifts(c *gin.Context) {
 	// Find shifts that start within next 24 hours and need volunteers
 	cutoffTime := time.Now().Add(24 * time.Hour)
 
-	db.DB.Where("start_time BETWEEN ? AND ? AND status = 'open'", time.Now(), cutoffTime).
-		Where("id NOT IN (SELECT shift_id FROM shift_assignments WHERE status IN ('confirmed', 'completed'))").
+	db.DB.Where("start_time BETWEEN ? AND ? AND assigned_volunteer_id IS NULL", time.Now(), cutoffTime).
+		Where("id NOT IN (SELECT shift_id FROM shift_assignments WHERE status IN ('Confirmed', 'Completed'))").
 		Order("start_time ASC").
 		Find(&emergencyShifts)
 
@@ -1075,69 +1166,20 @@ func GetHoursSummary(c *gin.Context) {
 	c.JSON(http.StatusOK, summary)
 }
 
-// GetTeamStats returns team statistics
+// GetTeamStats returns this month's statistics for the volunteer's team
 func GetTeamStats(c *gin.Context) {
-	userID := utils.GetUserIDFromContext(c)
-
-	var volunteer models.VolunteerProfile
-	if err := db.DB.Where("user_id = ?", userID).First(&volunteer).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Volunteer profile not found"})
+	service := services.NewVolunteerTeamService()
+	team, err := service.MemberTeam(utils.GetUserIDFromContext(c))
+	if err != nil {
+		teamError(c, err, "Failed to fetch team statistics")
 		return
 	}
 
-	// Get team stats
-	var totalMembers int64
-	var activeMembers int64
-
-	db.DB.Model(&models.VolunteerProfile{}).Count(&totalMembers)
-	db.DB.Model(&models.VolunteerProfile{}).Where("status = 'active'").Count(&activeMembers)
-
-	// Calculate total hours this month for all volunteers
-	now := time.Now()
-	thisMonthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-
-	var totalHoursThisMonth float64
-	db.DB.Model(&models.ShiftAssignment{}).
-		Select("COALESCE(SUM(hours_logged), 0)").
-		Joins("JOIN shifts ON shift_assignments.shift_id = shifts.id").
-		Where("shifts.start_time >= ?", thisMonthStart).
-		Scan(&totalHoursThisMonth)
-
-	averageHoursPerMember := float64(0)
-	if activeMembers > 0 {
-		averageHoursPerMember = totalHoursThisMonth / float64(activeMembers)
-	}
-
-	// Get top contributors this month
-	var topContributors []struct {
-		VolunteerID uint    `json:"volunteer_id"`
-		FirstName   string  `json:"first_name"`
-		LastName    string  `json:"last_name"`
-		Hours       float64 `json:"hours"`
-	}
-
-	db.DB.Model(&models.ShiftAssignment{}).
-		Select(`volunteer_profiles.id as volunteer_id, 
-				users.first_name, 
-				users.last_name, 
-				COALESCE(SUM(shift_assignments.hours_logged), 0) as hours`).
-		Joins("JOIN volunteer_profiles ON shift_assignments.volunteer_id = volunteer_profiles.id").
-		Joins("JOIN users ON volunteer_profiles.user_id = users.id").
-		Joins("JOIN shifts ON shift_assignments.shift_id = shifts.id").
-		Where("shifts.start_time >= ?", thisMonthStart).
-		Group("volunteer_profiles.id, users.first_name, users.last_name").
-		Order("hours DESC").
-		Limit(5).
-		Scan(&topContributors)
-
-	stats := gin.H{
-		"total_members":            totalMembers,
-		"active_members":           activeMembers,
-		"total_hours_this_month":   totalHoursThisMonth,
-		"average_hours_per_member": averageHoursPerMember,
-		"top_contributors":         topContributors,
+	stats, err := service.Stats(team, time.Now())
+	if err != nil {
+		teamError(c, err, "Failed to fetch team statistics")
+		return
 	}
-
 	c.JSON(http.StatusOK, stats)
 }
 
@@ -1,6 +1,7 @@
 package volunteer
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/geoo115/charity-management-system/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -192,78 +194,46 @@ func CreateVolunteerTeam(c *gin.Context) {
 		return
 	}
 
-	// Convert member IDs to string
-	memberIDStrings := make([]string, len(req.MemberIDs))
-	for i, id := range req.MemberIDs {
-		memberIDStrings[i] = strconv.FormatUint(uint64(id), 10)
-	}
-
-	team := models.VolunteerTeam{
+	team, err := services.NewVolunteerTeamService().CreateTeam(&models.VolunteerTeam{
 		Name:        req.Name,
 		Description: req.Description,
 		LeadID:      userID,
-		Members:     strings.Join(memberIDStrings, ","),
 		Active:      true,
-	}
-
-	if err := db.DB.Create(&team).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create team"})
+	}, req.MemberIDs, userID, time.Now())
+	if err != nil {
+		teamError(c, err, "Failed to create team")
 		return
 	}
 
-	// Update profile with team members
-	profile.TeamMembers = team.Members
+	// Keep the lead's profile list of team members in step
+	memberIDs := make([]string, 0, len(team.Members))
+	for _, member := range team.Members {
+		memberIDs = append(memberIDs, strconv.FormatUint(uint64(member.UserID), 10))
+	}
+	profile.TeamMembers = strings.Join(memberIDs, ",")
 	db.DB.Save(&profile)
 
+	utils.CreateAuditLog(c, "Create", "VolunteerTeam", team.ID, "Lead volunteer created team "+team.Name)
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Team created successfully",
 		"team":    team,
 	})
 }
 
-// GetVolunteerTeams returns teams for lead volunteers
+// GetVolunteerTeams returns the team a lead volunteer leads
 func GetVolunteerTeams(c *gin.Context) {
-	userID := utils.GetUserIDFromContext(c)
-
-	var teams []models.VolunteerTeam
-	if err := db.DB.Preload("Lead").Where("lead_id = ? AND active = ?", userID, true).Find(&teams).Error; err != nil {
+	teams := []models.VolunteerTeam{}
+	team, err := services.NewVolunteerTeamService().LedTeam(utils.GetUserIDFromContext(c))
+	switch {
+	case err == nil:
+		teams = append(teams, *team)
+	case !errors.Is(err, services.ErrNotTeamLead):
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch teams"})
 		return
 	}
 
-	// Enrich teams with member details
-	enrichedTeams := make([]gin.H, len(teams))
-	for i := range teams {
-		teamData := gin.H{
-			"id":          teams[i].ID,
-			"name":        teams[i].Name,
-			"description": teams[i].Description,
-			"lead_id":     teams[i].LeadID,
-			"lead":        teams[i].Lead,
-			"active":      teams[i].Active,
-			"created_at":  teams[i].CreatedAt,
-			"updated_at":  teams[i].UpdatedAt,
-			"members":     []models.User{},
-		}
-
-		if teams[i].Members != "" {
-			memberIDs := strings.Split(teams[i].Members, ",")
-			var members []models.User
-			for _, idStr := range memberIDs {
-				if id, err := strconv.ParseUint(idStr, 10, 32); err == nil {
-					var user models.User
-					if err := db.DB.Select("id, first_name, last_name, email").First(&user, uint(id)).Error; err == nil {
-						members = append(members, user)
-					}
-				}
-			}
-			teamData["members"] = members
-		}
-		enrichedTeams[i] = teamData
-	}
-
 	c.JSON(http.StatusOK, gin.H{
-		"teams": enrichedTeams,
+		"teams": teams,
 	})
 }
 
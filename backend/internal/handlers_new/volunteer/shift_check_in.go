@@ -0,0 +1,66 @@
+package volunteer
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShiftCodeCheckInRequest is the code a volunteer read off the coordinator's screen
+type ShiftCodeCheckInRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// CheckInWithShiftCode checks the volunteer in to their shift with the rotating code
+// shown at the venue, for venues without QR scanners
+func CheckInWithShiftCode(c *gin.Context) {
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+	var req ShiftCodeCheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assignment, err := services.NewShiftCodeService().CheckIn(utils.GetUserIDFromContext(c), uint(shiftID), req.Code, time.Now())
+	if err != nil {
+		shiftCodeError(c, err, "Failed to check in")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "You're checked in",
+		"shift_id":      assignment.ShiftID,
+		"checked_in_at": assignment.CheckedInAt,
+	})
+}
+
+// shiftCodeError maps shift code check-in errors to responses
+func shiftCodeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrShiftCodeShiftNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrShiftCodeNotAssigned):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrShiftCodeCheckedIn):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrShiftCodeLocked):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrShiftCodeInvalid), errors.Is(err, services.ErrShiftCodeTooEarly),
+		errors.Is(err, services.ErrShiftCodeEnded), errors.Is(err, services.ErrShiftCodeCancelled):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrShiftCodeKey):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Shift codes are not available"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,79 @@
+package volunteer
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShiftFeedbackRequest is a volunteer's quick feedback on a shift they worked
+type ShiftFeedbackRequest struct {
+	Rating           int      `json:"rating" binding:"required,min=1,max=5"`
+	StaffingAdequate *bool    `json:"staffing_adequate"`
+	Issues           []string `json:"issues"`
+	Comments         string   `json:"comments" binding:"max=2000"`
+}
+
+// GetPendingShiftFeedback returns the volunteer's recent shifts still waiting for
+// feedback, with the issues they can choose from
+func GetPendingShiftFeedback(c *gin.Context) {
+	assignments, err := services.NewShiftFeedbackService().Pending(utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shifts awaiting feedback"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"assignments": assignments,
+		"issues":      models.ShiftIssues,
+	})
+}
+
+// SubmitShiftFeedback records how a shift went: an overall rating, whether it was
+// adequately staffed and any issues
+func SubmitShiftFeedback(c *gin.Context) {
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+	var req ShiftFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	feedback, err := services.NewShiftFeedbackService().Submit(utils.GetUserIDFromContext(c), uint(shiftID), services.ShiftFeedbackInput{
+		Rating:           req.Rating,
+		StaffingAdequate: req.StaffingAdequate,
+		Issues:           req.Issues,
+		Comments:         req.Comments,
+	}, time.Now())
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Failed to save feedback"
+		switch {
+		case errors.Is(err, services.ErrShiftFeedbackNotWorked):
+			status, message = http.StatusForbidden, err.Error()
+		case errors.Is(err, services.ErrShiftFeedbackExists):
+			status, message = http.StatusConflict, err.Error()
+		case errors.Is(err, services.ErrShiftFeedbackTooEarly), errors.Is(err, services.ErrShiftFeedbackClosed),
+			errors.Is(err, services.ErrShiftFeedbackInvalid):
+			status, message = http.StatusBadRequest, err.Error()
+		}
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Thanks for your feedback",
+		"feedback": feedback,
+	})
+}
@@ -0,0 +1,69 @@
+package volunteer
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShiftReconfirmRequest answers a request to re-confirm a changed shift
+type ShiftReconfirmRequest struct {
+	Confirm *bool  `json:"confirm" binding:"required"`
+	Reason  string `json:"reason"` // Why the volunteer can no longer make it
+}
+
+// GetPendingShiftReconfirmations lists the volunteer's upcoming shifts that changed
+// and are waiting for them to re-confirm
+func GetPendingShiftReconfirmations(c *gin.Context) {
+	assignments, err := services.NewShiftSeriesService().PendingReconfirmations(utils.GetUserIDFromContext(c), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shifts to re-confirm"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"assignments": assignments})
+}
+
+// ReconfirmShift keeps or gives up the volunteer's place on a shift whose time or
+// place changed
+func ReconfirmShift(c *gin.Context) {
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+	var req ShiftReconfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	assignment, err := services.NewShiftSeriesService().Reconfirm(uint(shiftID), userID, *req.Confirm, req.Reason, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrReconfirmNotRequested) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save your answer"})
+		return
+	}
+
+	if !*req.Confirm {
+		utils.CreateAuditLog(c, "Cancel", "ShiftAssignment", assignment.ID, "Volunteer declined a changed shift")
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "Thanks for letting us know, you have been taken off this shift",
+			"assignment": assignment,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Thanks, your place on the shift is confirmed",
+		"assignment": assignment,
+	})
+}
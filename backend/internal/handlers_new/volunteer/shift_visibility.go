@@ -0,0 +1,90 @@
+package volunteer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShiftRoleTrainingRequest sets the training modules required for a shift role
+type ShiftRoleTrainingRequest struct {
+	Role              string `json:"role" binding:"required"`
+	TrainingModuleIDs []uint `json:"training_module_ids"`
+}
+
+// GetAlmostEligibleShifts lists open shifts the volunteer is a few qualifications
+// short of, with what each one is missing
+func GetAlmostEligibleShifts(c *gin.Context) {
+	var shifts []models.Shift
+	if err := db.DB.Where("assigned_volunteer_id IS NULL").Find(&shifts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve shifts"})
+		return
+	}
+
+	_, almost, err := services.NewShiftVisibilityService().Partition(utils.GetUserIDFromContext(c), shifts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check shift requirements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"shifts": almost,
+		"count":  len(almost),
+	})
+}
+
+// GetShiftRoleTrainingRequirements lists the training required for each shift role
+func GetShiftRoleTrainingRequirements(c *gin.Context) {
+	requirements, err := services.NewShiftVisibilityService().TrainingRequirements(c.Query("role"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve shift role training requirements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"requirements": requirements})
+}
+
+// SetShiftRoleTrainingRequirements replaces the training required for a shift role.
+// An empty list removes the role's requirements.
+func SetShiftRoleTrainingRequirements(c *gin.Context) {
+	var req ShiftRoleTrainingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	role := services.NormalizeShiftRole(req.Role)
+	if role == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role is required"})
+		return
+	}
+
+	requirements, err := services.NewShiftVisibilityService().SetTrainingRequirements(role, req.TrainingModuleIDs, utils.GetUserIDFromContext(c))
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownTrainingModule) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update shift role training requirements"})
+		return
+	}
+
+	names := make([]string, 0, len(requirements))
+	for _, requirement := range requirements {
+		names = append(names, requirement.TrainingModule.Name)
+	}
+	utils.CreateAuditLog(c, "Update", "ShiftRoleTrainingRequirement", 0,
+		fmt.Sprintf("Training required for shift role %q set to [%s]", role, strings.Join(names, ", ")))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Shift role training requirements updated",
+		"requirements": requirements,
+	})
+}
@@ -23,10 +23,20 @@ type ShiftRequest struct {
 	Role           string `json:"role"`
 	MaxVolunteers  int    `json:"maxVolunteers"`
 	RequiredSkills string `json:"requiredSkills"`
+	RoleLevel      string `json:"roleLevel"` // Minimum volunteer role level; empty for anyone
 	Type           string `json:"type"`      // "fixed", "flexible", "open"
 	OpenEnded      bool   `json:"openEnded"` // true if open-ended
 }
 
+// validShiftRoleLevel reports whether level can be required by a shift
+func validShiftRoleLevel(level string) bool {
+	switch level {
+	case "", models.VolunteerRoleGeneral, models.VolunteerRoleSpecialized, models.VolunteerRoleLead:
+		return true
+	}
+	return false
+}
+
 // CreateShift handles the creation of a new shift
 func CreateShift(c *gin.Context) {
 	var req ShiftRequest
@@ -139,6 +149,11 @@ func CreateShift(c *gin.Context) {
 		return
 	}
 
+	if !validShiftRoleLevel(req.RoleLevel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "roleLevel must be general, specialized or lead"})
+		return
+	}
+
 	// Handle max volunteers default value
 	maxVolunteers := req.MaxVolunteers
 	if maxVolunteers <= 0 {
@@ -158,6 +173,7 @@ func CreateShift(c *gin.Context) {
 		Role:           strings.TrimSpace(req.Role),
 		MaxVolunteers:  maxVolunteers,
 		RequiredSkills: strings.TrimSpace(req.RequiredSkills),
+		RoleLevel:      req.RoleLevel,
 		Type:           req.Type,
 		OpenEnded:      req.OpenEnded,
 		CreatedAt:      time.Now(),
@@ -299,6 +315,11 @@ func UpdateShift(c *gin.Context) {
 		return
 	}
 
+	if !validShiftRoleLevel(req.RoleLevel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "roleLevel must be general, specialized or lead"})
+		return
+	}
+
 	// Update the shift
 	shift.Date = date
 	shift.StartTime = startTime
@@ -307,6 +328,7 @@ func UpdateShift(c *gin.Context) {
 	shift.Description = req.Description
 	shift.MaxVolunteers = req.MaxVolunteers
 	shift.RequiredSkills = req.RequiredSkills
+	shift.RoleLevel = req.RoleLevel
 	shift.Type = req.Type
 	shift.OpenEnded = req.OpenEnded
 	shift.UpdatedAt = time.Now()
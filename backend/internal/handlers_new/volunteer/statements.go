@@ -0,0 +1,47 @@
+package volunteer
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMyStatements lists the volunteer's monthly hour statements, newest first
+func GetMyStatements(c *gin.Context) {
+	statements, err := services.NewVolunteerStatementService().ListForUser(utils.GetUserIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statements": statements})
+}
+
+// GetMyStatement returns one monthly statement with its shifts and achievements
+func GetMyStatement(c *gin.Context) {
+	month := c.Param("month")
+	if _, err := time.Parse("2006-01", month); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Month must be YYYY-MM"})
+		return
+	}
+
+	statement, content, err := services.NewVolunteerStatementService().Get(utils.GetUserIDFromContext(c), month)
+	if err != nil {
+		if errors.Is(err, services.ErrVolunteerStatementNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statement": statement,
+		"content":   content,
+	})
+}
@@ -0,0 +1,184 @@
+package volunteer
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// teamRosterDays is how far ahead a lead's team roster looks by default
+const teamRosterDays = 14
+
+// TeamAttendanceRequest records whether a team member turned up to a shift
+type TeamAttendanceRequest struct {
+	Attended *bool   `json:"attended" binding:"required"`
+	Hours    float64 `json:"hours"` // Hours worked; 0 takes the shift's length
+}
+
+// TeamHoursApprovalRequest approves a team member's hours, optionally correcting them
+type TeamHoursApprovalRequest struct {
+	Hours *float64 `json:"hours"`
+}
+
+// teamError maps volunteer team errors to responses
+func teamError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrNotTeamLead), errors.Is(err, services.ErrTeamPermission):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrVolunteerTeamNotFound), errors.Is(err, services.ErrVolunteerNotInTeam),
+		errors.Is(err, services.ErrTeamAssignmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrVolunteerTeamInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrVolunteerInAnotherTeam), errors.Is(err, services.ErrTeamAssignmentNotReady),
+		errors.Is(err, services.ErrTeamLeadCannotBeRemoved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// GetMyTeam returns the volunteer's team, its lead and members
+func GetMyTeam(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	team, err := services.NewVolunteerTeamService().MemberTeam(userID)
+	if err != nil {
+		teamError(c, err, "Failed to fetch your team")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"team":    team,
+		"is_lead": team.LeadID == userID,
+	})
+}
+
+// GetTeamRoster lists the shifts the lead's team members are signed up for, from
+// today for two weeks unless from and to (YYYY-MM-DD) are given
+func GetTeamRoster(c *gin.Context) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 0, teamRosterDays)
+	if v := c.Query("from"); v != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", v, now.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, use YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+	if v := c.Query("to"); v != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", v, now.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, use YYYY-MM-DD"})
+			return
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+
+	service := services.NewVolunteerTeamService()
+	team, err := service.LedTeam(utils.GetUserIDFromContext(c))
+	if err != nil {
+		teamError(c, err, "Failed to fetch team roster")
+		return
+	}
+	rosters, err := service.Rosters(team.ID, from, to)
+	if err != nil {
+		teamError(c, err, "Failed to fetch team roster")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"team_id": team.ID,
+		"from":    from.Format("2006-01-02"),
+		"to":      to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"shifts":  rosters,
+	})
+}
+
+// GetTeamPendingHours lists the lead's team members' shifts waiting for hours approval
+func GetTeamPendingHours(c *gin.Context) {
+	service := services.NewVolunteerTeamService()
+	team, err := service.LedTeam(utils.GetUserIDFromContext(c))
+	if err != nil {
+		teamError(c, err, "Failed to fetch hours to approve")
+		return
+	}
+	assignments, err := service.PendingHours(team.ID)
+	if err != nil {
+		teamError(c, err, "Failed to fetch hours to approve")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"can_approve_hours": team.CanApproveHours,
+		"assignments":       assignments,
+	})
+}
+
+// ConfirmTeamAttendance lets a lead record whether a team member attended a shift
+func ConfirmTeamAttendance(c *gin.Context) {
+	assignmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+	var req TeamAttendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assignment, err := services.NewVolunteerTeamService().ConfirmAttendance(
+		utils.GetUserIDFromContext(c), uint(assignmentID), *req.Attended, req.Hours, time.Now())
+	if err != nil {
+		teamError(c, err, "Failed to confirm attendance")
+		return
+	}
+
+	message := "Attendance confirmed, hours are waiting for approval"
+	if !*req.Attended {
+		message = "Recorded as a no-show"
+	}
+	utils.CreateAuditLog(c, "Update", "ShiftAssignment", assignment.ID, "Team lead: "+message)
+	c.JSON(http.StatusOK, gin.H{
+		"message":    message,
+		"assignment": assignment,
+	})
+}
+
+// ApproveTeamHours lets a lead approve a team member's hours for an attended shift
+func ApproveTeamHours(c *gin.Context) {
+	assignmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+	var req TeamHoursApprovalRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	assignment, err := services.NewVolunteerTeamService().ApproveHours(
+		utils.GetUserIDFromContext(c), uint(assignmentID), req.Hours, time.Now())
+	if err != nil {
+		teamError(c, err, "Failed to approve hours")
+		return
+	}
+
+	utils.CreateAuditLog(c, "Approve", "ShiftAssignment", assignment.ID, "Team lead approved shift hours")
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Hours approved",
+		"assignment": assignment,
+	})
+}
@@ -2,6 +2,7 @@ package volunteer
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/handlers_new/shared"
 	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -307,9 +309,13 @@ func GetShiftRecommendations(c *gin.Context) {
 	var volunteerApp models.VolunteerApplication
 	db.DB.Where("email = ?", volunteer.Email).First(&volunteerApp)
 
-	// Get available shifts
+	// Get available shifts, leaving out days the volunteer is away
 	var availableShifts []models.Shift
 	db.DB.Where("assigned_volunteer_id IS NULL AND date >= ?", time.Now()).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM volunteer_away_periods
+			WHERE volunteer_away_periods.user_id = ?
+			AND shifts.date::date BETWEEN volunteer_away_periods.starts_on AND volunteer_away_periods.ends_on)`, volunteerID).
 		Order("date ASC").
 		Limit(10).
 		Find(&availableShifts)
@@ -447,22 +453,11 @@ func checkShiftEligibility(volunteerID uint, shift models.Shift) ShiftEligibilit
 		}
 	}
 
-	// Check for time conflicts with other assigned shifts
-	var conflicts []models.Shift
-	db.DB.Where("assigned_volunteer_id = ? AND date::date = ?::date", volunteerID, shift.Date).Find(&conflicts)
-
-	for _, existingShift := range conflicts {
-		if timeRangesOverlapSameDay(shift.StartTime, shift.EndTime, existingShift.StartTime, existingShift.EndTime) {
-			return ShiftEligibilityResult{
-				Eligible:  false,
-				Reason:    fmt.Sprintf("Time conflict with existing shift from %s to %s", existingShift.StartTime.Format("15:04"), existingShift.EndTime.Format("15:04")),
-				Conflicts: []models.Shift{existingShift},
-				Suggestions: []string{
-					"Choose a different time slot",
-					"Cancel your existing shift if this one is more important",
-					"Contact coordinator about overlapping assignments",
-				},
-			}
+	// Check for clashes with other shifts that day, including travel between locations.
+	// Flexible shifts are checked once the volunteer has chosen their times.
+	if shift.Type != "flexible" {
+		if result := checkShiftClashes(volunteerID, shift, shift.StartTime, shift.EndTime); result != nil {
+			return *result
 		}
 	}
 
@@ -488,22 +483,63 @@ func checkShiftEligibility(volunteerID uint, shift models.Shift) ShiftEligibilit
 
 // ShiftEligibilityResult represents the result of basic eligibility checking
 type ShiftEligibilityResult struct {
-	Eligible    bool           `json:"eligible"`
-	Reason      string         `json:"reason,omitempty"`
-	Conflicts   []models.Shift `json:"conflicts,omitempty"`
-	Suggestions []string       `json:"suggestions,omitempty"`
-	ErrorCode   string         `json:"error_code,omitempty"`
+	Eligible     bool                  `json:"eligible"`
+	Reason       string                `json:"reason,omitempty"`
+	Conflicts    []models.Shift        `json:"conflicts,omitempty"`
+	Suggestions  []string              `json:"suggestions,omitempty"`
+	ErrorCode    string                `json:"error_code,omitempty"`
+	Clashes      []services.ShiftClash `json:"clashes,omitempty"`
+	Alternatives []models.Shift        `json:"alternatives,omitempty"`
 }
 
-// Helper function to check if time ranges overlap on the same day
-func timeRangesOverlapSameDay(start1, end1, start2, end2 time.Time) bool {
-	// Extract just the time components for comparison
-	time1Start := start1.Hour()*60 + start1.Minute()
-	time1End := end1.Hour()*60 + end1.Minute()
-	time2Start := start2.Hour()*60 + start2.Minute()
-	time2End := end2.Hour()*60 + end2.Minute()
+// checkShiftClashes checks the volunteer can work a shift from start to end alongside
+// their other shifts that day, leaving time to travel between locations. It returns
+// nil when there is no clash.
+func checkShiftClashes(volunteerID uint, shift models.Shift, start, end time.Time) *ShiftEligibilityResult {
+	clashService := services.NewShiftClashService()
+	clashes, err := clashService.Check(volunteerID, shift, start, end)
+	if err != nil {
+		log.Printf("Failed to check shift clashes for volunteer %d: %v", volunteerID, err)
+		return nil
+	}
+	if len(clashes) == 0 {
+		return nil
+	}
 
-	return time1Start < time2End && time2Start < time1End
+	result := &ShiftEligibilityResult{
+		Eligible: false,
+		Clashes:  clashes,
+	}
+	for _, clash := range clashes {
+		result.Conflicts = append(result.Conflicts, clash.Shift)
+	}
+
+	first := clashes[0]
+	if first.Kind == services.ShiftClashOverlap {
+		result.ErrorCode = "TIME_CONFLICT"
+		result.Reason = fmt.Sprintf("Time conflict with existing shift from %s to %s", first.Start, first.End)
+		result.Suggestions = []string{
+			"Choose a different time slot",
+			"Cancel your existing shift if this one is more important",
+			"Contact coordinator about overlapping assignments",
+		}
+	} else {
+		result.ErrorCode = "TRAVEL_CONFLICT"
+		result.Reason = fmt.Sprintf("Not enough time to travel from your %s to %s shift at %s: %d minutes between shifts, %d needed",
+			first.Start, first.End, first.Shift.Location, first.GapMinutes, first.RequiredMinutes)
+		result.Suggestions = []string{
+			"Choose a shift at the same location",
+			"Choose a shift with more time between them",
+			"Contact coordinator if you can get there sooner",
+		}
+	}
+
+	alternatives, err := clashService.Alternatives(volunteerID, shift, 5)
+	if err != nil {
+		log.Printf("Failed to find alternative shifts for volunteer %d: %v", volunteerID, err)
+	}
+	result.Alternatives = alternatives
+	return result
 }
 
 // Helper function to count flexible assignments
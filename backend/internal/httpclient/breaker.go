@@ -0,0 +1,148 @@
+package httpclient
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/observability"
+)
+
+// Circuit states
+const (
+	StateClosed   = "closed"    // Calls go through
+	StateOpen     = "open"      // Calls are rejected until the open period ends
+	StateHalfOpen = "half_open" // One trial call decides whether to close again
+)
+
+// CircuitStatus is a snapshot of one destination host's circuit breaker
+type CircuitStatus struct {
+	Destination string     `json:"destination"`
+	Host        string     `json:"host"`
+	State       string     `json:"state"`
+	Failures    int        `json:"consecutive_failures"`
+	OpenedAt    *time.Time `json:"opened_at,omitempty"`
+	RetryAt     *time.Time `json:"retry_at,omitempty"`
+}
+
+// breaker stops calls to a host after consecutive failures, then lets a single trial
+// call through once the open period has passed
+type breaker struct {
+	mu          sync.Mutex
+	destination string
+	host        string
+	threshold   int
+	openFor     time.Duration
+	state       string
+	failures    int
+	openedAt    time.Time
+	trial       bool // A half-open trial call is in flight
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*breaker{}
+)
+
+// breakerFor returns the shared breaker for a destination host
+func breakerFor(destination, host string, threshold int, openFor time.Duration) *breaker {
+	key := destination + "|" + host
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	b, ok := breakers[key]
+	if !ok {
+		b = &breaker{destination: destination, host: host, threshold: threshold, openFor: openFor, state: StateClosed}
+		breakers[key] = b
+	}
+	return b
+}
+
+// allow reports whether a call may go ahead now. Every allowed call must be followed
+// by record or release.
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case StateOpen:
+		if now.Sub(b.openedAt) < b.openFor {
+			return false
+		}
+		b.setState(StateHalfOpen)
+		b.trial = true
+		return true
+	case StateHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	}
+	return true
+}
+
+// record updates the breaker with the outcome of an allowed call
+func (b *breaker) record(success bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if success {
+		b.failures = 0
+		b.setState(StateClosed)
+		return
+	}
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.openedAt = now
+		b.setState(StateOpen)
+	}
+}
+
+// release gives back an allowed call that was never sent
+func (b *breaker) release() {
+	b.mu.Lock()
+	b.trial = false
+	b.mu.Unlock()
+}
+
+// setState changes state and reports it to Prometheus; the caller holds the lock
+func (b *breaker) setState(state string) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	observability.GetMetricsService().SetOutboundCircuitState(b.destination, b.host, state)
+}
+
+// status returns a snapshot of the breaker
+func (b *breaker) status() CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := CircuitStatus{Destination: b.destination, Host: b.host, State: b.state, Failures: b.failures}
+	if b.state != StateClosed {
+		openedAt, retryAt := b.openedAt, b.openedAt.Add(b.openFor)
+		status.OpenedAt, status.RetryAt = &openedAt, &retryAt
+	}
+	return status
+}
+
+// Circuits returns the state of every destination host called so far
+func Circuits() []CircuitStatus {
+	breakersMu.Lock()
+	list := make([]*breaker, 0, len(breakers))
+	for _, b := range breakers {
+		list = append(list, b)
+	}
+	breakersMu.Unlock()
+
+	statuses := make([]CircuitStatus, 0, len(list))
+	for _, b := range list {
+		statuses = append(statuses, b.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Destination != statuses[j].Destination {
+			return statuses[i].Destination < statuses[j].Destination
+		}
+		return statuses[i].Host < statuses[j].Host
+	})
+	return statuses
+}
@@ -0,0 +1,248 @@
+// Package httpclient builds the HTTP clients used to call external providers such as
+// payment, SMS, email, identity and supplier endpoints. Every client has a timeout,
+// retries transient failures with jittered backoff, stops calling a host that keeps
+// failing until it has had time to recover, records Prometheus metrics and passes the
+// current trace on to the provider.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/observability"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Defaults used when neither the options nor the environment set a value
+const (
+	DefaultTimeout          = 30 * time.Second
+	DefaultMaxRetries       = 2
+	DefaultRetryBackoff     = 200 * time.Millisecond
+	DefaultMaxRetryBackoff  = 5 * time.Second
+	DefaultFailureThreshold = 5
+	DefaultOpenFor          = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned without calling the provider while its circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Options configures a client. Zero values fall back to the environment and then to
+// the package defaults.
+type Options struct {
+	Timeout          time.Duration // Limit on the whole call, retries included
+	MaxRetries       int           // Retries after the first attempt; -1 disables them
+	FailureThreshold int           // Consecutive failures that open the circuit
+	OpenFor          time.Duration // How long an open circuit rejects calls
+	// RetryUnsafe allows retrying POST and PATCH requests after the provider may have
+	// seen them. Only set it for providers that ignore duplicates. Requests carrying an
+	// Idempotency-Key header are always retried.
+	RetryUnsafe bool
+	Transport   http.RoundTripper // Base transport, for example with client certificates
+}
+
+// New returns an HTTP client for calls to the named destination, such as "sendgrid"
+// or "supplier_webhook". The name labels metrics and traces; each host the client
+// calls gets its own circuit breaker.
+func New(destination string, opts Options) *http.Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = envInt("OUTBOUND_HTTP_MAX_RETRIES", DefaultMaxRetries)
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = envInt("OUTBOUND_HTTP_BREAKER_THRESHOLD", DefaultFailureThreshold)
+	}
+	if opts.OpenFor <= 0 {
+		opts.OpenFor = time.Duration(envInt("OUTBOUND_HTTP_BREAKER_OPEN_SECONDS", int(DefaultOpenFor/time.Second))) * time.Second
+	}
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+
+	return &http.Client{
+		Timeout: opts.Timeout,
+		Transport: &transport{
+			destination: destination,
+			opts:        opts,
+			base:        opts.Transport,
+		},
+	}
+}
+
+// transport applies the breaker, retries, metrics and tracing around a base transport
+type transport struct {
+	destination string
+	opts        Options
+	base        http.RoundTripper
+}
+
+// RoundTrip sends a request, retrying transient failures while the circuit allows it
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	breaker := breakerFor(t.destination, req.URL.Host, t.opts.FailureThreshold, t.opts.OpenFor)
+
+	ctx, span := observability.GetTracer().Start(req.Context(), "HTTP "+req.Method+" "+t.destination,
+		oteltrace.WithSpanKind(oteltrace.SpanKindClient),
+		oteltrace.WithAttributes(
+			attribute.String("http.method", req.Method),
+			attribute.String("net.peer.name", req.URL.Hostname()),
+			attribute.String("outbound.destination", t.destination),
+		),
+	)
+	defer span.End()
+
+	metrics := observability.GetMetricsService()
+	for attempt := 0; ; attempt++ {
+		if !breaker.allow(time.Now()) {
+			metrics.RecordOutboundRequest(t.destination, req.Method, "circuit_open", 0)
+			span.SetStatus(codes.Error, ErrCircuitOpen.Error())
+			return nil, fmt.Errorf("%s (%s): %w", t.destination, req.URL.Host, ErrCircuitOpen)
+		}
+
+		attemptReq, err := prepareAttempt(ctx, req, attempt)
+		if err != nil {
+			breaker.release()
+			return nil, err
+		}
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(attemptReq.Header))
+
+		start := time.Now()
+		resp, err := t.base.RoundTrip(attemptReq)
+		metrics.RecordOutboundRequest(t.destination, req.Method, resultLabel(resp, err), time.Since(start))
+
+		failed := isFailure(resp, err)
+		breaker.record(!failed, time.Now())
+		if !failed {
+			span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+			return resp, nil
+		}
+
+		if attempt >= t.opts.MaxRetries || !t.retryable(req, err) || ctx.Err() != nil {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			} else {
+				span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+				span.SetStatus(codes.Error, resp.Status)
+			}
+			return resp, err
+		}
+
+		wait := backoff(attempt, retryAfter(resp))
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+		metrics.RecordOutboundRetry(t.destination)
+		span.AddEvent("retry", oteltrace.WithAttributes(attribute.Int("attempt", attempt+1)))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// prepareAttempt copies the request for one attempt, rewinding its body for retries
+func prepareAttempt(ctx context.Context, req *http.Request, attempt int) (*http.Request, error) {
+	attemptReq := req.Clone(ctx)
+	if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, errors.New("request body cannot be replayed for retry")
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		attemptReq.Body = body
+	}
+	return attemptReq, nil
+}
+
+// retryable reports whether a failed attempt may be sent again. A request that never
+// reached the provider can always be retried; otherwise the method has to be safe to
+// repeat.
+func (t *transport) retryable(req *http.Request, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return t.opts.RetryUnsafe || req.Header.Get("Idempotency-Key") != ""
+}
+
+// isFailure reports whether an attempt counts against the provider's circuit
+func isFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// resultLabel is the metrics label for an attempt's outcome
+func resultLabel(resp *http.Response, err error) string {
+	if err != nil {
+		return "error"
+	}
+	return strconv.Itoa(resp.StatusCode/100) + "xx"
+}
+
+// backoff is how long to wait before retry attempt+1: exponential with full jitter,
+// or what the provider asked for in Retry-After when that is longer
+func backoff(attempt int, requested time.Duration) time.Duration {
+	ceiling := DefaultRetryBackoff << attempt
+	if ceiling > DefaultMaxRetryBackoff || ceiling <= 0 {
+		ceiling = DefaultMaxRetryBackoff
+	}
+	wait := time.Duration(rand.Int63n(int64(ceiling)) + 1)
+	if requested > wait {
+		wait = requested
+	}
+	if wait > DefaultMaxRetryBackoff {
+		wait = DefaultMaxRetryBackoff
+	}
+	return wait
+}
+
+// retryAfter reads a Retry-After header given in seconds
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
+
+func envInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,112 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetriesTransientFailures(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New("test_retry", Options{MaxRetries: 2, FailureThreshold: 10})
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls != 3 {
+		t.Errorf("got status %d after %d calls, want 200 after 3", resp.StatusCode, calls)
+	}
+}
+
+func TestDoesNotRetryUnsafeRequests(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := New("test_post", Options{MaxRetries: 2, FailureThreshold: 10})
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if calls != 1 {
+		t.Errorf("POST was sent %d times, want 1", calls)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{}`))
+	req.Header.Set("Idempotency-Key", "order-1")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if calls != 4 {
+		t.Errorf("POST with an idempotency key was sent %d times, want 3", calls-1)
+	}
+}
+
+func TestCircuitOpensAndRecovers(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if healthy.Load() {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := New("test_breaker", Options{MaxRetries: -1, FailureThreshold: 2, OpenFor: 50 * time.Millisecond})
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if _, err := client.Get(server.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the circuit to be open, got %v", err)
+	}
+
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the trial call to go through, got %v", err)
+	}
+	resp.Body.Close()
+
+	for _, circuit := range Circuits() {
+		if circuit.Destination == "test_breaker" && circuit.State != StateClosed {
+			t.Errorf("expected the circuit to close after a successful trial, got %s", circuit.State)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		if wait := backoff(attempt, 0); wait <= 0 || wait > DefaultMaxRetryBackoff {
+			t.Errorf("backoff(%d) = %v, outside (0, %v]", attempt, wait, DefaultMaxRetryBackoff)
+		}
+	}
+	if wait := backoff(0, 2*time.Second); wait != 2*time.Second {
+		t.Errorf("expected Retry-After to be honoured, got %v", wait)
+	}
+}
@@ -1,27 +1,128 @@
 package jobs
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"strconv"
 	"sync"
 	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
 )
 
 // JobConfig controls which background jobs are enabled
 type JobConfig struct {
-	EnableInventoryChecks  bool
-	EnableReminderEmails   bool
-	InventoryCheckInterval time.Duration
-	ReminderEmailInterval  time.Duration
+	EnableInventoryChecks      bool
+	EnableReminderEmails       bool
+	EnableCalloutExpiry        bool
+	EnableStandbyRelease       bool
+	EnableCampaignOutbox       bool
+	EnableDocumentExpiry       bool
+	EnableReverification       bool
+	EnableServiceTimes         bool
+	EnableAnalytics            bool
+	EnableAppRetention         bool
+	EnableSLAAlerts            bool
+	EnableQueueFairness        bool
+	EnableStatements           bool
+	EnableAppointments         bool
+	EnableChangeReports        bool
+	EnableOverrideExpiry       bool
+	EnableMissedCalls          bool
+	EnableShiftFeedback        bool
+	EnableAccountErasure       bool
+	EnableSystemAlerts         bool
+	EnableNotificationDigests  bool
+	EnableKPIAnomalies         bool
+	EnableDraftExpiry          bool
+	EnableTicketRelease        bool
+	EnableResearchDatasets     bool
+	EnableClosureReopening     bool
+	InventoryCheckInterval     time.Duration
+	ReminderEmailInterval      time.Duration
+	CalloutExpiryInterval      time.Duration
+	StandbyReleaseInterval     time.Duration
+	CampaignOutboxInterval     time.Duration
+	DocumentExpiryInterval     time.Duration
+	ReverificationInterval     time.Duration
+	ServiceTimeInterval        time.Duration
+	AnalyticsInterval          time.Duration
+	AppRetentionInterval       time.Duration
+	SLAAlertInterval           time.Duration
+	QueueFairnessInterval      time.Duration
+	StatementInterval          time.Duration
+	AppointmentInterval        time.Duration
+	ChangeReportInterval       time.Duration
+	OverrideExpiryInterval     time.Duration
+	MissedCallInterval         time.Duration
+	ShiftFeedbackInterval      time.Duration
+	AccountErasureInterval     time.Duration
+	SystemAlertInterval        time.Duration
+	NotificationDigestInterval time.Duration
+	KPIAnomalyInterval         time.Duration
+	DraftExpiryInterval        time.Duration
+	TicketReleaseInterval      time.Duration
+	ResearchDatasetInterval    time.Duration
+	ClosureReopeningInterval   time.Duration
 }
 
 // Default job configuration with sensible defaults
 var defaultJobConfig = JobConfig{
-	EnableInventoryChecks:  true,
-	EnableReminderEmails:   true,
-	InventoryCheckInterval: 6 * time.Hour,
-	ReminderEmailInterval:  24 * time.Hour,
+	EnableInventoryChecks:      true,
+	EnableReminderEmails:       true,
+	EnableCalloutExpiry:        true,
+	EnableStandbyRelease:       true,
+	EnableCampaignOutbox:       true,
+	EnableDocumentExpiry:       true,
+	EnableReverification:       true,
+	EnableServiceTimes:         true,
+	EnableAnalytics:            false,
+	EnableAppRetention:         true,
+	EnableSLAAlerts:            true,
+	EnableQueueFairness:        true,
+	EnableStatements:           true,
+	EnableAppointments:         true,
+	EnableChangeReports:        true,
+	EnableOverrideExpiry:       true,
+	EnableMissedCalls:          true,
+	EnableShiftFeedback:        true,
+	EnableAccountErasure:       true,
+	EnableSystemAlerts:         true,
+	EnableNotificationDigests:  true,
+	EnableKPIAnomalies:         true,
+	EnableDraftExpiry:          true,
+	EnableTicketRelease:        true,
+	EnableResearchDatasets:     true,
+	EnableClosureReopening:     true,
+	InventoryCheckInterval:     6 * time.Hour,
+	ReminderEmailInterval:      24 * time.Hour,
+	CalloutExpiryInterval:      5 * time.Minute,
+	StandbyReleaseInterval:     5 * time.Minute,
+	CampaignOutboxInterval:     time.Minute,
+	DocumentExpiryInterval:     24 * time.Hour,
+	ReverificationInterval:     24 * time.Hour,
+	ServiceTimeInterval:        time.Minute,
+	AnalyticsInterval:          15 * time.Minute,
+	AppRetentionInterval:       24 * time.Hour,
+	SLAAlertInterval:           time.Hour,
+	QueueFairnessInterval:      15 * time.Minute,
+	StatementInterval:          time.Hour,
+	AppointmentInterval:        15 * time.Minute,
+	ChangeReportInterval:       time.Hour,
+	OverrideExpiryInterval:     5 * time.Minute,
+	MissedCallInterval:         30 * time.Second,
+	ShiftFeedbackInterval:      15 * time.Minute,
+	AccountErasureInterval:     1 * time.Hour,
+	SystemAlertInterval:        5 * time.Minute,
+	NotificationDigestInterval: 15 * time.Minute,
+	KPIAnomalyInterval:         time.Hour,
+	DraftExpiryInterval:        time.Hour,
+	TicketReleaseInterval:      time.Minute,
+	ResearchDatasetInterval:    6 * time.Hour,
+	ClosureReopeningInterval:   5 * time.Minute,
 }
 
 var (
@@ -42,6 +143,102 @@ func GetJobConfigFromEnv() JobConfig {
 		config.EnableReminderEmails, _ = strconv.ParseBool(val)
 	}
 
+	if val, exists := os.LookupEnv("ENABLE_CALLOUT_EXPIRY"); exists {
+		config.EnableCalloutExpiry, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_STANDBY_RELEASE"); exists {
+		config.EnableStandbyRelease, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_CAMPAIGN_OUTBOX"); exists {
+		config.EnableCampaignOutbox, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_DOCUMENT_EXPIRY"); exists {
+		config.EnableDocumentExpiry, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_VISITOR_REVERIFICATION"); exists {
+		config.EnableReverification, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_SERVICE_TIME_ALERTS"); exists {
+		config.EnableServiceTimes, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_ANALYTICS_EXPORT"); exists {
+		config.EnableAnalytics, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_APPLICATION_RETENTION"); exists {
+		config.EnableAppRetention, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_SLA_ALERTS"); exists {
+		config.EnableSLAAlerts, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_QUEUE_FAIRNESS"); exists {
+		config.EnableQueueFairness, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_VOLUNTEER_STATEMENTS"); exists {
+		config.EnableStatements, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_APPOINTMENT_REMINDERS"); exists {
+		config.EnableAppointments, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_ADMIN_CHANGE_REPORTS"); exists {
+		config.EnableChangeReports, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_CAPACITY_OVERRIDE_EXPIRY"); exists {
+		config.EnableOverrideExpiry, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_QUEUE_MISSED_CALLS"); exists {
+		config.EnableMissedCalls, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_SHIFT_FEEDBACK_PROMPTS"); exists {
+		config.EnableShiftFeedback, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_ACCOUNT_ERASURE"); exists {
+		config.EnableAccountErasure, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_SYSTEM_ALERTS"); exists {
+		config.EnableSystemAlerts, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_NOTIFICATION_DIGESTS"); exists {
+		config.EnableNotificationDigests, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_KPI_ANOMALIES"); exists {
+		config.EnableKPIAnomalies, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_HELP_REQUEST_DRAFT_EXPIRY"); exists {
+		config.EnableDraftExpiry, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_SCHEDULED_TICKET_RELEASE"); exists {
+		config.EnableTicketRelease, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_RESEARCH_DATASET_EXPORT"); exists {
+		config.EnableResearchDatasets, _ = strconv.ParseBool(val)
+	}
+
+	if val, exists := os.LookupEnv("ENABLE_CLOSURE_REOPENING"); exists {
+		config.EnableClosureReopening, _ = strconv.ParseBool(val)
+	}
+
 	// Check for custom intervals
 	if val, exists := os.LookupEnv("INVENTORY_CHECK_INTERVAL_HOURS"); exists {
 		if hours, err := strconv.Atoi(val); err == nil && hours > 0 {
@@ -55,6 +252,138 @@ func GetJobConfigFromEnv() JobConfig {
 		}
 	}
 
+	if val, exists := os.LookupEnv("CALLOUT_EXPIRY_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.CalloutExpiryInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("STANDBY_RELEASE_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.StandbyReleaseInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("CAMPAIGN_OUTBOX_INTERVAL_SECONDS"); exists {
+		if seconds, err := strconv.Atoi(val); err == nil && seconds > 0 {
+			config.CampaignOutboxInterval = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if val, exists := os.LookupEnv("DOCUMENT_EXPIRY_INTERVAL_HOURS"); exists {
+		if hours, err := strconv.Atoi(val); err == nil && hours > 0 {
+			config.DocumentExpiryInterval = time.Duration(hours) * time.Hour
+		}
+	}
+
+	if val, exists := os.LookupEnv("VISITOR_REVERIFICATION_INTERVAL_HOURS"); exists {
+		if hours, err := strconv.Atoi(val); err == nil && hours > 0 {
+			config.ReverificationInterval = time.Duration(hours) * time.Hour
+		}
+	}
+
+	if val, exists := os.LookupEnv("SERVICE_TIME_INTERVAL_SECONDS"); exists {
+		if seconds, err := strconv.Atoi(val); err == nil && seconds > 0 {
+			config.ServiceTimeInterval = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if val, exists := os.LookupEnv("ANALYTICS_EXPORT_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.AnalyticsInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("APPLICATION_RETENTION_INTERVAL_HOURS"); exists {
+		if hours, err := strconv.Atoi(val); err == nil && hours > 0 {
+			config.AppRetentionInterval = time.Duration(hours) * time.Hour
+		}
+	}
+
+	if val, exists := os.LookupEnv("SLA_ALERT_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.SLAAlertInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("QUEUE_FAIRNESS_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.QueueFairnessInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("VOLUNTEER_STATEMENT_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.StatementInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("APPOINTMENT_REMINDER_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.AppointmentInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("ADMIN_CHANGE_REPORT_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.ChangeReportInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("CAPACITY_OVERRIDE_EXPIRY_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.OverrideExpiryInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("QUEUE_MISSED_CALL_INTERVAL_SECONDS"); exists {
+		if seconds, err := strconv.Atoi(val); err == nil && seconds > 0 {
+			config.MissedCallInterval = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if val, exists := os.LookupEnv("SHIFT_FEEDBACK_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.ShiftFeedbackInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("ACCOUNT_ERASURE_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.AccountErasureInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("SYSTEM_ALERT_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.SystemAlertInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("NOTIFICATION_DIGEST_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.NotificationDigestInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("KPI_ANOMALY_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.KPIAnomalyInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("HELP_REQUEST_DRAFT_EXPIRY_INTERVAL_MINUTES"); exists {
+		if minutes, err := strconv.Atoi(val); err == nil && minutes > 0 {
+			config.DraftExpiryInterval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if val, exists := os.LookupEnv("RESEARCH_DATASET_INTERVAL_HOURS"); exists {
+		if hours, err := strconv.Atoi(val); err == nil && hours > 0 {
+			config.ResearchDatasetInterval = time.Duration(hours) * time.Hour
+		}
+	}
+
 	return config
 }
 
@@ -79,12 +408,181 @@ func StartBackgroundJobs() {
 	} else {
 		log.Println("Reminder emails disabled")
 	}
+
+	if config.EnableCalloutExpiry {
+		jobsWaitGroup.Add(1)
+		go scheduleCalloutExpiry(config.CalloutExpiryInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Emergency call-out expiry disabled")
+	}
+
+	if config.EnableStandbyRelease {
+		jobsWaitGroup.Add(1)
+		go scheduleStandbyRelease(config.StandbyReleaseInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Standby capacity release disabled")
+	}
+
+	if config.EnableCampaignOutbox {
+		jobsWaitGroup.Add(1)
+		go scheduleCampaignOutbox(config.CampaignOutboxInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Campaign outbox disabled")
+	}
+
+	if config.EnableDocumentExpiry {
+		jobsWaitGroup.Add(1)
+		go scheduleDocumentExpiry(config.DocumentExpiryInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Volunteer document expiry notices disabled")
+	}
+
+	if config.EnableReverification {
+		jobsWaitGroup.Add(1)
+		go scheduleReverification(config.ReverificationInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Visitor re-verification disabled")
+	}
+
+	if config.EnableServiceTimes {
+		jobsWaitGroup.Add(1)
+		go scheduleServiceTimeAlerts(config.ServiceTimeInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Service time alerts disabled")
+	}
+
+	if config.EnableAnalytics {
+		jobsWaitGroup.Add(1)
+		go scheduleAnalyticsExport(config.AnalyticsInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Analytics event export disabled")
+	}
+
+	if config.EnableAppRetention {
+		jobsWaitGroup.Add(1)
+		go scheduleApplicationRetention(config.AppRetentionInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Volunteer application anonymization disabled")
+	}
+
+	if config.EnableSLAAlerts {
+		jobsWaitGroup.Add(1)
+		go scheduleSLAAlerts(config.SLAAlertInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Help request SLA alerts disabled")
+	}
+
+	if config.EnableQueueFairness {
+		jobsWaitGroup.Add(1)
+		go scheduleQueueFairness(config.QueueFairnessInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Queue wait anomaly detection and fairness reports disabled")
+	}
+
+	if config.EnableStatements {
+		jobsWaitGroup.Add(1)
+		go scheduleVolunteerStatements(config.StatementInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Monthly volunteer statements disabled")
+	}
+
+	if config.EnableAppointments {
+		jobsWaitGroup.Add(1)
+		go scheduleAppointmentReminders(config.AppointmentInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Appointment reminders disabled")
+	}
+
+	if config.EnableChangeReports {
+		jobsWaitGroup.Add(1)
+		go scheduleChangeReports(config.ChangeReportInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Weekly administrative change reports disabled")
+	}
+
+	if config.EnableOverrideExpiry {
+		jobsWaitGroup.Add(1)
+		go scheduleOverrideExpiry(config.OverrideExpiryInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Capacity override expiry disabled")
+	}
+
+	if config.EnableMissedCalls {
+		jobsWaitGroup.Add(1)
+		go scheduleMissedCalls(config.MissedCallInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Queue missed call handling disabled")
+	}
+
+	if config.EnableShiftFeedback {
+		jobsWaitGroup.Add(1)
+		go scheduleShiftFeedbackPrompts(config.ShiftFeedbackInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Shift feedback prompts disabled")
+	}
+
+	if config.EnableAccountErasure {
+		jobsWaitGroup.Add(1)
+		go scheduleAccountErasure(config.AccountErasureInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Account erasure disabled")
+	}
+
+	if config.EnableSystemAlerts {
+		jobsWaitGroup.Add(1)
+		go scheduleSystemAlerts(config.SystemAlertInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("System alert evaluation disabled")
+	}
+
+	if config.EnableNotificationDigests {
+		jobsWaitGroup.Add(1)
+		go scheduleNotificationDigests(config.NotificationDigestInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Notification digests disabled")
+	}
+
+	if config.EnableKPIAnomalies {
+		jobsWaitGroup.Add(1)
+		go scheduleKPIAnomalies(config.KPIAnomalyInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("KPI anomaly alerts disabled")
+	}
+
+	if config.EnableDraftExpiry {
+		jobsWaitGroup.Add(1)
+		go scheduleDraftExpiry(config.DraftExpiryInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Help request draft expiry disabled")
+	}
+
+	if config.EnableTicketRelease {
+		jobsWaitGroup.Add(1)
+		go scheduleTicketRelease(config.TicketReleaseInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Scheduled ticket release disabled")
+	}
+
+	if config.EnableResearchDatasets {
+		jobsWaitGroup.Add(1)
+		go scheduleResearchDatasets(config.ResearchDatasetInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Research dataset export disabled")
+	}
+
+	if config.EnableClosureReopening {
+		jobsWaitGroup.Add(1)
+		go scheduleClosureReopening(config.ClosureReopeningInterval, stopChan, &jobsWaitGroup)
+	} else {
+		log.Println("Closure reopening disabled")
+	}
 }
 
 // StopBackgroundJobs gracefully stops all background jobs
 func StopBackgroundJobs() {
 	log.Println("Stopping background jobs...")
 	close(stopChan)
+	stopRetries()
 
 	// Wait with timeout for jobs to finish
 	done := make(chan struct{})
@@ -111,12 +609,12 @@ func scheduleInventoryChecks(interval time.Duration, stop chan struct{}, wg *syn
 	defer ticker.Stop()
 
 	// Run an initial check immediately
-	runInventoryCheck()
+	runWithRetry("inventory_checks", runInventoryCheck)
 
 	for {
 		select {
 		case <-ticker.C:
-			runInventoryCheck()
+			runWithRetry("inventory_checks", runInventoryCheck)
 		case <-stop:
 			log.Println("Stopping inventory checks")
 			return
@@ -124,14 +622,22 @@ func scheduleInventoryChecks(interval time.Duration, stop chan struct{}, wg *syn
 	}
 }
 
-// runInventoryCheck performs the actual inventory check
-func runInventoryCheck() {
+// runInventoryCheck raises supplier orders for urgent needs that have fallen to their
+// reorder level
+func runInventoryCheck() error {
 	log.Println("Running scheduled inventory check")
-	// Implementation would check database inventory levels
-	// and flag items that are below threshold
+	orders, err := services.NewSupplierOrderService().CheckReorders(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to check inventory levels: %w", err)
+	}
+	if len(orders) > 0 {
+		log.Printf("Raised %d low stock supplier orders", len(orders))
+	}
+	return nil
 }
 
-// scheduleReminderEmails sends reminder emails for upcoming shifts
+// scheduleReminderEmails reminds volunteers of shifts starting in the next 24 hours,
+// skipping volunteers who are away
 func scheduleReminderEmails(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
 	defer wg.Done()
 	log.Printf("Starting reminder emails at %s intervals", interval)
@@ -142,12 +648,814 @@ func scheduleReminderEmails(interval time.Duration, stop chan struct{}, wg *sync
 	for {
 		select {
 		case <-ticker.C:
-			log.Println("Sending shift reminder emails")
-			// Implementation would find shifts in next 24 hours
-			// and send reminder emails to assigned volunteers
+			runWithRetry("reminder_emails", runReminderEmails)
 		case <-stop:
 			log.Println("Stopping reminder emails")
 			return
 		}
 	}
 }
+
+// runReminderEmails sends reminders for shifts starting in the next 24 hours
+func runReminderEmails() error {
+	sent, err := services.NewShiftReminderService().SendDue(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to send shift reminders: %w", err)
+	}
+	if sent > 0 {
+		log.Printf("Sent %d shift reminders", sent)
+	}
+	return nil
+}
+
+// scheduleCalloutExpiry closes emergency call-outs that were not filled in time
+func scheduleCalloutExpiry(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting emergency call-out expiry at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("callout_expiry", runCalloutExpiry)
+		case <-stop:
+			log.Println("Stopping emergency call-out expiry")
+			return
+		}
+	}
+}
+
+// runCalloutExpiry closes emergency call-outs past their deadline
+func runCalloutExpiry() error {
+	expired, err := services.NewEmergencyCalloutService().ExpireCallouts()
+	if err != nil {
+		return fmt.Errorf("failed to expire emergency call-outs: %w", err)
+	}
+	if expired > 0 {
+		log.Printf("Expired %d emergency call-outs", expired)
+	}
+	return nil
+}
+
+// scheduleStandbyRelease offers unused same-day capacity to the standby list once the
+// daily cutoff has passed, and logs how many offers are being converted to tickets
+func scheduleStandbyRelease(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting standby capacity release at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("standby_release", runStandbyRelease)
+		case <-stop:
+			log.Println("Stopping standby capacity release")
+			return
+		}
+	}
+}
+
+// runStandbyRelease performs one standby release and logs the results
+func runStandbyRelease() error {
+	standbyService := services.NewStandbyService()
+	now := time.Now()
+
+	releases, err := standbyService.RunRelease(now)
+	if err != nil {
+		return fmt.Errorf("failed to release same-day capacity: %w", err)
+	}
+	if len(releases) == 0 {
+		return nil
+	}
+
+	for _, release := range releases {
+		log.Printf("Standby release for %s: %d bookings released, %d waitlisted, %d offers sent, %d offers expired, %d places left",
+			release.Category, release.ReleasedSlots, release.WaitlistAdded, release.OffersSent, release.OffersExpired, release.AvailableSlots)
+	}
+
+	conversions, err := standbyService.ConversionStats(now.Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("failed to calculate standby conversion: %w", err)
+	}
+	for _, stats := range conversions {
+		log.Printf("Standby conversion for %s today: %d of %d offers claimed (%.1f%%)",
+			stats.Category, stats.Claimed, stats.Offered, stats.ConversionRate)
+	}
+	return nil
+}
+
+// scheduleCampaignOutbox sends queued campaign messages from the outbox
+func scheduleCampaignOutbox(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting campaign outbox at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("campaign_outbox", runCampaignOutbox)
+		case <-stop:
+			log.Println("Stopping campaign outbox")
+			return
+		}
+	}
+}
+
+// runCampaignOutbox sends the campaign messages waiting in the outbox
+func runCampaignOutbox() error {
+	sent, err := services.NewCampaignService().ProcessOutbox(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to process campaign outbox: %w", err)
+	}
+	if sent > 0 {
+		log.Printf("Campaign outbox sent %d messages", sent)
+	}
+	return nil
+}
+
+// scheduleDocumentExpiry warns volunteers about documents that are about to expire
+func scheduleDocumentExpiry(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting volunteer document expiry notices at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("document_expiry", runDocumentExpiry)
+		case <-stop:
+			log.Println("Stopping volunteer document expiry notices")
+			return
+		}
+	}
+}
+
+// runDocumentExpiry warns volunteers whose documents expire soon
+func runDocumentExpiry() error {
+	sent, err := services.NewVolunteerDocumentService().SendExpiryNotices(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to send document expiry notices: %w", err)
+	}
+	if sent > 0 {
+		log.Printf("Sent %d volunteer document expiry notices", sent)
+	}
+	return nil
+}
+
+// scheduleReverification flags visitors due to re-verify their documents and restricts
+// bookings once the grace period ends
+func scheduleReverification(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting visitor re-verification at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("visitor_reverification", runReverification)
+		case <-stop:
+			log.Println("Stopping visitor re-verification")
+			return
+		}
+	}
+}
+
+// runReverification moves visitors through the re-verification cadence
+func runReverification() error {
+	run, err := services.NewVisitorReverificationService().Run(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to run visitor re-verification: %w", err)
+	}
+	if run.Flagged > 0 || run.Restricted > 0 || run.Completed > 0 {
+		log.Printf("Visitor re-verification: %d flagged, %d restricted, %d completed", run.Flagged, run.Restricted, run.Completed)
+	}
+	return nil
+}
+
+// scheduleServiceTimeAlerts alerts the floor when visitors wait or are served for
+// longer than their service type's targets
+func scheduleServiceTimeAlerts(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting service time alerts at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("service_time_alerts", runServiceTimeAlerts)
+		case <-stop:
+			log.Println("Stopping service time alerts")
+			return
+		}
+	}
+}
+
+// runServiceTimeAlerts raises alerts for waits and services running over target
+func runServiceTimeAlerts() error {
+	raised, err := services.NewServiceTimeService().CheckServiceTimes(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to check service times: %w", err)
+	}
+	if raised > 0 {
+		log.Printf("Raised %d service time alerts", raised)
+	}
+	return nil
+}
+
+// scheduleAnalyticsExport captures anonymized domain events and exports them to the
+// configured analytics sink
+func scheduleAnalyticsExport(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting analytics event export at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("analytics_export", runAnalyticsExport)
+		case <-stop:
+			log.Println("Stopping analytics event export")
+			return
+		}
+	}
+}
+
+// runAnalyticsExport exports analytics events captured since the last export
+func runAnalyticsExport() error {
+	exporter, err := services.NewAnalyticsExportService()
+	if err != nil {
+		// Retrying will not fix the configuration
+		log.Printf("Analytics export is misconfigured: %v", err)
+		return nil
+	}
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to export analytics events: %w", err)
+	}
+	if result.Exported > 0 {
+		log.Printf("Exported %d analytics events in %d batches", result.Exported, len(result.Batches))
+	}
+	return nil
+}
+
+// scheduleApplicationRetention anonymizes rejected and withdrawn volunteer
+// applications once their retention period has passed
+func scheduleApplicationRetention(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting volunteer application anonymization at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("application_retention", runApplicationRetention)
+		case <-stop:
+			log.Println("Stopping volunteer application anonymization")
+			return
+		}
+	}
+}
+
+// runApplicationRetention anonymizes volunteer applications past their retention period
+func runApplicationRetention() error {
+	anonymized, err := services.NewApplicationRetentionService().AnonymizeDue(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to anonymize volunteer applications: %w", err)
+	}
+	if anonymized > 0 {
+		log.Printf("Anonymized %d volunteer applications past their retention period", anonymized)
+	}
+	return nil
+}
+
+// scheduleSLAAlerts alerts admins when rolling help request SLA compliance drops
+// below the configured threshold
+func scheduleSLAAlerts(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting help request SLA alerts at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("sla_alerts", runSLAAlerts)
+		case <-stop:
+			log.Println("Stopping help request SLA alerts")
+			return
+		}
+	}
+}
+
+// runSLAAlerts checks rolling SLA compliance and alerts admins when it drops
+func runSLAAlerts() error {
+	alerted, err := services.NewRequestSLAService().CheckCompliance(time.Now())
+	for _, metric := range alerted {
+		log.Printf("Help request SLA alert: %s compliance %.1f%%", metric.Metric, metric.Compliance)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check help request SLA compliance: %w", err)
+	}
+	return nil
+}
+
+// scheduleQueueFairness watches for sudden spikes in queue waits and sends the weekly
+// queue fairness report to trustees on Monday mornings
+func scheduleQueueFairness(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting queue wait anomaly detection at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("queue_fairness", runQueueFairness)
+		case <-stop:
+			log.Println("Stopping queue wait anomaly detection")
+			return
+		}
+	}
+}
+
+// runQueueFairness checks for queue wait spikes and sends the weekly fairness report when due
+func runQueueFairness() error {
+	fairness := services.NewQueueFairnessService()
+	anomalies, anomalyErr := fairness.DetectAnomalies(time.Now())
+	if anomalyErr != nil {
+		anomalyErr = fmt.Errorf("failed to check queue waits for spikes: %w", anomalyErr)
+	}
+	for _, anomaly := range anomalies {
+		log.Printf("Queue wait spike: %s averaging %.0f minutes against %.0f", anomaly.Category, anomaly.AverageWaitMinutes, anomaly.BaselineMinutes)
+	}
+
+	// The weekly report does not depend on the spike check, so try it either way
+	report, err := fairness.EnsureWeeklyReport(time.Now())
+	if err != nil {
+		return errors.Join(anomalyErr, fmt.Errorf("failed to send the weekly queue fairness report: %w", err))
+	}
+	if report != nil {
+		log.Printf("Sent the queue fairness report for the week of %s to %d recipients", report.WeekStart, report.EmailedTo)
+	}
+	return anomalyErr
+}
+
+// scheduleVolunteerStatements generates and emails last month's hour statements to
+// volunteers early on the 1st of each month
+func scheduleVolunteerStatements(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting monthly volunteer statements at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("volunteer_statements", runVolunteerStatements)
+		case <-stop:
+			log.Println("Stopping monthly volunteer statements")
+			return
+		}
+	}
+}
+
+// runVolunteerStatements generates and emails any monthly hour statements not yet sent
+func runVolunteerStatements() error {
+	generated, err := services.NewVolunteerStatementService().EnsureMonthlyStatements(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to generate volunteer statements: %w", err)
+	}
+	if generated > 0 {
+		log.Printf("Generated %d volunteer statements", generated)
+	}
+	return nil
+}
+
+// scheduleAppointmentReminders reminds visitors of advice appointments in the next
+// 24 hours
+func scheduleAppointmentReminders(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting appointment reminders at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("appointment_reminders", runAppointmentReminders)
+		case <-stop:
+			log.Println("Stopping appointment reminders")
+			return
+		}
+	}
+}
+
+// runAppointmentReminders reminds visitors of tomorrow's advice appointments
+func runAppointmentReminders() error {
+	sent, err := services.NewAppointmentService().SendReminders(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to send appointment reminders: %w", err)
+	}
+	if sent > 0 {
+		log.Printf("Sent %d appointment reminders", sent)
+	}
+	return nil
+}
+
+// scheduleChangeReports emails trustees last week's summary of administrative changes
+// on Monday mornings
+func scheduleChangeReports(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting weekly administrative change reports at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("admin_change_reports", runChangeReports)
+		case <-stop:
+			log.Println("Stopping weekly administrative change reports")
+			return
+		}
+	}
+}
+
+// runChangeReports sends the weekly administrative change report when due
+func runChangeReports() error {
+	report, err := services.NewAdminChangeReportService().EnsureWeeklyReport(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to send the weekly change report: %w", err)
+	}
+	if report != nil {
+		log.Printf("Sent the change report for the week of %s to %d recipients", report.WeekStart, report.EmailedTo)
+	}
+	return nil
+}
+
+// scheduleOverrideExpiry takes back the slots of capacity overrides whose time is up
+func scheduleOverrideExpiry(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting capacity override expiry at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("capacity_override_expiry", runOverrideExpiry)
+		case <-stop:
+			log.Println("Stopping capacity override expiry")
+			return
+		}
+	}
+}
+
+// runOverrideExpiry takes back the slots of expired capacity overrides
+func runOverrideExpiry() error {
+	expired, err := services.NewCapacityOverrideService().ExpireDue(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to expire capacity overrides: %w", err)
+	}
+	if expired > 0 {
+		log.Printf("Expired %d capacity overrides", expired)
+	}
+	return nil
+}
+
+// scheduleMissedCalls moves called visitors who have not come forward within the grace
+// period to the re-call pool, or marks them as no-shows, and calls the next visitor
+func scheduleMissedCalls(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting queue missed call handling at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("queue_missed_calls", runMissedCalls)
+		case <-stop:
+			log.Println("Stopping queue missed call handling")
+			return
+		}
+	}
+}
+
+// runMissedCalls moves called visitors who did not arrive to the re-call pool
+func runMissedCalls() error {
+	outcomes, err := services.NewQueueRecallService().ExpireCalls(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to check for missed queue calls: %w", err)
+	}
+	if len(outcomes) > 0 {
+		log.Printf("Handled %d missed queue calls", len(outcomes))
+	}
+	return nil
+}
+
+// scheduleShiftFeedbackPrompts asks volunteers how their shift went once it has ended
+func scheduleShiftFeedbackPrompts(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting shift feedback prompts at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("shift_feedback_prompts", runShiftFeedbackPrompts)
+		case <-stop:
+			log.Println("Stopping shift feedback prompts")
+			return
+		}
+	}
+}
+
+// runShiftFeedbackPrompts asks volunteers for feedback on shifts that have ended
+func runShiftFeedbackPrompts() error {
+	prompted, err := services.NewShiftFeedbackService().PromptDue(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to send shift feedback prompts: %w", err)
+	}
+	if prompted > 0 {
+		log.Printf("Asked %d volunteers for shift feedback", prompted)
+	}
+	return nil
+}
+
+// scheduleAccountErasure erases visitor accounts whose deletion grace period has ended
+func scheduleAccountErasure(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting account erasure at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("account_erasure", runAccountErasure)
+		case <-stop:
+			log.Println("Stopping account erasure")
+			return
+		}
+	}
+}
+
+// runAccountErasure erases accounts whose deletion grace period has ended
+func runAccountErasure() error {
+	erased, err := services.NewAccountDeletionService().EraseDue(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to erase deleted accounts: %w", err)
+	}
+	if erased > 0 {
+		log.Printf("Erased %d accounts after their deletion grace period", erased)
+	}
+	return nil
+}
+
+// scheduleSystemAlerts raises and resolves the admin dashboard's system alerts
+func scheduleSystemAlerts(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting system alert evaluation at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	runWithRetry("system_alerts", runSystemAlerts)
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("system_alerts", runSystemAlerts)
+		case <-stop:
+			log.Println("Stopping system alert evaluation")
+			return
+		}
+	}
+}
+
+// runSystemAlerts checks the alert rules
+func runSystemAlerts() error {
+	raised, resolved, err := services.NewSystemAlertService().Evaluate(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to evaluate system alerts: %w", err)
+	}
+	if raised > 0 || resolved > 0 {
+		log.Printf("Raised %d and resolved %d system alerts", raised, resolved)
+	}
+	return nil
+}
+
+// scheduleNotificationDigests sends daily and weekly notification digests once due
+func scheduleNotificationDigests(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting notification digests at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("notification_digests", runNotificationDigests)
+		case <-stop:
+			log.Println("Stopping notification digests")
+			return
+		}
+	}
+}
+
+// runNotificationDigests emails the users whose digest is due
+func runNotificationDigests() error {
+	sent, err := services.NewNotificationDigestService().SendDue(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to send notification digests: %w", err)
+	}
+	if sent > 0 {
+		log.Printf("Sent %d notification digests", sent)
+	}
+	return nil
+}
+
+// scheduleKPIAnomalies checks the last complete day's KPIs against their normal ranges
+func scheduleKPIAnomalies(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting KPI anomaly detection at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("kpi_anomalies", runKPIAnomalies)
+		case <-stop:
+			log.Println("Stopping KPI anomaly detection")
+			return
+		}
+	}
+}
+
+// runKPIAnomalies raises alerts for KPIs outside their normal range
+func runKPIAnomalies() error {
+	detected, err := services.NewKPIAnomalyService().Detect(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to detect KPI anomalies: %w", err)
+	}
+	if len(detected) > 0 {
+		log.Printf("Raised %d KPI anomaly alerts", len(detected))
+	}
+	return nil
+}
+
+// scheduleDraftExpiry ends help request drafts that have not been saved for a week
+func scheduleDraftExpiry(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting help request draft expiry at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("help_request_draft_expiry", runDraftExpiry)
+		case <-stop:
+			log.Println("Stopping help request draft expiry")
+			return
+		}
+	}
+}
+
+// runDraftExpiry expires stale help request drafts, clearing their answers
+func runDraftExpiry() error {
+	expired, err := services.NewHelpRequestDraftService().ExpireDrafts(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to expire help request drafts: %w", err)
+	}
+	if expired > 0 {
+		log.Printf("Expired %d help request drafts", expired)
+	}
+	return nil
+}
+
+// scheduleTicketRelease checks every minute whether a ticket release slot has come.
+// Admins pause or move the slots; the interval only sets how promptly one is noticed.
+func scheduleTicketRelease(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting scheduled ticket release checks at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("ticket_release", runTicketRelease)
+		case <-stop:
+			log.Println("Stopping scheduled ticket release")
+			return
+		}
+	}
+}
+
+// runTicketRelease releases tickets when a scheduled slot is due
+func runTicketRelease() error {
+	run, err := services.NewTicketReleaseScheduleService().RunDue(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to run the scheduled ticket release: %w", err)
+	}
+	if run != nil {
+		log.Printf("Scheduled ticket release for %s: %s, %d tickets issued", run.ReleaseDate, run.Status, run.TotalReleased)
+	}
+	return nil
+}
+
+// scheduleResearchDatasets makes the anonymised research dataset once each week has
+// ended; the interval only sets how soon after the week it appears
+func scheduleResearchDatasets(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting research dataset export at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("research_dataset_export", runResearchDatasets)
+		case <-stop:
+			log.Println("Stopping research dataset export")
+			return
+		}
+	}
+}
+
+// runResearchDatasets generates the research dataset for the week just ended
+func runResearchDatasets() error {
+	export, err := services.NewResearchDatasetService().RunDue(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to generate the research dataset: %w", err)
+	}
+	if export != nil {
+		log.Printf("Generated research dataset %d: %d rows, %d requests suppressed", export.ID, export.Rows, export.SuppressedRecords)
+	}
+	return nil
+}
+
+// scheduleClosureReopening hands what was received during a closure to staff once the
+// service reopens
+func scheduleClosureReopening(interval time.Duration, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	log.Printf("Starting closure reopening at %s intervals", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runWithRetry("closure_reopening", runClosureReopening)
+		case <-stop:
+			log.Println("Stopping closure reopening")
+			return
+		}
+	}
+}
+
+// runClosureReopening releases the queues of closures that have ended
+func runClosureReopening() error {
+	closures, err := services.NewServiceClosureService().ReleaseDue(time.Now())
+	for _, closure := range closures {
+		log.Printf("Released the queue of closure %d (%s) to staff", closure.ID, closure.Title)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release closure queues: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+)
+
+// runExclusive runs a scheduled job only if no other instance is running it. Every
+// instance starts the same tickers, so each run takes a Postgres advisory lock named
+// after the job and is skipped when another instance holds it. Without Postgres the
+// job simply runs. It reports whether the job ran.
+func runExclusive(name string, run func()) bool {
+	if db.DB == nil || db.DB.Dialector.Name() != "postgres" {
+		run()
+		return true
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		log.Printf("Skipping %s: %v", name, err)
+		return false
+	}
+
+	// Session locks belong to a connection, so hold one for the whole run
+	ctx := context.Background()
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		log.Printf("Skipping %s: %v", name, err)
+		return false
+	}
+	defer conn.Close()
+
+	key := "job:" + name
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", key).Scan(&locked); err != nil {
+		log.Printf("Skipping %s: failed to take the job lock: %v", name, err)
+		return false
+	}
+	if !locked {
+		return false
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtext($1))", key); err != nil {
+			log.Printf("Failed to release the %s job lock: %v", name, err)
+		}
+	}()
+
+	run()
+	return true
+}
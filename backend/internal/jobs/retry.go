@@ -0,0 +1,316 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultJobMaxAttempts is how many failed runs in a row dead-letter a job
+	defaultJobMaxAttempts = 3
+	// defaultJobRetryBackoff is the wait before the first retry; it doubles on each
+	// retry up to maxJobRetryBackoff
+	defaultJobRetryBackoff = 30 * time.Second
+	maxJobRetryBackoff     = 30 * time.Minute
+	// deadLetterKey is the Redis list dead-lettered runs are kept in, newest first
+	deadLetterKey  = "jobs:dead_letter"
+	maxDeadLetters = 500
+)
+
+var ErrDeadLetterNotFound = errors.New("dead-lettered job not found")
+
+// RetryPolicy is how a failed job is retried
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// jobRetryPolicies override the default policy for jobs that need it
+var jobRetryPolicies = map[string]RetryPolicy{
+	// The warehouse may be down for a while, so keep trying for longer
+	"analytics_export": {MaxAttempts: 5, Backoff: 5 * time.Minute},
+	// These run every minute, so the next tick is retry enough
+	"queue_missed_calls": {MaxAttempts: 3, Backoff: time.Minute},
+	"system_alerts":      {MaxAttempts: 3, Backoff: time.Minute},
+	"ticket_release":     {MaxAttempts: 3, Backoff: time.Minute},
+}
+
+// DeadLetter is a job run that failed every attempt
+type DeadLetter struct {
+	ID            string    `json:"id"`
+	Job           string    `json:"job"`
+	Error         string    `json:"error"`
+	Attempts      int       `json:"attempts"`
+	FirstFailedAt time.Time `json:"first_failed_at"`
+	LastFailedAt  time.Time `json:"last_failed_at"`
+}
+
+// jobFailure tracks a job's failed runs since it last succeeded
+type jobFailure struct {
+	attempts      int
+	firstFailedAt time.Time
+	retry         *time.Timer
+}
+
+var (
+	jobFailures  = map[string]*jobFailure{}
+	failureMutex sync.Mutex
+
+	// In-memory fallback for when Redis is unavailable
+	inMemoryDeadLetters []DeadLetter
+	deadLetterMutex     sync.Mutex
+)
+
+// retryPolicy returns a job's retry policy. JOB_MAX_ATTEMPTS and
+// JOB_RETRY_BACKOFF_SECONDS change the default for jobs without their own.
+func retryPolicy(name string) RetryPolicy {
+	if policy, ok := jobRetryPolicies[name]; ok {
+		return policy
+	}
+	policy := RetryPolicy{MaxAttempts: defaultJobMaxAttempts, Backoff: defaultJobRetryBackoff}
+	if attempts, err := strconv.Atoi(os.Getenv("JOB_MAX_ATTEMPTS")); err == nil && attempts > 0 {
+		policy.MaxAttempts = attempts
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("JOB_RETRY_BACKOFF_SECONDS")); err == nil && seconds > 0 {
+		policy.Backoff = time.Duration(seconds) * time.Second
+	}
+	return policy
+}
+
+// retryDelay is the wait before the retry that follows a job's nth failed run
+func retryDelay(policy RetryPolicy, attempts int) time.Duration {
+	delay := policy.Backoff
+	for i := 1; i < attempts && delay < maxJobRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxJobRetryBackoff {
+		delay = maxJobRetryBackoff
+	}
+	return delay
+}
+
+// runWithRetry runs a scheduled job under its lock. A failed run is retried with
+// exponential backoff, and once the job has failed as many times in a row as its
+// policy allows it is dead-lettered for an admin to look at. It reports whether the
+// job ran.
+func runWithRetry(name string, run func() error) bool {
+	var runErr error
+	if !runExclusive(name, func() { runErr = run() }) {
+		return false
+	}
+	recordJobOutcome(name, run, runErr, time.Now())
+	return true
+}
+
+// recordJobOutcome clears a job's failures when it succeeds, and otherwise schedules
+// a retry or dead-letters it
+func recordJobOutcome(name string, run func() error, runErr error, now time.Time) {
+	failureMutex.Lock()
+	defer failureMutex.Unlock()
+
+	failure := jobFailures[name]
+	if runErr == nil {
+		if failure != nil {
+			if failure.retry != nil {
+				failure.retry.Stop()
+			}
+			delete(jobFailures, name)
+			log.Printf("Job %s succeeded after %d failed attempts", name, failure.attempts)
+		}
+		return
+	}
+
+	if failure == nil {
+		failure = &jobFailure{firstFailedAt: now}
+		jobFailures[name] = failure
+	}
+	failure.attempts++
+	policy := retryPolicy(name)
+	log.Printf("Job %s failed (attempt %d of %d): %v", name, failure.attempts, policy.MaxAttempts, runErr)
+
+	if failure.attempts >= policy.MaxAttempts {
+		if failure.retry != nil {
+			failure.retry.Stop()
+		}
+		delete(jobFailures, name)
+		letter := DeadLetter{
+			ID:            newDeadLetterID(),
+			Job:           name,
+			Error:         runErr.Error(),
+			Attempts:      failure.attempts,
+			FirstFailedAt: failure.firstFailedAt,
+			LastFailedAt:  now,
+		}
+		if err := pushDeadLetter(letter); err != nil {
+			log.Printf("Failed to dead-letter job %s: %v", name, err)
+			return
+		}
+		log.Printf("Dead-lettered job %s after %d attempts", name, letter.Attempts)
+		return
+	}
+
+	// A scheduled run may fail while a retry is already waiting
+	if failure.retry != nil {
+		return
+	}
+	delay := retryDelay(policy, failure.attempts)
+	failure.retry = time.AfterFunc(delay, func() {
+		failureMutex.Lock()
+		if current := jobFailures[name]; current != nil {
+			current.retry = nil
+		}
+		failureMutex.Unlock()
+		runWithRetry(name, run)
+	})
+	log.Printf("Retrying job %s in %s", name, delay)
+}
+
+// stopRetries cancels retries that have not started yet
+func stopRetries() {
+	failureMutex.Lock()
+	defer failureMutex.Unlock()
+	for _, failure := range jobFailures {
+		if failure.retry != nil {
+			failure.retry.Stop()
+			failure.retry = nil
+		}
+	}
+}
+
+// DeadLetters lists dead-lettered job runs, newest first
+func DeadLetters() ([]DeadLetter, error) {
+	if RedisClient != nil {
+		values, err := RedisClient.LRange(context.Background(), deadLetterKey, 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		letters := make([]DeadLetter, 0, len(values))
+		for _, value := range values {
+			var letter DeadLetter
+			if err := json.Unmarshal([]byte(value), &letter); err != nil {
+				log.Printf("Skipping unreadable dead letter: %v", err)
+				continue
+			}
+			letters = append(letters, letter)
+		}
+		return letters, nil
+	}
+
+	deadLetterMutex.Lock()
+	defer deadLetterMutex.Unlock()
+	return append([]DeadLetter{}, inMemoryDeadLetters...), nil
+}
+
+// RetryDeadLetter runs a dead-lettered job again now. The dead letter is removed when
+// the run succeeds and kept, with the new error, when it fails.
+func RetryDeadLetter(id string) (*DeadLetter, error) {
+	letter, err := DiscardDeadLetter(id)
+	if err != nil {
+		return nil, err
+	}
+	run, ok := jobRunners[letter.Job]
+	if !ok {
+		if err := pushDeadLetter(*letter); err != nil {
+			log.Printf("Failed to dead-letter job %s again: %v", letter.Job, err)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJob, letter.Job)
+	}
+
+	var runErr error
+	if !runExclusive(letter.Job, func() { runErr = run() }) {
+		if err := pushDeadLetter(*letter); err != nil {
+			log.Printf("Failed to dead-letter job %s again: %v", letter.Job, err)
+		}
+		return nil, ErrJobBusy
+	}
+	if runErr != nil {
+		letter.Attempts++
+		letter.Error = runErr.Error()
+		letter.LastFailedAt = time.Now()
+		if err := pushDeadLetter(*letter); err != nil {
+			log.Printf("Failed to dead-letter job %s again: %v", letter.Job, err)
+		}
+		return letter, fmt.Errorf("%w: %v", ErrJobFailed, runErr)
+	}
+	return letter, nil
+}
+
+// DiscardDeadLetter removes a dead-lettered job run and returns it
+func DiscardDeadLetter(id string) (*DeadLetter, error) {
+	if RedisClient != nil {
+		ctx := context.Background()
+		values, err := RedisClient.LRange(ctx, deadLetterKey, 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			var letter DeadLetter
+			if json.Unmarshal([]byte(value), &letter) != nil || letter.ID != id {
+				continue
+			}
+			removed, err := RedisClient.LRem(ctx, deadLetterKey, 1, value).Result()
+			if err != nil {
+				return nil, err
+			}
+			if removed == 0 {
+				// Another instance got there first
+				return nil, ErrDeadLetterNotFound
+			}
+			return &letter, nil
+		}
+		return nil, ErrDeadLetterNotFound
+	}
+
+	deadLetterMutex.Lock()
+	defer deadLetterMutex.Unlock()
+	for i, letter := range inMemoryDeadLetters {
+		if letter.ID == id {
+			inMemoryDeadLetters = append(inMemoryDeadLetters[:i], inMemoryDeadLetters[i+1:]...)
+			return &letter, nil
+		}
+	}
+	return nil, ErrDeadLetterNotFound
+}
+
+// pushDeadLetter adds a dead letter to the front of the list, dropping the oldest
+// beyond maxDeadLetters
+func pushDeadLetter(letter DeadLetter) error {
+	if RedisClient != nil {
+		value, err := json.Marshal(letter)
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		pipe := RedisClient.TxPipeline()
+		pipe.LPush(ctx, deadLetterKey, value)
+		pipe.LTrim(ctx, deadLetterKey, 0, maxDeadLetters-1)
+		_, err = pipe.Exec(ctx)
+		return err
+	}
+
+	deadLetterMutex.Lock()
+	defer deadLetterMutex.Unlock()
+	inMemoryDeadLetters = append([]DeadLetter{letter}, inMemoryDeadLetters...)
+	if len(inMemoryDeadLetters) > maxDeadLetters {
+		inMemoryDeadLetters = inMemoryDeadLetters[:maxDeadLetters]
+	}
+	return nil
+}
+
+// newDeadLetterID returns a random ID for a dead letter
+func newDeadLetterID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, Backoff: 30 * time.Second}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{6, 16 * time.Minute},
+		{7, maxJobRetryBackoff},
+		{20, maxJobRetryBackoff},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(policy, tt.attempts); got != tt.want {
+			t.Errorf("retryDelay after %d attempts = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Setenv("JOB_MAX_ATTEMPTS", "4")
+	t.Setenv("JOB_RETRY_BACKOFF_SECONDS", "10")
+	if got := retryPolicy("reminder_emails"); got.MaxAttempts != 4 || got.Backoff != 10*time.Second {
+		t.Errorf("default policy = %+v, want 4 attempts at 10s", got)
+	}
+	// Jobs with their own policy ignore the defaults
+	if got := retryPolicy("analytics_export"); got != jobRetryPolicies["analytics_export"] {
+		t.Errorf("analytics_export policy = %+v", got)
+	}
+}
+
+func TestRecordJobOutcomeDeadLetters(t *testing.T) {
+	t.Setenv("JOB_MAX_ATTEMPTS", "2")
+	t.Setenv("JOB_RETRY_BACKOFF_SECONDS", "3600")
+	RedisClient = nil
+	inMemoryDeadLetters = nil
+	defer stopRetries()
+
+	run := func() error { return nil }
+	failed := errors.New("database unavailable")
+	first := time.Date(2025, 10, 14, 9, 0, 0, 0, time.UTC)
+
+	// A success clears earlier failures
+	recordJobOutcome("test_job", run, failed, first)
+	recordJobOutcome("test_job", run, nil, first.Add(time.Minute))
+	if _, ok := jobFailures["test_job"]; ok {
+		t.Fatal("expected a success to clear the failures")
+	}
+
+	recordJobOutcome("test_job", run, failed, first)
+	if jobFailures["test_job"] == nil || jobFailures["test_job"].retry == nil {
+		t.Fatal("expected a retry to be scheduled after the first failure")
+	}
+	recordJobOutcome("test_job", run, failed, first.Add(time.Hour))
+
+	letters, err := DeadLetters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("got %d dead letters, want 1", len(letters))
+	}
+	letter := letters[0]
+	if letter.Job != "test_job" || letter.Attempts != 2 || letter.Error != failed.Error() ||
+		!letter.FirstFailedAt.Equal(first) || !letter.LastFailedAt.Equal(first.Add(time.Hour)) {
+		t.Errorf("unexpected dead letter %+v", letter)
+	}
+	if _, ok := jobFailures["test_job"]; ok {
+		t.Error("expected dead-lettering to reset the failures")
+	}
+
+	if _, err := DiscardDeadLetter(letter.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DiscardDeadLetter(letter.ID); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("discarding twice: got %v, want ErrDeadLetterNotFound", err)
+	}
+}
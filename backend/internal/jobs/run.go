@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrUnknownJob = errors.New("unknown job")
+	ErrJobBusy    = errors.New("job is already running on another instance")
+	ErrJobFailed  = errors.New("job failed")
+)
+
+// jobRunners are the scheduled jobs that can be run on demand, by the name of their lock
+var jobRunners = map[string]func() error{
+	"inventory_checks":          runInventoryCheck,
+	"reminder_emails":           runReminderEmails,
+	"callout_expiry":            runCalloutExpiry,
+	"standby_release":           runStandbyRelease,
+	"campaign_outbox":           runCampaignOutbox,
+	"document_expiry":           runDocumentExpiry,
+	"visitor_reverification":    runReverification,
+	"service_time_alerts":       runServiceTimeAlerts,
+	"analytics_export":          runAnalyticsExport,
+	"application_retention":     runApplicationRetention,
+	"sla_alerts":                runSLAAlerts,
+	"queue_fairness":            runQueueFairness,
+	"volunteer_statements":      runVolunteerStatements,
+	"appointment_reminders":     runAppointmentReminders,
+	"admin_change_reports":      runChangeReports,
+	"capacity_override_expiry":  runOverrideExpiry,
+	"queue_missed_calls":        runMissedCalls,
+	"shift_feedback_prompts":    runShiftFeedbackPrompts,
+	"account_erasure":           runAccountErasure,
+	"system_alerts":             runSystemAlerts,
+	"notification_digests":      runNotificationDigests,
+	"kpi_anomalies":             runKPIAnomalies,
+	"help_request_draft_expiry": runDraftExpiry,
+	"ticket_release":            runTicketRelease,
+	"research_dataset_export":   runResearchDatasets,
+	"closure_reopening":         runClosureReopening,
+}
+
+// JobNames lists the jobs that can be run on demand
+func JobNames() []string {
+	names := make([]string, 0, len(jobRunners))
+	for name := range jobRunners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunJob runs one round of a scheduled job now, such as after a failed run. It takes
+// the same lock as the scheduler, so it never overlaps a scheduled run.
+func RunJob(name string) error {
+	run, ok := jobRunners[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+	var runErr error
+	if !runExclusive(name, func() { runErr = run() }) {
+		return ErrJobBusy
+	}
+	if runErr != nil {
+		return fmt.Errorf("%w: %v", ErrJobFailed, runErr)
+	}
+	return nil
+}
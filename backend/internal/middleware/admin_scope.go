@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminModules maps the first path segment under /api/v1/admin to its module.
+// Segments not listed here are only open to full admins.
+var adminModules = map[string]string{
+	"dashboard":           models.AdminModuleDashboard,
+	"activity":            models.AdminModuleDashboard,
+	"notifications":       models.AdminModuleDashboard,
+	"analytics":           models.AdminModuleAnalytics,
+	"reports":             models.AdminModuleReports,
+	"impact":              models.AdminModuleReports,
+	"users":               models.AdminModuleUsers,
+	"staff":               models.AdminModuleStaff,
+	"volunteers":          models.AdminModuleVolunteers,
+	"shifts":              models.AdminModuleVolunteers,
+	"volunteer-teams":     models.AdminModuleVolunteers,
+	"kudos":               models.AdminModuleVolunteers,
+	"carpool":             models.AdminModuleVolunteers,
+	"queue":               models.AdminModuleVisitorServices,
+	"checkin":             models.AdminModuleVisitorServices,
+	"walk-ins":            models.AdminModuleVisitorServices,
+	"standby":             models.AdminModuleVisitorServices,
+	"appointments":        models.AdminModuleVisitorServices,
+	"capacity":            models.AdminModuleVisitorServices,
+	"help-requests":       models.AdminModuleVisitorServices,
+	"service-types":       models.AdminModuleVisitorServices,
+	"operations":          models.AdminModuleVisitorServices,
+	"documents":           models.AdminModuleDocuments,
+	"donations":           models.AdminModuleDonations,
+	"pledges":             models.AdminModuleDonations,
+	"drives":              models.AdminModuleDonations,
+	"urgent-needs":        models.AdminModuleDonations,
+	"inventory":           models.AdminModuleDonations,
+	"bank-reconciliation": models.AdminModuleFinance,
+	"suppliers":           models.AdminModuleFinance,
+	"supplier-orders":     models.AdminModuleFinance,
+	"payments":            models.AdminModuleFinance,
+	"communications":      models.AdminModuleCommunications,
+	"announcements":       models.AdminModuleCommunications,
+	"campaigns":           models.AdminModuleCommunications,
+	"templates":           models.AdminModuleCommunications,
+	"emergency":           models.AdminModuleEmergency,
+	"feedback":            models.AdminModuleFeedback,
+	"bulk-operations":     models.AdminModuleBulk,
+	"import":              models.AdminModuleBulk,
+	"audit":               models.AdminModuleAudit,
+	"audit-logs":          models.AdminModuleAudit,
+	"history":             models.AdminModuleAudit,
+	"tags":                models.AdminModuleReports,
+	"system":              models.AdminModuleSystem,
+	"alerts":              models.AdminModuleSystem,
+	"performance":         models.AdminModuleSystem,
+}
+
+// adminRoutesOutsideAdmin maps admin-only routes that live outside /api/v1/admin
+// to their module
+var adminRoutesOutsideAdmin = map[string]string{
+	"/ws/admin/queue":               models.AdminModuleVisitorServices,
+	"/ws/admin/queue/display":       models.AdminModuleVisitorServices,
+	"/api/v1/staff/queue/call-next": models.AdminModuleVisitorServices,
+	"/api/v1/staff/queue/dashboard": models.AdminModuleVisitorServices,
+	"/api/v1/staff/queue/display":   models.AdminModuleVisitorServices,
+}
+
+// adminReadOnlyPosts are POST routes that only read data, such as report generation
+var adminReadOnlyPosts = map[string]bool{
+	"/api/v1/admin/reports/custom": true,
+}
+
+// AdminModuleForPath returns the module an admin route belongs to
+func AdminModuleForPath(path string) string {
+	rest := strings.TrimPrefix(path, "/api/v1/admin/")
+	if rest == path {
+		return adminRoutesOutsideAdmin[path]
+	}
+	segment := strings.SplitN(rest, "/", 2)[0]
+	return adminModules[segment]
+}
+
+// RequireAdminScope limits scoped admins to the modules their scope grants. It runs
+// after RequireAdmin; super admins and admins without a scope are not restricted.
+func RequireAdminScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if role, _ := c.Get("userRole"); role == models.RoleSuperAdmin || role == models.RoleSuperAdminLegacy {
+			c.Next()
+			return
+		}
+
+		var scope string
+		if user, ok := c.Get("user"); ok {
+			if u, ok := user.(models.User); ok {
+				scope = u.AdminScope
+			}
+		} else if userID := utils.GetUserIDFromContext(c); userID != 0 {
+			db.DB.Model(&models.User{}).Where("id = ?", userID).Pluck("admin_scope", &scope)
+		}
+		if scope == "" {
+			c.Next()
+			return
+		}
+
+		path := c.FullPath()
+		write := c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead && !adminReadOnlyPosts[path]
+		module := AdminModuleForPath(path)
+		if module == "" || !models.AdminScopeAllows(scope, module, write) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":       "Your admin role does not include access to this area",
+				"admin_scope": scope,
+				"module":      module,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("adminScope", scope)
+		c.Next()
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scopedRouter mounts a handler behind RequireAdminScope for a user with the given
+// role and scope
+func scopedRouter(method, path, role, scope string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Handle(method, path, func(c *gin.Context) {
+		c.Set("userRole", role)
+		c.Set("user", models.User{Role: role, AdminScope: scope})
+		c.Next()
+	}, RequireAdminScope(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestRequireAdminScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		role   string
+		scope  string
+		want   int
+	}{
+		{"unscoped admin", http.MethodPost, "/api/v1/admin/users", models.RoleAdmin, "", http.StatusOK},
+		{"module read", http.MethodGet, "/api/v1/admin/reports", models.RoleAdmin, models.AdminScopeAnalyticsViewer, http.StatusOK},
+		{"module write on read access", http.MethodPost, "/api/v1/admin/reports", models.RoleAdmin, models.AdminScopeAnalyticsViewer, http.StatusForbidden},
+		{"read-only post", http.MethodPost, "/api/v1/admin/reports/custom", models.RoleAdmin, models.AdminScopeAnalyticsViewer, http.StatusOK},
+		{"module outside scope", http.MethodGet, "/api/v1/admin/donations", models.RoleAdmin, models.AdminScopeVolunteerCoordinator, http.StatusForbidden},
+		{"unmapped module", http.MethodGet, "/api/v1/admin/unknown", models.RoleAdmin, models.AdminScopeFinanceOfficer, http.StatusForbidden},
+		{"staff queue outside scope", http.MethodPost, "/api/v1/staff/queue/call-next", models.RoleAdmin, models.AdminScopeFinanceOfficer, http.StatusForbidden},
+		{"admin websocket outside scope", http.MethodGet, "/ws/admin/queue", models.RoleAdmin, models.AdminScopeAnalyticsViewer, http.StatusForbidden},
+		{"super admin", http.MethodGet, "/api/v1/admin/donations", models.RoleSuperAdmin, models.AdminScopeVolunteerCoordinator, http.StatusOK},
+		{"legacy super admin", http.MethodGet, "/api/v1/admin/donations", models.RoleSuperAdminLegacy, models.AdminScopeVolunteerCoordinator, http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		scopedRouter(tt.method, tt.path, tt.role, tt.scope).
+			ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestAdminModuleForPath(t *testing.T) {
+	if got := AdminModuleForPath("/api/v1/admin/pledges/:id"); got != models.AdminModuleDonations {
+		t.Errorf("pledges: got %q", got)
+	}
+	if got := AdminModuleForPath("/api/v1/staff/queue/dashboard"); got != models.AdminModuleVisitorServices {
+		t.Errorf("staff queue: got %q", got)
+	}
+	if got := AdminModuleForPath("/api/v1/visitor/profile"); got != "" {
+		t.Errorf("non-admin route: got %q", got)
+	}
+}
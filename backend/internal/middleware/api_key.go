@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader carries a partner API key
+const APIKeyHeader = "X-API-Key"
+
+// defaultPartnerRateLimit is the requests per minute allowed for each partner key
+const defaultPartnerRateLimit = 120
+
+// APIKeyAuth authenticates a partner by API key instead of a user login, limits each
+// key to PARTNER_API_RATE_LIMIT requests a minute and counts every request against
+// the key's usage
+func APIKeyAuth() gin.HandlerFunc {
+	limit := defaultPartnerRateLimit
+	if n, err := strconv.Atoi(os.Getenv("PARTNER_API_RATE_LIMIT")); err == nil && n > 0 {
+		limit = n
+	}
+	limiter := NewRateLimiter(limit, time.Minute)
+
+	return func(c *gin.Context) {
+		service := services.NewAPIKeyService()
+		key, err := service.Authenticate(c.GetHeader(APIKeyHeader))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or revoked API key"})
+			c.Abort()
+			return
+		}
+
+		c.Set("apiKeyID", key.ID)
+		c.Set("apiKey", key)
+		defer func() {
+			endpoint := c.Request.Method + " " + c.FullPath()
+			if err := service.RecordUsage(key.ID, endpoint, c.Writer.Status(), time.Now()); err != nil {
+				log.Printf("Failed to record usage for API key %d: %v", key.ID, err)
+			}
+		}()
+
+		allowed, count := limiter.isAllowed("key_" + strconv.FormatUint(uint64(key.ID), 10))
+		remaining := limit - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !allowed {
+			c.Header("Retry-After", "60")
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":   "Rate limit exceeded",
+				"message": "This API key can make " + strconv.Itoa(limit) + " requests per minute",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,35 +1,17 @@
 package middleware
 
 import (
-	"errors"
 	"log"
 	"net/http"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/geoo115/charity-management-system/internal/auth"
 	"github.com/geoo115/charity-management-system/internal/db"
 	"github.com/geoo115/charity-management-system/internal/models"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
-// getJWTSecret returns the JWT secret with proper validation
-func getJWTSecret() ([]byte, error) {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return nil, errors.New("JWT_SECRET environment variable is required")
-	}
-
-	// Ensure minimum security for JWT secret
-	if len(jwtSecret) < 32 {
-		return nil, errors.New("JWT_SECRET must be at least 32 characters for security")
-	}
-
-	return []byte(jwtSecret), nil
-}
-
 // FastAuth provides a simplified authentication middleware for high-load scenarios
 func FastAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -111,6 +93,13 @@ func Auth() gin.HandlerFunc {
 			return
 		}
 
+		// Tokens issued before a forced logout are no longer accepted
+		if err := auth.CheckTokenVersion(claims, user.TokenVersion); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has ended, please sign in again"})
+			c.Abort()
+			return
+		}
+
 		// Store user info in context
 		c.Set("userID", user.ID)
 		c.Set("userRole", user.Role)
@@ -269,6 +258,13 @@ func WebSocketAuth() gin.HandlerFunc {
 			return
 		}
 
+		// Tokens issued before a forced logout are no longer accepted
+		if err := auth.CheckTokenVersion(claims, user.TokenVersion); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has ended, please sign in again"})
+			c.Abort()
+			return
+		}
+
 		// Set user context for handlers
 		c.Set("currentUser", user)
 		c.Set("userID", user.ID)
@@ -341,6 +337,13 @@ func AuthWithQueryParam() gin.HandlerFunc {
 			return
 		}
 
+		// Tokens issued before a forced logout are no longer accepted
+		if err := auth.CheckTokenVersion(claims, user.TokenVersion); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has ended, please sign in again"})
+			c.Abort()
+			return
+		}
+
 		// Store user info in context
 		c.Set("userID", user.ID)
 		c.Set("userRole", user.Role)
@@ -409,49 +412,30 @@ func RoleRequired(allowedRoles ...string) gin.HandlerFunc {
 
 // handleTokenAuth processes the JWT token and sets user info in the context
 func handleTokenAuth(c *gin.Context, tokenString string) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		secret, err := getJWTSecret()
-		if err != nil {
-			return nil, err
-		}
-		return secret, nil
-	})
-
+	claims, err := auth.ValidateToken(tokenString)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 		c.Abort()
 		return
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// Check if token is expired
-		if exp, ok := claims["exp"].(float64); ok {
-			if time.Unix(int64(exp), 0).Before(time.Now()) {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has expired"})
-				c.Abort()
-				return
-			}
-		}
-
-		// Set user ID and role in context
-		if userID, ok := claims["user_id"].(float64); ok {
-			c.Set("userID", uint(userID))
-		}
-
-		if email, ok := claims["email"].(string); ok {
-			c.Set("userEmail", email)
-		}
-
-		if role, ok := claims["role"].(string); ok {
-			c.Set("userRole", role)
-		} else {
-			c.Set("userRole", models.RoleUser) // Default to user role
-		}
-
-		c.Next()
-	} else {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+	// Tokens issued before a forced logout are no longer accepted
+	var user models.User
+	if err := db.DB.Select("id", "token_version").First(&user, claims.UserID).Error; err != nil ||
+		auth.CheckTokenVersion(claims, user.TokenVersion) != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
 		c.Abort()
 		return
 	}
+
+	// Set user ID and role in context
+	c.Set("userID", claims.UserID)
+	c.Set("userEmail", claims.Email)
+	if claims.Role != "" {
+		c.Set("userRole", claims.Role)
+	} else {
+		c.Set("userRole", models.RoleUser) // Default to user role
+	}
+
+	c.Next()
 }
@@ -34,6 +34,15 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Tokens issued before a forced logout are no longer accepted
+		var user models.User
+		if err := db.DB.Select("id", "token_version").First(&user, claims.UserID).Error; err != nil ||
+			auth.CheckTokenVersion(claims, user.TokenVersion) != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
 		// Set typed values in context
 		c.Set("userID", claims.UserID)
 		c.Set("userEmail", claims.Email)
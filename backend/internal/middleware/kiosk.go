@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/geoo115/charity-management-system/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// KioskTokenHeader carries the device token of a feedback kiosk
+const KioskTokenHeader = "X-Kiosk-Token"
+
+// KioskDeviceAuth authenticates a feedback kiosk by its device token instead of a
+// user login and stores the device ID in the context
+func KioskDeviceAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		device, err := services.NewKioskService().AuthenticateDevice(c.GetHeader(KioskTokenHeader))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or revoked kiosk token"})
+			c.Abort()
+			return
+		}
+
+		c.Set("kioskDeviceID", device.ID)
+		c.Set("kioskDevice", device)
+		c.Next()
+	}
+}
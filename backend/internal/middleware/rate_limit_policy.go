@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/jobs"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitPolicy is a token bucket: a caller may make Limit requests in a burst, and
+// the bucket refills evenly so that Limit more are allowed each Window. Each route
+// has its own bucket unless the policy is Shared, when every route using the policy
+// Name draws on one bucket, so a visitor can't get round the login limit by
+// switching between the login endpoints.
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Shared bool
+}
+
+// rateLimitResult is the outcome of taking a token from a bucket
+type rateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // Until the next token, when refused
+	ResetAfter time.Duration // Until the bucket is full again
+}
+
+// refillRate is the tokens added each second
+func (p RateLimitPolicy) refillRate() float64 {
+	return float64(p.Limit) / p.Window.Seconds()
+}
+
+// result describes a bucket with tokens left after a request was allowed or refused
+func (p RateLimitPolicy) result(allowed bool, tokens float64) rateLimitResult {
+	rate := p.refillRate()
+	result := rateLimitResult{
+		Allowed:    allowed,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration((float64(p.Limit) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return result
+}
+
+// tokenBucket is a bucket held in memory
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	window  time.Duration // Time to refill, after which the bucket can be dropped
+}
+
+// memoryBuckets holds token buckets for this instance when Redis is not configured or
+// can't be reached
+type memoryBuckets struct {
+	mutex   sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+var (
+	localBuckets     = &memoryBuckets{buckets: make(map[string]*tokenBucket)}
+	localBucketsOnce sync.Once
+)
+
+// take takes a token from the policy's bucket for key
+func (mb *memoryBuckets) take(policy RateLimitPolicy, key string, now time.Time) rateLimitResult {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	id := policy.Name + ":" + key
+	bucket, exists := mb.buckets[id]
+	if !exists {
+		bucket = &tokenBucket{tokens: float64(policy.Limit), updated: now, window: policy.Window}
+		mb.buckets[id] = bucket
+	}
+	if elapsed := now.Sub(bucket.updated); elapsed > 0 {
+		bucket.tokens = math.Min(float64(policy.Limit), bucket.tokens+elapsed.Seconds()*policy.refillRate())
+		bucket.updated = now
+	}
+
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+	return policy.result(allowed, bucket.tokens)
+}
+
+// cleanup drops buckets that have had time to refill, since a full bucket is the same
+// as no bucket
+func (mb *memoryBuckets) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		mb.mutex.Lock()
+		now := time.Now()
+		for id, bucket := range mb.buckets {
+			if now.Sub(bucket.updated) > bucket.window {
+				delete(mb.buckets, id)
+			}
+		}
+		mb.mutex.Unlock()
+	}
+}
+
+// PolicyRateLimit limits requests with a token bucket per caller: the signed-in user
+// when there is one, otherwise the client IP. Buckets are kept in Redis so every
+// instance shares them, and in memory when Redis is not available.
+func PolicyRateLimit(policy RateLimitPolicy) gin.HandlerFunc {
+	if policy.Limit <= 0 || policy.Window <= 0 {
+		log.Printf("Rate limit policy %s has no limit set; requests are not limited", policy.Name)
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	localBucketsOnce.Do(func() {
+		go localBuckets.cleanup()
+	})
+
+	return func(c *gin.Context) {
+		key := rateLimitKey(c)
+		if !policy.Shared {
+			key = c.Request.Method + " " + c.FullPath() + ":" + key
+		}
+		now := time.Now()
+
+		var result rateLimitResult
+		var err error
+		if jobs.RedisClient != nil {
+			result, err = takeRedisToken(jobs.RedisClient, policy, key, now)
+			if err != nil {
+				log.Printf("Rate limiter falling back to memory for %s: %v", policy.Name, err)
+			}
+		}
+		if jobs.RedisClient == nil || err != nil {
+			result = localBuckets.take(policy, key, now)
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(policy.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(now.Add(result.ResetAfter).Unix(), 10))
+
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":   "Rate limit exceeded",
+				"message": fmt.Sprintf("Too many requests. You can make %d requests per %v; please try again in %d seconds.", policy.Limit, policy.Window, retryAfter),
+				"details": gin.H{
+					"policy":      policy.Name,
+					"limit":       policy.Limit,
+					"window":      policy.Window.String(),
+					"retry_after": retryAfter,
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitKey identifies the caller: by user once signed in, so people sharing a
+// connection such as a library or hostel don't use up each other's limit, and by IP
+// before then
+func rateLimitKey(c *gin.Context) string {
+	if userID, exists := c.Get("userID"); exists {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return "ip:" + c.ClientIP()
+}
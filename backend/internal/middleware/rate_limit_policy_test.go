@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMemoryBucketsRefill(t *testing.T) {
+	buckets := &memoryBuckets{buckets: make(map[string]*tokenBucket)}
+	policy := RateLimitPolicy{Name: "test", Limit: 3, Window: time.Minute}
+	now := time.Date(2025, 10, 13, 9, 0, 0, 0, time.UTC)
+
+	for i := 2; i >= 0; i-- {
+		if result := buckets.take(policy, "ip:1.2.3.4", now); !result.Allowed || result.Remaining != i {
+			t.Fatalf("burst: got %+v, want allowed with %d left", result, i)
+		}
+	}
+	result := buckets.take(policy, "ip:1.2.3.4", now)
+	if result.Allowed || result.RetryAfter != 20*time.Second {
+		t.Fatalf("empty bucket: got %+v, want refused for 20s", result)
+	}
+	if other := buckets.take(policy, "ip:5.6.7.8", now); !other.Allowed {
+		t.Error("another caller should have their own bucket")
+	}
+
+	// One token comes back every 20 seconds, and the bucket never holds more than Limit
+	if result := buckets.take(policy, "ip:1.2.3.4", now.Add(20*time.Second)); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("after 20s: got %+v", result)
+	}
+	if result := buckets.take(policy, "ip:1.2.3.4", now.Add(time.Hour)); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("after an hour: got %+v", result)
+	}
+}
+
+func TestPolicyRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/feedback", func(c *gin.Context) {
+		if id := c.GetHeader("X-Test-User"); id != "" {
+			c.Set("userID", id)
+		}
+		c.Next()
+	}, PolicyRateLimit(RateLimitPolicy{Name: "headers-test", Limit: 2, Window: time.Hour}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	send := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/feedback", nil)
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 1; i >= 0; i-- {
+		w := send("7")
+		if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "2" || w.Header().Get("X-RateLimit-Remaining") != strconv.Itoa(i) {
+			t.Fatalf("request allowed with %d left: got %d %v", i, w.Code, w.Header())
+		}
+	}
+	w := send("7")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1800" || w.Header().Get("X-RateLimit-Reset") == "" {
+		t.Fatalf("over the limit: got %d %v", w.Code, w.Header())
+	}
+	if w := send("8"); w.Code != http.StatusOK {
+		t.Errorf("another user on the same IP: got %d", w.Code)
+	}
+}
+
+func TestPolicyRateLimitWithoutLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", PolicyRateLimit(RateLimitPolicy{Name: "off"}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatalf("got %d %v", w.Code, w.Header())
+		}
+	}
+}
+
+func TestPolicyRateLimitBucketPerRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	strict := RateLimitPolicy{Name: "route-test", Limit: 1, Window: time.Hour}
+	login := RateLimitPolicy{Name: "shared-test", Limit: 1, Window: time.Hour, Shared: true}
+	r.POST("/forgot-password", PolicyRateLimit(strict), ok)
+	r.POST("/reset-password", PolicyRateLimit(strict), ok)
+	r.POST("/login", PolicyRateLimit(login), ok)
+	r.POST("/otp/verify", PolicyRateLimit(login), ok)
+
+	send := func(path string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		return w.Code
+	}
+
+	// Routes have their own bucket unless the policy is shared
+	if send("/forgot-password") != http.StatusOK || send("/reset-password") != http.StatusOK {
+		t.Error("each route should have its own bucket")
+	}
+	if code := send("/forgot-password"); code != http.StatusTooManyRequests {
+		t.Errorf("second request to the same route: got %d", code)
+	}
+	if send("/login") != http.StatusOK {
+		t.Fatal("first login should be allowed")
+	}
+	if code := send("/otp/verify"); code != http.StatusTooManyRequests {
+		t.Errorf("shared policy across routes: got %d", code)
+	}
+}
@@ -144,65 +144,83 @@ func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
 	}
 }
 
-// ConfigurableRateLimit creates a rate limiter with configuration support
-func ConfigurableRateLimit(cfg *config.RateLimitConfig, limit int, window time.Duration) gin.HandlerFunc {
-	// In development mode, optionally bypass rate limiting
-	if cfg != nil && !cfg.EnabledInDev {
-		// Check if we're in development mode
-		return func(c *gin.Context) {
-			if gin.Mode() == gin.DebugMode {
-				c.Next()
-				return
-			}
-			// Use regular rate limiting in production
-			RateLimit(limit, window)(c)
-		}
+// ConfigurableRateLimit applies a rate limit policy. In development the limit is
+// skipped unless RATE_LIMIT_ENABLED_IN_DEV is set.
+func ConfigurableRateLimit(cfg *config.RateLimitConfig, policy RateLimitPolicy) gin.HandlerFunc {
+	limit := PolicyRateLimit(policy)
+	if cfg == nil || cfg.EnabledInDev {
+		return limit
 	}
 
-	return RateLimit(limit, window)
+	return func(c *gin.Context) {
+		if gin.Mode() == gin.DebugMode {
+			c.Next()
+			return
+		}
+		limit(c)
+	}
 }
 
 // AuthRateLimit provides stricter rate limiting for authentication endpoints
 func AuthRateLimit() gin.HandlerFunc {
 	cfg, _ := config.Load()
 	if cfg != nil {
-		return ConfigurableRateLimit(&cfg.RateLimit, cfg.RateLimit.AuthLimit, cfg.RateLimit.AuthWindow)
+		return ConfigurableRateLimit(&cfg.RateLimit, RateLimitPolicy{Name: "auth", Limit: cfg.RateLimit.AuthLimit, Window: cfg.RateLimit.AuthWindow})
 	}
-	return ConfigurableRateLimit(nil, 10, time.Minute)
+	return ConfigurableRateLimit(nil, RateLimitPolicy{Name: "auth", Limit: 10, Window: time.Minute})
 }
 
 // APIRateLimit provides general API rate limiting
 func APIRateLimit() gin.HandlerFunc {
 	cfg, _ := config.Load()
 	if cfg != nil {
-		return ConfigurableRateLimit(&cfg.RateLimit, cfg.RateLimit.APILimit, cfg.RateLimit.APIWindow)
+		return ConfigurableRateLimit(&cfg.RateLimit, RateLimitPolicy{Name: "api", Shared: true, Limit: cfg.RateLimit.APILimit, Window: cfg.RateLimit.APIWindow})
 	}
-	return ConfigurableRateLimit(nil, 100, time.Minute)
+	return ConfigurableRateLimit(nil, RateLimitPolicy{Name: "api", Shared: true, Limit: 100, Window: time.Minute})
 }
 
 // WebSocketRateLimit provides more lenient rate limiting for WebSocket connections
 func WebSocketRateLimit() gin.HandlerFunc {
 	cfg, _ := config.Load()
 	if cfg != nil {
-		return ConfigurableRateLimit(&cfg.RateLimit, cfg.RateLimit.WebSocketLimit, cfg.RateLimit.WebSocketWindow)
+		return ConfigurableRateLimit(&cfg.RateLimit, RateLimitPolicy{Name: "websocket", Shared: true, Limit: cfg.RateLimit.WebSocketLimit, Window: cfg.RateLimit.WebSocketWindow})
 	}
-	return ConfigurableRateLimit(nil, 50, time.Minute)
+	return ConfigurableRateLimit(nil, RateLimitPolicy{Name: "websocket", Shared: true, Limit: 50, Window: time.Minute})
 }
 
 // StrictRateLimit provides very strict rate limiting for sensitive operations
 func StrictRateLimit() gin.HandlerFunc {
 	cfg, _ := config.Load()
 	if cfg != nil {
-		return ConfigurableRateLimit(&cfg.RateLimit, cfg.RateLimit.StrictLimit, cfg.RateLimit.StrictWindow)
+		return ConfigurableRateLimit(&cfg.RateLimit, RateLimitPolicy{Name: "strict", Limit: cfg.RateLimit.StrictLimit, Window: cfg.RateLimit.StrictWindow})
 	}
-	return ConfigurableRateLimit(nil, 3, time.Minute*5)
+	return ConfigurableRateLimit(nil, RateLimitPolicy{Name: "strict", Limit: 3, Window: time.Minute * 5})
 }
 
 // LoginRateLimit provides rate limiting specifically for login endpoints
 func LoginRateLimit() gin.HandlerFunc {
 	cfg, _ := config.Load()
 	if cfg != nil {
-		return ConfigurableRateLimit(&cfg.RateLimit, cfg.RateLimit.LoginLimit, cfg.RateLimit.LoginWindow)
+		return ConfigurableRateLimit(&cfg.RateLimit, RateLimitPolicy{Name: "login", Shared: true, Limit: cfg.RateLimit.LoginLimit, Window: cfg.RateLimit.LoginWindow})
+	}
+	return ConfigurableRateLimit(nil, RateLimitPolicy{Name: "login", Shared: true, Limit: 10, Window: time.Minute * 15})
+}
+
+// RegisterRateLimit limits account registration, which sends email and SMS and is a
+// target for sign-up spam
+func RegisterRateLimit() gin.HandlerFunc {
+	cfg, _ := config.Load()
+	if cfg != nil {
+		return ConfigurableRateLimit(&cfg.RateLimit, RateLimitPolicy{Name: "register", Limit: cfg.RateLimit.RegisterLimit, Window: cfg.RateLimit.RegisterWindow})
+	}
+	return ConfigurableRateLimit(nil, RateLimitPolicy{Name: "register", Limit: 5, Window: time.Hour})
+}
+
+// FeedbackRateLimit limits feedback submission for each user
+func FeedbackRateLimit() gin.HandlerFunc {
+	cfg, _ := config.Load()
+	if cfg != nil {
+		return ConfigurableRateLimit(&cfg.RateLimit, RateLimitPolicy{Name: "feedback", Limit: cfg.RateLimit.FeedbackLimit, Window: cfg.RateLimit.FeedbackWindow})
 	}
-	return ConfigurableRateLimit(nil, 5, time.Minute)
+	return ConfigurableRateLimit(nil, RateLimitPolicy{Name: "feedback", Limit: 10, Window: time.Hour})
 }
@@ -2,89 +2,69 @@ package middleware
 
 import (
 	"context"
-	"fmt"
-	"net/http"
 	"strconv"
 	"time"
 
-	"github.com/geoo115/charity-management-system/internal/jobs"
-	"github.com/gin-gonic/gin"
-)
+	"github.com/geoo115/charity-management-system/internal/services"
 
-// RedisRateLimit returns a middleware that rate-limits by key (user or IP) using Redis INCR + EXPIRE.
-// It falls back to the in-memory RateLimiter if Redis is not available.
-func RedisRateLimit(limit int, window time.Duration) gin.HandlerFunc {
-	// Reuse in-memory limiter as fallback
-	fallback := NewRateLimiter(limit, window)
+	"github.com/go-redis/redis/v8"
+)
 
-	return func(c *gin.Context) {
-		// Determine key: prefer authenticated userID if present
-		key := c.ClientIP()
-		if userID, exists := c.Get("userID"); exists {
-			key = fmt.Sprintf("user_%v", userID)
-		}
+// redisRateLimitTimeout bounds a bucket check so a slow Redis falls back to memory
+// rather than holding up the request
+const redisRateLimitTimeout = 250 * time.Millisecond
 
-		// If Redis is configured, use it
-		if jobs.RedisClient != nil {
-			ctx := context.Background()
-			redisKey := fmt.Sprintf("rl:%s", key)
-			// Use a Lua script or simple INCR+EXPIRE. We'll do INCR then set expiry when count==1
-			count, err := jobs.RedisClient.Incr(ctx, redisKey).Result()
-			if err != nil {
-				// Fall back to in-memory limiter on Redis errors
-				allowed, _ := fallback.isAllowed(key)
-				if !allowed {
-					c.Header("Retry-After", strconv.Itoa(int(window.Seconds())))
-					c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded (fallback)"})
-					c.Abort()
-					return
-				}
-				c.Next()
-				return
-			}
+// takeTokenScript refills a bucket for the time since it was last used and takes a
+// token when one is left. Doing both in one script keeps the check atomic across
+// instances. It returns whether the request is allowed and the tokens left.
+var takeTokenScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
 
-			if count == 1 {
-				// set expiry
-				if err := jobs.RedisClient.Expire(ctx, redisKey, window).Err(); err != nil {
-					// ignore expiry set failure
-				}
-			}
+local state = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+if tokens == nil or updated == nil then
+	tokens = capacity
+	updated = now
+end
 
-			if count > int64(limit) {
-				c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
-				c.Header("X-RateLimit-Remaining", "0")
-				c.Header("X-RateLimit-Reset", strconv.Itoa(int(window.Seconds())))
-				c.Header("Retry-After", strconv.Itoa(int(window.Seconds())))
-				c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
-				c.Abort()
-				return
-			}
+tokens = math.min(capacity, tokens + math.max(0, now - updated) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
 
-			// Allowed
-			c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
-			c.Header("X-RateLimit-Remaining", strconv.FormatInt(int64(limit)-count, 10))
-			c.Next()
-			return
-		}
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated", tostring(now))
+redis.call("PEXPIRE", KEYS[1], ttl)
+return {allowed, tostring(tokens)}
+`)
 
-		// Redis not configured: fallback to in-memory
-		allowed, _ := fallback.isAllowed(key)
-		if !allowed {
-			c.Header("Retry-After", strconv.Itoa(int(window.Seconds())))
-			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded (fallback)"})
-			c.Abort()
-			return
-		}
+// takeRedisToken takes a token from the policy's bucket for key in Redis, shared by
+// every instance of the API
+func takeRedisToken(client *redis.Client, policy RateLimitPolicy, key string, now time.Time) (rateLimitResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisRateLimitTimeout)
+	defer cancel()
 
-		c.Next()
+	ratePerMilli := policy.refillRate() / 1000
+	reply, err := takeTokenScript.Run(ctx, client,
+		[]string{services.PrefixRateLimit + policy.Name + ":" + key},
+		policy.Limit, ratePerMilli, now.UnixMilli(), policy.Window.Milliseconds(),
+	).Slice()
+	if err != nil {
+		return rateLimitResult{}, err
 	}
-}
-
-// Helper constructors for common policies
-func RedisAuthRateLimit() gin.HandlerFunc {
-	return RedisRateLimit(5, time.Minute*15) // 5 attempts per 15 minutes
-}
-
-func RedisAPIRateLimit() gin.HandlerFunc {
-	return RedisRateLimit(100, time.Minute) // 100 req/min
+	if len(reply) != 2 {
+		return rateLimitResult{}, redis.Nil
+	}
+	allowed, _ := reply[0].(int64)
+	left, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(left, 64)
+	if err != nil {
+		return rateLimitResult{}, err
+	}
+	return policy.result(allowed == 1, tokens), nil
 }
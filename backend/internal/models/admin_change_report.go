@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Kinds of administrative change summarised in the weekly governance report
+const (
+	AdminChangeCapacity         = "capacity"
+	AdminChangeRoleGrant        = "role_grant"
+	AdminChangeDocumentDecision = "document_decision"
+	AdminChangeTicketOverride   = "ticket_override"
+)
+
+// AdminChangeCategories lists the kinds of change in report order
+var AdminChangeCategories = []string{
+	AdminChangeCapacity,
+	AdminChangeRoleGrant,
+	AdminChangeDocumentDecision,
+	AdminChangeTicketOverride,
+}
+
+// AdminChangeReport is a stored weekly report of who changed what, sent to trustees
+// for governance
+type AdminChangeReport struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WeekStart   string    `json:"week_start" gorm:"uniqueIndex"` // Monday, YYYY-MM-DD
+	WeekEnd     string    `json:"week_end"`
+	Changes     int       `json:"changes"`
+	Admins      int       `json:"admins"`             // Staff and admins who made changes
+	Content     string    `json:"-" gorm:"type:text"` // The report as JSON
+	EmailedTo   int       `json:"emailed_to"`
+	GeneratedAt time.Time `json:"generated_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (AdminChangeReport) TableName() string {
+	return "admin_change_reports"
+}
@@ -0,0 +1,109 @@
+package models
+
+// Scoped admin roles. An admin with no scope has full access; a scoped admin can only
+// use the admin modules their scope grants.
+const (
+	AdminScopeAnalyticsViewer      = "analytics_viewer"
+	AdminScopeVolunteerCoordinator = "volunteer_coordinator"
+	AdminScopeFinanceOfficer       = "finance_officer"
+)
+
+// Admin modules, each covering one or more admin route groups
+const (
+	AdminModuleDashboard       = "dashboard"
+	AdminModuleAnalytics       = "analytics"
+	AdminModuleReports         = "reports"
+	AdminModuleUsers           = "users"
+	AdminModuleStaff           = "staff"
+	AdminModuleVolunteers      = "volunteers"
+	AdminModuleVisitorServices = "visitor_services"
+	AdminModuleDocuments       = "documents"
+	AdminModuleDonations       = "donations"
+	AdminModuleFinance         = "finance"
+	AdminModuleCommunications  = "communications"
+	AdminModuleEmergency       = "emergency"
+	AdminModuleFeedback        = "feedback"
+	AdminModuleBulk            = "bulk"
+	AdminModuleAudit           = "audit"
+	AdminModuleSystem          = "system"
+)
+
+// Access levels a scope grants to a module
+const (
+	AdminAccessRead   = "read"   // Viewing only
+	AdminAccessManage = "manage" // Viewing and changes
+)
+
+// AdminScopeDefinition describes a predefined scoped admin role
+type AdminScopeDefinition struct {
+	Scope       string            `json:"scope"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Modules     map[string]string `json:"modules"` // Module to access level
+}
+
+// AdminScopes are the predefined scoped admin roles
+var AdminScopes = []AdminScopeDefinition{
+	{
+		Scope:       AdminScopeAnalyticsViewer,
+		Name:        "Analytics Viewer",
+		Description: "Read-only access to the dashboard, analytics and reports, for trustees and funders",
+		Modules: map[string]string{
+			AdminModuleDashboard: AdminAccessRead,
+			AdminModuleAnalytics: AdminAccessRead,
+			AdminModuleReports:   AdminAccessRead,
+		},
+	},
+	{
+		Scope:       AdminScopeVolunteerCoordinator,
+		Name:        "Volunteer Coordinator",
+		Description: "Manages volunteers, applications, shifts and recognition",
+		Modules: map[string]string{
+			AdminModuleDashboard:  AdminAccessRead,
+			AdminModuleVolunteers: AdminAccessManage,
+			AdminModuleReports:    AdminAccessRead,
+		},
+	},
+	{
+		Scope:       AdminScopeFinanceOfficer,
+		Name:        "Finance Officer",
+		Description: "Manages donations, pledges, drives, bank reconciliation and supplier orders",
+		Modules: map[string]string{
+			AdminModuleDashboard: AdminAccessRead,
+			AdminModuleDonations: AdminAccessManage,
+			AdminModuleFinance:   AdminAccessManage,
+			AdminModuleAnalytics: AdminAccessRead,
+			AdminModuleReports:   AdminAccessRead,
+		},
+	},
+}
+
+// FindAdminScope returns the definition of a scoped admin role
+func FindAdminScope(scope string) (*AdminScopeDefinition, bool) {
+	for i := range AdminScopes {
+		if AdminScopes[i].Scope == scope {
+			return &AdminScopes[i], true
+		}
+	}
+	return nil, false
+}
+
+// AdminScopeAllows reports whether an admin scope may use a module. An empty scope is
+// a full admin. Changes need manage access.
+func AdminScopeAllows(scope, module string, write bool) bool {
+	if scope == "" {
+		return true
+	}
+	definition, ok := FindAdminScope(scope)
+	if !ok {
+		return false
+	}
+	switch definition.Modules[module] {
+	case AdminAccessManage:
+		return true
+	case AdminAccessRead:
+		return !write
+	default:
+		return false
+	}
+}
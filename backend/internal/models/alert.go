@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+)
+
+// Alert severity values
+const (
+	AlertSeverityLow      = "low"
+	AlertSeverityMedium   = "medium"
+	AlertSeverityHigh     = "high"
+	AlertSeverityCritical = "critical"
+)
+
+// Alert states, worked out from the acknowledgement, snooze and resolve fields
+const (
+	AlertStatusActive       = "active"
+	AlertStatusAcknowledged = "acknowledged"
+	AlertStatusSnoozed      = "snoozed"
+	AlertStatusResolved     = "resolved"
+)
+
+// AlertSourceManual marks alerts raised by an admin rather than the evaluator
+const AlertSourceManual = "manual"
+
+// Alert is a system alert on the admin dashboard. The evaluator job raises alerts
+// while a condition holds and, when AutoResolve is set, resolves them once it clears.
+// Acknowledging or snoozing an alert sticks until it is resolved.
+type Alert struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Source      string `json:"source" gorm:"index;not null"` // Rule that raised it, or manual
+	Severity    string `json:"severity" gorm:"index;not null"`
+	Type        string `json:"type"` // info, warning, error
+	Title       string `json:"title" gorm:"not null"`
+	Message     string `json:"message" gorm:"type:text"`
+	ActionLabel string `json:"action_label,omitempty"`
+	ActionURL   string `json:"action_url,omitempty"`
+	AutoResolve bool   `json:"auto_resolve"` // Resolved by the evaluator once the condition clears
+
+	Occurrences int       `json:"occurrences" gorm:"default:1"` // Evaluator runs that found the condition
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+
+	AcknowledgedBy *uint      `json:"acknowledged_by"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	SnoozedBy      *uint      `json:"snoozed_by,omitempty"`
+	SnoozedUntil   *time.Time `json:"snoozed_until"`
+	ResolvedBy     *uint      `json:"resolved_by,omitempty"` // Empty when the evaluator resolved it
+	ResolvedAt     *time.Time `json:"resolved_at" gorm:"index"`
+	CreatedBy      *uint      `json:"created_by,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (Alert) TableName() string {
+	return "system_alerts"
+}
+
+// Status returns the alert's state at a point in time
+func (a *Alert) Status(now time.Time) string {
+	switch {
+	case a.ResolvedAt != nil:
+		return AlertStatusResolved
+	case a.SnoozedUntil != nil && a.SnoozedUntil.After(now):
+		return AlertStatusSnoozed
+	case a.AcknowledgedAt != nil:
+		return AlertStatusAcknowledged
+	default:
+		return AlertStatusActive
+	}
+}
+
+// ValidAlertSeverity reports whether a severity is known
+func ValidAlertSeverity(severity string) bool {
+	switch severity {
+	case AlertSeverityLow, AlertSeverityMedium, AlertSeverityHigh, AlertSeverityCritical:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+// Analytics event streams captured from the domain tables
+const (
+	AnalyticsStreamVisits       = "visits"
+	AnalyticsStreamHelpRequests = "help_requests"
+	AnalyticsStreamDonations    = "donations"
+)
+
+// AnalyticsStreams lists every analytics event stream
+var AnalyticsStreams = []string{AnalyticsStreamVisits, AnalyticsStreamHelpRequests, AnalyticsStreamDonations}
+
+// AnalyticsSchemaVersions is the current payload schema version of each stream. Bump a
+// stream's version whenever its payload fields change so downstream tables can tell
+// old rows from new.
+var AnalyticsSchemaVersions = map[string]int{
+	AnalyticsStreamVisits:       1,
+	AnalyticsStreamHelpRequests: 1,
+	AnalyticsStreamDonations:    1,
+}
+
+// IsAnalyticsStream checks if a stream name is known
+func IsAnalyticsStream(stream string) bool {
+	_, ok := AnalyticsSchemaVersions[stream]
+	return ok
+}
+
+// Analytics export batch statuses
+const (
+	AnalyticsBatchRunning   = "running"
+	AnalyticsBatchSucceeded = "succeeded"
+	AnalyticsBatchFailed    = "failed"
+)
+
+// AnalyticsEvent is an anonymized domain event kept for export. Rows are only ever
+// inserted; each state an entity reaches is recorded once.
+type AnalyticsEvent struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	EventKey      string    `json:"event_key" gorm:"size:64;uniqueIndex;not null"`
+	Stream        string    `json:"stream" gorm:"size:50;index;not null"`
+	EventType     string    `json:"event_type" gorm:"size:100;index;not null"` // e.g. visit.completed
+	SchemaVersion int       `json:"schema_version" gorm:"not null"`
+	EntityKey     string    `json:"entity_key" gorm:"size:64;index"`  // Pseudonymous ID of the visit, request or donation
+	SubjectKey    string    `json:"subject_key" gorm:"size:64;index"` // Pseudonymous ID of the visitor or donor
+	OccurredAt    time.Time `json:"occurred_at" gorm:"index"`
+	Payload       string    `json:"payload" gorm:"type:text"` // Scrubbed JSON fields
+	Backfill      bool      `json:"backfill" gorm:"default:false"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (AnalyticsEvent) TableName() string {
+	return "analytics_events"
+}
+
+// AnalyticsCaptureCursor records how far each stream's source table has been read
+type AnalyticsCaptureCursor struct {
+	Stream        string    `gorm:"primaryKey;size:50" json:"stream"`
+	LastUpdatedAt time.Time `json:"last_updated_at"`
+	LastSourceID  uint      `json:"last_source_id"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (AnalyticsCaptureCursor) TableName() string {
+	return "analytics_capture_cursors"
+}
+
+// AnalyticsExportBatch records one batch of events sent to an analytics sink
+type AnalyticsExportBatch struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Sink        string     `json:"sink" gorm:"size:50;index;not null"`
+	Format      string     `json:"format" gorm:"size:20"`
+	Status      string     `json:"status" gorm:"size:20;index;default:'running'"`
+	FromEventID uint       `json:"from_event_id"`
+	ToEventID   uint       `json:"to_event_id" gorm:"index"`
+	EventCount  int        `json:"event_count"`
+	Location    string     `json:"location"` // Object key, file path or table written to
+	Error       string     `json:"error,omitempty" gorm:"type:text"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (AnalyticsExportBatch) TableName() string {
+	return "analytics_export_batches"
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// APIKey lets a partner organisation call the partner API without a user login.
+// Only a hash of the key is stored.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Name       string     `json:"name" gorm:"not null"`
+	Partner    string     `json:"partner"`               // Organisation the key was issued to
+	OwnerID    *uint      `json:"owner_id" gorm:"index"` // Partner contact who may view the key's usage
+	KeyHash    string     `json:"-" gorm:"uniqueIndex;not null"`
+	KeyPrefix  string     `json:"key_prefix"` // First characters of the key, to tell keys apart
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	RevokedBy  *uint      `json:"revoked_by"`
+	CreatedBy  uint       `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsActive reports whether the key is still accepted
+func (k *APIKey) IsActive() bool {
+	return k.RevokedAt == nil
+}
+
+// APIKeyUsage counts one key's requests to one endpoint on one day
+type APIKeyUsage struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	APIKeyID    uint      `json:"api_key_id" gorm:"uniqueIndex:idx_api_key_usage_day"`
+	Day         string    `json:"day" gorm:"type:varchar(10);uniqueIndex:idx_api_key_usage_day"` // YYYY-MM-DD
+	Endpoint    string    `json:"endpoint" gorm:"uniqueIndex:idx_api_key_usage_day"`             // Method and route, e.g. GET /api/v1/partner/urgent-needs
+	Requests    int64     `json:"requests"`
+	Errors      int64     `json:"errors"`       // Responses with status 400 or above, excluding rate limits
+	RateLimited int64     `json:"rate_limited"` // Requests refused by the key's rate limit
+	LastUsedAt  time.Time `json:"last_used_at"`
+}
+
+// TableName specifies the table name
+func (APIKeyUsage) TableName() string {
+	return "api_key_usage"
+}
+
+// HashAPIKey returns the stored hash of an API key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Appointment slot statuses
+const (
+	AppointmentSlotOpen      = "open"
+	AppointmentSlotCancelled = "cancelled" // Withdrawn by staff; any booking is cancelled with it
+)
+
+// Appointment statuses
+const (
+	AppointmentBooked    = "booked"
+	AppointmentCancelled = "cancelled"
+	AppointmentAttended  = "attended"
+	AppointmentNoShow    = "no_show"
+)
+
+// AppointmentSlot is a time a member of staff is available for a one-to-one session,
+// such as debt or housing advice, booked outside the ticketed queue
+type AppointmentSlot struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	StaffID     uint       `json:"staff_id" gorm:"not null;index"`
+	Category    string     `json:"category" gorm:"not null;index"` // Service type code
+	StartsAt    time.Time  `json:"starts_at" gorm:"not null;index"`
+	EndsAt      time.Time  `json:"ends_at" gorm:"not null"`
+	Location    string     `json:"location"`
+	Status      string     `json:"status" gorm:"default:open;index"`
+	CreatedBy   uint       `json:"created_by"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Relationships
+	Staff User `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+}
+
+// TableName specifies the table name
+func (AppointmentSlot) TableName() string {
+	return "appointment_slots"
+}
+
+// Appointment is a visitor's booking of an appointment slot. No-shows are recorded
+// here, separately from queue tickets.
+type Appointment struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	SlotID             uint       `json:"slot_id" gorm:"not null;index"`
+	VisitorID          uint       `json:"visitor_id" gorm:"not null;index"`
+	Status             string     `json:"status" gorm:"default:booked;index"`
+	Notes              string     `json:"notes,omitempty" gorm:"type:text"` // What the visitor would like help with
+	BookedAt           time.Time  `json:"booked_at"`
+	ReminderSentAt     *time.Time `json:"reminder_sent_at,omitempty"`
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty"`
+	CancelledBy        *uint      `json:"cancelled_by,omitempty"`
+	CancellationReason string     `json:"cancellation_reason,omitempty"`
+	OutcomeRecordedAt  *time.Time `json:"outcome_recorded_at,omitempty"` // When attendance or a no-show was recorded
+	OutcomeRecordedBy  *uint      `json:"outcome_recorded_by,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	// Relationships
+	Slot    AppointmentSlot `json:"slot,omitempty" gorm:"foreignKey:SlotID"`
+	Visitor User            `json:"visitor,omitempty" gorm:"foreignKey:VisitorID"`
+}
+
+// TableName specifies the table name
+func (Appointment) TableName() string {
+	return "appointments"
+}
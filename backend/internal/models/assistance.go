@@ -9,36 +9,39 @@ import (
 
 // HelpRequest represents assistance requested by a visitor
 type HelpRequest struct {
-	ID               uint           `json:"id" gorm:"primaryKey"`
-	VisitorID        uint           `json:"visitor_id" gorm:"not null"`
-	VisitorName      string         `json:"visitor_name" gorm:"type:varchar(255)"`
-	Email            string         `json:"email" gorm:"type:varchar(255)"`
-	Phone            string         `json:"phone" gorm:"type:varchar(20)"`
-	Postcode         string         `json:"postcode" gorm:"type:varchar(10)"`
-	PreferredTime    time.Time      `json:"preferred_time"`
-	Category         string         `json:"category" gorm:"type:varchar(100)"`
-	Details          string         `json:"details" gorm:"type:text"`
-	SpecialNeeds     string         `json:"special_needs" gorm:"type:text"`
-	HouseholdSize    int            `json:"household_size" gorm:"default:1"`
-	Status           string         `json:"status" gorm:"type:varchar(50);default:'pending'"`
-	RequestDate      time.Time      `json:"request_date" gorm:"not null"`
-	ApprovedAt       *time.Time     `json:"approved_at"`
-	ApprovedBy       *uint          `json:"approved_by"`
-	RejectedAt       *time.Time     `json:"rejected_at"`
-	RejectedBy       *uint          `json:"rejected_by"`
-	RejectionReason  string         `json:"rejection_reason" gorm:"type:text"`
-	EligibilityNotes string         `json:"eligibility_notes" gorm:"type:text"`
-	TicketNumber     string         `json:"ticket_number" gorm:"type:varchar(50)"`
-	QRCode           string         `json:"qr_code" gorm:"type:text"`
-	Reference        string         `json:"reference" gorm:"type:varchar(50);uniqueIndex"`
-	VisitDay         string         `json:"visit_day" gorm:"type:varchar(20)"`
-	TimeSlot         string         `json:"time_slot" gorm:"type:varchar(20)"`
-	AssignedStaffID  *uint          `json:"assigned_staff_id"`
-	Notes            string         `json:"notes" gorm:"type:text"`
-	Priority         string         `json:"priority" gorm:"type:varchar(20);default:'normal'"`
-	CreatedAt        time.Time      `json:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at"`
-	DeletedAt        gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	ID                  uint           `json:"id" gorm:"primaryKey"`
+	VisitorID           uint           `json:"visitor_id" gorm:"not null"`
+	VisitorName         string         `json:"visitor_name" gorm:"type:varchar(255)"`
+	Email               string         `json:"email" gorm:"type:varchar(255)"`
+	Phone               string         `json:"phone" gorm:"type:varchar(20)"`
+	Postcode            string         `json:"postcode" gorm:"type:varchar(10)"`
+	PreferredTime       time.Time      `json:"preferred_time"`
+	Category            string         `json:"category" gorm:"type:varchar(100)"`
+	Details             string         `json:"details" gorm:"type:text"`
+	SpecialNeeds        string         `json:"special_needs" gorm:"type:text"`
+	HouseholdSize       int            `json:"household_size" gorm:"default:1"`
+	Status              string         `json:"status" gorm:"type:varchar(50);default:'pending'"`
+	RequestDate         time.Time      `json:"request_date" gorm:"not null"`
+	ApprovedAt          *time.Time     `json:"approved_at"`
+	ApprovedBy          *uint          `json:"approved_by"`
+	RejectedAt          *time.Time     `json:"rejected_at"`
+	RejectedBy          *uint          `json:"rejected_by"`
+	RejectionReason     string         `json:"rejection_reason" gorm:"type:text"`
+	EligibilityNotes    string         `json:"eligibility_notes" gorm:"type:text"`
+	TicketNumber        string         `json:"ticket_number" gorm:"type:varchar(50)"`
+	QRCode              string         `json:"qr_code" gorm:"type:text"`
+	Reference           string         `json:"reference" gorm:"type:varchar(50);uniqueIndex"`
+	VisitDay            string         `json:"visit_day" gorm:"type:varchar(20)"`
+	TimeSlot            string         `json:"time_slot" gorm:"type:varchar(20)"`
+	AssignedStaffID     *uint          `json:"assigned_staff_id"`
+	Notes               string         `json:"notes" gorm:"type:text"`
+	Priority            string         `json:"priority" gorm:"type:varchar(20);default:'normal'"`
+	FormAnswers         FormAnswers    `json:"form_answers,omitempty" gorm:"type:json"`                 // Answers to the service type's extra questions
+	FormVersion         int            `json:"form_version,omitempty"`                                  // Version of the form that was answered
+	InterpreterLanguage string         `json:"interpreter_language,omitempty" gorm:"type:varchar(100)"` // Set when the visitor needs an interpreter
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	// Relationships
 	Visitor        User  `json:"visitor" gorm:"foreignKey:VisitorID"`
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// OIDCSignInRoles are the roles that may sign in with an external provider. Visitors
+// and donors keep using their password.
+var OIDCSignInRoles = []string{RoleAdmin, RoleSuperAdmin, RoleStaff, RoleVolunteer,
+	RoleAdminLegacy, RoleSuperAdminLegacy, RoleStaffLegacy, RoleVolunteerLegacy}
+
+// AuthProvider is an OpenID Connect provider staff and volunteers can sign in with,
+// such as Google or Microsoft
+type AuthProvider struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Key            string    `json:"key" gorm:"type:varchar(40);not null;uniqueIndex"` // Used in the sign-in URL, e.g. google
+	Name           string    `json:"name" gorm:"not null"`                             // Shown on the sign-in button
+	IssuerURL      string    `json:"issuer_url" gorm:"not null"`
+	ClientID       string    `json:"client_id" gorm:"not null"`
+	ClientSecret   string    `json:"-"`
+	Scopes         string    `json:"scopes" gorm:"default:'openid email profile'"`
+	AllowedDomains string    `json:"allowed_domains"` // Comma-separated email domains new staff accounts may be created for
+	Enabled        bool      `json:"enabled" gorm:"default:false"`
+	CreatedBy      uint      `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (AuthProvider) TableName() string {
+	return "auth_providers"
+}
+
+// AllowsDomain reports whether new staff accounts may be provisioned for an email
+func (p *AuthProvider) AllowsDomain(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, allowed := range strings.Split(p.AllowedDomains, ",") {
+		if allowed = strings.ToLower(strings.TrimSpace(allowed)); allowed != "" && allowed == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// UserIdentity links a user to their account at an external provider
+type UserIdentity struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	ProviderID  uint       `json:"provider_id" gorm:"not null;uniqueIndex:idx_user_identity_subject"`
+	Subject     string     `json:"subject" gorm:"not null;uniqueIndex:idx_user_identity_subject"` // The provider's stable user ID
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	Provider AuthProvider `json:"provider,omitempty" gorm:"foreignKey:ProviderID"`
+}
+
+// TableName specifies the table name
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// OIDCLoginState holds an in-progress external sign-in between redirect and callback
+type OIDCLoginState struct {
+	ID           uint      `gorm:"primaryKey"`
+	State        string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Nonce        string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"` // PKCE verifier sent when exchanging the code
+	ProviderID   uint      `gorm:"not null"`
+	RedirectURI  string    `gorm:"not null"`
+	ExpiresAt    time.Time `gorm:"index"`
+	UsedAt       *time.Time
+	CreatedAt    time.Time
+}
+
+// TableName specifies the table name
+func (OIDCLoginState) TableName() string {
+	return "oidc_login_states"
+}
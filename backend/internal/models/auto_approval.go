@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// Human review of an auto-approved help request
+const (
+	AutoApprovalReviewNotSampled = "not_sampled" // Not picked for review
+	AutoApprovalReviewPending    = "pending"     // Sampled and waiting for a reviewer
+	AutoApprovalReviewUpheld     = "upheld"      // Reviewer agreed with the approval
+	AutoApprovalReviewOverturned = "overturned"  // Reviewer sent the request back for manual review
+)
+
+// AutoApprovalRule is an admin-configured set of conditions under which a help
+// request is approved as soon as it is made. Rules are tried in priority order and
+// the first one whose conditions all hold approves the request.
+type AutoApprovalRule struct {
+	ID                      uint        `gorm:"primaryKey" json:"id"`
+	Name                    string      `json:"name" gorm:"not null"`
+	Enabled                 bool        `json:"enabled" gorm:"index"`
+	Priority                int         `json:"priority" gorm:"default:100"` // Lower runs first
+	Categories              StringArray `json:"categories" gorm:"type:json"` // Service categories the rule covers
+	RequireVerifiedID       bool        `json:"require_verified_id"`         // Photo ID and proof of address approved
+	MaxRequests             int         `json:"max_requests"`                // Most earlier requests allowed in the frequency window
+	FrequencyDays           int         `json:"frequency_days"`              // Length of the frequency window; 0 skips the check
+	MaxHouseholdSize        int         `json:"max_household_size"`          // 0 for any size
+	MinPreviousVisits       int         `json:"min_previous_visits"`         // Completed visits needed before approval
+	RequireEligiblePostcode bool        `json:"require_eligible_postcode"`   // Postcode in the service area
+	AllowSpecialNeeds       bool        `json:"allow_special_needs"`         // Otherwise requests noting special needs go to staff
+	AllowUrgent             bool        `json:"allow_urgent"`                // Otherwise high or urgent priority requests go to staff
+	ReviewSamplePercent     int         `json:"review_sample_percent"`       // Share of approvals sampled for human review
+	CreatedBy               uint        `json:"created_by"`
+	UpdatedBy               uint        `json:"updated_by"`
+	CreatedAt               time.Time   `json:"created_at"`
+	UpdatedAt               time.Time   `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (AutoApprovalRule) TableName() string {
+	return "auto_approval_rules"
+}
+
+// AutoApproval records a help request approved by a rule, the checks it passed and
+// whether it was sampled for human review
+type AutoApproval struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	HelpRequestID uint       `json:"help_request_id" gorm:"uniqueIndex;not null"`
+	RuleID        uint       `json:"rule_id" gorm:"index;not null"`
+	RuleName      string     `json:"rule_name"`
+	Checks        string     `json:"checks" gorm:"type:text"` // Conditions checked and what was found
+	ReviewStatus  string     `json:"review_status" gorm:"index;not null"`
+	ReviewedBy    *uint      `json:"reviewed_by"`
+	ReviewedAt    *time.Time `json:"reviewed_at"`
+	ReviewNotes   string     `json:"review_notes" gorm:"type:text"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"index"`
+
+	HelpRequest *HelpRequest `json:"help_request,omitempty" gorm:"foreignKey:HelpRequestID"`
+}
+
+// TableName specifies the table name
+func (AutoApproval) TableName() string {
+	return "auto_approvals"
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Bank transaction reconciliation status values
+const (
+	BankTransactionStatusUnmatched = "unmatched"
+	BankTransactionStatusSuggested = "suggested"
+	BankTransactionStatusMatched   = "matched"
+	BankTransactionStatusIgnored   = "ignored"
+)
+
+// Bank transaction match target types
+const (
+	BankMatchTypePledge   = "pledge"
+	BankMatchTypeDonation = "donation"
+)
+
+// BankStatementImport records a bank statement CSV uploaded for reconciliation
+type BankStatementImport struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	FileName    string         `json:"file_name"`
+	ImportedBy  uint           `json:"imported_by" gorm:"index"`
+	RowCount    int            `json:"row_count"`
+	CreditCount int            `json:"credit_count"`
+	Duplicates  int            `json:"duplicates"`
+	AutoMatched int            `json:"auto_matched"`
+	Suggested   int            `json:"suggested"`
+	Unmatched   int            `json:"unmatched"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Transactions []BankTransaction `json:"transactions,omitempty" gorm:"foreignKey:ImportID"`
+}
+
+// TableName specifies the table name
+func (BankStatementImport) TableName() string {
+	return "bank_statement_imports"
+}
+
+// BankTransaction is a single credit line from an imported bank statement
+type BankTransaction struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	ImportID        uint           `json:"import_id" gorm:"not null;index"`
+	RowNumber       int            `json:"row_number"`
+	TransactionDate time.Time      `json:"transaction_date" gorm:"index"`
+	Amount          float64        `json:"amount"`
+	Reference       string         `json:"reference"`
+	Description     string         `json:"description"`
+	PayerName       string         `json:"payer_name"`
+	Fingerprint     string         `json:"-" gorm:"uniqueIndex"` // Date, amount and reference, used to skip re-imported rows
+	Status          string         `json:"status" gorm:"default:unmatched;index"`
+	MatchType       string         `json:"match_type"` // pledge, donation
+	MatchID         *uint          `json:"match_id"`
+	Confidence      float64        `json:"confidence"`
+	MatchReason     string         `json:"match_reason"`
+	MatchedBy       *uint          `json:"matched_by"` // Nil when matched automatically
+	MatchedAt       *time.Time     `json:"matched_at"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName specifies the table name
+func (BankTransaction) TableName() string {
+	return "bank_transactions"
+}
+
+// IsResolved returns true if the transaction no longer needs attention
+func (t *BankTransaction) IsResolved() bool {
+	return t.Status == BankTransactionStatusMatched || t.Status == BankTransactionStatusIgnored
+}
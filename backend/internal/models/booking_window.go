@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// BookingWindow sets when visitors can book a service for a visit day, e.g. food
+// visits open at 09:00 seven days before and close at 18:00 the day before. A
+// window with a Weekday applies only to visits on that day and takes precedence
+// over the category's default window. Categories without a window are always open.
+type BookingWindow struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	Category         string    `json:"category" gorm:"size:100;not null;index"` // Service type code
+	Weekday          *int      `json:"weekday"`                                 // 0 = Sunday; nil for the category default
+	OpensDaysBefore  int       `json:"opens_days_before" gorm:"default:7"`
+	OpensAt          string    `json:"opens_at" gorm:"size:5;default:'09:00'"` // HH:MM
+	ClosesDaysBefore int       `json:"closes_days_before" gorm:"default:1"`
+	ClosesAt         string    `json:"closes_at" gorm:"size:5;default:'18:00'"` // HH:MM
+	IsActive         bool      `json:"is_active" gorm:"default:true"`
+	UpdatedBy        uint      `json:"updated_by"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (BookingWindow) TableName() string {
+	return "booking_windows"
+}
+
+// Bounds returns when bookings for the visit day open and close, in local time
+func (w *BookingWindow) Bounds(visitDay time.Time) (time.Time, time.Time, error) {
+	opens, err := parseClockMinutes(w.OpensAt)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid opening time: %w", err)
+	}
+	closes, err := parseClockMinutes(w.ClosesAt)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid closing time: %w", err)
+	}
+
+	y, m, d := visitDay.Date()
+	opensAt := time.Date(y, m, d-w.OpensDaysBefore, opens/60, opens%60, 0, 0, time.Local)
+	closesAt := time.Date(y, m, d-w.ClosesDaysBefore, closes/60, closes%60, 0, 0, time.Local)
+	return opensAt, closesAt, nil
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"os"
+	"time"
+)
+
+// BrandingProfile is the letterhead used on emails, PDFs, tickets and certificates.
+// The profile with an empty location is the organisation default; a profile for a
+// location overrides it where a document relates to that location. Blank fields fall
+// back to the default profile and then to the built-in branding.
+type BrandingProfile struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	Location        string    `json:"location" gorm:"uniqueIndex"` // Empty for the organisation default
+	DisplayName     string    `json:"display_name"`
+	PrimaryColor    string    `json:"primary_color"` // Hex, e.g. #1D4ED8; used for headings
+	AccentColor     string    `json:"accent_color"`  // Hex; used for buttons and rules in emails
+	FooterText      string    `json:"footer_text" gorm:"type:text"`
+	CharityNumber   string    `json:"charity_number"`
+	Website         string    `json:"website"`
+	ContactEmail    string    `json:"contact_email"`
+	ContactPhone    string    `json:"contact_phone"`
+	Address         string    `json:"address" gorm:"type:text"`
+	LogoData        []byte    `json:"-"`
+	LogoContentType string    `json:"logo_content_type,omitempty"`
+	LogoURL         string    `json:"logo_url,omitempty"`               // External logo, used when none is uploaded
+	Language        string    `json:"language" gorm:"type:varchar(20)"` // Default message language for the location's users
+	UpdatedBy       *uint     `json:"updated_by,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (BrandingProfile) TableName() string {
+	return "branding_profiles"
+}
+
+// HasLogo reports whether a logo image has been uploaded
+func (b BrandingProfile) HasLogo() bool {
+	return len(b.LogoData) > 0
+}
+
+// Merge fills blank fields from a fallback profile
+func (b BrandingProfile) Merge(fallback BrandingProfile) BrandingProfile {
+	fill := func(value *string, fallback string) {
+		if *value == "" {
+			*value = fallback
+		}
+	}
+	fill(&b.DisplayName, fallback.DisplayName)
+	fill(&b.PrimaryColor, fallback.PrimaryColor)
+	fill(&b.AccentColor, fallback.AccentColor)
+	fill(&b.FooterText, fallback.FooterText)
+	fill(&b.CharityNumber, fallback.CharityNumber)
+	fill(&b.Website, fallback.Website)
+	fill(&b.ContactEmail, fallback.ContactEmail)
+	fill(&b.ContactPhone, fallback.ContactPhone)
+	fill(&b.Address, fallback.Address)
+	fill(&b.Language, fallback.Language)
+	if !b.HasLogo() && b.LogoURL == "" {
+		b.LogoData = fallback.LogoData
+		b.LogoContentType = fallback.LogoContentType
+		b.LogoURL = fallback.LogoURL
+	}
+	return b
+}
+
+// BuiltInBranding is the branding used when nothing has been configured. The name
+// and charity number can be set with CHARITY_NAME and CHARITY_NUMBER.
+func BuiltInBranding() BrandingProfile {
+	name := os.Getenv("CHARITY_NAME")
+	if name == "" {
+		name = "Lewisham Charity"
+	}
+	return BrandingProfile{
+		DisplayName:   name,
+		PrimaryColor:  "#1F2937",
+		AccentColor:   "#2563EB",
+		CharityNumber: os.Getenv("CHARITY_NUMBER"),
+		Website:       os.Getenv("FRONTEND_URL"),
+	}
+}
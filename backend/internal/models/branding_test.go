@@ -0,0 +1,41 @@
+package models
+
+import "testing"
+
+func TestBrandingProfileMerge(t *testing.T) {
+	fallback := BrandingProfile{
+		DisplayName:  "Lewisham Charity",
+		PrimaryColor: "#1F2937",
+		Language:     "en",
+		LogoData:     []byte("default-logo"),
+	}
+
+	own := BrandingProfile{Location: "Catford", DisplayName: "Catford Food Hub", Language: "pl"}
+	merged := own.Merge(fallback)
+	if merged.Location != "Catford" || merged.DisplayName != "Catford Food Hub" || merged.Language != "pl" {
+		t.Errorf("own fields replaced: %+v", merged)
+	}
+	if merged.PrimaryColor != "#1F2937" || string(merged.LogoData) != "default-logo" {
+		t.Errorf("blank fields not filled: %+v", merged)
+	}
+
+	// A location with its own external logo keeps it rather than the uploaded default
+	withURL := BrandingProfile{LogoURL: "https://cdn.example.org/catford.png"}.Merge(fallback)
+	if withURL.HasLogo() || withURL.LogoURL != "https://cdn.example.org/catford.png" {
+		t.Errorf("external logo replaced: %+v", withURL)
+	}
+}
+
+func TestBuiltInBranding(t *testing.T) {
+	t.Setenv("CHARITY_NAME", "")
+	t.Setenv("CHARITY_NUMBER", "1234567")
+	branding := BuiltInBranding()
+	if branding.DisplayName != "Lewisham Charity" || branding.CharityNumber != "1234567" || branding.PrimaryColor == "" {
+		t.Errorf("got %+v", branding)
+	}
+
+	t.Setenv("CHARITY_NAME", "Deptford Pantry")
+	if name := BuiltInBranding().DisplayName; name != "Deptford Pantry" {
+		t.Errorf("CHARITY_NAME not used: %q", name)
+	}
+}
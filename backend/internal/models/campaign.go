@@ -0,0 +1,109 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Campaign status values
+const (
+	CampaignStatusDraft     = "draft"
+	CampaignStatusScheduled = "scheduled"
+	CampaignStatusSending   = "sending"
+	CampaignStatusSent      = "sent"
+	CampaignStatusCancelled = "cancelled"
+)
+
+// Outbox message status values
+const (
+	OutboxStatusPending    = "pending"
+	OutboxStatusSending    = "sending" // Claimed by a worker and being delivered
+	OutboxStatusSent       = "sent"
+	OutboxStatusFailed     = "failed"
+	OutboxStatusCancelled  = "cancelled"
+	OutboxStatusSuppressed = "suppressed" // Withdrawn because the recipient was flagged do-not-contact
+)
+
+// OutboxKindDonorWelcome marks outbox messages in a new donor's welcome series.
+// Campaign messages have no kind.
+const OutboxKindDonorWelcome = "donor_welcome"
+
+// Campaign is a bulk email or SMS message sent to an audience of users. Messages are
+// queued in the notification outbox when the campaign is scheduled.
+type Campaign struct {
+	ID      uint   `gorm:"primaryKey" json:"id"`
+	Name    string `json:"name" gorm:"not null"`
+	Channel string `json:"channel" gorm:"not null"` // email, sms
+	Subject string `json:"subject"`
+	Body    string `json:"body" gorm:"type:text;not null"` // Go template, e.g. "Hi {{.FirstName}}"
+
+	// Audience filters
+	AudienceRoles    string `json:"audience_roles"`     // Comma-separated roles; empty means every role
+	ActiveWithinDays int    `json:"active_within_days"` // Only users who logged in within this many days; 0 means any
+	ConsentType      string `json:"consent_type"`       // Only users who granted this consent, e.g. marketing
+	AudienceTag      string `json:"audience_tag"`       // Only the cohort with this tag slug; empty means anyone
+
+	Status            string     `json:"status" gorm:"default:'draft';index"`
+	ScheduledFor      *time.Time `json:"scheduled_for" gorm:"index"`
+	ThrottlePerMinute int        `json:"throttle_per_minute" gorm:"default:60"`
+	RecipientCount    int        `json:"recipient_count"`
+	SentCount         int        `json:"sent_count"`
+	FailedCount       int        `json:"failed_count"`
+	OpenedCount       int        `json:"opened_count"`
+	StartedAt         *time.Time `json:"started_at"`
+	CompletedAt       *time.Time `json:"completed_at"`
+	CreatedBy         uint       `json:"created_by" gorm:"index"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Creator *User `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
+}
+
+// TableName specifies the table name
+func (Campaign) TableName() string {
+	return "campaigns"
+}
+
+// Roles returns the campaign's audience roles
+func (c *Campaign) Roles() []string {
+	var roles []string
+	for _, role := range strings.Split(c.AudienceRoles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// NotificationOutbox holds a rendered message waiting to be sent. A background job
+// drains it at each campaign's throttle rate; messages outside a campaign, such as a
+// donor's welcome series, are sent as they fall due.
+type NotificationOutbox struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	CampaignID    *uint      `json:"campaign_id" gorm:"index"`
+	Kind          string     `json:"kind,omitempty" gorm:"index"`
+	UserID        uint       `json:"user_id" gorm:"index"`
+	Channel       string     `json:"channel"`
+	Recipient     string     `json:"recipient"`
+	Subject       string     `json:"subject"`
+	Body          string     `json:"body" gorm:"type:text"`
+	Status        string     `json:"status" gorm:"default:'pending';index"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	AvailableAt   time.Time  `json:"available_at" gorm:"index"` // Not sent before this time
+	SentAt        *time.Time `json:"sent_at" gorm:"index"`
+	OpenedAt      *time.Time `json:"opened_at"`
+	TrackingToken string     `json:"-" gorm:"uniqueIndex"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (NotificationOutbox) TableName() string {
+	return "notification_outbox"
+}
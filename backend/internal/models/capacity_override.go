@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Capacity override statuses
+const (
+	CapacityOverridePending   = "pending"
+	CapacityOverrideApproved  = "approved" // Slots added to the day's capacity
+	CapacityOverrideRejected  = "rejected"
+	CapacityOverrideCancelled = "cancelled" // Withdrawn before a decision
+	CapacityOverrideRevoked   = "revoked"   // Slots taken back before it expired
+	CapacityOverrideExpired   = "expired"
+)
+
+// CapacityOverride is a request to take extra visits above a day's capacity, for
+// example in an emergency. Once a manager approves it the slots are added to the
+// day's capacity until it expires.
+type CapacityOverride struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Date          time.Time  `json:"date" gorm:"not null;index"`
+	Category      string     `json:"category" gorm:"type:varchar(20);not null"`
+	ExtraSlots    int        `json:"extra_slots" gorm:"not null"`
+	Reason        string     `json:"reason" gorm:"type:text;not null"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"not null;index"`
+	RequestedBy   uint       `json:"requested_by" gorm:"not null;index"`
+	DecidedBy     *uint      `json:"decided_by"`
+	DecidedAt     *time.Time `json:"decided_at"`
+	DecisionNotes string     `json:"decision_notes"`
+	EndedAt       *time.Time `json:"ended_at"` // When an approved override was revoked or expired
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	Requester User  `json:"requester,omitempty" gorm:"foreignKey:RequestedBy"`
+	Decider   *User `json:"decider,omitempty" gorm:"foreignKey:DecidedBy"`
+}
+
+// TableName specifies the table name
+func (CapacityOverride) TableName() string {
+	return "capacity_overrides"
+}
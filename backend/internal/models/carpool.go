@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+// Carpool post kinds
+const (
+	CarpoolOffer = "offer" // Driver offering seats
+	CarpoolNeed  = "need"  // Volunteer looking for a lift
+)
+
+// Carpool post status values
+const (
+	CarpoolPostOpen      = "open"
+	CarpoolPostMatched   = "matched"
+	CarpoolPostWithdrawn = "withdrawn"
+)
+
+// Carpool match status values
+const (
+	CarpoolMatchProposed  = "proposed"  // Waiting for the other volunteer to accept
+	CarpoolMatchAccepted  = "accepted"  // Both accepted; contact details are shared
+	CarpoolMatchDeclined  = "declined"  // The other volunteer said no
+	CarpoolMatchCancelled = "cancelled" // Withdrawn by a volunteer or a coordinator
+)
+
+// CarpoolDisclaimerVersion identifies the safety disclaimer wording volunteers accept
+const CarpoolDisclaimerVersion = "2026-10"
+
+// CarpoolDisclaimer is the safety disclaimer shown before volunteers can take part
+const CarpoolDisclaimer = "Lift sharing is arranged between volunteers and is not organised, checked or insured by the charity. " +
+	"Drivers must hold a valid licence and insurance and are responsible for their vehicle being roadworthy. " +
+	"Only share the contact details you are comfortable with, meet somewhere public if you prefer, and tell a coordinator " +
+	"straight away if anything makes you feel unsafe. You can withdraw at any time."
+
+// CarpoolPost is a volunteer offering or needing a lift to a shift. The full postcode
+// is never shown to other volunteers; they only see the district.
+type CarpoolPost struct {
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	ShiftID              uint      `json:"shift_id" gorm:"not null;uniqueIndex:idx_carpool_post_shift_user"`
+	UserID               uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_carpool_post_shift_user"`
+	Kind                 string    `json:"kind" gorm:"not null"` // offer, need
+	Postcode             string    `json:"-" gorm:"type:varchar(10)"`
+	Area                 string    `json:"area" gorm:"type:varchar(10);index"` // Postcode district, e.g. SE13
+	Seats                int       `json:"seats" gorm:"default:1"`             // Seats offered, or needed
+	Notes                string    `json:"notes" gorm:"type:text"`
+	Status               string    `json:"status" gorm:"default:'open';index"`
+	DisclaimerVersion    string    `json:"disclaimer_version"`
+	DisclaimerAcceptedAt time.Time `json:"disclaimer_accepted_at"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (CarpoolPost) TableName() string {
+	return "carpool_posts"
+}
+
+// CarpoolMatch pairs a driver's offer with a volunteer needing a lift. Contact details
+// are only shared once both have accepted.
+type CarpoolMatch struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	ShiftID          uint       `json:"shift_id" gorm:"not null;index"`
+	OfferPostID      uint       `json:"offer_post_id" gorm:"not null;index"`
+	NeedPostID       uint       `json:"need_post_id" gorm:"not null;index"`
+	DriverID         uint       `json:"driver_id" gorm:"not null;index"`
+	RiderID          uint       `json:"rider_id" gorm:"not null;index"`
+	ProposedBy       uint       `json:"proposed_by"`
+	Proximity        string     `json:"proximity"` // same_sector, same_district, nearby_district, same_area
+	Status           string     `json:"status" gorm:"default:'proposed';index"`
+	DriverAcceptedAt *time.Time `json:"driver_accepted_at"`
+	RiderAcceptedAt  *time.Time `json:"rider_accepted_at"`
+	CancelledBy      *uint      `json:"cancelled_by"`
+	CoordinatorNote  string     `json:"coordinator_note" gorm:"type:text"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (CarpoolMatch) TableName() string {
+	return "carpool_matches"
+}
+
+// IsParty checks if a user is the driver or rider in the match
+func (m *CarpoolMatch) IsParty(userID uint) bool {
+	return m.DriverID == userID || m.RiderID == userID
+}
+
+// IsLive checks if the match is still proposed or accepted
+func (m *CarpoolMatch) IsLive() bool {
+	return m.Status == CarpoolMatchProposed || m.Status == CarpoolMatchAccepted
+}
@@ -102,6 +102,18 @@ type InAppNotification struct {
 	User User `json:"user" gorm:"foreignKey:UserID"`
 }
 
+// InAppNotificationCreated is called after a notification is saved so it can be pushed
+// to the user's open devices. The notification center sets it.
+var InAppNotificationCreated func(notification *InAppNotification)
+
+// AfterCreate hands the new notification to the notification center
+func (n *InAppNotification) AfterCreate(tx *gorm.DB) error {
+	if InAppNotificationCreated != nil {
+		InAppNotificationCreated(n)
+	}
+	return nil
+}
+
 // NotificationLog represents a log of sent notifications
 type NotificationLog struct {
 	ID           uint           `gorm:"primaryKey" json:"id"`
@@ -137,16 +149,27 @@ type EmailTemplate struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
-// NotificationTemplate represents a notification template
+// NotificationTemplate represents a notification template. With TemplateType set it
+// is an admin's edit of a transactional email (such as volunteer_approval) that
+// replaces the built-in default; Body is then its HTML.
 type NotificationTemplate struct {
-	ID        string    `json:"id" gorm:"primaryKey"`
-	Name      string    `json:"name" gorm:"not null"`
-	Subject   string    `json:"subject" gorm:"not null"`
-	Body      string    `json:"body" gorm:"type:text;not null"`
-	Type      string    `json:"type" gorm:"not null"`
-	Category  string    `json:"category" gorm:"not null"` // 'volunteer', 'visitor', 'donor', 'system'
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           string    `json:"id" gorm:"primaryKey"`
+	Name         string    `json:"name" gorm:"not null"`
+	Subject      string    `json:"subject" gorm:"not null"`
+	Body         string    `json:"body" gorm:"type:text;not null"`
+	TextBody     string    `json:"text_body,omitempty" gorm:"type:text"` // Plain text for SMS; blank strips the HTML
+	Variables    string    `json:"variables,omitempty"`                  // Comma-separated variables the template uses
+	Type         string    `json:"type" gorm:"not null"`
+	Category     string    `json:"category" gorm:"not null"` // 'volunteer', 'visitor', 'donor', 'system'
+	TemplateType string    `json:"template_type,omitempty" gorm:"type:varchar(60);index"`
+	UpdatedBy    *uint     `json:"updated_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TransactionalTemplateID is the ID of the admin's edit of a transactional template
+func TransactionalTemplateID(templateType string) string {
+	return "transactional_" + templateType
 }
 
 // NotificationHistory represents sent notifications
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"time"
+)
+
+// ContactChannelCall is a member of staff phoning someone. Messages use the
+// notification types, such as NotificationTypeSMS.
+const ContactChannelCall = "call"
+
+// Reasons a contact attempt was suppressed
+const (
+	SuppressedDoNotSMS        = "do_not_sms"
+	SuppressedDoNotCall       = "do_not_call"
+	SuppressedVulnerableAdult = "vulnerable_adult"
+)
+
+// Where a suppressed contact attempt came from
+const (
+	SuppressionSourceNotification = "notification"
+	SuppressionSourceCampaign     = "campaign"
+	SuppressionSourceStaff        = "staff"
+)
+
+// ContactRestriction holds the do-not-contact and vulnerable adult flags staff have
+// set on an account. Do-not-SMS and do-not-call block those channels outright;
+// vulnerable adults are left out of every bulk campaign but still get messages about
+// their own visits and requests.
+type ContactRestriction struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	UserID          uint       `json:"user_id" gorm:"uniqueIndex;not null"`
+	DoNotCall       bool       `json:"do_not_call"`
+	DoNotSMS        bool       `json:"do_not_sms"`
+	VulnerableAdult bool       `json:"vulnerable_adult" gorm:"index"`
+	Reason          string     `json:"reason" gorm:"type:text"`
+	ReviewDate      *time.Time `json:"review_date" gorm:"index"`
+	SetBy           uint       `json:"set_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relationships
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName specifies the table name
+func (ContactRestriction) TableName() string {
+	return "contact_restrictions"
+}
+
+// Active reports whether any flag is set
+func (r *ContactRestriction) Active() bool {
+	return r != nil && (r.DoNotCall || r.DoNotSMS || r.VulnerableAdult)
+}
+
+// SuppressionReason returns why contacting the person on a channel is not allowed,
+// or "" when it is. Bulk messages are campaigns rather than messages about the
+// person's own visits and requests.
+func (r *ContactRestriction) SuppressionReason(channel string, bulk bool) string {
+	if r == nil {
+		return ""
+	}
+	switch {
+	case channel == NotificationTypeSMS && r.DoNotSMS:
+		return SuppressedDoNotSMS
+	case channel == ContactChannelCall && r.DoNotCall:
+		return SuppressedDoNotCall
+	case bulk && r.VulnerableAdult:
+		return SuppressedVulnerableAdult
+	}
+	return ""
+}
+
+// MaskEmail hides most of the local part of an email address
+func MaskEmail(email string) string {
+	for i, r := range email {
+		if r == '@' {
+			if i <= 1 {
+				return "*" + email[i:]
+			}
+			return email[:1] + "***" + email[i:]
+		}
+	}
+	return "***"
+}
+
+// ContactSuppressionLog records an attempt to contact someone that their contact
+// restrictions stopped, as evidence the flags are honoured
+type ContactSuppressionLog struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	UserID          uint      `json:"user_id" gorm:"index;not null"`
+	Channel         string    `json:"channel" gorm:"index"` // sms, email, call
+	Source          string    `json:"source" gorm:"index"`  // notification, campaign, staff
+	Reason          string    `json:"reason"`               // do_not_sms, do_not_call, vulnerable_adult
+	Recipient       string    `json:"recipient"`            // Masked phone number or email
+	TemplateType    string    `json:"template_type,omitempty"`
+	CampaignID      *uint     `json:"campaign_id,omitempty" gorm:"index"`
+	AttemptedBy     *uint     `json:"attempted_by,omitempty"`     // Staff member, for calls
+	FallbackChannel string    `json:"fallback_channel,omitempty"` // Channel used instead, if any
+	CreatedAt       time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name
+func (ContactSuppressionLog) TableName() string {
+	return "contact_suppression_logs"
+}
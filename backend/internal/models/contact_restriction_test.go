@@ -0,0 +1,40 @@
+package models
+
+import "testing"
+
+func TestContactRestrictionSuppressionReason(t *testing.T) {
+	tests := []struct {
+		name        string
+		restriction *ContactRestriction
+		channel     string
+		bulk        bool
+		want        string
+	}{
+		{"no restriction", nil, NotificationTypeSMS, true, ""},
+		{"do-not-SMS blocks texts", &ContactRestriction{DoNotSMS: true}, NotificationTypeSMS, false, SuppressedDoNotSMS},
+		{"do-not-SMS leaves email", &ContactRestriction{DoNotSMS: true}, NotificationTypeEmail, true, ""},
+		{"do-not-call blocks calls", &ContactRestriction{DoNotCall: true}, ContactChannelCall, false, SuppressedDoNotCall},
+		{"do-not-call leaves texts", &ContactRestriction{DoNotCall: true}, NotificationTypeSMS, false, ""},
+		{"vulnerable adult left out of campaigns", &ContactRestriction{VulnerableAdult: true}, NotificationTypeEmail, true, SuppressedVulnerableAdult},
+		{"vulnerable adult still gets own messages", &ContactRestriction{VulnerableAdult: true}, NotificationTypeEmail, false, ""},
+		{"channel flag reported before vulnerability", &ContactRestriction{DoNotSMS: true, VulnerableAdult: true}, NotificationTypeSMS, true, SuppressedDoNotSMS},
+	}
+	for _, tt := range tests {
+		if got := tt.restriction.SuppressionReason(tt.channel, tt.bulk); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMaskEmail(t *testing.T) {
+	tests := map[string]string{
+		"jane@example.org": "j***@example.org",
+		"j@example.org":    "*@example.org",
+		"not-an-email":     "***",
+	}
+	for email, want := range tests {
+		if got := MaskEmail(email); got != want {
+			t.Errorf("MaskEmail(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// Day operations
+const (
+	DayOperationOpen  = "open"
+	DayOperationClose = "close"
+)
+
+// Day operation run and step statuses
+const (
+	DayOperationPending   = "pending"
+	DayOperationRunning   = "running"
+	DayOperationCompleted = "completed"
+	DayOperationFailed    = "failed"
+)
+
+// DayOperationRun is one run of the daily open or close runbook. Running it again for
+// the same day retries only the steps that have not completed.
+type DayOperationRun struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Operation  string     `json:"operation" gorm:"type:varchar(10);not null;uniqueIndex:idx_day_operation"`
+	Day        time.Time  `json:"day" gorm:"type:date;not null;uniqueIndex:idx_day_operation"`
+	Status     string     `json:"status" gorm:"type:varchar(20);not null"`
+	StartedBy  uint       `json:"started_by"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Attempts   int        `json:"attempts" gorm:"default:1"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Steps []DayOperationStep `json:"steps" gorm:"foreignKey:RunID"`
+}
+
+// TableName specifies the table name
+func (DayOperationRun) TableName() string {
+	return "day_operation_runs"
+}
+
+// DayOperationStep is the status of one step in a day operation run
+type DayOperationStep struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	RunID      uint       `json:"run_id" gorm:"not null;index"`
+	Position   int        `json:"position"`
+	Key        string     `json:"key" gorm:"type:varchar(40);not null"`
+	Name       string     `json:"name"`
+	Status     string     `json:"status" gorm:"type:varchar(20);not null"`
+	Detail     string     `json:"detail"` // What the step did, e.g. counts
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// TableName specifies the table name
+func (DayOperationStep) TableName() string {
+	return "day_operation_steps"
+}
+
+// DailyServiceSummary is the end-of-day rollup of one service day
+type DailyServiceSummary struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	Day                time.Time `json:"day" gorm:"type:date;not null;uniqueIndex"`
+	TicketsIssued      int64     `json:"tickets_issued"`
+	VisitsCompleted    int64     `json:"visits_completed"`
+	VisitorNoShows     int64     `json:"visitor_no_shows"`
+	FoodVisits         int64     `json:"food_visits"`
+	GeneralVisits      int64     `json:"general_visits"`
+	QueueServed        int64     `json:"queue_served"`
+	AverageWaitMinutes float64   `json:"average_wait_minutes"`
+	VolunteerShifts    int64     `json:"volunteer_shifts"`
+	VolunteersAttended int64     `json:"volunteers_attended"`
+	VolunteerNoShows   int64     `json:"volunteer_no_shows"`
+	VolunteerHours     float64   `json:"volunteer_hours"`
+	GeneratedAt        time.Time `json:"generated_at"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (DailyServiceSummary) TableName() string {
+	return "daily_service_summaries"
+}
@@ -19,6 +19,33 @@ const (
 	DocumentTypeProofAddress = "proof_address"
 )
 
+// Volunteer document types
+const (
+	DocumentTypeRightToWork         = "right_to_work"
+	DocumentTypeTrainingCertificate = "training_certificate"
+	DocumentTypeFoodHygiene         = "food_hygiene"
+	DocumentTypeDBSCheck            = "dbs_check"
+)
+
+// VolunteerDocumentTypes are the document types volunteers can upload
+var VolunteerDocumentTypes = []string{
+	DocumentTypeID,
+	DocumentTypeRightToWork,
+	DocumentTypeTrainingCertificate,
+	DocumentTypeFoodHygiene,
+	DocumentTypeDBSCheck,
+}
+
+// IsVolunteerDocumentType reports whether t is a volunteer document type
+func IsVolunteerDocumentType(t string) bool {
+	for _, documentType := range VolunteerDocumentTypes {
+		if documentType == t {
+			return true
+		}
+	}
+	return false
+}
+
 // Document represents a user-uploaded document for verification
 type Document struct {
 	ID              uint           `gorm:"primaryKey" json:"id"`
@@ -35,10 +62,13 @@ type Document struct {
 	VerifiedAt      *time.Time     `json:"verified_at"`
 	UploadedAt      time.Time      `json:"uploaded_at"`
 	RejectionReason string         `json:"rejection_reason"`
+	RejectionCode   string         `json:"rejection_code" gorm:"type:varchar(50);index"`
 	Notes           string         `json:"notes"`      // Administrative notes
 	ExpiresAt       *time.Time     `json:"expires_at"` // When document expires
 	IsPrivate       bool           `json:"is_private"` // Is document private
 	Checksum        string         `json:"checksum"`   // MD5 or SHA checksum
+	ExpiryNoticeAt  *time.Time     `json:"-"`          // When the owner was warned about expiry
+	Source          string         `json:"source"`     // upload or email; blank for older documents
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
@@ -48,6 +78,11 @@ type Document struct {
 	VerifiedByUser *User `json:"-" gorm:"foreignKey:VerifiedBy"`
 }
 
+// IsValidOn reports whether the document is approved and has not expired by t
+func (d *Document) IsValidOn(t time.Time) bool {
+	return d.Status == DocumentStatusApproved && (d.ExpiresAt == nil || d.ExpiresAt.After(t))
+}
+
 // CanViewDocument checks if a user can view the document based on their ID and role
 func (d *Document) CanViewDocument(userID uint, role string) bool {
 	// Owner can always view their own documents
@@ -98,6 +133,7 @@ type DocumentVerificationResult struct {
 	Status          string    `json:"status"`
 	Notes           string    `json:"notes"`
 	RejectionReason string    `json:"rejection_reason"` // Only set when status is rejected
+	RejectionCode   string    `json:"rejection_code" gorm:"type:varchar(50);index"`
 	VerifiedAt      time.Time `json:"verified_at"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
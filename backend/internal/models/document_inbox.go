@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Document sources
+const (
+	DocumentSourceUpload = "upload" // Uploaded through the website or app
+	DocumentSourceEmail  = "email"  // Sent as an email attachment to the visitor's upload address
+)
+
+// Inbound document message status values
+const (
+	InboundDocumentProcessed = "processed" // At least one attachment was filed
+	InboundDocumentRejected  = "rejected"  // Nothing could be filed; see Reason
+	InboundDocumentUnmatched = "unmatched" // No active upload address matched the recipient
+)
+
+// DocumentInbox is a visitor's private upload address for sending document photos by
+// message when they cannot use the website. The token is the only credential, so it
+// can be rotated if it is shared by mistake.
+type DocumentInbox struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         uint       `json:"user_id" gorm:"not null;uniqueIndex"`
+	Token          string     `json:"-" gorm:"type:varchar(32);not null;uniqueIndex"`
+	IsActive       bool       `json:"is_active" gorm:"default:true"`
+	LastReceivedAt *time.Time `json:"last_received_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (DocumentInbox) TableName() string {
+	return "document_inboxes"
+}
+
+// InboundDocumentMessage records each message received by the ingestion webhook
+type InboundDocumentMessage struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Channel     string    `json:"channel" gorm:"index"` // email
+	InboxID     *uint     `json:"inbox_id" gorm:"index"`
+	UserID      *uint     `json:"user_id" gorm:"index"`
+	Sender      string    `json:"sender"`
+	Subject     string    `json:"subject"`
+	Attachments int       `json:"attachments"`
+	Filed       int       `json:"filed"`
+	DocumentIDs string    `json:"document_ids"` // Comma separated IDs of documents created
+	Status      string    `json:"status" gorm:"index"`
+	Reason      string    `json:"reason" gorm:"type:text"`
+	ReceivedAt  time.Time `json:"received_at" gorm:"index"`
+}
+
+// TableName specifies the table name
+func (InboundDocumentMessage) TableName() string {
+	return "inbound_document_messages"
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// DocumentRejectionReason is one of the managed reasons a verifier can give for
+// rejecting a document, with an explanation written for the visitor
+type DocumentRejectionReason struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Code          string    `json:"code" gorm:"type:varchar(50);uniqueIndex;not null"` // Stored on rejected documents for analytics
+	Label         string    `json:"label" gorm:"not null"`                             // Short name shown to verifiers
+	Explanation   string    `json:"explanation" gorm:"type:text;not null"`             // What went wrong and how to fix it, shown to the visitor
+	DocumentTypes string    `json:"document_types"`                                    // Comma-separated document types it applies to; blank for all
+	IsActive      bool      `json:"is_active" gorm:"default:true"`
+	SortOrder     int       `json:"sort_order" gorm:"default:0"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (DocumentRejectionReason) TableName() string {
+	return "document_rejection_reasons"
+}
+
+// AppliesTo reports whether the reason can be given for a document type
+func (r *DocumentRejectionReason) AppliesTo(documentType string) bool {
+	if strings.TrimSpace(r.DocumentTypes) == "" {
+		return true
+	}
+	for _, t := range strings.Split(r.DocumentTypes, ",") {
+		if strings.TrimSpace(t) == documentType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// Donation drive status values
+const (
+	DonationDriveDraft  = "draft"
+	DonationDriveActive = "active"
+	DonationDriveClosed = "closed"
+)
+
+// Drive team kinds
+var DriveTeamKinds = []string{"school", "community_group", "workplace", "faith_group", "other"}
+
+// DonationDrive is a time-limited goods collection where schools and community groups
+// compete as teams. Drop-offs are attributed to a team by its code.
+type DonationDrive struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `json:"name" gorm:"not null"`
+	Slug        string    `json:"slug" gorm:"type:varchar(80);not null;uniqueIndex"` // Used in the public leaderboard URL
+	Description string    `json:"description" gorm:"type:text"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	Status      string    `json:"status" gorm:"default:draft;index"`
+	CreatedBy   uint      `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (DonationDrive) TableName() string {
+	return "donation_drives"
+}
+
+// AcceptsDropoffs reports whether drop-offs can be attributed to the drive at a time
+func (d *DonationDrive) AcceptsDropoffs(now time.Time) bool {
+	return d.Status == DonationDriveActive && !now.Before(d.StartsAt) && !now.After(d.EndsAt)
+}
+
+// DriveTeam is a school or group taking part in a drive
+type DriveTeam struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	DriveID      uint      `json:"drive_id" gorm:"not null;index"`
+	Name         string    `json:"name" gorm:"not null"`
+	Code         string    `json:"code" gorm:"type:varchar(16);not null;uniqueIndex"` // Given to the team to quote at drop-off
+	Kind         string    `json:"kind"`
+	ContactName  string    `json:"contact_name"`
+	ContactEmail string    `json:"contact_email"`
+	Participants int       `json:"participants"` // Number of people taking part, for certificates
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (DriveTeam) TableName() string {
+	return "drive_teams"
+}
+
+// DriveDropoff records goods received for a team, weighed at intake
+type DriveDropoff struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	DriveID    uint      `json:"drive_id" gorm:"not null;index"`
+	TeamID     uint      `json:"team_id" gorm:"not null;index"`
+	DonationID *uint     `json:"donation_id" gorm:"index"`
+	WeightKg   float64   `json:"weight_kg"`
+	Items      int       `json:"items"`
+	Notes      string    `json:"notes"`
+	RecordedBy uint      `json:"recorded_by"`
+	ReceivedAt time.Time `json:"received_at" gorm:"index"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (DriveDropoff) TableName() string {
+	return "drive_dropoffs"
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Pledge status values
+const (
+	PledgeStatusPledged       = "pledged"
+	PledgeStatusInvoiced      = "invoiced"
+	PledgeStatusPartiallyPaid = "partially_paid"
+	PledgeStatusPaid          = "paid"
+	PledgeStatusCancelled     = "cancelled"
+)
+
+// Pledge payment sources
+const (
+	PledgePaymentSourceManual   = "manual"
+	PledgePaymentSourceBankFeed = "bank_feed"
+)
+
+// ErrPledgeOverpayment is returned when a payment is larger than the pledge's outstanding balance
+var ErrPledgeOverpayment = errors.New("payment exceeds the outstanding balance")
+
+// DonationPledge represents an amount a business has pledged and will pay later by invoice
+type DonationPledge struct {
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	BusinessName     string         `json:"business_name" gorm:"not null;index"`
+	ContactName      string         `json:"contact_name"`
+	ContactEmail     string         `json:"contact_email"`
+	ContactPhone     string         `json:"contact_phone"`
+	BillingAddress   string         `json:"billing_address" gorm:"type:text"`
+	PurchaseOrder    string         `json:"purchase_order"`
+	Amount           float64        `json:"amount" gorm:"not null"`
+	AmountPaid       float64        `json:"amount_paid" gorm:"default:0"`
+	Currency         string         `json:"currency" gorm:"default:GBP"`
+	PaymentReference string         `json:"payment_reference" gorm:"uniqueIndex;not null"` // Quoted by the business on their bank transfer
+	InvoiceNumber    string         `json:"invoice_number" gorm:"index"`
+	Status           string         `json:"status" gorm:"default:pledged;index"`
+	PledgedAt        time.Time      `json:"pledged_at"`
+	DueDate          time.Time      `json:"due_date" gorm:"index"`
+	InvoicedAt       *time.Time     `json:"invoiced_at"`
+	PaidAt           *time.Time     `json:"paid_at"`
+	DonationID       *uint          `json:"donation_id" gorm:"index"` // Donation recorded once the pledge is fully paid
+	CreatedBy        uint           `json:"created_by"`
+	Notes            string         `json:"notes" gorm:"type:text"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Payments []PledgePayment `json:"payments,omitempty" gorm:"foreignKey:PledgeID"`
+	Donation *Donation       `json:"donation,omitempty" gorm:"foreignKey:DonationID"`
+}
+
+// TableName specifies the table name
+func (DonationPledge) TableName() string {
+	return "donation_pledges"
+}
+
+// OutstandingAmount returns how much of the pledge is still unpaid
+func (p *DonationPledge) OutstandingAmount() float64 {
+	outstanding := p.Amount - p.AmountPaid
+	if outstanding < 0 {
+		return 0
+	}
+	return outstanding
+}
+
+// IsOpen returns true if the pledge still expects payment
+func (p *DonationPledge) IsOpen() bool {
+	return p.Status != PledgeStatusPaid && p.Status != PledgeStatusCancelled
+}
+
+// DaysOverdue returns the number of days past the due date, counting any part of a
+// day as a whole one, or 0 if not overdue
+func (p *DonationPledge) DaysOverdue(now time.Time) int {
+	if !p.IsOpen() || !now.After(p.DueDate) {
+		return 0
+	}
+	return int(math.Ceil(now.Sub(p.DueDate).Hours() / 24))
+}
+
+// ApplyPayment adds a payment to the pledge and updates its status. Payments larger
+// than the outstanding balance are rejected so they can be refunded or recorded as a
+// separate donation.
+func (p *DonationPledge) ApplyPayment(amount float64, paidOn time.Time) error {
+	if amount-p.OutstandingAmount() > 0.005 {
+		return fmt.Errorf("%w: %.2f paid against %.2f outstanding", ErrPledgeOverpayment, amount, p.OutstandingAmount())
+	}
+
+	p.AmountPaid += amount
+	if p.OutstandingAmount() <= 0.005 {
+		p.Status = PledgeStatusPaid
+		p.PaidAt = &paidOn
+		return nil
+	}
+	if p.AmountPaid > 0 {
+		p.Status = PledgeStatusPartiallyPaid
+	}
+	return nil
+}
+
+// PledgePayment records money received against a pledge
+type PledgePayment struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	PledgeID      uint           `json:"pledge_id" gorm:"not null;index"`
+	Amount        float64        `json:"amount" gorm:"not null"`
+	PaidOn        time.Time      `json:"paid_on"`
+	Source        string         `json:"source" gorm:"default:manual"` // manual, bank_feed
+	BankReference string         `json:"bank_reference"`
+	BankLineKey   *string        `json:"-" gorm:"uniqueIndex"` // Date, amount and reference of a bank feed line, so it is only recorded once
+	RecordedBy    *uint          `json:"recorded_by"`
+	Notes         string         `json:"notes"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Pledge         DonationPledge `json:"-" gorm:"foreignKey:PledgeID"`
+	RecordedByUser *User          `json:"recorded_by_user,omitempty" gorm:"foreignKey:RecordedBy"`
+}
+
+// TableName specifies the table name
+func (PledgePayment) TableName() string {
+	return "pledge_payments"
+}
+
+// PledgeBankLineKey identifies a bank statement line by its date, amount and reference
+func PledgeBankLineKey(paidOn time.Time, amount float64, reference string) string {
+	return fmt.Sprintf("%s|%.2f|%s", paidOn.Format("2006-01-02"), amount,
+		strings.ToUpper(strings.Join(strings.Fields(reference), "")))
+}
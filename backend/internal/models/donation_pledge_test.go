@@ -0,0 +1,85 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestApplyPaymentPartialThenFull(t *testing.T) {
+	pledge := DonationPledge{Amount: 500, Status: PledgeStatusInvoiced}
+	paidOn := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	if err := pledge.ApplyPayment(200, paidOn); err != nil {
+		t.Fatalf("part payment: %v", err)
+	}
+	if pledge.Status != PledgeStatusPartiallyPaid || pledge.OutstandingAmount() != 300 {
+		t.Fatalf("after part payment got status %s, outstanding %.2f", pledge.Status, pledge.OutstandingAmount())
+	}
+	if pledge.PaidAt != nil {
+		t.Fatal("part payment should not set PaidAt")
+	}
+
+	if err := pledge.ApplyPayment(300, paidOn); err != nil {
+		t.Fatalf("final payment: %v", err)
+	}
+	if pledge.Status != PledgeStatusPaid || pledge.PaidAt == nil {
+		t.Fatalf("after final payment got status %s", pledge.Status)
+	}
+}
+
+func TestApplyPaymentRejectsOverpayment(t *testing.T) {
+	pledge := DonationPledge{Amount: 100, AmountPaid: 60, Status: PledgeStatusPartiallyPaid}
+
+	err := pledge.ApplyPayment(40.01, time.Now())
+	if !errors.Is(err, ErrPledgeOverpayment) {
+		t.Fatalf("expected ErrPledgeOverpayment, got %v", err)
+	}
+	if pledge.AmountPaid != 60 || pledge.Status != PledgeStatusPartiallyPaid {
+		t.Fatalf("rejected payment changed the pledge: paid %.2f, status %s", pledge.AmountPaid, pledge.Status)
+	}
+
+	// Rounding differences below a penny are not overpayments
+	if err := pledge.ApplyPayment(40.004, time.Now()); err != nil {
+		t.Fatalf("sub-penny difference rejected: %v", err)
+	}
+}
+
+func TestDaysOverdue(t *testing.T) {
+	due := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	pledge := DonationPledge{Amount: 100, DueDate: due, Status: PledgeStatusInvoiced}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want int
+	}{
+		{"before due", due.Add(-time.Hour), 0},
+		{"on due date", due, 0},
+		{"an hour late", due.Add(time.Hour), 1},
+		{"exactly a day late", due.Add(24 * time.Hour), 1},
+		{"just over a day late", due.Add(25 * time.Hour), 2},
+	}
+	for _, tt := range tests {
+		if got := pledge.DaysOverdue(tt.now); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	pledge.Status = PledgeStatusPaid
+	if got := pledge.DaysOverdue(due.Add(48 * time.Hour)); got != 0 {
+		t.Errorf("paid pledge: got %d, want 0", got)
+	}
+}
+
+func TestPledgeBankLineKeyNormalisesReference(t *testing.T) {
+	paidOn := time.Date(2026, 3, 1, 15, 4, 0, 0, time.UTC)
+	a := PledgeBankLineKey(paidOn, 250, "plg-1a2b 3c4d")
+	b := PledgeBankLineKey(paidOn.Add(-time.Hour), 250.00, " PLG-1A2B3C4D ")
+	if a != b {
+		t.Fatalf("keys differ: %q and %q", a, b)
+	}
+	if a == PledgeBankLineKey(paidOn, 250.01, "PLG-1A2B3C4D") {
+		t.Fatal("different amounts produced the same key")
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// DonationReceipt is an entry in the receipt register. Receipts are numbered in
+// sequence within each calendar year, REC-2026-000001 onwards, and keep the details
+// printed on them so a re-download matches what the donor was first sent.
+type DonationReceipt struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Number     string     `json:"number" gorm:"uniqueIndex;not null"`
+	Year       int        `json:"year" gorm:"uniqueIndex:idx_donation_receipt_sequence;not null"`
+	Sequence   int        `json:"sequence" gorm:"uniqueIndex:idx_donation_receipt_sequence;not null"`
+	DonationID uint       `json:"donation_id" gorm:"uniqueIndex;not null"`
+	DonorID    *uint      `json:"donor_id" gorm:"index"`
+	DonorName  string     `json:"donor_name"`
+	Email      string     `json:"email"`
+	Amount     float64    `json:"amount"`      // Money given, before any later refund
+	Currency   string     `json:"currency"`    // Empty for goods
+	GoodsValue float64    `json:"goods_value"` // Estimated value of goods
+	IssuedAt   time.Time  `json:"issued_at" gorm:"index"`
+	EmailedAt  *time.Time `json:"emailed_at"`
+	EmailError string     `json:"email_error,omitempty"` // Why the last email attempt failed
+	CreatedAt  time.Time  `json:"created_at"`
+
+	Donation *Donation `json:"donation,omitempty" gorm:"foreignKey:DonationID"`
+}
+
+// TableName specifies the table name
+func (DonationReceipt) TableName() string {
+	return "donation_receipts"
+}
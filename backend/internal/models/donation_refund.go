@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Donation refund kinds
+const (
+	RefundKindRefund     = "refund"
+	RefundKindChargeback = "chargeback"
+)
+
+// Donation refund status values
+const (
+	RefundStatusSucceeded     = "succeeded"
+	RefundStatusFailed        = "failed"
+	RefundStatusNeedsResponse = "needs_response" // Chargeback opened, evidence can be submitted
+	RefundStatusWon           = "won"            // Chargeback decided in the charity's favour
+	RefundStatusLost          = "lost"           // Chargeback decided in the donor's favour
+)
+
+// Gift Aid adjustment status values
+const (
+	GiftAidAdjustmentPending = "pending"
+	GiftAidAdjustmentApplied = "applied"
+)
+
+// GiftAidRate is the basic rate tax reclaimed on each pound donated
+const GiftAidRate = 0.25
+
+// DonationRefund records money returned to a donor, either refunded by the charity
+// or taken back through a card chargeback
+type DonationRefund struct {
+	ID                uint           `gorm:"primaryKey" json:"id"`
+	DonationID        *uint          `json:"donation_id" gorm:"index"`
+	PaymentID         *uint          `json:"payment_id" gorm:"index"`
+	Kind              string         `json:"kind" gorm:"index"`
+	ProviderReference string         `json:"provider_reference" gorm:"index"` // Stripe refund or dispute ID
+	Amount            float64        `json:"amount"`
+	Currency          string         `json:"currency" gorm:"default:'GBP'"`
+	Reason            string         `json:"reason"`
+	Status            string         `json:"status" gorm:"index"`
+	InitiatedBy       *uint          `json:"initiated_by"` // Admin who issued the refund; empty for provider events
+	EvidenceDueBy     *time.Time     `json:"evidence_due_by"`
+	ResolvedAt        *time.Time     `json:"resolved_at"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Donation *Donation `json:"donation,omitempty" gorm:"foreignKey:DonationID"`
+	Payment  *Payment  `json:"payment,omitempty" gorm:"foreignKey:PaymentID"`
+}
+
+// TableName specifies the table name
+func (DonationRefund) TableName() string {
+	return "donation_refunds"
+}
+
+// GiftAidAdjustment is a reduction to Gift Aid claims for a donation that was refunded
+// or charged back. Tax already reclaimed on the amount must be offset in the next claim.
+type GiftAidAdjustment struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	DonationID     uint           `json:"donation_id" gorm:"not null;index"`
+	RefundID       uint           `json:"refund_id" gorm:"not null;index"`
+	DonorID        *uint          `json:"donor_id" gorm:"index"`
+	DonationAmount float64        `json:"donation_amount"` // Amount of the donation returned
+	TaxAmount      float64        `json:"tax_amount"`      // Gift Aid no longer claimable on it
+	Reason         string         `json:"reason"`
+	Status         string         `json:"status" gorm:"default:pending;index"`
+	ClaimReference string         `json:"claim_reference"` // HMRC claim the adjustment was included in
+	AppliedAt      *time.Time     `json:"applied_at"`
+	AppliedBy      *uint          `json:"applied_by"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Donation *Donation `json:"donation,omitempty" gorm:"foreignKey:DonationID"`
+}
+
+// TableName specifies the table name
+func (GiftAidAdjustment) TableName() string {
+	return "gift_aid_adjustments"
+}
@@ -12,6 +12,12 @@ const (
 	DonationStatusReceived  = "received"
 	DonationStatusProcessed = "processed"
 	DonationStatusCancelled = "cancelled"
+
+	// Monetary donation statuses after payment
+	DonationStatusCompleted         = "completed"
+	DonationStatusPartiallyRefunded = "partially_refunded"
+	DonationStatusRefunded          = "refunded"
+	DonationStatusDisputed          = "disputed"
 )
 
 // Donation represents a donation made to the organization
@@ -41,6 +47,10 @@ type Donation struct {
 	IsRecurring    bool           `json:"is_recurring" gorm:"default:false"` // Added for payment handler
 	SubscriptionID string         `json:"subscription_id,omitempty"`         // Added for payment handler
 	Notes          string         `json:"notes"`
+	DriveTeamID    *uint          `json:"drive_team_id" gorm:"index"` // Team credited in a donation drive
+	RefundedAmount float64        `json:"refunded_amount" gorm:"default:0"`
+	RefundedAt     *time.Time     `json:"refunded_at"`
+	GiftAidID      *uint          `json:"gift_aid_declaration_id,omitempty" gorm:"index"` // Declaration made with the donation
 	ReceivedBy     *uint          `json:"received_by"`
 	ReceivedAt     *time.Time     `json:"received_at"`
 	ProcessedBy    *uint          `json:"processed_by"`
@@ -56,21 +66,33 @@ type Donation struct {
 	ProcessedByUser *User `json:"processed_by_user,omitempty" gorm:"foreignKey:ProcessedBy"`
 }
 
-// DonationItem represents an individual item in a goods donation
+// Donation item statuses
+const (
+	DonationItemDeclared = "declared" // Listed by the donor, not yet checked in
+	DonationItemReceived = "received" // Counted at intake
+)
+
+// DonationItem represents an individual item in a goods donation. Quantity is what
+// the donor declared; ReceivedQuantity is what was counted at intake.
 type DonationItem struct {
-	ID           uint           `gorm:"primaryKey" json:"id"`
-	DonationID   uint           `json:"donation_id" gorm:"index"`
-	Name         string         `json:"name"`
-	Category     string         `json:"category"`
-	Quantity     int            `json:"quantity"`
-	Condition    string         `json:"condition"`
-	Value        float64        `json:"value"`
-	Description  string         `json:"description"`
-	Status       string         `json:"status" gorm:"default:received"`
-	InventoryTag string         `json:"inventory_tag"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	DonationID       uint           `json:"donation_id" gorm:"index"`
+	Name             string         `json:"name"`
+	Category         string         `json:"category"`
+	Quantity         int            `json:"quantity"`
+	Condition        string         `json:"condition"`
+	Value            float64        `json:"value"`
+	Description      string         `json:"description"`
+	Status           string         `json:"status" gorm:"default:declared;index"`
+	InventoryTag     string         `json:"inventory_tag"`
+	ReceivedQuantity *int           `json:"received_quantity"`
+	InventoryItemID  *uint          `json:"inventory_item_id" gorm:"index"` // Stock line the goods were booked into
+	IntakeNotes      string         `json:"intake_notes"`                   // Why the count differs, e.g. "2 tins dented"
+	ReceivedAt       *time.Time     `json:"received_at"`
+	ReceivedBy       *uint          `json:"received_by"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
 	Donation Donation `json:"-" gorm:"foreignKey:DonationID"`
@@ -137,6 +159,8 @@ type UrgentNeed struct {
 	FulfilledBy  *uint          `json:"fulfilled_by"`
 	Notes        string         `json:"notes" gorm:"type:text"`
 	IsPublic     bool           `json:"is_public" gorm:"default:true"`      // Public by default for donor visibility
+	SupplierID   *uint          `json:"supplier_id" gorm:"index"`           // Supplier reordered from when stock runs low
+	ReorderLevel int            `json:"reorder_level" gorm:"default:0"`     // Reorder at or below this stock; 0 uses the low stock level
 	LastUpdated  time.Time      `json:"last_updated" gorm:"autoUpdateTime"` // Real-time tracking
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
@@ -161,6 +185,15 @@ func (un *UrgentNeed) IsLowStock() bool {
 	return float64(un.CurrentStock)/float64(un.TargetStock) < 0.3
 }
 
+// NeedsReorder returns true once stock has fallen to the reorder level, or below the
+// low stock level when no reorder level is set
+func (un *UrgentNeed) NeedsReorder() bool {
+	if un.ReorderLevel > 0 {
+		return un.CurrentStock <= un.ReorderLevel
+	}
+	return un.IsLowStock()
+}
+
 // IsCriticalStock returns true if current stock is below 10% of target
 func (un *UrgentNeed) IsCriticalStock() bool {
 	if un.TargetStock == 0 {
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// How a donor first came to give
+const (
+	AcquisitionSourceCampaign      = "campaign"       // Followed a campaign link or code
+	AcquisitionSourceReferral      = "referral"       // Referred by another donor or partner
+	AcquisitionSourceDonationDrive = "donation_drive" // Gave through a donation drive team
+	AcquisitionSourceDirect        = "direct"
+)
+
+// Causes a donor can say they are interested in
+const (
+	DonorCauseFood             = "food"
+	DonorCauseClothing         = "clothing"
+	DonorCauseHousehold        = "household"
+	DonorCauseEmergencySupport = "emergency_support"
+	DonorCauseAdviceAndSupport = "advice_and_support"
+	DonorCauseVolunteering     = "volunteering"
+	DonorCauseWhereNeededMost  = "where_needed_most"
+)
+
+// maxDonorAcquisitionCodeSize is the longest campaign or referral code kept
+const maxDonorAcquisitionCodeSize = 50
+
+// DonorCauses lists the causes in display order
+var DonorCauses = []string{
+	DonorCauseFood, DonorCauseClothing, DonorCauseHousehold, DonorCauseEmergencySupport,
+	DonorCauseAdviceAndSupport, DonorCauseVolunteering, DonorCauseWhereNeededMost,
+}
+
+// DonorOnboarding starts with a donor's first donation. It records how they found us
+// for attribution, the welcome emails queued for them, and the preferences they set
+// when prompted. Donors are matched by email, as many give without an account.
+type DonorOnboarding struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	Email             string     `json:"email" gorm:"uniqueIndex;not null"` // Lowercased
+	UserID            *uint      `json:"user_id" gorm:"index"`
+	FirstDonationID   uint       `json:"first_donation_id"`
+	FirstDonationAt   time.Time  `json:"first_donation_at" gorm:"index"`
+	AcquisitionSource string     `json:"acquisition_source" gorm:"type:varchar(20);index"`
+	CampaignCode      string     `json:"campaign_code,omitempty" gorm:"type:varchar(50);index"`
+	ReferralCode      string     `json:"referral_code,omitempty" gorm:"type:varchar(50)"`
+	WelcomeQueued     int        `json:"welcome_queued"` // Welcome series emails queued in the outbox
+	Causes            string     `json:"causes"`         // Comma-separated causes of interest
+	MarketingOptIn    bool       `json:"marketing_opt_in"`
+	PreferencesSetAt  *time.Time `json:"preferences_set_at"`
+	PreferencesToken  string     `json:"-" gorm:"uniqueIndex"` // Hash of the token in the welcome email link
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (DonorOnboarding) TableName() string {
+	return "donor_onboardings"
+}
+
+// CauseList returns the donor's causes of interest
+func (d *DonorOnboarding) CauseList() []string {
+	causes := []string{}
+	for _, cause := range strings.Split(d.Causes, ",") {
+		if cause = strings.TrimSpace(cause); cause != "" {
+			causes = append(causes, cause)
+		}
+	}
+	return causes
+}
+
+// IsDonorCause reports whether a cause is known
+func IsDonorCause(cause string) bool {
+	for _, c := range DonorCauses {
+		if c == cause {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeAcquisitionCode tidies a campaign or referral code so the same code typed
+// differently is counted together
+func NormalizeAcquisitionCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) > maxDonorAcquisitionCodeSize {
+		code = code[:maxDonorAcquisitionCodeSize]
+	}
+	return code
+}
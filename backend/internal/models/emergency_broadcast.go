@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// Reasons for messaging today's ticket holders
+const (
+	BroadcastReasonEarlyClosure = "early_closure"
+	BroadcastReasonRelocation   = "relocation"
+	BroadcastReasonDelay        = "delay"
+	BroadcastReasonOther        = "other"
+)
+
+// BroadcastReasons lists the reasons a broadcast can give
+var BroadcastReasons = []string{BroadcastReasonEarlyClosure, BroadcastReasonRelocation, BroadcastReasonDelay, BroadcastReasonOther}
+
+// Outcomes of delivering a broadcast on one channel
+const (
+	BroadcastDeliverySent    = "sent"
+	BroadcastDeliveryFailed  = "failed"
+	BroadcastDeliverySkipped = "skipped" // Channel turned off, or no address for it
+)
+
+// EmergencyBroadcast is a message sent mid-day to everyone holding a ticket for the
+// day, such as an early closure or a change of venue. It is also posted to the queue
+// display and visitor dashboards as a critical announcement.
+type EmergencyBroadcast struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	VisitDate      time.Time  `json:"visit_date" gorm:"type:date;index"`
+	Reason         string     `json:"reason" gorm:"type:varchar(20);not null"`
+	Title          string     `json:"title" gorm:"not null"`
+	Message        string     `json:"message" gorm:"type:text;not null"`
+	AnnouncementID *uint      `json:"announcement_id"`
+	Recipients     int        `json:"recipients"` // Visitors holding a ticket for the day
+	Sent           int        `json:"sent"`       // Channel deliveries that succeeded
+	Failed         int        `json:"failed"`
+	Skipped        int        `json:"skipped"`
+	SentBy         uint       `json:"sent_by"`
+	CompletedAt    *time.Time `json:"completed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	Deliveries []EmergencyBroadcastDelivery `json:"deliveries,omitempty" gorm:"foreignKey:BroadcastID"`
+}
+
+// TableName specifies the table name
+func (EmergencyBroadcast) TableName() string {
+	return "emergency_broadcasts"
+}
+
+// EmergencyBroadcastDelivery is the outcome of sending a broadcast to one visitor on
+// one channel
+type EmergencyBroadcastDelivery struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	BroadcastID uint      `json:"broadcast_id" gorm:"not null;index"`
+	VisitorID   uint      `json:"visitor_id" gorm:"not null;index"`
+	TicketID    uint      `json:"ticket_id"`
+	Channel     string    `json:"channel" gorm:"type:varchar(10);not null"` // email, sms, push, in_app
+	Status      string    `json:"status" gorm:"type:varchar(10);not null"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (EmergencyBroadcastDelivery) TableName() string {
+	return "emergency_broadcast_deliveries"
+}
+
+// IsBroadcastReason reports whether a broadcast reason is known
+func IsBroadcastReason(reason string) bool {
+	for _, r := range BroadcastReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
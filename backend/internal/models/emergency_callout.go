@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Emergency call-out status values
+const (
+	CalloutStatusOpen      = "open"
+	CalloutStatusFilled    = "filled"
+	CalloutStatusCancelled = "cancelled"
+	CalloutStatusExpired   = "expired"
+)
+
+// Emergency call-out response status values
+const (
+	CalloutResponseNotified = "notified"
+	CalloutResponseViewed   = "viewed"
+	CalloutResponseDeclined = "declined"
+	CalloutResponseAccepted = "accepted"
+	CalloutResponseClosed   = "closed" // The call-out was filled or cancelled before this volunteer responded
+)
+
+// EmergencyCallout is an urgent request for volunteers to cover a shift
+type EmergencyCallout struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	ShiftID        uint           `json:"shift_id" gorm:"not null;index"`
+	CreatedBy      uint           `json:"created_by"`
+	Reason         string         `json:"reason" gorm:"type:text"`
+	RequiredSkills string         `json:"required_skills"` // Comma-separated; empty matches all volunteers
+	Status         string         `json:"status" gorm:"default:open;index"`
+	ExpiresAt      time.Time      `json:"expires_at"`
+	NotifiedCount  int            `json:"notified_count"`
+	FilledBy       *uint          `json:"filled_by"`
+	FilledAt       *time.Time     `json:"filled_at"`
+	ClosedAt       *time.Time     `json:"closed_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Shift        Shift                      `json:"shift,omitempty" gorm:"foreignKey:ShiftID"`
+	FilledByUser *User                      `json:"filled_by_user,omitempty" gorm:"foreignKey:FilledBy"`
+	Responses    []EmergencyCalloutResponse `json:"responses,omitempty" gorm:"foreignKey:CalloutID"`
+}
+
+// TableName specifies the table name
+func (EmergencyCallout) TableName() string {
+	return "emergency_callouts"
+}
+
+// IsOpen returns true if the call-out can still be accepted
+func (c *EmergencyCallout) IsOpen(now time.Time) bool {
+	return c.Status == CalloutStatusOpen && now.Before(c.ExpiresAt)
+}
+
+// EmergencyCalloutResponse tracks how a notified volunteer responded to a call-out
+type EmergencyCalloutResponse struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	CalloutID     uint           `json:"callout_id" gorm:"not null;uniqueIndex:idx_callout_response_user"`
+	UserID        uint           `json:"user_id" gorm:"not null;uniqueIndex:idx_callout_response_user;index"`
+	Status        string         `json:"status" gorm:"default:notified;index"`
+	NotifiedAt    time.Time      `json:"notified_at"`
+	ViewedAt      *time.Time     `json:"viewed_at"`
+	RespondedAt   *time.Time     `json:"responded_at"`
+	DeclineReason string         `json:"decline_reason"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Callout EmergencyCallout `json:"callout,omitempty" gorm:"foreignKey:CalloutID"`
+	User    User             `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName specifies the table name
+func (EmergencyCalloutResponse) TableName() string {
+	return "emergency_callout_responses"
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// RedactedValue replaces the before and after values of sensitive fields
+const RedactedValue = "[redacted]"
+
+// ChangeTrackedTables maps the tables whose updates are recorded field by field to the
+// entity type used in audit logs
+var ChangeTrackedTables = map[string]string{
+	"users":            "User",
+	"help_requests":    "HelpRequest",
+	"visit_capacities": "VisitCapacity",
+	"donations":        "Donation",
+}
+
+// changeIgnoredFields change on most updates and say nothing about what was edited
+var changeIgnoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"last_login": true,
+}
+
+// changeRedactedFields are recorded as changed without their values
+var changeRedactedFields = map[string]bool{
+	"password":           true,
+	"stripe_customer_id": true,
+	"payment_id":         true,
+	"subscription_id":    true,
+}
+
+// IsChangeIgnoredField reports whether a column is left out of change history
+func IsChangeIgnoredField(column string) bool {
+	return changeIgnoredFields[column]
+}
+
+// IsChangeRedactedField reports whether a column's values must not be stored in
+// change history. Anything that looks like a credential is redacted.
+func IsChangeRedactedField(column string) bool {
+	if changeRedactedFields[column] {
+		return true
+	}
+	for _, word := range []string{"password", "token", "secret", "otp"} {
+		if strings.Contains(column, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// EntityChange records the fields changed by one update to a tracked entity
+type EntityChange struct {
+	ID         uint         `gorm:"primaryKey" json:"id"`
+	EntityType string       `json:"entity_type" gorm:"type:varchar(50);index:idx_entity_change_entity"`
+	EntityID   uint         `json:"entity_id" gorm:"index:idx_entity_change_entity"`
+	Changes    FieldChanges `json:"changes" gorm:"type:json"`
+	CreatedAt  time.Time    `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name
+func (EntityChange) TableName() string {
+	return "entity_changes"
+}
+
+// FieldChange is the before and after value of one field
+type FieldChange struct {
+	Field    string      `json:"field"`
+	Before   interface{} `json:"before"`
+	After    interface{} `json:"after"`
+	Redacted bool        `json:"redacted,omitempty"`
+}
+
+// FieldChanges is a custom type for JSON serialization
+type FieldChanges []FieldChange
+
+// Value implements the driver.Valuer interface for database storage
+func (fc FieldChanges) Value() (driver.Value, error) {
+	return json.Marshal(fc)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (fc *FieldChanges) Scan(value interface{}) error {
+	if value == nil {
+		*fc = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, fc)
+}
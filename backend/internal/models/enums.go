@@ -0,0 +1,144 @@
+package models
+
+import "strings"
+
+// EnumValue is one allowed value of an enum, with the label UIs should show and, for
+// statuses whose changes are enforced, the values it may move to next
+type EnumValue struct {
+	Value       string   `json:"value"`
+	Label       string   `json:"label"`
+	Transitions []string `json:"transitions,omitempty"`
+}
+
+// Enum is a named list of allowed values
+type Enum struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Values      []EnumValue `json:"values"`
+}
+
+// SupplierOrderTransitions lists the statuses an order may move to by a status update.
+// Delivered and partially delivered are only reached by recording a delivery.
+var SupplierOrderTransitions = map[string][]string{
+	SupplierOrderDraft:              {SupplierOrderCancelled},
+	SupplierOrderFailed:             {SupplierOrderCancelled},
+	SupplierOrderSubmitted:          {SupplierOrderAcknowledged, SupplierOrderDispatched, SupplierOrderCancelled},
+	SupplierOrderAcknowledged:       {SupplierOrderDispatched, SupplierOrderCancelled},
+	SupplierOrderDispatched:         {SupplierOrderCancelled},
+	SupplierOrderPartiallyDelivered: {SupplierOrderCancelled},
+}
+
+// AppointmentTransitions lists the statuses an appointment may move to. Attendance can
+// be corrected after it is recorded.
+var AppointmentTransitions = map[string][]string{
+	AppointmentBooked:   {AppointmentCancelled, AppointmentAttended, AppointmentNoShow},
+	AppointmentAttended: {AppointmentNoShow},
+	AppointmentNoShow:   {AppointmentAttended},
+}
+
+// enumLabels overrides labels that cannot be derived from the value
+var enumLabels = map[string]string{
+	RoleSuperAdmin:          "Super admin",
+	DocumentTypeID:          "Photo ID",
+	DocumentTypeDBSCheck:    "DBS check",
+	KudosCategoryGoingExtra: "Above and beyond",
+	ComponentDegraded:       "Degraded performance",
+}
+
+// EnumLabel returns the display label for an enum value, e.g. "ticket_issued" becomes
+// "Ticket issued"
+func EnumLabel(value string) string {
+	if label, ok := enumLabels[value]; ok {
+		return label
+	}
+	label := strings.ReplaceAll(value, "_", " ")
+	if label == "" {
+		return label
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// newEnum builds an enum from its values, attaching transitions when given
+func newEnum(name, description string, transitions map[string][]string, values ...string) Enum {
+	enum := Enum{Name: name, Description: description, Values: make([]EnumValue, 0, len(values))}
+	for _, value := range values {
+		enum.Values = append(enum.Values, EnumValue{
+			Value:       value,
+			Label:       EnumLabel(value),
+			Transitions: transitions[value],
+		})
+	}
+	if transitions != nil {
+		// Terminal states are reported with an empty list rather than omitted
+		for i := range enum.Values {
+			if enum.Values[i].Transitions == nil {
+				enum.Values[i].Transitions = []string{}
+			}
+		}
+	}
+	return enum
+}
+
+// EnumCatalog returns the canonical value lists for statuses, categories, roles and
+// priorities so frontends and validations share one source. Service categories are
+// configurable; the live list is served by /service-types.
+func EnumCatalog() []Enum {
+	return []Enum{
+		newEnum("user_roles", "Account roles", nil,
+			RoleAdmin, RoleStaff, RoleVolunteer, RoleDonor, RoleVisitor, RoleSuperAdmin),
+		newEnum("user_statuses", "Account statuses", nil,
+			StatusPending, StatusActive, StatusInactive, StatusSuspended, StatusDeactivated),
+		newEnum("priorities", "Priority levels", nil,
+			PriorityLow, PriorityNormal, PriorityMedium, PriorityHigh, PriorityUrgent, PriorityCritical),
+		newEnum("service_categories", "Built-in service categories", nil,
+			CategoryFood, CategoryGeneral, CategoryEmergency, CategorySupport),
+		newEnum("help_request_statuses", "Help request statuses", nil,
+			HelpRequestStatusPending, HelpRequestStatusApproved, HelpRequestStatusRejected, HelpRequestStatusTicketIssued,
+			HelpRequestStatusCheckedIn, HelpRequestStatusCompleted, HelpRequestStatusCancelled),
+		newEnum("ticket_statuses", "Visit ticket statuses", nil,
+			TicketStatusActive, TicketStatusUsed, TicketStatusExpired, TicketStatusCancelled),
+		newEnum("volunteer_statuses", "Volunteer statuses", nil,
+			VolunteerStatusActive, VolunteerStatusInactive, VolunteerStatusSuspended, VolunteerStatusTraining),
+		newEnum("volunteer_role_levels", "Volunteer role levels", nil,
+			VolunteerRoleGeneral, VolunteerRoleSpecialized, VolunteerRoleLead),
+		newEnum("volunteer_shift_statuses", "Shift assignment statuses", nil,
+			VolunteerShiftStatusAssigned, VolunteerShiftStatusConfirmed, VolunteerShiftStatusCompleted,
+			VolunteerShiftStatusCancelled, VolunteerShiftStatusNoShow, VolunteerShiftStatusReassigned),
+		newEnum("document_statuses", "Document review statuses", nil,
+			DocumentStatusPending, DocumentStatusApproved, DocumentStatusRejected),
+		newEnum("document_types", "Document types", nil,
+			DocumentTypeID, DocumentTypeProofAddress, DocumentTypeRightToWork, DocumentTypeTrainingCertificate,
+			DocumentTypeFoodHygiene, DocumentTypeDBSCheck),
+		newEnum("donation_types", "Donation types", nil,
+			DonationTypeMoney, DonationTypeGoods, DonationTypeTime),
+		newEnum("donation_statuses", "Donation statuses", nil,
+			DonationStatusPending, DonationStatusReceived, DonationStatusProcessed, DonationStatusCompleted,
+			DonationStatusPartiallyRefunded, DonationStatusRefunded, DonationStatusDisputed, DonationStatusCancelled),
+		newEnum("pledge_statuses", "Donation pledge statuses", nil,
+			PledgeStatusPledged, PledgeStatusInvoiced, PledgeStatusPartiallyPaid, PledgeStatusPaid, PledgeStatusCancelled),
+		newEnum("feedback_types", "Feedback types", nil,
+			FeedbackTypeVisit, FeedbackTypeVolunteer, FeedbackTypeSystem, FeedbackTypeGeneral,
+			FeedbackTypeSuggestion, FeedbackTypeComplaint),
+		newEnum("kudos_categories", "Kudos categories", nil,
+			KudosCategoryTeamwork, KudosCategoryKindness, KudosCategoryLeadership, KudosCategoryReliability,
+			KudosCategoryGoingExtra),
+		newEnum("kudos_statuses", "Kudos moderation statuses", nil,
+			KudosStatusPending, KudosStatusApproved, KudosStatusRejected, KudosStatusHidden),
+		newEnum("support_ticket_categories", "Volunteer support ticket categories", nil,
+			TicketCategoryTechnical, TicketCategoryShift, TicketCategoryTraining, TicketCategoryEmergency,
+			TicketCategoryGeneral),
+		newEnum("support_ticket_priorities", "Volunteer support ticket priorities", nil,
+			TicketPriorityLow, TicketPriorityMedium, TicketPriorityHigh, TicketPriorityUrgent),
+		newEnum("support_ticket_statuses", "Volunteer support ticket statuses", nil,
+			TicketStatusOpen, TicketStatusInProgress, TicketStatusResolved, TicketStatusClosed),
+		newEnum("appointment_statuses", "Advice appointment statuses", AppointmentTransitions,
+			AppointmentBooked, AppointmentCancelled, AppointmentAttended, AppointmentNoShow),
+		newEnum("supplier_order_statuses", "Supplier order statuses", SupplierOrderTransitions,
+			SupplierOrderDraft, SupplierOrderSubmitted, SupplierOrderAcknowledged, SupplierOrderDispatched,
+			SupplierOrderPartiallyDelivered, SupplierOrderDelivered, SupplierOrderCancelled, SupplierOrderFailed),
+		newEnum("component_statuses", "Status page component health", nil,
+			ComponentOperational, ComponentDegraded, ComponentPartial, ComponentMajor),
+		newEnum("incident_statuses", "Status page incident statuses", nil,
+			IncidentStatusInvestigating, IncidentStatusIdentified, IncidentStatusMonitoring, IncidentStatusResolved),
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import "testing"
+
+func TestEnumCatalogTransitionsStayInEnum(t *testing.T) {
+	seen := map[string]bool{}
+	for _, enum := range EnumCatalog() {
+		if seen[enum.Name] {
+			t.Fatalf("enum %s listed twice", enum.Name)
+		}
+		seen[enum.Name] = true
+
+		values := map[string]bool{}
+		for _, value := range enum.Values {
+			if value.Label == "" {
+				t.Errorf("%s.%s has no label", enum.Name, value.Value)
+			}
+			values[value.Value] = true
+		}
+		for _, value := range enum.Values {
+			for _, next := range value.Transitions {
+				if !values[next] {
+					t.Errorf("%s.%s transitions to unknown value %s", enum.Name, value.Value, next)
+				}
+			}
+		}
+	}
+}
+
+func TestEnumLabel(t *testing.T) {
+	cases := map[string]string{
+		HelpRequestStatusTicketIssued: "Ticket issued",
+		DocumentTypeDBSCheck:          "DBS check",
+		TicketStatusInProgress:        "In Progress",
+	}
+	for value, want := range cases {
+		if got := EnumLabel(value); got != want {
+			t.Errorf("EnumLabel(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Types of goods weighed when they are handed out at a visit
+const (
+	GoodsTypeFood       = "food"
+	GoodsTypeClothing   = "clothing"
+	GoodsTypeHousehold  = "household"
+	GoodsTypeToiletries = "toiletries"
+	GoodsTypeOther      = "other"
+)
+
+// GoodsTypes lists the goods types in report order
+var GoodsTypes = []string{GoodsTypeFood, GoodsTypeClothing, GoodsTypeHousehold, GoodsTypeToiletries, GoodsTypeOther}
+
+// IsGoodsType reports whether a goods type is known
+func IsGoodsType(goodsType string) bool {
+	for _, t := range GoodsTypes {
+		if t == goodsType {
+			return true
+		}
+	}
+	return false
+}
+
+// VisitGoodsWeight is the weight of one type of goods a visitor took home from a
+// visit. A visit has at most one weight per goods type.
+type VisitGoodsWeight struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	HelpRequestID uint      `json:"help_request_id" gorm:"not null;uniqueIndex:idx_visit_goods_weight"`
+	GoodsType     string    `json:"goods_type" gorm:"type:varchar(20);not null;uniqueIndex:idx_visit_goods_weight"`
+	Category      string    `json:"category" gorm:"index"`             // Service type of the visit
+	VisitDate     time.Time `json:"visit_date" gorm:"type:date;index"` // Day the goods were handed out
+	WeightKg      float64   `json:"weight_kg"`
+	RecordedBy    *uint     `json:"recorded_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (VisitGoodsWeight) TableName() string {
+	return "visit_goods_weights"
+}
+
+// EnvironmentalFactor converts the weight of redistributed goods into waste diverted
+// from landfill and greenhouse gas avoided. Factors are dated so reports for past
+// periods use the figures that applied at the time.
+type EnvironmentalFactor struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	GoodsType     string    `json:"goods_type" gorm:"type:varchar(20);not null;uniqueIndex:idx_environmental_factor_from"`
+	DiversionRate float64   `json:"diversion_rate"` // Share of the weight that would otherwise have been thrown away, 0 to 1
+	CO2ePerKg     float64   `json:"co2e_per_kg"`    // kg CO2e avoided per kg diverted
+	EffectiveFrom time.Time `json:"effective_from" gorm:"not null;uniqueIndex:idx_environmental_factor_from"`
+	Source        string    `json:"source"` // Where the figure comes from, for funders
+	CreatedBy     *uint     `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (EnvironmentalFactor) TableName() string {
+	return "environmental_factors"
+}
+
+// Impact returns the waste diverted and CO2e avoided by redistributing a weight of goods
+func (f *EnvironmentalFactor) Impact(weightKg float64) (divertedKg, co2eKg float64) {
+	divertedKg = weightKg * f.DiversionRate
+	return divertedKg, divertedKg * f.CO2ePerKg
+}
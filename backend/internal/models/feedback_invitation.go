@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// FeedbackInvitation records the feedback survey a visitor was invited to after a
+// visit was checked out. There is at most one per visit.
+type FeedbackInvitation struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	VisitID     uint       `json:"visit_id" gorm:"not null;uniqueIndex"`
+	VisitorID   uint       `json:"visitor_id" gorm:"not null;index"`
+	Channels    string     `json:"channels"` // Comma-separated channels it was sent by, e.g. "in_app,email"
+	Link        string     `json:"link"`
+	SentAt      time.Time  `json:"sent_at" gorm:"index"`
+	RespondedAt *time.Time `json:"responded_at"`
+	FeedbackID  *uint      `json:"feedback_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (FeedbackInvitation) TableName() string {
+	return "feedback_invitations"
+}
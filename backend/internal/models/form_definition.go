@@ -0,0 +1,172 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Custom form field types
+const (
+	FormFieldText        = "text"
+	FormFieldTextarea    = "textarea"
+	FormFieldNumber      = "number"
+	FormFieldSelect      = "select"
+	FormFieldMultiSelect = "multiselect"
+	FormFieldCheckbox    = "checkbox"
+	FormFieldDate        = "date"
+)
+
+// FormFieldTypes lists the supported field types
+var FormFieldTypes = []string{
+	FormFieldText, FormFieldTextarea, FormFieldNumber, FormFieldSelect,
+	FormFieldMultiSelect, FormFieldCheckbox, FormFieldDate,
+}
+
+// FormDefinition holds the extra questions asked when a visitor requests a service
+// type, such as school uniform sizes. Each save bumps the version so stored answers
+// can be traced to the questions that were asked.
+type FormDefinition struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	ServiceTypeID uint       `json:"service_type_id" gorm:"not null;uniqueIndex"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description" gorm:"type:text"`
+	Fields        FormFields `json:"fields" gorm:"type:json"`
+	Version       int        `json:"version" gorm:"default:1"`
+	IsActive      bool       `json:"is_active" gorm:"default:true"`
+	UpdatedBy     *uint      `json:"updated_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	ServiceType ServiceType `json:"-" gorm:"foreignKey:ServiceTypeID"`
+}
+
+// TableName specifies the table name
+func (FormDefinition) TableName() string {
+	return "form_definitions"
+}
+
+// FormField is a single question on a form
+type FormField struct {
+	Key       string   `json:"key"` // Lowercase identifier the answer is stored under
+	Label     string   `json:"label"`
+	Type      string   `json:"type"`
+	Required  bool     `json:"required"`
+	HelpText  string   `json:"help_text,omitempty"`
+	Options   []string `json:"options,omitempty"`    // For select and multiselect
+	Min       *float64 `json:"min,omitempty"`        // For number
+	Max       *float64 `json:"max,omitempty"`        // For number
+	MaxLength int      `json:"max_length,omitempty"` // For text and textarea
+}
+
+// HasOption checks if a value is one of the field's options
+func (f *FormField) HasOption(value string) bool {
+	for _, option := range f.Options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+// FormFields is a custom type for JSON serialization
+type FormFields []FormField
+
+// Value implements the driver.Valuer interface for database storage
+func (ff FormFields) Value() (driver.Value, error) {
+	return json.Marshal(ff)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (ff *FormFields) Scan(value interface{}) error {
+	if value == nil {
+		*ff = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, ff)
+}
+
+// FormAnswer is a visitor's answer to a custom question. The label is kept with the
+// answer so it still reads correctly after the form is changed.
+type FormAnswer struct {
+	Key   string      `json:"key"`
+	Label string      `json:"label"`
+	Value interface{} `json:"value"`
+}
+
+// DisplayValue formats the answer for exports and emails
+func (a FormAnswer) DisplayValue() string {
+	switch v := a.Value.(type) {
+	case nil:
+		return ""
+	case bool:
+		if v {
+			return "Yes"
+		}
+		return "No"
+	case []string:
+		return strings.Join(v, ", ")
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// FormAnswers is a custom type for JSON serialization
+type FormAnswers []FormAnswer
+
+// Value implements the driver.Valuer interface for database storage
+func (fa FormAnswers) Value() (driver.Value, error) {
+	if fa == nil {
+		return nil, nil
+	}
+	return json.Marshal(fa)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (fa *FormAnswers) Scan(value interface{}) error {
+	if value == nil {
+		*fa = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, fa)
+}
+
+// Find returns the answer stored under a key
+func (fa FormAnswers) Find(key string) (FormAnswer, bool) {
+	for _, answer := range fa {
+		if answer.Key == key {
+			return answer, true
+		}
+	}
+	return FormAnswer{}, false
+}
+
+// Summary formats all answers on one line, e.g. for a CSV column
+func (fa FormAnswers) Summary() string {
+	parts := make([]string, 0, len(fa))
+	for _, answer := range fa {
+		parts = append(parts, answer.Label+": "+answer.DisplayValue())
+	}
+	return strings.Join(parts, "; ")
+}
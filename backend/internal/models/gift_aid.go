@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+)
+
+// How a Gift Aid declaration was made
+const (
+	GiftAidMethodOnline  = "online"
+	GiftAidMethodWritten = "written"
+	GiftAidMethodOral    = "oral" // Recorded by staff over the phone or in person
+)
+
+// GiftAidBackdateYears is how far back a declaration can cover donations
+const GiftAidBackdateYears = 4
+
+// GiftAidDeclaration records a donor's confirmation that they pay enough UK tax for
+// the charity to claim Gift Aid on their donations. HMRC needs the donor's name,
+// house name or number and postcode on each claim, so they are kept as declared.
+type GiftAidDeclaration struct {
+	ID                uint   `gorm:"primaryKey" json:"id"`
+	UserID            *uint  `json:"user_id" gorm:"index"`     // Donor account; empty for a guest donation
+	DonationID        *uint  `json:"donation_id" gorm:"index"` // Donation the declaration was made with
+	Title             string `json:"title"`
+	FirstName         string `json:"first_name" gorm:"not null"`
+	LastName          string `json:"last_name" gorm:"not null"`
+	HouseNameOrNumber string `json:"house_name_or_number" gorm:"not null"`
+	Postcode          string `json:"postcode"`
+	Overseas          bool   `json:"overseas"` // Lives outside the UK, so has no postcode
+	Method            string `json:"method" gorm:"not null"`
+	CoversPast        bool   `json:"covers_past"` // Also covers donations made in the previous four years
+
+	DeclaredAt    time.Time  `json:"declared_at"`
+	EffectiveFrom time.Time  `json:"effective_from"` // Earliest donation date covered
+	CancelledAt   *time.Time `json:"cancelled_at" gorm:"index"`
+	CancelledBy   *uint      `json:"cancelled_by,omitempty"`
+	CancelReason  string     `json:"cancel_reason,omitempty"`
+	RecordedBy    *uint      `json:"recorded_by,omitempty"` // Staff member who took an oral or written declaration
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (GiftAidDeclaration) TableName() string {
+	return "gift_aid_declarations"
+}
+
+// Covers reports whether a donation made at a time is covered by the declaration.
+// Cancelling a declaration stops it covering donations made after the cancellation.
+func (d *GiftAidDeclaration) Covers(donatedAt time.Time) bool {
+	if donatedAt.Before(d.EffectiveFrom) {
+		return false
+	}
+	return d.CancelledAt == nil || donatedAt.Before(*d.CancelledAt)
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Help request wizard steps, in the order the visitor completes them
+const (
+	WizardStepCategory  = "category"  // Which service they need
+	WizardStepNeeds     = "needs"     // Details, urgency, household and access needs
+	WizardStepVisit     = "visit"     // Visit day and time slot
+	WizardStepQuestions = "questions" // The service type's extra questions
+)
+
+// WizardStepSubmit is where a visitor who completed every step but never submitted
+// dropped off
+const WizardStepSubmit = "submit"
+
+// HelpRequestWizardSteps lists the wizard steps in order
+var HelpRequestWizardSteps = []string{WizardStepCategory, WizardStepNeeds, WizardStepVisit, WizardStepQuestions}
+
+// Help request draft statuses
+const (
+	HelpRequestDraftActive    = "active"
+	HelpRequestDraftSubmitted = "submitted"
+	HelpRequestDraftExpired   = "expired"   // Not touched for the draft lifetime
+	HelpRequestDraftDiscarded = "discarded" // Thrown away by the visitor
+)
+
+// HelpRequestDraft holds a visitor's partly completed help request so they can
+// resume it later. A visitor has at most one active draft. Answers are cleared when
+// the draft ends, but the row is kept so drop-off can be measured per step.
+type HelpRequestDraft struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	VisitorID     uint       `json:"visitor_id" gorm:"index;not null"`
+	Status        string     `json:"status" gorm:"type:varchar(20);index;default:'active'"`
+	FurthestStep  string     `json:"furthest_step" gorm:"type:varchar(20)"` // Last step completed in order; empty before the first
+	Answers       string     `json:"-" gorm:"type:text"`                    // JSON of the answers so far
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"index"`
+	EndedAt       *time.Time `json:"ended_at,omitempty"`
+	HelpRequestID *uint      `json:"help_request_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (HelpRequestDraft) TableName() string {
+	return "help_request_drafts"
+}
+
+// WizardStepIndex returns a step's position in the wizard, or -1 when it is unknown
+func WizardStepIndex(step string) int {
+	for i, s := range HelpRequestWizardSteps {
+		if s == step {
+			return i
+		}
+	}
+	return -1
+}
+
+// NextStep returns the step the draft resumes at: the first one not yet completed, or
+// WizardStepSubmit when every step is
+func (d *HelpRequestDraft) NextStep() string {
+	next := WizardStepIndex(d.FurthestStep) + 1
+	if next >= len(HelpRequestWizardSteps) {
+		return WizardStepSubmit
+	}
+	return HelpRequestWizardSteps[next]
+}
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// Historic import kinds, one CSV template each
+const (
+	HistoricImportVisitors       = "visitors"
+	HistoricImportDonations      = "donations"
+	HistoricImportVolunteerHours = "volunteer_hours"
+)
+
+// Historic import batch statuses
+const (
+	HistoricImportStaged     = "staged"    // Parsed and waiting for review
+	HistoricImportCommitted  = "committed" // Valid rows written to the live tables
+	HistoricImportRolledBack = "rolled_back"
+	HistoricImportDiscarded  = "discarded" // Abandoned before it was committed
+)
+
+// Historic import row statuses
+const (
+	HistoricRowValid     = "valid"
+	HistoricRowInvalid   = "invalid"
+	HistoricRowDuplicate = "duplicate" // Matches an existing record or an earlier row, skipped
+	HistoricRowImported  = "imported"
+)
+
+// HistoricImportBatch is one spreadsheet of past records staged for import
+type HistoricImportBatch struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Kind           string     `json:"kind" gorm:"size:30;not null;index"`
+	FileName       string     `json:"file_name"`
+	Status         string     `json:"status" gorm:"size:20;not null;default:'staged';index"`
+	TotalRows      int        `json:"total_rows"`
+	ValidRows      int        `json:"valid_rows"`
+	InvalidRows    int        `json:"invalid_rows"`
+	DuplicateRows  int        `json:"duplicate_rows"`
+	ImportedRows   int        `json:"imported_rows"`
+	CreatedBy      uint       `json:"created_by" gorm:"index"`
+	CommittedBy    *uint      `json:"committed_by"`
+	CommittedAt    *time.Time `json:"committed_at"`
+	RolledBackBy   *uint      `json:"rolled_back_by"`
+	RolledBackAt   *time.Time `json:"rolled_back_at"`
+	RollbackReason string     `json:"rollback_reason,omitempty" gorm:"type:text"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relationships
+	Rows []HistoricImportRow `json:"rows,omitempty" gorm:"foreignKey:BatchID"`
+}
+
+// TableName specifies the table name
+func (HistoricImportBatch) TableName() string {
+	return "historic_import_batches"
+}
+
+// HistoricImportRow is one spreadsheet row of a batch with its validation result and,
+// once committed, the record it created
+type HistoricImportRow struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	BatchID     uint   `json:"batch_id" gorm:"not null;index"`
+	RowNumber   int    `json:"row_number"`
+	Data        string `json:"-" gorm:"type:text"` // JSON of the row's values by column
+	Errors      string `json:"-" gorm:"type:text"` // JSON array of validation problems
+	Status      string `json:"status" gorm:"size:20;not null;index"`
+	MatchedType string `json:"matched_type,omitempty" gorm:"size:30"` // What the row duplicates
+	MatchedID   *uint  `json:"matched_id,omitempty"`
+	MatchReason string `json:"match_reason,omitempty"`
+	// Records written on commit, removed again on rollback
+	CreatedType string    `json:"created_type,omitempty" gorm:"size:30"`
+	CreatedID   *uint     `json:"created_id,omitempty"`
+	ExtraID     *uint     `json:"extra_id,omitempty"` // The visitor profile or shift written alongside
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (HistoricImportRow) TableName() string {
+	return "historic_import_rows"
+}
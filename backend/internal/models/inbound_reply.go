@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Records an inbound reply can be threaded onto
+const (
+	InboundReplyHelpRequest = "help_request"
+	InboundReplyFeedback    = "feedback" // Visit feedback
+)
+
+// Inbound reply status values
+const (
+	InboundReplyMatched   = "matched"   // Threaded onto a help request or feedback record
+	InboundReplyUnmatched = "unmatched" // No record matched, or the sender does not own it; see Reason
+)
+
+// InboundReply is an email reply to a help request or feedback notification, received
+// by the provider's inbound parse webhook and threaded onto the originating record
+type InboundReply struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	Provider        string    `json:"provider"` // sendgrid, mailgun
+	MessageID       *string   `json:"message_id,omitempty" gorm:"uniqueIndex"`
+	EntityType      string    `json:"entity_type" gorm:"index:idx_inbound_reply_entity"`
+	EntityID        *uint     `json:"entity_id" gorm:"index:idx_inbound_reply_entity"`
+	UserID          *uint     `json:"user_id" gorm:"index"`
+	Sender          string    `json:"sender"`
+	Subject         string    `json:"subject"`
+	Body            string    `json:"body" gorm:"type:text"` // Reply text with quoted history removed
+	Status          string    `json:"status" gorm:"index"`
+	Reason          string    `json:"reason" gorm:"type:text"`
+	NotifiedStaffID *uint     `json:"notified_staff_id"`
+	ReceivedAt      time.Time `json:"received_at" gorm:"index"`
+}
+
+// TableName specifies the table name
+func (InboundReply) TableName() string {
+	return "inbound_replies"
+}
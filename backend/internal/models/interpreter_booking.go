@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Interpreter booking statuses
+const (
+	InterpreterBookingRequested = "requested" // Task raised for staff, not sent to a provider
+	InterpreterBookingSent      = "sent"      // Waiting for the provider to confirm
+	InterpreterBookingConfirmed = "confirmed"
+	InterpreterBookingDeclined  = "declined"
+	InterpreterBookingFailed    = "failed" // The provider could not be reached; book by hand
+	InterpreterBookingCancelled = "cancelled"
+)
+
+// InterpreterBooking tracks securing an interpreter for a visitor's help request
+type InterpreterBooking struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	HelpRequestID   uint       `json:"help_request_id" gorm:"not null;uniqueIndex"`
+	VisitorID       uint       `json:"visitor_id" gorm:"not null;index"`
+	Reference       string     `json:"reference" gorm:"size:50"` // Help request reference, quoted to the provider
+	Language        string     `json:"language" gorm:"size:100;not null"`
+	VisitDay        string     `json:"visit_day" gorm:"size:20;index"`
+	TimeSlot        string     `json:"time_slot" gorm:"size:20"`
+	Status          string     `json:"status" gorm:"size:20;not null;default:'requested';index"`
+	TaskID          *uint      `json:"task_id"`                            // Staff task to secure the interpreter
+	ProviderRef     string     `json:"provider_ref" gorm:"size:100;index"` // Booking reference from the interpreter service
+	ProviderError   string     `json:"provider_error,omitempty" gorm:"type:text"`
+	InterpreterName string     `json:"interpreter_name"`
+	Notes           string     `json:"notes" gorm:"type:text"`
+	ConfirmedAt     *time.Time `json:"confirmed_at"`
+	UpdatedBy       *uint      `json:"updated_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (InterpreterBooking) TableName() string {
+	return "interpreter_bookings"
+}
+
+// IsSecured reports whether an interpreter has been confirmed
+func (b *InterpreterBooking) IsSecured() bool {
+	return b.Status == InterpreterBookingConfirmed
+}
+
+// IsOpen reports whether the booking still needs an interpreter
+func (b *InterpreterBooking) IsOpen() bool {
+	return b.Status != InterpreterBookingConfirmed && b.Status != InterpreterBookingCancelled
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Stock movement types
+const (
+	StockMovementIn     = "in"     // Donations, deliveries and purchases received
+	StockMovementOut    = "out"    // Handed out to visitors or used
+	StockMovementWaste  = "waste"  // Expired or damaged stock thrown away
+	StockMovementAdjust = "adjust" // Stocktake correction to the counted quantity
+)
+
+// InventoryCategory groups stock items, e.g. "Tinned Food" or "Toiletries"
+type InventoryCategory struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Name         string    `json:"name" gorm:"uniqueIndex;not null"`
+	Description  string    `json:"description" gorm:"type:text"`
+	TracksExpiry bool      `json:"tracks_expiry"` // Food: stock received must carry an expiry date
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (InventoryCategory) TableName() string {
+	return "inventory_categories"
+}
+
+// InventoryItem is a stock line held by the charity. Quantity only changes through
+// stock movements. While stock is at or below the minimum level the item is
+// published as an urgent need for donors.
+type InventoryItem struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	Name         string         `json:"name" gorm:"not null;index"`
+	CategoryID   uint           `json:"category_id" gorm:"index;not null"`
+	Description  string         `json:"description" gorm:"type:text"`
+	Unit         string         `json:"unit"`                              // What Quantity counts, e.g. "tins" or "packs"
+	Quantity     int            `json:"quantity"`                          // Current stock
+	MinimumLevel int            `json:"minimum_level"`                     // Urgent at or below this
+	TargetLevel  int            `json:"target_level"`                      // Stock the charity aims to hold
+	Location     string         `json:"location"`                          // Shelf or store room
+	Active       bool           `json:"active" gorm:"index"`               // Inactive items are no longer stocked
+	UrgentNeedID *uint          `json:"urgent_need_id" gorm:"uniqueIndex"` // Urgent need published for this item
+	LastMovedAt  *time.Time     `json:"last_moved_at"`                     // Most recent stock movement
+	CreatedBy    uint           `json:"created_by"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Category *InventoryCategory `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+}
+
+// TableName specifies the table name
+func (InventoryItem) TableName() string {
+	return "inventory_items"
+}
+
+// StockMovement records one change to an item's stock
+type StockMovement struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	ItemID        uint       `json:"item_id" gorm:"index;not null"`
+	Type          string     `json:"type" gorm:"index;not null"`
+	Change        int        `json:"change"`         // Signed change to the quantity
+	QuantityAfter int        `json:"quantity_after"` // Stock once the movement was applied
+	Reason        string     `json:"reason"`
+	Reference     string     `json:"reference"` // Source of the stock, e.g. "Supplier order 12"
+	BatchCode     string     `json:"batch_code,omitempty"`
+	ExpiresOn     *time.Time `json:"expires_on,omitempty" gorm:"type:date;index"` // Best-before or use-by date of the batch
+	DonationID    *uint      `json:"donation_id,omitempty" gorm:"index"`          // Goods donation the stock came in with
+	RecordedBy    *uint      `json:"recorded_by"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name
+func (StockMovement) TableName() string {
+	return "stock_movements"
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kiosk rating categories shown as optional buttons after the smiley
+const (
+	KioskCategoryFood       = "food"
+	KioskCategoryClothing   = "clothing"
+	KioskCategoryStaff      = "staff"
+	KioskCategoryWaitTime   = "wait_time"
+	KioskCategoryFacilities = "facilities"
+)
+
+// KioskCategories lists the categories a kiosk rating can be tagged with
+var KioskCategories = []string{
+	KioskCategoryFood,
+	KioskCategoryClothing,
+	KioskCategoryStaff,
+	KioskCategoryWaitTime,
+	KioskCategoryFacilities,
+}
+
+// KioskDevice is a tablet that submits anonymous ratings using a device token
+// instead of a user login. Only a hash of the token is stored.
+type KioskDevice struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `json:"name" gorm:"not null"`
+	Location    string         `json:"location"`
+	TokenHash   string         `json:"-" gorm:"uniqueIndex;not null"`
+	TokenPrefix string         `json:"token_prefix"` // First characters of the token, to tell devices apart
+	IsActive    bool           `json:"is_active" gorm:"default:true"`
+	LastSeenAt  *time.Time     `json:"last_seen_at"`
+	RevokedAt   *time.Time     `json:"revoked_at"`
+	CreatedBy   uint           `json:"created_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName specifies the table name
+func (KioskDevice) TableName() string {
+	return "kiosk_devices"
+}
+
+// KioskRating is a single anonymous 1-5 rating tapped at a kiosk. It is kept apart
+// from VisitFeedback and holds nothing that identifies the visitor.
+type KioskRating struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DeviceID  uint      `json:"device_id" gorm:"index"`
+	Rating    int       `json:"rating" gorm:"not null;check:rating >= 1 AND rating <= 5"`
+	Category  string    `json:"category" gorm:"index"`
+	VisitDay  string    `json:"visit_day" gorm:"index"` // YYYY-MM-DD
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (KioskRating) TableName() string {
+	return "kiosk_ratings"
+}
+
+// HashKioskToken returns the stored hash of a kiosk device token
+func HashKioskToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsKioskCategory reports whether category is a known kiosk rating category
+func IsKioskCategory(category string) bool {
+	for _, known := range KioskCategories {
+		if category == known {
+			return true
+		}
+	}
+	return false
+}
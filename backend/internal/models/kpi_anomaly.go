@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Daily KPIs watched for anomalies
+const (
+	KPIHelpRequests    = "help_requests"    // Help requests submitted
+	KPIVisitorNoShows  = "visitor_no_shows" // Tickets that expired unused
+	KPIDonations       = "donations"        // Donations received
+	KPIAverageWaitTime = "average_queue_wait"
+)
+
+// Which way a KPI moved from its normal range
+const (
+	KPIAnomalyAbove = "above"
+	KPIAnomalyBelow = "below"
+)
+
+// KPIs lists the watched KPIs in display order
+var KPIs = []string{KPIHelpRequests, KPIVisitorNoShows, KPIDonations, KPIAverageWaitTime}
+
+// KPIAnomaly records a day whose KPI value fell outside its normal range, so each KPI
+// is only alerted once per day
+type KPIAnomaly struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	KPI          string    `json:"kpi" gorm:"column:kpi;type:varchar(30);not null;uniqueIndex:idx_kpi_anomaly"`
+	Day          string    `json:"day" gorm:"type:varchar(10);not null;uniqueIndex:idx_kpi_anomaly"` // YYYY-MM-DD
+	Value        float64   `json:"value"`
+	BaselineMean float64   `json:"baseline_mean"`   // Mean on the same weekday over the baseline weeks
+	BaselineSD   float64   `json:"baseline_stddev"` // Standard deviation of the same
+	BaselineDays int       `json:"baseline_days"`
+	Sigma        float64   `json:"sigma"`     // Standard deviations from the mean
+	Direction    string    `json:"direction"` // above or below
+	Severity     string    `json:"severity"`
+	AlertID      *uint     `json:"alert_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name
+func (KPIAnomaly) TableName() string {
+	return "kpi_anomalies"
+}
+
+// IsKPI reports whether a KPI is watched
+func IsKPI(kpi string) bool {
+	for _, k := range KPIs {
+		if k == kpi {
+			return true
+		}
+	}
+	return false
+}
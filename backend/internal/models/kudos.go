@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kudos status values
+const (
+	KudosStatusPending  = "pending"
+	KudosStatusApproved = "approved"
+	KudosStatusRejected = "rejected"
+	KudosStatusHidden   = "hidden" // Hidden automatically after repeated abuse reports
+)
+
+// Kudos categories
+const (
+	KudosCategoryTeamwork    = "teamwork"
+	KudosCategoryKindness    = "kindness"
+	KudosCategoryLeadership  = "leadership"
+	KudosCategoryReliability = "reliability"
+	KudosCategoryGoingExtra  = "above_and_beyond"
+)
+
+// Kudos report status values
+const (
+	KudosReportStatusOpen      = "open"
+	KudosReportStatusUpheld    = "upheld"
+	KudosReportStatusDismissed = "dismissed"
+)
+
+// Kudos is a peer recognition message sent to a volunteer for a shift
+type Kudos struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	SenderID       uint           `json:"sender_id" gorm:"not null;index"`
+	RecipientID    uint           `json:"recipient_id" gorm:"not null;index"`
+	ShiftID        uint           `json:"shift_id" gorm:"not null;index"`
+	Category       string         `json:"category" gorm:"default:teamwork"`
+	Message        string         `json:"message" gorm:"type:text;not null"`
+	Status         string         `json:"status" gorm:"default:pending;index"`
+	ModeratedBy    *uint          `json:"moderated_by"`
+	ModeratedAt    *time.Time     `json:"moderated_at"`
+	ModerationNote string         `json:"moderation_note"`
+	ReportCount    int            `json:"report_count" gorm:"default:0"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Sender    User  `json:"sender,omitempty" gorm:"foreignKey:SenderID"`
+	Recipient User  `json:"recipient,omitempty" gorm:"foreignKey:RecipientID"`
+	Shift     Shift `json:"shift,omitempty" gorm:"foreignKey:ShiftID"`
+}
+
+// TableName specifies the table name
+func (Kudos) TableName() string {
+	return "volunteer_kudos"
+}
+
+// IsVisible returns true if the kudos can be shown to volunteers
+func (k *Kudos) IsVisible() bool {
+	return k.Status == KudosStatusApproved
+}
+
+// KudosReport records an abuse report raised against a kudos message
+type KudosReport struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	KudosID    uint           `json:"kudos_id" gorm:"not null;index"`
+	ReporterID uint           `json:"reporter_id" gorm:"not null;index"`
+	Reason     string         `json:"reason" gorm:"type:text"`
+	Status     string         `json:"status" gorm:"default:open;index"`
+	ResolvedBy *uint          `json:"resolved_by"`
+	ResolvedAt *time.Time     `json:"resolved_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Kudos    Kudos `json:"kudos,omitempty" gorm:"foreignKey:KudosID"`
+	Reporter User  `json:"reporter,omitempty" gorm:"foreignKey:ReporterID"`
+}
+
+// TableName specifies the table name
+func (KudosReport) TableName() string {
+	return "volunteer_kudos_reports"
+}
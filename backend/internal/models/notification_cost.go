@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+// Notification cost status values
+const (
+	NotificationCostSent    = "sent"
+	NotificationCostFailed  = "failed"
+	NotificationCostBlocked = "blocked" // SMS held back because the monthly budget was spent
+)
+
+// SMSBudgetConfigKey is the system config key holding the monthly SMS budget
+const SMSBudgetConfigKey = "sms_monthly_budget"
+
+// SMS budget alert thresholds, as a percentage of the monthly budget
+const (
+	SMSBudgetWarnPercent  = 80
+	SMSBudgetBlockPercent = 100
+)
+
+// NotificationCost records what a single outgoing message cost to send
+type NotificationCost struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	Channel         string    `json:"channel" gorm:"index"`  // sms, email
+	Provider        string    `json:"provider" gorm:"index"` // twilio, sendgrid, mock
+	Recipient       string    `json:"recipient"`             // Masked phone number or email
+	UserID          *uint     `json:"user_id" gorm:"index"`
+	TemplateType    string    `json:"template_type"`
+	Segments        int       `json:"segments"`
+	UnitCost        float64   `json:"unit_cost"`
+	Cost            float64   `json:"cost"`
+	Currency        string    `json:"currency" gorm:"default:'GBP'"`
+	Urgent          bool      `json:"urgent"`
+	Status          string    `json:"status" gorm:"index"`
+	FallbackChannel string    `json:"fallback_channel,omitempty"` // Channel used instead when the SMS was blocked
+	CreatedAt       time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name
+func (NotificationCost) TableName() string {
+	return "notification_costs"
+}
+
+// SMSBudgetAlert records that admins were warned about SMS spend for a month,
+// so each threshold is only alerted once
+type SMSBudgetAlert struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Month     string    `json:"month" gorm:"uniqueIndex:idx_sms_budget_alert"` // YYYY-MM
+	Threshold int       `json:"threshold" gorm:"uniqueIndex:idx_sms_budget_alert"`
+	Spend     float64   `json:"spend"`
+	Budget    float64   `json:"budget"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (SMSBudgetAlert) TableName() string {
+	return "sms_budget_alerts"
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// NotificationDigestItem is a non-urgent email held back for a user's daily or weekly
+// digest. Items are marked sent when the digest goes out.
+type NotificationDigestItem struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `json:"user_id" gorm:"not null;index:idx_digest_item_pending"`
+	Category  string     `json:"category" gorm:"type:varchar(30);not null"`
+	Frequency string     `json:"frequency" gorm:"type:varchar(10);not null"` // daily or weekly, when it was held back
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Message   string     `json:"message" gorm:"type:text"`
+	ActionURL string     `json:"action_url,omitempty"`
+	SentAt    *time.Time `json:"sent_at" gorm:"index:idx_digest_item_pending"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (NotificationDigestItem) TableName() string {
+	return "notification_digest_items"
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// DefaultLanguage is the language of the built-in templates and the last step of
+// every language fallback chain
+const DefaultLanguage = "en"
+
+// NotificationTemplateTranslation is a transactional email or SMS template in another
+// language. Body and subject use the same template fields as the English template.
+type NotificationTemplateTranslation struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	TemplateType string    `json:"template_type" gorm:"type:varchar(60);not null;uniqueIndex:idx_template_translation"`
+	Language     string    `json:"language" gorm:"type:varchar(20);not null;uniqueIndex:idx_template_translation"`
+	Subject      string    `json:"subject"` // Blank keeps the English subject
+	Body         string    `json:"body" gorm:"type:text;not null"`
+	UpdatedBy    *uint     `json:"updated_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (NotificationTemplateTranslation) TableName() string {
+	return "notification_template_translations"
+}
+
+// CampaignTranslation is a campaign's subject and body in another language. The
+// campaign's own subject and body are English.
+type CampaignTranslation struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CampaignID uint      `json:"campaign_id" gorm:"not null;uniqueIndex:idx_campaign_translation"`
+	Language   string    `json:"language" gorm:"type:varchar(20);not null;uniqueIndex:idx_campaign_translation"`
+	Subject    string    `json:"subject"`
+	Body       string    `json:"body" gorm:"type:text;not null"`
+	UpdatedBy  *uint     `json:"updated_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (CampaignTranslation) TableName() string {
+	return "campaign_translations"
+}
+
+// NotificationLanguageLog records the language one message was sent in and the
+// language the recipient would have preferred
+type NotificationLanguageLog struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `json:"user_id" gorm:"index"`
+	Channel      string    `json:"channel"`                                     // email or sms
+	TemplateType string    `json:"template_type" gorm:"type:varchar(60);index"` // Blank for campaigns
+	CampaignID   *uint     `json:"campaign_id,omitempty" gorm:"index"`
+	Requested    string    `json:"requested" gorm:"type:varchar(20);index"` // First language in the recipient's chain
+	Language     string    `json:"language" gorm:"type:varchar(20)"`        // Language actually used
+	FellBack     bool      `json:"fell_back"`                               // Requested language had no translation
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name
+func (NotificationLanguageLog) TableName() string {
+	return "notification_language_logs"
+}
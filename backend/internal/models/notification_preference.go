@@ -0,0 +1,130 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Notification categories a user can turn channels on and off for
+const (
+	NotificationCategoryHelpRequests = "help_requests"
+	NotificationCategoryShifts       = "shifts"
+	NotificationCategoryDonations    = "donations"
+	NotificationCategoryMarketing    = "marketing"
+)
+
+// NotificationCategories lists the preference categories in display order
+var NotificationCategories = []string{
+	NotificationCategoryHelpRequests, NotificationCategoryShifts,
+	NotificationCategoryDonations, NotificationCategoryMarketing,
+}
+
+// Notification channels a preference covers
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelSMS   = "sms"
+	NotificationChannelPush  = "push"
+	NotificationChannelInApp = "in_app"
+)
+
+// How often non-urgent emails in a category are sent
+const (
+	DigestImmediate = "immediate" // Each email as it happens
+	DigestDaily     = "daily"
+	DigestWeekly    = "weekly"
+)
+
+// DigestFrequencies lists the digest choices in display order
+var DigestFrequencies = []string{DigestImmediate, DigestDaily, DigestWeekly}
+
+// NotificationPreference is which channels a user accepts for one category of
+// notification, and whether its non-urgent emails are batched into a digest. Users
+// without a row for a category get DefaultNotificationPreference.
+type NotificationPreference struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_notification_preference"`
+	Category  string    `json:"category" gorm:"type:varchar(30);not null;uniqueIndex:idx_notification_preference"`
+	Email     bool      `json:"email"`
+	SMS       bool      `json:"sms"`
+	Push      bool      `json:"push"`
+	InApp     bool      `json:"in_app"`
+	Digest    string    `json:"digest" gorm:"type:varchar(10);default:'immediate'"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (NotificationPreference) TableName() string {
+	return "notification_category_preferences"
+}
+
+// DefaultNotificationPreference returns the channels a user gets for a category until
+// they choose. Service messages go everywhere; marketing is opt-in.
+func DefaultNotificationPreference(userID uint, category string) NotificationPreference {
+	on := category != NotificationCategoryMarketing
+	return NotificationPreference{UserID: userID, Category: category, Email: on, SMS: on, Push: on, InApp: on, Digest: DigestImmediate}
+}
+
+// IsDigestFrequency reports whether a digest frequency is known
+func IsDigestFrequency(frequency string) bool {
+	for _, f := range DigestFrequencies {
+		if f == frequency {
+			return true
+		}
+	}
+	return false
+}
+
+// Digested reports whether non-urgent emails in the category wait for a digest
+func (p NotificationPreference) Digested() bool {
+	return p.Digest == DigestDaily || p.Digest == DigestWeekly
+}
+
+// Allows reports whether the preference lets a notification through on a channel.
+// Unknown channels are allowed.
+func (p NotificationPreference) Allows(channel string) bool {
+	switch channel {
+	case NotificationChannelEmail:
+		return p.Email
+	case NotificationChannelSMS:
+		return p.SMS
+	case NotificationChannelPush:
+		return p.Push
+	case NotificationChannelInApp, "websocket":
+		return p.InApp
+	default:
+		return true
+	}
+}
+
+// IsNotificationCategory reports whether a category has user preferences
+func IsNotificationCategory(category string) bool {
+	for _, c := range NotificationCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationCategoryFor returns the preference category a notification belongs to,
+// from its template type or the category it was sent with. Account, security and
+// emergency messages have no category and are always sent.
+func NotificationCategoryFor(kind string) string {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	switch {
+	case IsNotificationCategory(kind):
+		return kind
+	case kind == "visitor" || kind == "queue" || kind == "ticket_issued" || kind == "appointment_reminder" ||
+		strings.HasPrefix(kind, "help_request") || strings.HasPrefix(kind, "appointment"):
+		return NotificationCategoryHelpRequests
+	case kind == "volunteer" || kind == "urgent_callout" || kind == "schedule_change" || strings.HasPrefix(kind, "shift"):
+		return NotificationCategoryShifts
+	case kind == "donor" || kind == "dropoff_scheduled" || strings.HasPrefix(kind, "donation"):
+		return NotificationCategoryDonations
+	case kind == "campaign" || kind == "newsletter":
+		return NotificationCategoryMarketing
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import "testing"
+
+func TestNotificationCategoryFor(t *testing.T) {
+	cases := map[string]string{
+		"help_request_submitted": NotificationCategoryHelpRequests,
+		"ticket_issued":          NotificationCategoryHelpRequests,
+		"queue":                  NotificationCategoryHelpRequests,
+		"shift_reminder":         NotificationCategoryShifts,
+		"volunteer":              NotificationCategoryShifts,
+		"donation_received":      NotificationCategoryDonations,
+		"marketing":              NotificationCategoryMarketing,
+		"password_reset":         "",
+		"emergency_alert":        "",
+		"security":               "",
+	}
+	for kind, want := range cases {
+		if got := NotificationCategoryFor(kind); got != want {
+			t.Errorf("NotificationCategoryFor(%q) = %q, want %q", kind, got, want)
+		}
+	}
+}
+
+func TestDefaultNotificationPreference(t *testing.T) {
+	shifts := DefaultNotificationPreference(1, NotificationCategoryShifts)
+	for _, channel := range []string{NotificationChannelEmail, NotificationChannelSMS, NotificationChannelPush, NotificationChannelInApp} {
+		if !shifts.Allows(channel) {
+			t.Errorf("shifts default should allow %s", channel)
+		}
+	}
+
+	marketing := DefaultNotificationPreference(1, NotificationCategoryMarketing)
+	if marketing.Allows(NotificationChannelEmail) || marketing.Allows(NotificationChannelInApp) {
+		t.Error("marketing should be opt-in")
+	}
+	if !marketing.Allows("fax") {
+		t.Error("unknown channels should be allowed")
+	}
+}
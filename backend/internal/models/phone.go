@@ -0,0 +1,51 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizePhone converts a phone number to E.164 format so the same number
+// always has the same representation. UK national numbers (07...) are
+// assumed when no country code is given.
+func NormalizePhone(raw string) (string, error) {
+	var digits strings.Builder
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+			// Formatting characters are ignored
+		default:
+			return "", fmt.Errorf("invalid character in phone number")
+		}
+	}
+
+	phone := digits.String()
+	switch {
+	case strings.HasPrefix(phone, "+"):
+	case strings.HasPrefix(phone, "00"):
+		phone = "+" + phone[2:]
+	case strings.HasPrefix(phone, "44"):
+		phone = "+" + phone
+	case strings.HasPrefix(phone, "0"):
+		phone = "+44" + phone[1:]
+	default:
+		return "", fmt.Errorf("phone number must include a country code or start with 0")
+	}
+
+	if n := len(phone) - 1; n < 10 || n > 15 {
+		return "", fmt.Errorf("phone number must have between 10 and 15 digits")
+	}
+	return phone, nil
+}
+
+// MaskPhone hides all but the last three digits of a phone number
+func MaskPhone(phone string) string {
+	if len(phone) <= 3 {
+		return phone
+	}
+	return strings.Repeat("*", len(phone)-3) + phone[len(phone)-3:]
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// One-time code purposes
+const (
+	OTPPurposeLogin       = "login"
+	OTPPurposeVerifyPhone = "verify_phone"
+)
+
+// PhoneOTP is a one-time code sent by SMS. Only a hash of the code is stored.
+type PhoneOTP struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Phone     string     `json:"phone" gorm:"index;not null"` // E.164 format
+	Purpose   string     `json:"purpose" gorm:"not null"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	Attempts  int        `json:"attempts" gorm:"default:0"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"index"`
+	UsedAt    *time.Time `json:"used_at"`
+	IPAddress string     `json:"ip_address"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (PhoneOTP) TableName() string {
+	return "phone_otps"
+}
+
+// IsUsable returns true if the code has not been used, expired or locked out
+func (o *PhoneOTP) IsUsable(maxAttempts int) bool {
+	return o.UsedAt == nil && time.Now().Before(o.ExpiresAt) && o.Attempts < maxAttempts
+}
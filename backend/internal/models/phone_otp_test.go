@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPhoneOTPIsUsable(t *testing.T) {
+	now := time.Now()
+	used := now.Add(-time.Minute)
+
+	tests := []struct {
+		name string
+		otp  PhoneOTP
+		want bool
+	}{
+		{"fresh", PhoneOTP{ExpiresAt: now.Add(time.Minute)}, true},
+		{"one attempt left", PhoneOTP{ExpiresAt: now.Add(time.Minute), Attempts: 4}, true},
+		{"attempts exhausted", PhoneOTP{ExpiresAt: now.Add(time.Minute), Attempts: 5}, false},
+		{"expired", PhoneOTP{ExpiresAt: now.Add(-time.Second)}, false},
+		{"already used", PhoneOTP{ExpiresAt: now.Add(time.Minute), UsedAt: &used}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.otp.IsUsable(5); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// ProductBarcode is a scanned EAN or UPC code and what it was resolved to. It caches
+// the lookup provider's answer, including that the provider did not know the code,
+// and remembers the stock item the product is counted into so later scans go
+// straight to it.
+type ProductBarcode struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Barcode     string     `json:"barcode" gorm:"size:14;uniqueIndex;not null"` // 8 or 13 digits; UPC-A is stored as EAN-13
+	Found       bool       `json:"found"`                                       // The provider knew the product
+	ProductName string     `json:"product_name"`
+	Brand       string     `json:"brand"`
+	PackSize    string     `json:"pack_size"`             // e.g. "400 g" or "6 x 330 ml"
+	Categories  string     `json:"-" gorm:"type:text"`    // Provider categories, comma-separated
+	Source      string     `json:"source" gorm:"size:50"` // Provider that resolved it, or "manual"
+	ItemID      *uint      `json:"item_id" gorm:"index"`  // Stock item scans are booked into
+	LinkedBy    *uint      `json:"linked_by,omitempty"`   // Who linked the code to its item
+	LookedUpAt  time.Time  `json:"looked_up_at"`          // When the provider was last asked
+	ScanCount   int        `json:"scan_count"`            // Scans booked into stock
+	LastScanAt  *time.Time `json:"last_scan_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	Item *InventoryItem `json:"item,omitempty" gorm:"foreignKey:ItemID"`
+}
+
+// TableName specifies the table name
+func (ProductBarcode) TableName() string {
+	return "product_barcodes"
+}
+
+// CategoryList returns the provider categories, most general first
+func (b *ProductBarcode) CategoryList() []string {
+	categories := []string{}
+	for _, category := range strings.Split(b.Categories, ",") {
+		if category = strings.TrimSpace(category); category != "" {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
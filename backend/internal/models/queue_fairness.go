@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Dimensions queue waits can be broken down by
+const (
+	QueueFairnessByCategory = "category"
+	QueueFairnessByPostcode = "postcode" // Postcode district, e.g. SE13
+	QueueFairnessByHour     = "hour"     // Hour of day the visitor joined the queue
+	QueueFairnessByWeekday  = "weekday"
+)
+
+// Queue wait anomaly statuses
+const (
+	QueueWaitAnomalyOpen         = "open"
+	QueueWaitAnomalyAcknowledged = "acknowledged"
+)
+
+// QueueWaitAnomaly records a sudden spike in waits for a queue category, so each
+// category is only alerted once per hour
+type QueueWaitAnomaly struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	Category           string     `json:"category" gorm:"uniqueIndex:idx_queue_wait_anomaly"`
+	WindowStart        time.Time  `json:"window_start" gorm:"uniqueIndex:idx_queue_wait_anomaly"`
+	Entries            int64      `json:"entries"` // Visitors called in the window
+	AverageWaitMinutes float64    `json:"average_wait_minutes"`
+	BaselineMinutes    float64    `json:"baseline_minutes"` // Average wait at this hour over the baseline period
+	BaselineEntries    int64      `json:"baseline_entries"`
+	Status             string     `json:"status" gorm:"default:open;index"`
+	AcknowledgedBy     *uint      `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt     *time.Time `json:"acknowledged_at,omitempty"`
+	Notes              string     `json:"notes,omitempty" gorm:"type:text"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (QueueWaitAnomaly) TableName() string {
+	return "queue_wait_anomalies"
+}
+
+// QueueFairnessReport is the weekly queue fairness report sent to trustees
+type QueueFairnessReport struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	WeekStart         string    `json:"week_start" gorm:"uniqueIndex"` // Monday, YYYY-MM-DD
+	WeekEnd           string    `json:"week_end"`
+	Entries           int64     `json:"entries"`
+	MedianWaitMinutes float64   `json:"median_wait_minutes"`
+	Findings          int       `json:"findings"`           // Groups that waited longer over several weeks
+	Anomalies         int       `json:"anomalies"`          // Wait spikes detected during the week
+	Content           string    `json:"-" gorm:"type:text"` // The report as JSON
+	EmailedTo         int       `json:"emailed_to"`
+	GeneratedAt       time.Time `json:"generated_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (QueueFairnessReport) TableName() string {
+	return "queue_fairness_reports"
+}
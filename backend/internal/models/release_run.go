@@ -0,0 +1,130 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// How a ticket release was started
+const (
+	ReleaseTriggerManual    = "manual"
+	ReleaseTriggerScheduled = "scheduled"
+)
+
+// ErrReleaseRunImmutable is returned when something tries to change or delete a
+// recorded ticket release
+var ErrReleaseRunImmutable = errors.New("ticket release runs cannot be changed once recorded")
+
+// ReleaseRun is the permanent record of one ticket release: what capacity each
+// category had, who was queued and in what order tickets were allocated. Each run
+// stores a hash of its contents chained to the previous run, so any later edit to
+// the table shows up when the chain is verified.
+type ReleaseRun struct {
+	ID               uint                 `gorm:"primaryKey" json:"id"`
+	ReleaseDate      string               `json:"release_date" gorm:"index;not null"` // Visit day tickets were released for, YYYY-MM-DD
+	RunAt            time.Time            `json:"run_at" gorm:"index"`
+	Trigger          string               `json:"trigger" gorm:"not null"`
+	TriggeredBy      *uint                `json:"triggered_by"`                     // Admin who ran it; empty for the scheduled release
+	Overrides        ReleaseOverrides     `json:"overrides" gorm:"type:json"`       // Capacities the admin set instead of the daily capacity
+	Categories       ReleaseRunCategories `json:"categories" gorm:"type:json"`      // Inputs and results per category
+	TotalEligible    int                  `json:"total_eligible"`                   // Approved requests queued for a ticket
+	TotalReleased    int                  `json:"total_released"`                   // Tickets issued
+	TotalFailed      int                  `json:"total_failed"`                     // Tickets allocated but not saved
+	RemainingInQueue int                  `json:"remaining_in_queue"`               // Requests still awaiting approval for the day
+	PrevHash         string               `json:"prev_hash"`                        // Hash of the run before this one
+	Hash             string               `json:"hash" gorm:"uniqueIndex;not null"` // SHA-256 of this run's contents and PrevHash
+	CreatedAt        time.Time            `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (ReleaseRun) TableName() string {
+	return "release_runs"
+}
+
+// BeforeUpdate stops recorded runs being changed through the ORM
+func (ReleaseRun) BeforeUpdate(tx *gorm.DB) error {
+	return ErrReleaseRunImmutable
+}
+
+// BeforeDelete stops recorded runs being deleted through the ORM
+func (ReleaseRun) BeforeDelete(tx *gorm.DB) error {
+	return ErrReleaseRunImmutable
+}
+
+// ReleaseRunCategory records one category of a ticket release
+type ReleaseRunCategory struct {
+	Category        string              `json:"category"`
+	DefaultCapacity int                 `json:"default_capacity"` // Daily capacity for the day
+	Capacity        int                 `json:"capacity"`         // Capacity used, after any override
+	Overridden      bool                `json:"overridden"`
+	QueueSize       int                 `json:"queue_size"` // Approved requests waiting for a ticket
+	Allocation      []ReleaseAllocation `json:"allocation"` // Whole queue in allocation order
+	Issued          int                 `json:"issued"`
+	Failed          int                 `json:"failed"`
+	NotReached      int                 `json:"not_reached"` // Queued requests beyond capacity
+}
+
+// ReleaseAllocation is one queued help request and what the release did with it
+type ReleaseAllocation struct {
+	Position      int       `json:"position"`
+	HelpRequestID uint      `json:"help_request_id"`
+	RequestedAt   time.Time `json:"requested_at"`
+	Outcome       string    `json:"outcome"` // issued, failed or not_reached
+}
+
+// Outcomes of a queued help request in a ticket release
+const (
+	ReleaseOutcomeIssued     = "issued"
+	ReleaseOutcomeFailed     = "failed"
+	ReleaseOutcomeNotReached = "not_reached"
+)
+
+// ReleaseRunCategories is stored as JSON
+type ReleaseRunCategories []ReleaseRunCategory
+
+// Value implements the driver.Valuer interface for database storage
+func (rc ReleaseRunCategories) Value() (driver.Value, error) {
+	return json.Marshal(rc)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (rc *ReleaseRunCategories) Scan(value interface{}) error {
+	if value == nil {
+		*rc = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, rc)
+}
+
+// ReleaseOverrides maps a category to the ticket limit an admin set for a release
+type ReleaseOverrides map[string]int
+
+// Value implements the driver.Valuer interface for database storage
+func (ro ReleaseOverrides) Value() (driver.Value, error) {
+	return json.Marshal(ro)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (ro *ReleaseOverrides) Scan(value interface{}) error {
+	if value == nil {
+		*ro = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, ro)
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// System config keys holding the help request SLA targets
+const (
+	SLAApprovalTargetConfigKey  = "sla_approval_target_hours"
+	SLATicketTargetConfigKey    = "sla_ticket_target_hours"
+	SLAComplianceAlertConfigKey = "sla_compliance_alert_percent"
+)
+
+// Help request SLA metrics
+const (
+	SLAMetricApproval = "time_to_approval" // Request submitted to approved
+	SLAMetricTicket   = "time_to_ticket"   // Request submitted to first ticket issued
+)
+
+// SLAComplianceAlert records that admins were warned about rolling SLA compliance for
+// a metric, so each metric is only alerted once a day
+type SLAComplianceAlert struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Metric     string    `json:"metric" gorm:"uniqueIndex:idx_sla_compliance_alert"`
+	Day        string    `json:"day" gorm:"uniqueIndex:idx_sla_compliance_alert"` // YYYY-MM-DD
+	Compliance float64   `json:"compliance"`                                      // Percentage within target
+	Threshold  float64   `json:"threshold"`
+	Completed  int64     `json:"completed"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (SLAComplianceAlert) TableName() string {
+	return "sla_compliance_alerts"
+}
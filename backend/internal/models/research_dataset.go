@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// ResearchDatasetSchemaVersion is the version of the research dataset's columns. Bump
+// it whenever the columns change so researchers can tell old files from new.
+const ResearchDatasetSchemaVersion = 1
+
+// Research dataset export statuses
+const (
+	ResearchDatasetRunning = "running"
+	ResearchDatasetReady   = "ready"
+	ResearchDatasetFailed  = "failed"
+)
+
+// ResearchDatasetExport is an anonymised dataset of service demand for researchers
+// and the council: help requests counted by week, ward and category. Every published
+// row covers at least MinGroupSize households; smaller groups are merged or left out.
+type ResearchDatasetExport struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	SchemaVersion      int        `json:"schema_version"`
+	PeriodStart        time.Time  `json:"period_start" gorm:"type:date"`     // Monday of the first week
+	PeriodEnd          time.Time  `json:"period_end" gorm:"type:date;index"` // Day after the last week
+	MinGroupSize       int        `json:"min_group_size"`                    // k: fewest households a row may describe
+	Status             string     `json:"status" gorm:"size:20;index"`
+	Rows               int        `json:"rows"`
+	Requests           int        `json:"requests"`            // Help requests in the period
+	GeneralisedRecords int        `json:"generalised_records"` // Requests reported under the "Other" ward
+	SuppressedRecords  int        `json:"suppressed_records"`  // Requests left out to keep groups at k or more
+	Content            string     `json:"-" gorm:"type:text"`  // The dataset as CSV
+	Checksum           string     `json:"checksum,omitempty" gorm:"size:64"`
+	Error              string     `json:"error,omitempty" gorm:"type:text"`
+	RequestedBy        *uint      `json:"requested_by,omitempty"` // Empty when made by the scheduled job
+	GeneratedAt        *time.Time `json:"generated_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (ResearchDatasetExport) TableName() string {
+	return "research_dataset_exports"
+}
+
+// PostcodeWard maps a postcode sector ("SE13 6") or district ("SE13") to the electoral
+// ward reported for it in research datasets. Sectors take precedence over districts.
+type PostcodeWard struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Prefix    string    `json:"prefix" gorm:"size:10;uniqueIndex;not null"`
+	Ward      string    `json:"ward" gorm:"size:100;not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (PostcodeWard) TableName() string {
+	return "postcode_wards"
+}
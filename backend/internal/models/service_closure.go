@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Channels a closure auto-reply is sent on
+const (
+	ClosureChannelHelpRequest = "help_request" // Help request made in the app
+	ClosureChannelMessage     = "message"      // In-app message to staff
+	ClosureChannelEmail       = "email"        // Email reply received by the inbound webhook
+)
+
+// ServiceClosure is a period the service is closed, such as over a holiday. Help
+// requests and messages received during it get an automatic reply giving the
+// reopening date and where to get help meanwhile, and are queued for staff to pick up
+// when the service reopens.
+type ServiceClosure struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Title        string     `json:"title" gorm:"not null"`         // e.g. "Christmas closure"
+	Message      string     `json:"message" gorm:"type:text"`      // Opening of the auto-reply
+	Alternatives string     `json:"alternatives" gorm:"type:text"` // Emergency help while closed
+	StartsAt     time.Time  `json:"starts_at" gorm:"index"`
+	ReopensAt    time.Time  `json:"reopens_at" gorm:"index"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+	ReleasedAt   *time.Time `json:"released_at,omitempty"` // When the queue was handed to staff on reopening
+	TaskID       *uint      `json:"task_id,omitempty"`     // Staff task to work through the queue
+	CreatedBy    uint       `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (ServiceClosure) TableName() string {
+	return "service_closures"
+}
+
+// ClosureQueueItem is a help request, message or email received while the service
+// was closed. Recipient is who the auto-reply went to; each recipient is only sent
+// one per closure, so two automatic responders cannot answer each other forever.
+type ClosureQueueItem struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	ClosureID   uint       `json:"closure_id" gorm:"not null;index;uniqueIndex:idx_closure_queue_entity"`
+	Channel     string     `json:"channel" gorm:"size:20;not null;uniqueIndex:idx_closure_queue_entity"`
+	EntityID    uint       `json:"entity_id" gorm:"not null;uniqueIndex:idx_closure_queue_entity"` // Help request, message or inbound reply
+	UserID      *uint      `json:"user_id,omitempty" gorm:"index"`
+	Recipient   string     `json:"recipient" gorm:"index"` // User ID or email address the auto-reply went to
+	Summary     string     `json:"summary"`
+	RepliedAt   *time.Time `json:"replied_at,omitempty"` // Empty when the recipient already had a reply
+	ReceivedAt  time.Time  `json:"received_at"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"` // Released to staff on reopening
+}
+
+// TableName specifies the table name
+func (ClosureQueueItem) TableName() string {
+	return "closure_queue_items"
+}
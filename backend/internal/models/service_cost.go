@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ServiceUnitCost is the estimated cost of delivering one unit of a service, such as a
+// food parcel or an advice session. Costs are dated so reports for past periods use
+// the figures that applied at the time.
+type ServiceUnitCost struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Category      string    `json:"category" gorm:"not null;uniqueIndex:idx_service_unit_cost_from"` // Service type code, e.g. food
+	UnitName      string    `json:"unit_name" gorm:"not null"`                                       // e.g. food parcel, advice session
+	UnitCost      float64   `json:"unit_cost"`                                                       // GBP per visit
+	PerPersonCost float64   `json:"per_person_cost"`                                                 // GBP for each household member after the first
+	EffectiveFrom time.Time `json:"effective_from" gorm:"not null;uniqueIndex:idx_service_unit_cost_from"`
+	Source        string    `json:"source"` // Where the figure comes from, for funders
+	CreatedBy     *uint     `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (ServiceUnitCost) TableName() string {
+	return "service_unit_costs"
+}
+
+// ValueFor estimates the value of one visit for a household of the given size
+func (c *ServiceUnitCost) ValueFor(householdSize int) float64 {
+	value := c.UnitCost
+	if householdSize > 1 {
+		value += c.PerPersonCost * float64(householdSize-1)
+	}
+	return value
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Service stages timed against a service type's targets
+const (
+	ServiceStageWaiting   = "waiting"    // Checked in, waiting to be served
+	ServiceStageInService = "in_service" // Being served
+)
+
+// Service time alert levels
+const (
+	ServiceAlertOverTarget = "over_target" // Past the target time
+	ServiceAlertCritical   = "critical"    // Past one and a half times the target
+)
+
+// ServiceTimeAlert records that a visitor went past a service time target. Each level
+// is raised once per queue entry and stage.
+type ServiceTimeAlert struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	QueueEntryID   uint       `json:"queue_entry_id" gorm:"not null;uniqueIndex:idx_service_time_alert"`
+	Stage          string     `json:"stage" gorm:"not null;uniqueIndex:idx_service_time_alert"`
+	Level          string     `json:"level" gorm:"not null;uniqueIndex:idx_service_time_alert"`
+	VisitorID      uint       `json:"visitor_id" gorm:"index"`
+	Category       string     `json:"category" gorm:"index"`
+	Reference      string     `json:"reference"`
+	Minutes        int        `json:"minutes"`        // Time in the stage when the alert was raised
+	TargetMinutes  int        `json:"target_minutes"` // The service type's target for the stage
+	AcknowledgedBy *uint      `json:"acknowledged_by"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name
+func (ServiceTimeAlert) TableName() string {
+	return "service_time_alerts"
+}
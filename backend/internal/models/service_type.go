@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ServiceType is a visitor service such as the food bank or general support.
+// Help requests and tickets refer to a service type by its Code in their Category field.
+type ServiceType struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Code        string `json:"code" gorm:"uniqueIndex;not null"` // Lowercase identifier stored as the help request category
+	Name        string `json:"name" gorm:"not null"`
+	Description string `json:"description" gorm:"type:text"`
+	IsActive    bool   `json:"is_active" gorm:"default:true"`
+	SortOrder   int    `json:"sort_order" gorm:"default:0"`
+
+	// Capacity rules
+	DailyCapacity       int    `json:"daily_capacity" gorm:"default:20"`
+	VisitIntervalDays   int    `json:"visit_interval_days" gorm:"default:7"`                       // Minimum days between visits for the same visitor
+	OperatingDays       string `json:"operating_days" gorm:"default:'Tuesday,Wednesday,Thursday'"` // Comma-separated weekday names
+	OpeningTime         string `json:"opening_time" gorm:"default:'10:30'"`                        // HH:MM
+	ClosingTime         string `json:"closing_time" gorm:"default:'14:30'"`                        // HH:MM
+	SlotIntervalMinutes int    `json:"slot_interval_minutes" gorm:"default:10"`
+	MaxVisitorsPerSlot  int    `json:"max_visitors_per_slot" gorm:"default:2"`
+	AutoApprove         bool   `json:"auto_approve" gorm:"default:false"` // Issue tickets immediately when capacity allows
+
+	// Ticket format
+	TicketPrefix        string `json:"ticket_prefix" gorm:"default:'TKT'"`
+	TicketValidityHours int    `json:"ticket_validity_hours" gorm:"default:24"`
+	WhatToBring         string `json:"what_to_bring" gorm:"type:text"` // One item per line
+	Instructions        string `json:"instructions" gorm:"type:text"`
+
+	// Service time targets used for live floor alerts; 0 turns off alerts for that stage
+	TargetWaitMinutes    int `json:"target_wait_minutes" gorm:"default:20"`    // Check-in until service starts
+	TargetServiceMinutes int `json:"target_service_minutes" gorm:"default:15"` // Service start until check-out
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName specifies the table name
+func (ServiceType) TableName() string {
+	return "service_types"
+}
+
+// IsOperatingDay returns true if the service runs on the given weekday
+func (s *ServiceType) IsOperatingDay(day time.Weekday) bool {
+	for _, d := range strings.Split(s.OperatingDays, ",") {
+		if strings.EqualFold(strings.TrimSpace(d), day.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// WhatToBringList returns the items visitors should bring as a list
+func (s *ServiceType) WhatToBringList() []string {
+	items := []string{}
+	for _, item := range strings.Split(s.WhatToBring, "\n") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// SlotWindow returns the opening and closing times as minutes after midnight
+func (s *ServiceType) SlotWindow() (int, int, error) {
+	open, err := parseClockMinutes(s.OpeningTime)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid opening time: %w", err)
+	}
+	closing, err := parseClockMinutes(s.ClosingTime)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid closing time: %w", err)
+	}
+	if closing <= open {
+		return 0, 0, fmt.Errorf("closing time must be after opening time")
+	}
+	return open, closing, nil
+}
+
+// parseClockMinutes parses an HH:MM time into minutes after midnight
+func parseClockMinutes(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestServiceTypeIsOperatingDay(t *testing.T) {
+	service := ServiceType{OperatingDays: "Tuesday, wednesday ,THURSDAY"}
+	for day, want := range map[time.Weekday]bool{
+		time.Monday:    false,
+		time.Tuesday:   true,
+		time.Wednesday: true,
+		time.Thursday:  true,
+		time.Saturday:  false,
+	} {
+		if got := service.IsOperatingDay(day); got != want {
+			t.Errorf("%s: got %v, want %v", day, got, want)
+		}
+	}
+}
+
+func TestServiceTypeSlotWindow(t *testing.T) {
+	tests := []struct {
+		opening, closing string
+		open, close      int
+		wantErr          bool
+	}{
+		{"10:30", "14:30", 630, 870, false},
+		{" 09:00 ", "9:45", 540, 585, false},
+		{"14:30", "10:30", 0, 0, true},
+		{"10:30", "10:30", 0, 0, true},
+		{"half ten", "14:30", 0, 0, true},
+		{"10:30", "25:00", 0, 0, true},
+	}
+	for _, tt := range tests {
+		service := ServiceType{OpeningTime: tt.opening, ClosingTime: tt.closing}
+		open, close, err := service.SlotWindow()
+		if (err != nil) != tt.wantErr || open != tt.open || close != tt.close {
+			t.Errorf("%s-%s: got %d, %d, %v", tt.opening, tt.closing, open, close, err)
+		}
+	}
+}
+
+func TestServiceTypeWhatToBringList(t *testing.T) {
+	service := ServiceType{WhatToBring: "Photo ID\n\n  Proof of address  \r\nBags\n"}
+	want := []string{"Photo ID", "Proof of address", "Bags"}
+	if got := service.WhatToBringList(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := (&ServiceType{}).WhatToBringList(); got == nil || len(got) != 0 {
+		t.Errorf("empty list: got %#v", got)
+	}
+}
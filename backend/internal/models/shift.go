@@ -17,6 +17,7 @@ type Shift struct {
 	Role                string    `json:"role"`
 	MaxVolunteers       int       `json:"max_volunteers" gorm:"default:1"`
 	RequiredSkills      string    `json:"required_skills"`
+	RoleLevel           string    `json:"role_level,omitempty"` // Minimum volunteer role level; empty for anyone
 	AssignedVolunteerID *uint     `json:"assigned_volunteer_id"`
 	Type                string    `json:"type"`       // e.g. "fixed", "flexible", "open"
 	OpenEnded           bool      `json:"open_ended"` // true if open-ended shift
@@ -28,10 +29,14 @@ type Shift struct {
 	TimeSlotInterval  int      `json:"time_slot_interval"`  // Interval in minutes (default 30)
 	BreakDuration     int      `json:"break_duration"`      // Break between shifts in minutes
 	// Shift metadata
-	Priority           string         `json:"priority"`            // urgent, high, normal, low
-	Tags               string         `json:"tags"`                // JSON array of tags
-	Equipment          string         `json:"equipment"`           // Required equipment
-	AccessibilityNotes string         `json:"accessibility_notes"` // Accessibility information
+	Priority           string         `json:"priority"`               // urgent, high, normal, low
+	Tags               string         `json:"tags"`                   // JSON array of tags
+	Equipment          string         `json:"equipment"`              // Required equipment
+	AccessibilityNotes string         `json:"accessibility_notes"`    // Accessibility information
+	CancelledAt        *time.Time     `json:"cancelled_at,omitempty"` // Set when the shift was called off
+	CancelledBy        *uint          `json:"cancelled_by,omitempty"`
+	CancellationReason string         `json:"cancellation_reason,omitempty"`
+	SeriesID           *uint          `json:"series_id,omitempty" gorm:"index"`
 	CreatedAt          time.Time      `json:"created_at"`
 	UpdatedAt          time.Time      `json:"updated_at"`
 	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
@@ -63,9 +68,11 @@ type ShiftAssignment struct {
 	CancelledAt *time.Time `json:"cancelled_at"`
 
 	// Check-in/out tracking
-	CheckedInAt  *time.Time `json:"checked_in_at"`
-	CheckedOutAt *time.Time `json:"checked_out_at"`
-	HoursLogged  float64    `json:"hours_logged" gorm:"default:0"`
+	CheckedInAt     *time.Time `json:"checked_in_at"`
+	CheckedOutAt    *time.Time `json:"checked_out_at"`
+	HoursLogged     float64    `json:"hours_logged" gorm:"default:0"`
+	CheckInMethod   string     `json:"check_in_method,omitempty"` // shift_code when checked in with the coordinator's code
+	CheckInFailures int        `json:"-" gorm:"default:0"`        // Wrong shift codes entered, to stop guessing
 
 	// Cancellation details
 	CancellationReason string  `json:"cancellation_reason"`
@@ -84,6 +91,24 @@ type ShiftAssignment struct {
 	ReassignedBy       *uint      `json:"reassigned_by"`
 	ReassignedAt       *time.Time `json:"reassigned_at"`
 
+	// Swap flagging when the volunteer marks themselves away
+	SwapFlaggedAt *time.Time `json:"swap_flagged_at" gorm:"index"`
+	AwayPeriodID  *uint      `json:"away_period_id" gorm:"index"`
+
+	// Re-confirmation asked for after an admin changed the shift's time or place
+	ReconfirmRequestedAt *time.Time `json:"reconfirm_requested_at,omitempty" gorm:"index"`
+	ReconfirmedAt        *time.Time `json:"reconfirmed_at,omitempty"`
+
+	// Attendance and hours sign-off, by an admin or the volunteer's team lead
+	AttendanceConfirmedBy *uint      `json:"attendance_confirmed_by,omitempty"`
+	AttendanceConfirmedAt *time.Time `json:"attendance_confirmed_at,omitempty"`
+	HoursApprovedBy       *uint      `json:"hours_approved_by,omitempty"`
+	HoursApprovedAt       *time.Time `json:"hours_approved_at,omitempty"`
+
+	// Reminder tracking
+	ReminderSentAt      *time.Time `json:"reminder_sent_at"`
+	FeedbackRequestedAt *time.Time `json:"feedback_requested_at"`
+
 	// Flexible shift support - custom time selection
 	CustomStartTime *time.Time `json:"custom_start_time"`
 	CustomEndTime   *time.Time `json:"custom_end_time"`
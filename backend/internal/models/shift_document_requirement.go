@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// ShiftRoleDocumentRequirement says that volunteers need a valid document of a type
+// before they can work shifts with a role. Roles are stored in lower case.
+type ShiftRoleDocumentRequirement struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Role         string    `json:"role" gorm:"not null;uniqueIndex:idx_shift_role_document"`
+	DocumentType string    `json:"document_type" gorm:"not null;uniqueIndex:idx_shift_role_document"`
+	CreatedBy    uint      `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (ShiftRoleDocumentRequirement) TableName() string {
+	return "shift_role_document_requirements"
+}
+
+// ShiftRoleTrainingRequirement says that volunteers must have completed a training
+// module before they can see or sign up for shifts with a role
+type ShiftRoleTrainingRequirement struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	Role             string    `json:"role" gorm:"not null;uniqueIndex:idx_shift_role_training"`
+	TrainingModuleID uint      `json:"training_module_id" gorm:"not null;uniqueIndex:idx_shift_role_training"`
+	CreatedBy        uint      `json:"created_by"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	// Relationships
+	TrainingModule TrainingModule `json:"training_module,omitempty" gorm:"foreignKey:TrainingModuleID"`
+}
+
+// TableName specifies the table name
+func (ShiftRoleTrainingRequirement) TableName() string {
+	return "shift_role_training_requirements"
+}
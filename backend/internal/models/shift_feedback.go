@@ -0,0 +1,63 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Shift feedback issue codes volunteers can tick
+const (
+	ShiftIssueUnderstaffed    = "understaffed"
+	ShiftIssueEquipment       = "equipment"        // Missing or broken equipment or supplies
+	ShiftIssueBriefing        = "briefing"         // Unclear instructions or no briefing
+	ShiftIssueLateStart       = "late_start"       // Site not open or shift started late
+	ShiftIssueSafety          = "safety"           // Health and safety concern
+	ShiftIssueVisitorConflict = "visitor_conflict" // Difficult or aggressive visitor
+	ShiftIssueFacilities      = "facilities"       // Toilets, heating, access or parking
+	ShiftIssueOther           = "other"
+)
+
+// ShiftIssues lists the issue codes in the order they are shown
+var ShiftIssues = []string{
+	ShiftIssueUnderstaffed,
+	ShiftIssueEquipment,
+	ShiftIssueBriefing,
+	ShiftIssueLateStart,
+	ShiftIssueSafety,
+	ShiftIssueVisitorConflict,
+	ShiftIssueFacilities,
+	ShiftIssueOther,
+}
+
+// ShiftFeedback is a volunteer's quick feedback on a shift they worked
+type ShiftFeedback struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	ShiftID          uint      `json:"shift_id" gorm:"not null;index"`
+	AssignmentID     uint      `json:"assignment_id" gorm:"not null;uniqueIndex"`
+	UserID           uint      `json:"user_id" gorm:"not null;index"`
+	Rating           int       `json:"rating" gorm:"not null"` // Overall shift quality from 1 to 5
+	StaffingAdequate *bool     `json:"staffing_adequate"`
+	Issues           string    `json:"issues"` // Comma separated issue codes
+	Comments         string    `json:"comments" gorm:"type:text"`
+	CreatedAt        time.Time `json:"created_at"`
+
+	// Relationships
+	Shift Shift `json:"shift,omitempty" gorm:"foreignKey:ShiftID"`
+	User  User  `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// TableName overrides the table name
+func (ShiftFeedback) TableName() string {
+	return "shift_feedback"
+}
+
+// IssueList returns the feedback's issue codes
+func (f *ShiftFeedback) IssueList() []string {
+	var issues []string
+	for _, issue := range strings.Split(f.Issues, ",") {
+		if issue = strings.TrimSpace(issue); issue != "" {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// How often a shift series repeats
+const (
+	ShiftSeriesDaily       = "daily"
+	ShiftSeriesWeekly      = "weekly"
+	ShiftSeriesFortnightly = "fortnightly"
+)
+
+// Which shifts in a series an edit applies to
+const (
+	ShiftEditThis   = "this"   // Only the chosen shift
+	ShiftEditFuture = "future" // The chosen shift and every later one in the series
+	ShiftEditAll    = "all"    // Every upcoming shift in the series
+)
+
+// ShiftSeries groups the shifts created from one recurring pattern, such as every
+// Tuesday morning at the food bank, so they can be edited together
+type ShiftSeries struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `json:"name"`
+	Frequency string    `json:"frequency" gorm:"not null"`
+	StartDate time.Time `json:"start_date"` // Date of the first shift
+	EndDate   time.Time `json:"end_date"`   // Date of the last shift
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (ShiftSeries) TableName() string {
+	return "shift_series"
+}
+
+// ShiftSeriesStep returns the gap between shifts in a series as days, or 0 when the
+// frequency is unknown
+func ShiftSeriesStep(frequency string) int {
+	switch frequency {
+	case ShiftSeriesDaily:
+		return 1
+	case ShiftSeriesWeekly:
+		return 7
+	case ShiftSeriesFortnightly:
+		return 14
+	}
+	return 0
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// LocationTravelTime is the time volunteers need to get between two shift locations.
+// Pairs are stored once with the locations in alphabetical order; pairs without an
+// entry use the default travel buffer.
+type LocationTravelTime struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	FromLocation string    `json:"from_location" gorm:"not null;uniqueIndex:idx_location_travel_pair"`
+	ToLocation   string    `json:"to_location" gorm:"not null;uniqueIndex:idx_location_travel_pair"`
+	Minutes      int       `json:"minutes"`
+	UpdatedBy    *uint     `json:"updated_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (LocationTravelTime) TableName() string {
+	return "location_travel_times"
+}
+
+// NormalizeShiftLocation tidies a shift location so the same site matches however it
+// was typed
+func NormalizeShiftLocation(location string) string {
+	return strings.ToLower(strings.Join(strings.Fields(location), " "))
+}
+
+// LocationPair returns two locations normalized and in the order they are stored
+func LocationPair(a, b string) (string, string) {
+	a, b = NormalizeShiftLocation(a), NormalizeShiftLocation(b)
+	if b < a {
+		return b, a
+	}
+	return a, b
+}
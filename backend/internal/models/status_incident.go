@@ -0,0 +1,84 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status page components
+const (
+	StatusComponentAPI           = "api"
+	StatusComponentBookings      = "bookings"
+	StatusComponentNotifications = "notifications"
+)
+
+// Component health values, from best to worst
+const (
+	ComponentOperational = "operational"
+	ComponentDegraded    = "degraded_performance"
+	ComponentPartial     = "partial_outage"
+	ComponentMajor       = "major_outage"
+)
+
+// Incident severity values
+const (
+	IncidentSeverityMinor    = "minor"
+	IncidentSeverityMajor    = "major"
+	IncidentSeverityCritical = "critical"
+)
+
+// Incident status values
+const (
+	IncidentStatusInvestigating = "investigating"
+	IncidentStatusIdentified    = "identified"
+	IncidentStatusMonitoring    = "monitoring"
+	IncidentStatusResolved      = "resolved"
+)
+
+// StatusIncident is an admin-controlled notice shown on the public status page and
+// in the frontend's degradation banner
+type StatusIncident struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	Title      string         `json:"title" gorm:"not null"`
+	Message    string         `json:"message" gorm:"type:text"`
+	Severity   string         `json:"severity" gorm:"default:'minor'"`
+	Status     string         `json:"status" gorm:"default:'investigating';index"`
+	Components string         `json:"components"` // Comma-separated affected components
+	StartedAt  time.Time      `json:"started_at"`
+	ResolvedAt *time.Time     `json:"resolved_at"`
+	CreatedBy  uint           `json:"created_by"`
+	UpdatedBy  *uint          `json:"updated_by"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName specifies the table name
+func (StatusIncident) TableName() string {
+	return "status_incidents"
+}
+
+// AffectedComponents returns the components the incident affects
+func (si *StatusIncident) AffectedComponents() []string {
+	var components []string
+	for _, component := range strings.Split(si.Components, ",") {
+		if component = strings.TrimSpace(component); component != "" {
+			components = append(components, component)
+		}
+	}
+	return components
+}
+
+// ComponentImpact returns the component health an incident of this severity implies
+func (si *StatusIncident) ComponentImpact() string {
+	switch si.Severity {
+	case IncidentSeverityCritical:
+		return ComponentMajor
+	case IncidentSeverityMajor:
+		return ComponentPartial
+	default:
+		return ComponentDegraded
+	}
+}
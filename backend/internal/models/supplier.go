@@ -0,0 +1,138 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// How a supplier receives orders
+const (
+	SupplierOrderMethodWebhook = "webhook" // JSON posted to the supplier's endpoint
+	SupplierOrderMethodEmail   = "email"   // Order form emailed to the supplier
+)
+
+// Supplier order status values
+const (
+	SupplierOrderDraft              = "draft"
+	SupplierOrderSubmitted          = "submitted"
+	SupplierOrderAcknowledged       = "acknowledged"
+	SupplierOrderDispatched         = "dispatched"
+	SupplierOrderPartiallyDelivered = "partially_delivered"
+	SupplierOrderDelivered          = "delivered"
+	SupplierOrderCancelled          = "cancelled"
+	SupplierOrderFailed             = "failed" // Submission failed; can be submitted again
+)
+
+// SupplierOrderOpenStatuses are orders still expected to bring stock in
+var SupplierOrderOpenStatuses = []string{
+	SupplierOrderDraft, SupplierOrderSubmitted, SupplierOrderAcknowledged,
+	SupplierOrderDispatched, SupplierOrderPartiallyDelivered, SupplierOrderFailed,
+}
+
+// Supplier order triggers
+const (
+	SupplierOrderTriggerLowStock = "low_stock"
+	SupplierOrderTriggerManual   = "manual"
+)
+
+// Supplier is a food bank, wholesaler or partner that stock can be ordered or
+// collected from
+type Supplier struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	Name          string         `json:"name" gorm:"not null;index"`
+	ContactName   string         `json:"contact_name"`
+	ContactEmail  string         `json:"contact_email"`
+	ContactPhone  string         `json:"contact_phone"`
+	OrderMethod   string         `json:"order_method" gorm:"default:'email'"` // webhook, email
+	WebhookURL    string         `json:"webhook_url"`
+	WebhookSecret string         `json:"-"`          // Signs outgoing orders and verifies status callbacks
+	Categories    string         `json:"categories"` // Comma separated urgent need categories supplied
+	LeadTimeDays  int            `json:"lead_time_days" gorm:"default:3"`
+	AutoSubmit    bool           `json:"auto_submit" gorm:"default:false"` // Submit low stock orders without review
+	IsActive      bool           `json:"is_active" gorm:"default:true"`
+	Notes         string         `json:"notes" gorm:"type:text"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName specifies the table name
+func (Supplier) TableName() string {
+	return "suppliers"
+}
+
+// Supplies checks if the supplier covers an urgent need category
+func (s *Supplier) Supplies(category string) bool {
+	for _, c := range strings.Split(s.Categories, ",") {
+		if strings.EqualFold(strings.TrimSpace(c), category) {
+			return true
+		}
+	}
+	return false
+}
+
+// SupplierOrder is a purchase or collection request sent to a supplier
+type SupplierOrder struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Reference   string         `json:"reference" gorm:"uniqueIndex;not null"`
+	SupplierID  uint           `json:"supplier_id" gorm:"not null;index"`
+	Status      string         `json:"status" gorm:"default:'draft';index"`
+	Trigger     string         `json:"trigger" gorm:"default:'manual'"` // low_stock, manual
+	ExternalRef string         `json:"external_ref"`                    // The supplier's own order number
+	ExpectedAt  *time.Time     `json:"expected_at"`
+	SubmittedAt *time.Time     `json:"submitted_at"`
+	DeliveredAt *time.Time     `json:"delivered_at"`
+	LastError   string         `json:"last_error,omitempty" gorm:"type:text"`
+	CreatedBy   *uint          `json:"created_by"` // Empty when raised by the low stock check
+	Notes       string         `json:"notes" gorm:"type:text"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Supplier Supplier            `json:"supplier" gorm:"foreignKey:SupplierID"`
+	Lines    []SupplierOrderLine `json:"lines" gorm:"foreignKey:OrderID"`
+}
+
+// TableName specifies the table name
+func (SupplierOrder) TableName() string {
+	return "supplier_orders"
+}
+
+// IsOpen checks if the order is still expected to bring stock in
+func (o *SupplierOrder) IsOpen() bool {
+	for _, status := range SupplierOrderOpenStatuses {
+		if o.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// SupplierOrderLine is one inventory item on a supplier order
+type SupplierOrderLine struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	OrderID          uint       `json:"order_id" gorm:"not null;index"`
+	UrgentNeedID     uint       `json:"urgent_need_id" gorm:"not null;index"`
+	ItemName         string     `json:"item_name"`
+	QuantityOrdered  int        `json:"quantity_ordered"`
+	QuantityReceived int        `json:"quantity_received" gorm:"default:0"`
+	ReceivedAt       *time.Time `json:"received_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (SupplierOrderLine) TableName() string {
+	return "supplier_order_lines"
+}
+
+// Outstanding returns how many ordered items have not yet arrived
+func (l *SupplierOrderLine) Outstanding() int {
+	if l.QuantityReceived >= l.QuantityOrdered {
+		return 0
+	}
+	return l.QuantityOrdered - l.QuantityReceived
+}
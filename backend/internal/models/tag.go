@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// Entity types a tag can be attached to. Visitors and volunteers are tagged by user ID.
+const (
+	TagEntityVisitor     = "visitor"
+	TagEntityVolunteer   = "volunteer"
+	TagEntityHelpRequest = "help_request"
+	TagEntityDonation    = "donation"
+)
+
+// TagEntityTypes lists every entity type a tag can be attached to
+var TagEntityTypes = []string{TagEntityVisitor, TagEntityVolunteer, TagEntityHelpRequest, TagEntityDonation}
+
+// Tag is a label admins attach to visitors, volunteers, help requests and donations,
+// such as "Christmas appeal" or "school partnership", to group them across the system
+type Tag struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `json:"name" gorm:"not null"`
+	Slug        string    `json:"slug" gorm:"uniqueIndex;not null"` // Lower-case name used in filters
+	Description string    `json:"description"`
+	Colour      string    `json:"colour"` // Hex colour for badges, e.g. #2E7D32
+	CreatedBy   uint      `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// TagAssignment attaches a tag to one visitor, volunteer, help request or donation
+type TagAssignment struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	TagID      uint      `json:"tag_id" gorm:"uniqueIndex:idx_tag_assignment;not null"`
+	EntityType string    `json:"entity_type" gorm:"uniqueIndex:idx_tag_assignment;index:idx_tag_assignment_entity;not null"`
+	EntityID   uint      `json:"entity_id" gorm:"uniqueIndex:idx_tag_assignment;index:idx_tag_assignment_entity;not null"`
+	AssignedBy uint      `json:"assigned_by"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// Relationships
+	Tag *Tag `json:"tag,omitempty" gorm:"foreignKey:TagID"`
+}
+
+// TableName specifies the table name
+func (TagAssignment) TableName() string {
+	return "tag_assignments"
+}
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Ticket scan outcomes
+const (
+	TicketScanAccepted = "accepted"
+	TicketScanFlagged  = "flagged" // Held for staff because of suspected misuse
+)
+
+// Reasons a ticket scan is suspected of misuse
+const (
+	TicketFraudDuplicateScan  = "duplicate_scan"  // Already checked in, or presented on two devices
+	TicketFraudImpossibleTime = "impossible_time" // Scanned before it was issued or outside opening hours
+	TicketFraudSharedDevice   = "shared_device"   // One device checking in tickets for several visitors
+)
+
+// Ticket fraud event review statuses
+const (
+	TicketFraudOpen      = "open"
+	TicketFraudConfirmed = "confirmed"
+	TicketFraudDismissed = "dismissed"
+)
+
+// TicketScan records each time a ticket is scanned or entered for check-in
+type TicketScan struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	TicketID     uint      `json:"ticket_id" gorm:"not null;index"`
+	TicketNumber string    `json:"ticket_number" gorm:"type:varchar(50)"`
+	VisitorID    uint      `json:"visitor_id" gorm:"index"`
+	Method       string    `json:"method" gorm:"type:varchar(30)"` // qr, ticket_number, self_service...
+	DeviceID     string    `json:"device_id" gorm:"type:varchar(100);index"`
+	IPAddress    string    `json:"ip_address" gorm:"type:varchar(45)"`
+	StaffID      *uint     `json:"staff_id"`
+	SelfService  bool      `json:"self_service"` // Scanned on the visitor's own device rather than at the desk
+	Outcome      string    `json:"outcome" gorm:"type:varchar(20);not null"`
+	ScannedAt    time.Time `json:"scanned_at" gorm:"not null;index"`
+}
+
+// TableName specifies the table name
+func (TicketScan) TableName() string {
+	return "ticket_scans"
+}
+
+// TicketFraudEvent is a suspected misuse of a ticket, held for staff review
+type TicketFraudEvent struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	TicketID     uint       `json:"ticket_id" gorm:"not null;index"`
+	TicketNumber string     `json:"ticket_number" gorm:"type:varchar(50)"`
+	VisitorID    uint       `json:"visitor_id" gorm:"index"`
+	ScanID       uint       `json:"scan_id"`
+	Reason       string     `json:"reason" gorm:"type:varchar(30);not null;index"`
+	Details      string     `json:"details"`
+	DeviceID     string     `json:"device_id" gorm:"type:varchar(100)"`
+	Status       string     `json:"status" gorm:"type:varchar(20);not null;default:'open';index"`
+	ReviewedBy   *uint      `json:"reviewed_by"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+	ReviewNotes  string     `json:"review_notes"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (TicketFraudEvent) TableName() string {
+	return "ticket_fraud_events"
+}
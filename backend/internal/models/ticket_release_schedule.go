@@ -0,0 +1,96 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Outcomes of a scheduled ticket release slot
+const (
+	ScheduledReleaseRunning  = "running"
+	ScheduledReleaseReleased = "released"
+	ScheduledReleaseSkipped  = "skipped" // Already released by hand, or the slot was missed
+	ScheduledReleaseFailed   = "failed"
+)
+
+// TicketReleaseSchedule is when tickets are released automatically. There is one
+// row; without it the release runs at 09:00 on Tuesday, Wednesday and Thursday,
+// issuing tickets for that day's visits under the stored daily capacity.
+type TicketReleaseSchedule struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Enabled   bool      `json:"enabled"`
+	Weekdays  string    `json:"-" gorm:"size:20"`         // Comma-separated weekday numbers, 0 = Sunday
+	ReleaseAt string    `json:"release_at" gorm:"size:5"` // HH:MM, local time
+	UpdatedBy *uint     `json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (TicketReleaseSchedule) TableName() string {
+	return "ticket_release_schedules"
+}
+
+// DefaultTicketReleaseSchedule is the schedule used until an admin changes it
+func DefaultTicketReleaseSchedule() TicketReleaseSchedule {
+	return TicketReleaseSchedule{Enabled: true, Weekdays: "2,3,4", ReleaseAt: "09:00"}
+}
+
+// Days returns the weekdays the release runs on
+func (s *TicketReleaseSchedule) Days() []time.Weekday {
+	days := []time.Weekday{}
+	for _, value := range strings.Split(s.Weekdays, ",") {
+		var day int
+		if _, err := fmt.Sscanf(strings.TrimSpace(value), "%d", &day); err == nil && day >= 0 && day <= 6 {
+			days = append(days, time.Weekday(day))
+		}
+	}
+	return days
+}
+
+// SetDays stores the weekdays the release runs on
+func (s *TicketReleaseSchedule) SetDays(days []time.Weekday) {
+	values := make([]string, len(days))
+	for i, day := range days {
+		values[i] = fmt.Sprint(int(day))
+	}
+	s.Weekdays = strings.Join(values, ",")
+}
+
+// SlotOn returns when the release runs on a day, and false when it does not run
+// that day
+func (s *TicketReleaseSchedule) SlotOn(day time.Time) (time.Time, bool) {
+	runs := false
+	for _, weekday := range s.Days() {
+		if weekday == day.Weekday() {
+			runs = true
+		}
+	}
+	minutes, err := parseClockMinutes(s.ReleaseAt)
+	if !runs || err != nil {
+		return time.Time{}, false
+	}
+	y, m, d := day.Date()
+	return time.Date(y, m, d, minutes/60, minutes%60, 0, 0, day.Location()), true
+}
+
+// ScheduledReleaseRun records what the scheduler did at one release slot. The slot is
+// unique, so however many instances are running a slot is only released once.
+type ScheduledReleaseRun struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	ScheduledFor  time.Time  `json:"scheduled_for" gorm:"uniqueIndex;not null"`
+	ReleaseDate   string     `json:"release_date" gorm:"size:10;index"` // Visit day released, YYYY-MM-DD
+	Status        string     `json:"status" gorm:"size:20;index"`
+	Reason        string     `json:"reason,omitempty" gorm:"type:text"` // Why the slot was skipped or failed
+	ReleaseRunID  *uint      `json:"release_run_id,omitempty"`          // The ticket release it made
+	TotalReleased int        `json:"total_released"`
+	StartedAt     time.Time  `json:"started_at"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (ScheduledReleaseRun) TableName() string {
+	return "scheduled_release_runs"
+}
@@ -16,6 +16,12 @@ type User struct {
 	Phone     string `json:"phone"`
 	Role      string `json:"role"`
 
+	// AdminScope limits an admin to some admin modules; empty means full admin access
+	AdminScope string `json:"admin_scope,omitempty" gorm:"type:varchar(40)"`
+
+	// Language is the preferred language for messages, e.g. pl; blank uses the location default
+	Language string `json:"language,omitempty" gorm:"type:varchar(20)"`
+
 	// Keep only common fields
 	Address  string `json:"address"`
 	City     string `json:"city"`
@@ -31,6 +37,9 @@ type User struct {
 	PhoneVerified   bool       `json:"phone_verified" gorm:"default:false"`
 	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
 
+	// TokenVersion is carried in issued tokens; raising it ends every existing session
+	TokenVersion int `json:"-" gorm:"default:0"`
+
 	// Payment integration fields
 	StripeCustomerID string `json:"stripe_customer_id,omitempty"`
 
@@ -273,15 +282,30 @@ type DataExportRequest struct {
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
+// Account deletion request statuses
+const (
+	AccountDeletionPending   = "pending"
+	AccountDeletionScheduled = "scheduled" // Account disabled until the grace period ends
+	AccountDeletionCompleted = "completed"
+	AccountDeletionCancelled = "cancelled"
+)
+
+// UserStatusPendingDeletion disables an account during its deletion grace period
+const UserStatusPendingDeletion = "pending_deletion"
+
 // AccountDeletionRequest tracks deletion requests and their status
 type AccountDeletionRequest struct {
-	ID          uint       `gorm:"primarykey" json:"id"`
-	UserID      uint       `gorm:"index;not null" json:"user_id"`
-	RequestedAt time.Time  `json:"requested_at"`
-	ConfirmedAt *time.Time `json:"confirmed_at"`
-	CompletedAt *time.Time `json:"completed_at"`
-	Status      string     `json:"status" gorm:"default:'pending'"` // pending, confirmed, completed, cancelled
-	Reason      string     `json:"reason"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID             uint       `gorm:"primarykey" json:"id"`
+	UserID         uint       `gorm:"index;not null" json:"user_id"`
+	RequestedAt    time.Time  `json:"requested_at"`
+	ConfirmedAt    *time.Time `json:"confirmed_at"`
+	ScheduledFor   *time.Time `json:"scheduled_for,omitempty" gorm:"index"` // When the account is erased
+	CancelledAt    *time.Time `json:"cancelled_at,omitempty"`
+	CompletedAt    *time.Time `json:"completed_at"`
+	Status         string     `json:"status" gorm:"default:'pending'"` // pending, confirmed, scheduled, completed, cancelled
+	Reason         string     `json:"reason"`
+	CancelToken    string     `json:"-" gorm:"type:varchar(64);index"` // Hash of the emailed cancel link token
+	PreviousStatus string     `json:"-" gorm:"type:varchar(20)"`       // Account status restored on cancellation
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
 }
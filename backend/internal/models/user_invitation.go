@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Invitation statuses, derived from the accepted, revoked and expiry times
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+	InvitationStatusExpired  = "expired"
+)
+
+// InvitableRoles are the roles new accounts can be invited to. Other users register
+// themselves.
+var InvitableRoles = []string{RoleAdmin, RoleStaff}
+
+// UserInvitation invites someone to create a staff or admin account. Only a hash of the
+// signed token emailed to the invitee is stored.
+type UserInvitation struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Email          string     `json:"email" gorm:"not null;index"`
+	FirstName      string     `json:"first_name"`
+	LastName       string     `json:"last_name"`
+	Role           string     `json:"role" gorm:"not null"`
+	AdminScope     string     `json:"admin_scope,omitempty" gorm:"type:varchar(40)"`
+	Department     string     `json:"department,omitempty"` // Staff only
+	Position       string     `json:"position,omitempty"`   // Staff only
+	TokenHash      string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null"`
+	InvitedBy      uint       `json:"invited_by" gorm:"not null"`
+	SentCount      int        `json:"sent_count" gorm:"default:1"`
+	LastSentAt     time.Time  `json:"last_sent_at"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+	AcceptedUserID *uint      `json:"accepted_user_id"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	RevokedBy      *uint      `json:"revoked_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (UserInvitation) TableName() string {
+	return "user_invitations"
+}
+
+// Status returns where the invitation is in its lifecycle
+func (i *UserInvitation) Status(now time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationStatusAccepted
+	case i.RevokedAt != nil:
+		return InvitationStatusRevoked
+	case !now.Before(i.ExpiresAt):
+		return InvitationStatusExpired
+	default:
+		return InvitationStatusPending
+	}
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"time"
 
@@ -26,6 +28,8 @@ type VisitorProfile struct {
 	EmergencyContact     string         `json:"emergency_contact"` // Changed to string for simplicity
 	PreferredContactTime string         `json:"preferred_contact_time"`
 	Notes                string         `json:"notes"`
+	PlainLanguage        bool           `json:"plain_language" gorm:"default:false"` // Tickets and emails in simpler words
+	LargePrint           bool           `json:"large_print" gorm:"default:false"`    // Larger email text and a large-print PDF ticket
 	CreatedAt            time.Time      `json:"created_at"`
 	UpdatedAt            time.Time      `json:"updated_at"`
 	DeletedAt            gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
@@ -110,19 +114,46 @@ func (v *Visit) MarkNoShow(staffID uint, notes string) {
 
 // VisitCapacity manages daily visit limits and operating schedule
 type VisitCapacity struct {
-	ID                   uint           `gorm:"primaryKey" json:"id"`
-	Date                 time.Time      `json:"date" gorm:"uniqueIndex"`
-	DayOfWeek            string         `json:"day_of_week"`
-	MaxFoodVisits        int            `json:"max_food_visits" gorm:"default:50"`
-	MaxGeneralVisits     int            `json:"max_general_visits" gorm:"default:20"`
-	CurrentFoodVisits    int            `json:"current_food_visits" gorm:"default:0"`
-	CurrentGeneralVisits int            `json:"current_general_visits" gorm:"default:0"`
-	IsOperatingDay       bool           `json:"is_operating_day" gorm:"default:true"`
-	Notes                string         `json:"notes"`
-	TemporaryAdjustment  bool           `json:"temporary_adjustment" gorm:"default:false"`
-	CreatedAt            time.Time      `json:"created_at"`
-	UpdatedAt            time.Time      `json:"updated_at"`
-	DeletedAt            gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	Date                 time.Time `json:"date" gorm:"uniqueIndex"`
+	DayOfWeek            string    `json:"day_of_week"`
+	MaxFoodVisits        int       `json:"max_food_visits" gorm:"default:50"`
+	MaxGeneralVisits     int       `json:"max_general_visits" gorm:"default:20"`
+	CurrentFoodVisits    int       `json:"current_food_visits" gorm:"default:0"`
+	CurrentGeneralVisits int       `json:"current_general_visits" gorm:"default:0"`
+	IsOperatingDay       bool      `json:"is_operating_day" gorm:"default:true"`
+	Notes                string    `json:"notes"`
+	TemporaryAdjustment  bool      `json:"temporary_adjustment" gorm:"default:false"`
+	// Slots included in the max visits by approved capacity overrides
+	OverrideFoodVisits    int            `json:"override_food_visits" gorm:"default:0"`
+	OverrideGeneralVisits int            `json:"override_general_visits" gorm:"default:0"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BaseCapacity returns a category's max visits without approved overrides
+func (vc *VisitCapacity) BaseCapacity(category string) int {
+	switch category {
+	case CategoryFood:
+		return vc.MaxFoodVisits - vc.OverrideFoodVisits
+	case CategoryGeneral:
+		return vc.MaxGeneralVisits - vc.OverrideGeneralVisits
+	default:
+		return 0
+	}
+}
+
+// OverrideCapacity returns the slots approved overrides add to a category
+func (vc *VisitCapacity) OverrideCapacity(category string) int {
+	switch category {
+	case CategoryFood:
+		return vc.OverrideFoodVisits
+	case CategoryGeneral:
+		return vc.OverrideGeneralVisits
+	default:
+		return 0
+	}
 }
 
 // HasCapacity checks if there's available capacity for a category
@@ -325,24 +356,26 @@ func (vf *VisitFeedback) CanEdit() bool {
 
 // Ticket represents a visitor's access ticket
 type Ticket struct {
-	ID            uint           `json:"id" gorm:"primaryKey"`
-	TicketNumber  string         `json:"ticket_number" gorm:"type:varchar(50);uniqueIndex;not null"`
-	HelpRequestID uint           `json:"help_request_id" gorm:"not null"`
-	VisitorID     uint           `json:"visitor_id" gorm:"not null"`
-	VisitorName   string         `json:"visitor_name" gorm:"type:varchar(255);not null"`
-	Category      string         `json:"category" gorm:"type:varchar(100)"`
-	VisitDate     time.Time      `json:"visit_date"`
-	TimeSlot      string         `json:"time_slot" gorm:"type:varchar(20)"`
-	QRCode        string         `json:"qr_code" gorm:"type:text"`
-	Status        string         `json:"status" gorm:"type:varchar(20);not null;default:'active'"`
-	IssuedAt      time.Time      `json:"issued_at" gorm:"not null"`
-	ValidUntil    time.Time      `json:"valid_until" gorm:"not null"`
-	ExpiresAt     time.Time      `json:"expires_at" gorm:"not null"`
-	UsedAt        *time.Time     `json:"used_at,omitempty"`
-	UsedBy        *uint          `json:"used_by,omitempty"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	TicketNumber      string         `json:"ticket_number" gorm:"type:varchar(50);uniqueIndex;not null"`
+	HelpRequestID     uint           `json:"help_request_id" gorm:"not null"`
+	VisitorID         uint           `json:"visitor_id" gorm:"not null"`
+	VisitorName       string         `json:"visitor_name" gorm:"type:varchar(255);not null"`
+	Category          string         `json:"category" gorm:"type:varchar(100)"`
+	VisitDate         time.Time      `json:"visit_date"`
+	TimeSlot          string         `json:"time_slot" gorm:"type:varchar(20)"`
+	QRCode            string         `json:"qr_code" gorm:"type:text"`
+	Status            string         `json:"status" gorm:"type:varchar(20);not null;default:'active'"`
+	IssuedAt          time.Time      `json:"issued_at" gorm:"not null"`
+	ValidUntil        time.Time      `json:"valid_until" gorm:"not null"`
+	ExpiresAt         time.Time      `json:"expires_at" gorm:"not null"`
+	UsedAt            *time.Time     `json:"used_at,omitempty"`
+	UsedBy            *uint          `json:"used_by,omitempty"`
+	InterpreterStatus string         `json:"interpreter_status,omitempty" gorm:"type:varchar(20)"` // Status of the visitor's interpreter booking, if any
+	LinkToken         string         `json:"-" gorm:"type:varchar(32);uniqueIndex"`                // Random token in the public short link sent by SMS
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	// Relationships
 	HelpRequest HelpRequest `json:"help_request" gorm:"foreignKey:HelpRequestID"`
@@ -355,6 +388,41 @@ func (Ticket) TableName() string {
 	return "tickets"
 }
 
+// BeforeCreate gives a new ticket its short link token and copies the help
+// request's interpreter booking status onto it, so staff see it wherever the ticket
+// is shown
+func (t *Ticket) BeforeCreate(tx *gorm.DB) error {
+	if t.LinkToken == "" {
+		token, err := NewTicketLinkToken()
+		if err != nil {
+			return err
+		}
+		t.LinkToken = token
+	}
+	if t.HelpRequestID == 0 || t.InterpreterStatus != "" {
+		return nil
+	}
+	var statuses []string
+	tx.Session(&gorm.Session{NewDB: true}).Model(&InterpreterBooking{}).
+		Where("help_request_id = ?", t.HelpRequestID).
+		Limit(1).
+		Pluck("status", &statuses)
+	if len(statuses) > 0 {
+		t.InterpreterStatus = statuses[0]
+	}
+	return nil
+}
+
+// NewTicketLinkToken returns a random 128-bit token for a ticket's public short link.
+// Ticket numbers are sequential, so they are never used to look a ticket up publicly.
+func NewTicketLinkToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
 // Ticket status constants
 const (
 	TicketStatusActive    = "active"
@@ -504,10 +572,13 @@ type QueueEntry struct {
 	Reference        string         `json:"reference" gorm:"index"` // Reference or ticket number
 	Position         int            `json:"position"`
 	EstimatedMinutes int            `json:"estimated_minutes"`                   // Estimated wait time in minutes
-	Status           string         `json:"status" gorm:"default:waiting;index"` // waiting, called, served, cancelled, completed
+	Status           string         `json:"status" gorm:"default:waiting;index"` // waiting, called, recall, served, cancelled, completed, no_show
 	JoinedAt         time.Time      `json:"joined_at"`
 	CalledAt         *time.Time     `json:"called_at"`
+	MissedCalls      int            `json:"missed_calls" gorm:"default:0"` // Calls the visitor did not answer within the grace period
+	RecallPoolAt     *time.Time     `json:"recall_pool_at"`                // When a missed call moved the entry to the re-call pool
 	ServedAt         *time.Time     `json:"served_at"`
+	CompletedAt      *time.Time     `json:"completed_at"`
 	CancelledAt      *time.Time     `json:"cancelled_at"`
 	Notes            string         `json:"notes"`
 	CreatedAt        time.Time      `json:"created_at"`
@@ -530,7 +601,7 @@ func (q *QueueEntry) MarkServiced() {
 func (q *QueueEntry) MarkCompleted() {
 	q.Status = "completed"
 	now := time.Now()
-	// Using UpdatedAt for completion time since there's no CompletedAt field
+	q.CompletedAt = &now
 	q.UpdatedAt = now
 }
 
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// System config keys holding the visitor re-verification policy
+const (
+	ReverificationMonthsConfigKey    = "visitor_reverification_months"
+	ReverificationGraceDaysConfigKey = "visitor_reverification_grace_days"
+)
+
+// Visitor re-verification statuses
+const (
+	ReverificationDue        = "due"        // Visitor has been asked to re-upload documents
+	ReverificationRestricted = "restricted" // Grace period passed, new bookings are blocked
+	ReverificationCompleted  = "completed"  // Documents were re-approved
+)
+
+// VisitorReverification is one cycle of a long-term visitor refreshing their identity
+// and address documents. A visitor has at most one open (due or restricted) cycle.
+type VisitorReverification struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `json:"user_id" gorm:"index;not null"`
+	VerifiedAt   time.Time  `json:"verified_at"` // When the documents being refreshed were approved
+	DueAt        time.Time  `json:"due_at"`
+	FlaggedAt    time.Time  `json:"flagged_at" gorm:"index"`
+	GraceEndsAt  time.Time  `json:"grace_ends_at"`
+	Status       string     `json:"status" gorm:"type:varchar(20);index;default:due"`
+	RestrictedAt *time.Time `json:"restricted_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName specifies the table name
+func (VisitorReverification) TableName() string {
+	return "visitor_reverifications"
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestNewTicketLinkToken(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		token, err := NewTicketLinkToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil || len(raw) != 16 {
+			t.Fatalf("token %q is not 128 bits of URL-safe base64", token)
+		}
+		if seen[token] {
+			t.Fatalf("token %q repeated", token)
+		}
+		seen[token] = true
+	}
+}
+
+func TestTicketBeforeCreateKeepsLinkToken(t *testing.T) {
+	ticket := Ticket{LinkToken: "existing"}
+	if err := ticket.BeforeCreate(nil); err != nil || ticket.LinkToken != "existing" {
+		t.Errorf("got %q, %v", ticket.LinkToken, err)
+	}
+
+	ticket = Ticket{}
+	if err := ticket.BeforeCreate(nil); err != nil || len(ticket.LinkToken) != 22 {
+		t.Errorf("new ticket got token %q, %v", ticket.LinkToken, err)
+	}
+}
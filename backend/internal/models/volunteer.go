@@ -42,6 +42,15 @@ type VolunteerProfile struct {
 	Mentor      *User                 `json:"mentor" gorm:"foreignKey:MentorID"`
 }
 
+// Volunteer application sources, kept after anonymization for statistics
+const (
+	VolunteerApplicationSourceRegistration = "registration"     // Created when signing up with the volunteer role
+	VolunteerApplicationSourceForm         = "application_form" // Submitted through the standalone application form
+)
+
+// VolunteerApplicationWithdrawn is the status of an application the applicant withdrew
+const VolunteerApplicationWithdrawn = "withdrawn"
+
 // VolunteerApplication represents a visitor's application to become a volunteer
 type VolunteerApplication struct {
 	ID              uint           `gorm:"primaryKey" json:"id"`
@@ -57,8 +66,11 @@ type VolunteerApplication struct {
 	FirstLogin      bool           `json:"first_login" gorm:"default:true"`
 	Status          string         `json:"status" gorm:"default:'pending'"`
 	RejectionReason string         `json:"rejection_reason" gorm:"type:text"`
+	Source          string         `json:"source" gorm:"default:registration;index"`
 	ApprovedAt      *time.Time     `json:"approved_at" gorm:"index"`
 	ApprovedBy      *uint          `json:"approved_by"`
+	ClosedAt        *time.Time     `json:"closed_at" gorm:"index"`     // When the application was rejected or withdrawn
+	AnonymizedAt    *time.Time     `json:"anonymized_at" gorm:"index"` // Personal details removed after the retention period
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
@@ -300,14 +312,28 @@ type UserTraining struct {
 	UpdatedAt        time.Time      `json:"updated_at"`
 }
 
-// Announcement represents system announcements
+// Announcement severities, shown as banner colours
+const (
+	AnnouncementSeverityInfo     = "info"
+	AnnouncementSeverityWarning  = "warning"
+	AnnouncementSeverityCritical = "critical" // e.g. closed due to weather
+)
+
+// AnnouncementAudienceAll targets every signed-in user
+const AnnouncementAudienceAll = "All"
+
+// Announcement represents system announcements. Live announcements are shown as
+// banners to the roles in TargetRole between StartsAt and ExpiresAt.
 type Announcement struct {
 	ID          uint           `gorm:"primarykey" json:"id"`
 	Title       string         `json:"title" binding:"required"`
 	Content     string         `json:"content" binding:"required"`
 	Priority    string         `json:"priority" gorm:"default:'medium'"` // low, medium, high
-	TargetRole  string         `json:"target_role"`                      // All, Admin, Volunteer, Donor, Visitor
+	TargetRole  string         `json:"target_role"`                      // All, or a comma separated list of roles e.g. Visitor,Volunteer
+	Severity    string         `json:"severity" gorm:"default:'info'"`   // info, warning, critical
+	Public      bool           `json:"public" gorm:"default:false"`      // Also shown to signed-out visitors
 	Active      bool           `json:"active" gorm:"default:true"`
+	StartsAt    *time.Time     `json:"starts_at"`
 	ExpiresAt   *time.Time     `json:"expires_at"`
 	CreatedByID uint           `json:"created_by_id"`
 	CreatedBy   User           `json:"created_by" gorm:"foreignKey:CreatedByID"`
@@ -316,6 +342,56 @@ type Announcement struct {
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// Roles returns the roles the announcement targets, lowercased. An empty list means
+// everyone.
+func (a *Announcement) Roles() []string {
+	var roles []string
+	for _, role := range strings.Split(a.TargetRole, ",") {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if role == strings.ToLower(AnnouncementAudienceAll) {
+			return nil
+		}
+		if role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// Targets reports whether users with the role should see the announcement
+func (a *Announcement) Targets(role string) bool {
+	roles := a.Roles()
+	if len(roles) == 0 {
+		return true
+	}
+	role = strings.ToLower(role)
+	for _, target := range roles {
+		if target == role || (target == RoleAdmin && role == RoleSuperAdmin) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnnouncementsForRole is a query scope matching announcements that target a role
+func AnnouncementsForRole(role string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("LOWER(target_role) IN ('', 'all') OR target_role IS NULL OR ',' || LOWER(REPLACE(target_role, ' ', '')) || ',' LIKE ?",
+			"%,"+strings.ToLower(role)+",%")
+	}
+}
+
+// IsLive reports whether the announcement should be shown at a time
+func (a *Announcement) IsLive(now time.Time) bool {
+	if !a.Active {
+		return false
+	}
+	if a.StartsAt != nil && now.Before(*a.StartsAt) {
+		return false
+	}
+	return a.ExpiresAt == nil || now.Before(*a.ExpiresAt)
+}
+
 // AnnouncementRead tracks which users have read which announcements
 type AnnouncementRead struct {
 	ID             uint         `gorm:"primarykey" json:"id"`
@@ -463,18 +539,21 @@ func (vp *VolunteerProfile) GetRolePermissions() map[string]bool {
 	return permissions
 }
 
-// VolunteerTeam represents a team structure for lead volunteers
+// VolunteerTeam groups volunteers under a lead volunteer. The team's permissions let
+// the lead confirm attendance and approve hours for the team's own members.
 type VolunteerTeam struct {
-	ID          uint           `gorm:"primarykey" json:"id"`
-	Name        string         `json:"name" binding:"required"`
-	Description string         `json:"description"`
-	LeadID      uint           `json:"lead_id"`
-	Lead        User           `json:"lead" gorm:"foreignKey:LeadID"`
-	Members     string         `json:"members"` // JSON array of volunteer IDs
-	Active      bool           `json:"active" gorm:"default:true"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                   uint                  `gorm:"primarykey" json:"id"`
+	Name                 string                `json:"name" binding:"required"`
+	Description          string                `json:"description"`
+	LeadID               uint                  `json:"lead_id" gorm:"index"`
+	Lead                 *User                 `json:"lead,omitempty" gorm:"foreignKey:LeadID"`
+	Members              []VolunteerTeamMember `json:"members,omitempty" gorm:"foreignKey:TeamID"`
+	CanConfirmAttendance bool                  `json:"can_confirm_attendance"` // Lead marks members attended or no-show
+	CanApproveHours      bool                  `json:"can_approve_hours"`      // Lead approves members' hours
+	Active               bool                  `json:"active" gorm:"default:true"`
+	CreatedAt            time.Time             `json:"created_at"`
+	UpdatedAt            time.Time             `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt        `gorm:"index" json:"-"`
 }
 
 // VolunteerTask represents tasks assigned to volunteers
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// VolunteerAwayPeriod is a stretch of days a volunteer has marked themselves away.
+// While away they get no emergency call-outs, shift suggestions or reminders.
+type VolunteerAwayPeriod struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	StartsOn  time.Time `json:"starts_on" gorm:"type:date;not null;index"`
+	EndsOn    time.Time `json:"ends_on" gorm:"type:date;not null;index"` // Inclusive
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (VolunteerAwayPeriod) TableName() string {
+	return "volunteer_away_periods"
+}
+
+// Covers reports whether the period includes the day of t
+func (p *VolunteerAwayPeriod) Covers(t time.Time) bool {
+	day := t.Format("2006-01-02")
+	return day >= p.StartsOn.Format("2006-01-02") && day <= p.EndsOn.Format("2006-01-02")
+}
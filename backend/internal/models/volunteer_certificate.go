@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Volunteer certificate statuses
+const (
+	VolunteerCertificatePending  = "pending"  // Waiting for a coordinator to approve
+	VolunteerCertificateApproved = "approved" // Signed and downloadable
+	VolunteerCertificateRejected = "rejected"
+	VolunteerCertificateRevoked  = "revoked" // Was approved, no longer vouched for
+)
+
+// VolunteerCertificate is a letter confirming a volunteer's hours and roles, for
+// example for a job application. A coordinator approves it before it is signed, and
+// employers can confirm it is genuine using its verification code.
+type VolunteerCertificate struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	UserID           uint       `json:"user_id" gorm:"index;not null"`
+	VolunteerName    string     `json:"volunteer_name"`
+	AddressedTo      string     `json:"addressed_to,omitempty"` // e.g. the employer the letter is for
+	Purpose          string     `json:"purpose,omitempty" gorm:"type:text"`
+	Status           string     `json:"status" gorm:"default:pending;index"`
+	TotalHours       float64    `json:"total_hours"`
+	ShiftsCompleted  int64      `json:"shifts_completed"`
+	Roles            string     `json:"roles" gorm:"type:text"` // JSON list of roles with hours
+	FirstShiftOn     *time.Time `json:"first_shift_on,omitempty"`
+	LastShiftOn      *time.Time `json:"last_shift_on,omitempty"`
+	ReviewedBy       *uint      `json:"reviewed_by,omitempty"`
+	ReviewedAt       *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNotes      string     `json:"review_notes,omitempty" gorm:"type:text"`
+	CoordinatorName  string     `json:"coordinator_name,omitempty"`
+	VerificationCode *string    `json:"verification_code,omitempty" gorm:"uniqueIndex"` // Set when approved
+	Signature        string     `json:"-"`                                              // HMAC over the certificate contents
+	IssuedAt         *time.Time `json:"issued_at,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (VolunteerCertificate) TableName() string {
+	return "volunteer_certificates"
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// VolunteerStatement is a volunteer's monthly statement of the hours, shifts and
+// achievements recorded for them, generated at the start of the following month
+type VolunteerStatement struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_volunteer_statement_month"`
+	Month          string     `json:"month" gorm:"not null;uniqueIndex:idx_volunteer_statement_month;index"` // YYYY-MM
+	Hours          float64    `json:"hours"`
+	Shifts         int64      `json:"shifts"`
+	LifetimeHours  float64    `json:"lifetime_hours"`       // Completed hours up to the end of the month
+	HoursCorrected float64    `json:"hours_corrected"`      // Change made to the profile's total hours when it had drifted
+	Content        string     `json:"-" gorm:"type:text"`   // The statement as JSON
+	EmailedAt      *time.Time `json:"emailed_at,omitempty"` // Not set when the volunteer has no email or no shifts that month
+	GeneratedAt    time.Time  `json:"generated_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (VolunteerStatement) TableName() string {
+	return "volunteer_statements"
+}
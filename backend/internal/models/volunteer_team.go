@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ShiftAssignmentPendingApproval is the status of an assignment whose attendance has
+// been confirmed and whose hours are waiting to be approved
+const ShiftAssignmentPendingApproval = "pending_approval"
+
+// VolunteerTeamMember places a volunteer in a team. A volunteer is in at most one team.
+type VolunteerTeamMember struct {
+	ID       uint      `gorm:"primaryKey" json:"id"`
+	TeamID   uint      `json:"team_id" gorm:"index;not null"`
+	UserID   uint      `json:"user_id" gorm:"uniqueIndex;not null"`
+	JoinedAt time.Time `json:"joined_at"`
+	AddedBy  uint      `json:"added_by"`
+
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName specifies the table name
+func (VolunteerTeamMember) TableName() string {
+	return "volunteer_team_members"
+}
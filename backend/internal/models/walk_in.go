@@ -0,0 +1,128 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Walk-in registration outcomes
+const (
+	WalkInOutcomeTicketIssued = "ticket_issued"
+	WalkInOutcomeStandby      = "standby"
+	WalkInOutcomeIneligible   = "ineligible"
+)
+
+// Standby entry status values
+const (
+	StandbyStatusWaiting   = "waiting"
+	StandbyStatusOffered   = "offered"
+	StandbyStatusClaimed   = "claimed"
+	StandbyStatusExpired   = "expired"
+	StandbyStatusCancelled = "cancelled"
+)
+
+// Standby entry sources
+const (
+	StandbySourceWalkIn   = "walk_in"
+	StandbySourceWaitlist = "waitlist"
+)
+
+// Standby priorities. Walk-ins already at the centre are offered released capacity first.
+const (
+	StandbyPriorityWalkIn = 100
+	StandbyPriorityUrgent = 30
+	StandbyPriorityHigh   = 20
+	StandbyPriorityNormal = 10
+)
+
+// WalkInRegistration records a visitor registered at the front desk without a booking
+type WalkInRegistration struct {
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	VisitorID        uint           `json:"visitor_id" gorm:"not null;index"`
+	Category         string         `json:"category"`
+	VisitDay         string         `json:"visit_day" gorm:"type:varchar(20);index"`
+	Outcome          string         `json:"outcome" gorm:"index"`
+	EligibilityNotes string         `json:"eligibility_notes" gorm:"type:text"`
+	HelpRequestID    *uint          `json:"help_request_id"`
+	TicketID         *uint          `json:"ticket_id"`
+	StandbyEntryID   *uint          `json:"standby_entry_id"`
+	NewVisitor       bool           `json:"new_visitor"` // False when an existing account was matched by phone
+	RegisteredBy     uint           `json:"registered_by"`
+	MergedFromUserID *uint          `json:"merged_from_user_id"` // The walk-in account merged into VisitorID
+	MergedAt         *time.Time     `json:"merged_at"`
+	MergedBy         *uint          `json:"merged_by"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Visitor          User          `json:"visitor,omitempty" gorm:"foreignKey:VisitorID"`
+	Ticket           *Ticket       `json:"ticket,omitempty" gorm:"foreignKey:TicketID"`
+	StandbyEntry     *StandbyEntry `json:"standby_entry,omitempty" gorm:"foreignKey:StandbyEntryID"`
+	RegisteredByUser *User         `json:"registered_by_user,omitempty" gorm:"foreignKey:RegisteredBy"`
+}
+
+// TableName specifies the table name
+func (WalkInRegistration) TableName() string {
+	return "walk_in_registrations"
+}
+
+// StandbyEntry is a visitor waiting for same-day capacity to become available
+type StandbyEntry struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	VisitorID      uint           `json:"visitor_id" gorm:"not null;index"`
+	HelpRequestID  *uint          `json:"help_request_id"`
+	Category       string         `json:"category" gorm:"index"`
+	VisitDay       string         `json:"visit_day" gorm:"type:varchar(20);index"`
+	Priority       int            `json:"priority" gorm:"default:0"` // Higher is offered first
+	Source         string         `json:"source" gorm:"default:'walk_in'"`
+	Status         string         `json:"status" gorm:"default:waiting;index"`
+	Notes          string         `json:"notes" gorm:"type:text"`
+	AddedBy        uint           `json:"added_by"`
+	OfferedAt      *time.Time     `json:"offered_at"`
+	OfferExpiresAt *time.Time     `json:"offer_expires_at"`
+	ClaimedAt      *time.Time     `json:"claimed_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Visitor User `json:"visitor,omitempty" gorm:"foreignKey:VisitorID"`
+}
+
+// TableName specifies the table name
+func (StandbyEntry) TableName() string {
+	return "standby_entries"
+}
+
+// CapacityRelease records one run of the same-day capacity release for a service,
+// so conversion from offers to claimed tickets can be tracked over time
+type CapacityRelease struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	VisitDay       string    `json:"visit_day" gorm:"type:varchar(20);index"`
+	Category       string    `json:"category" gorm:"index"`
+	ReleasedSlots  int       `json:"released_slots"` // Unused bookings cancelled at the cutoff
+	WaitlistAdded  int       `json:"waitlist_added"` // Pending requests moved onto the standby list
+	OffersSent     int       `json:"offers_sent"`
+	OffersExpired  int       `json:"offers_expired"`
+	AvailableSlots int       `json:"available_slots"` // Capacity free after the release
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (CapacityRelease) TableName() string {
+	return "capacity_releases"
+}
+
+// StandbyPriorityFor maps a help request priority to a standby priority
+func StandbyPriorityFor(priority string) int {
+	switch priority {
+	case PriorityUrgent, PriorityCritical:
+		return StandbyPriorityUrgent
+	case PriorityHigh:
+		return StandbyPriorityHigh
+	default:
+		return StandbyPriorityNormal
+	}
+}
@@ -0,0 +1,172 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+// brandingCacheTTL is how long a resolved branding profile is reused before it is
+// read again. Saving a profile clears the cache straight away.
+const brandingCacheTTL = time.Minute
+
+// htmlTagPattern spots bodies that are already HTML by the tags emails use, so plain
+// text that happens to contain angle brackets is still escaped
+var htmlTagPattern = regexp.MustCompile(`(?i)</?(p|br|div|span|a|b|strong|i|em|u|h[1-6]|ul|ol|li|table|thead|tbody|tr|td|th|img|hr|html|body|blockquote|pre|code)(\s[^>]*)?/?>`)
+
+type cachedBranding struct {
+	profile models.BrandingProfile
+	expires time.Time
+}
+
+var (
+	brandingCache   = map[string]cachedBranding{}
+	brandingCacheMu sync.Mutex
+)
+
+// LoadBranding returns the branding for a location: the location's own profile,
+// then the organisation default, then the built-in branding
+func LoadBranding(location string) models.BrandingProfile {
+	location = strings.TrimSpace(location)
+
+	brandingCacheMu.Lock()
+	cached, ok := brandingCache[location]
+	brandingCacheMu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.profile
+	}
+
+	profile := models.BuiltInBranding()
+	if db.DB != nil {
+		var profiles []models.BrandingProfile
+		if err := db.DB.Where("location IN ?", []string{location, ""}).Find(&profiles).Error; err != nil {
+			log.Printf("Failed to load branding for %q: %v", location, err)
+		} else {
+			var own, fallback *models.BrandingProfile
+			for i := range profiles {
+				if profiles[i].Location == location && location != "" {
+					own = &profiles[i]
+				} else if profiles[i].Location == "" {
+					fallback = &profiles[i]
+				}
+			}
+			if fallback != nil {
+				profile = fallback.Merge(profile)
+			}
+			if own != nil {
+				profile = own.Merge(profile)
+			}
+		}
+	}
+
+	brandingCacheMu.Lock()
+	brandingCache[location] = cachedBranding{profile: profile, expires: time.Now().Add(brandingCacheTTL)}
+	brandingCacheMu.Unlock()
+	return profile
+}
+
+// InvalidateBrandingCache drops resolved branding so the next email picks up changes
+func InvalidateBrandingCache() {
+	brandingCacheMu.Lock()
+	brandingCache = map[string]cachedBranding{}
+	brandingCacheMu.Unlock()
+}
+
+// BrandingLogoURL returns the address emails load a profile's logo from. Uploaded
+// logos are served by the API; otherwise the profile's external logo URL is used.
+func BrandingLogoURL(profile models.BrandingProfile) string {
+	if !profile.HasLogo() {
+		return profile.LogoURL
+	}
+	apiURL := strings.TrimRight(os.Getenv("API_URL"), "/")
+	if apiURL == "" {
+		apiURL = "http://localhost:8080"
+	}
+	return fmt.Sprintf("%s/api/v1/branding/logo?location=%s&v=%d",
+		apiURL, url.QueryEscape(profile.Location), profile.UpdatedAt.Unix())
+}
+
+// brandTemplateData adds the organisation's details to template data so templates
+// show the configured name rather than a hard-coded one
+func brandTemplateData(data map[string]interface{}, profile models.BrandingProfile) map[string]interface{} {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	data["OrganizationName"] = profile.DisplayName
+	data["CharityNumber"] = profile.CharityNumber
+	data["BrandPrimaryColor"] = profile.PrimaryColor
+	data["BrandAccentColor"] = profile.AccentColor
+	data["BrandLogoURL"] = BrandingLogoURL(profile)
+	return data
+}
+
+// brandedEmailLayout wraps an email body in the organisation's letterhead
+var brandedEmailLayout = htmltemplate.Must(htmltemplate.New("branded_email").Parse(`<div style="background-color: #f3f4f6; padding: 24px 0;">
+  <div style="font-family: sans-serif; max-width: 600px; margin: 0 auto; background-color: #ffffff; border-top: 4px solid {{.AccentColor}};">
+    <div style="padding: 16px 24px;">
+      {{if .LogoURL}}<img src="{{.LogoURL}}" alt="{{.Name}}" style="max-height: 48px;">{{else}}<span style="font-size: 20px; font-weight: bold; color: {{.PrimaryColor}};">{{.Name}}</span>{{end}}
+    </div>
+    <div style="padding: 0 24px 16px;">{{.Body}}</div>
+    <div style="padding: 16px 24px; border-top: 1px solid #e5e7eb; font-size: 12px; color: #6b7280;">
+      {{if .FooterText}}<p>{{.FooterText}}</p>{{end}}
+      <p>{{.Name}}{{if .Address}}, {{.Address}}{{end}}</p>
+      {{if .CharityNumber}}<p>Registered charity number {{.CharityNumber}}</p>{{end}}
+      {{if .Contact}}<p>{{.Contact}}</p>{{end}}
+    </div>
+  </div>
+</div>`))
+
+// BrandEmail wraps an email body in the letterhead for a location. Plain text
+// bodies are escaped and their line breaks kept.
+func BrandEmail(body string, profile models.BrandingProfile) string {
+	if !htmlTagPattern.MatchString(body) {
+		body = strings.ReplaceAll(html.EscapeString(body), "\n", "<br>\n")
+	}
+
+	var contact []string
+	for _, value := range []string{profile.Website, profile.ContactEmail, profile.ContactPhone} {
+		if value != "" {
+			contact = append(contact, value)
+		}
+	}
+
+	var rendered bytes.Buffer
+	err := brandedEmailLayout.Execute(&rendered, map[string]interface{}{
+		"Name":          profile.DisplayName,
+		"PrimaryColor":  htmltemplate.CSS(safeColor(profile.PrimaryColor, "#1F2937")),
+		"AccentColor":   htmltemplate.CSS(safeColor(profile.AccentColor, "#2563EB")),
+		"LogoURL":       BrandingLogoURL(profile),
+		"FooterText":    profile.FooterText,
+		"Address":       strings.ReplaceAll(profile.Address, "\n", ", "),
+		"CharityNumber": profile.CharityNumber,
+		"Contact":       strings.Join(contact, " | "),
+		"Body":          htmltemplate.HTML(body),
+	})
+	if err != nil {
+		log.Printf("Failed to apply email branding: %v", err)
+		return body
+	}
+	return rendered.String()
+}
+
+// colorPattern matches the hex colours branding accepts
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// safeColor returns a hex colour, or the fallback when it is not one
+func safeColor(color, fallback string) string {
+	if colorPattern.MatchString(color) {
+		return color
+	}
+	return fallback
+}
@@ -0,0 +1,64 @@
+package notifications
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestBrandEmail(t *testing.T) {
+	profile := models.BrandingProfile{
+		DisplayName:   "Lewisham Charity",
+		PrimaryColor:  "red;background:url(x)",
+		AccentColor:   "#2563EB",
+		CharityNumber: "1234567",
+		Address:       "1 High Street\nLondon",
+		Website:       "https://example.org",
+	}
+
+	email := BrandEmail("Hello <Sam>,\nSee you soon", profile)
+	for _, want := range []string{
+		"Hello &lt;Sam&gt;,<br>\nSee you soon", // Plain text is escaped with its line breaks kept
+		"border-top: 4px solid #2563EB",
+		"color: #1F2937", // An unsafe colour falls back to the default
+		"Lewisham Charity, 1 High Street, London",
+		"Registered charity number 1234567",
+		"https://example.org",
+	} {
+		if !strings.Contains(email, want) {
+			t.Errorf("missing %q in:\n%s", want, email)
+		}
+	}
+	if strings.Contains(email, "url(x)") {
+		t.Error("unsafe colour was used")
+	}
+
+	// HTML bodies are used as they are
+	if email := BrandEmail("<p>Thanks <b>Sam</b></p>", profile); !strings.Contains(email, "<p>Thanks <b>Sam</b></p>") {
+		t.Errorf("HTML body changed:\n%s", email)
+	}
+}
+
+func TestBrandingLogoURL(t *testing.T) {
+	t.Setenv("API_URL", "https://api.example.org/")
+	updated := time.Unix(1767225600, 0)
+
+	uploaded := models.BrandingProfile{Location: "Catford & Lee", LogoData: []byte("png"), UpdatedAt: updated}
+	if got := BrandingLogoURL(uploaded); got != "https://api.example.org/api/v1/branding/logo?location=Catford+%26+Lee&v=1767225600" {
+		t.Errorf("uploaded logo: got %q", got)
+	}
+
+	external := models.BrandingProfile{LogoURL: "https://cdn.example.org/logo.png"}
+	if got := BrandingLogoURL(external); got != "https://cdn.example.org/logo.png" {
+		t.Errorf("external logo: got %q", got)
+	}
+}
+
+func TestBrandTemplateData(t *testing.T) {
+	data := brandTemplateData(nil, models.BrandingProfile{DisplayName: "Food Hub", CharityNumber: "42"})
+	if data["OrganizationName"] != "Food Hub" || data["CharityNumber"] != "42" || data["BrandLogoURL"] != "" {
+		t.Errorf("got %v", data)
+	}
+}
@@ -0,0 +1,84 @@
+package notifications
+
+import (
+	"errors"
+	"log"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+// ErrContactSuppressed is returned when a message is not sent because the recipient
+// has asked not to be contacted on that channel and has no other way to reach them
+var ErrContactSuppressed = errors.New("recipient has asked not to be contacted on this channel")
+
+// smsRestriction returns the do-not-SMS restriction covering a number, checking the
+// user the message is for and anyone else registered with that number
+func smsRestriction(to string, user *models.User) *models.ContactRestriction {
+	if db.DB == nil || to == "" {
+		return nil
+	}
+
+	query := db.DB.Model(&models.ContactRestriction{}).
+		Joins("JOIN users ON users.id = contact_restrictions.user_id").
+		Where("contact_restrictions.do_not_sms = ?", true)
+	if user != nil && user.ID != 0 {
+		query = query.Where("(contact_restrictions.user_id = ? OR users.phone = ?)", user.ID, to)
+	} else {
+		query = query.Where("users.phone = ?", to)
+	}
+
+	var restrictions []models.ContactRestriction
+	if err := query.Limit(1).Find(&restrictions).Error; err != nil {
+		log.Printf("Failed to check contact restrictions for %s: %v", models.MaskPhone(to), err)
+		return nil
+	}
+	if len(restrictions) == 0 {
+		return nil
+	}
+	return &restrictions[0]
+}
+
+// suppressSMS records an SMS stopped by a do-not-SMS flag and emails the message
+// instead where the person has an email address
+func (ns *NotificationService) suppressSMS(to, message, subject string, templateType TemplateType, user *models.User, restriction *models.ContactRestriction) error {
+	if user == nil || user.ID != restriction.UserID {
+		var found models.User
+		if err := db.DB.First(&found, restriction.UserID).Error; err == nil {
+			user = &found
+		}
+	}
+
+	entry := models.ContactSuppressionLog{
+		UserID:       restriction.UserID,
+		Channel:      models.NotificationTypeSMS,
+		Source:       models.SuppressionSourceNotification,
+		Reason:       models.SuppressedDoNotSMS,
+		Recipient:    models.MaskPhone(to),
+		TemplateType: templateType.String(),
+	}
+	if user == nil || user.Email == "" {
+		RecordSuppression(&entry)
+		log.Printf("SMS to %s suppressed: recipient is flagged do-not-SMS", models.MaskPhone(to))
+		return ErrContactSuppressed
+	}
+
+	entry.FallbackChannel = EmailNotification.String()
+	RecordSuppression(&entry)
+
+	if subject == "" {
+		subject = smsFallbackSubject
+	}
+	return ns.sendTrackedEmail(user.Email, subject, BrandEmail(message, LoadBranding("")), templateType, user)
+}
+
+// RecordSuppression stores a suppressed contact attempt, logging rather than failing
+// when it cannot be saved
+func RecordSuppression(entry *models.ContactSuppressionLog) {
+	if db.DB == nil {
+		return
+	}
+	if err := db.DB.Create(entry).Error; err != nil {
+		log.Printf("Failed to record suppressed %s to user %d: %v", entry.Channel, entry.UserID, err)
+	}
+}
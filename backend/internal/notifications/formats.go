@@ -0,0 +1,147 @@
+package notifications
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"text/template"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+// CommsFormat is how a visitor asked to receive tickets, confirmations and reminders
+type CommsFormat struct {
+	PlainLanguage bool `json:"plain_language"` // Shorter sentences and everyday words
+	LargePrint    bool `json:"large_print"`    // Larger email text and large-print PDFs
+}
+
+// EmailAttachment is a file sent with an email, such as a large-print ticket
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// attachmentClient is an email client that can send attachments
+type attachmentClient interface {
+	SendEmailWithAttachments(to, subject, body string, attachments []EmailAttachment) error
+}
+
+// VisitorCommsFormat returns the formats a visitor chose on their profile. Other
+// users, and visitors without a profile, get the standard format.
+func VisitorCommsFormat(userID uint) CommsFormat {
+	var format CommsFormat
+	if db.DB == nil || userID == 0 {
+		return format
+	}
+	var profile models.VisitorProfile
+	if err := db.DB.Select("plain_language", "large_print").Where("user_id = ?", userID).
+		Limit(1).Find(&profile).Error; err != nil {
+		log.Printf("Failed to load communication format for user %d: %v", userID, err)
+		return format
+	}
+	format.PlainLanguage = profile.PlainLanguage
+	format.LargePrint = profile.LargePrint
+	return format
+}
+
+// userCommsFormat returns the format for a notification's recipient, which only
+// visitors can choose
+func userCommsFormat(user models.User) CommsFormat {
+	if user.Role != models.RoleVisitor && user.Role != models.RoleVisitorLegacy {
+		return CommsFormat{}
+	}
+	return VisitorCommsFormat(user.ID)
+}
+
+// LargePrintEmail sets an email body in large type
+func LargePrintEmail(body string) string {
+	return `<div style="font-size: 20px; line-height: 1.6;">` + body + `</div>`
+}
+
+// mockNotificationClient logs attachments rather than sending them
+func (c *mockNotificationClient) SendEmailWithAttachments(to, subject, body string, attachments []EmailAttachment) error {
+	for _, attachment := range attachments {
+		log.Printf("Mock Email Attachment for %s: %s (%d bytes)\n", to, attachment.Filename, len(attachment.Content))
+	}
+	return c.SendEmail(to, subject, body)
+}
+
+// SendEmailWithAttachments sends an email with files attached through SendGrid
+func (c *sendGridClient) SendEmailWithAttachments(to, subject, body string, attachments []EmailAttachment) error {
+	encoded := make([]map[string]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		encoded = append(encoded, map[string]string{
+			"content":     base64.StdEncoding.EncodeToString(attachment.Content),
+			"filename":    attachment.Filename,
+			"type":        attachment.ContentType,
+			"disposition": "attachment",
+		})
+	}
+	return c.send(to, subject, body, encoded)
+}
+
+// sendEmail sends an email through the client, with attachments where the client
+// supports them
+func sendEmail(client NotificationClient, to, subject, body string, attachments []EmailAttachment) error {
+	if len(attachments) == 0 {
+		return client.SendEmail(to, subject, body)
+	}
+	sender, ok := client.(attachmentClient)
+	if !ok {
+		return fmt.Errorf("email client cannot send attachments")
+	}
+	return sender.SendEmailWithAttachments(to, subject, body, attachments)
+}
+
+// plainLanguageTemplates are the plain-language variants of visitor templates
+var plainLanguageTemplates = parsePlainLanguageTemplates()
+
+// parsePlainLanguageTemplates parses the plain-language variants
+func parsePlainLanguageTemplates() map[TemplateType]*template.Template {
+	sources := map[TemplateType]string{
+		TicketIssued: `
+		<div style="font-family: sans-serif; max-width: 600px; margin: 0 auto;">
+			<h2>You have a ticket</h2>
+			<p>Hello {{.Name}},</p>
+			<p>You can come and get help from us.</p>
+			<p><strong>Day:</strong> {{.VisitDay}}</p>
+			{{if .TimeSlot}}<p><strong>Time:</strong> {{.TimeSlot}}</p>{{end}}
+			<p><strong>Your ticket number:</strong> {{.TicketNumber}}</p>
+			<p>What to do:</p>
+			<ol>
+				<li>Come 15 minutes early.</li>
+				<li>Show this email or your ticket at the desk.</li>
+				<li>Bring your ID.</li>
+			</ol>
+			<p>If you cannot come, please tell us so someone else can have your place.</p>
+			<p>{{.OrganizationName}}</p>
+		</div>
+	`,
+		AppointmentReminder: `
+		<div style="font-family: sans-serif; max-width: 600px; margin: 0 auto;">
+			<h2>Your appointment is soon</h2>
+			<p>Hello {{.Name}},</p>
+			{{if .Service}}<p>You have a {{.Service}} appointment.</p>
+			<p><strong>Day:</strong> {{.Day}}</p>
+			<p><strong>Time:</strong> {{.Time}}</p>
+			{{if .Place}}<p><strong>Where:</strong> {{.Place}}</p>{{end}}
+			{{else}}<p>{{.Message}}</p>{{end}}
+			<p>If you cannot come, please tell us.</p>
+			<p>{{.OrganizationName}}</p>
+		</div>
+	`,
+	}
+
+	templates := make(map[TemplateType]*template.Template, len(sources))
+	for templateType, source := range sources {
+		t, err := template.New(string(templateType) + "_plain").Parse(source)
+		if err != nil {
+			log.Printf("Error parsing plain-language template for %s: %v", templateType, err)
+			continue
+		}
+		templates[templateType] = t
+	}
+	return templates
+}
@@ -0,0 +1,139 @@
+package notifications
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+// languageTagPattern matches a normalised language tag such as pl or pt-br
+var languageTagPattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
+
+// NormalizeLanguage lower-cases a language tag, so "pt_BR" becomes "pt-br", and
+// reports whether it is well formed. A blank tag is valid and means no preference.
+func NormalizeLanguage(tag string) (string, bool) {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if tag == "" {
+		return "", true
+	}
+	return tag, languageTagPattern.MatchString(tag)
+}
+
+// LanguageChain returns the languages to try for a recipient, most preferred first:
+// their own language, their location's default, then English
+func LanguageChain(userLanguage, location string) []string {
+	return languageChain(userLanguage, LoadBranding(location).Language)
+}
+
+// languageChain builds a fallback chain. A regional tag tries its base language
+// before the next step, so pt-br falls back to pt.
+func languageChain(languages ...string) []string {
+	chain := make([]string, 0, 2*len(languages)+1)
+	seen := map[string]bool{}
+	add := func(language string) {
+		if language != "" && !seen[language] {
+			seen[language] = true
+			chain = append(chain, language)
+		}
+	}
+	for _, tag := range languages {
+		language, ok := NormalizeLanguage(tag)
+		if !ok {
+			continue
+		}
+		add(language)
+		if base, _, regional := strings.Cut(language, "-"); regional {
+			add(base)
+		}
+	}
+	add(models.DefaultLanguage)
+	return chain
+}
+
+// ResolveLanguage returns the first language in the chain that has a translation.
+// English always resolves because the built-in templates are English.
+func ResolveLanguage(chain []string, translated map[string]bool) string {
+	for _, language := range chain {
+		if language == models.DefaultLanguage || translated[language] {
+			return language
+		}
+	}
+	return models.DefaultLanguage
+}
+
+// TranslatableTemplates returns the template types that can be translated, sorted
+func TranslatableTemplates() []TemplateType {
+	types := make([]TemplateType, 0, len(templateFiles))
+	for templateType := range templateFiles {
+		types = append(types, templateType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// templateTranslation returns the translation of a template in the first language
+// of the chain that has one, or nil when English should be used
+func templateTranslation(templateType TemplateType, chain []string) *models.NotificationTemplateTranslation {
+	if db.DB == nil || len(chain) == 0 || chain[0] == models.DefaultLanguage {
+		return nil
+	}
+	var translations []models.NotificationTemplateTranslation
+	if err := db.DB.Where("template_type = ? AND language IN ?", string(templateType), chain).
+		Find(&translations).Error; err != nil {
+		log.Printf("Failed to load %s translations: %v", templateType, err)
+		return nil
+	}
+	byLanguage := make(map[string]*models.NotificationTemplateTranslation, len(translations))
+	translated := make(map[string]bool, len(translations))
+	for i := range translations {
+		byLanguage[translations[i].Language] = &translations[i]
+		translated[translations[i].Language] = true
+	}
+	return byLanguage[ResolveLanguage(chain, translated)]
+}
+
+// LanguageLogEntry builds the log entry for a message sent in language to a
+// recipient whose fallback chain is chain
+func LanguageLogEntry(userID uint, channel string, chain []string, language string) models.NotificationLanguageLog {
+	requested := models.DefaultLanguage
+	if len(chain) > 0 {
+		requested = chain[0]
+	}
+	return models.NotificationLanguageLog{
+		UserID:    userID,
+		Channel:   channel,
+		Requested: requested,
+		Language:  language,
+		FellBack:  language != requested,
+	}
+}
+
+// logLanguage records the language a transactional message was sent in
+func logLanguage(entry models.NotificationLanguageLog) {
+	if db.DB == nil {
+		return
+	}
+	if err := db.DB.Create(&entry).Error; err != nil {
+		log.Printf("Failed to log message language for user %d: %v", entry.UserID, err)
+	}
+}
+
+// renderSubject fills in a translated subject, keeping the fallback if it does not
+// render
+func renderSubject(subject string, data map[string]interface{}, fallback string) string {
+	tmpl, err := template.New("subject").Option("missingkey=zero").Parse(subject)
+	if err != nil {
+		return fallback
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return fallback
+	}
+	return rendered.String()
+}
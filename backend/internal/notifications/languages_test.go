@@ -0,0 +1,44 @@
+package notifications
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLanguageChain(t *testing.T) {
+	cases := []struct {
+		user, location string
+		want           []string
+	}{
+		{"", "", []string{"en"}},
+		{"pl", "", []string{"pl", "en"}},
+		{"", "so", []string{"so", "en"}},
+		{"pt_BR", "pl", []string{"pt-br", "pt", "pl", "en"}}, // Regional tags try their base language
+		{"en", "pl", []string{"en", "pl"}},                   // Choosing English overrides the location default
+		{"not a language", "ro", []string{"ro", "en"}},
+	}
+	for _, tc := range cases {
+		if got := languageChain(tc.user, tc.location); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("languageChain(%q, %q) = %v, want %v", tc.user, tc.location, got, tc.want)
+		}
+	}
+}
+
+func TestResolveLanguage(t *testing.T) {
+	translated := map[string]bool{"pt": true, "pl": true}
+	cases := []struct {
+		chain []string
+		want  string
+	}{
+		{[]string{"pt-br", "pt", "en"}, "pt"},
+		{[]string{"so", "pl", "en"}, "pl"},
+		{[]string{"so", "en"}, "en"},
+		{[]string{"en", "pl"}, "en"},
+		{nil, "en"},
+	}
+	for _, tc := range cases {
+		if got := ResolveLanguage(tc.chain, translated); got != tc.want {
+			t.Errorf("ResolveLanguage(%v) = %q, want %q", tc.chain, got, tc.want)
+		}
+	}
+}
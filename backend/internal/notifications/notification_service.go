@@ -8,13 +8,13 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
 	"text/template"
 	"time"
 
 	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/httpclient"
 	"github.com/geoo115/charity-management-system/internal/models"
 )
 
@@ -55,6 +55,8 @@ const (
 	SystemMaintenance     TemplateType = "system_maintenance"
 	EmergencyAlert        TemplateType = "emergency_alert"
 	ScheduleChange        TemplateType = "schedule_change"
+	TicketIssued          TemplateType = "ticket_issued"
+	AppointmentReminder   TemplateType = "appointment_reminder"
 )
 
 // String returns the string representation of TemplateType
@@ -70,6 +72,7 @@ type NotificationData struct {
 	TemplateData     map[string]interface{} `json:"templateData"`
 	NotificationType NotificationType       `json:"notificationType"`
 	ScheduledFor     *time.Time             `json:"scheduledFor,omitempty"`
+	Attachments      []EmailAttachment      `json:"-"`
 }
 
 // NotificationClient is the interface for sending notifications
@@ -91,6 +94,12 @@ func (c *mockNotificationClient) SendSMS(to, message string) error {
 	return nil
 }
 
+// HTTP clients for the email and SMS providers
+var (
+	sendGridHTTPClient = httpclient.New("sendgrid", httpclient.Options{Timeout: 30 * time.Second})
+	twilioHTTPClient   = httpclient.New("twilio", httpclient.Options{Timeout: 30 * time.Second})
+)
+
 // sendGridClient is an implementation for SendGrid
 type sendGridClient struct {
 	apiKey    string
@@ -99,6 +108,11 @@ type sendGridClient struct {
 }
 
 func (c *sendGridClient) SendEmail(to, subject, body string) error {
+	return c.send(to, subject, body, nil)
+}
+
+// send posts an email to SendGrid, with any attachments already encoded
+func (c *sendGridClient) send(to, subject, body string, attachments []map[string]string) error {
 	if c.apiKey == "" {
 		return fmt.Errorf("sendgrid api key not configured")
 	}
@@ -136,6 +150,9 @@ func (c *sendGridClient) SendEmail(to, subject, body string) error {
 			},
 		},
 	}
+	if len(attachments) > 0 {
+		payload["attachments"] = attachments
+	}
 
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
@@ -153,10 +170,7 @@ func (c *sendGridClient) SendEmail(to, subject, body string) error {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Send the request
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-	resp, err := client.Do(req)
+	resp, err := sendGridHTTPClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
@@ -210,8 +224,7 @@ func (c *twilioClient) SendSMS(to, message string) error {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	// Send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := twilioHTTPClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -283,7 +296,7 @@ func NewNotificationService(config NotificationConfig) (*NotificationService, er
 		}
 	}
 
-	// Load templates from files
+	// Load the built-in templates
 	templates := loadTemplates()
 
 	return &NotificationService{
@@ -303,103 +316,52 @@ func NewNotificationServiceSimple() *NotificationService {
 	return service
 }
 
-// loadTemplates loads all notification templates from files
+// templateFiles maps each template type to its file in the templates directory
+var templateFiles = map[TemplateType]string{
+	ShiftReminder:         "shift_reminder.html",
+	ShiftCancellation:     "shift_cancellation.html",
+	ShiftSignup:           "shift_signup.html",
+	UrgentCallout:         "urgent_callout.html",
+	HelpRequestSubmitted:  "help_request_submitted.html",
+	HelpRequestInProgress: "help_request_in_progress.html",
+	VolunteerApplication:  "volunteer_application.html",
+	VolunteerApproval:     "volunteer_approval.html",
+	VolunteerRejection:    "volunteer_rejection.html",
+	DonationReceived:      "donation_received.html",
+	DropoffScheduled:      "dropoff_scheduled.html",
+	PasswordReset:         "password_reset.html",
+	AccountCreated:        "account_created.html",
+	EmailVerification:     "email_verification.html",
+	ApplicationSubmitted:  "application_submitted.html",
+	ApplicationUpdate:     "application_update.html",
+	SystemMaintenance:     "system_maintenance.html",
+	EmergencyAlert:        "emergency_alert.html",
+	ScheduleChange:        "schedule_change.html",
+	TicketIssued:          "ticket_issued.html",
+	AppointmentReminder:   "appointment_reminder.html",
+}
+
+// loadTemplates parses the built-in template for every template type. Admins can
+// replace any of them from the database; see templateOverride.
 func loadTemplates() map[TemplateType]*template.Template {
 	templates := make(map[TemplateType]*template.Template)
-
-	// First try to find the templates relative to the current working directory
-	templatePaths := []string{
-		"internal/notifications/templates",
-		"backend/internal/notifications/templates",
-		"@/internal/notifications/templates",
-	}
-
-	var templatePath string
-	for _, path := range templatePaths {
-		if _, err := os.Stat(path); err == nil {
-			templatePath = path
-			break
-		}
-	}
-
-	if templatePath == "" {
-		log.Println("Warning: Could not find notification templates directory, using fallback templates")
-		return loadFallbackTemplates()
-	}
-
-	log.Printf("Loading notification templates from: %s", templatePath)
-
-	// Map of template types to file names
-	templateFiles := map[TemplateType]string{
-		ShiftReminder:         "shift_reminder.html",
-		ShiftCancellation:     "shift_cancellation.html",
-		ShiftSignup:           "shift_signup.html",
-		UrgentCallout:         "urgent_callout.html",
-		HelpRequestSubmitted:  "help_request_submitted.html",
-		HelpRequestInProgress: "help_request_in_progress.html",
-		VolunteerApplication:  "volunteer_application.html",
-		VolunteerApproval:     "volunteer_approval.html",
-		VolunteerRejection:    "volunteer_rejection.html",
-		DonationReceived:      "donation_received.html",
-		DropoffScheduled:      "dropoff_scheduled.html",
-		PasswordReset:         "password_reset.html",
-		AccountCreated:        "account_created.html",
-		EmailVerification:     "email_verification.html",
-		ApplicationSubmitted:  "application_submitted.html",
-		ApplicationUpdate:     "application_update.html",
-		SystemMaintenance:     "system_maintenance.html",
-		EmergencyAlert:        "emergency_alert.html",
-		ScheduleChange:        "schedule_change.html",
-	}
-
-	for templateType, fileName := range templateFiles {
-		filePath := filepath.Join(templatePath, fileName)
-		// Try to read the template file
-		templateData, err := os.ReadFile(filePath)
-		if err != nil {
-			// If we can't read the file, log it and use the fallback template
-			log.Printf("Error reading template file %s: %v, using fallback template", filePath, err)
-			// Use fallback template from hardcoded map if exists
-			if fallbackTemplate, ok := fallbackTemplates[templateType]; ok {
-				t, err := template.New(string(templateType)).Parse(fallbackTemplate)
-				if err != nil {
-					log.Printf("Error parsing fallback template for %s: %v", templateType, err)
-					continue
-				}
-				templates[templateType] = t
-			}
+	for templateType := range templateFiles {
+		source, ok := DefaultTemplate(templateType)
+		if !ok {
+			log.Printf("No built-in template for %s", templateType)
 			continue
 		}
-
-		// Parse the template from file
-		t, err := template.New(string(templateType)).Parse(string(templateData))
+		t, err := template.New(string(templateType)).Parse(source)
 		if err != nil {
-			log.Printf("Error parsing template file %s: %v", filePath, err)
+			log.Printf("Error parsing built-in template for %s: %v", templateType, err)
 			continue
 		}
 		templates[templateType] = t
 	}
-
 	return templates
 }
 
-// loadFallbackTemplates loads hardcoded templates
-func loadFallbackTemplates() map[TemplateType]*template.Template {
-	templates := make(map[TemplateType]*template.Template)
-
-	for templateType, templateStr := range fallbackTemplates {
-		t, err := template.New(string(templateType)).Parse(templateStr)
-		if err != nil {
-			log.Printf("Error parsing fallback template for %s: %v", templateType, err)
-			continue
-		}
-		templates[templateType] = t
-	}
-
-	return templates
-}
-
-// Fallback templates to use if file loading fails
+// Fallback templates for template types without an embedded file
 var fallbackTemplates = map[TemplateType]string{
 	ShiftReminder: `
 		<div style="font-family: sans-serif; max-width: 600px; margin: 0 auto;">
@@ -476,11 +438,42 @@ var fallbackTemplates = map[TemplateType]string{
 			<p>{{.OrganizationName}} Team</p>
 		</div>
 	`,
+	TicketIssued: `
+		<div style="font-family: sans-serif; max-width: 600px; margin: 0 auto;">
+			<h2>Your Visit Ticket is Ready</h2>
+			<p>Hello {{.Name}},</p>
+			<p>Your request {{.Reference}} has been approved and a ticket has been issued for your visit.</p>
+			<div style="background-color: #f3f4f6; padding: 15px; margin: 15px 0; border-radius: 5px;">
+				<p><strong>Ticket number:</strong> {{.TicketNumber}}</p>
+				<p><strong>Service:</strong> {{.Category}}</p>
+				<p><strong>Visit date:</strong> {{.VisitDay}}</p>
+				{{if .TimeSlot}}<p><strong>Time slot:</strong> {{.TimeSlot}}</p>{{end}}
+			</div>
+			<p>Please arrive 15 minutes before your slot, bring valid ID, and show your ticket number or QR code at the desk.</p>
+			<p>Best regards,</p>
+			<p>{{.OrganizationName}}</p>
+		</div>
+	`,
+	AppointmentReminder: `
+		<div style="font-family: sans-serif; max-width: 600px; margin: 0 auto;">
+			<h2>{{.Title}}</h2>
+			<p>Hello {{.Name}},</p>
+			<p>{{.Message}}</p>
+			<p>If you can no longer attend, please cancel from your visitor dashboard so the slot can be offered to someone else.</p>
+			<p>Best regards,</p>
+			<p>{{.OrganizationName}}</p>
+		</div>
+	`,
 	// Include other fallback templates here...
 }
 
 // shouldSendNotification checks if the user should receive a notification based on preferences
 func (ns *NotificationService) shouldSendNotification(templateType TemplateType, notificationChannel NotificationType, user models.User) bool {
+	// The channels the user chose for this category in the preference center
+	if !ChannelAllowed(user.ID, templateType.String(), notificationChannel.String()) {
+		return false
+	}
+
 	// If user has no preferences, default to sending notifications
 	if user.NotificationPreferences == nil {
 		return true
@@ -522,11 +515,56 @@ func (ns *NotificationService) SendNotification(data NotificationData, user mode
 		return nil
 	}
 
-	// Get the template for the notification
+	// Get the template for the notification: an admin's edit where there is one,
+	// otherwise the built-in default, and in plain language where the visitor asked
+	// for it and there is a plain-language variant
 	tmpl, ok := ns.templates[data.TemplateType]
+	var textTmpl *template.Template
+	override := templateOverride(data.TemplateType)
+	if override != nil {
+		edited, err := template.New(string(data.TemplateType) + "_edited").Parse(override.Body)
+		if err != nil {
+			log.Printf("Error parsing the edited %s template, using the default: %v", data.TemplateType, err)
+			override = nil
+		} else {
+			tmpl, ok = edited, true
+			if override.TextBody != "" {
+				textTmpl, _ = template.New(string(data.TemplateType) + "_text").Parse(override.TextBody)
+			}
+		}
+	}
 	if !ok {
 		return fmt.Errorf("template not found: %s", data.TemplateType)
 	}
+	format := userCommsFormat(user)
+	if plain, ok := plainLanguageTemplates[data.TemplateType]; ok && format.PlainLanguage {
+		tmpl, textTmpl = plain, nil
+	}
+
+	// Branding follows the location the notification is about, where there is one
+	location, _ := data.TemplateData["Location"].(string)
+	branding := LoadBranding(location)
+	data.TemplateData = brandTemplateData(data.TemplateData, branding)
+	if override != nil && override.Subject != "" {
+		data.Subject = renderSubject(override.Subject, data.TemplateData, data.Subject)
+	}
+
+	// Use a translation from the recipient's language fallback chain where there is
+	// one. Plain-language variants are English only.
+	chain := languageChain(user.Language, branding.Language)
+	language := models.DefaultLanguage
+	if translation := templateTranslation(data.TemplateType, chain); translation != nil {
+		translated, err := template.New(string(data.TemplateType) + "_" + translation.Language).Parse(translation.Body)
+		if err != nil {
+			log.Printf("Error parsing %s translation of %s, using English: %v", translation.Language, data.TemplateType, err)
+		} else {
+			tmpl, textTmpl = translated, nil
+			language = translation.Language
+			if translation.Subject != "" {
+				data.Subject = renderSubject(translation.Subject, data.TemplateData, data.Subject)
+			}
+		}
+	}
 
 	// Render the template with provided data
 	var rendered bytes.Buffer
@@ -534,14 +572,28 @@ func (ns *NotificationService) SendNotification(data NotificationData, user mode
 		return fmt.Errorf("failed to render template: %w", err)
 	}
 
+	entry := LanguageLogEntry(user.ID, data.NotificationType.String(), chain, language)
+	entry.TemplateType = data.TemplateType.String()
+	logLanguage(entry)
+
 	// Send notification based on type
 	switch data.NotificationType {
 	case EmailNotification:
-		return ns.emailClient.SendEmail(data.To, data.Subject, rendered.String())
+		body := rendered.String()
+		if format.LargePrint {
+			body = LargePrintEmail(body)
+		}
+		return ns.sendTrackedEmail(data.To, data.Subject, BrandEmail(body, branding), data.TemplateType, &user, data.Attachments...)
 	case SMSNotification:
-		// For SMS, create a plain text version of the notification
+		// For SMS, use the edited plain text or strip the HTML
 		plainText := stripHTML(rendered.String())
-		return ns.smsClient.SendSMS(data.To, plainText)
+		if textTmpl != nil {
+			var text bytes.Buffer
+			if err := textTmpl.Execute(&text, data.TemplateData); err == nil {
+				plainText = strings.TrimSpace(text.String())
+			}
+		}
+		return ns.deliverSMS(data.To, plainText, data.Subject, data.TemplateType, &user, data.TemplateType == UrgentCallout)
 	case PushNotification:
 		// Push notifications not implemented yet
 		return fmt.Errorf("push notifications not implemented")
@@ -550,6 +602,37 @@ func (ns *NotificationService) SendNotification(data NotificationData, user mode
 	}
 }
 
+// SendSMS sends a plain text SMS directly, without a template or preference check.
+// It is used for tickets sent to visitors who have no email. Once the monthly SMS
+// budget is spent the message is emailed instead where possible.
+func (ns *NotificationService) SendSMS(to, message string) error {
+	if !ns.enabled {
+		log.Println("Notification service is disabled")
+		return nil
+	}
+	return ns.deliverSMS(to, message, "", "", nil, false)
+}
+
+// SendUrgentSMS sends a plain text SMS that is never held back by the monthly
+// budget, such as one-time codes and time-limited offers
+func (ns *NotificationService) SendUrgentSMS(to, message string) error {
+	if !ns.enabled {
+		log.Println("Notification service is disabled")
+		return nil
+	}
+	return ns.deliverSMS(to, message, "", "", nil, true)
+}
+
+// SendEmail sends an already rendered email directly, without a template or
+// preference check. Callers are responsible for checking the user's preferences.
+func (ns *NotificationService) SendEmail(to, subject, body string) error {
+	if !ns.enabled {
+		log.Println("Notification service is disabled")
+		return nil
+	}
+	return ns.sendTrackedEmail(to, subject, BrandEmail(body, LoadBranding("")), "", nil)
+}
+
 // stripHTML is a helper function to convert HTML to plain text for SMS
 func stripHTML(html string) string {
 	// Very simple HTML stripping - in a real app, use a proper HTML parser
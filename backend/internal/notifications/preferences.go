@@ -0,0 +1,52 @@
+package notifications
+
+import (
+	"log"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+// CategoryPreference returns a user's channel preference for a notification
+// category, or the default when they have not chosen
+func CategoryPreference(userID uint, category string) models.NotificationPreference {
+	preference := models.DefaultNotificationPreference(userID, category)
+	if db.DB == nil || userID == 0 {
+		return preference
+	}
+
+	var saved []models.NotificationPreference
+	if err := db.DB.Where("user_id = ? AND category = ?", userID, category).Limit(1).Find(&saved).Error; err != nil {
+		log.Printf("Failed to load %s notification preference for user %d: %v", category, userID, err)
+		return preference
+	}
+	if len(saved) > 0 {
+		return saved[0]
+	}
+	return preference
+}
+
+// ChannelAllowed reports whether a user lets a kind of notification (a template type
+// or notification category) reach them on a channel. Notifications outside the
+// preference categories, such as account and emergency messages, are always allowed.
+func ChannelAllowed(userID uint, kind, channel string) bool {
+	category := models.NotificationCategoryFor(kind)
+	if category == "" || userID == 0 {
+		return true
+	}
+	return CategoryPreference(userID, category).Allows(channel)
+}
+
+// DigestFrequency returns how often a user wants non-urgent emails of a kind batched,
+// or "" when they are sent straight away
+func DigestFrequency(userID uint, kind string) string {
+	category := models.NotificationCategoryFor(kind)
+	if category == "" || userID == 0 {
+		return ""
+	}
+	preference := CategoryPreference(userID, category)
+	if !preference.Digested() {
+		return ""
+	}
+	return preference.Digest
+}
@@ -0,0 +1,416 @@
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/chaos"
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// ErrSMSBudgetExceeded is returned when a non-urgent SMS is blocked because the monthly
+// budget has been spent and the recipient has no email to fall back to
+var ErrSMSBudgetExceeded = errors.New("monthly SMS budget has been spent")
+
+// SMS segment sizes. Messages using only basic characters fit 160 per segment, or 153
+// once split; any other character switches the whole message to 70, or 67 once split.
+const (
+	smsBasicSegment      = 160
+	smsBasicMultipart    = 153
+	smsUnicodeSegment    = 70
+	smsUnicodeMultipart  = 67
+	defaultSMSUnitCost   = 0.04
+	defaultCostCurrency  = "GBP"
+	smsFallbackSubject   = "Message from Lewisham Charity"
+	smsBudgetAlertAction = "/admin/communications/spend"
+	smsSpendCacheTTL     = time.Minute
+)
+
+// smsSpendCache holds the current month's SMS spend so sends do not each run the
+// monthly aggregate. Sends from this instance are added as they happen; the total is
+// reloaded every minute to pick up other instances.
+var smsSpendCache struct {
+	sync.Mutex
+	month    string
+	spend    float64
+	loadedAt time.Time
+}
+
+// SMSBudgetStatus summarises SMS spend against the budget for one month
+type SMSBudgetStatus struct {
+	Month       string  `json:"month"`
+	Budget      float64 `json:"budget"` // 0 means no budget is set
+	Spend       float64 `json:"spend"`
+	Remaining   float64 `json:"remaining"`
+	PercentUsed float64 `json:"percent_used"`
+	Currency    string  `json:"currency"`
+	Warning     bool    `json:"warning"` // At or over the warning threshold
+	Blocked     bool    `json:"blocked"` // Non-urgent SMS are being held back
+}
+
+// SMSSegments returns how many billable segments a message is split into
+func SMSSegments(message string) int {
+	single, multipart := smsBasicSegment, smsBasicMultipart
+	for _, r := range message {
+		if r > 127 {
+			single, multipart = smsUnicodeSegment, smsUnicodeMultipart
+			break
+		}
+	}
+
+	length := len([]rune(message))
+	if length <= single {
+		return 1
+	}
+	return (length + multipart - 1) / multipart
+}
+
+// SMSUnitCost returns the cost of one SMS segment, set by SMS_COST_PER_SEGMENT
+func SMSUnitCost() float64 {
+	return envCost("SMS_COST_PER_SEGMENT", defaultSMSUnitCost)
+}
+
+// EmailUnitCost returns the cost of one email, set by EMAIL_COST_PER_MESSAGE
+func EmailUnitCost() float64 {
+	return envCost("EMAIL_COST_PER_MESSAGE", 0)
+}
+
+// CostCurrency returns the currency notification costs are recorded in
+func CostCurrency() string {
+	if currency := os.Getenv("NOTIFICATION_COST_CURRENCY"); currency != "" {
+		return currency
+	}
+	return defaultCostCurrency
+}
+
+// MonthlySMSBudget returns the monthly SMS budget. The system setting takes priority
+// over SMS_MONTHLY_BUDGET; zero means SMS spend is not capped.
+func MonthlySMSBudget() float64 {
+	if db.DB != nil {
+		var config models.SystemConfig
+		if err := db.DB.Where("key = ?", models.SMSBudgetConfigKey).First(&config).Error; err == nil {
+			if budget, err := strconv.ParseFloat(config.Value, 64); err == nil && budget >= 0 {
+				return budget
+			}
+		}
+	}
+	return envCost("SMS_MONTHLY_BUDGET", 0)
+}
+
+// SetMonthlySMSBudget stores the monthly SMS budget as a system setting
+func SetMonthlySMSBudget(budget float64, updatedBy uint) error {
+	if budget < 0 {
+		return fmt.Errorf("budget cannot be negative")
+	}
+
+	var config models.SystemConfig
+	err := db.DB.Where("key = ?", models.SMSBudgetConfigKey).
+		Attrs(models.SystemConfig{
+			Key:         models.SMSBudgetConfigKey,
+			Type:        models.ConfigTypeFloat,
+			Category:    "notifications",
+			Description: "Monthly SMS budget. Non-urgent SMS fall back to email once it is spent; 0 disables the cap",
+		}).
+		FirstOrInit(&config).Error
+	if err != nil {
+		return err
+	}
+
+	config.Value = strconv.FormatFloat(budget, 'f', 2, 64)
+	config.UpdatedBy = &updatedBy
+	return db.DB.Save(&config).Error
+}
+
+// GetSMSBudgetStatus returns SMS spend against the budget for the month containing t
+func GetSMSBudgetStatus(t time.Time) SMSBudgetStatus {
+	start, end := monthRange(t)
+	var spend float64
+	if db.DB != nil {
+		db.DB.Model(&models.NotificationCost{}).
+			Where("channel = ? AND status = ? AND created_at >= ? AND created_at < ?",
+				SMSNotification.String(), models.NotificationCostSent, start, end).
+			Select("COALESCE(SUM(cost), 0)").
+			Scan(&spend)
+	}
+	return smsBudgetStatus(start.Format("2006-01"), spend)
+}
+
+// currentSMSBudgetStatus returns this month's budget status from the spend cache,
+// reloading it once it is more than a minute old
+func currentSMSBudgetStatus(now time.Time) SMSBudgetStatus {
+	month := now.Format("2006-01")
+
+	smsSpendCache.Lock()
+	defer smsSpendCache.Unlock()
+	if smsSpendCache.month != month || now.Sub(smsSpendCache.loadedAt) > smsSpendCacheTTL {
+		status := GetSMSBudgetStatus(now)
+		smsSpendCache.month = month
+		smsSpendCache.spend = status.Spend
+		smsSpendCache.loadedAt = now
+		return status
+	}
+	return smsBudgetStatus(month, smsSpendCache.spend)
+}
+
+// addSMSSpend adds a sent message's cost to the cached spend and returns the status
+// after it
+func addSMSSpend(now time.Time, cost float64) SMSBudgetStatus {
+	status := currentSMSBudgetStatus(now)
+
+	smsSpendCache.Lock()
+	defer smsSpendCache.Unlock()
+	if smsSpendCache.month == status.Month {
+		smsSpendCache.spend += cost
+		status = smsBudgetStatus(status.Month, smsSpendCache.spend)
+	}
+	return status
+}
+
+// smsBudgetStatus works out the budget figures for a month's spend
+func smsBudgetStatus(month string, spend float64) SMSBudgetStatus {
+	status := SMSBudgetStatus{
+		Month:    month,
+		Budget:   MonthlySMSBudget(),
+		Spend:    roundCost(spend),
+		Currency: CostCurrency(),
+	}
+	if status.Budget > 0 {
+		status.Remaining = roundCost(math.Max(status.Budget-status.Spend, 0))
+		status.PercentUsed = math.Round(status.Spend/status.Budget*1000) / 10
+		status.Warning = status.PercentUsed >= models.SMSBudgetWarnPercent
+		status.Blocked = status.PercentUsed >= models.SMSBudgetBlockPercent
+	}
+	return status
+}
+
+// deliverSMS sends an SMS within the monthly budget. Once the budget is spent only
+// urgent messages go out by SMS; the rest are sent by email where we have an address.
+// Numbers flagged do-not-SMS are never texted, however urgent the message.
+func (ns *NotificationService) deliverSMS(to, message, subject string, templateType TemplateType, user *models.User, urgent bool) error {
+	if restriction := smsRestriction(to, user); restriction != nil {
+		return ns.suppressSMS(to, message, subject, templateType, user, restriction)
+	}
+	if !urgent && currentSMSBudgetStatus(time.Now()).Blocked {
+		return ns.smsFallback(to, message, subject, templateType, user)
+	}
+
+	err := chaos.Fail(chaos.KindNotification, SMSNotification.String())
+	if err == nil {
+		err = ns.smsClient.SendSMS(to, message)
+	}
+	segments := SMSSegments(message)
+	cost := models.NotificationCost{
+		Channel:      SMSNotification.String(),
+		Provider:     providerName(ns.smsClient),
+		Recipient:    models.MaskPhone(to),
+		UserID:       userIDOf(user),
+		TemplateType: templateType.String(),
+		Segments:     segments,
+		UnitCost:     SMSUnitCost(),
+		Cost:         roundCost(float64(segments) * SMSUnitCost()),
+		Currency:     CostCurrency(),
+		Urgent:       urgent,
+		Status:       models.NotificationCostSent,
+	}
+	if err != nil {
+		cost.Cost = 0
+		cost.Status = models.NotificationCostFailed
+	}
+	recordCost(&cost)
+
+	if err == nil {
+		ns.checkSMSBudgetAlerts(addSMSSpend(time.Now(), cost.Cost))
+	}
+	return err
+}
+
+// smsFallback emails a blocked SMS to the recipient instead
+func (ns *NotificationService) smsFallback(to, message, subject string, templateType TemplateType, user *models.User) error {
+	if user == nil && db.DB != nil && to != "" {
+		var found models.User
+		if err := db.DB.Where("phone = ?", to).First(&found).Error; err == nil {
+			user = &found
+		}
+	}
+
+	blocked := models.NotificationCost{
+		Channel:      SMSNotification.String(),
+		Provider:     providerName(ns.smsClient),
+		Recipient:    models.MaskPhone(to),
+		UserID:       userIDOf(user),
+		TemplateType: templateType.String(),
+		Segments:     SMSSegments(message),
+		Currency:     CostCurrency(),
+		Status:       models.NotificationCostBlocked,
+	}
+
+	if user == nil || user.Email == "" {
+		recordCost(&blocked)
+		log.Printf("SMS to %s blocked: monthly SMS budget spent and no email to fall back to", models.MaskPhone(to))
+		return ErrSMSBudgetExceeded
+	}
+
+	blocked.FallbackChannel = EmailNotification.String()
+	recordCost(&blocked)
+
+	if subject == "" {
+		subject = smsFallbackSubject
+	}
+	return ns.sendTrackedEmail(user.Email, subject, BrandEmail(message, LoadBranding("")), templateType, user)
+}
+
+// sendTrackedEmail sends an email and records its cost
+func (ns *NotificationService) sendTrackedEmail(to, subject, body string, templateType TemplateType, user *models.User, attachments ...EmailAttachment) error {
+	err := chaos.Fail(chaos.KindNotification, EmailNotification.String())
+	if err == nil {
+		err = sendEmail(ns.emailClient, to, subject, body, attachments)
+	}
+
+	cost := models.NotificationCost{
+		Channel:      EmailNotification.String(),
+		Provider:     providerName(ns.emailClient),
+		Recipient:    models.MaskEmail(to),
+		UserID:       userIDOf(user),
+		TemplateType: templateType.String(),
+		Segments:     1,
+		UnitCost:     EmailUnitCost(),
+		Cost:         EmailUnitCost(),
+		Currency:     CostCurrency(),
+		Status:       models.NotificationCostSent,
+	}
+	if err != nil {
+		cost.Cost = 0
+		cost.Status = models.NotificationCostFailed
+	}
+	recordCost(&cost)
+	return err
+}
+
+// checkSMSBudgetAlerts warns admins the first time a month's SMS spend passes
+// the warning and block thresholds
+func (ns *NotificationService) checkSMSBudgetAlerts(status SMSBudgetStatus) {
+	if db.DB == nil {
+		return
+	}
+
+	if !status.Warning {
+		return
+	}
+
+	for _, threshold := range []int{models.SMSBudgetWarnPercent, models.SMSBudgetBlockPercent} {
+		if status.PercentUsed < float64(threshold) {
+			continue
+		}
+
+		alert := models.SMSBudgetAlert{
+			Month:     status.Month,
+			Threshold: threshold,
+			Spend:     status.Spend,
+			Budget:    status.Budget,
+		}
+		result := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&alert)
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue
+		}
+		ns.notifyAdminsOfSMSBudget(status, threshold)
+	}
+}
+
+// notifyAdminsOfSMSBudget sends an in-app notification and email to every admin
+func (ns *NotificationService) notifyAdminsOfSMSBudget(status SMSBudgetStatus, threshold int) {
+	title := fmt.Sprintf("SMS budget %d%% used", threshold)
+	message := fmt.Sprintf("SMS spend for %s is %.2f %s of the %.2f %s budget (%.1f%%).",
+		status.Month, status.Spend, status.Currency, status.Budget, status.Currency, status.PercentUsed)
+	priority := "high"
+	if threshold >= models.SMSBudgetBlockPercent {
+		message += " Non-urgent SMS are now sent by email instead until the budget is raised or the month ends."
+		priority = "urgent"
+	}
+
+	var admins []models.User
+	if err := db.DB.Where("role IN ? AND status = ?",
+		[]string{models.RoleAdmin, models.RoleSuperAdmin}, models.StatusActive).
+		Find(&admins).Error; err != nil {
+		log.Printf("Failed to load admins for SMS budget alert: %v", err)
+		return
+	}
+
+	for _, admin := range admins {
+		notification := models.InAppNotification{
+			UserID:    admin.ID,
+			Title:     title,
+			Message:   message,
+			Type:      "warning",
+			Priority:  priority,
+			ActionURL: smsBudgetAlertAction,
+		}
+		if err := db.DB.Create(&notification).Error; err != nil {
+			log.Printf("Failed to create SMS budget alert for admin %d: %v", admin.ID, err)
+		}
+
+		if admin.Email != "" {
+			if err := ns.sendTrackedEmail(admin.Email, title, message, "", &admin); err != nil {
+				log.Printf("Failed to email SMS budget alert to admin %d: %v", admin.ID, err)
+			}
+		}
+	}
+}
+
+// recordCost stores a notification cost record, logging rather than failing the send
+func recordCost(cost *models.NotificationCost) {
+	if db.DB == nil {
+		return
+	}
+	if err := db.DB.Create(cost).Error; err != nil {
+		log.Printf("Failed to record %s notification cost: %v", cost.Channel, err)
+	}
+}
+
+// providerName identifies the provider behind a notification client
+func providerName(client NotificationClient) string {
+	switch client.(type) {
+	case *twilioClient:
+		return "twilio"
+	case *sendGridClient:
+		return "sendgrid"
+	default:
+		return "mock"
+	}
+}
+
+// userIDOf returns the user's ID, or nil when the recipient is not a known user
+func userIDOf(user *models.User) *uint {
+	if user == nil || user.ID == 0 {
+		return nil
+	}
+	id := user.ID
+	return &id
+}
+
+// monthRange returns the start of the month containing t and the start of the next
+func monthRange(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+// envCost reads a non-negative cost from the environment
+func envCost(key string, fallback float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil && value >= 0 {
+		return value
+	}
+	return fallback
+}
+
+// roundCost rounds an amount to four decimal places, enough for per-segment prices
+func roundCost(amount float64) float64 {
+	return math.Round(amount*10000) / 10000
+}
@@ -0,0 +1,47 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSMSBudgetStatusThresholds(t *testing.T) {
+	t.Setenv("SMS_MONTHLY_BUDGET", "100")
+
+	tests := []struct {
+		spend            float64
+		warning, blocked bool
+	}{
+		{50, false, false},
+		{80, true, false},
+		{99.9, true, false},
+		{100, true, true},
+	}
+	for _, tt := range tests {
+		status := smsBudgetStatus("2026-10", tt.spend)
+		if status.Warning != tt.warning || status.Blocked != tt.blocked {
+			t.Errorf("spend %.2f: warning %v blocked %v, want %v %v", tt.spend, status.Warning, status.Blocked, tt.warning, tt.blocked)
+		}
+	}
+}
+
+func TestAddSMSSpendUsesCachedTotal(t *testing.T) {
+	t.Setenv("SMS_MONTHLY_BUDGET", "1")
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	if status := currentSMSBudgetStatus(now); status.Spend != 0 {
+		t.Fatalf("expected no spend without a database, got %.2f", status.Spend)
+	}
+	for i := 0; i < 25; i++ {
+		addSMSSpend(now, 0.04)
+	}
+	status := currentSMSBudgetStatus(now.Add(30 * time.Second))
+	if status.Spend != 1 || !status.Blocked {
+		t.Fatalf("expected cached spend of 1.00 to block, got %.2f (blocked %v)", status.Spend, status.Blocked)
+	}
+
+	// A new month starts from the stored total again
+	if status := currentSMSBudgetStatus(now.AddDate(0, 1, 0)); status.Spend != 0 || status.Month != "2026-11" {
+		t.Fatalf("new month got %s spend %.2f", status.Month, status.Spend)
+	}
+}
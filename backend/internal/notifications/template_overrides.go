@@ -0,0 +1,154 @@
+package notifications
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"regexp"
+	"sort"
+	"text/template"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// templateActionPattern matches a template action such as {{if .TimeSlot}}
+	templateActionPattern = regexp.MustCompile(`\{\{(.*?)\}\}`)
+	// templateFieldPattern matches a top-level field used in an action
+	templateFieldPattern = regexp.MustCompile(`(?:^|[\s(|])\.([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// sampleTemplateValues fill common variables in template previews
+var sampleTemplateValues = map[string]interface{}{
+	"Name":          "Alex Morgan",
+	"FirstName":     "Alex",
+	"Email":         "alex.morgan@example.com",
+	"Title":         "Sample notification",
+	"Message":       "This is a sample message to show how the email will look.",
+	"Date":          "Tuesday 14 October",
+	"Day":           "Tuesday 14 October",
+	"VisitDay":      "Tuesday 14 October",
+	"Time":          "10:00",
+	"TimeSlot":      "10:00 - 10:30",
+	"StartTime":     "10:00",
+	"EndTime":       "13:00",
+	"Location":      "Community Hall",
+	"Place":         "Community Hall",
+	"Role":          "Food bank helper",
+	"Service":       "benefits advice",
+	"Reference":     "HR-1024",
+	"TicketNumber":  "T-0412",
+	"Amount":        "25.00",
+	"Currency":      "GBP",
+	"DonationType":  "money",
+	"Status":        "approved",
+	"Reason":        "We need more information about your household.",
+	"ResetURL":      "https://example.org/reset-password?token=sample",
+	"VerifyURL":     "https://example.org/verify-email?token=sample",
+	"LoginURL":      "https://example.org/login",
+	"DashboardURL":  "https://example.org/dashboard",
+	"TempPassword":  "sample-password",
+	"ExpiresIn":     "24 hours",
+	"ContactEmail":  "help@example.org",
+	"ContactPhone":  "020 7946 0000",
+	"CalloutReason": "Unexpected rise in visitors",
+}
+
+// templateOverride returns an admin's edit of a transactional template, or nil when
+// the built-in default applies
+func templateOverride(templateType TemplateType) *models.NotificationTemplate {
+	if db.DB == nil {
+		return nil
+	}
+	var override models.NotificationTemplate
+	if err := db.DB.Where("template_type = ?", string(templateType)).First(&override).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Failed to load the %s template, using the default: %v", templateType, err)
+		}
+		return nil
+	}
+	return &override
+}
+
+// TemplateVariables lists the variables the templates use, sorted
+func TemplateVariables(sources ...string) []string {
+	seen := map[string]bool{}
+	variables := []string{}
+	for _, source := range sources {
+		for _, action := range templateActionPattern.FindAllStringSubmatch(source, -1) {
+			for _, field := range templateFieldPattern.FindAllStringSubmatch(action[1], -1) {
+				if !seen[field[1]] {
+					seen[field[1]] = true
+					variables = append(variables, field[1])
+				}
+			}
+		}
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+// SampleTemplateData returns preview data for every variable the templates use.
+// Values the admin supplies win over the samples, and branding is filled in as it
+// would be when sending.
+func SampleTemplateData(values map[string]interface{}, sources ...string) map[string]interface{} {
+	data := map[string]interface{}{}
+	for _, variable := range TemplateVariables(sources...) {
+		if sample, ok := sampleTemplateValues[variable]; ok {
+			data[variable] = sample
+		} else {
+			data[variable] = "[" + variable + "]"
+		}
+	}
+	for key, value := range values {
+		data[key] = value
+	}
+	location, _ := data["Location"].(string)
+	return brandTemplateData(data, LoadBranding(location))
+}
+
+// TemplatePreview is a transactional template rendered with sample data
+type TemplatePreview struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"` // Wrapped in the letterhead, as sent
+	Text    string `json:"text"` // As sent by SMS
+}
+
+// PreviewTemplate renders a subject, HTML body and optional plain-text body. Without
+// a plain-text body the text is the HTML stripped of tags, as when sending.
+func PreviewTemplate(subject, htmlBody, textBody string, data map[string]interface{}) (*TemplatePreview, error) {
+	html, err := renderTemplateSource("html", htmlBody, data)
+	if err != nil {
+		return nil, err
+	}
+	preview := &TemplatePreview{Text: stripHTML(html)}
+	if subject != "" {
+		if preview.Subject, err = renderTemplateSource("subject", subject, data); err != nil {
+			return nil, err
+		}
+	}
+	if textBody != "" {
+		if preview.Text, err = renderTemplateSource("text", textBody, data); err != nil {
+			return nil, err
+		}
+	}
+	location, _ := data["Location"].(string)
+	preview.HTML = BrandEmail(html, LoadBranding(location))
+	return preview, nil
+}
+
+// renderTemplateSource parses and executes one template
+func renderTemplateSource(name, source string, data map[string]interface{}) (string, error) {
+	tmpl, err := template.New(name).Parse(source)
+	if err != nil {
+		return "", err
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
+}
@@ -0,0 +1,47 @@
+package notifications
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultTemplateEmbedded(t *testing.T) {
+	source, ok := DefaultTemplate(VolunteerApproval)
+	if !ok || !strings.Contains(source, "{{") {
+		t.Fatalf("volunteer_approval should have an embedded default, got %q", source)
+	}
+	if _, ok := DefaultTemplate(TemplateType("no_such_template")); ok {
+		t.Error("unknown template types should have no default")
+	}
+}
+
+func TestTemplateVariables(t *testing.T) {
+	source := `<p>Hello {{.Name}},</p>{{if .TimeSlot}}<p>{{.TimeSlot}}</p>{{end}}{{range .Items}}{{.Label}}{{end}}`
+	got := TemplateVariables(source, "Your visit on {{ .Date }}")
+	want := []string{"Date", "Items", "Label", "Name", "TimeSlot"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestPreviewTemplate(t *testing.T) {
+	data := SampleTemplateData(map[string]interface{}{"Name": "Sam"}, "Hi {{.Name}}", "<p>Role: {{.Role}} {{.Unusual}}</p>")
+	preview, err := PreviewTemplate("Hi {{.Name}}", "<p>Role: {{.Role}} {{.Unusual}}</p>", "", data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if preview.Subject != "Hi Sam" {
+		t.Errorf("subject: got %q", preview.Subject)
+	}
+	if preview.Text != "Role: Food bank helper [Unusual]" {
+		t.Errorf("text should strip the HTML, got %q", preview.Text)
+	}
+	if !strings.Contains(preview.HTML, "Role: Food bank helper") {
+		t.Errorf("html missing body: %s", preview.HTML)
+	}
+
+	if _, err := PreviewTemplate("", "{{.Name", "", data); err == nil {
+		t.Error("a template that does not parse should fail")
+	}
+}
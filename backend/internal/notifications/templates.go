@@ -1,5 +1,24 @@
 package notifications
 
+import "embed"
+
+// defaultTemplateFiles are the built-in HTML templates, compiled into the binary so
+// they do not depend on the working directory
+//
+//go:embed templates/*.html
+var defaultTemplateFiles embed.FS
+
+// DefaultTemplate returns the built-in HTML of a template type
+func DefaultTemplate(templateType TemplateType) (string, bool) {
+	if fileName, ok := templateFiles[templateType]; ok {
+		if data, err := defaultTemplateFiles.ReadFile("templates/" + fileName); err == nil {
+			return string(data), true
+		}
+	}
+	source, ok := fallbackTemplates[templateType]
+	return source, ok
+}
+
 // GetTemplateFilename returns the HTML template filename for a given template type
 func (t TemplateType) GetTemplateFilename() string {
 	switch t {
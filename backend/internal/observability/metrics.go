@@ -32,6 +32,15 @@ type MetricsService struct {
 	cacheDuration   *prometheus.HistogramVec
 	cacheHitRate    prometheus.Gauge
 	cacheSize       prometheus.Gauge
+	cacheLookups    *prometheus.CounterVec
+	cacheLoads      *prometheus.CounterVec
+	cacheInvalidate *prometheus.CounterVec
+
+	// Outbound HTTP Metrics
+	outboundRequests *prometheus.CounterVec
+	outboundDuration *prometheus.HistogramVec
+	outboundRetries  *prometheus.CounterVec
+	outboundCircuit  *prometheus.GaugeVec
 
 	// Business Metrics
 	helpRequests         *prometheus.CounterVec
@@ -39,6 +48,8 @@ type MetricsService struct {
 	donations            *prometheus.CounterVec
 	queueMetrics         *prometheus.GaugeVec
 	websocketConnections *prometheus.GaugeVec
+	websocketDropped     *prometheus.CounterVec
+	websocketRelay       *prometheus.CounterVec
 
 	// System Metrics
 	systemHealth *prometheus.GaugeVec
@@ -179,6 +190,64 @@ func (ms *MetricsService) initializeMetrics() {
 		},
 	)
 
+	ms.cacheLookups = promauto.With(ms.registry).NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_lookups_total",
+			Help: "Cache lookups by key group",
+		},
+		[]string{"group", "result"}, // result: hit/miss/error
+	)
+
+	ms.cacheLoads = promauto.With(ms.registry).NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_loads_total",
+			Help: "Values loaded on a cache miss, and how many callers shared an in-flight load",
+		},
+		[]string{"group", "shared"},
+	)
+
+	ms.cacheInvalidate = promauto.With(ms.registry).NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_invalidated_keys_total",
+			Help: "Cache keys removed by tag invalidation",
+		},
+		[]string{"tag"},
+	)
+
+	// Outbound HTTP Metrics
+	ms.outboundRequests = promauto.With(ms.registry).NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbound_http_requests_total",
+			Help: "Calls to external providers by destination and result",
+		},
+		[]string{"destination", "method", "result"}, // result: 2xx/4xx/5xx/error/circuit_open
+	)
+
+	ms.outboundDuration = promauto.With(ms.registry).NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "outbound_http_request_duration_seconds",
+			Help:    "Duration of each attempt to call an external provider",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"destination"},
+	)
+
+	ms.outboundRetries = promauto.With(ms.registry).NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbound_http_retries_total",
+			Help: "Retried calls to external providers",
+		},
+		[]string{"destination"},
+	)
+
+	ms.outboundCircuit = promauto.With(ms.registry).NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "outbound_http_circuit_state",
+			Help: "Circuit breaker state per provider host (0=closed, 1=half open, 2=open)",
+		},
+		[]string{"destination", "host"},
+	)
+
 	// Business Metrics
 	ms.helpRequests = promauto.With(ms.registry).NewCounterVec(
 		prometheus.CounterOpts{
@@ -220,6 +289,22 @@ func (ms *MetricsService) initializeMetrics() {
 		[]string{"user_role", "category"},
 	)
 
+	ms.websocketDropped = promauto.With(ms.registry).NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "websocket_dropped_messages_total",
+			Help: "WebSocket messages that could not be delivered",
+		},
+		[]string{"reason"}, // timeout/queue_full
+	)
+
+	ms.websocketRelay = promauto.With(ms.registry).NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "websocket_relay_messages_total",
+			Help: "WebSocket messages relayed between replicas through Redis",
+		},
+		[]string{"result"}, // published/received/failed
+	)
+
 	// System Metrics
 	ms.systemHealth = promauto.With(ms.registry).NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -318,6 +403,43 @@ func (ms *MetricsService) SetCacheSize(size int64) {
 	ms.cacheSize.Set(float64(size))
 }
 
+func (ms *MetricsService) RecordCacheLookup(group, result string) {
+	ms.cacheLookups.WithLabelValues(group, result).Inc()
+}
+
+func (ms *MetricsService) RecordCacheLoad(group string, shared bool) {
+	ms.cacheLoads.WithLabelValues(group, strconv.FormatBool(shared)).Inc()
+}
+
+func (ms *MetricsService) RecordCacheInvalidation(tag string, keys int) {
+	ms.cacheInvalidate.WithLabelValues(tag).Add(float64(keys))
+}
+
+// RecordOutboundRequest records one attempt to call an external provider
+func (ms *MetricsService) RecordOutboundRequest(destination, method, result string, duration time.Duration) {
+	ms.outboundRequests.WithLabelValues(destination, method, result).Inc()
+	if duration > 0 {
+		ms.outboundDuration.WithLabelValues(destination).Observe(duration.Seconds())
+	}
+}
+
+// RecordOutboundRetry records a retried call to an external provider
+func (ms *MetricsService) RecordOutboundRetry(destination string) {
+	ms.outboundRetries.WithLabelValues(destination).Inc()
+}
+
+// SetOutboundCircuitState records a provider host's circuit breaker state
+func (ms *MetricsService) SetOutboundCircuitState(destination, host, state string) {
+	value := 0.0
+	switch state {
+	case "half_open":
+		value = 1
+	case "open":
+		value = 2
+	}
+	ms.outboundCircuit.WithLabelValues(destination, host).Set(value)
+}
+
 // Business Metrics Methods
 func (ms *MetricsService) RecordHelpRequest(category, priority, status string) {
 	ms.helpRequests.WithLabelValues(category, priority, status).Inc()
@@ -339,6 +461,16 @@ func (ms *MetricsService) SetWebSocketConnections(userRole, category string, cou
 	ms.websocketConnections.WithLabelValues(userRole, category).Set(float64(count))
 }
 
+// RecordWebSocketDropped records a WebSocket message that could not be delivered
+func (ms *MetricsService) RecordWebSocketDropped(reason string) {
+	ms.websocketDropped.WithLabelValues(reason).Inc()
+}
+
+// RecordWebSocketRelay records a WebSocket message relayed through Redis
+func (ms *MetricsService) RecordWebSocketRelay(result string) {
+	ms.websocketRelay.WithLabelValues(result).Inc()
+}
+
 // System Metrics Methods
 func (ms *MetricsService) SetSystemHealth(component string, healthy bool) {
 	value := float64(0)
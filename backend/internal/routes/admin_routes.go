@@ -1,8 +1,10 @@
 package routes
 
 import (
+	"github.com/geoo115/charity-management-system/internal/chaos"
 	adminHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/admin"
 	authHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/auth"
+	inventoryHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/inventory"
 	systemHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/system"
 	visitorHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/visitor"
 	volunteerHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/volunteer"
@@ -36,15 +38,18 @@ func DefaultAdminRouteConfig() *AdminRouteConfig {
 func SetupAdminRoutes(r *gin.Engine) error {
 	// Create main admin route group with authentication and admin authorization
 	adminAPI := r.Group(AdminBasePath)
-	adminAPI.Use(middleware.Auth(), middleware.RequireAdmin())
+	adminAPI.Use(middleware.Auth(), middleware.RequireAdmin(), middleware.RequireAdminScope())
 
 	// Setup core admin functionality
 	setupCoreDashboard(adminAPI)
 	setupUserManagement(adminAPI)
 	setupStaffManagement(adminAPI)
 	setupVolunteerManagement(adminAPI)
+	setupVolunteerTeams(adminAPI)
+	setupKudosModeration(adminAPI)
 	setupShiftManagement(adminAPI)
 	setupSystemManagement(adminAPI)
+	setupSettings(adminAPI)
 
 	// Setup feature modules
 	setupAnalytics(adminAPI)
@@ -56,10 +61,25 @@ func SetupAdminRoutes(r *gin.Engine) error {
 	// Setup additional admin features
 	setupFeedbackManagement(adminAPI)
 	setupQueueManagement(adminAPI)
+	setupWalkInManagement(adminAPI)
+	setupAppointmentManagement(adminAPI)
+	setupDayOperations(adminAPI)
 	setupHelpRequestManagement(adminAPI)
+	setupServiceTypeManagement(adminAPI)
 	setupDocumentManagement(adminAPI)
 	setupDonationManagement(adminAPI)
+	setupPledgeManagement(adminAPI)
+	setupDonationDrives(adminAPI)
+	setupBankReconciliation(adminAPI)
+	setupSupplierOrdering(adminAPI)
+	setupInventory(adminAPI)
 	setupAuditLogs(adminAPI)
+	setupTags(adminAPI)
+
+	// Failure injection, only outside production when CHAOS_ENABLED is set
+	if chaos.Enabled() {
+		setupChaos(adminAPI)
+	}
 
 	return nil
 }
@@ -90,7 +110,24 @@ func setupUserManagement(group *gin.RouterGroup) {
 		userGroup.PUT("/:id", authHandlers.AdminUpdateUser)
 		userGroup.DELETE("/:id", authHandlers.DeleteUser)
 		userGroup.PUT("/:id/status", authHandlers.UpdateUserStatus)
+		userGroup.POST("/:id/force-logout", adminHandlers.AdminForceLogout)
 		userGroup.GET("/reports", adminHandlers.AdminGetUserReports)
+		userGroup.GET("/duplicate-phones", authHandlers.ListDuplicatePhones)
+		userGroup.GET("/admin-scopes", authHandlers.ListAdminScopes)
+
+		// Do-not-contact and vulnerable adult flags
+		userGroup.GET("/contact-restrictions", adminHandlers.ListContactRestrictions)
+		userGroup.GET("/contact-suppressions", adminHandlers.ListContactSuppressions)
+		userGroup.GET("/:id/contact-restrictions", adminHandlers.GetContactRestriction)
+		userGroup.PUT("/:id/contact-restrictions", adminHandlers.SetContactRestriction)
+		userGroup.DELETE("/:id/contact-restrictions", adminHandlers.ClearContactRestriction)
+		userGroup.POST("/:id/contact-check", adminHandlers.CheckContactAllowed)
+
+		// Admin and staff accounts are created by invitation
+		userGroup.GET("/invitations", adminHandlers.AdminListInvitations)
+		userGroup.POST("/invitations", adminHandlers.AdminCreateInvitation)
+		userGroup.POST("/invitations/:id/resend", adminHandlers.AdminResendInvitation)
+		userGroup.DELETE("/invitations/:id", adminHandlers.AdminRevokeInvitation)
 	}
 }
 
@@ -121,6 +158,10 @@ func setupStaffManagement(group *gin.RouterGroup) {
 		checkInGroup.POST("/scan", adminHandlers.ScanTicket)
 		checkInGroup.GET("/validate/:ticket", adminHandlers.ValidateTicket)
 		checkInGroup.POST("/visits/:id/complete", adminHandlers.CompleteVisit)
+
+		// Suspected ticket misuse held at the desk
+		checkInGroup.GET("/fraud-events", adminHandlers.ListTicketFraudEvents)
+		checkInGroup.POST("/fraud-events/:id/review", adminHandlers.ReviewTicketFraudEvent)
 	}
 }
 
@@ -137,11 +178,21 @@ func setupVolunteerManagement(group *gin.RouterGroup) {
 		// Volunteer approval workflow
 		volunteerGroup.POST("/:id/approve", volunteerHandlers.ApproveVolunteer)
 		volunteerGroup.POST("/:id/reject", volunteerHandlers.RejectVolunteer)
+		volunteerGroup.POST("/:id/withdraw", volunteerHandlers.WithdrawVolunteerApplication)
+		volunteerGroup.GET("/applications/retention", adminHandlers.AdminGetApplicationRetention)
 
 		// Performance and analytics
 		volunteerGroup.GET("/performance", systemHandlers.OptimizedVolunteerPerformance)
 		volunteerGroup.GET("/coverage-gaps", adminHandlers.AdminGetVolunteerCoverageGaps)
 		volunteerGroup.GET("/reliability", adminHandlers.AdminGetVolunteerReliabilityStats)
+		volunteerGroup.GET("/availability-heatmap", adminHandlers.AdminGetVolunteerAvailabilityHeatmap)
+		volunteerGroup.GET("/away/conflicts", adminHandlers.AdminGetAwayConflicts)
+
+		// Certificates of volunteering hours
+		volunteerGroup.GET("/certificates", adminHandlers.AdminListVolunteerCertificates)
+		volunteerGroup.POST("/certificates/:id/approve", adminHandlers.AdminApproveVolunteerCertificate)
+		volunteerGroup.POST("/certificates/:id/reject", adminHandlers.AdminRejectVolunteerCertificate)
+		volunteerGroup.POST("/certificates/:id/revoke", adminHandlers.AdminRevokeVolunteerCertificate)
 
 		// Individual volunteer management
 		volunteerGroup.GET("/:id/shifts/history", systemHandlers.OptimizedVolunteerShiftHistory)
@@ -157,6 +208,50 @@ func setupVolunteerManagement(group *gin.RouterGroup) {
 	}
 }
 
+// setupVolunteerTeams configures volunteer team endpoints
+func setupVolunteerTeams(group *gin.RouterGroup) {
+	teamGroup := group.Group("/volunteer-teams")
+	{
+		teamGroup.GET("", adminHandlers.AdminListVolunteerTeams)
+		teamGroup.POST("", adminHandlers.AdminCreateVolunteerTeam)
+		teamGroup.GET("/:id", adminHandlers.AdminGetVolunteerTeam)
+		teamGroup.PUT("/:id", adminHandlers.AdminUpdateVolunteerTeam)
+		teamGroup.DELETE("/:id", adminHandlers.AdminDeleteVolunteerTeam)
+		teamGroup.POST("/:id/members", adminHandlers.AdminAddVolunteerTeamMember)
+		teamGroup.DELETE("/:id/members/:userId", adminHandlers.AdminRemoveVolunteerTeamMember)
+	}
+}
+
+// setupKudosModeration configures volunteer peer recognition moderation endpoints
+func setupKudosModeration(group *gin.RouterGroup) {
+	kudosGroup := group.Group("/kudos")
+	{
+		kudosGroup.GET("", adminHandlers.AdminListKudos)
+		kudosGroup.POST("", adminHandlers.AdminSendKudos)
+		kudosGroup.GET("/volunteer-of-the-month", adminHandlers.AdminGetVolunteerOfTheMonth)
+		kudosGroup.GET("/reports", adminHandlers.AdminListKudosReports)
+		kudosGroup.POST("/reports/:id/resolve", adminHandlers.AdminResolveKudosReport)
+		kudosGroup.POST("/:id/approve", adminHandlers.AdminApproveKudos)
+		kudosGroup.POST("/:id/reject", adminHandlers.AdminRejectKudos)
+	}
+}
+
+// setupServiceTypeManagement configures configurable visitor service endpoints
+func setupServiceTypeManagement(group *gin.RouterGroup) {
+	serviceTypeGroup := group.Group("/service-types")
+	{
+		serviceTypeGroup.GET("", adminHandlers.AdminListServiceTypes)
+		serviceTypeGroup.POST("", adminHandlers.AdminCreateServiceType)
+		serviceTypeGroup.PUT("/:id", adminHandlers.AdminUpdateServiceType)
+		serviceTypeGroup.DELETE("/:id", adminHandlers.AdminDeactivateServiceType)
+		serviceTypeGroup.GET("/:id/analytics", adminHandlers.AdminGetServiceTypeAnalytics)
+
+		// Extra questions asked on help requests for the service
+		serviceTypeGroup.GET("/:id/form", adminHandlers.AdminGetServiceTypeForm)
+		serviceTypeGroup.PUT("/:id/form", adminHandlers.AdminSaveServiceTypeForm)
+	}
+}
+
 // setupShiftManagement configures shift management endpoints
 func setupShiftManagement(group *gin.RouterGroup) {
 	shiftGroup := group.Group("/shifts")
@@ -168,10 +263,37 @@ func setupShiftManagement(group *gin.RouterGroup) {
 		shiftGroup.PUT("/:id", volunteerHandlers.UpdateShift)
 		shiftGroup.DELETE("/:id", volunteerHandlers.DeleteShift)
 
+		// Recurring shifts: edit this shift, this and future shifts, or the whole series
+		shiftGroup.POST("/series", adminHandlers.AdminCreateShiftSeries)
+		shiftGroup.GET("/series/:id", adminHandlers.AdminGetShiftSeries)
+		shiftGroup.PUT("/:id/series-edit", adminHandlers.AdminEditShiftInSeries)
+
 		// Advanced shift management
 		shiftGroup.POST("/reassign", adminHandlers.AdminReassignShift)
+		shiftGroup.POST("/bulk-cancel", adminHandlers.AdminBulkCancelShifts)
+
+		// Lift sharing oversight
+		shiftGroup.GET("/:id/carpool", adminHandlers.GetShiftCarpoolOverview)
+
+		// Rotating check-in codes for venues without QR scanners
+		shiftGroup.GET("/:id/check-in-code", adminHandlers.GetShiftCheckInCode)
+		shiftGroup.POST("/:id/check-in", adminHandlers.CheckInVolunteerToShift)
+
+		// Travel times between locations used by shift clash checks
+		shiftGroup.GET("/travel-times", adminHandlers.ListShiftTravelTimes)
+		shiftGroup.PUT("/travel-times", adminHandlers.SetShiftTravelTime)
+		shiftGroup.DELETE("/travel-times/:id", adminHandlers.DeleteShiftTravelTime)
+
+		// Training volunteers need before they can see or take shifts with a role
+		shiftGroup.GET("/training-requirements", volunteerHandlers.GetShiftRoleTrainingRequirements)
+		shiftGroup.PUT("/training-requirements", volunteerHandlers.SetShiftRoleTrainingRequirements)
+
+		// Volunteer feedback on how shifts went
+		shiftGroup.GET("/feedback", adminHandlers.AdminListShiftFeedback)
 	}
 
+	group.POST("/carpool/matches/:id/cancel", adminHandlers.CancelCarpoolMatch)
+
 	// Volunteer shift assignment
 	volunteerShiftGroup := group.Group("/volunteers/shifts")
 	{
@@ -185,9 +307,70 @@ func setupSystemManagement(group *gin.RouterGroup) {
 	systemGroup := group.Group("/system")
 	{
 		systemGroup.GET("/health", adminHandlers.AdminSystemHealth)
+
+		// Public status page incidents
+		systemGroup.GET("/incidents", adminHandlers.ListStatusIncidents)
+		systemGroup.POST("/incidents", adminHandlers.CreateStatusIncident)
+		systemGroup.PUT("/incidents/:id", adminHandlers.UpdateStatusIncident)
+		systemGroup.POST("/incidents/:id/resolve", adminHandlers.ResolveStatusIncident)
+
+		// External sign-in providers
+		systemGroup.GET("/auth-providers", adminHandlers.ListAuthProviders)
+		systemGroup.POST("/auth-providers", adminHandlers.CreateAuthProvider)
+		systemGroup.PUT("/auth-providers/:id", adminHandlers.UpdateAuthProvider)
+		systemGroup.DELETE("/auth-providers/:id", adminHandlers.DeleteAuthProvider)
+
+		// Cache inspection and purging
+		systemGroup.GET("/cache", adminHandlers.AdminGetCacheStatus)
+		systemGroup.GET("/cache/keys", adminHandlers.AdminListCacheKeys)
+		systemGroup.GET("/cache/key", adminHandlers.AdminGetCacheKey)
+		systemGroup.POST("/cache/purge", adminHandlers.AdminPurgeCache)
+
+		// Partner API keys and their usage
+		systemGroup.GET("/api-keys", adminHandlers.ListAPIKeys)
+		systemGroup.POST("/api-keys", adminHandlers.CreateAPIKey)
+		systemGroup.GET("/api-keys/:id/usage", adminHandlers.GetAPIKeyUsage)
+		systemGroup.DELETE("/api-keys/:id", adminHandlers.RevokeAPIKey)
+
+		// Background jobs that failed every retry
+		systemGroup.GET("/jobs/dead-letters", adminHandlers.AdminListDeadLetters)
+		systemGroup.POST("/jobs/dead-letters/:id/retry", adminHandlers.AdminRetryDeadLetter)
+		systemGroup.DELETE("/jobs/dead-letters/:id", adminHandlers.AdminDiscardDeadLetter)
 	}
 
-	group.GET("/alerts", adminHandlers.AdminGetSystemAlerts)
+	alertGroup := group.Group("/alerts")
+	{
+		alertGroup.GET("", adminHandlers.AdminGetSystemAlerts)
+		alertGroup.POST("", adminHandlers.AdminCreateSystemAlert)
+		alertGroup.POST("/evaluate", adminHandlers.AdminEvaluateAlerts)
+		alertGroup.GET("/:id", adminHandlers.AdminGetSystemAlert)
+		alertGroup.POST("/:id/acknowledge", adminHandlers.AdminAcknowledgeAlert)
+		alertGroup.POST("/:id/snooze", adminHandlers.AdminSnoozeAlert)
+		alertGroup.POST("/:id/resolve", adminHandlers.AdminResolveAlert)
+		alertGroup.DELETE("/:id", adminHandlers.AdminDeleteAlert)
+	}
+}
+
+// setupSettings configures organisation settings endpoints
+func setupSettings(group *gin.RouterGroup) {
+	settingsGroup := group.Group("/settings")
+	{
+		// Letterhead for emails, PDFs, tickets and certificates
+		settingsGroup.GET("/branding", adminHandlers.AdminListBranding)
+		settingsGroup.PUT("/branding", adminHandlers.AdminSaveBranding)
+		settingsGroup.DELETE("/branding", adminHandlers.AdminDeleteBranding)
+		settingsGroup.PUT("/branding/logo", adminHandlers.AdminUploadBrandingLogo)
+		settingsGroup.DELETE("/branding/logo", adminHandlers.AdminDeleteBrandingLogo)
+		settingsGroup.GET("/branding/preview/email", adminHandlers.AdminPreviewBrandingEmail)
+		settingsGroup.GET("/branding/preview/pdf", adminHandlers.AdminPreviewBrandingPDF)
+
+		// Closure periods: auto-replies while closed and a queue for reopening
+		settingsGroup.GET("/closures", adminHandlers.AdminListServiceClosures)
+		settingsGroup.POST("/closures", adminHandlers.AdminCreateServiceClosure)
+		settingsGroup.PUT("/closures/:id", adminHandlers.AdminUpdateServiceClosure)
+		settingsGroup.POST("/closures/:id/cancel", adminHandlers.AdminCancelServiceClosure)
+		settingsGroup.GET("/closures/:id/queue", adminHandlers.AdminGetServiceClosureQueue)
+	}
 }
 
 // ================================================================
@@ -204,6 +387,36 @@ func setupAnalytics(group *gin.RouterGroup) {
 		analyticsGroup.GET("/donation-impact", adminHandlers.GetDonationImpact)
 		analyticsGroup.GET("/volunteer-performance", adminHandlers.GetVolunteerPerformance)
 		analyticsGroup.GET("/service-efficiency", adminHandlers.GetServiceEfficiency)
+
+		// Anonymized event log export to external analytics
+		analyticsGroup.GET("/export/status", adminHandlers.GetAnalyticsExportStatus)
+		analyticsGroup.GET("/export/batches", adminHandlers.ListAnalyticsExportBatches)
+		analyticsGroup.GET("/export/schemas", adminHandlers.GetAnalyticsExportSchemas)
+		analyticsGroup.POST("/export/run", adminHandlers.RunAnalyticsExport)
+		analyticsGroup.POST("/export/backfill", adminHandlers.BackfillAnalyticsEvents)
+
+		// Anonymised weekly service demand datasets for researchers and the council
+		analyticsGroup.GET("/research-datasets", adminHandlers.AdminListResearchDatasets)
+		analyticsGroup.POST("/research-datasets", adminHandlers.AdminGenerateResearchDataset)
+		analyticsGroup.GET("/research-datasets/schema", adminHandlers.AdminGetResearchDatasetSchema)
+		analyticsGroup.GET("/research-datasets/wards", adminHandlers.AdminListPostcodeWards)
+		analyticsGroup.PUT("/research-datasets/wards", adminHandlers.AdminSetPostcodeWards)
+		analyticsGroup.GET("/research-datasets/:id/download", adminHandlers.AdminDownloadResearchDataset)
+
+		// Whether some visitors systematically wait longer, and sudden wait spikes
+		analyticsGroup.GET("/queue-fairness", adminHandlers.AdminGetQueueFairness)
+		analyticsGroup.GET("/queue-fairness/anomalies", adminHandlers.AdminListQueueWaitAnomalies)
+		analyticsGroup.POST("/queue-fairness/anomalies/:id/acknowledge", adminHandlers.AdminAcknowledgeQueueWaitAnomaly)
+
+		// Daily KPIs against their learned normal ranges, and the days that fell outside
+		analyticsGroup.GET("/kpi-ranges", adminHandlers.AdminGetKPIRanges)
+		analyticsGroup.GET("/kpi-anomalies", adminHandlers.AdminListKPIAnomalies)
+
+		// Visitor document re-verification completion
+		analyticsGroup.GET("/reverification", adminHandlers.AdminGetReverificationAnalytics)
+
+		// "What if" capacity planning against recent queue history
+		analyticsGroup.POST("/capacity-simulation", adminHandlers.AdminSimulateCapacity)
 	}
 }
 
@@ -217,6 +430,39 @@ func setupReports(group *gin.RouterGroup) {
 		reportsGroup.GET("/feedback", adminHandlers.AdminGetFeedbackReports)
 		reportsGroup.GET("/documents", adminHandlers.AdminGetDocumentReports)
 		reportsGroup.POST("/custom", adminHandlers.AdminGenerateCustomReport)
+
+		// Estimated value of services delivered, for funders
+		reportsGroup.GET("/impact", adminHandlers.AdminGetImpactReport)
+
+		// Help request processing times against SLA targets
+		reportsGroup.GET("/sla", adminHandlers.AdminGetRequestSLA)
+		reportsGroup.GET("/sla/trend", adminHandlers.AdminGetRequestSLATrend)
+		reportsGroup.PUT("/sla/targets", adminHandlers.AdminUpdateRequestSLATargets)
+
+		// Weekly queue fairness reports for trustees
+		reportsGroup.GET("/queue-fairness", adminHandlers.AdminListQueueFairnessReports)
+		reportsGroup.GET("/queue-fairness/:week", adminHandlers.AdminGetQueueFairnessReport)
+		reportsGroup.POST("/queue-fairness", adminHandlers.AdminGenerateQueueFairnessReport)
+
+		// Weekly summaries of who changed capacity, roles, documents and tickets
+		reportsGroup.GET("/admin-changes", adminHandlers.AdminListChangeReports)
+		reportsGroup.GET("/admin-changes/:week", adminHandlers.AdminGetChangeReport)
+		reportsGroup.POST("/admin-changes", adminHandlers.AdminGenerateChangeReport)
+
+		// Shift quality from volunteer feedback, by location and role
+		reportsGroup.GET("/shift-quality", adminHandlers.AdminGetShiftQualityReport)
+	}
+
+	impactGroup := group.Group("/impact")
+	{
+		impactGroup.GET("/unit-costs", adminHandlers.AdminListServiceUnitCosts)
+		impactGroup.POST("/unit-costs", adminHandlers.AdminCreateServiceUnitCost)
+		impactGroup.PUT("/unit-costs/:id", adminHandlers.AdminUpdateServiceUnitCost)
+		impactGroup.DELETE("/unit-costs/:id", adminHandlers.AdminDeleteServiceUnitCost)
+		impactGroup.GET("/environmental", adminHandlers.AdminGetEnvironmentalImpact)
+		impactGroup.GET("/environmental-factors", adminHandlers.AdminListEnvironmentalFactors)
+		impactGroup.POST("/environmental-factors", adminHandlers.AdminCreateEnvironmentalFactor)
+		impactGroup.DELETE("/environmental-factors/:id", adminHandlers.AdminDeleteEnvironmentalFactor)
 	}
 }
 
@@ -231,6 +477,11 @@ func setupEmergencyManagement(group *gin.RouterGroup) {
 		emergencyGroup.POST("/incidents", systemHandlers.CreateIncident)
 		emergencyGroup.GET("/alerts", systemHandlers.GetEmergencyAlerts)
 		emergencyGroup.POST("/alerts", systemHandlers.SendEmergencyAlert)
+		emergencyGroup.GET("/shifts", volunteerHandlers.GetEmergencyShifts)
+		emergencyGroup.GET("/callouts", adminHandlers.AdminListEmergencyCallouts)
+		emergencyGroup.POST("/callouts", adminHandlers.AdminCreateEmergencyCallout)
+		emergencyGroup.GET("/callouts/:id", adminHandlers.AdminGetEmergencyCallout)
+		emergencyGroup.POST("/callouts/:id/cancel", adminHandlers.AdminCancelEmergencyCallout)
 	}
 }
 
@@ -243,6 +494,49 @@ func setupCommunications(group *gin.RouterGroup) {
 		commGroup.POST("/targeted", systemHandlers.SendTargetedMessage)
 		commGroup.GET("/messages", systemHandlers.GetCommunicationMessages)
 
+		// Notification spend and SMS budget
+		commGroup.GET("/spend", adminHandlers.GetNotificationSpend)
+		commGroup.PUT("/sms-budget", adminHandlers.UpdateSMSBudget)
+
+		// Email replies to help requests and feedback
+		commGroup.GET("/inbound-replies", adminHandlers.ListInboundReplies)
+
+		// Bulk email and SMS campaigns
+		campaignGroup := commGroup.Group("/campaigns")
+		{
+			campaignGroup.GET("", adminHandlers.ListCampaigns)
+			campaignGroup.POST("", adminHandlers.CreateCampaign)
+			campaignGroup.POST("/preview", adminHandlers.PreviewCampaignAudience)
+			campaignGroup.GET("/:id", adminHandlers.GetCampaign)
+			campaignGroup.PUT("/:id", adminHandlers.UpdateCampaign)
+			campaignGroup.GET("/:id/preview", adminHandlers.PreviewCampaign)
+			campaignGroup.POST("/:id/schedule", adminHandlers.ScheduleCampaign)
+			campaignGroup.POST("/:id/cancel", adminHandlers.CancelCampaign)
+			campaignGroup.GET("/:id/stats", adminHandlers.GetCampaignStats)
+			campaignGroup.GET("/:id/translations", adminHandlers.GetCampaignTranslations)
+			campaignGroup.PUT("/:id/translations/:language", adminHandlers.SaveCampaignTranslation)
+			campaignGroup.DELETE("/:id/translations/:language", adminHandlers.DeleteCampaignTranslation)
+		}
+
+		// Notification template translations and coverage of the languages in use
+		translationGroup := commGroup.Group("/translations")
+		{
+			translationGroup.GET("", adminHandlers.AdminListTemplateTranslations)
+			translationGroup.GET("/coverage", adminHandlers.AdminGetTranslationCoverage)
+			translationGroup.PUT("/:template/:language", adminHandlers.AdminSaveTemplateTranslation)
+			translationGroup.DELETE("/:template/:language", adminHandlers.AdminDeleteTemplateTranslation)
+		}
+
+		// Transactional email templates: edits replace the built-in defaults
+		emailTemplateGroup := commGroup.Group("/email-templates")
+		{
+			emailTemplateGroup.GET("", adminHandlers.AdminListEmailTemplates)
+			emailTemplateGroup.GET("/:template", adminHandlers.AdminGetEmailTemplate)
+			emailTemplateGroup.PUT("/:template", adminHandlers.AdminSaveEmailTemplate)
+			emailTemplateGroup.DELETE("/:template", adminHandlers.AdminResetEmailTemplate)
+			emailTemplateGroup.POST("/:template/preview", adminHandlers.AdminPreviewEmailTemplate)
+		}
+
 		// Template management
 		templateGroup := commGroup.Group("/templates")
 		{
@@ -252,6 +546,15 @@ func setupCommunications(group *gin.RouterGroup) {
 			templateGroup.DELETE("/:id", systemHandlers.DeleteMessageTemplate)
 		}
 	}
+
+	// System-wide announcement banners
+	announcementGroup := group.Group("/announcements")
+	{
+		announcementGroup.GET("", adminHandlers.AdminListAnnouncements)
+		announcementGroup.POST("", adminHandlers.AdminCreateAnnouncement)
+		announcementGroup.PUT("/:id", adminHandlers.AdminUpdateAnnouncement)
+		announcementGroup.DELETE("/:id", adminHandlers.AdminDeleteAnnouncement)
+	}
 }
 
 // setupBulkOperations configures bulk operation endpoints
@@ -261,6 +564,15 @@ func setupBulkOperations(group *gin.RouterGroup) {
 		bulkGroup.POST("/users", systemHandlers.ImportUsersFromCSV)
 		bulkGroup.POST("/donations", systemHandlers.ImportDonationsFromCSV)
 		bulkGroup.POST("/help-requests", systemHandlers.ImportHelpRequestsFromCSV)
+
+		// Historic spreadsheet imports, staged for review before they are committed
+		bulkGroup.GET("/historic", adminHandlers.ListHistoricImports)
+		bulkGroup.POST("/historic", adminHandlers.StageHistoricImport)
+		bulkGroup.GET("/historic/templates/:kind", adminHandlers.DownloadHistoricImportTemplate)
+		bulkGroup.GET("/historic/:id", adminHandlers.GetHistoricImport)
+		bulkGroup.POST("/historic/:id/commit", adminHandlers.CommitHistoricImport)
+		bulkGroup.POST("/historic/:id/rollback", adminHandlers.RollbackHistoricImport)
+		bulkGroup.DELETE("/historic/:id", adminHandlers.DiscardHistoricImport)
 	}
 
 	// Bulk operations placeholder
@@ -278,6 +590,13 @@ func setupFeedbackManagement(group *gin.RouterGroup) {
 		feedbackGroup.GET("", systemHandlers.GetAllFeedback)
 		feedbackGroup.PUT("/:feedback_id/status", systemHandlers.UpdateFeedbackReviewStatus)
 		feedbackGroup.GET("/analytics", systemHandlers.GetFeedbackAnalytics)
+		feedbackGroup.GET("/:feedback_id/replies", adminHandlers.ListFeedbackReplies)
+
+		// Exit kiosk quick ratings
+		feedbackGroup.GET("/kiosk/satisfaction", adminHandlers.GetKioskSatisfaction)
+		feedbackGroup.GET("/kiosk/devices", adminHandlers.ListKioskDevices)
+		feedbackGroup.POST("/kiosk/devices", adminHandlers.CreateKioskDevice)
+		feedbackGroup.POST("/kiosk/devices/:id/revoke", adminHandlers.RevokeKioskDevice)
 	}
 }
 
@@ -287,6 +606,51 @@ func setupQueueManagement(group *gin.RouterGroup) {
 	{
 		queueGroup.GET("", adminHandlers.GetQueue)
 		queueGroup.POST("/call-next", adminHandlers.CallNextVisitor)
+
+		// Visitors who missed their call and are waiting to be called again
+		queueGroup.GET("/recall-pool", adminHandlers.ListRecallPool)
+		queueGroup.POST("/recall-pool/:id/recall", adminHandlers.RecallQueueEntry)
+
+		// Service times against each service type's targets
+		queueGroup.GET("/service-times/live", adminHandlers.GetLiveServiceTimes)
+		queueGroup.GET("/service-times/stats", adminHandlers.GetServiceTimeStats)
+		queueGroup.GET("/service-times/alerts", adminHandlers.ListServiceTimeAlerts)
+		queueGroup.POST("/service-times/alerts/:id/acknowledge", adminHandlers.AcknowledgeServiceTimeAlert)
+	}
+}
+
+// setupWalkInManagement configures front desk walk-in registration endpoints
+func setupWalkInManagement(group *gin.RouterGroup) {
+	walkInGroup := group.Group("/walk-ins")
+	{
+		walkInGroup.GET("", adminHandlers.ListWalkIns)
+		walkInGroup.POST("", adminHandlers.RegisterWalkIn)
+		walkInGroup.GET("/eligibility", adminHandlers.CheckWalkInEligibility)
+		walkInGroup.GET("/:id/duplicates", adminHandlers.GetWalkInDuplicates)
+		walkInGroup.POST("/:id/merge", adminHandlers.MergeWalkIn)
+	}
+
+	standbyGroup := group.Group("/standby")
+	{
+		standbyGroup.GET("", adminHandlers.ListStandby)
+		standbyGroup.GET("/conversion", adminHandlers.GetStandbyConversion)
+		standbyGroup.POST("/release", adminHandlers.ReleaseStandbyCapacity)
+		standbyGroup.POST("/:id/claim", adminHandlers.ClaimStandbyForVisitor)
+	}
+}
+
+// setupAppointmentManagement configures advice appointment slots, staff calendars and
+// attendance endpoints
+func setupAppointmentManagement(group *gin.RouterGroup) {
+	appointmentGroup := group.Group("/appointments")
+	{
+		appointmentGroup.GET("/calendar", adminHandlers.GetAppointmentCalendar)
+		appointmentGroup.GET("/no-shows", adminHandlers.GetAppointmentNoShows)
+		appointmentGroup.POST("/slots", adminHandlers.CreateAppointmentSlots)
+		appointmentGroup.POST("/slots/:id/cancel", adminHandlers.CancelAppointmentSlot)
+		appointmentGroup.POST("/:id/cancel", adminHandlers.CancelAppointmentForVisitor)
+		appointmentGroup.POST("/:id/attended", adminHandlers.MarkAppointmentAttended)
+		appointmentGroup.POST("/:id/no-show", adminHandlers.MarkAppointmentNoShow)
 	}
 }
 
@@ -295,8 +659,67 @@ func setupHelpRequestManagement(group *gin.RouterGroup) {
 	helpRequestGroup := group.Group("/help-requests")
 	{
 		helpRequestGroup.GET("", visitorHandlers.ListHelpRequests)
+		helpRequestGroup.GET("/export", systemHandlers.ExportHelpRequestsToCSV)
+
+		// Where visitors give up on the help request wizard
+		helpRequestGroup.GET("/wizard-funnel", adminHandlers.AdminGetHelpRequestWizardFunnel)
+
 		helpRequestGroup.GET("/:id", visitorHandlers.GetHelpRequestDetails)
 		helpRequestGroup.PUT("/:id", visitorHandlers.UpdateHelpRequest)
+		helpRequestGroup.GET("/:id/replies", adminHandlers.ListHelpRequestReplies)
+		helpRequestGroup.POST("/:id/goods-weight", adminHandlers.AdminRecordVisitGoods)
+
+		// Daily ticket release; pass dry_run to preview the allocation
+		helpRequestGroup.POST("/ticket-release", adminHandlers.AdminTicketRelease)
+
+		// Automatic ticket release, at 09:00 Tuesday to Thursday unless changed
+		helpRequestGroup.GET("/ticket-release/schedule", adminHandlers.AdminGetTicketReleaseSchedule)
+		helpRequestGroup.PUT("/ticket-release/schedule", adminHandlers.AdminUpdateTicketReleaseSchedule)
+		helpRequestGroup.GET("/ticket-release/schedule/runs", adminHandlers.AdminListScheduledReleaseRuns)
+
+		// Permanent record of each ticket release, hash-chained so edits show up
+		helpRequestGroup.GET("/release-runs", adminHandlers.AdminListReleaseRuns)
+		helpRequestGroup.GET("/release-runs/verify", adminHandlers.AdminVerifyReleaseRuns)
+		helpRequestGroup.GET("/release-runs/:id", adminHandlers.AdminGetReleaseRun)
+
+		// Rules that approve low-risk requests instantly, and sampled review of them
+		helpRequestGroup.GET("/auto-approval/rules", adminHandlers.ListAutoApprovalRules)
+		helpRequestGroup.POST("/auto-approval/rules", adminHandlers.CreateAutoApprovalRule)
+		helpRequestGroup.PUT("/auto-approval/rules/:id", adminHandlers.UpdateAutoApprovalRule)
+		helpRequestGroup.DELETE("/auto-approval/rules/:id", adminHandlers.DeleteAutoApprovalRule)
+		helpRequestGroup.GET("/auto-approvals", adminHandlers.ListAutoApprovals)
+		helpRequestGroup.POST("/auto-approvals/:id/review", adminHandlers.ReviewAutoApproval)
+
+		// Optimized assignment of approved requests to visit slots
+		helpRequestGroup.POST("/slot-plan/preview", adminHandlers.AdminPreviewSlotPlan)
+		helpRequestGroup.POST("/slot-plan/apply", adminHandlers.AdminApplySlotPlan)
+
+		// Interpreters booked for visitors who asked for one
+		helpRequestGroup.GET("/interpreters", adminHandlers.ListInterpreterBookings)
+		helpRequestGroup.PUT("/interpreters/:id", adminHandlers.UpdateInterpreterBooking)
+		helpRequestGroup.POST("/interpreters/:id/send", adminHandlers.SendInterpreterBooking)
+	}
+
+	// Moving daily capacity between oversubscribed and underused categories, and
+	// emergency overrides
+	capacityGroup := group.Group("/capacity")
+	{
+		capacityGroup.GET("/rebalance", adminHandlers.GetCapacityRebalanceSuggestion)
+		capacityGroup.POST("/rebalance", adminHandlers.ApplyCapacityTransfer)
+		capacityGroup.POST("/interruptions", adminHandlers.AdminRebookServiceInterruption) // Close a day and rebook its visitors
+
+		// Time-boxed extra slots above a day's capacity, approved by a second manager
+		capacityGroup.GET("/overrides", adminHandlers.ListCapacityOverrides)
+		capacityGroup.POST("/overrides", adminHandlers.RequestCapacityOverride)
+		capacityGroup.POST("/overrides/:id/approve", adminHandlers.ApproveCapacityOverride)
+		capacityGroup.POST("/overrides/:id/reject", adminHandlers.RejectCapacityOverride)
+		capacityGroup.POST("/overrides/:id/cancel", adminHandlers.CancelCapacityOverride)
+
+		// When visitors can book each service ahead of a visit day
+		capacityGroup.GET("/booking-windows", adminHandlers.ListBookingWindows)
+		capacityGroup.POST("/booking-windows", adminHandlers.CreateBookingWindow)
+		capacityGroup.PUT("/booking-windows/:id", adminHandlers.UpdateBookingWindow)
+		capacityGroup.DELETE("/booking-windows/:id", adminHandlers.DeleteBookingWindow)
 	}
 }
 
@@ -307,6 +730,37 @@ func setupDocumentManagement(group *gin.RouterGroup) {
 		documentGroup.GET("", systemHandlers.AdminGetDocuments)
 		documentGroup.GET("/pending", systemHandlers.AdminGetPendingDocuments)
 		documentGroup.GET("/stats", systemHandlers.AdminGetDocumentStats)
+		documentGroup.POST("/bulk-decision", adminHandlers.AdminBulkDocumentDecision)
+
+		// Managed rejection reasons with visitor-friendly explanations
+		documentGroup.GET("/rejection-reasons", adminHandlers.AdminListRejectionReasons)
+		documentGroup.GET("/rejection-reasons/analytics", adminHandlers.AdminGetRejectionReasonAnalytics)
+		documentGroup.POST("/rejection-reasons", adminHandlers.AdminCreateRejectionReason)
+		documentGroup.PUT("/rejection-reasons/:id", adminHandlers.AdminUpdateRejectionReason)
+		documentGroup.DELETE("/rejection-reasons/:id", adminHandlers.AdminDeactivateRejectionReason)
+
+		// Documents visitors send by email to their private upload address
+		documentGroup.GET("/inbound", adminHandlers.ListInboundDocumentMessages)
+		documentGroup.GET("/inboxes/:userId", adminHandlers.GetVisitorDocumentInbox)
+		documentGroup.POST("/inboxes/:userId/rotate", adminHandlers.RotateVisitorDocumentInbox)
+		documentGroup.PUT("/inboxes/:userId", adminHandlers.UpdateVisitorDocumentInbox)
+
+		// Periodic re-verification of long-term visitors
+		documentGroup.GET("/reverification", adminHandlers.AdminListReverifications)
+		documentGroup.GET("/reverification/policy", adminHandlers.AdminGetReverificationPolicy)
+		documentGroup.PUT("/reverification/policy", adminHandlers.AdminUpdateReverificationPolicy)
+	}
+
+	// Volunteer documents: verification queue, expiry and shift role requirements
+	volunteerDocumentGroup := documentGroup.Group("/volunteers")
+	{
+		volunteerDocumentGroup.GET("", volunteerHandlers.GetAllVolunteerDocuments)
+		volunteerDocumentGroup.GET("/queue", volunteerHandlers.GetVolunteerDocumentQueue)
+		volunteerDocumentGroup.GET("/expiring", volunteerHandlers.GetExpiringVolunteerDocuments)
+		volunteerDocumentGroup.POST("/:documentId/verify", volunteerHandlers.VerifyVolunteerDocument)
+		volunteerDocumentGroup.POST("/:documentId/reject", volunteerHandlers.RejectVolunteerDocument)
+		volunteerDocumentGroup.GET("/requirements", volunteerHandlers.GetShiftRoleRequirements)
+		volunteerDocumentGroup.PUT("/requirements", volunteerHandlers.SetShiftRoleRequirements)
 	}
 }
 
@@ -316,6 +770,144 @@ func setupDonationManagement(group *gin.RouterGroup) {
 	{
 		donationGroup.GET("", adminHandlers.AdminListDonations)
 		donationGroup.GET("/analytics", adminHandlers.AdminGetDonationAnalytics)
+		donationGroup.GET("/acquisition", adminHandlers.AdminGetDonorAcquisitionReport)
+		donationGroup.GET("/export", systemHandlers.ExportDonationsToCSV)
+		donationGroup.PUT("/:id/status", adminHandlers.AdminUpdateDonationStatus)
+		donationGroup.POST("/:id/refund", adminHandlers.AdminRefundDonation)
+
+		// Goods donations counted into inventory
+		donationGroup.GET("/:id/intake", adminHandlers.AdminGetDonationIntake)
+		donationGroup.POST("/:id/intake", adminHandlers.AdminReceiveDonationGoods)
+		donationGroup.GET("/intake/discrepancies", adminHandlers.AdminListIntakeDiscrepancies)
+
+		// Numbered receipt register; receipts are emailed when a donation is received
+		donationGroup.GET("/receipts", adminHandlers.AdminListDonationReceipts)
+		donationGroup.GET("/:id/receipt", adminHandlers.AdminDownloadDonationReceipt)
+		donationGroup.POST("/:id/receipt/send", adminHandlers.AdminSendDonationReceipt)
+		donationGroup.GET("/refunds", adminHandlers.AdminListDonationRefunds)
+		donationGroup.GET("/refunds/export", adminHandlers.AdminExportDonationRefunds)
+		donationGroup.GET("/gift-aid/adjustments", adminHandlers.AdminListGiftAidAdjustments)
+		donationGroup.POST("/gift-aid/adjustments/apply", adminHandlers.AdminApplyGiftAidAdjustments)
+		donationGroup.GET("/gift-aid/declarations", adminHandlers.AdminListGiftAidDeclarations)
+		donationGroup.POST("/gift-aid/declarations", adminHandlers.AdminRecordGiftAidDeclaration)
+		donationGroup.DELETE("/gift-aid/declarations/users/:userId", adminHandlers.AdminCancelGiftAidDeclaration)
+		donationGroup.GET("/gift-aid/schedule", adminHandlers.AdminExportGiftAidSchedule)
+	}
+}
+
+// setupPledgeManagement configures business pledge and invoicing endpoints
+func setupPledgeManagement(group *gin.RouterGroup) {
+	pledgeGroup := group.Group("/pledges")
+	{
+		pledgeGroup.GET("", adminHandlers.AdminListPledges)
+		pledgeGroup.POST("", adminHandlers.AdminCreatePledge)
+		pledgeGroup.GET("/aging", adminHandlers.AdminGetPledgeAgingReport)
+		pledgeGroup.POST("/payments/import", adminHandlers.AdminImportPledgePayments)
+		pledgeGroup.GET("/:id", adminHandlers.AdminGetPledge)
+		pledgeGroup.POST("/:id/invoice", adminHandlers.AdminIssuePledgeInvoice)
+		pledgeGroup.GET("/:id/invoice", adminHandlers.AdminDownloadPledgeInvoice)
+		pledgeGroup.POST("/:id/payments", adminHandlers.AdminRecordPledgePayment)
+		pledgeGroup.POST("/:id/cancel", adminHandlers.AdminCancelPledge)
+	}
+}
+
+// setupDonationDrives configures team goods-donation drive endpoints
+func setupDonationDrives(group *gin.RouterGroup) {
+	driveGroup := group.Group("/drives")
+	{
+		driveGroup.GET("", adminHandlers.AdminListDonationDrives)
+		driveGroup.POST("", adminHandlers.AdminCreateDonationDrive)
+		driveGroup.PUT("/:id", adminHandlers.AdminUpdateDonationDrive)
+		driveGroup.GET("/:id/teams", adminHandlers.AdminListDriveTeams)
+		driveGroup.POST("/:id/teams", adminHandlers.AdminCreateDriveTeam)
+		driveGroup.GET("/:id/teams/:teamId/certificates", adminHandlers.AdminDownloadDriveCertificates)
+		driveGroup.GET("/:id/dropoffs", adminHandlers.AdminListDriveDropoffs)
+		driveGroup.POST("/:id/dropoffs", adminHandlers.AdminRecordDriveDropoff)
+		driveGroup.GET("/:id/summary", adminHandlers.AdminGetDonationDriveSummary)
+	}
+}
+
+// setupBankReconciliation configures bank statement import and matching endpoints
+func setupBankReconciliation(group *gin.RouterGroup) {
+	bankGroup := group.Group("/bank-reconciliation")
+	{
+		bankGroup.POST("/imports", adminHandlers.AdminImportBankStatement)
+		bankGroup.GET("/imports", adminHandlers.AdminListBankImports)
+		bankGroup.GET("/imports/:id", adminHandlers.AdminGetBankImport)
+		bankGroup.GET("/transactions", adminHandlers.AdminListBankTransactions)
+		bankGroup.GET("/transactions/:id/candidates", adminHandlers.AdminGetBankTransactionCandidates)
+		bankGroup.POST("/transactions/:id/match", adminHandlers.AdminMatchBankTransaction)
+		bankGroup.POST("/transactions/:id/create-donation", adminHandlers.AdminCreateDonationFromBankTransaction)
+		bankGroup.POST("/transactions/:id/ignore", adminHandlers.AdminIgnoreBankTransaction)
+	}
+}
+
+// setupSupplierOrdering configures supplier, low stock reorder and delivery endpoints
+func setupSupplierOrdering(group *gin.RouterGroup) {
+	supplierGroup := group.Group("/suppliers")
+	{
+		supplierGroup.GET("", adminHandlers.ListSuppliers)
+		supplierGroup.POST("", adminHandlers.CreateSupplier)
+		supplierGroup.GET("/:id", adminHandlers.GetSupplier)
+		supplierGroup.PUT("/:id", adminHandlers.UpdateSupplier)
+		supplierGroup.DELETE("/:id", adminHandlers.DeleteSupplier)
+		supplierGroup.POST("/:id/rotate-secret", adminHandlers.RotateSupplierSecret)
+	}
+
+	orderGroup := group.Group("/supplier-orders")
+	{
+		orderGroup.GET("", adminHandlers.ListSupplierOrders)
+		orderGroup.POST("", adminHandlers.CreateSupplierOrder)
+		orderGroup.POST("/reorder-check", adminHandlers.RunReorderCheck)
+		orderGroup.GET("/:id", adminHandlers.GetSupplierOrder)
+		orderGroup.POST("/:id/submit", adminHandlers.SubmitSupplierOrder)
+		orderGroup.PUT("/:id/status", adminHandlers.UpdateSupplierOrderStatus)
+		orderGroup.POST("/:id/receive", adminHandlers.ReceiveSupplierDelivery)
+	}
+
+	group.PUT("/urgent-needs/:id/reorder", adminHandlers.UpdateReorderSettings)
+}
+
+// setupInventory configures stock categories, items, movements, shortages and
+// barcode intake
+func setupInventory(group *gin.RouterGroup) {
+	inventoryGroup := group.Group("/inventory")
+	{
+		inventoryGroup.GET("/categories", inventoryHandlers.ListCategories)
+		inventoryGroup.POST("/categories", inventoryHandlers.CreateCategory)
+		inventoryGroup.PUT("/categories/:id", inventoryHandlers.UpdateCategory)
+		inventoryGroup.DELETE("/categories/:id", inventoryHandlers.DeleteCategory)
+
+		inventoryGroup.GET("/items", inventoryHandlers.ListItems)
+		inventoryGroup.POST("/items", inventoryHandlers.CreateItem)
+		inventoryGroup.GET("/items/:id", inventoryHandlers.GetItem)
+		inventoryGroup.PUT("/items/:id", inventoryHandlers.UpdateItem)
+		inventoryGroup.DELETE("/items/:id", inventoryHandlers.DeleteItem)
+		inventoryGroup.GET("/items/:id/movements", inventoryHandlers.ListMovements)
+		inventoryGroup.POST("/items/:id/movements", inventoryHandlers.RecordMovement)
+
+		inventoryGroup.GET("/shortages", inventoryHandlers.ListShortages)
+
+		// Barcode scanning at intake
+		inventoryGroup.POST("/intake/scan", inventoryHandlers.ScanIntake)
+		inventoryGroup.GET("/barcodes/:code", inventoryHandlers.ResolveBarcode)
+		inventoryGroup.PUT("/barcodes/:code", inventoryHandlers.LinkBarcode)
+	}
+}
+
+// setupDayOperations configures the one-click day open and close runbooks
+func setupDayOperations(group *gin.RouterGroup) {
+	dayGroup := group.Group("/operations/day")
+	{
+		dayGroup.GET("", adminHandlers.AdminListDayOperations)
+		dayGroup.GET("/:id", adminHandlers.AdminGetDayOperation)
+		dayGroup.POST("/open", adminHandlers.AdminOpenDay)
+		dayGroup.POST("/close", adminHandlers.AdminCloseDay)
+
+		// Mid-day changes, such as closing early, sent to today's ticket holders
+		dayGroup.GET("/broadcasts", adminHandlers.AdminListEmergencyBroadcasts)
+		dayGroup.GET("/broadcasts/:id", adminHandlers.AdminGetEmergencyBroadcast)
+		dayGroup.POST("/broadcasts", adminHandlers.AdminSendEmergencyBroadcast)
 	}
 }
 
@@ -328,8 +920,40 @@ func setupAuditLogs(group *gin.RouterGroup) {
 		auditGroup.GET("/analytics", systemHandlers.GetAuditLogAnalytics)
 	}
 
+	// Field-level change history for an entity
+	group.GET("/history/:entity/:id", adminHandlers.GetEntityChangeHistory)
+
 	// Legacy audit endpoint
 	group.GET("/audit", systemHandlers.ListAuditLogs)
 }
 
+// setupTags configures tags for visitors, volunteers, help requests and donations.
+// Scoped admins can read tags and tag reports; only full admins manage them.
+func setupTags(group *gin.RouterGroup) {
+	tagGroup := group.Group("/tags")
+	{
+		tagGroup.GET("", adminHandlers.AdminListTags)
+		tagGroup.POST("", adminHandlers.AdminCreateTag)
+		tagGroup.GET("/entities/:type/:entityId", adminHandlers.AdminGetEntityTags)
+		tagGroup.PUT("/:id", adminHandlers.AdminUpdateTag)
+		tagGroup.DELETE("/:id", adminHandlers.AdminDeleteTag)
+		tagGroup.GET("/:id/report", adminHandlers.AdminGetTagReport)
+		tagGroup.GET("/:id/assignments", adminHandlers.AdminListTagAssignments)
+		tagGroup.POST("/:id/assignments", adminHandlers.AdminAssignTag)
+		tagGroup.DELETE("/:id/assignments/:type/:entityId", adminHandlers.AdminUnassignTag)
+	}
+}
+
 // ================================================================
+
+// setupChaos configures failure injection endpoints. The segment is not mapped to an
+// admin module, so only full admins can use it.
+func setupChaos(group *gin.RouterGroup) {
+	chaosGroup := group.Group("/chaos")
+	{
+		chaosGroup.GET("/faults", adminHandlers.ListChaosFaults)
+		chaosGroup.POST("/faults", adminHandlers.CreateChaosFault)
+		chaosGroup.DELETE("/faults/:id", adminHandlers.DeleteChaosFault)
+		chaosGroup.DELETE("/faults", adminHandlers.ClearChaosFaults)
+	}
+}
@@ -15,9 +15,19 @@ func SetupAuthRoutes(r *gin.Engine) error {
 	authGroup := r.Group("/api/v1/auth")
 	{
 		// Core authentication
-		authGroup.POST("/register", middleware.AuthRateLimit(), auth.Register)
+		authGroup.POST("/register", middleware.RegisterRateLimit(), auth.Register)
 		authGroup.POST("/login", middleware.LoginRateLimit(), auth.Login)
+
+		// Phone-number registration and SMS one-time code login
+		authGroup.POST("/register/phone", middleware.RegisterRateLimit(), auth.RegisterWithPhone)
+		authGroup.POST("/otp/request", middleware.StrictRateLimit(), auth.RequestPhoneOTP)
+		authGroup.POST("/otp/verify", middleware.LoginRateLimit(), auth.VerifyPhoneOTP)
 		authGroup.POST("/refresh", auth.RefreshTokenHandler)
+
+		// Sign in with an external provider (staff and volunteers)
+		authGroup.GET("/oidc/providers", auth.ListOIDCProviders)
+		authGroup.GET("/oidc/:provider/start", middleware.AuthRateLimit(), auth.StartOIDCLogin)
+		authGroup.POST("/oidc/callback", middleware.LoginRateLimit(), auth.CompleteOIDCLogin)
 		authGroup.POST("/logout", middleware.Auth(), auth.Logout)
 		authGroup.GET("/validate-token", middleware.Auth(), auth.ValidateToken)
 
@@ -29,6 +39,10 @@ func SetupAuthRoutes(r *gin.Engine) error {
 		authGroup.POST("/forgot-password", middleware.StrictRateLimit(), auth.ForgotPassword)
 		authGroup.POST("/reset-password", middleware.AuthRateLimit(), auth.ResetPassword)
 
+		// Invitations for staff and admin accounts
+		authGroup.GET("/invitations/:token", middleware.AuthRateLimit(), auth.GetInvitation)
+		authGroup.POST("/invitations/accept", middleware.AuthRateLimit(), auth.AcceptInvitation)
+
 		// User profile access
 		authGroup.GET("/me", middleware.Auth(), auth.GetCurrentUser)
 
@@ -49,6 +63,9 @@ func SetupAuthRoutes(r *gin.Engine) error {
 		authGroup.POST("/consent", middleware.Auth(), middleware.AuthRateLimit(), privacy.UpdateConsent)
 	}
 
+	// Public signing keys for services that verify our tokens
+	r.GET("/.well-known/jwks.json", auth.JWKS)
+
 	// Legacy compatibility routes
 	setupLegacyAuthRoutes(r)
 
@@ -15,6 +15,10 @@ func SetupDonorRoutes(r *gin.Engine) {
 		publicDonation.POST("/donations", donorHandlers.CreateDonation)
 		publicDonation.GET("/donations/urgent", donorHandlers.ListUrgentNeeds)
 		publicDonation.GET("/users/:id/donations", donorHandlers.GetUserDonations)
+
+		// Welcome page linked from a new donor's welcome emails
+		publicDonation.GET("/donor-onboarding/:token", donorHandlers.GetDonorOnboardingByToken)
+		publicDonation.PUT("/donor-onboarding/:token", donorHandlers.UpdateDonorOnboardingByToken)
 	}
 
 	// Authenticated donor dashboard
@@ -23,9 +27,17 @@ func SetupDonorRoutes(r *gin.Engine) {
 	{
 		donorGroup.GET("/dashboard", donorHandlers.GetDonorDashboard)
 		donorGroup.GET("/history", donorHandlers.GetDonorHistory)
+		donorGroup.GET("/donations", donorHandlers.ListDonorDonations)
+		donorGroup.GET("/donations/:id/receipt", donorHandlers.DownloadDonorDonationReceipt)
 		donorGroup.GET("/impact", donorHandlers.GetDonorImpact)
 		donorGroup.GET("/recognition", donorHandlers.GetDonorRecognition)
 		donorGroup.GET("/profile", donorHandlers.GetDonorProfile)
 		donorGroup.GET("/urgent-needs", donorHandlers.GetDonorUrgentNeeds)
+		donorGroup.GET("/tax-summary", donorHandlers.GetDonorTaxSummary)
+		donorGroup.GET("/gift-aid", donorHandlers.GetDonorGiftAid)
+		donorGroup.POST("/gift-aid", donorHandlers.DeclareGiftAid)
+		donorGroup.DELETE("/gift-aid", donorHandlers.CancelGiftAid)
+		donorGroup.GET("/onboarding", donorHandlers.GetDonorOnboarding)
+		donorGroup.PUT("/onboarding", donorHandlers.UpdateDonorOnboarding)
 	}
 }
@@ -3,6 +3,7 @@ package routes
 import (
 	"net/http"
 
+	"github.com/geoo115/charity-management-system/internal/httpclient"
 	"github.com/geoo115/charity-management-system/internal/observability"
 	"github.com/geoo115/charity-management-system/internal/services"
 	"github.com/gin-gonic/gin"
@@ -26,6 +27,7 @@ func RegisterMetricsRoutes(router *gin.Engine) {
 		observability.GET("/cache/health", CacheHealthHandler)
 		observability.POST("/cache/invalidate", CacheInvalidateHandler)
 		observability.GET("/trace/status", TraceStatusHandler)
+		observability.GET("/outbound/circuits", OutboundCircuitsHandler)
 	}
 }
 
@@ -70,9 +72,30 @@ func DetailedHealthHandler(c *gin.Context) {
 		}
 	}
 
+	health["components"].(gin.H)["outbound"] = gin.H{
+		"circuits": httpclient.Circuits(),
+	}
+
 	c.JSON(http.StatusOK, health)
 }
 
+// OutboundCircuitsHandler lists the circuit breaker state of each external provider
+// host called since startup
+func OutboundCircuitsHandler(c *gin.Context) {
+	circuits := httpclient.Circuits()
+	open := 0
+	for _, circuit := range circuits {
+		if circuit.State != httpclient.StateClosed {
+			open++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"circuits": circuits,
+		"open":     open,
+	})
+}
+
 // MetricsSummaryHandler provides a summary of key metrics
 func MetricsSummaryHandler(c *gin.Context) {
 	// This would typically aggregate key metrics from Prometheus
@@ -27,29 +27,37 @@ func SetupPaymentRoutes(router *gin.Engine, jwtSecret string) {
 
 	// Admin-only payment routes
 	adminPaymentRoutes := router.Group("/api/v1/admin/payments")
-	adminPaymentRoutes.Use(middleware.AuthMiddleware())
-	adminPaymentRoutes.Use(func(c *gin.Context) {
-		userRole, exists := c.Get("userRole")
-		roleStr := ""
-		if exists {
-			if rs, ok := userRole.(string); ok {
-				roleStr = rs
-			}
-		}
-		if roleStr != "Admin" {
-			c.JSON(403, gin.H{"error": "Admin access required"})
-			c.Abort()
-			return
-		}
-		c.Next()
-	})
+	adminPaymentRoutes.Use(middleware.AuthMiddleware(), middleware.RequireAdmin(), middleware.RequireAdminScope())
 	{
 		adminPaymentRoutes.POST("/refund", payments.ProcessRefund)
 	}
+}
+
+// SetupWalletRoutes configures Apple Pay and Google Pay donations. They are mounted on
+// their own so the rest of the payments API is not exposed with them.
+func SetupWalletRoutes(router *gin.Engine) {
+	walletRoutes := router.Group("/api/v1/payments/wallet")
+	walletRoutes.Use(middleware.AuthMiddleware())
+	{
+		walletRoutes.GET("/config", payments.GetWalletConfig)
+		walletRoutes.POST("/apple-pay/session", payments.CreateApplePaySession)
+		walletRoutes.POST("/pay", payments.CreateWalletPayment)
+		walletRoutes.POST("/:id/confirm", payments.ConfirmWalletPayment)
+	}
 
-	// Webhook routes (no authentication required)
-	webhookRoutes := router.Group("/api/v1/webhooks")
+	adminWalletRoutes := router.Group("/api/v1/admin/payments")
+	adminWalletRoutes.Use(middleware.AuthMiddleware(), middleware.RequireAdmin(), middleware.RequireAdminScope())
 	{
-		webhookRoutes.POST("/stripe", payments.WebhookHandler)
+		adminWalletRoutes.POST("/apple-pay/domains", payments.RegisterApplePayDomain)
 	}
+
+	// Apple Pay domain verification file
+	router.GET("/.well-known/apple-developer-merchantid-domain-association", payments.ServeApplePayDomainAssociation)
+}
+
+// SetupPaymentWebhookRoutes configures the Stripe webhook, which keeps payments,
+// refunds and chargebacks in step with the provider. Requests are authenticated by
+// their Stripe signature.
+func SetupPaymentWebhookRoutes(router *gin.Engine) {
+	router.POST("/api/v1/webhooks/stripe", payments.WebhookHandler)
 }
@@ -41,9 +41,10 @@ func SetupRealTimeRoutes(r *gin.Engine) {
 
 	// Admin WebSocket endpoints
 	adminWs := wsGroup.Group("/admin")
-	adminWs.Use(middleware.RequireAdmin())
+	adminWs.Use(middleware.RequireAdmin(), middleware.RequireAdminScope())
 	{
 		adminWs.GET("/queue", systemHandlers.HandleQueueWebSocket)
+		adminWs.GET("/queue/display", systemHandlers.HandleQueueDisplayWebSocket)
 	}
 
 	// Real-time API endpoints (polling alternatives)
@@ -57,9 +58,10 @@ func SetupRealTimeRoutes(r *gin.Engine) {
 
 	// Staff call-next system
 	staffAPI := r.Group("/api/v1/staff")
-	staffAPI.Use(middleware.Auth(), middleware.RequireAdmin())
+	staffAPI.Use(middleware.Auth(), middleware.RequireAdmin(), middleware.RequireAdminScope())
 	{
 		staffAPI.POST("/queue/call-next", systemHandlers.StaffCallNextSystem)
 		staffAPI.GET("/queue/dashboard", systemHandlers.GetStaffQueueDashboard)
+		staffAPI.GET("/queue/display", systemHandlers.GetQueueDisplayBoard)
 	}
 }
@@ -104,7 +104,7 @@ func (mc *MiddlewareChains) Authenticated() []gin.HandlerFunc {
 // AdminOnly returns middleware chain for admin-only routes
 func (mc *MiddlewareChains) AdminOnly() []gin.HandlerFunc {
 	middlewares := mc.Authenticated()
-	middlewares = append(middlewares, middleware.RequireAdmin())
+	middlewares = append(middlewares, middleware.RequireAdmin(), middleware.RequireAdminScope())
 	return middlewares
 }
 
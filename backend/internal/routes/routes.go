@@ -8,8 +8,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/geoo115/charity-management-system/internal/chaos"
+	"github.com/geoo115/charity-management-system/internal/db"
 	systemHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/system"
-	"github.com/geoo115/charity-management-system/internal/jobs"
 	"github.com/geoo115/charity-management-system/internal/middleware"
 	"github.com/geoo115/charity-management-system/internal/services"
 )
@@ -107,6 +108,24 @@ func (rm *RouteManager) initializeSystem() error {
 	// Configure development-specific middleware
 	rm.setupDevelopmentMiddleware()
 
+	// Drop cached aggregates when the data behind them is written
+	if db.DB != nil {
+		if err := services.RegisterCacheInvalidation(db.DB); err != nil {
+			return err
+		}
+	}
+
+	// Failure injection for testing retries and alerting outside production
+	if chaos.Enabled() {
+		rm.router.Use(chaos.Middleware())
+		if db.DB != nil {
+			if err := chaos.RegisterDBCallbacks(db.DB); err != nil {
+				return err
+			}
+		}
+		log.Println("WARNING: chaos failure injection is enabled")
+	}
+
 	return nil
 }
 
@@ -161,13 +180,9 @@ func (rm *RouteManager) setupSecurityMiddleware() error {
 	rm.router.Use(securityValidator.ValidateRequest())
 	rm.router.Use(middleware.SanitizeInput())
 
-	// Apply global rate limiting (prefer Redis-backed if available)
+	// Apply global rate limiting, shared across instances through Redis when available
 	if rm.config.EnableRateLimit {
-		if jobs.RedisClient != nil {
-			rm.router.Use(middleware.RedisAPIRateLimit())
-		} else {
-			rm.router.Use(middleware.APIRateLimit())
-		}
+		rm.router.Use(middleware.APIRateLimit())
 	}
 
 	// Add query optimization middleware for enhanced performance
@@ -252,6 +267,8 @@ func (rm *RouteManager) setupRoleSpecificRoutes() error {
 
 	// Donor routes
 	SetupDonorRoutes(rm.router)
+	SetupWalletRoutes(rm.router)
+	SetupPaymentWebhookRoutes(rm.router)
 
 	// Volunteer routes
 	if err := SetupVolunteerRoutes(rm.router); err != nil {
@@ -2,11 +2,15 @@ package routes
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	donorHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/donor"
 	systemHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/system"
+	visitorHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/visitor"
+	volunteerHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/volunteer"
+	"github.com/geoo115/charity-management-system/internal/middleware"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -23,6 +27,7 @@ func SetupSystemRoutes(r *gin.Engine) error {
 			"description": "API for managing charity operations, volunteers, donations, and visitor services",
 			"endpoints": gin.H{
 				"health":       "/health",
+				"status":       "/api/v1/status",
 				"api_docs":     "/swagger/index.html",
 				"api_spec":     "/api/swagger.json",
 				"api_base":     "/api/v1",
@@ -36,7 +41,8 @@ func SetupSystemRoutes(r *gin.Engine) error {
 
 	// Health monitoring
 	r.GET("/health", systemHandlers.HealthCheck)
-	r.GET("/health-check", systemHandlers.HealthCheck) // Frontend compatibility
+	r.GET("/health-check", systemHandlers.HealthCheck)       // Frontend compatibility
+	r.GET("/api/v1/status", systemHandlers.GetServiceStatus) // Public status page and degradation banner
 
 	// API documentation
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -50,6 +56,47 @@ func SetupPublicRoutes(r *gin.Engine) error {
 	// Public information endpoints
 	r.GET("/urgent-needs", donorHandlers.ListUrgentNeeds)
 	r.GET("/api/v1/urgent-needs", donorHandlers.ListUrgentNeeds) // API v1 compatibility
+	r.GET("/api/v1/service-types", visitorHandlers.ListServiceTypes)
+	r.GET("/api/v1/service-types/:code/form", visitorHandlers.GetServiceTypeForm)
+	r.GET("/api/v1/booking-windows", visitorHandlers.ListUpcomingBookingWindows)
+	r.GET("/api/v1/campaigns/open/:token", systemHandlers.TrackCampaignOpen)     // Campaign email open-tracking pixel
+	r.GET("/api/v1/announcements/public", systemHandlers.GetPublicAnnouncements) // Banners for signed-out visitors
+	r.GET("/api/v1/drives/:slug/leaderboard", donorHandlers.GetDonationDriveLeaderboard)
+	r.GET("/api/v1/branding", systemHandlers.GetPublicBranding)
+	r.GET("/api/v1/branding/logo", systemHandlers.GetBrandingLogo)
+	r.GET("/api/v1/meta/enums", systemHandlers.GetEnumCatalog)
+	r.GET("/api/v1/certificates/verify/:code", middleware.RateLimit(30, time.Minute), volunteerHandlers.VerifyCertificate) // Employers checking a volunteer certificate
+	r.GET("/api/v1/transparency/tickets", middleware.RateLimit(30, time.Minute), systemHandlers.GetTicketTransparency)     // Weekly demand against tickets issued
+	r.GET("/api/v1/t/:token", middleware.RateLimit(30, time.Minute), visitorHandlers.GetPublicTicket)                      // Short link target for SMS tickets
+
+	// Feedback kiosk, authenticated by device token rather than a user login
+	kiosk := r.Group("/api/v1/kiosk")
+	kiosk.Use(middleware.RateLimit(60, time.Minute), middleware.KioskDeviceAuth())
+	{
+		kiosk.GET("/config", systemHandlers.GetKioskConfig)
+		kiosk.POST("/feedback", systemHandlers.SubmitKioskRating)
+	}
+
+	// Partner API, authenticated by API key rather than a user login
+	partner := r.Group("/api/v1/partner")
+	partner.Use(middleware.APIKeyAuth())
+	{
+		partner.GET("/usage", systemHandlers.GetPartnerAPIUsage)
+		partner.GET("/urgent-needs", donorHandlers.ListUrgentNeeds)
+		partner.GET("/status", systemHandlers.GetServiceStatus)
+	}
+
+	// Supplier order status callbacks, authenticated by each supplier's signing secret
+	r.POST("/api/v1/webhooks/suppliers/:id", middleware.RateLimit(60, time.Minute), systemHandlers.SupplierWebhook)
+
+	// Interpreter booking confirmations, signed with INTERPRETER_WEBHOOK_SECRET
+	r.POST("/api/v1/webhooks/interpreter", middleware.RateLimit(60, time.Minute), systemHandlers.InterpreterWebhook)
+
+	// Visitor documents sent by email, forwarded by the provider's inbound parse webhook
+	r.POST("/api/v1/webhooks/inbound-documents", middleware.RateLimit(120, time.Minute), systemHandlers.InboundDocumentWebhook)
+
+	// Replies to help request and feedback emails, forwarded the same way
+	r.POST("/api/v1/webhooks/inbound-replies", middleware.RateLimit(120, time.Minute), systemHandlers.InboundReplyWebhook)
 
 	return nil
 }
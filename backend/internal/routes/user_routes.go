@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	authHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/auth"
+	"github.com/geoo115/charity-management-system/internal/handlers_new/privacy"
 	systemHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/system"
 	"github.com/geoo115/charity-management-system/internal/middleware"
 )
@@ -25,6 +26,18 @@ func SetupUserRoutes(r *gin.Engine) {
 		// Dashboard and statistics
 		userGroup.GET("/dashboard/stats", authHandlers.GetUserDashboardStats)
 		userGroup.GET("/volunteer-status", authHandlers.GetUserVolunteerStatus)
+
+		// Partner API keys owned by the user, with their usage
+		userGroup.GET("/api-keys", systemHandlers.GetMyAPIKeys)
+	}
+
+	// Self-service account deletion with a grace period; the emailed cancel link works
+	// without signing in because the account is disabled
+	accountGroup := r.Group("/api/v1/account")
+	{
+		accountGroup.GET("/deletion", middleware.Auth(), privacy.GetAccountDeletionStatus)
+		accountGroup.DELETE("", middleware.Auth(), middleware.StrictRateLimit(), privacy.DeleteAccount)
+		accountGroup.POST("/deletion/cancel", middleware.StrictRateLimit(), privacy.CancelAccountDeletion)
 	}
 
 	// Basic notification routes
@@ -32,9 +45,11 @@ func SetupUserRoutes(r *gin.Engine) {
 	notificationGroup.Use(middleware.Auth())
 	{
 		// Core notification routes (simplified)
+		notificationGroup.GET("/announcements/live", systemHandlers.GetLiveAnnouncements)
 		notificationGroup.GET("/notifications", systemHandlers.GetInAppNotifications)
 		notificationGroup.GET("/notifications/count", systemHandlers.GetNotificationCount)
 		notificationGroup.PUT("/notifications/read-all", systemHandlers.MarkAllNotificationsAsRead)
+		notificationGroup.PUT("/notifications/read", systemHandlers.MarkNotificationsAsRead)
 		notificationGroup.GET("/notifications/preferences", systemHandlers.GetUnifiedNotificationPreferences)
 		notificationGroup.GET("/notifications/templates", systemHandlers.GetNotificationTemplates)
 		notificationGroup.PUT("/notifications/:notificationId/read", systemHandlers.MarkNotificationAsRead)
@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 
 	adminHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/admin"
@@ -131,6 +133,8 @@ func setupAuthenticatedVisitorRoutes(r *gin.Engine, config *VisitorRouteConfig)
 	setupVisitorProfile(visitorGroup)
 	setupVisitorEligibility(visitorGroup)
 	setupVisitorDocuments(visitorGroup)
+	setupVisitorStandby(visitorGroup)
+	setupVisitorAppointments(visitorGroup)
 
 	// Also setup alternative route structure for backwards compatibility
 	visitorsGroup := r.Group(APIBasePath + "/visitors")
@@ -173,11 +177,32 @@ func setupVisitorEligibility(group *gin.RouterGroup) {
 	}
 }
 
+// setupVisitorStandby configures same-day standby offer endpoints
+func setupVisitorStandby(group *gin.RouterGroup) {
+	standbyGroup := group.Group("/standby")
+	{
+		standbyGroup.GET("", visitorHandlers.GetMyStandby)
+		standbyGroup.POST("/:id/claim", visitorHandlers.ClaimStandbyOffer)
+		standbyGroup.POST("/:id/decline", visitorHandlers.DeclineStandbyOffer)
+	}
+}
+
+// setupVisitorAppointments configures advice appointment booking endpoints
+func setupVisitorAppointments(group *gin.RouterGroup) {
+	appointmentGroup := group.Group("/appointments")
+	{
+		appointmentGroup.GET("", visitorHandlers.GetMyAppointments)
+		appointmentGroup.GET("/slots", visitorHandlers.GetAvailableAppointmentSlots)
+		appointmentGroup.POST("", middleware.RateLimit(10, time.Hour), visitorHandlers.BookAppointment)
+		appointmentGroup.POST("/:id/cancel", visitorHandlers.CancelMyAppointment)
+	}
+}
+
 // setupVisitorFeedback configures feedback endpoints
 func setupVisitorFeedback(group *gin.RouterGroup) {
 	feedbackGroup := group.Group("/feedback")
 	{
-		feedbackGroup.POST("", visitorHandlers.SubmitVisitorFeedback)
+		feedbackGroup.POST("", middleware.FeedbackRateLimit(), visitorHandlers.SubmitVisitorFeedback)
 		feedbackGroup.GET("/history", visitorHandlers.GetVisitorFeedbackHistory)
 	}
 }
@@ -188,6 +213,9 @@ func setupVisitorDocuments(group *gin.RouterGroup) {
 	{
 		documentsGroup.GET("", visitorHandlers.GetVisitorDocuments)
 		documentsGroup.POST("/upload", visitorHandlers.UploadVisitorDocument)
+		documentsGroup.GET("/inbox", visitorHandlers.GetDocumentInbox)
+		documentsGroup.POST("/inbox/rotate", visitorHandlers.RotateDocumentInbox)
+		documentsGroup.GET("/reverification", visitorHandlers.GetMyReverification)
 	}
 }
 
@@ -202,6 +230,12 @@ func setupHelpRequestRoutes(r *gin.Engine, _ *VisitorRouteConfig) {
 
 	// CRUD operations for help requests
 	helpRequestGroup.POST("", visitorHandlers.CreateHelpRequest)
+
+	// Save-and-resume for the help request wizard, one step at a time
+	helpRequestGroup.GET("/draft", visitorHandlers.GetHelpRequestDraft)
+	helpRequestGroup.PUT("/draft/steps/:step", visitorHandlers.SaveHelpRequestDraftStep)
+	helpRequestGroup.DELETE("/draft", visitorHandlers.DiscardHelpRequestDraft)
+
 	helpRequestGroup.GET("/:id", visitorHandlers.GetHelpRequestDetails)
 	helpRequestGroup.PUT("/:id", visitorHandlers.UpdateHelpRequest)
 	helpRequestGroup.DELETE("/:id", visitorHandlers.CancelHelpRequest)
@@ -232,4 +266,5 @@ func setupTicketRoutes(r *gin.Engine, _ *VisitorRouteConfig) {
 
 	ticketGroup.GET("/:ticket/validate", adminHandlers.ValidateTicket)
 	ticketGroup.GET("/history", visitorHandlers.GetVisitorTicketHistory)
+	ticketGroup.GET("/:ticket/pdf", visitorHandlers.DownloadTicketPDF)
 }
@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 
 	volunteerHandlers "github.com/geoo115/charity-management-system/internal/handlers_new/volunteer"
@@ -57,7 +59,9 @@ func setupBasicVolunteerRoutes(r *gin.Engine, config *VolunteerRouteConfig) erro
 	setupVolunteerCore(basicVolunteerGroup)
 	setupVolunteerProfile(basicVolunteerGroup)
 	setupVolunteerApplication(basicVolunteerGroup)
+	setupVolunteerDocuments(basicVolunteerGroup)
 	setupVolunteerTasks(basicVolunteerGroup)
+	setupVolunteerMobile(basicVolunteerGroup)
 
 	// Optional features based on configuration
 	if config.EnableTraining {
@@ -93,6 +97,18 @@ func setupVolunteerCore(group *gin.RouterGroup) {
 	group.GET("/role/permissions", volunteerHandlers.GetVolunteerRoleInfo)
 }
 
+// setupVolunteerMobile configures lightweight endpoints for the mobile app home screen.
+// Each response stays under 2KB and accepts a fields=... projection.
+func setupVolunteerMobile(group *gin.RouterGroup) {
+	mobileGroup := group.Group("/mobile")
+	{
+		mobileGroup.GET("/summary", volunteerHandlers.GetMobileSummary)
+		mobileGroup.GET("/next-shift", volunteerHandlers.GetMobileNextShift)
+		mobileGroup.GET("/unread-counts", volunteerHandlers.GetMobileUnreadCounts)
+		mobileGroup.GET("/pending-actions", volunteerHandlers.GetMobilePendingActions)
+	}
+}
+
 // setupVolunteerProfile configures profile management endpoints
 func setupVolunteerProfile(group *gin.RouterGroup) {
 	profileGroup := group.Group("/profile")
@@ -110,6 +126,16 @@ func setupVolunteerApplication(group *gin.RouterGroup) {
 	}
 }
 
+// setupVolunteerDocuments configures document upload and requirement endpoints
+func setupVolunteerDocuments(group *gin.RouterGroup) {
+	documentGroup := group.Group("/documents")
+	{
+		documentGroup.GET("", volunteerHandlers.GetVolunteerDocuments)
+		documentGroup.POST("", volunteerHandlers.UploadVolunteerDocument)
+		documentGroup.GET("/requirements", volunteerHandlers.GetVolunteerDocumentRequirements)
+	}
+}
+
 // setupVolunteerTasks configures task management endpoints
 func setupVolunteerTasks(group *gin.RouterGroup) {
 	// Tasks management
@@ -119,6 +145,15 @@ func setupVolunteerTasks(group *gin.RouterGroup) {
 	group.GET("/notes", volunteerHandlers.GetVolunteerNotes)
 	group.GET("/hours/summary", volunteerHandlers.GetHoursSummary)
 	group.GET("/team/stats", volunteerHandlers.GetTeamStats)
+
+	// Teams and the sign-off their leads do for their own members
+	group.GET("/team", volunteerHandlers.GetMyTeam)
+	group.GET("/teams", volunteerHandlers.GetVolunteerTeams)
+	group.POST("/teams", volunteerHandlers.CreateVolunteerTeam)
+	group.GET("/team/roster", volunteerHandlers.GetTeamRoster)
+	group.GET("/team/pending-hours", volunteerHandlers.GetTeamPendingHours)
+	group.POST("/team/assignments/:id/attendance", volunteerHandlers.ConfirmTeamAttendance)
+	group.POST("/team/assignments/:id/approve-hours", volunteerHandlers.ApproveTeamHours)
 }
 
 // setupVolunteerTraining configures training endpoints
@@ -179,6 +214,25 @@ func setupApprovedVolunteerRoutes(r *gin.Engine, config *VolunteerRouteConfig) e
 	// Shift management
 	setupVolunteerShiftManagement(approvedVolunteerGroup)
 
+	// Peer recognition
+	setupVolunteerKudos(approvedVolunteerGroup)
+
+	// Emergency call-outs
+	setupVolunteerCallouts(approvedVolunteerGroup)
+
+	// Lift sharing
+	setupVolunteerCarpool(approvedVolunteerGroup)
+	setupVolunteerShiftFeedback(approvedVolunteerGroup)
+
+	// Away mode
+	setupVolunteerAway(approvedVolunteerGroup)
+
+	// Hour certificates and references
+	setupVolunteerCertificates(approvedVolunteerGroup)
+
+	// Monthly hour statements
+	setupVolunteerStatements(approvedVolunteerGroup)
+
 	return nil
 }
 
@@ -188,6 +242,7 @@ func setupVolunteerShiftManagement(group *gin.RouterGroup) {
 	{
 		// Shift availability and management
 		shiftGroup.GET("/available", volunteerHandlers.ListAvailableShifts)
+		shiftGroup.GET("/almost-eligible", volunteerHandlers.GetAlmostEligibleShifts)
 		shiftGroup.GET("/role-specific", volunteerHandlers.GetRoleSpecificShifts)
 		shiftGroup.GET("/assigned", volunteerHandlers.GetAssignedShifts)
 		shiftGroup.GET("/my-shifts", volunteerHandlers.GetAssignedShifts) // Alias for assigned shifts
@@ -196,6 +251,11 @@ func setupVolunteerShiftManagement(group *gin.RouterGroup) {
 		// Shift actions
 		shiftGroup.POST("/:id/signup", volunteerHandlers.SignupForShift)
 		shiftGroup.POST("/:id/cancel", volunteerHandlers.CancelShift)
+		shiftGroup.POST("/:id/check-in", middleware.RateLimit(10, time.Minute), volunteerHandlers.CheckInWithShiftCode) // Rotating code shown at the venue
+
+		// Re-confirming shifts whose time or place changed
+		shiftGroup.GET("/reconfirm", volunteerHandlers.GetPendingShiftReconfirmations)
+		shiftGroup.POST("/:id/reconfirm", volunteerHandlers.ReconfirmShift)
 
 		// Shift validation
 		shiftGroup.GET("/:id/validate", volunteerHandlers.ValidateShiftAvailability)
@@ -211,3 +271,76 @@ func setupVolunteerShiftManagement(group *gin.RouterGroup) {
 		shiftGroup.PUT("/:id/capacity", volunteerHandlers.UpdateFlexibleShiftCapacity)
 	}
 }
+
+// setupVolunteerKudos configures peer recognition endpoints
+func setupVolunteerKudos(group *gin.RouterGroup) {
+	kudosGroup := group.Group("/kudos")
+	{
+		kudosGroup.POST("", middleware.RateLimit(10, time.Hour), volunteerHandlers.SendKudos)
+		kudosGroup.GET("/received", volunteerHandlers.GetReceivedKudos)
+		kudosGroup.GET("/sent", volunteerHandlers.GetSentKudos)
+		kudosGroup.POST("/:id/report", middleware.RateLimit(10, time.Hour), volunteerHandlers.ReportKudos)
+	}
+
+	group.GET("/shifts/:id/teammates", volunteerHandlers.GetShiftTeammates)
+}
+
+// setupVolunteerAway configures endpoints for marking away periods
+func setupVolunteerAway(group *gin.RouterGroup) {
+	awayGroup := group.Group("/away")
+	{
+		awayGroup.GET("", volunteerHandlers.GetMyAwayPeriods)
+		awayGroup.POST("", volunteerHandlers.CreateAwayPeriod)
+		awayGroup.DELETE("/:id", volunteerHandlers.DeleteAwayPeriod)
+	}
+}
+
+// setupVolunteerCertificates configures certificate of volunteering hours endpoints
+func setupVolunteerCertificates(group *gin.RouterGroup) {
+	certificateGroup := group.Group("/certificates")
+	{
+		certificateGroup.GET("", volunteerHandlers.GetMyCertificates)
+		certificateGroup.POST("", middleware.RateLimit(5, time.Hour), volunteerHandlers.RequestCertificate)
+		certificateGroup.GET("/:id/download", volunteerHandlers.DownloadCertificate)
+	}
+}
+
+// setupVolunteerStatements configures monthly hour statement endpoints
+func setupVolunteerStatements(group *gin.RouterGroup) {
+	statementGroup := group.Group("/statements")
+	{
+		statementGroup.GET("", volunteerHandlers.GetMyStatements)
+		statementGroup.GET("/:month", volunteerHandlers.GetMyStatement)
+	}
+}
+
+// setupVolunteerCallouts configures emergency call-out response endpoints
+func setupVolunteerCallouts(group *gin.RouterGroup) {
+	calloutGroup := group.Group("/callouts")
+	{
+		calloutGroup.GET("", volunteerHandlers.GetMyEmergencyCallouts)
+		calloutGroup.GET("/:id", volunteerHandlers.ViewEmergencyCallout)
+		calloutGroup.POST("/:id/accept", volunteerHandlers.AcceptEmergencyCallout)
+		calloutGroup.POST("/:id/decline", volunteerHandlers.DeclineEmergencyCallout)
+	}
+}
+
+// setupVolunteerCarpool configures opt-in lift sharing endpoints
+func setupVolunteerCarpool(group *gin.RouterGroup) {
+	group.GET("/shifts/:id/carpool", volunteerHandlers.GetShiftCarpool)
+	group.PUT("/shifts/:id/carpool", volunteerHandlers.SaveShiftCarpool)
+	group.DELETE("/shifts/:id/carpool", volunteerHandlers.WithdrawShiftCarpool)
+	group.POST("/shifts/:id/carpool/matches", middleware.RateLimit(20, time.Hour), volunteerHandlers.ProposeCarpoolMatch)
+
+	carpoolGroup := group.Group("/carpool/matches")
+	{
+		carpoolGroup.POST("/:id/accept", volunteerHandlers.AcceptCarpoolMatch)
+		carpoolGroup.POST("/:id/decline", volunteerHandlers.DeclineCarpoolMatch)
+	}
+}
+
+// setupVolunteerShiftFeedback configures post-shift feedback endpoints
+func setupVolunteerShiftFeedback(group *gin.RouterGroup) {
+	group.GET("/shift-feedback/pending", volunteerHandlers.GetPendingShiftFeedback)
+	group.POST("/shifts/:id/feedback", middleware.RateLimit(20, time.Hour), volunteerHandlers.SubmitShiftFeedback)
+}
@@ -0,0 +1,456 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+)
+
+// defaultAccountDeletionGraceDays is how long a visitor can change their mind before
+// their account is erased
+const defaultAccountDeletionGraceDays = 14
+
+// erasedVisitorName replaces a visitor's name on records kept after erasure
+const erasedVisitorName = "Deleted visitor"
+
+var (
+	ErrAccountDeletionNotAllowed  = errors.New("only visitor accounts can be deleted from the app; please contact us to close this account")
+	ErrAccountDeletionBlocked     = errors.New("account cannot be deleted yet")
+	ErrAccountDeletionScheduled   = errors.New("account deletion is already scheduled")
+	ErrAccountDeletionLinkInvalid = errors.New("this link is invalid or the account has already been deleted")
+)
+
+// Help request statuses that are still being handled for the visitor
+var openHelpRequestStatuses = []string{models.HelpRequestStatusPending, models.HelpRequestStatusApproved}
+
+// Queue entry statuses of a visitor still waiting to be seen
+var openQueueStatuses = []string{"waiting", "called", "recall"}
+
+// AccountDeletionBlocker explains something that must be finished or cancelled before
+// an account can be deleted
+type AccountDeletionBlocker struct {
+	Type    string `json:"type"`
+	Count   int64  `json:"count"`
+	Message string `json:"message"`
+}
+
+// AccountDeletionStatus is a visitor's deletion request and anything stopping it
+type AccountDeletionStatus struct {
+	Request   *models.AccountDeletionRequest `json:"request,omitempty"`
+	GraceDays int                            `json:"grace_days"`
+	Blockers  []AccountDeletionBlocker       `json:"blockers"`
+	CanDelete bool                           `json:"can_delete"`
+}
+
+// accountDeletionCounts is what a visitor still has open
+type accountDeletionCounts struct {
+	ActiveTickets    int64
+	OpenRequests     int64
+	UpcomingAppts    int64
+	QueueEntries     int64
+	TicketedRequests int64
+}
+
+// AccountDeletionService lets visitors delete their own account. The account is
+// disabled straight away and erased once a grace period has passed, unless the
+// visitor cancels with the link they are emailed.
+type AccountDeletionService struct {
+	db        *gorm.DB
+	graceDays int
+}
+
+// NewAccountDeletionService creates a new account deletion service
+func NewAccountDeletionService() *AccountDeletionService {
+	days := defaultAccountDeletionGraceDays
+	if configured, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS")); err == nil && configured > 0 {
+		days = configured
+	}
+	return &AccountDeletionService{db: db.DB, graceDays: days}
+}
+
+// accountDeletionBlockers explains each kind of open activity that stops deletion
+func accountDeletionBlockers(counts accountDeletionCounts) []AccountDeletionBlocker {
+	blockers := []AccountDeletionBlocker{}
+	if tickets := counts.ActiveTickets + counts.TicketedRequests; tickets > 0 {
+		blockers = append(blockers, AccountDeletionBlocker{
+			Type: "active_tickets", Count: tickets,
+			Message: "You have a visit ticket that hasn't been used yet. Cancel the visit or wait until it has passed.",
+		})
+	}
+	if counts.OpenRequests > 0 {
+		blockers = append(blockers, AccountDeletionBlocker{
+			Type: "open_requests", Count: counts.OpenRequests,
+			Message: "You have help requests we are still handling. Cancel them or wait for a decision.",
+		})
+	}
+	if counts.UpcomingAppts > 0 {
+		blockers = append(blockers, AccountDeletionBlocker{
+			Type: "upcoming_appointments", Count: counts.UpcomingAppts,
+			Message: "You have an advice appointment booked. Cancel it first.",
+		})
+	}
+	if counts.QueueEntries > 0 {
+		blockers = append(blockers, AccountDeletionBlocker{
+			Type: "queue", Count: counts.QueueEntries,
+			Message: "You are in today's queue. You can delete your account after your visit.",
+		})
+	}
+	return blockers
+}
+
+// Blockers lists the tickets and open cases stopping a visitor's account being deleted
+func (ads *AccountDeletionService) Blockers(userID uint, now time.Time) ([]AccountDeletionBlocker, error) {
+	var counts accountDeletionCounts
+	if err := ads.db.Model(&models.Ticket{}).
+		Where("visitor_id = ? AND status = ? AND expires_at > ?", userID, models.TicketStatusActive, now).
+		Count(&counts.ActiveTickets).Error; err != nil {
+		return nil, err
+	}
+	if err := ads.db.Model(&models.HelpRequest{}).
+		Where("visitor_id = ? AND status IN ?", userID,
+			[]string{models.HelpRequestStatusTicketIssued, models.HelpRequestStatusCheckedIn}).
+		Where("NOT EXISTS (SELECT 1 FROM tickets WHERE tickets.help_request_id = help_requests.id AND tickets.deleted_at IS NULL)").
+		Count(&counts.TicketedRequests).Error; err != nil {
+		return nil, err
+	}
+	if err := ads.db.Model(&models.HelpRequest{}).
+		Where("visitor_id = ? AND status IN ?", userID, openHelpRequestStatuses).
+		Count(&counts.OpenRequests).Error; err != nil {
+		return nil, err
+	}
+	if err := ads.db.Model(&models.Appointment{}).
+		Joins("JOIN appointment_slots ON appointment_slots.id = appointments.slot_id").
+		Where("appointments.visitor_id = ? AND appointments.status = ? AND appointment_slots.ends_at > ?",
+			userID, models.AppointmentBooked, now).
+		Count(&counts.UpcomingAppts).Error; err != nil {
+		return nil, err
+	}
+	if err := ads.db.Model(&models.QueueEntry{}).
+		Where("visitor_id = ? AND status IN ?", userID, openQueueStatuses).
+		Count(&counts.QueueEntries).Error; err != nil {
+		return nil, err
+	}
+	return accountDeletionBlockers(counts), nil
+}
+
+// Status returns the visitor's current deletion request, if any, and what would stop
+// a new one
+func (ads *AccountDeletionService) Status(userID uint, now time.Time) (*AccountDeletionStatus, error) {
+	blockers, err := ads.Blockers(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	status := &AccountDeletionStatus{GraceDays: ads.graceDays, Blockers: blockers, CanDelete: len(blockers) == 0}
+
+	var request models.AccountDeletionRequest
+	err = ads.db.Where("user_id = ?", userID).Order("created_at DESC").First(&request).Error
+	if err == nil {
+		status.Request = &request
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Request disables a visitor's account and schedules its erasure after the grace
+// period. Active tickets and open cases block the request; the blockers are returned
+// so the visitor knows what to do first.
+func (ads *AccountDeletionService) Request(userID uint, reason string, now time.Time) (*models.AccountDeletionRequest, []AccountDeletionBlocker, error) {
+	var user models.User
+	if err := ads.db.First(&user, userID).Error; err != nil {
+		return nil, nil, err
+	}
+	if user.Role != models.RoleVisitor {
+		return nil, nil, ErrAccountDeletionNotAllowed
+	}
+	if user.Status == models.UserStatusPendingDeletion {
+		return nil, nil, ErrAccountDeletionScheduled
+	}
+
+	blockers, err := ads.Blockers(userID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(blockers) > 0 {
+		return nil, blockers, ErrAccountDeletionBlocked
+	}
+
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, nil, err
+	}
+	scheduledFor := now.AddDate(0, 0, ads.graceDays)
+	request := models.AccountDeletionRequest{
+		UserID:         userID,
+		RequestedAt:    now,
+		ConfirmedAt:    &now,
+		ScheduledFor:   &scheduledFor,
+		Status:         models.AccountDeletionScheduled,
+		Reason:         reason,
+		CancelToken:    hashAccountDeletionToken(token),
+		PreviousStatus: user.Status,
+	}
+
+	err = ads.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&request).Error; err != nil {
+			return err
+		}
+		// Disabling the account and raising the token version signs the visitor out everywhere
+		return tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"status":        models.UserStatusPendingDeletion,
+			"token_version": gorm.Expr("token_version + 1"),
+		}).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ads.sendScheduled(&user, &request, token)
+	return &request, nil, nil
+}
+
+// Cancel restores an account from the link emailed when its deletion was scheduled
+func (ads *AccountDeletionService) Cancel(token string, now time.Time) (*models.AccountDeletionRequest, error) {
+	if token == "" {
+		return nil, ErrAccountDeletionLinkInvalid
+	}
+
+	var request models.AccountDeletionRequest
+	if err := ads.db.Where("cancel_token = ? AND status = ?", hashAccountDeletionToken(token), models.AccountDeletionScheduled).
+		First(&request).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountDeletionLinkInvalid
+		}
+		return nil, err
+	}
+
+	previous := request.PreviousStatus
+	if previous == "" || previous == models.UserStatusPendingDeletion {
+		previous = "active"
+	}
+	err := ads.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&request).Updates(map[string]interface{}{
+			"status":       models.AccountDeletionCancelled,
+			"cancelled_at": now,
+			"cancel_token": "",
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).
+			Where("id = ? AND status = ?", request.UserID, models.UserStatusPendingDeletion).
+			Update("status", previous).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := ads.db.First(&user, request.UserID).Error; err == nil {
+		body := fmt.Sprintf("Hello %s,\n\nYour account deletion has been cancelled and your account is active again. "+
+			"You can sign in as usual.", user.FirstName)
+		if err := notifications.GetService().SendEmail(user.Email, "Your account has been restored", body); err != nil {
+			log.Printf("Failed to send deletion cancellation to user %d: %v", user.ID, err)
+		}
+	}
+	return &request, nil
+}
+
+// EraseDue erases accounts whose grace period has ended. Accounts that picked up a
+// ticket or open case while disabled are left for a later run.
+func (ads *AccountDeletionService) EraseDue(now time.Time) (int, error) {
+	var due []models.AccountDeletionRequest
+	if err := ads.db.Where("status = ? AND scheduled_for <= ?", models.AccountDeletionScheduled, now).
+		Order("scheduled_for").Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	erased := 0
+	for i := range due {
+		request := &due[i]
+		blockers, err := ads.Blockers(request.UserID, now)
+		if err != nil {
+			return erased, err
+		}
+		if len(blockers) > 0 {
+			log.Printf("Account deletion %d for user %d held: %s", request.ID, request.UserID, blockers[0].Type)
+			continue
+		}
+		if err := ads.erase(request, now); err != nil {
+			log.Printf("Failed to erase user %d for deletion request %d: %v", request.UserID, request.ID, err)
+			continue
+		}
+		erased++
+	}
+	return erased, nil
+}
+
+// erase removes a visitor's personal details. Records needed for service statistics,
+// such as visits and ratings, are kept without anything that identifies the visitor.
+func (ads *AccountDeletionService) erase(request *models.AccountDeletionRequest, now time.Time) error {
+	var user models.User
+	if err := ads.db.Unscoped().First(&user, request.UserID).Error; err != nil {
+		return err
+	}
+
+	var files []string
+	err := ads.db.Transaction(func(tx *gorm.DB) error {
+		var documents []models.Document
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Find(&documents).Error; err != nil {
+			return err
+		}
+		for _, document := range documents {
+			files = append(files, document.FilePath)
+		}
+		var exports []models.DataExportRequest
+		if err := tx.Where("user_id = ?", user.ID).Find(&exports).Error; err != nil {
+			return err
+		}
+		for _, export := range exports {
+			files = append(files, export.FilePath)
+		}
+
+		steps := []func() error{
+			func() error {
+				return tx.Unscoped().Model(&models.HelpRequest{}).Where("visitor_id = ?", user.ID).Updates(map[string]interface{}{
+					"visitor_name":      erasedVisitorName,
+					"email":             "",
+					"phone":             "",
+					"postcode":          "",
+					"details":           "",
+					"special_needs":     "",
+					"eligibility_notes": "",
+					"notes":             "",
+					"qr_code":           "",
+					"form_answers":      nil,
+				}).Error
+			},
+			func() error {
+				return tx.Unscoped().Model(&models.Ticket{}).Where("visitor_id = ?", user.ID).
+					Updates(map[string]interface{}{"visitor_name": erasedVisitorName, "qr_code": ""}).Error
+			},
+			func() error {
+				return tx.Model(&models.Appointment{}).Where("visitor_id = ?", user.ID).Update("notes", "").Error
+			},
+			func() error {
+				return tx.Unscoped().Model(&models.QueueEntry{}).Where("visitor_id = ?", user.ID).Update("notes", "").Error
+			},
+			func() error {
+				return tx.Model(&models.VisitFeedback{}).Where("visitor_id = ?", user.ID).Updates(map[string]interface{}{
+					"positive_comments":     "",
+					"areas_for_improvement": "",
+					"suggestions":           "",
+					"accessibility_notes":   "",
+				}).Error
+			},
+			func() error {
+				return tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Document{}).Error
+			},
+			func() error { return tx.Where("user_id = ?", user.ID).Delete(&models.DataExportRequest{}).Error },
+			func() error {
+				return tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.VisitorProfile{}).Error
+			},
+			func() error {
+				return tx.Where("user_id = ?", user.ID).Delete(&models.NotificationPreferences{}).Error
+			},
+			func() error {
+				return tx.Where("user_id = ?", user.ID).Delete(&models.NotificationPreference{}).Error
+			},
+			func() error {
+				return tx.Where("user_id = ?", user.ID).Delete(&models.NotificationDigestItem{}).Error
+			},
+			func() error {
+				return tx.Where("visitor_id = ?", user.ID).Delete(&models.HelpRequestDraft{}).Error
+			},
+			func() error {
+				return tx.Where("user_id = ? OR email = ?", user.ID, strings.ToLower(user.Email)).Delete(&models.DonorOnboarding{}).Error
+			},
+			func() error { return tx.Where("user_id = ?", user.ID).Delete(&models.Consent{}).Error },
+			func() error { return tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.RefreshToken{}).Error },
+			func() error {
+				return tx.Unscoped().Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+					"first_name":         "Deleted",
+					"last_name":          "Visitor",
+					"email":              fmt.Sprintf("deleted-%d@invalid", user.ID),
+					"phone":              "",
+					"address":            "",
+					"city":               "",
+					"postcode":           "",
+					"password":           "",
+					"stripe_customer_id": "",
+					"status":             "deleted",
+					"token_version":      gorm.Expr("token_version + 1"),
+				}).Error
+			},
+			func() error { return tx.Delete(&models.User{}, user.ID).Error },
+			func() error {
+				return tx.Model(request).Updates(map[string]interface{}{
+					"status":       models.AccountDeletionCompleted,
+					"completed_at": now,
+					"cancel_token": "",
+					"reason":       "",
+				}).Error
+			},
+		}
+		for _, step := range steps {
+			if err := step(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, path := range files {
+		if path == "" {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove file %s of erased user %d: %v", path, user.ID, err)
+		}
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\nYour account and personal details have now been deleted. "+
+		"If you need our help in future you are welcome to register again.", user.FirstName)
+	if err := notifications.GetService().SendEmail(user.Email, "Your account has been deleted", body); err != nil {
+		log.Printf("Failed to send deletion confirmation for user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+// sendScheduled emails the visitor the date their account will be erased and a link
+// to cancel
+func (ads *AccountDeletionService) sendScheduled(user *models.User, request *models.AccountDeletionRequest, token string) {
+	baseURL := os.Getenv("FRONTEND_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+	link := fmt.Sprintf("%s/account/restore?token=%s", baseURL, token)
+
+	body := fmt.Sprintf("Hello %s,\n\nWe have received your request to delete your account. "+
+		"Your account is now disabled and will be permanently deleted on %s.\n\n"+
+		"Changed your mind? Cancel the deletion and restore your account here:\n%s",
+		user.FirstName, request.ScheduledFor.Format("2 January 2006"), link)
+	if err := notifications.GetService().SendEmail(user.Email, "Your account will be deleted", body); err != nil {
+		log.Printf("Failed to send deletion notice for request %d to user %d: %v", request.ID, user.ID, err)
+	}
+}
+
+// hashAccountDeletionToken returns the stored form of a cancel link token
+func hashAccountDeletionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,38 @@
+package services
+
+import "testing"
+
+func TestAccountDeletionBlockers(t *testing.T) {
+	cases := []struct {
+		counts accountDeletionCounts
+		want   []string
+	}{
+		{accountDeletionCounts{}, nil},
+		{accountDeletionCounts{ActiveTickets: 1, TicketedRequests: 1}, []string{"active_tickets"}},
+		{accountDeletionCounts{OpenRequests: 2, QueueEntries: 1}, []string{"open_requests", "queue"}},
+		{accountDeletionCounts{TicketedRequests: 1, UpcomingAppts: 1}, []string{"active_tickets", "upcoming_appointments"}},
+	}
+	for _, tc := range cases {
+		got := accountDeletionBlockers(tc.counts)
+		if len(got) != len(tc.want) {
+			t.Errorf("accountDeletionBlockers(%+v) = %+v, want types %v", tc.counts, got, tc.want)
+			continue
+		}
+		for i, blocker := range got {
+			if blocker.Type != tc.want[i] || blocker.Message == "" || blocker.Count == 0 {
+				t.Errorf("accountDeletionBlockers(%+v)[%d] = %+v, want type %s", tc.counts, i, blocker, tc.want[i])
+			}
+		}
+	}
+
+	if got := accountDeletionBlockers(accountDeletionCounts{ActiveTickets: 1, TicketedRequests: 2}); got[0].Count != 3 {
+		t.Errorf("ticket count = %d, want 3", got[0].Count)
+	}
+}
+
+func TestHashAccountDeletionToken(t *testing.T) {
+	a, b := hashAccountDeletionToken("abc"), hashAccountDeletionToken("abd")
+	if len(a) != 64 || a == b || a != hashAccountDeletionToken("abc") {
+		t.Errorf("hashAccountDeletionToken gave %q and %q", a, b)
+	}
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultAdminChangeReportHour is the hour on Monday the weekly change report is sent
+const defaultAdminChangeReportHour = 7
+
+var (
+	ErrAdminChangeReportFuture   = errors.New("the week has not finished yet")
+	ErrAdminChangeReportNotFound = errors.New("change report not found")
+)
+
+// adminChangeRules maps audit log entity types, and optionally their actions, to the
+// kind of change the report groups them under
+var adminChangeRules = []struct {
+	entityType string
+	actions    []string // Any action when empty
+	category   string
+}{
+	{"VisitCapacity", nil, models.AdminChangeCapacity},
+	{"CapacityRelease", nil, models.AdminChangeCapacity},
+	{"QueueSettings", nil, models.AdminChangeCapacity},
+	{"CapacityOverride", nil, models.AdminChangeCapacity},
+	{"User", []string{"AssignRole", "CreateUser"}, models.AdminChangeRoleGrant},
+	{"UserInvitation", []string{"Invite", "RevokeInvitation"}, models.AdminChangeRoleGrant},
+	{"VolunteerProfile", []string{"RolePromotion"}, models.AdminChangeRoleGrant},
+	{"APIKey", nil, models.AdminChangeRoleGrant},
+	{"Document", []string{"Verify", "Reject", "UpdateDocumentStatus"}, models.AdminChangeDocumentDecision},
+	{"User", []string{"VerifyDocuments"}, models.AdminChangeDocumentDecision},
+	{"Ticket", []string{"CancelTicket", "BulkIssueTickets"}, models.AdminChangeTicketOverride},
+	{"HelpRequest", []string{"IssueTickets", "TicketRelease"}, models.AdminChangeTicketOverride},
+	{"TicketFraudEvent", nil, models.AdminChangeTicketOverride},
+}
+
+// adminChangeRoles are the roles whose changes are reported. Visitors and volunteers
+// acting on their own records are left out.
+var adminChangeRoles = []string{models.RoleSuperAdmin, models.RoleAdmin, models.RoleStaff}
+
+// AdminChange is one reported change
+type AdminChange struct {
+	At          time.Time `json:"at"`
+	Category    string    `json:"category"`
+	Action      string    `json:"action"`
+	EntityType  string    `json:"entity_type"`
+	EntityID    uint      `json:"entity_id,omitempty"`
+	Description string    `json:"description"`
+}
+
+// AdminChangeSummary is what one member of staff changed during the week
+type AdminChangeSummary struct {
+	UserID     uint           `json:"user_id"`
+	Name       string         `json:"name"`
+	Role       string         `json:"role"`
+	AdminScope string         `json:"admin_scope,omitempty"`
+	Total      int            `json:"total"`
+	Counts     map[string]int `json:"counts"` // By category
+	Changes    []AdminChange  `json:"changes"`
+}
+
+// AdminChangeWeeklyReport is the content of the weekly governance report
+type AdminChangeWeeklyReport struct {
+	WeekStart string               `json:"week_start"`
+	WeekEnd   string               `json:"week_end"`
+	Total     int                  `json:"total"`
+	Counts    map[string]int       `json:"counts"` // By category
+	Admins    []AdminChangeSummary `json:"admins"` // Most changes first
+}
+
+// AdminChangeReportService summarises administrative changes from the audit log each
+// week so trustees can see who changed capacity, access, documents and tickets
+type AdminChangeReportService struct {
+	db *gorm.DB
+}
+
+// NewAdminChangeReportService creates a new admin change report service
+func NewAdminChangeReportService() *AdminChangeReportService {
+	return &AdminChangeReportService{db: db.DB}
+}
+
+// adminChangeCategory returns the kind of change an audit log records, or "" when it
+// is not reported
+func adminChangeCategory(action, entityType string) string {
+	for _, rule := range adminChangeRules {
+		if rule.entityType != entityType {
+			continue
+		}
+		if len(rule.actions) == 0 {
+			return rule.category
+		}
+		for _, ruleAction := range rule.actions {
+			if ruleAction == action {
+				return rule.category
+			}
+		}
+	}
+	return ""
+}
+
+// auditLogActor returns the ID of the user who made a change. Handlers record it in
+// the details; the audit service records it as performed_by "user_<id>".
+func auditLogActor(entry models.AuditLog) uint {
+	var details struct {
+		UserID *float64 `json:"user_id"`
+	}
+	if json.Unmarshal([]byte(entry.DetailsJSON), &details) == nil && details.UserID != nil && *details.UserID > 0 {
+		return uint(*details.UserID)
+	}
+	var userID uint
+	if _, err := fmt.Sscanf(entry.PerformedBy, "user_%d", &userID); err == nil {
+		return userID
+	}
+	return 0
+}
+
+// BuildWeeklyReport summarises changes made by staff and admins in the week starting
+// on the Monday weekStart
+func (acr *AdminChangeReportService) BuildWeeklyReport(weekStart time.Time) (*AdminChangeWeeklyReport, error) {
+	weekEnd := weekStart.AddDate(0, 0, 7)
+
+	entityTypes := make([]string, 0, len(adminChangeRules))
+	for _, rule := range adminChangeRules {
+		entityTypes = append(entityTypes, rule.entityType)
+	}
+	var entries []models.AuditLog
+	if err := acr.db.Where("created_at >= ? AND created_at < ? AND entity_type IN ?", weekStart, weekEnd, entityTypes).
+		Order("created_at ASC, id ASC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	changesByActor := map[uint][]AdminChange{}
+	for _, entry := range entries {
+		category := adminChangeCategory(entry.Action, entry.EntityType)
+		actor := auditLogActor(entry)
+		if category == "" || actor == 0 {
+			continue
+		}
+		changesByActor[actor] = append(changesByActor[actor], AdminChange{
+			At:          entry.CreatedAt,
+			Category:    category,
+			Action:      entry.Action,
+			EntityType:  entry.EntityType,
+			EntityID:    entry.EntityID,
+			Description: entry.Description,
+		})
+	}
+
+	var users []models.User
+	if len(changesByActor) > 0 {
+		ids := make([]uint, 0, len(changesByActor))
+		for id := range changesByActor {
+			ids = append(ids, id)
+		}
+		if err := acr.db.Where("id IN ? AND role IN ?", ids, adminChangeRoles).Find(&users).Error; err != nil {
+			return nil, err
+		}
+	}
+	return buildAdminChangeReport(weekStart, users, changesByActor), nil
+}
+
+// buildAdminChangeReport groups changes under the staff and admins who made them
+func buildAdminChangeReport(weekStart time.Time, users []models.User, changesByActor map[uint][]AdminChange) *AdminChangeWeeklyReport {
+	report := &AdminChangeWeeklyReport{
+		WeekStart: weekStart.Format("2006-01-02"),
+		WeekEnd:   weekStart.AddDate(0, 0, 6).Format("2006-01-02"),
+		Counts:    map[string]int{},
+		Admins:    []AdminChangeSummary{},
+	}
+	for _, category := range models.AdminChangeCategories {
+		report.Counts[category] = 0
+	}
+
+	for _, user := range users {
+		changes := changesByActor[user.ID]
+		summary := AdminChangeSummary{
+			UserID:     user.ID,
+			Name:       strings.TrimSpace(user.FirstName + " " + user.LastName),
+			Role:       user.Role,
+			AdminScope: user.AdminScope,
+			Total:      len(changes),
+			Counts:     map[string]int{},
+			Changes:    changes,
+		}
+		for _, category := range models.AdminChangeCategories {
+			summary.Counts[category] = 0
+		}
+		for _, change := range changes {
+			summary.Counts[change.Category]++
+			report.Counts[change.Category]++
+		}
+		report.Total += summary.Total
+		report.Admins = append(report.Admins, summary)
+	}
+	sort.SliceStable(report.Admins, func(i, j int) bool {
+		if report.Admins[i].Total != report.Admins[j].Total {
+			return report.Admins[i].Total > report.Admins[j].Total
+		}
+		return report.Admins[i].Name < report.Admins[j].Name
+	})
+	return report
+}
+
+// GenerateWeeklyReport builds and stores the report for a finished week and emails it
+// to trustees. Generating a week again replaces its report.
+func (acr *AdminChangeReportService) GenerateWeeklyReport(weekStart time.Time, send bool) (*models.AdminChangeReport, error) {
+	weekStart = truncateSLAPeriod(weekStart, slaTrendIntervalWeek)
+	if weekStart.AddDate(0, 0, 7).After(time.Now()) {
+		return nil, ErrAdminChangeReportFuture
+	}
+
+	content, err := acr.BuildWeeklyReport(weekStart)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	record := models.AdminChangeReport{
+		WeekStart:   content.WeekStart,
+		WeekEnd:     content.WeekEnd,
+		Changes:     content.Total,
+		Admins:      len(content.Admins),
+		Content:     string(data),
+		GeneratedAt: time.Now(),
+	}
+	if send {
+		record.EmailedTo = acr.emailReport(content)
+	}
+	if err := acr.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "week_start"}},
+		DoUpdates: clause.AssignmentColumns([]string{"week_end", "changes", "admins", "content", "emailed_to", "generated_at", "updated_at"}),
+	}).Create(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// EnsureWeeklyReport sends last week's report once it is Monday morning, if it has not
+// been sent already. It returns the report when one was generated.
+func (acr *AdminChangeReportService) EnsureWeeklyReport(now time.Time) (*models.AdminChangeReport, error) {
+	thisWeek := truncateSLAPeriod(now, slaTrendIntervalWeek)
+	if now.Before(thisWeek.Add(time.Duration(fairnessIntSetting("ADMIN_CHANGE_REPORT_HOUR", defaultAdminChangeReportHour)) * time.Hour)) {
+		return nil, nil
+	}
+
+	lastWeek := thisWeek.AddDate(0, 0, -7)
+	var existing int64
+	if err := acr.db.Model(&models.AdminChangeReport{}).
+		Where("week_start = ?", lastWeek.Format("2006-01-02")).Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, nil
+	}
+	return acr.GenerateWeeklyReport(lastWeek, true)
+}
+
+// Reports lists the stored weekly reports, newest first
+func (acr *AdminChangeReportService) Reports(limit int) ([]models.AdminChangeReport, error) {
+	var reports []models.AdminChangeReport
+	err := acr.db.Omit("content").Order("week_start DESC").Limit(limit).Find(&reports).Error
+	return reports, err
+}
+
+// Report returns a stored weekly report with its content
+func (acr *AdminChangeReportService) Report(weekStart string) (*models.AdminChangeReport, *AdminChangeWeeklyReport, error) {
+	var record models.AdminChangeReport
+	if err := acr.db.Where("week_start = ?", weekStart).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrAdminChangeReportNotFound
+		}
+		return nil, nil, err
+	}
+
+	var content AdminChangeWeeklyReport
+	if err := json.Unmarshal([]byte(record.Content), &content); err != nil {
+		return nil, nil, err
+	}
+	return &record, &content, nil
+}
+
+// emailReport sends the weekly report to trustees and any addresses in
+// ADMIN_CHANGE_REPORT_RECIPIENTS, returning how many it reached
+func (acr *AdminChangeReportService) emailReport(report *AdminChangeWeeklyReport) int {
+	subject := fmt.Sprintf("Administrative changes for the week of %s", report.WeekStart)
+	body := adminChangeEmailBody(report)
+	sent := 0
+	for _, email := range trusteeRecipients(acr.db, "ADMIN_CHANGE_REPORT_RECIPIENTS") {
+		if err := notifications.GetService().SendEmail(email, subject, body); err != nil {
+			log.Printf("Failed to email the change report to %s: %v", email, err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+// adminChangeEmailBody writes the weekly report as plain text, listing counts for each
+// member of staff. The full list of changes is in the admin reports area.
+func adminChangeEmailBody(report *AdminChangeWeeklyReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Administrative changes for %s to %s\n\n", report.WeekStart, report.WeekEnd)
+	if report.Total == 0 {
+		b.WriteString("No capacity changes, role grants, document decisions or ticket overrides were made this week.\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "%d changes by %d members of staff: %s.\n\n", report.Total, len(report.Admins), adminChangeCounts(report.Counts))
+	for _, admin := range report.Admins {
+		role := models.EnumLabel(admin.Role)
+		if admin.AdminScope != "" {
+			role += ", " + strings.ToLower(models.EnumLabel(admin.AdminScope))
+		}
+		fmt.Fprintf(&b, "- %s (%s): %s\n", admin.Name, role, adminChangeCounts(admin.Counts))
+	}
+	return b.String()
+}
+
+// adminChangeCounts describes the non-zero counts by category, e.g. "capacity 3, role grant 1"
+func adminChangeCounts(counts map[string]int) string {
+	var parts []string
+	for _, category := range models.AdminChangeCategories {
+		if counts[category] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", strings.ToLower(models.EnumLabel(category)), counts[category]))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
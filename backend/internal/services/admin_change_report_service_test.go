@@ -0,0 +1,76 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestAdminChangeCategory(t *testing.T) {
+	cases := []struct {
+		action, entityType, want string
+	}{
+		{"UpdateCapacity", "VisitCapacity", models.AdminChangeCapacity},
+		{"AssignRole", "User", models.AdminChangeRoleGrant},
+		{"VerifyDocuments", "User", models.AdminChangeDocumentDecision},
+		{"Login", "User", ""},
+		{"Reject", "Document", models.AdminChangeDocumentDecision},
+		{"Upload", "Document", ""},
+		{"CancelTicket", "Ticket", models.AdminChangeTicketOverride},
+		{"ReviewFraud", "TicketFraudEvent", models.AdminChangeTicketOverride},
+		{"Create", "Campaign", ""},
+	}
+	for _, tc := range cases {
+		if got := adminChangeCategory(tc.action, tc.entityType); got != tc.want {
+			t.Errorf("adminChangeCategory(%s, %s) = %q, want %q", tc.action, tc.entityType, got, tc.want)
+		}
+	}
+}
+
+func TestAuditLogActor(t *testing.T) {
+	cases := []struct {
+		entry models.AuditLog
+		want  uint
+	}{
+		{models.AuditLog{DetailsJSON: `{"request_data":{},"user_id":42}`, PerformedBy: "Ann Admin"}, 42},
+		{models.AuditLog{DetailsJSON: `{"request_data":{},"user_id":null}`, PerformedBy: "Anonymous"}, 0},
+		{models.AuditLog{PerformedBy: "user_7"}, 7},
+		{models.AuditLog{DetailsJSON: "not json", PerformedBy: "Unknown User"}, 0},
+	}
+	for _, tc := range cases {
+		if got := auditLogActor(tc.entry); got != tc.want {
+			t.Errorf("auditLogActor(%+v) = %d, want %d", tc.entry, got, tc.want)
+		}
+	}
+}
+
+func TestBuildAdminChangeReport(t *testing.T) {
+	weekStart := time.Date(2026, time.October, 5, 0, 0, 0, 0, time.Local)
+	users := []models.User{
+		{ID: 1, FirstName: "Ann", LastName: "Admin", Role: models.RoleAdmin},
+		{ID: 2, FirstName: "Sam", LastName: "Staff", Role: models.RoleStaff},
+	}
+	changes := map[uint][]AdminChange{
+		1: {{Category: models.AdminChangeCapacity}},
+		2: {{Category: models.AdminChangeDocumentDecision}, {Category: models.AdminChangeDocumentDecision}, {Category: models.AdminChangeTicketOverride}},
+		3: {{Category: models.AdminChangeRoleGrant}}, // Not staff, so left out
+	}
+
+	report := buildAdminChangeReport(weekStart, users, changes)
+	if report.WeekEnd != "2026-10-11" || report.Total != 4 || len(report.Admins) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Admins[0].UserID != 2 || report.Admins[0].Counts[models.AdminChangeDocumentDecision] != 2 {
+		t.Errorf("expected Sam first with 2 document decisions, got %+v", report.Admins[0])
+	}
+	if report.Counts[models.AdminChangeRoleGrant] != 0 || report.Counts[models.AdminChangeCapacity] != 1 {
+		t.Errorf("unexpected totals: %v", report.Counts)
+	}
+
+	body := adminChangeEmailBody(report)
+	if !strings.Contains(body, "Sam Staff (Staff): document decision 2, ticket override 1") {
+		t.Errorf("email body missing Sam's counts:\n%s", body)
+	}
+}
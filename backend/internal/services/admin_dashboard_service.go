@@ -82,6 +82,9 @@ func (s *AdminDashboardService) GetAdminDashboardData() (gin.H, error) {
 	// Get volunteer coverage gaps
 	coverageGaps := s.getVolunteerCoverageGaps()
 
+	// Get days with capacity overrides, split from their base capacity
+	capacityOverrides := s.getCapacityOverrides()
+
 	// Get feedback metrics
 	var feedbackCount int64
 	var averageRating float64
@@ -122,12 +125,13 @@ func (s *AdminDashboardService) GetAdminDashboardData() (gin.H, error) {
 			"averageRating":     averageRating,
 			"systemUptime":      uptime,
 		},
-		"alerts":           alerts,
-		"recentActivity":   recentActivity,
-		"capacityWarnings": capacityWarnings,
-		"coverageGaps":     coverageGaps,
-		"queueStatus":      s.getTicketQueueStatus(),
-		"systemHealth":     s.getSystemHealthMetrics(),
+		"alerts":            alerts,
+		"recentActivity":    recentActivity,
+		"capacityWarnings":  capacityWarnings,
+		"capacityOverrides": capacityOverrides,
+		"coverageGaps":      coverageGaps,
+		"queueStatus":       s.getTicketQueueStatus(),
+		"systemHealth":      s.getSystemHealthMetrics(),
 	}, nil
 }
 
@@ -165,7 +169,9 @@ func (s *AdminDashboardService) calculateAdminKPIs(todayStr string) AdminKPIs {
 		Count(&kpis.AssignedShifts)
 
 	// General metrics
-	kpis.UrgentNeeds = 3 // Placeholder for removed inventory system
+	if urgent, err := (&InventoryService{db: s.db}).UrgentCount(); err == nil {
+		kpis.UrgentNeeds = urgent
+	}
 	s.db.Model(&models.User{}).Where("role = ? AND status = ?", models.RoleVolunteer, "active").Count(&kpis.ActiveVolunteers)
 	s.db.Model(&models.User{}).Where("role = ?", models.RoleVisitor).Count(&kpis.TotalVisitors)
 
@@ -195,10 +201,10 @@ func (s *AdminDashboardService) generateSystemAlerts(todayRequests, assignedShif
 		})
 	}
 
-	if urgentNeeds > 3 {
+	if urgentNeeds > 0 {
 		alerts = append(alerts, gin.H{
 			"type":    "warning",
-			"message": fmt.Sprintf("%d urgent inventory needs require attention", urgentNeeds),
+			"message": fmt.Sprintf("%d stock items are at or below their minimum level", urgentNeeds),
 		})
 	}
 
@@ -297,6 +303,17 @@ func (s *AdminDashboardService) getCapacityWarnings() []gin.H {
 	return warnings
 }
 
+// getCapacityOverrides returns the next 7 days that have capacity overrides approved
+// or waiting, with base and override capacity shown separately
+func (s *AdminDashboardService) getCapacityOverrides() []CapacityOverrideDay {
+	today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	days, err := (&CapacityOverrideService{db: s.db}).Days(today, 7)
+	if err != nil || days == nil {
+		return []CapacityOverrideDay{}
+	}
+	return days
+}
+
 func (s *AdminDashboardService) getVolunteerCoverageGaps() []gin.H {
 	var gaps []gin.H
 
@@ -0,0 +1,763 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Analytics export settings
+const (
+	analyticsCapturePageSize      = 500
+	defaultAnalyticsBatchSize     = 1000
+	maxAnalyticsBatchSize         = 10000
+	maxAnalyticsBatchesPerRun     = 20
+	defaultAnalyticsExportFormat  = "jsonl"
+	defaultAnalyticsExportPrefix  = "analytics"
+	defaultAnalyticsExportDir     = "./exports/analytics"
+	analyticsPIIModePseudonymous  = "pseudonymous"
+	analyticsPIIModeStrict        = "strict"
+	analyticsSinkNone             = "none"
+	analyticsSinkFile             = "file"
+	analyticsSinkS3               = "s3"
+	analyticsSinkBigQuery         = "bigquery"
+	analyticsStrictTimeResolution = time.Hour
+)
+
+var (
+	ErrAnalyticsNoSalt         = errors.New("ANALYTICS_HASH_SALT must be set before analytics events are captured")
+	ErrAnalyticsNoSink         = errors.New("no analytics export sink is configured")
+	ErrAnalyticsUnknownStream  = errors.New("unknown analytics stream")
+	ErrAnalyticsExportRunning  = errors.New("an analytics export is already running")
+	ErrAnalyticsInvalidRange   = errors.New("backfill range is invalid")
+	ErrAnalyticsUnknownFormat  = errors.New("analytics export format must be csv or jsonl; parquet is not supported")
+	ErrAnalyticsUnknownSink    = errors.New("analytics export sink must be none, file, s3 or bigquery")
+	ErrAnalyticsUnknownPIIMode = errors.New("analytics PII mode must be pseudonymous or strict")
+)
+
+// analyticsExportMu stops the scheduled job and a manual run exporting at once
+var analyticsExportMu sync.Mutex
+
+// AnalyticsExportConfig is the analytics export configuration read from the environment
+type AnalyticsExportConfig struct {
+	Sink          string   `json:"sink"`
+	Format        string   `json:"format"` // csv or jsonl; parquet is not supported
+	BatchSize     int      `json:"batch_size"`
+	Prefix        string   `json:"prefix"`
+	Dir           string   `json:"dir,omitempty"`
+	PIIMode       string   `json:"pii_mode"`
+	ExcludeFields []string `json:"exclude_fields"`
+
+	S3Bucket       string `json:"s3_bucket,omitempty"`
+	S3Region       string `json:"s3_region,omitempty"`
+	S3Endpoint     string `json:"s3_endpoint,omitempty"`
+	S3AccessKey    string `json:"-"`
+	S3SecretKey    string `json:"-"`
+	S3SessionToken string `json:"-"`
+
+	BigQueryProject     string `json:"bigquery_project,omitempty"`
+	BigQueryDataset     string `json:"bigquery_dataset,omitempty"`
+	BigQueryTable       string `json:"bigquery_table,omitempty"`
+	BigQueryCredentials string `json:"-"`
+
+	hashSalt string
+}
+
+// LoadAnalyticsExportConfig reads the analytics export settings from the environment
+func LoadAnalyticsExportConfig() (*AnalyticsExportConfig, error) {
+	cfg := &AnalyticsExportConfig{
+		Sink:                strings.ToLower(envOr("ANALYTICS_EXPORT_SINK", analyticsSinkNone)),
+		Format:              strings.ToLower(envOr("ANALYTICS_EXPORT_FORMAT", defaultAnalyticsExportFormat)),
+		BatchSize:           defaultAnalyticsBatchSize,
+		Prefix:              strings.Trim(envOr("ANALYTICS_EXPORT_PREFIX", defaultAnalyticsExportPrefix), "/"),
+		PIIMode:             strings.ToLower(envOr("ANALYTICS_PII_MODE", analyticsPIIModePseudonymous)),
+		ExcludeFields:       []string{},
+		S3Bucket:            os.Getenv("ANALYTICS_S3_BUCKET"),
+		S3Region:            envOr("ANALYTICS_S3_REGION", os.Getenv("AWS_REGION")),
+		S3Endpoint:          strings.TrimRight(os.Getenv("ANALYTICS_S3_ENDPOINT"), "/"),
+		S3AccessKey:         os.Getenv("AWS_ACCESS_KEY_ID"),
+		S3SecretKey:         os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		S3SessionToken:      os.Getenv("AWS_SESSION_TOKEN"),
+		BigQueryProject:     os.Getenv("ANALYTICS_BIGQUERY_PROJECT"),
+		BigQueryDataset:     os.Getenv("ANALYTICS_BIGQUERY_DATASET"),
+		BigQueryTable:       envOr("ANALYTICS_BIGQUERY_TABLE", "events"),
+		BigQueryCredentials: os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"),
+		hashSalt:            os.Getenv("ANALYTICS_HASH_SALT"),
+	}
+	if cfg.Sink == analyticsSinkFile {
+		cfg.Dir = envOr("ANALYTICS_EXPORT_DIR", defaultAnalyticsExportDir)
+	}
+	if size, err := strconv.Atoi(os.Getenv("ANALYTICS_EXPORT_BATCH_SIZE")); err == nil && size > 0 {
+		cfg.BatchSize = size
+		if size > maxAnalyticsBatchSize {
+			cfg.BatchSize = maxAnalyticsBatchSize
+		}
+	}
+	for _, field := range strings.Split(os.Getenv("ANALYTICS_EXCLUDE_FIELDS"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			cfg.ExcludeFields = append(cfg.ExcludeFields, field)
+		}
+	}
+
+	switch cfg.Sink {
+	case analyticsSinkNone, analyticsSinkFile, analyticsSinkS3, analyticsSinkBigQuery:
+	default:
+		return cfg, ErrAnalyticsUnknownSink
+	}
+	if cfg.Format != "csv" && cfg.Format != "jsonl" {
+		return cfg, ErrAnalyticsUnknownFormat
+	}
+	if cfg.PIIMode != analyticsPIIModePseudonymous && cfg.PIIMode != analyticsPIIModeStrict {
+		return cfg, ErrAnalyticsUnknownPIIMode
+	}
+	return cfg, nil
+}
+
+// envOr returns an environment variable or a default when it is unset
+func envOr(key, defaultValue string) string {
+	if val := strings.TrimSpace(os.Getenv(key)); val != "" {
+		return val
+	}
+	return defaultValue
+}
+
+// AnalyticsExportService captures anonymized domain events into an append-only log and
+// exports them in batches to the configured sink
+type AnalyticsExportService struct {
+	db  *gorm.DB
+	cfg *AnalyticsExportConfig
+}
+
+// AnalyticsExportRow is the shape of an exported event, the same in every sink
+type AnalyticsExportRow struct {
+	EventID       uint            `json:"event_id"`
+	EventKey      string          `json:"event_key"`
+	Stream        string          `json:"stream"`
+	EventType     string          `json:"event_type"`
+	SchemaVersion int             `json:"schema_version"`
+	EntityKey     string          `json:"entity_key"`
+	SubjectKey    string          `json:"subject_key"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Backfill      bool            `json:"backfill"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// AnalyticsStreamStatus summarises capture and export progress for one stream
+type AnalyticsStreamStatus struct {
+	Stream         string     `json:"stream"`
+	SchemaVersion  int        `json:"schema_version"`
+	CapturedUpTo   *time.Time `json:"captured_up_to"`
+	Events         int64      `json:"events"`
+	PendingExport  int64      `json:"pending_export"`
+	LastOccurredAt *time.Time `json:"last_occurred_at"`
+}
+
+// AnalyticsExportStatus is the overall analytics export status
+type AnalyticsExportStatus struct {
+	Config      *AnalyticsExportConfig       `json:"config"`
+	ConfigError string                       `json:"config_error,omitempty"`
+	SaltSet     bool                         `json:"salt_set"`
+	Streams     []AnalyticsStreamStatus      `json:"streams"`
+	LastBatch   *models.AnalyticsExportBatch `json:"last_batch"`
+}
+
+// AnalyticsExportResult reports what one capture and export run did
+type AnalyticsExportResult struct {
+	Captured map[string]int                `json:"captured"`
+	Batches  []models.AnalyticsExportBatch `json:"batches"`
+	Exported int                           `json:"exported"`
+}
+
+// analyticsRecord is a source row mapped to an event before it is scrubbed
+type analyticsRecord struct {
+	sourceID   uint
+	subjectID  uint
+	eventType  string
+	occurredAt time.Time
+	updatedAt  time.Time
+	fields     map[string]interface{}
+}
+
+// NewAnalyticsExportService creates a new analytics export service
+func NewAnalyticsExportService() (*AnalyticsExportService, error) {
+	cfg, err := LoadAnalyticsExportConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &AnalyticsExportService{
+		db:  db.DB,
+		cfg: cfg,
+	}, nil
+}
+
+// Config returns the settings the service is using
+func (aes *AnalyticsExportService) Config() *AnalyticsExportConfig {
+	return aes.cfg
+}
+
+// Run captures new events from every stream and exports everything not yet sent
+func (aes *AnalyticsExportService) Run(ctx context.Context) (*AnalyticsExportResult, error) {
+	if !analyticsExportMu.TryLock() {
+		return nil, ErrAnalyticsExportRunning
+	}
+	defer analyticsExportMu.Unlock()
+
+	result := &AnalyticsExportResult{Captured: map[string]int{}, Batches: []models.AnalyticsExportBatch{}}
+	for _, stream := range models.AnalyticsStreams {
+		captured, err := aes.Capture(stream)
+		if err != nil {
+			return result, fmt.Errorf("capture %s: %w", stream, err)
+		}
+		result.Captured[stream] = captured
+	}
+
+	if aes.cfg.Sink == analyticsSinkNone {
+		return result, nil
+	}
+	batches, err := aes.exportPending(ctx)
+	result.Batches = batches
+	for _, batch := range batches {
+		if batch.Status == models.AnalyticsBatchSucceeded {
+			result.Exported += batch.EventCount
+		}
+	}
+	return result, err
+}
+
+// Capture reads source rows changed since the stream's cursor and appends an event for
+// each new state they have reached. It returns how many events were added.
+func (aes *AnalyticsExportService) Capture(stream string) (int, error) {
+	if !models.IsAnalyticsStream(stream) {
+		return 0, ErrAnalyticsUnknownStream
+	}
+	if aes.cfg.hashSalt == "" {
+		return 0, ErrAnalyticsNoSalt
+	}
+
+	var cursor models.AnalyticsCaptureCursor
+	if err := aes.db.Where("stream = ?", stream).FirstOrInit(&cursor, models.AnalyticsCaptureCursor{Stream: stream}).Error; err != nil {
+		return 0, err
+	}
+
+	captured := 0
+	for {
+		records, err := aes.loadRecords(stream, func(q *gorm.DB) *gorm.DB {
+			return q.Where("updated_at > ? OR (updated_at = ? AND id > ?)", cursor.LastUpdatedAt, cursor.LastUpdatedAt, cursor.LastSourceID).
+				Order("updated_at ASC, id ASC")
+		})
+		if err != nil {
+			return captured, err
+		}
+		if len(records) == 0 {
+			break
+		}
+
+		added, err := aes.appendEvents(stream, records, false)
+		captured += added
+		if err != nil {
+			return captured, err
+		}
+
+		last := records[len(records)-1]
+		cursor.LastUpdatedAt, cursor.LastSourceID = last.updatedAt, last.sourceID
+		if err := aes.db.Save(&cursor).Error; err != nil {
+			return captured, err
+		}
+		if len(records) < analyticsCapturePageSize {
+			break
+		}
+	}
+
+	return captured, nil
+}
+
+// Backfill captures events for source rows created between two times without moving
+// the stream's cursor. Events that were already captured are left alone; the rest are
+// flagged as backfill and go out with the next export.
+func (aes *AnalyticsExportService) Backfill(stream string, from, to time.Time) (int, error) {
+	if !models.IsAnalyticsStream(stream) {
+		return 0, ErrAnalyticsUnknownStream
+	}
+	if !from.Before(to) {
+		return 0, ErrAnalyticsInvalidRange
+	}
+	if aes.cfg.hashSalt == "" {
+		return 0, ErrAnalyticsNoSalt
+	}
+
+	captured := 0
+	var lastID uint
+	for {
+		records, err := aes.loadRecords(stream, func(q *gorm.DB) *gorm.DB {
+			return q.Where("created_at >= ? AND created_at < ? AND id > ?", from, to, lastID).Order("id ASC")
+		})
+		if err != nil {
+			return captured, err
+		}
+		if len(records) == 0 {
+			break
+		}
+
+		added, err := aes.appendEvents(stream, records, true)
+		captured += added
+		if err != nil {
+			return captured, err
+		}
+
+		lastID = records[len(records)-1].sourceID
+		if len(records) < analyticsCapturePageSize {
+			break
+		}
+	}
+
+	return captured, nil
+}
+
+// loadRecords reads one page of a stream's source table and maps the rows to records
+func (aes *AnalyticsExportService) loadRecords(stream string, scope func(*gorm.DB) *gorm.DB) ([]analyticsRecord, error) {
+	switch stream {
+	case models.AnalyticsStreamVisits:
+		var visits []models.Visit
+		if err := scope(aes.db.Model(&models.Visit{})).Limit(analyticsCapturePageSize).Find(&visits).Error; err != nil {
+			return nil, err
+		}
+		records := make([]analyticsRecord, 0, len(visits))
+		for i := range visits {
+			records = append(records, visitRecord(&visits[i]))
+		}
+		return records, nil
+
+	case models.AnalyticsStreamHelpRequests:
+		var requests []models.HelpRequest
+		if err := scope(aes.db.Model(&models.HelpRequest{})).Limit(analyticsCapturePageSize).Find(&requests).Error; err != nil {
+			return nil, err
+		}
+		records := make([]analyticsRecord, 0, len(requests))
+		for i := range requests {
+			records = append(records, helpRequestRecord(&requests[i]))
+		}
+		return records, nil
+
+	case models.AnalyticsStreamDonations:
+		var donations []models.Donation
+		if err := scope(aes.db.Model(&models.Donation{})).Limit(analyticsCapturePageSize).Find(&donations).Error; err != nil {
+			return nil, err
+		}
+		records := make([]analyticsRecord, 0, len(donations))
+		for i := range donations {
+			records = append(records, donationRecord(&donations[i]))
+		}
+		return records, nil
+	}
+
+	return nil, ErrAnalyticsUnknownStream
+}
+
+// visitRecord maps a visit to an analytics record
+func visitRecord(visit *models.Visit) analyticsRecord {
+	occurredAt := visit.CheckInTime
+	if visit.CheckOutTime != nil {
+		occurredAt = *visit.CheckOutTime
+	}
+	fields := map[string]interface{}{
+		"status":          visit.Status,
+		"check_in_method": visit.CheckInMethod,
+		"check_in_hour":   visit.CheckInTime.Hour(),
+		"check_in_day":    strings.ToLower(visit.CheckInTime.Weekday().String()),
+	}
+	if visit.Duration != nil {
+		fields["duration_minutes"] = *visit.Duration
+	}
+	return analyticsRecord{
+		sourceID:   visit.ID,
+		subjectID:  visit.VisitorID,
+		eventType:  "visit." + visit.Status,
+		occurredAt: occurredAt,
+		updatedAt:  visit.UpdatedAt,
+		fields:     fields,
+	}
+}
+
+// helpRequestRecord maps a help request to an analytics record
+func helpRequestRecord(request *models.HelpRequest) analyticsRecord {
+	occurredAt := request.UpdatedAt
+	switch {
+	case request.Status == models.HelpRequestStatusApproved && request.ApprovedAt != nil:
+		occurredAt = *request.ApprovedAt
+	case request.Status == models.HelpRequestStatusRejected && request.RejectedAt != nil:
+		occurredAt = *request.RejectedAt
+	case request.Status == models.HelpRequestStatusPending:
+		occurredAt = request.CreatedAt
+	}
+	return analyticsRecord{
+		sourceID:   request.ID,
+		subjectID:  request.VisitorID,
+		eventType:  "help_request." + request.Status,
+		occurredAt: occurredAt,
+		updatedAt:  request.UpdatedAt,
+		fields: map[string]interface{}{
+			"status":            request.Status,
+			"category":          request.Category,
+			"priority":          request.Priority,
+			"household_size":    request.HouseholdSize,
+			"visit_day":         request.VisitDay,
+			"time_slot":         request.TimeSlot,
+			"request_date":      request.RequestDate.Format("2006-01-02"),
+			"has_special_needs": strings.TrimSpace(request.SpecialNeeds) != "",
+			"postcode":          request.Postcode, // Reduced to a district or area when scrubbed
+		},
+	}
+}
+
+// donationRecord maps a donation to an analytics record
+func donationRecord(donation *models.Donation) analyticsRecord {
+	var subjectID uint
+	if !donation.IsAnonymous {
+		if donation.DonorID != nil {
+			subjectID = *donation.DonorID
+		} else if donation.UserID != nil {
+			subjectID = *donation.UserID
+		}
+	}
+	return analyticsRecord{
+		sourceID:   donation.ID,
+		subjectID:  subjectID,
+		eventType:  "donation." + donation.Status,
+		occurredAt: donation.UpdatedAt,
+		updatedAt:  donation.UpdatedAt,
+		fields: map[string]interface{}{
+			"status":          donation.Status,
+			"type":            donation.Type,
+			"amount":          donation.Amount,
+			"currency":        donation.Currency,
+			"goods_value":     donation.GoodsValue,
+			"quantity":        donation.Quantity,
+			"payment_method":  donation.PaymentMethod,
+			"is_recurring":    donation.IsRecurring,
+			"is_anonymous":    donation.IsAnonymous,
+			"refunded_amount": donation.RefundedAmount,
+		},
+	}
+}
+
+// appendEvents scrubs records and inserts them as events, skipping any state already
+// captured. It returns how many events were added.
+func (aes *AnalyticsExportService) appendEvents(stream string, records []analyticsRecord, backfill bool) (int, error) {
+	events := make([]models.AnalyticsEvent, 0, len(records))
+	for _, record := range records {
+		event, err := aes.scrub(stream, record)
+		if err != nil {
+			log.Printf("Skipping %s analytics event for source %d: %v", stream, record.sourceID, err)
+			continue
+		}
+		event.Backfill = backfill
+		events = append(events, event)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	result := aes.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&events)
+	return int(result.RowsAffected), result.Error
+}
+
+// scrub turns a record into an event carrying no direct identifiers. Source IDs are
+// replaced with keyed hashes, postcodes are cut down and excluded fields are dropped.
+// In strict mode the subject is dropped entirely and times are rounded to the hour.
+func (aes *AnalyticsExportService) scrub(stream string, record analyticsRecord) (models.AnalyticsEvent, error) {
+	fields := make(map[string]interface{}, len(record.fields))
+	for name, value := range record.fields {
+		fields[name] = value
+	}
+
+	if postcode, ok := fields["postcode"].(string); ok {
+		delete(fields, "postcode")
+		if aes.cfg.PIIMode == analyticsPIIModeStrict {
+			fields["postcode_area"] = postcodeArea(postcode)
+		} else {
+			fields["postcode_district"] = postcodeDistrict(postcode)
+		}
+	}
+	for _, name := range aes.cfg.ExcludeFields {
+		delete(fields, name)
+	}
+
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return models.AnalyticsEvent{}, err
+	}
+
+	event := models.AnalyticsEvent{
+		EventKey:      aes.pseudonym(stream, "event", record.sourceID, record.eventType),
+		Stream:        stream,
+		EventType:     record.eventType,
+		SchemaVersion: models.AnalyticsSchemaVersions[stream],
+		EntityKey:     aes.pseudonym(stream, "entity", record.sourceID, ""),
+		OccurredAt:    record.occurredAt.UTC(),
+		Payload:       string(payload),
+	}
+	if aes.cfg.PIIMode == analyticsPIIModeStrict {
+		event.OccurredAt = event.OccurredAt.Truncate(analyticsStrictTimeResolution)
+	} else if record.subjectID != 0 {
+		event.SubjectKey = aes.pseudonym("subject", "user", record.subjectID, "")
+	}
+	return event, nil
+}
+
+// pseudonym returns a keyed hash of an ID so exported rows can be joined with each
+// other but not traced back without the salt
+func (aes *AnalyticsExportService) pseudonym(namespace, kind string, id uint, extra string) string {
+	mac := hmac.New(sha256.New, []byte(aes.cfg.hashSalt))
+	fmt.Fprintf(mac, "%s:%s:%d:%s", namespace, kind, id, extra)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// postcodeDistrict returns the outward part of a UK postcode, e.g. "SW1A" for "SW1A 1AA"
+func postcodeDistrict(postcode string) string {
+	postcode = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(postcode), " ", ""))
+	if len(postcode) > 3 {
+		return postcode[:len(postcode)-3]
+	}
+	return ""
+}
+
+// postcodeArea returns the leading letters of a UK postcode, e.g. "SW" for "SW1A 1AA"
+func postcodeArea(postcode string) string {
+	district := postcodeDistrict(postcode)
+	for i, r := range district {
+		if r < 'A' || r > 'Z' {
+			return district[:i]
+		}
+	}
+	return district
+}
+
+// exportPending sends events not yet exported to the sink in batches
+func (aes *AnalyticsExportService) exportPending(ctx context.Context) ([]models.AnalyticsExportBatch, error) {
+	sink, err := newAnalyticsSink(aes.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	lastID := aes.lastExportedID(sink.Name())
+	batches := []models.AnalyticsExportBatch{}
+	for i := 0; i < maxAnalyticsBatchesPerRun; i++ {
+		var events []models.AnalyticsEvent
+		if err := aes.db.Where("id > ?", lastID).Order("id ASC").Limit(aes.cfg.BatchSize).Find(&events).Error; err != nil {
+			return batches, err
+		}
+		if len(events) == 0 {
+			break
+		}
+
+		batch := models.AnalyticsExportBatch{
+			Sink:        sink.Name(),
+			Format:      aes.cfg.Format,
+			Status:      models.AnalyticsBatchRunning,
+			FromEventID: events[0].ID,
+			ToEventID:   events[len(events)-1].ID,
+			EventCount:  len(events),
+			StartedAt:   time.Now(),
+		}
+		if err := aes.db.Create(&batch).Error; err != nil {
+			return batches, err
+		}
+
+		rows := make([]AnalyticsExportRow, 0, len(events))
+		for _, event := range events {
+			rows = append(rows, AnalyticsExportRow{
+				EventID:       event.ID,
+				EventKey:      event.EventKey,
+				Stream:        event.Stream,
+				EventType:     event.EventType,
+				SchemaVersion: event.SchemaVersion,
+				EntityKey:     event.EntityKey,
+				SubjectKey:    event.SubjectKey,
+				OccurredAt:    event.OccurredAt,
+				Backfill:      event.Backfill,
+				Payload:       json.RawMessage(event.Payload),
+			})
+		}
+
+		location, writeErr := sink.Write(ctx, &batch, rows)
+		now := time.Now()
+		batch.CompletedAt = &now
+		batch.Location = location
+		batch.Status = models.AnalyticsBatchSucceeded
+		if writeErr != nil {
+			batch.Status = models.AnalyticsBatchFailed
+			batch.Error = writeErr.Error()
+		}
+		if err := aes.db.Save(&batch).Error; err != nil {
+			return batches, err
+		}
+		batches = append(batches, batch)
+		if writeErr != nil {
+			return batches, fmt.Errorf("export batch %d: %w", batch.ID, writeErr)
+		}
+
+		lastID = batch.ToEventID
+	}
+
+	return batches, nil
+}
+
+// lastExportedID returns the last event ID successfully sent to a sink
+func (aes *AnalyticsExportService) lastExportedID(sink string) uint {
+	var lastID uint
+	aes.db.Model(&models.AnalyticsExportBatch{}).
+		Where("sink = ? AND status = ?", sink, models.AnalyticsBatchSucceeded).
+		Select("COALESCE(MAX(to_event_id), 0)").
+		Scan(&lastID)
+	return lastID
+}
+
+// Status reports capture and export progress for every stream
+func (aes *AnalyticsExportService) Status() (*AnalyticsExportStatus, error) {
+	status := &AnalyticsExportStatus{
+		Config:  aes.cfg,
+		SaltSet: aes.cfg.hashSalt != "",
+		Streams: make([]AnalyticsStreamStatus, 0, len(models.AnalyticsStreams)),
+	}
+
+	var lastID uint
+	if aes.cfg.Sink != analyticsSinkNone {
+		lastID = aes.lastExportedID(aes.cfg.Sink)
+	}
+
+	for _, stream := range models.AnalyticsStreams {
+		row := AnalyticsStreamStatus{
+			Stream:        stream,
+			SchemaVersion: models.AnalyticsSchemaVersions[stream],
+		}
+
+		var cursor models.AnalyticsCaptureCursor
+		if err := aes.db.Where("stream = ?", stream).First(&cursor).Error; err == nil {
+			row.CapturedUpTo = &cursor.LastUpdatedAt
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		aes.db.Model(&models.AnalyticsEvent{}).Where("stream = ?", stream).Count(&row.Events)
+		if aes.cfg.Sink != analyticsSinkNone {
+			aes.db.Model(&models.AnalyticsEvent{}).Where("stream = ? AND id > ?", stream, lastID).Count(&row.PendingExport)
+		}
+
+		var latest models.AnalyticsEvent
+		if err := aes.db.Where("stream = ?", stream).Order("occurred_at DESC").First(&latest).Error; err == nil {
+			row.LastOccurredAt = &latest.OccurredAt
+		}
+
+		status.Streams = append(status.Streams, row)
+	}
+
+	var batch models.AnalyticsExportBatch
+	if err := aes.db.Order("id DESC").First(&batch).Error; err == nil {
+		status.LastBatch = &batch
+	}
+
+	return status, nil
+}
+
+// AnalyticsSchemaField describes one field of an exported event
+type AnalyticsSchemaField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Excluded    bool   `json:"excluded,omitempty"` // Dropped by ANALYTICS_EXCLUDE_FIELDS
+}
+
+// AnalyticsSchema describes the current payload of one stream
+type AnalyticsSchema struct {
+	Stream  string                 `json:"stream"`
+	Version int                    `json:"version"`
+	Fields  []AnalyticsSchemaField `json:"fields"`
+}
+
+// analyticsEnvelopeFields are the columns every exported row has
+var analyticsEnvelopeFields = []AnalyticsSchemaField{
+	{Name: "event_id", Type: "integer", Description: "Position in the event log"},
+	{Name: "event_key", Type: "string", Description: "Unique key for de-duplicating rows"},
+	{Name: "stream", Type: "string", Description: "visits, help_requests or donations"},
+	{Name: "event_type", Type: "string", Description: "Stream and state reached, e.g. visit.completed"},
+	{Name: "schema_version", Type: "integer", Description: "Version of the payload schema"},
+	{Name: "entity_key", Type: "string", Description: "Pseudonymous ID of the visit, request or donation"},
+	{Name: "subject_key", Type: "string", Description: "Pseudonymous ID of the visitor or donor; empty in strict mode or for anonymous donations"},
+	{Name: "occurred_at", Type: "timestamp", Description: "When the state was reached (UTC); rounded to the hour in strict mode"},
+	{Name: "backfill", Type: "boolean", Description: "Captured by a backfill rather than as it happened"},
+	{Name: "payload", Type: "json", Description: "Stream-specific fields below"},
+}
+
+// analyticsPayloadFields are the payload fields of each stream at its current version
+var analyticsPayloadFields = map[string][]AnalyticsSchemaField{
+	models.AnalyticsStreamVisits: {
+		{Name: "status", Type: "string", Description: "checked_in, in_service, completed or no_show"},
+		{Name: "check_in_method", Type: "string", Description: "qr_scan or manual_entry"},
+		{Name: "check_in_hour", Type: "integer", Description: "Hour of check-in"},
+		{Name: "check_in_day", Type: "string", Description: "Weekday of check-in"},
+		{Name: "duration_minutes", Type: "integer", Description: "Visit length, once checked out"},
+	},
+	models.AnalyticsStreamHelpRequests: {
+		{Name: "status", Type: "string", Description: "Request status"},
+		{Name: "category", Type: "string", Description: "Service category"},
+		{Name: "priority", Type: "string", Description: "Request priority"},
+		{Name: "household_size", Type: "integer", Description: "People in the household"},
+		{Name: "visit_day", Type: "string", Description: "Requested visit day"},
+		{Name: "time_slot", Type: "string", Description: "Requested time slot"},
+		{Name: "request_date", Type: "date", Description: "Date the request was made"},
+		{Name: "has_special_needs", Type: "boolean", Description: "Whether special needs were given; the text is never exported"},
+		{Name: "postcode_district", Type: "string", Description: "Outward postcode, e.g. SW1A (pseudonymous mode)"},
+		{Name: "postcode_area", Type: "string", Description: "Postcode area, e.g. SW (strict mode)"},
+	},
+	models.AnalyticsStreamDonations: {
+		{Name: "status", Type: "string", Description: "Donation status"},
+		{Name: "type", Type: "string", Description: "money, goods, time, etc."},
+		{Name: "amount", Type: "number", Description: "Monetary amount"},
+		{Name: "currency", Type: "string", Description: "Currency code"},
+		{Name: "goods_value", Type: "number", Description: "Estimated value of goods"},
+		{Name: "quantity", Type: "integer", Description: "Number of items"},
+		{Name: "payment_method", Type: "string", Description: "cash, card, bank transfer"},
+		{Name: "is_recurring", Type: "boolean", Description: "Part of a recurring donation"},
+		{Name: "is_anonymous", Type: "boolean", Description: "Donor asked to stay anonymous"},
+		{Name: "refunded_amount", Type: "number", Description: "Amount refunded"},
+	},
+}
+
+// Schemas describes the exported row and each stream's payload
+func (aes *AnalyticsExportService) Schemas() ([]AnalyticsSchemaField, []AnalyticsSchema) {
+	excluded := make(map[string]bool, len(aes.cfg.ExcludeFields))
+	for _, name := range aes.cfg.ExcludeFields {
+		excluded[name] = true
+	}
+
+	schemas := make([]AnalyticsSchema, 0, len(models.AnalyticsStreams))
+	for _, stream := range models.AnalyticsStreams {
+		fields := make([]AnalyticsSchemaField, 0, len(analyticsPayloadFields[stream]))
+		for _, field := range analyticsPayloadFields[stream] {
+			field.Excluded = excluded[field.Name]
+			fields = append(fields, field)
+		}
+		schemas = append(schemas, AnalyticsSchema{
+			Stream:  stream,
+			Version: models.AnalyticsSchemaVersions[stream],
+			Fields:  fields,
+		})
+	}
+	return analyticsEnvelopeFields, schemas
+}
@@ -241,14 +241,19 @@ func (as *AnalyticsService) HealthCheck(ctx context.Context) error {
 
 // GetDashboardMetrics returns comprehensive dashboard metrics
 func (as *AnalyticsService) GetDashboardMetrics(timeRange string) (*AnalyticsDashboardMetrics, error) {
-	// Try to get from cache first
+	// Concurrent misses share one build of the metrics
 	var metrics AnalyticsDashboardMetrics
 	cacheKey := fmt.Sprintf("dashboard_metrics_%s", timeRange)
-
-	if err := as.cacheService.Get(cacheKey, &metrics); err == nil {
-		return &metrics, nil
+	if _, err := as.cacheService.GetOrLoad(cacheKey, 15*time.Minute, &metrics, func() (interface{}, error) {
+		return as.buildDashboardMetrics(timeRange)
+	}, CacheTagDashboard); err != nil {
+		return nil, err
 	}
+	return &metrics, nil
+}
 
+// buildDashboardMetrics gathers the dashboard metrics for a time range
+func (as *AnalyticsService) buildDashboardMetrics(timeRange string) (*AnalyticsDashboardMetrics, error) {
 	// Calculate date ranges
 	endDate := time.Now()
 	var startDate time.Time
@@ -316,7 +321,7 @@ func (as *AnalyticsService) GetDashboardMetrics(timeRange string) (*AnalyticsDas
 		systemMetrics = &SystemMetrics{}
 	}
 
-	metrics = AnalyticsDashboardMetrics{
+	metrics := AnalyticsDashboardMetrics{
 		Users:         *userMetrics,
 		Volunteers:    *volunteerMetrics,
 		Visitors:      *visitorMetrics,
@@ -329,9 +334,6 @@ func (as *AnalyticsService) GetDashboardMetrics(timeRange string) (*AnalyticsDas
 		GeneratedAt:   time.Now(),
 	}
 
-	// Cache the results for 15 minutes
-	as.cacheService.Set(cacheKey, metrics, 15*time.Minute)
-
 	return &metrics, nil
 }
 
@@ -0,0 +1,475 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/httpclient"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Analytics sink settings
+const (
+	analyticsSinkTimeout     = 60 * time.Second
+	bigQueryInsertChunkSize  = 500
+	bigQueryInsertDataScope  = "https://www.googleapis.com/auth/bigquery.insertdata"
+	defaultGoogleTokenURI    = "https://oauth2.googleapis.com/token"
+	googleTokenRefreshMargin = 5 * time.Minute
+)
+
+// AnalyticsSink receives batches of exported analytics events
+type AnalyticsSink interface {
+	// Name identifies the sink; export progress is tracked per sink
+	Name() string
+	// Write stores a batch and returns where it was written
+	Write(ctx context.Context, batch *models.AnalyticsExportBatch, rows []AnalyticsExportRow) (string, error)
+}
+
+// newAnalyticsSink returns the sink selected by the configuration
+func newAnalyticsSink(cfg *AnalyticsExportConfig) (AnalyticsSink, error) {
+	switch cfg.Sink {
+	case analyticsSinkFile:
+		return &fileAnalyticsSink{dir: cfg.Dir, prefix: cfg.Prefix, format: cfg.Format}, nil
+	case analyticsSinkS3:
+		if cfg.S3Bucket == "" || cfg.S3Region == "" || cfg.S3AccessKey == "" || cfg.S3SecretKey == "" {
+			return nil, errors.New("S3 analytics export needs ANALYTICS_S3_BUCKET, ANALYTICS_S3_REGION, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		return &s3AnalyticsSink{cfg: cfg, client: httpclient.New("analytics_s3", httpclient.Options{Timeout: analyticsSinkTimeout})}, nil
+	case analyticsSinkBigQuery:
+		if cfg.BigQueryProject == "" || cfg.BigQueryDataset == "" || cfg.BigQueryCredentials == "" {
+			return nil, errors.New("BigQuery analytics export needs ANALYTICS_BIGQUERY_PROJECT, ANALYTICS_BIGQUERY_DATASET and GOOGLE_APPLICATION_CREDENTIALS")
+		}
+		return &bigQueryAnalyticsSink{cfg: cfg, client: httpclient.New("analytics_bigquery", httpclient.Options{Timeout: analyticsSinkTimeout})}, nil
+	}
+	return nil, ErrAnalyticsNoSink
+}
+
+// analyticsObjectKey names a batch file, partitioned by export date
+func analyticsObjectKey(prefix string, batch *models.AnalyticsExportBatch, format string) string {
+	name := fmt.Sprintf("dt=%s/batch-%08d.%s", batch.StartedAt.UTC().Format("2006-01-02"), batch.ID, format)
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
+
+// encodeAnalyticsRows writes rows as CSV with a header or as JSON lines
+func encodeAnalyticsRows(format string, rows []AnalyticsExportRow) ([]byte, string, error) {
+	var buf bytes.Buffer
+
+	if format == "csv" {
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"event_id", "event_key", "stream", "event_type", "schema_version",
+			"entity_key", "subject_key", "occurred_at", "backfill", "payload"})
+		for _, row := range rows {
+			w.Write([]string{
+				strconv.FormatUint(uint64(row.EventID), 10),
+				row.EventKey,
+				row.Stream,
+				row.EventType,
+				strconv.Itoa(row.SchemaVersion),
+				row.EntityKey,
+				row.SubjectKey,
+				row.OccurredAt.UTC().Format(time.RFC3339),
+				strconv.FormatBool(row.Backfill),
+				string(row.Payload),
+			})
+		}
+		w.Flush()
+		return buf.Bytes(), "text/csv", w.Error()
+	}
+
+	enc := json.NewEncoder(&buf)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return nil, "", err
+		}
+	}
+	return buf.Bytes(), "application/x-ndjson", nil
+}
+
+// fileAnalyticsSink writes batches to a local directory
+type fileAnalyticsSink struct {
+	dir    string
+	prefix string
+	format string
+}
+
+// Name identifies the sink
+func (s *fileAnalyticsSink) Name() string {
+	return analyticsSinkFile
+}
+
+// Write stores a batch as a file, writing to a temporary name first so readers never
+// see a partial batch
+func (s *fileAnalyticsSink) Write(ctx context.Context, batch *models.AnalyticsExportBatch, rows []AnalyticsExportRow) (string, error) {
+	body, _, err := encodeAnalyticsRows(s.format, rows)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(s.dir, filepath.FromSlash(analyticsObjectKey(s.prefix, batch, s.format)))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path+".tmp", body, 0o640); err != nil {
+		return "", err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// s3AnalyticsSink uploads batches to an S3 bucket, or any S3 compatible store when an
+// endpoint is set, signing requests with AWS Signature Version 4
+type s3AnalyticsSink struct {
+	cfg    *AnalyticsExportConfig
+	client *http.Client
+}
+
+// Name identifies the sink
+func (s *s3AnalyticsSink) Name() string {
+	return analyticsSinkS3
+}
+
+// Write uploads a batch as one object
+func (s *s3AnalyticsSink) Write(ctx context.Context, batch *models.AnalyticsExportBatch, rows []AnalyticsExportRow) (string, error) {
+	body, contentType, err := encodeAnalyticsRows(s.cfg.Format, rows)
+	if err != nil {
+		return "", err
+	}
+
+	key := analyticsObjectKey(s.cfg.Prefix, batch, s.cfg.Format)
+	host := fmt.Sprintf("%s.s3.%s.amazonaws.com", s.cfg.S3Bucket, s.cfg.S3Region)
+	scheme, path := "https", "/"+awsURIEncode(key, false)
+	if s.cfg.S3Endpoint != "" {
+		// Custom endpoints use path style addressing
+		endpoint, err := url.Parse(s.cfg.S3Endpoint)
+		if err != nil {
+			return "", err
+		}
+		scheme, host = endpoint.Scheme, endpoint.Host
+		path = "/" + awsURIEncode(s.cfg.S3Bucket, false) + path
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, scheme+"://"+host+path, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	signer := awsSigner{
+		accessKey:    s.cfg.S3AccessKey,
+		secretKey:    s.cfg.S3SecretKey,
+		sessionToken: s.cfg.S3SessionToken,
+		region:       s.cfg.S3Region,
+		service:      "s3",
+	}
+	signer.sign(req, host, path, body, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("S3 upload failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	return fmt.Sprintf("s3://%s/%s", s.cfg.S3Bucket, key), nil
+}
+
+// awsSigner signs requests with AWS Signature Version 4
+type awsSigner struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	region       string
+	service      string
+}
+
+// sign adds Signature Version 4 headers to a request with no query string. The host,
+// date, payload hash and any session token are always signed, along with the request
+// headers named in extra.
+func (a awsSigner) sign(req *http.Request, host, path string, body []byte, now time.Time, extra ...string) {
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	headers := map[string]string{
+		"host":                 host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if a.sessionToken != "" {
+		headers["x-amz-security-token"] = a.sessionToken
+	}
+	for name, value := range headers {
+		if name != "host" {
+			req.Header.Set(name, value)
+		}
+	}
+	for _, name := range extra {
+		headers[strings.ToLower(name)] = req.Header.Get(name)
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, "", canonicalHeaders.String(), signedHeaders, payloadHash,
+	}, "\n")
+	scope := dateStamp + "/" + a.region + "/" + a.service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(a.signingKey(dateStamp), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.accessKey, scope, signedHeaders, signature))
+}
+
+// signingKey derives the day's signing key from the secret key
+func (a awsSigner) signingKey(dateStamp string) []byte {
+	key := hmacSHA256([]byte("AWS4"+a.secretKey), dateStamp)
+	key = hmacSHA256(key, a.region)
+	key = hmacSHA256(key, a.service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// awsURIEncode percent-encodes everything except unreserved characters, as SigV4
+// requires, optionally leaving slashes alone
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// sha256Hex returns the hex SHA-256 digest of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// bigQueryAnalyticsSink streams batches into a BigQuery table with insertAll,
+// authenticating as a service account
+type bigQueryAnalyticsSink struct {
+	cfg    *AnalyticsExportConfig
+	client *http.Client
+}
+
+// googleServiceAccount is the part of a service account key file the sink needs
+type googleServiceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// Access tokens are reused across sink instances until shortly before they expire
+var (
+	googleTokenMu      sync.Mutex
+	googleToken        string
+	googleTokenExpires time.Time
+)
+
+// Name identifies the sink
+func (s *bigQueryAnalyticsSink) Name() string {
+	return analyticsSinkBigQuery
+}
+
+// Write inserts a batch in chunks. Each row's event key is sent as its insert ID so
+// BigQuery drops duplicates if a failed batch is sent again.
+func (s *bigQueryAnalyticsSink) Write(ctx context.Context, batch *models.AnalyticsExportBatch, rows []AnalyticsExportRow) (string, error) {
+	token, err := s.accessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	table := fmt.Sprintf("%s.%s.%s", s.cfg.BigQueryProject, s.cfg.BigQueryDataset, s.cfg.BigQueryTable)
+	endpoint := fmt.Sprintf("https://bigquery.googleapis.com/bigquery/v2/projects/%s/datasets/%s/tables/%s/insertAll",
+		url.PathEscape(s.cfg.BigQueryProject), url.PathEscape(s.cfg.BigQueryDataset), url.PathEscape(s.cfg.BigQueryTable))
+
+	for start := 0; start < len(rows); start += bigQueryInsertChunkSize {
+		end := start + bigQueryInsertChunkSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		insertRows := make([]map[string]interface{}, 0, end-start)
+		for _, row := range rows[start:end] {
+			insertRows = append(insertRows, map[string]interface{}{
+				"insertId": row.EventKey,
+				"json": map[string]interface{}{
+					"event_id":       row.EventID,
+					"event_key":      row.EventKey,
+					"stream":         row.Stream,
+					"event_type":     row.EventType,
+					"schema_version": row.SchemaVersion,
+					"entity_key":     row.EntityKey,
+					"subject_key":    row.SubjectKey,
+					"occurred_at":    row.OccurredAt.UTC().Format(time.RFC3339),
+					"backfill":       row.Backfill,
+					"payload":        string(row.Payload),
+				},
+			})
+		}
+		body, err := json.Marshal(map[string]interface{}{
+			"kind": "bigquery#tableDataInsertAllRequest",
+			"rows": insertRows,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		var result struct {
+			InsertErrors []json.RawMessage `json:"insertErrors"`
+			Error        *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		decodeErr := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result)
+		resp.Body.Close()
+
+		if resp.StatusCode/100 != 2 {
+			if decodeErr == nil && result.Error != nil {
+				return "", fmt.Errorf("BigQuery insert failed with status %d: %s", resp.StatusCode, result.Error.Message)
+			}
+			return "", fmt.Errorf("BigQuery insert failed with status %d", resp.StatusCode)
+		}
+		if len(result.InsertErrors) > 0 {
+			return "", fmt.Errorf("BigQuery rejected %d rows, first: %s", len(result.InsertErrors), string(result.InsertErrors[0]))
+		}
+	}
+
+	return table, nil
+}
+
+// accessToken returns an OAuth access token for the service account, exchanging a
+// signed JWT for a new one when needed
+func (s *bigQueryAnalyticsSink) accessToken(ctx context.Context) (string, error) {
+	googleTokenMu.Lock()
+	defer googleTokenMu.Unlock()
+
+	if googleToken != "" && time.Until(googleTokenExpires) > googleTokenRefreshMargin {
+		return googleToken, nil
+	}
+
+	raw, err := os.ReadFile(s.cfg.BigQueryCredentials)
+	if err != nil {
+		return "", fmt.Errorf("read BigQuery credentials: %w", err)
+	}
+	var account googleServiceAccount
+	if err := json.Unmarshal(raw, &account); err != nil {
+		return "", fmt.Errorf("parse BigQuery credentials: %w", err)
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = defaultGoogleTokenURI
+	}
+
+	now := time.Now()
+	assertion, err := googleJWTAssertion(account, now)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil || resp.StatusCode/100 != 2 || token.AccessToken == "" {
+		return "", fmt.Errorf("BigQuery token request failed with status %d", resp.StatusCode)
+	}
+
+	googleToken = token.AccessToken
+	googleTokenExpires = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return googleToken, nil
+}
+
+// googleJWTAssertion is the signed JWT a service account exchanges for an access
+// token, valid for an hour
+func googleJWTAssertion(account googleServiceAccount, now time.Time) (string, error) {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("parse BigQuery private key: %w", err)
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   account.ClientEmail,
+		"scope": bigQueryInsertDataScope,
+		"aud":   account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(key)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/websocket"
+
+	"gorm.io/gorm"
+)
+
+// announcementRoles are the roles an announcement can target
+var announcementRoles = []string{models.RoleAdmin, models.RoleStaff, models.RoleVolunteer, models.RoleDonor, models.RoleVisitor}
+
+var announcementSeverityRank = map[string]int{
+	models.AnnouncementSeverityCritical: 0,
+	models.AnnouncementSeverityWarning:  1,
+	models.AnnouncementSeverityInfo:     2,
+}
+
+// AnnouncementService manages system-wide banners and pushes them to connected clients
+type AnnouncementService struct {
+	db *gorm.DB
+}
+
+// NewAnnouncementService creates a new announcement service
+func NewAnnouncementService() *AnnouncementService {
+	return &AnnouncementService{
+		db: db.DB,
+	}
+}
+
+// Validate tidies an announcement and checks its audience, severity and dates
+func (as *AnnouncementService) Validate(announcement *models.Announcement) error {
+	announcement.Title = strings.TrimSpace(announcement.Title)
+	announcement.Content = strings.TrimSpace(announcement.Content)
+	if announcement.Title == "" || announcement.Content == "" {
+		return errors.New("title and content are required")
+	}
+
+	if announcement.Severity == "" {
+		announcement.Severity = models.AnnouncementSeverityInfo
+	}
+	if _, ok := announcementSeverityRank[announcement.Severity]; !ok {
+		return fmt.Errorf("severity must be one of %s, %s or %s",
+			models.AnnouncementSeverityInfo, models.AnnouncementSeverityWarning, models.AnnouncementSeverityCritical)
+	}
+
+	roles := announcement.Roles()
+	for _, role := range roles {
+		known := false
+		for _, allowed := range announcementRoles {
+			known = known || role == allowed
+		}
+		if !known {
+			return fmt.Errorf("unknown audience role %q", role)
+		}
+	}
+	if len(roles) == 0 {
+		announcement.TargetRole = models.AnnouncementAudienceAll
+	} else {
+		announcement.TargetRole = strings.Join(roles, ",")
+	}
+
+	if announcement.StartsAt != nil && announcement.ExpiresAt != nil && !announcement.ExpiresAt.After(*announcement.StartsAt) {
+		return errors.New("expires_at must be after starts_at")
+	}
+	return nil
+}
+
+// Live returns the announcements showing now for a role, most severe first. Signed-out
+// visitors pass an empty role and only see public announcements.
+func (as *AnnouncementService) Live(role string, now time.Time) ([]models.Announcement, error) {
+	var announcements []models.Announcement
+	err := as.db.Where("active = ? AND (starts_at IS NULL OR starts_at <= ?) AND (expires_at IS NULL OR expires_at > ?)", true, now, now).
+		Order("created_at DESC").
+		Find(&announcements).Error
+	if err != nil {
+		return nil, err
+	}
+	return announcementsFor(announcements, role), nil
+}
+
+// announcementsFor keeps the announcements a role should see, most severe first
+func announcementsFor(announcements []models.Announcement, role string) []models.Announcement {
+	live := []models.Announcement{}
+	for _, announcement := range announcements {
+		if role == "" {
+			if announcement.Public {
+				live = append(live, announcement)
+			}
+			continue
+		}
+		if announcement.Targets(role) {
+			live = append(live, announcement)
+		}
+	}
+	sort.SliceStable(live, func(i, j int) bool {
+		return announcementSeverityRank[live[i].Severity] < announcementSeverityRank[live[j].Severity]
+	})
+	return live
+}
+
+// Publish pushes a live announcement to connected clients in its audience so banners
+// appear without waiting for the next poll. Clients are also told when an
+// announcement is withdrawn.
+func (as *AnnouncementService) Publish(announcement *models.Announcement, now time.Time) {
+	payload := map[string]interface{}{
+		"type":         "announcement",
+		"announcement": announcement,
+		"live":         announcement.IsLive(now),
+		"timestamp":    now,
+	}
+
+	roles := announcement.Roles()
+	if len(roles) == 0 {
+		roles = announcementRoles
+	}
+	manager := websocket.GetGlobalManager()
+	for _, role := range roles {
+		if err := manager.BroadcastToRole(role, payload); err != nil {
+			log.Printf("Failed to broadcast announcement %d to %s: %v", announcement.ID, role, err)
+		}
+	}
+}
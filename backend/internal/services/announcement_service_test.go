@@ -0,0 +1,96 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestValidateAnnouncement(t *testing.T) {
+	as := &AnnouncementService{}
+	announcement := &models.Announcement{Title: " Closed today ", Content: " Snow ", TargetRole: " Visitor, volunteer "}
+	if err := as.Validate(announcement); err != nil {
+		t.Fatalf("valid announcement rejected: %v", err)
+	}
+	if announcement.Title != "Closed today" || announcement.Severity != models.AnnouncementSeverityInfo || announcement.TargetRole != "visitor,volunteer" {
+		t.Errorf("not tidied: %+v", announcement)
+	}
+
+	everyone := &models.Announcement{Title: "Hello", Content: "World"}
+	if err := as.Validate(everyone); err != nil || everyone.TargetRole != models.AnnouncementAudienceAll {
+		t.Errorf("no audience: got %q, %v", everyone.TargetRole, err)
+	}
+
+	start := time.Date(2026, 12, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		announcement models.Announcement
+	}{
+		{"blank title", models.Announcement{Title: " ", Content: "Snow"}},
+		{"unknown severity", models.Announcement{Title: "Closed", Content: "Snow", Severity: "urgent"}},
+		{"unknown role", models.Announcement{Title: "Closed", Content: "Snow", TargetRole: "visitor,trustee"}},
+		{"expires before it starts", models.Announcement{Title: "Closed", Content: "Snow", StartsAt: &start, ExpiresAt: &start}},
+	}
+	for _, tt := range tests {
+		if err := as.Validate(&tt.announcement); err == nil {
+			t.Errorf("%s: accepted", tt.name)
+		}
+	}
+}
+
+func TestAnnouncementsFor(t *testing.T) {
+	announcements := []models.Announcement{
+		{ID: 1, Severity: models.AnnouncementSeverityInfo, TargetRole: models.AnnouncementAudienceAll},
+		{ID: 2, Severity: models.AnnouncementSeverityCritical, TargetRole: "visitor", Public: true},
+		{ID: 3, Severity: models.AnnouncementSeverityWarning, TargetRole: "admin,staff"},
+		{ID: 4, Severity: models.AnnouncementSeverityInfo, TargetRole: "Volunteer"},
+	}
+	tests := []struct {
+		role string
+		want []uint
+	}{
+		{"visitor", []uint{2, 1}}, // Most severe first
+		{"super_admin", []uint{3, 1}},
+		{"volunteer", []uint{1, 4}},
+		{"", []uint{2}}, // Signed out: public only
+	}
+	for _, tt := range tests {
+		live := announcementsFor(announcements, tt.role)
+		var got []uint
+		for _, announcement := range live {
+			got = append(got, announcement.ID)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.role, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q: got %v, want %v", tt.role, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestAnnouncementIsLive(t *testing.T) {
+	now := time.Date(2026, 12, 1, 9, 0, 0, 0, time.UTC)
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+	tests := []struct {
+		name         string
+		announcement models.Announcement
+		want         bool
+	}{
+		{"no dates", models.Announcement{Active: true}, true},
+		{"inactive", models.Announcement{Active: false}, false},
+		{"not started", models.Announcement{Active: true, StartsAt: &later}, false},
+		{"starting now", models.Announcement{Active: true, StartsAt: &now}, true},
+		{"expired", models.Announcement{Active: true, StartsAt: &earlier, ExpiresAt: &now}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.announcement.IsLive(now); got != tt.want {
+			t.Errorf("%s: got %v", tt.name, got)
+		}
+	}
+}
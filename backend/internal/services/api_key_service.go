@@ -0,0 +1,261 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// A key with no requests for this many days is reported as dormant
+const apiKeyDormantDays = 30
+
+var ErrAPIKeyRevoked = errors.New("API key has already been revoked")
+
+// APIKeyService issues partner API keys and reports how they are used
+type APIKeyService struct {
+	db *gorm.DB
+}
+
+// APIKeyEndpointUsage is a key's traffic to one endpoint over a period
+type APIKeyEndpointUsage struct {
+	Endpoint    string     `json:"endpoint"`
+	Requests    int64      `json:"requests"`
+	Errors      int64      `json:"errors"`
+	RateLimited int64      `json:"rate_limited"`
+	ErrorRate   float64    `json:"error_rate"` // Percentage of requests
+	LastUsedAt  *time.Time `json:"last_used_at"`
+}
+
+// APIKeyDayUsage is a key's traffic on one day
+type APIKeyDayUsage struct {
+	Day         string `json:"day"`
+	Requests    int64  `json:"requests"`
+	Errors      int64  `json:"errors"`
+	RateLimited int64  `json:"rate_limited"`
+}
+
+// APIKeyReport summarises a key's usage over a period
+type APIKeyReport struct {
+	Key         models.APIKey         `json:"key"`
+	From        string                `json:"from"`
+	To          string                `json:"to"`
+	Requests    int64                 `json:"requests"`
+	Errors      int64                 `json:"errors"`
+	RateLimited int64                 `json:"rate_limited"`
+	ErrorRate   float64               `json:"error_rate"`
+	Dormant     bool                  `json:"dormant"` // Not used for apiKeyDormantDays days
+	ByEndpoint  []APIKeyEndpointUsage `json:"by_endpoint"`
+	ByDay       []APIKeyDayUsage      `json:"by_day"`
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService() *APIKeyService {
+	return &APIKeyService{
+		db: db.DB,
+	}
+}
+
+// CreateKey issues a key to a partner and returns it. The key is only available
+// here; afterwards only its hash is kept.
+func (as *APIKeyService) CreateKey(name, partner string, ownerID *uint, createdBy uint) (*models.APIKey, string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	secret := "pk_" + hex.EncodeToString(b)
+
+	key := models.APIKey{
+		Name:      name,
+		Partner:   partner,
+		OwnerID:   ownerID,
+		KeyHash:   models.HashAPIKey(secret),
+		KeyPrefix: secret[:11],
+		CreatedBy: createdBy,
+	}
+	if err := as.db.Create(&key).Error; err != nil {
+		return nil, "", err
+	}
+	return &key, secret, nil
+}
+
+// Authenticate finds the active key for a secret and records that it was used
+func (as *APIKeyService) Authenticate(secret string) (*models.APIKey, error) {
+	if secret == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var key models.APIKey
+	if err := as.db.Where("key_hash = ? AND revoked_at IS NULL", models.HashAPIKey(secret)).
+		First(&key).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	as.db.Model(&key).UpdateColumn("last_used_at", now)
+	key.LastUsedAt = &now
+	return &key, nil
+}
+
+// Revoke stops a key from being accepted
+func (as *APIKeyService) Revoke(key *models.APIKey, revokedBy uint) error {
+	if !key.IsActive() {
+		return ErrAPIKeyRevoked
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	key.RevokedBy = &revokedBy
+	return as.db.Save(key).Error
+}
+
+// RecordUsage counts one request made with a key
+func (as *APIKeyService) RecordUsage(keyID uint, endpoint string, status int, now time.Time) error {
+	var errorCount, rateLimited int64
+	switch {
+	case status == http.StatusTooManyRequests:
+		rateLimited = 1
+	case status >= 400:
+		errorCount = 1
+	}
+
+	usage := models.APIKeyUsage{
+		APIKeyID:    keyID,
+		Day:         now.Format("2006-01-02"),
+		Endpoint:    endpoint,
+		Requests:    1,
+		Errors:      errorCount,
+		RateLimited: rateLimited,
+		LastUsedAt:  now,
+	}
+	return as.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "api_key_id"}, {Name: "day"}, {Name: "endpoint"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests":     gorm.Expr("api_key_usage.requests + 1"),
+			"errors":       gorm.Expr("api_key_usage.errors + ?", errorCount),
+			"rate_limited": gorm.Expr("api_key_usage.rate_limited + ?", rateLimited),
+			"last_used_at": now,
+		}),
+	}).Create(&usage).Error
+}
+
+// ListKeys returns keys, newest first. Pass an owner to list only that partner
+// contact's keys.
+func (as *APIKeyService) ListKeys(ownerID *uint) ([]models.APIKey, error) {
+	query := as.db.Order("created_at DESC")
+	if ownerID != nil {
+		query = query.Where("owner_id = ?", *ownerID)
+	}
+	var keys []models.APIKey
+	err := query.Find(&keys).Error
+	return keys, err
+}
+
+// Report summarises a key's usage between two days, inclusive
+func (as *APIKeyService) Report(key models.APIKey, from, to string, now time.Time) (*APIKeyReport, error) {
+	var rows []models.APIKeyUsage
+	if err := as.db.Where("api_key_id = ? AND day BETWEEN ? AND ?", key.ID, from, to).
+		Order("day ASC, endpoint ASC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return buildAPIKeyReport(key, from, to, rows, now), nil
+}
+
+// Overview reports every key's usage between two days, so admins can spot abusive
+// or dormant keys
+func (as *APIKeyService) Overview(from, to string, now time.Time) ([]APIKeyReport, error) {
+	keys, err := as.ListKeys(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.APIKeyUsage
+	if err := as.db.Where("day BETWEEN ? AND ?", from, to).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	byKey := map[uint][]models.APIKeyUsage{}
+	for _, row := range rows {
+		byKey[row.APIKeyID] = append(byKey[row.APIKeyID], row)
+	}
+
+	reports := make([]APIKeyReport, 0, len(keys))
+	for _, key := range keys {
+		report := buildAPIKeyReport(key, from, to, byKey[key.ID], now)
+		report.ByDay = nil
+		reports = append(reports, *report)
+	}
+	return reports, nil
+}
+
+// buildAPIKeyReport totals daily usage rows by endpoint and by day
+func buildAPIKeyReport(key models.APIKey, from, to string, rows []models.APIKeyUsage, now time.Time) *APIKeyReport {
+	report := &APIKeyReport{
+		Key:        key,
+		From:       from,
+		To:         to,
+		ByEndpoint: []APIKeyEndpointUsage{},
+		ByDay:      []APIKeyDayUsage{},
+	}
+
+	endpoints := map[string]*APIKeyEndpointUsage{}
+	var order []string
+	days := map[string]int{}
+	for _, row := range rows {
+		report.Requests += row.Requests
+		report.Errors += row.Errors
+		report.RateLimited += row.RateLimited
+
+		endpoint, ok := endpoints[row.Endpoint]
+		if !ok {
+			endpoint = &APIKeyEndpointUsage{Endpoint: row.Endpoint}
+			endpoints[row.Endpoint] = endpoint
+			order = append(order, row.Endpoint)
+		}
+		endpoint.Requests += row.Requests
+		endpoint.Errors += row.Errors
+		endpoint.RateLimited += row.RateLimited
+		if lastUsed := row.LastUsedAt; endpoint.LastUsedAt == nil || lastUsed.After(*endpoint.LastUsedAt) {
+			endpoint.LastUsedAt = &lastUsed
+		}
+
+		i, ok := days[row.Day]
+		if !ok {
+			i = len(report.ByDay)
+			days[row.Day] = i
+			report.ByDay = append(report.ByDay, APIKeyDayUsage{Day: row.Day})
+		}
+		report.ByDay[i].Requests += row.Requests
+		report.ByDay[i].Errors += row.Errors
+		report.ByDay[i].RateLimited += row.RateLimited
+	}
+
+	for _, name := range order {
+		endpoint := endpoints[name]
+		endpoint.ErrorRate = percentOf(endpoint.Errors, endpoint.Requests)
+		report.ByEndpoint = append(report.ByEndpoint, *endpoint)
+	}
+	report.ErrorRate = percentOf(report.Errors, report.Requests)
+
+	lastUsed := key.CreatedAt
+	if key.LastUsedAt != nil {
+		lastUsed = *key.LastUsedAt
+	}
+	report.Dormant = key.IsActive() && now.Sub(lastUsed) > apiKeyDormantDays*24*time.Hour
+	return report
+}
+
+// percentOf returns part as a percentage of total, to one decimal place
+func percentOf(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*1000) / 10
+}
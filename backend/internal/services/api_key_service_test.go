@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestBuildAPIKeyReport(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	lastUsed := now.Add(-time.Hour)
+	key := models.APIKey{ID: 1, CreatedAt: now.AddDate(0, -3, 0), LastUsedAt: &lastUsed}
+	rows := []models.APIKeyUsage{
+		{Day: "2026-03-30", Endpoint: "GET /api/v1/partner/urgent-needs", Requests: 90, Errors: 9, RateLimited: 1, LastUsedAt: now.Add(-30 * time.Hour)},
+		{Day: "2026-03-31", Endpoint: "GET /api/v1/partner/urgent-needs", Requests: 10, Errors: 1, LastUsedAt: lastUsed},
+		{Day: "2026-03-31", Endpoint: "GET /api/v1/partner/status", Requests: 100, LastUsedAt: now.Add(-2 * time.Hour)},
+	}
+
+	report := buildAPIKeyReport(key, "2026-03-01", "2026-03-31", rows, now)
+	if report.Requests != 200 || report.Errors != 10 || report.RateLimited != 1 {
+		t.Fatalf("totals: got %d requests, %d errors, %d rate limited", report.Requests, report.Errors, report.RateLimited)
+	}
+	if report.ErrorRate != 5 {
+		t.Errorf("error rate: got %.1f, want 5", report.ErrorRate)
+	}
+	if len(report.ByEndpoint) != 2 || report.ByEndpoint[0].Requests != 100 || report.ByEndpoint[0].ErrorRate != 10 {
+		t.Fatalf("by endpoint: got %+v", report.ByEndpoint)
+	}
+	if !report.ByEndpoint[0].LastUsedAt.Equal(lastUsed) {
+		t.Errorf("endpoint last used: got %v", report.ByEndpoint[0].LastUsedAt)
+	}
+	if len(report.ByDay) != 2 || report.ByDay[1].Requests != 110 {
+		t.Fatalf("by day: got %+v", report.ByDay)
+	}
+	if report.Dormant {
+		t.Error("key used an hour ago reported as dormant")
+	}
+}
+
+func TestBuildAPIKeyReportDormant(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	lastUsed := now.AddDate(0, 0, -45)
+	key := models.APIKey{ID: 2, CreatedAt: now.AddDate(0, -6, 0), LastUsedAt: &lastUsed}
+	if report := buildAPIKeyReport(key, "2026-03-01", "2026-03-31", nil, now); !report.Dormant {
+		t.Error("key unused for 45 days not reported as dormant")
+	}
+
+	never := models.APIKey{ID: 3, CreatedAt: now.AddDate(0, 0, -5)}
+	if report := buildAPIKeyReport(never, "2026-03-01", "2026-03-31", nil, now); report.Dormant {
+		t.Error("new unused key reported as dormant")
+	}
+
+	revokedAt := now.AddDate(0, 0, -40)
+	revoked := models.APIKey{ID: 4, CreatedAt: now.AddDate(0, -6, 0), RevokedAt: &revokedAt}
+	if report := buildAPIKeyReport(revoked, "2026-03-01", "2026-03-31", nil, now); report.Dormant {
+		t.Error("revoked key reported as dormant")
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// applicationRetentionStatuses are the decided outcomes whose personal details are
+// removed once the retention period has passed
+var applicationRetentionStatuses = []string{"rejected", models.VolunteerApplicationWithdrawn}
+
+// anonymizedApplicantName replaces the name on anonymized applications
+const anonymizedApplicantName = "Anonymized"
+
+// UpcomingAnonymization is a closed application due to be anonymized
+type UpcomingAnonymization struct {
+	ApplicationID uint      `json:"application_id"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email"`
+	Status        string    `json:"status"`
+	Source        string    `json:"source"`
+	ClosedAt      time.Time `json:"closed_at"`
+	AnonymizeOn   time.Time `json:"anonymize_on"`
+	DaysRemaining int       `json:"days_remaining"` // Zero or less means it will go on the next run
+}
+
+// ApplicationOutcomeCount is the number of applications with an outcome from a source
+type ApplicationOutcomeCount struct {
+	Status     string `json:"status"`
+	Source     string `json:"source"`
+	Total      int64  `json:"total"`
+	Anonymized int64  `json:"anonymized"`
+}
+
+// ApplicationRetentionService removes personal details from rejected and withdrawn
+// volunteer applications after a retention period. The rows themselves are kept so
+// counts by outcome and source stay accurate.
+type ApplicationRetentionService struct {
+	db     *gorm.DB
+	period time.Duration
+}
+
+// NewApplicationRetentionService creates a new application retention service
+func NewApplicationRetentionService() *ApplicationRetentionService {
+	days := 365
+	if configured, err := strconv.Atoi(os.Getenv("VOLUNTEER_APPLICATION_RETENTION_DAYS")); err == nil && configured > 0 {
+		days = configured
+	}
+
+	return &ApplicationRetentionService{
+		db:     db.DB,
+		period: time.Duration(days) * 24 * time.Hour,
+	}
+}
+
+// RetentionDays returns the configured retention period in days
+func (ars *ApplicationRetentionService) RetentionDays() int {
+	return int(ars.period / (24 * time.Hour))
+}
+
+// closedApplications selects closed applications that still hold personal details,
+// including soft-deleted ones. Applications closed before ClosedAt was recorded fall
+// back to their last update.
+func (ars *ApplicationRetentionService) closedApplications() *gorm.DB {
+	return ars.db.Unscoped().Model(&models.VolunteerApplication{}).
+		Where("status IN ? AND anonymized_at IS NULL", applicationRetentionStatuses)
+}
+
+// Upcoming lists applications that will be anonymized within the given window,
+// including any already overdue, soonest first
+func (ars *ApplicationRetentionService) Upcoming(now time.Time, within time.Duration) ([]UpcomingAnonymization, error) {
+	var applications []models.VolunteerApplication
+	if err := ars.closedApplications().
+		Where("COALESCE(closed_at, updated_at) <= ?", now.Add(within-ars.period)).
+		Order("COALESCE(closed_at, updated_at)").
+		Find(&applications).Error; err != nil {
+		return nil, err
+	}
+
+	upcoming := make([]UpcomingAnonymization, 0, len(applications))
+	for _, application := range applications {
+		upcoming = append(upcoming, ars.upcomingAnonymization(application, now))
+	}
+	return upcoming, nil
+}
+
+// upcomingAnonymization works out when a closed application is due to be anonymized
+func (ars *ApplicationRetentionService) upcomingAnonymization(application models.VolunteerApplication, now time.Time) UpcomingAnonymization {
+	closedAt := application.UpdatedAt
+	if application.ClosedAt != nil {
+		closedAt = *application.ClosedAt
+	}
+	anonymizeOn := closedAt.Add(ars.period)
+	return UpcomingAnonymization{
+		ApplicationID: application.ID,
+		Name:          application.FirstName + " " + application.LastName,
+		Email:         application.Email,
+		Status:        application.Status,
+		Source:        application.Source,
+		ClosedAt:      closedAt,
+		AnonymizeOn:   anonymizeOn,
+		DaysRemaining: int(anonymizeOn.Sub(now).Hours() / 24),
+	}
+}
+
+// AnonymizeDue removes personal details from applications past the retention period.
+// The status, source and dates are kept for statistics.
+func (ars *ApplicationRetentionService) AnonymizeDue(now time.Time) (int64, error) {
+	result := ars.closedApplications().
+		Where("COALESCE(closed_at, updated_at) <= ?", now.Add(-ars.period)).
+		Updates(map[string]interface{}{
+			"first_name":       anonymizedApplicantName,
+			"last_name":        "",
+			"email":            gorm.Expr("CONCAT('anonymized-', id, '@invalid')"),
+			"phone":            "",
+			"skills":           "",
+			"experience":       "",
+			"availability":     "",
+			"password":         "",
+			"rejection_reason": "",
+			"closed_at":        gorm.Expr("COALESCE(closed_at, updated_at)"),
+			"anonymized_at":    now,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// OutcomeCounts returns application counts by outcome and source, including
+// anonymized applications
+func (ars *ApplicationRetentionService) OutcomeCounts() ([]ApplicationOutcomeCount, error) {
+	var counts []ApplicationOutcomeCount
+	err := ars.db.Unscoped().Model(&models.VolunteerApplication{}).
+		Select("status, source, COUNT(*) AS total, COUNT(anonymized_at) AS anonymized").
+		Group("status, source").
+		Order("status, source").
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestApplicationRetentionDays(t *testing.T) {
+	tests := []struct {
+		env  string
+		want int
+	}{
+		{"", 365},
+		{"90", 90},
+		{"0", 365},
+		{"-30", 365},
+		{"a year", 365},
+	}
+	for _, tt := range tests {
+		t.Setenv("VOLUNTEER_APPLICATION_RETENTION_DAYS", tt.env)
+		if got := NewApplicationRetentionService().RetentionDays(); got != tt.want {
+			t.Errorf("VOLUNTEER_APPLICATION_RETENTION_DAYS=%q: got %d days, want %d", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestUpcomingAnonymization(t *testing.T) {
+	ars := &ApplicationRetentionService{period: 90 * 24 * time.Hour}
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	closed := now.AddDate(0, 0, -80)
+
+	application := models.VolunteerApplication{
+		ID: 4, FirstName: "Sam", LastName: "Lee", Email: "sam@example.com",
+		Status: "rejected", Source: models.VolunteerApplicationSourceForm, ClosedAt: &closed,
+	}
+	application.UpdatedAt = now.AddDate(0, 0, -1) // Edited since it closed
+	upcoming := ars.upcomingAnonymization(application, now)
+	if !upcoming.ClosedAt.Equal(closed) || !upcoming.AnonymizeOn.Equal(closed.AddDate(0, 0, 90)) || upcoming.DaysRemaining != 10 {
+		t.Errorf("got %+v", upcoming)
+	}
+	if upcoming.Name != "Sam Lee" || upcoming.Source != models.VolunteerApplicationSourceForm {
+		t.Errorf("details: got %+v", upcoming)
+	}
+
+	// Applications closed before ClosedAt was recorded use their last update
+	application.ClosedAt = nil
+	application.UpdatedAt = now.AddDate(0, 0, -95)
+	if upcoming := ars.upcomingAnonymization(application, now); upcoming.DaysRemaining != -5 {
+		t.Errorf("overdue application: %d days remaining, want -5", upcoming.DaysRemaining)
+	}
+}
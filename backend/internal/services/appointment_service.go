@@ -0,0 +1,590 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// appointmentReminderWindow is how far ahead of an appointment its reminder is sent
+	appointmentReminderWindow = 24 * time.Hour
+	// maxSlotsPerRequest caps how many slots one request can open
+	maxSlotsPerRequest = 48
+	// Visitors with this many no-shows in the lookback period cannot book online
+	defaultAppointmentNoShowLimit = 3
+	appointmentNoShowLookback     = 90 * 24 * time.Hour
+)
+
+// Appointment errors
+var (
+	ErrAppointmentSlotNotFound   = errors.New("appointment slot not found")
+	ErrAppointmentSlotInvalid    = errors.New("slots must end after they start and be at least 10 minutes long")
+	ErrAppointmentSlotTooMany    = fmt.Errorf("at most %d slots can be opened at once", maxSlotsPerRequest)
+	ErrAppointmentSlotClash      = errors.New("the staff member already has a slot at this time")
+	ErrAppointmentSlotTaken      = errors.New("this slot has already been booked")
+	ErrAppointmentSlotPast       = errors.New("this slot has already started")
+	ErrAppointmentStaffInvalid   = errors.New("appointments can only be held by admin or staff accounts")
+	ErrAppointmentServiceInvalid = errors.New("unknown or inactive service")
+	ErrAppointmentNotFound       = errors.New("appointment not found")
+	ErrAppointmentNotBooked      = errors.New("this appointment is no longer booked")
+	ErrAppointmentDuplicate      = errors.New("you already have an upcoming appointment for this service")
+	ErrAppointmentNoShowLimit    = errors.New("online booking is paused after several missed appointments; please contact us to book")
+	ErrAppointmentNotStarted     = errors.New("attendance can only be recorded once the appointment has started")
+)
+
+// activeAppointmentStatuses are bookings that hold their slot
+var activeAppointmentStatuses = []string{models.AppointmentBooked, models.AppointmentAttended, models.AppointmentNoShow}
+
+// AppointmentSlotRequest opens one slot, or back-to-back slots of SlotMinutes between
+// StartsAt and EndsAt
+type AppointmentSlotRequest struct {
+	StaffID     uint      `json:"staff_id" binding:"required"`
+	Category    string    `json:"category" binding:"required"`
+	StartsAt    time.Time `json:"starts_at" binding:"required"`
+	EndsAt      time.Time `json:"ends_at" binding:"required"`
+	SlotMinutes int       `json:"slot_minutes"` // 0 opens a single slot
+	Location    string    `json:"location"`
+}
+
+// CalendarSlot is a slot on a staff calendar with the appointment holding it, if any
+type CalendarSlot struct {
+	models.AppointmentSlot
+	Appointment *models.Appointment `json:"appointment,omitempty"`
+}
+
+// AppointmentNoShowRow is one visitor's appointment attendance over a period
+type AppointmentNoShowRow struct {
+	VisitorID    uint    `json:"visitor_id"`
+	VisitorName  string  `json:"visitor_name"`
+	Appointments int64   `json:"appointments"`
+	NoShows      int64   `json:"no_shows"`
+	NoShowRate   float64 `json:"no_show_rate"` // Percentage of appointments with an outcome
+	BookingBlock bool    `json:"booking_blocked"`
+}
+
+// AppointmentService schedules one-to-one advice appointments with staff. It is
+// separate from the ticketed queue: slots belong to a member of staff and a service,
+// visitors book and cancel them, and attendance is recorded per appointment.
+type AppointmentService struct {
+	db          *gorm.DB
+	noShowLimit int
+}
+
+// NewAppointmentService creates a new appointment service. APPOINTMENT_NO_SHOW_LIMIT
+// sets how many recent no-shows pause online booking; 0 turns the limit off.
+func NewAppointmentService() *AppointmentService {
+	as := &AppointmentService{db: db.DB, noShowLimit: defaultAppointmentNoShowLimit}
+	if limit, err := strconv.Atoi(os.Getenv("APPOINTMENT_NO_SHOW_LIMIT")); err == nil && limit >= 0 {
+		as.noShowLimit = limit
+	}
+	return as
+}
+
+// CreateSlots opens appointment slots for a member of staff
+func (as *AppointmentService) CreateSlots(req AppointmentSlotRequest, createdBy uint) ([]models.AppointmentSlot, error) {
+	length := req.EndsAt.Sub(req.StartsAt)
+	if req.SlotMinutes > 0 {
+		length = time.Duration(req.SlotMinutes) * time.Minute
+	}
+	if length < 10*time.Minute || !req.EndsAt.After(req.StartsAt) {
+		return nil, ErrAppointmentSlotInvalid
+	}
+	if int(req.EndsAt.Sub(req.StartsAt)/length) > maxSlotsPerRequest {
+		return nil, ErrAppointmentSlotTooMany
+	}
+
+	var staff models.User
+	if err := as.db.First(&staff, req.StaffID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAppointmentStaffInvalid
+		}
+		return nil, err
+	}
+	switch staff.Role {
+	case models.RoleAdmin, models.RoleAdminLegacy, models.RoleSuperAdmin, models.RoleStaff:
+	default:
+		return nil, ErrAppointmentStaffInvalid
+	}
+
+	category := strings.ToLower(strings.TrimSpace(req.Category))
+	var active int64
+	if err := as.db.Model(&models.ServiceType{}).Where("code = ? AND is_active = ?", category, true).
+		Count(&active).Error; err != nil {
+		return nil, err
+	}
+	if active == 0 {
+		return nil, ErrAppointmentServiceInvalid
+	}
+
+	var slots []models.AppointmentSlot
+	for start := req.StartsAt; !start.Add(length).After(req.EndsAt); start = start.Add(length) {
+		slots = append(slots, models.AppointmentSlot{
+			StaffID:   req.StaffID,
+			Category:  category,
+			StartsAt:  start,
+			EndsAt:    start.Add(length),
+			Location:  strings.TrimSpace(req.Location),
+			Status:    models.AppointmentSlotOpen,
+			CreatedBy: createdBy,
+		})
+	}
+
+	err := as.db.Transaction(func(tx *gorm.DB) error {
+		var clashes int64
+		if err := tx.Model(&models.AppointmentSlot{}).
+			Where("staff_id = ? AND status = ? AND starts_at < ? AND ends_at > ?",
+				req.StaffID, models.AppointmentSlotOpen, slots[len(slots)-1].EndsAt, slots[0].StartsAt).
+			Count(&clashes).Error; err != nil {
+			return err
+		}
+		if clashes > 0 {
+			return ErrAppointmentSlotClash
+		}
+		return tx.Create(&slots).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slots, nil
+}
+
+// CancelSlot withdraws a slot, cancelling and notifying any visitor booked into it
+func (as *AppointmentService) CancelSlot(slotID, cancelledBy uint, reason string, now time.Time) (*models.AppointmentSlot, error) {
+	var slot models.AppointmentSlot
+	var cancelled []models.Appointment
+	err := as.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, slotID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAppointmentSlotNotFound
+			}
+			return err
+		}
+		if slot.Status == models.AppointmentSlotCancelled {
+			return nil
+		}
+		slot.Status = models.AppointmentSlotCancelled
+		slot.CancelledAt = &now
+		if err := tx.Save(&slot).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("slot_id = ? AND status = ?", slot.ID, models.AppointmentBooked).Find(&cancelled).Error; err != nil {
+			return err
+		}
+		for i := range cancelled {
+			cancelled[i].Status = models.AppointmentCancelled
+			cancelled[i].CancelledAt = &now
+			cancelled[i].CancelledBy = &cancelledBy
+			cancelled[i].CancellationReason = reason
+			if err := tx.Save(&cancelled[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, appointment := range cancelled {
+		appointment.Slot = slot
+		as.notifyVisitorCancelled(appointment)
+	}
+	return &slot, nil
+}
+
+// AvailableSlots lists open slots that start after now and have not been booked
+func (as *AppointmentService) AvailableSlots(category string, from, to, now time.Time) ([]models.AppointmentSlot, error) {
+	if from.Before(now) {
+		from = now
+	}
+	query := as.db.Preload("Staff", selectStaffName).
+		Where("status = ? AND starts_at > ? AND starts_at < ?", models.AppointmentSlotOpen, from, to).
+		Where("NOT EXISTS (SELECT 1 FROM appointments WHERE appointments.slot_id = appointment_slots.id AND appointments.status IN ?)",
+			activeAppointmentStatuses)
+	if category != "" {
+		query = query.Where("category = ?", strings.ToLower(category))
+	}
+
+	var slots []models.AppointmentSlot
+	err := query.Order("starts_at ASC").Limit(500).Find(&slots).Error
+	return slots, err
+}
+
+// Book reserves a slot for a visitor and sends a confirmation
+func (as *AppointmentService) Book(visitorID, slotID uint, notes string, now time.Time) (*models.Appointment, error) {
+	if as.noShowLimit > 0 {
+		noShows, err := as.recentNoShows(visitorID, now)
+		if err != nil {
+			return nil, err
+		}
+		if noShows >= int64(as.noShowLimit) {
+			return nil, ErrAppointmentNoShowLimit
+		}
+	}
+
+	appointment := models.Appointment{
+		SlotID:    slotID,
+		VisitorID: visitorID,
+		Status:    models.AppointmentBooked,
+		Notes:     strings.TrimSpace(notes),
+		BookedAt:  now,
+	}
+	err := as.db.Transaction(func(tx *gorm.DB) error {
+		var slot models.AppointmentSlot
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ?", models.AppointmentSlotOpen).First(&slot, slotID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAppointmentSlotNotFound
+			}
+			return err
+		}
+		if !slot.StartsAt.After(now) {
+			return ErrAppointmentSlotPast
+		}
+
+		var taken int64
+		if err := tx.Model(&models.Appointment{}).
+			Where("slot_id = ? AND status IN ?", slot.ID, activeAppointmentStatuses).
+			Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrAppointmentSlotTaken
+		}
+
+		var upcoming int64
+		if err := tx.Model(&models.Appointment{}).
+			Joins("JOIN appointment_slots ON appointment_slots.id = appointments.slot_id").
+			Where("appointments.visitor_id = ? AND appointments.status = ? AND appointment_slots.category = ? AND appointment_slots.starts_at > ?",
+				visitorID, models.AppointmentBooked, slot.Category, now).
+			Count(&upcoming).Error; err != nil {
+			return err
+		}
+		if upcoming > 0 {
+			return ErrAppointmentDuplicate
+		}
+
+		if err := tx.Create(&appointment).Error; err != nil {
+			return err
+		}
+		appointment.Slot = slot
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	as.notifyBooked(appointment)
+	return &appointment, nil
+}
+
+// Cancel cancels a booked appointment before it starts. A non-zero visitorID limits
+// it to that visitor's own appointments; staff pass 0 and may cancel at any time.
+func (as *AppointmentService) Cancel(appointmentID, visitorID, cancelledBy uint, reason string, now time.Time) (*models.Appointment, error) {
+	var appointment models.Appointment
+	err := as.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", appointmentID)
+		if visitorID != 0 {
+			query = query.Where("visitor_id = ?", visitorID)
+		}
+		if err := query.First(&appointment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAppointmentNotFound
+			}
+			return err
+		}
+		if appointment.Status != models.AppointmentBooked {
+			return ErrAppointmentNotBooked
+		}
+		if err := tx.First(&appointment.Slot, appointment.SlotID).Error; err != nil {
+			return err
+		}
+		if visitorID != 0 && !appointment.Slot.StartsAt.After(now) {
+			return ErrAppointmentSlotPast
+		}
+
+		appointment.Status = models.AppointmentCancelled
+		appointment.CancelledAt = &now
+		appointment.CancelledBy = &cancelledBy
+		appointment.CancellationReason = strings.TrimSpace(reason)
+		return tx.Omit("Slot").Save(&appointment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if visitorID != 0 {
+		as.notifyStaffCancelled(appointment)
+	} else {
+		as.notifyVisitorCancelled(appointment)
+	}
+	return &appointment, nil
+}
+
+// RecordOutcome records whether the visitor attended, once the appointment has started
+func (as *AppointmentService) RecordOutcome(appointmentID uint, status string, recordedBy uint, now time.Time) (*models.Appointment, error) {
+	var appointment models.Appointment
+	if err := as.db.Preload("Slot").First(&appointment, appointmentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAppointmentNotFound
+		}
+		return nil, err
+	}
+	if appointment.Status == models.AppointmentCancelled {
+		return nil, ErrAppointmentNotBooked
+	}
+	if now.Before(appointment.Slot.StartsAt) {
+		return nil, ErrAppointmentNotStarted
+	}
+
+	// Outcomes can be corrected, e.g. a visitor marked as a no-show who arrived late
+	appointment.Status = status
+	appointment.OutcomeRecordedAt = &now
+	appointment.OutcomeRecordedBy = &recordedBy
+	if err := as.db.Omit("Slot").Save(&appointment).Error; err != nil {
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+// ForVisitor returns a visitor's appointments, soonest first
+func (as *AppointmentService) ForVisitor(visitorID uint) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := as.db.Preload("Slot").Preload("Slot.Staff", selectStaffName).
+		Joins("JOIN appointment_slots ON appointment_slots.id = appointments.slot_id").
+		Where("appointments.visitor_id = ?", visitorID).
+		Order("appointment_slots.starts_at DESC").
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// Calendar returns slots between two times with the appointment holding each. A zero
+// staffID includes every member of staff.
+func (as *AppointmentService) Calendar(staffID uint, category string, from, to time.Time) ([]CalendarSlot, error) {
+	query := as.db.Preload("Staff", selectStaffName).
+		Where("starts_at >= ? AND starts_at < ?", from, to)
+	if staffID != 0 {
+		query = query.Where("staff_id = ?", staffID)
+	}
+	if category != "" {
+		query = query.Where("category = ?", strings.ToLower(category))
+	}
+	var slots []models.AppointmentSlot
+	if err := query.Order("starts_at ASC").Find(&slots).Error; err != nil {
+		return nil, err
+	}
+
+	calendar := make([]CalendarSlot, 0, len(slots))
+	if len(slots) == 0 {
+		return calendar, nil
+	}
+	ids := make([]uint, 0, len(slots))
+	for _, slot := range slots {
+		ids = append(ids, slot.ID)
+	}
+	var appointments []models.Appointment
+	if err := as.db.Preload("Visitor", func(tx *gorm.DB) *gorm.DB {
+		return tx.Select("id", "first_name", "last_name", "email", "phone")
+	}).Where("slot_id IN ? AND status IN ?", ids, activeAppointmentStatuses).
+		Find(&appointments).Error; err != nil {
+		return nil, err
+	}
+	bySlot := map[uint]*models.Appointment{}
+	for i := range appointments {
+		bySlot[appointments[i].SlotID] = &appointments[i]
+	}
+
+	for _, slot := range slots {
+		calendar = append(calendar, CalendarSlot{AppointmentSlot: slot, Appointment: bySlot[slot.ID]})
+	}
+	return calendar, nil
+}
+
+// NoShowReport lists visitors' attendance at appointments between two times, most
+// no-shows first
+func (as *AppointmentService) NoShowReport(from, to, now time.Time) ([]AppointmentNoShowRow, error) {
+	var rows []AppointmentNoShowRow
+	err := as.db.Table("appointments").
+		Select(`appointments.visitor_id,
+			TRIM(users.first_name || ' ' || users.last_name) AS visitor_name,
+			COUNT(*) AS appointments,
+			COUNT(*) FILTER (WHERE appointments.status = ?) AS no_shows`, models.AppointmentNoShow).
+		Joins("JOIN appointment_slots ON appointment_slots.id = appointments.slot_id").
+		Joins("JOIN users ON users.id = appointments.visitor_id").
+		Where("appointments.status IN ? AND appointment_slots.starts_at >= ? AND appointment_slots.starts_at < ?",
+			[]string{models.AppointmentAttended, models.AppointmentNoShow}, from, to).
+		Group("appointments.visitor_id, users.first_name, users.last_name").
+		Order("no_shows DESC, appointments DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		rows[i].NoShowRate = percentOf(rows[i].NoShows, rows[i].Appointments)
+		if as.noShowLimit > 0 && rows[i].NoShows > 0 {
+			recent, err := as.recentNoShows(rows[i].VisitorID, now)
+			if err != nil {
+				return nil, err
+			}
+			rows[i].BookingBlock = recent >= int64(as.noShowLimit)
+		}
+	}
+	return rows, nil
+}
+
+// SendReminders reminds visitors of appointments starting within the reminder window
+// that have not had a reminder yet, and returns how many were sent
+func (as *AppointmentService) SendReminders(now time.Time) (int, error) {
+	var appointments []models.Appointment
+	if err := as.db.Preload("Slot").
+		Joins("JOIN appointment_slots ON appointment_slots.id = appointments.slot_id").
+		Where("appointments.status = ? AND appointments.reminder_sent_at IS NULL", models.AppointmentBooked).
+		Where("appointment_slots.starts_at > ? AND appointment_slots.starts_at <= ?", now, now.Add(appointmentReminderWindow)).
+		Find(&appointments).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, appointment := range appointments {
+		if err := GetGlobalRealtimeNotificationService().SendNotification(RealtimeNotificationData{
+			UserID:    appointment.VisitorID,
+			Type:      "appointment_reminder",
+			Title:     "Appointment Reminder",
+			Message:   fmt.Sprintf("Reminder: your %s appointment is %s.", serviceName(appointment.Slot.Category), describeSlot(appointment.Slot)),
+			Priority:  "high",
+			Category:  "visitor",
+			ActionURL: "/visitor/appointments",
+			// The parts of the appointment, for the plain-language email
+			Data: map[string]interface{}{
+				"Service": serviceName(appointment.Slot.Category),
+				"Day":     appointment.Slot.StartsAt.Format("Monday 2 January"),
+				"Time":    appointment.Slot.StartsAt.Format("3:04 PM"),
+				"Place":   appointment.Slot.Location,
+			},
+			Channels: []string{"websocket", "push", "email"},
+		}); err != nil {
+			log.Printf("Failed to send reminder for appointment %d: %v", appointment.ID, err)
+			continue
+		}
+		if err := as.db.Model(&models.Appointment{}).Where("id = ?", appointment.ID).
+			Update("reminder_sent_at", now).Error; err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// recentNoShows counts a visitor's no-shows within the lookback period
+func (as *AppointmentService) recentNoShows(visitorID uint, now time.Time) (int64, error) {
+	var count int64
+	err := as.db.Model(&models.Appointment{}).
+		Joins("JOIN appointment_slots ON appointment_slots.id = appointments.slot_id").
+		Where("appointments.visitor_id = ? AND appointments.status = ? AND appointment_slots.starts_at >= ?",
+			visitorID, models.AppointmentNoShow, now.Add(-appointmentNoShowLookback)).
+		Count(&count).Error
+	return count, err
+}
+
+// notifyBooked confirms a booking to the visitor and tells the member of staff
+func (as *AppointmentService) notifyBooked(appointment models.Appointment) {
+	service := GetGlobalRealtimeNotificationService()
+	when := describeSlot(appointment.Slot)
+	if err := service.SendNotification(RealtimeNotificationData{
+		UserID:    appointment.VisitorID,
+		Type:      "appointment_booked",
+		Title:     "Appointment Booked",
+		Message:   fmt.Sprintf("Your %s appointment is booked for %s.", serviceName(appointment.Slot.Category), when),
+		Priority:  "normal",
+		Category:  "visitor",
+		ActionURL: "/visitor/appointments",
+		Channels:  []string{"websocket", "email"},
+	}); err != nil {
+		log.Printf("Failed to confirm appointment %d: %v", appointment.ID, err)
+	}
+	if err := service.SendNotification(RealtimeNotificationData{
+		UserID:    appointment.Slot.StaffID,
+		Type:      "appointment_booked",
+		Title:     "New appointment booked",
+		Message:   fmt.Sprintf("A visitor booked your %s slot on %s.", serviceName(appointment.Slot.Category), when),
+		Priority:  "normal",
+		Category:  "staff",
+		ActionURL: "/admin/appointments",
+		Channels:  []string{"websocket"},
+	}); err != nil {
+		log.Printf("Failed to tell staff about appointment %d: %v", appointment.ID, err)
+	}
+}
+
+// notifyVisitorCancelled tells a visitor staff cancelled their appointment
+func (as *AppointmentService) notifyVisitorCancelled(appointment models.Appointment) {
+	message := fmt.Sprintf("Sorry, your %s appointment on %s has been cancelled.", serviceName(appointment.Slot.Category), describeSlot(appointment.Slot))
+	if appointment.CancellationReason != "" {
+		message += " Reason: " + appointment.CancellationReason
+	}
+	message += " Please book another time."
+	if err := GetGlobalRealtimeNotificationService().SendNotification(RealtimeNotificationData{
+		UserID:    appointment.VisitorID,
+		Type:      "appointment_cancelled",
+		Title:     "Appointment Cancelled",
+		Message:   message,
+		Priority:  "high",
+		Category:  "visitor",
+		ActionURL: "/visitor/appointments",
+		Channels:  []string{"websocket", "email"},
+	}); err != nil {
+		log.Printf("Failed to tell visitor about cancelled appointment %d: %v", appointment.ID, err)
+	}
+}
+
+// notifyStaffCancelled tells the member of staff a visitor cancelled
+func (as *AppointmentService) notifyStaffCancelled(appointment models.Appointment) {
+	if err := GetGlobalRealtimeNotificationService().SendNotification(RealtimeNotificationData{
+		UserID:    appointment.Slot.StaffID,
+		Type:      "appointment_cancelled",
+		Title:     "Appointment cancelled",
+		Message:   fmt.Sprintf("The visitor booked into your %s slot on %s cancelled. The slot is open again.", serviceName(appointment.Slot.Category), describeSlot(appointment.Slot)),
+		Priority:  "normal",
+		Category:  "staff",
+		ActionURL: "/admin/appointments",
+		Channels:  []string{"websocket"},
+	}); err != nil {
+		log.Printf("Failed to tell staff about cancelled appointment %d: %v", appointment.ID, err)
+	}
+}
+
+// selectStaffName limits a preloaded member of staff to their name
+func selectStaffName(tx *gorm.DB) *gorm.DB {
+	return tx.Select("id", "first_name", "last_name")
+}
+
+// serviceName returns the display name of a service type code
+func serviceName(category string) string {
+	var serviceType models.ServiceType
+	if err := db.DB.Select("name").Where("code = ?", category).First(&serviceType).Error; err != nil || serviceType.Name == "" {
+		return category
+	}
+	return serviceType.Name
+}
+
+// describeSlot formats when and where a slot is, e.g. "Tue 4 Mar at 10:30 (Room 2)"
+func describeSlot(slot models.AppointmentSlot) string {
+	when := slot.StartsAt.Format("Mon 2 Jan at 15:04")
+	if slot.Location != "" {
+		when += " (" + slot.Location + ")"
+	}
+	return when
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestCreateSlotsRejectsInvalidRanges(t *testing.T) {
+	as := &AppointmentService{}
+	start := time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		req  AppointmentSlotRequest
+		want error
+	}{
+		{"ends before start", AppointmentSlotRequest{StartsAt: start, EndsAt: start.Add(-time.Hour)}, ErrAppointmentSlotInvalid},
+		{"too short", AppointmentSlotRequest{StartsAt: start, EndsAt: start.Add(5 * time.Minute)}, ErrAppointmentSlotInvalid},
+		{"slots too short", AppointmentSlotRequest{StartsAt: start, EndsAt: start.Add(time.Hour), SlotMinutes: 5}, ErrAppointmentSlotInvalid},
+		{"too many slots", AppointmentSlotRequest{StartsAt: start, EndsAt: start.Add(24 * time.Hour), SlotMinutes: 15}, ErrAppointmentSlotTooMany},
+	}
+	for _, tc := range cases {
+		if _, err := as.CreateSlots(tc.req, 1); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestDescribeSlot(t *testing.T) {
+	slot := models.AppointmentSlot{StartsAt: time.Date(2026, 3, 3, 10, 30, 0, 0, time.UTC), Location: "Room 2"}
+	if got := describeSlot(slot); got != "Tue 3 Mar at 10:30 (Room 2)" {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,415 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Auto-approval errors
+var (
+	ErrAutoApprovalRuleNotFound = errors.New("auto-approval rule not found")
+	ErrAutoApprovalRuleInvalid  = errors.New("invalid auto-approval rule")
+	ErrAutoApprovalNotFound     = errors.New("auto-approval not found")
+	ErrAutoApprovalReviewed     = errors.New("auto-approval has already been reviewed")
+)
+
+// autoApprovalHistoryDays is how far back a visitor's requests are loaded for the
+// frequency checks, and so the longest frequency window a rule can use
+const autoApprovalHistoryDays = 365
+
+// autoApprovalFacts is what is known about a visitor when their request is checked
+type autoApprovalFacts struct {
+	AccountActive   bool
+	Verified        bool        // Photo ID and proof of address approved
+	VulnerableAdult bool        // Flagged for safeguarding
+	EarlierRequests []time.Time // When the visitor's other live requests were made
+	CompletedVisits int
+}
+
+// AutoApprovalSummary counts auto-approvals and how their reviews went
+type AutoApprovalSummary struct {
+	Approved    int64    `json:"approved"`
+	Sampled     int64    `json:"sampled"`
+	Pending     int64    `json:"pending_review"`
+	Upheld      int64    `json:"upheld"`
+	Overturned  int64    `json:"overturned"`
+	OverturnPct *float64 `json:"overturn_percent"` // Of reviewed approvals, once any have been reviewed
+}
+
+// AutoApprovalService approves low-risk help requests under admin-configured rules
+// and samples the approvals for staff to check
+type AutoApprovalService struct {
+	db     *gorm.DB
+	sample func(percent int) bool
+}
+
+// NewAutoApprovalService creates a new auto-approval service
+func NewAutoApprovalService() *AutoApprovalService {
+	return &AutoApprovalService{
+		db:     db.DB,
+		sample: func(percent int) bool { return rand.Intn(100) < percent },
+	}
+}
+
+// Rules returns every rule in the order they are tried
+func (as *AutoApprovalService) Rules() ([]models.AutoApprovalRule, error) {
+	var rules []models.AutoApprovalRule
+	err := as.db.Order("priority ASC, id ASC").Find(&rules).Error
+	return rules, err
+}
+
+// CreateRule adds a rule
+func (as *AutoApprovalService) CreateRule(rule *models.AutoApprovalRule, createdBy uint) error {
+	if err := validateAutoApprovalRule(rule); err != nil {
+		return err
+	}
+	rule.ID = 0
+	rule.CreatedBy, rule.UpdatedBy = createdBy, createdBy
+	return as.db.Create(rule).Error
+}
+
+// UpdateRule replaces a rule's conditions
+func (as *AutoApprovalService) UpdateRule(id uint, changes *models.AutoApprovalRule, updatedBy uint) (*models.AutoApprovalRule, error) {
+	if err := validateAutoApprovalRule(changes); err != nil {
+		return nil, err
+	}
+
+	var rule models.AutoApprovalRule
+	if err := as.db.First(&rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAutoApprovalRuleNotFound
+		}
+		return nil, err
+	}
+
+	changes.ID = rule.ID
+	changes.CreatedBy = rule.CreatedBy
+	changes.CreatedAt = rule.CreatedAt
+	changes.UpdatedBy = updatedBy
+	if err := as.db.Save(changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// DeleteRule removes a rule. Approvals it already made keep its name.
+func (as *AutoApprovalService) DeleteRule(id uint) error {
+	result := as.db.Delete(&models.AutoApprovalRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAutoApprovalRuleNotFound
+	}
+	return nil
+}
+
+// Apply approves a newly made pending request when an enabled rule covers it.
+// It returns nil when no rule matched and the request stays with staff.
+func (as *AutoApprovalService) Apply(request *models.HelpRequest, now time.Time) (*models.AutoApproval, error) {
+	if request.Status != models.HelpRequestStatusPending {
+		return nil, nil
+	}
+
+	var rules []models.AutoApprovalRule
+	if err := as.db.Where("enabled = ?", true).Order("priority ASC, id ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	facts, err := as.facts(request, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		checks, ok := evaluateAutoApprovalRule(rule, *request, facts, now)
+		if !ok {
+			continue
+		}
+
+		approval := &models.AutoApproval{
+			HelpRequestID: request.ID,
+			RuleID:        rule.ID,
+			RuleName:      rule.Name,
+			Checks:        strings.Join(checks, "; "),
+			ReviewStatus:  models.AutoApprovalReviewNotSampled,
+			CreatedAt:     now,
+		}
+		if rule.ReviewSamplePercent > 0 && as.sample(rule.ReviewSamplePercent) {
+			approval.ReviewStatus = models.AutoApprovalReviewPending
+		}
+
+		notes := fmt.Sprintf("Auto-approved by rule %q: %s", rule.Name, approval.Checks)
+		err := as.db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.HelpRequest{}).
+				Where("id = ? AND status = ?", request.ID, models.HelpRequestStatusPending).
+				Updates(map[string]interface{}{
+					"status":            models.HelpRequestStatusApproved,
+					"approved_at":       now,
+					"eligibility_notes": notes,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				approval = nil // Staff got to it first
+				return nil
+			}
+			return tx.Create(approval).Error
+		})
+		if err != nil || approval == nil {
+			return nil, err
+		}
+
+		request.Status = models.HelpRequestStatusApproved
+		request.ApprovedAt = &now
+		request.EligibilityNotes = notes
+		return approval, nil
+	}
+	return nil, nil
+}
+
+// Approvals returns a page of auto-approvals, newest first, optionally with one
+// review status
+func (as *AutoApprovalService) Approvals(reviewStatus string, page, limit int) ([]models.AutoApproval, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := as.db.Model(&models.AutoApproval{})
+	if reviewStatus != "" {
+		query = query.Where("review_status = ?", reviewStatus)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var approvals []models.AutoApproval
+	err := query.Preload("HelpRequest").Order("created_at DESC").
+		Offset((page - 1) * limit).Limit(limit).Find(&approvals).Error
+	return approvals, total, err
+}
+
+// Summary counts auto-approvals made since a time and how their reviews went
+func (as *AutoApprovalService) Summary(since time.Time) (*AutoApprovalSummary, error) {
+	var rows []struct {
+		ReviewStatus string
+		Count        int64
+	}
+	if err := as.db.Model(&models.AutoApproval{}).
+		Select("review_status, COUNT(*) AS count").
+		Where("created_at >= ?", since).
+		Group("review_status").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	summary := &AutoApprovalSummary{}
+	for _, row := range rows {
+		summary.Approved += row.Count
+		switch row.ReviewStatus {
+		case models.AutoApprovalReviewPending:
+			summary.Pending += row.Count
+		case models.AutoApprovalReviewUpheld:
+			summary.Upheld += row.Count
+		case models.AutoApprovalReviewOverturned:
+			summary.Overturned += row.Count
+		}
+	}
+	summary.Sampled = summary.Pending + summary.Upheld + summary.Overturned
+	if reviewed := summary.Upheld + summary.Overturned; reviewed > 0 {
+		pct := roundPence(float64(summary.Overturned) / float64(reviewed) * 100)
+		summary.OverturnPct = &pct
+	}
+	return summary, nil
+}
+
+// Review records a reviewer's decision on an auto-approval. Overturning one whose
+// request has not had a ticket yet returns the request to pending for staff.
+func (as *AutoApprovalService) Review(id uint, upheld bool, notes string, reviewedBy uint, now time.Time) (*models.AutoApproval, error) {
+	var approval models.AutoApproval
+	err := as.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&approval, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAutoApprovalNotFound
+			}
+			return err
+		}
+		if approval.ReviewedAt != nil {
+			return ErrAutoApprovalReviewed
+		}
+
+		approval.ReviewStatus = models.AutoApprovalReviewUpheld
+		if !upheld {
+			approval.ReviewStatus = models.AutoApprovalReviewOverturned
+			if err := tx.Model(&models.HelpRequest{}).
+				Where("id = ? AND status = ?", approval.HelpRequestID, models.HelpRequestStatusApproved).
+				Updates(map[string]interface{}{
+					"status":            models.HelpRequestStatusPending,
+					"approved_at":       nil,
+					"eligibility_notes": "Auto-approval overturned on review: " + strings.TrimSpace(notes),
+				}).Error; err != nil {
+				return err
+			}
+		}
+		approval.ReviewedBy = &reviewedBy
+		approval.ReviewedAt = &now
+		approval.ReviewNotes = strings.TrimSpace(notes)
+		return tx.Save(&approval).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// facts gathers what the rules check about the visitor making a request
+func (as *AutoApprovalService) facts(request *models.HelpRequest, now time.Time) (autoApprovalFacts, error) {
+	var facts autoApprovalFacts
+
+	var visitor models.User
+	if err := as.db.Select("id", "status").First(&visitor, request.VisitorID).Error; err != nil {
+		return facts, err
+	}
+	facts.AccountActive = visitor.Status == models.StatusActive
+
+	var approvedTypes []string
+	if err := as.db.Model(&models.Document{}).
+		Where("user_id = ? AND status = ? AND type IN ?", request.VisitorID, models.DocumentStatusApproved,
+			[]string{models.DocumentTypeID, models.DocumentTypeProofAddress}).
+		Distinct().Pluck("type", &approvedTypes).Error; err != nil {
+		return facts, err
+	}
+	facts.Verified = slices.Contains(approvedTypes, models.DocumentTypeID) &&
+		slices.Contains(approvedTypes, models.DocumentTypeProofAddress)
+
+	var restriction models.ContactRestriction
+	if err := as.db.Where("user_id = ?", request.VisitorID).First(&restriction).Error; err == nil {
+		facts.VulnerableAdult = restriction.VulnerableAdult
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return facts, err
+	}
+
+	if err := as.db.Model(&models.HelpRequest{}).
+		Where("visitor_id = ? AND id <> ? AND created_at >= ? AND status NOT IN ?", request.VisitorID, request.ID,
+			now.AddDate(0, 0, -autoApprovalHistoryDays),
+			[]string{models.HelpRequestStatusRejected, models.HelpRequestStatusCancelled}).
+		Pluck("created_at", &facts.EarlierRequests).Error; err != nil {
+		return facts, err
+	}
+
+	var completed int64
+	if err := as.db.Model(&models.HelpRequest{}).
+		Where("visitor_id = ? AND status = ?", request.VisitorID, models.HelpRequestStatusCompleted).
+		Count(&completed).Error; err != nil {
+		return facts, err
+	}
+	facts.CompletedVisits = int(completed)
+
+	return facts, nil
+}
+
+// evaluateAutoApprovalRule reports whether a rule approves a request, with the
+// checks that passed. Inactive accounts and visitors flagged as vulnerable adults
+// always go to staff whatever the rule says.
+func evaluateAutoApprovalRule(rule models.AutoApprovalRule, request models.HelpRequest, facts autoApprovalFacts, now time.Time) ([]string, bool) {
+	if !facts.AccountActive || facts.VulnerableAdult {
+		return nil, false
+	}
+	if !slices.ContainsFunc(rule.Categories, func(category string) bool { return strings.EqualFold(category, request.Category) }) {
+		return nil, false
+	}
+	checks := []string{"category " + request.Category}
+
+	if rule.RequireVerifiedID {
+		if !facts.Verified {
+			return nil, false
+		}
+		checks = append(checks, "ID verified")
+	}
+	if rule.FrequencyDays > 0 {
+		since := now.AddDate(0, 0, -rule.FrequencyDays)
+		recent := 0
+		for _, made := range facts.EarlierRequests {
+			if !made.Before(since) {
+				recent++
+			}
+		}
+		if recent > rule.MaxRequests {
+			return nil, false
+		}
+		checks = append(checks, fmt.Sprintf("%d earlier requests in %d days", recent, rule.FrequencyDays))
+	}
+	if rule.MaxHouseholdSize > 0 {
+		if request.HouseholdSize > rule.MaxHouseholdSize {
+			return nil, false
+		}
+		checks = append(checks, fmt.Sprintf("household of %d", request.HouseholdSize))
+	}
+	if rule.MinPreviousVisits > 0 {
+		if facts.CompletedVisits < rule.MinPreviousVisits {
+			return nil, false
+		}
+		checks = append(checks, fmt.Sprintf("%d previous visits", facts.CompletedVisits))
+	}
+	if rule.RequireEligiblePostcode {
+		if !request.IsEligible() {
+			return nil, false
+		}
+		checks = append(checks, "postcode in area")
+	}
+	if !rule.AllowSpecialNeeds && strings.TrimSpace(request.SpecialNeeds) != "" {
+		return nil, false
+	}
+	if !rule.AllowUrgent && (request.Priority == "high" || request.Priority == "urgent") {
+		return nil, false
+	}
+
+	return checks, true
+}
+
+// validateAutoApprovalRule checks a rule's conditions make sense
+func validateAutoApprovalRule(rule *models.AutoApprovalRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrAutoApprovalRuleInvalid)
+	}
+
+	var categories models.StringArray
+	for _, category := range rule.Categories {
+		if category = strings.TrimSpace(category); category != "" && !slices.Contains(categories, category) {
+			categories = append(categories, category)
+		}
+	}
+	if len(categories) == 0 {
+		return fmt.Errorf("%w: at least one category is required", ErrAutoApprovalRuleInvalid)
+	}
+	rule.Categories = categories
+
+	switch {
+	case rule.FrequencyDays < 0 || rule.FrequencyDays > autoApprovalHistoryDays:
+		return fmt.Errorf("%w: frequency window must be 0 to %d days", ErrAutoApprovalRuleInvalid, autoApprovalHistoryDays)
+	case rule.MaxRequests < 0:
+		return fmt.Errorf("%w: max requests cannot be negative", ErrAutoApprovalRuleInvalid)
+	case rule.MaxHouseholdSize < 0 || rule.MinPreviousVisits < 0:
+		return fmt.Errorf("%w: household size and previous visits cannot be negative", ErrAutoApprovalRuleInvalid)
+	case rule.ReviewSamplePercent < 0 || rule.ReviewSamplePercent > 100:
+		return fmt.Errorf("%w: review sample must be 0 to 100 percent", ErrAutoApprovalRuleInvalid)
+	}
+	return nil
+}
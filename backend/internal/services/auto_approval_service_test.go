@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestEvaluateAutoApprovalRule(t *testing.T) {
+	now := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	rule := models.AutoApprovalRule{
+		Name:                    "Standard food parcel",
+		Categories:              models.StringArray{"Food"},
+		RequireVerifiedID:       true,
+		MaxRequests:             1,
+		FrequencyDays:           28,
+		MaxHouseholdSize:        6,
+		RequireEligiblePostcode: true,
+	}
+	request := models.HelpRequest{Category: "food", HouseholdSize: 3, Postcode: "SE13 5AB", Priority: "normal"}
+	facts := autoApprovalFacts{
+		AccountActive:   true,
+		Verified:        true,
+		EarlierRequests: []time.Time{now.AddDate(0, 0, -10), now.AddDate(0, 0, -40)},
+	}
+
+	tests := []struct {
+		name   string
+		change func(*models.AutoApprovalRule, *models.HelpRequest, *autoApprovalFacts)
+		want   bool
+	}{
+		{name: "standard request approved", want: true},
+		{name: "other category", change: func(_ *models.AutoApprovalRule, r *models.HelpRequest, _ *autoApprovalFacts) { r.Category = "General" }},
+		{name: "not verified", change: func(_ *models.AutoApprovalRule, _ *models.HelpRequest, f *autoApprovalFacts) { f.Verified = false }},
+		{name: "verification not required", want: true, change: func(rl *models.AutoApprovalRule, _ *models.HelpRequest, f *autoApprovalFacts) {
+			rl.RequireVerifiedID, f.Verified = false, false
+		}},
+		{name: "too many recent requests", change: func(_ *models.AutoApprovalRule, _ *models.HelpRequest, f *autoApprovalFacts) {
+			f.EarlierRequests = append(f.EarlierRequests, now.AddDate(0, 0, -2))
+		}},
+		{name: "large household", change: func(_ *models.AutoApprovalRule, r *models.HelpRequest, _ *autoApprovalFacts) { r.HouseholdSize = 7 }},
+		{name: "outside area", change: func(_ *models.AutoApprovalRule, r *models.HelpRequest, _ *autoApprovalFacts) { r.Postcode = "N1 9GU" }},
+		{name: "special needs", change: func(_ *models.AutoApprovalRule, r *models.HelpRequest, _ *autoApprovalFacts) {
+			r.SpecialNeeds = "Wheelchair user"
+		}},
+		{name: "urgent", change: func(_ *models.AutoApprovalRule, r *models.HelpRequest, _ *autoApprovalFacts) { r.Priority = "urgent" }},
+		{name: "urgent allowed", want: true, change: func(rl *models.AutoApprovalRule, r *models.HelpRequest, _ *autoApprovalFacts) {
+			rl.AllowUrgent, r.Priority = true, "urgent"
+		}},
+		{name: "not enough previous visits", change: func(rl *models.AutoApprovalRule, _ *models.HelpRequest, f *autoApprovalFacts) {
+			rl.MinPreviousVisits, f.CompletedVisits = 2, 1
+		}},
+		{name: "vulnerable adult always to staff", change: func(_ *models.AutoApprovalRule, _ *models.HelpRequest, f *autoApprovalFacts) {
+			f.VulnerableAdult = true
+		}},
+		{name: "inactive account", change: func(_ *models.AutoApprovalRule, _ *models.HelpRequest, f *autoApprovalFacts) { f.AccountActive = false }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, req, f := rule, request, facts
+			f.EarlierRequests = append([]time.Time(nil), facts.EarlierRequests...)
+			if tt.change != nil {
+				tt.change(&r, &req, &f)
+			}
+			checks, ok := evaluateAutoApprovalRule(r, req, f, now)
+			if ok != tt.want {
+				t.Fatalf("approved = %v, want %v (checks %v)", ok, tt.want, checks)
+			}
+			if ok && len(checks) == 0 {
+				t.Error("approval should record the checks that passed")
+			}
+		})
+	}
+}
+
+func TestValidateAutoApprovalRule(t *testing.T) {
+	rule := &models.AutoApprovalRule{Name: " Food ", Categories: models.StringArray{" Food", "Food", ""}, FrequencyDays: 28, ReviewSamplePercent: 10}
+	if err := validateAutoApprovalRule(rule); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.Name != "Food" || len(rule.Categories) != 1 || rule.Categories[0] != "Food" {
+		t.Errorf("rule not tidied: %+v", rule)
+	}
+
+	invalid := []models.AutoApprovalRule{
+		{Categories: models.StringArray{"Food"}},
+		{Name: "No categories"},
+		{Name: "Long window", Categories: models.StringArray{"Food"}, FrequencyDays: 400},
+		{Name: "Sample", Categories: models.StringArray{"Food"}, ReviewSamplePercent: 101},
+		{Name: "Negative", Categories: models.StringArray{"Food"}, MaxHouseholdSize: -1},
+	}
+	for _, rule := range invalid {
+		if err := validateAutoApprovalRule(&rule); !errors.Is(err, ErrAutoApprovalRuleInvalid) {
+			t.Errorf("%q: expected ErrAutoApprovalRuleInvalid, got %v", rule.Name, err)
+		}
+	}
+}
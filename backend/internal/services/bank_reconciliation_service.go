@@ -0,0 +1,496 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Confidence thresholds used when matching bank transactions
+const (
+	BankAutoMatchConfidence = 0.9
+	BankSuggestConfidence   = 0.5
+)
+
+// BankReconciliationService matches bank statement credits to pledges and expected donations
+type BankReconciliationService struct {
+	db      *gorm.DB
+	pledges *PledgeService
+}
+
+// BankStatementRow is a parsed credit line from a bank statement export
+type BankStatementRow struct {
+	RowNumber   int
+	Date        time.Time
+	Amount      float64
+	Reference   string
+	Description string
+	PayerName   string
+}
+
+// BankMatchCandidate is a possible match for a bank transaction
+type BankMatchCandidate struct {
+	MatchType  string    `json:"match_type"`
+	MatchID    uint      `json:"match_id"`
+	Label      string    `json:"label"`
+	Amount     float64   `json:"amount"`
+	Date       time.Time `json:"date"`
+	Confidence float64   `json:"confidence"`
+	Reasons    []string  `json:"reasons"`
+}
+
+// Pledge import line outcomes
+const (
+	PledgeImportMatched   = "matched"
+	PledgeImportUnmatched = "unmatched"
+	PledgeImportDuplicate = "duplicate"
+)
+
+// PledgeImportLine is the outcome of one credit in a pledge payment import
+type PledgeImportLine struct {
+	RowNumber     int                    `json:"row"`
+	Reference     string                 `json:"reference"`
+	Amount        float64                `json:"amount"`
+	Status        string                 `json:"status"`
+	Reason        string                 `json:"reason,omitempty"`
+	TransactionID uint                   `json:"transaction_id,omitempty"`
+	Pledge        *models.DonationPledge `json:"pledge,omitempty"`
+}
+
+// NewBankReconciliationService creates a new bank reconciliation service
+func NewBankReconciliationService() *BankReconciliationService {
+	return &BankReconciliationService{
+		db:      db.DB,
+		pledges: NewPledgeService(),
+	}
+}
+
+// ImportStatement stores the rows of a bank statement and auto-matches those
+// with a high enough confidence score
+func (bs *BankReconciliationService) ImportStatement(fileName string, importedBy uint, rows []BankStatementRow) (*models.BankStatementImport, error) {
+	statement := models.BankStatementImport{
+		FileName:   fileName,
+		ImportedBy: importedBy,
+		RowCount:   len(rows),
+	}
+	if err := bs.db.Create(&statement).Error; err != nil {
+		return nil, fmt.Errorf("failed to create import record: %w", err)
+	}
+
+	for _, row := range rows {
+		if row.Amount <= 0 {
+			continue
+		}
+		statement.CreditCount++
+
+		txn := newBankTransaction(statement.ID, row)
+		candidates, err := bs.FindCandidates(txn)
+		if err == nil && len(candidates) > 0 {
+			best := candidates[0]
+			txn.MatchType = best.MatchType
+			txn.MatchID = &best.MatchID
+			txn.Confidence = best.Confidence
+			txn.MatchReason = strings.Join(best.Reasons, "; ")
+			txn.Status = models.BankTransactionStatusSuggested
+		}
+
+		claimed, err := bs.claimTransaction(txn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store row %d: %w", row.RowNumber, err)
+		}
+		if !claimed {
+			statement.Duplicates++
+			continue
+		}
+
+		if txn.Status == models.BankTransactionStatusSuggested && txn.Confidence >= BankAutoMatchConfidence {
+			if err := bs.ApplyMatch(txn, txn.MatchType, *txn.MatchID, nil); err == nil {
+				statement.AutoMatched++
+				continue
+			}
+		}
+
+		if txn.Status == models.BankTransactionStatusSuggested {
+			statement.Suggested++
+		} else {
+			statement.Unmatched++
+		}
+	}
+
+	if err := bs.db.Save(&statement).Error; err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+// ImportPledgePayments records the credits of a bank feed export against the open
+// pledges whose payment reference they quote. Lines are stored as bank transactions
+// like any other statement, so a line can only be applied once whichever import it
+// arrives through, and lines that match no pledge are left in the reconciliation queue.
+func (bs *BankReconciliationService) ImportPledgePayments(fileName string, importedBy uint, rows []BankStatementRow) (*models.BankStatementImport, []PledgeImportLine, error) {
+	statement := models.BankStatementImport{
+		FileName:   fileName,
+		ImportedBy: importedBy,
+		RowCount:   len(rows),
+	}
+	if err := bs.db.Create(&statement).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to create import record: %w", err)
+	}
+
+	lines := []PledgeImportLine{}
+	for _, row := range rows {
+		if row.Amount <= 0 {
+			// Debits are not pledge payments
+			continue
+		}
+		statement.CreditCount++
+		line := PledgeImportLine{RowNumber: row.RowNumber, Reference: row.Reference, Amount: row.Amount}
+
+		txn := newBankTransaction(statement.ID, row)
+		claimed, err := bs.claimTransaction(txn)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to store row %d: %w", row.RowNumber, err)
+		}
+		if !claimed {
+			statement.Duplicates++
+			line.Status = PledgeImportDuplicate
+			line.Reason = "Already imported"
+			lines = append(lines, line)
+			continue
+		}
+		line.TransactionID = txn.ID
+
+		pledge, err := bs.pledges.FindOpenPledgeByReference(row.Reference + " " + row.Description)
+		if err != nil {
+			statement.Unmatched++
+			line.Status = PledgeImportUnmatched
+			line.Reason = "No open pledge matches this reference"
+			lines = append(lines, line)
+			continue
+		}
+
+		if err := bs.ApplyMatch(txn, models.BankMatchTypePledge, pledge.ID, &importedBy); err != nil {
+			line.Status = PledgeImportUnmatched
+			line.Reason = err.Error()
+			if errors.Is(err, ErrPledgePaymentRecorded) {
+				// Recorded before bank lines were stored, by reference, date and amount
+				statement.Duplicates++
+				line.Status = PledgeImportDuplicate
+				bs.db.Model(txn).Updates(map[string]interface{}{
+					"status":       models.BankTransactionStatusIgnored,
+					"match_reason": err.Error(),
+				})
+			} else {
+				statement.Unmatched++
+			}
+			lines = append(lines, line)
+			continue
+		}
+		bs.db.Model(txn).Update("match_reason", "payment reference found by pledge import")
+
+		statement.AutoMatched++
+		line.Status = PledgeImportMatched
+		bs.db.First(pledge, pledge.ID) // Reload the balance and status after the payment
+		line.Pledge = pledge
+		lines = append(lines, line)
+	}
+
+	if err := bs.db.Save(&statement).Error; err != nil {
+		return nil, nil, err
+	}
+	return &statement, lines, nil
+}
+
+// newBankTransaction builds the unmatched transaction for a statement row
+func newBankTransaction(importID uint, row BankStatementRow) *models.BankTransaction {
+	return &models.BankTransaction{
+		ImportID:        importID,
+		RowNumber:       row.RowNumber,
+		TransactionDate: row.Date,
+		Amount:          row.Amount,
+		Reference:       row.Reference,
+		Description:     row.Description,
+		PayerName:       row.PayerName,
+		Fingerprint:     bankRowFingerprint(row),
+		Status:          models.BankTransactionStatusUnmatched,
+	}
+}
+
+// claimTransaction stores a transaction unless a row with the same fingerprint has
+// already been imported. The unique index makes this safe against concurrent imports.
+func (bs *BankReconciliationService) claimTransaction(txn *models.BankTransaction) (bool, error) {
+	result := bs.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "fingerprint"}},
+		DoNothing: true,
+	}).Create(txn)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindCandidates scores open pledges and pending monetary donations against a
+// bank transaction, returning those above the suggestion threshold, best first
+func (bs *BankReconciliationService) FindCandidates(txn *models.BankTransaction) ([]BankMatchCandidate, error) {
+	text := strings.ToUpper(strings.ReplaceAll(txn.Reference+txn.Description, " ", ""))
+	candidates := []BankMatchCandidate{}
+
+	// Only pledges that can reach the suggestion threshold: those quoted in the
+	// reference, or owing this exact amount and due within 30 days of the payment
+	var pledges []models.DonationPledge
+	if err := bs.db.
+		Where("status NOT IN ?", []string{models.PledgeStatusPaid, models.PledgeStatusCancelled}).
+		Where(`(payment_reference <> '' AND strpos(?, upper(payment_reference)) > 0)
+			OR (invoice_number <> '' AND strpos(?, upper(invoice_number)) > 0)
+			OR (abs(amount - amount_paid - ?) < 0.005 AND due_date > ? AND due_date < ?)`,
+			text, text, txn.Amount, txn.TransactionDate.AddDate(0, 0, -31), txn.TransactionDate.AddDate(0, 0, 31)).
+		Find(&pledges).Error; err != nil {
+		return nil, err
+	}
+	for _, pledge := range pledges {
+		score, reasons := scorePledgeMatch(txn, text, pledge)
+		if score >= BankSuggestConfidence {
+			candidates = append(candidates, BankMatchCandidate{
+				MatchType:  models.BankMatchTypePledge,
+				MatchID:    pledge.ID,
+				Label:      fmt.Sprintf("%s (%s)", pledge.BusinessName, pledge.PaymentReference),
+				Amount:     pledge.OutstandingAmount(),
+				Date:       pledge.DueDate,
+				Confidence: math.Min(score, 1),
+				Reasons:    reasons,
+			})
+		}
+	}
+
+	// Likewise only donations quoted in the reference or for this exact amount
+	var donations []models.Donation
+	if err := bs.db.
+		Where("status = ? AND type IN ?", models.DonationStatusPending, []string{"monetary", "money"}).
+		Where("(payment_id <> '' AND strpos(?, upper(replace(payment_id, ' ', ''))) > 0) OR abs(amount - ?) < 0.005",
+			text, txn.Amount).
+		Find(&donations).Error; err != nil {
+		return nil, err
+	}
+	for _, donation := range donations {
+		score, reasons := scoreDonationMatch(txn, text, donation)
+		if score >= BankSuggestConfidence {
+			candidates = append(candidates, BankMatchCandidate{
+				MatchType:  models.BankMatchTypeDonation,
+				MatchID:    donation.ID,
+				Label:      fmt.Sprintf("Donation #%d from %s", donation.ID, donation.Name),
+				Amount:     donation.Amount,
+				Date:       donation.CreatedAt,
+				Confidence: math.Min(score, 1),
+				Reasons:    reasons,
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return candidates, nil
+}
+
+// scorePledgeMatch scores how likely a transaction is to be a payment towards a
+// pledge. text is the transaction's reference and description, upper case without spaces.
+func scorePledgeMatch(txn *models.BankTransaction, text string, pledge models.DonationPledge) (float64, []string) {
+	var score float64
+	var reasons []string
+
+	if pledge.PaymentReference != "" && strings.Contains(text, strings.ToUpper(pledge.PaymentReference)) {
+		score += 0.6
+		reasons = append(reasons, "payment reference found")
+	} else if pledge.InvoiceNumber != "" && strings.Contains(text, strings.ToUpper(pledge.InvoiceNumber)) {
+		score += 0.5
+		reasons = append(reasons, "invoice number found")
+	}
+	if amountsEqual(txn.Amount, pledge.OutstandingAmount()) {
+		score += 0.3
+		reasons = append(reasons, "amount matches outstanding balance")
+	} else if txn.Amount < pledge.OutstandingAmount() {
+		score += 0.1
+		reasons = append(reasons, "part payment of outstanding balance")
+	}
+	if daysBetween(txn.TransactionDate, pledge.DueDate) <= 30 {
+		score += 0.1
+		reasons = append(reasons, "paid within 30 days of due date")
+	}
+	if namesOverlap(txn.PayerName+" "+txn.Description, pledge.BusinessName) {
+		score += 0.1
+		reasons = append(reasons, "payer name matches business")
+	}
+	return roundScore(score), reasons
+}
+
+// scoreDonationMatch scores how likely a transaction is to be the payment for a
+// pending donation
+func scoreDonationMatch(txn *models.BankTransaction, text string, donation models.Donation) (float64, []string) {
+	var score float64
+	var reasons []string
+
+	if donation.PaymentID != "" && strings.Contains(text, strings.ToUpper(strings.ReplaceAll(donation.PaymentID, " ", ""))) {
+		score += 0.6
+		reasons = append(reasons, "payment reference found")
+	}
+	if amountsEqual(txn.Amount, donation.Amount) {
+		score += 0.3
+		reasons = append(reasons, "amount matches")
+	}
+	if daysBetween(txn.TransactionDate, donation.CreatedAt) <= 7 {
+		score += 0.1
+		reasons = append(reasons, "received within 7 days of pledge")
+	}
+	if donation.Name != "" && namesOverlap(txn.PayerName+" "+txn.Description, donation.Name) {
+		score += 0.2
+		reasons = append(reasons, "payer name matches donor")
+	}
+	return roundScore(score), reasons
+}
+
+// roundScore rounds a confidence score to two places, so that 0.6 + 0.3 reaches the
+// 0.9 auto-match threshold rather than falling just short of it in floating point
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
+
+// ApplyMatch links a bank transaction to a pledge or donation and records the
+// money against it. matchedBy is nil for automatic matches.
+func (bs *BankReconciliationService) ApplyMatch(txn *models.BankTransaction, matchType string, matchID uint, matchedBy *uint) error {
+	if txn.IsResolved() {
+		return fmt.Errorf("transaction is already %s", txn.Status)
+	}
+
+	var receivedDonation uint
+	switch matchType {
+	case models.BankMatchTypePledge:
+		if _, _, err := bs.pledges.RecordPayment(matchID, txn.Amount, txn.TransactionDate,
+			models.PledgePaymentSourceBankFeed, txn.Reference, matchedBy, txn.Description); err != nil {
+			return err
+		}
+	case models.BankMatchTypeDonation:
+		var donation models.Donation
+		if err := bs.db.First(&donation, matchID).Error; err != nil {
+			return err
+		}
+		if donation.Status != models.DonationStatusPending {
+			return fmt.Errorf("donation is already %s", donation.Status)
+		}
+		donation.Status = models.DonationStatusReceived
+		donation.PaymentMethod = "bank_transfer"
+		donation.ReceivedAt = &txn.TransactionDate
+		donation.ReceivedBy = matchedBy
+		if donation.PaymentID == "" {
+			donation.PaymentID = txn.Reference
+		}
+		if err := bs.db.Save(&donation).Error; err != nil {
+			return err
+		}
+		receivedDonation = donation.ID
+	default:
+		return fmt.Errorf("unknown match type: %s", matchType)
+	}
+
+	now := time.Now()
+	txn.Status = models.BankTransactionStatusMatched
+	txn.MatchType = matchType
+	txn.MatchID = &matchID
+	txn.MatchedBy = matchedBy
+	txn.MatchedAt = &now
+	if matchedBy != nil {
+		txn.Confidence = 1
+		txn.MatchReason = "matched manually"
+	}
+	if err := bs.db.Save(txn).Error; err != nil {
+		return err
+	}
+
+	if receivedDonation != 0 {
+		go SendReceiptWhenReceived(receivedDonation)
+	}
+	return nil
+}
+
+// CreateDonationFromTransaction records an unmatched credit as a new monetary donation
+func (bs *BankReconciliationService) CreateDonationFromTransaction(txn *models.BankTransaction, name, email, notes string, createdBy uint) (*models.Donation, error) {
+	if txn.IsResolved() {
+		return nil, fmt.Errorf("transaction is already %s", txn.Status)
+	}
+	if name == "" {
+		name = txn.PayerName
+	}
+
+	donation := models.Donation{
+		Name:          name,
+		ContactEmail:  email,
+		Type:          "monetary",
+		Amount:        txn.Amount,
+		Currency:      "GBP",
+		PaymentMethod: "bank_transfer",
+		PaymentID:     txn.Reference,
+		Status:        models.DonationStatusReceived,
+		ReceivedBy:    &createdBy,
+		ReceivedAt:    &txn.TransactionDate,
+		Notes:         notes,
+	}
+
+	err := bs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&donation).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		txn.Status = models.BankTransactionStatusMatched
+		txn.MatchType = models.BankMatchTypeDonation
+		txn.MatchID = &donation.ID
+		txn.MatchedBy = &createdBy
+		txn.MatchedAt = &now
+		txn.Confidence = 1
+		txn.MatchReason = "new donation created from bank credit"
+		return tx.Save(txn).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go SendReceiptWhenReceived(donation.ID)
+	return &donation, nil
+}
+
+// bankRowFingerprint identifies a statement row so re-imports of overlapping statements are skipped
+func bankRowFingerprint(row BankStatementRow) string {
+	key := fmt.Sprintf("%s|%.2f|%s|%s", row.Date.Format("2006-01-02"), row.Amount,
+		strings.ToUpper(strings.TrimSpace(row.Reference)), strings.ToUpper(strings.TrimSpace(row.Description)))
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// amountsEqual compares money amounts to the penny
+func amountsEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
+
+// daysBetween returns the absolute number of days between two dates
+func daysBetween(a, b time.Time) int {
+	return int(math.Abs(a.Sub(b).Hours()) / 24)
+}
+
+// namesOverlap returns true if a significant word of name appears in text
+func namesOverlap(text, name string) bool {
+	upper := strings.ToUpper(text)
+	for _, word := range strings.Fields(strings.ToUpper(name)) {
+		if len(word) >= 4 && strings.Contains(upper, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+// matchText normalises a transaction's reference and description as FindCandidates does
+func matchText(txn *models.BankTransaction) string {
+	return strings.ToUpper(strings.ReplaceAll(txn.Reference+txn.Description, " ", ""))
+}
+
+func TestScorePledgeMatch(t *testing.T) {
+	paid := time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC)
+	pledge := models.DonationPledge{
+		BusinessName:     "Deptford Bakery Ltd",
+		PaymentReference: "PLG-1A2B3C4D",
+		InvoiceNumber:    "INV-2026-00012",
+		Amount:           500,
+		AmountPaid:       100,
+		DueDate:          paid.AddDate(0, 0, 5),
+	}
+
+	tests := []struct {
+		name        string
+		txn         models.BankTransaction
+		want        float64
+		autoMatched bool
+	}{
+		{"reference, balance, on time and name",
+			models.BankTransaction{Reference: "plg-1a2b 3c4d", PayerName: "DEPTFORD BAKERY", Amount: 400}, 1.1, true},
+		{"reference and balance",
+			models.BankTransaction{Reference: "PLG-1A2B3C4D", Amount: 400, TransactionDate: paid.AddDate(0, 2, 0)}, 0.9, true},
+		{"invoice number and part payment",
+			models.BankTransaction{Description: "Payment INV-2026-00012", Amount: 150}, 0.7, false},
+		{"amount and date alone are only a suggestion",
+			models.BankTransaction{Reference: "THANK YOU", Amount: 400}, 0.4, false},
+		{"overpayment with no reference",
+			models.BankTransaction{Reference: "DONATION", Amount: 900, TransactionDate: paid.AddDate(0, 3, 0)}, 0, false},
+	}
+	for _, tt := range tests {
+		if tt.txn.TransactionDate.IsZero() {
+			tt.txn.TransactionDate = paid
+		}
+		score, reasons := scorePledgeMatch(&tt.txn, matchText(&tt.txn), pledge)
+		if math.Abs(score-tt.want) > 1e-9 {
+			t.Errorf("%s: score %.2f (%v), want %.2f", tt.name, score, reasons, tt.want)
+		}
+		if (score >= BankAutoMatchConfidence) != tt.autoMatched {
+			t.Errorf("%s: auto-matched %v, want %v", tt.name, !tt.autoMatched, tt.autoMatched)
+		}
+	}
+}
+
+func TestScoreDonationMatch(t *testing.T) {
+	pledged := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	donation := models.Donation{Name: "Amara Okafor", Amount: 25, PaymentID: "DON 4471"}
+	donation.CreatedAt = pledged
+
+	txn := models.BankTransaction{Reference: "DON4471", PayerName: "A OKAFOR", Amount: 25, TransactionDate: pledged.AddDate(0, 0, 3)}
+	score, reasons := scoreDonationMatch(&txn, matchText(&txn), donation)
+	if math.Abs(score-1.2) > 1e-9 || len(reasons) != 4 {
+		t.Errorf("full match: score %.2f, reasons %v", score, reasons)
+	}
+
+	// The same amount from a stranger a fortnight later is not suggested
+	txn = models.BankTransaction{Reference: "GIFT", PayerName: "J SMITH", Amount: 25, TransactionDate: pledged.AddDate(0, 0, 14)}
+	if score, _ := scoreDonationMatch(&txn, matchText(&txn), donation); score >= BankSuggestConfidence {
+		t.Errorf("amount alone scored %.2f", score)
+	}
+}
+
+func TestNamesOverlap(t *testing.T) {
+	tests := []struct {
+		text, name string
+		want       bool
+	}{
+		{"FPS CREDIT DEPTFORD BAKERY", "Deptford Bakery Ltd", true},
+		{"J SMITH", "Amara Okafor", false},
+		{"LTD CO", "Bakery Ltd Co", false}, // Short words are ignored
+	}
+	for _, tt := range tests {
+		if got := namesOverlap(tt.text, tt.name); got != tt.want {
+			t.Errorf("namesOverlap(%q, %q) = %v, want %v", tt.text, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBankRowFingerprint(t *testing.T) {
+	row := BankStatementRow{RowNumber: 2, Date: time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC), Amount: 400, Reference: "PLG-1A2B3C4D", Description: "Bakery"}
+
+	// The same line in an overlapping statement, on a different row with different spacing
+	again := row
+	again.RowNumber = 9
+	again.Reference = " plg-1a2b3c4d "
+	again.PayerName = "DEPTFORD BAKERY"
+	if bankRowFingerprint(row) != bankRowFingerprint(again) {
+		t.Error("re-imported line has a different fingerprint")
+	}
+
+	other := row
+	other.Amount = 400.01
+	if bankRowFingerprint(row) == bankRowFingerprint(other) {
+		t.Error("different amount has the same fingerprint")
+	}
+}
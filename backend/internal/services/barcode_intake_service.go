@@ -0,0 +1,628 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/httpclient"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// barcodeLookupTimeout bounds a provider lookup so a slow provider does not hold
+	// up the scanner
+	barcodeLookupTimeout = 5 * time.Second
+	// barcodeNegativeCacheFor is how long a code the provider did not know is kept
+	// before asking again
+	barcodeNegativeCacheFor = 24 * time.Hour
+	barcodeUserAgent        = "charity-management-system/1.0 (inventory intake)"
+)
+
+// Barcode intake errors
+var (
+	ErrInvalidBarcode       = errors.New("invalid barcode")
+	ErrBarcodeUnknown       = errors.New("product not recognised, choose the stock item it belongs to")
+	ErrBarcodeUncategorised = errors.New("could not work out a category for this product, choose one")
+)
+
+// BarcodeProduct is what a lookup provider knows about a barcode
+type BarcodeProduct struct {
+	Name       string
+	Brand      string
+	PackSize   string
+	Categories []string // Most general first
+}
+
+// BarcodeLookup resolves barcodes to products. Lookup returns nil when the provider
+// does not know the code.
+type BarcodeLookup interface {
+	Source() string
+	Lookup(ctx context.Context, barcode string) (*BarcodeProduct, error)
+}
+
+// BarcodeScanInput is one scan at intake. Quantity defaults to one unit.
+type BarcodeScanInput struct {
+	Barcode    string
+	Quantity   int
+	ItemID     *uint // Stock item to book into; links the code to it
+	CategoryID *uint // Category for a new item when it cannot be worked out
+	Reference  string
+	BatchCode  string
+	ExpiresOn  *time.Time
+	DonationID *uint
+}
+
+// BarcodeScanResult is the stock booked in for a scan
+type BarcodeScanResult struct {
+	Barcode         *models.ProductBarcode `json:"barcode"`
+	Item            *InventoryItemView     `json:"item"`
+	Movement        *models.StockMovement  `json:"movement"`
+	ItemCreated     bool                   `json:"item_created"`     // A new stock item was added for the product
+	AutoCategorised bool                   `json:"auto_categorised"` // Its category was worked out from the product
+	Warning         string                 `json:"warning,omitempty"`
+}
+
+// BarcodeResolution is what a scan would be booked into, without changing stock
+type BarcodeResolution struct {
+	Barcode            *models.ProductBarcode    `json:"barcode"`
+	ProviderCategories []string                  `json:"provider_categories"`
+	Item               *InventoryItemView        `json:"item,omitempty"`               // Item the code is linked to
+	SuggestedCategory  *models.InventoryCategory `json:"suggested_category,omitempty"` // For a new item
+	Warning            string                    `json:"warning,omitempty"`
+}
+
+// BarcodeIntakeService books scanned goods into stock. Codes are resolved to products
+// through the configured lookup provider and cached, and new products are added as
+// stock items in the category their description matches best.
+type BarcodeIntakeService struct {
+	db       *gorm.DB
+	lookup   BarcodeLookup
+	cacheFor time.Duration
+}
+
+// NewBarcodeIntakeService creates a new barcode intake service
+func NewBarcodeIntakeService() *BarcodeIntakeService {
+	cacheDays := 30
+	if n, err := strconv.Atoi(os.Getenv("BARCODE_LOOKUP_CACHE_DAYS")); err == nil && n > 0 {
+		cacheDays = n
+	}
+	bs := &BarcodeIntakeService{
+		db:       db.DB,
+		cacheFor: time.Duration(cacheDays) * 24 * time.Hour,
+	}
+	if lookupURL := strings.TrimSpace(os.Getenv("BARCODE_LOOKUP_URL")); lookupURL != "" {
+		bs.lookup = &productDatabaseLookup{
+			client: httpclient.New("barcode_lookup", httpclient.Options{Timeout: barcodeLookupTimeout}),
+			url:    lookupURL,
+			apiKey: os.Getenv("BARCODE_LOOKUP_API_KEY"),
+		}
+	}
+	return bs
+}
+
+// Resolve shows what a barcode is and where a scan of it would be booked
+func (bs *BarcodeIntakeService) Resolve(ctx context.Context, code string, now time.Time) (*BarcodeResolution, error) {
+	code, err := NormalizeBarcode(code)
+	if err != nil {
+		return nil, err
+	}
+	barcode, warning, err := bs.resolve(ctx, code, now)
+	if err != nil {
+		return nil, err
+	}
+
+	resolution := &BarcodeResolution{Barcode: barcode, ProviderCategories: barcode.CategoryList(), Warning: warning}
+	if barcode.ItemID != nil {
+		item, err := NewInventoryService().Item(*barcode.ItemID)
+		if err != nil && !errors.Is(err, ErrInventoryItemNotFound) {
+			return nil, err
+		}
+		if item != nil && item.Active {
+			resolution.Item = item
+			return resolution, nil
+		}
+	}
+	if barcode.Found {
+		var categories []models.InventoryCategory
+		if err := bs.db.Order("name ASC").Find(&categories).Error; err != nil {
+			return nil, err
+		}
+		resolution.SuggestedCategory = categorizeProduct(barcode, categories)
+	}
+	return resolution, nil
+}
+
+// Scan books one scan into stock. The code goes to the item given, then the item it
+// is linked to; a product seen for the first time becomes a new item in the category
+// worked out for it.
+func (bs *BarcodeIntakeService) Scan(ctx context.Context, input BarcodeScanInput, recordedBy uint, now time.Time) (*BarcodeScanResult, error) {
+	code, err := NormalizeBarcode(input.Barcode)
+	if err != nil {
+		return nil, err
+	}
+	if input.Quantity == 0 {
+		input.Quantity = 1
+	}
+	if input.Quantity < 0 {
+		return nil, fmt.Errorf("%w: quantity must be positive", ErrInventoryInvalid)
+	}
+
+	barcode, warning, err := bs.resolve(ctx, code, now)
+	if err != nil {
+		return nil, err
+	}
+	result := &BarcodeScanResult{Warning: warning}
+
+	var item models.InventoryItem
+	err = bs.db.Transaction(func(tx *gorm.DB) error {
+		linkedBy := barcode.LinkedBy
+		switch {
+		case input.ItemID != nil:
+			if err := lockInventoryItem(tx, *input.ItemID, &item); err != nil {
+				return err
+			}
+			if barcode.ItemID == nil || *barcode.ItemID != item.ID {
+				linkedBy = &recordedBy
+			}
+		case barcode.ItemID != nil && linkedItemUsable(tx, *barcode.ItemID, &item):
+		case barcode.Found:
+			if err := bs.createScannedItem(tx, barcode, input.CategoryID, recordedBy, &item, result); err != nil {
+				return err
+			}
+			linkedBy = &recordedBy
+		default:
+			return ErrBarcodeUnknown
+		}
+
+		if err := checkItemExpiry(tx, &item, input.ExpiresOn, now); err != nil {
+			return err
+		}
+		reference := strings.TrimSpace(input.Reference)
+		if reference == "" {
+			reference = "Barcode " + code
+		}
+		result.Movement, err = applyStockMovement(tx, &item, StockMovementInput{
+			Type:       models.StockMovementIn,
+			Quantity:   input.Quantity,
+			Reason:     "Scanned at intake",
+			Reference:  reference,
+			BatchCode:  input.BatchCode,
+			ExpiresOn:  input.ExpiresOn,
+			DonationID: input.DonationID,
+		}, &recordedBy, now)
+		if err != nil {
+			return err
+		}
+		return recordBarcodeScan(tx, barcode, item.ID, linkedBy, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Barcode = barcode
+	if result.Item, err = NewInventoryService().Item(item.ID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Link points a barcode at a stock item, so later scans are booked into it
+func (bs *BarcodeIntakeService) Link(code string, itemID, linkedBy uint, now time.Time) (*models.ProductBarcode, error) {
+	code, err := NormalizeBarcode(code)
+	if err != nil {
+		return nil, err
+	}
+	var item models.InventoryItem
+	if err := bs.db.First(&item, itemID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInventoryItemNotFound
+		}
+		return nil, err
+	}
+
+	barcode := &models.ProductBarcode{Barcode: code, Source: "manual", ItemID: &item.ID, LinkedBy: &linkedBy}
+	err = bs.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "barcode"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"item_id": item.ID, "linked_by": linkedBy, "updated_at": now}),
+	}).Create(barcode).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := bs.db.Preload("Item").Where("barcode = ?", code).First(barcode).Error; err != nil {
+		return nil, err
+	}
+	return barcode, nil
+}
+
+// resolve returns the cached barcode, asking the provider when the cache has nothing
+// fresh. When the provider cannot be reached the scan carries on with what is known,
+// along with a warning, so intake is not held up.
+func (bs *BarcodeIntakeService) resolve(ctx context.Context, code string, now time.Time) (*models.ProductBarcode, string, error) {
+	var barcode models.ProductBarcode
+	err := bs.db.Where("barcode = ?", code).First(&barcode).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", err
+	}
+	if err == nil && !bs.stale(&barcode, now) {
+		return &barcode, "", nil
+	}
+	if bs.lookup == nil {
+		barcode.Barcode = code
+		return &barcode, "", nil
+	}
+
+	product, err := bs.lookup.Lookup(ctx, code)
+	if err != nil {
+		log.Printf("Barcode lookup for %s failed: %v", code, err)
+		barcode.Barcode = code
+		return &barcode, "The product lookup is unavailable, so the product could not be recognised", nil
+	}
+
+	looked := models.ProductBarcode{Barcode: code, Found: product != nil, Source: bs.lookup.Source(), LookedUpAt: now}
+	if product != nil {
+		looked.ProductName = product.Name
+		looked.Brand = product.Brand
+		looked.PackSize = product.PackSize
+		looked.Categories = strings.Join(product.Categories, ",")
+	}
+	err = bs.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "barcode"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"found":        looked.Found,
+			"product_name": looked.ProductName,
+			"brand":        looked.Brand,
+			"pack_size":    looked.PackSize,
+			"categories":   looked.Categories,
+			"source":       looked.Source,
+			"looked_up_at": now,
+			"updated_at":   now,
+		}),
+	}).Create(&looked).Error
+	if err != nil {
+		return nil, "", err
+	}
+	if err := bs.db.Where("barcode = ?", code).First(&barcode).Error; err != nil {
+		return nil, "", err
+	}
+	return &barcode, "", nil
+}
+
+// stale reports whether a cached barcode should be looked up again. Codes linked to
+// an item are kept; the link is what scans need.
+func (bs *BarcodeIntakeService) stale(barcode *models.ProductBarcode, now time.Time) bool {
+	if barcode.ItemID != nil {
+		return false
+	}
+	if !barcode.Found {
+		return now.Sub(barcode.LookedUpAt) > barcodeNegativeCacheFor
+	}
+	return now.Sub(barcode.LookedUpAt) > bs.cacheFor
+}
+
+// createScannedItem adds a stock item for a product scanned for the first time
+func (bs *BarcodeIntakeService) createScannedItem(tx *gorm.DB, barcode *models.ProductBarcode, categoryID *uint, createdBy uint, item *models.InventoryItem, result *BarcodeScanResult) error {
+	var category *models.InventoryCategory
+	if categoryID != nil {
+		category = &models.InventoryCategory{}
+		if err := tx.First(category, *categoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInventoryCategoryNotFound
+			}
+			return err
+		}
+	} else {
+		var categories []models.InventoryCategory
+		if err := tx.Order("name ASC").Find(&categories).Error; err != nil {
+			return err
+		}
+		if category = categorizeProduct(barcode, categories); category == nil {
+			return ErrBarcodeUncategorised
+		}
+		result.AutoCategorised = true
+	}
+
+	details := []string{}
+	for _, detail := range []string{barcode.Brand, barcode.PackSize} {
+		if detail != "" {
+			details = append(details, detail)
+		}
+	}
+	*item = models.InventoryItem{
+		Name:        scannedItemName(barcode),
+		CategoryID:  category.ID,
+		Description: strings.Join(details, ", "),
+		Active:      true,
+		CreatedBy:   createdBy,
+	}
+	if err := validateInventoryItem(item); err != nil {
+		return err
+	}
+	if err := tx.Create(item).Error; err != nil {
+		return err
+	}
+	result.ItemCreated = true
+	return nil
+}
+
+// lockInventoryItem loads an item for a stock movement
+func lockInventoryItem(tx *gorm.DB, id uint, item *models.InventoryItem) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(item, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInventoryItemNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// linkedItemUsable loads the item a barcode is linked to, reporting false when it has
+// since been deleted or is no longer stocked
+func linkedItemUsable(tx *gorm.DB, id uint, item *models.InventoryItem) bool {
+	if err := lockInventoryItem(tx, id, item); err != nil {
+		return false
+	}
+	return item.Active
+}
+
+// recordBarcodeScan links the code to the item it was booked into and counts the scan
+func recordBarcodeScan(tx *gorm.DB, barcode *models.ProductBarcode, itemID uint, linkedBy *uint, now time.Time) error {
+	if barcode.ID == 0 {
+		barcode.Source = "manual"
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(barcode).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(&models.ProductBarcode{}).Where("barcode = ?", barcode.Barcode).Updates(map[string]interface{}{
+		"item_id":      itemID,
+		"linked_by":    linkedBy,
+		"scan_count":   gorm.Expr("scan_count + 1"),
+		"last_scan_at": now,
+		"updated_at":   now,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Where("barcode = ?", barcode.Barcode).First(barcode).Error
+}
+
+// NormalizeBarcode strips spaces and dashes from a scanned EAN-8, EAN-13 or UPC-A code
+// and checks its check digit. UPC-A codes are returned as their EAN-13 form, so both
+// spellings of a product share one cache entry.
+func NormalizeBarcode(code string) (string, error) {
+	code = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' {
+			return -1
+		}
+		return r
+	}, code)
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("%w: only digits are allowed", ErrInvalidBarcode)
+		}
+	}
+	switch len(code) {
+	case 8, 12, 13:
+	default:
+		return "", fmt.Errorf("%w: EAN and UPC codes have 8, 12 or 13 digits", ErrInvalidBarcode)
+	}
+	if !validCheckDigit(code) {
+		return "", fmt.Errorf("%w: check digit does not match, scan it again", ErrInvalidBarcode)
+	}
+	if len(code) == 12 {
+		code = "0" + code
+	}
+	return code, nil
+}
+
+// validCheckDigit checks the last digit of a GTIN. Working left from the digit before
+// it, digits are weighted 3, 1, 3, ...; the check digit brings the sum to a multiple
+// of ten.
+func validCheckDigit(code string) bool {
+	sum, weight := 0, 3
+	for i := len(code) - 2; i >= 0; i-- {
+		sum += int(code[i]-'0') * weight
+		weight = 4 - weight
+	}
+	return (10-sum%10)%10 == int(code[len(code)-1]-'0')
+}
+
+// scannedItemName names a new stock item after the product, with its brand in front
+// when the product name leaves it out
+func scannedItemName(barcode *models.ProductBarcode) string {
+	name := strings.TrimSpace(barcode.ProductName)
+	brand := strings.TrimSpace(strings.Split(barcode.Brand, ",")[0])
+	if brand != "" && !strings.Contains(strings.ToLower(name), strings.ToLower(brand)) {
+		name = brand + " " + name
+	}
+	return name
+}
+
+// productWordSynonyms maps words used by product databases onto the words charities
+// tend to name stock categories with
+var productWordSynonyms = map[string]string{
+	"canned":     "tinned",
+	"can":        "tinned",
+	"tin":        "tinned",
+	"diaper":     "baby",
+	"nappy":      "baby",
+	"nappie":     "baby",
+	"infant":     "baby",
+	"shampoo":    "toiletry",
+	"soap":       "toiletry",
+	"toothpaste": "toiletry",
+	"deodorant":  "toiletry",
+	"hygiene":    "toiletry",
+	"beverage":   "drink",
+	"juice":      "drink",
+	"detergent":  "cleaning",
+	"household":  "cleaning",
+}
+
+// productStopWords carry no meaning for categorising
+var productStopWords = map[string]bool{
+	"and": true, "or": true, "of": true, "in": true, "the": true, "with": true, "their": true,
+	"for": true, "product": true, "food": true, "based": true, "other": true,
+}
+
+// categorizeProduct picks the stock category whose name and description best match
+// the product's provider categories. Provider categories run from general to
+// specific, so later ones count for more; the product name counts least. Admins can
+// steer the match by describing categories, e.g. "Tins of beans, soup and fish".
+// It returns nil when nothing matches.
+func categorizeProduct(barcode *models.ProductBarcode, categories []models.InventoryCategory) *models.InventoryCategory {
+	weighted := map[string]int{}
+	for _, word := range productWords(barcode.ProductName) {
+		weighted[word] = max(weighted[word], 1)
+	}
+	for i, category := range barcode.CategoryList() {
+		for _, word := range productWords(category) {
+			weighted[word] = max(weighted[word], i+2)
+		}
+	}
+
+	var best *models.InventoryCategory
+	bestScore := 0
+	for i := range categories {
+		score := 0
+		seen := map[string]bool{}
+		for _, word := range productWords(categories[i].Name + " " + categories[i].Description) {
+			if !seen[word] {
+				seen[word] = true
+				score += weighted[word]
+			}
+		}
+		if score > bestScore {
+			best, bestScore = &categories[i], score
+		}
+	}
+	return best
+}
+
+// productWords splits text into lower case singular words, mapped onto category
+// vocabulary
+func productWords(text string) []string {
+	words := []string{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		switch {
+		case strings.HasSuffix(word, "ies") && len(word) > 4:
+			word = strings.TrimSuffix(word, "ies") + "y"
+		case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && len(word) > 3:
+			word = strings.TrimSuffix(word, "s")
+		}
+		if synonym, ok := productWordSynonyms[word]; ok {
+			word = synonym
+		}
+		if len(word) > 1 && !productStopWords[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// productDatabaseLookup looks barcodes up in an Open Food Facts compatible product
+// database. The URL holds a {barcode} placeholder, e.g.
+// https://world.openfoodfacts.org/api/v2/product/{barcode}.json
+type productDatabaseLookup struct {
+	client *http.Client
+	url    string
+	apiKey string
+}
+
+// Source names the provider by its host
+func (pl *productDatabaseLookup) Source() string {
+	if parsed, err := url.Parse(pl.url); err == nil && parsed.Host != "" {
+		return parsed.Host
+	}
+	return "lookup"
+}
+
+// Lookup asks the product database for a barcode
+func (pl *productDatabaseLookup) Lookup(ctx context.Context, barcode string) (*BarcodeProduct, error) {
+	lookupURL := strings.ReplaceAll(pl.url, "{barcode}", barcode)
+	if lookupURL == pl.url {
+		lookupURL = strings.TrimSuffix(pl.url, "/") + "/" + barcode
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, lookupURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", barcodeUserAgent)
+	if pl.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+pl.apiKey)
+	}
+
+	resp, err := pl.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("responded %d", resp.StatusCode)
+	}
+	return parseProductDatabaseResponse(body)
+}
+
+// parseProductDatabaseResponse reads an Open Food Facts style product response. A
+// product without a name is treated as unknown, since no stock item can be named
+// after it.
+func parseProductDatabaseResponse(body []byte) (*BarcodeProduct, error) {
+	var response struct {
+		Status  int `json:"status"`
+		Product struct {
+			ProductName   string   `json:"product_name"`
+			ProductNameEn string   `json:"product_name_en"`
+			Brands        string   `json:"brands"`
+			Quantity      string   `json:"quantity"`
+			CategoriesTag []string `json:"categories_tags"`
+		} `json:"product"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("unreadable response: %w", err)
+	}
+
+	name := strings.TrimSpace(response.Product.ProductNameEn)
+	if name == "" {
+		name = strings.TrimSpace(response.Product.ProductName)
+	}
+	if response.Status != 1 || name == "" {
+		return nil, nil
+	}
+
+	product := &BarcodeProduct{
+		Name:       name,
+		Brand:      strings.TrimSpace(response.Product.Brands),
+		PackSize:   strings.TrimSpace(response.Product.Quantity),
+		Categories: []string{},
+	}
+	for _, tag := range response.Product.CategoriesTag {
+		// Tags look like "en:canned-foods"
+		if _, value, ok := strings.Cut(tag, ":"); ok {
+			tag = value
+		}
+		if tag = strings.TrimSpace(strings.ReplaceAll(tag, "-", " ")); tag != "" {
+			product.Categories = append(product.Categories, tag)
+		}
+	}
+	return product, nil
+}
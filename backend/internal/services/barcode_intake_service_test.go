@@ -0,0 +1,92 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestNormalizeBarcode(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{code: "5000157024671", want: "5000157024671"},   // EAN-13
+		{code: "5 000157 024671", want: "5000157024671"}, // Printed with spaces
+		{code: "96385074", want: "96385074"},             // EAN-8
+		{code: "036000291452", want: "0036000291452"},    // UPC-A stored as EAN-13
+		{code: "5000157024672", wantErr: true},           // Wrong check digit
+		{code: "50001570246", wantErr: true},             // Too short
+		{code: "50001570246A1", wantErr: true},
+		{code: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeBarcode(tt.code)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidBarcode) {
+				t.Errorf("NormalizeBarcode(%q) error = %v, want ErrInvalidBarcode", tt.code, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeBarcode(%q) = %q, %v, want %q", tt.code, got, err, tt.want)
+		}
+	}
+}
+
+func TestCategorizeProduct(t *testing.T) {
+	categories := []models.InventoryCategory{
+		{ID: 1, Name: "Baby Supplies"},
+		{ID: 2, Name: "Breakfast", Description: "Cereals, porridge and spreads"},
+		{ID: 3, Name: "Drinks"},
+		{ID: 4, Name: "Tinned Food", Description: "Tins of beans, soup and fish"},
+		{ID: 5, Name: "Toiletries"},
+	}
+	tests := []struct {
+		name    string
+		barcode models.ProductBarcode
+		want    uint
+	}{
+		{"canned goods", models.ProductBarcode{ProductName: "Baked Beans", Categories: "plant based foods,legumes,canned foods,baked beans"}, 4},
+		{"specific category beats general", models.ProductBarcode{ProductName: "Oat Drink", Categories: "beverages,breakfast cereals"}, 2},
+		{"synonyms", models.ProductBarcode{ProductName: "Shampoo", Categories: "hygiene,shampoos"}, 5},
+		{"nappies", models.ProductBarcode{ProductName: "Size 4 Nappies"}, 1},
+		{"nothing matches", models.ProductBarcode{ProductName: "Dog Biscuits", Categories: "pet foods"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got uint
+			if category := categorizeProduct(&tt.barcode, categories); category != nil {
+				got = category.ID
+			}
+			if got != tt.want {
+				t.Errorf("got category %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProductDatabaseResponse(t *testing.T) {
+	product, err := parseProductDatabaseResponse([]byte(`{"status":1,"product":{
+		"product_name":"Baked Beanz","brands":"Heinz","quantity":"415 g",
+		"categories_tags":["en:plant-based-foods","en:canned-foods"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product == nil || product.Name != "Baked Beanz" || product.Brand != "Heinz" || product.PackSize != "415 g" ||
+		!slices.Equal(product.Categories, []string{"plant based foods", "canned foods"}) {
+		t.Fatalf("unexpected product %+v", product)
+	}
+	if name := scannedItemName(&models.ProductBarcode{ProductName: product.Name, Brand: product.Brand}); name != "Heinz Baked Beanz" {
+		t.Errorf("item named %q", name)
+	}
+
+	for _, body := range []string{`{"status":0,"status_verbose":"product not found"}`, `{"status":1,"product":{"product_name":""}}`} {
+		if product, err := parseProductDatabaseResponse([]byte(body)); err != nil || product != nil {
+			t.Errorf("%s: got %+v, %v, want an unknown product", body, product, err)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBookingWindowNotFound = errors.New("booking window not found")
+	ErrBookingWindowInvalid  = errors.New("invalid booking window")
+	ErrBookingWindowExists   = errors.New("a booking window already exists for this category and day")
+	ErrInvalidVisitDay       = errors.New("visit day must be YYYY-MM-DD")
+	ErrBookingNotOpen        = errors.New("bookings are not open yet")
+	ErrBookingClosed         = errors.New("bookings have closed")
+)
+
+// Booking window states
+const (
+	BookingWindowUpcoming = "upcoming"
+	BookingWindowOpen     = "open"
+	BookingWindowClosed   = "closed"
+)
+
+// maxBookingWindowDays is how far ahead of a visit a window can open, and how far
+// ahead the upcoming windows can be listed
+const maxBookingWindowDays = 60
+
+// BookingWindowStatus is when bookings for one category and visit day open and close
+type BookingWindowStatus struct {
+	Category string    `json:"category"`
+	VisitDay string    `json:"visit_day"`
+	OpensAt  time.Time `json:"opens_at"`
+	ClosesAt time.Time `json:"closes_at"`
+	State    string    `json:"state"`
+	Message  string    `json:"message"`
+}
+
+// BookingWindowService manages and enforces booking windows
+type BookingWindowService struct {
+	db *gorm.DB
+}
+
+// NewBookingWindowService creates a new booking window service
+func NewBookingWindowService() *BookingWindowService {
+	return &BookingWindowService{
+		db: db.DB,
+	}
+}
+
+// List returns the booking windows, optionally for one category
+func (bs *BookingWindowService) List(category string) ([]models.BookingWindow, error) {
+	query := bs.db.Order("category ASC, weekday ASC NULLS FIRST")
+	if category != "" {
+		query = query.Where("category = ?", strings.ToLower(strings.TrimSpace(category)))
+	}
+	var windows []models.BookingWindow
+	if err := query.Find(&windows).Error; err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+// Validate checks a booking window before it is saved
+func (bs *BookingWindowService) Validate(window *models.BookingWindow) error {
+	window.Category = strings.ToLower(strings.TrimSpace(window.Category))
+	if window.Category == "" {
+		return fmt.Errorf("%w: category is required", ErrBookingWindowInvalid)
+	}
+	if window.Weekday != nil && (*window.Weekday < 0 || *window.Weekday > 6) {
+		return fmt.Errorf("%w: weekday must be between 0 (Sunday) and 6 (Saturday)", ErrBookingWindowInvalid)
+	}
+	if window.OpensDaysBefore < 0 || window.OpensDaysBefore > maxBookingWindowDays {
+		return fmt.Errorf("%w: opens_days_before must be between 0 and %d", ErrBookingWindowInvalid, maxBookingWindowDays)
+	}
+	if window.ClosesDaysBefore < 0 {
+		return fmt.Errorf("%w: closes_days_before cannot be negative", ErrBookingWindowInvalid)
+	}
+	opens, closes, err := window.Bounds(time.Now())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBookingWindowInvalid, err)
+	}
+	if !opens.Before(closes) {
+		return fmt.Errorf("%w: bookings must open before they close", ErrBookingWindowInvalid)
+	}
+	return nil
+}
+
+// Create saves a new booking window
+func (bs *BookingWindowService) Create(window *models.BookingWindow, adminID uint) error {
+	window.ID = 0
+	if err := bs.Validate(window); err != nil {
+		return err
+	}
+	if err := bs.checkUnique(window); err != nil {
+		return err
+	}
+	window.UpdatedBy = adminID
+	return bs.db.Create(window).Error
+}
+
+// Update replaces a booking window's settings
+func (bs *BookingWindowService) Update(id uint, changes models.BookingWindow, adminID uint) (*models.BookingWindow, error) {
+	var window models.BookingWindow
+	if err := bs.db.First(&window, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBookingWindowNotFound
+		}
+		return nil, err
+	}
+
+	changes.ID = window.ID
+	changes.CreatedAt = window.CreatedAt
+	if err := bs.Validate(&changes); err != nil {
+		return nil, err
+	}
+	if err := bs.checkUnique(&changes); err != nil {
+		return nil, err
+	}
+	changes.UpdatedBy = adminID
+	if err := bs.db.Save(&changes).Error; err != nil {
+		return nil, err
+	}
+	return &changes, nil
+}
+
+// Delete removes a booking window
+func (bs *BookingWindowService) Delete(id uint) (*models.BookingWindow, error) {
+	var window models.BookingWindow
+	if err := bs.db.First(&window, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBookingWindowNotFound
+		}
+		return nil, err
+	}
+	if err := bs.db.Delete(&window).Error; err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// checkUnique rejects a second window for the same category and weekday
+func (bs *BookingWindowService) checkUnique(window *models.BookingWindow) error {
+	query := bs.db.Model(&models.BookingWindow{}).Where("category = ? AND id <> ?", window.Category, window.ID)
+	if window.Weekday == nil {
+		query = query.Where("weekday IS NULL")
+	} else {
+		query = query.Where("weekday = ?", *window.Weekday)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrBookingWindowExists
+	}
+	return nil
+}
+
+// activeWindows loads the active windows, optionally for one category
+func (bs *BookingWindowService) activeWindows(category string) ([]models.BookingWindow, error) {
+	query := bs.db.Where("is_active = ?", true)
+	if category != "" {
+		query = query.Where("category = ?", strings.ToLower(strings.TrimSpace(category)))
+	}
+	var windows []models.BookingWindow
+	if err := query.Find(&windows).Error; err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+// Check reports whether a visit day can be booked now. It returns a nil status when
+// the category has no booking window, and ErrBookingNotOpen or ErrBookingClosed
+// with the status when the window is not open.
+func (bs *BookingWindowService) Check(category, visitDay string, now time.Time) (*BookingWindowStatus, error) {
+	windows, err := bs.activeWindows(category)
+	if err != nil || len(windows) == 0 {
+		return nil, err
+	}
+	day, err := time.ParseInLocation("2006-01-02", visitDay, time.Local)
+	if err != nil {
+		return nil, ErrInvalidVisitDay
+	}
+	window := resolveBookingWindow(windows, category, day.Weekday())
+	if window == nil {
+		return nil, nil
+	}
+
+	status, err := bookingWindowStatus(window, category, day, now)
+	if err != nil {
+		return nil, err
+	}
+	switch status.State {
+	case BookingWindowUpcoming:
+		return &status, ErrBookingNotOpen
+	case BookingWindowClosed:
+		return &status, ErrBookingClosed
+	}
+	return &status, nil
+}
+
+// Upcoming lists the booking windows for visit days over the next few days that have
+// not yet closed, for every category with a window or just the one given
+func (bs *BookingWindowService) Upcoming(category string, days int, now time.Time) ([]BookingWindowStatus, error) {
+	if days <= 0 || days > maxBookingWindowDays {
+		days = 14
+	}
+	windows, err := bs.activeWindows(category)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := map[string]bool{}
+	for _, window := range windows {
+		categories[window.Category] = true
+	}
+
+	statuses := []BookingWindowStatus{}
+	serviceTypeService := NewServiceTypeService()
+	y, m, d := now.In(time.Local).Date()
+	for code := range categories {
+		// Without a service type, visits run on the default operating days
+		serviceType, err := serviceTypeService.GetByCode(code)
+		if err != nil {
+			serviceType = &models.ServiceType{OperatingDays: "Tuesday,Wednesday,Thursday"}
+		}
+
+		for i := 0; i <= days; i++ {
+			day := time.Date(y, m, d+i, 0, 0, 0, 0, time.Local)
+			if !serviceType.IsOperatingDay(day.Weekday()) {
+				continue
+			}
+			window := resolveBookingWindow(windows, code, day.Weekday())
+			if window == nil {
+				continue
+			}
+			status, err := bookingWindowStatus(window, code, day, now)
+			if err != nil || status.State == BookingWindowClosed {
+				continue
+			}
+			statuses = append(statuses, status)
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].VisitDay != statuses[j].VisitDay {
+			return statuses[i].VisitDay < statuses[j].VisitDay
+		}
+		return statuses[i].Category < statuses[j].Category
+	})
+	return statuses, nil
+}
+
+// resolveBookingWindow picks the window for a category's weekday, falling back to the
+// category default. It returns nil when the category has no window.
+func resolveBookingWindow(windows []models.BookingWindow, category string, weekday time.Weekday) *models.BookingWindow {
+	var fallback *models.BookingWindow
+	for i := range windows {
+		window := &windows[i]
+		if !window.IsActive || !strings.EqualFold(window.Category, strings.TrimSpace(category)) {
+			continue
+		}
+		if window.Weekday == nil {
+			fallback = window
+		} else if time.Weekday(*window.Weekday) == weekday {
+			return window
+		}
+	}
+	return fallback
+}
+
+// bookingWindowStatus works out whether a window is upcoming, open or closed for a
+// visit day and describes it for visitors
+func bookingWindowStatus(window *models.BookingWindow, category string, day, now time.Time) (BookingWindowStatus, error) {
+	opensAt, closesAt, err := window.Bounds(day)
+	if err != nil {
+		return BookingWindowStatus{}, err
+	}
+
+	status := BookingWindowStatus{
+		Category: strings.ToLower(strings.TrimSpace(category)),
+		VisitDay: day.Format("2006-01-02"),
+		OpensAt:  opensAt,
+		ClosesAt: closesAt,
+	}
+	visit := fmt.Sprintf("%s visits on %s", status.Category, day.Format("Monday 2 January"))
+	switch {
+	case now.Before(opensAt):
+		status.State = BookingWindowUpcoming
+		status.Message = fmt.Sprintf("Bookings for %s open at %s", visit, opensAt.Format("3:04 PM on Monday 2 January"))
+	case now.Before(closesAt):
+		status.State = BookingWindowOpen
+		status.Message = fmt.Sprintf("Bookings for %s close at %s", visit, closesAt.Format("3:04 PM on Monday 2 January"))
+	default:
+		status.State = BookingWindowClosed
+		status.Message = fmt.Sprintf("Bookings for %s closed at %s", visit, closesAt.Format("3:04 PM on Monday 2 January"))
+	}
+	return status, nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestResolveBookingWindow(t *testing.T) {
+	tuesday := int(time.Tuesday)
+	windows := []models.BookingWindow{
+		{ID: 1, Category: "food", IsActive: true},
+		{ID: 2, Category: "food", Weekday: &tuesday, IsActive: true},
+		{ID: 3, Category: "general", Weekday: &tuesday, IsActive: false},
+	}
+	cases := []struct {
+		category string
+		weekday  time.Weekday
+		want     uint
+	}{
+		{"food", time.Tuesday, 2},
+		{"Food", time.Wednesday, 1},  // Falls back to the category default
+		{"general", time.Tuesday, 0}, // Inactive windows are ignored
+		{"clothing", time.Tuesday, 0},
+	}
+	for _, tc := range cases {
+		var got uint
+		if window := resolveBookingWindow(windows, tc.category, tc.weekday); window != nil {
+			got = window.ID
+		}
+		if got != tc.want {
+			t.Errorf("resolveBookingWindow(%s, %s) = %d, want %d", tc.category, tc.weekday, got, tc.want)
+		}
+	}
+}
+
+func TestBookingWindowStatus(t *testing.T) {
+	window := &models.BookingWindow{OpensDaysBefore: 7, OpensAt: "09:00", ClosesDaysBefore: 1, ClosesAt: "18:00"}
+	visit := time.Date(2026, 3, 10, 0, 0, 0, 0, time.Local) // Tuesday
+	cases := []struct {
+		now     time.Time
+		state   string
+		message string
+	}{
+		{time.Date(2026, 3, 3, 8, 59, 0, 0, time.Local), BookingWindowUpcoming,
+			"Bookings for food visits on Tuesday 10 March open at 9:00 AM on Tuesday 3 March"},
+		{time.Date(2026, 3, 3, 9, 0, 0, 0, time.Local), BookingWindowOpen,
+			"Bookings for food visits on Tuesday 10 March close at 6:00 PM on Monday 9 March"},
+		{time.Date(2026, 3, 9, 18, 0, 0, 0, time.Local), BookingWindowClosed,
+			"Bookings for food visits on Tuesday 10 March closed at 6:00 PM on Monday 9 March"},
+	}
+	for _, tc := range cases {
+		status, err := bookingWindowStatus(window, "Food", visit, tc.now)
+		if err != nil {
+			t.Fatalf("bookingWindowStatus: %v", err)
+		}
+		if status.State != tc.state || status.Message != tc.message {
+			t.Errorf("at %v got %s %q, want %s %q", tc.now, status.State, status.Message, tc.state, tc.message)
+		}
+	}
+}
+
+func TestValidateBookingWindow(t *testing.T) {
+	bs := &BookingWindowService{}
+	cases := []struct {
+		window models.BookingWindow
+		valid  bool
+	}{
+		{models.BookingWindow{Category: " Food ", OpensDaysBefore: 7, OpensAt: "09:00", ClosesDaysBefore: 1, ClosesAt: "18:00"}, true},
+		{models.BookingWindow{Category: "food", OpensDaysBefore: 0, OpensAt: "06:00", ClosesDaysBefore: 0, ClosesAt: "10:00"}, true},
+		{models.BookingWindow{Category: "food", OpensDaysBefore: 1, OpensAt: "18:00", ClosesDaysBefore: 1, ClosesAt: "09:00"}, false},
+		{models.BookingWindow{Category: "food", OpensDaysBefore: 1, OpensAt: "09:00", ClosesDaysBefore: 2, ClosesAt: "09:00"}, false},
+		{models.BookingWindow{Category: "food", OpensDaysBefore: 7, OpensAt: "9am", ClosesDaysBefore: 1, ClosesAt: "18:00"}, false},
+		{models.BookingWindow{Category: "", OpensDaysBefore: 7, OpensAt: "09:00", ClosesDaysBefore: 1, ClosesAt: "18:00"}, false},
+	}
+	for i, tc := range cases {
+		window := tc.window
+		err := bs.Validate(&window)
+		if (err == nil) != tc.valid {
+			t.Errorf("case %d: Validate = %v, want valid %v", i, err, tc.valid)
+		}
+	}
+}
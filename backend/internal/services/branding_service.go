@@ -0,0 +1,271 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// Branding errors
+var (
+	ErrBrandingNotFound = errors.New("branding profile not found")
+	ErrBrandingColor    = errors.New("colours must be hex values such as #1D4ED8")
+	ErrBrandingLogo     = errors.New("logo must be a PNG or JPEG image")
+	ErrBrandingLogoSize = errors.New("logo must be 512 KB or smaller and at most 2000 pixels across")
+	ErrBrandingLanguage = errors.New("language must be a language tag such as en or pl")
+)
+
+// Logo limits, kept small because the logo is embedded in every PDF
+const (
+	maxBrandingLogoBytes  = 512 * 1024
+	maxBrandingLogoPixels = 2000
+)
+
+// brandingColorPattern matches the hex colours branding accepts
+var brandingColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// BrandingUpdate is the editable part of a branding profile. Blank fields fall back
+// to the organisation default.
+type BrandingUpdate struct {
+	DisplayName   string `json:"display_name"`
+	PrimaryColor  string `json:"primary_color"`
+	AccentColor   string `json:"accent_color"`
+	FooterText    string `json:"footer_text"`
+	CharityNumber string `json:"charity_number"`
+	Website       string `json:"website"`
+	ContactEmail  string `json:"contact_email"`
+	ContactPhone  string `json:"contact_phone"`
+	Address       string `json:"address"`
+	LogoURL       string `json:"logo_url"`
+	Language      string `json:"language"` // Default message language for the location's users
+}
+
+// BrandingService manages the letterhead used on emails, PDFs, tickets and
+// certificates, with an organisation default and optional per-location overrides
+type BrandingService struct {
+	db *gorm.DB
+}
+
+// NewBrandingService creates a new branding service
+func NewBrandingService() *BrandingService {
+	return &BrandingService{db: db.DB}
+}
+
+// List returns every saved profile, the organisation default first
+func (bs *BrandingService) List() ([]models.BrandingProfile, error) {
+	var profiles []models.BrandingProfile
+	err := bs.db.Order("location ASC").Find(&profiles).Error
+	return profiles, err
+}
+
+// Get returns the profile saved for a location, without fallbacks
+func (bs *BrandingService) Get(location string) (*models.BrandingProfile, error) {
+	var profile models.BrandingProfile
+	if err := bs.db.Where("location = ?", strings.TrimSpace(location)).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBrandingNotFound
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Resolve returns the branding in effect for a location after fallbacks
+func (bs *BrandingService) Resolve(location string) models.BrandingProfile {
+	return notifications.LoadBranding(location)
+}
+
+// Save creates or updates the profile for a location. An empty location is the
+// organisation default.
+func (bs *BrandingService) Save(location string, update BrandingUpdate, updatedBy uint) (*models.BrandingProfile, error) {
+	for _, color := range []string{update.PrimaryColor, update.AccentColor} {
+		if color != "" && !brandingColorPattern.MatchString(color) {
+			return nil, ErrBrandingColor
+		}
+	}
+	language, ok := notifications.NormalizeLanguage(update.Language)
+	if !ok {
+		return nil, ErrBrandingLanguage
+	}
+
+	profile, err := bs.Get(location)
+	if errors.Is(err, ErrBrandingNotFound) {
+		profile = &models.BrandingProfile{Location: strings.TrimSpace(location)}
+	} else if err != nil {
+		return nil, err
+	}
+
+	profile.DisplayName = strings.TrimSpace(update.DisplayName)
+	profile.PrimaryColor = strings.ToUpper(update.PrimaryColor)
+	profile.AccentColor = strings.ToUpper(update.AccentColor)
+	profile.FooterText = strings.TrimSpace(update.FooterText)
+	profile.CharityNumber = strings.TrimSpace(update.CharityNumber)
+	profile.Website = strings.TrimSpace(update.Website)
+	profile.ContactEmail = strings.TrimSpace(update.ContactEmail)
+	profile.ContactPhone = strings.TrimSpace(update.ContactPhone)
+	profile.Address = strings.TrimSpace(update.Address)
+	profile.LogoURL = strings.TrimSpace(update.LogoURL)
+	profile.Language = language
+	profile.UpdatedBy = &updatedBy
+
+	if err := bs.db.Save(profile).Error; err != nil {
+		return nil, err
+	}
+	notifications.InvalidateBrandingCache()
+	return profile, nil
+}
+
+// Delete removes a location's profile so it uses the organisation default again.
+// Deleting the default returns to the built-in branding.
+func (bs *BrandingService) Delete(location string) error {
+	result := bs.db.Where("location = ?", strings.TrimSpace(location)).Delete(&models.BrandingProfile{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBrandingNotFound
+	}
+	notifications.InvalidateBrandingCache()
+	return nil
+}
+
+// SetLogo stores an uploaded logo for a location, creating its profile if needed
+func (bs *BrandingService) SetLogo(location string, data []byte, updatedBy uint) (*models.BrandingProfile, error) {
+	if len(data) > maxBrandingLogoBytes {
+		return nil, ErrBrandingLogoSize
+	}
+	contentType := http.DetectContentType(data)
+	if contentType != "image/png" && contentType != "image/jpeg" {
+		return nil, ErrBrandingLogo
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrBrandingLogo
+	}
+	if config.Width > maxBrandingLogoPixels || config.Height > maxBrandingLogoPixels {
+		return nil, ErrBrandingLogoSize
+	}
+
+	profile, err := bs.Get(location)
+	if errors.Is(err, ErrBrandingNotFound) {
+		profile = &models.BrandingProfile{Location: strings.TrimSpace(location)}
+	} else if err != nil {
+		return nil, err
+	}
+	profile.LogoData = data
+	profile.LogoContentType = contentType
+	profile.UpdatedBy = &updatedBy
+
+	if err := bs.db.Save(profile).Error; err != nil {
+		return nil, err
+	}
+	notifications.InvalidateBrandingCache()
+	return profile, nil
+}
+
+// RemoveLogo deletes a location's uploaded logo
+func (bs *BrandingService) RemoveLogo(location string, updatedBy uint) (*models.BrandingProfile, error) {
+	profile, err := bs.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	if err := bs.db.Model(profile).Updates(map[string]interface{}{
+		"logo_data":         nil,
+		"logo_content_type": "",
+		"updated_by":        updatedBy,
+	}).Error; err != nil {
+		return nil, err
+	}
+	profile.LogoData = nil
+	profile.LogoContentType = ""
+	notifications.InvalidateBrandingCache()
+	return profile, nil
+}
+
+// Logo returns the logo in effect for a location
+func (bs *BrandingService) Logo(location string) ([]byte, string, time.Time, bool) {
+	profile := bs.Resolve(location)
+	if !profile.HasLogo() {
+		return nil, "", time.Time{}, false
+	}
+	return profile.LogoData, profile.LogoContentType, profile.UpdatedAt, true
+}
+
+// PreviewEmail renders a sample email with a location's branding
+func (bs *BrandingService) PreviewEmail(location string) string {
+	profile := bs.Resolve(location)
+	body := fmt.Sprintf(`<h2>Thank you for your support</h2>
+<p>Hello Alex,</p>
+<p>This is a preview of how emails from %s will look. Your message appears here,
+between the letterhead and the footer.</p>
+<p>Best regards,</p>
+<p>%s</p>`, profile.DisplayName, profile.DisplayName)
+	return notifications.BrandEmail(body, profile)
+}
+
+// PreviewPDF renders a sample document with a location's branding
+func (bs *BrandingService) PreviewPDF(location string) []byte {
+	profile := bs.Resolve(location)
+	doc := BrandedPDF("Branding preview", location)
+	doc.Heading(profile.DisplayName+" - Sample document").
+		Blank().
+		Line("This is a preview of how receipts, invoices, tickets and certificates").
+		Line("will look with the current branding.").
+		Blank().
+		Subheading("Details").
+		Field("Reference", "PREVIEW-0001").
+		Field("Date", time.Now().Format("2 January 2006"))
+	return doc.Bytes()
+}
+
+// BrandedPDF starts a PDF with the letterhead for a location
+func BrandedPDF(title, location string) *utils.PDFDocument {
+	profile := notifications.LoadBranding(location)
+	return utils.NewPDFDocument(title).Brand(utils.PDFBranding{
+		Name:        profile.DisplayName,
+		Color:       profile.PrimaryColor,
+		FooterLines: brandingFooter(profile),
+		Logo:        profile.LogoData,
+	})
+}
+
+// brandingFooter returns the footer lines printed on branded PDFs: the footer text,
+// the registered name and the contact details
+func brandingFooter(profile models.BrandingProfile) []string {
+	var footer []string
+	if profile.FooterText != "" {
+		footer = append(footer, profile.FooterText)
+	}
+	registered := profile.DisplayName
+	if profile.CharityNumber != "" {
+		registered += " - Registered charity number " + profile.CharityNumber
+	}
+	footer = append(footer, registered)
+	var contact []string
+	for _, value := range []string{profile.Website, profile.ContactEmail, profile.ContactPhone} {
+		if value != "" {
+			contact = append(contact, value)
+		}
+	}
+	if len(contact) > 0 {
+		footer = append(footer, strings.Join(contact, " | "))
+	}
+	return footer
+}
+
+// BrandName returns the organisation name to print for a location
+func BrandName(location string) string {
+	return notifications.LoadBranding(location).DisplayName
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"reflect"
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestSaveBrandingValidation(t *testing.T) {
+	bs := &BrandingService{}
+	tests := []struct {
+		name   string
+		update BrandingUpdate
+		want   error
+	}{
+		{"short colour", BrandingUpdate{PrimaryColor: "#FFF"}, ErrBrandingColor},
+		{"named colour", BrandingUpdate{AccentColor: "blue"}, ErrBrandingColor},
+		{"css injection", BrandingUpdate{PrimaryColor: "#000000;background:url(x)"}, ErrBrandingColor},
+		{"bad language", BrandingUpdate{PrimaryColor: "#1d4ed8", Language: "english!"}, ErrBrandingLanguage},
+	}
+	for _, tt := range tests {
+		if _, err := bs.Save("", tt.update, 1); err != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSetLogoValidation(t *testing.T) {
+	encode := func(width, height int) []byte {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	bs := &BrandingService{}
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), ErrBrandingLogo},
+		{"truncated png", encode(10, 10)[:20], ErrBrandingLogo},
+		{"too wide", encode(2001, 1), ErrBrandingLogoSize},
+		{"too large", append(encode(10, 10), make([]byte, maxBrandingLogoBytes)...), ErrBrandingLogoSize},
+	}
+	for _, tt := range tests {
+		if _, err := bs.SetLogo("", tt.data, 1); err != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestBrandingFooter(t *testing.T) {
+	profile := models.BrandingProfile{
+		DisplayName:   "Lewisham Charity",
+		FooterText:    "Thank you for your support",
+		CharityNumber: "1234567",
+		Website:       "https://example.org",
+		ContactPhone:  "020 7946 0000",
+	}
+	want := []string{
+		"Thank you for your support",
+		"Lewisham Charity - Registered charity number 1234567",
+		"https://example.org | 020 7946 0000",
+	}
+	if got := brandingFooter(profile); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q", got)
+	}
+
+	if got := brandingFooter(models.BrandingProfile{DisplayName: "Food Hub"}); !reflect.DeepEqual(got, []string{"Food Hub"}) {
+		t.Errorf("name only: got %q", got)
+	}
+}
@@ -8,10 +8,14 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/geoo115/charity-management-system/internal/observability"
+
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
+	"golang.org/x/sync/singleflight"
 )
 
 // CacheService provides Redis-based caching operations for high-performance endpoints
@@ -19,6 +23,7 @@ type CacheService struct {
 	client *redis.Client
 	ctx    context.Context
 	stats  *CacheStats
+	loads  singleflight.Group // One load per key at a time on a miss
 }
 
 // CacheStats tracks cache performance metrics
@@ -140,24 +145,29 @@ func (cs *CacheService) Get(key string, dest interface{}) error {
 	}
 
 	cs.incrementStat("total_ops")
+	start := time.Now()
 
 	val, err := cs.client.Get(cs.ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
 			cs.incrementStat("misses")
+			cs.recordLookup(key, "miss", start)
 			return ErrCacheMiss
 		}
 		cs.incrementStat("errors")
+		cs.recordLookup(key, "error", start)
 		return fmt.Errorf("cache get error for key %s: %w", key, err)
 	}
 
 	// Deserialize JSON
 	if err := json.Unmarshal([]byte(val), dest); err != nil {
 		cs.incrementStat("errors")
+		cs.recordLookup(key, "error", start)
 		return fmt.Errorf("cache deserialization error for key %s: %w", key, err)
 	}
 
 	cs.incrementStat("hits")
+	cs.recordLookup(key, "hit", start)
 	return nil
 }
 
@@ -168,21 +178,25 @@ func (cs *CacheService) Set(key string, value interface{}, expiration time.Durat
 	}
 
 	cs.incrementStat("total_ops")
+	start := time.Now()
 
 	// Serialize to JSON
 	data, err := json.Marshal(value)
 	if err != nil {
 		cs.incrementStat("errors")
+		cs.recordOperation("set", "error", start)
 		return fmt.Errorf("cache serialization error for key %s: %w", key, err)
 	}
 
 	// Set with expiration
 	if err := cs.client.Set(cs.ctx, key, data, expiration).Err(); err != nil {
 		cs.incrementStat("errors")
+		cs.recordOperation("set", "error", start)
 		return fmt.Errorf("cache set error for key %s: %w", key, err)
 	}
 
 	cs.incrementStat("sets")
+	cs.recordOperation("set", "ok", start)
 	return nil
 }
 
@@ -293,6 +307,7 @@ func (cs *CacheService) DeletePattern(pattern string) error {
 	}
 
 	cs.incrementStat("total_ops")
+	start := time.Now()
 
 	// Use SCAN to find matching keys (more efficient than KEYS)
 	iter := cs.client.Scan(cs.ctx, 0, pattern, 0).Iterator()
@@ -304,17 +319,20 @@ func (cs *CacheService) DeletePattern(pattern string) error {
 
 	if err := iter.Err(); err != nil {
 		cs.incrementStat("errors")
+		cs.recordOperation("delete", "error", start)
 		return fmt.Errorf("scan error for pattern %s: %w", pattern, err)
 	}
 
 	if len(keys) > 0 {
 		if err := cs.client.Del(cs.ctx, keys...).Err(); err != nil {
 			cs.incrementStat("errors")
+			cs.recordOperation("delete", "error", start)
 			return fmt.Errorf("delete error for pattern %s: %w", pattern, err)
 		}
 		cs.incrementStat("deletes")
 	}
 
+	cs.recordOperation("delete", "ok", start)
 	return nil
 }
 
@@ -371,19 +389,17 @@ func (cs *CacheService) GetStats() interface{} {
 	}
 
 	uptime := time.Since(cs.stats.StartTime)
-	hitRate := float64(0)
-	if cs.stats.Hits+cs.stats.Misses > 0 {
-		hitRate = float64(cs.stats.Hits) / float64(cs.stats.Hits+cs.stats.Misses) * 100
-	}
+	hits := atomic.LoadInt64(&cs.stats.Hits)
+	misses := atomic.LoadInt64(&cs.stats.Misses)
 
 	stats := map[string]interface{}{
-		"hits":       cs.stats.Hits,
-		"misses":     cs.stats.Misses,
-		"sets":       cs.stats.Sets,
-		"deletes":    cs.stats.Deletes,
-		"errors":     cs.stats.Errors,
-		"total_ops":  cs.stats.TotalOps,
-		"hit_rate":   fmt.Sprintf("%.2f%%", hitRate),
+		"hits":       hits,
+		"misses":     misses,
+		"sets":       atomic.LoadInt64(&cs.stats.Sets),
+		"deletes":    atomic.LoadInt64(&cs.stats.Deletes),
+		"errors":     atomic.LoadInt64(&cs.stats.Errors),
+		"total_ops":  atomic.LoadInt64(&cs.stats.TotalOps),
+		"hit_rate":   fmt.Sprintf("%.2f%%", cs.hitRate()),
 		"uptime":     uptime.String(),
 		"start_time": cs.stats.StartTime,
 	}
@@ -444,18 +460,50 @@ func (cs *CacheService) incrementStat(stat string) {
 
 	switch stat {
 	case "hits":
-		cs.stats.Hits++
+		atomic.AddInt64(&cs.stats.Hits, 1)
 	case "misses":
-		cs.stats.Misses++
+		atomic.AddInt64(&cs.stats.Misses, 1)
 	case "sets":
-		cs.stats.Sets++
+		atomic.AddInt64(&cs.stats.Sets, 1)
 	case "deletes":
-		cs.stats.Deletes++
+		atomic.AddInt64(&cs.stats.Deletes, 1)
 	case "errors":
-		cs.stats.Errors++
+		atomic.AddInt64(&cs.stats.Errors, 1)
 	case "total_ops":
-		cs.stats.TotalOps++
+		atomic.AddInt64(&cs.stats.TotalOps, 1)
+	}
+}
+
+// hitRate returns the percentage of lookups served from the cache
+func (cs *CacheService) hitRate() float64 {
+	hits := atomic.LoadInt64(&cs.stats.Hits)
+	misses := atomic.LoadInt64(&cs.stats.Misses)
+	if hits+misses == 0 {
+		return 0
 	}
+	return float64(hits) / float64(hits+misses) * 100
+}
+
+// cacheKeyGroup returns the prefix of a key, e.g. "dashboard" for "dashboard:admin:stats",
+// so metrics are labelled by kind of data rather than by individual key
+func cacheKeyGroup(key string) string {
+	if i := strings.IndexAny(key, ":_"); i > 0 {
+		return key[:i]
+	}
+	return "other"
+}
+
+// recordOperation reports a cache operation to Prometheus
+func (cs *CacheService) recordOperation(operation, status string, start time.Time) {
+	observability.GetMetricsService().RecordCacheOperation(operation, status, time.Since(start))
+}
+
+// recordLookup reports a cache read, by key group, to Prometheus
+func (cs *CacheService) recordLookup(key, result string, start time.Time) {
+	metrics := observability.GetMetricsService()
+	metrics.RecordCacheOperation("get", result, time.Since(start))
+	metrics.RecordCacheLookup(cacheKeyGroup(key), result)
+	metrics.SetCacheHitRate(cs.hitRate())
 }
 
 // parseRedisInfo parses Redis INFO output for memory statistics
@@ -483,7 +531,7 @@ func (cs *CacheService) startMaintenance() {
 	for range ticker.C {
 		// Cleanup expired keys (Redis handles this automatically, but we can optimize)
 		// Log statistics periodically
-		if cs.stats.TotalOps > 0 && cs.stats.TotalOps%1000 == 0 {
+		if ops := atomic.LoadInt64(&cs.stats.TotalOps); ops > 0 && ops%1000 == 0 {
 			log.Printf("Cache Stats: %+v", cs.GetStats())
 		}
 	}
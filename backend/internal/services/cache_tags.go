@@ -0,0 +1,283 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/observability"
+
+	"gorm.io/gorm"
+)
+
+// Cache tags group cached values by the data they are built from, so a write can
+// invalidate every value that depends on it
+const (
+	CacheTagDashboard    = "dashboard"
+	CacheTagUsers        = "users"
+	CacheTagVolunteers   = "volunteers"
+	CacheTagShifts       = "shifts"
+	CacheTagQueue        = "queue"
+	CacheTagHelpRequests = "help_requests"
+	CacheTagDonations    = "donations"
+	CacheTagFeedback     = "feedback"
+)
+
+const (
+	// cacheTagPrefix prefixes the Redis sets holding each tag's keys
+	cacheTagPrefix = "cachetag:"
+	// cacheTagTTL keeps tag sets a little longer than any value they point at
+	cacheTagTTL = 24 * time.Hour
+	// cacheInspectMaxKeys caps how many keys one inspection page returns
+	cacheInspectMaxKeys = 500
+)
+
+// cacheTagsByTable lists the tags invalidated when a table is written to
+var cacheTagsByTable = map[string][]string{
+	"users":              {CacheTagUsers, CacheTagVolunteers, CacheTagDashboard},
+	"volunteer_profiles": {CacheTagVolunteers, CacheTagDashboard},
+	"shifts":             {CacheTagShifts, CacheTagDashboard},
+	"shift_assignments":  {CacheTagShifts, CacheTagVolunteers, CacheTagDashboard},
+	"help_requests":      {CacheTagHelpRequests, CacheTagDashboard},
+	"tickets":            {CacheTagHelpRequests, CacheTagQueue, CacheTagDashboard},
+	"visits":             {CacheTagQueue, CacheTagDashboard},
+	"queue_entries":      {CacheTagQueue, CacheTagDashboard},
+	"donations":          {CacheTagDonations, CacheTagDashboard},
+	"documents":          {CacheTagDashboard},
+	"feedback":           {CacheTagFeedback, CacheTagDashboard},
+}
+
+// CacheKeyInfo describes a cached key for inspection
+type CacheKeyInfo struct {
+	Key       string `json:"key"`
+	Type      string `json:"type"`
+	TTL       int64  `json:"ttl_seconds"` // -1 when the key does not expire
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// SetWithTags stores a value and records its key against each tag
+func (cs *CacheService) SetWithTags(key string, value interface{}, expiration time.Duration, tags ...string) error {
+	if err := cs.Set(key, value, expiration); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	pipe := cs.client.Pipeline()
+	for _, tag := range tags {
+		pipe.SAdd(cs.ctx, cacheTagPrefix+tag, key)
+		pipe.Expire(cs.ctx, cacheTagPrefix+tag, cacheTagTTL)
+	}
+	if _, err := pipe.Exec(cs.ctx); err != nil {
+		cs.incrementStat("errors")
+		return fmt.Errorf("cache tag error for key %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetOrLoad reads a value into dest, calling load on a miss. Concurrent misses for the
+// same key share a single load so an expensive aggregate is only built once when it
+// expires. The loaded value is cached under the given tags.
+func (cs *CacheService) GetOrLoad(key string, expiration time.Duration, dest interface{}, load func() (interface{}, error), tags ...string) (bool, error) {
+	if err := cs.Get(key, dest); err == nil {
+		return true, nil
+	}
+	if cs.client == nil {
+		value, err := load()
+		if err != nil {
+			return false, err
+		}
+		return false, copyCacheValue(value, dest)
+	}
+
+	data, err, shared := cs.loads.Do(key, func() (interface{}, error) {
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+		if err := cs.SetWithTags(key, value, expiration, tags...); err != nil {
+			log.Printf("Failed to cache %s: %v", key, err)
+		}
+		return json.Marshal(value)
+	})
+	observability.GetMetricsService().RecordCacheLoad(cacheKeyGroup(key), shared)
+	if err != nil {
+		return false, err
+	}
+	return false, json.Unmarshal(data.([]byte), dest)
+}
+
+// copyCacheValue fills dest from a freshly loaded value the same way a cache hit would
+func copyCacheValue(value, dest interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// Delete removes keys from the cache
+func (cs *CacheService) Delete(keys ...string) (int64, error) {
+	if cs.client == nil || len(keys) == 0 {
+		return 0, nil
+	}
+
+	cs.incrementStat("total_ops")
+	start := time.Now()
+	deleted, err := cs.client.Del(cs.ctx, keys...).Result()
+	if err != nil {
+		cs.incrementStat("errors")
+		cs.recordOperation("delete", "error", start)
+		return 0, fmt.Errorf("cache delete error: %w", err)
+	}
+	cs.incrementStat("deletes")
+	cs.recordOperation("delete", "ok", start)
+	return deleted, nil
+}
+
+// InvalidateTags removes every key cached under the tags and returns how many went
+func (cs *CacheService) InvalidateTags(tags ...string) (int64, error) {
+	if cs.client == nil {
+		return 0, nil
+	}
+
+	var total int64
+	for _, tag := range tags {
+		keys, err := cs.client.SMembers(cs.ctx, cacheTagPrefix+tag).Result()
+		if err != nil {
+			cs.incrementStat("errors")
+			return total, fmt.Errorf("cache tag lookup error for %s: %w", tag, err)
+		}
+		deleted, err := cs.Delete(append(keys, cacheTagPrefix+tag)...)
+		if err != nil {
+			return total, err
+		}
+		if len(keys) > 0 && deleted > 0 {
+			// The tag set itself is not a cached value
+			deleted--
+		}
+		observability.GetMetricsService().RecordCacheInvalidation(tag, int(deleted))
+		total += deleted
+	}
+	return total, nil
+}
+
+// Tags returns each cache tag with the number of keys recorded against it
+func (cs *CacheService) Tags() (map[string]int64, error) {
+	tags := map[string]int64{}
+	if cs.client == nil {
+		return tags, nil
+	}
+
+	iter := cs.client.Scan(cs.ctx, 0, cacheTagPrefix+"*", 0).Iterator()
+	for iter.Next(cs.ctx) {
+		count, err := cs.client.SCard(cs.ctx, iter.Val()).Result()
+		if err != nil {
+			return nil, err
+		}
+		tags[strings.TrimPrefix(iter.Val(), cacheTagPrefix)] = count
+	}
+	return tags, iter.Err()
+}
+
+// ListKeys returns one page of keys matching a pattern with their TTL and size. Pass
+// the returned cursor to get the next page; it is 0 after the last page.
+func (cs *CacheService) ListKeys(pattern string, cursor uint64, count int64) ([]CacheKeyInfo, uint64, error) {
+	if cs.client == nil {
+		return nil, 0, nil
+	}
+	if pattern == "" {
+		pattern = "*"
+	}
+	if count <= 0 || count > cacheInspectMaxKeys {
+		count = 100
+	}
+
+	keys, next, err := cs.client.Scan(cs.ctx, cursor, pattern, count).Result()
+	if err != nil {
+		cs.incrementStat("errors")
+		return nil, 0, fmt.Errorf("scan error for pattern %s: %w", pattern, err)
+	}
+	sort.Strings(keys)
+
+	infos := make([]CacheKeyInfo, 0, len(keys))
+	for _, key := range keys {
+		info, err := cs.KeyInfo(key)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, *info)
+	}
+	return infos, next, nil
+}
+
+// KeyInfo returns the type, TTL and memory use of one key
+func (cs *CacheService) KeyInfo(key string) (*CacheKeyInfo, error) {
+	if cs.client == nil {
+		return nil, ErrCacheMiss
+	}
+
+	pipe := cs.client.Pipeline()
+	keyType := pipe.Type(cs.ctx, key)
+	ttl := pipe.TTL(cs.ctx, key)
+	size := pipe.MemoryUsage(cs.ctx, key)
+	if _, err := pipe.Exec(cs.ctx); err != nil && keyType.Err() != nil {
+		return nil, err
+	}
+	if keyType.Val() == "none" {
+		return nil, ErrCacheMiss
+	}
+
+	info := &CacheKeyInfo{Key: key, Type: keyType.Val(), TTL: -1, SizeBytes: size.Val()}
+	if ttl.Val() > 0 {
+		info.TTL = int64(ttl.Val().Seconds())
+	}
+	return info, nil
+}
+
+// Peek returns the raw cached value of a string key, for inspection
+func (cs *CacheService) Peek(key string) (json.RawMessage, error) {
+	if cs.client == nil {
+		return nil, ErrCacheMiss
+	}
+	val, err := cs.client.Get(cs.ctx, key).Bytes()
+	if err != nil {
+		return nil, ErrCacheMiss
+	}
+	if !json.Valid(val) {
+		quoted, _ := json.Marshal(string(val))
+		return quoted, nil
+	}
+	return val, nil
+}
+
+// RegisterCacheInvalidation invalidates the cache tags for a table after each create,
+// update or delete on it, so write paths do not each need to remember which cached
+// aggregates they affect
+func RegisterCacheInvalidation(database *gorm.DB) error {
+	cache := GetCacheService()
+	invalidate := func(tx *gorm.DB) {
+		if tx.Error != nil || tx.RowsAffected == 0 || tx.Statement.Schema == nil {
+			return
+		}
+		tags, ok := cacheTagsByTable[tx.Statement.Schema.Table]
+		if !ok {
+			return
+		}
+		if _, err := cache.InvalidateTags(tags...); err != nil {
+			log.Printf("Failed to invalidate cache for %s: %v", tx.Statement.Schema.Table, err)
+		}
+	}
+
+	if err := database.Callback().Create().After("gorm:create").Register("cache:invalidate_create", invalidate); err != nil {
+		return err
+	}
+	if err := database.Callback().Update().After("gorm:update").Register("cache:invalidate_update", invalidate); err != nil {
+		return err
+	}
+	return database.Callback().Delete().After("gorm:delete").Register("cache:invalidate_delete", invalidate)
+}
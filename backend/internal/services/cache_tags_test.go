@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCacheKeyGroup(t *testing.T) {
+	for key, want := range map[string]string{
+		"dashboard:admin:stats": "dashboard",
+		"analytics_volunteers":  "analytics",
+		"plain":                 "other",
+		":leading":              "other",
+	} {
+		if got := cacheKeyGroup(key); got != want {
+			t.Errorf("cacheKeyGroup(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestCacheHitRate(t *testing.T) {
+	cs := &CacheService{stats: &CacheStats{}}
+	if cs.hitRate() != 0 {
+		t.Error("no lookups should be a zero hit rate")
+	}
+	cs.stats.Hits, cs.stats.Misses = 3, 1
+	if got := cs.hitRate(); got != 75 {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestGetOrLoadWithoutRedis(t *testing.T) {
+	cs := &CacheService{stats: &CacheStats{}}
+	loads := 0
+	load := func() (interface{}, error) {
+		loads++
+		return map[string]int{"visits": 12}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		var dest struct{ Visits int }
+		hit, err := cs.GetOrLoad("dashboard:stats", 0, &dest, load, CacheTagDashboard)
+		if hit || err != nil || dest.Visits != 12 {
+			t.Fatalf("got %v, %+v, %v", hit, dest, err)
+		}
+	}
+	if loads != 2 {
+		t.Errorf("loaded %d times; nothing is cached without Redis", loads)
+	}
+
+	failed := errors.New("database down")
+	var dest map[string]int
+	if _, err := cs.GetOrLoad("dashboard:stats", 0, &dest, func() (interface{}, error) { return nil, failed }); err != failed {
+		t.Errorf("load error: got %v", err)
+	}
+
+	if deleted, err := cs.InvalidateTags(CacheTagDashboard); deleted != 0 || err != nil {
+		t.Errorf("invalidate: got %d, %v", deleted, err)
+	}
+}
+
+func TestCacheTagsByTable(t *testing.T) {
+	// Any write behind the dashboard aggregates must clear them
+	for table, tags := range cacheTagsByTable {
+		found := false
+		for _, tag := range tags {
+			found = found || tag == CacheTagDashboard
+		}
+		if !found {
+			t.Errorf("writes to %s do not invalidate the dashboard", table)
+		}
+	}
+}
@@ -0,0 +1,808 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Campaign sending limits
+const (
+	defaultCampaignThrottle = 60
+	maxCampaignThrottle     = 1000
+	campaignPreviewSamples  = 3
+	outboxMaxAttempts       = 3
+	outboxRetryDelay        = 5 * time.Minute
+	outboxClaimTimeout      = 10 * time.Minute
+)
+
+var (
+	ErrCampaignNotEditable         = errors.New("only draft campaigns can be changed")
+	ErrCampaignNotSchedulable      = errors.New("campaign has already been scheduled or sent")
+	ErrCampaignNotCancellable      = errors.New("campaign has already finished sending")
+	ErrCampaignNoRecipients        = errors.New("campaign audience has no recipients")
+	ErrCampaignChannel             = errors.New("channel must be email or sms")
+	ErrCampaignTranslation         = errors.New("invalid campaign translation")
+	ErrCampaignTranslationNotFound = errors.New("campaign translation not found")
+)
+
+// CampaignService builds campaign audiences, queues messages in the outbox and
+// sends them at each campaign's throttle rate
+type CampaignService struct {
+	db *gorm.DB
+}
+
+// CampaignPreview shows who a campaign would reach and what they would receive
+type CampaignPreview struct {
+	RecipientCount  int64                   `json:"recipient_count"`
+	SuppressedCount int64                   `json:"suppressed_count"` // Left out by do-not-contact or vulnerable adult flags
+	Samples         []CampaignPreviewSample `json:"samples"`
+}
+
+// CampaignPreviewSample is the campaign rendered for one recipient
+type CampaignPreviewSample struct {
+	UserID    uint   `json:"user_id"`
+	Name      string `json:"name"`
+	Recipient string `json:"recipient"`
+	Subject   string `json:"subject,omitempty"`
+	Body      string `json:"body"`
+	Language  string `json:"language"` // Language from the recipient's fallback chain
+}
+
+// CampaignStats summarises delivery and opens for a campaign
+type CampaignStats struct {
+	CampaignID uint             `json:"campaign_id"`
+	Status     string           `json:"status"`
+	Recipients int              `json:"recipients"`
+	ByStatus   map[string]int64 `json:"by_status"`
+	Sent       int64            `json:"sent"`
+	Failed     int64            `json:"failed"`
+	Pending    int64            `json:"pending"`
+	Suppressed int64            `json:"suppressed"` // Withdrawn after recipients were flagged do-not-contact
+	Opened     int64            `json:"opened"`
+	OpenRate   float64          `json:"open_rate"` // Percentage of sent emails opened
+	StartedAt  *time.Time       `json:"started_at"`
+	FinishedAt *time.Time       `json:"completed_at"`
+}
+
+// NewCampaignService creates a new campaign service
+func NewCampaignService() *CampaignService {
+	return &CampaignService{
+		db: db.DB,
+	}
+}
+
+// ValidateCampaign checks the channel, throttle and message template
+func (cs *CampaignService) ValidateCampaign(campaign *models.Campaign) error {
+	if campaign.Channel != models.NotificationTypeEmail && campaign.Channel != models.NotificationTypeSMS {
+		return ErrCampaignChannel
+	}
+	if campaign.Channel == models.NotificationTypeEmail && strings.TrimSpace(campaign.Subject) == "" {
+		return fmt.Errorf("subject is required for email campaigns")
+	}
+	if campaign.ThrottlePerMinute <= 0 {
+		campaign.ThrottlePerMinute = defaultCampaignThrottle
+	}
+	if campaign.ThrottlePerMinute > maxCampaignThrottle {
+		return fmt.Errorf("throttle cannot be more than %d messages a minute", maxCampaignThrottle)
+	}
+	if campaign.AudienceTag != "" {
+		tag, err := (&TagService{db: cs.db}).Find(campaign.AudienceTag)
+		if err != nil {
+			return fmt.Errorf("audience tag %q: %w", campaign.AudienceTag, err)
+		}
+		campaign.AudienceTag = tag.Slug
+	}
+	if _, err := template.New("body").Parse(campaign.Body); err != nil {
+		return fmt.Errorf("invalid message template: %w", err)
+	}
+	if _, err := template.New("subject").Parse(campaign.Subject); err != nil {
+		return fmt.Errorf("invalid subject template: %w", err)
+	}
+	return nil
+}
+
+// AudienceQuery returns the users a campaign would be sent to. Users whose contact
+// restrictions rule out the campaign are never included.
+func (cs *CampaignService) AudienceQuery(campaign *models.Campaign, now time.Time) *gorm.DB {
+	return cs.audienceBase(campaign, now).
+		Where("NOT EXISTS (SELECT 1 FROM contact_restrictions WHERE contact_restrictions.user_id = users.id AND " + campaignRestrictionSQL(campaign.Channel) + ")")
+}
+
+// SuppressedQuery returns the contact restrictions keeping users who match the
+// campaign's filters out of its audience
+func (cs *CampaignService) SuppressedQuery(campaign *models.Campaign, now time.Time) *gorm.DB {
+	return cs.db.Model(&models.ContactRestriction{}).
+		Where("contact_restrictions.user_id IN (?)", cs.audienceBase(campaign, now).Select("users.id")).
+		Where(campaignRestrictionSQL(campaign.Channel))
+}
+
+// audienceBase returns the users matching a campaign's filters and reachable on its
+// channel, before contact restrictions are applied
+func (cs *CampaignService) audienceBase(campaign *models.Campaign, now time.Time) *gorm.DB {
+	query := cs.db.Model(&models.User{}).
+		Where("users.status NOT IN ?", []string{models.StatusInactive, models.StatusSuspended, models.StatusDeactivated})
+
+	if roles := campaign.Roles(); len(roles) > 0 {
+		query = query.Where("users.role IN ?", roles)
+	}
+	if campaign.ActiveWithinDays > 0 {
+		query = query.Where("users.last_login >= ?", now.AddDate(0, 0, -campaign.ActiveWithinDays))
+	}
+	if campaign.AudienceTag != "" {
+		query = query.Where("users.id IN (?)", (&TagService{db: cs.db}).Cohort(campaign.AudienceTag))
+	}
+	if campaign.ConsentType != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM consents WHERE consents.user_id = users.id AND consents.type = ? AND consents.granted = ?)",
+			campaign.ConsentType, true)
+	}
+
+	// Users must be reachable on the channel and not have switched it off
+	switch campaign.Channel {
+	case models.NotificationTypeSMS:
+		query = query.Where("users.phone <> ''").
+			Where("NOT EXISTS (SELECT 1 FROM notification_preferences WHERE notification_preferences.user_id = users.id AND notification_preferences.sms_enabled = ?)", false)
+	default:
+		query = query.Where("users.email <> ''").
+			Where("NOT EXISTS (SELECT 1 FROM notification_preferences WHERE notification_preferences.user_id = users.id AND notification_preferences.email_enabled = ?)", false)
+	}
+
+	return query
+}
+
+// Preview counts the audience and renders the message for the first few recipients
+func (cs *CampaignService) Preview(campaign *models.Campaign) (*CampaignPreview, error) {
+	if err := cs.ValidateCampaign(campaign); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	preview := &CampaignPreview{Samples: []CampaignPreviewSample{}}
+	if err := cs.AudienceQuery(campaign, now).Count(&preview.RecipientCount).Error; err != nil {
+		return nil, err
+	}
+	if err := cs.SuppressedQuery(campaign, now).Count(&preview.SuppressedCount).Error; err != nil {
+		return nil, err
+	}
+
+	translations, err := cs.translationsByLanguage(campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+	var users []models.User
+	if err := cs.AudienceQuery(campaign, now).Order("users.id ASC").Limit(campaignPreviewSamples).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		localised, language := localiseCampaign(campaign, translations, notifications.LanguageChain(user.Language, ""))
+		subject, body, err := renderCampaign(localised, &user)
+		if err != nil {
+			return nil, err
+		}
+		preview.Samples = append(preview.Samples, CampaignPreviewSample{
+			UserID:    user.ID,
+			Name:      user.FirstName + " " + user.LastName,
+			Recipient: campaignRecipient(campaign.Channel, &user, true),
+			Subject:   subject,
+			Body:      body,
+			Language:  language,
+		})
+	}
+
+	return preview, nil
+}
+
+// Schedule renders the campaign for every recipient, in the first language of their
+// fallback chain the campaign has been translated into, and queues the messages in
+// the outbox to be sent from the given time. The language of each message is logged.
+func (cs *CampaignService) Schedule(campaignID uint, sendAt time.Time) (*models.Campaign, error) {
+	var campaign models.Campaign
+	err := cs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&campaign, campaignID).Error; err != nil {
+			return err
+		}
+		if campaign.Status != models.CampaignStatusDraft {
+			return ErrCampaignNotSchedulable
+		}
+		if err := cs.ValidateCampaign(&campaign); err != nil {
+			return err
+		}
+
+		scoped := &CampaignService{db: tx}
+		var users []models.User
+		if err := scoped.AudienceQuery(&campaign, time.Now()).Find(&users).Error; err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return ErrCampaignNoRecipients
+		}
+		if err := scoped.logSuppressedAudience(&campaign); err != nil {
+			return err
+		}
+		translations, err := scoped.translationsByLanguage(campaign.ID)
+		if err != nil {
+			return err
+		}
+
+		messages := make([]models.NotificationOutbox, 0, len(users))
+		languages := make([]models.NotificationLanguageLog, 0, len(users))
+		for i := range users {
+			chain := notifications.LanguageChain(users[i].Language, "")
+			localised, language := localiseCampaign(&campaign, translations, chain)
+			subject, body, err := renderCampaign(localised, &users[i])
+			if err != nil {
+				return err
+			}
+			entry := notifications.LanguageLogEntry(users[i].ID, campaign.Channel, chain, language)
+			entry.CampaignID = &campaign.ID
+			languages = append(languages, entry)
+			messages = append(messages, models.NotificationOutbox{
+				CampaignID:    &campaign.ID,
+				UserID:        users[i].ID,
+				Channel:       campaign.Channel,
+				Recipient:     campaignRecipient(campaign.Channel, &users[i], false),
+				Subject:       subject,
+				Body:          body,
+				Status:        models.OutboxStatusPending,
+				AvailableAt:   sendAt,
+				TrackingToken: newTrackingToken(),
+			})
+		}
+		if err := tx.CreateInBatches(&messages, 500).Error; err != nil {
+			return err
+		}
+		if err := tx.CreateInBatches(&languages, 500).Error; err != nil {
+			return err
+		}
+
+		campaign.Status = models.CampaignStatusScheduled
+		campaign.ScheduledFor = &sendAt
+		campaign.RecipientCount = len(messages)
+		return tx.Save(&campaign).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// Cancel stops a campaign and withdraws any messages that have not been sent yet
+func (cs *CampaignService) Cancel(campaignID uint) (*models.Campaign, int64, error) {
+	var campaign models.Campaign
+	var withdrawn int64
+	err := cs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&campaign, campaignID).Error; err != nil {
+			return err
+		}
+		if campaign.Status == models.CampaignStatusSent || campaign.Status == models.CampaignStatusCancelled {
+			return ErrCampaignNotCancellable
+		}
+
+		result := tx.Model(&models.NotificationOutbox{}).
+			Where("campaign_id = ? AND status = ?", campaign.ID, models.OutboxStatusPending).
+			Update("status", models.OutboxStatusCancelled)
+		if result.Error != nil {
+			return result.Error
+		}
+		withdrawn = result.RowsAffected
+
+		now := time.Now()
+		campaign.Status = models.CampaignStatusCancelled
+		campaign.CompletedAt = &now
+		return tx.Save(&campaign).Error
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return &campaign, withdrawn, nil
+}
+
+// ProcessOutbox sends due outbox messages, never sending more of a campaign's
+// messages in any minute than its throttle allows. It returns how many were sent.
+func (cs *CampaignService) ProcessOutbox(now time.Time) (int, error) {
+	var campaigns []models.Campaign
+	if err := cs.db.Where("status IN ? AND scheduled_for <= ?",
+		[]string{models.CampaignStatusScheduled, models.CampaignStatusSending}, now).
+		Find(&campaigns).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range campaigns {
+		count, err := cs.processCampaign(&campaigns[i], now)
+		if err != nil {
+			log.Printf("Failed to process outbox for campaign %d: %v", campaigns[i].ID, err)
+			continue
+		}
+		sent += count
+	}
+
+	// Messages outside a campaign, such as donor welcome emails, at the default rate
+	messages, err := cs.claimBatch(nil, now, defaultCampaignThrottle)
+	if err != nil {
+		log.Printf("Failed to claim outbox messages outside campaigns: %v", err)
+		return sent, nil
+	}
+	for i := range messages {
+		if cs.deliver(&messages[i]) {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// RecordOpen marks the message with the given tracking token as opened
+func (cs *CampaignService) RecordOpen(token string) error {
+	if token == "" {
+		return gorm.ErrRecordNotFound
+	}
+
+	return cs.db.Transaction(func(tx *gorm.DB) error {
+		var message models.NotificationOutbox
+		if err := tx.Where("tracking_token = ?", token).First(&message).Error; err != nil {
+			return err
+		}
+		if message.OpenedAt != nil {
+			return nil
+		}
+
+		now := time.Now()
+		if err := tx.Model(&message).Update("opened_at", now).Error; err != nil {
+			return err
+		}
+		if message.CampaignID == nil {
+			return nil
+		}
+		return tx.Model(&models.Campaign{}).Where("id = ?", *message.CampaignID).
+			UpdateColumn("opened_count", gorm.Expr("opened_count + 1")).Error
+	})
+}
+
+// Stats returns delivery and open statistics for a campaign
+func (cs *CampaignService) Stats(campaign *models.Campaign) (*CampaignStats, error) {
+	type statusCount struct {
+		Status string
+		Count  int64
+	}
+	var counts []statusCount
+	if err := cs.db.Model(&models.NotificationOutbox{}).
+		Select("status, COUNT(*) AS count").
+		Where("campaign_id = ?", campaign.ID).
+		Group("status").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	stats := &CampaignStats{
+		CampaignID: campaign.ID,
+		Status:     campaign.Status,
+		Recipients: campaign.RecipientCount,
+		ByStatus:   map[string]int64{},
+		StartedAt:  campaign.StartedAt,
+		FinishedAt: campaign.CompletedAt,
+	}
+	for _, count := range counts {
+		stats.ByStatus[count.Status] = count.Count
+	}
+	stats.Sent = stats.ByStatus[models.OutboxStatusSent]
+	stats.Failed = stats.ByStatus[models.OutboxStatusFailed]
+	stats.Pending = stats.ByStatus[models.OutboxStatusPending] + stats.ByStatus[models.OutboxStatusSending]
+	stats.Suppressed = stats.ByStatus[models.OutboxStatusSuppressed]
+
+	cs.db.Model(&models.NotificationOutbox{}).
+		Where("campaign_id = ? AND opened_at IS NOT NULL", campaign.ID).
+		Count(&stats.Opened)
+	if campaign.Channel == models.NotificationTypeEmail && stats.Sent > 0 {
+		stats.OpenRate = math.Round(float64(stats.Opened)/float64(stats.Sent)*1000) / 10
+	}
+
+	return stats, nil
+}
+
+// processCampaign sends the next batch of a campaign's due messages. The batch is
+// claimed and committed before anything is sent, so a slow provider never holds
+// row locks and a second worker cannot pick up the same messages.
+func (cs *CampaignService) processCampaign(campaign *models.Campaign, now time.Time) (int, error) {
+	var inFlight int64
+	cs.db.Model(&models.NotificationOutbox{}).
+		Where("campaign_id = ? AND (sent_at > ? OR (status = ? AND available_at > ?))",
+			campaign.ID, now.Add(-time.Minute), models.OutboxStatusSending, now).
+		Count(&inFlight)
+
+	limit := campaign.ThrottlePerMinute - int(inFlight)
+	if limit <= 0 {
+		return 0, nil
+	}
+
+	messages, err := cs.claimBatch(&campaign.ID, now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range messages {
+		if cs.deliver(&messages[i]) {
+			sent++
+		}
+	}
+
+	return sent, cs.updateCampaignProgress(campaign, now)
+}
+
+// claimBatch marks up to limit due messages of a campaign, or outside any campaign
+// when campaignID is nil, as sending and returns them. A claim expires after
+// outboxClaimTimeout so messages held by a crashed worker are picked up again.
+func (cs *CampaignService) claimBatch(campaignID *uint, now time.Time, limit int) ([]models.NotificationOutbox, error) {
+	var messages []models.NotificationOutbox
+	err := cs.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		if campaignID != nil {
+			query = query.Where("campaign_id = ?", *campaignID)
+		} else {
+			query = query.Where("campaign_id IS NULL")
+		}
+		if err := query.
+			Where("status IN ? AND available_at <= ?",
+				[]string{models.OutboxStatusPending, models.OutboxStatusSending}, now).
+			Order("id ASC").
+			Limit(limit).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(messages))
+		for i := range messages {
+			ids[i] = messages[i].ID
+			messages[i].Status = models.OutboxStatusSending
+		}
+		return tx.Model(&models.NotificationOutbox{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":       models.OutboxStatusSending,
+				"available_at": now.Add(outboxClaimTimeout),
+			}).Error
+	})
+	return messages, err
+}
+
+// deliver sends one claimed outbox message and records the result on its row,
+// scheduling a retry on failure. It reports whether the message was sent.
+func (cs *CampaignService) deliver(message *models.NotificationOutbox) bool {
+	if cs.suppressed(message) {
+		return false
+	}
+	service := notifications.GetService()
+
+	var err error
+	switch {
+	case service == nil:
+		err = fmt.Errorf("notification service is not initialized")
+	case message.Channel == models.NotificationTypeSMS:
+		err = service.SendSMS(message.Recipient, message.Body)
+	default:
+		err = service.SendEmail(message.Recipient, message.Subject, emailWithTracking(message))
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"attempts": message.Attempts + 1,
+	}
+	if err != nil {
+		updates["last_error"] = err.Error()
+		if message.Attempts+1 >= outboxMaxAttempts {
+			updates["status"] = models.OutboxStatusFailed
+		} else {
+			updates["status"] = models.OutboxStatusPending
+			updates["available_at"] = now.Add(outboxRetryDelay)
+		}
+	} else {
+		updates["status"] = models.OutboxStatusSent
+		updates["sent_at"] = now
+		updates["last_error"] = ""
+	}
+
+	result := cs.db.Model(&models.NotificationOutbox{}).
+		Where("id = ? AND status = ?", message.ID, models.OutboxStatusSending).
+		Updates(updates)
+	if result.Error != nil {
+		log.Printf("Failed to update outbox message %d: %v", message.ID, result.Error)
+	}
+	return err == nil
+}
+
+// logSuppressedAudience records each user a campaign leaves out because of their
+// contact restrictions
+func (cs *CampaignService) logSuppressedAudience(campaign *models.Campaign) error {
+	var restrictions []models.ContactRestriction
+	if err := cs.SuppressedQuery(campaign, time.Now()).Preload("User").Find(&restrictions).Error; err != nil {
+		return err
+	}
+	if len(restrictions) == 0 {
+		return nil
+	}
+
+	entries := make([]models.ContactSuppressionLog, 0, len(restrictions))
+	for i := range restrictions {
+		entry := models.ContactSuppressionLog{
+			UserID:     restrictions[i].UserID,
+			Channel:    campaign.Channel,
+			Source:     models.SuppressionSourceCampaign,
+			Reason:     restrictions[i].SuppressionReason(campaign.Channel, true),
+			CampaignID: &campaign.ID,
+		}
+		if restrictions[i].User != nil {
+			entry.Recipient = maskedRecipient(campaign.Channel, campaignRecipient(campaign.Channel, restrictions[i].User, false))
+		}
+		entries = append(entries, entry)
+	}
+	return cs.db.CreateInBatches(&entries, 500).Error
+}
+
+// suppressed withdraws a queued message whose recipient has been flagged since the
+// campaign was scheduled, recording the attempt. It reports whether the message was
+// withdrawn.
+func (cs *CampaignService) suppressed(message *models.NotificationOutbox) bool {
+	var restriction models.ContactRestriction
+	if err := cs.db.Where("user_id = ?", message.UserID).First(&restriction).Error; err != nil {
+		return false
+	}
+	reason := restriction.SuppressionReason(message.Channel, true)
+	if reason == "" {
+		return false
+	}
+
+	if err := cs.db.Model(&models.NotificationOutbox{}).
+		Where("id = ? AND status = ?", message.ID, models.OutboxStatusSending).
+		Updates(map[string]interface{}{
+			"status":     models.OutboxStatusSuppressed,
+			"last_error": "suppressed: " + reason,
+		}).Error; err != nil {
+		log.Printf("Failed to withdraw suppressed outbox message %d: %v", message.ID, err)
+	}
+
+	notifications.RecordSuppression(&models.ContactSuppressionLog{
+		UserID:     message.UserID,
+		Channel:    message.Channel,
+		Source:     models.SuppressionSourceCampaign,
+		Reason:     reason,
+		Recipient:  maskedRecipient(message.Channel, message.Recipient),
+		CampaignID: message.CampaignID,
+	})
+	return true
+}
+
+// updateCampaignProgress refreshes a campaign's counters and marks it sent once
+// nothing is left in the outbox
+func (cs *CampaignService) updateCampaignProgress(campaign *models.Campaign, now time.Time) error {
+	var sent, failed, pending int64
+	cs.db.Model(&models.NotificationOutbox{}).Where("campaign_id = ? AND status = ?", campaign.ID, models.OutboxStatusSent).Count(&sent)
+	cs.db.Model(&models.NotificationOutbox{}).Where("campaign_id = ? AND status = ?", campaign.ID, models.OutboxStatusFailed).Count(&failed)
+	cs.db.Model(&models.NotificationOutbox{}).
+		Where("campaign_id = ? AND status IN ?", campaign.ID, []string{models.OutboxStatusPending, models.OutboxStatusSending}).
+		Count(&pending)
+
+	updates := map[string]interface{}{
+		"sent_count":   sent,
+		"failed_count": failed,
+	}
+	if campaign.StartedAt == nil {
+		updates["started_at"] = now
+		updates["status"] = models.CampaignStatusSending
+	}
+	if pending == 0 {
+		updates["status"] = models.CampaignStatusSent
+		updates["completed_at"] = now
+	}
+
+	return cs.db.Model(&models.Campaign{}).
+		Where("id = ? AND status IN ?", campaign.ID, []string{models.CampaignStatusScheduled, models.CampaignStatusSending}).
+		Updates(updates).Error
+}
+
+// campaignRestrictionSQL matches the contact restrictions that keep someone out of a
+// campaign on the given channel
+func campaignRestrictionSQL(channel string) string {
+	if channel == models.NotificationTypeSMS {
+		return "(contact_restrictions.do_not_sms = TRUE OR contact_restrictions.vulnerable_adult = TRUE)"
+	}
+	return "contact_restrictions.vulnerable_adult = TRUE"
+}
+
+// Translations returns a campaign's translations
+func (cs *CampaignService) Translations(campaignID uint) ([]models.CampaignTranslation, error) {
+	var translations []models.CampaignTranslation
+	err := cs.db.Where("campaign_id = ?", campaignID).Order("language ASC").Find(&translations).Error
+	return translations, err
+}
+
+// SaveTranslation adds or replaces a draft campaign's subject and body in a language
+func (cs *CampaignService) SaveTranslation(campaignID uint, language, subject, body string, updatedBy uint) (*models.CampaignTranslation, error) {
+	language, ok := notifications.NormalizeLanguage(language)
+	if !ok || language == "" || language == models.DefaultLanguage {
+		return nil, fmt.Errorf("%w: language must be a language tag such as pl other than en", ErrCampaignTranslation)
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil, fmt.Errorf("%w: body is required", ErrCampaignTranslation)
+	}
+	if _, err := template.New("body").Parse(body); err != nil {
+		return nil, fmt.Errorf("%w: invalid message template: %v", ErrCampaignTranslation, err)
+	}
+	if _, err := template.New("subject").Parse(subject); err != nil {
+		return nil, fmt.Errorf("%w: invalid subject template: %v", ErrCampaignTranslation, err)
+	}
+
+	var translation models.CampaignTranslation
+	err := cs.db.Transaction(func(tx *gorm.DB) error {
+		var campaign models.Campaign
+		if err := tx.First(&campaign, campaignID).Error; err != nil {
+			return err
+		}
+		if campaign.Status != models.CampaignStatusDraft {
+			return ErrCampaignNotEditable
+		}
+		if err := tx.Where("campaign_id = ? AND language = ?", campaignID, language).
+			Attrs(models.CampaignTranslation{CampaignID: campaignID, Language: language}).
+			FirstOrInit(&translation).Error; err != nil {
+			return err
+		}
+		translation.Subject = strings.TrimSpace(subject)
+		translation.Body = body
+		translation.UpdatedBy = &updatedBy
+		return tx.Save(&translation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
+// DeleteTranslation removes a draft campaign's translation
+func (cs *CampaignService) DeleteTranslation(campaignID uint, language string) error {
+	language, _ = notifications.NormalizeLanguage(language)
+	return cs.db.Transaction(func(tx *gorm.DB) error {
+		var campaign models.Campaign
+		if err := tx.First(&campaign, campaignID).Error; err != nil {
+			return err
+		}
+		if campaign.Status != models.CampaignStatusDraft {
+			return ErrCampaignNotEditable
+		}
+		result := tx.Where("campaign_id = ? AND language = ?", campaignID, language).Delete(&models.CampaignTranslation{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCampaignTranslationNotFound
+		}
+		return nil
+	})
+}
+
+// translationsByLanguage returns a saved campaign's translations keyed by language
+func (cs *CampaignService) translationsByLanguage(campaignID uint) (map[string]*models.CampaignTranslation, error) {
+	if campaignID == 0 {
+		return nil, nil
+	}
+	translations, err := cs.Translations(campaignID)
+	if err != nil {
+		return nil, err
+	}
+	byLanguage := make(map[string]*models.CampaignTranslation, len(translations))
+	for i := range translations {
+		byLanguage[translations[i].Language] = &translations[i]
+	}
+	return byLanguage, nil
+}
+
+// localiseCampaign returns the campaign in the first language of the chain it has
+// been translated into, and that language. The campaign itself is English; a
+// translation without a subject keeps the English one.
+func localiseCampaign(campaign *models.Campaign, translations map[string]*models.CampaignTranslation, chain []string) (*models.Campaign, string) {
+	translated := make(map[string]bool, len(translations))
+	for language := range translations {
+		translated[language] = true
+	}
+	language := notifications.ResolveLanguage(chain, translated)
+	translation, ok := translations[language]
+	if !ok {
+		return campaign, language
+	}
+	localised := *campaign
+	localised.Body = translation.Body
+	if translation.Subject != "" {
+		localised.Subject = translation.Subject
+	}
+	return &localised, language
+}
+
+// renderCampaign fills in the campaign subject and body for a recipient
+func renderCampaign(campaign *models.Campaign, user *models.User) (string, string, error) {
+	// String values so that an unknown placeholder renders empty rather than "<no value>"
+	data := map[string]string{
+		"FirstName": user.FirstName,
+		"LastName":  user.LastName,
+		"Name":      strings.TrimSpace(user.FirstName + " " + user.LastName),
+		"Email":     user.Email,
+	}
+
+	render := func(name, text string) (string, error) {
+		tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return "", err
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, data); err != nil {
+			return "", err
+		}
+		return out.String(), nil
+	}
+
+	subject, err := render("subject", campaign.Subject)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := render("body", campaign.Body)
+	if err != nil {
+		return "", "", err
+	}
+	return subject, body, nil
+}
+
+// campaignRecipient returns the address a campaign is sent to, optionally masked
+func campaignRecipient(channel string, user *models.User, masked bool) string {
+	if channel == models.NotificationTypeSMS {
+		if masked {
+			return models.MaskPhone(user.Phone)
+		}
+		return user.Phone
+	}
+	return user.Email
+}
+
+// maskedRecipient hides most of a campaign recipient's address for logs
+func maskedRecipient(channel, address string) string {
+	if channel == models.NotificationTypeSMS {
+		return models.MaskPhone(address)
+	}
+	return models.MaskEmail(address)
+}
+
+// emailWithTracking converts a plain text campaign body to HTML and adds the
+// open-tracking pixel
+func emailWithTracking(message *models.NotificationOutbox) string {
+	apiURL := os.Getenv("API_URL")
+	if apiURL == "" {
+		apiURL = "http://localhost:8080"
+	}
+	body := strings.ReplaceAll(html.EscapeString(message.Body), "\n", "<br>")
+	return fmt.Sprintf(`<p>%s</p><img src="%s/api/v1/campaigns/open/%s" width="1" height="1" alt="">`,
+		body, apiURL, message.TrackingToken)
+}
+
+// newTrackingToken returns a random token identifying one outbox message
+func newTrackingToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
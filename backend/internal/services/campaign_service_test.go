@@ -0,0 +1,105 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestLocaliseCampaign(t *testing.T) {
+	campaign := &models.Campaign{Subject: "Winter coats", Body: "Hi {{.FirstName}}"}
+	translations := map[string]*models.CampaignTranslation{
+		"pl": {Language: "pl", Subject: "Zimowe kurtki", Body: "Cześć {{.FirstName}}"},
+		"so": {Language: "so", Body: "Salaan {{.FirstName}}"},
+	}
+	cases := []struct {
+		chain    []string
+		language string
+		subject  string
+		body     string
+	}{
+		{[]string{"pl", "en"}, "pl", "Zimowe kurtki", "Cześć {{.FirstName}}"},
+		{[]string{"ro", "so", "en"}, "so", "Winter coats", "Salaan {{.FirstName}}"}, // No translated subject keeps English
+		{[]string{"ro", "en"}, "en", "Winter coats", "Hi {{.FirstName}}"},
+	}
+	for _, tc := range cases {
+		localised, language := localiseCampaign(campaign, translations, tc.chain)
+		if language != tc.language || localised.Subject != tc.subject || localised.Body != tc.body {
+			t.Errorf("localiseCampaign(%v) = %s %q %q, want %s %q %q", tc.chain,
+				language, localised.Subject, localised.Body, tc.language, tc.subject, tc.body)
+		}
+	}
+	if campaign.Subject != "Winter coats" || campaign.Body != "Hi {{.FirstName}}" {
+		t.Errorf("localiseCampaign changed the original campaign")
+	}
+}
+
+func TestValidateCampaign(t *testing.T) {
+	cs := &CampaignService{}
+
+	campaign := &models.Campaign{Channel: models.NotificationTypeSMS, Body: "Hi {{.FirstName}}, the food bank is open on Saturday"}
+	if err := cs.ValidateCampaign(campaign); err != nil || campaign.ThrottlePerMinute != defaultCampaignThrottle {
+		t.Errorf("valid SMS campaign: throttle %d, %v", campaign.ThrottlePerMinute, err)
+	}
+
+	invalid := map[string]*models.Campaign{
+		"unknown channel":       {Channel: "fax", Body: "Hi"},
+		"email without subject": {Channel: models.NotificationTypeEmail, Subject: " ", Body: "Hi"},
+		"throttle too high":     {Channel: models.NotificationTypeSMS, Body: "Hi", ThrottlePerMinute: maxCampaignThrottle + 1},
+		"broken body template":  {Channel: models.NotificationTypeSMS, Body: "Hi {{.FirstName"},
+		"broken subject":        {Channel: models.NotificationTypeEmail, Subject: "{{if}}", Body: "Hi"},
+	}
+	for name, campaign := range invalid {
+		if err := cs.ValidateCampaign(campaign); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestRenderCampaign(t *testing.T) {
+	campaign := &models.Campaign{Subject: "News for {{.Name}}", Body: "Hi {{.FirstName}}, {{.Unknown}}see you soon"}
+	subject, body, err := renderCampaign(campaign, &models.User{FirstName: "Amara", LastName: "Okafor"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "News for Amara Okafor" || body != "Hi Amara, see you soon" {
+		t.Errorf("got %q, %q", subject, body)
+	}
+
+	// A recipient without a surname has no trailing space in their name
+	if subject, _, _ := renderCampaign(campaign, &models.User{FirstName: "Amara"}); subject != "News for Amara" {
+		t.Errorf("got %q", subject)
+	}
+}
+
+func TestCampaignRecipient(t *testing.T) {
+	user := &models.User{Email: "amara@example.org", Phone: "+447700900123"}
+	tests := []struct {
+		channel string
+		masked  bool
+		want    string
+	}{
+		{models.NotificationTypeEmail, false, "amara@example.org"},
+		{models.NotificationTypeSMS, false, "+447700900123"},
+		{models.NotificationTypeSMS, true, "**********123"},
+	}
+	for _, tt := range tests {
+		if got := campaignRecipient(tt.channel, user, tt.masked); got != tt.want {
+			t.Errorf("%s masked=%v: got %q, want %q", tt.channel, tt.masked, got, tt.want)
+		}
+	}
+	if got := maskedRecipient(models.NotificationTypeEmail, user.Email); got != "a***@example.org" {
+		t.Errorf("masked email %q", got)
+	}
+}
+
+func TestEmailWithTracking(t *testing.T) {
+	t.Setenv("API_URL", "https://api.example.org")
+	message := &models.NotificationOutbox{Body: "Coats <free>\nSaturday & Sunday", TrackingToken: "abc123"}
+
+	got := emailWithTracking(message)
+	want := `<p>Coats &lt;free&gt;<br>Saturday &amp; Sunday</p><img src="https://api.example.org/api/v1/campaigns/open/abc123" width="1" height="1" alt="">`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
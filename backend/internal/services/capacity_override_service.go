@@ -0,0 +1,518 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultCapacityOverrideMaxSlots is how many extra visits one override can add
+const defaultCapacityOverrideMaxSlots = 20
+
+// capacityOverrideAction is where managers review override requests
+const capacityOverrideAction = "/admin/capacity/overrides"
+
+var (
+	ErrCapacityOverrideNotFound = errors.New("capacity override not found")
+	ErrCapacityOverrideInvalid  = errors.New("invalid capacity override")
+	ErrCapacityOverrideDecided  = errors.New("capacity override has already been decided")
+	ErrCapacityOverrideNotLive  = errors.New("capacity override is not approved")
+	ErrCapacityOverrideApprover = errors.New("capacity overrides must be approved by a manager other than the requester")
+	ErrCapacityOverrideClosed   = errors.New("the day is not an operating day")
+	ErrCapacityOverrideCancel   = errors.New("only the requester or a manager can withdraw a request, and only a manager can revoke an approved override")
+)
+
+// CapacityOverrideRequest asks for extra visits on a day
+type CapacityOverrideRequest struct {
+	Date       time.Time  // The visit day, as stored on its capacity record
+	Category   string     // food or general
+	ExtraSlots int        // Visits to add
+	Reason     string     // Why the extra visits are needed
+	ExpiresAt  *time.Time // When the extra visits end; the end of the day if nil
+}
+
+// CapacityOverrideDay is a day's capacity in one category split into its base limit
+// and the slots overrides add
+type CapacityOverrideDay struct {
+	Date           string `json:"date"`
+	Category       string `json:"category"`
+	BaseVisits     int    `json:"base_visits"`
+	OverrideVisits int    `json:"override_visits"`
+	MaxVisits      int    `json:"max_visits"`
+	Used           int    `json:"used"`
+	Pending        int    `json:"pending_overrides"` // Requests waiting for a decision
+}
+
+// CapacityOverrideService handles requests to go above a day's capacity. Approved
+// overrides add their slots to the day and are taken back when they expire or are
+// revoked; the capacity record keeps the override share separate from the base limit.
+type CapacityOverrideService struct {
+	db *gorm.DB
+}
+
+// NewCapacityOverrideService creates a new capacity override service
+func NewCapacityOverrideService() *CapacityOverrideService {
+	return &CapacityOverrideService{db: db.DB}
+}
+
+// capacityOverrideMaxSlots is the most extra visits one override can add
+func capacityOverrideMaxSlots() int {
+	if v, err := strconv.Atoi(os.Getenv("CAPACITY_OVERRIDE_MAX_SLOTS")); err == nil && v > 0 {
+		return v
+	}
+	return defaultCapacityOverrideMaxSlots
+}
+
+// capacityDayEnd returns the local midnight that ends a visit day
+func capacityDayEnd(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, time.Local)
+}
+
+// validateCapacityOverride checks a request and returns when it should expire
+func validateCapacityOverride(req CapacityOverrideRequest, now time.Time) (time.Time, error) {
+	if req.Category != models.CategoryFood && req.Category != models.CategoryGeneral {
+		return time.Time{}, fmt.Errorf("%w: category must be food or general", ErrCapacityOverrideInvalid)
+	}
+	if max := capacityOverrideMaxSlots(); req.ExtraSlots < 1 || req.ExtraSlots > max {
+		return time.Time{}, fmt.Errorf("%w: extra slots must be between 1 and %d", ErrCapacityOverrideInvalid, max)
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return time.Time{}, fmt.Errorf("%w: a reason is required", ErrCapacityOverrideInvalid)
+	}
+
+	dayEnd := capacityDayEnd(req.Date)
+	if !dayEnd.After(now) {
+		return time.Time{}, fmt.Errorf("%w: %s has already passed", ErrCapacityOverrideInvalid, req.Date.Format("2006-01-02"))
+	}
+	expiresAt := dayEnd
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+	if !expiresAt.After(now) || expiresAt.After(dayEnd) {
+		return time.Time{}, fmt.Errorf("%w: the override must end after now and no later than the end of %s",
+			ErrCapacityOverrideInvalid, req.Date.Format("2006-01-02"))
+	}
+	return expiresAt, nil
+}
+
+// Request records a request for extra visits and asks managers to decide on it
+func (cs *CapacityOverrideService) Request(requestedBy uint, req CapacityOverrideRequest) (*models.CapacityOverride, error) {
+	expiresAt, err := validateCapacityOverride(req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	override := models.CapacityOverride{
+		Date:        req.Date,
+		Category:    req.Category,
+		ExtraSlots:  req.ExtraSlots,
+		Reason:      strings.TrimSpace(req.Reason),
+		Status:      models.CapacityOverridePending,
+		ExpiresAt:   expiresAt,
+		RequestedBy: requestedBy,
+	}
+	if err := cs.db.Create(&override).Error; err != nil {
+		return nil, err
+	}
+
+	cs.notifyManagers(override)
+	return &override, nil
+}
+
+// List returns overrides, newest first, optionally for one status and from a date on
+func (cs *CapacityOverrideService) List(status string, from *time.Time, limit int) ([]models.CapacityOverride, error) {
+	query := cs.db.Preload("Requester").Preload("Decider").Order("created_at DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if from != nil {
+		query = query.Where("date >= ?", *from)
+	}
+
+	var overrides []models.CapacityOverride
+	if err := query.Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// Approve adds the override's slots to the day's capacity. The approver must be a
+// manager and cannot approve their own request.
+func (cs *CapacityOverrideService) Approve(id, approverID uint, notes string) (*models.CapacityOverride, error) {
+	var override models.CapacityOverride
+	err := cs.db.Transaction(func(tx *gorm.DB) error {
+		if err := cs.pending(tx, id, &override); err != nil {
+			return err
+		}
+		if err := cs.checkApprover(tx, override, approverID); err != nil {
+			return err
+		}
+		now := time.Now()
+		if !override.ExpiresAt.After(now) {
+			return fmt.Errorf("%w: the override window has already ended", ErrCapacityOverrideInvalid)
+		}
+
+		capacity, err := lockCapacity(tx, override.Date)
+		if err != nil {
+			return err
+		}
+		if !capacity.IsOperatingDay {
+			return ErrCapacityOverrideClosed
+		}
+		if err := adjustOverrideCapacity(tx, capacity, override.Category, override.ExtraSlots); err != nil {
+			return err
+		}
+
+		override.Status = models.CapacityOverrideApproved
+		override.DecidedBy = &approverID
+		override.DecidedAt = &now
+		override.DecisionNotes = notes
+		return tx.Save(&override).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cs.notifyRequester(override)
+	return &override, nil
+}
+
+// Reject turns down a pending override
+func (cs *CapacityOverrideService) Reject(id, deciderID uint, notes string) (*models.CapacityOverride, error) {
+	var override models.CapacityOverride
+	err := cs.db.Transaction(func(tx *gorm.DB) error {
+		if err := cs.pending(tx, id, &override); err != nil {
+			return err
+		}
+		if err := cs.checkApprover(tx, override, deciderID); err != nil {
+			return err
+		}
+		now := time.Now()
+		override.Status = models.CapacityOverrideRejected
+		override.DecidedBy = &deciderID
+		override.DecidedAt = &now
+		override.DecisionNotes = notes
+		return tx.Save(&override).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cs.notifyRequester(override)
+	return &override, nil
+}
+
+// Cancel withdraws a pending override, or revokes an approved one and takes its
+// slots back. Visits already booked into the extra slots are kept.
+func (cs *CapacityOverrideService) Cancel(id, userID uint, notes string) (*models.CapacityOverride, error) {
+	var override models.CapacityOverride
+	err := cs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&override, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCapacityOverrideNotFound
+			}
+			return err
+		}
+
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return ErrCapacityOverrideCancel
+		}
+		manager := isCapacityManager(user)
+
+		now := time.Now()
+		switch override.Status {
+		case models.CapacityOverridePending:
+			if !manager && userID != override.RequestedBy {
+				return ErrCapacityOverrideCancel
+			}
+			override.Status = models.CapacityOverrideCancelled
+			override.DecidedBy = &userID
+			override.DecidedAt = &now
+			override.DecisionNotes = notes
+		case models.CapacityOverrideApproved:
+			if !manager {
+				return ErrCapacityOverrideCancel
+			}
+			if err := endOverride(tx, &override, models.CapacityOverrideRevoked, now); err != nil {
+				return err
+			}
+			if notes != "" {
+				override.DecisionNotes = strings.TrimSpace(override.DecisionNotes + "\n" + notes)
+			}
+		default:
+			return ErrCapacityOverrideDecided
+		}
+		return tx.Save(&override).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+// ExpireDue ends approved overrides whose window has passed and returns how many it
+// ended. Pending requests whose window passed without a decision expire too.
+func (cs *CapacityOverrideService) ExpireDue(now time.Time) (int, error) {
+	var due []models.CapacityOverride
+	if err := cs.db.Where("status IN ? AND expires_at <= ?",
+		[]string{models.CapacityOverridePending, models.CapacityOverrideApproved}, now).
+		Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, candidate := range due {
+		err := cs.db.Transaction(func(tx *gorm.DB) error {
+			var override models.CapacityOverride
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&override, candidate.ID).Error; err != nil {
+				return err
+			}
+			switch override.Status {
+			case models.CapacityOverrideApproved:
+				if err := endOverride(tx, &override, models.CapacityOverrideExpired, now); err != nil {
+					return err
+				}
+			case models.CapacityOverridePending:
+				override.Status = models.CapacityOverrideExpired
+				override.EndedAt = &now
+			default:
+				return nil // Decided since it was loaded
+			}
+			if err := tx.Save(&override).Error; err != nil {
+				return err
+			}
+			expired++
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to expire capacity override %d: %v", candidate.ID, err)
+		}
+	}
+	return expired, nil
+}
+
+// Days returns each day's base and override capacity from a date for a number of days,
+// for days that have an override approved or waiting
+func (cs *CapacityOverrideService) Days(from time.Time, days int) ([]CapacityOverrideDay, error) {
+	to := from.AddDate(0, 0, days)
+
+	var capacities []models.VisitCapacity
+	if err := cs.db.Where("date >= ? AND date < ?", from, to).Order("date").Find(&capacities).Error; err != nil {
+		return nil, err
+	}
+	var pending []struct {
+		Date     time.Time
+		Category string
+		Count    int
+	}
+	if err := cs.db.Model(&models.CapacityOverride{}).
+		Select("date, category, COUNT(*) AS count").
+		Where("status = ? AND date >= ? AND date < ?", models.CapacityOverridePending, from, to).
+		Group("date, category").
+		Scan(&pending).Error; err != nil {
+		return nil, err
+	}
+
+	waiting := map[string]int{}
+	for _, row := range pending {
+		waiting[row.Date.Format("2006-01-02")+"|"+row.Category] = row.Count
+	}
+
+	var result []CapacityOverrideDay
+	for i := range capacities {
+		capacity := &capacities[i]
+		date := capacity.Date.Format("2006-01-02")
+		for _, category := range []string{models.CategoryFood, models.CategoryGeneral} {
+			overrideVisits := capacity.OverrideCapacity(category)
+			waitingCount := waiting[date+"|"+category]
+			if overrideVisits == 0 && waitingCount == 0 {
+				continue
+			}
+			used := capacity.CurrentFoodVisits
+			if category == models.CategoryGeneral {
+				used = capacity.CurrentGeneralVisits
+			}
+			result = append(result, CapacityOverrideDay{
+				Date:           date,
+				Category:       category,
+				BaseVisits:     capacity.BaseCapacity(category),
+				OverrideVisits: overrideVisits,
+				MaxVisits:      capacity.BaseCapacity(category) + overrideVisits,
+				Used:           used,
+				Pending:        waitingCount,
+			})
+		}
+	}
+	return result, nil
+}
+
+// pending loads and locks an override that is waiting for a decision
+func (cs *CapacityOverrideService) pending(tx *gorm.DB, id uint, override *models.CapacityOverride) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(override, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCapacityOverrideNotFound
+		}
+		return err
+	}
+	if override.Status != models.CapacityOverridePending {
+		return ErrCapacityOverrideDecided
+	}
+	return nil
+}
+
+// checkApprover makes sure a decision is made by a manager other than the requester
+func (cs *CapacityOverrideService) checkApprover(tx *gorm.DB, override models.CapacityOverride, approverID uint) error {
+	if approverID == 0 || approverID == override.RequestedBy {
+		return ErrCapacityOverrideApprover
+	}
+	var approver models.User
+	if err := tx.First(&approver, approverID).Error; err != nil {
+		return ErrCapacityOverrideApprover
+	}
+	if !isCapacityManager(approver) {
+		return ErrCapacityOverrideApprover
+	}
+	return nil
+}
+
+// isCapacityManager reports whether a user can decide on capacity overrides: super
+// admins and admins without a restricted scope
+func isCapacityManager(user models.User) bool {
+	switch user.Role {
+	case models.RoleSuperAdmin, models.RoleSuperAdminLegacy:
+		return true
+	case models.RoleAdmin, models.RoleAdminLegacy:
+		return user.AdminScope == ""
+	default:
+		return false
+	}
+}
+
+// lockCapacity returns the locked capacity record for a day, creating one with the
+// default limits if there is none yet
+func lockCapacity(tx *gorm.DB, date time.Time) (*models.VisitCapacity, error) {
+	var capacity models.VisitCapacity
+	if err := tx.Where("date = ?", date).
+		Attrs(models.VisitCapacity{
+			Date:             date,
+			DayOfWeek:        date.Format("Monday"),
+			MaxFoodVisits:    50,
+			MaxGeneralVisits: 20,
+			IsOperatingDay:   date.Weekday() >= time.Tuesday && date.Weekday() <= time.Thursday,
+		}).
+		FirstOrCreate(&capacity).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&capacity, capacity.ID).Error; err != nil {
+		return nil, err
+	}
+	return &capacity, nil
+}
+
+// adjustOverrideCapacity adds slots to, or with a negative number takes them from, a
+// category's max visits and its override share
+func adjustOverrideCapacity(tx *gorm.DB, capacity *models.VisitCapacity, category string, slots int) error {
+	updates := map[string]interface{}{}
+	if category == models.CategoryFood {
+		capacity.MaxFoodVisits += slots
+		capacity.OverrideFoodVisits += slots
+		updates["max_food_visits"] = capacity.MaxFoodVisits
+		updates["override_food_visits"] = capacity.OverrideFoodVisits
+	} else {
+		capacity.MaxGeneralVisits += slots
+		capacity.OverrideGeneralVisits += slots
+		updates["max_general_visits"] = capacity.MaxGeneralVisits
+		updates["override_general_visits"] = capacity.OverrideGeneralVisits
+	}
+	return tx.Model(capacity).Updates(updates).Error
+}
+
+// endOverride takes an approved override's slots back off its day
+func endOverride(tx *gorm.DB, override *models.CapacityOverride, status string, now time.Time) error {
+	if override.Status != models.CapacityOverrideApproved {
+		return ErrCapacityOverrideNotLive
+	}
+	capacity, err := lockCapacity(tx, override.Date)
+	if err != nil {
+		return err
+	}
+	// Never take back more than the day still holds from overrides
+	slots := override.ExtraSlots
+	if current := capacity.OverrideCapacity(override.Category); slots > current {
+		slots = current
+	}
+	if err := adjustOverrideCapacity(tx, capacity, override.Category, -slots); err != nil {
+		return err
+	}
+	override.Status = status
+	override.EndedAt = &now
+	return nil
+}
+
+// notifyManagers tells the managers who can approve it about a new override request
+func (cs *CapacityOverrideService) notifyManagers(override models.CapacityOverride) {
+	var managers []models.User
+	if err := cs.db.Where("role IN ? AND status = ? AND id <> ?",
+		[]string{models.RoleAdmin, models.RoleSuperAdmin}, models.StatusActive, override.RequestedBy).
+		Find(&managers).Error; err != nil {
+		log.Printf("Failed to load managers for capacity override %d: %v", override.ID, err)
+		return
+	}
+
+	title := "Capacity override needs approval"
+	message := fmt.Sprintf("%d extra %s visits requested for %s: %s",
+		override.ExtraSlots, override.Category, override.Date.Format("Monday 2 January"), override.Reason)
+	for _, manager := range managers {
+		if !isCapacityManager(manager) {
+			continue
+		}
+		notification := models.InAppNotification{
+			UserID:    manager.ID,
+			Title:     title,
+			Message:   message,
+			Type:      "capacity_override",
+			Priority:  models.PriorityHigh,
+			ActionURL: capacityOverrideAction,
+		}
+		if err := cs.db.Create(&notification).Error; err != nil {
+			log.Printf("Failed to notify manager %d of capacity override %d: %v", manager.ID, override.ID, err)
+		}
+		if manager.Email != "" {
+			if err := notifications.GetService().SendEmail(manager.Email, title, message); err != nil {
+				log.Printf("Failed to email manager %d about capacity override %d: %v", manager.ID, override.ID, err)
+			}
+		}
+	}
+}
+
+// notifyRequester tells the requester what was decided
+func (cs *CapacityOverrideService) notifyRequester(override models.CapacityOverride) {
+	message := fmt.Sprintf("Your request for %d extra %s visits on %s was %s",
+		override.ExtraSlots, override.Category, override.Date.Format("Monday 2 January"), override.Status)
+	if override.DecisionNotes != "" {
+		message += ": " + override.DecisionNotes
+	}
+	notification := models.InAppNotification{
+		UserID:    override.RequestedBy,
+		Title:     "Capacity override " + override.Status,
+		Message:   message,
+		Type:      "capacity_override",
+		Priority:  models.PriorityMedium,
+		ActionURL: capacityOverrideAction,
+	}
+	if err := cs.db.Create(&notification).Error; err != nil {
+		log.Printf("Failed to notify requester of capacity override %d: %v", override.ID, err)
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestValidateCapacityOverride(t *testing.T) {
+	now := time.Date(2026, time.October, 13, 10, 0, 0, 0, time.Local)
+	today := time.Date(2026, time.October, 13, 0, 0, 0, 0, time.UTC)
+	tomorrow := today.AddDate(0, 0, 1)
+	at := func(t time.Time) *time.Time { return &t }
+
+	request := func(change func(*CapacityOverrideRequest)) CapacityOverrideRequest {
+		req := CapacityOverrideRequest{Date: tomorrow, Category: models.CategoryFood, ExtraSlots: 5, Reason: "Flooding nearby"}
+		change(&req)
+		return req
+	}
+
+	expiresAt, err := validateCapacityOverride(request(func(*CapacityOverrideRequest) {}), now)
+	if err != nil || !expiresAt.Equal(time.Date(2026, time.October, 15, 0, 0, 0, 0, time.Local)) {
+		t.Fatalf("expected the override to last until the end of tomorrow, got %v, %v", expiresAt, err)
+	}
+
+	if _, err := validateCapacityOverride(request(func(r *CapacityOverrideRequest) { r.Date = today }), now); err != nil {
+		t.Errorf("expected an override for the rest of today to be allowed, got %v", err)
+	}
+
+	invalid := map[string]CapacityOverrideRequest{
+		"past day":       request(func(r *CapacityOverrideRequest) { r.Date = today.AddDate(0, 0, -1) }),
+		"no slots":       request(func(r *CapacityOverrideRequest) { r.ExtraSlots = 0 }),
+		"too many slots": request(func(r *CapacityOverrideRequest) { r.ExtraSlots = defaultCapacityOverrideMaxSlots + 1 }),
+		"no reason":      request(func(r *CapacityOverrideRequest) { r.Reason = "  " }),
+		"bad category":   request(func(r *CapacityOverrideRequest) { r.Category = "clothing" }),
+		"already ended":  request(func(r *CapacityOverrideRequest) { r.ExpiresAt = at(now.Add(-time.Minute)) }),
+		"after the day":  request(func(r *CapacityOverrideRequest) { r.ExpiresAt = at(now.AddDate(0, 0, 3)) }),
+	}
+	for name, req := range invalid {
+		if _, err := validateCapacityOverride(req, now); !errors.Is(err, ErrCapacityOverrideInvalid) {
+			t.Errorf("%s: expected ErrCapacityOverrideInvalid, got %v", name, err)
+		}
+	}
+}
+
+func TestIsCapacityManager(t *testing.T) {
+	cases := []struct {
+		user models.User
+		want bool
+	}{
+		{models.User{Role: models.RoleSuperAdmin}, true},
+		{models.User{Role: models.RoleAdmin}, true},
+		{models.User{Role: models.RoleAdmin, AdminScope: models.AdminScopeFinanceOfficer}, false},
+		{models.User{Role: models.RoleStaff}, false},
+		{models.User{Role: models.RoleVolunteer}, false},
+	}
+	for _, tc := range cases {
+		if got := isCapacityManager(tc.user); got != tc.want {
+			t.Errorf("isCapacityManager(%s, %q) = %v, want %v", tc.user.Role, tc.user.AdminScope, got, tc.want)
+		}
+	}
+}
+
+func TestVisitCapacityBaseAndOverride(t *testing.T) {
+	capacity := models.VisitCapacity{MaxFoodVisits: 58, OverrideFoodVisits: 8, MaxGeneralVisits: 20}
+	if capacity.BaseCapacity(models.CategoryFood) != 50 || capacity.OverrideCapacity(models.CategoryFood) != 8 {
+		t.Errorf("unexpected food split: base %d, override %d",
+			capacity.BaseCapacity(models.CategoryFood), capacity.OverrideCapacity(models.CategoryFood))
+	}
+	if capacity.BaseCapacity(models.CategoryGeneral) != 20 || capacity.OverrideCapacity(models.CategoryGeneral) != 0 {
+		t.Errorf("unexpected general split")
+	}
+}
@@ -0,0 +1,399 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultSimulationWeeks is how much history a simulation replays by default
+	defaultSimulationWeeks = 8
+	// maxSimulationWeeks is the longest history a simulation may replay
+	maxSimulationWeeks = 26
+	// maxSimulationScenarios limits how many scenarios one request compares
+	maxSimulationScenarios = 5
+	// defaultSimulationServers is used on days with no volunteers rostered
+	defaultSimulationServers = 2
+)
+
+var ErrCapacitySimulationInvalid = errors.New("invalid capacity simulation")
+
+// CapacityScenario is a "what if" change replayed against recent history
+type CapacityScenario struct {
+	Name                     string   `json:"name"`
+	DemandChangePercent      float64  `json:"demand_change_percent"`       // 20 adds a fifth more arrivals
+	ServiceTimeChangeMinutes float64  `json:"service_time_change_minutes"` // Added to every visit's service time
+	RemoveRoles              []string `json:"remove_roles"`                // Shift roles taken off the floor
+	VolunteerChange          int      `json:"volunteer_change"`            // Volunteers added to (or removed from) each day
+}
+
+// CapacitySimulationRequest chooses the service, history and scenarios to simulate
+type CapacitySimulationRequest struct {
+	Category  string
+	Weeks     int
+	Scenarios []CapacityScenario
+}
+
+// CapacityProjection is the projected outcome of one scenario over the replayed days
+type CapacityProjection struct {
+	Scenario              CapacityScenario `json:"scenario"`
+	Days                  int              `json:"days"`
+	AverageDailyDemand    float64          `json:"average_daily_demand"`
+	AverageServers        float64          `json:"average_servers"`
+	AverageServiceMinutes float64          `json:"average_service_minutes"`
+	AverageWaitMinutes    float64          `json:"average_wait_minutes"`
+	P90WaitMinutes        float64          `json:"p90_wait_minutes"`
+	MaxWaitMinutes        float64          `json:"max_wait_minutes"`
+	Served                int              `json:"served"`
+	OverCapacity          int              `json:"over_capacity"`       // Arrivals beyond the daily capacity
+	NotServedByClose      int              `json:"not_served_by_close"` // Arrivals still waiting at closing time
+	UnmetDemand           int              `json:"unmet_demand"`
+	UnmetPercent          float64          `json:"unmet_percent"`
+	WaitChangeMinutes     float64          `json:"wait_change_minutes"` // Against the baseline
+	UnmetChange           int              `json:"unmet_change"`        // Against the baseline
+}
+
+// CapacitySimulation compares scenarios with a baseline replay of recent history
+type CapacitySimulation struct {
+	Category       string               `json:"category"`
+	From           string               `json:"from"`
+	To             string               `json:"to"`
+	HistoricalDays int                  `json:"historical_days"`
+	DailyCapacity  int                  `json:"daily_capacity"`
+	ClosingTime    string               `json:"closing_time"`
+	Roles          map[string]float64   `json:"roles"` // Average volunteers per day by shift role
+	Baseline       CapacityProjection   `json:"baseline"`
+	Scenarios      []CapacityProjection `json:"scenarios"`
+}
+
+// simulationDay is one historical operating day
+type simulationDay struct {
+	Date     string
+	Arrivals []int          // Minutes after midnight, in order
+	Roles    map[string]int // Volunteers rostered by shift role
+}
+
+// simulationDayResult is how one simulated day went
+type simulationDayResult struct {
+	Served           int
+	OverCapacity     int
+	NotServedByClose int
+	Waits            []float64
+}
+
+// CapacitySimulationService replays recent queue history under "what if" changes
+// to demand, staffing and service time so admins can plan capacity
+type CapacitySimulationService struct {
+	db *gorm.DB
+}
+
+// NewCapacitySimulationService creates a new capacity simulation service
+func NewCapacitySimulationService() *CapacitySimulationService {
+	return &CapacitySimulationService{db: db.DB}
+}
+
+// simulationDefaultServers is how many visitors can be served at once on days
+// with no volunteers rostered
+func simulationDefaultServers() int {
+	if v, err := strconv.Atoi(os.Getenv("CAPACITY_SIMULATION_DEFAULT_SERVERS")); err == nil && v > 0 {
+		return v
+	}
+	return defaultSimulationServers
+}
+
+// validateScenario checks a scenario is within sensible planning bounds
+func validateScenario(scenario CapacityScenario) error {
+	if scenario.DemandChangePercent <= -100 || scenario.DemandChangePercent > 500 {
+		return fmt.Errorf("%w: demand change must be above -100%% and at most 500%%", ErrCapacitySimulationInvalid)
+	}
+	if math.Abs(scenario.ServiceTimeChangeMinutes) > 60 {
+		return fmt.Errorf("%w: service time change must be within 60 minutes", ErrCapacitySimulationInvalid)
+	}
+	if scenario.VolunteerChange < -50 || scenario.VolunteerChange > 50 {
+		return fmt.Errorf("%w: volunteer change must be within 50", ErrCapacitySimulationInvalid)
+	}
+	return nil
+}
+
+// Simulate replays the last weeks of queue history for a service, once as it
+// happened and once per scenario, and projects waits and unmet demand
+func (cs *CapacitySimulationService) Simulate(req CapacitySimulationRequest, now time.Time) (*CapacitySimulation, error) {
+	category := strings.ToLower(strings.TrimSpace(req.Category))
+	if category == "" {
+		return nil, fmt.Errorf("%w: category is required", ErrCapacitySimulationInvalid)
+	}
+	weeks := req.Weeks
+	if weeks == 0 {
+		weeks = defaultSimulationWeeks
+	}
+	if weeks < 1 || weeks > maxSimulationWeeks {
+		return nil, fmt.Errorf("%w: replay between 1 and %d weeks", ErrCapacitySimulationInvalid, maxSimulationWeeks)
+	}
+	if len(req.Scenarios) == 0 || len(req.Scenarios) > maxSimulationScenarios {
+		return nil, fmt.Errorf("%w: compare between 1 and %d scenarios", ErrCapacitySimulationInvalid, maxSimulationScenarios)
+	}
+	for _, scenario := range req.Scenarios {
+		if err := validateScenario(scenario); err != nil {
+			return nil, err
+		}
+	}
+
+	serviceType := models.ServiceType{
+		DailyCapacity:        20,
+		ClosingTime:          "14:30",
+		TargetServiceMinutes: defaultTargetServiceMinutes,
+	}
+	if st, err := NewServiceTypeService().GetByCode(category); err == nil {
+		serviceType = *st
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	_, closing, err := serviceType.SlotWindow()
+	if err != nil {
+		closing = 14*60 + 30
+	}
+
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	from := to.AddDate(0, 0, -7*weeks)
+	days, serviceTimes, err := cs.loadHistory(category, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(serviceTimes) == 0 && serviceType.TargetServiceMinutes > 0 {
+		serviceTimes = []float64{float64(serviceType.TargetServiceMinutes)}
+	}
+	if len(serviceTimes) == 0 {
+		serviceTimes = []float64{defaultTargetServiceMinutes}
+	}
+
+	simulation := &CapacitySimulation{
+		Category:       category,
+		From:           from.Format("2006-01-02"),
+		To:             to.AddDate(0, 0, -1).Format("2006-01-02"),
+		HistoricalDays: len(days),
+		DailyCapacity:  serviceType.DailyCapacity,
+		ClosingTime:    fmt.Sprintf("%02d:%02d", closing/60, closing%60),
+		Roles:          averageRoles(days),
+		Scenarios:      make([]CapacityProjection, 0, len(req.Scenarios)),
+	}
+
+	servers := simulationDefaultServers()
+	simulation.Baseline = projectScenario(CapacityScenario{Name: "baseline"}, days, serviceTimes, servers, closing, serviceType.DailyCapacity)
+	for _, scenario := range req.Scenarios {
+		projection := projectScenario(scenario, days, serviceTimes, servers, closing, serviceType.DailyCapacity)
+		projection.WaitChangeMinutes = roundMinutes(projection.AverageWaitMinutes - simulation.Baseline.AverageWaitMinutes)
+		projection.UnmetChange = projection.UnmetDemand - simulation.Baseline.UnmetDemand
+		simulation.Scenarios = append(simulation.Scenarios, projection)
+	}
+
+	return simulation, nil
+}
+
+// loadHistory returns each day's queue arrivals and rostered volunteers, and the
+// service times of completed visits, between two dates
+func (cs *CapacitySimulationService) loadHistory(category string, from, to time.Time) ([]simulationDay, []float64, error) {
+	var entries []models.QueueEntry
+	if err := cs.db.Select("joined_at", "served_at", "completed_at").
+		Where("category = ? AND joined_at >= ? AND joined_at < ?", category, from, to).
+		Order("joined_at ASC").
+		Find(&entries).Error; err != nil {
+		return nil, nil, err
+	}
+
+	byDate := map[string]*simulationDay{}
+	var order []string
+	var serviceTimes []float64
+	for _, entry := range entries {
+		date := entry.JoinedAt.Format("2006-01-02")
+		day, ok := byDate[date]
+		if !ok {
+			day = &simulationDay{Date: date, Roles: map[string]int{}}
+			byDate[date] = day
+			order = append(order, date)
+		}
+		day.Arrivals = append(day.Arrivals, entry.JoinedAt.Hour()*60+entry.JoinedAt.Minute())
+		if entry.ServedAt != nil && entry.CompletedAt != nil {
+			if minutes := entry.CompletedAt.Sub(*entry.ServedAt).Minutes(); minutes > 0 {
+				serviceTimes = append(serviceTimes, minutes)
+			}
+		}
+	}
+
+	var rostered []struct {
+		Date  time.Time
+		Role  string
+		Count int
+	}
+	if err := cs.db.Table("shift_assignments").
+		Select("shifts.date, shifts.role, COUNT(*) AS count").
+		Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id AND shifts.deleted_at IS NULL AND shifts.cancelled_at IS NULL").
+		Where("shifts.date >= ? AND shifts.date < ?", from, to).
+		Where("shift_assignments.status NOT IN ?", []string{"Cancelled", "NoShow"}).
+		Group("shifts.date, shifts.role").
+		Scan(&rostered).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, row := range rostered {
+		if day, ok := byDate[row.Date.Format("2006-01-02")]; ok {
+			day.Roles[strings.TrimSpace(row.Role)] += row.Count
+		}
+	}
+
+	days := make([]simulationDay, 0, len(order))
+	for _, date := range order {
+		days = append(days, *byDate[date])
+	}
+	return days, serviceTimes, nil
+}
+
+// averageRoles returns the average number of volunteers rostered per day by role
+func averageRoles(days []simulationDay) map[string]float64 {
+	roles := map[string]float64{}
+	if len(days) == 0 {
+		return roles
+	}
+	for _, day := range days {
+		for role, count := range day.Roles {
+			roles[role] += float64(count)
+		}
+	}
+	for role, total := range roles {
+		roles[role] = math.Round(total/float64(len(days))*10) / 10
+	}
+	return roles
+}
+
+// projectScenario replays every historical day under a scenario and totals the results
+func projectScenario(scenario CapacityScenario, days []simulationDay, serviceTimes []float64, defaultServers, closing, capacity int) CapacityProjection {
+	projection := CapacityProjection{Scenario: scenario, Days: len(days)}
+	if len(days) == 0 {
+		return projection
+	}
+
+	adjusted := make([]float64, len(serviceTimes))
+	var serviceTotal float64
+	for i, minutes := range serviceTimes {
+		adjusted[i] = math.Max(1, minutes+scenario.ServiceTimeChangeMinutes)
+		serviceTotal += adjusted[i]
+	}
+
+	removed := map[string]bool{}
+	for _, role := range scenario.RemoveRoles {
+		removed[strings.ToLower(strings.TrimSpace(role))] = true
+	}
+
+	var waits []float64
+	demand, serverTotal, next := 0, 0, 0
+	for _, day := range days {
+		servers := 0
+		for role, count := range day.Roles {
+			if !removed[strings.ToLower(role)] {
+				servers += count
+			}
+		}
+		if len(day.Roles) == 0 {
+			servers = defaultServers
+		}
+		servers += scenario.VolunteerChange
+		if servers < 0 {
+			servers = 0
+		}
+		serverTotal += servers
+
+		arrivals := scaleArrivals(day.Arrivals, scenario.DemandChangePercent)
+		demand += len(arrivals)
+
+		// Service times are drawn from history in turn so every scenario sees the
+		// same visits in the same order
+		times := make([]float64, len(arrivals))
+		for i := range times {
+			times[i] = adjusted[next%len(adjusted)]
+			next++
+		}
+
+		result := simulateQueueDay(arrivals, times, servers, closing, capacity)
+		projection.Served += result.Served
+		projection.OverCapacity += result.OverCapacity
+		projection.NotServedByClose += result.NotServedByClose
+		waits = append(waits, result.Waits...)
+	}
+
+	projection.AverageDailyDemand = math.Round(float64(demand)/float64(len(days))*10) / 10
+	projection.AverageServers = math.Round(float64(serverTotal)/float64(len(days))*10) / 10
+	projection.AverageServiceMinutes = roundMinutes(serviceTotal / float64(len(adjusted)))
+	projection.UnmetDemand = projection.OverCapacity + projection.NotServedByClose
+	if demand > 0 {
+		projection.UnmetPercent = math.Round(float64(projection.UnmetDemand)/float64(demand)*1000) / 10
+	}
+	if len(waits) > 0 {
+		sort.Float64s(waits)
+		var total float64
+		for _, wait := range waits {
+			total += wait
+		}
+		projection.AverageWaitMinutes = roundMinutes(total / float64(len(waits)))
+		projection.P90WaitMinutes = roundMinutes(waits[int(math.Ceil(0.9*float64(len(waits))))-1])
+		projection.MaxWaitMinutes = roundMinutes(waits[len(waits)-1])
+	}
+	return projection
+}
+
+// scaleArrivals grows or shrinks a day's arrivals by a percentage while keeping
+// their spread through the day
+func scaleArrivals(arrivals []int, changePercent float64) []int {
+	n := len(arrivals)
+	m := int(math.Round(float64(n) * (1 + changePercent/100)))
+	if n == 0 || m <= 0 {
+		return []int{}
+	}
+	scaled := make([]int, m)
+	for i := range scaled {
+		scaled[i] = arrivals[i*n/m]
+	}
+	return scaled
+}
+
+// simulateQueueDay serves arrivals first come, first served across a number of
+// servers. Arrivals beyond the daily capacity are turned away and anyone not
+// started by closing time goes unserved.
+func simulateQueueDay(arrivals []int, serviceTimes []float64, servers, closing, capacity int) simulationDayResult {
+	result := simulationDayResult{}
+	if capacity > 0 && len(arrivals) > capacity {
+		result.OverCapacity = len(arrivals) - capacity
+		arrivals = arrivals[:capacity]
+	}
+	if servers <= 0 {
+		result.NotServedByClose = len(arrivals)
+		return result
+	}
+
+	free := make([]float64, servers)
+	for i, arrival := range arrivals {
+		server := 0
+		for s := range free {
+			if free[s] < free[server] {
+				server = s
+			}
+		}
+		start := math.Max(float64(arrival), free[server])
+		if start >= float64(closing) {
+			result.NotServedByClose++
+			continue
+		}
+		free[server] = start + serviceTimes[i]
+		result.Served++
+		result.Waits = append(result.Waits, start-float64(arrival))
+	}
+	return result
+}
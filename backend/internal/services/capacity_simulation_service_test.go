@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScaleArrivals(t *testing.T) {
+	arrivals := []int{600, 610, 620, 630, 640}
+
+	if got := scaleArrivals(arrivals, 0); len(got) != 5 || got[4] != 640 {
+		t.Errorf("scaleArrivals(0%%) = %v, want the original arrivals", got)
+	}
+	if got := scaleArrivals(arrivals, 20); len(got) != 6 || got[0] != 600 || got[5] != 640 {
+		t.Errorf("scaleArrivals(20%%) = %v, want 6 arrivals spread over the same times", got)
+	}
+	if got := scaleArrivals(arrivals, -60); len(got) != 2 || got[1] != 620 {
+		t.Errorf("scaleArrivals(-60%%) = %v, want [600 620]", got)
+	}
+}
+
+func TestSimulateQueueDay(t *testing.T) {
+	arrivals := []int{600, 600, 600, 600, 600}
+	times := []float64{10, 10, 10, 10, 10}
+
+	// Two servers: waits of 0, 0, 10, 10, 20
+	result := simulateQueueDay(arrivals, times, 2, 700, 0)
+	if result.Served != 5 || result.NotServedByClose != 0 {
+		t.Fatalf("served %d, unserved %d; want 5 and 0", result.Served, result.NotServedByClose)
+	}
+	if result.Waits[4] != 20 {
+		t.Errorf("last wait = %v, want 20", result.Waits[4])
+	}
+
+	// Closing at 10:15 leaves the fifth visitor unserved and capacity turns one away
+	result = simulateQueueDay(arrivals, times, 2, 615, 4)
+	if result.OverCapacity != 1 || result.Served != 4 || result.NotServedByClose != 0 {
+		t.Errorf("got %+v, want 1 over capacity and 4 served", result)
+	}
+	result = simulateQueueDay(arrivals, times, 1, 615, 0)
+	if result.Served != 2 || result.NotServedByClose != 3 {
+		t.Errorf("got %+v, want 2 served and 3 unserved", result)
+	}
+
+	if result = simulateQueueDay(arrivals, times, 0, 700, 0); result.NotServedByClose != 5 {
+		t.Errorf("no servers: got %+v, want everyone unserved", result)
+	}
+}
+
+func TestProjectScenario(t *testing.T) {
+	days := []simulationDay{
+		{Date: "2026-03-10", Arrivals: []int{630, 630, 640, 650}, Roles: map[string]int{"Front desk": 1, "Packer": 1}},
+		{Date: "2026-03-11", Arrivals: []int{630, 640}, Roles: map[string]int{"Front desk": 1, "Packer": 1}},
+	}
+	times := []float64{10}
+
+	baseline := projectScenario(CapacityScenario{}, days, times, 2, 870, 20)
+	if baseline.UnmetDemand != 0 || baseline.AverageServers != 2 || baseline.AverageDailyDemand != 3 {
+		t.Fatalf("baseline = %+v", baseline)
+	}
+
+	fewer := projectScenario(CapacityScenario{RemoveRoles: []string{"packer"}, ServiceTimeChangeMinutes: 2}, days, times, 2, 870, 20)
+	if fewer.AverageServers != 1 || fewer.AverageServiceMinutes != 12 {
+		t.Errorf("servers %v, service %v; want 1 and 12", fewer.AverageServers, fewer.AverageServiceMinutes)
+	}
+	if fewer.AverageWaitMinutes <= baseline.AverageWaitMinutes {
+		t.Errorf("wait %v should exceed the baseline %v", fewer.AverageWaitMinutes, baseline.AverageWaitMinutes)
+	}
+}
+
+func TestValidateScenario(t *testing.T) {
+	if err := validateScenario(CapacityScenario{DemandChangePercent: 20, ServiceTimeChangeMinutes: 2}); err != nil {
+		t.Errorf("valid scenario rejected: %v", err)
+	}
+	for _, scenario := range []CapacityScenario{
+		{DemandChangePercent: -100},
+		{ServiceTimeChangeMinutes: 90},
+		{VolunteerChange: 80},
+	} {
+		if err := validateScenario(scenario); !errors.Is(err, ErrCapacitySimulationInvalid) {
+			t.Errorf("validateScenario(%+v) = %v, want ErrCapacitySimulationInvalid", scenario, err)
+		}
+	}
+}
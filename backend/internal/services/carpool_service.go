@@ -0,0 +1,631 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Carpool limits
+const (
+	CarpoolMaxSeats        = 6
+	CarpoolMaxNotesLength  = 300
+	carpoolNearbyDistricts = 2 // District numbers this close in the same area count as nearby
+)
+
+// Carpool proximity tiers, closest first
+const (
+	CarpoolSameSector     = "same_sector"
+	CarpoolSameDistrict   = "same_district"
+	CarpoolNearbyDistrict = "nearby_district"
+	CarpoolSameArea       = "same_area"
+	CarpoolFarther        = "farther"
+)
+
+var carpoolProximityRank = map[string]int{
+	CarpoolSameSector:     0,
+	CarpoolSameDistrict:   1,
+	CarpoolNearbyDistrict: 2,
+	CarpoolSameArea:       3,
+	CarpoolFarther:        4,
+}
+
+var (
+	ErrCarpoolNotOnShift      = errors.New("you are not signed up for this shift")
+	ErrCarpoolShiftStarted    = errors.New("this shift has already started")
+	ErrCarpoolDisclaimer      = errors.New("you must accept the lift sharing safety disclaimer")
+	ErrCarpoolNoPostcode      = errors.New("a valid UK postcode is needed to match lifts")
+	ErrCarpoolInvalidKind     = errors.New("kind must be offer or need")
+	ErrCarpoolInvalidSeats    = fmt.Errorf("seats must be between 1 and %d", CarpoolMaxSeats)
+	ErrCarpoolNotesTooLong    = fmt.Errorf("notes must be at most %d characters", CarpoolMaxNotesLength)
+	ErrCarpoolHasMatches      = errors.New("withdraw from your current lift matches before changing between offering and needing a lift")
+	ErrCarpoolNoPost          = errors.New("post a lift offer or request for this shift first")
+	ErrCarpoolSameKind        = errors.New("lift offers can only be matched with lift requests")
+	ErrCarpoolPostClosed      = errors.New("that lift is no longer available")
+	ErrCarpoolAlreadyProposed = errors.New("you already have a match with this volunteer")
+	ErrCarpoolNoSeats         = errors.New("no seats are left on this lift")
+	ErrCarpoolMatchClosed     = errors.New("this match is no longer open")
+	ErrCarpoolNotParty        = errors.New("you are not part of this match")
+)
+
+// CarpoolService coordinates opt-in lift sharing between volunteers on the same shift
+type CarpoolService struct {
+	db *gorm.DB
+}
+
+// CarpoolCandidate is another volunteer's post as shown before a match is accepted:
+// only the district and how close it is, never a name or full postcode
+type CarpoolCandidate struct {
+	PostID    uint   `json:"post_id"`
+	Kind      string `json:"kind"`
+	Area      string `json:"area"`
+	Proximity string `json:"proximity"`
+	Seats     int    `json:"seats"`
+	Notes     string `json:"notes"`
+}
+
+// CarpoolContact is shared with the other volunteer once both accept a match
+type CarpoolContact struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Phone     string `json:"phone"`
+	Email     string `json:"email"`
+	Postcode  string `json:"postcode"`
+}
+
+// CarpoolMatchView is a match as seen by one of the volunteers in it
+type CarpoolMatchView struct {
+	models.CarpoolMatch
+	Role         string          `json:"role"` // driver or rider
+	OtherArea    string          `json:"other_area"`
+	AwaitingMe   bool            `json:"awaiting_me"`
+	OtherContact *CarpoolContact `json:"other_contact,omitempty"`
+}
+
+// CarpoolCoordinatorPost is a post with the volunteer's details for coordinators
+type CarpoolCoordinatorPost struct {
+	models.CarpoolPost
+	Postcode  string `json:"postcode"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Phone     string `json:"phone"`
+}
+
+// NewCarpoolService creates a new carpool service
+func NewCarpoolService() *CarpoolService {
+	return &CarpoolService{
+		db: db.DB,
+	}
+}
+
+// CarpoolProximity compares two postcodes by sector, district and area. Without
+// geocoding this is approximate: districts in the same area with close numbers, such
+// as SE13 and SE14, are treated as nearby.
+func CarpoolProximity(a, b string) string {
+	sectorA, sectorB := postcodeSector(a), postcodeSector(b)
+	districtA, districtB := postcodeDistrict(a), postcodeDistrict(b)
+	areaA, areaB := postcodeArea(a), postcodeArea(b)
+
+	switch {
+	case sectorA != "" && sectorA == sectorB:
+		return CarpoolSameSector
+	case districtA != "" && districtA == districtB:
+		return CarpoolSameDistrict
+	case areaA == "" || areaA != areaB:
+		return CarpoolFarther
+	}
+
+	numA, errA := strconv.Atoi(strings.TrimRight(districtA[len(areaA):], "ABCDEFGHIJKLMNOPQRSTUVWXYZ"))
+	numB, errB := strconv.Atoi(strings.TrimRight(districtB[len(areaB):], "ABCDEFGHIJKLMNOPQRSTUVWXYZ"))
+	if errA == nil && errB == nil && numA-numB <= carpoolNearbyDistricts && numB-numA <= carpoolNearbyDistricts {
+		return CarpoolNearbyDistrict
+	}
+	return CarpoolSameArea
+}
+
+// postcodeSector returns the outward code and first inward digit, e.g. "SE13 6" for
+// "SE13 6AB"
+func postcodeSector(postcode string) string {
+	compact := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(postcode), " ", ""))
+	if len(compact) < 5 {
+		return ""
+	}
+	return compact[:len(compact)-3] + " " + compact[len(compact)-3:len(compact)-2]
+}
+
+// normalizeCarpoolPostcode tidies a postcode and checks it looks like a full UK postcode
+func normalizeCarpoolPostcode(postcode string) (string, bool) {
+	compact := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(postcode), " ", ""))
+	if len(compact) < 5 || len(compact) > 7 || postcodeArea(compact) == "" {
+		return "", false
+	}
+	inward := compact[len(compact)-3:]
+	if inward[0] < '0' || inward[0] > '9' {
+		return "", false
+	}
+	return compact[:len(compact)-3] + " " + inward, true
+}
+
+// onShift checks the user is signed up for the shift
+func (cs *CarpoolService) onShift(shiftID, userID uint) bool {
+	var count int64
+	cs.db.Model(&models.ShiftAssignment{}).
+		Where("shift_id = ? AND user_id = ? AND status NOT IN ?", shiftID, userID, []string{"Cancelled", "NoShow"}).
+		Count(&count)
+	return count > 0
+}
+
+// checkShift makes sure the user is on a shift that has not started
+func (cs *CarpoolService) checkShift(shiftID, userID uint, now time.Time) error {
+	var shift models.Shift
+	if err := cs.db.First(&shift, shiftID).Error; err != nil {
+		return err
+	}
+	if !cs.onShift(shiftID, userID) {
+		return ErrCarpoolNotOnShift
+	}
+	if shift.StartTime.Before(now) {
+		return ErrCarpoolShiftStarted
+	}
+	return nil
+}
+
+// MyPost returns the user's post for a shift, if any
+func (cs *CarpoolService) MyPost(shiftID, userID uint) (*models.CarpoolPost, error) {
+	var post models.CarpoolPost
+	if err := cs.db.Where("shift_id = ? AND user_id = ?", shiftID, userID).First(&post).Error; err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// SavePost creates or updates the user's lift offer or request for a shift. The
+// postcode defaults to the one on their profile.
+func (cs *CarpoolService) SavePost(shiftID, userID uint, kind, postcode string, seats int, notes string, acceptDisclaimer bool, now time.Time) (*models.CarpoolPost, error) {
+	if !acceptDisclaimer {
+		return nil, ErrCarpoolDisclaimer
+	}
+	if kind != models.CarpoolOffer && kind != models.CarpoolNeed {
+		return nil, ErrCarpoolInvalidKind
+	}
+	if seats == 0 {
+		seats = 1
+	}
+	if seats < 1 || seats > CarpoolMaxSeats {
+		return nil, ErrCarpoolInvalidSeats
+	}
+	notes = strings.TrimSpace(notes)
+	if len(notes) > CarpoolMaxNotesLength {
+		return nil, ErrCarpoolNotesTooLong
+	}
+	if err := cs.checkShift(shiftID, userID, now); err != nil {
+		return nil, err
+	}
+
+	if postcode == "" {
+		var user models.User
+		if err := cs.db.Select("id", "postcode").First(&user, userID).Error; err != nil {
+			return nil, err
+		}
+		postcode = user.Postcode
+	}
+	normalized, ok := normalizeCarpoolPostcode(postcode)
+	if !ok {
+		return nil, ErrCarpoolNoPostcode
+	}
+
+	post, err := cs.MyPost(shiftID, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if post == nil {
+		post = &models.CarpoolPost{ShiftID: shiftID, UserID: userID}
+	} else if post.Kind != kind && cs.liveMatchCount(post) > 0 {
+		return nil, ErrCarpoolHasMatches
+	}
+
+	post.Kind = kind
+	post.Postcode = normalized
+	post.Area = postcodeDistrict(normalized)
+	post.Seats = seats
+	post.Notes = notes
+	post.DisclaimerVersion = models.CarpoolDisclaimerVersion
+	post.DisclaimerAcceptedAt = now
+	if post.Status != models.CarpoolPostMatched {
+		post.Status = models.CarpoolPostOpen
+	}
+	if err := cs.db.Save(post).Error; err != nil {
+		return nil, err
+	}
+	cs.refreshPostStatus(cs.db, post)
+	return post, nil
+}
+
+// liveMatchCount counts proposed and accepted matches involving a post
+func (cs *CarpoolService) liveMatchCount(post *models.CarpoolPost) int64 {
+	var count int64
+	cs.db.Model(&models.CarpoolMatch{}).
+		Where("(offer_post_id = ? OR need_post_id = ?) AND status IN ?", post.ID, post.ID,
+			[]string{models.CarpoolMatchProposed, models.CarpoolMatchAccepted}).
+		Count(&count)
+	return count
+}
+
+// seatsTaken counts riders accepted onto an offer
+func (cs *CarpoolService) seatsTaken(tx *gorm.DB, offerPostID uint) int {
+	var seats int
+	tx.Model(&models.CarpoolMatch{}).
+		Joins("JOIN carpool_posts ON carpool_posts.id = carpool_matches.need_post_id").
+		Where("carpool_matches.offer_post_id = ? AND carpool_matches.status = ?", offerPostID, models.CarpoolMatchAccepted).
+		Select("COALESCE(SUM(carpool_posts.seats), 0)").
+		Scan(&seats)
+	return seats
+}
+
+// refreshPostStatus marks a post matched while it has a full set of accepted matches,
+// and open again when it does not
+func (cs *CarpoolService) refreshPostStatus(tx *gorm.DB, post *models.CarpoolPost) {
+	if post.Status == models.CarpoolPostWithdrawn {
+		return
+	}
+
+	var full bool
+	if post.Kind == models.CarpoolOffer {
+		full = cs.seatsTaken(tx, post.ID) >= post.Seats
+	} else {
+		var accepted int64
+		tx.Model(&models.CarpoolMatch{}).
+			Where("need_post_id = ? AND status = ?", post.ID, models.CarpoolMatchAccepted).
+			Count(&accepted)
+		full = accepted > 0
+	}
+
+	status := models.CarpoolPostOpen
+	if full {
+		status = models.CarpoolPostMatched
+	}
+	if status != post.Status {
+		post.Status = status
+		tx.Model(post).UpdateColumn("status", status)
+	}
+}
+
+// Withdraw takes the user's post down and cancels its matches
+func (cs *CarpoolService) Withdraw(post *models.CarpoolPost) error {
+	var matches []models.CarpoolMatch
+	cs.db.Where("(offer_post_id = ? OR need_post_id = ?) AND status IN ?", post.ID, post.ID,
+		[]string{models.CarpoolMatchProposed, models.CarpoolMatchAccepted}).
+		Find(&matches)
+
+	for i := range matches {
+		if err := cs.closeMatch(&matches[i], models.CarpoolMatchCancelled, post.UserID, ""); err != nil {
+			return err
+		}
+	}
+
+	post.Status = models.CarpoolPostWithdrawn
+	return cs.db.Model(post).UpdateColumn("status", post.Status).Error
+}
+
+// Candidates returns open posts of the opposite kind on the same shift, closest first
+func (cs *CarpoolService) Candidates(post *models.CarpoolPost) ([]CarpoolCandidate, error) {
+	kind := models.CarpoolOffer
+	if post.Kind == models.CarpoolOffer {
+		kind = models.CarpoolNeed
+	}
+
+	var others []models.CarpoolPost
+	if err := cs.db.Where("shift_id = ? AND user_id <> ? AND kind = ? AND status = ?",
+		post.ShiftID, post.UserID, kind, models.CarpoolPostOpen).
+		Find(&others).Error; err != nil {
+		return nil, err
+	}
+
+	// Leave out volunteers already matched with this post
+	var linked []uint
+	cs.db.Model(&models.CarpoolMatch{}).
+		Where("(offer_post_id = ? OR need_post_id = ?) AND status IN ?", post.ID, post.ID,
+			[]string{models.CarpoolMatchProposed, models.CarpoolMatchAccepted}).
+		Select("CASE WHEN offer_post_id = ? THEN need_post_id ELSE offer_post_id END", post.ID).
+		Scan(&linked)
+	skip := make(map[uint]bool, len(linked))
+	for _, id := range linked {
+		skip[id] = true
+	}
+
+	candidates := make([]CarpoolCandidate, 0, len(others))
+	for _, other := range others {
+		if skip[other.ID] {
+			continue
+		}
+		candidates = append(candidates, CarpoolCandidate{
+			PostID:    other.ID,
+			Kind:      other.Kind,
+			Area:      other.Area,
+			Proximity: CarpoolProximity(post.Postcode, other.Postcode),
+			Seats:     other.Seats,
+			Notes:     other.Notes,
+		})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return carpoolProximityRank[candidates[i].Proximity] < carpoolProximityRank[candidates[j].Proximity]
+	})
+	return candidates, nil
+}
+
+// Propose asks the volunteer behind another post to share a lift. The proposer's
+// acceptance is recorded straight away.
+func (cs *CarpoolService) Propose(myPost *models.CarpoolPost, otherPostID uint, now time.Time) (*models.CarpoolMatch, error) {
+	if myPost.Status == models.CarpoolPostWithdrawn {
+		return nil, ErrCarpoolNoPost
+	}
+	if err := cs.checkShift(myPost.ShiftID, myPost.UserID, now); err != nil {
+		return nil, err
+	}
+
+	var other models.CarpoolPost
+	if err := cs.db.Where("id = ? AND shift_id = ?", otherPostID, myPost.ShiftID).First(&other).Error; err != nil {
+		return nil, err
+	}
+	if other.UserID == myPost.UserID || other.Kind == myPost.Kind {
+		return nil, ErrCarpoolSameKind
+	}
+	if other.Status != models.CarpoolPostOpen {
+		return nil, ErrCarpoolPostClosed
+	}
+
+	offer, need := myPost, &other
+	if myPost.Kind == models.CarpoolNeed {
+		offer, need = &other, myPost
+	}
+	if cs.seatsTaken(cs.db, offer.ID)+need.Seats > offer.Seats {
+		return nil, ErrCarpoolNoSeats
+	}
+
+	var existing int64
+	cs.db.Model(&models.CarpoolMatch{}).
+		Where("offer_post_id = ? AND need_post_id = ? AND status IN ?", offer.ID, need.ID,
+			[]string{models.CarpoolMatchProposed, models.CarpoolMatchAccepted}).
+		Count(&existing)
+	if existing > 0 {
+		return nil, ErrCarpoolAlreadyProposed
+	}
+
+	match := models.CarpoolMatch{
+		ShiftID:     myPost.ShiftID,
+		OfferPostID: offer.ID,
+		NeedPostID:  need.ID,
+		DriverID:    offer.UserID,
+		RiderID:     need.UserID,
+		ProposedBy:  myPost.UserID,
+		Proximity:   CarpoolProximity(offer.Postcode, need.Postcode),
+		Status:      models.CarpoolMatchProposed,
+	}
+	if myPost.Kind == models.CarpoolOffer {
+		match.DriverAcceptedAt = &now
+	} else {
+		match.RiderAcceptedAt = &now
+	}
+	if err := cs.db.Create(&match).Error; err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("A volunteer from %s would like to share a lift to your shift. Accept to swap contact details.", myPost.Area)
+	cs.notify(other.UserID, "Lift sharing request", message, match.ShiftID)
+	return &match, nil
+}
+
+// Accept records the user's acceptance. Once both have accepted the match is confirmed
+// and contact details are shared.
+func (cs *CarpoolService) Accept(match *models.CarpoolMatch, userID uint, now time.Time) error {
+	if !match.IsParty(userID) {
+		return ErrCarpoolNotParty
+	}
+	if match.Status != models.CarpoolMatchProposed {
+		return ErrCarpoolMatchClosed
+	}
+
+	err := cs.db.Transaction(func(tx *gorm.DB) error {
+		var offer, need models.CarpoolPost
+		if err := tx.First(&offer, match.OfferPostID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&need, match.NeedPostID).Error; err != nil {
+			return err
+		}
+		if offer.Status == models.CarpoolPostWithdrawn || need.Status == models.CarpoolPostWithdrawn {
+			return ErrCarpoolPostClosed
+		}
+
+		if userID == match.DriverID {
+			match.DriverAcceptedAt = &now
+		} else {
+			match.RiderAcceptedAt = &now
+		}
+		if match.DriverAcceptedAt != nil && match.RiderAcceptedAt != nil {
+			if cs.seatsTaken(tx, offer.ID)+need.Seats > offer.Seats {
+				return ErrCarpoolNoSeats
+			}
+			match.Status = models.CarpoolMatchAccepted
+		}
+		if err := tx.Save(match).Error; err != nil {
+			return err
+		}
+
+		cs.refreshPostStatus(tx, &offer)
+		cs.refreshPostStatus(tx, &need)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if match.Status == models.CarpoolMatchAccepted {
+		message := "Your lift share is confirmed. You can now see each other's contact details on the shift's lift sharing page."
+		cs.notify(match.DriverID, "Lift share confirmed", message, match.ShiftID)
+		cs.notify(match.RiderID, "Lift share confirmed", message, match.ShiftID)
+	}
+	return nil
+}
+
+// Decline turns down a proposed match, or cancels an accepted one
+func (cs *CarpoolService) Decline(match *models.CarpoolMatch, userID uint) error {
+	if !match.IsParty(userID) {
+		return ErrCarpoolNotParty
+	}
+	if !match.IsLive() {
+		return ErrCarpoolMatchClosed
+	}
+
+	status := models.CarpoolMatchCancelled
+	if match.Status == models.CarpoolMatchProposed && match.ProposedBy != userID {
+		status = models.CarpoolMatchDeclined
+	}
+	return cs.closeMatch(match, status, userID, "")
+}
+
+// CoordinatorCancel lets a coordinator stop a match, e.g. after a safety concern
+func (cs *CarpoolService) CoordinatorCancel(match *models.CarpoolMatch, coordinatorID uint, note string) error {
+	if !match.IsLive() {
+		return ErrCarpoolMatchClosed
+	}
+	return cs.closeMatch(match, models.CarpoolMatchCancelled, coordinatorID, note)
+}
+
+// closeMatch ends a match, reopens its posts and tells the volunteers affected
+func (cs *CarpoolService) closeMatch(match *models.CarpoolMatch, status string, byUserID uint, note string) error {
+	wasAccepted := match.Status == models.CarpoolMatchAccepted
+	match.Status = status
+	match.CancelledBy = &byUserID
+	if note != "" {
+		match.CoordinatorNote = note
+	}
+	if err := cs.db.Save(match).Error; err != nil {
+		return err
+	}
+
+	for _, postID := range []uint{match.OfferPostID, match.NeedPostID} {
+		var post models.CarpoolPost
+		if err := cs.db.First(&post, postID).Error; err == nil {
+			cs.refreshPostStatus(cs.db, &post)
+		}
+	}
+
+	title, message := "Lift share request declined", "Your lift sharing request was declined. You can look for another lift on the shift page."
+	if status == models.CarpoolMatchCancelled {
+		title, message = "Lift share cancelled", "A lift share for your shift has been cancelled. You can look for another lift on the shift page."
+	}
+	for _, userID := range []uint{match.DriverID, match.RiderID} {
+		if userID == byUserID {
+			continue
+		}
+		if status == models.CarpoolMatchDeclined || wasAccepted || match.ProposedBy == userID || note != "" {
+			cs.notify(userID, title, message, match.ShiftID)
+		}
+	}
+	return nil
+}
+
+// notify sends a lift sharing notice to a volunteer
+func (cs *CarpoolService) notify(userID uint, title, message string, shiftID uint) {
+	notification := models.InAppNotification{
+		UserID:    userID,
+		Title:     title,
+		Message:   message,
+		Type:      "info",
+		Priority:  "normal",
+		ActionURL: fmt.Sprintf("/volunteer/shifts/%d/carpool", shiftID),
+	}
+	if err := cs.db.Create(&notification).Error; err != nil {
+		log.Printf("Failed to send carpool notice to user %d: %v", userID, err)
+	}
+}
+
+// MatchesFor returns the user's matches on a shift, with contact details for accepted
+// ones
+func (cs *CarpoolService) MatchesFor(shiftID, userID uint) ([]CarpoolMatchView, error) {
+	var matches []models.CarpoolMatch
+	if err := cs.db.Where("shift_id = ? AND (driver_id = ? OR rider_id = ?)", shiftID, userID, userID).
+		Order("created_at DESC").
+		Find(&matches).Error; err != nil {
+		return nil, err
+	}
+
+	views := make([]CarpoolMatchView, 0, len(matches))
+	for _, match := range matches {
+		view := CarpoolMatchView{CarpoolMatch: match, Role: "rider"}
+		otherUserID, otherPostID := match.DriverID, match.OfferPostID
+		if match.DriverID == userID {
+			view.Role = "driver"
+			otherUserID, otherPostID = match.RiderID, match.NeedPostID
+			view.AwaitingMe = match.Status == models.CarpoolMatchProposed && match.DriverAcceptedAt == nil
+		} else {
+			view.AwaitingMe = match.Status == models.CarpoolMatchProposed && match.RiderAcceptedAt == nil
+		}
+
+		var otherPost models.CarpoolPost
+		cs.db.First(&otherPost, otherPostID)
+		view.OtherArea = otherPost.Area
+
+		if match.Status == models.CarpoolMatchAccepted {
+			var other models.User
+			if err := cs.db.Select("id", "first_name", "last_name", "phone", "email").First(&other, otherUserID).Error; err == nil {
+				view.OtherContact = &CarpoolContact{
+					FirstName: other.FirstName,
+					LastName:  other.LastName,
+					Phone:     other.Phone,
+					Email:     other.Email,
+					Postcode:  otherPost.Postcode,
+				}
+			}
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// ShiftOverview returns every post and match on a shift for coordinators
+func (cs *CarpoolService) ShiftOverview(shiftID uint) ([]CarpoolCoordinatorPost, []models.CarpoolMatch, error) {
+	var posts []models.CarpoolPost
+	if err := cs.db.Where("shift_id = ?", shiftID).Order("kind ASC, area ASC").Find(&posts).Error; err != nil {
+		return nil, nil, err
+	}
+
+	userIDs := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		userIDs = append(userIDs, post.UserID)
+	}
+	var users []models.User
+	cs.db.Select("id", "first_name", "last_name", "phone").Where("id IN ?", userIDs).Find(&users)
+	byID := make(map[uint]models.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	overview := make([]CarpoolCoordinatorPost, 0, len(posts))
+	for _, post := range posts {
+		user := byID[post.UserID]
+		overview = append(overview, CarpoolCoordinatorPost{
+			CarpoolPost: post,
+			Postcode:    post.Postcode,
+			FirstName:   user.FirstName,
+			LastName:    user.LastName,
+			Phone:       user.Phone,
+		})
+	}
+
+	var matches []models.CarpoolMatch
+	if err := cs.db.Where("shift_id = ?", shiftID).Order("created_at DESC").Find(&matches).Error; err != nil {
+		return nil, nil, err
+	}
+	return overview, matches, nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestCarpoolProximity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"SE13 6AB", "se136xy", CarpoolSameSector},
+		{"SE13 6AB", "SE13 7LN", CarpoolSameDistrict},
+		{"SE13 6AB", "SE15 2QT", CarpoolNearbyDistrict},
+		{"SW1A 1AA", "SW2 5RS", CarpoolNearbyDistrict}, // Sub-district letters are ignored
+		{"SE1 7PB", "SE13 6AB", CarpoolSameArea},
+		{"SE13 6AB", "E13 6AB", CarpoolFarther},
+		{"", "SE13 6AB", CarpoolFarther},
+	}
+	for _, tt := range tests {
+		if got := CarpoolProximity(tt.a, tt.b); got != tt.want {
+			t.Errorf("CarpoolProximity(%q, %q) = %s, want %s", tt.a, tt.b, got, tt.want)
+		}
+		if got := CarpoolProximity(tt.b, tt.a); got != tt.want {
+			t.Errorf("CarpoolProximity(%q, %q) = %s, want %s", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeCarpoolPostcode(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{" se13 6ab ", "SE13 6AB", true},
+		{"sw1a1aa", "SW1A 1AA", true},
+		{"E1 6AN", "E1 6AN", true},
+		{"SE13", "", false},       // District only
+		{"SE13 ABC", "", false},   // Inward code must start with a digit
+		{"123 4AB", "", false},    // No area letters
+		{"SE13 6AB 1", "", false}, // Too long
+	}
+	for _, tt := range tests {
+		got, ok := normalizeCarpoolPostcode(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("normalizeCarpoolPostcode(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+	if got := postcodeSector("se13 6ab"); got != "SE13 6" {
+		t.Errorf("postcodeSector = %q, want SE13 6", got)
+	}
+}
+
+func TestSavePostValidation(t *testing.T) {
+	cs := &CarpoolService{}
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		kind       string
+		seats      int
+		notes      string
+		disclaimer bool
+		want       error
+	}{
+		{"disclaimer not accepted", models.CarpoolOffer, 2, "", false, ErrCarpoolDisclaimer},
+		{"unknown kind", "taxi", 2, "", true, ErrCarpoolInvalidKind},
+		{"too many seats", models.CarpoolOffer, CarpoolMaxSeats + 1, "", true, ErrCarpoolInvalidSeats},
+		{"negative seats", models.CarpoolNeed, -1, "", true, ErrCarpoolInvalidSeats},
+		{"long notes", models.CarpoolOffer, 1, strings.Repeat("a", CarpoolMaxNotesLength+1), true, ErrCarpoolNotesTooLong},
+	}
+	for _, tt := range tests {
+		if _, err := cs.SavePost(1, 2, tt.kind, "SE13 6AB", tt.seats, tt.notes, tt.disclaimer, now); err != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestCarpoolMatchParties(t *testing.T) {
+	match := models.CarpoolMatch{DriverID: 3, RiderID: 4, Status: models.CarpoolMatchProposed}
+	if !match.IsParty(3) || !match.IsParty(4) || match.IsParty(5) {
+		t.Error("only the driver and rider are parties to a match")
+	}
+	for status, live := range map[string]bool{
+		models.CarpoolMatchProposed:  true,
+		models.CarpoolMatchAccepted:  true,
+		models.CarpoolMatchDeclined:  false,
+		models.CarpoolMatchCancelled: false,
+	} {
+		match.Status = status
+		if match.IsLive() != live {
+			t.Errorf("%s match live = %v", status, !live)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrContactUserNotFound        = errors.New("user not found")
+	ErrContactRestrictionNotFound = errors.New("user has no contact restrictions")
+	ErrContactRestrictionInvalid  = errors.New("invalid contact restriction")
+)
+
+// ContactRestrictionInput sets the flags on an account. At least one flag must be
+// set, with a reason and a date to review the flags by.
+type ContactRestrictionInput struct {
+	DoNotCall       bool       `json:"do_not_call"`
+	DoNotSMS        bool       `json:"do_not_sms"`
+	VulnerableAdult bool       `json:"vulnerable_adult"`
+	Reason          string     `json:"reason"`
+	ReviewDate      *time.Time `json:"review_date"`
+}
+
+// ContactCheck tells staff whether they may contact someone on a channel
+type ContactCheck struct {
+	UserID          uint       `json:"user_id"`
+	Channel         string     `json:"channel"`
+	Allowed         bool       `json:"allowed"`
+	Reason          string     `json:"reason,omitempty"` // Why contact is suppressed
+	VulnerableAdult bool       `json:"vulnerable_adult"`
+	ReviewDate      *time.Time `json:"review_date,omitempty"`
+}
+
+// ContactSuppressionFilter narrows the suppressed contact log
+type ContactSuppressionFilter struct {
+	UserID  uint
+	Channel string
+	Source  string
+	From    *time.Time
+	To      *time.Time
+	Limit   int
+}
+
+// ContactRestrictionService manages do-not-contact and vulnerable adult flags and
+// the log of contact attempts they suppressed
+type ContactRestrictionService struct {
+	db *gorm.DB
+}
+
+// NewContactRestrictionService creates a new contact restriction service
+func NewContactRestrictionService() *ContactRestrictionService {
+	return &ContactRestrictionService{db: db.DB}
+}
+
+// Get returns a user's contact restrictions
+func (rs *ContactRestrictionService) Get(userID uint) (*models.ContactRestriction, error) {
+	var restriction models.ContactRestriction
+	if err := rs.db.Where("user_id = ?", userID).First(&restriction).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrContactRestrictionNotFound
+		}
+		return nil, err
+	}
+	return &restriction, nil
+}
+
+// Set replaces the flags on a user's account
+func (rs *ContactRestrictionService) Set(userID uint, input ContactRestrictionInput, setBy uint) (*models.ContactRestriction, error) {
+	input.Reason = strings.TrimSpace(input.Reason)
+	if err := validateContactRestriction(input, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var restriction models.ContactRestriction
+	err := rs.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Select("id").First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrContactUserNotFound
+			}
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&restriction).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			restriction = models.ContactRestriction{UserID: userID}
+		}
+		restriction.DoNotCall = input.DoNotCall
+		restriction.DoNotSMS = input.DoNotSMS
+		restriction.VulnerableAdult = input.VulnerableAdult
+		restriction.Reason = input.Reason
+		restriction.ReviewDate = input.ReviewDate
+		restriction.SetBy = setBy
+		return tx.Save(&restriction).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &restriction, nil
+}
+
+// Clear removes every flag from a user's account
+func (rs *ContactRestrictionService) Clear(userID uint) (*models.ContactRestriction, error) {
+	restriction, err := rs.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := rs.db.Delete(restriction).Error; err != nil {
+		return nil, err
+	}
+	return restriction, nil
+}
+
+// List returns flagged accounts, soonest review first. With dueOnly, only accounts
+// whose review date has passed are returned.
+func (rs *ContactRestrictionService) List(dueOnly bool, now time.Time) ([]models.ContactRestriction, error) {
+	query := rs.db.Preload("User")
+	if dueOnly {
+		query = query.Where("review_date <= ?", now)
+	}
+	var restrictions []models.ContactRestriction
+	err := query.Order("review_date ASC, id ASC").Find(&restrictions).Error
+	return restrictions, err
+}
+
+// CheckContact tells a member of staff whether they may contact someone on a channel.
+// Blocked attempts are recorded in the suppression log.
+func (rs *ContactRestrictionService) CheckContact(userID uint, channel string, staffID uint) (*ContactCheck, error) {
+	if channel != models.ContactChannelCall && channel != models.NotificationTypeSMS && channel != models.NotificationTypeEmail {
+		return nil, fmt.Errorf("%w: channel must be call, sms or email", ErrContactRestrictionInvalid)
+	}
+
+	var user models.User
+	if err := rs.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrContactUserNotFound
+		}
+		return nil, err
+	}
+
+	check := &ContactCheck{UserID: userID, Channel: channel, Allowed: true}
+	restriction, err := rs.Get(userID)
+	if errors.Is(err, ErrContactRestrictionNotFound) {
+		return check, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	check.VulnerableAdult = restriction.VulnerableAdult
+	check.ReviewDate = restriction.ReviewDate
+	if check.Reason = restriction.SuppressionReason(channel, false); check.Reason == "" {
+		return check, nil
+	}
+	check.Allowed = false
+
+	recipient := models.MaskPhone(user.Phone)
+	if channel == models.NotificationTypeEmail {
+		recipient = models.MaskEmail(user.Email)
+	}
+	notifications.RecordSuppression(&models.ContactSuppressionLog{
+		UserID:      userID,
+		Channel:     channel,
+		Source:      models.SuppressionSourceStaff,
+		Reason:      check.Reason,
+		Recipient:   recipient,
+		AttemptedBy: &staffID,
+	})
+	return check, nil
+}
+
+// Suppressions returns suppressed contact attempts, newest first
+func (rs *ContactRestrictionService) Suppressions(filter ContactSuppressionFilter) ([]models.ContactSuppressionLog, error) {
+	query := rs.db.Model(&models.ContactSuppressionLog{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Channel != "" {
+		query = query.Where("channel = ?", filter.Channel)
+	}
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.Limit <= 0 || filter.Limit > 1000 {
+		filter.Limit = 200
+	}
+
+	var entries []models.ContactSuppressionLog
+	err := query.Order("created_at DESC, id DESC").Limit(filter.Limit).Find(&entries).Error
+	return entries, err
+}
+
+// validateContactRestriction checks flags are only set with a reason and a future
+// review date
+func validateContactRestriction(input ContactRestrictionInput, now time.Time) error {
+	if !input.DoNotCall && !input.DoNotSMS && !input.VulnerableAdult {
+		return fmt.Errorf("%w: set at least one flag, or clear the restrictions instead", ErrContactRestrictionInvalid)
+	}
+	if strings.TrimSpace(input.Reason) == "" {
+		return fmt.Errorf("%w: a reason is required", ErrContactRestrictionInvalid)
+	}
+	if input.ReviewDate == nil {
+		return fmt.Errorf("%w: a review date is required", ErrContactRestrictionInvalid)
+	}
+	if !input.ReviewDate.After(now) {
+		return fmt.Errorf("%w: review date must be in the future", ErrContactRestrictionInvalid)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidateContactRestriction(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	future := now.AddDate(0, 6, 0)
+	past := now.AddDate(0, 0, -1)
+
+	tests := []struct {
+		name  string
+		input ContactRestrictionInput
+		valid bool
+	}{
+		{"flag with reason and review", ContactRestrictionInput{DoNotSMS: true, Reason: "Asked on the phone", ReviewDate: &future}, true},
+		{"no flags", ContactRestrictionInput{Reason: "Asked on the phone", ReviewDate: &future}, false},
+		{"missing reason", ContactRestrictionInput{DoNotCall: true, Reason: "  ", ReviewDate: &future}, false},
+		{"missing review date", ContactRestrictionInput{VulnerableAdult: true, Reason: "Safeguarding referral"}, false},
+		{"review date passed", ContactRestrictionInput{VulnerableAdult: true, Reason: "Safeguarding referral", ReviewDate: &past}, false},
+	}
+	for _, tt := range tests {
+		err := validateContactRestriction(tt.input, now)
+		if tt.valid && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrContactRestrictionInvalid) {
+			t.Errorf("%s: expected ErrContactRestrictionInvalid, got %v", tt.name, err)
+		}
+	}
+}
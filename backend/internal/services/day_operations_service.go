@@ -0,0 +1,587 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/websocket"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dayOperationStaleAfter is how long a run can stay running before it is assumed to
+// have been interrupted, e.g. by a restart, and may be started again
+const dayOperationStaleAfter = 30 * time.Minute
+
+var (
+	ErrDayOperationUnknown   = errors.New("unknown day operation")
+	ErrDayOperationRunning   = errors.New("this day operation is already running")
+	ErrDayOperationCompleted = errors.New("this day operation has already completed")
+	ErrDayOperationFuture    = errors.New("day operations cannot be run for future days")
+	ErrDayOperationNotFound  = errors.New("day operation run not found")
+)
+
+// dayOperationStep is one step of the open or close runbook. It returns a description
+// of what it did.
+type dayOperationStep struct {
+	key  string
+	name string
+	run  func(day time.Time, runBy uint) (string, error)
+}
+
+// DayOperationsService runs the daily open and close runbooks as jobs with step-level
+// status
+type DayOperationsService struct {
+	db *gorm.DB
+}
+
+// NewDayOperationsService creates a new day operations service
+func NewDayOperationsService() *DayOperationsService {
+	return &DayOperationsService{db: db.DB}
+}
+
+// steps returns the runbook for an operation, in order
+func (ds *DayOperationsService) steps(operation string) []dayOperationStep {
+	switch operation {
+	case models.DayOperationOpen:
+		return []dayOperationStep{
+			{"activate_queue", "Activate the queue", ds.activateQueue},
+			{"publish_capacity", "Publish today's capacity", ds.publishCapacity},
+			{"notify_volunteers", "Notify rota'd volunteers", ds.notifyVolunteers},
+		}
+	case models.DayOperationClose:
+		return []dayOperationStep{
+			{"close_queue", "Close the queue", ds.closeQueue},
+			{"mark_no_shows", "Mark unexcused no-shows", ds.markNoShows},
+			{"finalize_visits", "Finalise visit records", ds.finalizeVisits},
+			{"stats_rollup", "Roll up the day's statistics", ds.rollup},
+			{"email_summary", "Email the day summary to managers", ds.emailSummary},
+		}
+	}
+	return nil
+}
+
+// Start begins an operation for a day and runs its steps in the background. Starting a
+// run that failed retries the steps that did not complete.
+func (ds *DayOperationsService) Start(operation string, day time.Time, startedBy uint) (*models.DayOperationRun, error) {
+	steps := ds.steps(operation)
+	if steps == nil {
+		return nil, ErrDayOperationUnknown
+	}
+	today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	if day.After(today) {
+		return nil, ErrDayOperationFuture
+	}
+
+	var run models.DayOperationRun
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("operation = ? AND day = ?", operation, day).
+			First(&run).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			run = models.DayOperationRun{
+				Operation: operation,
+				Day:       day,
+				Status:    models.DayOperationRunning,
+				StartedBy: startedBy,
+				StartedAt: time.Now(),
+				Attempts:  1,
+			}
+			for i, step := range steps {
+				run.Steps = append(run.Steps, models.DayOperationStep{
+					Position: i + 1,
+					Key:      step.key,
+					Name:     step.name,
+					Status:   models.DayOperationPending,
+				})
+			}
+			return tx.Create(&run).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := checkDayOperationRestart(&run, time.Now()); err != nil {
+			return err
+		}
+
+		// Retry everything that did not complete
+		if err := tx.Model(&models.DayOperationStep{}).
+			Where("run_id = ? AND status <> ?", run.ID, models.DayOperationCompleted).
+			Updates(map[string]interface{}{"status": models.DayOperationPending, "error": ""}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&run).Updates(map[string]interface{}{
+			"status":      models.DayOperationRunning,
+			"started_by":  startedBy,
+			"started_at":  time.Now(),
+			"finished_at": nil,
+			"attempts":    gorm.Expr("attempts + 1"),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go ds.execute(run.ID, operation, day, startedBy)
+	return ds.Get(run.ID)
+}
+
+// checkDayOperationRestart reports why an existing run cannot be started again. A run
+// still marked running is only restarted once it has gone stale.
+func checkDayOperationRestart(run *models.DayOperationRun, now time.Time) error {
+	switch {
+	case run.Status == models.DayOperationCompleted:
+		return ErrDayOperationCompleted
+	case run.Status == models.DayOperationRunning && now.Sub(run.UpdatedAt) < dayOperationStaleAfter:
+		return ErrDayOperationRunning
+	}
+	return nil
+}
+
+// Get returns a run with its steps
+func (ds *DayOperationsService) Get(id uint) (*models.DayOperationRun, error) {
+	var run models.DayOperationRun
+	err := ds.db.Preload("Steps", func(tx *gorm.DB) *gorm.DB { return tx.Order("position ASC") }).
+		First(&run, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDayOperationNotFound
+	}
+	return &run, err
+}
+
+// List returns the runs between two days, newest first
+func (ds *DayOperationsService) List(from, to time.Time) ([]models.DayOperationRun, error) {
+	var runs []models.DayOperationRun
+	err := ds.db.Preload("Steps", func(tx *gorm.DB) *gorm.DB { return tx.Order("position ASC") }).
+		Where("day BETWEEN ? AND ?", from, to).
+		Order("day DESC, operation ASC").
+		Find(&runs).Error
+	return runs, err
+}
+
+// execute runs each step that has not completed, recording its status as it goes.
+// Later steps still run after a failure so one problem does not hold up the rest.
+func (ds *DayOperationsService) execute(runID uint, operation string, day time.Time, runBy uint) {
+	failed := false
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Day operation run %d panicked: %v", runID, r)
+			failed = true
+		}
+		status := models.DayOperationCompleted
+		if failed {
+			status = models.DayOperationFailed
+		}
+		now := time.Now()
+		ds.db.Model(&models.DayOperationRun{}).Where("id = ?", runID).
+			Updates(map[string]interface{}{"status": status, "finished_at": &now})
+	}()
+
+	var records []models.DayOperationStep
+	if err := ds.db.Where("run_id = ?", runID).Order("position ASC").Find(&records).Error; err != nil {
+		log.Printf("Failed to load steps for day operation run %d: %v", runID, err)
+		failed = true
+		return
+	}
+	runners := map[string]dayOperationStep{}
+	for _, step := range ds.steps(operation) {
+		runners[step.key] = step
+	}
+
+	for _, record := range records {
+		if record.Status == models.DayOperationCompleted {
+			continue
+		}
+		step, ok := runners[record.Key]
+		if !ok {
+			continue
+		}
+
+		started := time.Now()
+		ds.db.Model(&record).Updates(map[string]interface{}{"status": models.DayOperationRunning, "started_at": &started})
+		ds.db.Model(&models.DayOperationRun{}).Where("id = ?", runID).Update("updated_at", started)
+
+		detail, err := step.run(day, runBy)
+		finished := time.Now()
+		updates := map[string]interface{}{
+			"status":      models.DayOperationCompleted,
+			"detail":      detail,
+			"error":       "",
+			"finished_at": &finished,
+		}
+		if err != nil {
+			log.Printf("Day operation %s step %s failed for %s: %v", operation, record.Key, day.Format("2006-01-02"), err)
+			updates["status"] = models.DayOperationFailed
+			updates["error"] = err.Error()
+			failed = true
+		}
+		ds.db.Model(&record).Updates(updates)
+	}
+}
+
+// dayRange returns the start of a day and the start of the next
+func dayRange(day time.Time) (time.Time, time.Time) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// broadcastQueue tells queue screens and staff about a change to the day
+func broadcastQueue(message map[string]interface{}) {
+	message["timestamp"] = time.Now().Unix()
+	if err := websocket.GetGlobalManager().BroadcastToTopic("queue_updates", message); err != nil {
+		log.Printf("Failed to broadcast queue update: %v", err)
+	}
+}
+
+// activateQueue opens every queue category and clears entries left over from earlier days
+func (ds *DayOperationsService) activateQueue(day time.Time, runBy uint) (string, error) {
+	start, _ := dayRange(day)
+	if err := ds.db.Model(&models.QueueSettings{}).Where("is_active = ?", false).
+		Update("is_active", true).Error; err != nil {
+		return "", err
+	}
+	var categories int64
+	ds.db.Model(&models.QueueSettings{}).Where("is_active = ?", true).Count(&categories)
+
+	now := time.Now()
+	stale := ds.db.Model(&models.QueueEntry{}).
+		Where("status IN ? AND joined_at < ?", []string{"waiting", "called", "recall"}, start).
+		Updates(map[string]interface{}{"status": "cancelled", "cancelled_at": now})
+	if stale.Error != nil {
+		return "", stale.Error
+	}
+
+	broadcastQueue(map[string]interface{}{"type": "queue_opened", "day": day.Format("2006-01-02")})
+	return fmt.Sprintf("%d queue categories active, %d stale entries cleared", categories, stale.RowsAffected), nil
+}
+
+// configInt reads an integer system setting
+func (ds *DayOperationsService) configInt(key string, fallback int) int {
+	var config models.SystemConfig
+	if err := ds.db.Where("key = ?", key).First(&config).Error; err == nil {
+		if value, err := strconv.Atoi(config.Value); err == nil && value > 0 {
+			return value
+		}
+	}
+	return fallback
+}
+
+// publishCapacity makes sure the day has a capacity record and announces its places
+func (ds *DayOperationsService) publishCapacity(day time.Time, runBy uint) (string, error) {
+	var capacity models.VisitCapacity
+	if err := ds.db.Where("date = ?", day).
+		Attrs(models.VisitCapacity{
+			Date:             day,
+			DayOfWeek:        day.Format("Monday"),
+			MaxFoodVisits:    ds.configInt(models.ConfigMaxDailyFoodVisits, 50),
+			MaxGeneralVisits: ds.configInt(models.ConfigMaxDailyGeneralVisits, 20),
+			IsOperatingDay:   true,
+		}).
+		FirstOrCreate(&capacity).Error; err != nil {
+		return "", err
+	}
+	if !capacity.IsOperatingDay {
+		return "", fmt.Errorf("%s is marked as not operating", day.Format("2006-01-02"))
+	}
+
+	food := capacity.MaxFoodVisits - capacity.CurrentFoodVisits
+	general := capacity.MaxGeneralVisits - capacity.CurrentGeneralVisits
+	broadcastQueue(map[string]interface{}{
+		"type":           "capacity_published",
+		"day":            day.Format("2006-01-02"),
+		"food_places":    food,
+		"general_places": general,
+	})
+	return fmt.Sprintf("Food: %d of %d places left, General: %d of %d places left",
+		food, capacity.MaxFoodVisits, general, capacity.MaxGeneralVisits), nil
+}
+
+// rotaAssignment is a volunteer committed to a shift on the day
+type rotaAssignment struct {
+	AssignmentID uint
+	UserID       uint
+	StartTime    time.Time
+	Location     string
+	Role         string
+}
+
+// rota returns the day's committed assignments, leaving out volunteers who are away
+func (ds *DayOperationsService) rota(day time.Time) ([]rotaAssignment, error) {
+	var assignments []rotaAssignment
+	err := ds.db.Model(&models.ShiftAssignment{}).
+		Select("shift_assignments.id AS assignment_id, shift_assignments.user_id, shifts.start_time, shifts.location, shifts.role").
+		Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id").
+		Where("shifts.date::date = ?::date", day).
+		Where("shift_assignments.status IN ? AND shift_assignments.away_period_id IS NULL", activeAssignmentStatuses).
+		Order("shifts.start_time ASC").
+		Scan(&assignments).Error
+	return assignments, err
+}
+
+// notifyVolunteers reminds each volunteer on the day's rota of their shift
+func (ds *DayOperationsService) notifyVolunteers(day time.Time, runBy uint) (string, error) {
+	assignments, err := ds.rota(day)
+	if err != nil {
+		return "", err
+	}
+
+	notified := map[uint]bool{}
+	failures := 0
+	for _, assignment := range assignments {
+		// One message per volunteer, for their first shift of the day
+		if notified[assignment.UserID] {
+			continue
+		}
+		notified[assignment.UserID] = true
+
+		where := assignment.Location
+		if where == "" {
+			where = "the centre"
+		}
+		err := GetGlobalRealtimeNotificationService().SendNotification(RealtimeNotificationData{
+			UserID:    assignment.UserID,
+			Type:      "shift_reminder",
+			Title:     "You're on the rota today",
+			Message:   fmt.Sprintf("We're open today. Your %s shift starts at %s at %s.", assignment.Role, assignment.StartTime.Format("15:04"), where),
+			Priority:  "high",
+			Category:  "volunteer",
+			ActionURL: "/volunteer/shifts",
+			Channels:  []string{"websocket", "email"},
+		})
+		if err != nil {
+			failures++
+		}
+	}
+
+	if failures > 0 {
+		return "", fmt.Errorf("%d of %d volunteers could not be notified", failures, len(notified))
+	}
+	return fmt.Sprintf("%d volunteers notified for %d shifts", len(notified), len(assignments)), nil
+}
+
+// closeQueue stops new queue entries and cancels anyone still waiting
+func (ds *DayOperationsService) closeQueue(day time.Time, runBy uint) (string, error) {
+	if err := ds.db.Model(&models.QueueSettings{}).Where("is_active = ?", true).
+		Update("is_active", false).Error; err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	left := ds.db.Model(&models.QueueEntry{}).
+		Where("status IN ?", []string{"waiting", "called", "recall"}).
+		Updates(map[string]interface{}{"status": "cancelled", "cancelled_at": now, "notes": "Queue closed at end of day"})
+	if left.Error != nil {
+		return "", left.Error
+	}
+
+	broadcastQueue(map[string]interface{}{"type": "queue_closed", "day": day.Format("2006-01-02")})
+	return fmt.Sprintf("Queue closed, %d waiting entries cancelled", left.RowsAffected), nil
+}
+
+// markNoShows records volunteers who never checked in to a committed shift, and expires
+// visitor tickets that were not used. Volunteers who cancelled or are away are excused.
+func (ds *DayOperationsService) markNoShows(day time.Time, runBy uint) (string, error) {
+	assignments, err := ds.rota(day)
+	if err != nil {
+		return "", err
+	}
+
+	volunteers := 0
+	for _, assignment := range assignments {
+		var record models.ShiftAssignment
+		if err := ds.db.First(&record, assignment.AssignmentID).Error; err != nil {
+			return "", err
+		}
+		if record.CheckedInAt != nil {
+			continue
+		}
+
+		now := time.Now()
+		err := ds.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&record).Updates(map[string]interface{}{
+				"status":              "NoShow",
+				"no_show_recorded":    true,
+				"no_show_reason":      "Did not check in",
+				"no_show_recorded_by": runBy,
+				"no_show_recorded_at": now,
+			}).Error; err != nil {
+				return err
+			}
+			return tx.Create(&models.VolunteerNoShow{
+				ShiftID:     record.ShiftID,
+				VolunteerID: record.UserID,
+				Reason:      "Did not check in (recorded at day close)",
+				ReportedBy:  runBy,
+				ReportedAt:  now,
+			}).Error
+		})
+		if err != nil {
+			return "", err
+		}
+		volunteers++
+	}
+
+	start, end := dayRange(day)
+	tickets := ds.db.Model(&models.Ticket{}).
+		Where("status = ? AND used_at IS NULL AND visit_date >= ? AND visit_date < ?", models.TicketStatusActive, start, end).
+		Update("status", models.TicketStatusExpired)
+	if tickets.Error != nil {
+		return "", tickets.Error
+	}
+
+	return fmt.Sprintf("%d volunteer no-shows recorded, %d unused visitor tickets expired", volunteers, tickets.RowsAffected), nil
+}
+
+// finalizeVisits completes visits that were never checked out
+func (ds *DayOperationsService) finalizeVisits(day time.Time, runBy uint) (string, error) {
+	start, end := dayRange(day)
+	var visits []models.Visit
+	if err := ds.db.Where("status IN ? AND check_in_time >= ? AND check_in_time < ?",
+		[]string{"checked_in", "in_service"}, start, end).Find(&visits).Error; err != nil {
+		return "", err
+	}
+
+	for i := range visits {
+		notes := strings.TrimSpace(visits[i].Notes + " Closed at end of day.")
+		visits[i].Complete(runBy, notes)
+		if err := ds.db.Save(&visits[i]).Error; err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%d open visits finalised", len(visits)), nil
+}
+
+// Summary builds the statistics for a day from its records
+func (ds *DayOperationsService) Summary(day time.Time) (*models.DailyServiceSummary, error) {
+	start, end := dayRange(day)
+	summary := models.DailyServiceSummary{Day: day, GeneratedAt: time.Now()}
+
+	ds.db.Model(&models.Ticket{}).
+		Where("status <> ? AND visit_date >= ? AND visit_date < ?", models.TicketStatusCancelled, start, end).
+		Count(&summary.TicketsIssued)
+	ds.db.Model(&models.Ticket{}).
+		Where("status = ? AND used_at IS NULL AND visit_date >= ? AND visit_date < ?", models.TicketStatusExpired, start, end).
+		Count(&summary.VisitorNoShows)
+
+	visits := ds.db.Model(&models.Visit{}).
+		Where("visits.status = ? AND visits.check_in_time >= ? AND visits.check_in_time < ?", "completed", start, end)
+	if err := visits.Count(&summary.VisitsCompleted).Error; err != nil {
+		return nil, err
+	}
+	ds.db.Model(&models.Visit{}).
+		Joins("JOIN tickets ON tickets.id = visits.ticket_id").
+		Where("visits.status = ? AND visits.check_in_time >= ? AND visits.check_in_time < ? AND tickets.category = ?",
+			"completed", start, end, models.CategoryFood).
+		Count(&summary.FoodVisits)
+	ds.db.Model(&models.Visit{}).
+		Joins("JOIN tickets ON tickets.id = visits.ticket_id").
+		Where("visits.status = ? AND visits.check_in_time >= ? AND visits.check_in_time < ? AND tickets.category = ?",
+			"completed", start, end, models.CategoryGeneral).
+		Count(&summary.GeneralVisits)
+
+	var queue struct {
+		Served      int64
+		AverageWait float64
+	}
+	ds.db.Model(&models.QueueEntry{}).
+		Select("COUNT(*) FILTER (WHERE status IN ('served', 'completed')) AS served, "+
+			"COALESCE(AVG(EXTRACT(EPOCH FROM (called_at - joined_at)) / 60) FILTER (WHERE called_at IS NOT NULL), 0) AS average_wait").
+		Where("joined_at >= ? AND joined_at < ?", start, end).
+		Scan(&queue)
+	summary.QueueServed = queue.Served
+	summary.AverageWaitMinutes = queue.AverageWait
+
+	var shifts struct {
+		Assigned int64
+		Attended int64
+		NoShows  int64
+		Hours    float64
+	}
+	ds.db.Model(&models.ShiftAssignment{}).
+		Select("COUNT(*) AS assigned, "+
+			"COUNT(*) FILTER (WHERE shift_assignments.checked_in_at IS NOT NULL) AS attended, "+
+			"COUNT(*) FILTER (WHERE shift_assignments.status = 'NoShow') AS no_shows, "+
+			"COALESCE(SUM(shift_assignments.hours_logged), 0) AS hours").
+		Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id").
+		Where("shifts.date::date = ?::date AND shift_assignments.status IN ?", day,
+			[]string{"Confirmed", "Assigned", "Completed", "NoShow"}).
+		Scan(&shifts)
+	summary.VolunteerShifts = shifts.Assigned
+	summary.VolunteersAttended = shifts.Attended
+	summary.VolunteerNoShows = shifts.NoShows
+	summary.VolunteerHours = shifts.Hours
+
+	return &summary, nil
+}
+
+// rollup stores the day's statistics, replacing any earlier rollup for the day
+func (ds *DayOperationsService) rollup(day time.Time, runBy uint) (string, error) {
+	summary, err := ds.Summary(day)
+	if err != nil {
+		return "", err
+	}
+	if err := ds.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "day"}},
+		DoUpdates: clause.AssignmentColumns([]string{"tickets_issued", "visits_completed", "visitor_no_shows", "food_visits", "general_visits", "queue_served", "average_wait_minutes", "volunteer_shifts", "volunteers_attended", "volunteer_no_shows", "volunteer_hours", "generated_at", "updated_at"}),
+	}).Create(summary).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d visits, %d visitor no-shows, %d volunteer no-shows, %.1f volunteer hours",
+		summary.VisitsCompleted, summary.VisitorNoShows, summary.VolunteerNoShows, summary.VolunteerHours), nil
+}
+
+// emailSummary sends the day's rollup to every active admin
+func (ds *DayOperationsService) emailSummary(day time.Time, runBy uint) (string, error) {
+	var summary models.DailyServiceSummary
+	if err := ds.db.Where("day = ?", day).First(&summary).Error; err != nil {
+		return "", fmt.Errorf("no statistics rollup for the day: %w", err)
+	}
+
+	var managers []models.User
+	if err := ds.db.Where("role IN ? AND status = ?", []string{models.RoleAdmin, models.RoleSuperAdmin}, models.StatusActive).
+		Find(&managers).Error; err != nil {
+		return "", err
+	}
+
+	subject := fmt.Sprintf("Day summary for %s", day.Format("Monday 2 January 2006"))
+	body := fmt.Sprintf("Here is the summary for %s.\n\n"+
+		"Visitors\n"+
+		"- Tickets issued: %d\n"+
+		"- Visits completed: %d (food %d, general %d)\n"+
+		"- No-shows: %d\n"+
+		"- Served from the queue: %d, average wait %.0f minutes\n\n"+
+		"Volunteers\n"+
+		"- Shifts: %d\n"+
+		"- Attended: %d\n"+
+		"- No-shows: %d\n"+
+		"- Hours logged: %.1f\n",
+		day.Format("Monday 2 January 2006"),
+		summary.TicketsIssued, summary.VisitsCompleted, summary.FoodVisits, summary.GeneralVisits,
+		summary.VisitorNoShows, summary.QueueServed, summary.AverageWaitMinutes,
+		summary.VolunteerShifts, summary.VolunteersAttended, summary.VolunteerNoShows, summary.VolunteerHours)
+
+	sent := 0
+	for _, manager := range managers {
+		if manager.Email == "" {
+			continue
+		}
+		if err := notifications.GetService().SendEmail(manager.Email, subject, body); err != nil {
+			log.Printf("Failed to email day summary to %s: %v", manager.Email, err)
+			continue
+		}
+		sent++
+	}
+	if sent == 0 && len(managers) > 0 {
+		return "", fmt.Errorf("the summary could not be emailed to any of %d managers", len(managers))
+	}
+	return fmt.Sprintf("Summary emailed to %d managers", sent), nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestDayOperationSteps(t *testing.T) {
+	ds := &DayOperationsService{}
+	keys := func(operation string) []string {
+		var keys []string
+		for _, step := range ds.steps(operation) {
+			keys = append(keys, step.key)
+		}
+		return keys
+	}
+	if got, want := keys(models.DayOperationOpen), []string{"activate_queue", "publish_capacity", "notify_volunteers"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("open: got %v", got)
+	}
+	// No-shows are marked before visits are finalised and the rollup is taken last
+	if got, want := keys(models.DayOperationClose), []string{"close_queue", "mark_no_shows", "finalize_visits", "stats_rollup", "email_summary"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("close: got %v", got)
+	}
+}
+
+func TestStartDayOperationChecks(t *testing.T) {
+	ds := &DayOperationsService{}
+	if _, err := ds.Start("lunch", time.Now(), 1); err != ErrDayOperationUnknown {
+		t.Errorf("unknown operation: got %v", err)
+	}
+	tomorrow, _ := time.Parse("2006-01-02", time.Now().AddDate(0, 0, 1).Format("2006-01-02"))
+	if _, err := ds.Start(models.DayOperationOpen, tomorrow, 1); err != ErrDayOperationFuture {
+		t.Errorf("tomorrow: got %v", err)
+	}
+}
+
+func TestCheckDayOperationRestart(t *testing.T) {
+	now := time.Date(2026, 5, 4, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		status  string
+		updated time.Duration // Before now
+		want    error
+	}{
+		{models.DayOperationCompleted, time.Hour, ErrDayOperationCompleted},
+		{models.DayOperationRunning, 5 * time.Minute, ErrDayOperationRunning},
+		{models.DayOperationRunning, 31 * time.Minute, nil}, // Interrupted
+		{models.DayOperationFailed, time.Minute, nil},
+	}
+	for _, tt := range tests {
+		run := &models.DayOperationRun{Status: tt.status, UpdatedAt: now.Add(-tt.updated)}
+		if err := checkDayOperationRestart(run, now); err != tt.want {
+			t.Errorf("%s %v ago: got %v, want %v", tt.status, tt.updated, err, tt.want)
+		}
+	}
+}
+
+func TestDayRange(t *testing.T) {
+	start, end := dayRange(time.Date(2026, 3, 29, 15, 4, 0, 0, time.Local))
+	if start != time.Date(2026, 3, 29, 0, 0, 0, 0, time.Local) || end != time.Date(2026, 3, 30, 0, 0, 0, 0, time.Local) {
+		t.Errorf("got %v to %v", start, end)
+	}
+}
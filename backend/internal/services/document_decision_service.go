@@ -0,0 +1,404 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+)
+
+// maxBulkDocumentDecisions is how many documents one bulk decision can cover
+const maxBulkDocumentDecisions = 100
+
+var (
+	ErrRejectionReasonNotFound = errors.New("rejection reason not found")
+	ErrRejectionReasonInactive = errors.New("rejection reason is no longer in use")
+	ErrRejectionReasonRequired = errors.New("a rejection reason is required")
+	ErrRejectionReasonExists   = errors.New("a rejection reason with this code already exists")
+	ErrRejectionReasonInvalid  = errors.New("invalid rejection reason")
+	ErrBulkDecisionInvalid     = errors.New("invalid bulk decision")
+)
+
+// rejectionCodePattern is the form of a rejection reason code
+var rejectionCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// BulkDocumentDecision is one decision applied to several documents
+type BulkDocumentDecision struct {
+	DocumentIDs []uint
+	Decision    string // approved or rejected
+	ReasonCode  string // Managed rejection reason; required to reject
+	Note        string // Added to the reason's explanation for the visitor
+}
+
+// DocumentDecisionResult is the outcome of a bulk decision for one document
+type DocumentDecisionResult struct {
+	DocumentID uint   `json:"document_id"`
+	Status     string `json:"status,omitempty"` // The document's new status when it succeeded
+	Error      string `json:"error,omitempty"`
+}
+
+// RejectionReasonStats is how often a rejection reason was used and how many visitors
+// went on to get an approved document
+type RejectionReasonStats struct {
+	Code           string           `json:"code"`
+	Label          string           `json:"label"`
+	Rejections     int64            `json:"rejections"`
+	Share          float64          `json:"share"` // Percentage of all rejections
+	ByDocumentType map[string]int64 `json:"by_document_type"`
+	Resubmitted    int64            `json:"resubmitted"`   // Followed by a new upload of the same type
+	Resolved       int64            `json:"resolved"`      // Followed by an approved upload of the same type
+	ResolvedRate   float64          `json:"resolved_rate"` // Percentage of rejections resolved
+}
+
+// rejectionReasonRow is one reason and document type from the analytics query
+type rejectionReasonRow struct {
+	Code         string
+	DocumentType string
+	Rejections   int64
+	Resubmitted  int64
+	Resolved     int64
+}
+
+// DocumentDecisionService applies verification decisions to batches of documents using
+// a managed list of rejection reasons, and reports how often each reason is used
+type DocumentDecisionService struct {
+	db *gorm.DB
+}
+
+// NewDocumentDecisionService creates a new document decision service
+func NewDocumentDecisionService() *DocumentDecisionService {
+	return &DocumentDecisionService{db: db.DB}
+}
+
+// Reasons returns the rejection reasons in display order, optionally with inactive ones
+func (ds *DocumentDecisionService) Reasons(includeInactive bool) ([]models.DocumentRejectionReason, error) {
+	query := ds.db.Order("sort_order ASC, label ASC")
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var reasons []models.DocumentRejectionReason
+	if err := query.Find(&reasons).Error; err != nil {
+		return nil, err
+	}
+	return reasons, nil
+}
+
+// Reason returns an active rejection reason by its code
+func (ds *DocumentDecisionService) Reason(code string) (*models.DocumentRejectionReason, error) {
+	var reason models.DocumentRejectionReason
+	if err := ds.db.Where("code = ?", code).First(&reason).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRejectionReasonNotFound
+		}
+		return nil, err
+	}
+	if !reason.IsActive {
+		return nil, ErrRejectionReasonInactive
+	}
+	return &reason, nil
+}
+
+// SaveReason creates or updates a rejection reason. A reason's code cannot change once
+// it has been created because decisions refer to it.
+func (ds *DocumentDecisionService) SaveReason(reason *models.DocumentRejectionReason) error {
+	reason.Label = strings.TrimSpace(reason.Label)
+	reason.Explanation = strings.TrimSpace(reason.Explanation)
+	if reason.Label == "" || reason.Explanation == "" {
+		return fmt.Errorf("%w: label and explanation are required", ErrRejectionReasonInvalid)
+	}
+	var types []string
+	for _, t := range strings.Split(reason.DocumentTypes, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	reason.DocumentTypes = strings.Join(types, ",")
+
+	if reason.ID == 0 {
+		reason.Code = strings.ToLower(strings.TrimSpace(reason.Code))
+		if !rejectionCodePattern.MatchString(reason.Code) {
+			return fmt.Errorf("%w: code must be lowercase letters, numbers and underscores", ErrRejectionReasonInvalid)
+		}
+		var existing int64
+		ds.db.Model(&models.DocumentRejectionReason{}).Where("code = ?", reason.Code).Count(&existing)
+		if existing > 0 {
+			return ErrRejectionReasonExists
+		}
+		return ds.db.Create(reason).Error
+	}
+	return ds.db.Save(reason).Error
+}
+
+// rejectionMessage is what the visitor is told about a rejection
+func rejectionMessage(reason *models.DocumentRejectionReason, note string) string {
+	if note = strings.TrimSpace(note); note != "" {
+		return reason.Explanation + " " + note
+	}
+	return reason.Explanation
+}
+
+// Decide applies one decision to each document in turn. Documents that cannot take the
+// decision are reported in the results and do not stop the others. Each visitor is
+// sent one notification covering all of their documents.
+func (ds *DocumentDecisionService) Decide(decision BulkDocumentDecision, verifierID uint) ([]DocumentDecisionResult, error) {
+	if decision.Decision != models.DocumentStatusApproved && decision.Decision != models.DocumentStatusRejected {
+		return nil, fmt.Errorf("%w: decision must be approved or rejected", ErrBulkDecisionInvalid)
+	}
+	ids := uniqueIDs(decision.DocumentIDs)
+	if len(ids) == 0 || len(ids) > maxBulkDocumentDecisions {
+		return nil, fmt.Errorf("%w: choose between 1 and %d documents", ErrBulkDecisionInvalid, maxBulkDocumentDecisions)
+	}
+
+	var reason *models.DocumentRejectionReason
+	if decision.Decision == models.DocumentStatusRejected {
+		if decision.ReasonCode == "" {
+			return nil, ErrRejectionReasonRequired
+		}
+		var err error
+		if reason, err = ds.Reason(decision.ReasonCode); err != nil {
+			return nil, err
+		}
+	}
+
+	var documents []models.Document
+	if err := ds.db.Where("id IN ?", ids).Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.Document, len(documents))
+	for i := range documents {
+		byID[documents[i].ID] = &documents[i]
+	}
+
+	now := time.Now()
+	results := make([]DocumentDecisionResult, 0, len(ids))
+	decided := map[uint][]models.Document{}
+	for _, id := range ids {
+		result := DocumentDecisionResult{DocumentID: id}
+		document, ok := byID[id]
+		switch {
+		case !ok:
+			result.Error = "document not found"
+		case document.Status != models.DocumentStatusPending:
+			result.Error = ErrDocumentNotPending.Error()
+		case reason != nil && !reason.AppliesTo(document.Type):
+			result.Error = fmt.Sprintf("reason %q does not apply to %s documents", reason.Code, document.Type)
+		case reason == nil && document.ExpiresAt != nil && !document.ExpiresAt.After(now):
+			result.Error = ErrDocumentAlreadyExpired.Error()
+		default:
+			if err := ds.apply(document, decision, reason, verifierID, now); err != nil {
+				log.Printf("Failed to record decision on document %d: %v", id, err)
+				result.Error = "failed to save decision"
+			} else {
+				result.Status = document.Status
+				decided[document.UserID] = append(decided[document.UserID], *document)
+			}
+		}
+		results = append(results, result)
+	}
+
+	for userID, userDocuments := range decided {
+		ds.notifyOwner(userID, userDocuments)
+	}
+	return results, nil
+}
+
+// apply saves a decision on a document with its verification history entry
+func (ds *DocumentDecisionService) apply(document *models.Document, decision BulkDocumentDecision, reason *models.DocumentRejectionReason, verifierID uint, now time.Time) error {
+	document.Status = decision.Decision
+	document.VerifiedBy = &verifierID
+	document.VerifiedAt = &now
+	if reason != nil {
+		document.RejectionReason = rejectionMessage(reason, decision.Note)
+		document.RejectionCode = reason.Code
+	} else {
+		document.RejectionReason = ""
+		document.RejectionCode = ""
+		document.ExpiryNoticeAt = nil
+	}
+
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(document).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.DocumentVerificationResult{
+			DocumentID:      document.ID,
+			VerifiedBy:      verifierID,
+			Status:          document.Status,
+			Notes:           decision.Note,
+			RejectionReason: document.RejectionReason,
+			RejectionCode:   document.RejectionCode,
+			VerifiedAt:      now,
+		}).Error
+	})
+}
+
+// notifyOwner tells a visitor what was decided about their documents, in the app and
+// by email
+func (ds *DocumentDecisionService) notifyOwner(userID uint, documents []models.Document) {
+	var user models.User
+	if err := ds.db.First(&user, userID).Error; err != nil {
+		log.Printf("Failed to load user %d for document decision notice: %v", userID, err)
+		return
+	}
+
+	title, lines := documentDecisionSummary(documents)
+	if err := GetGlobalRealtimeNotificationService().SendNotification(RealtimeNotificationData{
+		UserID:    user.ID,
+		Type:      "document_status",
+		Title:     title,
+		Message:   strings.Join(lines, " "),
+		Priority:  models.PriorityMedium,
+		Category:  "documents",
+		ActionURL: "/visitor/documents",
+		Channels:  []string{"websocket"},
+	}); err != nil {
+		log.Printf("Failed to notify user %d of document decisions: %v", user.ID, err)
+	}
+
+	if user.Email == "" {
+		return
+	}
+	baseURL := os.Getenv("FRONTEND_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+	var body strings.Builder
+	fmt.Fprintf(&body, "<p>Hi %s,</p><p>We've checked your documents.</p><ul>", html.EscapeString(user.FirstName))
+	for _, line := range lines {
+		fmt.Fprintf(&body, "<li>%s</li>", html.EscapeString(line))
+	}
+	fmt.Fprintf(&body, "</ul><p><a href=\"%s/visitor/documents\">View your documents</a></p>", html.EscapeString(baseURL))
+	if err := notifications.GetService().SendEmail(user.Email, title, body.String()); err != nil {
+		log.Printf("Failed to email user %d about document decisions: %v", user.ID, err)
+	}
+}
+
+// documentDecisionSummary writes a title and one line per document for a visitor
+func documentDecisionSummary(documents []models.Document) (string, []string) {
+	rejected := 0
+	lines := make([]string, 0, len(documents))
+	for _, document := range documents {
+		name := document.Title
+		if name == "" {
+			name = strings.ReplaceAll(document.Type, "_", " ")
+		}
+		if document.Status == models.DocumentStatusRejected {
+			rejected++
+			lines = append(lines, fmt.Sprintf("%s was not accepted: %s", name, document.RejectionReason))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s has been approved.", name))
+		}
+	}
+
+	switch {
+	case rejected == 0:
+		return "Your documents have been approved", lines
+	case rejected == len(documents):
+		return "Please upload your documents again", lines
+	default:
+		return "Some of your documents need uploading again", lines
+	}
+}
+
+// ReasonAnalytics counts rejections by reason between two times and how many were
+// followed by a new or approved upload of the same document type. Rejections made
+// without a managed reason are grouped under "other".
+func (ds *DocumentDecisionService) ReasonAnalytics(from, to time.Time) ([]RejectionReasonStats, error) {
+	var rows []rejectionReasonRow
+	if err := ds.db.Raw(`
+		SELECT COALESCE(NULLIF(r.rejection_code, ''), 'other') AS code,
+			d.type AS document_type,
+			COUNT(*) AS rejections,
+			SUM(CASE WHEN EXISTS (
+				SELECT 1 FROM documents later
+				WHERE later.user_id = d.user_id AND later.type = d.type
+					AND later.created_at > r.verified_at AND later.deleted_at IS NULL
+			) THEN 1 ELSE 0 END) AS resubmitted,
+			SUM(CASE WHEN EXISTS (
+				SELECT 1 FROM documents later
+				WHERE later.user_id = d.user_id AND later.type = d.type
+					AND later.created_at > r.verified_at AND later.deleted_at IS NULL
+					AND later.status = ?
+			) THEN 1 ELSE 0 END) AS resolved
+		FROM document_verification_results r
+		JOIN documents d ON d.id = r.document_id
+		WHERE r.status = ? AND r.verified_at >= ? AND r.verified_at < ?
+		GROUP BY 1, 2`,
+		models.DocumentStatusApproved, models.DocumentStatusRejected, from, to).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	reasons, err := ds.Reasons(true)
+	if err != nil {
+		return nil, err
+	}
+	return buildRejectionReasonStats(rows, reasons), nil
+}
+
+// buildRejectionReasonStats combines the analytics rows into one entry per reason,
+// most used first
+func buildRejectionReasonStats(rows []rejectionReasonRow, reasons []models.DocumentRejectionReason) []RejectionReasonStats {
+	labels := map[string]string{"other": "Other (no managed reason)"}
+	for _, reason := range reasons {
+		labels[reason.Code] = reason.Label
+	}
+
+	var total int64
+	byCode := map[string]*RejectionReasonStats{}
+	for _, row := range rows {
+		stats, ok := byCode[row.Code]
+		if !ok {
+			label := labels[row.Code]
+			if label == "" {
+				label = row.Code
+			}
+			stats = &RejectionReasonStats{Code: row.Code, Label: label, ByDocumentType: map[string]int64{}}
+			byCode[row.Code] = stats
+		}
+		stats.Rejections += row.Rejections
+		stats.ByDocumentType[row.DocumentType] += row.Rejections
+		stats.Resubmitted += row.Resubmitted
+		stats.Resolved += row.Resolved
+		total += row.Rejections
+	}
+
+	result := make([]RejectionReasonStats, 0, len(byCode))
+	for _, stats := range byCode {
+		stats.Share = percentOf(stats.Rejections, total)
+		stats.ResolvedRate = percentOf(stats.Resolved, stats.Rejections)
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Rejections != result[j].Rejections {
+			return result[i].Rejections > result[j].Rejections
+		}
+		return result[i].Code < result[j].Code
+	})
+	return result
+}
+
+// uniqueIDs returns the non-zero IDs in their first order without repeats
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestRejectionReasonAppliesTo(t *testing.T) {
+	cases := []struct {
+		types, documentType string
+		want                bool
+	}{
+		{"", "photo_id", true},
+		{"photo_id", "photo_id", true},
+		{"photo_id,proof_address", "proof_address", true},
+		{"photo_id, proof_address", "proof_address", true},
+		{"photo_id", "proof_address", false},
+	}
+	for _, tc := range cases {
+		reason := models.DocumentRejectionReason{DocumentTypes: tc.types}
+		if got := reason.AppliesTo(tc.documentType); got != tc.want {
+			t.Errorf("AppliesTo(%q) with types %q = %v, want %v", tc.documentType, tc.types, got, tc.want)
+		}
+	}
+}
+
+func TestUniqueIDs(t *testing.T) {
+	got := uniqueIDs([]uint{3, 1, 3, 0, 2, 1})
+	if want := []uint{3, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("uniqueIDs = %v, want %v", got, want)
+	}
+}
+
+func TestDocumentDecisionSummary(t *testing.T) {
+	approved := models.Document{Title: "Passport", Status: models.DocumentStatusApproved}
+	rejected := models.Document{Type: "proof_address", Status: models.DocumentStatusRejected, RejectionReason: "It's too old."}
+
+	cases := []struct {
+		documents []models.Document
+		title     string
+	}{
+		{[]models.Document{approved}, "Your documents have been approved"},
+		{[]models.Document{rejected}, "Please upload your documents again"},
+		{[]models.Document{approved, rejected}, "Some of your documents need uploading again"},
+	}
+	for _, tc := range cases {
+		title, lines := documentDecisionSummary(tc.documents)
+		if title != tc.title || len(lines) != len(tc.documents) {
+			t.Errorf("documentDecisionSummary = %q %v, want %q", title, lines, tc.title)
+		}
+	}
+
+	_, lines := documentDecisionSummary([]models.Document{rejected})
+	if lines[0] != "proof address was not accepted: It's too old." {
+		t.Errorf("unexpected line %q", lines[0])
+	}
+}
+
+func TestBuildRejectionReasonStats(t *testing.T) {
+	reasons := []models.DocumentRejectionReason{{Code: "unreadable", Label: "Unreadable"}, {Code: "expired", Label: "Expired"}}
+	rows := []rejectionReasonRow{
+		{Code: "unreadable", DocumentType: "photo_id", Rejections: 4, Resubmitted: 3, Resolved: 2},
+		{Code: "unreadable", DocumentType: "proof_address", Rejections: 2, Resubmitted: 2, Resolved: 2},
+		{Code: "expired", DocumentType: "photo_id", Rejections: 2, Resubmitted: 1, Resolved: 1},
+		{Code: "other", DocumentType: "photo_id", Rejections: 2},
+	}
+
+	stats := buildRejectionReasonStats(rows, reasons)
+	if len(stats) != 3 {
+		t.Fatalf("expected 3 reasons, got %+v", stats)
+	}
+	first := stats[0]
+	if first.Code != "unreadable" || first.Rejections != 6 || first.Share != 60 || first.ResolvedRate != percentOf(4, 6) {
+		t.Errorf("unexpected first reason: %+v", first)
+	}
+	if first.ByDocumentType["photo_id"] != 4 || first.ByDocumentType["proof_address"] != 2 {
+		t.Errorf("unexpected type counts: %v", first.ByDocumentType)
+	}
+	if stats[1].Code != "expired" || stats[2].Label != "Other (no managed reason)" {
+		t.Errorf("expected ties ordered by code, got %s then %s", stats[1].Code, stats[2].Code)
+	}
+}
@@ -0,0 +1,422 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Inbound document limits
+const (
+	InboundMaxAttachments    = 5
+	InboundMaxAttachmentSize = 5 * 1024 * 1024 // Same limit as website uploads
+	documentInboxTokenLength = 12
+)
+
+// Lowercase base32 without look-alike characters, so addresses can be read out over the phone
+const documentInboxAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+var (
+	ErrInboundUnauthorized = errors.New("invalid inbound webhook key")
+	ErrInboundNotVisitor   = errors.New("upload addresses are only available to visitors")
+)
+
+// inboundDocumentKeywords hint at the document type from a filename or subject
+var inboundDocumentKeywords = map[string][]string{
+	models.DocumentTypeID:           {"passport", "licence", "license", "photo id", "photo_id", "id card", "identity", "brp", "residence permit"},
+	models.DocumentTypeProofAddress: {"address", "bill", "statement", "council tax", "tenancy", "utility", "letter"},
+}
+
+// inboundAllowedTypes maps detected content types to file extensions
+var inboundAllowedTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+// InboundAttachment is a file attached to an inbound message
+type InboundAttachment struct {
+	Filename string
+	Data     []byte
+}
+
+// InboundMessage is a message received from the email provider's inbound webhook
+type InboundMessage struct {
+	Channel     string
+	Recipients  []string
+	Sender      string
+	Subject     string
+	Attachments []InboundAttachment
+}
+
+// InboundResult describes what happened to each attachment
+type InboundResult struct {
+	Message   *models.InboundDocumentMessage `json:"message"`
+	Documents []models.Document              `json:"documents"`
+	Skipped   []string                       `json:"skipped"`
+}
+
+// DocumentInboxService gives visitors a private address for sending document photos
+// and files what arrives into the verification queue
+type DocumentInboxService struct {
+	db         *gorm.DB
+	address    string // e.g. documents@inbound.example.org
+	webhookKey string
+	uploadDir  string
+}
+
+// NewDocumentInboxService creates a new document inbox service
+func NewDocumentInboxService() *DocumentInboxService {
+	return &DocumentInboxService{
+		db:         db.DB,
+		address:    strings.TrimSpace(os.Getenv("INBOUND_DOCUMENTS_ADDRESS")),
+		webhookKey: os.Getenv("INBOUND_DOCUMENTS_WEBHOOK_KEY"),
+		uploadDir:  "uploads/visitor_documents",
+	}
+}
+
+// Configured reports whether inbound documents can be received
+func (ds *DocumentInboxService) Configured() bool {
+	return strings.Contains(ds.address, "@") && ds.webhookKey != ""
+}
+
+// VerifyWebhookKey checks the shared key the provider is configured to send
+func (ds *DocumentInboxService) VerifyWebhookKey(key string) error {
+	if ds.webhookKey == "" || subtle.ConstantTimeCompare([]byte(ds.webhookKey), []byte(key)) != 1 {
+		return ErrInboundUnauthorized
+	}
+	return nil
+}
+
+// Address returns the email address for an inbox, using plus addressing on the
+// configured inbound address
+func (ds *DocumentInboxService) Address(inbox *models.DocumentInbox) string {
+	at := strings.LastIndex(ds.address, "@")
+	if at <= 0 || !inbox.IsActive {
+		return ""
+	}
+	return ds.address[:at] + "+" + inbox.Token + ds.address[at:]
+}
+
+// InboxFor returns the visitor's upload address, creating it on first use
+func (ds *DocumentInboxService) InboxFor(userID uint) (*models.DocumentInbox, error) {
+	var inbox models.DocumentInbox
+	err := ds.db.Where("user_id = ?", userID).First(&inbox).Error
+	if err == nil {
+		return &inbox, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var user models.User
+	if err := ds.db.Select("id", "role").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	if user.Role != models.RoleVisitor {
+		return nil, ErrInboundNotVisitor
+	}
+
+	token, err := generateDocumentInboxToken()
+	if err != nil {
+		return nil, err
+	}
+	inbox = models.DocumentInbox{UserID: userID, Token: token, IsActive: true}
+	if err := ds.db.Create(&inbox).Error; err != nil {
+		return nil, err
+	}
+	return &inbox, nil
+}
+
+// Rotate gives the visitor a new upload address; the old one stops working at once
+func (ds *DocumentInboxService) Rotate(userID uint) (*models.DocumentInbox, error) {
+	inbox, err := ds.InboxFor(userID)
+	if err != nil {
+		return nil, err
+	}
+	token, err := generateDocumentInboxToken()
+	if err != nil {
+		return nil, err
+	}
+	inbox.Token = token
+	inbox.IsActive = true
+	if err := ds.db.Save(inbox).Error; err != nil {
+		return nil, err
+	}
+	return inbox, nil
+}
+
+// SetActive turns a visitor's upload address on or off
+func (ds *DocumentInboxService) SetActive(inbox *models.DocumentInbox, active bool) error {
+	inbox.IsActive = active
+	return ds.db.Model(inbox).Update("is_active", active).Error
+}
+
+// generateDocumentInboxToken creates a random, easy to read token
+func generateDocumentInboxToken() (string, error) {
+	b := make([]byte, documentInboxTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = documentInboxAlphabet[int(b[i])%len(documentInboxAlphabet)]
+	}
+	return string(b), nil
+}
+
+// findInbox matches a recipient such as documents+token@inbound.example.org to an
+// active inbox
+func (ds *DocumentInboxService) findInbox(recipients []string) *models.DocumentInbox {
+	for _, token := range inboxTokens(recipients) {
+		var inbox models.DocumentInbox
+		if err := ds.db.Where("token = ? AND is_active = ?", token, true).First(&inbox).Error; err == nil {
+			return &inbox
+		}
+	}
+	return nil
+}
+
+// inboxTokens returns the plus-address tokens of the recipients, in order
+func inboxTokens(recipients []string) []string {
+	var tokens []string
+	for _, recipient := range recipients {
+		addresses, err := mail.ParseAddressList(recipient)
+		if err != nil {
+			continue
+		}
+		for _, address := range addresses {
+			local, _, ok := strings.Cut(strings.ToLower(address.Address), "@")
+			if !ok {
+				continue
+			}
+			if _, token, ok := strings.Cut(local, "+"); ok && len(token) == documentInboxTokenLength {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+// Ingest files the attachments of an inbound message against the visitor's missing
+// or rejected documents. Everything received is logged, including messages that
+// match no inbox.
+func (ds *DocumentInboxService) Ingest(msg InboundMessage, now time.Time) (*InboundResult, error) {
+	record := &models.InboundDocumentMessage{
+		Channel:     msg.Channel,
+		Sender:      msg.Sender,
+		Subject:     msg.Subject,
+		Attachments: len(msg.Attachments),
+		ReceivedAt:  now,
+	}
+	result := &InboundResult{Message: record, Documents: []models.Document{}, Skipped: []string{}}
+
+	inbox := ds.findInbox(msg.Recipients)
+	switch {
+	case inbox == nil:
+		record.Status = models.InboundDocumentUnmatched
+		record.Reason = "no active upload address matched the recipient"
+	case len(msg.Attachments) == 0:
+		record.Status = models.InboundDocumentRejected
+		record.Reason = "message had no attachments"
+	}
+	if inbox != nil {
+		record.InboxID = &inbox.ID
+		record.UserID = &inbox.UserID
+	}
+	if record.Status != "" {
+		return result, ds.db.Create(record).Error
+	}
+
+	attachments := msg.Attachments
+	if len(attachments) > InboundMaxAttachments {
+		for _, extra := range attachments[InboundMaxAttachments:] {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: only the first %d attachments are read", extra.Filename, InboundMaxAttachments))
+		}
+		attachments = attachments[:InboundMaxAttachments]
+	}
+
+	open := ds.openDocumentTypes(inbox.UserID)
+	for _, attachment := range attachments {
+		ext, problem := checkInboundAttachment(attachment)
+		if problem != "" {
+			result.Skipped = append(result.Skipped, attachment.Filename+": "+problem)
+			continue
+		}
+
+		documentType := pickInboundDocumentType(attachment.Filename, msg.Subject, open)
+		if documentType == "" {
+			result.Skipped = append(result.Skipped, attachment.Filename+": no documents are waiting to be sent")
+			continue
+		}
+
+		document, err := ds.fileAttachment(inbox.UserID, documentType, ext, attachment, msg, now)
+		if err != nil {
+			log.Printf("Failed to file inbound document for user %d: %v", inbox.UserID, err)
+			result.Skipped = append(result.Skipped, attachment.Filename+": could not be saved")
+			continue
+		}
+		delete(open, documentType)
+		result.Documents = append(result.Documents, *document)
+	}
+
+	record.Filed = len(result.Documents)
+	ids := make([]string, 0, len(result.Documents))
+	for _, document := range result.Documents {
+		ids = append(ids, strconv.FormatUint(uint64(document.ID), 10))
+	}
+	record.DocumentIDs = strings.Join(ids, ",")
+	if record.Filed > 0 {
+		record.Status = models.InboundDocumentProcessed
+	} else {
+		record.Status = models.InboundDocumentRejected
+	}
+	record.Reason = strings.Join(result.Skipped, "; ")
+	if err := ds.db.Create(record).Error; err != nil {
+		return nil, err
+	}
+
+	ds.db.Model(inbox).UpdateColumn("last_received_at", now)
+	ds.notifyVisitor(inbox.UserID, result)
+	return result, nil
+}
+
+// openDocumentTypes returns the visitor's required document types that are missing or
+// were rejected, and so can be filled by an inbound attachment
+func (ds *DocumentInboxService) openDocumentTypes(userID uint) map[string]bool {
+	open := map[string]bool{
+		models.DocumentTypeID:           true,
+		models.DocumentTypeProofAddress: true,
+	}
+
+	var documents []models.Document
+	ds.db.Where("user_id = ? AND type IN ?", userID, []string{models.DocumentTypeID, models.DocumentTypeProofAddress}).
+		Find(&documents)
+	for _, document := range documents {
+		if document.Status != models.DocumentStatusRejected {
+			delete(open, document.Type)
+		}
+	}
+	return open
+}
+
+// checkInboundAttachment checks the file's size and real content type and returns
+// the extension to save it with
+func checkInboundAttachment(attachment InboundAttachment) (string, string) {
+	if len(attachment.Data) == 0 {
+		return "", "file is empty"
+	}
+	if len(attachment.Data) > InboundMaxAttachmentSize {
+		return "", "file is larger than 5MB"
+	}
+	contentType := http.DetectContentType(attachment.Data)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	ext, ok := inboundAllowedTypes[contentType]
+	if !ok {
+		return "", "only PDF, JPG and PNG files are accepted"
+	}
+	return ext, ""
+}
+
+// pickInboundDocumentType uses the filename or subject to choose a document type,
+// falling back to the first open slot
+func pickInboundDocumentType(filename, subject string, open map[string]bool) string {
+	for _, text := range []string{filename, subject} {
+		text = strings.ToLower(strings.NewReplacer("_", " ", "-", " ").Replace(text))
+		for _, documentType := range []string{models.DocumentTypeID, models.DocumentTypeProofAddress} {
+			if !open[documentType] {
+				continue
+			}
+			for _, keyword := range inboundDocumentKeywords[documentType] {
+				if strings.Contains(text, keyword) {
+					return documentType
+				}
+			}
+		}
+	}
+
+	for _, documentType := range []string{models.DocumentTypeID, models.DocumentTypeProofAddress} {
+		if open[documentType] {
+			return documentType
+		}
+	}
+	return ""
+}
+
+// fileAttachment saves an attachment and adds it to the verification queue, replacing
+// a rejected document of the same type
+func (ds *DocumentInboxService) fileAttachment(userID uint, documentType, ext string, attachment InboundAttachment, msg InboundMessage, now time.Time) (*models.Document, error) {
+	dir := filepath.Join(ds.uploadDir, strconv.FormatUint(uint64(userID), 10))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s_%d%s", documentType, msg.Channel, now.UnixNano(), ext))
+	if err := os.WriteFile(path, attachment.Data, 0644); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(attachment.Data)
+	document := models.Document{
+		UserID:      userID,
+		Type:        documentType,
+		Name:        filepath.Base(attachment.Filename),
+		Title:       "Sent by " + msg.Channel,
+		FilePath:    path,
+		FileType:    http.DetectContentType(attachment.Data),
+		FileSize:    int64(len(attachment.Data)),
+		Status:      models.DocumentStatusPending,
+		Description: strings.TrimSpace(msg.Subject),
+		UploadedAt:  now,
+		Checksum:    hex.EncodeToString(sum[:]),
+		Source:      models.DocumentSourceEmail,
+		Notes:       "Received from " + msg.Sender,
+	}
+
+	var rejected models.Document
+	if err := ds.db.Where("user_id = ? AND type = ? AND status = ?", userID, documentType, models.DocumentStatusRejected).
+		First(&rejected).Error; err == nil {
+		document.ID = rejected.ID
+		document.CreatedAt = rejected.CreatedAt
+		return &document, ds.db.Save(&document).Error
+	}
+	return &document, ds.db.Create(&document).Error
+}
+
+// notifyVisitor lets the visitor know what was received
+func (ds *DocumentInboxService) notifyVisitor(userID uint, result *InboundResult) {
+	title := "Documents received"
+	message := fmt.Sprintf("We received %d document(s) you sent by email. Staff will check them within 2-3 business days.", len(result.Documents))
+	if len(result.Documents) == 0 {
+		title = "We could not use the documents you sent"
+		message = "We received your email but could not file any attachments: " + result.Message.Reason
+	}
+
+	notification := models.InAppNotification{
+		UserID:    userID,
+		Title:     title,
+		Message:   message,
+		Type:      "info",
+		Priority:  "normal",
+		ActionURL: "/visitor/documents",
+	}
+	if err := ds.db.Create(&notification).Error; err != nil {
+		log.Printf("Failed to notify user %d about inbound documents: %v", userID, err)
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestDocumentInboxAddress(t *testing.T) {
+	ds := &DocumentInboxService{address: "documents@inbound.example.org", webhookKey: "s3cret"}
+	if !ds.Configured() {
+		t.Error("address and key set but not configured")
+	}
+
+	inbox := &models.DocumentInbox{Token: "abcdefghjkmn", IsActive: true}
+	if got := ds.Address(inbox); got != "documents+abcdefghjkmn@inbound.example.org" {
+		t.Errorf("got %q", got)
+	}
+	inbox.IsActive = false
+	if got := ds.Address(inbox); got != "" {
+		t.Errorf("paused inbox has address %q", got)
+	}
+
+	if err := ds.VerifyWebhookKey("s3cret"); err != nil {
+		t.Errorf("right key rejected: %v", err)
+	}
+	if err := ds.VerifyWebhookKey("guess"); err != ErrInboundUnauthorized {
+		t.Errorf("wrong key: got %v", err)
+	}
+	if err := (&DocumentInboxService{}).VerifyWebhookKey(""); err != ErrInboundUnauthorized {
+		t.Error("empty key accepted when none is configured")
+	}
+}
+
+func TestGenerateDocumentInboxToken(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		token, err := generateDocumentInboxToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(token) != documentInboxTokenLength || strings.Trim(token, documentInboxAlphabet) != "" {
+			t.Fatalf("token %q", token)
+		}
+		if seen[token] {
+			t.Fatalf("token %q repeated", token)
+		}
+		seen[token] = true
+	}
+}
+
+func TestInboxTokens(t *testing.T) {
+	got := inboxTokens([]string{
+		"not an address",
+		`"Visitor" <Documents+ABCDEFGHJKMN@inbound.example.org>, someone@example.org`,
+		"documents+short@inbound.example.org",
+		"documents+pqrstuvwxyz2@inbound.example.org",
+	})
+	want := []string{"abcdefghjkmn", "pqrstuvwxyz2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCheckInboundAttachment(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 16)...)
+	tests := []struct {
+		name    string
+		data    []byte
+		ext     string
+		problem bool
+	}{
+		{"pdf", []byte("%PDF-1.4\n..."), ".pdf", false},
+		{"png", png, ".png", false},
+		{"jpeg", []byte("\xff\xd8\xff\xe0\x00\x10JFIF"), ".jpg", false},
+		{"empty", nil, "", true},
+		{"html named as a pdf", []byte("<html><body>hi</body></html>"), "", true},
+		{"too large", append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("a"), InboundMaxAttachmentSize)...), "", true},
+	}
+	for _, tt := range tests {
+		ext, problem := checkInboundAttachment(InboundAttachment{Filename: "scan.pdf", Data: tt.data})
+		if ext != tt.ext || (problem != "") != tt.problem {
+			t.Errorf("%s: got %q, %q", tt.name, ext, problem)
+		}
+	}
+}
+
+func TestPickInboundDocumentType(t *testing.T) {
+	both := map[string]bool{models.DocumentTypeID: true, models.DocumentTypeProofAddress: true}
+	addressOnly := map[string]bool{models.DocumentTypeProofAddress: true}
+	tests := []struct {
+		filename, subject string
+		open              map[string]bool
+		want              string
+	}{
+		{"council_tax-2026.pdf", "", both, models.DocumentTypeProofAddress},
+		{"IMG_0042.jpg", "My passport", both, models.DocumentTypeID},
+		{"IMG_0042.jpg", "", both, models.DocumentTypeID},                  // First open slot
+		{"passport.jpg", "", addressOnly, models.DocumentTypeProofAddress}, // ID already on file
+		{"bill.pdf", "", map[string]bool{}, ""},
+	}
+	for _, tt := range tests {
+		if got := pickInboundDocumentType(tt.filename, tt.subject, tt.open); got != tt.want {
+			t.Errorf("pickInboundDocumentType(%q, %q) = %q, want %q", tt.filename, tt.subject, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Leaderboard ranking metrics
+const (
+	DriveRankByWeight = "weight"
+	DriveRankByItems  = "items"
+)
+
+// ErrDriveTeamNotFound is returned when a team code does not match any team
+var ErrDriveTeamNotFound = errors.New("team code not recognised")
+
+// DriveStanding is a team's position on a drive leaderboard. It holds no contact
+// details so it can be shown publicly.
+type DriveStanding struct {
+	Rank          int        `json:"rank"`
+	TeamID        uint       `json:"team_id"`
+	TeamName      string     `json:"team_name"`
+	Kind          string     `json:"kind"`
+	WeightKg      float64    `json:"weight_kg"`
+	Items         int        `json:"items"`
+	Dropoffs      int        `json:"dropoffs"`
+	LastDropoffAt *time.Time `json:"last_dropoff_at"`
+}
+
+// DriveDailyTotal is the goods received on one day of a drive
+type DriveDailyTotal struct {
+	Date     string  `json:"date"`
+	WeightKg float64 `json:"weight_kg"`
+	Items    int     `json:"items"`
+}
+
+// DriveSummary is the end-of-drive report
+type DriveSummary struct {
+	Drive         models.DonationDrive `json:"drive"`
+	Teams         int                  `json:"teams"`
+	Participants  int                  `json:"participants"`
+	TotalWeightKg float64              `json:"total_weight_kg"`
+	TotalItems    int                  `json:"total_items"`
+	Dropoffs      int                  `json:"dropoffs"`
+	Leaderboard   []DriveStanding      `json:"leaderboard"`
+	ByKind        map[string]float64   `json:"weight_by_kind"`
+	Daily         []DriveDailyTotal    `json:"daily"`
+}
+
+// DonationDriveService runs team goods-donation drives and their leaderboards
+type DonationDriveService struct {
+	db *gorm.DB
+}
+
+// NewDonationDriveService creates a new donation drive service
+func NewDonationDriveService() *DonationDriveService {
+	return &DonationDriveService{
+		db: db.DB,
+	}
+}
+
+// GenerateDriveTeamCode creates the code a team quotes when dropping off goods
+func GenerateDriveTeamCode() string {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("TEAM-%06d", time.Now().UnixNano()%1000000)
+	}
+	return "TEAM-" + strings.ToUpper(hex.EncodeToString(b))
+}
+
+// ValidateDrive tidies a drive and checks its dates and status
+func (ds *DonationDriveService) ValidateDrive(drive *models.DonationDrive) error {
+	drive.Name = strings.TrimSpace(drive.Name)
+	if drive.Name == "" {
+		return errors.New("name is required")
+	}
+	drive.Slug = driveSlug(drive.Slug)
+	if drive.Slug == "" {
+		drive.Slug = driveSlug(drive.Name)
+	}
+	if drive.Slug == "" {
+		return errors.New("slug must contain letters or numbers")
+	}
+	if drive.StartsAt.IsZero() || drive.EndsAt.IsZero() || !drive.EndsAt.After(drive.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+
+	switch drive.Status {
+	case "":
+		drive.Status = models.DonationDriveDraft
+	case models.DonationDriveDraft, models.DonationDriveActive, models.DonationDriveClosed:
+	default:
+		return fmt.Errorf("status must be one of %s, %s or %s",
+			models.DonationDriveDraft, models.DonationDriveActive, models.DonationDriveClosed)
+	}
+	return nil
+}
+
+// CreateTeam registers a team on a drive with a unique drop-off code
+func (ds *DonationDriveService) CreateTeam(team *models.DriveTeam) error {
+	team.Name = strings.TrimSpace(team.Name)
+	if team.Name == "" {
+		return errors.New("team name is required")
+	}
+	if team.Kind == "" {
+		team.Kind = "other"
+	}
+	known := false
+	for _, kind := range models.DriveTeamKinds {
+		known = known || team.Kind == kind
+	}
+	if !known {
+		return fmt.Errorf("kind must be one of %s", strings.Join(models.DriveTeamKinds, ", "))
+	}
+	if team.Participants < 0 {
+		return errors.New("participants cannot be negative")
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		team.Code = GenerateDriveTeamCode()
+		var existing int64
+		ds.db.Model(&models.DriveTeam{}).Where("code = ?", team.Code).Count(&existing)
+		if existing == 0 {
+			return ds.db.Create(team).Error
+		}
+	}
+	return errors.New("could not allocate a unique team code")
+}
+
+// FindTeamByCode looks up a team and its drive from a drop-off code
+func (ds *DonationDriveService) FindTeamByCode(code string) (*models.DriveTeam, *models.DonationDrive, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return nil, nil, ErrDriveTeamNotFound
+	}
+
+	var team models.DriveTeam
+	if err := ds.db.Where("code = ?", code).First(&team).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrDriveTeamNotFound
+		}
+		return nil, nil, err
+	}
+	var drive models.DonationDrive
+	if err := ds.db.First(&drive, team.DriveID).Error; err != nil {
+		return nil, nil, err
+	}
+	return &team, &drive, nil
+}
+
+// RecordDropoff saves goods received for a team. Drop-offs can only be recorded
+// while the drive is active and dated within it. When the drop-off is for a
+// donation that was registered online, the donation is credited to the team.
+func (ds *DonationDriveService) RecordDropoff(drive *models.DonationDrive, team *models.DriveTeam, dropoff *models.DriveDropoff) error {
+	if team.DriveID != drive.ID {
+		return errors.New("team is not part of this drive")
+	}
+	if dropoff.WeightKg < 0 || dropoff.Items < 0 {
+		return errors.New("weight and items cannot be negative")
+	}
+	if dropoff.WeightKg == 0 && dropoff.Items == 0 {
+		return errors.New("weight_kg or items is required")
+	}
+	if dropoff.ReceivedAt.IsZero() {
+		dropoff.ReceivedAt = time.Now()
+	}
+	if !drive.AcceptsDropoffs(dropoff.ReceivedAt) {
+		return errors.New("drive is not accepting drop-offs at this time")
+	}
+
+	dropoff.DriveID = drive.ID
+	dropoff.TeamID = team.ID
+
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if dropoff.DonationID != nil {
+			var donation models.Donation
+			if err := tx.First(&donation, *dropoff.DonationID).Error; err != nil {
+				return errors.New("donation not found")
+			}
+			if donation.DriveTeamID != nil && *donation.DriveTeamID != team.ID {
+				return errors.New("donation is already credited to another team")
+			}
+			if err := tx.Model(&donation).Update("drive_team_id", team.ID).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(dropoff).Error
+	})
+}
+
+// Leaderboard ranks a drive's teams by weight or items received. Teams with equal
+// totals share a rank.
+func (ds *DonationDriveService) Leaderboard(driveID uint, by string) ([]DriveStanding, error) {
+	var teams []models.DriveTeam
+	if err := ds.db.Where("drive_id = ?", driveID).Find(&teams).Error; err != nil {
+		return nil, err
+	}
+
+	var totals []struct {
+		TeamID        uint
+		WeightKg      float64
+		Items         int
+		Dropoffs      int
+		LastDropoffAt *time.Time
+	}
+	if err := ds.db.Model(&models.DriveDropoff{}).
+		Select("team_id, COALESCE(SUM(weight_kg), 0) AS weight_kg, COALESCE(SUM(items), 0) AS items, COUNT(*) AS dropoffs, MAX(received_at) AS last_dropoff_at").
+		Where("drive_id = ?", driveID).
+		Group("team_id").
+		Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+
+	standings := make([]DriveStanding, 0, len(teams))
+	byTeam := make(map[uint]int, len(teams))
+	for _, team := range teams {
+		byTeam[team.ID] = len(standings)
+		standings = append(standings, DriveStanding{TeamID: team.ID, TeamName: team.Name, Kind: team.Kind})
+	}
+	for _, total := range totals {
+		i, ok := byTeam[total.TeamID]
+		if !ok {
+			continue
+		}
+		standings[i].WeightKg = roundDriveWeight(total.WeightKg)
+		standings[i].Items = total.Items
+		standings[i].Dropoffs = total.Dropoffs
+		standings[i].LastDropoffAt = total.LastDropoffAt
+	}
+	rankDriveStandings(standings, by)
+	return standings, nil
+}
+
+// rankDriveStandings sorts standings by weight or items and numbers them, with equal
+// totals sharing a rank
+func rankDriveStandings(standings []DriveStanding, by string) {
+	score := func(s DriveStanding) float64 {
+		if by == DriveRankByItems {
+			return float64(s.Items)
+		}
+		return s.WeightKg
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		if score(standings[i]) != score(standings[j]) {
+			return score(standings[i]) > score(standings[j])
+		}
+		return standings[i].TeamName < standings[j].TeamName
+	})
+	for i := range standings {
+		if i > 0 && score(standings[i]) == score(standings[i-1]) {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+}
+
+// Summary builds the end-of-drive report
+func (ds *DonationDriveService) Summary(drive *models.DonationDrive) (*DriveSummary, error) {
+	leaderboard, err := ds.Leaderboard(drive.ID, DriveRankByWeight)
+	if err != nil {
+		return nil, err
+	}
+
+	var teams []models.DriveTeam
+	if err := ds.db.Where("drive_id = ?", drive.ID).Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	kinds := make(map[uint]string, len(teams))
+	summary := &DriveSummary{
+		Drive:       *drive,
+		Teams:       len(teams),
+		Leaderboard: leaderboard,
+		ByKind:      map[string]float64{},
+		Daily:       []DriveDailyTotal{},
+	}
+	for _, team := range teams {
+		kinds[team.ID] = team.Kind
+		summary.Participants += team.Participants
+	}
+
+	var dropoffs []models.DriveDropoff
+	if err := ds.db.Where("drive_id = ?", drive.ID).Order("received_at").Find(&dropoffs).Error; err != nil {
+		return nil, err
+	}
+	days := map[string]int{}
+	for _, dropoff := range dropoffs {
+		summary.Dropoffs++
+		summary.TotalWeightKg += dropoff.WeightKg
+		summary.TotalItems += dropoff.Items
+		summary.ByKind[kinds[dropoff.TeamID]] += dropoff.WeightKg
+
+		date := dropoff.ReceivedAt.Format("2006-01-02")
+		i, ok := days[date]
+		if !ok {
+			i = len(summary.Daily)
+			days[date] = i
+			summary.Daily = append(summary.Daily, DriveDailyTotal{Date: date})
+		}
+		summary.Daily[i].WeightKg += dropoff.WeightKg
+		summary.Daily[i].Items += dropoff.Items
+	}
+	for i := range summary.Daily {
+		summary.Daily[i].WeightKg = roundDriveWeight(summary.Daily[i].WeightKg)
+	}
+	for kind, weight := range summary.ByKind {
+		summary.ByKind[kind] = roundDriveWeight(weight)
+	}
+	summary.TotalWeightKg = roundDriveWeight(summary.TotalWeightKg)
+
+	return summary, nil
+}
+
+// RenderDriveCertificates renders thank-you certificates for a team, one page per
+// named participant, or a single team certificate when no names are given
+func RenderDriveCertificates(drive models.DonationDrive, team models.DriveTeam, standing DriveStanding, participants []string) []byte {
+	organisation := BrandName("")
+	doc := BrandedPDF(drive.Name+" - "+team.Name, "")
+
+	names := participants
+	if len(names) == 0 {
+		names = []string{team.Name}
+	}
+	for i, name := range names {
+		if i > 0 {
+			doc.PageBreak()
+		}
+		doc.Heading("Certificate of Appreciation").
+			Blank().
+			Line(organisation + " is proud to present this certificate to").
+			Blank().
+			Heading(name).
+			Blank()
+		if name != team.Name {
+			doc.Linef("as a member of %s", team.Name)
+		}
+		doc.Linef("for taking part in %s,", drive.Name).
+			Linef("%s to %s.", drive.StartsAt.Format("2 January 2006"), drive.EndsAt.Format("2 January 2006")).
+			Blank().
+			Subheading("Team achievement").
+			Field("Goods collected", fmt.Sprintf("%.1f kg", standing.WeightKg)).
+			Field("Items donated", fmt.Sprintf("%d", standing.Items))
+		if standing.Rank > 0 {
+			doc.Field("Leaderboard position", fmt.Sprintf("%d", standing.Rank))
+		}
+		doc.Blank().
+			Line("Every item collected helps local families through our food bank.").
+			Line("Thank you for your generosity.")
+	}
+
+	return doc.Bytes()
+}
+
+// driveSlug turns text into a URL-safe slug
+func driveSlug(text string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			sb.WriteRune(r)
+			dash = false
+		case !dash && sb.Len() > 0:
+			sb.WriteRune('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(sb.String(), "-")
+	if len(slug) > 80 {
+		slug = strings.TrimSuffix(slug[:80], "-")
+	}
+	return slug
+}
+
+// roundDriveWeight rounds a weight to the nearest 100g
+func roundDriveWeight(kg float64) float64 {
+	return float64(int64(kg*10+0.5)) / 10
+}
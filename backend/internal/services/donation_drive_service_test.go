@@ -0,0 +1,118 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestRankDriveStandings(t *testing.T) {
+	standings := []DriveStanding{
+		{TeamName: "St Mary's", WeightKg: 40.5, Items: 90},
+		{TeamName: "Brockley Scouts", WeightKg: 12, Items: 120},
+		{TeamName: "Albion School", WeightKg: 40.5, Items: 60},
+		{TeamName: "Late Starters"},
+	}
+
+	rankDriveStandings(standings, DriveRankByWeight)
+	want := []struct {
+		name string
+		rank int
+	}{{"Albion School", 1}, {"St Mary's", 1}, {"Brockley Scouts", 3}, {"Late Starters", 4}}
+	for i, w := range want {
+		if standings[i].TeamName != w.name || standings[i].Rank != w.rank {
+			t.Errorf("by weight %d: got %s ranked %d, want %s ranked %d", i, standings[i].TeamName, standings[i].Rank, w.name, w.rank)
+		}
+	}
+
+	rankDriveStandings(standings, DriveRankByItems)
+	if standings[0].TeamName != "Brockley Scouts" || standings[0].Rank != 1 || standings[3].Rank != 4 {
+		t.Errorf("by items: got %+v", standings)
+	}
+}
+
+func TestValidateDrive(t *testing.T) {
+	ds := &DonationDriveService{}
+	start := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	drive := &models.DonationDrive{Name: "  Harvest Festival 2026! ", StartsAt: start, EndsAt: start.AddDate(0, 0, 14)}
+	if err := ds.ValidateDrive(drive); err != nil {
+		t.Fatalf("valid drive rejected: %v", err)
+	}
+	if drive.Name != "Harvest Festival 2026!" || drive.Slug != "harvest-festival-2026" || drive.Status != models.DonationDriveDraft {
+		t.Errorf("not tidied: %+v", drive)
+	}
+
+	tests := []struct {
+		name  string
+		drive models.DonationDrive
+	}{
+		{"no name", models.DonationDrive{StartsAt: start, EndsAt: start.AddDate(0, 0, 1)}},
+		{"slug without letters", models.DonationDrive{Name: "!!!", StartsAt: start, EndsAt: start.AddDate(0, 0, 1)}},
+		{"ends before it starts", models.DonationDrive{Name: "Drive", StartsAt: start, EndsAt: start}},
+		{"unknown status", models.DonationDrive{Name: "Drive", StartsAt: start, EndsAt: start.AddDate(0, 0, 1), Status: "paused"}},
+	}
+	for _, tt := range tests {
+		if err := ds.ValidateDrive(&tt.drive); err == nil {
+			t.Errorf("%s: accepted", tt.name)
+		}
+	}
+}
+
+func TestDriveSlug(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Spring Drive", "spring-drive"},
+		{"  --Café & Co--  ", "caf-co"},
+		{strings.Repeat("ab ", 40), strings.TrimSuffix(strings.Repeat("ab-", 27)[:80], "-")},
+	}
+	for _, tt := range tests {
+		if got := driveSlug(tt.in); got != tt.want {
+			t.Errorf("driveSlug(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRecordDropoffChecks(t *testing.T) {
+	ds := &DonationDriveService{}
+	start := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	drive := &models.DonationDrive{ID: 1, Status: models.DonationDriveActive, StartsAt: start, EndsAt: start.AddDate(0, 0, 14)}
+	team := &models.DriveTeam{ID: 5, DriveID: 1}
+
+	tests := []struct {
+		name    string
+		team    *models.DriveTeam
+		dropoff models.DriveDropoff
+	}{
+		{"team from another drive", &models.DriveTeam{ID: 6, DriveID: 2}, models.DriveDropoff{Items: 3, ReceivedAt: start}},
+		{"nothing received", team, models.DriveDropoff{ReceivedAt: start}},
+		{"negative weight", team, models.DriveDropoff{WeightKg: -1, Items: 3, ReceivedAt: start}},
+		{"after the drive", team, models.DriveDropoff{Items: 3, ReceivedAt: start.AddDate(0, 0, 15)}},
+	}
+	for _, tt := range tests {
+		if err := ds.RecordDropoff(drive, tt.team, &tt.dropoff); err == nil {
+			t.Errorf("%s: accepted", tt.name)
+		}
+	}
+
+	closed := *drive
+	closed.Status = models.DonationDriveClosed
+	if closed.AcceptsDropoffs(start.AddDate(0, 0, 1)) {
+		t.Error("closed drive accepts drop-offs")
+	}
+}
+
+func TestDriveTeamCodeAndWeight(t *testing.T) {
+	if code := GenerateDriveTeamCode(); !regexp.MustCompile(`^TEAM-[0-9A-F]{6}$`).MatchString(code) {
+		t.Errorf("team code %q", code)
+	}
+	if got := roundDriveWeight(12.345); got != 12.3 {
+		t.Errorf("roundDriveWeight(12.345) = %v", got)
+	}
+	if got := roundDriveWeight(0.06); got != 0.1 {
+		t.Errorf("roundDriveWeight(0.06) = %v", got)
+	}
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Goods intake errors
+var (
+	ErrIntakeDonationNotFound = errors.New("donation not found")
+	ErrIntakeNotGoods         = errors.New("only goods donations can be received into stock")
+	ErrIntakeAlreadyReceived  = errors.New("this donation's goods have already been received into stock")
+	ErrIntakeInvalid          = errors.New("invalid goods intake")
+)
+
+// IntakeLine is one line counted when a goods donation is received. DonationItemID
+// is empty for goods the donor did not declare; a quantity of 0 records a declared
+// item that did not arrive.
+type IntakeLine struct {
+	DonationItemID  *uint
+	InventoryItemID uint
+	Quantity        int
+	BatchCode       string
+	ExpiresOn       *time.Time
+	Notes           string
+}
+
+// IntakeDiscrepancy is an item whose count at intake differs from what the donor
+// declared
+type IntakeDiscrepancy struct {
+	DonationItemID uint       `json:"donation_item_id"`
+	DonationID     uint       `json:"donation_id"`
+	Name           string     `json:"name"`
+	Declared       int        `json:"declared"`
+	Received       int        `json:"received"`
+	Difference     int        `json:"difference"` // Received minus declared
+	Notes          string     `json:"notes,omitempty"`
+	ReceivedAt     *time.Time `json:"received_at"`
+}
+
+// DonationIntake is a goods donation with its declared and received items and the
+// stock booked from it
+type DonationIntake struct {
+	Donation      models.Donation        `json:"donation"`
+	Received      bool                   `json:"received"` // Goods have been counted into stock
+	Items         []models.DonationItem  `json:"items"`
+	Movements     []models.StockMovement `json:"movements"`
+	Discrepancies []IntakeDiscrepancy    `json:"discrepancies"`
+}
+
+// DonationIntakeService receives goods donations into inventory
+type DonationIntakeService struct {
+	db *gorm.DB
+}
+
+// NewDonationIntakeService creates a new donation intake service
+func NewDonationIntakeService() *DonationIntakeService {
+	return &DonationIntakeService{db: db.DB}
+}
+
+// Intake returns a goods donation's items, the stock booked from it and where the
+// count differed from the declaration
+func (ds *DonationIntakeService) Intake(donationID uint) (*DonationIntake, error) {
+	var donation models.Donation
+	if err := ds.db.First(&donation, donationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIntakeDonationNotFound
+		}
+		return nil, err
+	}
+	if donation.Type != models.DonationTypeGoods {
+		return nil, ErrIntakeNotGoods
+	}
+
+	intake := &DonationIntake{Donation: donation}
+	if err := ds.db.Where("donation_id = ?", donationID).Order("id ASC").Find(&intake.Items).Error; err != nil {
+		return nil, err
+	}
+	if err := ds.db.Where("donation_id = ?", donationID).Order("id ASC").Find(&intake.Movements).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range intake.Items {
+		if item.Status == models.DonationItemReceived {
+			intake.Received = true
+			break
+		}
+	}
+	intake.Discrepancies = intakeDiscrepancies(intake.Items)
+	return intake, nil
+}
+
+// Receive counts a goods donation into stock. Each line with goods books a stock-in
+// movement carrying its batch and expiry; declared items left off the lines are
+// recorded as not received. A pending donation becomes received, and the second
+// result reports that so its receipt can be sent.
+func (ds *DonationIntakeService) Receive(donationID uint, lines []IntakeLine, receivedBy uint, now time.Time) (*DonationIntake, bool, error) {
+	markedReceived := false
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		var donation models.Donation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&donation, donationID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrIntakeDonationNotFound
+			}
+			return err
+		}
+		if donation.Type != models.DonationTypeGoods {
+			return ErrIntakeNotGoods
+		}
+		if donation.Status != models.DonationStatusPending && donation.Status != models.DonationStatusReceived {
+			return fmt.Errorf("%w: a %s donation cannot be received", ErrIntakeInvalid, donation.Status)
+		}
+
+		var booked int64
+		if err := tx.Model(&models.DonationItem{}).
+			Where("donation_id = ? AND status = ?", donationID, models.DonationItemReceived).
+			Count(&booked).Error; err != nil {
+			return err
+		}
+		if booked > 0 {
+			return ErrIntakeAlreadyReceived
+		}
+
+		var declared []models.DonationItem
+		if err := tx.Where("donation_id = ?", donationID).Order("id ASC").Find(&declared).Error; err != nil {
+			return err
+		}
+		if err := validateIntakeLines(declared, lines); err != nil {
+			return err
+		}
+
+		reference := fmt.Sprintf("Donation %d", donationID)
+		counted := make(map[uint]bool, len(lines))
+		total := 0
+		for _, line := range lines {
+			name := ""
+			if line.Quantity > 0 {
+				var stock models.InventoryItem
+				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stock, line.InventoryItemID).Error; err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						return fmt.Errorf("%w: stock item %d", ErrInventoryItemNotFound, line.InventoryItemID)
+					}
+					return err
+				}
+				if !stock.Active {
+					return fmt.Errorf("%w: %s is no longer stocked", ErrIntakeInvalid, stock.Name)
+				}
+				if err := checkItemExpiry(tx, &stock, line.ExpiresOn, now); err != nil {
+					return fmt.Errorf("%s: %w", stock.Name, err)
+				}
+				if _, err := applyStockMovement(tx, &stock, StockMovementInput{
+					Type:       models.StockMovementIn,
+					Quantity:   line.Quantity,
+					Reason:     "Goods donation received",
+					Reference:  reference,
+					BatchCode:  line.BatchCode,
+					ExpiresOn:  line.ExpiresOn,
+					DonationID: &donationID,
+				}, &receivedBy, now); err != nil {
+					return err
+				}
+				name = stock.Name
+				total += line.Quantity
+			}
+
+			var inventoryItemID *uint
+			if line.InventoryItemID != 0 {
+				inventoryItemID = &line.InventoryItemID
+			}
+			quantity := line.Quantity
+			if line.DonationItemID == nil {
+				if err := tx.Create(&models.DonationItem{
+					DonationID:       donationID,
+					Name:             name,
+					Status:           models.DonationItemReceived,
+					ReceivedQuantity: &quantity,
+					InventoryItemID:  inventoryItemID,
+					IntakeNotes:      intakeNotes(line.Notes, "Not declared by the donor"),
+					ReceivedAt:       &now,
+					ReceivedBy:       &receivedBy,
+				}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			counted[*line.DonationItemID] = true
+			if err := tx.Model(&models.DonationItem{}).Where("id = ?", *line.DonationItemID).Updates(map[string]interface{}{
+				"status":            models.DonationItemReceived,
+				"received_quantity": quantity,
+				"inventory_item_id": inventoryItemID,
+				"intake_notes":      strings.TrimSpace(line.Notes),
+				"received_at":       now,
+				"received_by":       receivedBy,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, item := range declared {
+			if counted[item.ID] {
+				continue
+			}
+			if err := tx.Model(&item).Updates(map[string]interface{}{
+				"status":            models.DonationItemReceived,
+				"received_quantity": 0,
+				"intake_notes":      "Not received",
+				"received_at":       now,
+				"received_by":       receivedBy,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{"quantity": total}
+		if donation.ReceivedAt == nil {
+			updates["received_at"] = now
+			updates["received_by"] = receivedBy
+		}
+		if donation.Status == models.DonationStatusPending {
+			updates["status"] = models.DonationStatusReceived
+			markedReceived = !donation.ReceiptSent
+		}
+		return tx.Model(&donation).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	intake, err := ds.Intake(donationID)
+	return intake, markedReceived, err
+}
+
+// Discrepancies returns the items counted since the given time whose received
+// quantity differs from what was declared, most recent first
+func (ds *DonationIntakeService) Discrepancies(since time.Time) ([]IntakeDiscrepancy, error) {
+	var items []models.DonationItem
+	if err := ds.db.Where("received_quantity IS NOT NULL AND received_quantity <> quantity AND received_at >= ?", since).
+		Order("received_at DESC, id DESC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return intakeDiscrepancies(items), nil
+}
+
+// validateIntakeLines checks the lines counted at intake against the declared items
+func validateIntakeLines(declared []models.DonationItem, lines []IntakeLine) error {
+	declaredIDs := make(map[uint]bool, len(declared))
+	for _, item := range declared {
+		declaredIDs[item.ID] = true
+	}
+	seen := make(map[uint]bool, len(lines))
+	received := false
+	for i, line := range lines {
+		if line.Quantity < 0 {
+			return fmt.Errorf("%w: line %d has a negative quantity", ErrIntakeInvalid, i+1)
+		}
+		if line.DonationItemID != nil {
+			if !declaredIDs[*line.DonationItemID] {
+				return fmt.Errorf("%w: item %d is not part of this donation", ErrIntakeInvalid, *line.DonationItemID)
+			}
+			if seen[*line.DonationItemID] {
+				return fmt.Errorf("%w: item %d is counted twice", ErrIntakeInvalid, *line.DonationItemID)
+			}
+			seen[*line.DonationItemID] = true
+		} else if line.Quantity == 0 {
+			return fmt.Errorf("%w: line %d has nothing to receive", ErrIntakeInvalid, i+1)
+		}
+		if line.Quantity > 0 && line.InventoryItemID == 0 {
+			return fmt.Errorf("%w: line %d needs a stock item to book into", ErrIntakeInvalid, i+1)
+		}
+		received = received || line.Quantity > 0
+	}
+	if !received {
+		return fmt.Errorf("%w: no goods were counted, cancel the donation if nothing arrived", ErrIntakeInvalid)
+	}
+	return nil
+}
+
+// intakeDiscrepancies lists the counted items whose received quantity differs
+// from the declaration
+func intakeDiscrepancies(items []models.DonationItem) []IntakeDiscrepancy {
+	discrepancies := []IntakeDiscrepancy{}
+	for _, item := range items {
+		if item.ReceivedQuantity == nil || *item.ReceivedQuantity == item.Quantity {
+			continue
+		}
+		discrepancies = append(discrepancies, IntakeDiscrepancy{
+			DonationItemID: item.ID,
+			DonationID:     item.DonationID,
+			Name:           item.Name,
+			Declared:       item.Quantity,
+			Received:       *item.ReceivedQuantity,
+			Difference:     *item.ReceivedQuantity - item.Quantity,
+			Notes:          item.IntakeNotes,
+			ReceivedAt:     item.ReceivedAt,
+		})
+	}
+	return discrepancies
+}
+
+// intakeNotes returns the notes given at intake, or the fallback when there are none
+func intakeNotes(notes, fallback string) string {
+	if notes = strings.TrimSpace(notes); notes != "" {
+		return notes
+	}
+	return fallback
+}
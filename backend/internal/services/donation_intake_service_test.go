@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestValidateIntakeLines(t *testing.T) {
+	declared := []models.DonationItem{{ID: 1, Quantity: 10}, {ID: 2, Quantity: 4}}
+	id := func(v uint) *uint { return &v }
+
+	tests := []struct {
+		name     string
+		declared []models.DonationItem
+		lines    []IntakeLine
+		wantErr  bool
+	}{
+		{name: "all declared items counted", declared: declared, lines: []IntakeLine{
+			{DonationItemID: id(1), InventoryItemID: 7, Quantity: 10},
+			{DonationItemID: id(2), InventoryItemID: 8, Quantity: 3},
+		}},
+		{name: "declared item missing", declared: declared, lines: []IntakeLine{
+			{DonationItemID: id(1), Quantity: 0},
+			{DonationItemID: id(2), InventoryItemID: 8, Quantity: 4},
+		}},
+		{name: "nothing arrived", declared: declared, lines: []IntakeLine{{DonationItemID: id(1), Quantity: 0}}, wantErr: true},
+		{name: "no lines", declared: declared, wantErr: true},
+		{name: "extra undeclared goods", declared: declared, lines: []IntakeLine{{InventoryItemID: 9, Quantity: 2}}},
+		{name: "nothing declared or counted", wantErr: true},
+		{name: "item from another donation", declared: declared, lines: []IntakeLine{{DonationItemID: id(3), InventoryItemID: 7, Quantity: 1}}, wantErr: true},
+		{name: "item counted twice", declared: declared, lines: []IntakeLine{
+			{DonationItemID: id(1), InventoryItemID: 7, Quantity: 5},
+			{DonationItemID: id(1), InventoryItemID: 7, Quantity: 5},
+		}, wantErr: true},
+		{name: "negative quantity", declared: declared, lines: []IntakeLine{{DonationItemID: id(1), InventoryItemID: 7, Quantity: -1}}, wantErr: true},
+		{name: "goods without a stock item", declared: declared, lines: []IntakeLine{{DonationItemID: id(1), Quantity: 10}}, wantErr: true},
+		{name: "empty undeclared line", declared: declared, lines: []IntakeLine{{InventoryItemID: 9}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateIntakeLines(tt.declared, tt.lines)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateIntakeLines() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrIntakeInvalid) {
+				t.Errorf("error %v does not wrap ErrIntakeInvalid", err)
+			}
+		})
+	}
+}
+
+func TestIntakeDiscrepancies(t *testing.T) {
+	count := func(v int) *int { return &v }
+	items := []models.DonationItem{
+		{ID: 1, DonationID: 5, Name: "Rice", Quantity: 10, ReceivedQuantity: count(10)},
+		{ID: 2, DonationID: 5, Name: "Beans", Quantity: 6, ReceivedQuantity: count(4), IntakeNotes: "2 tins dented"},
+		{ID: 3, DonationID: 5, Name: "Pasta", Quantity: 3},
+		{ID: 4, DonationID: 5, Name: "Soap", Quantity: 0, ReceivedQuantity: count(2)},
+	}
+
+	got := intakeDiscrepancies(items)
+	if len(got) != 2 {
+		t.Fatalf("got %d discrepancies, want 2: %+v", len(got), got)
+	}
+	if got[0].DonationItemID != 2 || got[0].Difference != -2 || got[0].Notes != "2 tins dented" {
+		t.Errorf("first discrepancy = %+v, want Beans short by 2", got[0])
+	}
+	if got[1].DonationItemID != 4 || got[1].Declared != 0 || got[1].Difference != 2 {
+		t.Errorf("second discrepancy = %+v, want 2 undeclared Soap", got[1])
+	}
+}
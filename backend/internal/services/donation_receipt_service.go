@@ -0,0 +1,309 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+)
+
+// Donation receipt errors
+var (
+	ErrDonationReceiptNoEmail = errors.New("no email address to send the receipt to")
+)
+
+// DonationReceiptFilter narrows the receipt register
+type DonationReceiptFilter struct {
+	Year    int
+	DonorID uint
+	Page    int
+	Limit   int
+}
+
+// DonationReceiptService keeps the numbered register of donation receipts, renders
+// them as PDFs and emails them to donors
+type DonationReceiptService struct {
+	db *gorm.DB
+}
+
+// NewDonationReceiptService creates a new donation receipt service
+func NewDonationReceiptService() *DonationReceiptService {
+	return &DonationReceiptService{db: db.DB}
+}
+
+// Issue returns a donation's receipt, adding it to the register the first time.
+// Numbers are given out one at a time so the register has no gaps or repeats.
+func (rs *DonationReceiptService) Issue(donationID uint, now time.Time) (*models.DonationReceipt, error) {
+	var receipt models.DonationReceipt
+	err := rs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "donation_receipts").Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("donation_id = ?", donationID).First(&receipt).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var donation models.Donation
+		if err := tx.First(&donation, donationID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDonorDonationNotFound
+			}
+			return err
+		}
+		if !slices.Contains(taxYearDonationStatuses, donation.Status) {
+			return ErrDonationReceiptUnavailable
+		}
+
+		var donor *models.User
+		if donorID := donationDonor(donation); donorID != nil {
+			var user models.User
+			if err := tx.Select("id", "first_name", "last_name", "email").First(&user, *donorID).Error; err == nil {
+				donor = &user
+			}
+		}
+
+		var last int
+		if err := tx.Model(&models.DonationReceipt{}).Where("year = ?", now.Year()).
+			Select("COALESCE(MAX(sequence), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+
+		receipt = buildDonationReceipt(donation, donor, now.Year(), last+1, now)
+		return tx.Create(&receipt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// Register returns a page of the receipt register, most recent first
+func (rs *DonationReceiptService) Register(filter DonationReceiptFilter) ([]models.DonationReceipt, int64, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+
+	query := rs.db.Model(&models.DonationReceipt{})
+	if filter.Year > 0 {
+		query = query.Where("year = ?", filter.Year)
+	}
+	if filter.DonorID > 0 {
+		query = query.Where("donor_id = ?", filter.DonorID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var receipts []models.DonationReceipt
+	err := query.Order("year DESC, sequence DESC").
+		Offset((filter.Page - 1) * filter.Limit).Limit(filter.Limit).
+		Find(&receipts).Error
+	return receipts, total, err
+}
+
+// Render draws a receipt as a PDF. The donation's current refunds and Gift Aid
+// status are shown alongside the details recorded when the receipt was issued.
+func (rs *DonationReceiptService) Render(receipt *models.DonationReceipt) ([]byte, error) {
+	donation, err := rs.describe(receipt)
+	if err != nil {
+		return nil, err
+	}
+	return renderDonationReceipt(receipt, *donation), nil
+}
+
+// describe returns the receipted donation as its donor sees it
+func (rs *DonationReceiptService) describe(receipt *models.DonationReceipt) (*DonorDonation, error) {
+	var donation models.Donation
+	if err := rs.db.First(&donation, receipt.DonationID).Error; err != nil {
+		return nil, err
+	}
+
+	ds := &DonorDonationService{db: rs.db}
+	declared := donation.GiftAidID != nil || (receipt.DonorID != nil && ds.giftAidDeclared(*receipt.DonorID))
+	described, err := ds.describe([]models.Donation{donation}, declared)
+	if err != nil {
+		return nil, err
+	}
+	return &described[0], nil
+}
+
+// Send emails a donation's receipt with the PDF attached, issuing it first if
+// needed. The outcome is recorded on the register entry.
+func (rs *DonationReceiptService) Send(donationID uint, now time.Time) (*models.DonationReceipt, error) {
+	receipt, err := rs.Issue(donationID, now)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Email == "" {
+		rs.recordEmail(receipt, now, ErrDonationReceiptNoEmail)
+		return receipt, ErrDonationReceiptNoEmail
+	}
+
+	donation, err := rs.describe(receipt)
+	if err != nil {
+		return receipt, err
+	}
+
+	service := notifications.GetService()
+	if service == nil {
+		return receipt, fmt.Errorf("notification service is not initialized")
+	}
+
+	user := models.User{
+		FirstName:               receipt.DonorName,
+		Email:                   receipt.Email,
+		NotificationPreferences: &models.NotificationPreferences{EmailEnabled: true},
+	}
+	if receipt.DonorID != nil {
+		var donor models.User
+		if err := rs.db.Preload("NotificationPreferences").First(&donor, *receipt.DonorID).Error; err == nil {
+			user = donor
+		}
+	}
+
+	// The email template shows an amount for monetary donations and the items otherwise
+	donationType, amount := "goods", ""
+	if receipt.Currency != "" {
+		donationType, amount = "monetary", fmt.Sprintf("%.2f", receipt.Amount)
+	}
+	data := notifications.NotificationData{
+		To:               receipt.Email,
+		Subject:          "Your donation receipt " + receipt.Number,
+		TemplateType:     notifications.DonationReceived,
+		NotificationType: notifications.EmailNotification,
+		TemplateData: map[string]interface{}{
+			"Name":             receipt.DonorName,
+			"ReceiptNumber":    receipt.Number,
+			"DonationType":     donationType,
+			"Amount":           amount,
+			"Currency":         receipt.Currency,
+			"Goods":            donation.Description,
+			"Date":             receipt.IssuedAt.Format("January 2, 2006"),
+			"ID":               receipt.DonationID,
+			"OrganizationName": BrandName(""),
+		},
+		Attachments: []notifications.EmailAttachment{{
+			Filename:    fmt.Sprintf("receipt-%s.pdf", receipt.Number),
+			ContentType: "application/pdf",
+			Content:     renderDonationReceipt(receipt, *donation),
+		}},
+	}
+	sendErr := service.SendNotification(data, user)
+	rs.recordEmail(receipt, now, sendErr)
+	if sendErr != nil {
+		return receipt, sendErr
+	}
+
+	if err := rs.db.Model(&models.Donation{}).Where("id = ?", donationID).Update("receipt_sent", true).Error; err != nil {
+		return receipt, err
+	}
+	return receipt, nil
+}
+
+// recordEmail stores when a receipt was emailed, or why it could not be
+func (rs *DonationReceiptService) recordEmail(receipt *models.DonationReceipt, now time.Time, sendErr error) {
+	updates := map[string]interface{}{"email_error": ""}
+	if sendErr != nil {
+		updates["email_error"] = sendErr.Error()
+	} else {
+		updates["emailed_at"] = now
+	}
+	if err := rs.db.Model(receipt).Updates(updates).Error; err != nil {
+		log.Printf("Failed to record receipt %s email: %v", receipt.Number, err)
+	}
+}
+
+// SendReceiptWhenReceived emails the receipt for a donation that has just been
+// marked received. Failures are logged and left on the register for staff to resend.
+func SendReceiptWhenReceived(donationID uint) {
+	if receipt, err := NewDonationReceiptService().Send(donationID, time.Now()); err != nil {
+		number := ""
+		if receipt != nil {
+			number = receipt.Number
+		}
+		log.Printf("Failed to send receipt %s for donation %d: %v", number, donationID, err)
+	}
+}
+
+// buildDonationReceipt records the details printed on a new receipt
+func buildDonationReceipt(donation models.Donation, donor *models.User, year, sequence int, now time.Time) models.DonationReceipt {
+	receipt := models.DonationReceipt{
+		Number:     fmt.Sprintf("REC-%d-%06d", year, sequence),
+		Year:       year,
+		Sequence:   sequence,
+		DonationID: donation.ID,
+		DonorID:    donationDonor(donation),
+		DonorName:  strings.TrimSpace(donation.Name),
+		Email:      strings.TrimSpace(donation.ContactEmail),
+		IssuedAt:   now,
+	}
+	if donor != nil {
+		if name := strings.TrimSpace(donor.FirstName + " " + donor.LastName); name != "" {
+			receipt.DonorName = name
+		}
+		if donor.Email != "" {
+			receipt.Email = donor.Email
+		}
+	}
+
+	if donation.Type == models.DonationTypeMoney || donation.Type == "monetary" {
+		receipt.Amount = roundPence(donation.Amount)
+		receipt.Currency = strings.ToUpper(donation.Currency)
+		if receipt.Currency == "" {
+			receipt.Currency = "GBP"
+		}
+	} else {
+		receipt.GoodsValue = roundPence(donation.GoodsValue)
+	}
+	return receipt
+}
+
+// renderDonationReceipt lays out a receipt PDF
+func renderDonationReceipt(receipt *models.DonationReceipt, donation DonorDonation) []byte {
+	doc := BrandedPDF("Donation receipt "+receipt.Number, "")
+	doc.Heading(BrandName("")+" - Donation Receipt").
+		Blank().
+		Field("Receipt number", receipt.Number).
+		Field("Issued", receipt.IssuedAt.In(time.Local).Format("2 January 2006"))
+	if receipt.DonorName != "" {
+		doc.Field("Donor", receipt.DonorName)
+	}
+	doc.Field("Donation date", donation.Date.In(time.Local).Format("2 January 2006"))
+
+	if receipt.Currency != "" {
+		doc.Field("Amount", fmt.Sprintf("%s %.2f", receipt.Currency, receipt.Amount))
+		if donation.Refunded > 0 {
+			doc.Field("Refunded", fmt.Sprintf("%s %.2f", receipt.Currency, donation.Refunded))
+		}
+	} else {
+		doc.Field("Donated", donation.Description)
+		if receipt.GoodsValue > 0 {
+			doc.Field("Estimated value", fmt.Sprintf("GBP %.2f", receipt.GoodsValue))
+		}
+	}
+	if donation.Campaign != nil {
+		doc.Field("Given to", donation.Campaign.Name)
+	}
+	if donation.GiftAidStatus == GiftAidClaimable {
+		doc.Field("Gift Aid", fmt.Sprintf("We will claim GBP %.2f from HMRC on this donation", donation.GiftAid))
+	}
+	doc.Blank().Line("Thank you for supporting our community.")
+	return doc.Bytes()
+}
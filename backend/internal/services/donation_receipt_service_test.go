@@ -0,0 +1,63 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestBuildDonationReceipt(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	donorID := uint(4)
+
+	tests := []struct {
+		name         string
+		donation     models.Donation
+		donor        *models.User
+		wantName     string
+		wantEmail    string
+		wantCurrency string
+		wantAmount   float64
+		wantGoods    float64
+	}{
+		{
+			name:         "registered donor",
+			donation:     models.Donation{ID: 12, UserID: &donorID, Type: models.DonationTypeMoney, Amount: 25.005, Currency: "gbp", ContactEmail: "old@example.org"},
+			donor:        &models.User{FirstName: "Amira", LastName: "Khan", Email: "amira@example.org"},
+			wantName:     "Amira Khan",
+			wantEmail:    "amira@example.org",
+			wantCurrency: "GBP",
+			wantAmount:   25.01,
+		},
+		{
+			name:         "guest bank transfer",
+			donation:     models.Donation{ID: 13, Name: " J Smith ", ContactEmail: "js@example.org", Type: "monetary", Amount: 40},
+			wantName:     "J Smith",
+			wantEmail:    "js@example.org",
+			wantCurrency: "GBP",
+			wantAmount:   40,
+		},
+		{
+			name:      "goods",
+			donation:  models.Donation{ID: 14, Name: "Corner Shop", Type: models.DonationTypeGoods, GoodsValue: 60},
+			wantName:  "Corner Shop",
+			wantGoods: 60,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt := buildDonationReceipt(tt.donation, tt.donor, 2026, 7, now)
+			if receipt.Number != "REC-2026-000007" || receipt.DonationID != tt.donation.ID {
+				t.Errorf("numbered %q for donation %d", receipt.Number, receipt.DonationID)
+			}
+			if receipt.DonorName != tt.wantName || receipt.Email != tt.wantEmail {
+				t.Errorf("donor = %q <%s>, want %q <%s>", receipt.DonorName, receipt.Email, tt.wantName, tt.wantEmail)
+			}
+			if receipt.Currency != tt.wantCurrency || receipt.Amount != tt.wantAmount || receipt.GoodsValue != tt.wantGoods {
+				t.Errorf("amount = %s %.2f, goods %.2f", receipt.Currency, receipt.Amount, receipt.GoodsValue)
+			}
+		})
+	}
+}
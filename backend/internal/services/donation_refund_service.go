@@ -0,0 +1,388 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/stripe/stripe-go/v74"
+	"github.com/stripe/stripe-go/v74/refund"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRefundNotMonetary    = errors.New("only monetary donations can be refunded")
+	ErrRefundNoPayment      = errors.New("donation has no card payment to refund")
+	ErrRefundExceedsBalance = errors.New("refund amount is more than the amount left to refund")
+	ErrRefundDisputed       = errors.New("donation is under a chargeback and cannot be refunded")
+)
+
+// DonationRefundService issues refunds through Stripe and keeps donations, payments
+// and Gift Aid claims in step with refunds and chargebacks
+type DonationRefundService struct {
+	db *gorm.DB
+}
+
+// NewDonationRefundService creates a new donation refund service
+func NewDonationRefundService() *DonationRefundService {
+	return &DonationRefundService{
+		db: db.DB,
+	}
+}
+
+// RefundDonation refunds a card donation through Stripe. An amount of zero refunds
+// everything not already refunded.
+func (rs *DonationRefundService) RefundDonation(donationID uint, amount float64, reason string, adminID uint) (*models.DonationRefund, error) {
+	var donation models.Donation
+	if err := rs.db.First(&donation, donationID).Error; err != nil {
+		return nil, err
+	}
+	if donation.Type != "monetary" && donation.Type != "money" {
+		return nil, ErrRefundNotMonetary
+	}
+	if donation.Status == models.DonationStatusDisputed {
+		return nil, ErrRefundDisputed
+	}
+
+	payment, err := rs.paymentForDonation(&donation)
+	if err != nil {
+		return nil, ErrRefundNoPayment
+	}
+
+	remaining := roundPence(donation.Amount - donation.RefundedAmount)
+	if amount <= 0 {
+		amount = remaining
+	}
+	if amount <= 0 || roundPence(amount) > remaining {
+		return nil, ErrRefundExceedsBalance
+	}
+
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(payment.StripePaymentID),
+		Amount:        stripe.Int64(int64(math.Round(amount * 100))),
+	}
+	params.Reason = stripeRefundReason(reason)
+	params.AddMetadata("donation_id", fmt.Sprintf("%d", donation.ID))
+
+	r, err := refund.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("payment provider refused the refund: %w", err)
+	}
+
+	return rs.recordRefund(payment.StripePaymentID, models.RefundKindRefund, r.ID, float64(r.Amount)/100, false, reason, &adminID)
+}
+
+// RefundPayment refunds a Stripe payment identified by its PaymentIntent. An amount
+// of zero refunds the full remaining balance.
+func (rs *DonationRefundService) RefundPayment(paymentIntentID string, amountPence int64, reason string, adminID uint) (*models.DonationRefund, error) {
+	var payment models.Payment
+	if err := rs.db.Where("stripe_payment_id = ?", paymentIntentID).First(&payment).Error; err != nil {
+		return nil, err
+	}
+	if payment.DonationID != nil {
+		return rs.RefundDonation(*payment.DonationID, float64(amountPence)/100, reason, adminID)
+	}
+
+	params := &stripe.RefundParams{PaymentIntent: stripe.String(paymentIntentID)}
+	if amountPence > 0 {
+		params.Amount = stripe.Int64(amountPence)
+	}
+	params.Reason = stripeRefundReason(reason)
+	r, err := refund.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("payment provider refused the refund: %w", err)
+	}
+
+	return rs.recordRefund(paymentIntentID, models.RefundKindRefund, r.ID, float64(r.Amount)/100, false, reason, &adminID)
+}
+
+// SyncChargeRefunds records refunds made outside the system, for example from the
+// Stripe dashboard, when a charge.refunded event arrives. Only the part of the
+// provider's refunded total not already recorded is stored.
+func (rs *DonationRefundService) SyncChargeRefunds(paymentIntentID, reference string, totalRefunded float64) (*models.DonationRefund, error) {
+	return rs.recordRefund(paymentIntentID, models.RefundKindRefund, reference, totalRefunded, true, "Refunded at payment provider", nil)
+}
+
+// OpenChargeback marks a donation as disputed when the donor's bank opens a chargeback
+func (rs *DonationRefundService) OpenChargeback(paymentIntentID, disputeID string, amount float64, reason string, evidenceDueBy *time.Time) (*models.DonationRefund, error) {
+	var existing models.DonationRefund
+	if err := rs.db.Where("provider_reference = ?", disputeID).First(&existing).Error; err == nil {
+		return &existing, nil
+	}
+
+	var payment models.Payment
+	if err := rs.db.Where("stripe_payment_id = ?", paymentIntentID).First(&payment).Error; err != nil {
+		return nil, err
+	}
+
+	chargeback := models.DonationRefund{
+		DonationID:        payment.DonationID,
+		PaymentID:         &payment.ID,
+		Kind:              models.RefundKindChargeback,
+		ProviderReference: disputeID,
+		Amount:            amount,
+		Currency:          payment.Currency,
+		Reason:            reason,
+		Status:            models.RefundStatusNeedsResponse,
+		EvidenceDueBy:     evidenceDueBy,
+	}
+
+	err := rs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&chargeback).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&payment).Update("status", models.DonationStatusDisputed).Error; err != nil {
+			return err
+		}
+		if payment.DonationID != nil {
+			return tx.Model(&models.Donation{}).Where("id = ?", *payment.DonationID).
+				Update("status", models.DonationStatusDisputed).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &chargeback, nil
+}
+
+// CloseChargeback applies the outcome of a chargeback. A lost chargeback is treated
+// as a refund; a won chargeback restores the donation's previous status.
+func (rs *DonationRefundService) CloseChargeback(disputeID string, won bool) (*models.DonationRefund, error) {
+	var chargeback models.DonationRefund
+	if err := rs.db.Where("provider_reference = ? AND kind = ?", disputeID, models.RefundKindChargeback).
+		First(&chargeback).Error; err != nil {
+		return nil, err
+	}
+	if chargeback.ResolvedAt != nil {
+		return &chargeback, nil
+	}
+
+	now := time.Now()
+	err := rs.db.Transaction(func(tx *gorm.DB) error {
+		status := models.RefundStatusWon
+		if !won {
+			status = models.RefundStatusLost
+		}
+		if err := tx.Model(&chargeback).Updates(map[string]interface{}{
+			"status":      status,
+			"resolved_at": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		var payment models.Payment
+		if chargeback.PaymentID == nil || tx.First(&payment, *chargeback.PaymentID).Error != nil {
+			return nil
+		}
+		if !won {
+			return rs.applyRefund(tx, &payment, &chargeback, now)
+		}
+
+		// Funds were returned to the charity, so restore the status the refunds imply
+		if err := tx.Model(&payment).Update("status", refundedStatus(payment.Amount, payment.RefundedAmount, "succeeded")).Error; err != nil {
+			return err
+		}
+		if payment.DonationID != nil {
+			var donation models.Donation
+			if err := tx.First(&donation, *payment.DonationID).Error; err == nil {
+				return tx.Model(&donation).Update("status",
+					refundedStatus(donation.Amount, donation.RefundedAmount, models.DonationStatusCompleted)).Error
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	chargeback.ResolvedAt = &now
+	return &chargeback, nil
+}
+
+// ApplyGiftAidAdjustments marks pending adjustments as included in a Gift Aid claim
+func (rs *DonationRefundService) ApplyGiftAidAdjustments(ids []uint, claimReference string, adminID uint) (int64, error) {
+	now := time.Now()
+	result := rs.db.Model(&models.GiftAidAdjustment{}).
+		Where("id IN ? AND status = ?", ids, models.GiftAidAdjustmentPending).
+		Updates(map[string]interface{}{
+			"status":          models.GiftAidAdjustmentApplied,
+			"claim_reference": claimReference,
+			"applied_at":      now,
+			"applied_by":      adminID,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// recordRefund stores a refund against the payment for a PaymentIntent and updates the
+// payment, the donation and any Gift Aid claim. When total is true, amount is the
+// provider's running refunded total and only the part not yet recorded is stored; it
+// is worked out under the payment lock so a refund being recorded at the same time is
+// not counted twice. A nil refund means there was nothing left to record.
+func (rs *DonationRefundService) recordRefund(paymentIntentID, kind, reference string, amount float64, total bool, reason string, initiatedBy *uint) (*models.DonationRefund, error) {
+	var record models.DonationRefund
+	now := time.Now()
+
+	err := rs.db.Transaction(func(tx *gorm.DB) error {
+		var payment models.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("stripe_payment_id = ?", paymentIntentID).First(&payment).Error; err != nil {
+			return err
+		}
+
+		if reference != "" {
+			var existing int64
+			tx.Model(&models.DonationRefund{}).Where("provider_reference = ?", reference).Count(&existing)
+			if existing > 0 {
+				return tx.Where("provider_reference = ?", reference).First(&record).Error
+			}
+		}
+
+		if total {
+			var recorded float64
+			if err := tx.Model(&models.DonationRefund{}).
+				Where("payment_id = ? AND kind = ? AND status = ?", payment.ID, models.RefundKindRefund, models.RefundStatusSucceeded).
+				Select("COALESCE(SUM(amount), 0)").
+				Scan(&recorded).Error; err != nil {
+				return err
+			}
+			amount = unrecordedRefund(amount, recorded)
+			if amount <= 0 {
+				return nil
+			}
+		}
+
+		record = models.DonationRefund{
+			DonationID:        payment.DonationID,
+			PaymentID:         &payment.ID,
+			Kind:              kind,
+			ProviderReference: reference,
+			Amount:            amount,
+			Currency:          payment.Currency,
+			Reason:            reason,
+			Status:            models.RefundStatusSucceeded,
+			InitiatedBy:       initiatedBy,
+			ResolvedAt:        &now,
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		return rs.applyRefund(tx, &payment, &record, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if record.ID == 0 {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// applyRefund moves money returned to the donor off the payment and donation totals and
+// raises a Gift Aid adjustment if the donor had made a Gift Aid declaration
+func (rs *DonationRefundService) applyRefund(tx *gorm.DB, payment *models.Payment, record *models.DonationRefund, now time.Time) error {
+	payment.RefundedAmount = roundPence(payment.RefundedAmount + record.Amount)
+	if err := tx.Model(payment).Updates(map[string]interface{}{
+		"refunded_amount": payment.RefundedAmount,
+		"refund_amount":   payment.RefundedAmount,
+		"refunded_at":     now,
+		"status":          refundedStatus(payment.Amount, payment.RefundedAmount, "succeeded"),
+	}).Error; err != nil {
+		return err
+	}
+
+	if payment.DonationID == nil {
+		return nil
+	}
+
+	var donation models.Donation
+	if err := tx.First(&donation, *payment.DonationID).Error; err != nil {
+		return nil
+	}
+	donation.RefundedAmount = roundPence(donation.RefundedAmount + record.Amount)
+	if err := tx.Model(&donation).Updates(map[string]interface{}{
+		"refunded_amount": donation.RefundedAmount,
+		"refunded_at":     now,
+		"status":          refundedStatus(donation.Amount, donation.RefundedAmount, models.DonationStatusCompleted),
+	}).Error; err != nil {
+		return err
+	}
+
+	donorID := donation.DonorID
+	if donorID == nil {
+		donorID = donation.UserID
+	}
+	if donorID == nil {
+		return nil
+	}
+
+	var profile models.DonorProfile
+	if err := tx.Where("user_id = ?", *donorID).First(&profile).Error; err != nil || !profile.GiftAidEligible {
+		return nil
+	}
+
+	reason := fmt.Sprintf("Donation %s", strings.ReplaceAll(record.Kind, "_", " "))
+	if record.Reason != "" {
+		reason += ": " + record.Reason
+	}
+	return tx.Create(&models.GiftAidAdjustment{
+		DonationID:     donation.ID,
+		RefundID:       record.ID,
+		DonorID:        donorID,
+		DonationAmount: record.Amount,
+		TaxAmount:      roundPence(record.Amount * models.GiftAidRate),
+		Reason:         reason,
+		Status:         models.GiftAidAdjustmentPending,
+	}).Error
+}
+
+// paymentForDonation finds the Stripe payment a donation was paid with
+func (rs *DonationRefundService) paymentForDonation(donation *models.Donation) (*models.Payment, error) {
+	var payment models.Payment
+	if err := rs.db.Where("(donation_id = ? OR stripe_payment_id = ?) AND stripe_payment_id <> ''", donation.ID, donation.PaymentID).
+		First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// refundedStatus returns the status for an amount after refunds
+func refundedStatus(amount, refunded float64, paidStatus string) string {
+	switch {
+	case refunded <= 0:
+		return paidStatus
+	case roundPence(refunded) >= roundPence(amount):
+		return models.DonationStatusRefunded
+	default:
+		return models.DonationStatusPartiallyRefunded
+	}
+}
+
+// unrecordedRefund returns the part of the provider's refunded total not yet recorded
+func unrecordedRefund(providerTotal, recorded float64) float64 {
+	missing := roundPence(providerTotal - recorded)
+	if missing < 0 {
+		return 0
+	}
+	return missing
+}
+
+// stripeRefundReason returns the reason to send to Stripe, which only accepts its own
+// reason codes. Free-text reasons are kept on the refund record only.
+func stripeRefundReason(reason string) *string {
+	switch reason {
+	case string(stripe.RefundReasonDuplicate), string(stripe.RefundReasonFraudulent), string(stripe.RefundReasonRequestedByCustomer):
+		return stripe.String(reason)
+	}
+	return nil
+}
+
+// roundPence rounds an amount to whole pence
+func roundPence(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestUnrecordedRefund(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    float64
+		recorded float64
+		want     float64
+	}{
+		{"nothing recorded", 25, 0, 25},
+		{"partly recorded", 25, 10, 15},
+		{"already recorded", 25, 25, 0},
+		{"recorded more than provider total", 10, 25, 0},
+		{"floating point noise", 0.3, 0.1 + 0.2, 0},
+	}
+	for _, tt := range tests {
+		if got := unrecordedRefund(tt.total, tt.recorded); got != tt.want {
+			t.Errorf("%s: got %.2f, want %.2f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRefundedStatus(t *testing.T) {
+	tests := []struct {
+		amount, refunded float64
+		want             string
+	}{
+		{50, 0, models.DonationStatusCompleted},
+		{50, 20, models.DonationStatusPartiallyRefunded},
+		{50, 49.999, models.DonationStatusRefunded},
+		{50, 50, models.DonationStatusRefunded},
+	}
+	for _, tt := range tests {
+		if got := refundedStatus(tt.amount, tt.refunded, models.DonationStatusCompleted); got != tt.want {
+			t.Errorf("refunded %.3f of %.2f: got %s, want %s", tt.refunded, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestStripeRefundReasonOnlyPassesStripeCodes(t *testing.T) {
+	for _, reason := range []string{"duplicate", "fraudulent", "requested_by_customer"} {
+		if got := stripeRefundReason(reason); got == nil || *got != reason {
+			t.Errorf("%q should be passed to Stripe", reason)
+		}
+	}
+	for _, reason := range []string{"", "Donor asked on the phone", "Duplicate"} {
+		if got := stripeRefundReason(reason); got != nil {
+			t.Errorf("%q should not be passed to Stripe, got %q", reason, *got)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidTaxYear = errors.New("tax year must look like 2025-26 or 2025")
+
+// taxYearDonationStatuses are the statuses of donations that were paid
+var taxYearDonationStatuses = []string{
+	models.DonationStatusCompleted,
+	models.DonationStatusPartiallyRefunded,
+	models.DonationStatusRefunded,
+	models.DonationStatusDisputed,
+	models.DonationStatusReceived,
+	models.DonationStatusProcessed,
+}
+
+// TaxYear is a UK tax year, running from 6 April to 5 April
+type TaxYear struct {
+	StartYear int
+	Start     time.Time // 6 April, inclusive
+	End       time.Time // 6 April the following year, exclusive
+}
+
+// Label returns the tax year in the form HMRC uses, e.g. "2025-26"
+func (ty TaxYear) Label() string {
+	return fmt.Sprintf("%d-%02d", ty.StartYear, (ty.StartYear+1)%100)
+}
+
+// NewTaxYear returns the tax year starting on 6 April of startYear in local time
+func NewTaxYear(startYear int) TaxYear {
+	return TaxYear{
+		StartYear: startYear,
+		Start:     time.Date(startYear, time.April, 6, 0, 0, 0, 0, time.Local),
+		End:       time.Date(startYear+1, time.April, 6, 0, 0, 0, 0, time.Local),
+	}
+}
+
+// TaxYearFor returns the tax year a moment falls in
+func TaxYearFor(t time.Time) TaxYear {
+	t = t.In(time.Local)
+	year := t.Year()
+	if t.Before(time.Date(year, time.April, 6, 0, 0, 0, 0, time.Local)) {
+		year--
+	}
+	return NewTaxYear(year)
+}
+
+// ParseTaxYear reads a tax year written as "2025-26", "2025/26" or its starting year "2025"
+func ParseTaxYear(value string) (TaxYear, error) {
+	value = strings.TrimSpace(value)
+	start, end, hasEnd := strings.Cut(strings.ReplaceAll(value, "/", "-"), "-")
+	startYear, err := strconv.Atoi(start)
+	if err != nil || startYear < 2000 || startYear > 2100 {
+		return TaxYear{}, ErrInvalidTaxYear
+	}
+	if hasEnd {
+		endYear, err := strconv.Atoi(end)
+		if err != nil || (endYear != (startYear+1)%100 && endYear != startYear+1) {
+			return TaxYear{}, ErrInvalidTaxYear
+		}
+	}
+	return NewTaxYear(startYear), nil
+}
+
+// TaxYearDonation is one donation in a donor's tax-year summary
+type TaxYearDonation struct {
+	DonationID           uint       `json:"donation_id"`
+	Reference            string     `json:"reference"`
+	Date                 time.Time  `json:"date"`
+	Status               string     `json:"status"`
+	Currency             string     `json:"currency"`
+	Amount               float64    `json:"amount"`
+	Refunded             float64    `json:"refunded"`
+	Net                  float64    `json:"net"`
+	GiftAidEligible      bool       `json:"gift_aid_eligible"`
+	GiftAid              float64    `json:"gift_aid"`
+	RefundedAt           *time.Time `json:"refunded_at,omitempty"`
+	RefundedAfterYearEnd bool       `json:"refunded_after_year_end"` // Refunded after 5 April, so earlier statements overstated it
+}
+
+// DonationTaxSummary totals a donor's money donations for a UK tax year, for their
+// self-assessment return
+type DonationTaxSummary struct {
+	TaxYear         string            `json:"tax_year"`
+	StartDate       string            `json:"start_date"` // Inclusive
+	EndDate         string            `json:"end_date"`   // Inclusive
+	GiftAidDeclared bool              `json:"gift_aid_declared"`
+	Currency        string            `json:"currency"`
+	DonationCount   int               `json:"donation_count"`
+	TotalDonated    float64           `json:"total_donated"`
+	TotalRefunded   float64           `json:"total_refunded"`
+	NetDonated      float64           `json:"net_donated"`
+	GiftAidEligible float64           `json:"gift_aid_eligible"` // Net GBP donations covered by a Gift Aid declaration
+	GiftAidClaimed  float64           `json:"gift_aid_claimed"`  // Basic rate tax the charity reclaims on them
+	GrossGiftAid    float64           `json:"gross_gift_aid"`    // Eligible donations plus the tax reclaimed
+	OtherCurrencies float64           `json:"other_currencies"`  // Net donations in other currencies, not in the totals
+	Donations       []TaxYearDonation `json:"donations"`
+}
+
+// DonationTaxService builds tax-year donation summaries for donors
+type DonationTaxService struct {
+	db *gorm.DB
+}
+
+// NewDonationTaxService creates a new donation tax service
+func NewDonationTaxService() *DonationTaxService {
+	return &DonationTaxService{
+		db: db.DB,
+	}
+}
+
+// Summary totals a donor's money donations received in a tax year. Refunds and lost
+// chargebacks are taken off the donation they returned, whenever they happened.
+func (dts *DonationTaxService) Summary(userID uint, taxYear TaxYear) (*DonationTaxSummary, error) {
+	var profile models.DonorProfile
+	declared := dts.db.Where("user_id = ?", userID).First(&profile).Error == nil && profile.GiftAidEligible
+
+	var donations []models.Donation
+	if err := dts.db.
+		Where("(user_id = ? OR donor_id = ?) AND type = ? AND status IN ?", userID, userID, models.DonationTypeMoney, taxYearDonationStatuses).
+		Where("COALESCE(received_at, created_at) >= ? AND COALESCE(received_at, created_at) < ?", taxYear.Start, taxYear.End).
+		Order("COALESCE(received_at, created_at) ASC, id ASC").
+		Find(&donations).Error; err != nil {
+		return nil, err
+	}
+
+	return buildTaxSummary(taxYear, declared, donations), nil
+}
+
+// buildTaxSummary totals donations already selected for a tax year
+func buildTaxSummary(taxYear TaxYear, declared bool, donations []models.Donation) *DonationTaxSummary {
+	summary := &DonationTaxSummary{
+		TaxYear:         taxYear.Label(),
+		StartDate:       taxYear.Start.Format("2006-01-02"),
+		EndDate:         taxYear.End.AddDate(0, 0, -1).Format("2006-01-02"),
+		GiftAidDeclared: declared,
+		Currency:        "GBP",
+		Donations:       []TaxYearDonation{},
+	}
+
+	for _, donation := range donations {
+		currency := strings.ToUpper(donation.Currency)
+		if currency == "" {
+			currency = "GBP"
+		}
+		refunded := donation.RefundedAmount
+		if refunded > donation.Amount {
+			refunded = donation.Amount
+		}
+		if refunded < 0 {
+			refunded = 0
+		}
+		date := donation.CreatedAt
+		if donation.ReceivedAt != nil {
+			date = *donation.ReceivedAt
+		}
+
+		entry := TaxYearDonation{
+			DonationID: donation.ID,
+			Reference:  fmt.Sprintf("REC-%d", donation.ID),
+			Date:       date,
+			Status:     donation.Status,
+			Currency:   currency,
+			Amount:     roundPence(donation.Amount),
+			Refunded:   roundPence(refunded),
+			Net:        roundPence(donation.Amount - refunded),
+		}
+		if refunded > 0 && donation.RefundedAt != nil {
+			entry.RefundedAt = donation.RefundedAt
+			entry.RefundedAfterYearEnd = !donation.RefundedAt.Before(taxYear.End)
+		}
+		// Gift Aid can only be claimed on sterling donations that were kept
+		if declared && currency == "GBP" && entry.Net > 0 && donation.Status != models.DonationStatusDisputed {
+			entry.GiftAidEligible = true
+			entry.GiftAid = roundPence(entry.Net * models.GiftAidRate)
+		}
+		summary.Donations = append(summary.Donations, entry)
+
+		if currency != "GBP" {
+			summary.OtherCurrencies = roundPence(summary.OtherCurrencies + entry.Net)
+			continue
+		}
+		summary.DonationCount++
+		summary.TotalDonated = roundPence(summary.TotalDonated + entry.Amount)
+		summary.TotalRefunded = roundPence(summary.TotalRefunded + entry.Refunded)
+		summary.NetDonated = roundPence(summary.NetDonated + entry.Net)
+		if entry.GiftAidEligible {
+			summary.GiftAidEligible = roundPence(summary.GiftAidEligible + entry.Net)
+			summary.GiftAidClaimed = roundPence(summary.GiftAidClaimed + entry.GiftAid)
+		}
+	}
+	summary.GrossGiftAid = roundPence(summary.GiftAidEligible + summary.GiftAidClaimed)
+	return summary
+}
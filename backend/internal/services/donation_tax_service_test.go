@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestTaxYearBoundaries(t *testing.T) {
+	cases := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2026, time.April, 5, 23, 59, 59, 0, time.Local), "2025-26"},
+		{time.Date(2026, time.April, 6, 0, 0, 0, 0, time.Local), "2026-27"},
+		{time.Date(2026, time.January, 1, 12, 0, 0, 0, time.Local), "2025-26"},
+		{time.Date(1999, time.December, 31, 12, 0, 0, 0, time.Local), "1999-00"},
+	}
+	for _, tc := range cases {
+		if got := TaxYearFor(tc.at).Label(); got != tc.want {
+			t.Errorf("TaxYearFor(%s) = %s, want %s", tc.at, got, tc.want)
+		}
+	}
+}
+
+func TestParseTaxYear(t *testing.T) {
+	for _, value := range []string{"2025-26", "2025/26", "2025", "2025-2026"} {
+		year, err := ParseTaxYear(value)
+		if err != nil || year.StartYear != 2025 {
+			t.Errorf("ParseTaxYear(%q) = %+v, %v", value, year, err)
+		}
+	}
+	for _, value := range []string{"", "25-26", "2025-27", "twenty"} {
+		if _, err := ParseTaxYear(value); !errors.Is(err, ErrInvalidTaxYear) {
+			t.Errorf("ParseTaxYear(%q) err = %v", value, err)
+		}
+	}
+}
+
+func TestBuildTaxSummaryHandlesRefunds(t *testing.T) {
+	year := NewTaxYear(2025)
+	afterYearEnd := time.Date(2026, time.May, 1, 0, 0, 0, 0, time.Local)
+	donations := []models.Donation{
+		{ID: 1, Amount: 100, Currency: "GBP", Status: models.DonationStatusCompleted},
+		{ID: 2, Amount: 50, RefundedAmount: 20, Currency: "GBP", Status: models.DonationStatusPartiallyRefunded, RefundedAt: &afterYearEnd},
+		{ID: 3, Amount: 30, RefundedAmount: 30, Currency: "gbp", Status: models.DonationStatusRefunded},
+		{ID: 4, Amount: 40, Currency: "EUR", Status: models.DonationStatusCompleted},
+		{ID: 5, Amount: 10, Currency: "GBP", Status: models.DonationStatusDisputed},
+	}
+
+	summary := buildTaxSummary(year, true, donations)
+	if summary.TaxYear != "2025-26" || summary.StartDate != "2025-04-06" || summary.EndDate != "2026-04-05" {
+		t.Fatalf("period %s %s to %s", summary.TaxYear, summary.StartDate, summary.EndDate)
+	}
+	if summary.DonationCount != 4 || summary.TotalDonated != 190 || summary.TotalRefunded != 50 || summary.NetDonated != 140 {
+		t.Fatalf("totals %+v", summary)
+	}
+	if summary.GiftAidEligible != 130 || summary.GiftAidClaimed != 32.5 || summary.GrossGiftAid != 162.5 {
+		t.Fatalf("gift aid %+v", summary)
+	}
+	if summary.OtherCurrencies != 40 {
+		t.Fatalf("other currencies %.2f", summary.OtherCurrencies)
+	}
+	if !summary.Donations[1].RefundedAfterYearEnd || summary.Donations[2].GiftAidEligible {
+		t.Fatalf("donations %+v", summary.Donations)
+	}
+
+	if undeclared := buildTaxSummary(year, false, donations); undeclared.GiftAidEligible != 0 {
+		t.Fatalf("gift aid counted without a declaration: %.2f", undeclared.GiftAidEligible)
+	}
+}
@@ -0,0 +1,420 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDonorDonationNotFound      = errors.New("donation not found")
+	ErrDonorDonationFilter        = errors.New("invalid donation filter")
+	ErrDonationReceiptUnavailable = errors.New("a receipt is only available once a donation has been received")
+)
+
+// Gift Aid status of a donation in a donor's history
+const (
+	GiftAidClaimable   = "claimable"    // Covered by the donor's declaration
+	GiftAidNotDeclared = "not_declared" // Could be claimed if the donor made a declaration
+	GiftAidNotEligible = "not_eligible" // Goods, other currencies, refunded or not yet paid
+)
+
+// donorDonationStatuses are the statuses donors can filter their history by
+var donorDonationStatuses = []string{
+	models.DonationStatusPending,
+	models.DonationStatusReceived,
+	models.DonationStatusProcessed,
+	models.DonationStatusCompleted,
+	models.DonationStatusPartiallyRefunded,
+	models.DonationStatusRefunded,
+	models.DonationStatusDisputed,
+	models.DonationStatusCancelled,
+}
+
+// DonorDonationFilter narrows a donor's donation history
+type DonorDonationFilter struct {
+	Statuses []string
+	Type     string
+	From     *time.Time // Inclusive
+	To       *time.Time // Exclusive
+	Page     int
+	Limit    int
+}
+
+// DonationCampaign is the appeal or donation drive a donation was made to
+type DonationCampaign struct {
+	Kind string `json:"kind"` // "appeal" or "drive"
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Team string `json:"team,omitempty"` // The drive team credited with the donation
+}
+
+// DonorDonation is one donation in a donor's own history
+type DonorDonation struct {
+	ID            uint              `json:"id"`
+	Date          time.Time         `json:"date"`
+	Type          string            `json:"type"`
+	Status        string            `json:"status"`
+	Description   string            `json:"description"`
+	Currency      string            `json:"currency"`
+	Amount        float64           `json:"amount"`
+	Refunded      float64           `json:"refunded"`
+	Net           float64           `json:"net"`
+	GoodsValue    float64           `json:"goods_value,omitempty"`
+	Quantity      int               `json:"quantity,omitempty"`
+	GiftAidStatus string            `json:"gift_aid_status"`
+	GiftAid       float64           `json:"gift_aid"` // Tax reclaimed when the donation is claimable
+	ReceiptNumber string            `json:"receipt_number,omitempty"`
+	ReceiptURL    string            `json:"receipt_url,omitempty"`
+	Campaign      *DonationCampaign `json:"campaign,omitempty"`
+}
+
+// DonorDonationPage is a page of a donor's donation history
+type DonorDonationPage struct {
+	Donations       []DonorDonation  `json:"donations"`
+	Total           int64            `json:"total"`
+	Page            int              `json:"page"`
+	Limit           int              `json:"limit"`
+	TotalPages      int64            `json:"total_pages"`
+	StatusCounts    map[string]int64 `json:"status_counts"` // Across all the donor's donations
+	GiftAidDeclared bool             `json:"gift_aid_declared"`
+}
+
+// DonorDonationService lets donors look up and download their own donations
+type DonorDonationService struct {
+	db *gorm.DB
+}
+
+// NewDonorDonationService creates a new donor donation service
+func NewDonorDonationService() *DonorDonationService {
+	return &DonorDonationService{
+		db: db.DB,
+	}
+}
+
+// ParseDonorDonationStatuses reads a comma-separated list of statuses to filter by
+func ParseDonorDonationStatuses(value string) ([]string, error) {
+	var statuses []string
+	for _, status := range strings.Split(value, ",") {
+		status = strings.ToLower(strings.TrimSpace(status))
+		if status == "" {
+			continue
+		}
+		if !slices.Contains(donorDonationStatuses, status) {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrDonorDonationFilter, status)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// List returns a page of the donor's donations, newest first
+func (ds *DonorDonationService) List(userID uint, filter DonorDonationFilter) (*DonorDonationPage, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+
+	page := &DonorDonationPage{
+		Donations:       []DonorDonation{},
+		Page:            filter.Page,
+		Limit:           filter.Limit,
+		StatusCounts:    map[string]int64{},
+		GiftAidDeclared: ds.giftAidDeclared(userID),
+	}
+
+	var counts []struct {
+		Status string
+		Count  int64
+	}
+	if err := ds.db.Model(&models.Donation{}).Select("status, COUNT(*) AS count").
+		Where("user_id = ? OR donor_id = ?", userID, userID).Group("status").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	for _, count := range counts {
+		page.StatusCounts[count.Status] = count.Count
+	}
+
+	query := ds.filtered(userID, filter)
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	page.TotalPages = (page.Total + int64(filter.Limit) - 1) / int64(filter.Limit)
+
+	var donations []models.Donation
+	if err := query.Order("created_at DESC, id DESC").
+		Offset((filter.Page - 1) * filter.Limit).Limit(filter.Limit).Find(&donations).Error; err != nil {
+		return nil, err
+	}
+	described, err := ds.describe(donations, page.GiftAidDeclared)
+	if err != nil {
+		return nil, err
+	}
+	page.Donations = described
+	return page, nil
+}
+
+// Export returns every donation matching the filter, oldest first, for a CSV download
+func (ds *DonorDonationService) Export(userID uint, filter DonorDonationFilter) ([]DonorDonation, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	var donations []models.Donation
+	if err := ds.filtered(userID, filter).Order("created_at ASC, id ASC").Find(&donations).Error; err != nil {
+		return nil, err
+	}
+	return ds.describe(donations, ds.giftAidDeclared(userID))
+}
+
+// Get returns one of the donor's donations
+func (ds *DonorDonationService) Get(userID, donationID uint) (*DonorDonation, error) {
+	var donation models.Donation
+	err := ds.db.Where("id = ? AND (user_id = ? OR donor_id = ?)", donationID, userID, userID).First(&donation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDonorDonationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	described, err := ds.describe([]models.Donation{donation}, ds.giftAidDeclared(userID))
+	if err != nil {
+		return nil, err
+	}
+	return &described[0], nil
+}
+
+// Receipt renders a PDF receipt for one of the donor's received donations. The
+// receipt is added to the register the first time it is downloaded or emailed.
+func (ds *DonorDonationService) Receipt(userID, donationID uint) (*DonorDonation, []byte, error) {
+	donation, err := ds.Get(userID, donationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if donation.ReceiptURL == "" {
+		return nil, nil, ErrDonationReceiptUnavailable
+	}
+
+	receipt, err := (&DonationReceiptService{db: ds.db}).Issue(donationID, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	donation.ReceiptNumber = receipt.Number
+	return donation, renderDonationReceipt(receipt, *donation), nil
+}
+
+// validate checks the filter's type and date range
+func (f DonorDonationFilter) validate() error {
+	if f.Type != "" && f.Type != models.DonationTypeMoney && f.Type != models.DonationTypeGoods {
+		return fmt.Errorf("%w: type must be %s or %s", ErrDonorDonationFilter, models.DonationTypeMoney, models.DonationTypeGoods)
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return fmt.Errorf("%w: from must be before to", ErrDonorDonationFilter)
+	}
+	return nil
+}
+
+// filtered selects the donor's donations matching a filter
+func (ds *DonorDonationService) filtered(userID uint, filter DonorDonationFilter) *gorm.DB {
+	query := ds.db.Model(&models.Donation{}).Where("(user_id = ? OR donor_id = ?)", userID, userID)
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
+
+// giftAidDeclared reports whether the donor has a Gift Aid declaration on their profile
+func (ds *DonorDonationService) giftAidDeclared(userID uint) bool {
+	var profile models.DonorProfile
+	return ds.db.Where("user_id = ?", userID).First(&profile).Error == nil && profile.GiftAidEligible
+}
+
+// describe turns donations into history entries with their campaigns
+func (ds *DonorDonationService) describe(donations []models.Donation, declared bool) ([]DonorDonation, error) {
+	campaigns, err := ds.campaigns(donations)
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := ds.receiptNumbers(donations)
+	if err != nil {
+		return nil, err
+	}
+	described := make([]DonorDonation, 0, len(donations))
+	for _, donation := range donations {
+		entry := buildDonorDonation(donation, declared)
+		entry.Campaign = campaigns[donation.ID]
+		if entry.ReceiptURL != "" {
+			entry.ReceiptNumber = receipts[donation.ID]
+		}
+		described = append(described, entry)
+	}
+	return described, nil
+}
+
+// receiptNumbers looks up the register number of each donation's receipt
+func (ds *DonorDonationService) receiptNumbers(donations []models.Donation) (map[uint]string, error) {
+	numbers := map[uint]string{}
+	if len(donations) == 0 {
+		return numbers, nil
+	}
+	ids := make([]uint, 0, len(donations))
+	for _, donation := range donations {
+		ids = append(ids, donation.ID)
+	}
+
+	var receipts []models.DonationReceipt
+	if err := ds.db.Select("donation_id", "number").Where("donation_id IN ?", ids).Find(&receipts).Error; err != nil {
+		return nil, err
+	}
+	for _, receipt := range receipts {
+		numbers[receipt.DonationID] = receipt.Number
+	}
+	return numbers, nil
+}
+
+// campaigns looks up the appeal or drive each donation was attributed to
+func (ds *DonorDonationService) campaigns(donations []models.Donation) (map[uint]*DonationCampaign, error) {
+	campaigns := map[uint]*DonationCampaign{}
+	if len(donations) == 0 {
+		return campaigns, nil
+	}
+
+	ids := make([]uint, 0, len(donations))
+	teamDonations := map[uint][]uint{}
+	for _, donation := range donations {
+		ids = append(ids, donation.ID)
+		if donation.DriveTeamID != nil {
+			teamDonations[*donation.DriveTeamID] = append(teamDonations[*donation.DriveTeamID], donation.ID)
+		}
+	}
+
+	var appeals []struct {
+		DonationID uint
+		AppealID   uint
+		Title      string
+	}
+	if err := ds.db.Table("appeal_donations").
+		Select("appeal_donations.donation_id, donation_appeals.id AS appeal_id, donation_appeals.title").
+		Joins("JOIN donation_appeals ON donation_appeals.id = appeal_donations.donation_appeal_id").
+		Where("appeal_donations.donation_id IN ?", ids).Scan(&appeals).Error; err != nil {
+		return nil, err
+	}
+	for _, appeal := range appeals {
+		campaigns[appeal.DonationID] = &DonationCampaign{Kind: "appeal", ID: appeal.AppealID, Name: appeal.Title}
+	}
+
+	if len(teamDonations) == 0 {
+		return campaigns, nil
+	}
+	teamIDs := make([]uint, 0, len(teamDonations))
+	for teamID := range teamDonations {
+		teamIDs = append(teamIDs, teamID)
+	}
+	var teams []struct {
+		TeamID    uint
+		TeamName  string
+		DriveID   uint
+		DriveName string
+	}
+	if err := ds.db.Table("drive_teams").
+		Select("drive_teams.id AS team_id, drive_teams.name AS team_name, donation_drives.id AS drive_id, donation_drives.name AS drive_name").
+		Joins("JOIN donation_drives ON donation_drives.id = drive_teams.drive_id").
+		Where("drive_teams.id IN ?", teamIDs).Scan(&teams).Error; err != nil {
+		return nil, err
+	}
+	for _, team := range teams {
+		for _, donationID := range teamDonations[team.TeamID] {
+			campaigns[donationID] = &DonationCampaign{Kind: "drive", ID: team.DriveID, Name: team.DriveName, Team: team.TeamName}
+		}
+	}
+	return campaigns, nil
+}
+
+// buildDonorDonation describes a donation for its donor, with its Gift Aid status
+// and receipt
+func buildDonorDonation(donation models.Donation, declared bool) DonorDonation {
+	currency := strings.ToUpper(donation.Currency)
+	if currency == "" {
+		currency = "GBP"
+	}
+	refunded := donation.RefundedAmount
+	if refunded > donation.Amount {
+		refunded = donation.Amount
+	}
+	if refunded < 0 {
+		refunded = 0
+	}
+	date := donation.CreatedAt
+	if donation.ReceivedAt != nil {
+		date = *donation.ReceivedAt
+	}
+
+	entry := DonorDonation{
+		ID:            donation.ID,
+		Date:          date,
+		Type:          donation.Type,
+		Status:        donation.Status,
+		Description:   getDonorDonationDescription(donation),
+		Currency:      currency,
+		GiftAidStatus: GiftAidNotEligible,
+	}
+	paid := slices.Contains(taxYearDonationStatuses, donation.Status)
+
+	if donation.Type != models.DonationTypeMoney {
+		entry.GoodsValue = roundPence(donation.GoodsValue)
+		entry.Quantity = donation.Quantity
+	} else {
+		entry.Amount = roundPence(donation.Amount)
+		entry.Refunded = roundPence(refunded)
+		entry.Net = roundPence(donation.Amount - refunded)
+		// Gift Aid can only be claimed on sterling donations that were paid and kept
+		if paid && currency == "GBP" && entry.Net > 0 && donation.Status != models.DonationStatusDisputed {
+			if declared {
+				entry.GiftAidStatus = GiftAidClaimable
+				entry.GiftAid = roundPence(entry.Net * models.GiftAidRate)
+			} else {
+				entry.GiftAidStatus = GiftAidNotDeclared
+			}
+		}
+	}
+
+	// The receipt number comes from the register once the receipt has been issued
+	if paid {
+		entry.ReceiptURL = fmt.Sprintf("/api/v1/donor/donations/%d/receipt", donation.ID)
+	}
+	return entry
+}
+
+// getDonorDonationDescription summarises what was given
+func getDonorDonationDescription(donation models.Donation) string {
+	if donation.Description != "" {
+		return donation.Description
+	}
+	if donation.Type == models.DonationTypeMoney {
+		return "Money donation"
+	}
+	if donation.Goods != "" {
+		return donation.Goods
+	}
+	return "Goods donation"
+}
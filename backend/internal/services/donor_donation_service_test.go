@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestBuildDonorDonation(t *testing.T) {
+	created := time.Date(2025, 5, 1, 10, 0, 0, 0, time.Local)
+	cases := []struct {
+		name     string
+		donation models.Donation
+		declared bool
+		giftAid  string
+		amount   float64
+		receipt  bool
+	}{
+		{"claimable", models.Donation{ID: 1, Type: models.DonationTypeMoney, Status: models.DonationStatusCompleted, Amount: 20}, true, GiftAidClaimable, 5, true},
+		{"no declaration", models.Donation{ID: 2, Type: models.DonationTypeMoney, Status: models.DonationStatusCompleted, Amount: 20}, false, GiftAidNotDeclared, 0, true},
+		{"partly refunded", models.Donation{ID: 3, Type: models.DonationTypeMoney, Status: models.DonationStatusPartiallyRefunded, Amount: 20, RefundedAmount: 8}, true, GiftAidClaimable, 3, true},
+		{"fully refunded", models.Donation{ID: 4, Type: models.DonationTypeMoney, Status: models.DonationStatusRefunded, Amount: 20, RefundedAmount: 20}, true, GiftAidNotEligible, 0, true},
+		{"other currency", models.Donation{ID: 5, Type: models.DonationTypeMoney, Status: models.DonationStatusCompleted, Amount: 20, Currency: "eur"}, true, GiftAidNotEligible, 0, true},
+		{"pending", models.Donation{ID: 6, Type: models.DonationTypeMoney, Status: models.DonationStatusPending, Amount: 20}, true, GiftAidNotEligible, 0, false},
+		{"goods", models.Donation{ID: 7, Type: models.DonationTypeGoods, Status: models.DonationStatusReceived, Goods: "Tins", GoodsValue: 12}, true, GiftAidNotEligible, 0, true},
+	}
+	for _, tc := range cases {
+		tc.donation.CreatedAt = created
+		got := buildDonorDonation(tc.donation, tc.declared)
+		if got.GiftAidStatus != tc.giftAid || got.GiftAid != tc.amount {
+			t.Errorf("%s: Gift Aid %s %.2f, want %s %.2f", tc.name, got.GiftAidStatus, got.GiftAid, tc.giftAid, tc.amount)
+		}
+		if (got.ReceiptURL != "") != tc.receipt {
+			t.Errorf("%s: receipt URL %q, want receipt = %v", tc.name, got.ReceiptURL, tc.receipt)
+		}
+	}
+}
+
+func TestParseDonorDonationStatuses(t *testing.T) {
+	statuses, err := ParseDonorDonationStatuses(" Completed, refunded ,,")
+	if err != nil || len(statuses) != 2 || statuses[0] != "completed" || statuses[1] != "refunded" {
+		t.Errorf("got %v, %v", statuses, err)
+	}
+	if _, err := ParseDonorDonationStatuses("completed,lost"); !errors.Is(err, ErrDonorDonationFilter) {
+		t.Errorf("unknown status error = %v, want ErrDonorDonationFilter", err)
+	}
+}
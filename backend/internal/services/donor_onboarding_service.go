@@ -0,0 +1,446 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidDonorPreferences = errors.New("invalid donor preferences")
+
+// donorWelcomeEmail is one email in the welcome series, sent Delay after the first
+// donation. %[1]s is the donor's first name and %[2]s the preferences link.
+type donorWelcomeEmail struct {
+	Delay   time.Duration
+	Subject string
+	Body    string
+}
+
+// donorWelcomeSeries is sent to every new donor through the notification outbox
+var donorWelcomeSeries = []donorWelcomeEmail{
+	{
+		Delay:   0,
+		Subject: "Thank you for your first donation",
+		Body: "Hello %[1]s,\n\nThank you for your first donation. It goes straight to local families who need it.\n\n" +
+			"Tell us which causes you care about and how you'd like to hear from us:\n%[2]s",
+	},
+	{
+		Delay:   3 * 24 * time.Hour,
+		Subject: "Where your donation goes",
+		Body: "Hello %[1]s,\n\nEvery week we give food, clothing and household essentials to hundreds of visitors, " +
+			"and offer advice and support alongside. Your gift helps keep our doors open.\n\n" +
+			"You can choose the causes you'd most like to hear about here:\n%[2]s",
+	},
+	{
+		Delay:   14 * 24 * time.Hour,
+		Subject: "Other ways to get involved",
+		Body: "Hello %[1]s,\n\nAs well as donating, you can volunteer on a shift, run a donation drive with friends or " +
+			"colleagues, or drop off goods from our urgent needs list.\n\n" +
+			"Update your preferences at any time:\n%[2]s",
+	},
+}
+
+// DonorAcquisition is how a new donor found us, from the donation form
+type DonorAcquisition struct {
+	CampaignCode string
+	ReferralCode string
+}
+
+// DonorPreferencesInput is what a new donor chooses when prompted. Fields left nil are
+// unchanged.
+type DonorPreferencesInput struct {
+	Causes          []string `json:"causes"`
+	MarketingOptIn  *bool    `json:"marketing_opt_in"` // Campaigns and appeals by email
+	DonationUpdates *bool    `json:"donation_updates"` // Donation follow-ups and the welcome series
+}
+
+// AcquisitionSummary is the donors acquired through one source and code
+type AcquisitionSummary struct {
+	Source          string  `json:"source"`
+	CampaignCode    string  `json:"campaign_code,omitempty"`
+	Donors          int     `json:"donors"`
+	PreferencesSet  int     `json:"preferences_set"`
+	RepeatDonors    int     `json:"repeat_donors"` // Gave again after their first donation
+	FirstGiftTotal  float64 `json:"first_gift_total"`
+	LaterGiftsTotal float64 `json:"later_gifts_total"`
+}
+
+// DonorAcquisitionReport attributes new donors and what they went on to give to how
+// they were acquired
+type DonorAcquisitionReport struct {
+	StartDate string               `json:"start_date"`
+	EndDate   string               `json:"end_date"`
+	Donors    int                  `json:"donors"`
+	Sources   []AcquisitionSummary `json:"sources"`
+}
+
+// DonorOnboardingService welcomes first-time donors, captures their preferences and
+// records how they were acquired
+type DonorOnboardingService struct {
+	db *gorm.DB
+}
+
+// NewDonorOnboardingService creates a new donor onboarding service
+func NewDonorOnboardingService() *DonorOnboardingService {
+	return &DonorOnboardingService{db: db.DB}
+}
+
+// acquisitionSource decides how a donor was acquired from the codes they gave
+func acquisitionSource(donation *models.Donation, acquisition DonorAcquisition) string {
+	switch {
+	case acquisition.CampaignCode != "":
+		return models.AcquisitionSourceCampaign
+	case acquisition.ReferralCode != "":
+		return models.AcquisitionSourceReferral
+	case donation.DriveTeamID != nil:
+		return models.AcquisitionSourceDonationDrive
+	default:
+		return models.AcquisitionSourceDirect
+	}
+}
+
+// hashDonorPreferencesToken returns the stored form of a preferences link token
+func hashDonorPreferencesToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Start onboards the donor of a donation if it is their first, recording how they
+// were acquired and queueing the welcome series. It returns nil when the donor has
+// given before or left no email address.
+func (ds *DonorOnboardingService) Start(donation *models.Donation, user *models.User, acquisition DonorAcquisition, now time.Time) (*models.DonorOnboarding, error) {
+	email := strings.ToLower(strings.TrimSpace(donation.ContactEmail))
+	firstName := strings.TrimSpace(donation.Name)
+	var userID *uint
+	if user != nil && user.ID != 0 {
+		userID = &user.ID
+		if email == "" {
+			email = strings.ToLower(user.Email)
+		}
+		if user.FirstName != "" {
+			firstName = user.FirstName
+		}
+	}
+	if email == "" {
+		return nil, nil
+	}
+	if i := strings.Index(firstName, " "); i > 0 {
+		firstName = firstName[:i]
+	}
+	if firstName == "" {
+		firstName = "there"
+	}
+
+	// Only a donor's first donation starts onboarding
+	var earlier int64
+	query := ds.db.Model(&models.Donation{}).Where("id <> ?", donation.ID)
+	if userID != nil {
+		query = query.Where("LOWER(contact_email) = ? OR user_id = ? OR donor_id = ?", email, *userID, *userID)
+	} else {
+		query = query.Where("LOWER(contact_email) = ?", email)
+	}
+	if err := query.Count(&earlier).Error; err != nil {
+		return nil, err
+	}
+	var existing int64
+	ds.db.Model(&models.DonorOnboarding{}).Where("email = ?", email).Count(&existing)
+	if earlier > 0 || existing > 0 {
+		return nil, nil
+	}
+
+	acquisition.CampaignCode = models.NormalizeAcquisitionCode(acquisition.CampaignCode)
+	acquisition.ReferralCode = models.NormalizeAcquisitionCode(acquisition.ReferralCode)
+	token := newTrackingToken()
+	onboarding := models.DonorOnboarding{
+		Email:             email,
+		UserID:            userID,
+		FirstDonationID:   donation.ID,
+		FirstDonationAt:   now,
+		AcquisitionSource: acquisitionSource(donation, acquisition),
+		CampaignCode:      acquisition.CampaignCode,
+		ReferralCode:      acquisition.ReferralCode,
+		PreferencesToken:  hashDonorPreferencesToken(token),
+	}
+
+	// Donors who already turned off donation emails in their account get no series
+	sendSeries := userID == nil ||
+		notifications.ChannelAllowed(*userID, models.NotificationCategoryDonations, models.NotificationChannelEmail)
+
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&onboarding).Error; err != nil {
+			return err
+		}
+		if !sendSeries {
+			return nil
+		}
+
+		link := donorPreferencesLink(token)
+		messages := make([]models.NotificationOutbox, 0, len(donorWelcomeSeries))
+		for _, step := range donorWelcomeSeries {
+			message := models.NotificationOutbox{
+				Kind:          models.OutboxKindDonorWelcome,
+				Channel:       models.NotificationTypeEmail,
+				Recipient:     onboarding.Email,
+				Subject:       step.Subject,
+				Body:          fmt.Sprintf(step.Body, firstName, link),
+				Status:        models.OutboxStatusPending,
+				AvailableAt:   now.Add(step.Delay),
+				TrackingToken: newTrackingToken(),
+			}
+			if userID != nil {
+				message.UserID = *userID
+			}
+			messages = append(messages, message)
+		}
+		if err := tx.Create(&messages).Error; err != nil {
+			return err
+		}
+		onboarding.WelcomeQueued = len(messages)
+		return tx.Model(&onboarding).Update("welcome_queued", onboarding.WelcomeQueued).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &onboarding, nil
+}
+
+// donorPreferencesLink returns the link in welcome emails to the preferences page
+func donorPreferencesLink(token string) string {
+	baseURL := os.Getenv("FRONTEND_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+	return fmt.Sprintf("%s/donor/welcome?token=%s", baseURL, token)
+}
+
+// ForToken returns the onboarding a welcome email link belongs to
+func (ds *DonorOnboardingService) ForToken(token string) (*models.DonorOnboarding, error) {
+	if token == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var onboarding models.DonorOnboarding
+	if err := ds.db.Where("preferences_token = ?", hashDonorPreferencesToken(token)).First(&onboarding).Error; err != nil {
+		return nil, err
+	}
+	return &onboarding, nil
+}
+
+// ForUser returns a signed-in donor's onboarding, matching by email for donors who
+// gave before creating an account
+func (ds *DonorOnboardingService) ForUser(user *models.User) (*models.DonorOnboarding, error) {
+	var onboarding models.DonorOnboarding
+	err := ds.db.Where("user_id = ? OR email = ?", user.ID, strings.ToLower(user.Email)).
+		Order("id ASC").First(&onboarding).Error
+	if err != nil {
+		return nil, err
+	}
+	if onboarding.UserID == nil {
+		onboarding.UserID = &user.ID
+		if err := ds.db.Model(&onboarding).Update("user_id", user.ID).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &onboarding, nil
+}
+
+// normalizeCauses validates and de-duplicates causes of interest, in display order
+func normalizeCauses(causes []string) ([]string, error) {
+	chosen := map[string]bool{}
+	for _, cause := range causes {
+		cause = strings.ToLower(strings.TrimSpace(cause))
+		if !models.IsDonorCause(cause) {
+			return nil, fmt.Errorf("%w: unknown cause %q", ErrInvalidDonorPreferences, cause)
+		}
+		chosen[cause] = true
+	}
+	normalized := []string{}
+	for _, cause := range models.DonorCauses {
+		if chosen[cause] {
+			normalized = append(normalized, cause)
+		}
+	}
+	return normalized, nil
+}
+
+// SavePreferences records the causes and communication choices a donor makes when
+// prompted. Turning off donation updates withdraws the rest of the welcome series.
+// Donors with an account also have their notification preferences updated.
+func (ds *DonorOnboardingService) SavePreferences(onboarding *models.DonorOnboarding, input DonorPreferencesInput, now time.Time) error {
+	if input.Causes != nil {
+		causes, err := normalizeCauses(input.Causes)
+		if err != nil {
+			return err
+		}
+		onboarding.Causes = strings.Join(causes, ",")
+	}
+	if input.MarketingOptIn != nil {
+		onboarding.MarketingOptIn = *input.MarketingOptIn
+	}
+	onboarding.PreferencesSetAt = &now
+	updates := map[string]interface{}{
+		"causes":             onboarding.Causes,
+		"marketing_opt_in":   onboarding.MarketingOptIn,
+		"preferences_set_at": now,
+	}
+
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(onboarding).Updates(updates).Error; err != nil {
+			return err
+		}
+		if input.DonationUpdates != nil && !*input.DonationUpdates {
+			if err := tx.Model(&models.NotificationOutbox{}).
+				Where("kind = ? AND recipient = ? AND status = ?", models.OutboxKindDonorWelcome, onboarding.Email, models.OutboxStatusPending).
+				Update("status", models.OutboxStatusCancelled).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if onboarding.UserID == nil {
+		return nil
+	}
+	var changes []NotificationPreferenceUpdate
+	if input.MarketingOptIn != nil {
+		changes = append(changes, NotificationPreferenceUpdate{Category: models.NotificationCategoryMarketing, Email: input.MarketingOptIn})
+	}
+	if input.DonationUpdates != nil {
+		changes = append(changes, NotificationPreferenceUpdate{Category: models.NotificationCategoryDonations, Email: input.DonationUpdates})
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	_, err = NewNotificationPreferenceService().Update(*onboarding.UserID, changes)
+	return err
+}
+
+// AcquisitionReport attributes donors whose first donation was between start and end,
+// inclusive, and what they have given since, to how they were acquired
+func (ds *DonorOnboardingService) AcquisitionReport(start, end time.Time) (*DonorAcquisitionReport, error) {
+	var onboardings []models.DonorOnboarding
+	if err := ds.db.Where("first_donation_at >= ? AND first_donation_at < ?", start, end.AddDate(0, 0, 1)).
+		Find(&onboardings).Error; err != nil {
+		return nil, err
+	}
+
+	report := &DonorAcquisitionReport{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Donors:    len(onboardings),
+		Sources:   []AcquisitionSummary{},
+	}
+	if len(onboardings) == 0 {
+		return report, nil
+	}
+
+	emails := make([]string, 0, len(onboardings))
+	var userIDs []uint
+	for _, onboarding := range onboardings {
+		emails = append(emails, onboarding.Email)
+		if onboarding.UserID != nil {
+			userIDs = append(userIDs, *onboarding.UserID)
+		}
+	}
+	query := ds.db.Where("created_at >= ?", start)
+	if len(userIDs) > 0 {
+		query = query.Where("LOWER(contact_email) IN ? OR user_id IN ? OR donor_id IN ?", emails, userIDs, userIDs)
+	} else {
+		query = query.Where("LOWER(contact_email) IN ?", emails)
+	}
+	var donations []models.Donation
+	if err := query.Find(&donations).Error; err != nil {
+		return nil, err
+	}
+
+	report.Sources = summariseAcquisition(onboardings, donations)
+	return report, nil
+}
+
+// summariseAcquisition groups new donors by source and campaign code and totals the
+// money they gave, first and since. Failed, cancelled and refunded amounts are left
+// out.
+func summariseAcquisition(onboardings []models.DonorOnboarding, donations []models.Donation) []AcquisitionSummary {
+	byEmail := map[string]int{}
+	byUser := map[uint]int{}
+	for i, onboarding := range onboardings {
+		byEmail[onboarding.Email] = i
+		if onboarding.UserID != nil {
+			byUser[*onboarding.UserID] = i
+		}
+	}
+
+	firstGift := make([]float64, len(onboardings))
+	laterGifts := make([]float64, len(onboardings))
+	repeat := make([]bool, len(onboardings))
+	for _, donation := range donations {
+		i, ok := byEmail[strings.ToLower(donation.ContactEmail)]
+		if !ok && donation.UserID != nil {
+			i, ok = byUser[*donation.UserID]
+		}
+		if !ok && donation.DonorID != nil {
+			i, ok = byUser[*donation.DonorID]
+		}
+		if !ok || donation.Status == "failed" || donation.Status == models.DonationStatusCancelled {
+			continue
+		}
+		amount := donation.Amount - donation.RefundedAmount
+		if donation.ID == onboardings[i].FirstDonationID {
+			firstGift[i] += amount
+			continue
+		}
+		if donation.CreatedAt.Before(onboardings[i].FirstDonationAt) {
+			continue
+		}
+		laterGifts[i] += amount
+		repeat[i] = true
+	}
+
+	type key struct{ source, code string }
+	summaries := map[key]*AcquisitionSummary{}
+	for i, onboarding := range onboardings {
+		k := key{onboarding.AcquisitionSource, onboarding.CampaignCode}
+		summary := summaries[k]
+		if summary == nil {
+			summary = &AcquisitionSummary{Source: k.source, CampaignCode: k.code}
+			summaries[k] = summary
+		}
+		summary.Donors++
+		if onboarding.PreferencesSetAt != nil {
+			summary.PreferencesSet++
+		}
+		if repeat[i] {
+			summary.RepeatDonors++
+		}
+		summary.FirstGiftTotal += firstGift[i]
+		summary.LaterGiftsTotal += laterGifts[i]
+	}
+
+	result := make([]AcquisitionSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Donors != result[j].Donors {
+			return result[i].Donors > result[j].Donors
+		}
+		if result[i].Source != result[j].Source {
+			return result[i].Source < result[j].Source
+		}
+		return result[i].CampaignCode < result[j].CampaignCode
+	})
+	return result
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestAcquisitionSource(t *testing.T) {
+	teamID := uint(4)
+	tests := []struct {
+		name        string
+		donation    models.Donation
+		acquisition DonorAcquisition
+		want        string
+	}{
+		{"campaign code wins", models.Donation{DriveTeamID: &teamID}, DonorAcquisition{CampaignCode: "SPRING26", ReferralCode: "AMY"}, models.AcquisitionSourceCampaign},
+		{"referral", models.Donation{}, DonorAcquisition{ReferralCode: "AMY"}, models.AcquisitionSourceReferral},
+		{"donation drive team", models.Donation{DriveTeamID: &teamID}, DonorAcquisition{}, models.AcquisitionSourceDonationDrive},
+		{"direct", models.Donation{}, DonorAcquisition{}, models.AcquisitionSourceDirect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := acquisitionSource(&tt.donation, tt.acquisition); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeCauses(t *testing.T) {
+	causes, err := normalizeCauses([]string{" Volunteering", "food", "food"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{models.DonorCauseFood, models.DonorCauseVolunteering}; !reflect.DeepEqual(causes, want) {
+		t.Errorf("got %v, want %v", causes, want)
+	}
+
+	if _, err := normalizeCauses([]string{"pets"}); !errors.Is(err, ErrInvalidDonorPreferences) {
+		t.Errorf("got %v, want ErrInvalidDonorPreferences", err)
+	}
+}
+
+func TestSummariseAcquisition(t *testing.T) {
+	first := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	userID := uint(9)
+	set := first.Add(time.Hour)
+	onboardings := []models.DonorOnboarding{
+		{Email: "a@example.com", FirstDonationID: 1, FirstDonationAt: first, AcquisitionSource: models.AcquisitionSourceCampaign, CampaignCode: "SPRING26", PreferencesSetAt: &set},
+		{Email: "b@example.com", UserID: &userID, FirstDonationID: 2, FirstDonationAt: first, AcquisitionSource: models.AcquisitionSourceCampaign, CampaignCode: "SPRING26"},
+		{Email: "c@example.com", FirstDonationID: 3, FirstDonationAt: first, AcquisitionSource: models.AcquisitionSourceDirect},
+	}
+	donations := []models.Donation{
+		{ID: 1, ContactEmail: "A@example.com", Amount: 20, CreatedAt: first},
+		{ID: 2, UserID: &userID, Amount: 10, CreatedAt: first},
+		{ID: 3, ContactEmail: "c@example.com", Amount: 5, CreatedAt: first},
+		{ID: 4, ContactEmail: "a@example.com", Amount: 15, RefundedAmount: 5, CreatedAt: first.AddDate(0, 1, 0)},
+		{ID: 5, ContactEmail: "c@example.com", Amount: 50, Status: "failed", CreatedAt: first.AddDate(0, 1, 0)},
+	}
+
+	summaries := summariseAcquisition(onboardings, donations)
+	if len(summaries) != 2 {
+		t.Fatalf("got %d summaries, want 2", len(summaries))
+	}
+	campaign := summaries[0]
+	if campaign.CampaignCode != "SPRING26" || campaign.Donors != 2 || campaign.PreferencesSet != 1 ||
+		campaign.RepeatDonors != 1 || campaign.FirstGiftTotal != 30 || campaign.LaterGiftsTotal != 10 {
+		t.Errorf("unexpected campaign summary: %+v", campaign)
+	}
+	direct := summaries[1]
+	if direct.Source != models.AcquisitionSourceDirect || direct.RepeatDonors != 0 || direct.LaterGiftsTotal != 0 {
+		t.Errorf("failed donation should not count as a repeat gift: %+v", direct)
+	}
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/websocket"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidBroadcast = errors.New("invalid broadcast")
+	ErrNoTicketHolders  = errors.New("nobody holds a ticket for today")
+)
+
+// EmergencyBroadcastRequest is what staff send to today's ticket holders
+type EmergencyBroadcastRequest struct {
+	Reason  string `json:"reason" binding:"required"` // early_closure, relocation, delay, other
+	Title   string `json:"title" binding:"required"`
+	Message string `json:"message" binding:"required"`
+}
+
+// broadcastRecipient is a visitor holding a ticket for the day
+type broadcastRecipient struct {
+	TicketID  uint
+	VisitorID uint
+	Email     string
+	Phone     string
+}
+
+// broadcastChannel is a channel a broadcast will try, or the reason it is skipped
+type broadcastChannel struct {
+	Channel    string
+	SkipReason string
+}
+
+// EmergencyBroadcastService messages everyone holding a ticket for today when plans
+// change mid-day, and records how each message was delivered
+type EmergencyBroadcastService struct {
+	db *gorm.DB
+}
+
+// NewEmergencyBroadcastService creates a new emergency broadcast service
+func NewEmergencyBroadcastService() *EmergencyBroadcastService {
+	return &EmergencyBroadcastService{db: db.DB}
+}
+
+// validate tidies a broadcast request
+func (bs *EmergencyBroadcastService) validate(req *EmergencyBroadcastRequest) error {
+	req.Reason = strings.ToLower(strings.TrimSpace(req.Reason))
+	req.Title = strings.TrimSpace(req.Title)
+	req.Message = strings.TrimSpace(req.Message)
+	if !models.IsBroadcastReason(req.Reason) {
+		return fmt.Errorf("%w: reason must be one of %s", ErrInvalidBroadcast, strings.Join(models.BroadcastReasons, ", "))
+	}
+	if req.Title == "" || req.Message == "" {
+		return fmt.Errorf("%w: title and message are required", ErrInvalidBroadcast)
+	}
+	// Keep it short enough to arrive as a single text message
+	if len(req.Title)+len(req.Message) > 300 {
+		return fmt.Errorf("%w: title and message must be 300 characters or fewer together", ErrInvalidBroadcast)
+	}
+	return nil
+}
+
+// recipients returns one unused ticket per visitor for the day
+func (bs *EmergencyBroadcastService) recipients(day time.Time) ([]broadcastRecipient, error) {
+	start, end := dayRange(day)
+	var rows []broadcastRecipient
+	err := bs.db.Model(&models.Ticket{}).
+		Select("tickets.id AS ticket_id, tickets.visitor_id, users.email, users.phone").
+		Joins("JOIN users ON users.id = tickets.visitor_id").
+		Where("tickets.status = ? AND tickets.used_at IS NULL AND tickets.visit_date >= ? AND tickets.visit_date < ?",
+			models.TicketStatusActive, start, end).
+		Order("tickets.id ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[uint]bool{}
+	recipients := make([]broadcastRecipient, 0, len(rows))
+	for _, row := range rows {
+		if seen[row.VisitorID] {
+			continue
+		}
+		seen[row.VisitorID] = true
+		recipients = append(recipients, row)
+	}
+	return recipients, nil
+}
+
+// Send messages every visitor holding an unused ticket for today, posts the message to
+// the queue display and visitor dashboards, and delivers it in the background. The
+// returned broadcast is updated with delivery outcomes as they come in.
+func (bs *EmergencyBroadcastService) Send(req EmergencyBroadcastRequest, sentBy uint, now time.Time) (*models.EmergencyBroadcast, error) {
+	if err := bs.validate(&req); err != nil {
+		return nil, err
+	}
+	recipients, err := bs.recipients(now)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipients) == 0 {
+		return nil, ErrNoTicketHolders
+	}
+
+	_, endOfDay := dayRange(now)
+	announcement := models.Announcement{
+		Title:       req.Title,
+		Content:     req.Message,
+		Priority:    "high",
+		TargetRole:  models.RoleVisitor,
+		Severity:    models.AnnouncementSeverityCritical,
+		Public:      true,
+		Active:      true,
+		StartsAt:    &now,
+		ExpiresAt:   &endOfDay,
+		CreatedByID: sentBy,
+	}
+	broadcast := models.EmergencyBroadcast{
+		VisitDate:  time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		Reason:     req.Reason,
+		Title:      req.Title,
+		Message:    req.Message,
+		Recipients: len(recipients),
+		SentBy:     sentBy,
+	}
+	err = bs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&announcement).Error; err != nil {
+			return err
+		}
+		broadcast.AnnouncementID = &announcement.ID
+		return tx.Create(&broadcast).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	NewAnnouncementService().Publish(&announcement, now)
+	if err := websocket.GetGlobalManager().BroadcastToTopic(QueueDisplayTopic, map[string]interface{}{
+		"type":         "emergency_broadcast",
+		"reason":       broadcast.Reason,
+		"title":        broadcast.Title,
+		"message":      broadcast.Message,
+		"announcement": announcement,
+		"timestamp":    now.Unix(),
+	}); err != nil {
+		log.Printf("Failed to post broadcast %d to queue displays: %v", broadcast.ID, err)
+	}
+
+	go bs.deliver(broadcast, recipients)
+	return &broadcast, nil
+}
+
+// broadcastChannels returns the channels to try for a visitor. The in-app message
+// always goes, as it backs the visitor dashboard; other channels follow the visitor's
+// help request preferences and whether they have an address for them.
+func broadcastChannels(preference models.NotificationPreference, recipient broadcastRecipient, pushDevices int64) []broadcastChannel {
+	channels := []broadcastChannel{{Channel: models.NotificationChannelInApp}}
+	add := func(channel string, allowed, reachable bool, unreachable string) {
+		switch {
+		case !allowed:
+			channels = append(channels, broadcastChannel{Channel: channel, SkipReason: "turned off in preferences"})
+		case !reachable:
+			channels = append(channels, broadcastChannel{Channel: channel, SkipReason: unreachable})
+		default:
+			channels = append(channels, broadcastChannel{Channel: channel})
+		}
+	}
+	add(models.NotificationChannelEmail, preference.Email, recipient.Email != "", "no email address")
+	add(models.NotificationChannelSMS, preference.SMS, recipient.Phone != "", "no phone number")
+	add(models.NotificationChannelPush, preference.Push, pushDevices > 0, "no push devices")
+	return channels
+}
+
+// deliver sends a broadcast to each recipient on their channels and records the
+// outcomes
+func (bs *EmergencyBroadcastService) deliver(broadcast models.EmergencyBroadcast, recipients []broadcastRecipient) {
+	service := notifications.GetService()
+	for _, recipient := range recipients {
+		preference := notifications.CategoryPreference(recipient.VisitorID, models.NotificationCategoryHelpRequests)
+		var pushDevices int64
+		bs.db.Model(&models.PushSubscription{}).Where("user_id = ? AND active = ?", recipient.VisitorID, true).Count(&pushDevices)
+
+		for _, channel := range broadcastChannels(preference, recipient, pushDevices) {
+			delivery := models.EmergencyBroadcastDelivery{
+				BroadcastID: broadcast.ID,
+				VisitorID:   recipient.VisitorID,
+				TicketID:    recipient.TicketID,
+				Channel:     channel.Channel,
+				Status:      models.BroadcastDeliverySent,
+			}
+			if channel.SkipReason != "" {
+				delivery.Status = models.BroadcastDeliverySkipped
+				delivery.Error = channel.SkipReason
+			} else if err := bs.sendOn(service, channel.Channel, broadcast, recipient); err != nil {
+				delivery.Status = models.BroadcastDeliveryFailed
+				delivery.Error = err.Error()
+			}
+
+			switch delivery.Status {
+			case models.BroadcastDeliverySent:
+				broadcast.Sent++
+			case models.BroadcastDeliveryFailed:
+				broadcast.Failed++
+			default:
+				broadcast.Skipped++
+			}
+			if err := bs.db.Create(&delivery).Error; err != nil {
+				log.Printf("Failed to record broadcast %d delivery to visitor %d: %v", broadcast.ID, recipient.VisitorID, err)
+			}
+		}
+	}
+
+	completed := time.Now()
+	if err := bs.db.Model(&models.EmergencyBroadcast{}).Where("id = ?", broadcast.ID).Updates(map[string]interface{}{
+		"sent":         broadcast.Sent,
+		"failed":       broadcast.Failed,
+		"skipped":      broadcast.Skipped,
+		"completed_at": completed,
+	}).Error; err != nil {
+		log.Printf("Failed to record outcome of broadcast %d: %v", broadcast.ID, err)
+	}
+}
+
+// sendOn sends a broadcast to a visitor on one channel
+func (bs *EmergencyBroadcastService) sendOn(service *notifications.NotificationService, channel string, broadcast models.EmergencyBroadcast, recipient broadcastRecipient) error {
+	switch channel {
+	case models.NotificationChannelInApp:
+		return bs.db.Create(&models.InAppNotification{
+			UserID:    recipient.VisitorID,
+			Type:      "emergency_broadcast",
+			Title:     broadcast.Title,
+			Message:   broadcast.Message,
+			Priority:  "urgent",
+			ActionURL: "/visitor/tickets",
+			CreatedAt: time.Now(),
+		}).Error
+	case models.NotificationChannelEmail:
+		return service.SendEmail(recipient.Email, broadcast.Title, broadcast.Message)
+	case models.NotificationChannelSMS:
+		// Sent even when the monthly SMS budget is spent, as the news cannot wait
+		return service.SendUrgentSMS(recipient.Phone, broadcast.Title+": "+broadcast.Message)
+	case models.NotificationChannelPush:
+		return GetGlobalRealtimeNotificationService().sendPushNotification(RealtimeNotificationData{
+			UserID:  recipient.VisitorID,
+			Type:    "emergency_broadcast",
+			Title:   broadcast.Title,
+			Message: broadcast.Message,
+		})
+	}
+	return fmt.Errorf("unknown channel %q", channel)
+}
+
+// List returns the broadcasts sent between two days, newest first
+func (bs *EmergencyBroadcastService) List(from, to time.Time) ([]models.EmergencyBroadcast, error) {
+	var broadcasts []models.EmergencyBroadcast
+	err := bs.db.Where("visit_date BETWEEN ? AND ?", from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("created_at DESC").
+		Find(&broadcasts).Error
+	return broadcasts, err
+}
+
+// Get returns a broadcast with its delivery outcomes
+func (bs *EmergencyBroadcastService) Get(id uint) (*models.EmergencyBroadcast, error) {
+	var broadcast models.EmergencyBroadcast
+	err := bs.db.Preload("Deliveries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("visitor_id ASC, channel ASC")
+	}).First(&broadcast, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &broadcast, nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestEmergencyBroadcastValidate(t *testing.T) {
+	bs := &EmergencyBroadcastService{}
+
+	req := EmergencyBroadcastRequest{Reason: " Early_Closure ", Title: " Closing at 2pm ", Message: "Sorry, we are closing early today."}
+	if err := bs.validate(&req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Reason != models.BroadcastReasonEarlyClosure || req.Title != "Closing at 2pm" {
+		t.Errorf("request not tidied: %+v", req)
+	}
+
+	invalid := []EmergencyBroadcastRequest{
+		{Reason: "fire drill", Title: "Closed", Message: "Closed"},
+		{Reason: models.BroadcastReasonRelocation, Title: "Moved", Message: "  "},
+		{Reason: models.BroadcastReasonOther, Title: "Update", Message: strings.Repeat("x", 300)},
+	}
+	for _, req := range invalid {
+		if err := bs.validate(&req); !errors.Is(err, ErrInvalidBroadcast) {
+			t.Errorf("%+v: got %v, want ErrInvalidBroadcast", req, err)
+		}
+	}
+}
+
+func TestBroadcastChannels(t *testing.T) {
+	preference := models.NotificationPreference{Email: true, SMS: false, Push: true, InApp: false}
+	recipient := broadcastRecipient{VisitorID: 3, Email: "visitor@example.com"}
+
+	channels := broadcastChannels(preference, recipient, 0)
+	want := map[string]string{
+		models.NotificationChannelInApp: "",
+		models.NotificationChannelEmail: "",
+		models.NotificationChannelSMS:   "turned off in preferences",
+		models.NotificationChannelPush:  "no push devices",
+	}
+	if len(channels) != len(want) {
+		t.Fatalf("got %d channels, want %d", len(channels), len(want))
+	}
+	for _, channel := range channels {
+		if reason, ok := want[channel.Channel]; !ok || reason != channel.SkipReason {
+			t.Errorf("%s: got skip reason %q, want %q", channel.Channel, channel.SkipReason, reason)
+		}
+	}
+}
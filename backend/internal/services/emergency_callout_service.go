@@ -0,0 +1,398 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCalloutClosed      = errors.New("this call-out has already been filled or closed")
+	ErrCalloutNotNotified = errors.New("you were not invited to this call-out")
+	ErrCalloutShiftFilled = errors.New("shift already has a confirmed volunteer")
+	ErrCalloutAssigned    = errors.New("you are already assigned to this shift")
+)
+
+// EmergencyCalloutService broadcasts urgent shift call-outs to matching volunteers
+type EmergencyCalloutService struct {
+	db *gorm.DB
+}
+
+// NewEmergencyCalloutService creates a new emergency call-out service
+func NewEmergencyCalloutService() *EmergencyCalloutService {
+	return &EmergencyCalloutService{
+		db: db.DB,
+	}
+}
+
+// CreateCallout opens a call-out for a shift and notifies every active volunteer
+// whose skills match. If requiredSkills is empty the shift's own required skills are used.
+func (es *EmergencyCalloutService) CreateCallout(shiftID, createdBy uint, reason, requiredSkills string, expiresAt time.Time) (*models.EmergencyCallout, error) {
+	var shift models.Shift
+	if err := es.db.First(&shift, shiftID).Error; err != nil {
+		return nil, err
+	}
+	if shift.StartTime.Before(time.Now()) {
+		return nil, errors.New("cannot raise a call-out for a shift that has already started")
+	}
+
+	var open int64
+	es.db.Model(&models.EmergencyCallout{}).
+		Where("shift_id = ? AND status = ?", shiftID, models.CalloutStatusOpen).
+		Count(&open)
+	if open > 0 {
+		return nil, errors.New("an open call-out already exists for this shift")
+	}
+
+	if requiredSkills == "" {
+		requiredSkills = shift.RequiredSkills
+	}
+	expiresAt = calloutExpiry(expiresAt, shift.StartTime)
+
+	volunteers, err := es.matchingVolunteers(shift, requiredSkills)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	callout := models.EmergencyCallout{
+		ShiftID:        shiftID,
+		CreatedBy:      createdBy,
+		Reason:         reason,
+		RequiredSkills: requiredSkills,
+		Status:         models.CalloutStatusOpen,
+		ExpiresAt:      expiresAt,
+		NotifiedCount:  len(volunteers),
+	}
+
+	err = es.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&callout).Error; err != nil {
+			return err
+		}
+		for _, volunteer := range volunteers {
+			response := models.EmergencyCalloutResponse{
+				CalloutID:  callout.ID,
+				UserID:     volunteer.ID,
+				Status:     models.CalloutResponseNotified,
+				NotifiedAt: now,
+			}
+			if err := tx.Create(&response).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create call-out: %w", err)
+	}
+
+	if notificationService := notifications.GetService(); notificationService != nil && len(volunteers) > 0 {
+		calloutData := map[string]interface{}{
+			"date":     shift.Date.Format("Monday, January 2, 2006"),
+			"time":     fmt.Sprintf("%s - %s", shift.StartTime.Format("3:04 PM"), shift.EndTime.Format("3:04 PM")),
+			"location": shift.Location,
+			"role":     shift.Role,
+			"reason":   reason,
+		}
+		go func() {
+			for _, err := range notificationService.SendUrgentCallout(calloutData, volunteers) {
+				log.Printf("Failed to send emergency call-out %d notification: %v", callout.ID, err)
+			}
+		}()
+	}
+
+	callout.Shift = shift
+	return &callout, nil
+}
+
+// MarkViewed records that a volunteer has opened the call-out
+func (es *EmergencyCalloutService) MarkViewed(calloutID, userID uint) (*models.EmergencyCalloutResponse, error) {
+	response, err := es.findResponse(es.db, calloutID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.ViewedAt == nil {
+		now := time.Now()
+		response.ViewedAt = &now
+		if response.Status == models.CalloutResponseNotified {
+			response.Status = models.CalloutResponseViewed
+		}
+		if err := es.db.Save(response).Error; err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// Decline records that a volunteer cannot help with the call-out
+func (es *EmergencyCalloutService) Decline(calloutID, userID uint, reason string) (*models.EmergencyCalloutResponse, error) {
+	response, err := es.findResponse(es.db, calloutID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if response.Status == models.CalloutResponseAccepted || response.Status == models.CalloutResponseClosed {
+		return nil, ErrCalloutClosed
+	}
+
+	now := time.Now()
+	response.Status = models.CalloutResponseDeclined
+	response.RespondedAt = &now
+	response.DeclineReason = reason
+	if response.ViewedAt == nil {
+		response.ViewedAt = &now
+	}
+	if err := es.db.Save(response).Error; err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Accept assigns the shift to the first volunteer to accept and closes the
+// call-out for everyone else
+func (es *EmergencyCalloutService) Accept(calloutID, userID uint) (*models.EmergencyCallout, *models.ShiftAssignment, error) {
+	var callout models.EmergencyCallout
+	var assignment models.ShiftAssignment
+
+	err := es.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the call-out so concurrent acceptances are serialised
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&callout, calloutID).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		if !callout.IsOpen(now) {
+			return ErrCalloutClosed
+		}
+
+		response, err := es.findResponse(tx, calloutID, userID)
+		if err != nil {
+			return err
+		}
+
+		// Lock the shift as well, since it can also be filled through normal sign-up
+		var shift models.Shift
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shift, callout.ShiftID).Error; err != nil {
+			return err
+		}
+		if shift.Type != "flexible" && shift.AssignedVolunteerID != nil {
+			return ErrCalloutShiftFilled
+		}
+		if shift.Type == "flexible" && shift.FlexibleSlotsUsed >= shift.FlexibleSlots {
+			return ErrCalloutShiftFilled
+		}
+
+		var existing int64
+		if err := tx.Model(&models.ShiftAssignment{}).
+			Where("shift_id = ? AND user_id = ? AND status IN ?", shift.ID, userID, activeAssignmentStatuses).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrCalloutAssigned
+		}
+
+		var profile models.VolunteerProfile
+		tx.Where("user_id = ?", userID).First(&profile)
+
+		if shift.Type != "flexible" {
+			if err := tx.Model(&shift).Update("assigned_volunteer_id", userID).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Model(&shift).Update("flexible_slots_used", gorm.Expr("flexible_slots_used + ?", 1)).Error; err != nil {
+				return err
+			}
+		}
+
+		assignment = models.ShiftAssignment{
+			ShiftID:     shift.ID,
+			UserID:      userID,
+			VolunteerID: profile.ID,
+			Status:      "Confirmed",
+			AssignedAt:  now,
+		}
+		if err := tx.Create(&assignment).Error; err != nil {
+			return err
+		}
+
+		response.Status = models.CalloutResponseAccepted
+		response.RespondedAt = &now
+		if response.ViewedAt == nil {
+			response.ViewedAt = &now
+		}
+		if err := tx.Save(response).Error; err != nil {
+			return err
+		}
+
+		callout.Status = models.CalloutStatusFilled
+		callout.FilledBy = &userID
+		callout.FilledAt = &now
+		callout.ClosedAt = &now
+		if err := tx.Save(&callout).Error; err != nil {
+			return err
+		}
+
+		return closePendingResponses(tx, callout.ID)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &callout, &assignment, nil
+}
+
+// Cancel closes an open call-out without filling it
+func (es *EmergencyCalloutService) Cancel(calloutID uint) (*models.EmergencyCallout, error) {
+	var callout models.EmergencyCallout
+
+	err := es.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&callout, calloutID).Error; err != nil {
+			return err
+		}
+		if callout.Status != models.CalloutStatusOpen {
+			return ErrCalloutClosed
+		}
+
+		now := time.Now()
+		callout.Status = models.CalloutStatusCancelled
+		callout.ClosedAt = &now
+		if err := tx.Save(&callout).Error; err != nil {
+			return err
+		}
+		return closePendingResponses(tx, callout.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &callout, nil
+}
+
+// ExpireCallouts closes open call-outs whose expiry time has passed
+func (es *EmergencyCalloutService) ExpireCallouts() (int, error) {
+	var callouts []models.EmergencyCallout
+	if err := es.db.Where("status = ? AND expires_at <= ?", models.CalloutStatusOpen, time.Now()).
+		Find(&callouts).Error; err != nil {
+		return 0, err
+	}
+
+	for _, callout := range callouts {
+		now := time.Now()
+		err := es.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&callout).Updates(map[string]interface{}{
+				"status":    models.CalloutStatusExpired,
+				"closed_at": now,
+			}).Error; err != nil {
+				return err
+			}
+			return closePendingResponses(tx, callout.ID)
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(callouts), nil
+}
+
+// ResponseSummary counts responses to a call-out by status
+func (es *EmergencyCalloutService) ResponseSummary(calloutID uint) map[string]int64 {
+	summary := map[string]int64{
+		models.CalloutResponseNotified: 0,
+		models.CalloutResponseViewed:   0,
+		models.CalloutResponseDeclined: 0,
+		models.CalloutResponseAccepted: 0,
+		models.CalloutResponseClosed:   0,
+	}
+
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	es.db.Model(&models.EmergencyCalloutResponse{}).
+		Select("status, COUNT(*) AS count").
+		Where("callout_id = ?", calloutID).
+		Group("status").
+		Scan(&rows)
+	for _, row := range rows {
+		summary[row.Status] = row.Count
+	}
+	return summary
+}
+
+// matchingVolunteers returns active volunteers who have any of the required
+// skills, are not already working at the time of the shift and are not away that day
+func (es *EmergencyCalloutService) matchingVolunteers(shift models.Shift, requiredSkills string) ([]models.User, error) {
+	query := es.db.Model(&models.User{}).
+		Joins("JOIN volunteer_profiles ON volunteer_profiles.user_id = users.id").
+		Where("users.role IN ? AND users.status = ?", []string{models.RoleVolunteer, models.RoleVolunteerLegacy}, "active").
+		Where(`users.id NOT IN (
+			SELECT shift_assignments.user_id FROM shift_assignments
+			JOIN shifts ON shifts.id = shift_assignments.shift_id
+			WHERE shift_assignments.status = 'Confirmed'
+			AND shifts.start_time < ? AND shifts.end_time > ?)`, shift.EndTime, shift.StartTime).
+		Where(`users.id NOT IN (
+			SELECT user_id FROM volunteer_away_periods
+			WHERE ?::date BETWEEN starts_on AND ends_on)`, shift.Date)
+
+	if condition, args := calloutSkillCondition(requiredSkills); condition != "" {
+		query = query.Where(condition, args...)
+	}
+
+	var volunteers []models.User
+	if err := query.Preload("NotificationPreferences").Find(&volunteers).Error; err != nil {
+		return nil, err
+	}
+	return volunteers, nil
+}
+
+// calloutExpiry returns when a call-out closes: the time asked for, but never after
+// the shift starts
+func calloutExpiry(requested, shiftStart time.Time) time.Time {
+	if requested.IsZero() || requested.After(shiftStart) {
+		return shiftStart
+	}
+	return requested
+}
+
+// calloutSkillCondition builds the condition matching volunteers with any of a
+// comma-separated list of skills, or "" when no skills are required
+func calloutSkillCondition(requiredSkills string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	for _, skill := range strings.Split(requiredSkills, ",") {
+		skill = strings.TrimSpace(skill)
+		if skill == "" {
+			continue
+		}
+		conditions = append(conditions, "volunteer_profiles.skills ILIKE ?")
+		args = append(args, "%"+skill+"%")
+	}
+	return strings.Join(conditions, " OR "), args
+}
+
+// findResponse loads the response row for a volunteer invited to a call-out
+func (es *EmergencyCalloutService) findResponse(tx *gorm.DB, calloutID, userID uint) (*models.EmergencyCalloutResponse, error) {
+	var response models.EmergencyCalloutResponse
+	if err := tx.Where("callout_id = ? AND user_id = ?", calloutID, userID).First(&response).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCalloutNotNotified
+		}
+		return nil, err
+	}
+	return &response, nil
+}
+
+// closePendingResponses marks every response that has not declined or accepted as closed
+func closePendingResponses(tx *gorm.DB, calloutID uint) error {
+	return tx.Model(&models.EmergencyCalloutResponse{}).
+		Where("callout_id = ? AND status IN ?", calloutID,
+			[]string{models.CalloutResponseNotified, models.CalloutResponseViewed}).
+		Update("status", models.CalloutResponseClosed).Error
+}
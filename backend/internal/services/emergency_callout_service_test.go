@@ -0,0 +1,52 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestCalloutExpiry(t *testing.T) {
+	start := time.Date(2026, 5, 2, 9, 0, 0, 0, time.UTC)
+
+	if got := calloutExpiry(time.Time{}, start); !got.Equal(start) {
+		t.Errorf("no expiry: got %v, want the shift start", got)
+	}
+	if got := calloutExpiry(start.Add(time.Hour), start); !got.Equal(start) {
+		t.Errorf("after the shift starts: got %v, want the shift start", got)
+	}
+	if earlier := start.Add(-2 * time.Hour); !calloutExpiry(earlier, start).Equal(earlier) {
+		t.Error("an earlier expiry should be kept")
+	}
+}
+
+func TestCalloutSkillCondition(t *testing.T) {
+	condition, args := calloutSkillCondition(" first aid, ,forklift ")
+	if condition != "volunteer_profiles.skills ILIKE ? OR volunteer_profiles.skills ILIKE ?" {
+		t.Errorf("condition %q", condition)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"%first aid%", "%forklift%"}) {
+		t.Errorf("args %v", args)
+	}
+
+	if condition, args := calloutSkillCondition(" , "); condition != "" || len(args) != 0 {
+		t.Errorf("no skills: got %q, %v", condition, args)
+	}
+}
+
+func TestEmergencyCalloutIsOpen(t *testing.T) {
+	now := time.Date(2026, 5, 2, 8, 0, 0, 0, time.UTC)
+	callout := models.EmergencyCallout{Status: models.CalloutStatusOpen, ExpiresAt: now.Add(time.Minute)}
+	if !callout.IsOpen(now) {
+		t.Error("open call-out before expiry should be open")
+	}
+	if callout.IsOpen(now.Add(time.Minute)) {
+		t.Error("call-out should close at its expiry time")
+	}
+	callout.Status = models.CalloutStatusFilled
+	if callout.IsOpen(now) {
+		t.Error("filled call-out should not be open")
+	}
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidGoodsWeight         = errors.New("invalid goods weight")
+	ErrVisitNotDelivered          = errors.New("goods can only be weighed for visits that have been checked in or completed")
+	ErrInvalidEnvironmentalFactor = errors.New("invalid environmental factor")
+)
+
+// maxVisitGoodsKg is the heaviest single goods type accepted for one visit, to catch
+// grams entered as kilograms
+const maxVisitGoodsKg = 200
+
+// defaultEnvironmentalFactors apply to goods types with no configured factor. They are
+// deliberately cautious figures so funder reports do not overstate the impact.
+var defaultEnvironmentalFactors = map[string]models.EnvironmentalFactor{
+	models.GoodsTypeFood:       {GoodsType: models.GoodsTypeFood, DiversionRate: 0.9, CO2ePerKg: 2.5, Source: "default"},
+	models.GoodsTypeClothing:   {GoodsType: models.GoodsTypeClothing, DiversionRate: 0.8, CO2ePerKg: 15, Source: "default"},
+	models.GoodsTypeHousehold:  {GoodsType: models.GoodsTypeHousehold, DiversionRate: 0.7, CO2ePerKg: 3, Source: "default"},
+	models.GoodsTypeToiletries: {GoodsType: models.GoodsTypeToiletries, DiversionRate: 0.5, CO2ePerKg: 2, Source: "default"},
+	models.GoodsTypeOther:      {GoodsType: models.GoodsTypeOther, DiversionRate: 0.5, CO2ePerKg: 1, Source: "default"},
+}
+
+// EnvironmentalImpactService records the weight of goods handed out at visits and
+// estimates the waste diverted and CO2e avoided
+type EnvironmentalImpactService struct {
+	db      *gorm.DB
+	factors map[string][]models.EnvironmentalFactor // By goods type, newest first
+}
+
+// EnvironmentalImpact is the estimated environmental impact of redistributed goods
+type EnvironmentalImpact struct {
+	WeightKg   float64 `json:"weight_kg"`
+	DivertedKg float64 `json:"diverted_kg"`
+	CO2eKg     float64 `json:"co2e_kg"`
+}
+
+// GoodsTypeImpact is the impact of one type of goods
+type GoodsTypeImpact struct {
+	GoodsType string `json:"goods_type"`
+	EnvironmentalImpact
+}
+
+// MonthlyEnvironmentalImpact is the impact of goods handed out in a month
+type MonthlyEnvironmentalImpact struct {
+	Month  string `json:"month"`
+	Visits int    `json:"visits"`
+	EnvironmentalImpact
+}
+
+// EnvironmentalImpactReport summarises goods redistributed in a period
+type EnvironmentalImpactReport struct {
+	StartDate      string                       `json:"start_date"`
+	EndDate        string                       `json:"end_date"`
+	Visits         int                          `json:"visits"`          // Visits with goods weighed
+	DefaultFactors bool                         `json:"default_factors"` // Some figures used the built-in factors
+	Total          EnvironmentalImpact          `json:"total"`
+	ByGoodsType    []GoodsTypeImpact            `json:"by_goods_type"`
+	Monthly        []MonthlyEnvironmentalImpact `json:"monthly"`
+}
+
+// NewEnvironmentalImpactService creates a new environmental impact service
+func NewEnvironmentalImpactService() *EnvironmentalImpactService {
+	return &EnvironmentalImpactService{db: db.DB}
+}
+
+// RecordVisitGoods saves the weight of each type of goods handed out at a visit,
+// replacing earlier figures. A weight of zero removes that goods type.
+func (es *EnvironmentalImpactService) RecordVisitGoods(helpRequestID uint, weights map[string]float64, userID uint) ([]models.VisitGoodsWeight, error) {
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("%w: at least one goods type is required", ErrInvalidGoodsWeight)
+	}
+	for goodsType, kg := range weights {
+		if !models.IsGoodsType(goodsType) {
+			return nil, fmt.Errorf("%w: unknown goods type %q", ErrInvalidGoodsWeight, goodsType)
+		}
+		if kg < 0 || kg > maxVisitGoodsKg {
+			return nil, fmt.Errorf("%w: %s must be between 0 and %d kg", ErrInvalidGoodsWeight, goodsType, maxVisitGoodsKg)
+		}
+	}
+
+	var request models.HelpRequest
+	if err := es.db.Select("id", "status", "category", "visit_day", "created_at").First(&request, helpRequestID).Error; err != nil {
+		return nil, err
+	}
+	if request.Status != models.HelpRequestStatusCheckedIn && request.Status != models.HelpRequestStatusCompleted {
+		return nil, ErrVisitNotDelivered
+	}
+	visitDate := serviceValueDate(request.VisitDay, request.CreatedAt)
+	visitDate = time.Date(visitDate.Year(), visitDate.Month(), visitDate.Day(), 0, 0, 0, 0, time.UTC)
+
+	err := es.db.Transaction(func(tx *gorm.DB) error {
+		for goodsType, kg := range weights {
+			if kg == 0 {
+				if err := tx.Where("help_request_id = ? AND goods_type = ?", helpRequestID, goodsType).
+					Delete(&models.VisitGoodsWeight{}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			weight := models.VisitGoodsWeight{
+				HelpRequestID: helpRequestID,
+				GoodsType:     goodsType,
+				Category:      strings.ToLower(request.Category),
+				VisitDate:     visitDate,
+				WeightKg:      math.Round(kg*100) / 100,
+				RecordedBy:    &userID,
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "help_request_id"}, {Name: "goods_type"}},
+				DoUpdates: clause.AssignmentColumns([]string{"weight_kg", "recorded_by", "updated_at"}),
+			}).Create(&weight).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var saved []models.VisitGoodsWeight
+	err = es.db.Where("help_request_id = ?", helpRequestID).Order("goods_type ASC").Find(&saved).Error
+	return saved, err
+}
+
+// loadFactors reads the factor table once per service
+func (es *EnvironmentalImpactService) loadFactors() {
+	if es.factors != nil {
+		return
+	}
+	var factors []models.EnvironmentalFactor
+	es.db.Order("effective_from DESC").Find(&factors)
+
+	es.factors = make(map[string][]models.EnvironmentalFactor)
+	for _, factor := range factors {
+		es.factors[factor.GoodsType] = append(es.factors[factor.GoodsType], factor)
+	}
+}
+
+// FactorOn returns the factor that applied to a goods type on a date, falling back
+// to the built-in default. The second result is false for a default.
+func (es *EnvironmentalImpactService) FactorOn(goodsType string, date time.Time) (models.EnvironmentalFactor, bool) {
+	es.loadFactors()
+	for _, factor := range es.factors[goodsType] {
+		if !factor.EffectiveFrom.After(date) {
+			return factor, true
+		}
+	}
+	return defaultEnvironmentalFactors[goodsType], false
+}
+
+// DefaultEnvironmentalFactors returns the built-in factors used where none are configured
+func DefaultEnvironmentalFactors() []models.EnvironmentalFactor {
+	factors := make([]models.EnvironmentalFactor, 0, len(models.GoodsTypes))
+	for _, goodsType := range models.GoodsTypes {
+		factors = append(factors, defaultEnvironmentalFactors[goodsType])
+	}
+	return factors
+}
+
+// ValidateEnvironmentalFactor checks a factor before it is saved
+func ValidateEnvironmentalFactor(factor *models.EnvironmentalFactor) error {
+	factor.GoodsType = strings.ToLower(strings.TrimSpace(factor.GoodsType))
+	if !models.IsGoodsType(factor.GoodsType) {
+		return fmt.Errorf("%w: unknown goods type %q", ErrInvalidEnvironmentalFactor, factor.GoodsType)
+	}
+	if factor.DiversionRate < 0 || factor.DiversionRate > 1 {
+		return fmt.Errorf("%w: diversion_rate must be between 0 and 1", ErrInvalidEnvironmentalFactor)
+	}
+	if factor.CO2ePerKg < 0 {
+		return fmt.Errorf("%w: co2e_per_kg cannot be negative", ErrInvalidEnvironmentalFactor)
+	}
+	return nil
+}
+
+// Report estimates the environmental impact of goods handed out between start and
+// end, inclusive, by goods type and month
+func (es *EnvironmentalImpactService) Report(start, end time.Time) (*EnvironmentalImpactReport, error) {
+	var weights []models.VisitGoodsWeight
+	if err := es.db.Where("visit_date BETWEEN ? AND ?", start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Find(&weights).Error; err != nil {
+		return nil, err
+	}
+
+	report := &EnvironmentalImpactReport{
+		StartDate:   start.Format("2006-01-02"),
+		EndDate:     end.Format("2006-01-02"),
+		ByGoodsType: []GoodsTypeImpact{},
+		Monthly:     []MonthlyEnvironmentalImpact{},
+	}
+	byType := map[string]*GoodsTypeImpact{}
+	byMonth := map[string]*MonthlyEnvironmentalImpact{}
+	visits := map[uint]bool{}
+	monthVisits := map[string]map[uint]bool{}
+
+	for _, weight := range weights {
+		factor, configured := es.FactorOn(weight.GoodsType, weight.VisitDate)
+		if !configured {
+			report.DefaultFactors = true
+		}
+		diverted, co2e := factor.Impact(weight.WeightKg)
+
+		entry := byType[weight.GoodsType]
+		if entry == nil {
+			entry = &GoodsTypeImpact{GoodsType: weight.GoodsType}
+			byType[weight.GoodsType] = entry
+		}
+		monthKey := weight.VisitDate.Format("2006-01")
+		month := byMonth[monthKey]
+		if month == nil {
+			month = &MonthlyEnvironmentalImpact{Month: monthKey}
+			byMonth[monthKey] = month
+			monthVisits[monthKey] = map[uint]bool{}
+		}
+
+		for _, impact := range []*EnvironmentalImpact{&entry.EnvironmentalImpact, &month.EnvironmentalImpact, &report.Total} {
+			impact.add(weight.WeightKg, diverted, co2e)
+		}
+		visits[weight.HelpRequestID] = true
+		monthVisits[monthKey][weight.HelpRequestID] = true
+	}
+
+	report.Visits = len(visits)
+	report.Total.round()
+	for _, goodsType := range models.GoodsTypes {
+		if entry, ok := byType[goodsType]; ok {
+			entry.round()
+			report.ByGoodsType = append(report.ByGoodsType, *entry)
+		}
+	}
+	for key, month := range byMonth {
+		month.Visits = len(monthVisits[key])
+		month.round()
+		report.Monthly = append(report.Monthly, *month)
+	}
+	sort.Slice(report.Monthly, func(i, j int) bool {
+		return report.Monthly[i].Month < report.Monthly[j].Month
+	})
+
+	return report, nil
+}
+
+// add counts a weighed goods type towards an impact total
+func (ei *EnvironmentalImpact) add(weightKg, divertedKg, co2eKg float64) {
+	ei.WeightKg += weightKg
+	ei.DivertedKg += divertedKg
+	ei.CO2eKg += co2eKg
+}
+
+// round rounds an impact to one decimal place
+func (ei *EnvironmentalImpact) round() {
+	ei.WeightKg = math.Round(ei.WeightKg*10) / 10
+	ei.DivertedKg = math.Round(ei.DivertedKg*10) / 10
+	ei.CO2eKg = math.Round(ei.CO2eKg*10) / 10
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestFactorOn(t *testing.T) {
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	jun := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	es := &EnvironmentalImpactService{factors: map[string][]models.EnvironmentalFactor{
+		models.GoodsTypeFood: {
+			{GoodsType: models.GoodsTypeFood, DiversionRate: 0.95, CO2ePerKg: 3, EffectiveFrom: jun},
+			{GoodsType: models.GoodsTypeFood, DiversionRate: 0.85, CO2ePerKg: 2, EffectiveFrom: jan},
+		},
+	}}
+
+	tests := []struct {
+		name       string
+		goodsType  string
+		date       time.Time
+		want       float64
+		configured bool
+	}{
+		{"newest factor", models.GoodsTypeFood, jun.AddDate(0, 1, 0), 3, true},
+		{"factor in force on the day", models.GoodsTypeFood, jun.AddDate(0, 0, -1), 2, true},
+		{"before any factor", models.GoodsTypeFood, jan.AddDate(0, 0, -1), 2.5, false},
+		{"no configured factor", models.GoodsTypeClothing, jun, 15, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factor, configured := es.FactorOn(tt.goodsType, tt.date)
+			if factor.CO2ePerKg != tt.want || configured != tt.configured {
+				t.Errorf("got %.2f (configured %v), want %.2f (configured %v)", factor.CO2ePerKg, configured, tt.want, tt.configured)
+			}
+		})
+	}
+}
+
+func TestValidateEnvironmentalFactor(t *testing.T) {
+	factor := models.EnvironmentalFactor{GoodsType: " Food ", DiversionRate: 0.5, CO2ePerKg: 2}
+	if err := ValidateEnvironmentalFactor(&factor); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if factor.GoodsType != models.GoodsTypeFood {
+		t.Errorf("goods type not normalised: %q", factor.GoodsType)
+	}
+
+	invalid := []models.EnvironmentalFactor{
+		{GoodsType: "furniture", DiversionRate: 0.5},
+		{GoodsType: models.GoodsTypeFood, DiversionRate: 1.2},
+		{GoodsType: models.GoodsTypeFood, DiversionRate: 0.5, CO2ePerKg: -1},
+	}
+	for _, factor := range invalid {
+		if err := ValidateEnvironmentalFactor(&factor); !errors.Is(err, ErrInvalidEnvironmentalFactor) {
+			t.Errorf("%+v: got %v, want ErrInvalidEnvironmentalFactor", factor, err)
+		}
+	}
+}
+
+func TestEnvironmentalFactorImpact(t *testing.T) {
+	factor := models.EnvironmentalFactor{DiversionRate: 0.8, CO2ePerKg: 2.5}
+	diverted, co2e := factor.Impact(10)
+	if math.Abs(diverted-8) > 1e-9 || math.Abs(co2e-20) > 1e-9 {
+		t.Errorf("got %.2f kg diverted and %.2f kg CO2e, want 8 and 20", diverted, co2e)
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Channels a feedback invitation can be sent by
+const (
+	SurveyChannelInApp = "in_app"
+	SurveyChannelEmail = "email"
+	SurveyChannelSMS   = "sms"
+)
+
+// SurveyResponseStats is how many visitors were invited to give feedback and how many did
+type SurveyResponseStats struct {
+	Invited      int64   `json:"invited"`
+	Responded    int64   `json:"responded"`
+	ResponseRate float64 `json:"response_rate"` // Percentage of invitations answered
+}
+
+// FeedbackSurveyService invites visitors to rate their visit once they are checked out
+// and tracks how many respond
+type FeedbackSurveyService struct {
+	db       *gorm.DB
+	enabled  bool
+	cooldown time.Duration
+}
+
+// NewFeedbackSurveyService creates a new feedback survey service
+func NewFeedbackSurveyService() *FeedbackSurveyService {
+	cooldownDays := 7
+	if n, err := strconv.Atoi(os.Getenv("FEEDBACK_SURVEY_COOLDOWN_DAYS")); err == nil && n >= 0 {
+		cooldownDays = n
+	}
+	return &FeedbackSurveyService{
+		db:       db.DB,
+		enabled:  os.Getenv("FEEDBACK_SURVEY_ENABLED") != "false",
+		cooldown: time.Duration(cooldownDays) * 24 * time.Hour,
+	}
+}
+
+// QueueInvitation invites the visitor to give feedback on a visit in the background,
+// so checking out is not held up by email or SMS delivery
+func (fss *FeedbackSurveyService) QueueInvitation(visitID uint) {
+	if !fss.enabled {
+		return
+	}
+	go func() {
+		if _, err := fss.Invite(visitID, time.Now()); err != nil {
+			log.Printf("Failed to send feedback invitation for visit %d: %v", visitID, err)
+		}
+	}()
+}
+
+// Invite sends the visitor a feedback invitation for a completed visit. It returns nil
+// without sending when the visit already has an invitation or feedback, or the visitor
+// was invited within the cooldown period.
+func (fss *FeedbackSurveyService) Invite(visitID uint, now time.Time) (*models.FeedbackInvitation, error) {
+	var visit models.Visit
+	if err := fss.db.First(&visit, visitID).Error; err != nil {
+		return nil, err
+	}
+	if visit.Status != "completed" {
+		return nil, nil
+	}
+
+	var answered int64
+	if err := fss.db.Model(&models.VisitFeedback{}).Where("visit_id = ?", visit.ID).Count(&answered).Error; err != nil {
+		return nil, err
+	}
+	if answered > 0 {
+		return nil, nil
+	}
+	if fss.cooldown > 0 {
+		var recent int64
+		if err := fss.db.Model(&models.FeedbackInvitation{}).
+			Where("visitor_id = ? AND sent_at > ?", visit.VisitorID, now.Add(-fss.cooldown)).
+			Count(&recent).Error; err != nil {
+			return nil, err
+		}
+		if recent > 0 {
+			return nil, nil
+		}
+	}
+
+	var visitor models.User
+	if err := fss.db.Preload("NotificationPreferences").First(&visitor, visit.VisitorID).Error; err != nil {
+		return nil, err
+	}
+
+	baseURL := os.Getenv("FRONTEND_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+	path := fmt.Sprintf("/visitor/feedback?visit_id=%d", visit.ID)
+	channels := surveyChannels(visitor)
+	invitation := models.FeedbackInvitation{
+		VisitID:   visit.ID,
+		VisitorID: visit.VisitorID,
+		Channels:  strings.Join(channels, ","),
+		Link:      baseURL + path,
+		SentAt:    now,
+	}
+	// The unique visit index stops two checkouts of the same visit both sending
+	result := fss.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&invitation)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	fss.deliver(visitor, invitation, path, channels)
+	return &invitation, nil
+}
+
+// deliver sends an invitation by each of its channels, logging failures so one channel
+// failing does not stop the others
+func (fss *FeedbackSurveyService) deliver(visitor models.User, invitation models.FeedbackInvitation, path string, channels []string) {
+	message := "Thank you for visiting us today. How did we do? It takes two minutes to tell us."
+	for _, channel := range channels {
+		var err error
+		switch channel {
+		case SurveyChannelInApp:
+			err = GetGlobalRealtimeNotificationService().SendNotification(RealtimeNotificationData{
+				UserID:    visitor.ID,
+				Type:      "feedback_invitation",
+				Title:     "How was your visit?",
+				Message:   message,
+				Priority:  models.PriorityLow,
+				Category:  "visitor",
+				ActionURL: path,
+				Channels:  []string{"websocket"},
+				Data:      map[string]interface{}{"visit_id": invitation.VisitID},
+			})
+		case SurveyChannelEmail:
+			body := fmt.Sprintf("<p>Hi %s,</p><p>%s</p><p><a href=\"%s\">Share your feedback</a></p>",
+				html.EscapeString(visitor.FirstName), message, html.EscapeString(invitation.Link))
+			err = notifications.GetService().SendEmail(visitor.Email, "How was your visit?", body)
+		case SurveyChannelSMS:
+			err = notifications.GetService().SendSMS(visitor.Phone, fmt.Sprintf("Lewisham Charity: %s %s", message, invitation.Link))
+		}
+		if err != nil {
+			log.Printf("Failed to send feedback invitation for visit %d by %s: %v", invitation.VisitID, channel, err)
+		}
+	}
+}
+
+// surveyChannels picks how to invite a visitor: always in the app, plus email or SMS
+// as their notification preferences allow
+func surveyChannels(visitor models.User) []string {
+	channels := []string{SurveyChannelInApp}
+	prefs := visitor.NotificationPreferences
+	if prefs == nil {
+		if visitor.Email != "" {
+			channels = append(channels, SurveyChannelEmail)
+		}
+		return channels
+	}
+
+	canEmail := prefs.EmailEnabled && visitor.Email != ""
+	canSMS := prefs.SMSEnabled && visitor.Phone != ""
+	switch prefs.PreferredMethod {
+	case "sms":
+		if canSMS {
+			return append(channels, SurveyChannelSMS)
+		}
+		if canEmail {
+			return append(channels, SurveyChannelEmail)
+		}
+	case "both":
+		if canEmail {
+			channels = append(channels, SurveyChannelEmail)
+		}
+		if canSMS {
+			channels = append(channels, SurveyChannelSMS)
+		}
+	default:
+		if canEmail {
+			channels = append(channels, SurveyChannelEmail)
+		}
+	}
+	return channels
+}
+
+// RecordResponse marks a visit's invitation as answered by a piece of feedback
+func (fss *FeedbackSurveyService) RecordResponse(visitID, feedbackID uint, now time.Time) error {
+	return fss.db.Model(&models.FeedbackInvitation{}).
+		Where("visit_id = ? AND responded_at IS NULL", visitID).
+		Updates(map[string]interface{}{"responded_at": now, "feedback_id": feedbackID}).Error
+}
+
+// ResponseStats counts invitations sent in a period and how many were answered
+func (fss *FeedbackSurveyService) ResponseStats(from, to time.Time) (*SurveyResponseStats, error) {
+	stats := &SurveyResponseStats{}
+	if err := fss.db.Model(&models.FeedbackInvitation{}).
+		Where("sent_at >= ? AND sent_at < ?", from, to).
+		Count(&stats.Invited).Error; err != nil {
+		return nil, err
+	}
+	if err := fss.db.Model(&models.FeedbackInvitation{}).
+		Where("sent_at >= ? AND sent_at < ? AND responded_at IS NOT NULL", from, to).
+		Count(&stats.Responded).Error; err != nil {
+		return nil, err
+	}
+	stats.ResponseRate = percentOf(stats.Responded, stats.Invited)
+	return stats, nil
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestSurveyChannels(t *testing.T) {
+	prefs := func(method string, email, sms bool) *models.NotificationPreferences {
+		return &models.NotificationPreferences{PreferredMethod: method, EmailEnabled: email, SMSEnabled: sms}
+	}
+	cases := []struct {
+		name    string
+		visitor models.User
+		want    []string
+	}{
+		{"no preferences", models.User{Email: "a@example.com", Phone: "07700900000"}, []string{"in_app", "email"}},
+		{"no preferences or email", models.User{Phone: "07700900000"}, []string{"in_app"}},
+		{"email preferred", models.User{Email: "a@example.com", Phone: "07700900000", NotificationPreferences: prefs("email", true, true)}, []string{"in_app", "email"}},
+		{"email turned off", models.User{Email: "a@example.com", NotificationPreferences: prefs("email", false, true)}, []string{"in_app"}},
+		{"sms preferred", models.User{Email: "a@example.com", Phone: "07700900000", NotificationPreferences: prefs("sms", true, true)}, []string{"in_app", "sms"}},
+		{"sms preferred without a phone", models.User{Email: "a@example.com", NotificationPreferences: prefs("sms", true, true)}, []string{"in_app", "email"}},
+		{"both", models.User{Email: "a@example.com", Phone: "07700900000", NotificationPreferences: prefs("both", true, true)}, []string{"in_app", "email", "sms"}},
+		{"both with sms off", models.User{Email: "a@example.com", Phone: "07700900000", NotificationPreferences: prefs("both", true, false)}, []string{"in_app", "email"}},
+	}
+	for _, tc := range cases {
+		if got := surveyChannels(tc.visitor); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Custom form limits
+const (
+	FormMaxFields          = 30
+	FormMaxOptions         = 50
+	formDefaultTextLength  = 500
+	formDefaultNotesLength = 2000
+)
+
+var formFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// ErrFormAnswersInvalid is returned when answers do not match the form. The field
+// errors say what is wrong with each answer.
+var ErrFormAnswersInvalid = errors.New("some answers are missing or invalid")
+
+// FormDefinitionService manages the extra questions asked for each service type
+type FormDefinitionService struct {
+	db *gorm.DB
+}
+
+// NewFormDefinitionService creates a new form definition service
+func NewFormDefinitionService() *FormDefinitionService {
+	return &FormDefinitionService{
+		db: db.DB,
+	}
+}
+
+// ForServiceType returns the form attached to a service type, if any
+func (fs *FormDefinitionService) ForServiceType(serviceTypeID uint) (*models.FormDefinition, error) {
+	var form models.FormDefinition
+	if err := fs.db.Where("service_type_id = ?", serviceTypeID).First(&form).Error; err != nil {
+		return nil, err
+	}
+	return &form, nil
+}
+
+// ActiveForCategory returns the active form for a help request category, or nil if
+// the category has no questions
+func (fs *FormDefinitionService) ActiveForCategory(category string) (*models.FormDefinition, error) {
+	serviceType, err := NewServiceTypeService().GetByCode(category)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	form, err := fs.ForServiceType(serviceType.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !form.IsActive || len(form.Fields) == 0 {
+		return nil, nil
+	}
+	return form, nil
+}
+
+// Save creates or replaces the form for a service type, bumping its version
+func (fs *FormDefinitionService) Save(serviceTypeID uint, title, description string, fields models.FormFields, isActive bool, updatedBy uint) (*models.FormDefinition, error) {
+	if err := ValidateFormFields(fields); err != nil {
+		return nil, err
+	}
+
+	form, err := fs.ForServiceType(serviceTypeID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		form = &models.FormDefinition{ServiceTypeID: serviceTypeID}
+	} else {
+		form.Version++
+	}
+	if form.Version == 0 {
+		form.Version = 1
+	}
+
+	form.Title = strings.TrimSpace(title)
+	form.Description = strings.TrimSpace(description)
+	form.Fields = fields
+	form.IsActive = isActive
+	form.UpdatedBy = &updatedBy
+	if err := fs.db.Save(form).Error; err != nil {
+		return nil, err
+	}
+	return form, nil
+}
+
+// ValidateFormFields checks a form's questions before they are saved. Keys and
+// labels are tidied in place.
+func ValidateFormFields(fields models.FormFields) error {
+	if len(fields) > FormMaxFields {
+		return fmt.Errorf("a form can have at most %d questions", FormMaxFields)
+	}
+
+	seen := make(map[string]bool, len(fields))
+	for i := range fields {
+		field := &fields[i]
+		field.Key = strings.ToLower(strings.TrimSpace(field.Key))
+		field.Label = strings.TrimSpace(field.Label)
+		field.Type = strings.ToLower(strings.TrimSpace(field.Type))
+
+		if !formFieldKeyPattern.MatchString(field.Key) {
+			return fmt.Errorf("question %d: key must start with a letter and use only lowercase letters, digits and underscores", i+1)
+		}
+		if seen[field.Key] {
+			return fmt.Errorf("question %d: key %s is used more than once", i+1, field.Key)
+		}
+		seen[field.Key] = true
+		if field.Label == "" {
+			return fmt.Errorf("question %s: label is required", field.Key)
+		}
+
+		switch field.Type {
+		case models.FormFieldSelect, models.FormFieldMultiSelect:
+			if len(field.Options) == 0 || len(field.Options) > FormMaxOptions {
+				return fmt.Errorf("question %s: give between 1 and %d options", field.Key, FormMaxOptions)
+			}
+			options := make(map[string]bool, len(field.Options))
+			for j, option := range field.Options {
+				option = strings.TrimSpace(option)
+				if option == "" || options[option] {
+					return fmt.Errorf("question %s: options must be unique and not blank", field.Key)
+				}
+				options[option] = true
+				field.Options[j] = option
+			}
+		case models.FormFieldNumber:
+			if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+				return fmt.Errorf("question %s: min cannot be greater than max", field.Key)
+			}
+		case models.FormFieldText, models.FormFieldTextarea, models.FormFieldCheckbox, models.FormFieldDate:
+		default:
+			return fmt.Errorf("question %s: type must be one of %s", field.Key, strings.Join(models.FormFieldTypes, ", "))
+		}
+		if field.MaxLength < 0 {
+			return fmt.Errorf("question %s: max_length cannot be negative", field.Key)
+		}
+	}
+	return nil
+}
+
+// ValidateAnswers checks submitted answers against a form and returns them in the
+// form's order with their labels. Unknown keys are rejected so answers stay tied to
+// the questions asked.
+func ValidateAnswers(form *models.FormDefinition, raw map[string]interface{}) (models.FormAnswers, map[string]string, error) {
+	fieldErrors := map[string]string{}
+	known := make(map[string]bool, len(form.Fields))
+	answers := models.FormAnswers{}
+
+	for i := range form.Fields {
+		field := &form.Fields[i]
+		known[field.Key] = true
+
+		value, present := raw[field.Key]
+		if s, ok := value.(string); ok && strings.TrimSpace(s) == "" {
+			present = false
+		}
+		if !present || value == nil {
+			if field.Required {
+				fieldErrors[field.Key] = field.Label + " is required"
+			}
+			continue
+		}
+
+		normalized, problem := normalizeFormAnswer(field, value)
+		if problem != "" {
+			fieldErrors[field.Key] = problem
+			continue
+		}
+		if field.Type == models.FormFieldMultiSelect && field.Required && len(normalized.([]string)) == 0 {
+			fieldErrors[field.Key] = field.Label + " is required"
+			continue
+		}
+		answers = append(answers, models.FormAnswer{Key: field.Key, Label: field.Label, Value: normalized})
+	}
+
+	for key := range raw {
+		if !known[key] {
+			fieldErrors[key] = "not a question on this form"
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, fieldErrors, ErrFormAnswersInvalid
+	}
+	return answers, nil, nil
+}
+
+// normalizeFormAnswer converts a JSON value to the field's type, or describes why it
+// cannot be
+func normalizeFormAnswer(field *models.FormField, value interface{}) (interface{}, string) {
+	switch field.Type {
+	case models.FormFieldText, models.FormFieldTextarea:
+		s, ok := value.(string)
+		if !ok {
+			return nil, field.Label + " must be text"
+		}
+		s = strings.TrimSpace(s)
+		limit := field.MaxLength
+		if limit == 0 {
+			limit = formDefaultTextLength
+			if field.Type == models.FormFieldTextarea {
+				limit = formDefaultNotesLength
+			}
+		}
+		if utf8.RuneCountInString(s) > limit {
+			return nil, fmt.Sprintf("%s must be at most %d characters", field.Label, limit)
+		}
+		return s, ""
+
+	case models.FormFieldNumber:
+		n, ok := value.(float64)
+		if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, field.Label + " must be a number"
+		}
+		if field.Min != nil && n < *field.Min {
+			return nil, fmt.Sprintf("%s must be at least %g", field.Label, *field.Min)
+		}
+		if field.Max != nil && n > *field.Max {
+			return nil, fmt.Sprintf("%s must be at most %g", field.Label, *field.Max)
+		}
+		return n, ""
+
+	case models.FormFieldSelect:
+		s, ok := value.(string)
+		if !ok || !field.HasOption(strings.TrimSpace(s)) {
+			return nil, field.Label + " must be one of the listed options"
+		}
+		return strings.TrimSpace(s), ""
+
+	case models.FormFieldMultiSelect:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, field.Label + " must be a list of options"
+		}
+		selected := make([]string, 0, len(items))
+		chosen := make(map[string]bool, len(items))
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok || !field.HasOption(strings.TrimSpace(s)) {
+				return nil, field.Label + " must only contain the listed options"
+			}
+			if s = strings.TrimSpace(s); !chosen[s] {
+				chosen[s] = true
+				selected = append(selected, s)
+			}
+		}
+		return selected, ""
+
+	case models.FormFieldCheckbox:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, field.Label + " must be true or false"
+		}
+		if field.Required && !b {
+			return nil, field.Label + " must be ticked"
+		}
+		return b, ""
+
+	case models.FormFieldDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, field.Label + " must be a date in YYYY-MM-DD format"
+		}
+		if _, err := time.Parse("2006-01-02", strings.TrimSpace(s)); err != nil {
+			return nil, field.Label + " must be a date in YYYY-MM-DD format"
+		}
+		return strings.TrimSpace(s), ""
+	}
+
+	return nil, field.Label + " has an unsupported type"
+}
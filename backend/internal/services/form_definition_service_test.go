@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestValidateFormFields(t *testing.T) {
+	fields := models.FormFields{
+		{Key: " Uniform_Size ", Label: " Uniform size ", Type: "SELECT", Options: []string{" 5-6 ", "7-8"}},
+		{Key: "children", Label: "Children", Type: models.FormFieldNumber},
+	}
+	if err := ValidateFormFields(fields); err != nil {
+		t.Fatalf("valid form rejected: %v", err)
+	}
+	if f := fields[0]; f.Key != "uniform_size" || f.Label != "Uniform size" || f.Type != models.FormFieldSelect || f.Options[0] != "5-6" {
+		t.Errorf("field not tidied: %+v", f)
+	}
+
+	min, max := 5.0, 1.0
+	tests := []struct {
+		name  string
+		field models.FormField
+	}{
+		{"key starting with a digit", models.FormField{Key: "1st", Label: "First", Type: models.FormFieldText}},
+		{"missing label", models.FormField{Key: "notes", Type: models.FormFieldText}},
+		{"select without options", models.FormField{Key: "size", Label: "Size", Type: models.FormFieldSelect}},
+		{"duplicate options", models.FormField{Key: "size", Label: "Size", Type: models.FormFieldMultiSelect, Options: []string{"S", " S"}}},
+		{"min above max", models.FormField{Key: "age", Label: "Age", Type: models.FormFieldNumber, Min: &min, Max: &max}},
+		{"unknown type", models.FormField{Key: "photo", Label: "Photo", Type: "file"}},
+		{"negative length", models.FormField{Key: "notes", Label: "Notes", Type: models.FormFieldText, MaxLength: -1}},
+	}
+	for _, tt := range tests {
+		if err := ValidateFormFields(models.FormFields{tt.field}); err == nil {
+			t.Errorf("%s: accepted", tt.name)
+		}
+	}
+
+	duplicate := models.FormFields{
+		{Key: "notes", Label: "Notes", Type: models.FormFieldText},
+		{Key: "NOTES", Label: "More notes", Type: models.FormFieldTextarea},
+	}
+	if err := ValidateFormFields(duplicate); err == nil {
+		t.Error("duplicate key accepted")
+	}
+	if err := ValidateFormFields(make(models.FormFields, FormMaxFields+1)); err == nil {
+		t.Error("too many questions accepted")
+	}
+}
+
+func TestValidateAnswers(t *testing.T) {
+	min, max := 1.0, 10.0
+	form := &models.FormDefinition{Fields: models.FormFields{
+		{Key: "children", Label: "Children", Type: models.FormFieldNumber, Required: true, Min: &min, Max: &max},
+		{Key: "sizes", Label: "Sizes", Type: models.FormFieldMultiSelect, Options: []string{"S", "M", "L"}},
+		{Key: "consent", Label: "Consent", Type: models.FormFieldCheckbox, Required: true},
+		{Key: "start", Label: "Start date", Type: models.FormFieldDate},
+		{Key: "notes", Label: "Notes", Type: models.FormFieldText, MaxLength: 5},
+	}}
+
+	answers, fieldErrors, err := ValidateAnswers(form, map[string]interface{}{
+		"children": 2.0,
+		"sizes":    []interface{}{"M", " S", "M"},
+		"consent":  true,
+		"start":    "",
+		"notes":    "  café ",
+	})
+	if err != nil {
+		t.Fatalf("valid answers rejected: %v %v", err, fieldErrors)
+	}
+	want := models.FormAnswers{
+		{Key: "children", Label: "Children", Value: 2.0},
+		{Key: "sizes", Label: "Sizes", Value: []string{"M", "S"}},
+		{Key: "consent", Label: "Consent", Value: true},
+		{Key: "notes", Label: "Notes", Value: "café"},
+	}
+	if !reflect.DeepEqual(answers, want) {
+		t.Errorf("got %+v, want %+v", answers, want)
+	}
+	if got := answers.Summary(); got != "Children: 2; Sizes: M, S; Consent: Yes; Notes: café" {
+		t.Errorf("summary %q", got)
+	}
+
+	_, fieldErrors, err = ValidateAnswers(form, map[string]interface{}{
+		"children": 11.0,
+		"sizes":    []interface{}{"XL"},
+		"consent":  false,
+		"start":    "01/05/2026",
+		"notes":    strings.Repeat("é", 6),
+		"pets":     "cat",
+	})
+	if !errors.Is(err, ErrFormAnswersInvalid) {
+		t.Fatalf("got %v, want ErrFormAnswersInvalid", err)
+	}
+	for _, key := range []string{"children", "sizes", "consent", "start", "notes", "pets"} {
+		if fieldErrors[key] == "" {
+			t.Errorf("no error for %s", key)
+		}
+	}
+
+	// A missing required answer is reported by label
+	_, fieldErrors, _ = ValidateAnswers(form, map[string]interface{}{"consent": true})
+	if fieldErrors["children"] != "Children is required" || len(fieldErrors) != 1 {
+		t.Errorf("got %v", fieldErrors)
+	}
+}
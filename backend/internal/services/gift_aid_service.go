@@ -0,0 +1,416 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Gift Aid declaration errors
+var (
+	ErrGiftAidInvalid        = errors.New("invalid Gift Aid declaration")
+	ErrGiftAidNotFound       = errors.New("no active Gift Aid declaration")
+	ErrGiftAidSchedulePeriod = errors.New("schedule period must end on or after its start and cover at most four years")
+)
+
+// HMRC limits on the donor fields of a Gift Aid schedule
+const (
+	giftAidTitleMax = 4
+	giftAidNameMax  = 35
+	giftAidHouseMax = 40
+)
+
+// GiftAidDeclarationInput is a Gift Aid declaration as made by the donor or recorded
+// by staff
+type GiftAidDeclarationInput struct {
+	UserID            *uint
+	DonationID        *uint
+	Title             string
+	FirstName         string
+	LastName          string
+	HouseNameOrNumber string
+	Postcode          string
+	Overseas          bool
+	Method            string
+	CoversPast        bool
+	RecordedBy        *uint
+}
+
+// GiftAidScheduleRow is one donation on an HMRC Gift Aid schedule
+type GiftAidScheduleRow struct {
+	DonationID        uint      `json:"donation_id"`
+	DeclarationID     uint      `json:"declaration_id"`
+	Title             string    `json:"title"`
+	FirstName         string    `json:"first_name"`
+	LastName          string    `json:"last_name"`
+	HouseNameOrNumber string    `json:"house_name_or_number"`
+	Postcode          string    `json:"postcode"` // X for donors living outside the UK
+	DonationDate      time.Time `json:"donation_date"`
+	Amount            float64   `json:"amount"` // Net of refunds
+}
+
+// GiftAidSchedule is the Gift Aid claim for a period: donations covered by a
+// declaration, and the totals to check against the claim
+type GiftAidSchedule struct {
+	From                time.Time            `json:"from"`
+	To                  time.Time            `json:"to"`
+	Rows                []GiftAidScheduleRow `json:"rows"`
+	TotalDonations      float64              `json:"total_donations"`
+	TotalGiftAid        float64              `json:"total_gift_aid"`
+	MissingDeclarations []uint               `json:"missing_declarations"` // Donors marked Gift Aid eligible without declaration details
+}
+
+// GiftAidService records Gift Aid declarations and builds the schedule of donations
+// the charity can claim Gift Aid on from HMRC
+type GiftAidService struct {
+	db *gorm.DB
+}
+
+// NewGiftAidService creates a new Gift Aid service
+func NewGiftAidService() *GiftAidService {
+	return &GiftAidService{db: db.DB}
+}
+
+// Declare stores a Gift Aid declaration. A declaration covers donations from the day
+// it is made, or from four years earlier when it covers past donations. Declarations
+// for a donor account mark their donor profile as Gift Aid eligible.
+func (gs *GiftAidService) Declare(input GiftAidDeclarationInput, now time.Time) (*models.GiftAidDeclaration, error) {
+	declaration, err := buildGiftAidDeclaration(input, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = gs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(declaration).Error; err != nil {
+			return err
+		}
+		if declaration.DonationID != nil {
+			if err := tx.Model(&models.Donation{}).Where("id = ?", *declaration.DonationID).
+				Update("gift_aid_id", declaration.ID).Error; err != nil {
+				return err
+			}
+		}
+		if declaration.UserID == nil {
+			return nil
+		}
+		return setGiftAidEligible(tx, *declaration.UserID, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return declaration, nil
+}
+
+// Validate checks a declaration without storing it, so a donation made with one can
+// be rejected before it is saved
+func (gs *GiftAidService) Validate(input GiftAidDeclarationInput, now time.Time) error {
+	_, err := buildGiftAidDeclaration(input, now)
+	return err
+}
+
+// Current returns the donor's most recent declaration that has not been cancelled
+func (gs *GiftAidService) Current(userID uint) (*models.GiftAidDeclaration, error) {
+	var declaration models.GiftAidDeclaration
+	err := gs.db.Where("user_id = ? AND cancelled_at IS NULL", userID).
+		Order("declared_at DESC").First(&declaration).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrGiftAidNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &declaration, nil
+}
+
+// History returns all of a donor's declarations, newest first
+func (gs *GiftAidService) History(userID uint) ([]models.GiftAidDeclaration, error) {
+	var declarations []models.GiftAidDeclaration
+	err := gs.db.Where("user_id = ?", userID).Order("declared_at DESC").Find(&declarations).Error
+	return declarations, err
+}
+
+// Cancel withdraws the donor's declarations. Donations already made stay covered;
+// later ones are not claimed.
+func (gs *GiftAidService) Cancel(userID uint, reason string, cancelledBy uint, now time.Time) (int64, error) {
+	var cancelled int64
+	err := gs.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.GiftAidDeclaration{}).
+			Where("user_id = ? AND cancelled_at IS NULL", userID).
+			Updates(map[string]interface{}{
+				"cancelled_at":  now,
+				"cancelled_by":  cancelledBy,
+				"cancel_reason": strings.TrimSpace(reason),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrGiftAidNotFound
+		}
+		cancelled = result.RowsAffected
+		return setGiftAidEligible(tx, userID, false)
+	})
+	return cancelled, err
+}
+
+// Declarations lists declarations for the admin view, optionally for one donor and
+// only those not cancelled
+func (gs *GiftAidService) Declarations(userID uint, activeOnly bool) ([]models.GiftAidDeclaration, error) {
+	query := gs.db.Model(&models.GiftAidDeclaration{})
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if activeOnly {
+		query = query.Where("cancelled_at IS NULL")
+	}
+	var declarations []models.GiftAidDeclaration
+	err := query.Order("declared_at DESC").Limit(500).Find(&declarations).Error
+	return declarations, err
+}
+
+// Schedule builds the Gift Aid schedule for donations received from one date to
+// another, inclusive. Only sterling money donations that were paid and kept, and
+// are covered by a declaration, are included.
+func (gs *GiftAidService) Schedule(from, to time.Time) (*GiftAidSchedule, error) {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+	if !end.After(start) || end.After(start.AddDate(models.GiftAidBackdateYears, 0, 1)) {
+		return nil, ErrGiftAidSchedulePeriod
+	}
+
+	var donations []models.Donation
+	if err := gs.db.
+		Where("type IN ? AND status IN ? AND UPPER(COALESCE(NULLIF(currency, ''), 'GBP')) = 'GBP'",
+			[]string{models.DonationTypeMoney, "monetary"}, taxYearDonationStatuses).
+		Where("COALESCE(received_at, created_at) >= ? AND COALESCE(received_at, created_at) < ?", start, end).
+		Order("COALESCE(received_at, created_at) ASC, id ASC").
+		Find(&donations).Error; err != nil {
+		return nil, err
+	}
+
+	donorIDs := map[uint]bool{}
+	declarationIDs := map[uint]bool{}
+	for _, donation := range donations {
+		if donor := donationDonor(donation); donor != nil {
+			donorIDs[*donor] = true
+		}
+		if donation.GiftAidID != nil {
+			declarationIDs[*donation.GiftAidID] = true
+		}
+	}
+
+	var declarations []models.GiftAidDeclaration
+	if len(donorIDs) > 0 || len(declarationIDs) > 0 {
+		if err := gs.db.Where("user_id IN ? OR id IN ?", mapKeys(donorIDs), mapKeys(declarationIDs)).
+			Order("declared_at DESC").Find(&declarations).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var eligible []uint
+	if len(donorIDs) > 0 {
+		if err := gs.db.Model(&models.DonorProfile{}).
+			Where("user_id IN ? AND gift_aid_eligible = ?", mapKeys(donorIDs), true).
+			Pluck("user_id", &eligible).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	schedule := buildGiftAidSchedule(donations, declarations, eligible)
+	schedule.From = start
+	schedule.To = end.AddDate(0, 0, -1)
+	return schedule, nil
+}
+
+// WriteGiftAidSchedule writes a schedule as CSV in the column order of HMRC's Gift
+// Aid schedule spreadsheet, ready to paste into a Charities Online claim
+func WriteGiftAidSchedule(w io.Writer, schedule *GiftAidSchedule) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"Title", "First name or initial", "Last name", "House name or number", "Postcode",
+		"Aggregated donations", "Sponsored event", "Donation date", "Amount"}); err != nil {
+		return err
+	}
+	for _, row := range schedule.Rows {
+		if err := writer.Write([]string{
+			row.Title,
+			row.FirstName,
+			row.LastName,
+			row.HouseNameOrNumber,
+			row.Postcode,
+			"",
+			"",
+			row.DonationDate.In(time.Local).Format("02/01/06"),
+			fmt.Sprintf("%.2f", row.Amount),
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// buildGiftAidSchedule matches donations to the declarations covering them. A
+// declaration made with a donation covers it; otherwise the donor's newest
+// declaration covering the donation date is used.
+func buildGiftAidSchedule(donations []models.Donation, declarations []models.GiftAidDeclaration, eligible []uint) *GiftAidSchedule {
+	byID := make(map[uint]models.GiftAidDeclaration, len(declarations))
+	byUser := map[uint][]models.GiftAidDeclaration{}
+	for _, declaration := range declarations {
+		byID[declaration.ID] = declaration
+		if declaration.UserID != nil {
+			byUser[*declaration.UserID] = append(byUser[*declaration.UserID], declaration)
+		}
+	}
+	for _, list := range byUser {
+		sort.Slice(list, func(i, j int) bool { return list[i].DeclaredAt.After(list[j].DeclaredAt) })
+	}
+	flagged := make(map[uint]bool, len(eligible))
+	for _, userID := range eligible {
+		flagged[userID] = true
+	}
+
+	schedule := &GiftAidSchedule{Rows: []GiftAidScheduleRow{}, MissingDeclarations: []uint{}}
+	missing := map[uint]bool{}
+	for _, donation := range donations {
+		net := roundPence(donation.Amount - donation.RefundedAmount)
+		if net <= 0 || donation.Status == models.DonationStatusDisputed {
+			continue
+		}
+		date := donation.CreatedAt
+		if donation.ReceivedAt != nil {
+			date = *donation.ReceivedAt
+		}
+
+		var covering *models.GiftAidDeclaration
+		if donation.GiftAidID != nil {
+			if declaration, ok := byID[*donation.GiftAidID]; ok {
+				covering = &declaration
+			}
+		}
+		donor := donationDonor(donation)
+		if covering == nil && donor != nil {
+			for _, declaration := range byUser[*donor] {
+				if declaration.Covers(date) {
+					covering = &declaration
+					break
+				}
+			}
+		}
+		if covering == nil {
+			if donor != nil && flagged[*donor] && len(byUser[*donor]) == 0 && !missing[*donor] {
+				missing[*donor] = true
+				schedule.MissingDeclarations = append(schedule.MissingDeclarations, *donor)
+			}
+			continue
+		}
+
+		postcode := covering.Postcode
+		if covering.Overseas {
+			postcode = "X"
+		}
+		schedule.Rows = append(schedule.Rows, GiftAidScheduleRow{
+			DonationID:        donation.ID,
+			DeclarationID:     covering.ID,
+			Title:             covering.Title,
+			FirstName:         covering.FirstName,
+			LastName:          covering.LastName,
+			HouseNameOrNumber: covering.HouseNameOrNumber,
+			Postcode:          postcode,
+			DonationDate:      date,
+			Amount:            net,
+		})
+		schedule.TotalDonations = roundPence(schedule.TotalDonations + net)
+	}
+	schedule.TotalGiftAid = roundPence(schedule.TotalDonations * models.GiftAidRate)
+	return schedule
+}
+
+// buildGiftAidDeclaration validates a declaration against HMRC's limits and tidies
+// the donor's details
+func buildGiftAidDeclaration(input GiftAidDeclarationInput, now time.Time) (*models.GiftAidDeclaration, error) {
+	clean := func(value string) string { return strings.Join(strings.Fields(value), " ") }
+	declaration := &models.GiftAidDeclaration{
+		UserID:            input.UserID,
+		DonationID:        input.DonationID,
+		Title:             strings.TrimSuffix(clean(input.Title), "."),
+		FirstName:         clean(input.FirstName),
+		LastName:          clean(input.LastName),
+		HouseNameOrNumber: clean(input.HouseNameOrNumber),
+		Overseas:          input.Overseas,
+		Method:            input.Method,
+		CoversPast:        input.CoversPast,
+		RecordedBy:        input.RecordedBy,
+		DeclaredAt:        now,
+		EffectiveFrom:     time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()),
+	}
+	if declaration.Method == "" {
+		declaration.Method = models.GiftAidMethodOnline
+	}
+
+	switch {
+	case declaration.Method != models.GiftAidMethodOnline && declaration.Method != models.GiftAidMethodWritten &&
+		declaration.Method != models.GiftAidMethodOral:
+		return nil, fmt.Errorf("%w: method must be online, written or oral", ErrGiftAidInvalid)
+	case declaration.FirstName == "" || declaration.LastName == "":
+		return nil, fmt.Errorf("%w: first and last name are required", ErrGiftAidInvalid)
+	case len(declaration.Title) > giftAidTitleMax:
+		return nil, fmt.Errorf("%w: title must be %d characters or fewer", ErrGiftAidInvalid, giftAidTitleMax)
+	case len(declaration.FirstName) > giftAidNameMax || len(declaration.LastName) > giftAidNameMax:
+		return nil, fmt.Errorf("%w: names must be %d characters or fewer", ErrGiftAidInvalid, giftAidNameMax)
+	case declaration.HouseNameOrNumber == "":
+		return nil, fmt.Errorf("%w: house name or number is required", ErrGiftAidInvalid)
+	case len(declaration.HouseNameOrNumber) > giftAidHouseMax:
+		return nil, fmt.Errorf("%w: house name or number must be %d characters or fewer", ErrGiftAidInvalid, giftAidHouseMax)
+	}
+
+	if !declaration.Overseas {
+		postcode, ok := normalizeCarpoolPostcode(input.Postcode)
+		if !ok {
+			return nil, fmt.Errorf("%w: a valid UK postcode is required unless you live outside the UK", ErrGiftAidInvalid)
+		}
+		declaration.Postcode = postcode
+	}
+	if declaration.CoversPast {
+		declaration.EffectiveFrom = declaration.EffectiveFrom.AddDate(-models.GiftAidBackdateYears, 0, 0)
+	}
+	return declaration, nil
+}
+
+// setGiftAidEligible keeps the donor profile flag used by receipts, tax summaries and
+// refunds in step with the donor's declarations
+func setGiftAidEligible(tx *gorm.DB, userID uint, eligible bool) error {
+	profile := models.DonorProfile{UserID: userID}
+	if err := tx.Where("user_id = ?", userID).FirstOrCreate(&profile).Error; err != nil {
+		return err
+	}
+	return tx.Model(&profile).Update("gift_aid_eligible", eligible).Error
+}
+
+// donationDonor returns the donor account a donation belongs to, if any
+func donationDonor(donation models.Donation) *uint {
+	if donation.UserID != nil {
+		return donation.UserID
+	}
+	return donation.DonorID
+}
+
+// mapKeys returns the keys of a set, or a zero ID so IN clauses stay valid
+func mapKeys(set map[uint]bool) []uint {
+	keys := make([]uint, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		keys = append(keys, 0)
+	}
+	return keys
+}
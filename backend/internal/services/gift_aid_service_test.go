@@ -0,0 +1,141 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestBuildGiftAidDeclaration(t *testing.T) {
+	now := time.Date(2026, 10, 17, 14, 30, 0, 0, time.UTC)
+	valid := GiftAidDeclarationInput{
+		Title:             "Mrs.",
+		FirstName:         " Jane ",
+		LastName:          "Smith",
+		HouseNameOrNumber: "12",
+		Postcode:          "se136ab",
+	}
+
+	declaration, err := buildGiftAidDeclaration(valid, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if declaration.Title != "Mrs" || declaration.FirstName != "Jane" || declaration.Postcode != "SE13 6AB" {
+		t.Errorf("details not tidied: %+v", declaration)
+	}
+	if declaration.Method != models.GiftAidMethodOnline {
+		t.Errorf("method = %q, want online", declaration.Method)
+	}
+	if want := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC); !declaration.EffectiveFrom.Equal(want) {
+		t.Errorf("effective from %s, want %s", declaration.EffectiveFrom, want)
+	}
+
+	backdated := valid
+	backdated.CoversPast = true
+	declaration, err = buildGiftAidDeclaration(backdated, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2022, 10, 17, 0, 0, 0, 0, time.UTC); !declaration.EffectiveFrom.Equal(want) {
+		t.Errorf("backdated declaration effective from %s, want %s", declaration.EffectiveFrom, want)
+	}
+
+	overseas := valid
+	overseas.Postcode = ""
+	overseas.Overseas = true
+	if declaration, err = buildGiftAidDeclaration(overseas, now); err != nil || declaration.Postcode != "" {
+		t.Errorf("overseas donor should not need a postcode: %+v, %v", declaration, err)
+	}
+
+	invalid := map[string]func(*GiftAidDeclarationInput){
+		"missing last name":  func(in *GiftAidDeclarationInput) { in.LastName = " " },
+		"missing house":      func(in *GiftAidDeclarationInput) { in.HouseNameOrNumber = "" },
+		"bad postcode":       func(in *GiftAidDeclarationInput) { in.Postcode = "12345" },
+		"long title":         func(in *GiftAidDeclarationInput) { in.Title = "Professor" },
+		"long first name":    func(in *GiftAidDeclarationInput) { in.FirstName = strings.Repeat("a", 36) },
+		"unknown method":     func(in *GiftAidDeclarationInput) { in.Method = "email" },
+		"long house details": func(in *GiftAidDeclarationInput) { in.HouseNameOrNumber = strings.Repeat("b", 41) },
+	}
+	for name, change := range invalid {
+		t.Run(name, func(t *testing.T) {
+			input := valid
+			change(&input)
+			if _, err := buildGiftAidDeclaration(input, now); !errors.Is(err, ErrGiftAidInvalid) {
+				t.Errorf("expected ErrGiftAidInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildGiftAidSchedule(t *testing.T) {
+	donor, guestDeclarationID, lapsedDonor := uint(7), uint(20), uint(9)
+	declaredAt := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	cancelledAt := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	declarations := []models.GiftAidDeclaration{
+		{ID: 10, UserID: &donor, FirstName: "Jane", LastName: "Smith", HouseNameOrNumber: "12", Postcode: "SE13 6AB",
+			DeclaredAt: declaredAt, EffectiveFrom: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), CancelledAt: &cancelledAt},
+		{ID: guestDeclarationID, FirstName: "Tom", LastName: "Jones", HouseNameOrNumber: "Rose Cottage", Overseas: true,
+			DeclaredAt: declaredAt, EffectiveFrom: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	on := func(month time.Month, day int) time.Time { return time.Date(2026, month, day, 12, 0, 0, 0, time.UTC) }
+	received := on(6, 8)
+	donations := []models.Donation{
+		{ID: 1, UserID: &donor, Amount: 50, CreatedAt: on(6, 1)},                                        // Covered
+		{ID: 2, DonorID: &donor, Amount: 30, RefundedAmount: 10, CreatedAt: on(6, 2)},                   // Covered, net of refund
+		{ID: 3, UserID: &donor, Amount: 20, CreatedAt: on(4, 1)},                                        // Before the declaration
+		{ID: 4, UserID: &donor, Amount: 20, CreatedAt: on(8, 1)},                                        // After it was cancelled
+		{ID: 5, UserID: &donor, Amount: 25, RefundedAmount: 25, CreatedAt: on(6, 3)},                    // Fully refunded
+		{ID: 6, Amount: 15, GiftAidID: &guestDeclarationID, CreatedAt: on(6, 4)},                        // Guest declaration
+		{ID: 7, UserID: &donor, Amount: 40, Status: models.DonationStatusDisputed, CreatedAt: on(6, 5)}, // In dispute
+		{ID: 8, UserID: &lapsedDonor, Amount: 10, CreatedAt: on(6, 6)},                                  // Flag without declaration
+		{ID: 9, UserID: &lapsedDonor, Amount: 10, CreatedAt: on(6, 7), ReceivedAt: &received},           // Same donor again
+	}
+
+	schedule := buildGiftAidSchedule(donations, declarations, []uint{donor, lapsedDonor})
+
+	var ids []uint
+	for _, row := range schedule.Rows {
+		ids = append(ids, row.DonationID)
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 2 || ids[2] != 6 {
+		t.Fatalf("scheduled donations = %v, want [1 2 6]", ids)
+	}
+	if schedule.Rows[1].Amount != 20 {
+		t.Errorf("refunded donation amount = %.2f, want net 20.00", schedule.Rows[1].Amount)
+	}
+	if guest := schedule.Rows[2]; guest.Postcode != "X" || guest.LastName != "Jones" {
+		t.Errorf("overseas guest row = %+v, want postcode X", guest)
+	}
+	if schedule.TotalDonations != 85 || schedule.TotalGiftAid != 21.25 {
+		t.Errorf("totals = %.2f / %.2f, want 85.00 / 21.25", schedule.TotalDonations, schedule.TotalGiftAid)
+	}
+	if len(schedule.MissingDeclarations) != 1 || schedule.MissingDeclarations[0] != lapsedDonor {
+		t.Errorf("missing declarations = %v, want [%d]", schedule.MissingDeclarations, lapsedDonor)
+	}
+}
+
+func TestWriteGiftAidSchedule(t *testing.T) {
+	schedule := &GiftAidSchedule{Rows: []GiftAidScheduleRow{{
+		Title: "Mr", FirstName: "Tom", LastName: "Jones", HouseNameOrNumber: "12", Postcode: "SE13 6AB",
+		DonationDate: time.Date(2026, 6, 4, 12, 0, 0, 0, time.Local), Amount: 15,
+	}}}
+
+	var buf bytes.Buffer
+	if err := WriteGiftAidSchedule(&buf, schedule); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want header and one row", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "Title,First name or initial,Last name,House name or number,Postcode") {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if want := "Mr,Tom,Jones,12,SE13 6AB,,,04/06/26,15.00"; lines[1] != want {
+		t.Errorf("row = %q, want %q", lines[1], want)
+	}
+}
@@ -0,0 +1,413 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// helpRequestDraftLifetime is how long a draft is kept after it was last saved
+	helpRequestDraftLifetime = 7 * 24 * time.Hour
+	// minHelpRequestDetails matches the shortest description the form accepts
+	minHelpRequestDetails = 10
+	maxHouseholdSize      = 20
+)
+
+// Help request draft errors
+var (
+	ErrDraftNotFound        = errors.New("no help request draft to resume")
+	ErrUnknownWizardStep    = errors.New("unknown help request step")
+	ErrWizardStepIncomplete = errors.New("help request step has problems")
+)
+
+// helpRequestUrgencies are the urgency levels a visitor can choose
+var helpRequestUrgencies = []string{"Low", "Medium", "High"}
+
+// HelpRequestDraftAnswers are the answers a visitor has given so far. The fields
+// match a help request submission, so a finished draft can be submitted as it is.
+type HelpRequestDraftAnswers struct {
+	Category            string                 `json:"category,omitempty"`
+	Details             string                 `json:"details,omitempty"`
+	UrgencyLevel        string                 `json:"urgency_level,omitempty"`
+	HouseholdSize       int                    `json:"household_size,omitempty"`
+	SpecialNeeds        string                 `json:"special_needs,omitempty"`
+	InterpreterLanguage string                 `json:"interpreter_language,omitempty"`
+	VisitDay            string                 `json:"visit_day,omitempty"`
+	TimeSlot            string                 `json:"time_slot,omitempty"`
+	FormAnswers         map[string]interface{} `json:"form_answers,omitempty"`
+}
+
+// WizardDraft is a visitor's draft as they resume it
+type WizardDraft struct {
+	ID           uint                    `json:"id"`
+	Answers      HelpRequestDraftAnswers `json:"answers"`
+	Steps        []string                `json:"steps"`
+	FurthestStep string                  `json:"furthest_step"`
+	NextStep     string                  `json:"next_step"` // "submit" once every step is complete
+	ExpiresAt    time.Time               `json:"expires_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+}
+
+// WizardStepFunnel is how many drafts reached, completed and dropped off at a step
+type WizardStepFunnel struct {
+	Step        string  `json:"step"`
+	Reached     int64   `json:"reached"`
+	Completed   int64   `json:"completed"`
+	DroppedOff  int64   `json:"dropped_off"`   // Expired or discarded with this step next
+	DropOffRate float64 `json:"drop_off_rate"` // Percent of those reaching the step
+}
+
+// WizardFunnel is drop-off through the help request wizard for drafts started in a
+// period
+type WizardFunnel struct {
+	From      string             `json:"from"`
+	To        string             `json:"to"`
+	Started   int64              `json:"started"`
+	Submitted int64              `json:"submitted"`
+	Abandoned int64              `json:"abandoned"`
+	Active    int64              `json:"active"`
+	Steps     []WizardStepFunnel `json:"steps"` // Ends with the submit step
+}
+
+// wizardFunnelRow counts drafts by outcome and progress
+type wizardFunnelRow struct {
+	Status       string
+	FurthestStep string
+	Count        int64
+}
+
+// HelpRequestDraftService saves a visitor's progress through the help request wizard
+// so they can resume it, validates each step as it is saved, and measures where
+// visitors give up
+type HelpRequestDraftService struct {
+	db *gorm.DB
+}
+
+// NewHelpRequestDraftService creates a new help request draft service
+func NewHelpRequestDraftService() *HelpRequestDraftService {
+	return &HelpRequestDraftService{db: db.DB}
+}
+
+// Current returns the visitor's active draft
+func (ds *HelpRequestDraftService) Current(visitorID uint, now time.Time) (*WizardDraft, error) {
+	draft, err := ds.active(visitorID, now)
+	if err != nil {
+		return nil, err
+	}
+	if draft == nil {
+		return nil, ErrDraftNotFound
+	}
+	return wizardDraft(draft)
+}
+
+// SaveStep saves the answers for one step, starting a draft if the visitor has none.
+// Answers are kept even when the step has problems, so nothing typed is lost; the
+// problems come back by field with ErrWizardStepIncomplete and the draft does not
+// move past the step.
+func (ds *HelpRequestDraftService) SaveStep(visitorID uint, step string, input HelpRequestDraftAnswers, now time.Time) (*WizardDraft, map[string]string, error) {
+	if models.WizardStepIndex(step) < 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownWizardStep, step)
+	}
+
+	draft, err := ds.active(visitorID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	var answers HelpRequestDraftAnswers
+	if draft == nil {
+		draft = &models.HelpRequestDraft{VisitorID: visitorID, Status: models.HelpRequestDraftActive}
+	} else if draft.Answers != "" {
+		if err := json.Unmarshal([]byte(draft.Answers), &answers); err != nil {
+			return nil, nil, fmt.Errorf("failed to read draft %d: %w", draft.ID, err)
+		}
+	}
+	mergeWizardStep(step, &answers, input)
+
+	furthest, fieldErrors, err := wizardProgress(answers, step, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	encoded, err := json.Marshal(answers)
+	if err != nil {
+		return nil, nil, err
+	}
+	draft.Answers = string(encoded)
+	draft.FurthestStep = furthest
+	draft.ExpiresAt = now.Add(helpRequestDraftLifetime)
+	if err := ds.db.Save(draft).Error; err != nil {
+		return nil, nil, err
+	}
+
+	view, err := wizardDraft(draft)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(fieldErrors) > 0 {
+		return view, fieldErrors, ErrWizardStepIncomplete
+	}
+	return view, nil, nil
+}
+
+// Discard ends the visitor's active draft without submitting it
+func (ds *HelpRequestDraftService) Discard(visitorID uint, now time.Time) error {
+	result := ds.end(ds.db.Where("visitor_id = ? AND status = ? AND expires_at > ?", visitorID, models.HelpRequestDraftActive, now),
+		models.HelpRequestDraftDiscarded, nil, now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDraftNotFound
+	}
+	return nil
+}
+
+// Complete marks the visitor's active draft as submitted once their help request is
+// created. It does nothing when they have no draft.
+func (ds *HelpRequestDraftService) Complete(visitorID, helpRequestID uint, now time.Time) error {
+	return ds.end(ds.db.Where("visitor_id = ? AND status = ?", visitorID, models.HelpRequestDraftActive),
+		models.HelpRequestDraftSubmitted, &helpRequestID, now).Error
+}
+
+// ExpireDrafts ends drafts that have not been saved for the draft lifetime
+func (ds *HelpRequestDraftService) ExpireDrafts(now time.Time) (int64, error) {
+	result := ds.end(ds.db.Where("status = ? AND expires_at <= ?", models.HelpRequestDraftActive, now),
+		models.HelpRequestDraftExpired, nil, now)
+	return result.RowsAffected, result.Error
+}
+
+// Funnel measures drop-off through the wizard for drafts started in [from, to)
+func (ds *HelpRequestDraftService) Funnel(from, to time.Time) (*WizardFunnel, error) {
+	var rows []wizardFunnelRow
+	if err := ds.db.Model(&models.HelpRequestDraft{}).
+		Select("status, furthest_step, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("status, furthest_step").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	funnel := wizardFunnel(rows)
+	funnel.From, funnel.To = from.Format("2006-01-02"), to.Format("2006-01-02")
+	return &funnel, nil
+}
+
+// active loads the visitor's unexpired active draft, or nil when they have none
+func (ds *HelpRequestDraftService) active(visitorID uint, now time.Time) (*models.HelpRequestDraft, error) {
+	var draft models.HelpRequestDraft
+	err := ds.db.Where("visitor_id = ? AND status = ? AND expires_at > ?", visitorID, models.HelpRequestDraftActive, now).
+		Order("updated_at DESC").First(&draft).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// end closes the drafts a query selects, clearing their answers
+func (ds *HelpRequestDraftService) end(query *gorm.DB, status string, helpRequestID *uint, now time.Time) *gorm.DB {
+	updates := map[string]interface{}{"status": status, "answers": "", "ended_at": now}
+	if helpRequestID != nil {
+		updates["help_request_id"] = *helpRequestID
+	}
+	return query.Model(&models.HelpRequestDraft{}).Updates(updates)
+}
+
+// wizardDraft builds the view of a draft
+func wizardDraft(draft *models.HelpRequestDraft) (*WizardDraft, error) {
+	view := &WizardDraft{
+		ID:           draft.ID,
+		Steps:        models.HelpRequestWizardSteps,
+		FurthestStep: draft.FurthestStep,
+		NextStep:     draft.NextStep(),
+		ExpiresAt:    draft.ExpiresAt,
+		UpdatedAt:    draft.UpdatedAt,
+	}
+	if draft.Answers != "" {
+		if err := json.Unmarshal([]byte(draft.Answers), &view.Answers); err != nil {
+			return nil, fmt.Errorf("failed to read draft %d: %w", draft.ID, err)
+		}
+	}
+	return view, nil
+}
+
+// mergeWizardStep copies the fields a step asks for into the saved answers
+func mergeWizardStep(step string, answers *HelpRequestDraftAnswers, input HelpRequestDraftAnswers) {
+	switch step {
+	case models.WizardStepCategory:
+		answers.Category = strings.TrimSpace(input.Category)
+	case models.WizardStepNeeds:
+		answers.Details = strings.TrimSpace(input.Details)
+		answers.UrgencyLevel = strings.TrimSpace(input.UrgencyLevel)
+		answers.HouseholdSize = input.HouseholdSize
+		answers.SpecialNeeds = strings.TrimSpace(input.SpecialNeeds)
+		answers.InterpreterLanguage = strings.TrimSpace(input.InterpreterLanguage)
+	case models.WizardStepVisit:
+		answers.VisitDay = strings.TrimSpace(input.VisitDay)
+		answers.TimeSlot = strings.TrimSpace(input.TimeSlot)
+	case models.WizardStepQuestions:
+		answers.FormAnswers = input.FormAnswers
+	}
+}
+
+// wizardProgress validates the steps in order and returns the last one completed
+// before any with problems, along with the problems in the step just saved
+func wizardProgress(answers HelpRequestDraftAnswers, saved string, now time.Time) (string, map[string]string, error) {
+	furthest := ""
+	blocked := false
+	var savedErrors map[string]string
+	for i, step := range models.HelpRequestWizardSteps {
+		fieldErrors, err := validateWizardStep(step, answers, now)
+		if err != nil {
+			return "", nil, err
+		}
+		if step == saved {
+			savedErrors = fieldErrors
+		}
+		if len(fieldErrors) > 0 {
+			blocked = true
+		} else if !blocked {
+			furthest = step
+		}
+		if blocked && i >= models.WizardStepIndex(saved) {
+			break
+		}
+	}
+	return furthest, savedErrors, nil
+}
+
+// validateWizardStep checks one step's answers and returns problems by field
+func validateWizardStep(step string, answers HelpRequestDraftAnswers, now time.Time) (map[string]string, error) {
+	fieldErrors := map[string]string{}
+	switch step {
+	case models.WizardStepCategory:
+		if answers.Category == "" {
+			fieldErrors["category"] = "Choose the help you need"
+			break
+		}
+		serviceType, err := NewServiceTypeService().GetByCode(answers.Category)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err == nil && !serviceType.IsActive {
+			fieldErrors["category"] = fmt.Sprintf("%s is not currently available", serviceType.Name)
+		}
+
+	case models.WizardStepNeeds:
+		if len(answers.Details) < minHelpRequestDetails {
+			fieldErrors["details"] = "Please provide more details about your needs"
+		}
+		if answers.UrgencyLevel != "" && !slices.Contains(helpRequestUrgencies, answers.UrgencyLevel) {
+			fieldErrors["urgency_level"] = "Urgency must be " + strings.Join(helpRequestUrgencies, ", ")
+		}
+		if answers.HouseholdSize < 0 || answers.HouseholdSize > maxHouseholdSize {
+			fieldErrors["household_size"] = fmt.Sprintf("Household size must be between 1 and %d", maxHouseholdSize)
+		}
+		if len(answers.InterpreterLanguage) > 100 {
+			fieldErrors["interpreter_language"] = "Interpreter language must be at most 100 characters"
+		}
+
+	case models.WizardStepVisit:
+		if answers.TimeSlot == "" {
+			fieldErrors["time_slot"] = "Please select a time slot"
+		}
+		if answers.VisitDay == "" {
+			fieldErrors["visit_day"] = "Please select a date"
+			break
+		}
+		day, err := time.ParseInLocation("2006-01-02", answers.VisitDay, time.Local)
+		if err != nil {
+			fieldErrors["visit_day"] = ErrInvalidVisitDay.Error()
+			break
+		}
+		if today, _ := dayRange(now); day.Before(today) {
+			fieldErrors["visit_day"] = "Please select a date from today onwards"
+			break
+		}
+		window, err := NewBookingWindowService().Check(answers.Category, answers.VisitDay, now)
+		switch {
+		case errors.Is(err, ErrBookingNotOpen), errors.Is(err, ErrBookingClosed):
+			fieldErrors["visit_day"] = window.Message
+		case err != nil:
+			return nil, err
+		}
+
+	case models.WizardStepQuestions:
+		if answers.Category == "" {
+			fieldErrors["category"] = "Choose the help you need first"
+			break
+		}
+		form, err := NewFormDefinitionService().ActiveForCategory(answers.Category)
+		if err != nil {
+			return nil, err
+		}
+		if form == nil {
+			break
+		}
+		if _, problems, err := ValidateAnswers(form, answers.FormAnswers); err != nil {
+			for key, problem := range problems {
+				fieldErrors["form_answers."+key] = problem
+			}
+		}
+	}
+	if len(fieldErrors) == 0 {
+		return nil, nil
+	}
+	return fieldErrors, nil
+}
+
+// wizardFunnel works out reach and drop-off per step. Submitted drafts passed every
+// step, whatever the wizard recorded, as submission checks the whole request.
+func wizardFunnel(rows []wizardFunnelRow) WizardFunnel {
+	steps := append(append([]string{}, models.HelpRequestWizardSteps...), models.WizardStepSubmit)
+	funnel := WizardFunnel{Steps: make([]WizardStepFunnel, len(steps))}
+	for i, step := range steps {
+		funnel.Steps[i].Step = step
+	}
+
+	for _, row := range rows {
+		funnel.Started += row.Count
+		completed := models.WizardStepIndex(row.FurthestStep) // Index of the last step completed
+		ended := false
+		switch row.Status {
+		case models.HelpRequestDraftSubmitted:
+			funnel.Submitted += row.Count
+			completed = len(steps) - 1
+		case models.HelpRequestDraftExpired, models.HelpRequestDraftDiscarded:
+			funnel.Abandoned += row.Count
+			ended = true
+		default:
+			funnel.Active += row.Count
+		}
+
+		for i := range steps {
+			if i <= completed+1 {
+				funnel.Steps[i].Reached += row.Count
+			}
+			if i <= completed {
+				funnel.Steps[i].Completed += row.Count
+			}
+		}
+		if ended {
+			funnel.Steps[completed+1].DroppedOff += row.Count
+		}
+	}
+
+	for i := range funnel.Steps {
+		if funnel.Steps[i].Reached > 0 {
+			rate := float64(funnel.Steps[i].DroppedOff) / float64(funnel.Steps[i].Reached) * 100
+			funnel.Steps[i].DropOffRate = math.Round(rate*10) / 10
+		}
+	}
+	return funnel
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestValidateWizardNeedsStep(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		answers HelpRequestDraftAnswers
+		fields  []string
+	}{
+		{"complete", HelpRequestDraftAnswers{Details: "We need food for the week", UrgencyLevel: "High", HouseholdSize: 3}, nil},
+		{"optional fields left out", HelpRequestDraftAnswers{Details: "We need food for the week"}, nil},
+		{"details too short", HelpRequestDraftAnswers{Details: "Food"}, []string{"details"}},
+		{"unknown urgency and household too big", HelpRequestDraftAnswers{Details: "We need food for the week", UrgencyLevel: "Critical", HouseholdSize: 25},
+			[]string{"urgency_level", "household_size"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fieldErrors, err := validateWizardStep(models.WizardStepNeeds, tt.answers, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(fieldErrors) != len(tt.fields) {
+				t.Fatalf("got problems %v, want %v", fieldErrors, tt.fields)
+			}
+			for _, field := range tt.fields {
+				if fieldErrors[field] == "" {
+					t.Errorf("expected a problem with %s, got %v", field, fieldErrors)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeWizardStep(t *testing.T) {
+	answers := HelpRequestDraftAnswers{Category: "Food", VisitDay: "2025-10-14"}
+	mergeWizardStep(models.WizardStepNeeds, &answers, HelpRequestDraftAnswers{
+		Category:      "General", // Not part of this step
+		Details:       "  We need food for the week ",
+		HouseholdSize: 2,
+	})
+	if answers.Category != "Food" || answers.VisitDay != "2025-10-14" {
+		t.Errorf("other steps' answers changed: %+v", answers)
+	}
+	if answers.Details != "We need food for the week" || answers.HouseholdSize != 2 {
+		t.Errorf("step answers not saved: %+v", answers)
+	}
+}
+
+func TestHelpRequestDraftNextStep(t *testing.T) {
+	tests := map[string]string{
+		"":                         models.WizardStepCategory,
+		models.WizardStepCategory:  models.WizardStepNeeds,
+		models.WizardStepVisit:     models.WizardStepQuestions,
+		models.WizardStepQuestions: models.WizardStepSubmit,
+	}
+	for furthest, want := range tests {
+		draft := models.HelpRequestDraft{FurthestStep: furthest}
+		if got := draft.NextStep(); got != want {
+			t.Errorf("after %q got next step %q, want %q", furthest, got, want)
+		}
+	}
+}
+
+func TestWizardFunnel(t *testing.T) {
+	funnel := wizardFunnel([]wizardFunnelRow{
+		{Status: models.HelpRequestDraftExpired, FurthestStep: "", Count: 2},                         // Gave up choosing a category
+		{Status: models.HelpRequestDraftExpired, FurthestStep: models.WizardStepNeeds, Count: 3},     // Gave up picking a visit
+		{Status: models.HelpRequestDraftDiscarded, FurthestStep: models.WizardStepNeeds, Count: 1},   // Same, but discarded
+		{Status: models.HelpRequestDraftExpired, FurthestStep: models.WizardStepQuestions, Count: 1}, // Never submitted
+		{Status: models.HelpRequestDraftActive, FurthestStep: models.WizardStepCategory, Count: 2},
+		{Status: models.HelpRequestDraftSubmitted, FurthestStep: models.WizardStepVisit, Count: 4},
+	})
+
+	if funnel.Started != 13 || funnel.Submitted != 4 || funnel.Abandoned != 7 || funnel.Active != 2 {
+		t.Fatalf("got started %d submitted %d abandoned %d active %d",
+			funnel.Started, funnel.Submitted, funnel.Abandoned, funnel.Active)
+	}
+
+	want := []WizardStepFunnel{
+		{Step: models.WizardStepCategory, Reached: 13, Completed: 11, DroppedOff: 2, DropOffRate: 15.4},
+		{Step: models.WizardStepNeeds, Reached: 11, Completed: 9, DroppedOff: 0},
+		{Step: models.WizardStepVisit, Reached: 9, Completed: 5, DroppedOff: 4, DropOffRate: 44.4},
+		{Step: models.WizardStepQuestions, Reached: 5, Completed: 5, DroppedOff: 0},
+		{Step: models.WizardStepSubmit, Reached: 5, Completed: 4, DroppedOff: 1, DropOffRate: 20},
+	}
+	if len(funnel.Steps) != len(want) {
+		t.Fatalf("got %d steps, want %d", len(funnel.Steps), len(want))
+	}
+	for i := range want {
+		if funnel.Steps[i] != want[i] {
+			t.Errorf("step %d: got %+v, want %+v", i, funnel.Steps[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,1052 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxHistoricImportRows caps one spreadsheet so a batch can be reviewed and committed
+// in one go
+const maxHistoricImportRows = 20000
+
+var (
+	ErrHistoricImportNotFound     = errors.New("import batch not found")
+	ErrHistoricImportKind         = errors.New("import kind must be visitors, donations or volunteer_hours")
+	ErrHistoricImportFile         = errors.New("invalid import file")
+	ErrHistoricImportNotStaged    = errors.New("only a staged import can be committed or discarded")
+	ErrHistoricImportNotCommitted = errors.New("only a committed import can be rolled back")
+	ErrHistoricImportNothingValid = errors.New("the import has no valid rows to commit")
+	ErrHistoricImportInUse        = errors.New("imported records have been used since the import")
+)
+
+// HistoricImportTemplate describes the CSV columns for one kind of import
+type HistoricImportTemplate struct {
+	Kind     string   `json:"kind"`
+	Columns  []string `json:"columns"`
+	Required []string `json:"required"`
+	Example  []string `json:"example"`
+}
+
+// historicImportTemplates are the spreadsheet layouts the import accepts
+var historicImportTemplates = map[string]HistoricImportTemplate{
+	models.HistoricImportVisitors: {
+		Kind:     models.HistoricImportVisitors,
+		Columns:  []string{"FirstName", "LastName", "Email", "Phone", "Address", "City", "Postcode", "HouseholdSize", "FirstVisit", "Notes"},
+		Required: []string{"FirstName", "LastName"},
+		Example:  []string{"Jane", "Smith", "jane@example.org", "07700 900123", "1 High Street", "London", "SE13 5AB", "3", "14/03/2019", "Referred by GP"},
+	},
+	models.HistoricImportDonations: {
+		Kind:     models.HistoricImportDonations,
+		Columns:  []string{"Date", "DonorName", "DonorEmail", "Type", "Amount", "Goods", "GoodsValue", "PaymentMethod", "Reference", "Notes"},
+		Required: []string{"Date", "Type"},
+		Example:  []string{"02/12/2018", "John Brown", "john@example.org", "monetary", "25.00", "", "", "cash", "", "Christmas appeal"},
+	},
+	models.HistoricImportVolunteerHours: {
+		Kind:     models.HistoricImportVolunteerHours,
+		Columns:  []string{"VolunteerEmail", "Date", "Hours", "Role", "Location", "Notes"},
+		Required: []string{"VolunteerEmail", "Date", "Hours"},
+		Example:  []string{"sam@example.org", "21/06/2017", "3.5", "Warehouse", "Main hall", ""},
+	},
+}
+
+// HistoricImportProblem is one validation problem with a spreadsheet row
+type HistoricImportProblem struct {
+	Column string `json:"column,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// HistoricImportRowReport is a staged row with its values and problems decoded
+type HistoricImportRowReport struct {
+	models.HistoricImportRow
+	Values   map[string]string       `json:"values"`
+	Problems []HistoricImportProblem `json:"problems"`
+}
+
+// HistoricImportReport is the validation report and preview of a batch
+type HistoricImportReport struct {
+	Batch *models.HistoricImportBatch `json:"batch"`
+	// ProblemsByColumn counts the problems found in each column across the batch
+	ProblemsByColumn map[string]int            `json:"problems_by_column"`
+	Rows             []HistoricImportRowReport `json:"rows"`
+	Total            int64                     `json:"total"`
+}
+
+// historicRow is a spreadsheet row being validated and matched
+type historicRow struct {
+	number      int
+	values      map[string]string
+	problems    []HistoricImportProblem
+	status      string
+	matchedType string
+	matchedID   *uint
+	matchReason string
+	userID      uint // The volunteer the hours belong to, or the donor found by email
+}
+
+// HistoricImportService stages spreadsheets of past records, reports on them, and
+// commits or rolls back whole batches
+type HistoricImportService struct {
+	db *gorm.DB
+}
+
+// NewHistoricImportService creates a new historic import service
+func NewHistoricImportService() *HistoricImportService {
+	return &HistoricImportService{
+		db: db.DB,
+	}
+}
+
+// Template returns the CSV layout for a kind of import
+func (hs *HistoricImportService) Template(kind string) (*HistoricImportTemplate, error) {
+	template, ok := historicImportTemplates[kind]
+	if !ok {
+		return nil, ErrHistoricImportKind
+	}
+	return &template, nil
+}
+
+// historicColumnKey normalises a header so "First Name", "first_name" and
+// "FirstName" all match, ignoring the byte order mark Excel writes
+func historicColumnKey(header string) string {
+	header = strings.TrimPrefix(strings.TrimSpace(header), "\ufeff")
+	return strings.ToLower(strings.NewReplacer(" ", "", "_", "", "-", "").Replace(header))
+}
+
+// parseHistoricCSV reads a template spreadsheet and validates each row
+func parseHistoricCSV(kind string, r io.Reader, now time.Time) ([]*historicRow, error) {
+	template, ok := historicImportTemplates[kind]
+	if !ok {
+		return nil, ErrHistoricImportKind
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not read the header row: %v", ErrHistoricImportFile, err)
+	}
+
+	known := make(map[string]string, len(template.Columns))
+	for _, column := range template.Columns {
+		known[historicColumnKey(column)] = column
+	}
+	columnIndices := make(map[string]int)
+	for i, h := range header {
+		if column, ok := known[historicColumnKey(h)]; ok {
+			columnIndices[column] = i
+		}
+	}
+	for _, column := range template.Required {
+		if _, ok := columnIndices[column]; !ok {
+			return nil, fmt.Errorf("%w: required column '%s' not found", ErrHistoricImportFile, column)
+		}
+	}
+
+	var rows []*historicRow
+	rowNum := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		rowNum++
+		if err != nil {
+			rows = append(rows, &historicRow{
+				number:   rowNum,
+				values:   map[string]string{},
+				problems: []HistoricImportProblem{{Reason: err.Error()}},
+				status:   models.HistoricRowInvalid,
+			})
+			continue
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(rows) >= maxHistoricImportRows {
+			return nil, fmt.Errorf("%w: a file can hold at most %d rows", ErrHistoricImportFile, maxHistoricImportRows)
+		}
+
+		values := make(map[string]string, len(template.Columns))
+		for column, idx := range columnIndices {
+			if idx < len(record) {
+				values[column] = strings.TrimSpace(record[idx])
+			}
+		}
+		row := &historicRow{number: rowNum, values: values, status: models.HistoricRowValid}
+		row.problems = validateHistoricRow(kind, values, now)
+		if len(row.problems) > 0 {
+			row.status = models.HistoricRowInvalid
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the file has no rows", ErrHistoricImportFile)
+	}
+	return rows, nil
+}
+
+// parseHistoricDate parses the date formats found in old spreadsheets, day first
+func parseHistoricDate(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "02/01/2006", "2/1/2006", "02-01-2006", "02/01/06", "2 Jan 2006", "02 Jan 2006"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date; use DD/MM/YYYY")
+}
+
+// parseHistoricAmount parses a money amount, allowing a pound sign and thousands
+// separators
+func parseHistoricAmount(value string) (float64, error) {
+	value = strings.NewReplacer("£", "", ",", "", " ", "").Replace(value)
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, fmt.Errorf("not a number")
+	}
+	return math.Round(amount*100) / 100, nil
+}
+
+// validateHistoricRow checks a row against its template, rewriting dates, amounts,
+// emails and phone numbers to one form so rows can be compared
+func validateHistoricRow(kind string, values map[string]string, now time.Time) []HistoricImportProblem {
+	var problems []HistoricImportProblem
+	add := func(column, reason string) {
+		problems = append(problems, HistoricImportProblem{Column: column, Value: values[column], Reason: reason})
+	}
+	required := func(column string) bool {
+		if values[column] == "" {
+			add(column, "is required")
+			return false
+		}
+		return true
+	}
+	email := func(column string) {
+		if values[column] == "" {
+			return
+		}
+		address, err := mail.ParseAddress(values[column])
+		if err != nil || address.Address != values[column] {
+			add(column, "is not a valid email address")
+			return
+		}
+		values[column] = strings.ToLower(address.Address)
+	}
+	date := func(column string) {
+		if values[column] == "" {
+			return
+		}
+		t, err := parseHistoricDate(values[column])
+		if err != nil {
+			add(column, err.Error())
+			return
+		}
+		if t.After(now) {
+			add(column, "is in the future")
+			return
+		}
+		values[column] = t.Format("2006-01-02")
+	}
+
+	switch kind {
+	case models.HistoricImportVisitors:
+		required("FirstName")
+		required("LastName")
+		email("Email")
+		if values["Phone"] != "" {
+			if phone, err := models.NormalizePhone(values["Phone"]); err != nil {
+				add("Phone", err.Error())
+			} else {
+				values["Phone"] = phone
+			}
+		}
+		if values["Email"] == "" && values["Phone"] == "" {
+			add("Email", "an email or phone number is needed to match the visitor")
+		}
+		if values["HouseholdSize"] == "" {
+			values["HouseholdSize"] = "1"
+		} else if size, err := strconv.Atoi(values["HouseholdSize"]); err != nil || size < 1 || size > 20 {
+			add("HouseholdSize", "must be a whole number between 1 and 20")
+		}
+		date("FirstVisit")
+
+	case models.HistoricImportDonations:
+		if required("Date") {
+			date("Date")
+		}
+		email("DonorEmail")
+		switch strings.ToLower(values["Type"]) {
+		case "monetary", "money", "cash":
+			values["Type"] = "monetary"
+		case "goods", "food", "items":
+			values["Type"] = "goods"
+		case "":
+			add("Type", "is required")
+		default:
+			add("Type", "must be monetary or goods")
+		}
+		for _, column := range []string{"Amount", "GoodsValue"} {
+			if values[column] == "" {
+				continue
+			}
+			if amount, err := parseHistoricAmount(values[column]); err != nil || amount < 0 {
+				add(column, "must be an amount of zero or more")
+			} else {
+				values[column] = strconv.FormatFloat(amount, 'f', 2, 64)
+			}
+		}
+		if values["Type"] == "monetary" {
+			if amount, _ := strconv.ParseFloat(values["Amount"], 64); amount <= 0 {
+				add("Amount", "a monetary donation needs an amount above zero")
+			}
+		}
+		if values["Type"] == "goods" && values["Goods"] == "" {
+			add("Goods", "describe the goods donated")
+		}
+
+	case models.HistoricImportVolunteerHours:
+		if required("VolunteerEmail") {
+			email("VolunteerEmail")
+		}
+		if required("Date") {
+			date("Date")
+		}
+		if required("Hours") {
+			if hours, err := strconv.ParseFloat(values["Hours"], 64); err != nil || hours <= 0 || hours > 24 {
+				add("Hours", "must be more than 0 and at most 24")
+			} else {
+				values["Hours"] = strconv.FormatFloat(math.Round(hours*100)/100, 'f', -1, 64)
+			}
+		}
+	}
+	return problems
+}
+
+// historicRowKeys returns the keys two rows of a file share when they describe the
+// same record
+func historicRowKeys(kind string, values map[string]string) []string {
+	switch kind {
+	case models.HistoricImportVisitors:
+		var keys []string
+		if values["Email"] != "" {
+			keys = append(keys, "email:"+values["Email"])
+		}
+		if values["Phone"] != "" {
+			keys = append(keys, "phone:"+values["Phone"])
+		}
+		return keys
+	case models.HistoricImportDonations:
+		if values["Reference"] != "" {
+			return []string{"ref:" + strings.ToLower(values["Reference"])}
+		}
+		donor := values["DonorEmail"]
+		if donor == "" {
+			donor = strings.ToLower(values["DonorName"])
+		}
+		return []string{strings.Join([]string{values["Date"], donor, values["Type"], values["Amount"], strings.ToLower(values["Goods"])}, "|")}
+	case models.HistoricImportVolunteerHours:
+		return []string{strings.Join([]string{values["VolunteerEmail"], values["Date"], values["Hours"]}, "|")}
+	}
+	return nil
+}
+
+// match marks valid rows that repeat an earlier row of the file or a record already
+// in the system, and finds the volunteers and donors rows belong to
+func (hs *HistoricImportService) match(tx *gorm.DB, kind string, rows []*historicRow) error {
+	seen := map[string]int{}
+	for _, row := range rows {
+		if row.status != models.HistoricRowValid {
+			continue
+		}
+		row.matchedType, row.matchedID, row.matchReason = "", nil, ""
+
+		duplicateOf := 0
+		keys := historicRowKeys(kind, row.values)
+		for _, key := range keys {
+			if n, ok := seen[key]; ok {
+				duplicateOf = n
+				break
+			}
+		}
+		if duplicateOf > 0 {
+			row.status = models.HistoricRowDuplicate
+			row.matchedType = "row"
+			row.matchReason = fmt.Sprintf("Repeats row %d of this file", duplicateOf)
+			continue
+		}
+		for _, key := range keys {
+			seen[key] = row.number
+		}
+
+		var err error
+		switch kind {
+		case models.HistoricImportVisitors:
+			err = hs.matchVisitor(tx, row)
+		case models.HistoricImportDonations:
+			err = hs.matchDonation(tx, row)
+		case models.HistoricImportVolunteerHours:
+			err = hs.matchVolunteerHours(tx, row)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// duplicateOf marks a row as repeating an existing record
+func (row *historicRow) duplicateOf(recordType string, id uint, reason string) {
+	row.status = models.HistoricRowDuplicate
+	row.matchedType = recordType
+	row.matchedID = &id
+	row.matchReason = reason
+}
+
+// matchVisitor matches a visitor to an existing account by email or phone number
+func (hs *HistoricImportService) matchVisitor(tx *gorm.DB, row *historicRow) error {
+	var user models.User
+	if email := row.values["Email"]; email != "" {
+		err := tx.Select("id").Where("LOWER(email) = ?", email).First(&user).Error
+		if err == nil {
+			row.duplicateOf("user", user.ID, "An account already uses this email")
+			return nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+	if phone := row.values["Phone"]; phone != "" {
+		err := tx.Select("id").Where("phone = ?", phone).First(&user).Error
+		if err == nil {
+			row.duplicateOf("user", user.ID, "An account already uses this phone number")
+			return nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchDonation matches a donation by its payment reference, or the same donor,
+// day, type and amount, and links it to the donor's account when there is one
+func (hs *HistoricImportService) matchDonation(tx *gorm.DB, row *historicRow) error {
+	values := row.values
+	var donation models.Donation
+	var err error
+	switch {
+	case values["Reference"] != "":
+		err = tx.Select("id").Where("payment_id = ?", values["Reference"]).First(&donation).Error
+	case values["DonorEmail"] != "" || values["DonorName"] != "":
+		query := tx.Select("id").
+			Where("DATE(COALESCE(received_at, created_at)) = ? AND type = ?", values["Date"], values["Type"])
+		if values["DonorEmail"] != "" {
+			query = query.Where("LOWER(contact_email) = ?", values["DonorEmail"])
+		} else {
+			query = query.Where("LOWER(name) = ?", strings.ToLower(values["DonorName"]))
+		}
+		if values["Type"] == "monetary" {
+			query = query.Where("ABS(amount - ?) < 0.005", values["Amount"])
+		} else {
+			query = query.Where("LOWER(goods) = ?", strings.ToLower(values["Goods"]))
+		}
+		err = query.First(&donation).Error
+	default:
+		// Anonymous donations on the same day for the same amount are common, so
+		// only the file itself is checked for repeats
+		err = gorm.ErrRecordNotFound
+	}
+	if err == nil {
+		row.duplicateOf("donation", donation.ID, "A donation with the same details is already recorded")
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if email := values["DonorEmail"]; email != "" {
+		var donor models.User
+		if err := tx.Select("id").Where("LOWER(email) = ?", email).First(&donor).Error; err == nil {
+			row.userID = donor.ID
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchVolunteerHours finds the volunteer the hours belong to and matches completed
+// shifts already recorded for them on the day
+func (hs *HistoricImportService) matchVolunteerHours(tx *gorm.DB, row *historicRow) error {
+	var volunteer models.User
+	if err := tx.Select("id").Where("LOWER(email) = ? AND role = ?", row.values["VolunteerEmail"], models.RoleVolunteer).
+		First(&volunteer).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		row.status = models.HistoricRowInvalid
+		row.problems = append(row.problems, HistoricImportProblem{
+			Column: "VolunteerEmail",
+			Value:  row.values["VolunteerEmail"],
+			Reason: "no volunteer has this email; add the volunteer first",
+		})
+		return nil
+	}
+	row.userID = volunteer.ID
+
+	var assignment models.ShiftAssignment
+	err := tx.Table("shift_assignments").Select("shift_assignments.id").
+		Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id AND shifts.deleted_at IS NULL").
+		Where("shift_assignments.user_id = ? AND LOWER(shift_assignments.status) = 'completed'", volunteer.ID).
+		Where("DATE(shifts.date) = ? AND ABS(("+assignmentHoursSQL+") - ?) < 0.05", row.values["Date"], row.values["Hours"]).
+		First(&assignment).Error
+	if err == nil {
+		row.duplicateOf("shift_assignment", assignment.ID, "The volunteer already has these hours recorded for the day")
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Stage validates and matches a spreadsheet and saves it as a batch for review.
+// Nothing is written to the live tables until the batch is committed.
+func (hs *HistoricImportService) Stage(kind, fileName string, r io.Reader, adminID uint, now time.Time) (*models.HistoricImportBatch, error) {
+	rows, err := parseHistoricCSV(kind, r, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := hs.match(hs.db, kind, rows); err != nil {
+		return nil, err
+	}
+
+	batch := &models.HistoricImportBatch{
+		Kind:      kind,
+		FileName:  fileName,
+		Status:    models.HistoricImportStaged,
+		TotalRows: len(rows),
+		CreatedBy: adminID,
+	}
+	records := make([]models.HistoricImportRow, 0, len(rows))
+	for _, row := range rows {
+		record, err := row.record()
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	countHistoricRows(batch, records)
+
+	err = hs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		for i := range records {
+			records[i].BatchID = batch.ID
+		}
+		return tx.CreateInBatches(records, 500).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// record converts a row for saving with its batch
+func (row *historicRow) record() (models.HistoricImportRow, error) {
+	data, err := json.Marshal(row.values)
+	if err != nil {
+		return models.HistoricImportRow{}, err
+	}
+	problems := row.problems
+	if problems == nil {
+		problems = []HistoricImportProblem{}
+	}
+	errs, err := json.Marshal(problems)
+	if err != nil {
+		return models.HistoricImportRow{}, err
+	}
+	return models.HistoricImportRow{
+		RowNumber:   row.number,
+		Data:        string(data),
+		Errors:      string(errs),
+		Status:      row.status,
+		MatchedType: row.matchedType,
+		MatchedID:   row.matchedID,
+		MatchReason: row.matchReason,
+	}, nil
+}
+
+// countHistoricRows sets a batch's row counts from its rows
+func countHistoricRows(batch *models.HistoricImportBatch, rows []models.HistoricImportRow) {
+	batch.ValidRows, batch.InvalidRows, batch.DuplicateRows = 0, 0, 0
+	for _, row := range rows {
+		switch row.Status {
+		case models.HistoricRowValid, models.HistoricRowImported:
+			batch.ValidRows++
+		case models.HistoricRowInvalid:
+			batch.InvalidRows++
+		case models.HistoricRowDuplicate:
+			batch.DuplicateRows++
+		}
+	}
+}
+
+// List returns import batches, newest first
+func (hs *HistoricImportService) List(kind, status string) ([]models.HistoricImportBatch, error) {
+	query := hs.db.Order("created_at DESC")
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var batches []models.HistoricImportBatch
+	if err := query.Limit(200).Find(&batches).Error; err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+// Get fetches an import batch
+func (hs *HistoricImportService) Get(id uint) (*models.HistoricImportBatch, error) {
+	var batch models.HistoricImportBatch
+	if err := hs.db.First(&batch, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHistoricImportNotFound
+		}
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// Report returns a batch's validation report with a page of its rows, optionally
+// only those with one status
+func (hs *HistoricImportService) Report(id uint, status string, page, limit int) (*HistoricImportReport, error) {
+	batch, err := hs.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	report := &HistoricImportReport{Batch: batch, ProblemsByColumn: map[string]int{}, Rows: []HistoricImportRowReport{}}
+	var invalid []models.HistoricImportRow
+	if err := hs.db.Select("errors").Where("batch_id = ? AND status = ?", id, models.HistoricRowInvalid).
+		Find(&invalid).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range invalid {
+		var problems []HistoricImportProblem
+		json.Unmarshal([]byte(row.Errors), &problems)
+		for _, problem := range problems {
+			column := problem.Column
+			if column == "" {
+				column = "file"
+			}
+			report.ProblemsByColumn[column]++
+		}
+	}
+
+	query := hs.db.Model(&models.HistoricImportRow{}).Where("batch_id = ?", id)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&report.Total).Error; err != nil {
+		return nil, err
+	}
+	var rows []models.HistoricImportRow
+	if err := query.Order("row_number ASC").Offset((page - 1) * limit).Limit(limit).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		entry := HistoricImportRowReport{HistoricImportRow: row, Values: map[string]string{}, Problems: []HistoricImportProblem{}}
+		json.Unmarshal([]byte(row.Data), &entry.Values)
+		json.Unmarshal([]byte(row.Errors), &entry.Problems)
+		report.Rows = append(report.Rows, entry)
+	}
+	return report, nil
+}
+
+// lockHistoricBatch fetches a batch for update within a transaction
+func lockHistoricBatch(tx *gorm.DB, id uint) (*models.HistoricImportBatch, error) {
+	var batch models.HistoricImportBatch
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&batch, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHistoricImportNotFound
+		}
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// Commit writes a staged batch's valid rows to the live tables in one transaction.
+// Rows are matched again first, so records added since staging are not duplicated.
+func (hs *HistoricImportService) Commit(id, adminID uint, now time.Time) (*models.HistoricImportBatch, error) {
+	var batch *models.HistoricImportBatch
+	volunteers := map[uint]bool{}
+	err := hs.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if batch, err = lockHistoricBatch(tx, id); err != nil {
+			return err
+		}
+		if batch.Status != models.HistoricImportStaged {
+			return ErrHistoricImportNotStaged
+		}
+
+		var records []models.HistoricImportRow
+		if err := tx.Where("batch_id = ? AND status = ?", id, models.HistoricRowValid).
+			Order("row_number ASC").Find(&records).Error; err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return ErrHistoricImportNothingValid
+		}
+
+		rows := make([]*historicRow, len(records))
+		for i, record := range records {
+			rows[i] = &historicRow{number: record.RowNumber, values: map[string]string{}, status: models.HistoricRowValid}
+			if err := json.Unmarshal([]byte(record.Data), &rows[i].values); err != nil {
+				return err
+			}
+		}
+		if err := hs.match(tx, batch.Kind, rows); err != nil {
+			return err
+		}
+
+		for i, row := range rows {
+			record := &records[i]
+			if row.status == models.HistoricRowValid {
+				if err := hs.createRecords(tx, batch, row, record, now); err != nil {
+					return fmt.Errorf("row %d: %w", row.number, err)
+				}
+				record.Status = models.HistoricRowImported
+				if batch.Kind == models.HistoricImportVolunteerHours {
+					volunteers[row.userID] = true
+				}
+			} else {
+				updated, err := row.record()
+				if err != nil {
+					return err
+				}
+				record.Status, record.Errors = updated.Status, updated.Errors
+				record.MatchedType, record.MatchedID, record.MatchReason = updated.MatchedType, updated.MatchedID, updated.MatchReason
+			}
+			if err := tx.Save(record).Error; err != nil {
+				return err
+			}
+		}
+
+		var all []models.HistoricImportRow
+		if err := tx.Select("status").Where("batch_id = ?", id).Find(&all).Error; err != nil {
+			return err
+		}
+		countHistoricRows(batch, all)
+		batch.ImportedRows = 0
+		for _, row := range all {
+			if row.Status == models.HistoricRowImported {
+				batch.ImportedRows++
+			}
+		}
+		batch.Status = models.HistoricImportCommitted
+		batch.CommittedBy = &adminID
+		batch.CommittedAt = &now
+		return tx.Save(batch).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hs.recomputeVolunteerHours(volunteers)
+	return batch, nil
+}
+
+// createRecords writes the live records for one row, noting them on the row so a
+// rollback can remove them
+func (hs *HistoricImportService) createRecords(tx *gorm.DB, batch *models.HistoricImportBatch, row *historicRow, record *models.HistoricImportRow, now time.Time) error {
+	values := row.values
+	note := fmt.Sprintf("Imported from %s (import batch %d)", batch.FileName, batch.ID)
+	if values["Notes"] != "" {
+		note = values["Notes"] + "\n" + note
+	}
+
+	switch batch.Kind {
+	case models.HistoricImportVisitors:
+		createdAt := now
+		if values["FirstVisit"] != "" {
+			createdAt, _ = time.ParseInLocation("2006-01-02", values["FirstVisit"], time.Local)
+		}
+		// Past visitors are kept for history and matching; they can be invited to
+		// activate an account if they come back
+		user := models.User{
+			FirstName: values["FirstName"],
+			LastName:  values["LastName"],
+			Email:     values["Email"],
+			Phone:     values["Phone"],
+			Address:   values["Address"],
+			City:      values["City"],
+			Postcode:  strings.ToUpper(values["Postcode"]),
+			Role:      models.RoleVisitor,
+			Status:    models.StatusInactive,
+			CreatedAt: createdAt,
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		householdSize, _ := strconv.Atoi(values["HouseholdSize"])
+		profile := models.VisitorProfile{UserID: user.ID, HouseholdSize: householdSize, Notes: note, CreatedAt: createdAt}
+		if err := tx.Create(&profile).Error; err != nil {
+			return err
+		}
+		record.CreatedType, record.CreatedID, record.ExtraID = "user", &user.ID, &profile.ID
+
+	case models.HistoricImportDonations:
+		day, _ := time.ParseInLocation("2006-01-02", values["Date"], time.Local)
+		amount, _ := strconv.ParseFloat(values["Amount"], 64)
+		goodsValue, _ := strconv.ParseFloat(values["GoodsValue"], 64)
+		donation := models.Donation{
+			Name:          values["DonorName"],
+			ContactEmail:  values["DonorEmail"],
+			Type:          values["Type"],
+			Amount:        amount,
+			Currency:      "GBP",
+			Goods:         values["Goods"],
+			GoodsValue:    goodsValue,
+			PaymentMethod: values["PaymentMethod"],
+			PaymentID:     values["Reference"],
+			Status:        models.DonationStatusReceived,
+			IsAnonymous:   values["DonorName"] == "" && values["DonorEmail"] == "",
+			Notes:         note,
+			ReceivedAt:    &day,
+			CreatedAt:     day,
+		}
+		if row.userID != 0 {
+			donation.DonorID = &row.userID
+		}
+		if err := tx.Create(&donation).Error; err != nil {
+			return err
+		}
+		record.CreatedType, record.CreatedID = "donation", &donation.ID
+
+	case models.HistoricImportVolunteerHours:
+		day, _ := time.ParseInLocation("2006-01-02", values["Date"], time.Local)
+		hours, _ := strconv.ParseFloat(values["Hours"], 64)
+		var profile models.VolunteerProfile
+		if err := tx.Select("id").Where("user_id = ?", row.userID).First(&profile).Error; err != nil &&
+			!errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// Each past session becomes a completed shift of its own so it counts
+		// towards statements, certificates and total hours like any other shift
+		start := day.Add(9 * time.Hour)
+		shift := models.Shift{
+			Date:                day,
+			StartTime:           start,
+			EndTime:             start.Add(time.Duration(hours * float64(time.Hour))),
+			Location:            values["Location"],
+			Role:                values["Role"],
+			Description:         note,
+			MaxVolunteers:       1,
+			AssignedVolunteerID: &row.userID,
+			Type:                "fixed",
+		}
+		if err := tx.Create(&shift).Error; err != nil {
+			return err
+		}
+		assignment := models.ShiftAssignment{
+			ShiftID:      shift.ID,
+			UserID:       row.userID,
+			VolunteerID:  profile.ID,
+			Status:       "Completed",
+			AssignedAt:   start,
+			AssignedBy:   &batch.CreatedBy,
+			CheckedInAt:  &shift.StartTime,
+			CheckedOutAt: &shift.EndTime,
+			HoursLogged:  hours,
+			// The admin importing the records vouches for the hours
+			HoursApprovedBy: &batch.CreatedBy,
+			HoursApprovedAt: &now,
+		}
+		if err := tx.Create(&assignment).Error; err != nil {
+			return err
+		}
+		record.CreatedType, record.CreatedID, record.ExtraID = "shift_assignment", &assignment.ID, &shift.ID
+	}
+	return nil
+}
+
+// Rollback removes everything a committed batch wrote. Imported visitors who have
+// since activated their account or have any record of their own are kept, and the
+// rollback refused, so no history is lost.
+func (hs *HistoricImportService) Rollback(id, adminID uint, reason string, now time.Time) (*models.HistoricImportBatch, error) {
+	var batch *models.HistoricImportBatch
+	volunteers := map[uint]bool{}
+	err := hs.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if batch, err = lockHistoricBatch(tx, id); err != nil {
+			return err
+		}
+		if batch.Status != models.HistoricImportCommitted {
+			return ErrHistoricImportNotCommitted
+		}
+
+		var records []models.HistoricImportRow
+		if err := tx.Where("batch_id = ? AND status = ? AND created_id IS NOT NULL", id, models.HistoricRowImported).
+			Find(&records).Error; err != nil {
+			return err
+		}
+		created, extra := []uint{}, []uint{}
+		for _, record := range records {
+			created = append(created, *record.CreatedID)
+			if record.ExtraID != nil {
+				extra = append(extra, *record.ExtraID)
+			}
+		}
+
+		if len(created) > 0 {
+			switch batch.Kind {
+			case models.HistoricImportVisitors:
+				if err := checkImportedVisitorsUnused(tx, created); err != nil {
+					return err
+				}
+				if len(extra) > 0 {
+					if err := tx.Unscoped().Delete(&models.VisitorProfile{}, extra).Error; err != nil {
+						return err
+					}
+				}
+				if err := tx.Unscoped().Delete(&models.User{}, created).Error; err != nil {
+					return err
+				}
+			case models.HistoricImportDonations:
+				if err := tx.Unscoped().Delete(&models.Donation{}, created).Error; err != nil {
+					return err
+				}
+			case models.HistoricImportVolunteerHours:
+				var userIDs []uint
+				if err := tx.Model(&models.ShiftAssignment{}).Where("id IN ?", created).Pluck("user_id", &userIDs).Error; err != nil {
+					return err
+				}
+				for _, userID := range userIDs {
+					volunteers[userID] = true
+				}
+				if err := tx.Unscoped().Delete(&models.ShiftAssignment{}, created).Error; err != nil {
+					return err
+				}
+				if len(extra) > 0 {
+					if err := tx.Unscoped().Delete(&models.Shift{}, extra).Error; err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		if err := tx.Model(&models.HistoricImportRow{}).Where("batch_id = ? AND status = ?", id, models.HistoricRowImported).
+			Updates(map[string]interface{}{"status": models.HistoricRowValid, "created_id": nil, "extra_id": nil}).Error; err != nil {
+			return err
+		}
+		batch.Status = models.HistoricImportRolledBack
+		batch.RolledBackBy = &adminID
+		batch.RolledBackAt = &now
+		batch.RollbackReason = strings.TrimSpace(reason)
+		return tx.Save(batch).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hs.recomputeVolunteerHours(volunteers)
+	return batch, nil
+}
+
+// historicVisitorReferences are the records that point at a visitor's user, each
+// of which stops an imported visitor being removed by a rollback
+var historicVisitorReferences = []struct {
+	model  interface{}
+	column string
+	label  string
+}{
+	{&models.HelpRequest{}, "visitor_id", "help requests"},
+	{&models.Ticket{}, "visitor_id", "tickets"},
+	{&models.Visit{}, "visitor_id", "visits"},
+	{&models.Appointment{}, "visitor_id", "appointments"},
+	{&models.TimeSlotBooking{}, "visitor_id", "time slot bookings"},
+	{&models.QueueEntry{}, "visitor_id", "queue entries"},
+	{&models.VisitFeedback{}, "visitor_id", "visit feedback"},
+	{&models.Feedback{}, "user_id", "feedback"},
+	{&models.Document{}, "user_id", "documents"},
+	{&models.Donation{}, "donor_id", "donations"},
+	{&models.Donation{}, "user_id", "donations"},
+	{&models.RecurringDonation{}, "donor_id", "recurring donations"},
+	{&models.Payment{}, "user_id", "payments"},
+	{&models.Subscription{}, "user_id", "subscriptions"},
+}
+
+// checkImportedVisitorsUnused returns ErrHistoricImportInUse when any imported
+// visitor has activated their account or is referenced by another record, including
+// soft-deleted ones and donations matched to them by a later import
+func checkImportedVisitorsUnused(tx *gorm.DB, userIDs []uint) error {
+	var active int64
+	if err := tx.Unscoped().Model(&models.User{}).Where("id IN ? AND status <> ?", userIDs, models.StatusInactive).
+		Count(&active).Error; err != nil {
+		return err
+	}
+	if active > 0 {
+		return fmt.Errorf("%w: %d imported visitors have activated their accounts", ErrHistoricImportInUse, active)
+	}
+
+	for _, ref := range historicVisitorReferences {
+		var used int64
+		if err := tx.Unscoped().Model(ref.model).Where(ref.column+" IN ?", userIDs).Count(&used).Error; err != nil {
+			return err
+		}
+		if used > 0 {
+			return fmt.Errorf("%w: %d %s belong to imported visitors", ErrHistoricImportInUse, used, ref.label)
+		}
+	}
+	return nil
+}
+
+// Discard abandons a staged batch without importing it
+func (hs *HistoricImportService) Discard(id uint) (*models.HistoricImportBatch, error) {
+	batch, err := hs.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != models.HistoricImportStaged {
+		return nil, ErrHistoricImportNotStaged
+	}
+	batch.Status = models.HistoricImportDiscarded
+	if err := hs.db.Model(batch).Update("status", batch.Status).Error; err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// recomputeVolunteerHours rebuilds the total hours of volunteers whose imported
+// shifts were added or removed
+func (hs *HistoricImportService) recomputeVolunteerHours(volunteers map[uint]bool) {
+	statements := NewVolunteerStatementService()
+	for userID := range volunteers {
+		if _, _, err := statements.RecomputeTotalHours(userID); err != nil && !errors.Is(err, ErrVolunteerProfileNotFound) {
+			log.Printf("Failed to recompute total hours for volunteer %d after an import: %v", userID, err)
+		}
+	}
+}
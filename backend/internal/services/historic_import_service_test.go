@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"gorm.io/gorm/schema"
+)
+
+func TestParseHistoricCSV(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	file := "\ufeffFirst Name,last_name,Email,Phone,Household Size,First Visit\n" +
+		"Jane,Smith,Jane@Example.org,07700 900123,3,14/03/2019\n" +
+		",,,,,\n" +
+		"Sam,,sam.example.org,,0,31/02/2020\n" +
+		"Ali,Khan,,,,01/01/2030\n"
+
+	rows, err := parseHistoricCSV(models.HistoricImportVisitors, strings.NewReader(file), now)
+	if err != nil {
+		t.Fatalf("parseHistoricCSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3 (blank rows are skipped)", len(rows))
+	}
+
+	jane := rows[0]
+	if jane.number != 2 || jane.status != models.HistoricRowValid {
+		t.Errorf("row 2 = %d %s %v, want a valid row", jane.number, jane.status, jane.problems)
+	}
+	want := map[string]string{"Email": "jane@example.org", "Phone": "+447700900123", "FirstVisit": "2019-03-14", "HouseholdSize": "3"}
+	for column, value := range want {
+		if jane.values[column] != value {
+			t.Errorf("%s = %q, want %q", column, jane.values[column], value)
+		}
+	}
+
+	columns := func(row *historicRow) string {
+		var got []string
+		for _, problem := range row.problems {
+			got = append(got, problem.Column)
+		}
+		return strings.Join(got, ",")
+	}
+	if rows[1].number != 4 || columns(rows[1]) != "LastName,Email,HouseholdSize,FirstVisit" {
+		t.Errorf("row %d problems = %s", rows[1].number, columns(rows[1]))
+	}
+	if columns(rows[2]) != "Email,FirstVisit" {
+		t.Errorf("row %d problems = %s, want a missing contact and a future date", rows[2].number, columns(rows[2]))
+	}
+}
+
+func TestParseHistoricCSVRejectsFiles(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		kind string
+		file string
+		want error
+	}{
+		{"pledges", "Date\n", ErrHistoricImportKind},
+		{models.HistoricImportVolunteerHours, "VolunteerEmail,Date\nsam@example.org,01/01/2020\n", ErrHistoricImportFile},
+		{models.HistoricImportDonations, "Date,Type\n", ErrHistoricImportFile},
+		{models.HistoricImportDonations, "", ErrHistoricImportFile},
+	}
+	for _, tc := range cases {
+		if _, err := parseHistoricCSV(tc.kind, strings.NewReader(tc.file), now); !errors.Is(err, tc.want) {
+			t.Errorf("parseHistoricCSV(%s, %q) error = %v, want %v", tc.kind, tc.file, err, tc.want)
+		}
+	}
+}
+
+func TestValidateHistoricDonation(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	cases := []struct {
+		values   map[string]string
+		problems string
+		amount   string
+	}{
+		{map[string]string{"Date": "02/12/2018", "Type": "Money", "Amount": "£1,250.5"}, "", "1250.50"},
+		{map[string]string{"Date": "2018-12-02", "Type": "monetary", "Amount": "0"}, "Amount", "0.00"},
+		{map[string]string{"Date": "2018-12-02", "Type": "goods"}, "Goods", ""},
+		{map[string]string{"Date": "yesterday", "Type": "cheque", "Amount": "ten"}, "Date,Type,Amount", "ten"},
+	}
+	for _, tc := range cases {
+		problems := validateHistoricRow(models.HistoricImportDonations, tc.values, now)
+		var got []string
+		for _, problem := range problems {
+			got = append(got, problem.Column)
+		}
+		if strings.Join(got, ",") != tc.problems || tc.values["Amount"] != tc.amount {
+			t.Errorf("got problems %v and amount %q, want %q and %q", got, tc.values["Amount"], tc.problems, tc.amount)
+		}
+	}
+}
+
+func TestHistoricRowKeys(t *testing.T) {
+	cases := []struct {
+		kind string
+		a, b map[string]string
+		same bool
+	}{
+		{models.HistoricImportVisitors,
+			map[string]string{"Email": "jane@example.org", "Phone": "+447700900123"},
+			map[string]string{"Phone": "+447700900123"}, true},
+		{models.HistoricImportDonations,
+			map[string]string{"Date": "2018-12-02", "DonorName": "John Brown", "Type": "monetary", "Amount": "25.00"},
+			map[string]string{"Date": "2018-12-02", "DonorName": "JOHN BROWN", "Type": "monetary", "Amount": "25.00"}, true},
+		{models.HistoricImportDonations,
+			map[string]string{"Date": "2018-12-02", "DonorName": "John Brown", "Type": "monetary", "Amount": "25.00"},
+			map[string]string{"Date": "2018-12-03", "DonorName": "John Brown", "Type": "monetary", "Amount": "25.00"}, false},
+		{models.HistoricImportVolunteerHours,
+			map[string]string{"VolunteerEmail": "sam@example.org", "Date": "2017-06-21", "Hours": "3.5"},
+			map[string]string{"VolunteerEmail": "sam@example.org", "Date": "2017-06-21", "Hours": "3"}, false},
+	}
+	for i, tc := range cases {
+		keys := map[string]bool{}
+		for _, key := range historicRowKeys(tc.kind, tc.a) {
+			keys[key] = true
+		}
+		same := false
+		for _, key := range historicRowKeys(tc.kind, tc.b) {
+			same = same || keys[key]
+		}
+		if same != tc.same {
+			t.Errorf("case %d: rows match = %v, want %v", i, same, tc.same)
+		}
+	}
+}
+
+func TestHistoricVisitorReferences(t *testing.T) {
+	cache := &sync.Map{}
+	for _, ref := range historicVisitorReferences {
+		parsed, err := schema.Parse(ref.model, cache, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("%s: %v", ref.label, err)
+		}
+		if parsed.LookUpField(ref.column) == nil {
+			t.Errorf("%s: %s has no column %s", ref.label, parsed.Table, ref.column)
+		}
+	}
+}
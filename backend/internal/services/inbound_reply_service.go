@@ -0,0 +1,248 @@
+package services
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"html"
+	"log"
+	"net/mail"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// inboundReplyMaxBody caps the reply text kept for each message
+const inboundReplyMaxBody = 20000
+
+var (
+	// helpRequestReferencePattern finds a help request reference such as
+	// HR-20250301-042 in a subject line
+	helpRequestReferencePattern = regexp.MustCompile(`(?i)\bHR-[A-Z0-9]+(?:-[A-Z0-9]+)*\b`)
+	// feedbackReferencePattern finds a feedback reference such as FB-123
+	feedbackReferencePattern = regexp.MustCompile(`(?i)\bFB-(\d+)\b`)
+	// quotedReplyHeader matches the line mail clients put above quoted history
+	quotedReplyHeader = regexp.MustCompile(`(?i)^(on .+ wrote:|-+ ?original message ?-+|from: .+|sent from my .+)$`)
+)
+
+// InboundReplyMessage is an email reply received from the provider's inbound webhook
+type InboundReplyMessage struct {
+	Provider  string
+	MessageID string
+	Sender    string
+	Subject   string
+	Text      string
+}
+
+// InboundReplyService threads email replies onto the help request or feedback they
+// answer and tells the member of staff handling it
+type InboundReplyService struct {
+	db         *gorm.DB
+	webhookKey string
+}
+
+// NewInboundReplyService creates a new inbound reply service
+func NewInboundReplyService() *InboundReplyService {
+	return &InboundReplyService{
+		db:         db.DB,
+		webhookKey: os.Getenv("INBOUND_REPLIES_WEBHOOK_KEY"),
+	}
+}
+
+// VerifyWebhookKey checks the shared key the provider is configured to send
+func (rs *InboundReplyService) VerifyWebhookKey(key string) error {
+	if rs.webhookKey == "" || subtle.ConstantTimeCompare([]byte(rs.webhookKey), []byte(key)) != 1 {
+		return ErrInboundUnauthorized
+	}
+	return nil
+}
+
+// FeedbackReference returns the reference quoted in feedback emails, so replies can
+// be matched back to the feedback
+func FeedbackReference(feedbackID uint) string {
+	return "FB-" + strconv.FormatUint(uint64(feedbackID), 10)
+}
+
+// Receive logs a reply and, when its subject names a help request or feedback owned
+// by the sender, threads it onto that record. A message the provider delivers twice
+// is only recorded once.
+func (rs *InboundReplyService) Receive(msg InboundReplyMessage, now time.Time) (*models.InboundReply, error) {
+	reply := &models.InboundReply{
+		Provider:   msg.Provider,
+		Sender:     msg.Sender,
+		Subject:    strings.TrimSpace(msg.Subject),
+		Body:       stripQuotedReply(msg.Text),
+		ReceivedAt: now,
+	}
+	if id := strings.Trim(strings.TrimSpace(msg.MessageID), "<>"); id != "" {
+		var existing models.InboundReply
+		if err := rs.db.Where("message_id = ?", id).First(&existing).Error; err == nil {
+			return &existing, nil
+		}
+		reply.MessageID = &id
+	}
+
+	sender := senderAddress(msg.Sender)
+	staffID, reason := rs.match(reply, sender)
+	if reason != "" {
+		reply.Status = models.InboundReplyUnmatched
+		reply.Reason = reason
+	} else {
+		reply.Status = models.InboundReplyMatched
+		reply.NotifiedStaffID = staffID
+	}
+	if err := rs.db.Create(reply).Error; err != nil {
+		return nil, err
+	}
+	if reason == "" && staffID != nil {
+		rs.notifyStaff(*staffID, reply)
+	}
+
+	// During a closure the sender is told when we reopen and the email is queued
+	if err := NewServiceClosureService().EmailReceived(reply, now); err != nil {
+		log.Printf("Failed to queue inbound reply %d during a service closure: %v", reply.ID, err)
+	}
+	return reply, nil
+}
+
+// match finds the record a reply answers and the member of staff to tell. It returns
+// a reason when the reply cannot be threaded.
+func (rs *InboundReplyService) match(reply *models.InboundReply, sender string) (*uint, string) {
+	if sender == "" {
+		return nil, "sender address could not be read"
+	}
+
+	if reference := helpRequestReferencePattern.FindString(reply.Subject); reference != "" {
+		var request models.HelpRequest
+		if err := rs.db.Preload("Visitor").Where("UPPER(reference) = ?", strings.ToUpper(reference)).
+			First(&request).Error; err != nil {
+			return nil, "no help request has reference " + strings.ToUpper(reference)
+		}
+		reply.EntityType = models.InboundReplyHelpRequest
+		reply.EntityID = &request.ID
+		if !sameAddress(sender, request.Email) && !sameAddress(sender, request.Visitor.Email) {
+			return nil, "sender does not match the help request's email address"
+		}
+		reply.UserID = &request.VisitorID
+		return request.AssignedStaffID, ""
+	}
+
+	if match := feedbackReferencePattern.FindStringSubmatch(reply.Subject); match != nil {
+		id, _ := strconv.ParseUint(match[1], 10, 32)
+		var feedback models.VisitFeedback
+		if err := rs.db.First(&feedback, id).Error; err != nil {
+			return nil, "no feedback has reference " + strings.ToUpper(match[0])
+		}
+		reply.EntityType = models.InboundReplyFeedback
+		reply.EntityID = &feedback.ID
+		var visitor models.User
+		if err := rs.db.Select("id", "email").First(&visitor, feedback.VisitorID).Error; err != nil || !sameAddress(sender, visitor.Email) {
+			return nil, "sender does not match the feedback's email address"
+		}
+		reply.UserID = &visitor.ID
+		if feedback.AdminResponseBy != nil {
+			return feedback.AdminResponseBy, ""
+		}
+		return feedback.ReviewedBy, ""
+	}
+
+	return nil, "subject does not name a help request or feedback reference"
+}
+
+// List returns logged replies, newest first
+func (rs *InboundReplyService) List(entityType string, entityID uint, status string) ([]models.InboundReply, error) {
+	query := rs.db.Model(&models.InboundReply{})
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID != 0 {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var replies []models.InboundReply
+	err := query.Order("received_at DESC").Limit(200).Find(&replies).Error
+	return replies, err
+}
+
+// notifyStaff tells the member of staff handling the record that the visitor replied
+func (rs *InboundReplyService) notifyStaff(staffID uint, reply *models.InboundReply) {
+	var staff models.User
+	if err := rs.db.Select("id", "email").First(&staff, staffID).Error; err != nil {
+		log.Printf("Failed to load staff member %d for inbound reply %d: %v", staffID, reply.ID, err)
+		return
+	}
+
+	title := "New reply to a help request"
+	actionURL := fmt.Sprintf("/admin/help-requests/%d", *reply.EntityID)
+	if reply.EntityType == models.InboundReplyFeedback {
+		title = "New reply to feedback"
+		actionURL = fmt.Sprintf("/admin/feedback/%d", *reply.EntityID)
+	}
+	notification := models.InAppNotification{
+		UserID:    staff.ID,
+		Title:     title,
+		Message:   fmt.Sprintf("%s replied: %s", reply.Sender, utils.TruncateText(reply.Body, 200)),
+		Type:      "info",
+		Priority:  "normal",
+		ActionURL: actionURL,
+	}
+	if err := rs.db.Create(&notification).Error; err != nil {
+		log.Printf("Failed to notify staff member %d about inbound reply %d: %v", staff.ID, reply.ID, err)
+	}
+
+	if staff.Email != "" {
+		body := fmt.Sprintf("<p>%s replied to <strong>%s</strong>:</p><blockquote>%s</blockquote>",
+			html.EscapeString(reply.Sender), html.EscapeString(reply.Subject),
+			strings.ReplaceAll(html.EscapeString(reply.Body), "\n", "<br>"))
+		if err := notifications.GetService().SendEmail(staff.Email, title, body); err != nil {
+			log.Printf("Failed to email staff member %d about inbound reply %d: %v", staff.ID, reply.ID, err)
+		}
+	}
+}
+
+// stripQuotedReply keeps the new text of a reply, dropping the quoted message below it
+func stripQuotedReply(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ">") || quotedReplyHeader.MatchString(trimmed) {
+			break
+		}
+		kept = append(kept, line)
+	}
+	body := strings.TrimSpace(strings.Join(kept, "\n"))
+	if body == "" {
+		// Nothing above the quote, e.g. a reply written inline; keep everything
+		body = strings.TrimSpace(text)
+	}
+	if len(body) > inboundReplyMaxBody {
+		body = body[:inboundReplyMaxBody]
+	}
+	return body
+}
+
+// senderAddress returns the bare, lower-case address from a From header
+func senderAddress(from string) string {
+	address, err := mail.ParseAddress(from)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(from))
+	}
+	return strings.ToLower(address.Address)
+}
+
+// sameAddress compares two email addresses ignoring case
+func sameAddress(a, b string) bool {
+	return b != "" && strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
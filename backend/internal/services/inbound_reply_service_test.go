@@ -0,0 +1,37 @@
+package services
+
+import "testing"
+
+func TestStripQuotedReply(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "Thanks, Tuesday works.\n", "Thanks, Tuesday works."},
+		{"gmail quote", "Tuesday works.\r\n\r\nOn Mon, 2 Mar 2026 at 10:00, Hub <noreply@example.org> wrote:\r\n> Your request", "Tuesday works."},
+		{"outlook quote", "Yes please\n-----Original Message-----\nFrom: Hub", "Yes please"},
+		{"only quoted", "> quoted text", "> quoted text"},
+	}
+	for _, tt := range tests {
+		if got := stripQuotedReply(tt.text); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReplyReferencePatterns(t *testing.T) {
+	if got := helpRequestReferencePattern.FindString("Re: Update on Your Request: HR-20260302-042"); got != "HR-20260302-042" {
+		t.Errorf("help request reference: got %q", got)
+	}
+	if got := helpRequestReferencePattern.FindString("RE: request hr-f-17 follow up"); got != "hr-f-17" {
+		t.Errorf("lower-case reference: got %q", got)
+	}
+	match := feedbackReferencePattern.FindStringSubmatch("Re: Response to your feedback (" + FeedbackReference(31) + ")")
+	if match == nil || match[1] != "31" {
+		t.Errorf("feedback reference: got %v", match)
+	}
+	if senderAddress(`"Jo Bloggs" <Jo@Example.org>`) != "jo@example.org" {
+		t.Error("sender address not normalised")
+	}
+}
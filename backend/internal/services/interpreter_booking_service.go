@@ -0,0 +1,421 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/httpclient"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/websocket"
+
+	"gorm.io/gorm"
+)
+
+// interpreterAPITimeout bounds each booking sent to the interpreter service
+const interpreterAPITimeout = 15 * time.Second
+
+// InterpreterSignatureHeader carries the HMAC of interpreter service callbacks
+const InterpreterSignatureHeader = "X-Interpreter-Signature"
+
+var (
+	ErrInterpreterBookingNotFound = errors.New("interpreter booking not found")
+	ErrInterpreterNoProvider      = errors.New("no interpreter service is configured")
+	ErrInterpreterNotSendable     = errors.New("only requested, failed or declined bookings can be sent")
+	ErrInterpreterInvalidStatus   = errors.New("status must be requested, confirmed, declined or cancelled")
+	ErrInterpreterBadSignature    = errors.New("invalid interpreter service signature")
+	ErrInterpreterProviderFailed  = errors.New("interpreter service request failed")
+)
+
+// InterpreterCheckIn is what the check-in desk needs to know about a visitor's interpreter
+type InterpreterCheckIn struct {
+	BookingID uint   `json:"booking_id"`
+	Language  string `json:"language"`
+	Status    string `json:"status"`
+	Secured   bool   `json:"secured"`
+	Warning   string `json:"warning,omitempty"`
+}
+
+// InterpreterProviderUpdate is a status change reported by the interpreter service
+type InterpreterProviderUpdate struct {
+	BookingID       uint   `json:"booking_id"`
+	ProviderRef     string `json:"provider_ref"`
+	Status          string `json:"status"`
+	InterpreterName string `json:"interpreter_name"`
+	Notes           string `json:"notes"`
+}
+
+// InterpreterBookingService raises interpreter bookings for help requests, sends them
+// to the configured interpreter service and tracks whether one was secured
+type InterpreterBookingService struct {
+	db            *gorm.DB
+	client        *http.Client
+	apiURL        string
+	apiKey        string
+	webhookSecret string
+}
+
+// NewInterpreterBookingService creates a new interpreter booking service
+func NewInterpreterBookingService() *InterpreterBookingService {
+	return &InterpreterBookingService{
+		db:            db.DB,
+		client:        httpclient.New("interpreter_service", httpclient.Options{Timeout: interpreterAPITimeout}),
+		apiURL:        strings.TrimSpace(os.Getenv("INTERPRETER_API_URL")),
+		apiKey:        os.Getenv("INTERPRETER_API_KEY"),
+		webhookSecret: os.Getenv("INTERPRETER_WEBHOOK_SECRET"),
+	}
+}
+
+// ProviderConfigured reports whether bookings are sent to an interpreter service
+func (is *InterpreterBookingService) ProviderConfigured() bool {
+	return is.apiURL != ""
+}
+
+// Request raises an interpreter booking and a staff task to secure one for a help
+// request that asks for an interpreter. It returns nil when none is needed and the
+// existing booking when one was already raised.
+func (is *InterpreterBookingService) Request(helpRequest *models.HelpRequest) (*models.InterpreterBooking, error) {
+	language := strings.TrimSpace(helpRequest.InterpreterLanguage)
+	if language == "" {
+		return nil, nil
+	}
+
+	var existing models.InterpreterBooking
+	if err := is.db.Where("help_request_id = ?", helpRequest.ID).First(&existing).Error; err == nil {
+		return &existing, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	booking := models.InterpreterBooking{
+		HelpRequestID: helpRequest.ID,
+		VisitorID:     helpRequest.VisitorID,
+		Reference:     helpRequest.Reference,
+		Language:      language,
+		VisitDay:      helpRequest.VisitDay,
+		TimeSlot:      helpRequest.TimeSlot,
+		Status:        models.InterpreterBookingRequested,
+	}
+
+	err := is.db.Transaction(func(tx *gorm.DB) error {
+		task := models.Task{
+			Title: fmt.Sprintf("Book a %s interpreter for %s", language, helpRequest.Reference),
+			Description: fmt.Sprintf("%s needs a %s interpreter for their %s visit on %s at %s. Record the booking on the help request once it is confirmed.",
+				helpRequest.VisitorName, language, helpRequest.Category, helpRequest.VisitDay, helpRequest.TimeSlot),
+			Status:      "pending",
+			Priority:    "high",
+			CreatedByID: helpRequest.VisitorID,
+		}
+		if day, err := time.ParseInLocation("2006-01-02", helpRequest.VisitDay, time.Local); err == nil {
+			task.DueDate = &day
+		}
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		booking.TaskID = &task.ID
+		if err := tx.Create(&booking).Error; err != nil {
+			return err
+		}
+		return syncInterpreterTicket(tx, &booking)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// Send books the interpreter with the configured interpreter service. A failed call
+// leaves the booking failed so staff can book by hand or try again.
+func (is *InterpreterBookingService) Send(ctx context.Context, booking *models.InterpreterBooking) error {
+	if !is.ProviderConfigured() {
+		return ErrInterpreterNoProvider
+	}
+	switch booking.Status {
+	case models.InterpreterBookingRequested, models.InterpreterBookingFailed, models.InterpreterBookingDeclined:
+	default:
+		return ErrInterpreterNotSendable
+	}
+
+	ref, status, err := is.post(ctx, booking)
+	if err != nil {
+		is.setStatus(booking, models.InterpreterBookingFailed, map[string]interface{}{"provider_error": err.Error()}, nil)
+		return fmt.Errorf("%w: %v", ErrInterpreterProviderFailed, err)
+	}
+
+	next := models.InterpreterBookingSent
+	if status == models.InterpreterBookingConfirmed {
+		next = models.InterpreterBookingConfirmed
+	}
+	return is.setStatus(booking, next, map[string]interface{}{"provider_ref": ref, "provider_error": ""}, nil)
+}
+
+// post sends one booking to the interpreter service and returns its reference and status
+func (is *InterpreterBookingService) post(ctx context.Context, booking *models.InterpreterBooking) (string, string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"booking_id": booking.ID,
+		"reference":  booking.Reference,
+		"language":   booking.Language,
+		"date":       booking.VisitDay,
+		"time_slot":  booking.TimeSlot,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, is.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// A new key for each attempt, so a retry after a decline is a fresh booking
+	req.Header.Set("Idempotency-Key", fmt.Sprintf("interpreter-%d-%d", booking.ID, booking.UpdatedAt.UnixNano()))
+	if is.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+is.apiKey)
+	}
+
+	resp, err := is.client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode >= 300 {
+		return "", "", fmt.Errorf("responded %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var result struct {
+		Reference string `json:"reference"`
+		Status    string `json:"status"`
+	}
+	if len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, &result); err != nil {
+			return "", "", fmt.Errorf("unreadable response: %w", err)
+		}
+	}
+	return result.Reference, strings.ToLower(result.Status), nil
+}
+
+// Get loads an interpreter booking
+func (is *InterpreterBookingService) Get(id uint) (*models.InterpreterBooking, error) {
+	var booking models.InterpreterBooking
+	if err := is.db.First(&booking, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInterpreterBookingNotFound
+		}
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// List returns interpreter bookings for a visit day and status, soonest first
+func (is *InterpreterBookingService) List(visitDay, status string) ([]models.InterpreterBooking, error) {
+	query := is.db.Order("visit_day ASC, time_slot ASC, id ASC")
+	if visitDay != "" {
+		query = query.Where("visit_day = ?", visitDay)
+	}
+	if status == "open" {
+		query = query.Where("status NOT IN ?", []string{models.InterpreterBookingConfirmed, models.InterpreterBookingCancelled})
+	} else if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var bookings []models.InterpreterBooking
+	if err := query.Limit(500).Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// Record saves a status staff have confirmed with the interpreter service or
+// interpreter directly
+func (is *InterpreterBookingService) Record(id uint, status, interpreterName, notes string, staffID uint) (*models.InterpreterBooking, error) {
+	switch status {
+	case models.InterpreterBookingRequested, models.InterpreterBookingConfirmed,
+		models.InterpreterBookingDeclined, models.InterpreterBookingCancelled:
+	default:
+		return nil, ErrInterpreterInvalidStatus
+	}
+	booking, err := is.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	if interpreterName != "" {
+		fields["interpreter_name"] = interpreterName
+	}
+	if notes != "" {
+		fields["notes"] = notes
+	}
+	if err := is.setStatus(booking, status, fields, &staffID); err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+// VerifySignature checks an interpreter service callback was signed with the shared secret
+func (is *InterpreterBookingService) VerifySignature(body []byte, signature string) error {
+	if is.webhookSecret == "" || signature == "" {
+		return ErrInterpreterBadSignature
+	}
+	mac := hmac.New(sha256.New, []byte(is.webhookSecret))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInterpreterBadSignature
+	}
+	return nil
+}
+
+// ApplyProviderUpdate records a status change reported by the interpreter service,
+// matched by our booking ID or the provider's reference
+func (is *InterpreterBookingService) ApplyProviderUpdate(update InterpreterProviderUpdate) (*models.InterpreterBooking, error) {
+	status := strings.ToLower(strings.TrimSpace(update.Status))
+	switch status {
+	case models.InterpreterBookingConfirmed, models.InterpreterBookingDeclined, models.InterpreterBookingCancelled:
+	default:
+		return nil, ErrInterpreterInvalidStatus
+	}
+
+	var booking models.InterpreterBooking
+	query := is.db
+	if update.BookingID > 0 {
+		query = query.Where("id = ?", update.BookingID)
+	} else if update.ProviderRef != "" {
+		query = query.Where("provider_ref = ?", update.ProviderRef)
+	} else {
+		return nil, ErrInterpreterBookingNotFound
+	}
+	if err := query.First(&booking).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInterpreterBookingNotFound
+		}
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	if update.ProviderRef != "" {
+		fields["provider_ref"] = update.ProviderRef
+	}
+	if update.InterpreterName != "" {
+		fields["interpreter_name"] = update.InterpreterName
+	}
+	if update.Notes != "" {
+		fields["notes"] = update.Notes
+	}
+	if err := is.setStatus(&booking, status, fields, nil); err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// setStatus moves a booking to a new status, keeps its ticket and staff task in step
+// and reloads it
+func (is *InterpreterBookingService) setStatus(booking *models.InterpreterBooking, status string, fields map[string]interface{}, staffID *uint) error {
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	fields["status"] = status
+	if status == models.InterpreterBookingConfirmed {
+		fields["confirmed_at"] = time.Now()
+	} else {
+		fields["confirmed_at"] = nil
+	}
+	if staffID != nil {
+		fields["updated_by"] = *staffID
+	}
+
+	err := is.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(booking).Updates(fields).Error; err != nil {
+			return err
+		}
+		booking.Status = status
+		if err := syncInterpreterTicket(tx, booking); err != nil {
+			return err
+		}
+		if booking.TaskID == nil {
+			return nil
+		}
+		// The task stays open until an interpreter is confirmed or the need goes away
+		task := map[string]interface{}{"status": "pending", "completed_at": nil}
+		if !booking.IsOpen() {
+			task = map[string]interface{}{"status": "completed", "completed_at": time.Now()}
+		}
+		return tx.Model(&models.Task{}).Where("id = ?", *booking.TaskID).Updates(task).Error
+	})
+	if err != nil {
+		return err
+	}
+	return is.db.First(booking, booking.ID).Error
+}
+
+// syncInterpreterTicket shows the booking's status on the help request's tickets
+func syncInterpreterTicket(tx *gorm.DB, booking *models.InterpreterBooking) error {
+	return tx.Model(&models.Ticket{}).
+		Where("help_request_id = ?", booking.HelpRequestID).
+		Update("interpreter_status", booking.Status).Error
+}
+
+// CheckIn returns the interpreter status for a ticket being checked in, or nil when
+// the visitor did not ask for one. When no interpreter was secured the check-in desk
+// is warned as well.
+func (is *InterpreterBookingService) CheckIn(ticket *models.Ticket) *InterpreterCheckIn {
+	var booking models.InterpreterBooking
+	if err := is.db.Where("help_request_id = ?", ticket.HelpRequestID).First(&booking).Error; err != nil {
+		return nil
+	}
+
+	status := interpreterCheckIn(&booking)
+	if status.Warning != "" {
+		payload := map[string]interface{}{
+			"type":          "interpreter_alert",
+			"ticket_id":     ticket.ID,
+			"ticket_number": ticket.TicketNumber,
+			"visitor_id":    ticket.VisitorID,
+			"visitor_name":  ticket.VisitorName,
+			"interpreter":   status,
+			"title":         "No interpreter secured",
+			"message":       fmt.Sprintf("%s (%s): %s", ticket.VisitorName, ticket.TicketNumber, status.Warning),
+			"timestamp":     time.Now(),
+		}
+		manager := websocket.GetGlobalManager()
+		for _, role := range []string{models.RoleStaff, models.RoleAdmin} {
+			if err := manager.BroadcastToRole(role, payload); err != nil {
+				log.Printf("Failed to broadcast interpreter alert to %s: %v", role, err)
+			}
+		}
+	}
+	return &status
+}
+
+// interpreterCheckIn describes a booking for the check-in desk, warning when no
+// interpreter was secured
+func interpreterCheckIn(booking *models.InterpreterBooking) InterpreterCheckIn {
+	status := InterpreterCheckIn{
+		BookingID: booking.ID,
+		Language:  booking.Language,
+		Status:    booking.Status,
+		Secured:   booking.IsSecured(),
+	}
+	switch booking.Status {
+	case models.InterpreterBookingConfirmed, models.InterpreterBookingCancelled:
+	case models.InterpreterBookingSent:
+		status.Warning = fmt.Sprintf("The %s interpreter booking was never confirmed. Check with the interpreter service or use a telephone interpreter.", booking.Language)
+	case models.InterpreterBookingDeclined:
+		status.Warning = fmt.Sprintf("The interpreter service could not provide a %s interpreter. Use a telephone interpreter.", booking.Language)
+	default:
+		status.Warning = fmt.Sprintf("No %s interpreter was booked for this visit. Use a telephone interpreter.", booking.Language)
+	}
+	return status
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestInterpreterCheckIn(t *testing.T) {
+	cases := []struct {
+		status  string
+		secured bool
+		warns   bool
+	}{
+		{models.InterpreterBookingConfirmed, true, false},
+		{models.InterpreterBookingCancelled, false, false},
+		{models.InterpreterBookingRequested, false, true},
+		{models.InterpreterBookingSent, false, true},
+		{models.InterpreterBookingDeclined, false, true},
+		{models.InterpreterBookingFailed, false, true},
+	}
+	for _, tc := range cases {
+		got := interpreterCheckIn(&models.InterpreterBooking{Language: "Bengali", Status: tc.status})
+		if got.Secured != tc.secured || (got.Warning != "") != tc.warns {
+			t.Errorf("%s: secured %v warning %q", tc.status, got.Secured, got.Warning)
+		}
+		if tc.warns && !strings.Contains(got.Warning, "Bengali") {
+			t.Errorf("%s: warning %q does not name the language", tc.status, got.Warning)
+		}
+	}
+}
+
+func TestInterpreterVerifySignature(t *testing.T) {
+	body := []byte(`{"booking_id":1,"status":"confirmed"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	valid := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	cases := []struct {
+		secret    string
+		signature string
+		ok        bool
+	}{
+		{"secret", valid, true},
+		{"secret", "sha256=00", false},
+		{"secret", "", false},
+		{"", valid, false}, // Callbacks are refused until a secret is configured
+	}
+	for _, tc := range cases {
+		is := &InterpreterBookingService{webhookSecret: tc.secret}
+		if err := is.VerifySignature(body, tc.signature); (err == nil) != tc.ok {
+			t.Errorf("secret %q signature %q: err = %v", tc.secret, tc.signature, err)
+		}
+	}
+}
+
+func TestInterpreterPost(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" || r.Header.Get("Idempotency-Key") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"reference":"INT-42","status":"Confirmed"}`))
+	}))
+	defer server.Close()
+
+	is := &InterpreterBookingService{client: server.Client(), apiURL: server.URL, apiKey: "key"}
+	booking := &models.InterpreterBooking{ID: 7, Reference: "HR-F-7", Language: "Polish", VisitDay: "2026-03-10", TimeSlot: "10:30"}
+	ref, status, err := is.post(context.Background(), booking)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if ref != "INT-42" || status != models.InterpreterBookingConfirmed {
+		t.Errorf("post = %q, %q", ref, status)
+	}
+	if received["language"] != "Polish" || received["date"] != "2026-03-10" {
+		t.Errorf("provider received %v", received)
+	}
+
+	is.apiKey = "wrong"
+	if _, _, err := is.post(context.Background(), booking); err == nil {
+		t.Error("expected an error when the provider rejects the booking")
+	}
+}
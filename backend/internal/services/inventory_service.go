@@ -0,0 +1,599 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Inventory errors
+var (
+	ErrInventoryCategoryNotFound = errors.New("inventory category not found")
+	ErrInventoryCategoryInUse    = errors.New("inventory category still has stock items")
+	ErrInventoryItemNotFound     = errors.New("inventory item not found")
+	ErrInventoryInvalid          = errors.New("invalid inventory details")
+	ErrInsufficientStock         = errors.New("not enough stock for this movement")
+)
+
+// Stock urgencies, matching the urgency levels of urgent needs
+const (
+	StockUrgencyCritical = "Critical" // Empty, or at or below half the minimum level
+	StockUrgencyHigh     = "High"     // At or below the minimum level
+	StockUrgencyMedium   = "Medium"   // Under half the target level
+	StockUrgencyLow      = "Low"      // Under the target level
+)
+
+// urgentStockCondition selects active items at or below their minimum level, or
+// empty with a target to hold. It must agree with stockUrgency.
+const urgentStockCondition = "active = ? AND ((minimum_level > 0 AND quantity <= minimum_level) OR (target_level > 0 AND quantity <= 0))"
+
+// InventoryItemFilter narrows the stock list
+type InventoryItemFilter struct {
+	CategoryID      uint
+	Search          string
+	UrgentOnly      bool
+	IncludeInactive bool
+}
+
+// InventoryItemView is a stock item with its computed urgency
+type InventoryItemView struct {
+	models.InventoryItem
+	Urgency   string `json:"urgency"`   // Empty when stock is at or above target
+	Urgent    bool   `json:"urgent"`    // At or below the minimum level
+	Shortfall int    `json:"shortfall"` // Units needed to reach the target level
+}
+
+// StockMovementInput is a stock change to record. Quantity is the number of units
+// moved, or for an adjustment the quantity counted. Batch and expiry only apply to
+// stock coming in.
+type StockMovementInput struct {
+	Type       string
+	Quantity   int
+	Reason     string
+	Reference  string
+	BatchCode  string
+	ExpiresOn  *time.Time
+	DonationID *uint
+}
+
+// InventoryService manages stock items and their movements, and publishes items
+// running short as urgent needs
+type InventoryService struct {
+	db *gorm.DB
+}
+
+// NewInventoryService creates a new inventory service
+func NewInventoryService() *InventoryService {
+	return &InventoryService{db: db.DB}
+}
+
+// Categories returns every category by name
+func (is *InventoryService) Categories() ([]models.InventoryCategory, error) {
+	var categories []models.InventoryCategory
+	err := is.db.Order("name ASC").Find(&categories).Error
+	return categories, err
+}
+
+// CreateCategory adds a category
+func (is *InventoryService) CreateCategory(category *models.InventoryCategory) error {
+	category.Name = strings.TrimSpace(category.Name)
+	if category.Name == "" {
+		return fmt.Errorf("%w: category name is required", ErrInventoryInvalid)
+	}
+	category.ID = 0
+	return is.db.Create(category).Error
+}
+
+// UpdateCategory renames or redescribes a category and sets whether its stock
+// carries expiry dates
+func (is *InventoryService) UpdateCategory(id uint, name, description string, tracksExpiry bool) (*models.InventoryCategory, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: category name is required", ErrInventoryInvalid)
+	}
+
+	var category models.InventoryCategory
+	if err := is.db.First(&category, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInventoryCategoryNotFound
+		}
+		return nil, err
+	}
+	category.Name, category.Description, category.TracksExpiry = name, description, tracksExpiry
+	if err := is.db.Save(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// DeleteCategory removes a category that has no stock items left
+func (is *InventoryService) DeleteCategory(id uint) error {
+	var items int64
+	if err := is.db.Model(&models.InventoryItem{}).Where("category_id = ?", id).Count(&items).Error; err != nil {
+		return err
+	}
+	if items > 0 {
+		return ErrInventoryCategoryInUse
+	}
+
+	result := is.db.Delete(&models.InventoryCategory{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInventoryCategoryNotFound
+	}
+	return nil
+}
+
+// Items returns stock items by name
+func (is *InventoryService) Items(filter InventoryItemFilter) ([]InventoryItemView, error) {
+	query := is.db.Preload("Category").Order("name ASC")
+	if !filter.IncludeInactive {
+		query = query.Where("active = ?", true)
+	}
+	if filter.CategoryID > 0 {
+		query = query.Where("category_id = ?", filter.CategoryID)
+	}
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		query = query.Where("name ILIKE ?", "%"+search+"%")
+	}
+	if filter.UrgentOnly {
+		query = query.Where(urgentStockCondition, true)
+	}
+
+	var items []models.InventoryItem
+	if err := query.Find(&items).Error; err != nil {
+		return nil, err
+	}
+	views := make([]InventoryItemView, len(items))
+	for i, item := range items {
+		views[i] = inventoryItemView(item)
+	}
+	return views, nil
+}
+
+// Item returns one stock item
+func (is *InventoryService) Item(id uint) (*InventoryItemView, error) {
+	var item models.InventoryItem
+	if err := is.db.Preload("Category").First(&item, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInventoryItemNotFound
+		}
+		return nil, err
+	}
+	view := inventoryItemView(item)
+	return &view, nil
+}
+
+// CreateItem adds a stock item. Any opening quantity is booked as a stocktake
+// adjustment so the movement ledger accounts for all of the stock.
+func (is *InventoryService) CreateItem(item *models.InventoryItem, createdBy uint, now time.Time) (*InventoryItemView, error) {
+	if err := validateInventoryItem(item); err != nil {
+		return nil, err
+	}
+	opening := item.Quantity
+
+	err := is.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.InventoryCategory{}, item.CategoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInventoryCategoryNotFound
+			}
+			return err
+		}
+
+		item.ID = 0
+		item.Quantity = 0
+		item.UrgentNeedID = nil
+		item.CreatedBy = createdBy
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+
+		if opening > 0 {
+			_, err := applyStockMovement(tx, item, StockMovementInput{
+				Type:     models.StockMovementAdjust,
+				Quantity: opening,
+				Reason:   "Opening stock",
+			}, &createdBy, now)
+			return err
+		}
+		return syncInventoryNeed(tx, item, createdBy, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return is.Item(item.ID)
+}
+
+// UpdateItem changes a stock item's details and levels. The quantity is left
+// alone; stock only changes through movements.
+func (is *InventoryService) UpdateItem(id uint, changes *models.InventoryItem, updatedBy uint, now time.Time) (*InventoryItemView, error) {
+	changes.Quantity = 0
+	if err := validateInventoryItem(changes); err != nil {
+		return nil, err
+	}
+
+	err := is.db.Transaction(func(tx *gorm.DB) error {
+		var item models.InventoryItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInventoryItemNotFound
+			}
+			return err
+		}
+		if changes.CategoryID != item.CategoryID {
+			if err := tx.First(&models.InventoryCategory{}, changes.CategoryID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrInventoryCategoryNotFound
+				}
+				return err
+			}
+		}
+
+		item.Name = changes.Name
+		item.CategoryID = changes.CategoryID
+		item.Description = changes.Description
+		item.Unit = changes.Unit
+		item.MinimumLevel = changes.MinimumLevel
+		item.TargetLevel = changes.TargetLevel
+		item.Location = changes.Location
+		item.Active = changes.Active
+		if err := tx.Omit("Category").Save(&item).Error; err != nil {
+			return err
+		}
+		return syncInventoryNeed(tx, &item, updatedBy, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return is.Item(id)
+}
+
+// DeleteItem removes a stock item and withdraws its urgent need. Its movements
+// are kept.
+func (is *InventoryService) DeleteItem(id uint) error {
+	return is.db.Transaction(func(tx *gorm.DB) error {
+		var item models.InventoryItem
+		if err := tx.First(&item, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInventoryItemNotFound
+			}
+			return err
+		}
+		if item.UrgentNeedID != nil {
+			if err := tx.Model(&models.UrgentNeed{}).
+				Where("id = ? AND status = ?", *item.UrgentNeedID, "active").
+				Update("status", "cancelled").Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&item).Error
+	})
+}
+
+// RecordMovement applies a stock change to an item and updates its urgent need
+func (is *InventoryService) RecordMovement(itemID uint, input StockMovementInput, recordedBy uint, now time.Time) (*models.StockMovement, error) {
+	var movement *models.StockMovement
+	err := is.db.Transaction(func(tx *gorm.DB) error {
+		var item models.InventoryItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, itemID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInventoryItemNotFound
+			}
+			return err
+		}
+
+		if input.Type == models.StockMovementIn {
+			if err := checkItemExpiry(tx, &item, input.ExpiresOn, now); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		movement, err = applyStockMovement(tx, &item, input, &recordedBy, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// Movements returns a page of an item's stock movements, newest first
+func (is *InventoryService) Movements(itemID uint, page, limit int) ([]models.StockMovement, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	query := is.db.Model(&models.StockMovement{}).Where("item_id = ?", itemID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var movements []models.StockMovement
+	err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&movements).Error
+	return movements, total, err
+}
+
+// Shortages returns the items at or below their minimum level, most urgent first
+func (is *InventoryService) Shortages() ([]InventoryItemView, error) {
+	items, err := is.Items(InventoryItemFilter{UrgentOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	sortShortages(items)
+	return items, nil
+}
+
+// UrgentCount counts the items at or below their minimum level
+func (is *InventoryService) UrgentCount() (int64, error) {
+	var count int64
+	err := is.db.Model(&models.InventoryItem{}).Where(urgentStockCondition, true).Count(&count).Error
+	return count, err
+}
+
+// ReceiveForNeed books stock received against an urgent need into the item that
+// published it. It reports false when no stock item is linked to the need.
+func ReceiveForNeed(tx *gorm.DB, needID uint, quantity int, reference string, now time.Time) (bool, error) {
+	var item models.InventoryItem
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("urgent_need_id = ?", needID).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = applyStockMovement(tx, &item, StockMovementInput{
+		Type:      models.StockMovementIn,
+		Quantity:  quantity,
+		Reason:    "Delivery received",
+		Reference: reference,
+	}, nil, now)
+	return err == nil, err
+}
+
+// applyStockMovement records a movement against a locked item and saves its new
+// quantity
+func applyStockMovement(tx *gorm.DB, item *models.InventoryItem, input StockMovementInput, recordedBy *uint, now time.Time) (*models.StockMovement, error) {
+	change, err := stockChange(input.Type, item.Quantity, input.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Quantity += change
+	item.LastMovedAt = &now
+	if err := tx.Model(item).Updates(map[string]interface{}{
+		"quantity":      item.Quantity,
+		"last_moved_at": now,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	movement := &models.StockMovement{
+		ItemID:        item.ID,
+		Type:          input.Type,
+		Change:        change,
+		QuantityAfter: item.Quantity,
+		Reason:        strings.TrimSpace(input.Reason),
+		Reference:     strings.TrimSpace(input.Reference),
+		DonationID:    input.DonationID,
+		RecordedBy:    recordedBy,
+		CreatedAt:     now,
+	}
+	if input.Type == models.StockMovementIn {
+		movement.BatchCode = strings.TrimSpace(input.BatchCode)
+		movement.ExpiresOn = input.ExpiresOn
+	}
+	if err := tx.Create(movement).Error; err != nil {
+		return nil, err
+	}
+
+	var userID uint
+	if recordedBy != nil {
+		userID = *recordedBy
+	}
+	if err := syncInventoryNeed(tx, item, userID, now); err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// checkItemExpiry checks the expiry date of stock coming into an item against the
+// item's category
+func checkItemExpiry(tx *gorm.DB, item *models.InventoryItem, expiresOn *time.Time, now time.Time) error {
+	var category models.InventoryCategory
+	if err := tx.Select("id", "name", "tracks_expiry").First(&category, item.CategoryID).Error; err != nil {
+		return err
+	}
+	return checkExpiry(category.TracksExpiry, expiresOn, now)
+}
+
+// syncInventoryNeed keeps an item's urgent need in step with its stock. A need is
+// published the first time the item runs short and reused after that, so supplier
+// and reorder settings made on it are kept.
+func syncInventoryNeed(tx *gorm.DB, item *models.InventoryItem, userID uint, now time.Time) error {
+	urgency := stockUrgency(item.Quantity, item.MinimumLevel, item.TargetLevel)
+	urgent := item.Active && stockIsUrgent(urgency)
+	target := max(item.TargetLevel, item.MinimumLevel)
+
+	var need models.UrgentNeed
+	if item.UrgentNeedID != nil {
+		err := tx.First(&need, *item.UrgentNeedID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	if need.ID == 0 {
+		if !urgent {
+			return nil
+		}
+		var category models.InventoryCategory
+		if err := tx.First(&category, item.CategoryID).Error; err != nil {
+			return err
+		}
+		need = models.UrgentNeed{
+			Name:         item.Name,
+			Category:     category.Name,
+			Description:  item.Description,
+			CurrentStock: item.Quantity,
+			TargetStock:  target,
+			Urgency:      urgency,
+			Status:       "active",
+			RequestedBy:  userID,
+			IsPublic:     true,
+		}
+		if err := tx.Create(&need).Error; err != nil {
+			return err
+		}
+		item.UrgentNeedID = &need.ID
+		return tx.Model(item).Update("urgent_need_id", need.ID).Error
+	}
+
+	updates := map[string]interface{}{
+		"name":          item.Name,
+		"current_stock": item.Quantity,
+		"target_stock":  target,
+	}
+	switch {
+	case urgent:
+		updates["urgency"] = urgency
+		updates["status"] = "active"
+		updates["fulfilled_at"] = nil
+	case !item.Active && need.Status == "active":
+		updates["status"] = "cancelled"
+	case need.Status == "active":
+		updates["status"] = "fulfilled"
+		updates["fulfilled_at"] = now
+		if urgency != "" {
+			updates["urgency"] = urgency
+		}
+	}
+	return tx.Model(&need).Updates(updates).Error
+}
+
+// stockChange works out the signed change a movement makes to the current stock
+func stockChange(movementType string, current, quantity int) (int, error) {
+	switch movementType {
+	case models.StockMovementIn:
+		if quantity <= 0 {
+			return 0, fmt.Errorf("%w: quantity received must be positive", ErrInventoryInvalid)
+		}
+		return quantity, nil
+	case models.StockMovementOut, models.StockMovementWaste:
+		if quantity <= 0 {
+			return 0, fmt.Errorf("%w: quantity removed must be positive", ErrInventoryInvalid)
+		}
+		if quantity > current {
+			return 0, fmt.Errorf("%w: %d requested, %d in stock", ErrInsufficientStock, quantity, current)
+		}
+		return -quantity, nil
+	case models.StockMovementAdjust:
+		if quantity < 0 {
+			return 0, fmt.Errorf("%w: counted quantity cannot be negative", ErrInventoryInvalid)
+		}
+		return quantity - current, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown movement type %q", ErrInventoryInvalid, movementType)
+	}
+}
+
+// checkExpiry checks a batch's expiry date. Categories that track expiry need one,
+// and stock already past its date is not taken in.
+func checkExpiry(tracksExpiry bool, expiresOn *time.Time, now time.Time) error {
+	if expiresOn == nil {
+		if tracksExpiry {
+			return fmt.Errorf("%w: an expiry date is required for this category", ErrInventoryInvalid)
+		}
+		return nil
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if expiresOn.Before(today) {
+		return fmt.Errorf("%w: stock expired on %s, record it as waste instead", ErrInventoryInvalid, expiresOn.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// stockUrgency grades stock against an item's levels. It is empty when the item
+// is at or above its target, or has no levels set.
+func stockUrgency(quantity, minimum, target int) string {
+	switch {
+	case minimum <= 0 && target <= 0:
+		return ""
+	case quantity <= 0, minimum > 0 && quantity*2 <= minimum:
+		return StockUrgencyCritical
+	case minimum > 0 && quantity <= minimum:
+		return StockUrgencyHigh
+	case target > 0 && quantity*2 < target:
+		return StockUrgencyMedium
+	case target > 0 && quantity < target:
+		return StockUrgencyLow
+	default:
+		return ""
+	}
+}
+
+// stockIsUrgent reports whether an urgency means the item is at or below its minimum
+func stockIsUrgent(urgency string) bool {
+	return urgency == StockUrgencyCritical || urgency == StockUrgencyHigh
+}
+
+// inventoryItemView adds an item's computed urgency
+func inventoryItemView(item models.InventoryItem) InventoryItemView {
+	urgency := stockUrgency(item.Quantity, item.MinimumLevel, item.TargetLevel)
+	return InventoryItemView{
+		InventoryItem: item,
+		Urgency:       urgency,
+		Urgent:        item.Active && stockIsUrgent(urgency),
+		Shortfall:     max(max(item.TargetLevel, item.MinimumLevel)-item.Quantity, 0),
+	}
+}
+
+// sortShortages orders critical items first, then by how much of the minimum
+// level is left
+func sortShortages(items []InventoryItemView) {
+	left := func(item InventoryItemView) float64 {
+		if item.MinimumLevel <= 0 {
+			return 0
+		}
+		return float64(item.Quantity) / float64(item.MinimumLevel)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		ci, cj := items[i].Urgency == StockUrgencyCritical, items[j].Urgency == StockUrgencyCritical
+		if ci != cj {
+			return ci
+		}
+		return left(items[i]) < left(items[j])
+	})
+}
+
+// validateInventoryItem checks an item's details and levels
+func validateInventoryItem(item *models.InventoryItem) error {
+	item.Name = strings.TrimSpace(item.Name)
+	switch {
+	case item.Name == "":
+		return fmt.Errorf("%w: item name is required", ErrInventoryInvalid)
+	case item.CategoryID == 0:
+		return fmt.Errorf("%w: category is required", ErrInventoryInvalid)
+	case item.Quantity < 0:
+		return fmt.Errorf("%w: quantity cannot be negative", ErrInventoryInvalid)
+	case item.MinimumLevel < 0 || item.TargetLevel < 0:
+		return fmt.Errorf("%w: stock levels cannot be negative", ErrInventoryInvalid)
+	case item.TargetLevel > 0 && item.TargetLevel < item.MinimumLevel:
+		return fmt.Errorf("%w: target level must be at least the minimum level", ErrInventoryInvalid)
+	}
+	return nil
+}
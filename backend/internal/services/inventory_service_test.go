@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestStockUrgency(t *testing.T) {
+	tests := []struct {
+		name                      string
+		quantity, minimum, target int
+		want                      string
+	}{
+		{name: "no levels set", quantity: 0, want: ""},
+		{name: "empty", quantity: 0, minimum: 10, target: 50, want: StockUrgencyCritical},
+		{name: "empty with only a target", quantity: 0, target: 50, want: StockUrgencyCritical},
+		{name: "half the minimum", quantity: 5, minimum: 10, target: 50, want: StockUrgencyCritical},
+		{name: "at the minimum", quantity: 10, minimum: 10, target: 50, want: StockUrgencyHigh},
+		{name: "under half the target", quantity: 20, minimum: 10, target: 50, want: StockUrgencyMedium},
+		{name: "under the target", quantity: 40, minimum: 10, target: 50, want: StockUrgencyLow},
+		{name: "at the target", quantity: 50, minimum: 10, target: 50, want: ""},
+		{name: "minimum only, above it", quantity: 11, minimum: 10, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stockUrgency(tt.quantity, tt.minimum, tt.target); got != tt.want {
+				t.Errorf("stockUrgency(%d, %d, %d) = %q, want %q", tt.quantity, tt.minimum, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStockChange(t *testing.T) {
+	tests := []struct {
+		name         string
+		movementType string
+		current      int
+		quantity     int
+		want         int
+		wantErr      error
+	}{
+		{name: "stock in", movementType: models.StockMovementIn, current: 5, quantity: 20, want: 20},
+		{name: "stock out", movementType: models.StockMovementOut, current: 5, quantity: 3, want: -3},
+		{name: "waste all of it", movementType: models.StockMovementWaste, current: 5, quantity: 5, want: -5},
+		{name: "more out than held", movementType: models.StockMovementOut, current: 5, quantity: 6, wantErr: ErrInsufficientStock},
+		{name: "stocktake down", movementType: models.StockMovementAdjust, current: 12, quantity: 9, want: -3},
+		{name: "stocktake to zero", movementType: models.StockMovementAdjust, current: 12, quantity: 0, want: -12},
+		{name: "nothing received", movementType: models.StockMovementIn, current: 5, quantity: 0, wantErr: ErrInventoryInvalid},
+		{name: "negative count", movementType: models.StockMovementAdjust, current: 5, quantity: -1, wantErr: ErrInventoryInvalid},
+		{name: "unknown type", movementType: "transfer", current: 5, quantity: 1, wantErr: ErrInventoryInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stockChange(tt.movementType, tt.current, tt.quantity)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("stockChange() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("stockChange() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("stockChange() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInventoryItemView(t *testing.T) {
+	view := inventoryItemView(models.InventoryItem{Name: "Rice", Quantity: 8, MinimumLevel: 10, TargetLevel: 40, Active: true})
+	if view.Urgency != StockUrgencyHigh || !view.Urgent || view.Shortfall != 32 {
+		t.Errorf("active item view = %+v, want High, urgent, shortfall 32", view)
+	}
+
+	inactive := inventoryItemView(models.InventoryItem{Name: "Rice", Quantity: 0, MinimumLevel: 10, Active: false})
+	if inactive.Urgent {
+		t.Error("inactive items are never urgent")
+	}
+}
+
+func TestSortShortages(t *testing.T) {
+	items := []InventoryItemView{
+		inventoryItemView(models.InventoryItem{Name: "Pasta", Quantity: 9, MinimumLevel: 10, Active: true}),
+		inventoryItemView(models.InventoryItem{Name: "Soap", Quantity: 6, MinimumLevel: 10, Active: true}),
+		inventoryItemView(models.InventoryItem{Name: "Nappies", Quantity: 2, MinimumLevel: 10, Active: true}),
+	}
+	sortShortages(items)
+
+	want := []string{"Nappies", "Soap", "Pasta"}
+	for i, name := range want {
+		if items[i].Name != name {
+			t.Fatalf("position %d = %s, want %s", i, items[i].Name, name)
+		}
+	}
+}
+
+func TestValidateInventoryItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		item    models.InventoryItem
+		wantErr bool
+	}{
+		{name: "valid", item: models.InventoryItem{Name: "Rice", CategoryID: 1, MinimumLevel: 10, TargetLevel: 40}},
+		{name: "no target", item: models.InventoryItem{Name: "Rice", CategoryID: 1, MinimumLevel: 10}},
+		{name: "blank name", item: models.InventoryItem{Name: "  ", CategoryID: 1}, wantErr: true},
+		{name: "no category", item: models.InventoryItem{Name: "Rice"}, wantErr: true},
+		{name: "negative opening stock", item: models.InventoryItem{Name: "Rice", CategoryID: 1, Quantity: -1}, wantErr: true},
+		{name: "target under minimum", item: models.InventoryItem{Name: "Rice", CategoryID: 1, MinimumLevel: 10, TargetLevel: 5}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateInventoryItem(&tt.item)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateInventoryItem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInventoryInvalid) {
+				t.Errorf("error %v does not wrap ErrInventoryInvalid", err)
+			}
+		})
+	}
+}
+
+func TestCheckExpiry(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	date := func(s string) *time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return &d
+	}
+
+	tests := []struct {
+		name         string
+		tracksExpiry bool
+		expiresOn    *time.Time
+		wantErr      bool
+	}{
+		{name: "non-food without a date", tracksExpiry: false},
+		{name: "food with a future date", tracksExpiry: true, expiresOn: date("2026-06-01")},
+		{name: "food expiring today", tracksExpiry: true, expiresOn: date("2026-03-10")},
+		{name: "food without a date", tracksExpiry: true, wantErr: true},
+		{name: "already expired", tracksExpiry: true, expiresOn: date("2026-03-09"), wantErr: true},
+		{name: "non-food already expired", tracksExpiry: false, expiresOn: date("2025-01-01"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkExpiry(tt.tracksExpiry, tt.expiresOn, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkExpiry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInventoryInvalid) {
+				t.Errorf("error %v does not wrap ErrInventoryInvalid", err)
+			}
+		})
+	}
+}
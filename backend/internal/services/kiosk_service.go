@@ -0,0 +1,214 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// A second tap from the same kiosk within this window is treated as a double tap
+const kioskDuplicateTapWindow = 3 * time.Second
+
+var (
+	ErrKioskInvalidRating   = errors.New("rating must be between 1 and 5")
+	ErrKioskInvalidCategory = errors.New("unknown rating category")
+	ErrKioskDuplicateTap    = errors.New("rating already recorded")
+)
+
+// KioskService manages feedback kiosk devices and their anonymous ratings
+type KioskService struct {
+	db *gorm.DB
+}
+
+// KioskDailyMetrics is the satisfaction summary for one day of kiosk ratings
+type KioskDailyMetrics struct {
+	Day           string             `json:"day"`
+	Responses     int64              `json:"responses"`
+	AverageRating float64            `json:"average_rating"`
+	Satisfaction  float64            `json:"satisfaction"` // Percentage of 4 and 5 ratings
+	Distribution  map[int]int64      `json:"distribution"`
+	ByCategory    map[string]float64 `json:"by_category"` // Average rating per category
+}
+
+// kioskRatingCount is the number of ratings of one value in a category on a day
+type kioskRatingCount struct {
+	VisitDay string
+	Category string
+	Rating   int
+	Count    int64
+}
+
+// NewKioskService creates a new kiosk service
+func NewKioskService() *KioskService {
+	return &KioskService{
+		db: db.DB,
+	}
+}
+
+// CreateDevice registers a kiosk and returns its device token. The token is only
+// available here; afterwards only its hash is kept.
+func (ks *KioskService) CreateDevice(name, location string, createdBy uint) (*models.KioskDevice, string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	token := "ksk_" + hex.EncodeToString(b)
+
+	device := models.KioskDevice{
+		Name:        name,
+		Location:    location,
+		TokenHash:   models.HashKioskToken(token),
+		TokenPrefix: token[:12],
+		IsActive:    true,
+		CreatedBy:   createdBy,
+	}
+	if err := ks.db.Create(&device).Error; err != nil {
+		return nil, "", err
+	}
+	return &device, token, nil
+}
+
+// AuthenticateDevice finds the active kiosk for a device token and records that it
+// was seen
+func (ks *KioskService) AuthenticateDevice(token string) (*models.KioskDevice, error) {
+	if token == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var device models.KioskDevice
+	if err := ks.db.Where("token_hash = ? AND is_active = ?", models.HashKioskToken(token), true).
+		First(&device).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ks.db.Model(&device).UpdateColumn("last_seen_at", now)
+	device.LastSeenAt = &now
+	return &device, nil
+}
+
+// RevokeDevice stops a kiosk's token from being accepted
+func (ks *KioskService) RevokeDevice(device *models.KioskDevice) error {
+	now := time.Now()
+	device.IsActive = false
+	device.RevokedAt = &now
+	return ks.db.Save(device).Error
+}
+
+// RecordRating stores an anonymous rating from a kiosk
+func (ks *KioskService) RecordRating(deviceID uint, rating int, category string, now time.Time) (*models.KioskRating, error) {
+	if rating < 1 || rating > 5 {
+		return nil, ErrKioskInvalidRating
+	}
+	if category != "" && !models.IsKioskCategory(category) {
+		return nil, ErrKioskInvalidCategory
+	}
+
+	var recent int64
+	ks.db.Model(&models.KioskRating{}).
+		Where("device_id = ? AND created_at > ?", deviceID, now.Add(-kioskDuplicateTapWindow)).
+		Count(&recent)
+	if recent > 0 {
+		return nil, ErrKioskDuplicateTap
+	}
+
+	entry := models.KioskRating{
+		DeviceID: deviceID,
+		Rating:   rating,
+		Category: category,
+		VisitDay: now.Format("2006-01-02"),
+	}
+	if err := ks.db.Create(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// DailyMetrics aggregates kiosk ratings into daily satisfaction figures between two
+// days inclusive, optionally for one device
+func (ks *KioskService) DailyMetrics(from, to string, deviceID uint) ([]KioskDailyMetrics, error) {
+	query := func() *gorm.DB {
+		q := ks.db.Model(&models.KioskRating{}).Where("visit_day BETWEEN ? AND ?", from, to)
+		if deviceID != 0 {
+			q = q.Where("device_id = ?", deviceID)
+		}
+		return q
+	}
+
+	var counts []kioskRatingCount
+	if err := query().
+		Select("visit_day, category, rating, COUNT(*) AS count").
+		Group("visit_day, category, rating").
+		Order("visit_day ASC").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	return kioskDailyMetrics(counts), nil
+}
+
+// kioskDailyMetrics works out each day's satisfaction figures from rating counts
+// ordered by day
+func kioskDailyMetrics(counts []kioskRatingCount) []KioskDailyMetrics {
+	type categoryTotal struct {
+		sum   int64
+		count int64
+	}
+	days := []KioskDailyMetrics{}
+	index := map[string]int{}
+	totals := map[string]map[string]*categoryTotal{}
+	sums := map[string]int64{}
+	satisfied := map[string]int64{}
+
+	for _, row := range counts {
+		i, ok := index[row.VisitDay]
+		if !ok {
+			i = len(days)
+			index[row.VisitDay] = i
+			days = append(days, KioskDailyMetrics{
+				Day:          row.VisitDay,
+				Distribution: map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0},
+				ByCategory:   map[string]float64{},
+			})
+			totals[row.VisitDay] = map[string]*categoryTotal{}
+		}
+
+		days[i].Responses += row.Count
+		days[i].Distribution[row.Rating] += row.Count
+		sums[row.VisitDay] += int64(row.Rating) * row.Count
+		if row.Rating >= 4 {
+			satisfied[row.VisitDay] += row.Count
+		}
+
+		if row.Category != "" {
+			total, ok := totals[row.VisitDay][row.Category]
+			if !ok {
+				total = &categoryTotal{}
+				totals[row.VisitDay][row.Category] = total
+			}
+			total.sum += int64(row.Rating) * row.Count
+			total.count += row.Count
+		}
+	}
+
+	for i := range days {
+		day := &days[i]
+		if day.Responses == 0 {
+			continue
+		}
+		day.AverageRating = math.Round(float64(sums[day.Day])/float64(day.Responses)*100) / 100
+		day.Satisfaction = math.Round(float64(satisfied[day.Day])/float64(day.Responses)*1000) / 10
+		for category, total := range totals[day.Day] {
+			day.ByCategory[category] = math.Round(float64(total.sum)/float64(total.count)*100) / 100
+		}
+	}
+
+	return days
+}
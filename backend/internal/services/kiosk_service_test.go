@@ -0,0 +1,64 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestKioskDailyMetrics(t *testing.T) {
+	days := kioskDailyMetrics([]kioskRatingCount{
+		{VisitDay: "2026-05-05", Rating: 5, Count: 6},
+		{VisitDay: "2026-05-05", Category: models.KioskCategoryFood, Rating: 4, Count: 2},
+		{VisitDay: "2026-05-05", Category: models.KioskCategoryWaitTime, Rating: 1, Count: 1},
+		{VisitDay: "2026-05-05", Category: models.KioskCategoryWaitTime, Rating: 2, Count: 2},
+		{VisitDay: "2026-05-07", Rating: 3, Count: 1},
+	})
+	if len(days) != 2 || days[0].Day != "2026-05-05" || days[1].Day != "2026-05-07" {
+		t.Fatalf("got %+v", days)
+	}
+
+	first := days[0]
+	// 6x5 + 2x4 + 1x1 + 2x2 = 43 over 11 ratings, of which 8 are 4 or 5
+	if first.Responses != 11 || first.AverageRating != 3.91 || first.Satisfaction != 72.7 {
+		t.Errorf("first day: got %d responses, average %.2f, %.1f%% satisfied", first.Responses, first.AverageRating, first.Satisfaction)
+	}
+	if first.Distribution[5] != 6 || first.Distribution[3] != 0 || len(first.Distribution) != 5 {
+		t.Errorf("distribution %v", first.Distribution)
+	}
+	if first.ByCategory[models.KioskCategoryFood] != 4 || first.ByCategory[models.KioskCategoryWaitTime] != 1.67 || len(first.ByCategory) != 2 {
+		t.Errorf("by category %v", first.ByCategory)
+	}
+
+	if second := days[1]; second.Satisfaction != 0 || second.AverageRating != 3 || len(second.ByCategory) != 0 {
+		t.Errorf("second day: got %+v", second)
+	}
+	if empty := kioskDailyMetrics(nil); empty == nil || len(empty) != 0 {
+		t.Errorf("no ratings: got %#v", empty)
+	}
+}
+
+func TestRecordRatingValidation(t *testing.T) {
+	ks := &KioskService{}
+	now := time.Now()
+	for _, rating := range []int{0, 6, -1} {
+		if _, err := ks.RecordRating(1, rating, "", now); !errors.Is(err, ErrKioskInvalidRating) {
+			t.Errorf("rating %d: got %v", rating, err)
+		}
+	}
+	if _, err := ks.RecordRating(1, 4, "parking", now); !errors.Is(err, ErrKioskInvalidCategory) {
+		t.Errorf("unknown category: got %v", err)
+	}
+}
+
+func TestKioskTokenHash(t *testing.T) {
+	if _, err := (&KioskService{}).AuthenticateDevice(""); err == nil {
+		t.Error("empty token accepted")
+	}
+	hash := models.HashKioskToken("ksk_abc")
+	if len(hash) != 64 || hash == models.HashKioskToken("ksk_abd") || hash != models.HashKioskToken("ksk_abc") {
+		t.Errorf("hash %q", hash)
+	}
+}
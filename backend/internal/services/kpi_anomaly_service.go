@@ -0,0 +1,300 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// defaultKPIAnomalySigma flags a day this many standard deviations from the usual
+	// value for its weekday; defaultKPIAnomalyHighSigma raises the alert as high
+	defaultKPIAnomalySigma     = 2.5
+	defaultKPIAnomalyHighSigma = 3.5
+	// kpiBaselineWeeks is how many of the same weekday the usual value is learned from
+	kpiBaselineWeeks = 8
+	// minKPIBaselineDays is how many of those days need data before a KPI is judged
+	minKPIBaselineDays    = 4
+	alertSourceKPIAnomaly = "kpi_anomaly"
+	kpiAnomalyListLimit   = 500
+)
+
+// kpiDefinition is how one KPI is measured and where to look into it
+type kpiDefinition struct {
+	Label     string
+	Unit      string
+	Counted   bool    // A day without records counts as zero rather than no data
+	MinStdDev float64 // Floor on the spread, so a single extra record is never an anomaly
+	ActionURL string
+	series    func(tx *gorm.DB, from, to time.Time) *gorm.DB
+}
+
+// kpiDefinitions are the KPIs the anomaly job watches. Each series selects day
+// (YYYY-MM-DD) and value for records in [from, to).
+var kpiDefinitions = map[string]kpiDefinition{
+	models.KPIHelpRequests: {
+		Label: "Help requests", Unit: "requests", Counted: true, MinStdDev: 1, ActionURL: "/admin/help-requests",
+		series: func(tx *gorm.DB, from, to time.Time) *gorm.DB {
+			return tx.Model(&models.HelpRequest{}).
+				Select("TO_CHAR(created_at, 'YYYY-MM-DD') AS day, COUNT(*) AS value").
+				Where("created_at >= ? AND created_at < ?", from, to)
+		},
+	},
+	models.KPIVisitorNoShows: {
+		Label: "Visitor no-shows", Unit: "no-shows", Counted: true, MinStdDev: 1, ActionURL: "/admin/reports",
+		series: func(tx *gorm.DB, from, to time.Time) *gorm.DB {
+			return tx.Model(&models.Ticket{}).
+				Select("TO_CHAR(visit_date, 'YYYY-MM-DD') AS day, COUNT(*) AS value").
+				Where("status = ? AND used_at IS NULL AND visit_date >= ? AND visit_date < ?", models.TicketStatusExpired, from, to)
+		},
+	},
+	models.KPIDonations: {
+		Label: "Donations", Unit: "donations", Counted: true, MinStdDev: 1, ActionURL: "/admin/donations",
+		series: func(tx *gorm.DB, from, to time.Time) *gorm.DB {
+			return tx.Model(&models.Donation{}).
+				Select("TO_CHAR(created_at, 'YYYY-MM-DD') AS day, COUNT(*) AS value").
+				Where("status <> ? AND created_at >= ? AND created_at < ?", models.DonationStatusCancelled, from, to)
+		},
+	},
+	models.KPIAverageWaitTime: {
+		Label: "Average queue wait", Unit: "minutes", MinStdDev: 1, ActionURL: queueFairnessAlertAction,
+		series: func(tx *gorm.DB, from, to time.Time) *gorm.DB {
+			return tx.Model(&models.QueueEntry{}).
+				Select("TO_CHAR(joined_at, 'YYYY-MM-DD') AS day, AVG(EXTRACT(EPOCH FROM (called_at - joined_at)) / 60) AS value").
+				Where("called_at IS NOT NULL AND called_at >= joined_at AND joined_at >= ? AND joined_at < ?", from, to)
+		},
+	},
+}
+
+// KPIAnomalyService learns the normal range of daily KPIs and raises an admin alert
+// when a day falls outside it
+type KPIAnomalyService struct {
+	db *gorm.DB
+}
+
+// KPIRange is a KPI's value on a day against its normal range for that weekday
+type KPIRange struct {
+	KPI          string   `json:"kpi"`
+	Label        string   `json:"label"`
+	Unit         string   `json:"unit"`
+	Day          string   `json:"day"`
+	Value        *float64 `json:"value"` // Empty when the day has no data
+	Mean         float64  `json:"mean"`
+	StdDev       float64  `json:"stddev"`
+	Low          float64  `json:"low"` // Normal range at the configured sigma
+	High         float64  `json:"high"`
+	BaselineDays int      `json:"baseline_days"`
+	Sigma        float64  `json:"sigma"` // Standard deviations from the mean
+	Anomalous    bool     `json:"anomalous"`
+	Severity     string   `json:"severity,omitempty"`
+	ActionURL    string   `json:"action_url"`
+}
+
+// NewKPIAnomalyService creates a new KPI anomaly service
+func NewKPIAnomalyService() *KPIAnomalyService {
+	return &KPIAnomalyService{db: db.DB}
+}
+
+// kpiSigmaThresholds returns the sigma at which a day is flagged and the sigma at
+// which it is raised as high severity
+func kpiSigmaThresholds() (float64, float64) {
+	sigma := fairnessFloatSetting("KPI_ANOMALY_SIGMA", defaultKPIAnomalySigma)
+	high := fairnessFloatSetting("KPI_ANOMALY_HIGH_SIGMA", defaultKPIAnomalyHighSigma)
+	if high < sigma {
+		high = sigma
+	}
+	return sigma, high
+}
+
+// Ranges returns every KPI's value on a day against its normal range
+func (ks *KPIAnomalyService) Ranges(day time.Time) ([]KPIRange, error) {
+	sigma, high := kpiSigmaThresholds()
+	ranges := make([]KPIRange, 0, len(models.KPIs))
+	for _, kpi := range models.KPIs {
+		r, err := ks.assess(kpi, day, sigma, high)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// Detect judges yesterday, the last complete day, and raises an alert for each KPI
+// outside its normal range. Each KPI is alerted at most once per day, so the job can
+// run as often as it likes.
+func (ks *KPIAnomalyService) Detect(now time.Time) ([]models.KPIAnomaly, error) {
+	day, _ := dayRange(now.AddDate(0, 0, -1))
+	ranges, err := ks.Ranges(day)
+	if err != nil {
+		return nil, err
+	}
+
+	detected := []models.KPIAnomaly{}
+	for _, r := range ranges {
+		if !r.Anomalous {
+			continue
+		}
+		anomaly := models.KPIAnomaly{
+			KPI:          r.KPI,
+			Day:          r.Day,
+			Value:        *r.Value,
+			BaselineMean: r.Mean,
+			BaselineSD:   r.StdDev,
+			BaselineDays: r.BaselineDays,
+			Sigma:        r.Sigma,
+			Direction:    models.KPIAnomalyAbove,
+			Severity:     r.Severity,
+		}
+		if r.Sigma < 0 {
+			anomaly.Direction = models.KPIAnomalyBelow
+		}
+		result := ks.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&anomaly)
+		if result.Error != nil {
+			return detected, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		alert, err := (&SystemAlertService{db: ks.db}).raise(kpiAnomalyCondition(r, day), now)
+		if err != nil {
+			return detected, err
+		}
+		anomaly.AlertID = &alert.ID
+		if err := ks.db.Model(&anomaly).Update("alert_id", alert.ID).Error; err != nil {
+			return detected, err
+		}
+		detected = append(detected, anomaly)
+	}
+	return detected, nil
+}
+
+// Anomalies lists past anomalies, newest first, optionally for one KPI
+func (ks *KPIAnomalyService) Anomalies(kpi string, limit int) ([]models.KPIAnomaly, error) {
+	if limit <= 0 || limit > kpiAnomalyListLimit {
+		limit = kpiAnomalyListLimit
+	}
+	query := ks.db.Order("day DESC, kpi").Limit(limit)
+	if kpi != "" {
+		query = query.Where("kpi = ?", kpi)
+	}
+	var anomalies []models.KPIAnomaly
+	err := query.Find(&anomalies).Error
+	return anomalies, err
+}
+
+// assess measures a KPI on a day and the same weekday over the baseline weeks
+func (ks *KPIAnomalyService) assess(kpi string, day time.Time, sigma, high float64) (KPIRange, error) {
+	definition := kpiDefinitions[kpi]
+	start, end := dayRange(day)
+
+	var rows []struct {
+		Day   string
+		Value float64
+	}
+	if err := definition.series(ks.db, start.AddDate(0, 0, -7*kpiBaselineWeeks), end).
+		Group("day").Scan(&rows).Error; err != nil {
+		return KPIRange{}, fmt.Errorf("failed to measure %s: %w", kpi, err)
+	}
+	series := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		series[row.Day] = row.Value
+	}
+
+	r := KPIRange{
+		KPI:       kpi,
+		Label:     definition.Label,
+		Unit:      definition.Unit,
+		Day:       start.Format("2006-01-02"),
+		ActionURL: definition.ActionURL,
+	}
+	r.Mean, r.StdDev, r.BaselineDays = kpiBaseline(series, start, kpiBaselineWeeks, definition.Counted)
+	r.StdDev = math.Max(r.StdDev, definition.MinStdDev)
+	r.Low, r.High = math.Max(r.Mean-sigma*r.StdDev, 0), r.Mean+sigma*r.StdDev
+	r.Mean, r.StdDev = roundKPI(r.Mean), roundKPI(r.StdDev)
+	r.Low, r.High = roundKPI(r.Low), roundKPI(r.High)
+
+	value, ok := series[r.Day]
+	if !ok && !definition.Counted {
+		return r, nil
+	}
+	value = roundKPI(value)
+	r.Value = &value
+	if r.BaselineDays >= minKPIBaselineDays {
+		r.Sigma, r.Severity, r.Anomalous = judgeKPI(value, r.Mean, r.StdDev, sigma, high)
+	}
+	return r, nil
+}
+
+// kpiBaseline returns the mean and standard deviation of a KPI on the same weekday in
+// the weeks before a day, and how many of those days had data
+func kpiBaseline(series map[string]float64, day time.Time, weeks int, counted bool) (float64, float64, int) {
+	var values []float64
+	for week := 1; week <= weeks; week++ {
+		value, ok := series[day.AddDate(0, 0, -7*week).Format("2006-01-02")]
+		if !ok && !counted {
+			continue
+		}
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return 0, 0, 0
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values))), len(values)
+}
+
+// judgeKPI returns how many standard deviations a value is from the mean and, when
+// that is past the threshold, the alert severity
+func judgeKPI(value, mean, stddev, sigma, high float64) (float64, string, bool) {
+	if stddev <= 0 {
+		return 0, "", false
+	}
+	z := roundKPI((value - mean) / stddev)
+	switch {
+	case math.Abs(z) >= high:
+		return z, models.AlertSeverityHigh, true
+	case math.Abs(z) >= sigma:
+		return z, models.AlertSeverityMedium, true
+	}
+	return z, "", false
+}
+
+// kpiAnomalyCondition describes an anomaly for the admin dashboard
+func kpiAnomalyCondition(r KPIRange, day time.Time) alertCondition {
+	direction := "high"
+	if r.Sigma < 0 {
+		direction = "low"
+	}
+	return alertCondition{
+		Source:   alertSourceKPIAnomaly,
+		Severity: r.Severity,
+		Title:    fmt.Sprintf("%s unusually %s", r.Label, direction),
+		Message: fmt.Sprintf("%s on %s: %g %s, against a usual %g (normal range %g to %g over the last %d %ss, %+.1f sigma)",
+			r.Label, day.Format("Mon 2 Jan"), *r.Value, r.Unit, r.Mean, r.Low, r.High,
+			r.BaselineDays, day.Weekday(), r.Sigma),
+		ActionLabel: "View Dashboard",
+		ActionURL:   r.ActionURL,
+	}
+}
+
+// roundKPI rounds to one decimal place
+func roundKPI(value float64) float64 {
+	return math.Round(value*10) / 10
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestKPIBaseline(t *testing.T) {
+	// Tuesday 14 October 2025, with the four Tuesdays before it
+	day := time.Date(2025, 10, 14, 0, 0, 0, 0, time.Local)
+	series := map[string]float64{
+		"2025-10-07": 10,
+		"2025-09-30": 12,
+		"2025-09-23": 8,
+		"2025-09-16": 10,
+		"2025-10-13": 99, // Monday, not the same weekday
+	}
+
+	mean, stddev, days := kpiBaseline(series, day, 4, false)
+	if mean != 10 || days != 4 || math.Abs(stddev-math.Sqrt(2)) > 0.001 {
+		t.Errorf("got mean %v stddev %v over %d days", mean, stddev, days)
+	}
+
+	// Counted KPIs treat a missing day as zero
+	mean, _, days = kpiBaseline(series, day, 5, true)
+	if days != 5 || mean != 8 {
+		t.Errorf("counted: got mean %v over %d days, want 8 over 5", mean, days)
+	}
+}
+
+func TestJudgeKPI(t *testing.T) {
+	tests := []struct {
+		value     float64
+		severity  string
+		anomalous bool
+	}{
+		{12, "", false},
+		{15.5, models.AlertSeverityMedium, true},
+		{3, models.AlertSeverityHigh, true},
+	}
+	for _, tt := range tests {
+		_, severity, anomalous := judgeKPI(tt.value, 10, 2, 2.5, 3.5)
+		if severity != tt.severity || anomalous != tt.anomalous {
+			t.Errorf("%v: got %q %v, want %q %v", tt.value, severity, anomalous, tt.severity, tt.anomalous)
+		}
+	}
+	if _, _, anomalous := judgeKPI(50, 10, 0, 2.5, 3.5); anomalous {
+		t.Error("a KPI with no spread should not be judged")
+	}
+}
+
+func TestKPIAnomalyCondition(t *testing.T) {
+	value := 3.0
+	r := KPIRange{Label: "Donations", Unit: "donations", Value: &value, Mean: 10, Low: 5, High: 15,
+		BaselineDays: 8, Sigma: -3.5, Severity: models.AlertSeverityHigh, ActionURL: "/admin/donations"}
+
+	condition := kpiAnomalyCondition(r, time.Date(2025, 10, 14, 0, 0, 0, 0, time.Local))
+	if condition.Title != "Donations unusually low" || condition.Source != alertSourceKPIAnomaly {
+		t.Errorf("unexpected condition %+v", condition)
+	}
+	if !strings.Contains(condition.Message, "Tue 14 Oct") || !strings.Contains(condition.Message, "8 Tuesdays") {
+		t.Errorf("message missing the day or baseline: %s", condition.Message)
+	}
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// Kudos limits
+const (
+	KudosDailyLimit          = 5   // Maximum kudos a user may send per day
+	KudosMaxMessageLength    = 500 // Maximum kudos message length
+	KudosAutoHideReportCount = 3   // Open reports after which kudos are hidden pending review
+)
+
+var (
+	ErrKudosSelf           = errors.New("you cannot send kudos to yourself")
+	ErrKudosNotOnShift     = errors.New("both volunteers must have worked this shift")
+	ErrKudosDuplicate      = errors.New("you have already sent kudos to this volunteer for this shift")
+	ErrKudosDailyLimit     = fmt.Errorf("you can send at most %d kudos per day", KudosDailyLimit)
+	ErrKudosAlreadyHandled = errors.New("kudos has already been moderated")
+	ErrKudosAlreadyFlagged = errors.New("you have already reported this kudos")
+)
+
+// KudosService handles peer recognition between volunteers and staff
+type KudosService struct {
+	db *gorm.DB
+}
+
+// VolunteerOfTheMonthEntry ranks a volunteer by the kudos they received in a month
+type VolunteerOfTheMonthEntry struct {
+	UserID        uint   `json:"user_id"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	KudosCount    int    `json:"kudos_count"`
+	UniqueSenders int    `json:"unique_senders"`
+	ShiftCount    int    `json:"shift_count"`
+}
+
+// NewKudosService creates a new kudos service
+func NewKudosService() *KudosService {
+	return &KudosService{
+		db: db.DB,
+	}
+}
+
+// SendKudos creates a kudos awaiting moderation. Volunteers may only send kudos
+// to people they worked the shift with; staff may send kudos to anyone on the shift.
+func (ks *KudosService) SendKudos(senderID uint, senderIsStaff bool, recipientID, shiftID uint, category, message string) (*models.Kudos, error) {
+	message, err := cleanKudosMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	if senderID == recipientID {
+		return nil, ErrKudosSelf
+	}
+	if category == "" {
+		category = models.KudosCategoryTeamwork
+	}
+
+	if !ks.workedShift(recipientID, shiftID) {
+		return nil, ErrKudosNotOnShift
+	}
+	if !senderIsStaff && !ks.workedShift(senderID, shiftID) {
+		return nil, ErrKudosNotOnShift
+	}
+
+	var existing int64
+	ks.db.Model(&models.Kudos{}).
+		Where("sender_id = ? AND recipient_id = ? AND shift_id = ?", senderID, recipientID, shiftID).
+		Count(&existing)
+	if existing > 0 {
+		return nil, ErrKudosDuplicate
+	}
+
+	var sentToday int64
+	ks.db.Model(&models.Kudos{}).
+		Where("sender_id = ? AND created_at >= ?", senderID, time.Now().Add(-24*time.Hour)).
+		Count(&sentToday)
+	if sentToday >= KudosDailyLimit {
+		return nil, ErrKudosDailyLimit
+	}
+
+	kudos := models.Kudos{
+		SenderID:    senderID,
+		RecipientID: recipientID,
+		ShiftID:     shiftID,
+		Category:    category,
+		Message:     message,
+		Status:      models.KudosStatusPending,
+	}
+	if err := ks.db.Create(&kudos).Error; err != nil {
+		return nil, fmt.Errorf("failed to save kudos: %w", err)
+	}
+
+	return &kudos, nil
+}
+
+// ModerateKudos approves or rejects a pending or hidden kudos
+func (ks *KudosService) ModerateKudos(kudosID, moderatorID uint, approve bool, note string) (*models.Kudos, error) {
+	var kudos models.Kudos
+	if err := ks.db.First(&kudos, kudosID).Error; err != nil {
+		return nil, err
+	}
+	if kudos.Status != models.KudosStatusPending && kudos.Status != models.KudosStatusHidden {
+		return nil, ErrKudosAlreadyHandled
+	}
+
+	now := time.Now()
+	kudos.Status = models.KudosStatusRejected
+	if approve {
+		kudos.Status = models.KudosStatusApproved
+	}
+	kudos.ModeratedBy = &moderatorID
+	kudos.ModeratedAt = &now
+	kudos.ModerationNote = note
+
+	if err := ks.db.Save(&kudos).Error; err != nil {
+		return nil, err
+	}
+	return &kudos, nil
+}
+
+// ReportKudos records an abuse report. Kudos reported repeatedly are hidden
+// until a coordinator reviews them.
+func (ks *KudosService) ReportKudos(kudosID, reporterID uint, reason string) (*models.KudosReport, error) {
+	var report models.KudosReport
+
+	err := ks.db.Transaction(func(tx *gorm.DB) error {
+		var kudos models.Kudos
+		if err := tx.First(&kudos, kudosID).Error; err != nil {
+			return err
+		}
+
+		var existing int64
+		tx.Model(&models.KudosReport{}).
+			Where("kudos_id = ? AND reporter_id = ?", kudosID, reporterID).
+			Count(&existing)
+		if existing > 0 {
+			return ErrKudosAlreadyFlagged
+		}
+
+		report = models.KudosReport{
+			KudosID:    kudosID,
+			ReporterID: reporterID,
+			Reason:     strings.TrimSpace(reason),
+			Status:     models.KudosReportStatusOpen,
+		}
+		if err := tx.Create(&report).Error; err != nil {
+			return err
+		}
+
+		countKudosReport(&kudos)
+		return tx.Save(&kudos).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// ResolveReport upholds or dismisses an abuse report. Upholding a report rejects the kudos.
+func (ks *KudosService) ResolveReport(reportID, moderatorID uint, uphold bool) (*models.KudosReport, error) {
+	var report models.KudosReport
+
+	err := ks.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&report, reportID).Error; err != nil {
+			return err
+		}
+		if report.Status != models.KudosReportStatusOpen {
+			return errors.New("report has already been resolved")
+		}
+
+		now := time.Now()
+		report.Status = models.KudosReportStatusDismissed
+		if uphold {
+			report.Status = models.KudosReportStatusUpheld
+		}
+		report.ResolvedBy = &moderatorID
+		report.ResolvedAt = &now
+		if err := tx.Save(&report).Error; err != nil {
+			return err
+		}
+
+		if uphold {
+			return tx.Model(&models.Kudos{}).Where("id = ?", report.KudosID).Updates(map[string]interface{}{
+				"status":          models.KudosStatusRejected,
+				"moderated_by":    moderatorID,
+				"moderated_at":    now,
+				"moderation_note": "Removed after abuse report",
+			}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// VolunteerOfTheMonth ranks volunteers by the approved kudos they received in the given month
+func (ks *KudosService) VolunteerOfTheMonth(month time.Time, limit int) ([]VolunteerOfTheMonthEntry, error) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	end := start.AddDate(0, 1, 0)
+
+	entries := []VolunteerOfTheMonthEntry{}
+	err := ks.db.Model(&models.Kudos{}).
+		Select(`volunteer_kudos.recipient_id AS user_id, users.first_name, users.last_name,
+			COUNT(*) AS kudos_count,
+			COUNT(DISTINCT volunteer_kudos.sender_id) AS unique_senders,
+			COUNT(DISTINCT volunteer_kudos.shift_id) AS shift_count`).
+		Joins("JOIN users ON users.id = volunteer_kudos.recipient_id").
+		Where("volunteer_kudos.status = ? AND volunteer_kudos.created_at >= ? AND volunteer_kudos.created_at < ?",
+			models.KudosStatusApproved, start, end).
+		Group("volunteer_kudos.recipient_id, users.first_name, users.last_name").
+		Order("unique_senders DESC, kudos_count DESC").
+		Limit(limit).
+		Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// workedShift returns true if the user was assigned to the shift and did not cancel
+func (ks *KudosService) workedShift(userID, shiftID uint) bool {
+	var count int64
+	ks.db.Model(&models.ShiftAssignment{}).
+		Where("shift_id = ? AND user_id = ? AND status NOT IN ?", shiftID, userID, []string{"Cancelled", "NoShow"}).
+		Count(&count)
+	if count > 0 {
+		return true
+	}
+
+	ks.db.Model(&models.Shift{}).
+		Where("id = ? AND assigned_volunteer_id = ?", shiftID, userID).
+		Count(&count)
+	return count > 0
+}
+
+// cleanKudosMessage trims a kudos message and checks it is present and not too long
+func cleanKudosMessage(message string) (string, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return "", errors.New("message is required")
+	}
+	if utf8.RuneCountInString(message) > KudosMaxMessageLength {
+		return "", fmt.Errorf("message must be at most %d characters", KudosMaxMessageLength)
+	}
+	return message, nil
+}
+
+// countKudosReport records another abuse report against a kudos, hiding it for
+// review once it has been reported KudosAutoHideReportCount times
+func countKudosReport(kudos *models.Kudos) {
+	kudos.ReportCount++
+	if kudos.ReportCount >= KudosAutoHideReportCount && kudos.Status == models.KudosStatusApproved {
+		kudos.Status = models.KudosStatusHidden
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestCleanKudosMessage(t *testing.T) {
+	if got, err := cleanKudosMessage("  Thanks for covering the till!  "); err != nil || got != "Thanks for covering the till!" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if _, err := cleanKudosMessage(" \n "); err == nil {
+		t.Error("blank message accepted")
+	}
+
+	// The limit is in characters, so accented and emoji messages get the same room
+	if _, err := cleanKudosMessage(strings.Repeat("é", KudosMaxMessageLength)); err != nil {
+		t.Errorf("%d characters rejected: %v", KudosMaxMessageLength, err)
+	}
+	if _, err := cleanKudosMessage(strings.Repeat("a", KudosMaxMessageLength+1)); err == nil {
+		t.Error("overlong message accepted")
+	}
+}
+
+func TestCountKudosReport(t *testing.T) {
+	kudos := models.Kudos{Status: models.KudosStatusApproved}
+	for i := 1; i < KudosAutoHideReportCount; i++ {
+		countKudosReport(&kudos)
+		if kudos.Status != models.KudosStatusApproved {
+			t.Fatalf("hidden after %d reports", i)
+		}
+	}
+	countKudosReport(&kudos)
+	if kudos.ReportCount != KudosAutoHideReportCount || kudos.Status != models.KudosStatusHidden {
+		t.Errorf("after %d reports: got %d, %s", KudosAutoHideReportCount, kudos.ReportCount, kudos.Status)
+	}
+
+	// Kudos still awaiting moderation or already rejected keep their status
+	for _, status := range []string{models.KudosStatusPending, models.KudosStatusRejected} {
+		kudos := models.Kudos{Status: status, ReportCount: KudosAutoHideReportCount}
+		countKudosReport(&kudos)
+		if kudos.Status != status {
+			t.Errorf("%s kudos became %s", status, kudos.Status)
+		}
+	}
+}
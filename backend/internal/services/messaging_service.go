@@ -56,6 +56,11 @@ func (s *MessagingService) SendMessage(senderID, recipientID uint, content, mess
 	// Send real-time notification to recipient
 	go s.sendRealTimeNotification(message)
 
+	// During a closure messages to staff get an automatic reply and are queued
+	if err := NewServiceClosureService().MessageReceived(message, time.Now()); err != nil {
+		log.Printf("Failed to queue message %d during a service closure: %v", message.ID, err)
+	}
+
 	return message, nil
 }
 
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/websocket"
+
+	"gorm.io/gorm"
+)
+
+// NotificationCenterCategory is the WebSocket category of notification center connections
+const NotificationCenterCategory = "notifications"
+
+// Notification center WebSocket event types
+const (
+	NotificationEventCreated = "notification"
+	NotificationEventRead    = "notification_read"
+	NotificationEventSync    = "notification_sync"
+)
+
+// NotificationCenterEvent is pushed to each of a user's open notification connections
+type NotificationCenterEvent struct {
+	Type         string                    `json:"type"`
+	Notification *models.InAppNotification `json:"notification,omitempty"`
+	IDs          []uint                    `json:"ids,omitempty"` // Notifications marked read
+	All          bool                      `json:"all,omitempty"` // Every notification was marked read
+	UnreadIDs    []uint                    `json:"unread_ids,omitempty"`
+	UnreadCount  int64                     `json:"unread_count"`
+	Timestamp    time.Time                 `json:"timestamp"`
+}
+
+// NotificationCenterService keeps in-app notifications and their read state in sync
+// across a user's devices
+type NotificationCenterService struct {
+	db *gorm.DB
+}
+
+// NewNotificationCenterService creates a new notification center service
+func NewNotificationCenterService() *NotificationCenterService {
+	return &NotificationCenterService{db: db.DB}
+}
+
+// registerHandlers is run once, when the first device connects
+var registerHandlers sync.Once
+
+func init() {
+	// Push every saved notification, wherever it was created
+	models.InAppNotificationCreated = func(notification *models.InAppNotification) {
+		go NewNotificationCenterService().publishCreated(*notification)
+	}
+}
+
+// registerMessageHandlers lets devices mark notifications read over the socket as well
+// as through the API
+func registerMessageHandlers() {
+	manager := websocket.GetGlobalManager()
+	manager.RegisterMessageHandler("mark_read", func(conn *websocket.ManagedConnection, msg map[string]interface{}) {
+		if _, err := NewNotificationCenterService().MarkRead(conn.UserID, markReadIDs(msg)); err != nil {
+			log.Printf("Failed to mark notifications read for user %d: %v", conn.UserID, err)
+		}
+	})
+	manager.RegisterMessageHandler("mark_all_read", func(conn *websocket.ManagedConnection, msg map[string]interface{}) {
+		if _, err := NewNotificationCenterService().MarkAllRead(conn.UserID); err != nil {
+			log.Printf("Failed to mark all notifications read for user %d: %v", conn.UserID, err)
+		}
+	})
+}
+
+// markReadIDs returns the notification IDs in a mark_read message, skipping anything
+// that is not a positive whole number
+func markReadIDs(msg map[string]interface{}) []uint {
+	var ids []uint
+	raw, _ := msg["ids"].([]interface{})
+	for _, value := range raw {
+		if id, ok := value.(float64); ok && id > 0 && id == float64(uint(id)) {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// unread scopes a query to a user's unread notifications that have not expired
+func (ns *NotificationCenterService) unread(userID uint) *gorm.DB {
+	return ns.db.Model(&models.InAppNotification{}).
+		Where("user_id = ? AND is_read = ? AND (expires_at IS NULL OR expires_at > ?)", userID, false, time.Now())
+}
+
+// UnreadCount returns the badge count for a user
+func (ns *NotificationCenterService) UnreadCount(userID uint) (int64, error) {
+	var count int64
+	err := ns.unread(userID).Count(&count).Error
+	return count, err
+}
+
+// UnreadIDs returns the IDs of a user's unread notifications, newest first
+func (ns *NotificationCenterService) UnreadIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	err := ns.unread(userID).Order("created_at DESC").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// MarkRead marks some of a user's notifications read and tells their other devices
+func (ns *NotificationCenterService) MarkRead(userID uint, ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	result := ns.db.Model(&models.InAppNotification{}).
+		Where("user_id = ? AND id IN ? AND is_read = ?", userID, ids, false).
+		Updates(map[string]interface{}{"is_read": true, "read": true, "read_at": now})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		ns.publish(userID, NotificationCenterEvent{Type: NotificationEventRead, IDs: ids})
+	}
+	return result.RowsAffected, nil
+}
+
+// MarkAllRead marks all of a user's notifications read and tells their other devices
+func (ns *NotificationCenterService) MarkAllRead(userID uint) (int64, error) {
+	now := time.Now()
+	result := ns.db.Model(&models.InAppNotification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{"is_read": true, "read": true, "read_at": now})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		ns.publish(userID, NotificationCenterEvent{Type: NotificationEventRead, All: true})
+	}
+	return result.RowsAffected, nil
+}
+
+// Sync sends a newly connected device the current unread state
+func (ns *NotificationCenterService) Sync(userID uint) error {
+	registerHandlers.Do(registerMessageHandlers)
+
+	ids, err := ns.UnreadIDs(userID)
+	if err != nil {
+		return err
+	}
+	return ns.send(userID, NotificationCenterEvent{
+		Type:        NotificationEventSync,
+		UnreadIDs:   ids,
+		UnreadCount: int64(len(ids)),
+		Timestamp:   time.Now(),
+	})
+}
+
+// publishCreated pushes a new notification with the updated badge count
+func (ns *NotificationCenterService) publishCreated(notification models.InAppNotification) {
+	ns.publish(notification.UserID, NotificationCenterEvent{
+		Type:         NotificationEventCreated,
+		Notification: &notification,
+	})
+}
+
+// publish fills in the badge count and sends an event to the user's devices
+func (ns *NotificationCenterService) publish(userID uint, event NotificationCenterEvent) {
+	count, err := ns.UnreadCount(userID)
+	if err != nil {
+		log.Printf("Failed to count unread notifications for user %d: %v", userID, err)
+		return
+	}
+	event.UnreadCount = count
+	event.Timestamp = time.Now()
+
+	// Users without an open connection pick the state up when they next connect
+	_ = ns.send(userID, event)
+}
+
+// send delivers an event to the user's notification connections
+func (ns *NotificationCenterService) send(userID uint, event NotificationCenterEvent) error {
+	return websocket.GetGlobalManager().BroadcastToUserCategory(userID, NotificationCenterCategory, event)
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMarkReadIDs(t *testing.T) {
+	var msg map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"type":"mark_read","ids":[4,"5",0,-2,7.5,9]}`), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := markReadIDs(msg), []uint{4, 9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := markReadIDs(map[string]interface{}{"ids": "all"}); got != nil {
+		t.Errorf("ids not a list: got %v", got)
+	}
+}
+
+func TestMarkReadNothing(t *testing.T) {
+	ns := &NotificationCenterService{}
+	if count, err := ns.MarkRead(3, nil); count != 0 || err != nil {
+		t.Errorf("got %d, %v", count, err)
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+)
+
+// defaultDigestHour is the hour of day digests go out unless NOTIFICATION_DIGEST_HOUR
+// says otherwise. Weekly digests go out on Mondays.
+const defaultDigestHour = 8
+
+// NotificationDigestService batches non-urgent emails for users who chose a daily or
+// weekly digest and sends each user one email when their digest is due
+type NotificationDigestService struct {
+	db *gorm.DB
+}
+
+// NewNotificationDigestService creates a new notification digest service
+func NewNotificationDigestService() *NotificationDigestService {
+	return &NotificationDigestService{db: db.DB}
+}
+
+// urgentNotification reports whether a notification is too important to wait for a
+// digest
+func urgentNotification(priority string) bool {
+	switch strings.ToLower(priority) {
+	case "high", "urgent", "critical":
+		return true
+	}
+	return false
+}
+
+// holdForDigest keeps an email back for the user's next digest
+func holdForDigest(data RealtimeNotificationData, kind, frequency string) error {
+	item := models.NotificationDigestItem{
+		UserID:    data.UserID,
+		Category:  models.NotificationCategoryFor(kind),
+		Frequency: frequency,
+		Type:      data.Type,
+		Title:     data.Title,
+		Message:   data.Message,
+		ActionURL: data.ActionURL,
+	}
+	return db.DB.Create(&item).Error
+}
+
+// digestHour returns the configured hour of day digests are sent
+func digestHour() int {
+	if hour, err := strconv.Atoi(os.Getenv("NOTIFICATION_DIGEST_HOUR")); err == nil && hour >= 0 && hour < 24 {
+		return hour
+	}
+	return defaultDigestHour
+}
+
+// digestCutoff returns the most recent send time for a frequency at or before now:
+// today's send hour for daily digests, Monday's for weekly. Items held before the
+// cutoff are due.
+func digestCutoff(frequency string, now time.Time, hour int) time.Time {
+	cutoff := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if cutoff.After(now) {
+		cutoff = cutoff.AddDate(0, 0, -1)
+	}
+	if frequency == models.DigestWeekly {
+		daysSinceMonday := (int(cutoff.Weekday()) + 6) % 7
+		cutoff = cutoff.AddDate(0, 0, -daysSinceMonday)
+	}
+	return cutoff
+}
+
+// SendDue emails every user whose digest is due and returns how many digests went out
+func (ds *NotificationDigestService) SendDue(now time.Time) (int, error) {
+	hour := digestHour()
+	sent := 0
+	for _, frequency := range []string{models.DigestDaily, models.DigestWeekly} {
+		cutoff := digestCutoff(frequency, now, hour)
+
+		var items []models.NotificationDigestItem
+		if err := ds.db.Where("frequency = ? AND sent_at IS NULL AND created_at < ?", frequency, cutoff).
+			Order("user_id, created_at").Find(&items).Error; err != nil {
+			return sent, fmt.Errorf("failed to load digest items: %w", err)
+		}
+
+		byUser := make(map[uint][]models.NotificationDigestItem)
+		userIDs := []uint{}
+		for _, item := range items {
+			if _, seen := byUser[item.UserID]; !seen {
+				userIDs = append(userIDs, item.UserID)
+			}
+			byUser[item.UserID] = append(byUser[item.UserID], item)
+		}
+
+		for _, userID := range userIDs {
+			if err := ds.sendDigest(userID, frequency, byUser[userID], now); err != nil {
+				log.Printf("Failed to send %s digest to user %d: %v", frequency, userID, err)
+				continue
+			}
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// sendDigest emails one user their held items and marks them sent
+func (ds *NotificationDigestService) sendDigest(userID uint, frequency string, items []models.NotificationDigestItem, now time.Time) error {
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+
+	var user models.User
+	if err := ds.db.First(&user, userID).Error; err != nil {
+		// The account has gone; drop its items rather than retrying forever
+		return ds.db.Model(&models.NotificationDigestItem{}).Where("id IN ?", ids).Update("sent_at", now).Error
+	}
+
+	subject, body := digestEmail(user.FirstName, frequency, items)
+	if err := notifications.GetService().SendEmail(user.Email, subject, body); err != nil {
+		return err
+	}
+	return ds.db.Model(&models.NotificationDigestItem{}).Where("id IN ?", ids).Update("sent_at", now).Error
+}
+
+// digestEmail builds the subject and plain-text body of a digest, grouped by category
+func digestEmail(firstName, frequency string, items []models.NotificationDigestItem) (string, string) {
+	period := "today"
+	if frequency == models.DigestWeekly {
+		period = "this week"
+	}
+	subject := fmt.Sprintf("Your %s summary: %d update", frequency, len(items))
+	if len(items) != 1 {
+		subject += "s"
+	}
+
+	byCategory := make(map[string][]models.NotificationDigestItem)
+	for _, item := range items {
+		byCategory[item.Category] = append(byCategory[item.Category], item)
+	}
+	categories := make([]string, 0, len(byCategory))
+	for category := range byCategory {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hello %s,\n\nHere is what happened %s.\n", firstName, period)
+	for _, category := range categories {
+		fmt.Fprintf(&body, "\n%s\n", strings.ToUpper(strings.ReplaceAll(category, "_", " ")))
+		for _, item := range byCategory[category] {
+			fmt.Fprintf(&body, "- %s: %s\n", item.Title, item.Message)
+			if item.ActionURL != "" {
+				fmt.Fprintf(&body, "  %s\n", item.ActionURL)
+			}
+		}
+	}
+	body.WriteString("\nYou can change how often you get these summaries in your notification preferences.\n")
+	return subject, body.String()
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestDigestCutoff(t *testing.T) {
+	// Wednesday 15 October 2025
+	wednesdayMorning := time.Date(2025, 10, 15, 7, 30, 0, 0, time.UTC)
+	wednesdayNoon := time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		frequency string
+		now       time.Time
+		want      time.Time
+	}{
+		{models.DigestDaily, wednesdayMorning, time.Date(2025, 10, 14, 8, 0, 0, 0, time.UTC)},
+		{models.DigestDaily, wednesdayNoon, time.Date(2025, 10, 15, 8, 0, 0, 0, time.UTC)},
+		{models.DigestWeekly, wednesdayNoon, time.Date(2025, 10, 13, 8, 0, 0, 0, time.UTC)},
+		{models.DigestWeekly, time.Date(2025, 10, 13, 7, 0, 0, 0, time.UTC), time.Date(2025, 10, 6, 8, 0, 0, 0, time.UTC)},
+		{models.DigestWeekly, time.Date(2025, 10, 13, 9, 0, 0, 0, time.UTC), time.Date(2025, 10, 13, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := digestCutoff(tt.frequency, tt.now, 8); !got.Equal(tt.want) {
+			t.Errorf("%s at %s: got %s, want %s", tt.frequency, tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestDigestEmail(t *testing.T) {
+	items := []models.NotificationDigestItem{
+		{Category: models.NotificationCategoryShifts, Title: "Shift confirmed", Message: "See you on Tuesday"},
+		{Category: models.NotificationCategoryDonations, Title: "Receipt ready", Message: "Your receipt is ready", ActionURL: "/donor/receipts"},
+	}
+
+	subject, body := digestEmail("Sam", models.DigestWeekly, items)
+	if subject != "Your weekly summary: 2 updates" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	donations, shifts := strings.Index(body, "DONATIONS"), strings.Index(body, "SHIFTS")
+	if donations < 0 || shifts < 0 || donations > shifts {
+		t.Errorf("categories should be listed in order:\n%s", body)
+	}
+	if !strings.Contains(body, "/donor/receipts") || !strings.Contains(body, "this week") {
+		t.Errorf("digest body missing details:\n%s", body)
+	}
+}
+
+func TestUrgentNotification(t *testing.T) {
+	for priority, want := range map[string]bool{"high": true, "Critical": true, "normal": false, "": false} {
+		if got := urgentNotification(priority); got != want {
+			t.Errorf("%q: got %v, want %v", priority, got, want)
+		}
+	}
+}
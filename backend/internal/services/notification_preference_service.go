@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidNotificationPreference = errors.New("invalid notification preference")
+
+// NotificationPreferenceUpdate changes some channels for one category. Channels left
+// nil keep their current setting.
+type NotificationPreferenceUpdate struct {
+	Category string  `json:"category" binding:"required"`
+	Email    *bool   `json:"email"`
+	SMS      *bool   `json:"sms"`
+	Push     *bool   `json:"push"`
+	InApp    *bool   `json:"in_app"`
+	Digest   *string `json:"digest"` // immediate, daily or weekly
+}
+
+// NotificationPreferenceService stores which channels each user accepts per
+// notification category
+type NotificationPreferenceService struct {
+	db *gorm.DB
+}
+
+// NewNotificationPreferenceService creates a new notification preference service
+func NewNotificationPreferenceService() *NotificationPreferenceService {
+	return &NotificationPreferenceService{db: db.DB}
+}
+
+// Preferences returns a user's preference for every category, using the defaults for
+// categories they have not set
+func (ps *NotificationPreferenceService) Preferences(userID uint) ([]models.NotificationPreference, error) {
+	var saved []models.NotificationPreference
+	if err := ps.db.Where("user_id = ?", userID).Find(&saved).Error; err != nil {
+		return nil, err
+	}
+	return mergePreferences(userID, saved), nil
+}
+
+// mergePreferences fills in defaults for categories without a saved preference, in
+// display order
+func mergePreferences(userID uint, saved []models.NotificationPreference) []models.NotificationPreference {
+	byCategory := make(map[string]models.NotificationPreference, len(saved))
+	for _, preference := range saved {
+		byCategory[preference.Category] = preference
+	}
+
+	preferences := make([]models.NotificationPreference, 0, len(models.NotificationCategories))
+	for _, category := range models.NotificationCategories {
+		preference, ok := byCategory[category]
+		if !ok {
+			preference = models.DefaultNotificationPreference(userID, category)
+		}
+		preferences = append(preferences, preference)
+	}
+	return preferences
+}
+
+// applyPreferenceUpdate sets the channels an update names
+func applyPreferenceUpdate(preference *models.NotificationPreference, update NotificationPreferenceUpdate) {
+	if update.Email != nil {
+		preference.Email = *update.Email
+	}
+	if update.SMS != nil {
+		preference.SMS = *update.SMS
+	}
+	if update.Push != nil {
+		preference.Push = *update.Push
+	}
+	if update.InApp != nil {
+		preference.InApp = *update.InApp
+	}
+	if update.Digest != nil {
+		preference.Digest = *update.Digest
+	}
+}
+
+// Update saves channel changes for one or more categories and returns the user's
+// preferences for every category
+func (ps *NotificationPreferenceService) Update(userID uint, updates []NotificationPreferenceUpdate) ([]models.NotificationPreference, error) {
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("%w: no categories given", ErrInvalidNotificationPreference)
+	}
+	for _, update := range updates {
+		if !models.IsNotificationCategory(update.Category) {
+			return nil, fmt.Errorf("%w: unknown category %q", ErrInvalidNotificationPreference, update.Category)
+		}
+		if update.Digest != nil && !models.IsDigestFrequency(*update.Digest) {
+			return nil, fmt.Errorf("%w: digest must be immediate, daily or weekly", ErrInvalidNotificationPreference)
+		}
+	}
+
+	current, err := ps.Preferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	byCategory := make(map[string]*models.NotificationPreference, len(current))
+	for i := range current {
+		byCategory[current[i].Category] = &current[i]
+	}
+
+	err = ps.db.Transaction(func(tx *gorm.DB) error {
+		for _, update := range updates {
+			preference := byCategory[update.Category]
+			applyPreferenceUpdate(preference, update)
+			if preference.ID != 0 {
+				if err := tx.Save(preference).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			// Upsert so two first saves of the same category do not collide
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}},
+				DoUpdates: clause.AssignmentColumns([]string{"email", "sms", "push", "in_app", "digest", "updated_at"}),
+			}).Create(preference).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// Reset removes a user's choices so every category goes back to its default
+func (ps *NotificationPreferenceService) Reset(userID uint) error {
+	return ps.db.Where("user_id = ?", userID).Delete(&models.NotificationPreference{}).Error
+}
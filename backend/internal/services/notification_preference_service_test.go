@@ -0,0 +1,44 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestMergePreferences(t *testing.T) {
+	saved := []models.NotificationPreference{
+		{ID: 4, UserID: 7, Category: models.NotificationCategoryMarketing, Email: true},
+	}
+
+	preferences := mergePreferences(7, saved)
+	if len(preferences) != len(models.NotificationCategories) {
+		t.Fatalf("got %d preferences, want one per category", len(preferences))
+	}
+	if preferences[0].Category != models.NotificationCategoryHelpRequests || !preferences[0].SMS {
+		t.Errorf("help requests should default to every channel, got %+v", preferences[0])
+	}
+	marketing := preferences[3]
+	if marketing.ID != 4 || !marketing.Email || marketing.SMS {
+		t.Errorf("saved marketing preference not kept: %+v", marketing)
+	}
+}
+
+func TestApplyPreferenceUpdate(t *testing.T) {
+	off, on := false, true
+	preference := models.DefaultNotificationPreference(7, models.NotificationCategoryShifts)
+
+	applyPreferenceUpdate(&preference, NotificationPreferenceUpdate{SMS: &off, InApp: &on})
+	if preference.SMS || !preference.Email || !preference.Push || !preference.InApp {
+		t.Errorf("only SMS should be turned off, got %+v", preference)
+	}
+	if preference.Digested() {
+		t.Errorf("emails should be sent straight away by default, got %q", preference.Digest)
+	}
+
+	weekly := models.DigestWeekly
+	applyPreferenceUpdate(&preference, NotificationPreferenceUpdate{Digest: &weekly})
+	if !preference.Digested() || preference.Digest != models.DigestWeekly || !preference.Email {
+		t.Errorf("digest should be weekly with channels kept, got %+v", preference)
+	}
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+)
+
+// Transactional template errors
+var (
+	ErrTemplateNotFound  = errors.New("template not found")
+	ErrTemplateNotEdited = errors.New("template has not been edited")
+	ErrInvalidTemplate   = errors.New("invalid template")
+)
+
+// TransactionalTemplate is a transactional email template as it is sent: the admin's
+// edit where there is one, otherwise the built-in default
+type TransactionalTemplate struct {
+	TemplateType     string     `json:"template_type"`
+	Edited           bool       `json:"edited"`
+	Subject          string     `json:"subject"` // Blank keeps the subject the sender sets
+	HTMLBody         string     `json:"html_body"`
+	TextBody         string     `json:"text_body"`
+	Variables        []string   `json:"variables"`
+	DefaultVariables []string   `json:"default_variables"`
+	UnknownVariables []string   `json:"unknown_variables,omitempty"` // Used but never set by the default
+	UpdatedBy        *uint      `json:"updated_by,omitempty"`
+	UpdatedAt        *time.Time `json:"updated_at,omitempty"`
+}
+
+// TransactionalTemplateInput edits a transactional template
+type TransactionalTemplateInput struct {
+	Subject  string `json:"subject"`
+	HTMLBody string `json:"html_body" binding:"required"`
+	TextBody string `json:"text_body"`
+}
+
+// NotificationTemplateService lets admins edit the transactional email templates,
+// such as volunteer_approval, and preview them with sample data
+type NotificationTemplateService struct {
+	db *gorm.DB
+}
+
+// NewNotificationTemplateService creates a new notification template service
+func NewNotificationTemplateService() *NotificationTemplateService {
+	return &NotificationTemplateService{db: db.DB}
+}
+
+// List returns every transactional template
+func (ts *NotificationTemplateService) List() ([]TransactionalTemplate, error) {
+	var edits []models.NotificationTemplate
+	if err := ts.db.Where("template_type <> ''").Find(&edits).Error; err != nil {
+		return nil, err
+	}
+	byType := make(map[string]*models.NotificationTemplate, len(edits))
+	for i := range edits {
+		byType[edits[i].TemplateType] = &edits[i]
+	}
+
+	templates := []TransactionalTemplate{}
+	for _, templateType := range notifications.TranslatableTemplates() {
+		templates = append(templates, transactionalTemplate(templateType.String(), byType[templateType.String()]))
+	}
+	return templates, nil
+}
+
+// Get returns one transactional template
+func (ts *NotificationTemplateService) Get(templateType string) (*TransactionalTemplate, error) {
+	if !isTranslatableTemplate(templateType) {
+		return nil, ErrTemplateNotFound
+	}
+	edit, err := ts.edit(templateType)
+	if err != nil {
+		return nil, err
+	}
+	view := transactionalTemplate(templateType, edit)
+	return &view, nil
+}
+
+// Save replaces a transactional template's subject and bodies with an admin's edit
+func (ts *NotificationTemplateService) Save(templateType string, input TransactionalTemplateInput, updatedBy uint) (*TransactionalTemplate, error) {
+	if !isTranslatableTemplate(templateType) {
+		return nil, ErrTemplateNotFound
+	}
+	if err := validateTemplateInput(&input); err != nil {
+		return nil, err
+	}
+
+	edit, err := ts.edit(templateType)
+	if err != nil {
+		return nil, err
+	}
+	if edit == nil {
+		edit = &models.NotificationTemplate{
+			ID:           models.TransactionalTemplateID(templateType),
+			Name:         strings.ReplaceAll(templateType, "_", " "),
+			Type:         string(notifications.EmailNotification),
+			Category:     "system",
+			TemplateType: templateType,
+		}
+	}
+	edit.Subject = input.Subject
+	edit.Body = input.HTMLBody
+	edit.TextBody = input.TextBody
+	edit.Variables = strings.Join(notifications.TemplateVariables(input.Subject, input.HTMLBody, input.TextBody), ",")
+	edit.UpdatedBy = &updatedBy
+	if err := ts.db.Save(edit).Error; err != nil {
+		return nil, err
+	}
+
+	view := transactionalTemplate(templateType, edit)
+	return &view, nil
+}
+
+// Reset removes an admin's edit, so the built-in default is sent again
+func (ts *NotificationTemplateService) Reset(templateType string) error {
+	if !isTranslatableTemplate(templateType) {
+		return ErrTemplateNotFound
+	}
+	result := ts.db.Where("template_type = ?", templateType).Delete(&models.NotificationTemplate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTemplateNotEdited
+	}
+	return nil
+}
+
+// Preview renders a template with sample data. A draft previews unsaved changes;
+// without one the template as currently sent is rendered. values replace samples.
+func (ts *NotificationTemplateService) Preview(templateType string, draft *TransactionalTemplateInput, values map[string]interface{}) (*notifications.TemplatePreview, error) {
+	current, err := ts.Get(templateType)
+	if err != nil {
+		return nil, err
+	}
+	input := TransactionalTemplateInput{Subject: current.Subject, HTMLBody: current.HTMLBody, TextBody: current.TextBody}
+	if draft != nil {
+		input = *draft
+		if err := validateTemplateInput(&input); err != nil {
+			return nil, err
+		}
+	}
+
+	data := notifications.SampleTemplateData(values, input.Subject, input.HTMLBody, input.TextBody)
+	preview, err := notifications.PreviewTemplate(input.Subject, input.HTMLBody, input.TextBody, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return preview, nil
+}
+
+// edit loads the admin's edit of a template, or nil when the default is used
+func (ts *NotificationTemplateService) edit(templateType string) (*models.NotificationTemplate, error) {
+	var edit models.NotificationTemplate
+	if err := ts.db.Where("template_type = ?", templateType).First(&edit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &edit, nil
+}
+
+// validateTemplateInput tidies an edit and checks each part parses
+func validateTemplateInput(input *TransactionalTemplateInput) error {
+	input.Subject = strings.TrimSpace(input.Subject)
+	input.TextBody = strings.TrimSpace(input.TextBody)
+	if strings.TrimSpace(input.HTMLBody) == "" {
+		return fmt.Errorf("%w: html_body is required", ErrInvalidTemplate)
+	}
+	parts := []struct{ name, source string }{
+		{"subject", input.Subject}, {"html_body", input.HTMLBody}, {"text_body", input.TextBody},
+	}
+	for _, part := range parts {
+		if _, err := template.New(part.name).Parse(part.source); err != nil {
+			return fmt.Errorf("%w: %s does not parse: %v", ErrInvalidTemplate, part.name, err)
+		}
+	}
+	return nil
+}
+
+// transactionalTemplate builds the view of a template from its edit, if any, and
+// its built-in default
+func transactionalTemplate(templateType string, edit *models.NotificationTemplate) TransactionalTemplate {
+	defaultBody, _ := notifications.DefaultTemplate(notifications.TemplateType(templateType))
+	view := TransactionalTemplate{
+		TemplateType:     templateType,
+		HTMLBody:         defaultBody,
+		DefaultVariables: notifications.TemplateVariables(defaultBody),
+	}
+	view.Variables = view.DefaultVariables
+	if edit == nil {
+		return view
+	}
+
+	view.Edited = true
+	view.Subject = edit.Subject
+	view.HTMLBody = edit.Body
+	view.TextBody = edit.TextBody
+	view.Variables = notifications.TemplateVariables(edit.Subject, edit.Body, edit.TextBody)
+	view.UnknownVariables = unknownTemplateVariables(view.Variables, view.DefaultVariables)
+	view.UpdatedBy = edit.UpdatedBy
+	updatedAt := edit.UpdatedAt
+	view.UpdatedAt = &updatedAt
+	return view
+}
+
+// unknownTemplateVariables lists variables an edit uses that the sender is not known
+// to set. Branding variables are always set.
+func unknownTemplateVariables(used, known []string) []string {
+	set := map[string]bool{
+		"OrganizationName": true, "CharityNumber": true, "BrandPrimaryColor": true,
+		"BrandAccentColor": true, "BrandLogoURL": true,
+	}
+	for _, variable := range known {
+		set[variable] = true
+	}
+	unknown := []string{}
+	for _, variable := range used {
+		if !set[variable] {
+			unknown = append(unknown, variable)
+		}
+	}
+	return unknown
+}
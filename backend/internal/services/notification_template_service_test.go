@@ -0,0 +1,47 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestValidateTemplateInput(t *testing.T) {
+	input := TransactionalTemplateInput{Subject: "  Welcome {{.Name}} ", HTMLBody: "<p>Hi {{.Name}}</p>"}
+	if err := validateTemplateInput(&input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if input.Subject != "Welcome {{.Name}}" {
+		t.Errorf("subject not trimmed: %q", input.Subject)
+	}
+
+	invalid := []TransactionalTemplateInput{
+		{HTMLBody: "  "},
+		{HTMLBody: "<p>{{.Name</p>"},
+		{HTMLBody: "<p>Hi</p>", TextBody: "{{if .Name}}no end"},
+	}
+	for _, input := range invalid {
+		if err := validateTemplateInput(&input); !errors.Is(err, ErrInvalidTemplate) {
+			t.Errorf("%+v: got %v, want ErrInvalidTemplate", input, err)
+		}
+	}
+}
+
+func TestTransactionalTemplate(t *testing.T) {
+	builtIn := transactionalTemplate("volunteer_approval", nil)
+	if builtIn.Edited || builtIn.HTMLBody == "" || !reflect.DeepEqual(builtIn.Variables, builtIn.DefaultVariables) {
+		t.Errorf("template without an edit should show the default: %+v", builtIn)
+	}
+
+	edit := &models.NotificationTemplate{TemplateType: "volunteer_approval", Subject: "Welcome {{.Name}}",
+		Body: "<p>{{.Name}} from {{.OrganizationName}}, see {{.Mystery}}</p>"}
+	view := transactionalTemplate("volunteer_approval", edit)
+	if !view.Edited || view.Subject != "Welcome {{.Name}}" || len(view.DefaultVariables) == 0 {
+		t.Errorf("unexpected view: %+v", view)
+	}
+	if !reflect.DeepEqual(view.UnknownVariables, []string{"Mystery"}) {
+		t.Errorf("unknown variables: got %v", view.UnknownVariables)
+	}
+}
@@ -0,0 +1,520 @@
+package services
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/httpclient"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	oidcLoginValidity = 10 * time.Minute
+	oidcHTTPTimeout   = 10 * time.Second
+	oidcMetadataTTL   = time.Hour
+)
+
+var (
+	ErrOIDCProviderNotFound = errors.New("sign-in provider not found or disabled")
+	ErrOIDCRedirectMissing  = errors.New("OIDC_REDIRECT_URL is not configured")
+	ErrOIDCStateInvalid     = errors.New("sign-in has expired or was already used, please try again")
+	ErrOIDCTokenInvalid     = errors.New("the provider's sign-in response could not be verified")
+	ErrOIDCEmailUnverified  = errors.New("your email address is not verified with the provider")
+	ErrOIDCNoAccount        = errors.New("no staff or volunteer account uses this email address")
+	ErrOIDCPasswordOnly     = errors.New("this account signs in with a password")
+)
+
+// oidcMetadata is the part of a provider's discovery document we use
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	fetchedAt             time.Time
+	keys                  map[string]*rsa.PublicKey
+}
+
+// oidcClaims are the ID token claims used to find or create the user
+type oidcClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     *bool  `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	GivenName         string `json:"given_name"`
+	FamilyName        string `json:"family_name"`
+	Name              string `json:"name"`
+	Nonce             string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// OIDCLoginResult is the outcome of a completed external sign-in
+type OIDCLoginResult struct {
+	User        *models.User
+	Linked      bool // An existing account was linked to the provider on this sign-in
+	Provisioned bool // A new staff account was created
+}
+
+// OIDCService signs staff and volunteers in with OpenID Connect providers. Existing
+// accounts are linked by verified email; new staff accounts are only created for the
+// provider's allowed domains. Visitors and donors keep using their password.
+type OIDCService struct {
+	db          *gorm.DB
+	client      *http.Client
+	redirectURL string
+}
+
+var (
+	oidcMetadataCache   = map[string]*oidcMetadata{}
+	oidcMetadataCacheMu sync.Mutex
+)
+
+// NewOIDCService creates a new OIDC service. OIDC_REDIRECT_URL is the frontend page
+// providers send the user back to, which posts the code and state to the callback.
+func NewOIDCService() *OIDCService {
+	return &OIDCService{
+		db:          db.DB,
+		client:      httpclient.New("oidc", httpclient.Options{Timeout: oidcHTTPTimeout}),
+		redirectURL: os.Getenv("OIDC_REDIRECT_URL"),
+	}
+}
+
+// EnabledProviders returns the providers shown on the sign-in page
+func (oidc *OIDCService) EnabledProviders() ([]models.AuthProvider, error) {
+	var providers []models.AuthProvider
+	err := oidc.db.Where("enabled = ?", true).Order("name ASC").Find(&providers).Error
+	return providers, err
+}
+
+// ValidateProvider checks a provider's settings and that its discovery document loads
+func (oidc *OIDCService) ValidateProvider(provider *models.AuthProvider) error {
+	provider.Key = strings.ToLower(strings.TrimSpace(provider.Key))
+	provider.IssuerURL = strings.TrimRight(strings.TrimSpace(provider.IssuerURL), "/")
+	if provider.Key == "" || provider.Name == "" || provider.ClientID == "" {
+		return errors.New("key, name and client ID are required")
+	}
+	if u, err := url.Parse(provider.IssuerURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("issuer URL must be an https URL")
+	}
+	if _, err := oidc.metadata(provider.IssuerURL, true); err != nil {
+		return fmt.Errorf("could not load the provider's OpenID configuration: %w", err)
+	}
+	return nil
+}
+
+// StartLogin records a new sign-in attempt and returns the provider URL to send the
+// user to
+func (oidc *OIDCService) StartLogin(providerKey string) (string, error) {
+	if oidc.redirectURL == "" {
+		return "", ErrOIDCRedirectMissing
+	}
+	provider, err := oidc.provider(providerKey)
+	if err != nil {
+		return "", err
+	}
+	metadata, err := oidc.metadata(provider.IssuerURL, false)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomHex(24)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	oidc.db.Where("expires_at < ?", now).Delete(&models.OIDCLoginState{})
+	if err := oidc.db.Create(&models.OIDCLoginState{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ProviderID:   provider.ID,
+		RedirectURI:  oidc.redirectURL,
+		ExpiresAt:    now.Add(oidcLoginValidity),
+	}).Error; err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	scopes := provider.Scopes
+	if scopes == "" {
+		scopes = "openid email profile"
+	}
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.ClientID},
+		"redirect_uri":          {oidc.redirectURL},
+		"scope":                 {scopes},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+		"prompt":                {"select_account"},
+	}
+	return metadata.AuthorizationEndpoint + "?" + query.Encode(), nil
+}
+
+// CompleteLogin exchanges the code from the provider's redirect, verifies the ID token
+// and returns the user it belongs to, linking or provisioning the account as needed
+func (oidc *OIDCService) CompleteLogin(state, code string) (*OIDCLoginResult, error) {
+	var login models.OIDCLoginState
+	now := time.Now()
+	result := oidc.db.Model(&models.OIDCLoginState{}).
+		Where("state = ? AND used_at IS NULL AND expires_at > ?", state, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrOIDCStateInvalid
+	}
+	if err := oidc.db.Where("state = ?", state).First(&login).Error; err != nil {
+		return nil, err
+	}
+
+	var provider models.AuthProvider
+	if err := oidc.db.Where("id = ? AND enabled = ?", login.ProviderID, true).First(&provider).Error; err != nil {
+		return nil, ErrOIDCProviderNotFound
+	}
+	metadata, err := oidc.metadata(provider.IssuerURL, false)
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := oidc.exchangeCode(metadata, &provider, &login, code)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := oidc.verifyIDToken(metadata, &provider, idToken, login.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return oidc.resolveUser(&provider, claims)
+}
+
+// resolveUser finds the user for verified claims: by existing link, then by email,
+// then by provisioning a staff account for an allowed domain
+func (oidc *OIDCService) resolveUser(provider *models.AuthProvider, claims *oidcClaims) (*OIDCLoginResult, error) {
+	now := time.Now()
+
+	var identity models.UserIdentity
+	err := oidc.db.Where("provider_id = ? AND subject = ?", provider.ID, claims.Subject).First(&identity).Error
+	if err == nil {
+		var user models.User
+		if err := oidc.db.First(&user, identity.UserID).Error; err != nil {
+			return nil, ErrOIDCNoAccount
+		}
+		if !oidcCanSignIn(user.Role) {
+			return nil, ErrOIDCPasswordOnly
+		}
+		oidc.db.Model(&identity).Update("last_login_at", now)
+		return &OIDCLoginResult{User: &user}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	email, err := oidcVerifiedEmail(provider, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	loginResult := &OIDCLoginResult{}
+	err = oidc.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		err := tx.Where("LOWER(email) = ?", email).First(&user).Error
+		switch {
+		case err == nil:
+			if !oidcCanSignIn(user.Role) {
+				return ErrOIDCPasswordOnly
+			}
+			loginResult.Linked = true
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if !provider.AllowsDomain(email) {
+				return ErrOIDCNoAccount
+			}
+			if user, err = oidc.provisionStaff(tx, email, claims); err != nil {
+				return err
+			}
+			loginResult.Provisioned = true
+		default:
+			return err
+		}
+
+		if err := tx.Create(&models.UserIdentity{
+			UserID:      user.ID,
+			ProviderID:  provider.ID,
+			Subject:     claims.Subject,
+			Email:       email,
+			LastLoginAt: &now,
+		}).Error; err != nil {
+			return err
+		}
+		if !user.EmailVerified {
+			if err := tx.Model(&user).Updates(map[string]interface{}{
+				"email_verified":    true,
+				"email_verified_at": now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		loginResult.User = &user
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return loginResult, nil
+}
+
+// oidcVerifiedEmail returns the email address from the claims if the provider vouches
+// for it
+func oidcVerifiedEmail(provider *models.AuthProvider, claims *oidcClaims) (string, error) {
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" {
+		email = strings.ToLower(strings.TrimSpace(claims.PreferredUsername))
+	}
+	if email == "" || !strings.Contains(email, "@") {
+		return "", ErrOIDCEmailUnverified
+	}
+	// Providers that do not send email_verified, such as Microsoft Entra, are only
+	// trusted for the organisation's own domains
+	verified := claims.EmailVerified != nil && *claims.EmailVerified
+	if claims.EmailVerified == nil {
+		verified = provider.AllowsDomain(email)
+	}
+	if !verified {
+		return "", ErrOIDCEmailUnverified
+	}
+	return email, nil
+}
+
+// provisionStaff creates an active staff account and profile for a first sign-in from
+// an allowed domain. It gets an unusable random password; the provider is how they
+// sign in.
+func (oidc *OIDCService) provisionStaff(tx *gorm.DB, email string, claims *oidcClaims) (models.User, error) {
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" && lastName == "" {
+		parts := strings.Fields(claims.Name)
+		if len(parts) > 0 {
+			firstName, lastName = parts[0], strings.Join(parts[1:], " ")
+		}
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return models.User{}, err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{
+		FirstName: firstName,
+		LastName:  lastName,
+		Email:     email,
+		Role:      models.RoleStaff,
+		Password:  string(hashed),
+		Status:    models.StatusActive,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return models.User{}, err
+	}
+
+	// A basic staff profile an admin can fill in later
+	if err := tx.Create(&models.StaffProfile{
+		UserID:     user.ID,
+		EmployeeID: fmt.Sprintf("SSO-%d", user.ID),
+		Department: models.DepartmentGeneral,
+		Position:   models.PositionStaffMember,
+		HireDate:   time.Now(),
+		Status:     models.StaffStatusActive,
+		Notes:      "Created on first sign-in with " + claims.Issuer,
+	}).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// provider loads an enabled provider by key
+func (oidc *OIDCService) provider(key string) (*models.AuthProvider, error) {
+	var provider models.AuthProvider
+	if err := oidc.db.Where("key = ? AND enabled = ?", strings.ToLower(key), true).First(&provider).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOIDCProviderNotFound
+		}
+		return nil, err
+	}
+	return &provider, nil
+}
+
+// exchangeCode swaps the authorization code for tokens and returns the ID token
+func (oidc *OIDCService) exchangeCode(metadata *oidcMetadata, provider *models.AuthProvider, login *models.OIDCLoginState, code string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {login.RedirectURI},
+		"client_id":     {provider.ClientID},
+		"client_secret": {provider.ClientSecret},
+		"code_verifier": {login.CodeVerifier},
+	}
+	resp, err := oidc.client.PostForm(metadata.TokenEndpoint, form)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.IDToken == "" {
+		return "", fmt.Errorf("%w: %s %s", ErrOIDCTokenInvalid, body.Error, body.ErrorDescription)
+	}
+	return body.IDToken, nil
+}
+
+// verifyIDToken checks the ID token's signature, issuer, audience, expiry and nonce
+func (oidc *OIDCService) verifyIDToken(metadata *oidcMetadata, provider *models.AuthProvider, idToken, nonce string) (*oidcClaims, error) {
+	claims := &oidcClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return oidc.signingKey(provider.IssuerURL, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCTokenInvalid, err)
+	}
+
+	if claims.Issuer != metadata.Issuer || !claims.VerifyAudience(provider.ClientID, true) ||
+		claims.Nonce != nonce || claims.Subject == "" || claims.ExpiresAt == nil {
+		return nil, ErrOIDCTokenInvalid
+	}
+	return claims, nil
+}
+
+// signingKey returns the provider's public key with the given ID, refreshing the key
+// set once if it is not known (providers rotate keys)
+func (oidc *OIDCService) signingKey(issuer, kid string) (*rsa.PublicKey, error) {
+	metadata, err := oidc.metadata(issuer, false)
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := metadata.keys[kid]; ok {
+		return key, nil
+	}
+	if metadata, err = oidc.metadata(issuer, true); err != nil {
+		return nil, err
+	}
+	if key, ok := metadata.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// metadata returns a provider's discovery document and signing keys, cached for an hour
+func (oidc *OIDCService) metadata(issuer string, refresh bool) (*oidcMetadata, error) {
+	oidcMetadataCacheMu.Lock()
+	cached := oidcMetadataCache[issuer]
+	oidcMetadataCacheMu.Unlock()
+	if cached != nil && !refresh && time.Since(cached.fetchedAt) < oidcMetadataTTL {
+		return cached, nil
+	}
+
+	metadata := &oidcMetadata{fetchedAt: time.Now()}
+	if err := oidc.getJSON(issuer+"/.well-known/openid-configuration", metadata); err != nil {
+		return nil, err
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing endpoints")
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := oidc.getJSON(metadata.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+	metadata.keys = map[string]*rsa.PublicKey{}
+	for _, key := range jwks.Keys {
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			continue
+		}
+		metadata.keys[key.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	oidcMetadataCacheMu.Lock()
+	oidcMetadataCache[issuer] = metadata
+	oidcMetadataCacheMu.Unlock()
+	return metadata, nil
+}
+
+// getJSON fetches and decodes a JSON document
+func (oidc *OIDCService) getJSON(endpoint string, into interface{}) error {
+	resp, err := oidc.client.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(into)
+}
+
+// oidcCanSignIn reports whether a role may sign in with an external provider
+func oidcCanSignIn(role string) bool {
+	for _, allowed := range models.OIDCSignInRoles {
+		if role == allowed {
+			return true
+		}
+	}
+	return false
+}
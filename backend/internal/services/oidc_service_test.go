@@ -0,0 +1,202 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// oidcTestProvider serves a discovery document, a key set with one RSA key and a
+// token endpoint that returns idToken for the code "good-code"
+type oidcTestProvider struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	idToken string
+}
+
+func newOIDCTestProvider(t *testing.T) *oidcTestProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &oidcTestProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			{"kid": "enc", "kty": "RSA", "use": "enc", "n": "AQAB", "e": "AQAB"}, // Not for signatures
+			{
+				"kid": "k1", "kty": "RSA", "use": "sig",
+				"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			},
+		}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("code") != "good-code" || r.PostForm.Get("code_verifier") != "verifier" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": p.idToken})
+	})
+	p.server = httptest.NewTLSServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// sign creates an ID token from the claims with the given key ID
+func (p *oidcTestProvider) sign(t *testing.T, claims *oidcClaims, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestOIDCVerifyIDToken(t *testing.T) {
+	p := newOIDCTestProvider(t)
+	oidc := &OIDCService{client: p.server.Client()}
+	provider := &models.AuthProvider{IssuerURL: p.server.URL, ClientID: "charity-app"}
+	metadata, err := oidc.metadata(provider.IssuerURL, true)
+	if err != nil {
+		t.Fatalf("metadata: %v", err)
+	}
+	if len(metadata.keys) != 1 {
+		t.Errorf("loaded %d signing keys, want 1", len(metadata.keys))
+	}
+
+	valid := func() *oidcClaims {
+		return &oidcClaims{Email: "sam@example.org", Nonce: "n-1", RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    p.server.URL,
+			Subject:   "user-123",
+			Audience:  jwt.ClaimStrings{"charity-app"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+		}}
+	}
+	claims, err := oidc.verifyIDToken(metadata, provider, p.sign(t, valid(), "k1"), "n-1")
+	if err != nil || claims.Subject != "user-123" || claims.Email != "sam@example.org" {
+		t.Fatalf("valid token: got %+v, %v", claims, err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*oidcClaims)
+		kid    string
+		nonce  string
+	}{
+		{"replayed with another nonce", func(c *oidcClaims) {}, "k1", "n-2"},
+		{"for another client", func(c *oidcClaims) { c.Audience = jwt.ClaimStrings{"other-app"} }, "k1", "n-1"},
+		{"from another issuer", func(c *oidcClaims) { c.Issuer = "https://evil.example" }, "k1", "n-1"},
+		{"expired", func(c *oidcClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) }, "k1", "n-1"},
+		{"no expiry", func(c *oidcClaims) { c.ExpiresAt = nil }, "k1", "n-1"},
+		{"no subject", func(c *oidcClaims) { c.Subject = "" }, "k1", "n-1"},
+		{"unknown key", func(c *oidcClaims) {}, "k2", "n-1"},
+	}
+	for _, tt := range tests {
+		claims := valid()
+		tt.modify(claims)
+		if _, err := oidc.verifyIDToken(metadata, provider, p.sign(t, claims, tt.kid), tt.nonce); !errors.Is(err, ErrOIDCTokenInvalid) {
+			t.Errorf("%s: got %v", tt.name, err)
+		}
+	}
+
+	// A token signed with a shared secret is refused even if it names a known key
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, valid())
+	hmac.Header["kid"] = "k1"
+	signed, _ := hmac.SignedString([]byte("client-secret"))
+	if _, err := oidc.verifyIDToken(metadata, provider, signed, "n-1"); !errors.Is(err, ErrOIDCTokenInvalid) {
+		t.Errorf("HS256 token: got %v", err)
+	}
+}
+
+func TestOIDCExchangeCode(t *testing.T) {
+	p := newOIDCTestProvider(t)
+	p.idToken = "id-token"
+	oidc := &OIDCService{client: p.server.Client()}
+	provider := &models.AuthProvider{IssuerURL: p.server.URL, ClientID: "charity-app"}
+	metadata, err := oidc.metadata(provider.IssuerURL, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	login := &models.OIDCLoginState{CodeVerifier: "verifier", RedirectURI: "https://app.example.org/sso"}
+
+	if token, err := oidc.exchangeCode(metadata, provider, login, "good-code"); err != nil || token != "id-token" {
+		t.Errorf("got %q, %v", token, err)
+	}
+	if _, err := oidc.exchangeCode(metadata, provider, login, "stolen-code"); !errors.Is(err, ErrOIDCTokenInvalid) {
+		t.Errorf("rejected code: got %v", err)
+	}
+	login.CodeVerifier = "guessed"
+	if _, err := oidc.exchangeCode(metadata, provider, login, "good-code"); !errors.Is(err, ErrOIDCTokenInvalid) {
+		t.Errorf("wrong PKCE verifier: got %v", err)
+	}
+}
+
+func TestOIDCVerifiedEmail(t *testing.T) {
+	provider := &models.AuthProvider{AllowedDomains: "Lewisham-Charity.org, example.org"}
+	yes, no := true, false
+	tests := []struct {
+		name   string
+		claims oidcClaims
+		want   string
+	}{
+		{"verified", oidcClaims{Email: " Sam@Gmail.com ", EmailVerified: &yes}, "sam@gmail.com"},
+		{"unverified", oidcClaims{Email: "sam@example.org", EmailVerified: &no}, ""},
+		{"no claim, own domain", oidcClaims{PreferredUsername: "sam@lewisham-charity.org"}, "sam@lewisham-charity.org"},
+		{"no claim, other domain", oidcClaims{Email: "sam@gmail.com"}, ""},
+		{"not an email", oidcClaims{PreferredUsername: "sam", EmailVerified: &yes}, ""},
+	}
+	for _, tt := range tests {
+		email, err := oidcVerifiedEmail(provider, &tt.claims)
+		if email != tt.want || (tt.want == "") != errors.Is(err, ErrOIDCEmailUnverified) {
+			t.Errorf("%s: got %q, %v", tt.name, email, err)
+		}
+	}
+}
+
+func TestOIDCProviderChecks(t *testing.T) {
+	oidc := &OIDCService{}
+	if _, err := oidc.StartLogin("google"); err != ErrOIDCRedirectMissing {
+		t.Errorf("no redirect URL: got %v", err)
+	}
+
+	for name, provider := range map[string]models.AuthProvider{
+		"missing client ID": {Key: "google", Name: "Google", IssuerURL: "https://accounts.google.com"},
+		"plain http issuer": {Key: "google", Name: "Google", ClientID: "id", IssuerURL: "http://accounts.google.com"},
+	} {
+		if err := oidc.ValidateProvider(&provider); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+
+	for role, want := range map[string]bool{models.RoleStaff: true, models.RoleVolunteer: true, models.RoleVisitor: false, models.RoleDonor: false} {
+		if oidcCanSignIn(role) != want {
+			t.Errorf("oidcCanSignIn(%s) = %v", role, !want)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	otpValidity        = 10 * time.Minute
+	otpMaxAttempts     = 5
+	otpMaxPerWindow    = 3
+	otpRateLimitWindow = 15 * time.Minute
+)
+
+var (
+	ErrOTPRateLimited = errors.New("too many codes requested, please try again later")
+	ErrOTPInvalid     = errors.New("invalid or expired code")
+	ErrPhoneInUse     = errors.New("phone number is already registered")
+	ErrPhoneAmbiguous = errors.New("phone number is shared by more than one account")
+)
+
+// PhoneAuthService handles phone-number identity: one-time codes sent by SMS,
+// lookup and deduplication of users by phone, and SMS ticket delivery
+type PhoneAuthService struct {
+	db *gorm.DB
+}
+
+// DuplicatePhone is a phone number shared by more than one account
+type DuplicatePhone struct {
+	Phone   string `json:"phone"`
+	Count   int64  `json:"count"`
+	UserIDs string `json:"user_ids"`
+}
+
+// NewPhoneAuthService creates a new phone authentication service
+func NewPhoneAuthService() *PhoneAuthService {
+	return &PhoneAuthService{
+		db: db.DB,
+	}
+}
+
+// FindUserByPhone returns the account registered to a phone number. Numbers shared by
+// more than one account are refused until an admin resolves the duplicates, so a code
+// sent to the number cannot sign into the wrong account.
+func (ps *PhoneAuthService) FindUserByPhone(phone string) (*models.User, error) {
+	normalized, err := models.NormalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []models.User
+	if err := ps.db.Where("phone = ?", normalized).Order("id").Limit(2).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	switch len(users) {
+	case 0:
+		return nil, gorm.ErrRecordNotFound
+	case 1:
+		return &users[0], nil
+	default:
+		return nil, ErrPhoneAmbiguous
+	}
+}
+
+// CanSignInByPhone reports whether the account may sign in with an SMS code. Only
+// visitors can; staff, volunteer and admin accounts keep their password or single
+// sign-on login.
+func CanSignInByPhone(user *models.User) bool {
+	return user.Role == models.RoleVisitor || user.Role == models.RoleVisitorLegacy
+}
+
+// PhoneInUse returns true if another account already uses the phone number
+func (ps *PhoneAuthService) PhoneInUse(phone string, excludeUserID uint) bool {
+	var count int64
+	query := ps.db.Model(&models.User{}).Where("phone = ?", phone)
+	if excludeUserID != 0 {
+		query = query.Where("id <> ?", excludeUserID)
+	}
+	query.Count(&count)
+	return count > 0
+}
+
+// RequestOTP generates a one-time code for the phone number and sends it by SMS
+func (ps *PhoneAuthService) RequestOTP(phone, purpose, ipAddress string) error {
+	var recent int64
+	ps.db.Model(&models.PhoneOTP{}).
+		Where("phone = ? AND created_at > ?", phone, time.Now().Add(-otpRateLimitWindow)).
+		Count(&recent)
+	if recent >= otpMaxPerWindow {
+		return ErrOTPRateLimited
+	}
+
+	code, err := generateOTPCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate code: %w", err)
+	}
+
+	otp := models.PhoneOTP{
+		Phone:     phone,
+		Purpose:   purpose,
+		CodeHash:  hashOTP(phone, purpose, code),
+		ExpiresAt: time.Now().Add(otpValidity),
+		IPAddress: ipAddress,
+	}
+	if err := ps.db.Create(&otp).Error; err != nil {
+		return fmt.Errorf("failed to store code: %w", err)
+	}
+
+	message := fmt.Sprintf("Your Lewisham Charity code is %s. It expires in %d minutes. Do not share this code.",
+		code, int(otpValidity.Minutes()))
+	return sendUrgentSMS(phone, message)
+}
+
+// VerifyOTP checks a code against the latest unused code for the phone number
+// and marks it as used. Each wrong guess counts towards the attempt limit.
+func (ps *PhoneAuthService) VerifyOTP(phone, purpose, code string) error {
+	matched := false
+	err := ps.db.Transaction(func(tx *gorm.DB) error {
+		var otp models.PhoneOTP
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("phone = ? AND purpose = ? AND used_at IS NULL", phone, purpose).
+			Order("created_at DESC").
+			First(&otp).Error; err != nil {
+			return ErrOTPInvalid
+		}
+
+		if !otp.IsUsable(otpMaxAttempts) {
+			return ErrOTPInvalid
+		}
+
+		// Record the failed attempt and commit it so guesses count towards the limit
+		if subtle.ConstantTimeCompare([]byte(otp.CodeHash), []byte(hashOTP(phone, purpose, code))) != 1 {
+			return tx.Model(&otp).Update("attempts", gorm.Expr("attempts + 1")).Error
+		}
+
+		matched = true
+		now := time.Now()
+		return tx.Model(&otp).Update("used_at", &now).Error
+	})
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return ErrOTPInvalid
+	}
+	return nil
+}
+
+// MarkPhoneVerified records that the user has proven they own their phone number
+func (ps *PhoneAuthService) MarkPhoneVerified(user *models.User) error {
+	if user.PhoneVerified {
+		return nil
+	}
+	now := time.Now()
+	user.PhoneVerified = true
+	user.PhoneVerifiedAt = &now
+	return ps.db.Model(user).Updates(map[string]interface{}{
+		"phone_verified":    true,
+		"phone_verified_at": &now,
+	}).Error
+}
+
+// SendTicketSMS sends the ticket number and a short link to the ticket's QR code
+// to the visitor's phone, falling back to the phone given on the help request
+func (ps *PhoneAuthService) SendTicketSMS(user models.User, helpRequest models.HelpRequest) error {
+	phone := user.Phone
+	if phone == "" {
+		phone = helpRequest.Phone
+	}
+	if phone == "" || helpRequest.TicketNumber == "" {
+		return fmt.Errorf("no phone number or ticket to send")
+	}
+
+	normalized, err := models.NormalizePhone(phone)
+	if err != nil {
+		return err
+	}
+
+	var tokens []string
+	if err := ps.db.Model(&models.Ticket{}).Where("ticket_number = ? AND link_token IS NOT NULL", helpRequest.TicketNumber).
+		Limit(1).Pluck("link_token", &tokens).Error; err != nil {
+		return err
+	}
+	if len(tokens) == 0 || tokens[0] == "" {
+		return fmt.Errorf("ticket %s has no short link", helpRequest.TicketNumber)
+	}
+	link := TicketShortLink(tokens[0])
+
+	message := fmt.Sprintf("Lewisham Charity: your ticket %s is confirmed for %s at %s. Show this ticket on arrival: %s",
+		helpRequest.TicketNumber, helpRequest.VisitDay, helpRequest.TimeSlot, link)
+	if notifications.VisitorCommsFormat(user.ID).PlainLanguage {
+		message = fmt.Sprintf("Lewisham Charity: You have a ticket. Come on %s at %s. Your number is %s. Show this at the desk: %s",
+			helpRequest.VisitDay, helpRequest.TimeSlot, helpRequest.TicketNumber, link)
+	}
+	return sendSMS(normalized, message)
+}
+
+// DuplicatePhones returns phone numbers that are shared by more than one account
+func (ps *PhoneAuthService) DuplicatePhones() ([]DuplicatePhone, error) {
+	var duplicates []DuplicatePhone
+	err := ps.db.Model(&models.User{}).
+		Select("phone, COUNT(*) AS count, STRING_AGG(CAST(id AS TEXT), ',' ORDER BY id) AS user_ids").
+		Where("phone <> ''").
+		Group("phone").
+		Having("COUNT(*) > 1").
+		Order("count DESC").
+		Scan(&duplicates).Error
+	return duplicates, err
+}
+
+// EnsureUniquePhoneIndex creates the unique phone index once no duplicates remain. The
+// migration skips it while duplicates exist, so it is retried after admins resolve them.
+func (ps *PhoneAuthService) EnsureUniquePhoneIndex() error {
+	return ps.db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_phone_unique ON users (phone) " +
+		"WHERE phone <> '' AND deleted_at IS NULL").Error
+}
+
+// TicketShortLink returns the public link that shows a ticket, from its random link
+// token
+func TicketShortLink(token string) string {
+	baseURL := os.Getenv("FRONTEND_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+	return fmt.Sprintf("%s/t/%s", baseURL, token)
+}
+
+// sendSMS sends a plain text message through the notification service
+func sendSMS(to, message string) error {
+	notificationService := notifications.GetService()
+	if notificationService == nil {
+		return fmt.Errorf("notification service is not initialized")
+	}
+	return notificationService.SendSMS(to, message)
+}
+
+// sendUrgentSMS sends a plain text message that must go out even when the monthly
+// SMS budget is spent
+func sendUrgentSMS(to, message string) error {
+	notificationService := notifications.GetService()
+	if notificationService == nil {
+		return fmt.Errorf("notification service is not initialized")
+	}
+	return notificationService.SendUrgentSMS(to, message)
+}
+
+// generateOTPCode returns a random six digit code
+func generateOTPCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashOTP hashes a code together with the phone number and purpose it was issued for
+func hashOTP(phone, purpose, code string) string {
+	sum := sha256.Sum256([]byte(phone + ":" + purpose + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestCanSignInByPhoneOnlyVisitors(t *testing.T) {
+	allowed := map[string]bool{
+		models.RoleVisitor:          true,
+		models.RoleVisitorLegacy:    true,
+		models.RoleVolunteer:        false,
+		models.RoleDonor:            false,
+		models.RoleStaff:            false,
+		models.RoleAdmin:            false,
+		models.RoleAdminLegacy:      false,
+		models.RoleSuperAdmin:       false,
+		models.RoleSuperAdminLegacy: false,
+	}
+	for role, want := range allowed {
+		if got := CanSignInByPhone(&models.User{Role: role}); got != want {
+			t.Errorf("role %s: got %v, want %v", role, got, want)
+		}
+	}
+}
+
+func TestHashOTPBindsPhoneAndPurpose(t *testing.T) {
+	hash := hashOTP("+447700900123", models.OTPPurposeLogin, "123456")
+	if hash != hashOTP("+447700900123", models.OTPPurposeLogin, "123456") {
+		t.Fatal("hash is not deterministic")
+	}
+	if hash == hashOTP("+447700900124", models.OTPPurposeLogin, "123456") {
+		t.Error("code for one phone matches another")
+	}
+	if hash == hashOTP("+447700900123", models.OTPPurposeVerifyPhone, "123456") {
+		t.Error("verification code matches a login code")
+	}
+}
+
+func TestGenerateOTPCodeIsSixDigits(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		code, err := generateOTPCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != 6 {
+			t.Fatalf("code %q is not six digits", code)
+		}
+		for _, r := range code {
+			if r < '0' || r > '9' {
+				t.Fatalf("code %q is not numeric", code)
+			}
+		}
+	}
+}
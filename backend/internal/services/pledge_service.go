@@ -0,0 +1,297 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPledgePaymentRecorded is returned when a bank feed line has already been recorded
+var ErrPledgePaymentRecorded = errors.New("this bank payment has already been recorded")
+
+// PledgeService handles business pledges, invoicing and payment reconciliation
+type PledgeService struct {
+	db *gorm.DB
+}
+
+// PledgeAgingEntry is an unpaid pledge as shown in the aging report
+type PledgeAgingEntry struct {
+	ID               uint      `json:"id"`
+	BusinessName     string    `json:"business_name"`
+	InvoiceNumber    string    `json:"invoice_number"`
+	PaymentReference string    `json:"payment_reference"`
+	DueDate          time.Time `json:"due_date"`
+	DaysOverdue      int       `json:"days_overdue"`
+	Outstanding      float64   `json:"outstanding"`
+}
+
+// PledgeAgingBucket groups unpaid pledges by how overdue they are
+type PledgeAgingBucket struct {
+	Label       string             `json:"label"`
+	Count       int                `json:"count"`
+	Outstanding float64            `json:"outstanding"`
+	Pledges     []PledgeAgingEntry `json:"pledges"`
+}
+
+// PledgeAgingReport summarises outstanding pledge balances
+type PledgeAgingReport struct {
+	GeneratedAt      time.Time           `json:"generated_at"`
+	TotalOutstanding float64             `json:"total_outstanding"`
+	Buckets          []PledgeAgingBucket `json:"buckets"`
+}
+
+// NewPledgeService creates a new pledge service
+func NewPledgeService() *PledgeService {
+	return &PledgeService{
+		db: db.DB,
+	}
+}
+
+// GeneratePledgeReference creates the payment reference a business quotes on its bank transfer
+func GeneratePledgeReference() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("PLG-%08d", time.Now().UnixNano()%100000000)
+	}
+	return "PLG-" + strings.ToUpper(hex.EncodeToString(b))
+}
+
+// FindOpenPledgeByReference finds an open pledge whose payment reference appears in the given text
+func (ps *PledgeService) FindOpenPledgeByReference(text string) (*models.DonationPledge, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(text, " ", ""))
+	if normalized == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var pledge models.DonationPledge
+	err := ps.db.
+		Where("status NOT IN ?", []string{models.PledgeStatusPaid, models.PledgeStatusCancelled}).
+		Where("strpos(?, payment_reference) > 0", normalized).
+		First(&pledge).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pledge, nil
+}
+
+// IssueInvoice assigns an invoice number the first time a pledge is invoiced
+func (ps *PledgeService) IssueInvoice(pledge *models.DonationPledge) error {
+	if pledge.Status == models.PledgeStatusCancelled {
+		return fmt.Errorf("cannot invoice a cancelled pledge")
+	}
+	if pledge.InvoiceNumber != "" {
+		return nil
+	}
+
+	now := time.Now()
+	pledge.InvoiceNumber = fmt.Sprintf("INV-%d-%05d", now.Year(), pledge.ID)
+	pledge.InvoicedAt = &now
+	if pledge.Status == models.PledgeStatusPledged {
+		pledge.Status = models.PledgeStatusInvoiced
+	}
+
+	return ps.db.Save(pledge).Error
+}
+
+// RecordPayment records money received against a pledge. When the pledge becomes
+// fully paid a donation record is created so it shows up in donation reporting.
+// Bank feed payments are keyed on their date, amount and bank reference so the same
+// statement line is never recorded twice.
+func (ps *PledgeService) RecordPayment(pledgeID uint, amount float64, paidOn time.Time, source, bankReference string, recordedBy *uint, notes string) (*models.DonationPledge, *models.PledgePayment, error) {
+	if amount <= 0 {
+		return nil, nil, fmt.Errorf("payment amount must be positive")
+	}
+
+	var pledge models.DonationPledge
+	var payment models.PledgePayment
+
+	err := ps.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pledge, pledgeID).Error; err != nil {
+			return err
+		}
+		if !pledge.IsOpen() {
+			return fmt.Errorf("pledge is already %s", pledge.Status)
+		}
+
+		payment = models.PledgePayment{
+			PledgeID:      pledge.ID,
+			Amount:        amount,
+			PaidOn:        paidOn,
+			Source:        source,
+			BankReference: bankReference,
+			RecordedBy:    recordedBy,
+			Notes:         notes,
+		}
+		if source == models.PledgePaymentSourceBankFeed && strings.TrimSpace(bankReference) != "" {
+			key := models.PledgeBankLineKey(paidOn, amount, bankReference)
+			payment.BankLineKey = &key
+		}
+
+		if err := pledge.ApplyPayment(amount, paidOn); err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&payment)
+		if result.Error != nil {
+			return fmt.Errorf("failed to record payment: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrPledgePaymentRecorded
+		}
+
+		if pledge.Status == models.PledgeStatusPaid && pledge.DonationID == nil {
+			donation := models.Donation{
+				Name:          pledge.BusinessName,
+				ContactEmail:  pledge.ContactEmail,
+				ContactPhone:  pledge.ContactPhone,
+				Type:          "monetary",
+				Amount:        pledge.AmountPaid,
+				Currency:      pledge.Currency,
+				PaymentMethod: "bank_transfer",
+				PaymentID:     pledge.PaymentReference,
+				Status:        models.DonationStatusReceived,
+				ReceivedAt:    &paidOn,
+				Notes:         fmt.Sprintf("Business pledge %s (invoice %s)", pledge.PaymentReference, pledge.InvoiceNumber),
+			}
+			if err := tx.Create(&donation).Error; err != nil {
+				return fmt.Errorf("failed to create donation for paid pledge: %w", err)
+			}
+			pledge.DonationID = &donation.ID
+		}
+
+		return tx.Save(&pledge).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &pledge, &payment, nil
+}
+
+// AgingReport groups all unpaid pledges by how far past their due date they are
+func (ps *PledgeService) AgingReport(now time.Time) (*PledgeAgingReport, error) {
+	var pledges []models.DonationPledge
+	if err := ps.db.
+		Where("status NOT IN ?", []string{models.PledgeStatusPaid, models.PledgeStatusCancelled}).
+		Order("due_date ASC").
+		Find(&pledges).Error; err != nil {
+		return nil, err
+	}
+	return buildPledgeAgingReport(pledges, now), nil
+}
+
+// buildPledgeAgingReport sorts unpaid pledges into the aging buckets
+func buildPledgeAgingReport(pledges []models.DonationPledge, now time.Time) *PledgeAgingReport {
+	report := &PledgeAgingReport{
+		GeneratedAt: now,
+		Buckets: []PledgeAgingBucket{
+			{Label: "current", Pledges: []PledgeAgingEntry{}},
+			{Label: "1-30", Pledges: []PledgeAgingEntry{}},
+			{Label: "31-60", Pledges: []PledgeAgingEntry{}},
+			{Label: "61-90", Pledges: []PledgeAgingEntry{}},
+			{Label: "90+", Pledges: []PledgeAgingEntry{}},
+		},
+	}
+
+	for _, pledge := range pledges {
+		days := pledge.DaysOverdue(now)
+		outstanding := pledge.OutstandingAmount()
+
+		var idx int
+		switch {
+		case days == 0:
+			idx = 0
+		case days <= 30:
+			idx = 1
+		case days <= 60:
+			idx = 2
+		case days <= 90:
+			idx = 3
+		default:
+			idx = 4
+		}
+
+		bucket := &report.Buckets[idx]
+		bucket.Count++
+		bucket.Outstanding += outstanding
+		bucket.Pledges = append(bucket.Pledges, PledgeAgingEntry{
+			ID:               pledge.ID,
+			BusinessName:     pledge.BusinessName,
+			InvoiceNumber:    pledge.InvoiceNumber,
+			PaymentReference: pledge.PaymentReference,
+			DueDate:          pledge.DueDate,
+			DaysOverdue:      days,
+			Outstanding:      outstanding,
+		})
+		report.TotalOutstanding += outstanding
+	}
+
+	return report
+}
+
+// RenderPledgeInvoice renders the invoice PDF for a pledge
+func RenderPledgeInvoice(pledge models.DonationPledge) []byte {
+	organisation := BrandName("")
+	doc := BrandedPDF("Invoice "+pledge.InvoiceNumber, "")
+
+	doc.Heading(organisation+" - Invoice").
+		Blank().
+		Field("Invoice number", pledge.InvoiceNumber).
+		Field("Invoice date", formatOptionalDate(pledge.InvoicedAt)).
+		Field("Payment due", pledge.DueDate.Format("2 January 2006")).
+		Blank().
+		Subheading("Bill to").
+		Line(pledge.BusinessName)
+
+	if pledge.ContactName != "" {
+		doc.Field("Attn", pledge.ContactName)
+	}
+	for _, line := range strings.Split(pledge.BillingAddress, "\n") {
+		if strings.TrimSpace(line) != "" {
+			doc.Line(strings.TrimSpace(line))
+		}
+	}
+	if pledge.PurchaseOrder != "" {
+		doc.Field("Purchase order", pledge.PurchaseOrder)
+	}
+
+	doc.Blank().
+		Subheading("Details").
+		Linef("Charitable pledge made on %s", pledge.PledgedAt.Format("2 January 2006")).
+		Field("Pledged amount", formatMoney(pledge.Amount, pledge.Currency)).
+		Field("Paid to date", formatMoney(pledge.AmountPaid, pledge.Currency)).
+		Field("Amount due", formatMoney(pledge.OutstandingAmount(), pledge.Currency)).
+		Blank().
+		Subheading("How to pay").
+		Line("Please pay by bank transfer and quote the payment reference below").
+		Line("so that we can match your payment to this invoice.").
+		Field("Payment reference", pledge.PaymentReference).
+		Blank().
+		Line("Thank you for supporting " + organisation + ".")
+
+	return doc.Bytes()
+}
+
+// formatMoney formats an amount with its currency symbol
+func formatMoney(amount float64, currency string) string {
+	if currency == "" || currency == "GBP" {
+		return fmt.Sprintf("£%.2f", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+// formatOptionalDate formats a nullable date for display
+func formatOptionalDate(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("2 January 2006")
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestBuildPledgeAgingReport(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	due := func(daysAgo int) time.Time { return now.AddDate(0, 0, -daysAgo) }
+	pledges := []models.DonationPledge{
+		{BusinessName: "Not yet due", Amount: 100, DueDate: now.AddDate(0, 0, 14)},
+		{BusinessName: "Due today", Amount: 50, DueDate: now},
+		{BusinessName: "One day", Amount: 200, AmountPaid: 50, DueDate: due(1)},
+		{BusinessName: "Thirty days", Amount: 30, DueDate: due(30)},
+		{BusinessName: "Thirty-one days", Amount: 31, DueDate: due(31)},
+		{BusinessName: "Sixty days", Amount: 60, DueDate: due(60)},
+		{BusinessName: "Ninety days", Amount: 90, DueDate: due(90)},
+		{BusinessName: "Ninety-one days", Amount: 400, AmountPaid: 9, DueDate: due(91)},
+	}
+
+	report := buildPledgeAgingReport(pledges, now)
+
+	want := []struct {
+		label       string
+		businesses  []string
+		outstanding float64
+	}{
+		{"current", []string{"Not yet due", "Due today"}, 150},
+		{"1-30", []string{"One day", "Thirty days"}, 180},
+		{"31-60", []string{"Thirty-one days", "Sixty days"}, 91},
+		{"61-90", []string{"Ninety days"}, 90},
+		{"90+", []string{"Ninety-one days"}, 391},
+	}
+	if len(report.Buckets) != len(want) {
+		t.Fatalf("got %d buckets", len(report.Buckets))
+	}
+	for i, w := range want {
+		bucket := report.Buckets[i]
+		if bucket.Label != w.label || bucket.Count != len(w.businesses) || bucket.Outstanding != w.outstanding {
+			t.Errorf("bucket %d: got %s with %d pledges, £%.2f; want %s with %d, £%.2f",
+				i, bucket.Label, bucket.Count, bucket.Outstanding, w.label, len(w.businesses), w.outstanding)
+			continue
+		}
+		for j, name := range w.businesses {
+			if bucket.Pledges[j].BusinessName != name {
+				t.Errorf("%s: pledge %d is %s, want %s", w.label, j, bucket.Pledges[j].BusinessName, name)
+			}
+		}
+	}
+	if report.TotalOutstanding != 902 {
+		t.Errorf("total outstanding £%.2f, want £902.00", report.TotalOutstanding)
+	}
+	if entry := report.Buckets[4].Pledges[0]; entry.DaysOverdue != 91 || entry.Outstanding != 391 {
+		t.Errorf("90+ entry: got %+v", entry)
+	}
+
+	// Empty buckets serialise as lists rather than null
+	empty := buildPledgeAgingReport(nil, now)
+	for _, bucket := range empty.Buckets {
+		if bucket.Pledges == nil || bucket.Count != 0 {
+			t.Errorf("empty %s bucket: got %+v", bucket.Label, bucket)
+		}
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		want     string
+	}{
+		{250, "", "£250.00"},
+		{12.5, "GBP", "£12.50"},
+		{99.999, "EUR", "100.00 EUR"},
+	}
+	for _, tt := range tests {
+		if got := formatMoney(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("formatMoney(%v, %q) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/geoo115/charity-management-system/internal/db"
@@ -272,6 +273,17 @@ func (qo *QueryOptimizer) processBulkAssignmentBatch(tx *gorm.DB, batch []BulkAs
 			continue
 		}
 
+		// Check the volunteer holds the documents the shift role needs
+		documents := &VolunteerDocumentService{db: tx}
+		if missing, err := documents.MissingDocuments(assignment.VolunteerID, shift.Role, shift.Date); err == nil && len(missing) > 0 {
+			result.Failed = append(result.Failed, FailedAssignment{
+				ShiftID:     assignment.ShiftID,
+				VolunteerID: assignment.VolunteerID,
+				Reason:      "Missing required documents: " + strings.Join(missing, ", "),
+			})
+			continue
+		}
+
 		// Create assignment
 		shiftAssignment := models.ShiftAssignment{
 			ShiftID:    assignment.ShiftID,
@@ -0,0 +1,635 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/websocket"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// defaultFairnessWaitRatio flags a group whose median wait is this many times the
+	// overall median
+	defaultFairnessWaitRatio = 1.25
+	// defaultFairnessMinExtraMinutes stops a group being flagged over a few minutes
+	defaultFairnessMinExtraMinutes = 5
+	// defaultFairnessMinSample is how many waits a group needs before it is flagged
+	defaultFairnessMinSample = 20
+	// defaultFairnessMinPostcodeGroup hides postcode districts with fewer waits, so
+	// small areas cannot be used to single out visitors
+	defaultFairnessMinPostcodeGroup = 10
+	// defaultFairnessTrailingWeeks is how many weeks the weekly report looks back over
+	// to find groups that keep waiting longer
+	defaultFairnessTrailingWeeks = 8
+	// defaultFairnessReportHour is the hour on Monday the weekly report is sent
+	defaultFairnessReportHour = 7
+
+	// defaultWaitSpikeRatio flags an hour whose average wait is this many times the
+	// usual wait at that hour
+	defaultWaitSpikeRatio = 2.0
+	// defaultWaitSpikeMinMinutes is how much longer than usual a spike must be
+	defaultWaitSpikeMinMinutes = 15
+	// Spikes need this many waits in the hour, and the baseline this many, to count
+	minWaitSpikeEntries    = 5
+	minWaitBaselineEntries = 20
+	// waitBaselineDays is how far back the usual wait at an hour is measured
+	waitBaselineDays = 28
+)
+
+const queueFairnessAlertAction = "/admin/analytics/queue-fairness"
+
+// queueWaitsQuery selects each visitor's wait from joining the queue to being called,
+// with the postcode district of their address. %s is the time column the range
+// applies to, joined_at or called_at.
+const queueWaitsQuery = `SELECT * FROM (SELECT queue_entries.category, queue_entries.joined_at,
+		COALESCE(queue_entries.called_at, queue_entries.served_at) AS called_at,
+		COALESCE(CASE WHEN LENGTH(REPLACE(users.postcode, ' ', '')) >= 5
+			THEN UPPER(LEFT(REPLACE(users.postcode, ' ', ''), LENGTH(REPLACE(users.postcode, ' ', '')) - 3)) END, 'unknown') AS postcode,
+		EXTRACT(EPOCH FROM COALESCE(queue_entries.called_at, queue_entries.served_at) - queue_entries.joined_at) / 60 AS minutes
+		FROM queue_entries LEFT JOIN users ON users.id = queue_entries.visitor_id
+		WHERE queue_entries.deleted_at IS NULL AND queue_entries.status <> 'cancelled'
+		AND COALESCE(queue_entries.called_at, queue_entries.served_at) >= queue_entries.joined_at) w
+		WHERE %s >= ? AND %s < ?`
+
+const queueWaitAggregates = `COUNT(*) AS entries,
+		COALESCE(AVG(minutes), 0) AS average_minutes,
+		COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY minutes), 0) AS median_minutes,
+		COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY minutes), 0) AS p90_minutes`
+
+// queueFairnessGroupings are the SQL expressions each dimension groups and sorts by
+var queueFairnessGroupings = map[string]struct{ group, sort string }{
+	models.QueueFairnessByCategory: {"category", "category"},
+	models.QueueFairnessByPostcode: {"postcode", "postcode"},
+	models.QueueFairnessByHour: {"LPAD(EXTRACT(HOUR FROM joined_at)::text, 2, '0') || ':00'",
+		"LPAD(EXTRACT(HOUR FROM joined_at)::text, 2, '0')"},
+	models.QueueFairnessByWeekday: {"TRIM(TO_CHAR(joined_at, 'Day'))", "EXTRACT(ISODOW FROM joined_at)::text"},
+}
+
+// QueueFairnessDimensions lists the dimensions in report order
+var QueueFairnessDimensions = []string{
+	models.QueueFairnessByCategory,
+	models.QueueFairnessByPostcode,
+	models.QueueFairnessByHour,
+	models.QueueFairnessByWeekday,
+}
+
+var (
+	ErrQueueFairnessDimension      = errors.New("dimension must be category, postcode, hour or weekday")
+	ErrQueueWaitAnomalyNotFound    = errors.New("wait anomaly not found")
+	ErrQueueWaitAnomalyAcked       = errors.New("wait anomaly has already been acknowledged")
+	ErrQueueFairnessReportFuture   = errors.New("the week has not finished yet")
+	ErrQueueFairnessReportNotFound = errors.New("fairness report not found")
+)
+
+// QueueWaitGroup is the wait for visitors sharing a category, postcode district, hour
+// or weekday
+type QueueWaitGroup struct {
+	Group          string  `json:"group"`
+	Entries        int64   `json:"entries"`
+	AverageMinutes float64 `json:"average_minutes"`
+	MedianMinutes  float64 `json:"median_minutes"`
+	P90Minutes     float64 `json:"p90_minutes"`
+	Ratio          float64 `json:"ratio"`       // Median against the overall median
+	Weeks          int     `json:"weeks"`       // Weeks the group had waits in
+	WeeksAbove     int     `json:"weeks_above"` // Weeks its median was above that week's overall median
+	Flagged        bool    `json:"flagged"`     // Waited noticeably longer than everyone
+	Systematic     bool    `json:"systematic"`  // Flagged and above the overall median in most weeks
+	SortKey        string  `json:"-"`
+}
+
+// QueueFairnessBreakdown compares the waits of each group in a dimension against the
+// overall wait
+type QueueFairnessBreakdown struct {
+	Dimension  string           `json:"dimension"`
+	StartDate  string           `json:"start_date"`
+	EndDate    string           `json:"end_date"`
+	Overall    QueueWaitGroup   `json:"overall"`
+	Groups     []QueueWaitGroup `json:"groups"`
+	Suppressed int64            `json:"suppressed"` // Waits left out because their group was too small to show
+}
+
+// QueueFairnessFinding is a group that waited longer than everyone over several weeks
+type QueueFairnessFinding struct {
+	Dimension string `json:"dimension"`
+	QueueWaitGroup
+	OverallMedianMinutes float64 `json:"overall_median_minutes"`
+}
+
+// QueueFairnessWeeklyReport is the content of the weekly trustee report
+type QueueFairnessWeeklyReport struct {
+	WeekStart     string                    `json:"week_start"`
+	WeekEnd       string                    `json:"week_end"`
+	Overall       QueueWaitGroup            `json:"overall"`
+	Breakdowns    []QueueFairnessBreakdown  `json:"breakdowns"`
+	TrailingWeeks int                       `json:"trailing_weeks"`
+	Findings      []QueueFairnessFinding    `json:"findings"`
+	Anomalies     []models.QueueWaitAnomaly `json:"anomalies"`
+}
+
+// QueueFairnessService checks whether some visitors systematically wait longer in the
+// queue than others, and watches for sudden spikes in waits
+type QueueFairnessService struct {
+	db *gorm.DB
+}
+
+// NewQueueFairnessService creates a new queue fairness service
+func NewQueueFairnessService() *QueueFairnessService {
+	return &QueueFairnessService{db: db.DB}
+}
+
+// Breakdown compares waits per group of a dimension for visitors who joined the queue
+// between start and end (inclusive dates)
+func (qf *QueueFairnessService) Breakdown(dimension string, start, end time.Time) (*QueueFairnessBreakdown, error) {
+	grouping, ok := queueFairnessGroupings[dimension]
+	if !ok {
+		return nil, ErrQueueFairnessDimension
+	}
+	from, to := start, end.AddDate(0, 0, 1)
+
+	overall, err := qf.overall(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []QueueWaitGroup
+	query := fmt.Sprintf("SELECT %s AS \"group\", MIN(%s) AS sort_key, %s FROM (%s) waits GROUP BY 1",
+		grouping.group, grouping.sort, queueWaitAggregates, waitsInRange("joined_at"))
+	if err := qf.db.Raw(query, from, to).Scan(&groups).Error; err != nil {
+		return nil, err
+	}
+
+	weeks, err := qf.weeksAbove(grouping.group, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := &QueueFairnessBreakdown{
+		Dimension: dimension,
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Overall:   overall,
+		Groups:    []QueueWaitGroup{},
+	}
+	minPostcodeGroup := fairnessIntSetting("QUEUE_FAIRNESS_MIN_POSTCODE_GROUP", defaultFairnessMinPostcodeGroup)
+	for _, group := range groups {
+		if dimension == models.QueueFairnessByPostcode && group.Entries < int64(minPostcodeGroup) {
+			breakdown.Suppressed += group.Entries
+			continue
+		}
+		group.Weeks, group.WeeksAbove = weeks[group.Group][0], weeks[group.Group][1]
+		qf.assess(&group, overall)
+		breakdown.Groups = append(breakdown.Groups, group)
+	}
+	sort.SliceStable(breakdown.Groups, func(i, j int) bool {
+		return breakdown.Groups[i].SortKey < breakdown.Groups[j].SortKey
+	})
+	return breakdown, nil
+}
+
+// overall aggregates every wait for visitors who joined in [from, to)
+func (qf *QueueFairnessService) overall(from, to time.Time) (QueueWaitGroup, error) {
+	overall := QueueWaitGroup{Group: "all"}
+	query := fmt.Sprintf("SELECT %s FROM (%s) waits", queueWaitAggregates, waitsInRange("joined_at"))
+	if err := qf.db.Raw(query, from, to).Scan(&overall).Error; err != nil {
+		return overall, err
+	}
+	roundQueueWaitGroup(&overall)
+	overall.Ratio = 1
+	return overall, nil
+}
+
+// weeksAbove counts, per group, the weeks it had waits in and the weeks its median
+// wait was above that week's overall median
+func (qf *QueueFairnessService) weeksAbove(groupExpr string, from, to time.Time) (map[string][2]int, error) {
+	var rows []struct {
+		Group      string
+		Weeks      int
+		WeeksAbove int
+	}
+	waits := waitsInRange("joined_at")
+	query := fmt.Sprintf(`SELECT g."group", COUNT(*) AS weeks, COUNT(*) FILTER (WHERE g.median > o.median) AS weeks_above
+		FROM (SELECT date_trunc('week', joined_at) AS week, %s AS "group",
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY minutes) AS median FROM (%s) waits GROUP BY 1, 2) g
+		JOIN (SELECT date_trunc('week', joined_at) AS week,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY minutes) AS median FROM (%s) waits GROUP BY 1) o ON o.week = g.week
+		GROUP BY g."group"`, groupExpr, waits, waits)
+	if err := qf.db.Raw(query, from, to, from, to).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	weeks := make(map[string][2]int, len(rows))
+	for _, row := range rows {
+		weeks[row.Group] = [2]int{row.Weeks, row.WeeksAbove}
+	}
+	return weeks, nil
+}
+
+// assess compares a group's median wait with the overall median. A group is flagged
+// when it has enough waits and its median is both relatively and noticeably longer;
+// it is systematic when it was also above the overall median in most weeks.
+func (qf *QueueFairnessService) assess(group *QueueWaitGroup, overall QueueWaitGroup) {
+	roundQueueWaitGroup(group)
+	if overall.MedianMinutes > 0 {
+		group.Ratio = math.Round(group.MedianMinutes/overall.MedianMinutes*100) / 100
+	}
+
+	ratio := fairnessFloatSetting("QUEUE_FAIRNESS_WAIT_RATIO", defaultFairnessWaitRatio)
+	minExtra := fairnessFloatSetting("QUEUE_FAIRNESS_MIN_EXTRA_MINUTES", defaultFairnessMinExtraMinutes)
+	minSample := fairnessIntSetting("QUEUE_FAIRNESS_MIN_SAMPLE", defaultFairnessMinSample)
+	group.Flagged = group.Entries >= int64(minSample) &&
+		group.MedianMinutes >= overall.MedianMinutes*ratio &&
+		group.MedianMinutes-overall.MedianMinutes >= minExtra
+	group.Systematic = group.Flagged && group.Weeks >= 2 && group.WeeksAbove*2 > group.Weeks
+}
+
+// queueCategoryWait is the number of waits and average wait for a queue category
+type queueCategoryWait struct {
+	Category       string
+	Entries        int64
+	AverageMinutes float64
+}
+
+// isWaitSpike reports whether the current wait is far enough above the usual wait, with
+// enough waits in both, to count as a spike
+func isWaitSpike(current, usual queueCategoryWait, ratio, minMinutes float64) bool {
+	if current.Entries < minWaitSpikeEntries || usual.Entries < minWaitBaselineEntries {
+		return false
+	}
+	return current.AverageMinutes >= usual.AverageMinutes*ratio && current.AverageMinutes-usual.AverageMinutes >= minMinutes
+}
+
+// DetectAnomalies compares each category's average wait over the last hour with the
+// usual wait at that hour over the previous four weeks, and alerts admins and the desk
+// about spikes. Each category is alerted at most once per hour. It returns the new
+// anomalies.
+func (qf *QueueFairnessService) DetectAnomalies(now time.Time) ([]models.QueueWaitAnomaly, error) {
+	aggregate := fmt.Sprintf("SELECT category, COUNT(*) AS entries, COALESCE(AVG(minutes), 0) AS average_minutes FROM (%s) waits",
+		waitsInRange("called_at"))
+
+	var current []queueCategoryWait
+	if err := qf.db.Raw(aggregate+" GROUP BY category", now.Add(-time.Hour), now).Scan(&current).Error; err != nil {
+		return nil, err
+	}
+
+	var baseline []queueCategoryWait
+	baselineFrom := now.AddDate(0, 0, -waitBaselineDays).Truncate(24 * time.Hour)
+	baselineTo := now.Truncate(24 * time.Hour)
+	if err := qf.db.Raw(aggregate+" WHERE EXTRACT(HOUR FROM called_at) = ? GROUP BY category",
+		baselineFrom, baselineTo, now.Hour()).Scan(&baseline).Error; err != nil {
+		return nil, err
+	}
+	usual := make(map[string]queueCategoryWait, len(baseline))
+	for _, b := range baseline {
+		usual[b.Category] = b
+	}
+
+	ratio := fairnessFloatSetting("QUEUE_WAIT_SPIKE_RATIO", defaultWaitSpikeRatio)
+	minMinutes := fairnessFloatSetting("QUEUE_WAIT_SPIKE_MIN_MINUTES", defaultWaitSpikeMinMinutes)
+	detected := []models.QueueWaitAnomaly{}
+	for _, c := range current {
+		b, ok := usual[c.Category]
+		if !ok || !isWaitSpike(c, b, ratio, minMinutes) {
+			continue
+		}
+
+		anomaly := models.QueueWaitAnomaly{
+			Category:           c.Category,
+			WindowStart:        now.Truncate(time.Hour),
+			Entries:            c.Entries,
+			AverageWaitMinutes: math.Round(c.AverageMinutes*10) / 10,
+			BaselineMinutes:    math.Round(b.AverageMinutes*10) / 10,
+			BaselineEntries:    b.Entries,
+			Status:             models.QueueWaitAnomalyOpen,
+		}
+		result := qf.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&anomaly)
+		if result.Error != nil {
+			return detected, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		qf.alertAnomaly(anomaly)
+		detected = append(detected, anomaly)
+	}
+	return detected, nil
+}
+
+// Anomalies lists wait spikes, newest first, optionally filtered by status
+func (qf *QueueFairnessService) Anomalies(status string, limit int) ([]models.QueueWaitAnomaly, error) {
+	var anomalies []models.QueueWaitAnomaly
+	query := qf.db.Order("window_start DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&anomalies).Error
+	return anomalies, err
+}
+
+// AcknowledgeAnomaly records that someone has looked into a wait spike
+func (qf *QueueFairnessService) AcknowledgeAnomaly(id, userID uint, notes string) (*models.QueueWaitAnomaly, error) {
+	var anomaly models.QueueWaitAnomaly
+	if err := qf.db.First(&anomaly, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQueueWaitAnomalyNotFound
+		}
+		return nil, err
+	}
+	if anomaly.Status == models.QueueWaitAnomalyAcknowledged {
+		return nil, ErrQueueWaitAnomalyAcked
+	}
+
+	now := time.Now()
+	anomaly.Status = models.QueueWaitAnomalyAcknowledged
+	anomaly.AcknowledgedBy = &userID
+	anomaly.AcknowledgedAt = &now
+	anomaly.Notes = notes
+	if err := qf.db.Save(&anomaly).Error; err != nil {
+		return nil, err
+	}
+	return &anomaly, nil
+}
+
+// BuildWeeklyReport puts together the fairness report for the week starting on the
+// Monday weekStart. Findings look back over the trailing weeks so a group has to keep
+// waiting longer to be reported.
+func (qf *QueueFairnessService) BuildWeeklyReport(weekStart time.Time) (*QueueFairnessWeeklyReport, error) {
+	weekEnd := weekStart.AddDate(0, 0, 6)
+	trailingWeeks := fairnessIntSetting("QUEUE_FAIRNESS_TRAILING_WEEKS", defaultFairnessTrailingWeeks)
+	trailingStart := weekStart.AddDate(0, 0, -7*(trailingWeeks-1))
+
+	report := &QueueFairnessWeeklyReport{
+		WeekStart:     weekStart.Format("2006-01-02"),
+		WeekEnd:       weekEnd.Format("2006-01-02"),
+		TrailingWeeks: trailingWeeks,
+		Breakdowns:    []QueueFairnessBreakdown{},
+		Findings:      []QueueFairnessFinding{},
+	}
+	for _, dimension := range QueueFairnessDimensions {
+		week, err := qf.Breakdown(dimension, weekStart, weekEnd)
+		if err != nil {
+			return nil, err
+		}
+		report.Overall = week.Overall
+		report.Breakdowns = append(report.Breakdowns, *week)
+
+		trailing, err := qf.Breakdown(dimension, trailingStart, weekEnd)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range trailing.Groups {
+			if group.Systematic {
+				report.Findings = append(report.Findings, QueueFairnessFinding{
+					Dimension:            dimension,
+					QueueWaitGroup:       group,
+					OverallMedianMinutes: trailing.Overall.MedianMinutes,
+				})
+			}
+		}
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Ratio > report.Findings[j].Ratio
+	})
+
+	if err := qf.db.Where("window_start >= ? AND window_start < ?", weekStart, weekEnd.AddDate(0, 0, 1)).
+		Order("window_start").Find(&report.Anomalies).Error; err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GenerateWeeklyReport builds and stores the report for a finished week and emails it
+// to trustees. Generating a week again replaces its report.
+func (qf *QueueFairnessService) GenerateWeeklyReport(weekStart time.Time, send bool) (*models.QueueFairnessReport, error) {
+	weekStart = truncateSLAPeriod(weekStart, slaTrendIntervalWeek)
+	if weekStart.AddDate(0, 0, 7).After(time.Now()) {
+		return nil, ErrQueueFairnessReportFuture
+	}
+
+	content, err := qf.BuildWeeklyReport(weekStart)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	record := models.QueueFairnessReport{
+		WeekStart:         content.WeekStart,
+		WeekEnd:           content.WeekEnd,
+		Entries:           content.Overall.Entries,
+		MedianWaitMinutes: content.Overall.MedianMinutes,
+		Findings:          len(content.Findings),
+		Anomalies:         len(content.Anomalies),
+		Content:           string(data),
+		GeneratedAt:       time.Now(),
+	}
+	if send {
+		record.EmailedTo = qf.emailReport(content)
+	}
+	if err := qf.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "week_start"}},
+		DoUpdates: clause.AssignmentColumns([]string{"week_end", "entries", "median_wait_minutes", "findings", "anomalies", "content", "emailed_to", "generated_at", "updated_at"}),
+	}).Create(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// EnsureWeeklyReport sends last week's report once it is Monday morning, if it has not
+// been sent already. It returns the report when one was generated.
+func (qf *QueueFairnessService) EnsureWeeklyReport(now time.Time) (*models.QueueFairnessReport, error) {
+	thisWeek := truncateSLAPeriod(now, slaTrendIntervalWeek)
+	if now.Before(thisWeek.Add(time.Duration(fairnessIntSetting("QUEUE_FAIRNESS_REPORT_HOUR", defaultFairnessReportHour)) * time.Hour)) {
+		return nil, nil
+	}
+
+	lastWeek := thisWeek.AddDate(0, 0, -7)
+	var existing int64
+	if err := qf.db.Model(&models.QueueFairnessReport{}).
+		Where("week_start = ?", lastWeek.Format("2006-01-02")).Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, nil
+	}
+	return qf.GenerateWeeklyReport(lastWeek, true)
+}
+
+// Reports lists the stored weekly reports, newest first
+func (qf *QueueFairnessService) Reports(limit int) ([]models.QueueFairnessReport, error) {
+	var reports []models.QueueFairnessReport
+	err := qf.db.Omit("content").Order("week_start DESC").Limit(limit).Find(&reports).Error
+	return reports, err
+}
+
+// Report returns a stored weekly report with its content
+func (qf *QueueFairnessService) Report(weekStart string) (*models.QueueFairnessReport, *QueueFairnessWeeklyReport, error) {
+	var record models.QueueFairnessReport
+	if err := qf.db.Where("week_start = ?", weekStart).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrQueueFairnessReportNotFound
+		}
+		return nil, nil, err
+	}
+
+	var content QueueFairnessWeeklyReport
+	if err := json.Unmarshal([]byte(record.Content), &content); err != nil {
+		return nil, nil, err
+	}
+	return &record, &content, nil
+}
+
+// emailReport sends the weekly report to trustees and any addresses in
+// QUEUE_FAIRNESS_REPORT_RECIPIENTS, returning how many it reached
+func (qf *QueueFairnessService) emailReport(report *QueueFairnessWeeklyReport) int {
+	subject := fmt.Sprintf("Queue fairness report for the week of %s", report.WeekStart)
+	body := queueFairnessEmailBody(report)
+	sent := 0
+	for _, email := range trusteeRecipients(qf.db, "QUEUE_FAIRNESS_REPORT_RECIPIENTS") {
+		if err := notifications.GetService().SendEmail(email, subject, body); err != nil {
+			log.Printf("Failed to email the fairness report to %s: %v", email, err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+// trusteeRecipients returns the email addresses of trustees (analytics viewer admins)
+// and the extra comma-separated addresses in the env variable, without duplicates
+func trusteeRecipients(db *gorm.DB, env string) []string {
+	var trustees []models.User
+	if err := db.Where("role = ? AND admin_scope = ? AND status = ?",
+		models.RoleAdmin, models.AdminScopeAnalyticsViewer, models.StatusActive).
+		Find(&trustees).Error; err != nil {
+		log.Printf("Failed to load trustees for a report: %v", err)
+	}
+
+	seen := map[string]bool{}
+	var recipients []string
+	add := func(email string) {
+		if email = strings.TrimSpace(email); email != "" && !seen[strings.ToLower(email)] {
+			seen[strings.ToLower(email)] = true
+			recipients = append(recipients, email)
+		}
+	}
+	for _, trustee := range trustees {
+		add(trustee.Email)
+	}
+	for _, email := range strings.Split(os.Getenv(env), ",") {
+		add(email)
+	}
+	return recipients
+}
+
+// queueFairnessEmailBody writes the weekly report as plain text
+func queueFairnessEmailBody(report *QueueFairnessWeeklyReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Queue fairness for %s to %s\n\n", report.WeekStart, report.WeekEnd)
+	fmt.Fprintf(&b, "%d visitors were called from the queue. The median wait was %.0f minutes and 1 in 10 waited over %.0f minutes.\n\n",
+		report.Overall.Entries, report.Overall.MedianMinutes, report.Overall.P90Minutes)
+
+	if len(report.Findings) == 0 {
+		fmt.Fprintf(&b, "No category, area, time of day or weekday has waited noticeably longer over the last %d weeks.\n", report.TrailingWeeks)
+	} else {
+		fmt.Fprintf(&b, "Waiting longer over the last %d weeks:\n", report.TrailingWeeks)
+		for _, finding := range report.Findings {
+			fmt.Fprintf(&b, "- %s %s: median %.0f minutes against %.0f overall (%.2fx), longer in %d of %d weeks, %d visitors\n",
+				finding.Dimension, finding.Group, finding.MedianMinutes, finding.OverallMedianMinutes,
+				finding.Ratio, finding.WeeksAbove, finding.Weeks, finding.Entries)
+		}
+	}
+
+	if len(report.Anomalies) > 0 {
+		fmt.Fprintf(&b, "\nWait spikes this week:\n")
+		for _, anomaly := range report.Anomalies {
+			fmt.Fprintf(&b, "- %s %s: average %.0f minutes against a usual %.0f\n",
+				anomaly.WindowStart.Format("Mon 2 Jan 15:04"), anomaly.Category, anomaly.AverageWaitMinutes, anomaly.BaselineMinutes)
+		}
+	}
+	return b.String()
+}
+
+// alertAnomaly tells the desk and admins about a wait spike
+func (qf *QueueFairnessService) alertAnomaly(anomaly models.QueueWaitAnomaly) {
+	title := fmt.Sprintf("Queue waits spiking for %s", anomaly.Category)
+	message := fmt.Sprintf("Visitors called for %s in the last hour waited %.0f minutes on average, against a usual %.0f minutes at this time.",
+		anomaly.Category, anomaly.AverageWaitMinutes, anomaly.BaselineMinutes)
+
+	alert := map[string]interface{}{
+		"type":    "queue_wait_anomaly",
+		"title":   title,
+		"message": message,
+		"anomaly": anomaly,
+	}
+	manager := websocket.GetGlobalManager()
+	for _, role := range []string{models.RoleStaff, models.RoleAdmin} {
+		if err := manager.BroadcastToRole(role, alert); err != nil {
+			log.Printf("Failed to broadcast wait anomaly to %s: %v", role, err)
+		}
+	}
+
+	var admins []models.User
+	if err := qf.db.Where("role IN ? AND status = ?",
+		[]string{models.RoleAdmin, models.RoleSuperAdmin}, models.StatusActive).
+		Find(&admins).Error; err != nil {
+		log.Printf("Failed to load admins for wait anomaly alert: %v", err)
+		return
+	}
+	for _, admin := range admins {
+		notification := models.InAppNotification{
+			UserID:    admin.ID,
+			Title:     title,
+			Message:   message,
+			Type:      "warning",
+			Priority:  models.PriorityHigh,
+			ActionURL: queueFairnessAlertAction,
+		}
+		if err := qf.db.Create(&notification).Error; err != nil {
+			log.Printf("Failed to create wait anomaly alert for admin %d: %v", admin.ID, err)
+		}
+	}
+}
+
+// waitsInRange selects queue waits with the time column in [?, ?)
+func waitsInRange(column string) string {
+	return fmt.Sprintf(queueWaitsQuery, column, column)
+}
+
+// roundQueueWaitGroup rounds the minute figures to one decimal place
+func roundQueueWaitGroup(group *QueueWaitGroup) {
+	group.AverageMinutes = math.Round(group.AverageMinutes*10) / 10
+	group.MedianMinutes = math.Round(group.MedianMinutes*10) / 10
+	group.P90Minutes = math.Round(group.P90Minutes*10) / 10
+}
+
+// fairnessFloatSetting reads a positive float from the environment
+func fairnessFloatSetting(env string, fallback float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(env), 64); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// fairnessIntSetting reads a positive int from the environment
+func fairnessIntSetting(env string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(env)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
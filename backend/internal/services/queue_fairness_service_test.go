@@ -0,0 +1,121 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestAssessQueueWaitGroup(t *testing.T) {
+	qf := &QueueFairnessService{}
+	overall := QueueWaitGroup{MedianMinutes: 20}
+	tests := []struct {
+		name       string
+		group      QueueWaitGroup
+		ratio      float64
+		flagged    bool
+		systematic bool
+	}{
+		{"longer most weeks", QueueWaitGroup{Entries: 40, MedianMinutes: 30, Weeks: 6, WeeksAbove: 4}, 1.5, true, true},
+		{"longer half the weeks", QueueWaitGroup{Entries: 40, MedianMinutes: 30, Weeks: 6, WeeksAbove: 3}, 1.5, true, false},
+		{"one week only", QueueWaitGroup{Entries: 40, MedianMinutes: 30, Weeks: 1, WeeksAbove: 1}, 1.5, true, false},
+		{"too few waits", QueueWaitGroup{Entries: 19, MedianMinutes: 30, Weeks: 6, WeeksAbove: 6}, 1.5, false, false},
+		{"relatively short", QueueWaitGroup{Entries: 40, MedianMinutes: 24.04, Weeks: 6, WeeksAbove: 6}, 1.2, false, false},
+	}
+	for _, tt := range tests {
+		group := tt.group
+		qf.assess(&group, overall)
+		if group.Ratio != tt.ratio || group.Flagged != tt.flagged || group.Systematic != tt.systematic {
+			t.Errorf("%s: got ratio %v, flagged %v, systematic %v", tt.name, group.Ratio, group.Flagged, group.Systematic)
+		}
+	}
+
+	// A few extra minutes on a short wait is not flagged, however large the ratio
+	short := QueueWaitGroup{Entries: 40, MedianMinutes: 6}
+	qf.assess(&short, QueueWaitGroup{MedianMinutes: 2})
+	if short.Ratio != 3 || short.Flagged {
+		t.Errorf("short waits: got %+v", short)
+	}
+
+	// Without an overall median there is nothing to compare against
+	none := QueueWaitGroup{Entries: 40, MedianMinutes: 6}
+	qf.assess(&none, QueueWaitGroup{})
+	if none.Ratio != 0 {
+		t.Errorf("no overall median: got ratio %v", none.Ratio)
+	}
+}
+
+func TestIsWaitSpike(t *testing.T) {
+	usual := queueCategoryWait{Entries: 40, AverageMinutes: 20}
+	tests := []struct {
+		name    string
+		current queueCategoryWait
+		usual   queueCategoryWait
+		want    bool
+	}{
+		{"double and longer", queueCategoryWait{Entries: 8, AverageMinutes: 45}, usual, true},
+		{"not double", queueCategoryWait{Entries: 8, AverageMinutes: 39}, usual, false},
+		{"too few waits", queueCategoryWait{Entries: 4, AverageMinutes: 60}, usual, false},
+		{"thin baseline", queueCategoryWait{Entries: 8, AverageMinutes: 60}, queueCategoryWait{Entries: 19, AverageMinutes: 20}, false},
+		{"double but short", queueCategoryWait{Entries: 8, AverageMinutes: 10}, queueCategoryWait{Entries: 40, AverageMinutes: 4}, false},
+	}
+	for _, tt := range tests {
+		if got := isWaitSpike(tt.current, tt.usual, defaultWaitSpikeRatio, defaultWaitSpikeMinMinutes); got != tt.want {
+			t.Errorf("%s: got %v", tt.name, got)
+		}
+	}
+}
+
+func TestQueueFairnessChecks(t *testing.T) {
+	qf := &QueueFairnessService{}
+	if _, err := qf.Breakdown("ethnicity", time.Now(), time.Now()); err != ErrQueueFairnessDimension {
+		t.Errorf("unknown dimension: got %v", err)
+	}
+	if _, err := qf.GenerateWeeklyReport(time.Now(), false); err != ErrQueueFairnessReportFuture {
+		t.Errorf("this week: got %v", err)
+	}
+	for _, dimension := range QueueFairnessDimensions {
+		if _, ok := queueFairnessGroupings[dimension]; !ok {
+			t.Errorf("%s has no grouping", dimension)
+		}
+	}
+}
+
+func TestQueueFairnessEmailBody(t *testing.T) {
+	report := &QueueFairnessWeeklyReport{
+		WeekStart:     "2026-04-13",
+		WeekEnd:       "2026-04-19",
+		Overall:       QueueWaitGroup{Entries: 310, MedianMinutes: 18, P90Minutes: 41},
+		TrailingWeeks: 8,
+	}
+	body := queueFairnessEmailBody(report)
+	for _, want := range []string{
+		"Queue fairness for 2026-04-13 to 2026-04-19",
+		"310 visitors were called from the queue. The median wait was 18 minutes and 1 in 10 waited over 41 minutes.",
+		"No category, area, time of day or weekday has waited noticeably longer over the last 8 weeks.",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+
+	report.Findings = []QueueFairnessFinding{{
+		Dimension:            models.QueueFairnessByPostcode,
+		QueueWaitGroup:       QueueWaitGroup{Group: "SE6", Entries: 64, MedianMinutes: 27, Ratio: 1.5, Weeks: 8, WeeksAbove: 7},
+		OverallMedianMinutes: 18,
+	}}
+	report.Anomalies = []models.QueueWaitAnomaly{{
+		Category: "food", WindowStart: time.Date(2026, 4, 15, 11, 0, 0, 0, time.UTC), AverageWaitMinutes: 52, BaselineMinutes: 19,
+	}}
+	body = queueFairnessEmailBody(report)
+	for _, want := range []string{
+		"- postcode SE6: median 27 minutes against 18 overall (1.50x), longer in 7 of 8 weeks, 64 visitors",
+		"- Wed 15 Apr 11:00 food: average 52 minutes against a usual 19",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/websocket"
+)
+
+// QueueDisplayTopic is the WebSocket topic display screens subscribe to for the
+// "now serving" board
+const QueueDisplayTopic = "queue_display"
+
+// queueBoardNextUp is how many waiting tickets the display board lists
+const queueBoardNextUp = 5
+
+// activeQueueStatuses are queue entries still waiting, called or being served
+var activeQueueStatuses = []string{"waiting", "called", "recall", "being_served"}
+
+// QueueBoardEntry is a ticket on the display board. Boards show ticket references
+// only, never names.
+type QueueBoardEntry struct {
+	Reference string     `json:"reference"`
+	Position  int        `json:"position,omitempty"`
+	Status    string     `json:"status"`
+	CalledAt  *time.Time `json:"called_at,omitempty"`
+}
+
+// QueueBoard is the "now serving" board for one service category
+type QueueBoard struct {
+	Category      string            `json:"category"`
+	NowServing    []QueueBoardEntry `json:"now_serving"` // Called or at the desk, most recent first
+	NextUp        []QueueBoardEntry `json:"next_up"`
+	Waiting       int               `json:"waiting"`
+	EstimatedWait string            `json:"estimated_wait"` // For someone joining now
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// VisitorQueueUpdate tells a visitor where they are in the queue
+type VisitorQueueUpdate struct {
+	Type          string   `json:"type"`
+	QueueID       uint     `json:"queue_id"`
+	Category      string   `json:"category"`
+	Reference     string   `json:"reference"`
+	Status        string   `json:"status"`
+	Position      int      `json:"position"`
+	PeopleAhead   int      `json:"people_ahead"`
+	EstimatedWait string   `json:"estimated_wait"`
+	NowServing    []string `json:"now_serving"` // Ticket references being served in the visitor's category
+	Timestamp     int64    `json:"timestamp"`
+}
+
+// QueueBoards returns the "now serving" board for each category with people queueing
+func (qs *QueueService) QueueBoards() ([]QueueBoard, error) {
+	entries, err := qs.activeEntries()
+	if err != nil {
+		return nil, err
+	}
+	return qs.buildQueueBoards(entries, time.Now()), nil
+}
+
+// VisitorQueueStatus returns a visitor's place in the queue
+func (qs *QueueService) VisitorQueueStatus(visitorID uint) (*VisitorQueueUpdate, error) {
+	entries, err := qs.activeEntries()
+	if err != nil {
+		return nil, err
+	}
+	for _, update := range qs.buildVisitorQueueUpdates(entries, time.Now()) {
+		if update.visitorID == visitorID {
+			return &update.VisitorQueueUpdate, nil
+		}
+	}
+	return nil, fmt.Errorf("visitor not found in queue")
+}
+
+// publishLiveQueue pushes the display boards to display screens and each queueing
+// visitor's position, wait estimate and the tickets being served
+func (qs *QueueService) publishLiveQueue() {
+	entries, err := qs.activeEntries()
+	if err != nil {
+		log.Printf("Failed to load queue for live updates: %v", err)
+		return
+	}
+	now := time.Now()
+	manager := websocket.GetGlobalManager()
+
+	if err := manager.BroadcastToTopic(QueueDisplayTopic, map[string]interface{}{
+		"type":   "queue_board",
+		"boards": qs.buildQueueBoards(entries, now),
+	}); err != nil {
+		log.Printf("Failed to broadcast queue board: %v", err)
+	}
+
+	for _, update := range qs.buildVisitorQueueUpdates(entries, now) {
+		if !manager.UserOnline(update.visitorID) {
+			continue
+		}
+		if err := manager.BroadcastToUser(update.visitorID, update.VisitorQueueUpdate); err != nil {
+			log.Printf("Failed to send queue position to visitor %d: %v", update.visitorID, err)
+		}
+	}
+}
+
+// activeEntries loads the queue entries still in the queue, in queue order
+func (qs *QueueService) activeEntries() ([]models.QueueEntry, error) {
+	var entries []models.QueueEntry
+	err := qs.db.Where("status IN ?", activeQueueStatuses).Order("position ASC, joined_at ASC").Find(&entries).Error
+	return entries, err
+}
+
+// buildQueueBoards groups active queue entries into a board per category
+func (qs *QueueService) buildQueueBoards(entries []models.QueueEntry, now time.Time) []QueueBoard {
+	byCategory := map[string]*QueueBoard{}
+	var categories []string
+	for _, entry := range entries {
+		board, ok := byCategory[entry.Category]
+		if !ok {
+			board = &QueueBoard{Category: entry.Category, NowServing: []QueueBoardEntry{}, NextUp: []QueueBoardEntry{}, UpdatedAt: now}
+			byCategory[entry.Category] = board
+			categories = append(categories, entry.Category)
+		}
+
+		ticket := QueueBoardEntry{Reference: queueReference(entry), Status: entry.Status, CalledAt: entry.CalledAt}
+		switch entry.Status {
+		case "waiting":
+			board.Waiting++
+			if len(board.NextUp) < queueBoardNextUp {
+				ticket.Position = board.Waiting
+				board.NextUp = append(board.NextUp, ticket)
+			}
+		default:
+			board.NowServing = append(board.NowServing, ticket)
+		}
+	}
+
+	boards := make([]QueueBoard, 0, len(categories))
+	sort.Strings(categories)
+	for _, category := range categories {
+		board := byCategory[category]
+		sort.SliceStable(board.NowServing, func(i, j int) bool {
+			return calledAt(board.NowServing[i]).After(calledAt(board.NowServing[j]))
+		})
+		board.EstimatedWait = qs.calculateEstimatedWaitTime(board.Waiting+1, category)
+		boards = append(boards, *board)
+	}
+	return boards
+}
+
+// visitorQueueUpdate is a queue update addressed to a visitor
+type visitorQueueUpdate struct {
+	VisitorQueueUpdate
+	visitorID uint
+}
+
+// buildVisitorQueueUpdates works out each queueing visitor's position within their
+// category and the tickets being served there
+func (qs *QueueService) buildVisitorQueueUpdates(entries []models.QueueEntry, now time.Time) []visitorQueueUpdate {
+	serving := map[string][]string{}
+	for _, entry := range entries {
+		if entry.Status != "waiting" {
+			serving[entry.Category] = append(serving[entry.Category], queueReference(entry))
+		}
+	}
+
+	waiting := map[string]int{}
+	updates := make([]visitorQueueUpdate, 0, len(entries))
+	for _, entry := range entries {
+		nowServing := serving[entry.Category]
+		if nowServing == nil {
+			nowServing = []string{}
+		}
+		update := VisitorQueueUpdate{
+			Type:       "queue_position",
+			QueueID:    entry.ID,
+			Category:   entry.Category,
+			Reference:  queueReference(entry),
+			Status:     entry.Status,
+			NowServing: nowServing,
+			Timestamp:  now.Unix(),
+		}
+		if entry.Status == "waiting" {
+			waiting[entry.Category]++
+			update.Position = waiting[entry.Category]
+			update.PeopleAhead = update.Position - 1
+			update.EstimatedWait = qs.calculateEstimatedWaitTime(update.Position, entry.Category)
+		} else {
+			update.EstimatedWait = "Now"
+		}
+		updates = append(updates, visitorQueueUpdate{VisitorQueueUpdate: update, visitorID: entry.VisitorID})
+	}
+	return updates
+}
+
+// queueReference is the ticket reference shown for a queue entry
+func queueReference(entry models.QueueEntry) string {
+	if entry.Reference != "" {
+		return entry.Reference
+	}
+	return fmt.Sprintf("Q-%d", entry.ID)
+}
+
+// calledAt orders board entries with uncalled ones last
+func calledAt(entry QueueBoardEntry) time.Time {
+	if entry.CalledAt == nil {
+		return time.Time{}
+	}
+	return *entry.CalledAt
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestBuildQueueBoards(t *testing.T) {
+	now := time.Date(2026, 3, 10, 11, 0, 0, 0, time.Local)
+	earlier, later := now.Add(-10*time.Minute), now.Add(-2*time.Minute)
+	entries := []models.QueueEntry{
+		{ID: 1, VisitorID: 11, Category: "food", Reference: "LDH-001", Status: "being_served", CalledAt: &earlier},
+		{ID: 2, VisitorID: 12, Category: "food", Reference: "LDH-002", Status: "called", CalledAt: &later},
+		{ID: 3, VisitorID: 13, Category: "food", Status: "waiting"},
+		{ID: 4, VisitorID: 14, Category: "advice", Reference: "LDH-004", Status: "waiting"},
+		{ID: 5, VisitorID: 15, Category: "food", Reference: "LDH-005", Status: "waiting"},
+	}
+
+	qs := &QueueService{}
+	boards := qs.buildQueueBoards(entries, now)
+	if len(boards) != 2 || boards[0].Category != "advice" || boards[1].Category != "food" {
+		t.Fatalf("got boards %+v, want advice then food", boards)
+	}
+	food := boards[1]
+	if food.Waiting != 2 || len(food.NextUp) != 2 || food.NextUp[0].Reference != "Q-3" || food.NextUp[1].Position != 2 {
+		t.Errorf("food next up = %+v, waiting %d", food.NextUp, food.Waiting)
+	}
+	if len(food.NowServing) != 2 || food.NowServing[0].Reference != "LDH-002" {
+		t.Errorf("food now serving = %+v, want the most recent call first", food.NowServing)
+	}
+	if food.EstimatedWait != "30 minutes" {
+		t.Errorf("food estimated wait = %q, want 30 minutes for a third person", food.EstimatedWait)
+	}
+
+	updates := qs.buildVisitorQueueUpdates(entries, now)
+	byVisitor := map[uint]VisitorQueueUpdate{}
+	for _, update := range updates {
+		byVisitor[update.visitorID] = update.VisitorQueueUpdate
+	}
+	if got := byVisitor[15]; got.Position != 2 || got.PeopleAhead != 1 || got.EstimatedWait != "15 minutes" || len(got.NowServing) != 2 {
+		t.Errorf("visitor 15 update = %+v", got)
+	}
+	if got := byVisitor[14]; got.Position != 1 || got.EstimatedWait != "Now" || len(got.NowServing) != 0 {
+		t.Errorf("visitor 14 update = %+v, want first in the advice queue", got)
+	}
+	if got := byVisitor[12]; got.Status != "called" || got.Position != 0 {
+		t.Errorf("visitor 12 update = %+v, want called with no position", got)
+	}
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// defaultQueueCallGraceMinutes is how long a called visitor has to reach the desk
+	defaultQueueCallGraceMinutes = 5
+	// defaultQueueMaxMissedCalls is how many calls a visitor can miss before they are
+	// marked as a no-show
+	defaultQueueMaxMissedCalls = 2
+)
+
+var (
+	ErrRecallEntryNotFound = errors.New("queue entry is not in the re-call pool")
+)
+
+// MissedCallOutcome is what happened to a called visitor who did not come forward
+type MissedCallOutcome struct {
+	EntryID     uint   `json:"entry_id"`
+	VisitorID   uint   `json:"visitor_id"`
+	Category    string `json:"category"`
+	MissedCalls int    `json:"missed_calls"`
+	Status      string `json:"status"`      // recall or no_show
+	NextCalled  uint   `json:"next_called"` // Entry called in their place, if any
+}
+
+// QueueRecallService handles called visitors who do not come to the desk. After a
+// grace period the entry moves to a re-call pool and the next visitor is called; a
+// visitor who misses too many calls is marked as a no-show.
+type QueueRecallService struct {
+	db *gorm.DB
+}
+
+// NewQueueRecallService creates a new queue re-call service
+func NewQueueRecallService() *QueueRecallService {
+	return &QueueRecallService{db: db.DB}
+}
+
+// queueCallGrace is how long a called visitor has before the call counts as missed
+func queueCallGrace() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("QUEUE_CALL_GRACE_MINUTES")); err == nil && v > 0 {
+		return time.Duration(v) * time.Minute
+	}
+	return defaultQueueCallGraceMinutes * time.Minute
+}
+
+// queueMaxMissedCalls is how many missed calls make a visitor a no-show
+func queueMaxMissedCalls() int {
+	if v, err := strconv.Atoi(os.Getenv("QUEUE_MAX_MISSED_CALLS")); err == nil && v > 0 {
+		return v
+	}
+	return defaultQueueMaxMissedCalls
+}
+
+// missedCallStatus returns the status an entry moves to after missing a call
+func missedCallStatus(missedCalls, maxMissed int) string {
+	if missedCalls >= maxMissed {
+		return "no_show"
+	}
+	return "recall"
+}
+
+// ExpireCalls handles every call whose grace period has run out by now, calling the
+// next visitor in each one's place
+func (rs *QueueRecallService) ExpireCalls(now time.Time) ([]MissedCallOutcome, error) {
+	var due []models.QueueEntry
+	if err := rs.db.Where("status = ? AND called_at <= ?", "called", now.Add(-queueCallGrace())).
+		Order("called_at").Find(&due).Error; err != nil {
+		return nil, err
+	}
+
+	maxMissed := queueMaxMissedCalls()
+	var outcomes []MissedCallOutcome
+	for _, candidate := range due {
+		var entry models.QueueEntry
+		err := rs.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&entry, candidate.ID).Error; err != nil {
+				return err
+			}
+			if entry.Status != "called" {
+				return nil // Served or cancelled since it was loaded
+			}
+
+			entry.MissedCalls++
+			entry.Status = missedCallStatus(entry.MissedCalls, maxMissed)
+			if entry.Status == "no_show" {
+				entry.CancelledAt = &now
+				entry.Notes = fmt.Sprintf("No-show after %d missed calls", entry.MissedCalls)
+			} else {
+				entry.RecallPoolAt = &now
+			}
+			return tx.Save(&entry).Error
+		})
+		if err != nil {
+			log.Printf("Failed to handle missed call for queue entry %d: %v", candidate.ID, err)
+			continue
+		}
+		if entry.Status != "recall" && entry.Status != "no_show" {
+			continue
+		}
+
+		outcome := MissedCallOutcome{
+			EntryID:     entry.ID,
+			VisitorID:   entry.VisitorID,
+			Category:    entry.Category,
+			MissedCalls: entry.MissedCalls,
+			Status:      entry.Status,
+		}
+		rs.notifyMissedCall(entry)
+		if next, err := rs.CallNext(entry.Category, entry.ID); err != nil {
+			log.Printf("Failed to call the next visitor after queue entry %d: %v", entry.ID, err)
+		} else {
+			outcome.NextCalled = next
+		}
+		broadcastQueue(map[string]interface{}{
+			"type":         "missed_call",
+			"category":     entry.Category,
+			"reference":    entry.Reference,
+			"status":       entry.Status,
+			"missed_calls": entry.MissedCalls,
+		})
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes, nil
+}
+
+// CallNext calls the next waiting visitor in a category and returns their entry, or 0
+// if there is nobody to call. When nobody is waiting the longest-waiting visitor in the
+// re-call pool is called again instead, other than the entry given in skip.
+func (rs *QueueRecallService) CallNext(category string, skip uint) (uint, error) {
+	var waiting int64
+	rs.db.Model(&models.QueueEntry{}).Where("category = ? AND status = ?", category, "waiting").Count(&waiting)
+	if waiting > 0 {
+		next, err := NewQueueService().CallNext(category)
+		if err != nil {
+			return 0, err
+		}
+		return next.ID, nil
+	}
+
+	var pooled models.QueueEntry
+	err := rs.db.Where("category = ? AND status = ? AND id <> ?", category, "recall", skip).
+		Order("recall_pool_at ASC").First(&pooled).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	entry, err := rs.Recall(pooled.ID)
+	if err != nil {
+		return 0, err
+	}
+	return entry.ID, nil
+}
+
+// Pool returns the entries waiting to be called again, longest waiting first
+func (rs *QueueRecallService) Pool(category string) ([]models.QueueEntry, error) {
+	query := rs.db.Preload("Visitor").Where("status = ?", "recall").Order("recall_pool_at ASC")
+	if category != "" && category != "all" {
+		query = query.Where("category = ?", category)
+	}
+
+	var entries []models.QueueEntry
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Recall calls a visitor in the re-call pool again, starting a new grace period
+func (rs *QueueRecallService) Recall(entryID uint) (*models.QueueEntry, error) {
+	var entry models.QueueEntry
+	err := rs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status = ?", entryID, "recall").First(&entry).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRecallEntryNotFound
+			}
+			return err
+		}
+		entry.MarkCalled()
+		return tx.Save(&entry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := GetGlobalRealtimeNotificationService().SendNotification(RealtimeNotificationData{
+		UserID:   entry.VisitorID,
+		Type:     "queue_called",
+		Title:    "You're Being Called Again",
+		Message:  "We missed you earlier. Please come to the service desk now.",
+		Priority: "high",
+		Category: "queue",
+		Channels: []string{"websocket", "push"},
+		Data: map[string]interface{}{
+			"queue_id":     entry.ID,
+			"service_type": entry.Category,
+			"missed_calls": entry.MissedCalls,
+		},
+	}); err != nil {
+		log.Printf("Failed to notify visitor %d of re-call: %v", entry.VisitorID, err)
+	}
+	broadcastQueue(map[string]interface{}{
+		"type":      "visitor_recalled",
+		"category":  entry.Category,
+		"reference": entry.Reference,
+	})
+	return &entry, nil
+}
+
+// notifyMissedCall tells a visitor they missed their call, and whether they will be
+// called again
+func (rs *QueueRecallService) notifyMissedCall(entry models.QueueEntry) {
+	data := RealtimeNotificationData{
+		UserID:   entry.VisitorID,
+		Type:     "queue_missed_call",
+		Title:    "We Missed You",
+		Message:  "You were called but we couldn't find you. We'll call you again shortly, so please stay close to the service desk.",
+		Priority: "high",
+		Category: "queue",
+		Channels: []string{"websocket", "push"},
+		Data: map[string]interface{}{
+			"queue_id":     entry.ID,
+			"missed_calls": entry.MissedCalls,
+		},
+	}
+	if entry.Status == "no_show" {
+		data.Type = "queue_no_show"
+		data.Title = "Marked as No-Show"
+		data.Message = fmt.Sprintf("You didn't come forward after %d calls, so your place in the queue has been released. Please speak to a member of staff if you still need help.", entry.MissedCalls)
+	}
+
+	if err := GetGlobalRealtimeNotificationService().SendNotification(data); err != nil {
+		log.Printf("Failed to notify visitor %d of missed call: %v", entry.VisitorID, err)
+	}
+}
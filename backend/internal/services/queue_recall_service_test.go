@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMissedCallStatus(t *testing.T) {
+	cases := []struct {
+		missed, max int
+		want        string
+	}{
+		{1, 2, "recall"},
+		{2, 2, "no_show"},
+		{3, 2, "no_show"},
+		{1, 1, "no_show"},
+		{2, 3, "recall"},
+	}
+	for _, tc := range cases {
+		if got := missedCallStatus(tc.missed, tc.max); got != tc.want {
+			t.Errorf("missedCallStatus(%d, %d) = %q, want %q", tc.missed, tc.max, got, tc.want)
+		}
+	}
+}
+
+func TestQueueCallGrace(t *testing.T) {
+	t.Setenv("QUEUE_CALL_GRACE_MINUTES", "")
+	if got := queueCallGrace(); got != 5*time.Minute {
+		t.Errorf("expected a 5 minute default grace period, got %s", got)
+	}
+	t.Setenv("QUEUE_CALL_GRACE_MINUTES", "3")
+	if got := queueCallGrace(); got != 3*time.Minute {
+		t.Errorf("expected a 3 minute grace period, got %s", got)
+	}
+	t.Setenv("QUEUE_CALL_GRACE_MINUTES", "-1")
+	if got := queueCallGrace(); got != 5*time.Minute {
+		t.Errorf("expected an invalid setting to fall back to 5 minutes, got %s", got)
+	}
+}
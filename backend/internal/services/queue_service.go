@@ -23,7 +23,7 @@ type QueueEntry struct {
 	VisitorID     uint       `json:"visitor_id"`
 	Position      int        `json:"position"`
 	EstimatedWait string     `json:"estimated_wait"`
-	Status        string     `json:"status"`       // "waiting", "called", "being_served", "completed", "cancelled"
+	Status        string     `json:"status"`       // "waiting", "called", "recall", "being_served", "completed", "cancelled", "no_show"
 	Priority      string     `json:"priority"`     // "normal", "urgent", "elderly", "disability"
 	ServiceType   string     `json:"service_type"` // "food", "clothing", "advice", "general"
 	JoinedAt      time.Time  `json:"joined_at"`
@@ -59,7 +59,7 @@ func NewQueueService() *QueueService {
 func (qs *QueueService) AddToQueue(visitorID uint, serviceType, priority string, notes string) (*QueueEntry, error) {
 	// Check if visitor is already in queue
 	var existingEntry models.QueueEntry
-	if err := qs.db.Where("visitor_id = ? AND status IN ?", visitorID, []string{"waiting", "called", "being_served"}).First(&existingEntry).Error; err == nil {
+	if err := qs.db.Where("visitor_id = ? AND status IN ?", visitorID, []string{"waiting", "called", "recall", "being_served"}).First(&existingEntry).Error; err == nil {
 		return nil, fmt.Errorf("visitor is already in queue")
 	}
 
@@ -127,7 +127,7 @@ func (qs *QueueService) AddToQueue(visitorID uint, serviceType, priority string,
 // GetQueuePosition returns the current position of a visitor in the queue
 func (qs *QueueService) GetQueuePosition(visitorID uint) (*QueueEntry, error) {
 	var queueEntry models.QueueEntry
-	if err := qs.db.Where("visitor_id = ? AND status IN ?", visitorID, []string{"waiting", "called", "being_served"}).First(&queueEntry).Error; err != nil {
+	if err := qs.db.Where("visitor_id = ? AND status IN ?", visitorID, []string{"waiting", "called", "recall", "being_served"}).First(&queueEntry).Error; err != nil {
 		return nil, fmt.Errorf("visitor not found in queue")
 	}
 
@@ -156,7 +156,7 @@ func (qs *QueueService) GetQueuePosition(visitorID uint) (*QueueEntry, error) {
 // GetQueue returns the current queue with all waiting visitors
 func (qs *QueueService) GetQueue(serviceType string) ([]*QueueEntry, error) {
 	var queueEntries []models.QueueEntry
-	query := qs.db.Where("status IN ?", []string{"waiting", "called", "being_served"}).Order("position ASC")
+	query := qs.db.Where("status IN ?", []string{"waiting", "called", "recall", "being_served"}).Order("position ASC")
 
 	if serviceType != "" && serviceType != "all" {
 		query = query.Where("category = ?", serviceType)
@@ -304,6 +304,7 @@ func (qs *QueueService) CompleteService(queueID uint, notes string) error {
 
 	now := time.Now()
 	queueEntry.Status = "completed"
+	queueEntry.CompletedAt = &now
 	if notes != "" {
 		queueEntry.Notes = notes
 	}
@@ -489,8 +490,11 @@ func (qs *QueueService) calculateEstimatedWaitTime(position int, serviceType str
 	}
 }
 
-// broadcastQueueStats broadcasts queue statistics to admin/volunteer dashboards
+// broadcastQueueStats broadcasts queue statistics to admin/volunteer dashboards, and
+// the live queue to display screens and queueing visitors
 func (qs *QueueService) broadcastQueueStats() {
+	go qs.publishLiveQueue()
+
 	stats, err := qs.GetQueueStats()
 	if err != nil {
 		log.Printf("Failed to get queue stats for broadcast: %v", err)
@@ -42,6 +42,17 @@ type RealtimeNotificationData struct {
 func (rns *RealtimeNotificationService) SendNotification(data RealtimeNotificationData) error {
 	log.Printf("Sending real-time notification to user %d: %s", data.UserID, data.Title)
 
+	// Respect the channels the user chose for this category; the category decides
+	// where there is one, otherwise the notification type
+	kind := data.Category
+	if models.NotificationCategoryFor(kind) == "" {
+		kind = data.Type
+	}
+	if !notifications.ChannelAllowed(data.UserID, kind, models.NotificationChannelInApp) {
+		log.Printf("In-app notification skipped based on user preferences: %s for user %d", data.Type, data.UserID)
+		return rns.sendChannels(data, kind)
+	}
+
 	// Create in-app notification record
 	inAppNotification := models.InAppNotification{
 		UserID:    data.UserID,
@@ -66,18 +77,31 @@ func (rns *RealtimeNotificationService) SendNotification(data RealtimeNotificati
 		return fmt.Errorf("failed to create notification: %w", err)
 	}
 
-	// Send via requested channels
+	return rns.sendChannels(data, kind)
+}
+
+// sendChannels sends a notification on each requested channel the user accepts for
+// its kind
+func (rns *RealtimeNotificationService) sendChannels(data RealtimeNotificationData, kind string) error {
 	for _, channel := range data.Channels {
+		if !notifications.ChannelAllowed(data.UserID, kind, channel) {
+			continue
+		}
 		switch channel {
 		case "websocket":
-			if err := rns.sendWebSocketNotification(data, inAppNotification.ID); err != nil {
-				log.Printf("Failed to send WebSocket notification: %v", err)
-			}
+			// Delivered by the notification center when the record is saved
 		case "push":
 			if err := rns.sendPushNotification(data); err != nil {
 				log.Printf("Failed to send push notification: %v", err)
 			}
 		case "email":
+			// Users can batch non-urgent emails into a daily or weekly digest
+			if frequency := notifications.DigestFrequency(data.UserID, kind); frequency != "" && !urgentNotification(data.Priority) {
+				if err := holdForDigest(data, kind, frequency); err != nil {
+					log.Printf("Failed to hold email for digest: %v", err)
+				}
+				continue
+			}
 			if err := rns.sendEmailNotification(data); err != nil {
 				log.Printf("Failed to send email notification: %v", err)
 			}
@@ -91,31 +115,6 @@ func (rns *RealtimeNotificationService) SendNotification(data RealtimeNotificati
 	return nil
 }
 
-// sendWebSocketNotification sends notification via WebSocket
-func (rns *RealtimeNotificationService) sendWebSocketNotification(data RealtimeNotificationData, notificationID uint) error {
-	wsMessage := map[string]interface{}{
-		"type":              "notification",
-		"id":                notificationID,
-		"user_id":           data.UserID,
-		"notification_type": data.Type,
-		"title":             data.Title,
-		"message":           data.Message,
-		"priority":          data.Priority,
-		"category":          data.Category,
-		"action_url":        data.ActionURL,
-		"timestamp":         time.Now(),
-		"data":              data.Data,
-	}
-
-	// Send to specific user via WebSocket manager
-	if err := rns.wsManager.BroadcastToUser(data.UserID, wsMessage); err != nil {
-		return fmt.Errorf("failed to send WebSocket message: %w", err)
-	}
-
-	log.Printf("WebSocket notification sent to user %d", data.UserID)
-	return nil
-}
-
 // sendPushNotification sends notification via browser push
 func (rns *RealtimeNotificationService) sendPushNotification(data RealtimeNotificationData) error {
 	// Get user's active push subscriptions
@@ -157,6 +156,13 @@ func (rns *RealtimeNotificationService) sendEmailNotification(data RealtimeNotif
 		},
 		NotificationType: notifications.EmailNotification,
 	}
+	// Templates can use the notification's details, such as a plain-language
+	// variant spelling out an appointment
+	for key, value := range data.Data {
+		if _, taken := notificationData.TemplateData[key]; !taken {
+			notificationData.TemplateData[key] = value
+		}
+	}
 
 	return rns.notificationService.SendNotification(notificationData, user)
 }
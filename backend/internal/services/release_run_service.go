@@ -0,0 +1,325 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Ticket release record errors
+var (
+	ErrReleaseRunNotFound = errors.New("ticket release run not found")
+	ErrTransparencyRange  = errors.New("transparency stats cover between 1 and 52 weeks")
+)
+
+// transparencyMinCount is the smallest count published in the transparency stats.
+// Smaller non-zero counts are suppressed so a quiet week cannot identify anyone.
+const transparencyMinCount = 5
+
+// ReleaseChainReport is the result of checking the hash chain of recorded releases
+type ReleaseChainReport struct {
+	Runs       int    `json:"runs"`
+	Intact     bool   `json:"intact"`
+	BrokenAtID uint   `json:"broken_at_id,omitempty"` // First run whose hash or link does not match
+	Reason     string `json:"reason,omitempty"`
+	LatestHash string `json:"latest_hash,omitempty"`
+}
+
+// TransparencyCount is a published count. Value is empty when the count was
+// suppressed for being too small to publish.
+type TransparencyCount struct {
+	Value      *int `json:"value"`
+	Suppressed bool `json:"suppressed,omitempty"`
+}
+
+// TransparencyCategory is the demand and tickets issued for one category in a week
+type TransparencyCategory struct {
+	Category  string            `json:"category"`
+	Requested TransparencyCount `json:"requested"` // Help requests made for visit days in the week
+	Issued    TransparencyCount `json:"issued"`    // Tickets issued by recorded releases
+	FillRate  *float64          `json:"fill_rate"` // Percentage of requests that got a ticket, when both counts are published
+}
+
+// TransparencyWeek is one week of the transparency stats, Monday to Sunday
+type TransparencyWeek struct {
+	WeekStart  string                 `json:"week_start"`
+	Releases   int                    `json:"releases"`
+	Categories []TransparencyCategory `json:"categories"`
+}
+
+// TicketTransparency is the anonymised record of demand against tickets issued
+// that is published for the community
+type TicketTransparency struct {
+	Weeks       []TransparencyWeek `json:"weeks"`
+	Allocation  string             `json:"allocation"`
+	MinCount    int                `json:"min_published_count"`
+	GeneratedAt time.Time          `json:"generated_at"`
+}
+
+// visitDayDemand is the number of help requests for a visit day and category
+type visitDayDemand struct {
+	VisitDay string
+	Category string
+	Count    int
+}
+
+// ReleaseRunService keeps the permanent record of ticket releases and publishes
+// anonymised stats from it
+type ReleaseRunService struct {
+	db *gorm.DB
+}
+
+// NewReleaseRunService creates a new release run service
+func NewReleaseRunService() *ReleaseRunService {
+	return &ReleaseRunService{db: db.DB}
+}
+
+// Record stores a ticket release, chaining its hash to the run recorded before it.
+// Recording is serialised so two releases cannot both claim the same previous run.
+func (rs *ReleaseRunService) Record(run *models.ReleaseRun) error {
+	run.RunAt = run.RunAt.Truncate(time.Microsecond)
+
+	return rs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "release_runs").Error; err != nil {
+			return err
+		}
+
+		var previous models.ReleaseRun
+		err := tx.Select("hash").Order("id DESC").First(&previous).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		run.PrevHash = previous.Hash
+		hash, err := releaseRunHash(run)
+		if err != nil {
+			return err
+		}
+		run.Hash = hash
+
+		return tx.Create(run).Error
+	})
+}
+
+// List returns recorded releases, newest first, optionally for one visit day
+func (rs *ReleaseRunService) List(releaseDate string, limit int) ([]models.ReleaseRun, error) {
+	query := rs.db.Order("id DESC")
+	if releaseDate != "" {
+		query = query.Where("release_date = ?", releaseDate)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var runs []models.ReleaseRun
+	err := query.Find(&runs).Error
+	return runs, err
+}
+
+// Get returns one recorded release
+func (rs *ReleaseRunService) Get(id uint) (*models.ReleaseRun, error) {
+	var run models.ReleaseRun
+	if err := rs.db.First(&run, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReleaseRunNotFound
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// Verify recomputes every run's hash and link, in the order they were recorded
+func (rs *ReleaseRunService) Verify() (*ReleaseChainReport, error) {
+	var runs []models.ReleaseRun
+	if err := rs.db.Order("id ASC").Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return verifyReleaseChain(runs), nil
+}
+
+// Transparency returns weekly demand and tickets issued for the weeks up to and
+// including the one containing now. Counts below the publishing minimum are
+// suppressed and nothing identifies a visitor or a request.
+func (rs *ReleaseRunService) Transparency(weeks int, now time.Time) (*TicketTransparency, error) {
+	if weeks < 1 || weeks > 52 {
+		return nil, ErrTransparencyRange
+	}
+
+	thisWeek := truncateSLAPeriod(now, slaTrendIntervalWeek)
+	firstWeek := thisWeek.AddDate(0, 0, -7*(weeks-1))
+	from := firstWeek.Format("2006-01-02")
+	to := thisWeek.AddDate(0, 0, 6).Format("2006-01-02")
+
+	var demand []visitDayDemand
+	if err := rs.db.Model(&models.HelpRequest{}).
+		Select("visit_day, category, COUNT(*) AS count").
+		Where("visit_day BETWEEN ? AND ?", from, to).
+		Group("visit_day, category").
+		Scan(&demand).Error; err != nil {
+		return nil, err
+	}
+
+	var runs []models.ReleaseRun
+	if err := rs.db.Where("release_date BETWEEN ? AND ?", from, to).Find(&runs).Error; err != nil {
+		return nil, err
+	}
+
+	return buildTicketTransparency(firstWeek, weeks, demand, runs, now), nil
+}
+
+// releaseRunHash is the SHA-256 of a run's contents and the hash of the run before it
+func releaseRunHash(run *models.ReleaseRun) (string, error) {
+	contents, err := json.Marshal(struct {
+		ReleaseDate      string                      `json:"release_date"`
+		RunAt            string                      `json:"run_at"`
+		Trigger          string                      `json:"trigger"`
+		TriggeredBy      *uint                       `json:"triggered_by"`
+		Overrides        models.ReleaseOverrides     `json:"overrides"`
+		Categories       models.ReleaseRunCategories `json:"categories"`
+		TotalEligible    int                         `json:"total_eligible"`
+		TotalReleased    int                         `json:"total_released"`
+		TotalFailed      int                         `json:"total_failed"`
+		RemainingInQueue int                         `json:"remaining_in_queue"`
+		PrevHash         string                      `json:"prev_hash"`
+	}{
+		ReleaseDate:      run.ReleaseDate,
+		RunAt:            run.RunAt.UTC().Format(time.RFC3339Nano),
+		Trigger:          run.Trigger,
+		TriggeredBy:      run.TriggeredBy,
+		Overrides:        run.Overrides,
+		Categories:       run.Categories,
+		TotalEligible:    run.TotalEligible,
+		TotalReleased:    run.TotalReleased,
+		TotalFailed:      run.TotalFailed,
+		RemainingInQueue: run.RemainingInQueue,
+		PrevHash:         run.PrevHash,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode release run: %w", err)
+	}
+
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// verifyReleaseChain checks runs given oldest first
+func verifyReleaseChain(runs []models.ReleaseRun) *ReleaseChainReport {
+	report := &ReleaseChainReport{Runs: len(runs), Intact: true}
+
+	previous := ""
+	for i := range runs {
+		run := &runs[i]
+		if run.PrevHash != previous {
+			report.Intact, report.BrokenAtID = false, run.ID
+			report.Reason = "previous hash does not match the run before it"
+			return report
+		}
+		hash, err := releaseRunHash(run)
+		if err != nil || hash != run.Hash {
+			report.Intact, report.BrokenAtID = false, run.ID
+			report.Reason = "contents do not match the recorded hash"
+			return report
+		}
+		previous = run.Hash
+	}
+	report.LatestHash = previous
+
+	return report
+}
+
+// buildTicketTransparency buckets demand and issued tickets into weeks starting
+// firstWeek
+func buildTicketTransparency(firstWeek time.Time, weeks int, demand []visitDayDemand, runs []models.ReleaseRun, now time.Time) *TicketTransparency {
+	type totals struct{ requested, issued int }
+	byWeek := make([]map[string]*totals, weeks)
+	releases := make([]int, weeks)
+	for i := range byWeek {
+		byWeek[i] = map[string]*totals{}
+	}
+
+	start := time.Date(firstWeek.Year(), firstWeek.Month(), firstWeek.Day(), 0, 0, 0, 0, time.UTC)
+	weekOf := func(day string) (int, bool) {
+		date, err := time.Parse("2006-01-02", day)
+		if err != nil || date.Before(start) {
+			return 0, false
+		}
+		index := int(date.Sub(start).Hours()/24) / 7
+		return index, index < weeks
+	}
+	add := func(week int, category string) *totals {
+		if byWeek[week][category] == nil {
+			byWeek[week][category] = &totals{}
+		}
+		return byWeek[week][category]
+	}
+
+	for _, row := range demand {
+		if week, ok := weekOf(row.VisitDay); ok && row.Category != "" {
+			add(week, row.Category).requested += row.Count
+		}
+	}
+	for _, run := range runs {
+		week, ok := weekOf(run.ReleaseDate)
+		if !ok {
+			continue
+		}
+		releases[week]++
+		for _, category := range run.Categories {
+			add(week, category.Category).issued += category.Issued
+		}
+	}
+
+	transparency := &TicketTransparency{
+		Weeks:       make([]TransparencyWeek, 0, weeks),
+		Allocation:  "Approved requests receive tickets in the order they were made, up to each day's capacity",
+		MinCount:    transparencyMinCount,
+		GeneratedAt: now,
+	}
+	for i := range weeks {
+		week := TransparencyWeek{
+			WeekStart:  firstWeek.AddDate(0, 0, 7*i).Format("2006-01-02"),
+			Releases:   releases[i],
+			Categories: []TransparencyCategory{},
+		}
+
+		categories := make([]string, 0, len(byWeek[i]))
+		for category := range byWeek[i] {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+
+		for _, category := range categories {
+			counts := byWeek[i][category]
+			row := TransparencyCategory{
+				Category:  category,
+				Requested: publishedCount(counts.requested),
+				Issued:    publishedCount(counts.issued),
+			}
+			if row.Requested.Value != nil && row.Issued.Value != nil && counts.requested > 0 {
+				rate := roundPence(float64(counts.issued) / float64(counts.requested) * 100)
+				row.FillRate = &rate
+			}
+			week.Categories = append(week.Categories, row)
+		}
+		transparency.Weeks = append(transparency.Weeks, week)
+	}
+
+	return transparency
+}
+
+// publishedCount suppresses counts too small to publish safely
+func publishedCount(count int) TransparencyCount {
+	if count > 0 && count < transparencyMinCount {
+		return TransparencyCount{Suppressed: true}
+	}
+	return TransparencyCount{Value: &count}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestVerifyReleaseChain(t *testing.T) {
+	chain := func() []models.ReleaseRun {
+		runs := []models.ReleaseRun{
+			{ID: 1, ReleaseDate: "2026-10-13", RunAt: time.Date(2026, 10, 13, 9, 0, 0, 0, time.UTC), Trigger: models.ReleaseTriggerScheduled,
+				Categories: models.ReleaseRunCategories{{Category: models.CategoryFood, Capacity: 2, QueueSize: 3, Issued: 2, NotReached: 1}}},
+			{ID: 2, ReleaseDate: "2026-10-14", RunAt: time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC), Trigger: models.ReleaseTriggerManual,
+				Overrides: models.ReleaseOverrides{models.CategoryFood: 5}, TotalReleased: 5},
+		}
+		previous := ""
+		for i := range runs {
+			runs[i].PrevHash = previous
+			hash, err := releaseRunHash(&runs[i])
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			runs[i].Hash, previous = hash, hash
+		}
+		return runs
+	}
+
+	if report := verifyReleaseChain(chain()); !report.Intact || report.LatestHash == "" {
+		t.Fatalf("untouched chain reported broken: %+v", report)
+	}
+
+	// The same instant read back in another zone hashes the same
+	runs := chain()
+	runs[0].RunAt = runs[0].RunAt.In(time.FixedZone("BST", 3600))
+	if report := verifyReleaseChain(runs); !report.Intact {
+		t.Errorf("time zone change broke the chain: %+v", report)
+	}
+
+	runs = chain()
+	runs[0].Categories[0].Issued = 3
+	if report := verifyReleaseChain(runs); report.Intact || report.BrokenAtID != 1 {
+		t.Errorf("edited allocation not detected: %+v", report)
+	}
+
+	runs = chain()
+	runs = runs[1:]
+	if report := verifyReleaseChain(runs); report.Intact || report.BrokenAtID != 2 {
+		t.Errorf("deleted run not detected: %+v", report)
+	}
+}
+
+func TestBuildTicketTransparency(t *testing.T) {
+	firstWeek := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+	demand := []visitDayDemand{
+		{VisitDay: "2026-10-06", Category: models.CategoryFood, Count: 12},
+		{VisitDay: "2026-10-08", Category: models.CategoryFood, Count: 8},
+		{VisitDay: "2026-10-07", Category: models.CategoryGeneral, Count: 3},
+		{VisitDay: "2026-10-14", Category: models.CategoryFood, Count: 6},
+		{VisitDay: "2026-09-30", Category: models.CategoryFood, Count: 40}, // Before the period
+	}
+	runs := []models.ReleaseRun{
+		{ReleaseDate: "2026-10-06", Categories: models.ReleaseRunCategories{{Category: models.CategoryFood, Issued: 10}, {Category: models.CategoryGeneral, Issued: 3}}},
+		{ReleaseDate: "2026-10-08", Categories: models.ReleaseRunCategories{{Category: models.CategoryFood, Issued: 5}}},
+	}
+
+	transparency := buildTicketTransparency(firstWeek, 2, demand, runs, firstWeek.AddDate(0, 0, 10))
+	if len(transparency.Weeks) != 2 {
+		t.Fatalf("got %d weeks, want 2", len(transparency.Weeks))
+	}
+
+	first := transparency.Weeks[0]
+	if first.WeekStart != "2026-10-05" || first.Releases != 2 || len(first.Categories) != 2 {
+		t.Fatalf("first week = %+v", first)
+	}
+	food := first.Categories[0]
+	if food.Category != models.CategoryFood || *food.Requested.Value != 20 || *food.Issued.Value != 15 || *food.FillRate != 75 {
+		t.Errorf("food = %+v", food)
+	}
+	general := first.Categories[1]
+	if !general.Requested.Suppressed || general.Requested.Value != nil || general.FillRate != nil {
+		t.Errorf("small general counts should be suppressed: %+v", general)
+	}
+
+	second := transparency.Weeks[1]
+	if second.Releases != 0 || len(second.Categories) != 1 || *second.Categories[0].Issued.Value != 0 || *second.Categories[0].FillRate != 0 {
+		t.Errorf("second week = %+v", second)
+	}
+}
@@ -0,0 +1,427 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Default SLA targets, used when neither a system setting nor an env var is set
+const (
+	defaultSLAApprovalHours = 48
+	defaultSLATicketHours   = 72
+	defaultSLAAlertPercent  = 90
+	defaultSLARollingDays   = 7
+)
+
+// Fewer completions than this in the rolling window are not alerted on
+const minSLAAlertSampleSize = 5
+
+const slaComplianceAlertAction = "/admin/reports/sla"
+
+// SLA trend intervals, as understood by Postgres date_trunc
+const (
+	slaTrendIntervalDay   = "day"
+	slaTrendIntervalWeek  = "week"
+	slaTrendIntervalMonth = "month"
+)
+
+// Queries selecting when each help request reached a milestone and how many hours it
+// took, and the aggregates computed over them
+const (
+	slaApprovalDurationsQuery = `SELECT approved_at AS completed_at,
+		EXTRACT(EPOCH FROM approved_at - created_at) / 3600 AS hours
+		FROM help_requests WHERE deleted_at IS NULL AND approved_at IS NOT NULL`
+	slaTicketDurationsQuery = `SELECT MIN(tickets.issued_at) AS completed_at,
+		EXTRACT(EPOCH FROM MIN(tickets.issued_at) - help_requests.created_at) / 3600 AS hours
+		FROM help_requests JOIN tickets ON tickets.help_request_id = help_requests.id
+		WHERE help_requests.deleted_at IS NULL
+		GROUP BY help_requests.id, help_requests.created_at`
+	slaMetricAggregates = `COUNT(*) AS completed,
+		COALESCE(AVG(hours), 0) AS average_hours,
+		COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY hours), 0) AS median_hours,
+		COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY hours), 0) AS p90_hours,
+		COUNT(*) FILTER (WHERE hours <= ?) AS within_target`
+)
+
+// ErrInvalidSLATargets is returned when SLA targets are out of range
+var ErrInvalidSLATargets = errors.New("SLA targets must be positive hours and the alert threshold a percentage above 0")
+
+// SLATargets are the help request processing targets
+type SLATargets struct {
+	ApprovalHours float64 `json:"approval_hours"`
+	TicketHours   float64 `json:"ticket_hours"`
+	AlertPercent  float64 `json:"alert_percent"` // Alert when rolling compliance drops below this
+	RollingDays   int     `json:"rolling_days"`
+}
+
+// SLAMetric summarises how long requests took to reach a milestone against its target
+type SLAMetric struct {
+	Metric       string  `json:"metric"`
+	TargetHours  float64 `json:"target_hours"`
+	Completed    int64   `json:"completed"`
+	AverageHours float64 `json:"average_hours"`
+	MedianHours  float64 `json:"median_hours"`
+	P90Hours     float64 `json:"p90_hours"`
+	WithinTarget int64   `json:"within_target"`
+	Compliance   float64 `json:"compliance"` // Percentage within target, 100 when nothing completed
+}
+
+// SLATrendPoint is one period of an SLA trend
+type SLATrendPoint struct {
+	Period   string    `json:"period"` // Start of the period, YYYY-MM-DD
+	Approval SLAMetric `json:"approval"`
+	Ticket   SLAMetric `json:"ticket"`
+}
+
+// SLASummary is the SLA dashboard for a date range
+type SLASummary struct {
+	StartDate          string      `json:"start_date"`
+	EndDate            string      `json:"end_date"`
+	Targets            SLATargets  `json:"targets"`
+	Approval           SLAMetric   `json:"approval"`
+	Ticket             SLAMetric   `json:"ticket"`
+	OpenApprovalBreach int64       `json:"open_approval_breaches"` // Pending requests already past the approval target
+	Rolling            []SLAMetric `json:"rolling"`                // Compliance over the rolling window ending now
+}
+
+// RequestSLAService measures help request processing times from their timestamps
+// against configurable targets
+type RequestSLAService struct {
+	db *gorm.DB
+}
+
+// NewRequestSLAService creates a new request SLA service
+func NewRequestSLAService() *RequestSLAService {
+	return &RequestSLAService{db: db.DB}
+}
+
+// Targets returns the SLA targets. System settings take priority over
+// HELP_REQUEST_APPROVAL_SLA_HOURS, HELP_REQUEST_TICKET_SLA_HOURS and
+// SLA_COMPLIANCE_ALERT_PERCENT. The rolling window is set by SLA_ROLLING_WINDOW_DAYS.
+func (rs *RequestSLAService) Targets() SLATargets {
+	targets := SLATargets{
+		ApprovalHours: rs.setting(models.SLAApprovalTargetConfigKey, "HELP_REQUEST_APPROVAL_SLA_HOURS", defaultSLAApprovalHours),
+		TicketHours:   rs.setting(models.SLATicketTargetConfigKey, "HELP_REQUEST_TICKET_SLA_HOURS", defaultSLATicketHours),
+		AlertPercent:  rs.setting(models.SLAComplianceAlertConfigKey, "SLA_COMPLIANCE_ALERT_PERCENT", defaultSLAAlertPercent),
+		RollingDays:   defaultSLARollingDays,
+	}
+	if days, err := strconv.Atoi(os.Getenv("SLA_ROLLING_WINDOW_DAYS")); err == nil && days > 0 {
+		targets.RollingDays = days
+	}
+	return targets
+}
+
+// SetTargets stores the SLA targets as system settings
+func (rs *RequestSLAService) SetTargets(approvalHours, ticketHours, alertPercent float64, updatedBy uint) error {
+	if approvalHours <= 0 || ticketHours <= 0 || alertPercent <= 0 || alertPercent > 100 {
+		return ErrInvalidSLATargets
+	}
+
+	return rs.db.Transaction(func(tx *gorm.DB) error {
+		settings := []struct {
+			key         string
+			value       float64
+			description string
+		}{
+			{models.SLAApprovalTargetConfigKey, approvalHours, "Target hours from a help request being submitted to it being approved"},
+			{models.SLATicketTargetConfigKey, ticketHours, "Target hours from a help request being submitted to its ticket being issued"},
+			{models.SLAComplianceAlertConfigKey, alertPercent, "Admins are alerted when rolling SLA compliance drops below this percentage"},
+		}
+		for _, setting := range settings {
+			var config models.SystemConfig
+			if err := tx.Where("key = ?", setting.key).
+				Attrs(models.SystemConfig{
+					Key:         setting.key,
+					Type:        models.ConfigTypeFloat,
+					Category:    "sla",
+					Description: setting.description,
+				}).
+				FirstOrInit(&config).Error; err != nil {
+				return err
+			}
+			config.Value = strconv.FormatFloat(setting.value, 'f', -1, 64)
+			config.UpdatedBy = &updatedBy
+			if err := tx.Save(&config).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Summary returns processing times for requests that reached each milestone between
+// start and end (inclusive dates), with rolling compliance up to now
+func (rs *RequestSLAService) Summary(start, end time.Time, now time.Time) (*SLASummary, error) {
+	targets := rs.Targets()
+	from, to := start, end.AddDate(0, 0, 1)
+
+	approval, err := rs.metric(models.SLAMetricApproval, targets.ApprovalHours, from, to)
+	if err != nil {
+		return nil, err
+	}
+	ticket, err := rs.metric(models.SLAMetricTicket, targets.TicketHours, from, to)
+	if err != nil {
+		return nil, err
+	}
+	rolling, err := rs.RollingCompliance(now)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &SLASummary{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Targets:   targets,
+		Approval:  approval,
+		Ticket:    ticket,
+		Rolling:   rolling,
+	}
+	cutoff := now.Add(-time.Duration(targets.ApprovalHours * float64(time.Hour)))
+	if err := rs.db.Model(&models.HelpRequest{}).
+		Where("status = ? AND approved_at IS NULL AND rejected_at IS NULL AND created_at <= ?",
+			models.HelpRequestStatusPending, cutoff).
+		Count(&summary.OpenApprovalBreach).Error; err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// Trend returns both metrics for each day, week or month between start and end
+func (rs *RequestSLAService) Trend(start, end time.Time, interval string) ([]SLATrendPoint, error) {
+	if interval != slaTrendIntervalDay && interval != slaTrendIntervalMonth {
+		interval = slaTrendIntervalWeek
+	}
+	targets := rs.Targets()
+	from, to := start, end.AddDate(0, 0, 1)
+
+	approval, err := rs.metricTrend(models.SLAMetricApproval, targets.ApprovalHours, from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+	ticket, err := rs.metricTrend(models.SLAMetricTicket, targets.TicketHours, from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+	return slaTrendPoints(start, to, interval, targets, approval, ticket), nil
+}
+
+// slaTrendPoints lays out every period from start up to to, including periods with no
+// completions, from the metrics keyed by period start
+func slaTrendPoints(start, to time.Time, interval string, targets SLATargets, approval, ticket map[string]SLAMetric) []SLATrendPoint {
+	points := []SLATrendPoint{}
+	for period := truncateSLAPeriod(start, interval); period.Before(to); period = nextSLAPeriod(period, interval) {
+		key := period.Format("2006-01-02")
+		point := SLATrendPoint{Period: key, Approval: approval[key], Ticket: ticket[key]}
+		point.Approval.Metric, point.Approval.TargetHours = models.SLAMetricApproval, targets.ApprovalHours
+		point.Ticket.Metric, point.Ticket.TargetHours = models.SLAMetricTicket, targets.TicketHours
+		point.Approval.Compliance = slaCompliance(point.Approval)
+		point.Ticket.Compliance = slaCompliance(point.Ticket)
+		points = append(points, point)
+	}
+	return points
+}
+
+// RollingCompliance returns both metrics over the rolling window ending at now
+func (rs *RequestSLAService) RollingCompliance(now time.Time) ([]SLAMetric, error) {
+	targets := rs.Targets()
+	from := now.AddDate(0, 0, -targets.RollingDays)
+
+	approval, err := rs.metric(models.SLAMetricApproval, targets.ApprovalHours, from, now)
+	if err != nil {
+		return nil, err
+	}
+	ticket, err := rs.metric(models.SLAMetricTicket, targets.TicketHours, from, now)
+	if err != nil {
+		return nil, err
+	}
+	return []SLAMetric{approval, ticket}, nil
+}
+
+// Approval returns time to approval for requests approved between two times
+func (rs *RequestSLAService) Approval(from, to time.Time) (SLAMetric, error) {
+	return rs.metric(models.SLAMetricApproval, rs.Targets().ApprovalHours, from, to)
+}
+
+// CheckCompliance alerts admins when rolling compliance for a metric is below the
+// threshold, at most once a day per metric. It returns the metrics alerted on.
+func (rs *RequestSLAService) CheckCompliance(now time.Time) ([]SLAMetric, error) {
+	targets := rs.Targets()
+	rolling, err := rs.RollingCompliance(now)
+	if err != nil {
+		return nil, err
+	}
+
+	alerted := []SLAMetric{}
+	for _, metric := range rolling {
+		if metric.Completed < minSLAAlertSampleSize || metric.Compliance >= targets.AlertPercent {
+			continue
+		}
+
+		alert := models.SLAComplianceAlert{
+			Metric:     metric.Metric,
+			Day:        now.Format("2006-01-02"),
+			Compliance: metric.Compliance,
+			Threshold:  targets.AlertPercent,
+			Completed:  metric.Completed,
+		}
+		result := rs.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&alert)
+		if result.Error != nil {
+			return alerted, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		rs.notifyAdmins(metric, targets)
+		alerted = append(alerted, metric)
+	}
+	return alerted, nil
+}
+
+// metric aggregates one metric for milestones reached in [from, to)
+func (rs *RequestSLAService) metric(name string, targetHours float64, from, to time.Time) (SLAMetric, error) {
+	metric := SLAMetric{Metric: name, TargetHours: targetHours}
+	query := fmt.Sprintf("SELECT %s FROM (%s) durations WHERE completed_at >= ? AND completed_at < ?",
+		slaMetricAggregates, slaDurationsQuery(name))
+	if err := rs.db.Raw(query, targetHours, from, to).Scan(&metric).Error; err != nil {
+		return metric, err
+	}
+	roundSLAMetric(&metric)
+	metric.Compliance = slaCompliance(metric)
+	return metric, nil
+}
+
+// metricTrend aggregates one metric per period, keyed by the period start
+func (rs *RequestSLAService) metricTrend(name string, targetHours float64, from, to time.Time, interval string) (map[string]SLAMetric, error) {
+	var rows []struct {
+		Period time.Time
+		SLAMetric
+	}
+	query := fmt.Sprintf("SELECT date_trunc(?, completed_at) AS period, %s FROM (%s) durations WHERE completed_at >= ? AND completed_at < ? GROUP BY 1",
+		slaMetricAggregates, slaDurationsQuery(name))
+	if err := rs.db.Raw(query, interval, targetHours, from, to).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	metrics := make(map[string]SLAMetric, len(rows))
+	for _, row := range rows {
+		roundSLAMetric(&row.SLAMetric)
+		metrics[row.Period.Format("2006-01-02")] = row.SLAMetric
+	}
+	return metrics, nil
+}
+
+// setting reads a float SLA setting from system config, then the environment
+func (rs *RequestSLAService) setting(key, env string, fallback float64) float64 {
+	var config models.SystemConfig
+	if err := rs.db.Where("key = ?", key).First(&config).Error; err == nil {
+		if value, err := strconv.ParseFloat(config.Value, 64); err == nil && value > 0 {
+			return value
+		}
+	}
+	if value, err := strconv.ParseFloat(os.Getenv(env), 64); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// notifyAdmins sends an in-app notification and email to every admin about a metric
+// below its compliance threshold
+func (rs *RequestSLAService) notifyAdmins(metric SLAMetric, targets SLATargets) {
+	label := "Time to approval"
+	if metric.Metric == models.SLAMetricTicket {
+		label = "Time to ticket"
+	}
+	title := fmt.Sprintf("%s SLA below %.0f%%", label, targets.AlertPercent)
+	message := fmt.Sprintf("%s compliance over the last %d days is %.1f%% (%d of %d help requests within %.0f hours). Median %.1f hours, 90th percentile %.1f hours.",
+		label, targets.RollingDays, metric.Compliance, metric.WithinTarget, metric.Completed, metric.TargetHours, metric.MedianHours, metric.P90Hours)
+
+	var admins []models.User
+	if err := rs.db.Where("role IN ? AND status = ?",
+		[]string{models.RoleAdmin, models.RoleSuperAdmin}, models.StatusActive).
+		Find(&admins).Error; err != nil {
+		log.Printf("Failed to load admins for SLA alert: %v", err)
+		return
+	}
+
+	for _, admin := range admins {
+		notification := models.InAppNotification{
+			UserID:    admin.ID,
+			Title:     title,
+			Message:   message,
+			Type:      "warning",
+			Priority:  models.PriorityHigh,
+			ActionURL: slaComplianceAlertAction,
+		}
+		if err := rs.db.Create(&notification).Error; err != nil {
+			log.Printf("Failed to create SLA alert for admin %d: %v", admin.ID, err)
+		}
+
+		if admin.Email != "" {
+			if err := notifications.GetService().SendEmail(admin.Email, title, message); err != nil {
+				log.Printf("Failed to email SLA alert to admin %d: %v", admin.ID, err)
+			}
+		}
+	}
+}
+
+// slaDurationsQuery selects when each request reached the metric's milestone and how
+// many hours it took
+func slaDurationsQuery(metric string) string {
+	if metric == models.SLAMetricTicket {
+		return slaTicketDurationsQuery
+	}
+	return slaApprovalDurationsQuery
+}
+
+// slaCompliance returns the percentage of completions within target
+func slaCompliance(metric SLAMetric) float64 {
+	if metric.Completed == 0 {
+		return 100
+	}
+	return math.Round(float64(metric.WithinTarget)/float64(metric.Completed)*1000) / 10
+}
+
+// roundSLAMetric rounds the hour figures to one decimal place
+func roundSLAMetric(metric *SLAMetric) {
+	metric.AverageHours = math.Round(metric.AverageHours*10) / 10
+	metric.MedianHours = math.Round(metric.MedianHours*10) / 10
+	metric.P90Hours = math.Round(metric.P90Hours*10) / 10
+}
+
+// truncateSLAPeriod returns the start of the period containing t, matching Postgres
+// date_trunc where weeks start on Monday
+func truncateSLAPeriod(t time.Time, interval string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch interval {
+	case slaTrendIntervalMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	case slaTrendIntervalWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	default:
+		return day
+	}
+}
+
+// nextSLAPeriod returns the start of the period after the one starting at t
+func nextSLAPeriod(t time.Time, interval string) time.Time {
+	switch interval {
+	case slaTrendIntervalMonth:
+		return t.AddDate(0, 1, 0)
+	case slaTrendIntervalWeek:
+		return t.AddDate(0, 0, 7)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestSLACompliance(t *testing.T) {
+	tests := []struct {
+		completed, within int64
+		want              float64
+	}{
+		{0, 0, 100}, // Nothing completed is not a breach
+		{3, 2, 66.7},
+		{8, 8, 100},
+		{7, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := slaCompliance(SLAMetric{Completed: tt.completed, WithinTarget: tt.within}); got != tt.want {
+			t.Errorf("%d of %d: got %v, want %v", tt.within, tt.completed, got, tt.want)
+		}
+	}
+
+	metric := SLAMetric{AverageHours: 12.345, MedianHours: 9.96, P90Hours: 47.04}
+	roundSLAMetric(&metric)
+	if metric.AverageHours != 12.3 || metric.MedianHours != 10 || metric.P90Hours != 47 {
+		t.Errorf("rounded: %+v", metric)
+	}
+}
+
+func TestSLAPeriods(t *testing.T) {
+	wednesday := time.Date(2026, 4, 15, 14, 30, 0, 0, time.UTC)
+	sunday := time.Date(2026, 4, 19, 23, 0, 0, 0, time.UTC)
+	tests := []struct {
+		at       time.Time
+		interval string
+		start    string
+		next     string
+	}{
+		{wednesday, slaTrendIntervalDay, "2026-04-15", "2026-04-16"},
+		{wednesday, slaTrendIntervalWeek, "2026-04-13", "2026-04-20"},
+		{sunday, slaTrendIntervalWeek, "2026-04-13", "2026-04-20"}, // Weeks start on Monday
+		{wednesday, slaTrendIntervalMonth, "2026-04-01", "2026-05-01"},
+	}
+	for _, tt := range tests {
+		start := truncateSLAPeriod(tt.at, tt.interval)
+		if got := start.Format("2006-01-02"); got != tt.start {
+			t.Errorf("%s of %s starts %s, want %s", tt.interval, tt.at.Format("Mon 2 Jan"), got, tt.start)
+		}
+		if got := nextSLAPeriod(start, tt.interval).Format("2006-01-02"); got != tt.next {
+			t.Errorf("%s after %s is %s, want %s", tt.interval, tt.start, got, tt.next)
+		}
+	}
+}
+
+func TestSLATrendPoints(t *testing.T) {
+	targets := SLATargets{ApprovalHours: 48, TicketHours: 72}
+	start := time.Date(2026, 4, 8, 0, 0, 0, 0, time.UTC) // A Wednesday
+	to := time.Date(2026, 4, 21, 0, 0, 0, 0, time.UTC)
+	approval := map[string]SLAMetric{"2026-04-13": {Completed: 4, WithinTarget: 3, MedianHours: 20}}
+
+	points := slaTrendPoints(start, to, slaTrendIntervalWeek, targets, approval, map[string]SLAMetric{})
+	if len(points) != 3 || points[0].Period != "2026-04-06" || points[2].Period != "2026-04-20" {
+		t.Fatalf("got periods %+v", points)
+	}
+
+	week := points[1]
+	if week.Approval.Metric != models.SLAMetricApproval || week.Approval.TargetHours != 48 || week.Approval.Compliance != 75 || week.Approval.MedianHours != 20 {
+		t.Errorf("approval: %+v", week.Approval)
+	}
+	// Weeks with no completions are still shown, fully compliant
+	if empty := points[0].Ticket; empty.Metric != models.SLAMetricTicket || empty.TargetHours != 72 || empty.Compliance != 100 {
+		t.Errorf("empty week: %+v", empty)
+	}
+}
+
+func TestSetSLATargetsRange(t *testing.T) {
+	rs := &RequestSLAService{}
+	for _, targets := range [][3]float64{{0, 72, 90}, {48, -1, 90}, {48, 72, 0}, {48, 72, 101}} {
+		if err := rs.SetTargets(targets[0], targets[1], targets[2], 1); err != ErrInvalidSLATargets {
+			t.Errorf("%v: got %v", targets, err)
+		}
+	}
+}
+
+func TestSLADurationsQuery(t *testing.T) {
+	if slaDurationsQuery(models.SLAMetricTicket) != slaTicketDurationsQuery {
+		t.Error("ticket metric not measured from tickets")
+	}
+	if slaDurationsQuery(models.SLAMetricApproval) != slaApprovalDurationsQuery || slaDurationsQuery("") != slaApprovalDurationsQuery {
+		t.Error("approval is the default metric")
+	}
+}
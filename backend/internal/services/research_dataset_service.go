@@ -0,0 +1,472 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultResearchMinGroupSize = 10
+	minResearchMinGroupSize     = 3
+	defaultResearchWeeks        = 52
+	maxResearchWeeks            = 260
+	researchDatasetListLimit    = 100
+
+	// researchOtherWard holds small groups merged within a week and category;
+	// researchUnmappedWard holds postcodes no ward mapping covers
+	researchOtherWard    = "Other"
+	researchUnmappedWard = "Unmapped"
+)
+
+// Research dataset errors
+var (
+	ErrResearchDatasetNotFound = errors.New("research dataset not found")
+	ErrResearchDatasetNotReady = errors.New("research dataset is not ready")
+	ErrInvalidResearchRange    = errors.New("invalid research dataset range")
+	ErrInvalidPostcodeWard     = errors.New("invalid postcode ward mapping")
+	ErrResearchKAnonymity      = errors.New("research dataset row describes too few households")
+)
+
+// researchSupportedStatuses are the help request states counted as supported
+var researchSupportedStatuses = []string{
+	models.HelpRequestStatusApproved, models.HelpRequestStatusTicketIssued,
+	models.HelpRequestStatusCheckedIn, models.HelpRequestStatusCompleted,
+}
+
+// postcodeWardPrefix matches a postcode district ("SE13") or sector ("SE13 6")
+var postcodeWardPrefix = regexp.MustCompile(`^[A-Z]{1,2}[0-9][A-Z0-9]?( [0-9])?$`)
+
+// ResearchDatasetField documents a column of the research dataset
+type ResearchDatasetField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// ResearchDatasetSchema documents the research dataset for the people it is shared with
+type ResearchDatasetSchema struct {
+	SchemaVersion int                    `json:"schema_version"`
+	MinGroupSize  int                    `json:"min_group_size"`
+	Fields        []ResearchDatasetField `json:"fields"`
+	Notes         []string               `json:"notes"`
+}
+
+// researchDatasetFields are the dataset's columns, in file order
+var researchDatasetFields = []ResearchDatasetField{
+	{Name: "week_start", Type: "date", Description: "Monday of the week the requests were made (YYYY-MM-DD)"},
+	{Name: "ward", Type: "string", Description: `Electoral ward of the household's postcode; "Other" for small groups merged within the week and category, "Unmapped" for postcodes outside the mapped wards`},
+	{Name: "category", Type: "string", Description: "Service asked for, e.g. Food or General"},
+	{Name: "requests", Type: "integer", Description: "Help requests made"},
+	{Name: "households", Type: "integer", Description: "Different households making them; never below min_group_size"},
+	{Name: "people", Type: "integer", Description: "People living in those households, as the households reported"},
+	{Name: "supported_requests", Type: "integer", Description: "Requests approved or given a ticket"},
+}
+
+// ResearchDatasetRow is one published group of help requests
+type ResearchDatasetRow struct {
+	WeekStart         string `json:"week_start"`
+	Ward              string `json:"ward"`
+	Category          string `json:"category"`
+	Requests          int    `json:"requests"`
+	Households        int    `json:"households"`
+	People            int    `json:"people"`
+	SupportedRequests int    `json:"supported_requests"`
+}
+
+// PostcodeWardInput is one postcode to ward mapping
+type PostcodeWardInput struct {
+	Prefix string `json:"prefix" binding:"required"`
+	Ward   string `json:"ward" binding:"required"`
+}
+
+// researchRecord is the part of a help request the dataset is built from
+type researchRecord struct {
+	VisitorID     uint
+	Postcode      string
+	Category      string
+	HouseholdSize int
+	Status        string
+	RequestDate   time.Time
+}
+
+// researchDataset is a built dataset before it is written out
+type researchDataset struct {
+	Rows        []ResearchDatasetRow
+	Requests    int
+	Generalised int
+	Suppressed  int
+}
+
+// ResearchDatasetService builds anonymised, k-anonymous datasets of service demand for
+// researchers and the council and keeps them for admins to download
+type ResearchDatasetService struct {
+	db           *gorm.DB
+	minGroupSize int
+	weeks        int
+}
+
+// NewResearchDatasetService creates a new research dataset service
+func NewResearchDatasetService() *ResearchDatasetService {
+	rs := &ResearchDatasetService{db: db.DB, minGroupSize: defaultResearchMinGroupSize, weeks: defaultResearchWeeks}
+	if k, err := strconv.Atoi(os.Getenv("RESEARCH_DATASET_MIN_GROUP_SIZE")); err == nil && k >= minResearchMinGroupSize {
+		rs.minGroupSize = k
+	}
+	if weeks, err := strconv.Atoi(os.Getenv("RESEARCH_DATASET_WEEKS")); err == nil && weeks > 0 && weeks <= maxResearchWeeks {
+		rs.weeks = weeks
+	}
+	return rs
+}
+
+// Schema documents the dataset's columns and how it is anonymised
+func (rs *ResearchDatasetService) Schema() ResearchDatasetSchema {
+	return ResearchDatasetSchema{
+		SchemaVersion: models.ResearchDatasetSchemaVersion,
+		MinGroupSize:  rs.minGroupSize,
+		Fields:        researchDatasetFields,
+		Notes: []string{
+			"Each row counts the help requests made in one week, from one ward, for one service.",
+			fmt.Sprintf("Every row describes at least %d households. Smaller groups are merged into the ward \"Other\" for that week and service, and left out when the merged group is still too small.", rs.minGroupSize),
+			"No names, contact details, full postcodes, dates of requests or free text are included.",
+			"Totals across rows are lower than the service's real totals by the suppressed requests.",
+		},
+	}
+}
+
+// Generate builds a dataset of the weeks up to the last complete one
+func (rs *ResearchDatasetService) Generate(weeks int, requestedBy *uint, now time.Time) (*models.ResearchDatasetExport, error) {
+	if weeks == 0 {
+		weeks = rs.weeks
+	}
+	if weeks < 1 || weeks > maxResearchWeeks {
+		return nil, fmt.Errorf("%w: weeks must be between 1 and %d", ErrInvalidResearchRange, maxResearchWeeks)
+	}
+	end := researchWeekStart(now)
+	start := end.AddDate(0, 0, -7*weeks)
+
+	export := &models.ResearchDatasetExport{
+		SchemaVersion: models.ResearchDatasetSchemaVersion,
+		PeriodStart:   start,
+		PeriodEnd:     end,
+		MinGroupSize:  rs.minGroupSize,
+		Status:        models.ResearchDatasetRunning,
+		RequestedBy:   requestedBy,
+	}
+	if err := rs.db.Create(export).Error; err != nil {
+		return nil, err
+	}
+
+	content, dataset, err := rs.build(start, end)
+	generatedAt := time.Now()
+	updates := map[string]interface{}{"generated_at": generatedAt}
+	if err != nil {
+		updates["status"] = models.ResearchDatasetFailed
+		updates["error"] = err.Error()
+	} else {
+		sum := sha256.Sum256(content)
+		updates["status"] = models.ResearchDatasetReady
+		updates["rows"] = len(dataset.Rows)
+		updates["requests"] = dataset.Requests
+		updates["generalised_records"] = dataset.Generalised
+		updates["suppressed_records"] = dataset.Suppressed
+		updates["content"] = string(content)
+		updates["checksum"] = hex.EncodeToString(sum[:])
+	}
+	if updateErr := rs.db.Model(export).Updates(updates).Error; updateErr != nil {
+		return nil, updateErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rs.Get(export.ID)
+}
+
+// RunDue makes the scheduled dataset once each week has ended. It returns nil when
+// this week's dataset already exists.
+func (rs *ResearchDatasetService) RunDue(now time.Time) (*models.ResearchDatasetExport, error) {
+	var existing int64
+	err := rs.db.Model(&models.ResearchDatasetExport{}).
+		Where("period_end = ? AND status = ? AND requested_by IS NULL", researchWeekStart(now), models.ResearchDatasetReady).
+		Count(&existing).Error
+	if err != nil || existing > 0 {
+		return nil, err
+	}
+	return rs.Generate(rs.weeks, nil, now)
+}
+
+// List returns the most recent datasets, without their content
+func (rs *ResearchDatasetService) List(limit int) ([]models.ResearchDatasetExport, error) {
+	if limit <= 0 || limit > researchDatasetListLimit {
+		limit = researchDatasetListLimit
+	}
+	var exports []models.ResearchDatasetExport
+	err := rs.db.Omit("content").Order("id DESC").Limit(limit).Find(&exports).Error
+	return exports, err
+}
+
+// Get returns a dataset with its content
+func (rs *ResearchDatasetService) Get(id uint) (*models.ResearchDatasetExport, error) {
+	var export models.ResearchDatasetExport
+	if err := rs.db.First(&export, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResearchDatasetNotFound
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+// Download returns a ready dataset's CSV and a file name for it
+func (rs *ResearchDatasetService) Download(id uint) ([]byte, string, error) {
+	export, err := rs.Get(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if export.Status != models.ResearchDatasetReady {
+		return nil, "", ErrResearchDatasetNotReady
+	}
+	name := fmt.Sprintf("service-demand-%s-to-%s.csv",
+		export.PeriodStart.Format("2006-01-02"), export.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"))
+	return []byte(export.Content), name, nil
+}
+
+// Wards returns the postcode to ward mappings
+func (rs *ResearchDatasetService) Wards() ([]models.PostcodeWard, error) {
+	var wards []models.PostcodeWard
+	err := rs.db.Order("prefix ASC").Find(&wards).Error
+	return wards, err
+}
+
+// SetWards replaces the postcode to ward mappings
+func (rs *ResearchDatasetService) SetWards(inputs []PostcodeWardInput) ([]models.PostcodeWard, error) {
+	wards := make([]models.PostcodeWard, 0, len(inputs))
+	seen := map[string]bool{}
+	for _, input := range inputs {
+		prefix := normalizeWardPrefix(input.Prefix)
+		ward := strings.TrimSpace(input.Ward)
+		switch {
+		case !postcodeWardPrefix.MatchString(prefix):
+			return nil, fmt.Errorf("%w: %q is not a postcode district or sector", ErrInvalidPostcodeWard, input.Prefix)
+		case ward == "":
+			return nil, fmt.Errorf("%w: ward for %s is required", ErrInvalidPostcodeWard, prefix)
+		case strings.EqualFold(ward, researchOtherWard) || strings.EqualFold(ward, researchUnmappedWard):
+			return nil, fmt.Errorf("%w: %q is reserved", ErrInvalidPostcodeWard, ward)
+		case seen[prefix]:
+			return nil, fmt.Errorf("%w: %s is mapped twice", ErrInvalidPostcodeWard, prefix)
+		}
+		seen[prefix] = true
+		wards = append(wards, models.PostcodeWard{Prefix: prefix, Ward: ward})
+	}
+
+	err := rs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.PostcodeWard{}).Error; err != nil {
+			return err
+		}
+		if len(wards) == 0 {
+			return nil
+		}
+		return tx.Create(&wards).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rs.Wards()
+}
+
+// build reads the period's help requests and writes the dataset as CSV
+func (rs *ResearchDatasetService) build(start, end time.Time) ([]byte, *researchDataset, error) {
+	var records []researchRecord
+	err := rs.db.Model(&models.HelpRequest{}).
+		Select("visitor_id, postcode, category, household_size, status, request_date").
+		Where("request_date >= ? AND request_date < ?", start, end).
+		Scan(&records).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mappings, err := rs.Wards()
+	if err != nil {
+		return nil, nil, err
+	}
+	wards := make(map[string]string, len(mappings))
+	for _, mapping := range mappings {
+		wards[mapping.Prefix] = mapping.Ward
+	}
+
+	dataset := buildResearchDataset(records, wards, rs.minGroupSize)
+	if err := checkKAnonymity(dataset.Rows, rs.minGroupSize); err != nil {
+		return nil, nil, err
+	}
+	content, err := researchCSV(dataset.Rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	return content, dataset, nil
+}
+
+// researchCell gathers the requests of one week, ward and category
+type researchCell struct {
+	requests   int
+	supported  int
+	households map[uint]int // Visitor to the largest household size they gave
+}
+
+func (cell *researchCell) add(record researchRecord) {
+	cell.requests++
+	status := strings.TrimSpace(record.Status)
+	if slices.ContainsFunc(researchSupportedStatuses, func(supported string) bool { return strings.EqualFold(supported, status) }) {
+		cell.supported++
+	}
+	cell.households[record.VisitorID] = max(cell.households[record.VisitorID], record.HouseholdSize, 1)
+}
+
+func (cell *researchCell) merge(other *researchCell) {
+	cell.requests += other.requests
+	cell.supported += other.supported
+	for visitor, size := range other.households {
+		cell.households[visitor] = max(cell.households[visitor], size)
+	}
+}
+
+// buildResearchDataset groups requests by week, ward and category. Within a week and
+// category, groups of fewer than k households are merged into the "Other" ward, and
+// the merged group is suppressed when it is still smaller than k.
+func buildResearchDataset(records []researchRecord, wards map[string]string, k int) *researchDataset {
+	type groupKey struct{ week, category string }
+	groups := map[groupKey]map[string]*researchCell{}
+	for _, record := range records {
+		key := groupKey{researchWeekStart(record.RequestDate).Format("2006-01-02"), strings.TrimSpace(record.Category)}
+		if key.category == "" {
+			key.category = "Unspecified"
+		}
+		ward := wardFor(record.Postcode, wards)
+		if groups[key] == nil {
+			groups[key] = map[string]*researchCell{}
+		}
+		if groups[key][ward] == nil {
+			groups[key][ward] = &researchCell{households: map[uint]int{}}
+		}
+		groups[key][ward].add(record)
+	}
+
+	dataset := &researchDataset{Rows: []ResearchDatasetRow{}, Requests: len(records)}
+	for key, cells := range groups {
+		other := &researchCell{households: map[uint]int{}}
+		for ward, cell := range cells {
+			if len(cell.households) < k {
+				other.merge(cell)
+				continue
+			}
+			dataset.Rows = append(dataset.Rows, researchRow(key.week, ward, key.category, cell))
+		}
+		switch {
+		case other.requests == 0:
+		case len(other.households) < k:
+			dataset.Suppressed += other.requests
+		default:
+			dataset.Generalised += other.requests
+			dataset.Rows = append(dataset.Rows, researchRow(key.week, researchOtherWard, key.category, other))
+		}
+	}
+
+	sort.Slice(dataset.Rows, func(i, j int) bool {
+		a, b := dataset.Rows[i], dataset.Rows[j]
+		if a.WeekStart != b.WeekStart {
+			return a.WeekStart < b.WeekStart
+		}
+		if a.Ward != b.Ward {
+			return a.Ward < b.Ward
+		}
+		return a.Category < b.Category
+	})
+	return dataset
+}
+
+// researchRow turns a group into a published row
+func researchRow(week, ward, category string, cell *researchCell) ResearchDatasetRow {
+	row := ResearchDatasetRow{
+		WeekStart:         week,
+		Ward:              ward,
+		Category:          category,
+		Requests:          cell.requests,
+		Households:        len(cell.households),
+		SupportedRequests: cell.supported,
+	}
+	for _, size := range cell.households {
+		row.People += size
+	}
+	return row
+}
+
+// checkKAnonymity confirms every row describes at least k households before the
+// dataset leaves the service
+func checkKAnonymity(rows []ResearchDatasetRow, k int) error {
+	for _, row := range rows {
+		if row.Households < k {
+			return fmt.Errorf("%w: %s %s %s has %d", ErrResearchKAnonymity, row.WeekStart, row.Ward, row.Category, row.Households)
+		}
+	}
+	return nil
+}
+
+// researchCSV writes the rows under the documented header
+func researchCSV(rows []ResearchDatasetRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	header := make([]string, len(researchDatasetFields))
+	for i, field := range researchDatasetFields {
+		header[i] = field.Name
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write([]string{
+			row.WeekStart, row.Ward, row.Category,
+			strconv.Itoa(row.Requests), strconv.Itoa(row.Households),
+			strconv.Itoa(row.People), strconv.Itoa(row.SupportedRequests),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// wardFor finds a postcode's ward, by its sector and then its district
+func wardFor(postcode string, wards map[string]string) string {
+	if ward, ok := wards[postcodeSector(postcode)]; ok {
+		return ward
+	}
+	if ward, ok := wards[postcodeDistrict(postcode)]; ok {
+		return ward
+	}
+	return researchUnmappedWard
+}
+
+// normalizeWardPrefix upper-cases a district or sector and keeps one space before a
+// sector digit
+func normalizeWardPrefix(prefix string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(prefix)), " ")
+}
+
+// researchWeekStart returns midnight on the Monday of a day's week
+func researchWeekStart(day time.Time) time.Time {
+	start, _ := dayRange(day)
+	return start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildResearchDataset(t *testing.T) {
+	wards := map[string]string{"SE13 6": "Lewisham Central", "SE13": "Ladywell", "SE4": "Brockley"}
+	monday := time.Date(2025, 10, 13, 10, 0, 0, 0, time.Local)
+	records := []researchRecord{}
+	add := func(visitor uint, postcode, status string, size int, day time.Time) {
+		records = append(records, researchRecord{VisitorID: visitor, Postcode: postcode, Category: "Food", HouseholdSize: size, Status: status, RequestDate: day})
+	}
+	// Lewisham Central: three households, one of them asking twice
+	add(1, "SE13 6AB", "approved", 2, monday)
+	add(1, "SE136AB", "completed", 3, monday.AddDate(0, 0, 2))
+	add(2, "SE13 6CD", "Pending", 1, monday)
+	add(3, "se13 6ef", "ticket_issued", 4, monday)
+	// Ladywell and Brockley are too small alone, but make three households together
+	add(4, "SE13 7AA", "approved", 1, monday)
+	add(5, "SE13 5AA", "rejected", 2, monday)
+	add(6, "SE4 1AA", "approved", 2, monday)
+	// The next week only one household asked: suppressed
+	add(7, "SE13 6AB", "approved", 1, monday.AddDate(0, 0, 7))
+
+	dataset := buildResearchDataset(records, wards, 3)
+
+	if dataset.Requests != 8 || dataset.Generalised != 3 || dataset.Suppressed != 1 {
+		t.Fatalf("got requests %d generalised %d suppressed %d", dataset.Requests, dataset.Generalised, dataset.Suppressed)
+	}
+	want := []ResearchDatasetRow{
+		{WeekStart: "2025-10-13", Ward: "Lewisham Central", Category: "Food", Requests: 4, Households: 3, People: 8, SupportedRequests: 3},
+		{WeekStart: "2025-10-13", Ward: "Other", Category: "Food", Requests: 3, Households: 3, People: 5, SupportedRequests: 2},
+	}
+	if len(dataset.Rows) != len(want) {
+		t.Fatalf("got rows %+v, want %+v", dataset.Rows, want)
+	}
+	for i := range want {
+		if dataset.Rows[i] != want[i] {
+			t.Errorf("row %d: got %+v, want %+v", i, dataset.Rows[i], want[i])
+		}
+	}
+	if err := checkKAnonymity(dataset.Rows, 3); err != nil {
+		t.Errorf("built dataset failed its own check: %v", err)
+	}
+	if err := checkKAnonymity(dataset.Rows, 4); !errors.Is(err, ErrResearchKAnonymity) {
+		t.Errorf("got %v, want ErrResearchKAnonymity", err)
+	}
+
+	content, err := researchCSV(dataset.Rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if lines[0] != "week_start,ward,category,requests,households,people,supported_requests" ||
+		lines[1] != "2025-10-13,Lewisham Central,Food,4,3,8,3" || len(lines) != 3 {
+		t.Errorf("unexpected CSV:\n%s", content)
+	}
+}
+
+func TestWardFor(t *testing.T) {
+	wards := map[string]string{"SE13 6": "Lewisham Central", "SE13": "Ladywell"}
+	tests := map[string]string{
+		"SE13 6AB": "Lewisham Central", // Sector mapping wins
+		"SE13 7AB": "Ladywell",         // Falls back to the district
+		"BR1 1AA":  researchUnmappedWard,
+		"":         researchUnmappedWard,
+	}
+	for postcode, want := range tests {
+		if got := wardFor(postcode, wards); got != want {
+			t.Errorf("wardFor(%q) = %q, want %q", postcode, got, want)
+		}
+	}
+}
+
+func TestResearchWeekStart(t *testing.T) {
+	for _, day := range []time.Time{
+		time.Date(2025, 10, 13, 0, 0, 0, 0, time.Local),   // Monday
+		time.Date(2025, 10, 16, 15, 0, 0, 0, time.Local),  // Thursday
+		time.Date(2025, 10, 19, 23, 59, 0, 0, time.Local), // Sunday
+	} {
+		if got := researchWeekStart(day); !got.Equal(time.Date(2025, 10, 13, 0, 0, 0, 0, time.Local)) {
+			t.Errorf("week of %s starts %s", day, got)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+	"github.com/geoo115/charity-management-system/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const serviceClosureListLimit = 100
+
+// Service closure errors
+var (
+	ErrServiceClosureNotFound = errors.New("service closure not found")
+	ErrInvalidServiceClosure  = errors.New("invalid service closure")
+	ErrServiceClosureOverlaps = errors.New("service closure overlaps another closure")
+	ErrServiceClosureEnded    = errors.New("service closure has already ended")
+)
+
+// ServiceClosureInput sets up or changes a closure
+type ServiceClosureInput struct {
+	Title        string    `json:"title" binding:"required"`
+	Message      string    `json:"message"`
+	Alternatives string    `json:"alternatives"`
+	StartsAt     time.Time `json:"starts_at" binding:"required"`
+	ReopensAt    time.Time `json:"reopens_at" binding:"required"`
+}
+
+// ServiceClosureNotice is what visitors are told about a closure
+type ServiceClosureNotice struct {
+	Title        string    `json:"title"`
+	Message      string    `json:"message"`
+	Alternatives string    `json:"alternatives,omitempty"`
+	ReopensAt    time.Time `json:"reopens_at"`
+}
+
+// ServiceClosureService manages closure periods. While the service is closed it sends
+// an automatic reply to help requests, in-app messages and emails, and queues them for
+// staff to work through when it reopens.
+type ServiceClosureService struct {
+	db *gorm.DB
+}
+
+// NewServiceClosureService creates a new service closure service
+func NewServiceClosureService() *ServiceClosureService {
+	return &ServiceClosureService{db: db.DB}
+}
+
+// Active returns the closure in force now, or nil while the service is open
+func (cs *ServiceClosureService) Active(now time.Time) (*models.ServiceClosure, error) {
+	var closure models.ServiceClosure
+	err := cs.db.Where("starts_at <= ? AND reopens_at > ? AND cancelled_at IS NULL", now, now).
+		Order("starts_at DESC").First(&closure).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &closure, nil
+}
+
+// List returns closures, latest first
+func (cs *ServiceClosureService) List() ([]models.ServiceClosure, error) {
+	var closures []models.ServiceClosure
+	err := cs.db.Order("starts_at DESC").Limit(serviceClosureListLimit).Find(&closures).Error
+	return closures, err
+}
+
+// Get returns one closure
+func (cs *ServiceClosureService) Get(id uint) (*models.ServiceClosure, error) {
+	var closure models.ServiceClosure
+	if err := cs.db.First(&closure, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrServiceClosureNotFound
+		}
+		return nil, err
+	}
+	return &closure, nil
+}
+
+// Create schedules a closure
+func (cs *ServiceClosureService) Create(input ServiceClosureInput, createdBy uint, now time.Time) (*models.ServiceClosure, error) {
+	closure := &models.ServiceClosure{CreatedBy: createdBy}
+	if err := applyServiceClosure(closure, input, now); err != nil {
+		return nil, err
+	}
+	if err := cs.checkOverlap(closure); err != nil {
+		return nil, err
+	}
+	if err := cs.db.Create(closure).Error; err != nil {
+		return nil, err
+	}
+	return closure, nil
+}
+
+// Update changes a closure that has not yet ended
+func (cs *ServiceClosureService) Update(id uint, input ServiceClosureInput, now time.Time) (*models.ServiceClosure, error) {
+	closure, err := cs.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if closure.CancelledAt != nil || closure.ReleasedAt != nil || !closure.ReopensAt.After(now) {
+		return nil, ErrServiceClosureEnded
+	}
+	startedAt := closure.StartsAt
+	if err := applyServiceClosure(closure, input, now); err != nil {
+		return nil, err
+	}
+	// A closure already under way keeps its start; what was received stays queued
+	if !startedAt.After(now) {
+		closure.StartsAt = startedAt
+	}
+	if err := cs.checkOverlap(closure); err != nil {
+		return nil, err
+	}
+	if err := cs.db.Save(closure).Error; err != nil {
+		return nil, err
+	}
+	return closure, nil
+}
+
+// Cancel calls off a closure that has not started, or reopens the service early when
+// it has. An early reopening hands the queue to staff on the next run of the job.
+func (cs *ServiceClosureService) Cancel(id uint, now time.Time) (*models.ServiceClosure, error) {
+	closure, err := cs.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if closure.CancelledAt != nil || !closure.ReopensAt.After(now) {
+		return nil, ErrServiceClosureEnded
+	}
+	updates := map[string]interface{}{"reopens_at": now}
+	if closure.StartsAt.After(now) {
+		updates = map[string]interface{}{"cancelled_at": now}
+	}
+	if err := cs.db.Model(closure).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return cs.Get(id)
+}
+
+// Queue returns what was received during a closure, oldest first
+func (cs *ServiceClosureService) Queue(id uint) ([]models.ClosureQueueItem, error) {
+	if _, err := cs.Get(id); err != nil {
+		return nil, err
+	}
+	var items []models.ClosureQueueItem
+	err := cs.db.Where("closure_id = ?", id).Order("received_at ASC, id ASC").Find(&items).Error
+	return items, err
+}
+
+// HoldHelpRequest queues a help request made while the service is closed and emails
+// the visitor the closure notice
+func (cs *ServiceClosureService) HoldHelpRequest(closure *models.ServiceClosure, request *models.HelpRequest, now time.Time) error {
+	recipient := strings.ToLower(strings.TrimSpace(request.Email))
+	if recipient == "" {
+		recipient = fmt.Sprintf("user:%d", request.VisitorID)
+	}
+	summary := fmt.Sprintf("%s: %s request for %s", request.Reference, request.Category, request.VisitDay)
+	reply, err := cs.queue(closure, models.ClosureChannelHelpRequest, request.ID, &request.VisitorID, recipient, summary, now)
+	if err != nil || !reply || request.Email == "" {
+		return err
+	}
+	cs.sendEmailReply(closure, request.Email, "We have received your help request "+request.Reference)
+	return nil
+}
+
+// MessageReceived queues an in-app message sent to staff while the service is closed
+// and answers it in the same conversation. Messages between staff are left alone.
+func (cs *ServiceClosureService) MessageReceived(message *models.Message, now time.Time) error {
+	closure, err := cs.Active(now)
+	if err != nil || closure == nil {
+		return err
+	}
+	var users []models.User
+	if err := cs.db.Select("id", "role").Where("id IN ?", []uint{message.SenderID, message.RecipientID}).Find(&users).Error; err != nil {
+		return err
+	}
+	roles := map[uint]string{}
+	for _, user := range users {
+		roles[user.ID] = user.Role
+	}
+	if isStaffRole(roles[message.SenderID]) || !isStaffRole(roles[message.RecipientID]) {
+		return nil
+	}
+
+	reply, err := cs.queue(closure, models.ClosureChannelMessage, message.ID, &message.SenderID,
+		fmt.Sprintf("user:%d", message.SenderID), utils.TruncateText(message.Content, 200), now)
+	if err != nil || !reply {
+		return err
+	}
+	autoReply := &models.Message{
+		ConversationID: message.ConversationID,
+		SenderID:       message.RecipientID,
+		RecipientID:    message.SenderID,
+		Content:        closureReplyText(closure),
+		MessageType:    "text",
+		IsSystemMsg:    true,
+		ReplyToID:      &message.ID,
+	}
+	return cs.db.Create(autoReply).Error
+}
+
+// EmailReceived queues an email received while the service is closed and replies to
+// the sender with the closure notice
+func (cs *ServiceClosureService) EmailReceived(received *models.InboundReply, now time.Time) error {
+	closure, err := cs.Active(now)
+	if err != nil || closure == nil {
+		return err
+	}
+	address := senderAddress(received.Sender)
+	if address == "" {
+		return nil
+	}
+	reply, err := cs.queue(closure, models.ClosureChannelEmail, received.ID, received.UserID,
+		strings.ToLower(address), received.Subject, now)
+	if err != nil || !reply {
+		return err
+	}
+	subject := "Re: " + received.Subject
+	if received.Subject == "" {
+		subject = closure.Title
+	}
+	cs.sendEmailReply(closure, address, subject)
+	return nil
+}
+
+// ReleaseDue hands the queue of each closure that has ended to staff: held help
+// requests are checked against the auto-approval rules again and a task is raised for
+// the rest
+func (cs *ServiceClosureService) ReleaseDue(now time.Time) ([]models.ServiceClosure, error) {
+	var closures []models.ServiceClosure
+	if err := cs.db.Where("reopens_at <= ? AND released_at IS NULL", now).Order("reopens_at ASC").Find(&closures).Error; err != nil {
+		return nil, err
+	}
+	for i := range closures {
+		if err := cs.release(&closures[i], now); err != nil {
+			return closures[:i], fmt.Errorf("closure %d: %w", closures[i].ID, err)
+		}
+	}
+	return closures, nil
+}
+
+// release processes one ended closure's queue
+func (cs *ServiceClosureService) release(closure *models.ServiceClosure, now time.Time) error {
+	var items []models.ClosureQueueItem
+	if err := cs.db.Where("closure_id = ? AND processed_at IS NULL", closure.ID).Order("received_at ASC").Find(&items).Error; err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	approved := 0
+	for _, item := range items {
+		counts[item.Channel]++
+		if item.Channel != models.ClosureChannelHelpRequest {
+			continue
+		}
+		var request models.HelpRequest
+		if err := cs.db.First(&request, item.EntityID).Error; err != nil {
+			continue
+		}
+		if approval, err := NewAutoApprovalService().Apply(&request, now); err != nil {
+			log.Printf("Failed to check auto-approval for help request %d held by closure %d: %v", request.ID, closure.ID, err)
+		} else if approval != nil {
+			approved++
+		}
+	}
+
+	return cs.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"released_at": now}
+		if len(items) > 0 {
+			task := models.Task{
+				Title:       fmt.Sprintf("Follow up what came in during %s", closure.Title),
+				Description: closureQueueSummary(counts, approved),
+				Status:      "pending",
+				Priority:    "high",
+				DueDate:     &now,
+				CreatedByID: closure.CreatedBy,
+			}
+			if err := tx.Create(&task).Error; err != nil {
+				return err
+			}
+			updates["task_id"] = task.ID
+			closure.TaskID = &task.ID
+		}
+		if err := tx.Model(&models.ClosureQueueItem{}).Where("closure_id = ? AND processed_at IS NULL", closure.ID).
+			Update("processed_at", now).Error; err != nil {
+			return err
+		}
+		closure.ReleasedAt = &now
+		return tx.Model(closure).Updates(updates).Error
+	})
+}
+
+// queue records an item received during a closure. It reports whether to send an
+// auto-reply: only the first item from each recipient in a closure gets one, and an
+// item the provider delivers twice is only queued once.
+func (cs *ServiceClosureService) queue(closure *models.ServiceClosure, channel string, entityID uint, userID *uint, recipient, summary string, now time.Time) (bool, error) {
+	var replied int64
+	if err := cs.db.Model(&models.ClosureQueueItem{}).
+		Where("closure_id = ? AND recipient = ? AND replied_at IS NOT NULL", closure.ID, recipient).
+		Count(&replied).Error; err != nil {
+		return false, err
+	}
+
+	item := &models.ClosureQueueItem{
+		ClosureID:  closure.ID,
+		Channel:    channel,
+		EntityID:   entityID,
+		UserID:     userID,
+		Recipient:  recipient,
+		Summary:    utils.TruncateText(summary, 250),
+		ReceivedAt: now,
+	}
+	if replied == 0 {
+		item.RepliedAt = &now
+	}
+	result := cs.db.Clauses(clause.OnConflict{DoNothing: true}).Create(item)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0 && replied == 0, nil
+}
+
+// checkOverlap stops two closures covering the same time
+func (cs *ServiceClosureService) checkOverlap(closure *models.ServiceClosure) error {
+	var overlapping int64
+	err := cs.db.Model(&models.ServiceClosure{}).
+		Where("id <> ? AND cancelled_at IS NULL AND starts_at < ? AND reopens_at > ?", closure.ID, closure.ReopensAt, closure.StartsAt).
+		Count(&overlapping).Error
+	if err != nil {
+		return err
+	}
+	if overlapping > 0 {
+		return ErrServiceClosureOverlaps
+	}
+	return nil
+}
+
+// sendEmailReply emails the closure notice
+func (cs *ServiceClosureService) sendEmailReply(closure *models.ServiceClosure, to, subject string) {
+	body := "<p>" + strings.ReplaceAll(html.EscapeString(closureReplyText(closure)), "\n", "<br>") + "</p>"
+	if err := notifications.GetService().SendEmail(to, subject, body); err != nil {
+		log.Printf("Failed to send closure auto-reply for closure %d: %v", closure.ID, err)
+	}
+}
+
+// Notice returns what visitors are told about a closure
+func (cs *ServiceClosureService) Notice(closure *models.ServiceClosure) *ServiceClosureNotice {
+	return &ServiceClosureNotice{
+		Title:        closure.Title,
+		Message:      closureReplyText(closure),
+		Alternatives: closure.Alternatives,
+		ReopensAt:    closure.ReopensAt,
+	}
+}
+
+// applyServiceClosure checks a closure's details and applies them
+func applyServiceClosure(closure *models.ServiceClosure, input ServiceClosureInput, now time.Time) error {
+	title := strings.TrimSpace(input.Title)
+	switch {
+	case title == "":
+		return fmt.Errorf("%w: title is required", ErrInvalidServiceClosure)
+	case !input.ReopensAt.After(input.StartsAt):
+		return fmt.Errorf("%w: the service must reopen after it closes", ErrInvalidServiceClosure)
+	case !input.ReopensAt.After(now):
+		return fmt.Errorf("%w: the reopening date has passed", ErrInvalidServiceClosure)
+	}
+	closure.Title = title
+	closure.Message = strings.TrimSpace(input.Message)
+	closure.Alternatives = strings.TrimSpace(input.Alternatives)
+	closure.StartsAt = input.StartsAt
+	closure.ReopensAt = input.ReopensAt
+	return nil
+}
+
+// closureReplyText is the auto-reply sent during a closure
+func closureReplyText(closure *models.ServiceClosure) string {
+	message := closure.Message
+	if message == "" {
+		message = fmt.Sprintf("Thank you for getting in touch. We are closed for %s.", closure.Title)
+	}
+	text := fmt.Sprintf("%s\n\nWe reopen on %s. We will pick up your message then; there is no need to send it again.",
+		message, closure.ReopensAt.Local().Format("Monday 2 January at 15:04"))
+	if closure.Alternatives != "" {
+		text += "\n\nIf you need help before then:\n" + closure.Alternatives
+	}
+	return text
+}
+
+// closureQueueSummary describes a closure's queue for the follow-up task
+func closureQueueSummary(counts map[string]int, approved int) string {
+	parts := []string{}
+	for _, channel := range []struct{ key, label string }{
+		{models.ClosureChannelHelpRequest, "help requests"},
+		{models.ClosureChannelMessage, "in-app messages"},
+		{models.ClosureChannelEmail, "emails"},
+	} {
+		if counts[channel.key] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[channel.key], channel.label))
+		}
+	}
+	summary := "Received while the service was closed: " + strings.Join(parts, ", ") + "."
+	if approved > 0 {
+		summary += fmt.Sprintf(" %d help requests were approved automatically on reopening.", approved)
+	}
+	return summary + " Everyone was sent the closure notice; see the closure's queue for the details."
+}
+
+// isStaffRole reports whether a role belongs to the charity's staff
+func isStaffRole(role string) bool {
+	switch role {
+	case models.RoleAdmin, models.RoleSuperAdmin, models.RoleStaff,
+		models.RoleAdminLegacy, models.RoleSuperAdminLegacy, models.RoleStaffLegacy:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestClosureReplyText(t *testing.T) {
+	closure := &models.ServiceClosure{
+		Title:        "Christmas closure",
+		Alternatives: "Call 111 for urgent medical help",
+		ReopensAt:    time.Date(2025, 1, 2, 9, 0, 0, 0, time.Local),
+	}
+
+	text := closureReplyText(closure)
+	for _, want := range []string{
+		"We are closed for Christmas closure.",
+		"We reopen on Thursday 2 January at 09:00.",
+		"If you need help before then:\nCall 111 for urgent medical help",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("reply missing %q:\n%s", want, text)
+		}
+	}
+
+	closure.Message = "We are closed over the holidays."
+	closure.Alternatives = ""
+	text = closureReplyText(closure)
+	if !strings.HasPrefix(text, "We are closed over the holidays.") || strings.Contains(text, "before then") {
+		t.Errorf("unexpected reply:\n%s", text)
+	}
+}
+
+func TestApplyServiceClosure(t *testing.T) {
+	now := time.Date(2025, 12, 1, 12, 0, 0, 0, time.UTC)
+	valid := ServiceClosureInput{
+		Title:     " Christmas closure ",
+		StartsAt:  now.AddDate(0, 0, 23),
+		ReopensAt: now.AddDate(0, 0, 32),
+	}
+	var closure models.ServiceClosure
+	if err := applyServiceClosure(&closure, valid, now); err != nil {
+		t.Fatal(err)
+	}
+	if closure.Title != "Christmas closure" || !closure.ReopensAt.Equal(valid.ReopensAt) {
+		t.Errorf("got %+v", closure)
+	}
+
+	tests := map[string]func(*ServiceClosureInput){
+		"no title":           func(in *ServiceClosureInput) { in.Title = "  " },
+		"reopens before":     func(in *ServiceClosureInput) { in.ReopensAt = in.StartsAt.Add(-time.Hour) },
+		"reopens at start":   func(in *ServiceClosureInput) { in.ReopensAt = in.StartsAt },
+		"reopening has past": func(in *ServiceClosureInput) { in.StartsAt, in.ReopensAt = now.Add(-2*time.Hour), now.Add(-time.Hour) },
+	}
+	for name, change := range tests {
+		input := valid
+		change(&input)
+		if err := applyServiceClosure(&models.ServiceClosure{}, input, now); !errors.Is(err, ErrInvalidServiceClosure) {
+			t.Errorf("%s: got %v, want ErrInvalidServiceClosure", name, err)
+		}
+	}
+}
+
+func TestClosureQueueSummary(t *testing.T) {
+	summary := closureQueueSummary(map[string]int{
+		models.ClosureChannelHelpRequest: 4,
+		models.ClosureChannelEmail:       2,
+	}, 3)
+	want := "Received while the service was closed: 4 help requests, 2 emails. 3 help requests were approved automatically on reopening."
+	if !strings.HasPrefix(summary, want) {
+		t.Errorf("got %q", summary)
+	}
+}
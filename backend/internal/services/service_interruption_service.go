@@ -0,0 +1,466 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+)
+
+// rebookingPriorityRank orders affected visitors so the most urgent get the earliest dates
+var rebookingPriorityRank = map[string]int{
+	models.PriorityCritical: 0,
+	models.PriorityUrgent:   1,
+	models.PriorityHigh:     2,
+	models.PriorityMedium:   3,
+	models.PriorityNormal:   4,
+	models.PriorityLow:      5,
+}
+
+// rebookingBookedStatuses are the help request statuses that take a place on a day
+var rebookingBookedStatuses = []string{
+	models.HelpRequestStatusApproved, models.HelpRequestStatusTicketIssued,
+	models.HelpRequestStatusCheckedIn, models.HelpRequestStatusCompleted,
+}
+
+// rebookingAlternatives is how many other dates are offered alongside the new booking
+const rebookingAlternatives = 3
+
+// ServiceInterruption describes a day that has to be cancelled
+type ServiceInterruption struct {
+	Date       time.Time
+	Reason     string
+	SearchDays int  // How far ahead to look for new dates
+	Notify     bool // Tell visitors about their new booking
+	DryRun     bool // Plan the rebooking without changing anything
+}
+
+// RebookingOutcome is what happened to one affected help request
+type RebookingOutcome struct {
+	HelpRequestID    uint     `json:"help_request_id"`
+	VisitorID        uint     `json:"visitor_id"`
+	VisitorName      string   `json:"visitor_name"`
+	Reference        string   `json:"reference"`
+	Category         string   `json:"category"`
+	Priority         string   `json:"priority"`
+	OldTicketNumber  string   `json:"old_ticket_number,omitempty"`
+	NewVisitDay      string   `json:"new_visit_day,omitempty"`
+	NewTicketNumber  string   `json:"new_ticket_number,omitempty"`
+	AlternativeDates []string `json:"alternative_dates,omitempty"`
+	Reason           string   `json:"reason,omitempty"` // Why the request could not be rebooked
+}
+
+// ServiceInterruptionResult reports the rebooking of a cancelled day
+type ServiceInterruptionResult struct {
+	Date             string             `json:"date"`
+	Reason           string             `json:"reason"`
+	DryRun           bool               `json:"dry_run"`
+	Affected         int                `json:"affected"`
+	TicketsCancelled int                `json:"tickets_cancelled"`
+	Rebooked         []RebookingOutcome `json:"rebooked"`
+	NotRebooked      []RebookingOutcome `json:"not_rebooked"`
+}
+
+// ServiceInterruptionService cancels a service day and moves its bookings to the
+// next days with space
+type ServiceInterruptionService struct {
+	db *gorm.DB
+}
+
+// NewServiceInterruptionService creates a new service interruption service
+func NewServiceInterruptionService() *ServiceInterruptionService {
+	return &ServiceInterruptionService{
+		db: db.DB,
+	}
+}
+
+// Rebook closes the interrupted day, cancels its tickets and rebooks each affected
+// help request on the earliest later day with space for its category. Visitors are
+// placed in priority order, then by when they asked for help. Requests that cannot
+// be placed within the search window go back to pending for staff to follow up.
+func (sis *ServiceInterruptionService) Rebook(interruption ServiceInterruption) (*ServiceInterruptionResult, error) {
+	day := interruption.Date.Format("2006-01-02")
+	if interruption.SearchDays <= 0 {
+		interruption.SearchDays = 14
+	}
+
+	var requests []models.HelpRequest
+	if err := sis.db.Where("visit_day = ? AND status IN ?", day,
+		[]string{models.HelpRequestStatusApproved, models.HelpRequestStatusTicketIssued}).
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	sortRebookingQueue(requests)
+
+	result := &ServiceInterruptionResult{
+		Date:        day,
+		Reason:      interruption.Reason,
+		DryRun:      interruption.DryRun,
+		Affected:    len(requests),
+		Rebooked:    []RebookingOutcome{},
+		NotRebooked: []RebookingOutcome{},
+	}
+
+	// Plan every move first so a dry run shows exactly what would happen
+	remaining := map[string]map[string]int{} // category -> day -> places left
+	plans := make([]RebookingOutcome, len(requests))
+	for i, request := range requests {
+		category := strings.ToLower(request.Category)
+		if remaining[category] == nil {
+			places, err := sis.placesAfter(category, interruption.Date, interruption.SearchDays)
+			if err != nil {
+				return nil, err
+			}
+			remaining[category] = places
+		}
+		plans[i] = planRebooking(request, remaining[category], interruption.SearchDays)
+	}
+
+	if !interruption.DryRun {
+		err := sis.db.Transaction(func(tx *gorm.DB) error {
+			if err := sis.closeDay(tx, interruption); err != nil {
+				return err
+			}
+			for i := range requests {
+				cancelled, err := sis.apply(tx, &requests[i], &plans[i], interruption)
+				if err != nil {
+					return err
+				}
+				result.TicketsCancelled += cancelled
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, plan := range plans {
+		if plan.NewVisitDay == "" {
+			result.NotRebooked = append(result.NotRebooked, plan)
+		} else {
+			result.Rebooked = append(result.Rebooked, plan)
+		}
+		if !interruption.DryRun && interruption.Notify {
+			go sis.notify(requests[i], plan, interruption)
+		}
+	}
+
+	return result, nil
+}
+
+// sortRebookingQueue orders requests by priority, then by when they asked for help
+func sortRebookingQueue(requests []models.HelpRequest) {
+	sort.SliceStable(requests, func(i, j int) bool {
+		ri, rj := rebookingRank(requests[i].Priority), rebookingRank(requests[j].Priority)
+		if ri != rj {
+			return ri < rj
+		}
+		return requests[i].CreatedAt.Before(requests[j].CreatedAt)
+	})
+}
+
+// planRebooking books a request on the earliest day with a place, taking the place,
+// and offers the next few open days as alternatives
+func planRebooking(request models.HelpRequest, places map[string]int, searchDays int) RebookingOutcome {
+	plan := RebookingOutcome{
+		HelpRequestID:   request.ID,
+		VisitorID:       request.VisitorID,
+		VisitorName:     request.VisitorName,
+		Reference:       request.Reference,
+		Category:        request.Category,
+		Priority:        request.Priority,
+		OldTicketNumber: request.TicketNumber,
+	}
+
+	dates := openDates(places)
+	if len(dates) == 0 {
+		plan.Reason = fmt.Sprintf("No %s places in the next %d days", request.Category, searchDays)
+		return plan
+	}
+	plan.NewVisitDay = dates[0]
+	places[dates[0]]--
+	for _, date := range dates[1:] {
+		if len(plan.AlternativeDates) == rebookingAlternatives {
+			break
+		}
+		if places[date] > 0 {
+			plan.AlternativeDates = append(plan.AlternativeDates, date)
+		}
+	}
+	return plan
+}
+
+// closeDay marks the interrupted day as not operating so nothing else is booked on it
+func (sis *ServiceInterruptionService) closeDay(tx *gorm.DB, interruption ServiceInterruption) error {
+	date := time.Date(interruption.Date.Year(), interruption.Date.Month(), interruption.Date.Day(), 0, 0, 0, 0, time.UTC)
+	note := "Service interruption: " + interruption.Reason
+
+	var capacity models.VisitCapacity
+	err := tx.Where("date = ?", date).First(&capacity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		capacity = models.VisitCapacity{
+			Date:             date,
+			DayOfWeek:        date.Format("Monday"),
+			MaxFoodVisits:    50,
+			MaxGeneralVisits: 20,
+		}
+		err = tx.Create(&capacity).Error
+	}
+	if err != nil {
+		return err
+	}
+	// Updated separately as a false is_operating_day would be replaced by the column default on create
+	return tx.Model(&capacity).Updates(map[string]interface{}{
+		"is_operating_day":     false,
+		"notes":                note,
+		"temporary_adjustment": true,
+	}).Error
+}
+
+// apply cancels a help request's tickets for the interrupted day and moves it to its
+// planned day, issuing a new ticket if it had one. It returns the tickets cancelled.
+func (sis *ServiceInterruptionService) apply(tx *gorm.DB, request *models.HelpRequest, plan *RebookingOutcome, interruption ServiceInterruption) (int, error) {
+	now := time.Now()
+	cancelled := tx.Model(&models.Ticket{}).
+		Where("help_request_id = ? AND status = ?", request.ID, models.TicketStatusActive).
+		Updates(map[string]interface{}{"status": models.TicketStatusCancelled, "updated_at": now})
+	if cancelled.Error != nil {
+		return 0, fmt.Errorf("failed to cancel tickets for help request %d: %w", request.ID, cancelled.Error)
+	}
+
+	hadTicket := request.Status == models.HelpRequestStatusTicketIssued
+	note := fmt.Sprintf("%s visit cancelled (%s)", interruption.Date.Format("2006-01-02"), interruption.Reason)
+
+	if plan.NewVisitDay == "" {
+		request.Status = models.HelpRequestStatusPending
+		request.TicketNumber = ""
+		request.QRCode = ""
+		request.Notes = appendRebookingNote(request.Notes, note+"; awaiting a new date")
+		if err := tx.Save(request).Error; err != nil {
+			return 0, fmt.Errorf("failed to update help request %d: %w", request.ID, err)
+		}
+		return int(cancelled.RowsAffected), nil
+	}
+
+	request.VisitDay = plan.NewVisitDay
+	request.Notes = appendRebookingNote(request.Notes, note+"; rebooked for "+plan.NewVisitDay)
+	if hadTicket {
+		visitDate, _ := time.Parse("2006-01-02", plan.NewVisitDay)
+		endOfDay := visitDate.Add(24*time.Hour - time.Second)
+
+		ticketNumber, err := sis.ticketNumber(request.Category)
+		if err != nil {
+			return 0, err
+		}
+		request.TicketNumber = ticketNumber
+		request.QRCode = "QR_" + ticketNumber
+		ticket := models.Ticket{
+			TicketNumber:  ticketNumber,
+			HelpRequestID: request.ID,
+			VisitorID:     request.VisitorID,
+			VisitorName:   request.VisitorName,
+			Category:      request.Category,
+			VisitDate:     visitDate,
+			TimeSlot:      request.TimeSlot,
+			QRCode:        request.QRCode,
+			Status:        models.TicketStatusActive,
+			IssuedAt:      now,
+			ValidUntil:    endOfDay,
+			ExpiresAt:     endOfDay,
+		}
+		if err := tx.Create(&ticket).Error; err != nil {
+			return 0, fmt.Errorf("failed to issue ticket for help request %d: %w", request.ID, err)
+		}
+		plan.NewTicketNumber = ticketNumber
+	}
+	if err := tx.Save(request).Error; err != nil {
+		return 0, fmt.Errorf("failed to update help request %d: %w", request.ID, err)
+	}
+	return int(cancelled.RowsAffected), nil
+}
+
+// placesAfter returns the places left for a category on each operating day in the
+// search window after the interrupted day
+func (sis *ServiceInterruptionService) placesAfter(category string, after time.Time, days int) (map[string]int, error) {
+	var serviceType *models.ServiceType
+	if category != models.CategoryFood && category != models.CategoryGeneral {
+		found, err := NewServiceTypeService().GetByCode(category)
+		if err != nil {
+			return map[string]int{}, nil // Unknown categories cannot be rebooked automatically
+		}
+		serviceType = found
+	}
+
+	start := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	end := start.AddDate(0, 0, days-1)
+
+	var capacities []models.VisitCapacity
+	if err := sis.db.Where("date BETWEEN ? AND ?", start, end).Find(&capacities).Error; err != nil {
+		return nil, err
+	}
+	byDay := make(map[string]models.VisitCapacity, len(capacities))
+	for _, capacity := range capacities {
+		byDay[capacity.Date.Format("2006-01-02")] = capacity
+	}
+
+	var booked []struct {
+		VisitDay string
+		Count    int
+	}
+	if err := sis.db.Model(&models.HelpRequest{}).
+		Select("visit_day, COUNT(*) AS count").
+		Where("LOWER(category) = ? AND visit_day BETWEEN ? AND ? AND status IN ?",
+			category, start.Format("2006-01-02"), end.Format("2006-01-02"), rebookingBookedStatuses).
+		Group("visit_day").
+		Scan(&booked).Error; err != nil {
+		return nil, err
+	}
+	bookedByDay := make(map[string]int, len(booked))
+	for _, b := range booked {
+		bookedByDay[b.VisitDay] = b.Count
+	}
+	return rebookingPlaces(category, serviceType, start, end, byDay, bookedByDay), nil
+}
+
+// rebookingPlaces works out the places left on each operating day from start to end,
+// from the configured capacities or the defaults, less what is already booked
+func rebookingPlaces(category string, serviceType *models.ServiceType, start, end time.Time, byDay map[string]models.VisitCapacity, bookedByDay map[string]int) map[string]int {
+	places := map[string]int{}
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		day := date.Format("2006-01-02")
+		capacity, configured := byDay[day]
+		if configured && !capacity.IsOperatingDay {
+			continue
+		}
+		if !configured && (date.Weekday() < time.Tuesday || date.Weekday() > time.Thursday) {
+			continue // Default operating days are Tuesday to Thursday
+		}
+
+		max := 0
+		switch {
+		case serviceType != nil:
+			max = serviceType.DailyCapacity
+		case category == models.CategoryFood:
+			max = 50
+			if configured {
+				max = capacity.MaxFoodVisits
+			}
+		default:
+			max = 20
+			if configured {
+				max = capacity.MaxGeneralVisits
+			}
+		}
+		if left := max - bookedByDay[day]; left > 0 {
+			places[day] = left
+		}
+	}
+	return places
+}
+
+// ticketNumber creates a ticket number using the category's service type prefix if it has one
+func (sis *ServiceInterruptionService) ticketNumber(category string) (string, error) {
+	serviceTypeService := NewServiceTypeService()
+	if serviceType, err := serviceTypeService.GetByCode(strings.ToLower(category)); err == nil {
+		return serviceTypeService.GenerateTicketNumber(serviceType), nil
+	}
+	suffix, err := randomHex(4)
+	if err != nil {
+		return "", err
+	}
+	return "TKT-" + strings.ToUpper(suffix), nil
+}
+
+// notify tells a visitor their visit was cancelled and when they have been rebooked
+func (sis *ServiceInterruptionService) notify(request models.HelpRequest, plan RebookingOutcome, interruption ServiceInterruption) {
+	var visitor models.User
+	if err := sis.db.First(&visitor, request.VisitorID).Error; err != nil {
+		log.Printf("Failed to find visitor %d for rebooking notice: %v", request.VisitorID, err)
+		return
+	}
+
+	title := "Your visit has been moved"
+	message := fmt.Sprintf("We're sorry, we have had to close on %s (%s). ",
+		interruption.Date.Format("Monday 2 January"), interruption.Reason)
+	if plan.NewVisitDay == "" {
+		title = "Your visit has been cancelled"
+		message += "We could not find you a new date straight away. A member of staff will contact you to rebook."
+	} else {
+		newDate, _ := time.Parse("2006-01-02", plan.NewVisitDay)
+		message += fmt.Sprintf("Your visit is now on %s.", newDate.Format("Monday 2 January"))
+		if plan.NewTicketNumber != "" {
+			message += " Your new ticket number is " + plan.NewTicketNumber + "."
+		}
+		if len(plan.AlternativeDates) > 0 {
+			message += " If that day doesn't suit you, contact us to move to " + strings.Join(plan.AlternativeDates, ", ") + "."
+		}
+	}
+
+	notification := models.InAppNotification{
+		UserID:    visitor.ID,
+		Title:     title,
+		Message:   message,
+		Type:      "warning",
+		Priority:  "high",
+		ActionURL: "/visitor/help-requests",
+	}
+	if err := sis.db.Create(&notification).Error; err != nil {
+		log.Printf("Failed to create rebooking notice for visitor %d: %v", visitor.ID, err)
+	}
+
+	if visitor.Email != "" {
+		if notificationService := notifications.GetService(); notificationService != nil {
+			if err := notificationService.SendEmail(visitor.Email, title, message); err != nil {
+				log.Printf("Failed to email rebooking notice to visitor %d: %v", visitor.ID, err)
+			}
+		}
+		return
+	}
+
+	phone := visitor.Phone
+	if phone == "" {
+		phone = request.Phone
+	}
+	if normalized, err := models.NormalizePhone(phone); err == nil {
+		if err := sendUrgentSMS(normalized, "Lewisham Charity: "+message); err != nil {
+			log.Printf("Failed to text rebooking notice to visitor %d: %v", visitor.ID, err)
+		}
+	}
+}
+
+// rebookingRank returns the order a priority is rebooked in
+func rebookingRank(priority string) int {
+	if rank, ok := rebookingPriorityRank[strings.ToLower(priority)]; ok {
+		return rank
+	}
+	return rebookingPriorityRank[models.PriorityNormal]
+}
+
+// openDates lists the days with places left, earliest first
+func openDates(places map[string]int) []string {
+	dates := make([]string, 0, len(places))
+	for date, left := range places {
+		if left > 0 {
+			dates = append(dates, date)
+		}
+	}
+	sort.Strings(dates)
+	return dates
+}
+
+// appendRebookingNote adds a line to a help request's notes
+func appendRebookingNote(notes, note string) string {
+	if notes == "" {
+		return note
+	}
+	return notes + "\n" + note
+}
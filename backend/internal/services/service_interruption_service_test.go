@@ -0,0 +1,96 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestRebookingPlaces(t *testing.T) {
+	// Closed on Tuesday 5 May; search the following week
+	start := time.Date(2026, 5, 6, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 6)
+	byDay := map[string]models.VisitCapacity{
+		"2026-05-07": {IsOperatingDay: false},                                       // Closed Thursday
+		"2026-05-08": {IsOperatingDay: true, MaxFoodVisits: 2, MaxGeneralVisits: 0}, // Extra Friday session
+	}
+	booked := map[string]int{"2026-05-06": 49}
+
+	tests := []struct {
+		category    string
+		serviceType *models.ServiceType
+		want        map[string]int
+	}{
+		{models.CategoryFood, nil, map[string]int{"2026-05-06": 1, "2026-05-08": 2, "2026-05-12": 50}},
+		{models.CategoryGeneral, nil, map[string]int{"2026-05-12": 20}},
+		{"uniform", &models.ServiceType{DailyCapacity: 60}, map[string]int{"2026-05-06": 11, "2026-05-08": 60, "2026-05-12": 60}},
+	}
+	for _, tt := range tests {
+		if got := rebookingPlaces(tt.category, tt.serviceType, start, end, byDay, booked); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.category, got, tt.want)
+		}
+	}
+}
+
+func TestPlanRebooking(t *testing.T) {
+	places := map[string]int{"2026-05-06": 1, "2026-05-07": 2, "2026-05-12": 1, "2026-05-13": 1, "2026-05-14": 1}
+	request := models.HelpRequest{ID: 3, Category: models.CategoryFood, TicketNumber: "FOOD-001"}
+
+	want := []struct {
+		day          string
+		alternatives []string
+	}{
+		{"2026-05-06", []string{"2026-05-07", "2026-05-12", "2026-05-13"}},
+		{"2026-05-07", []string{"2026-05-12", "2026-05-13", "2026-05-14"}},
+		{"2026-05-07", []string{"2026-05-12", "2026-05-13", "2026-05-14"}},
+		{"2026-05-12", []string{"2026-05-13", "2026-05-14"}},
+	}
+	for i, w := range want {
+		plan := planRebooking(request, places, 14)
+		if plan.NewVisitDay != w.day || !reflect.DeepEqual(plan.AlternativeDates, w.alternatives) {
+			t.Errorf("request %d: got %s with %v, want %s with %v", i, plan.NewVisitDay, plan.AlternativeDates, w.day, w.alternatives)
+		}
+		if plan.OldTicketNumber != "FOOD-001" {
+			t.Errorf("request %d: old ticket %q", i, plan.OldTicketNumber)
+		}
+	}
+
+	full := planRebooking(request, map[string]int{"2026-05-06": 0}, 14)
+	if full.NewVisitDay != "" || full.Reason != "No food places in the next 14 days" {
+		t.Errorf("no places: got %+v", full)
+	}
+}
+
+func TestSortRebookingQueue(t *testing.T) {
+	asked := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	requests := []models.HelpRequest{
+		{ID: 1, Priority: models.PriorityNormal},
+		{ID: 2, Priority: "URGENT"},
+		{ID: 3, Priority: ""}, // Treated as normal
+		{ID: 4, Priority: models.PriorityCritical},
+		{ID: 5, Priority: models.PriorityLow},
+	}
+	for i := range requests {
+		requests[i].CreatedAt = asked.Add(time.Duration(5-i) * time.Minute)
+	}
+
+	sortRebookingQueue(requests)
+	var got []uint
+	for _, request := range requests {
+		got = append(got, request.ID)
+	}
+	if want := []uint{4, 2, 3, 1, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestAppendRebookingNote(t *testing.T) {
+	if got := appendRebookingNote("", "moved"); got != "moved" {
+		t.Errorf("got %q", got)
+	}
+	if got := appendRebookingNote("needs halal", "moved"); got != "needs halal\nmoved" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/websocket"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// A visitor past this multiple of a target raises a critical alert
+const serviceTimeCriticalFactor = 1.5
+
+// Default targets for queues whose category has no service type
+const (
+	defaultTargetWaitMinutes    = 20
+	defaultTargetServiceMinutes = 15
+)
+
+// serviceTimeAlertTopic is the WebSocket topic floor dashboards subscribe to
+const serviceTimeAlertTopic = "service_time_alerts"
+
+// ServiceTimeService times visitors from check-in to check-out against each service
+// type's targets and alerts the floor when they run over
+type ServiceTimeService struct {
+	db *gorm.DB
+}
+
+// LiveServiceTime is a visitor currently waiting or being served
+type LiveServiceTime struct {
+	QueueEntryID  uint      `json:"queue_entry_id"`
+	VisitorID     uint      `json:"visitor_id"`
+	VisitorName   string    `json:"visitor_name"`
+	Reference     string    `json:"reference"`
+	Category      string    `json:"category"`
+	Stage         string    `json:"stage"`
+	Since         time.Time `json:"since"`
+	Minutes       int       `json:"minutes"`
+	TargetMinutes int       `json:"target_minutes"`
+	Level         string    `json:"level"` // ok, over_target, critical
+}
+
+// ServiceTimeStats summarises completed visits for one service type
+type ServiceTimeStats struct {
+	Category              string  `json:"category"`
+	Completed             int     `json:"completed"`
+	AverageWaitMinutes    float64 `json:"average_wait_minutes"`
+	AverageServiceMinutes float64 `json:"average_service_minutes"`
+	AverageTotalMinutes   float64 `json:"average_total_minutes"`
+	TargetWaitMinutes     int     `json:"target_wait_minutes"`
+	TargetServiceMinutes  int     `json:"target_service_minutes"`
+	WaitWithinTarget      float64 `json:"wait_within_target"`    // Percentage of visits
+	ServiceWithinTarget   float64 `json:"service_within_target"` // Percentage of visits
+}
+
+// NewServiceTimeService creates a new service time service
+func NewServiceTimeService() *ServiceTimeService {
+	return &ServiceTimeService{
+		db: db.DB,
+	}
+}
+
+// targets returns the wait and service targets for each service type code
+func (sts *ServiceTimeService) targets() map[string][2]int {
+	var serviceTypes []models.ServiceType
+	sts.db.Find(&serviceTypes)
+
+	targets := make(map[string][2]int, len(serviceTypes))
+	for _, serviceType := range serviceTypes {
+		targets[serviceType.Code] = [2]int{serviceType.TargetWaitMinutes, serviceType.TargetServiceMinutes}
+	}
+	return targets
+}
+
+// targetFor returns the target minutes for a category and stage
+func targetFor(targets map[string][2]int, category, stage string) int {
+	target, ok := targets[strings.ToLower(category)]
+	if !ok {
+		target = [2]int{defaultTargetWaitMinutes, defaultTargetServiceMinutes}
+	}
+	if stage == models.ServiceStageInService {
+		return target[1]
+	}
+	return target[0]
+}
+
+// serviceTimeLevel compares minutes in a stage with its target
+func serviceTimeLevel(minutes, target int) string {
+	switch {
+	case target <= 0:
+		return "ok"
+	case float64(minutes) >= float64(target)*serviceTimeCriticalFactor:
+		return models.ServiceAlertCritical
+	case minutes >= target:
+		return models.ServiceAlertOverTarget
+	default:
+		return "ok"
+	}
+}
+
+// LiveServiceTimes returns today's visitors who are waiting or being served, longest
+// first
+func (sts *ServiceTimeService) LiveServiceTimes(now time.Time) ([]LiveServiceTime, error) {
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var entries []models.QueueEntry
+	if err := sts.db.Preload("Visitor", func(tx *gorm.DB) *gorm.DB {
+		return tx.Select("id", "first_name", "last_name")
+	}).
+		Where("status IN ? AND joined_at >= ?", []string{"waiting", "called", "being_served"}, startOfDay).
+		Order("joined_at ASC").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	targets := sts.targets()
+	live := make([]LiveServiceTime, 0, len(entries))
+	for _, entry := range entries {
+		stage, since := models.ServiceStageWaiting, entry.JoinedAt
+		if entry.Status == "being_served" && entry.ServedAt != nil {
+			stage, since = models.ServiceStageInService, *entry.ServedAt
+		}
+		minutes := int(now.Sub(since).Minutes())
+		target := targetFor(targets, entry.Category, stage)
+
+		live = append(live, LiveServiceTime{
+			QueueEntryID:  entry.ID,
+			VisitorID:     entry.VisitorID,
+			VisitorName:   strings.TrimSpace(entry.Visitor.FirstName + " " + entry.Visitor.LastName),
+			Reference:     entry.Reference,
+			Category:      entry.Category,
+			Stage:         stage,
+			Since:         since,
+			Minutes:       minutes,
+			TargetMinutes: target,
+			Level:         serviceTimeLevel(minutes, target),
+		})
+	}
+
+	return live, nil
+}
+
+// CheckServiceTimes raises an alert for each visitor who has newly gone past a
+// target and tells the floor. It returns how many alerts were raised.
+func (sts *ServiceTimeService) CheckServiceTimes(now time.Time) (int, error) {
+	live, err := sts.LiveServiceTimes(now)
+	if err != nil {
+		return 0, err
+	}
+
+	raised := 0
+	for _, visit := range live {
+		if visit.Level == "ok" {
+			continue
+		}
+
+		alert := models.ServiceTimeAlert{
+			QueueEntryID:  visit.QueueEntryID,
+			Stage:         visit.Stage,
+			Level:         visit.Level,
+			VisitorID:     visit.VisitorID,
+			Category:      visit.Category,
+			Reference:     visit.Reference,
+			Minutes:       visit.Minutes,
+			TargetMinutes: visit.TargetMinutes,
+		}
+		result := sts.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&alert)
+		if result.Error != nil {
+			log.Printf("Failed to record service time alert for queue entry %d: %v", visit.QueueEntryID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue // Already alerted at this level
+		}
+
+		sts.notifyFloor(&alert, visit)
+		raised++
+	}
+
+	return raised, nil
+}
+
+// notifyFloor sends a service time alert to floor dashboards and coordinators
+func (sts *ServiceTimeService) notifyFloor(alert *models.ServiceTimeAlert, visit LiveServiceTime) {
+	stage := "waiting"
+	if alert.Stage == models.ServiceStageInService {
+		stage = "in service"
+	}
+	title := "Visitor over service time target"
+	priority := "high"
+	if alert.Level == models.ServiceAlertCritical {
+		title = "Visitor well over service time target"
+		priority = "urgent"
+	}
+	message := fmt.Sprintf("%s (%s, %s) has been %s for %d minutes against a %d minute target.",
+		visit.VisitorName, alert.Reference, alert.Category, stage, alert.Minutes, alert.TargetMinutes)
+
+	payload := map[string]interface{}{
+		"type":      "service_time_alert",
+		"alert":     alert,
+		"visit":     visit,
+		"title":     title,
+		"message":   message,
+		"priority":  priority,
+		"timestamp": time.Now(),
+	}
+	manager := websocket.GetGlobalManager()
+	if err := manager.BroadcastToTopic(serviceTimeAlertTopic, payload); err != nil {
+		log.Printf("Failed to broadcast service time alert: %v", err)
+	}
+	if err := manager.BroadcastToRole(models.RoleAdmin, payload); err != nil {
+		log.Printf("Failed to broadcast service time alert to admins: %v", err)
+	}
+
+	realtime := NewRealtimeNotificationService()
+	for _, userID := range sts.floorCoordinators() {
+		realtime.SendNotification(RealtimeNotificationData{
+			UserID:    userID,
+			Type:      "service_time_alert",
+			Title:     title,
+			Message:   message,
+			Priority:  priority,
+			Category:  "queue",
+			ActionURL: "/admin/queue",
+			Channels:  []string{"websocket"},
+			Data: map[string]interface{}{
+				"alert_id":       alert.ID,
+				"queue_entry_id": alert.QueueEntryID,
+			},
+		})
+	}
+}
+
+// floorCoordinators returns the users who receive service time alerts: active staff
+// coordinators, or admins if there are none
+func (sts *ServiceTimeService) floorCoordinators() []uint {
+	var userIDs []uint
+	sts.db.Model(&models.StaffProfile{}).
+		Where("position = ? AND status = ?", models.PositionCoordinator, models.StaffStatusActive).
+		Pluck("user_id", &userIDs)
+	if len(userIDs) > 0 {
+		return userIDs
+	}
+
+	sts.db.Model(&models.User{}).
+		Where("role IN ? AND status = ?", []string{models.RoleAdmin, models.RoleSuperAdmin}, models.StatusActive).
+		Pluck("id", &userIDs)
+	return userIDs
+}
+
+// AcknowledgeAlert records that someone on the floor has dealt with an alert
+func (sts *ServiceTimeService) AcknowledgeAlert(alert *models.ServiceTimeAlert, userID uint) error {
+	now := time.Now()
+	alert.AcknowledgedBy = &userID
+	alert.AcknowledgedAt = &now
+	return sts.db.Save(alert).Error
+}
+
+// Stats summarises completed visits per service type between two times
+func (sts *ServiceTimeService) Stats(from, to time.Time) ([]ServiceTimeStats, error) {
+	var entries []models.QueueEntry
+	if err := sts.db.Select("category", "joined_at", "served_at", "completed_at").
+		Where("status = ? AND completed_at >= ? AND completed_at < ?", "completed", from, to).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	return serviceTimeStats(entries, sts.targets()), nil
+}
+
+// serviceTimeStats averages completed visits per category and measures them against
+// the targets
+func serviceTimeStats(entries []models.QueueEntry, targets map[string][2]int) []ServiceTimeStats {
+	type totals struct {
+		count, serviced               int
+		wait, service, total          float64
+		waitOnTarget, serviceOnTarget int
+	}
+	byCategory := map[string]*totals{}
+	var order []string
+
+	for _, entry := range entries {
+		if entry.CompletedAt == nil {
+			continue
+		}
+		t, ok := byCategory[entry.Category]
+		if !ok {
+			t = &totals{}
+			byCategory[entry.Category] = t
+			order = append(order, entry.Category)
+		}
+		t.count++
+		t.total += entry.CompletedAt.Sub(entry.JoinedAt).Minutes()
+		if entry.ServedAt == nil {
+			continue
+		}
+
+		t.serviced++
+		wait := entry.ServedAt.Sub(entry.JoinedAt).Minutes()
+		service := entry.CompletedAt.Sub(*entry.ServedAt).Minutes()
+		t.wait += wait
+		t.service += service
+		if target := targetFor(targets, entry.Category, models.ServiceStageWaiting); target <= 0 || wait <= float64(target) {
+			t.waitOnTarget++
+		}
+		if target := targetFor(targets, entry.Category, models.ServiceStageInService); target <= 0 || service <= float64(target) {
+			t.serviceOnTarget++
+		}
+	}
+
+	stats := make([]ServiceTimeStats, 0, len(order))
+	for _, category := range order {
+		t := byCategory[category]
+		row := ServiceTimeStats{
+			Category:             category,
+			Completed:            t.count,
+			AverageTotalMinutes:  roundMinutes(t.total / float64(t.count)),
+			TargetWaitMinutes:    targetFor(targets, category, models.ServiceStageWaiting),
+			TargetServiceMinutes: targetFor(targets, category, models.ServiceStageInService),
+		}
+		if t.serviced > 0 {
+			row.AverageWaitMinutes = roundMinutes(t.wait / float64(t.serviced))
+			row.AverageServiceMinutes = roundMinutes(t.service / float64(t.serviced))
+			row.WaitWithinTarget = math.Round(float64(t.waitOnTarget)/float64(t.serviced)*1000) / 10
+			row.ServiceWithinTarget = math.Round(float64(t.serviceOnTarget)/float64(t.serviced)*1000) / 10
+		}
+		stats = append(stats, row)
+	}
+
+	return stats
+}
+
+// roundMinutes rounds a number of minutes to one decimal place
+func roundMinutes(minutes float64) float64 {
+	return math.Round(minutes*10) / 10
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestTargetFor(t *testing.T) {
+	targets := map[string][2]int{"food": {30, 10}, "clothing": {0, 0}}
+
+	tests := []struct {
+		category, stage string
+		want            int
+	}{
+		{"Food", models.ServiceStageWaiting, 30},
+		{"food", models.ServiceStageInService, 10},
+		{"clothing", models.ServiceStageWaiting, 0},
+		{"advice", models.ServiceStageWaiting, defaultTargetWaitMinutes},
+		{"advice", models.ServiceStageInService, defaultTargetServiceMinutes},
+	}
+	for _, tt := range tests {
+		if got := targetFor(targets, tt.category, tt.stage); got != tt.want {
+			t.Errorf("targetFor(%s, %s) = %d, want %d", tt.category, tt.stage, got, tt.want)
+		}
+	}
+}
+
+func TestServiceTimeLevel(t *testing.T) {
+	tests := []struct {
+		minutes, target int
+		want            string
+	}{
+		{19, 20, "ok"},
+		{20, 20, models.ServiceAlertOverTarget},
+		{29, 20, models.ServiceAlertOverTarget},
+		{30, 20, models.ServiceAlertCritical},
+		{500, 0, "ok"}, // No target means no alerts
+	}
+	for _, tt := range tests {
+		if got := serviceTimeLevel(tt.minutes, tt.target); got != tt.want {
+			t.Errorf("serviceTimeLevel(%d, %d) = %s, want %s", tt.minutes, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestServiceTimeStats(t *testing.T) {
+	start := time.Date(2026, 5, 5, 10, 30, 0, 0, time.UTC)
+	visit := func(category string, waited, served int) models.QueueEntry {
+		entry := models.QueueEntry{Category: category, JoinedAt: start}
+		completed := start.Add(time.Duration(waited+served) * time.Minute)
+		entry.CompletedAt = &completed
+		if served > 0 {
+			servedAt := start.Add(time.Duration(waited) * time.Minute)
+			entry.ServedAt = &servedAt
+		}
+		return entry
+	}
+	entries := []models.QueueEntry{
+		visit("food", 10, 5),
+		visit("food", 40, 20),
+		visit("advice", 15, 0), // Completed without being marked as served
+		visit("food", 25, 8),
+		{Category: "food", JoinedAt: start}, // Not completed
+	}
+
+	stats := serviceTimeStats(entries, map[string][2]int{"food": {30, 10}})
+	if len(stats) != 2 || stats[0].Category != "food" || stats[1].Category != "advice" {
+		t.Fatalf("got %+v", stats)
+	}
+
+	food := stats[0]
+	if food.Completed != 3 || food.AverageWaitMinutes != 25 || food.AverageServiceMinutes != 11 || food.AverageTotalMinutes != 36 {
+		t.Errorf("food averages: got %+v", food)
+	}
+	if food.WaitWithinTarget != 66.7 || food.ServiceWithinTarget != 66.7 || food.TargetWaitMinutes != 30 {
+		t.Errorf("food targets: got %+v", food)
+	}
+
+	advice := stats[1]
+	if advice.Completed != 1 || advice.AverageTotalMinutes != 15 || advice.AverageWaitMinutes != 0 || advice.WaitWithinTarget != 0 ||
+		advice.TargetWaitMinutes != defaultTargetWaitMinutes {
+		t.Errorf("advice: got %+v", advice)
+	}
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// ServiceTypeService manages configurable visitor service types
+type ServiceTypeService struct {
+	db *gorm.DB
+}
+
+// ServiceTypeAnalytics summarises demand for a service type over a period
+type ServiceTypeAnalytics struct {
+	Code            string           `json:"code"`
+	Name            string           `json:"name"`
+	StartDate       string           `json:"start_date"`
+	EndDate         string           `json:"end_date"`
+	TotalRequests   int64            `json:"total_requests"`
+	ByStatus        map[string]int64 `json:"by_status"`
+	TicketsIssued   int64            `json:"tickets_issued"`
+	TicketsUsed     int64            `json:"tickets_used"`
+	UtilizationRate float64          `json:"utilization_rate"`
+	DailyCapacity   int              `json:"daily_capacity"`
+	DailyDemand     []DailyDemand    `json:"daily_demand"`
+}
+
+// DailyDemand is the number of requests for a service type on a visit day
+type DailyDemand struct {
+	VisitDay string `json:"visit_day"`
+	Requests int64  `json:"requests"`
+}
+
+// NewServiceTypeService creates a new service type service
+func NewServiceTypeService() *ServiceTypeService {
+	return &ServiceTypeService{
+		db: db.DB,
+	}
+}
+
+// ListActive returns active service types in display order
+func (ss *ServiceTypeService) ListActive() ([]models.ServiceType, error) {
+	var serviceTypes []models.ServiceType
+	err := ss.db.Where("is_active = ?", true).
+		Order("sort_order ASC, name ASC").
+		Find(&serviceTypes).Error
+	return serviceTypes, err
+}
+
+// ActiveCodes returns the codes of all active service types
+func (ss *ServiceTypeService) ActiveCodes() []string {
+	var codes []string
+	ss.db.Model(&models.ServiceType{}).
+		Where("is_active = ?", true).
+		Order("sort_order ASC").
+		Pluck("code", &codes)
+	if len(codes) == 0 {
+		return []string{models.CategoryFood, models.CategoryGeneral}
+	}
+	return codes
+}
+
+// GetByCode finds a service type by its code, ignoring case so legacy
+// categories such as "Food" resolve to the "food" service type
+func (ss *ServiceTypeService) GetByCode(code string) (*models.ServiceType, error) {
+	var serviceType models.ServiceType
+	if err := ss.db.Where("LOWER(code) = ?", strings.ToLower(strings.TrimSpace(code))).
+		First(&serviceType).Error; err != nil {
+		return nil, err
+	}
+	return &serviceType, nil
+}
+
+// Validate checks a service type's configuration before it is saved
+func (ss *ServiceTypeService) Validate(serviceType *models.ServiceType) error {
+	serviceType.Code = strings.ToLower(strings.TrimSpace(serviceType.Code))
+	if serviceType.Code == "" || strings.ContainsAny(serviceType.Code, " /") {
+		return fmt.Errorf("code must be a single lowercase word, e.g. clothing_bank")
+	}
+	if serviceType.DailyCapacity < 0 || serviceType.MaxVisitorsPerSlot < 0 {
+		return fmt.Errorf("capacity values cannot be negative")
+	}
+	if serviceType.TargetWaitMinutes < 0 || serviceType.TargetServiceMinutes < 0 {
+		return fmt.Errorf("service time targets cannot be negative")
+	}
+	if serviceType.SlotIntervalMinutes <= 0 {
+		return fmt.Errorf("slot interval must be greater than zero")
+	}
+	if _, _, err := serviceType.SlotWindow(); err != nil {
+		return err
+	}
+	for _, day := range strings.Split(serviceType.OperatingDays, ",") {
+		if !isWeekdayName(strings.TrimSpace(day)) {
+			return fmt.Errorf("invalid operating day: %s", day)
+		}
+	}
+	serviceType.TicketPrefix = strings.ToUpper(strings.TrimSpace(serviceType.TicketPrefix))
+	if serviceType.TicketPrefix == "" {
+		serviceType.TicketPrefix = "TKT"
+	}
+	return nil
+}
+
+// RemainingCapacity returns how many more visits can be booked for the service on a day
+func (ss *ServiceTypeService) RemainingCapacity(serviceType *models.ServiceType, visitDay string) int {
+	var booked int64
+	ss.db.Model(&models.HelpRequest{}).
+		Where("LOWER(category) = ? AND visit_day = ? AND status IN ?", serviceType.Code, visitDay,
+			[]string{models.HelpRequestStatusApproved, models.HelpRequestStatusTicketIssued,
+				models.HelpRequestStatusCheckedIn, models.HelpRequestStatusCompleted}).
+		Count(&booked)
+
+	remaining := serviceType.DailyCapacity - int(booked)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// GenerateTicketNumber creates a ticket number using the service type's prefix
+func (ss *ServiceTypeService) GenerateTicketNumber(serviceType *models.ServiceType) string {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%s-%d", serviceType.TicketPrefix, time.Now().UnixNano()%1000000)
+	}
+	return fmt.Sprintf("%s-%s-%s", serviceType.TicketPrefix, time.Now().Format("0102"), strings.ToUpper(hex.EncodeToString(b)))
+}
+
+// Analytics returns request and ticket statistics for a service type between two dates
+func (ss *ServiceTypeService) Analytics(serviceType *models.ServiceType, start, end time.Time) (*ServiceTypeAnalytics, error) {
+	startDay := start.Format("2006-01-02")
+	endDay := end.Format("2006-01-02")
+
+	analytics := &ServiceTypeAnalytics{
+		Code:          serviceType.Code,
+		Name:          serviceType.Name,
+		StartDate:     startDay,
+		EndDate:       endDay,
+		ByStatus:      map[string]int64{},
+		DailyCapacity: serviceType.DailyCapacity,
+		DailyDemand:   []DailyDemand{},
+	}
+
+	requests := ss.db.Model(&models.HelpRequest{}).
+		Where("LOWER(category) = ? AND visit_day BETWEEN ? AND ?", serviceType.Code, startDay, endDay)
+
+	var statusRows []struct {
+		Status string
+		Count  int64
+	}
+	if err := requests.Session(&gorm.Session{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&statusRows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range statusRows {
+		analytics.ByStatus[row.Status] = row.Count
+		analytics.TotalRequests += row.Count
+	}
+
+	if err := requests.Session(&gorm.Session{}).
+		Select("visit_day, COUNT(*) AS requests").
+		Group("visit_day").
+		Order("visit_day ASC").
+		Scan(&analytics.DailyDemand).Error; err != nil {
+		return nil, err
+	}
+
+	endOfPeriod := end.AddDate(0, 0, 1)
+	ss.db.Model(&models.Ticket{}).
+		Where("LOWER(category) = ? AND issued_at >= ? AND issued_at < ?", serviceType.Code, start, endOfPeriod).
+		Count(&analytics.TicketsIssued)
+	ss.db.Model(&models.Ticket{}).
+		Where("LOWER(category) = ? AND status = ? AND used_at >= ? AND used_at < ?",
+			serviceType.Code, models.TicketStatusUsed, start, endOfPeriod).
+		Count(&analytics.TicketsUsed)
+
+	if analytics.TicketsIssued > 0 {
+		analytics.UtilizationRate = float64(analytics.TicketsUsed) / float64(analytics.TicketsIssued) * 100
+	}
+
+	return analytics, nil
+}
+
+// isWeekdayName returns true if name is an English weekday name
+func isWeekdayName(name string) bool {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(name, d.String()) {
+			return true
+		}
+	}
+	return false
+}
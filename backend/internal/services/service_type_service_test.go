@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestServiceTypeValidate(t *testing.T) {
+	valid := func() models.ServiceType {
+		return models.ServiceType{
+			Code:                " Clothing_Bank ",
+			DailyCapacity:       20,
+			OperatingDays:       "Tuesday,Thursday",
+			OpeningTime:         "10:30",
+			ClosingTime:         "14:30",
+			SlotIntervalMinutes: 10,
+			MaxVisitorsPerSlot:  2,
+			TicketPrefix:        " clo ",
+		}
+	}
+	ss := &ServiceTypeService{}
+
+	serviceType := valid()
+	if err := ss.Validate(&serviceType); err != nil {
+		t.Fatalf("valid service type rejected: %v", err)
+	}
+	if serviceType.Code != "clothing_bank" || serviceType.TicketPrefix != "CLO" {
+		t.Errorf("got code %q and prefix %q", serviceType.Code, serviceType.TicketPrefix)
+	}
+
+	serviceType = valid()
+	serviceType.TicketPrefix = ""
+	if err := ss.Validate(&serviceType); err != nil || serviceType.TicketPrefix != "TKT" {
+		t.Errorf("blank prefix: got %q, %v", serviceType.TicketPrefix, err)
+	}
+
+	invalid := map[string]func(*models.ServiceType){
+		"blank code":        func(s *models.ServiceType) { s.Code = " " },
+		"code with a space": func(s *models.ServiceType) { s.Code = "clothing bank" },
+		"code with a slash": func(s *models.ServiceType) { s.Code = "food/clothing" },
+		"negative capacity": func(s *models.ServiceType) { s.DailyCapacity = -1 },
+		"negative target":   func(s *models.ServiceType) { s.TargetWaitMinutes = -5 },
+		"no slot interval":  func(s *models.ServiceType) { s.SlotIntervalMinutes = 0 },
+		"closes first":      func(s *models.ServiceType) { s.ClosingTime = "09:00" },
+		"misspelt day":      func(s *models.ServiceType) { s.OperatingDays = "Tuesday,Thurs" },
+	}
+	for name, change := range invalid {
+		serviceType := valid()
+		change(&serviceType)
+		if err := ss.Validate(&serviceType); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
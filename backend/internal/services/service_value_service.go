@@ -0,0 +1,210 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ServiceDeliveredStatuses are the help request statuses that count as a visit
+// delivered for impact reporting
+var ServiceDeliveredStatuses = []string{models.HelpRequestStatusCheckedIn, models.HelpRequestStatusCompleted}
+
+// ServiceValueService estimates the value of services delivered from configured unit costs
+type ServiceValueService struct {
+	db    *gorm.DB
+	costs map[string][]models.ServiceUnitCost // By category, newest first
+}
+
+// ServiceValueReport summarises the estimated value of services delivered in a period
+type ServiceValueReport struct {
+	StartDate     string                 `json:"start_date"`
+	EndDate       string                 `json:"end_date"`
+	Currency      string                 `json:"currency"`
+	TotalValue    float64                `json:"total_value"`
+	Deliveries    int64                  `json:"deliveries"`
+	PeopleReached int64                  `json:"people_reached"`
+	Unpriced      int64                  `json:"unpriced"` // Deliveries with no unit cost configured
+	ByCategory    []CategoryServiceValue `json:"by_category"`
+	Monthly       []MonthlyServiceValue  `json:"monthly"`
+}
+
+// CategoryServiceValue is the estimated value delivered for one service
+type CategoryServiceValue struct {
+	Category   string  `json:"category"`
+	UnitName   string  `json:"unit_name"`
+	Deliveries int64   `json:"deliveries"`
+	People     int64   `json:"people"`
+	Value      float64 `json:"value"`
+	Priced     bool    `json:"priced"`
+}
+
+// MonthlyServiceValue is the estimated value delivered in a month
+type MonthlyServiceValue struct {
+	Month      string  `json:"month"`
+	Deliveries int64   `json:"deliveries"`
+	Value      float64 `json:"value"`
+}
+
+// NewServiceValueService creates a new service value service
+func NewServiceValueService() *ServiceValueService {
+	return &ServiceValueService{
+		db: db.DB,
+	}
+}
+
+// loadCosts reads the unit cost table once per service
+func (vs *ServiceValueService) loadCosts() {
+	if vs.costs != nil {
+		return
+	}
+	var costs []models.ServiceUnitCost
+	vs.db.Order("effective_from DESC").Find(&costs)
+
+	vs.costs = make(map[string][]models.ServiceUnitCost)
+	for _, cost := range costs {
+		category := strings.ToLower(cost.Category)
+		vs.costs[category] = append(vs.costs[category], cost)
+	}
+}
+
+// CostOn returns the unit cost that applied to a category on a date
+func (vs *ServiceValueService) CostOn(category string, date time.Time) *models.ServiceUnitCost {
+	vs.loadCosts()
+	for _, cost := range vs.costs[strings.ToLower(category)] {
+		if !cost.EffectiveFrom.After(date) {
+			return &cost
+		}
+	}
+	return nil
+}
+
+// EstimateRequest returns the estimated value of a help request's visit. Requests that
+// were not delivered, or have no unit cost configured, are worth nothing.
+func (vs *ServiceValueService) EstimateRequest(request *models.HelpRequest) (float64, bool) {
+	delivered := false
+	for _, status := range ServiceDeliveredStatuses {
+		if request.Status == status {
+			delivered = true
+		}
+	}
+	if !delivered {
+		return 0, false
+	}
+
+	cost := vs.CostOn(request.Category, serviceValueDate(request.VisitDay, request.CreatedAt))
+	if cost == nil {
+		return 0, false
+	}
+	return roundPounds(cost.ValueFor(request.HouseholdSize)), true
+}
+
+// Report estimates the value of visits delivered between start and end, inclusive
+func (vs *ServiceValueService) Report(start, end time.Time) (*ServiceValueReport, error) {
+	var rows []serviceValueRow
+	err := vs.db.Model(&models.HelpRequest{}).
+		Select("category, household_size, visit_day, created_at").
+		Where("status IN ? AND COALESCE(NULLIF(visit_day, ''), TO_CHAR(created_at, 'YYYY-MM-DD')) BETWEEN ? AND ?",
+			ServiceDeliveredStatuses, start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return vs.buildReport(rows, start, end), nil
+}
+
+// serviceValueRow is a delivered visit as read for the value report
+type serviceValueRow struct {
+	Category      string
+	HouseholdSize int
+	VisitDay      string
+	CreatedAt     time.Time
+}
+
+// buildReport totals the estimated value of delivered visits by category and month
+func (vs *ServiceValueService) buildReport(rows []serviceValueRow, start, end time.Time) *ServiceValueReport {
+	report := &ServiceValueReport{
+		StartDate:  start.Format("2006-01-02"),
+		EndDate:    end.Format("2006-01-02"),
+		Currency:   "GBP",
+		ByCategory: []CategoryServiceValue{},
+		Monthly:    []MonthlyServiceValue{},
+	}
+	byCategory := map[string]*CategoryServiceValue{}
+	byMonth := map[string]*MonthlyServiceValue{}
+
+	for _, row := range rows {
+		people := int64(row.HouseholdSize)
+		if people < 1 {
+			people = 1
+		}
+		category := strings.ToLower(row.Category)
+		date := serviceValueDate(row.VisitDay, row.CreatedAt)
+
+		entry := byCategory[category]
+		if entry == nil {
+			entry = &CategoryServiceValue{Category: category}
+			byCategory[category] = entry
+		}
+		month := byMonth[date.Format("2006-01")]
+		if month == nil {
+			month = &MonthlyServiceValue{Month: date.Format("2006-01")}
+			byMonth[month.Month] = month
+		}
+
+		entry.Deliveries++
+		entry.People += people
+		month.Deliveries++
+		report.Deliveries++
+		report.PeopleReached += people
+
+		cost := vs.CostOn(category, date)
+		if cost == nil {
+			report.Unpriced++
+			continue
+		}
+		value := cost.ValueFor(int(people))
+		entry.Priced = true
+		entry.UnitName = cost.UnitName
+		entry.Value += value
+		month.Value += value
+		report.TotalValue += value
+	}
+
+	for _, entry := range byCategory {
+		entry.Value = roundPounds(entry.Value)
+		report.ByCategory = append(report.ByCategory, *entry)
+	}
+	sort.Slice(report.ByCategory, func(i, j int) bool {
+		return report.ByCategory[i].Value > report.ByCategory[j].Value
+	})
+	for _, month := range byMonth {
+		month.Value = roundPounds(month.Value)
+		report.Monthly = append(report.Monthly, *month)
+	}
+	sort.Slice(report.Monthly, func(i, j int) bool {
+		return report.Monthly[i].Month < report.Monthly[j].Month
+	})
+	report.TotalValue = roundPounds(report.TotalValue)
+
+	return report
+}
+
+// serviceValueDate is the day a visit was delivered, falling back to when it was requested
+func serviceValueDate(visitDay string, createdAt time.Time) time.Time {
+	if date, err := time.Parse("2006-01-02", visitDay); err == nil {
+		return date
+	}
+	return createdAt
+}
+
+// roundPounds rounds to whole pence
+func roundPounds(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+// testServiceValueService prices food and advice visits, with food going up in April
+func testServiceValueService() *ServiceValueService {
+	day := func(month time.Month, d int) time.Time { return time.Date(2026, month, d, 0, 0, 0, 0, time.UTC) }
+	return &ServiceValueService{costs: map[string][]models.ServiceUnitCost{
+		"food": {
+			{Category: "food", UnitName: "food parcel", UnitCost: 12, PerPersonCost: 3, EffectiveFrom: day(4, 1)},
+			{Category: "food", UnitName: "food parcel", UnitCost: 10, PerPersonCost: 2, EffectiveFrom: day(1, 1)},
+		},
+		"advice": {
+			{Category: "advice", UnitName: "advice session", UnitCost: 40, EffectiveFrom: day(1, 1)},
+		},
+	}}
+}
+
+func TestServiceValueCostOn(t *testing.T) {
+	vs := testServiceValueService()
+	tests := []struct {
+		category string
+		date     string
+		want     float64
+	}{
+		{"food", "2026-03-31", 10},
+		{"FOOD", "2026-04-01", 12}, // The new cost applies from its first day
+		{"advice", "2026-06-01", 40},
+	}
+	for _, tt := range tests {
+		date, _ := time.Parse("2006-01-02", tt.date)
+		cost := vs.CostOn(tt.category, date)
+		if cost == nil || cost.UnitCost != tt.want {
+			t.Errorf("CostOn(%s, %s) = %+v, want £%.2f", tt.category, tt.date, cost, tt.want)
+		}
+	}
+	if cost := vs.CostOn("food", time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)); cost != nil {
+		t.Errorf("cost before the first figure: %+v", cost)
+	}
+	if cost := vs.CostOn("clothing", time.Now()); cost != nil {
+		t.Errorf("unpriced category: %+v", cost)
+	}
+}
+
+func TestEstimateRequest(t *testing.T) {
+	vs := testServiceValueService()
+	request := &models.HelpRequest{Category: "Food", Status: models.HelpRequestStatusCompleted, VisitDay: "2026-04-02", HouseholdSize: 3}
+	if value, ok := vs.EstimateRequest(request); !ok || value != 18 {
+		t.Errorf("got £%.2f, %v; want £18.00", value, ok)
+	}
+
+	request.Status = models.HelpRequestStatusApproved
+	if value, ok := vs.EstimateRequest(request); ok || value != 0 {
+		t.Errorf("visit not delivered valued at £%.2f", value)
+	}
+}
+
+func TestBuildServiceValueReport(t *testing.T) {
+	vs := testServiceValueService()
+	rows := []serviceValueRow{
+		{Category: "food", HouseholdSize: 3, VisitDay: "2026-03-15"},
+		{Category: "food", HouseholdSize: 1, VisitDay: "2026-04-02"},
+		{Category: "Food", CreatedAt: time.Date(2026, 4, 20, 10, 0, 0, 0, time.UTC)}, // No visit day or household size
+		{Category: "advice", HouseholdSize: 2, VisitDay: "2026-03-01"},
+		{Category: "clothing", HouseholdSize: 4, VisitDay: "2026-04-05"},
+		{Category: "food", HouseholdSize: 1, VisitDay: "2025-12-01"}, // Before any food cost
+	}
+	start := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	report := vs.buildReport(rows, start, start.AddDate(0, 6, 0))
+
+	if report.TotalValue != 78 || report.Deliveries != 6 || report.PeopleReached != 12 || report.Unpriced != 2 {
+		t.Errorf("totals: £%.2f, %d deliveries, %d people, %d unpriced",
+			report.TotalValue, report.Deliveries, report.PeopleReached, report.Unpriced)
+	}
+
+	wantCategories := []CategoryServiceValue{
+		{Category: "advice", UnitName: "advice session", Deliveries: 1, People: 2, Value: 40, Priced: true},
+		{Category: "food", UnitName: "food parcel", Deliveries: 4, People: 6, Value: 38, Priced: true},
+		{Category: "clothing", Deliveries: 1, People: 4},
+	}
+	if !reflect.DeepEqual(report.ByCategory, wantCategories) {
+		t.Errorf("by category: got %+v", report.ByCategory)
+	}
+
+	wantMonths := []MonthlyServiceValue{
+		{Month: "2025-12", Deliveries: 1},
+		{Month: "2026-03", Deliveries: 2, Value: 54},
+		{Month: "2026-04", Deliveries: 3, Value: 24},
+	}
+	if !reflect.DeepEqual(report.Monthly, wantMonths) {
+		t.Errorf("monthly: got %+v", report.Monthly)
+	}
+
+	// An empty period still has lists to draw
+	empty := vs.buildReport(nil, start, start)
+	if empty.ByCategory == nil || empty.Monthly == nil || empty.Currency != "GBP" {
+		t.Errorf("empty report: %+v", empty)
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/websocket"
+
+	"gorm.io/gorm"
+)
+
+var ErrSessionUserNotFound = errors.New("user not found")
+
+// SessionService ends user sessions
+type SessionService struct {
+	db *gorm.DB
+}
+
+// NewSessionService creates a new session service
+func NewSessionService() *SessionService {
+	return &SessionService{db: db.DB}
+}
+
+// SessionsEnded reports what was cut off when a user's sessions were ended
+type SessionsEnded struct {
+	UserID               uint `json:"user_id"`
+	RefreshTokensRevoked int  `json:"refresh_tokens_revoked"`
+	ConnectionsClosed    int  `json:"connections_closed"`
+}
+
+// EndAllSessions invalidates every token issued to a user. Raising the user's token
+// version makes the access and refresh tokens they hold fail on their next request,
+// without waiting for them to expire. Open WebSocket connections are closed too.
+func (s *SessionService) EndAllSessions(userID uint, endedBy string) (*SessionsEnded, error) {
+	result := &SessionsEnded{UserID: userID}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Select("id").First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSessionUserNotFound
+			}
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).
+			Update("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+			return fmt.Errorf("failed to raise token version: %w", err)
+		}
+
+		now := time.Now()
+		revoked := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked = ?", userID, false).
+			Updates(map[string]interface{}{"revoked": true, "revoked_at": now, "revoked_by": endedBy})
+		if revoked.Error != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %w", revoked.Error)
+		}
+		result.RefreshTokensRevoked = int(revoked.RowsAffected)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.ConnectionsClosed = websocket.GetGlobalManager().DisconnectUser(userID)
+	return result, nil
+}
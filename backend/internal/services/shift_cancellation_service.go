@@ -0,0 +1,389 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/websocket"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Bulk shift cancellation errors
+var (
+	ErrBulkCancelRange    = errors.New("date range must end on or after its start and cover at most 92 days")
+	ErrBulkCancelNoShifts = errors.New("no upcoming shifts match the filters")
+)
+
+// maxBulkCancelDays limits how many days one bulk cancellation can cover
+const maxBulkCancelDays = 92
+
+// BulkShiftCancelFilter selects the shifts to cancel. Only shifts that have not
+// started yet are cancelled.
+type BulkShiftCancelFilter struct {
+	From     time.Time // First day, inclusive
+	To       time.Time // Last day, inclusive
+	Location string    // Optional, matched case-insensitively
+}
+
+// CancelledShift is one shift called off by a bulk cancellation
+type CancelledShift struct {
+	ShiftID   uint      `json:"shift_id"`
+	Date      time.Time `json:"date"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Location  string    `json:"location"`
+	Role      string    `json:"role"`
+	Released  int       `json:"released"` // Volunteer assignments released
+}
+
+// NotifiedVolunteer is a volunteer told their shifts were cancelled
+type NotifiedVolunteer struct {
+	UserID   uint   `json:"user_id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	ShiftIDs []uint `json:"shift_ids"`
+	Notified bool   `json:"notified"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ShiftCoverageDay is a day's volunteer coverage after shifts were cancelled
+type ShiftCoverageDay struct {
+	Date          string `json:"date"`
+	Shifts        int64  `json:"shifts"`
+	CoveredShifts int64  `json:"covered_shifts"` // Shifts with at least one confirmed volunteer
+	CoveragePct   int    `json:"coverage_pct"`
+	Cancelled     int    `json:"cancelled"` // Shifts cancelled on the day
+}
+
+// BulkShiftCancellation summarises a bulk cancellation, or what one would do when
+// run as a dry run
+type BulkShiftCancellation struct {
+	DryRun               bool                `json:"dry_run"`
+	Reason               string              `json:"reason"`
+	Shifts               []CancelledShift    `json:"shifts"`
+	AssignmentsReleased  int                 `json:"assignments_released"`
+	CalloutsCancelled    int                 `json:"callouts_cancelled"`
+	Volunteers           []NotifiedVolunteer `json:"volunteers"`
+	VolunteersNotified   int                 `json:"volunteers_notified"`
+	NotificationFailures int                 `json:"notification_failures"`
+	Coverage             []ShiftCoverageDay  `json:"coverage,omitempty"`
+}
+
+// ShiftCancellationService calls off shifts in bulk, for example when a venue has to
+// close, releasing the volunteers booked on them
+type ShiftCancellationService struct {
+	db *gorm.DB
+}
+
+// NewShiftCancellationService creates a new shift cancellation service
+func NewShiftCancellationService() *ShiftCancellationService {
+	return &ShiftCancellationService{db: db.DB}
+}
+
+// CancelShifts cancels every upcoming shift matching the filter. Shifts, their
+// assignments and open emergency call-outs are updated in one transaction; affected
+// volunteers are then sent one notice each with a link to re-book. A dry run reports
+// what would be cancelled without changing anything.
+func (scs *ShiftCancellationService) CancelShifts(filter BulkShiftCancelFilter, reason string, cancelledBy uint, dryRun bool) (*BulkShiftCancellation, error) {
+	from, to, err := bulkCancelRange(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkShiftCancellation{DryRun: dryRun, Reason: reason}
+	var shifts []models.Shift
+	var assignments []models.ShiftAssignment
+	now := time.Now()
+
+	err = scs.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("date >= ? AND date < ? AND start_time > ?", from, to, now)
+		if location := strings.TrimSpace(filter.Location); location != "" {
+			query = query.Where("LOWER(location) = LOWER(?)", location)
+		}
+		if err := query.Order("start_time ASC").Find(&shifts).Error; err != nil {
+			return err
+		}
+		if len(shifts) == 0 {
+			return ErrBulkCancelNoShifts
+		}
+
+		shiftIDs := make([]uint, len(shifts))
+		for i, shift := range shifts {
+			shiftIDs[i] = shift.ID
+		}
+		if err := tx.Where("shift_id IN ? AND status IN ?", shiftIDs, activeAssignmentStatuses).
+			Find(&assignments).Error; err != nil {
+			return err
+		}
+
+		var callouts []models.EmergencyCallout
+		if err := tx.Where("shift_id IN ? AND status = ?", shiftIDs, models.CalloutStatusOpen).
+			Find(&callouts).Error; err != nil {
+			return err
+		}
+		result.CalloutsCancelled = len(callouts)
+		result.AssignmentsReleased = len(assignments)
+		if dryRun {
+			return nil
+		}
+
+		for _, assignment := range assignments {
+			if err := tx.Model(&models.ShiftAssignment{}).Where("id = ?", assignment.ID).Updates(map[string]interface{}{
+				"status":              "Cancelled",
+				"cancelled_at":        now,
+				"cancellation_reason": "Shift cancelled: " + reason,
+				"hours_notice":        0,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, callout := range callouts {
+			if err := tx.Model(&models.EmergencyCallout{}).Where("id = ?", callout.ID).Updates(map[string]interface{}{
+				"status":    models.CalloutStatusCancelled,
+				"closed_at": now,
+			}).Error; err != nil {
+				return err
+			}
+			if err := closePendingResponses(tx, callout.ID); err != nil {
+				return err
+			}
+		}
+
+		// Cancelled shifts are soft deleted so they drop out of every listing, keeping
+		// the record of why they were called off
+		if err := tx.Model(&models.Shift{}).Where("id IN ?", shiftIDs).Updates(map[string]interface{}{
+			"cancelled_at":          now,
+			"cancelled_by":          cancelledBy,
+			"cancellation_reason":   reason,
+			"assigned_volunteer_id": nil,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Shift{}, shiftIDs).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Shifts = cancelledShifts(shifts, assignments)
+	shiftsByID := make(map[uint]models.Shift, len(shifts))
+	for _, shift := range shifts {
+		shiftsByID[shift.ID] = shift
+	}
+
+	result.Volunteers, err = scs.affectedVolunteers(assignments)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	for i := range result.Volunteers {
+		volunteer := &result.Volunteers[i]
+		if err := scs.notify(volunteer, shiftsByID, reason); err != nil {
+			log.Printf("Failed to notify volunteer %d about cancelled shifts: %v", volunteer.UserID, err)
+			volunteer.Error = err.Error()
+			result.NotificationFailures++
+			continue
+		}
+		volunteer.Notified = true
+		result.VolunteersNotified++
+	}
+
+	if _, err := GetCacheService().InvalidateTags(CacheTagShifts, CacheTagVolunteers, CacheTagDashboard); err != nil {
+		log.Printf("Failed to invalidate shift caches after bulk cancellation: %v", err)
+	}
+	result.Coverage = scs.coverage(from, to, result.Shifts)
+	scs.broadcast(result)
+	return result, nil
+}
+
+// bulkCancelRange returns the start of the first day and the end of the last day of a
+// bulk cancellation, checking the range is the right way round and not too long
+func bulkCancelRange(filter BulkShiftCancelFilter) (time.Time, time.Time, error) {
+	from := time.Date(filter.From.Year(), filter.From.Month(), filter.From.Day(), 0, 0, 0, 0, time.Local)
+	to := time.Date(filter.To.Year(), filter.To.Month(), filter.To.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+	if !to.After(from) || to.AddDate(0, 0, -maxBulkCancelDays).After(from) {
+		return from, to, ErrBulkCancelRange
+	}
+	return from, to, nil
+}
+
+// cancelledShifts summarises the cancelled shifts with the assignments each released
+func cancelledShifts(shifts []models.Shift, assignments []models.ShiftAssignment) []CancelledShift {
+	released := map[uint]int{}
+	for _, assignment := range assignments {
+		released[assignment.ShiftID]++
+	}
+	cancelled := make([]CancelledShift, 0, len(shifts))
+	for _, shift := range shifts {
+		cancelled = append(cancelled, CancelledShift{
+			ShiftID:   shift.ID,
+			Date:      shift.Date,
+			StartTime: shift.StartTime,
+			EndTime:   shift.EndTime,
+			Location:  shift.Location,
+			Role:      shift.Role,
+			Released:  released[shift.ID],
+		})
+	}
+	return cancelled
+}
+
+// affectedVolunteers groups released assignments by volunteer
+func (scs *ShiftCancellationService) affectedVolunteers(assignments []models.ShiftAssignment) ([]NotifiedVolunteer, error) {
+	shiftsByUser := map[uint][]uint{}
+	for _, assignment := range assignments {
+		shiftsByUser[assignment.UserID] = append(shiftsByUser[assignment.UserID], assignment.ShiftID)
+	}
+	if len(shiftsByUser) == 0 {
+		return []NotifiedVolunteer{}, nil
+	}
+
+	userIDs := make([]uint, 0, len(shiftsByUser))
+	for userID := range shiftsByUser {
+		userIDs = append(userIDs, userID)
+	}
+	var users []models.User
+	if err := scs.db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	volunteers := make([]NotifiedVolunteer, 0, len(users))
+	for _, user := range users {
+		volunteers = append(volunteers, NotifiedVolunteer{
+			UserID:   user.ID,
+			Name:     strings.TrimSpace(user.FirstName + " " + user.LastName),
+			Email:    user.Email,
+			ShiftIDs: shiftsByUser[user.ID],
+		})
+	}
+	sort.Slice(volunteers, func(i, j int) bool { return volunteers[i].Name < volunteers[j].Name })
+	return volunteers, nil
+}
+
+// notify sends a volunteer one notice listing their cancelled shifts, with a link to
+// book another shift
+func (scs *ShiftCancellationService) notify(volunteer *NotifiedVolunteer, shiftsByID map[uint]models.Shift, reason string) error {
+	baseURL := os.Getenv("FRONTEND_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+	title, message := shiftCancellationNotice(volunteer.ShiftIDs, shiftsByID, reason, baseURL)
+
+	return GetGlobalRealtimeNotificationService().SendNotification(RealtimeNotificationData{
+		UserID:    volunteer.UserID,
+		Type:      "shift_cancelled",
+		Title:     title,
+		Message:   message,
+		Priority:  models.PriorityHigh,
+		Category:  "volunteer",
+		ActionURL: shiftRebookPath,
+		Channels:  []string{"websocket", "email"},
+		Data: map[string]interface{}{
+			"shift_ids": volunteer.ShiftIDs,
+			"reason":    reason,
+		},
+	})
+}
+
+// shiftRebookPath is where volunteers book another shift
+const shiftRebookPath = "/volunteer/shifts/available"
+
+// shiftCancellationNotice writes the title and message telling a volunteer which of
+// their shifts were cancelled
+func shiftCancellationNotice(shiftIDs []uint, shiftsByID map[uint]models.Shift, reason, baseURL string) (string, string) {
+	lines := make([]string, 0, len(shiftIDs))
+	for _, shiftID := range shiftIDs {
+		shift := shiftsByID[shiftID]
+		lines = append(lines, fmt.Sprintf("%s %s-%s at %s (%s)", shift.Date.Format("Mon 2 Jan"),
+			shift.StartTime.Format("15:04"), shift.EndTime.Format("15:04"), shift.Location, shift.Role))
+	}
+
+	title := "Your shift has been cancelled"
+	if len(lines) > 1 {
+		title = fmt.Sprintf("%d of your shifts have been cancelled", len(lines))
+	}
+	message := fmt.Sprintf("We're sorry, the following shifts have been cancelled: %s.", strings.Join(lines, "; "))
+	if reason != "" {
+		message += " Reason: " + reason + "."
+	}
+	message += fmt.Sprintf(" You can book another shift at %s%s", baseURL, shiftRebookPath)
+	return title, message
+}
+
+// coverage recalculates volunteer coverage for each day in the range that lost shifts
+func (scs *ShiftCancellationService) coverage(from, to time.Time, cancelled []CancelledShift) []ShiftCoverageDay {
+	var rows []struct {
+		Day     time.Time
+		Shifts  int64
+		Covered int64
+	}
+	err := scs.db.Table("shifts").
+		Select(`DATE(shifts.date) AS day, COUNT(*) AS shifts,
+			COUNT(*) FILTER (WHERE EXISTS (
+				SELECT 1 FROM shift_assignments
+				WHERE shift_assignments.shift_id = shifts.id AND shift_assignments.status IN ?)) AS covered`, activeAssignmentStatuses).
+		Where("shifts.deleted_at IS NULL AND shifts.date >= ? AND shifts.date < ?", from, to).
+		Group("DATE(shifts.date)").
+		Scan(&rows).Error
+	if err != nil {
+		log.Printf("Failed to recalculate shift coverage: %v", err)
+		return nil
+	}
+	remaining := make(map[string]ShiftCoverageDay, len(rows))
+	for _, row := range rows {
+		day := ShiftCoverageDay{Date: row.Day.Format("2006-01-02"), Shifts: row.Shifts, CoveredShifts: row.Covered}
+		if row.Shifts > 0 {
+			day.CoveragePct = int(row.Covered * 100 / row.Shifts)
+		}
+		remaining[day.Date] = day
+	}
+	return cancellationCoverage(cancelled, remaining)
+}
+
+// cancellationCoverage returns the remaining coverage of each day that lost shifts
+func cancellationCoverage(cancelled []CancelledShift, remaining map[string]ShiftCoverageDay) []ShiftCoverageDay {
+	cancelledByDay := map[string]int{}
+	for _, shift := range cancelled {
+		cancelledByDay[shift.Date.In(time.Local).Format("2006-01-02")]++
+	}
+
+	days := make([]ShiftCoverageDay, 0, len(cancelledByDay))
+	for date, count := range cancelledByDay {
+		day, ok := remaining[date]
+		if !ok {
+			day = ShiftCoverageDay{Date: date}
+		}
+		day.Cancelled = count
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+	return days
+}
+
+// broadcast tells admin dashboards that shifts were cancelled so coverage refreshes
+func (scs *ShiftCancellationService) broadcast(result *BulkShiftCancellation) {
+	payload := map[string]interface{}{
+		"type":                 "shifts_cancelled",
+		"shifts":               len(result.Shifts),
+		"assignments_released": result.AssignmentsReleased,
+		"coverage":             result.Coverage,
+		"timestamp":            time.Now(),
+	}
+	if err := websocket.GetGlobalManager().BroadcastToRole(models.RoleAdmin, payload); err != nil {
+		log.Printf("Failed to broadcast shift cancellation: %v", err)
+	}
+}
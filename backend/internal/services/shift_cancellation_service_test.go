@@ -0,0 +1,78 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestBulkCancelRange(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2026, month, d, 15, 30, 0, 0, time.Local) }
+	tests := []struct {
+		name     string
+		from, to time.Time
+		ok       bool
+	}{
+		{"one day", day(6, 1), day(6, 1), true},
+		{"92 days", day(8, 1), day(10, 31), true}, // Crosses the October clock change
+		{"93 days", day(8, 1), day(11, 1), false},
+		{"backwards", day(6, 2), day(6, 1), false},
+	}
+	for _, tt := range tests {
+		from, to, err := bulkCancelRange(BulkShiftCancelFilter{From: tt.from, To: tt.to})
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got %v", tt.name, err)
+			continue
+		}
+		if tt.ok && (from.Hour() != 0 || !to.Equal(time.Date(tt.to.Year(), tt.to.Month(), tt.to.Day()+1, 0, 0, 0, 0, time.Local))) {
+			t.Errorf("%s: got %v to %v", tt.name, from, to)
+		}
+	}
+}
+
+func TestCancelledShifts(t *testing.T) {
+	shifts := []models.Shift{{ID: 1, Location: "Hall"}, {ID: 2, Location: "Hall"}}
+	assignments := []models.ShiftAssignment{{ShiftID: 1, UserID: 7}, {ShiftID: 1, UserID: 8}}
+	got := cancelledShifts(shifts, assignments)
+	if len(got) != 2 || got[0].Released != 2 || got[1].Released != 0 || got[0].Location != "Hall" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestShiftCancellationNotice(t *testing.T) {
+	date := time.Date(2026, 6, 3, 0, 0, 0, 0, time.UTC)
+	shiftsByID := map[uint]models.Shift{
+		1: {Date: date, StartTime: date.Add(9 * time.Hour), EndTime: date.Add(13 * time.Hour), Location: "Hall", Role: "Packing"},
+		2: {Date: date.AddDate(0, 0, 1), StartTime: date.Add(34 * time.Hour), EndTime: date.Add(37 * time.Hour), Location: "Hall", Role: "Reception"},
+	}
+
+	title, message := shiftCancellationNotice([]uint{1}, shiftsByID, "", "https://app.example.org")
+	if title != "Your shift has been cancelled" ||
+		message != "We're sorry, the following shifts have been cancelled: Wed 3 Jun 09:00-13:00 at Hall (Packing). You can book another shift at https://app.example.org/volunteer/shifts/available" {
+		t.Errorf("one shift: got %q, %q", title, message)
+	}
+
+	title, message = shiftCancellationNotice([]uint{1, 2}, shiftsByID, "Flooding", "https://app.example.org")
+	if title != "2 of your shifts have been cancelled" ||
+		message != "We're sorry, the following shifts have been cancelled: Wed 3 Jun 09:00-13:00 at Hall (Packing); Thu 4 Jun 10:00-13:00 at Hall (Reception). Reason: Flooding. You can book another shift at https://app.example.org/volunteer/shifts/available" {
+		t.Errorf("two shifts: got %q, %q", title, message)
+	}
+}
+
+func TestCancellationCoverage(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 6, d, 9, 0, 0, 0, time.Local) }
+	cancelled := []CancelledShift{{Date: day(4)}, {Date: day(3)}, {Date: day(3)}}
+	remaining := map[string]ShiftCoverageDay{
+		"2026-06-03": {Date: "2026-06-03", Shifts: 4, CoveredShifts: 3, CoveragePct: 75},
+		"2026-06-05": {Date: "2026-06-05", Shifts: 2}, // No shifts cancelled
+	}
+	want := []ShiftCoverageDay{
+		{Date: "2026-06-03", Shifts: 4, CoveredShifts: 3, CoveragePct: 75, Cancelled: 2},
+		{Date: "2026-06-04", Cancelled: 1}, // Every shift on the day was cancelled
+	}
+	if got := cancellationCoverage(cancelled, remaining); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v", got)
+	}
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Shift clash kinds
+const (
+	ShiftClashOverlap = "overlap" // The shifts run at the same time
+	ShiftClashTravel  = "travel"  // Not enough time to get between the two locations
+)
+
+const defaultShiftTravelBuffer = 30 // Minutes between shifts at different locations
+
+// ShiftClash is an existing commitment that a shift does not fit around
+type ShiftClash struct {
+	Shift           models.Shift `json:"shift"`
+	Kind            string       `json:"kind"`
+	Start           string       `json:"start"` // HH:MM the volunteer is committed from
+	End             string       `json:"end"`
+	GapMinutes      int          `json:"gap_minutes"`      // Time between the two shifts
+	RequiredMinutes int          `json:"required_minutes"` // Travel time needed between them
+}
+
+// ShiftClashService checks a volunteer's shifts on the same day for overlaps and for
+// back-to-back shifts at different locations without time to travel between them
+type ShiftClashService struct {
+	db            *gorm.DB
+	defaultBuffer int
+	travel        map[[2]string]int // Configured minutes by location pair
+}
+
+// shiftCommitment is a shift the volunteer is already doing, with the times they are
+// actually committed for
+type shiftCommitment struct {
+	shift      models.Shift
+	start, end int // Minutes since midnight
+}
+
+// NewShiftClashService creates a new shift clash service. The travel buffer used for
+// locations without a configured travel time can be set with SHIFT_TRAVEL_BUFFER_MINUTES.
+func NewShiftClashService() *ShiftClashService {
+	ss := &ShiftClashService{
+		db:            db.DB,
+		defaultBuffer: defaultShiftTravelBuffer,
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("SHIFT_TRAVEL_BUFFER_MINUTES")); err == nil && minutes >= 0 {
+		ss.defaultBuffer = minutes
+	}
+	return ss
+}
+
+// DefaultBuffer returns the travel time assumed between locations with no configured time
+func (ss *ShiftClashService) DefaultBuffer() int {
+	return ss.defaultBuffer
+}
+
+// TravelMinutes returns the time needed to get from one location to another. Shifts at
+// the same location, or with no location set, need no travel time.
+func (ss *ShiftClashService) TravelMinutes(from, to string) int {
+	a, b := models.LocationPair(from, to)
+	if a == "" || b == "" || a == b {
+		return 0
+	}
+
+	ss.loadTravelTimes()
+	if minutes, ok := ss.travel[[2]string{a, b}]; ok {
+		return minutes
+	}
+	return ss.defaultBuffer
+}
+
+// loadTravelTimes reads the travel time table once per service
+func (ss *ShiftClashService) loadTravelTimes() {
+	if ss.travel != nil {
+		return
+	}
+	var times []models.LocationTravelTime
+	ss.db.Find(&times)
+
+	ss.travel = make(map[[2]string]int, len(times))
+	for _, travel := range times {
+		ss.travel[[2]string{travel.FromLocation, travel.ToLocation}] = travel.Minutes
+	}
+}
+
+// Check returns the volunteer's shifts that clash with working shift from start to end
+// on the shift's date. The shift itself is ignored so a volunteer can change their times.
+func (ss *ShiftClashService) Check(volunteerID uint, shift models.Shift, start, end time.Time) ([]ShiftClash, error) {
+	commitments, err := ss.commitments(volunteerID, shift.Date)
+	if err != nil {
+		return nil, err
+	}
+	return ss.clashes(commitments, shift, minuteOfDay(start), minuteOfDay(end)), nil
+}
+
+// clashes compares a shift against the volunteer's commitments
+func (ss *ShiftClashService) clashes(commitments []shiftCommitment, shift models.Shift, start, end int) []ShiftClash {
+	clashes := []ShiftClash{}
+	for _, commitment := range commitments {
+		if commitment.shift.ID == shift.ID {
+			continue
+		}
+
+		clash := ShiftClash{
+			Shift: commitment.shift,
+			Start: formatMinuteOfDay(commitment.start),
+			End:   formatMinuteOfDay(commitment.end),
+		}
+		if start < commitment.end && commitment.start < end {
+			clash.Kind = ShiftClashOverlap
+			clashes = append(clashes, clash)
+			continue
+		}
+
+		clash.GapMinutes = start - commitment.end
+		if commitment.start >= end {
+			clash.GapMinutes = commitment.start - end
+		}
+		clash.RequiredMinutes = ss.TravelMinutes(commitment.shift.Location, shift.Location)
+		if clash.GapMinutes < clash.RequiredMinutes {
+			clash.Kind = ShiftClashTravel
+			clashes = append(clashes, clash)
+		}
+	}
+	return clashes
+}
+
+// Alternatives suggests open shifts for the same role in the week from the shift's date
+// that fit around the volunteer's other commitments and away days, same day first
+func (ss *ShiftClashService) Alternatives(volunteerID uint, shift models.Shift, limit int) ([]models.Shift, error) {
+	from := shift.Date
+	if today := time.Now().Truncate(24 * time.Hour); from.Before(today) {
+		from = today
+	}
+
+	var candidates []models.Shift
+	err := ss.db.Where("id <> ? AND role = ? AND assigned_volunteer_id IS NULL AND date::date BETWEEN ?::date AND ?::date",
+		shift.ID, shift.Role, from, from.AddDate(0, 0, 7)).
+		Order("date ASC, start_time ASC").
+		Limit(50).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	awayDays, err := NewVolunteerAwayService().AwayDays(volunteerID, from, from.AddDate(0, 0, 7))
+	if err != nil {
+		return nil, err
+	}
+
+	byDay := map[string][]shiftCommitment{}
+	alternatives := []models.Shift{}
+	for _, candidate := range candidates {
+		day := candidate.Date.Format("2006-01-02")
+		if awayDays[day] {
+			continue
+		}
+		commitments, ok := byDay[day]
+		if !ok {
+			if commitments, err = ss.commitments(volunteerID, candidate.Date); err != nil {
+				return nil, err
+			}
+			byDay[day] = commitments
+		}
+		if len(ss.clashes(commitments, candidate, minuteOfDay(candidate.StartTime), minuteOfDay(candidate.EndTime))) > 0 {
+			continue
+		}
+		alternatives = append(alternatives, candidate)
+	}
+
+	sameDay := shift.Date.Format("2006-01-02")
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		return alternatives[i].Date.Format("2006-01-02") == sameDay && alternatives[j].Date.Format("2006-01-02") != sameDay
+	})
+	if len(alternatives) > limit {
+		alternatives = alternatives[:limit]
+	}
+	return alternatives, nil
+}
+
+// commitments loads the shifts a volunteer is working on a date, from both fixed
+// assignments and confirmed assignment records with their custom times
+func (ss *ShiftClashService) commitments(volunteerID uint, date time.Time) ([]shiftCommitment, error) {
+	var assigned []models.Shift
+	if err := ss.db.Where("assigned_volunteer_id = ? AND date::date = ?::date", volunteerID, date).
+		Find(&assigned).Error; err != nil {
+		return nil, err
+	}
+
+	var assignments []models.ShiftAssignment
+	if err := ss.db.Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id").
+		Where("shift_assignments.user_id = ? AND shifts.date::date = ?::date AND shift_assignments.status IN (?, ?)",
+			volunteerID, date, "Confirmed", "Assigned").
+		Preload("Shift").
+		Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+
+	seen := map[uint]bool{}
+	commitments := []shiftCommitment{}
+	for _, assignment := range assignments {
+		commitment := shiftCommitment{
+			shift: assignment.Shift,
+			start: minuteOfDay(assignment.Shift.StartTime),
+			end:   minuteOfDay(assignment.Shift.EndTime),
+		}
+		if assignment.CustomStartTime != nil && assignment.CustomEndTime != nil {
+			commitment.start = minuteOfDay(*assignment.CustomStartTime)
+			commitment.end = minuteOfDay(*assignment.CustomEndTime)
+		}
+		seen[assignment.ShiftID] = true
+		commitments = append(commitments, commitment)
+	}
+	for _, shift := range assigned {
+		if seen[shift.ID] {
+			continue
+		}
+		commitments = append(commitments, shiftCommitment{
+			shift: shift,
+			start: minuteOfDay(shift.StartTime),
+			end:   minuteOfDay(shift.EndTime),
+		})
+	}
+	return commitments, nil
+}
+
+// minuteOfDay returns the minutes since midnight, ignoring the date part
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+// formatMinuteOfDay formats minutes since midnight as HH:MM
+func formatMinuteOfDay(minutes int) string {
+	return time.Date(0, 1, 1, minutes/60, minutes%60, 0, 0, time.UTC).Format("15:04")
+}
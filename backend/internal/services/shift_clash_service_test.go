@@ -0,0 +1,84 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestShiftTravelMinutes(t *testing.T) {
+	ss := &ShiftClashService{defaultBuffer: 30, travel: map[[2]string]int{
+		{"deptford hub", "lewisham food bank"}: 15,
+	}}
+	tests := []struct {
+		from, to string
+		want     int
+	}{
+		{"Lewisham  Food Bank", "Deptford Hub", 15}, // Stored once, in either direction
+		{"Deptford Hub", "deptford hub", 0},
+		{"", "Deptford Hub", 0},
+		{"Deptford Hub", "Catford Library", 30},
+	}
+	for _, tt := range tests {
+		if got := ss.TravelMinutes(tt.from, tt.to); got != tt.want {
+			t.Errorf("TravelMinutes(%q, %q) = %d, want %d", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestShiftClashes(t *testing.T) {
+	ss := &ShiftClashService{defaultBuffer: 30, travel: map[[2]string]int{
+		{"deptford hub", "lewisham food bank"}: 15,
+	}}
+	at := func(hour, minute int) int { return hour*60 + minute }
+	morning := shiftCommitment{shift: models.Shift{ID: 1, Location: "Lewisham Food Bank"}, start: at(9, 0), end: at(12, 0)}
+	evening := shiftCommitment{shift: models.Shift{ID: 2, Location: "Catford Library"}, start: at(17, 0), end: at(19, 0)}
+	commitments := []shiftCommitment{morning, evening}
+
+	tests := []struct {
+		name       string
+		shift      models.Shift
+		start, end int
+		want       map[uint]string // Clashing shift to kind
+	}{
+		{"free afternoon", models.Shift{ID: 9, Location: "Deptford Hub"}, at(12, 15), at(16, 30), map[uint]string{}},
+		{"overlapping", models.Shift{ID: 9, Location: "Lewisham Food Bank"}, at(11, 30), at(14, 0), map[uint]string{1: ShiftClashOverlap}},
+		{"back to back at the same site", models.Shift{ID: 9, Location: "lewisham food bank"}, at(12, 0), at(17, 0), map[uint]string{2: ShiftClashTravel}},
+		{"too soon after", models.Shift{ID: 9, Location: "Deptford Hub"}, at(12, 10), at(16, 45), map[uint]string{1: ShiftClashTravel, 2: ShiftClashTravel}},
+		{"changing own times", models.Shift{ID: 1, Location: "Lewisham Food Bank"}, at(8, 0), at(13, 0), map[uint]string{}},
+	}
+	for _, tt := range tests {
+		clashes := ss.clashes(commitments, tt.shift, tt.start, tt.end)
+		got := map[uint]string{}
+		for _, clash := range clashes {
+			got[clash.Shift.ID] = clash.Kind
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for id, kind := range tt.want {
+			if got[id] != kind {
+				t.Errorf("%s: shift %d got %q, want %q", tt.name, id, got[id], kind)
+			}
+		}
+	}
+
+	// Travel clashes report the gap and the time needed
+	clashes := ss.clashes(commitments, models.Shift{ID: 9, Location: "Deptford Hub"}, at(12, 10), at(13, 0))
+	if len(clashes) != 1 || clashes[0].GapMinutes != 10 || clashes[0].RequiredMinutes != 15 ||
+		clashes[0].Start != "09:00" || clashes[0].End != "12:00" {
+		t.Errorf("got %+v", clashes)
+	}
+}
+
+func TestMinuteOfDay(t *testing.T) {
+	start := time.Date(2026, 5, 1, 13, 45, 0, 0, time.UTC)
+	if got := minuteOfDay(start); got != 825 {
+		t.Errorf("minuteOfDay = %d", got)
+	}
+	if got := formatMinuteOfDay(825); got != "13:45" {
+		t.Errorf("formatMinuteOfDay = %q", got)
+	}
+}
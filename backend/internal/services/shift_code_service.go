@@ -0,0 +1,270 @@
+package services
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultShiftCodePeriodSeconds = 60
+	defaultShiftCodeEarlyMinutes  = 30
+	maxShiftCodeFailures          = 5
+)
+
+// shiftCodeKeyLabel is the HKDF context the code key is derived under
+const shiftCodeKeyLabel = "shift-code"
+
+// ShiftCheckInMethodCode marks an assignment checked in with the coordinator's code
+const ShiftCheckInMethodCode = "shift_code"
+
+var (
+	ErrShiftCodeKey           = errors.New("SHIFT_CODE_SECRET or JWT_SECRET is required for shift codes")
+	ErrShiftCodeShiftNotFound = errors.New("shift not found")
+	ErrShiftCodeCancelled     = errors.New("shift has been cancelled")
+	ErrShiftCodeNotAssigned   = errors.New("you are not signed up for this shift")
+	ErrShiftCodeCheckedIn     = errors.New("you have already checked in to this shift")
+	ErrShiftCodeTooEarly      = errors.New("check-in has not opened for this shift yet")
+	ErrShiftCodeEnded         = errors.New("this shift has ended")
+	ErrShiftCodeInvalid       = errors.New("that code is wrong or has expired; enter the code on the coordinator's screen")
+	ErrShiftCodeLocked        = errors.New("too many wrong codes; ask the coordinator to check you in")
+)
+
+// ShiftCode is the code shown on the coordinator's screen for a shift
+type ShiftCode struct {
+	ShiftID       uint      `json:"shift_id"`
+	Code          string    `json:"code"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	PeriodSeconds int       `json:"period_seconds"`
+	OpensAt       time.Time `json:"opens_at"` // Earliest a volunteer can check in
+	ClosesAt      time.Time `json:"closes_at"`
+	Assigned      int64     `json:"assigned"`
+	CheckedIn     int64     `json:"checked_in"`
+}
+
+// ShiftCodeService issues the rotating check-in codes for venues without QR scanners.
+// Codes are derived from a server key, the shift and the time, so they change every
+// period and only someone looking at the coordinator's screen knows the current one.
+type ShiftCodeService struct {
+	db     *gorm.DB
+	period time.Duration
+	early  time.Duration
+}
+
+// NewShiftCodeService creates a new shift code service
+func NewShiftCodeService() *ShiftCodeService {
+	period := defaultShiftCodePeriodSeconds
+	if v, err := strconv.Atoi(os.Getenv("SHIFT_CODE_PERIOD_SECONDS")); err == nil && v >= 15 {
+		period = v
+	}
+	early := defaultShiftCodeEarlyMinutes
+	if v, err := strconv.Atoi(os.Getenv("SHIFT_CODE_EARLY_MINUTES")); err == nil && v >= 0 {
+		early = v
+	}
+	return &ShiftCodeService{
+		db:     db.DB,
+		period: time.Duration(period) * time.Second,
+		early:  time.Duration(early) * time.Minute,
+	}
+}
+
+// shiftCodeKey returns the key codes are derived from. It is derived with HKDF from
+// SHIFT_CODE_SECRET, or JWT_SECRET when that is unset, so the secret that signs
+// session tokens is never used directly as the code key.
+func shiftCodeKey() ([]byte, error) {
+	secret := os.Getenv("SHIFT_CODE_SECRET")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	if secret == "" {
+		return nil, ErrShiftCodeKey
+	}
+	return hkdf.Key(sha256.New, []byte(secret), nil, shiftCodeKeyLabel, sha256.Size)
+}
+
+// shiftCodeAt derives the six digit code for a shift in one period, truncating an
+// HMAC the way TOTP does
+func shiftCodeAt(key []byte, shiftID uint, step int64) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "shift-code:%d:%d", shiftID, step)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+// matchesShiftCode accepts the current code and the one before it, so a code typed
+// just as the screen changes still works
+func matchesShiftCode(key []byte, shiftID uint, code string, now time.Time, period time.Duration) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != 6 {
+		return false
+	}
+	step := now.Unix() / int64(period/time.Second)
+	for _, s := range []int64{step, step - 1} {
+		if hmac.Equal([]byte(shiftCodeAt(key, shiftID, s)), []byte(code)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkInWindow is when volunteers can check in to a shift
+func (scs *ShiftCodeService) checkInWindow(shift *models.Shift) (time.Time, time.Time) {
+	return shift.StartTime.Add(-scs.early), shift.EndTime
+}
+
+// loadShift fetches a shift that is still going ahead
+func (scs *ShiftCodeService) loadShift(shiftID uint) (*models.Shift, error) {
+	var shift models.Shift
+	if err := scs.db.First(&shift, shiftID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShiftCodeShiftNotFound
+		}
+		return nil, err
+	}
+	if shift.CancelledAt != nil {
+		return nil, ErrShiftCodeCancelled
+	}
+	return &shift, nil
+}
+
+// Current returns the code to show on the coordinator's screen now, with how many of
+// the shift's volunteers have checked in
+func (scs *ShiftCodeService) Current(shiftID uint, now time.Time) (*ShiftCode, error) {
+	key, err := shiftCodeKey()
+	if err != nil {
+		return nil, err
+	}
+	shift, err := scs.loadShift(shiftID)
+	if err != nil {
+		return nil, err
+	}
+
+	seconds := int64(scs.period / time.Second)
+	step := now.Unix() / seconds
+	opens, closes := scs.checkInWindow(shift)
+	code := &ShiftCode{
+		ShiftID:       shift.ID,
+		Code:          shiftCodeAt(key, shift.ID, step),
+		ExpiresAt:     time.Unix((step+1)*seconds, 0),
+		PeriodSeconds: int(seconds),
+		OpensAt:       opens,
+		ClosesAt:      closes,
+	}
+
+	scs.db.Model(&models.ShiftAssignment{}).
+		Where("shift_id = ? AND status NOT IN ?", shift.ID, finishedAssignmentExclusions).
+		Count(&code.Assigned)
+	scs.db.Model(&models.ShiftAssignment{}).
+		Where("shift_id = ? AND status NOT IN ? AND checked_in_at IS NOT NULL", shift.ID, finishedAssignmentExclusions).
+		Count(&code.CheckedIn)
+	return code, nil
+}
+
+// CheckIn checks a volunteer in to a shift they are signed up for with the code on
+// the coordinator's screen. Wrong codes are counted and lock the volunteer out of
+// code check-in for the shift after a few attempts.
+func (scs *ShiftCodeService) CheckIn(userID, shiftID uint, code string, now time.Time) (*models.ShiftAssignment, error) {
+	key, err := shiftCodeKey()
+	if err != nil {
+		return nil, err
+	}
+	shift, err := scs.loadShift(shiftID)
+	if err != nil {
+		return nil, err
+	}
+
+	var assignment models.ShiftAssignment
+	err = scs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("shift_id = ? AND user_id = ? AND status NOT IN ?", shift.ID, userID, finishedAssignmentExclusions).
+			First(&assignment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShiftCodeNotAssigned
+			}
+			return err
+		}
+		if assignment.CheckedInAt != nil {
+			return ErrShiftCodeCheckedIn
+		}
+		if assignment.CheckInFailures >= maxShiftCodeFailures {
+			return ErrShiftCodeLocked
+		}
+
+		opens, closes := scs.checkInWindow(shift)
+		if assignment.CustomStartTime != nil && assignment.CustomEndTime != nil {
+			opens, closes = assignment.CustomStartTime.Add(-scs.early), *assignment.CustomEndTime
+		}
+		if now.Before(opens) {
+			return ErrShiftCodeTooEarly
+		}
+		if !now.Before(closes) {
+			return ErrShiftCodeEnded
+		}
+
+		if !matchesShiftCode(key, shift.ID, code, now, scs.period) {
+			return tx.Model(&assignment).Update("check_in_failures", gorm.Expr("check_in_failures + 1")).Error
+		}
+		assignment.CheckedInAt = &now
+		assignment.CheckInMethod = ShiftCheckInMethodCode
+		return tx.Model(&assignment).Updates(map[string]interface{}{
+			"checked_in_at":   now,
+			"check_in_method": ShiftCheckInMethodCode,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The failure count is saved, so report the wrong code outside the transaction
+	if assignment.CheckedInAt == nil {
+		return nil, ErrShiftCodeInvalid
+	}
+	return &assignment, nil
+}
+
+// ManualCheckIn lets a coordinator check in a volunteer who cannot use a code, such
+// as one locked out after wrong codes
+func (scs *ShiftCodeService) ManualCheckIn(shiftID, userID uint, now time.Time) (*models.ShiftAssignment, error) {
+	shift, err := scs.loadShift(shiftID)
+	if err != nil {
+		return nil, err
+	}
+
+	var assignment models.ShiftAssignment
+	if err := scs.db.Where("shift_id = ? AND user_id = ? AND status NOT IN ?", shift.ID, userID, finishedAssignmentExclusions).
+		First(&assignment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShiftCodeNotAssigned
+		}
+		return nil, err
+	}
+	if assignment.CheckedInAt != nil {
+		return nil, ErrShiftCodeCheckedIn
+	}
+
+	assignment.CheckedInAt = &now
+	assignment.CheckInMethod = "manual"
+	assignment.CheckInFailures = 0
+	if err := scs.db.Model(&assignment).Updates(map[string]interface{}{
+		"checked_in_at":     now,
+		"check_in_method":   "manual",
+		"check_in_failures": 0,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
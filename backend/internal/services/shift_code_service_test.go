@@ -0,0 +1,76 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestShiftCodeKey(t *testing.T) {
+	t.Setenv("SHIFT_CODE_SECRET", "")
+	t.Setenv("JWT_SECRET", "")
+	if _, err := shiftCodeKey(); err != ErrShiftCodeKey {
+		t.Errorf("no secret: got %v", err)
+	}
+
+	// Falling back to JWT_SECRET still derives a separate key
+	t.Setenv("JWT_SECRET", "jwt-secret-of-at-least-32-characters")
+	fallback, err := shiftCodeKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fallback) != 32 || bytes.Contains(fallback, []byte("jwt-secret")) {
+		t.Errorf("key %x is not derived from the secret", fallback)
+	}
+	if again, _ := shiftCodeKey(); !bytes.Equal(again, fallback) {
+		t.Error("key changes between calls")
+	}
+
+	t.Setenv("SHIFT_CODE_SECRET", "shift-secret")
+	if key, _ := shiftCodeKey(); bytes.Equal(key, fallback) {
+		t.Error("SHIFT_CODE_SECRET not preferred")
+	}
+}
+
+func TestShiftCodeAt(t *testing.T) {
+	key := []byte("test-key")
+	code := shiftCodeAt(key, 12, 1000)
+	if len(code) != 6 {
+		t.Fatalf("code %q is not six digits", code)
+	}
+	if again := shiftCodeAt(key, 12, 1000); again != code {
+		t.Errorf("codes differ for the same period: %s, %s", code, again)
+	}
+	if shiftCodeAt(key, 13, 1000) == code && shiftCodeAt(key, 12, 1001) == code {
+		t.Errorf("code %s does not change with the shift or period", code)
+	}
+	if shiftCodeAt([]byte("other-key"), 12, 1000) == code && shiftCodeAt([]byte("third-key"), 12, 1000) == code {
+		t.Errorf("code %s does not depend on the key", code)
+	}
+}
+
+func TestMatchesShiftCode(t *testing.T) {
+	key := []byte("test-key")
+	period := time.Minute
+	now := time.Unix(1_800_000_030, 0)
+	step := now.Unix() / 60
+
+	cases := []struct {
+		name string
+		code string
+		want bool
+	}{
+		{"current code", shiftCodeAt(key, 5, step), true},
+		{"previous code", shiftCodeAt(key, 5, step-1), true},
+		{"padded with spaces", " " + shiftCodeAt(key, 5, step) + " ", true},
+		{"two periods old", shiftCodeAt(key, 5, step-2), false},
+		{"next code", shiftCodeAt(key, 5, step+1), false},
+		{"another shift's code", shiftCodeAt(key, 6, step), false},
+		{"too short", "12345", false},
+	}
+	for _, tc := range cases {
+		if got := matchesShiftCode(key, 5, tc.code, now, period); got != tc.want {
+			t.Errorf("%s: matchesShiftCode = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultShiftFeedbackWindowDays is how long after a shift feedback can be given
+	defaultShiftFeedbackWindowDays = 7
+	// defaultRecurringIssueShifts is how many shifts at a location and role have to
+	// report an issue before it counts as recurring
+	defaultRecurringIssueShifts = 3
+)
+
+var (
+	ErrShiftFeedbackNotWorked = errors.New("you can only give feedback on shifts you worked")
+	ErrShiftFeedbackTooEarly  = errors.New("feedback opens once the shift has ended")
+	ErrShiftFeedbackClosed    = errors.New("feedback for this shift has closed")
+	ErrShiftFeedbackExists    = errors.New("you have already given feedback on this shift")
+	ErrShiftFeedbackInvalid   = errors.New("invalid shift feedback")
+)
+
+// finishedAssignmentExclusions are assignment statuses that did not work the shift
+var finishedAssignmentExclusions = []string{"Cancelled", "NoShow"}
+
+// ShiftFeedbackInput is a volunteer's feedback on a shift
+type ShiftFeedbackInput struct {
+	Rating           int
+	StaffingAdequate *bool
+	Issues           []string
+	Comments         string
+}
+
+// ShiftQualityGroup is the feedback for one location and role
+type ShiftQualityGroup struct {
+	Location             string         `json:"location"`
+	Role                 string         `json:"role"`
+	Shifts               int            `json:"shifts"` // Shifts with at least one response
+	Responses            int            `json:"responses"`
+	AverageRating        float64        `json:"average_rating"`
+	QualityScore         float64        `json:"quality_score"`          // Average rating out of 100
+	StaffingAdequateRate float64        `json:"staffing_adequate_rate"` // Percentage of answers saying yes
+	IssueCounts          map[string]int `json:"issue_counts"`
+	RecurringIssues      []string       `json:"recurring_issues"`
+}
+
+// RecurringShiftIssue is an issue reported on several shifts at one location and role
+type RecurringShiftIssue struct {
+	Location string `json:"location"`
+	Role     string `json:"role"`
+	Issue    string `json:"issue"`
+	Shifts   int    `json:"shifts"`
+}
+
+// ShiftQualityReport summarises volunteer feedback on shifts for coordinators
+type ShiftQualityReport struct {
+	From              string                `json:"from"`
+	To                string                `json:"to"`
+	Responses         int                   `json:"responses"`
+	AverageRating     float64               `json:"average_rating"`
+	QualityScore      float64               `json:"quality_score"`
+	Groups            []ShiftQualityGroup   `json:"groups"`
+	RecurringProblems []RecurringShiftIssue `json:"recurring_problems"`
+}
+
+// shiftFeedbackRow is one response with the shift's location and role
+type shiftFeedbackRow struct {
+	ShiftID          uint
+	Location         string
+	Role             string
+	Rating           int
+	StaffingAdequate *bool
+	Issues           string
+}
+
+// ShiftFeedbackService asks volunteers how their shifts went and reports recurring
+// problems by location and role
+type ShiftFeedbackService struct {
+	db *gorm.DB
+}
+
+// NewShiftFeedbackService creates a new shift feedback service
+func NewShiftFeedbackService() *ShiftFeedbackService {
+	return &ShiftFeedbackService{db: db.DB}
+}
+
+// shiftFeedbackWindow is how long after a shift ends feedback is accepted
+func shiftFeedbackWindow() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("SHIFT_FEEDBACK_WINDOW_DAYS")); err == nil && v > 0 {
+		return time.Duration(v) * 24 * time.Hour
+	}
+	return defaultShiftFeedbackWindowDays * 24 * time.Hour
+}
+
+// recurringIssueShifts is how many shifts make an issue recurring
+func recurringIssueShifts() int {
+	if v, err := strconv.Atoi(os.Getenv("SHIFT_FEEDBACK_RECURRING_SHIFTS")); err == nil && v > 0 {
+		return v
+	}
+	return defaultRecurringIssueShifts
+}
+
+// PromptDue asks each volunteer whose shift has ended for feedback, once per
+// assignment, and returns how many were asked
+func (sfs *ShiftFeedbackService) PromptDue(now time.Time) (int, error) {
+	var assignments []models.ShiftAssignment
+	if err := sfs.db.Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id").
+		Where("shift_assignments.status NOT IN ? AND shift_assignments.feedback_requested_at IS NULL", finishedAssignmentExclusions).
+		Where("shifts.end_time <= ? AND shifts.end_time > ? AND shifts.cancelled_at IS NULL", now, now.Add(-shiftFeedbackWindow())).
+		Where("shift_assignments.id NOT IN (SELECT assignment_id FROM shift_feedback)").
+		Preload("Shift").
+		Find(&assignments).Error; err != nil {
+		return 0, err
+	}
+
+	prompted := 0
+	for _, assignment := range assignments {
+		if err := GetGlobalRealtimeNotificationService().SendNotification(RealtimeNotificationData{
+			UserID:    assignment.UserID,
+			Type:      "shift_feedback",
+			Title:     "How Was Your Shift?",
+			Message:   fmt.Sprintf("Thanks for volunteering at %s. Tell us in a few taps whether it was well staffed and if anything got in the way.", assignment.Shift.Location),
+			Priority:  "low",
+			Category:  "shifts",
+			ActionURL: fmt.Sprintf("/volunteer/shifts/%d/feedback", assignment.ShiftID),
+			Channels:  []string{"websocket", "push"},
+			Data: map[string]interface{}{
+				"shift_id":      assignment.ShiftID,
+				"assignment_id": assignment.ID,
+			},
+		}); err != nil {
+			log.Printf("Failed to ask for feedback on shift assignment %d: %v", assignment.ID, err)
+			continue
+		}
+		if err := sfs.db.Model(&models.ShiftAssignment{}).Where("id = ?", assignment.ID).
+			Update("feedback_requested_at", now).Error; err != nil {
+			return prompted, err
+		}
+		prompted++
+	}
+	return prompted, nil
+}
+
+// Pending returns the volunteer's recent shifts that are still waiting for feedback
+func (sfs *ShiftFeedbackService) Pending(userID uint, now time.Time) ([]models.ShiftAssignment, error) {
+	var assignments []models.ShiftAssignment
+	if err := sfs.db.Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id").
+		Where("shift_assignments.user_id = ? AND shift_assignments.status NOT IN ?", userID, finishedAssignmentExclusions).
+		Where("shifts.end_time <= ? AND shifts.end_time > ? AND shifts.cancelled_at IS NULL", now, now.Add(-shiftFeedbackWindow())).
+		Where("shift_assignments.id NOT IN (SELECT assignment_id FROM shift_feedback)").
+		Preload("Shift").
+		Order("shifts.end_time DESC").
+		Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// Submit records a volunteer's feedback on a shift they worked
+func (sfs *ShiftFeedbackService) Submit(userID, shiftID uint, input ShiftFeedbackInput, now time.Time) (*models.ShiftFeedback, error) {
+	issues, err := validateShiftFeedback(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var assignment models.ShiftAssignment
+	if err := sfs.db.Preload("Shift").
+		Where("shift_id = ? AND user_id = ? AND status NOT IN ?", shiftID, userID, finishedAssignmentExclusions).
+		First(&assignment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShiftFeedbackNotWorked
+		}
+		return nil, err
+	}
+	if assignment.Shift.EndTime.After(now) {
+		return nil, ErrShiftFeedbackTooEarly
+	}
+	if now.Sub(assignment.Shift.EndTime) > shiftFeedbackWindow() {
+		return nil, ErrShiftFeedbackClosed
+	}
+
+	var existing int64
+	sfs.db.Model(&models.ShiftFeedback{}).Where("assignment_id = ?", assignment.ID).Count(&existing)
+	if existing > 0 {
+		return nil, ErrShiftFeedbackExists
+	}
+
+	feedback := models.ShiftFeedback{
+		ShiftID:          shiftID,
+		AssignmentID:     assignment.ID,
+		UserID:           userID,
+		Rating:           input.Rating,
+		StaffingAdequate: input.StaffingAdequate,
+		Issues:           strings.Join(issues, ","),
+		Comments:         strings.TrimSpace(input.Comments),
+	}
+	if err := sfs.db.Create(&feedback).Error; err != nil {
+		return nil, err
+	}
+	return &feedback, nil
+}
+
+// validateShiftFeedback checks a rating and returns the known issues without repeats
+func validateShiftFeedback(input ShiftFeedbackInput) ([]string, error) {
+	if input.Rating < 1 || input.Rating > 5 {
+		return nil, fmt.Errorf("%w: rating must be between 1 and 5", ErrShiftFeedbackInvalid)
+	}
+	known := make(map[string]bool, len(models.ShiftIssues))
+	for _, issue := range models.ShiftIssues {
+		known[issue] = true
+	}
+
+	seen := map[string]bool{}
+	var issues []string
+	for _, issue := range input.Issues {
+		issue = strings.TrimSpace(issue)
+		if !known[issue] {
+			return nil, fmt.Errorf("%w: unknown issue %q", ErrShiftFeedbackInvalid, issue)
+		}
+		if !seen[issue] {
+			seen[issue] = true
+			issues = append(issues, issue)
+		}
+	}
+	if input.StaffingAdequate != nil && !*input.StaffingAdequate && !seen[models.ShiftIssueUnderstaffed] {
+		issues = append(issues, models.ShiftIssueUnderstaffed)
+	}
+	return issues, nil
+}
+
+// Report summarises feedback on shifts that ended between two times, optionally for
+// one location or role
+func (sfs *ShiftFeedbackService) Report(from, to time.Time, location, role string) (*ShiftQualityReport, error) {
+	query := sfs.db.Table("shift_feedback").
+		Select("shift_feedback.shift_id, shifts.location, shifts.role, shift_feedback.rating, shift_feedback.staffing_adequate, shift_feedback.issues").
+		Joins("JOIN shifts ON shifts.id = shift_feedback.shift_id").
+		Where("shifts.end_time >= ? AND shifts.end_time < ?", from, to)
+	if location != "" {
+		query = query.Where("shifts.location = ?", location)
+	}
+	if role != "" {
+		query = query.Where("shifts.role = ?", role)
+	}
+
+	var rows []shiftFeedbackRow
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	report := buildShiftQualityReport(rows, recurringIssueShifts())
+	report.From = from.Format("2006-01-02")
+	report.To = to.AddDate(0, 0, -1).Format("2006-01-02")
+	return report, nil
+}
+
+// buildShiftQualityReport groups responses by location and role. An issue is
+// recurring when it was reported on at least minShifts different shifts in a group.
+func buildShiftQualityReport(rows []shiftFeedbackRow, minShifts int) *ShiftQualityReport {
+	type groupTotals struct {
+		group        ShiftQualityGroup
+		ratingSum    int
+		staffingYes  int
+		staffingAsks int
+		shifts       map[uint]bool
+		issueShifts  map[string]map[uint]bool
+	}
+
+	report := &ShiftQualityReport{Groups: []ShiftQualityGroup{}, RecurringProblems: []RecurringShiftIssue{}}
+	groups := map[string]*groupTotals{}
+	ratingSum := 0
+	for _, row := range rows {
+		key := row.Location + "\x00" + row.Role
+		totals, ok := groups[key]
+		if !ok {
+			totals = &groupTotals{
+				group:       ShiftQualityGroup{Location: row.Location, Role: row.Role, IssueCounts: map[string]int{}, RecurringIssues: []string{}},
+				shifts:      map[uint]bool{},
+				issueShifts: map[string]map[uint]bool{},
+			}
+			groups[key] = totals
+		}
+
+		totals.group.Responses++
+		totals.ratingSum += row.Rating
+		totals.shifts[row.ShiftID] = true
+		if row.StaffingAdequate != nil {
+			totals.staffingAsks++
+			if *row.StaffingAdequate {
+				totals.staffingYes++
+			}
+		}
+		feedback := models.ShiftFeedback{Issues: row.Issues}
+		for _, issue := range feedback.IssueList() {
+			totals.group.IssueCounts[issue]++
+			if totals.issueShifts[issue] == nil {
+				totals.issueShifts[issue] = map[uint]bool{}
+			}
+			totals.issueShifts[issue][row.ShiftID] = true
+		}
+
+		report.Responses++
+		ratingSum += row.Rating
+	}
+
+	for _, totals := range groups {
+		group := totals.group
+		group.Shifts = len(totals.shifts)
+		group.AverageRating = roundTo(float64(totals.ratingSum)/float64(group.Responses), 2)
+		group.QualityScore = roundTo(group.AverageRating*20, 1)
+		if totals.staffingAsks > 0 {
+			group.StaffingAdequateRate = roundTo(float64(totals.staffingYes)*100/float64(totals.staffingAsks), 1)
+		}
+		for _, issue := range models.ShiftIssues {
+			if shifts := len(totals.issueShifts[issue]); shifts >= minShifts {
+				group.RecurringIssues = append(group.RecurringIssues, issue)
+				report.RecurringProblems = append(report.RecurringProblems, RecurringShiftIssue{
+					Location: group.Location,
+					Role:     group.Role,
+					Issue:    issue,
+					Shifts:   shifts,
+				})
+			}
+		}
+		report.Groups = append(report.Groups, group)
+	}
+
+	// Lowest rated groups first, so coordinators see problems at the top
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if a.AverageRating != b.AverageRating {
+			return a.AverageRating < b.AverageRating
+		}
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		return a.Role < b.Role
+	})
+	sort.SliceStable(report.RecurringProblems, func(i, j int) bool {
+		a, b := report.RecurringProblems[i], report.RecurringProblems[j]
+		if a.Shifts != b.Shifts {
+			return a.Shifts > b.Shifts
+		}
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		return a.Role < b.Role
+	})
+
+	if report.Responses > 0 {
+		report.AverageRating = roundTo(float64(ratingSum)/float64(report.Responses), 2)
+		report.QualityScore = roundTo(report.AverageRating*20, 1)
+	}
+	return report
+}
+
+// VolunteerQualityScore returns the average feedback rating, out of 100, of the shifts
+// a volunteer worked since a time, and how many responses it is based on
+func (sfs *ShiftFeedbackService) VolunteerQualityScore(userID uint, since time.Time) (float64, int64, error) {
+	var result struct {
+		Average   float64
+		Responses int64
+	}
+	if err := sfs.db.Table("shift_feedback").
+		Select("COALESCE(AVG(shift_feedback.rating), 0) AS average, COUNT(*) AS responses").
+		Where("shift_feedback.shift_id IN (?)", sfs.db.Model(&models.ShiftAssignment{}).
+			Select("shift_id").
+			Where("user_id = ? AND status NOT IN ?", userID, finishedAssignmentExclusions)).
+		Where("shift_feedback.created_at >= ?", since).
+		Scan(&result).Error; err != nil {
+		return 0, 0, err
+	}
+	return roundTo(result.Average*20, 1), result.Responses, nil
+}
+
+// roundTo rounds a value to a number of decimal places
+func roundTo(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
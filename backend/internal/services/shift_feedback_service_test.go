@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestValidateShiftFeedback(t *testing.T) {
+	no := false
+	cases := []struct {
+		input   ShiftFeedbackInput
+		want    []string
+		invalid bool
+	}{
+		{ShiftFeedbackInput{Rating: 4}, nil, false},
+		{ShiftFeedbackInput{Rating: 0}, nil, true},
+		{ShiftFeedbackInput{Rating: 6}, nil, true},
+		{ShiftFeedbackInput{Rating: 3, Issues: []string{"equipment", "equipment", "safety"}}, []string{"equipment", "safety"}, false},
+		{ShiftFeedbackInput{Rating: 3, Issues: []string{"too_cold"}}, nil, true},
+		{ShiftFeedbackInput{Rating: 2, StaffingAdequate: &no}, []string{models.ShiftIssueUnderstaffed}, false},
+	}
+	for _, tc := range cases {
+		got, err := validateShiftFeedback(tc.input)
+		if tc.invalid {
+			if !errors.Is(err, ErrShiftFeedbackInvalid) {
+				t.Errorf("validateShiftFeedback(%+v) error = %v, want invalid", tc.input, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("validateShiftFeedback(%+v) = %v, %v; want %v", tc.input, got, err, tc.want)
+		}
+	}
+}
+
+func TestBuildShiftQualityReport(t *testing.T) {
+	yes, no := true, false
+	rows := []shiftFeedbackRow{
+		{ShiftID: 1, Location: "Warehouse", Role: "Packer", Rating: 2, StaffingAdequate: &no, Issues: "understaffed,equipment"},
+		{ShiftID: 1, Location: "Warehouse", Role: "Packer", Rating: 3, StaffingAdequate: &no, Issues: "understaffed"},
+		{ShiftID: 2, Location: "Warehouse", Role: "Packer", Rating: 2, StaffingAdequate: &yes, Issues: "equipment"},
+		{ShiftID: 3, Location: "Warehouse", Role: "Packer", Rating: 3, Issues: "understaffed"},
+		{ShiftID: 4, Location: "Front desk", Role: "Greeter", Rating: 5, StaffingAdequate: &yes},
+	}
+
+	report := buildShiftQualityReport(rows, 2)
+	if report.Responses != 5 || report.AverageRating != 3 || report.QualityScore != 60 || len(report.Groups) != 2 {
+		t.Fatalf("unexpected report totals: %+v", report)
+	}
+
+	warehouse := report.Groups[0]
+	if warehouse.Location != "Warehouse" || warehouse.Shifts != 3 || warehouse.Responses != 4 || warehouse.AverageRating != 2.5 {
+		t.Errorf("expected the warehouse group first, got %+v", warehouse)
+	}
+	if warehouse.StaffingAdequateRate != 33.3 || warehouse.IssueCounts["understaffed"] != 3 {
+		t.Errorf("unexpected warehouse staffing or issues: %+v", warehouse)
+	}
+	if !reflect.DeepEqual(warehouse.RecurringIssues, []string{"understaffed", "equipment"}) {
+		t.Errorf("recurring issues = %v", warehouse.RecurringIssues)
+	}
+	if len(report.RecurringProblems) != 2 || report.RecurringProblems[0].Issue != "understaffed" || report.RecurringProblems[0].Shifts != 2 {
+		t.Errorf("unexpected recurring problems: %+v", report.RecurringProblems)
+	}
+	if report.Groups[1].QualityScore != 100 || len(report.Groups[1].RecurringIssues) != 0 {
+		t.Errorf("unexpected front desk group: %+v", report.Groups[1])
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// shiftReminderWindow is how far ahead of a shift its reminder is sent
+const shiftReminderWindow = 24 * time.Hour
+
+// ShiftReminderService reminds volunteers of their upcoming shifts. Reminders are
+// paused for volunteers who are away or have turned shift reminders off.
+type ShiftReminderService struct {
+	db *gorm.DB
+}
+
+// NewShiftReminderService creates a new shift reminder service
+func NewShiftReminderService() *ShiftReminderService {
+	return &ShiftReminderService{db: db.DB}
+}
+
+// SendDue sends a reminder for each committed assignment starting within the reminder
+// window that has not had one yet, and returns how many were sent
+func (srs *ShiftReminderService) SendDue(now time.Time) (int, error) {
+	var assignments []models.ShiftAssignment
+	if err := srs.db.Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id").
+		Where("shift_assignments.status IN ? AND shift_assignments.reminder_sent_at IS NULL", activeAssignmentStatuses).
+		Where("shifts.start_time > ? AND shifts.start_time <= ?", now, now.Add(shiftReminderWindow)).
+		Where(`shift_assignments.user_id NOT IN (
+			SELECT user_id FROM volunteer_away_periods
+			WHERE shifts.date::date BETWEEN starts_on AND ends_on)`).
+		Where(`shift_assignments.user_id NOT IN (
+			SELECT user_id FROM notification_preferences WHERE shift_reminders = false)`).
+		Preload("Shift").
+		Find(&assignments).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, assignment := range assignments {
+		if err := GetGlobalRealtimeNotificationService().SendShiftReminder(assignment.UserID, assignment.Shift.StartTime, assignment.Shift.Location); err != nil {
+			log.Printf("Failed to send shift reminder for assignment %d: %v", assignment.ID, err)
+			continue
+		}
+		if err := srs.db.Model(&models.ShiftAssignment{}).Where("id = ?", assignment.ID).
+			Update("reminder_sent_at", now).Error; err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
@@ -0,0 +1,534 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Shift series errors
+var (
+	ErrShiftSeriesNotFound   = errors.New("shift series not found")
+	ErrShiftSeriesInvalid    = errors.New("invalid shift series")
+	ErrShiftEditNotFound     = errors.New("shift not found")
+	ErrShiftEditInvalid      = errors.New("invalid shift change")
+	ErrShiftNotInSeries      = errors.New("shift is not part of a series")
+	ErrReconfirmNotRequested = errors.New("no re-confirmation is pending for this shift")
+)
+
+// maxShiftSeriesShifts limits how many shifts one series can create
+const maxShiftSeriesShifts = 104
+
+// ShiftSeriesInput describes a recurring shift. The series ends on Until or after
+// Occurrences shifts, whichever comes first; one of them is required.
+type ShiftSeriesInput struct {
+	Name        string
+	Frequency   string
+	FirstDate   time.Time
+	Until       *time.Time
+	Occurrences int
+	Template    models.Shift // Times, place and role copied onto every shift
+}
+
+// ShiftSeriesDetail is a series with its shifts in date order
+type ShiftSeriesDetail struct {
+	models.ShiftSeries
+	Shifts []models.Shift `json:"shifts"`
+}
+
+// ShiftEdit is a change to a shift, applied to the shifts its scope selects. Only
+// the fields that are set change; StartTime and EndTime are times of day.
+type ShiftEdit struct {
+	Scope          string
+	StartTime      *time.Time
+	EndTime        *time.Time
+	Location       *string
+	Description    *string
+	Role           *string
+	RequiredSkills *string
+	MaxVolunteers  *int
+	Reason         string
+}
+
+// ShiftEditResult summarises a series-aware shift edit
+type ShiftEditResult struct {
+	Scope                string              `json:"scope"`
+	SeriesID             *uint               `json:"series_id,omitempty"`
+	ShiftIDs             []uint              `json:"shift_ids"`
+	Changed              []string            `json:"changed"`
+	ReconfirmRequested   int                 `json:"reconfirm_requested"` // Assignments asked to re-confirm
+	Volunteers           []NotifiedVolunteer `json:"volunteers"`
+	VolunteersNotified   int                 `json:"volunteers_notified"`
+	NotificationFailures int                 `json:"notification_failures"`
+}
+
+// ShiftSeriesService creates recurring shifts and edits one shift, the rest of its
+// series or the whole series, asking booked volunteers to re-confirm when the time
+// or place changes
+type ShiftSeriesService struct {
+	db *gorm.DB
+}
+
+// NewShiftSeriesService creates a new shift series service
+func NewShiftSeriesService() *ShiftSeriesService {
+	return &ShiftSeriesService{db: db.DB}
+}
+
+// Create stores a series and one shift for each of its dates
+func (sss *ShiftSeriesService) Create(input ShiftSeriesInput, createdBy uint) (*ShiftSeriesDetail, error) {
+	dates, err := shiftSeriesDates(input.FirstDate, input.Frequency, input.Until, input.Occurrences)
+	if err != nil {
+		return nil, err
+	}
+	template := input.Template
+	if err := validateShiftTimes(template.StartTime, template.EndTime); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrShiftSeriesInvalid, err)
+	}
+	if strings.TrimSpace(template.Location) == "" {
+		return nil, fmt.Errorf("%w: location is required", ErrShiftSeriesInvalid)
+	}
+	switch template.RoleLevel {
+	case "", models.VolunteerRoleGeneral, models.VolunteerRoleSpecialized, models.VolunteerRoleLead:
+	default:
+		return nil, fmt.Errorf("%w: role level must be general, specialized or lead", ErrShiftSeriesInvalid)
+	}
+	if template.MaxVolunteers < 1 || template.MaxVolunteers > 50 {
+		return nil, fmt.Errorf("%w: max volunteers must be between 1 and 50", ErrShiftSeriesInvalid)
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		name = fmt.Sprintf("%s %s %s", strings.TrimSpace(template.Location), input.Frequency, dates[0].Format("Monday 15:04"))
+	}
+	detail := &ShiftSeriesDetail{ShiftSeries: models.ShiftSeries{
+		Name:      name,
+		Frequency: input.Frequency,
+		StartDate: dates[0],
+		EndDate:   dates[len(dates)-1],
+		CreatedBy: createdBy,
+	}}
+
+	err = sss.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&detail.ShiftSeries).Error; err != nil {
+			return err
+		}
+		detail.Shifts = make([]models.Shift, len(dates))
+		for i, date := range dates {
+			shift := template
+			shift.ID = 0
+			shift.Date = date
+			shift.SeriesID = &detail.ID
+			detail.Shifts[i] = shift
+		}
+		return tx.Create(&detail.Shifts).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := GetCacheService().InvalidateTags(CacheTagShifts, CacheTagDashboard); err != nil {
+		log.Printf("Failed to invalidate shift caches after creating series %d: %v", detail.ID, err)
+	}
+	return detail, nil
+}
+
+// Get returns a series and its shifts
+func (sss *ShiftSeriesService) Get(id uint) (*ShiftSeriesDetail, error) {
+	var detail ShiftSeriesDetail
+	if err := sss.db.First(&detail.ShiftSeries, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShiftSeriesNotFound
+		}
+		return nil, err
+	}
+	if err := sss.db.Where("series_id = ?", id).Order("date ASC").Find(&detail.Shifts).Error; err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
+
+// Edit changes a shift and, depending on the scope, the later shifts in its series or
+// every upcoming shift in it. Shifts that have already happened are never changed.
+// When the time or place changes, volunteers booked on the changed shifts are asked
+// to re-confirm; everyone booked on a changed shift is told what changed.
+func (sss *ShiftSeriesService) Edit(shiftID uint, edit ShiftEdit, editedBy uint, now time.Time) (*ShiftEditResult, error) {
+	if edit.Scope == "" {
+		edit.Scope = models.ShiftEditThis
+	}
+	switch edit.Scope {
+	case models.ShiftEditThis, models.ShiftEditFuture, models.ShiftEditAll:
+	default:
+		return nil, fmt.Errorf("%w: scope must be this, future or all", ErrShiftEditInvalid)
+	}
+
+	result := &ShiftEditResult{Scope: edit.Scope, ShiftIDs: []uint{}, Volunteers: []NotifiedVolunteer{}}
+	var changedShifts []models.Shift
+	var assignments []models.ShiftAssignment
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	err := sss.db.Transaction(func(tx *gorm.DB) error {
+		var shift models.Shift
+		if err := tx.First(&shift, shiftID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShiftEditNotFound
+			}
+			return err
+		}
+		result.SeriesID = shift.SeriesID
+
+		shifts := []models.Shift{shift}
+		if edit.Scope != models.ShiftEditThis {
+			if shift.SeriesID == nil {
+				return ErrShiftNotInSeries
+			}
+			from := today
+			if edit.Scope == models.ShiftEditFuture && shift.Date.After(from) {
+				from = shift.Date
+			}
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("series_id = ? AND date >= ?", *shift.SeriesID, from).
+				Order("date ASC").Find(&shifts).Error; err != nil {
+				return err
+			}
+		} else if shift.Date.Before(today) {
+			return fmt.Errorf("%w: the shift has already happened", ErrShiftEditInvalid)
+		}
+
+		changed := map[string]bool{}
+		for _, shift := range shifts {
+			fields, err := applyShiftEdit(&shift, edit)
+			if err != nil {
+				return err
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			if edit.MaxVolunteers != nil {
+				var booked int64
+				if err := tx.Model(&models.ShiftAssignment{}).
+					Where("shift_id = ? AND status IN ?", shift.ID, activeAssignmentStatuses).
+					Count(&booked).Error; err != nil {
+					return err
+				}
+				if int64(shift.MaxVolunteers) < booked {
+					return fmt.Errorf("%w: the shift on %s already has %d volunteers booked",
+						ErrShiftEditInvalid, shift.Date.Format("2 Jan 2006"), booked)
+				}
+			}
+			if err := tx.Save(&shift).Error; err != nil {
+				return err
+			}
+			for _, field := range fields {
+				changed[field] = true
+			}
+			changedShifts = append(changedShifts, shift)
+			result.ShiftIDs = append(result.ShiftIDs, shift.ID)
+		}
+		for _, field := range shiftEditFields {
+			if changed[field] {
+				result.Changed = append(result.Changed, field)
+			}
+		}
+		if len(changedShifts) == 0 {
+			return nil
+		}
+
+		if err := tx.Where("shift_id IN ? AND status IN ?", result.ShiftIDs, activeAssignmentStatuses).
+			Find(&assignments).Error; err != nil {
+			return err
+		}
+		if !shiftEditNeedsReconfirm(result.Changed) || len(assignments) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(assignments))
+		for i, assignment := range assignments {
+			ids[i] = assignment.ID
+		}
+		result.ReconfirmRequested = len(ids)
+		return tx.Model(&models.ShiftAssignment{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"reconfirm_requested_at": now,
+			"reconfirmed_at":         nil,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(changedShifts) == 0 {
+		return result, nil
+	}
+
+	result.Volunteers, err = (&ShiftCancellationService{db: sss.db}).affectedVolunteers(assignments)
+	if err != nil {
+		return nil, err
+	}
+	shiftsByID := make(map[uint]models.Shift, len(changedShifts))
+	for _, shift := range changedShifts {
+		shiftsByID[shift.ID] = shift
+	}
+	reconfirm := result.ReconfirmRequested > 0
+	for i := range result.Volunteers {
+		volunteer := &result.Volunteers[i]
+		if err := sss.notify(volunteer, shiftsByID, result.Changed, edit.Reason, reconfirm); err != nil {
+			log.Printf("Failed to notify volunteer %d about changed shifts: %v", volunteer.UserID, err)
+			volunteer.Error = err.Error()
+			result.NotificationFailures++
+			continue
+		}
+		volunteer.Notified = true
+		result.VolunteersNotified++
+	}
+
+	if _, err := GetCacheService().InvalidateTags(CacheTagShifts, CacheTagVolunteers, CacheTagDashboard); err != nil {
+		log.Printf("Failed to invalidate shift caches after editing shift %d by %d: %v", shiftID, editedBy, err)
+	}
+	return result, nil
+}
+
+// PendingReconfirmations returns a volunteer's upcoming assignments waiting for them
+// to re-confirm a changed shift
+func (sss *ShiftSeriesService) PendingReconfirmations(userID uint, now time.Time) ([]models.ShiftAssignment, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var assignments []models.ShiftAssignment
+	err := sss.db.Preload("Shift").
+		Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id AND shifts.deleted_at IS NULL").
+		Where("shift_assignments.user_id = ? AND shift_assignments.status IN ?", userID, activeAssignmentStatuses).
+		Where("shift_assignments.reconfirm_requested_at IS NOT NULL AND shift_assignments.reconfirmed_at IS NULL").
+		Where("shifts.date >= ?", today).
+		Order("shifts.date ASC").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+// Reconfirm records a volunteer's answer to a changed shift. Confirming keeps their
+// place; declining releases it without counting as a late cancellation.
+func (sss *ShiftSeriesService) Reconfirm(shiftID, userID uint, confirm bool, reason string, now time.Time) (*models.ShiftAssignment, error) {
+	var assignment models.ShiftAssignment
+	err := sss.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("shift_id = ? AND user_id = ? AND status IN ?", shiftID, userID, activeAssignmentStatuses).
+			Where("reconfirm_requested_at IS NOT NULL AND reconfirmed_at IS NULL").
+			First(&assignment).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrReconfirmNotRequested
+		}
+		if err != nil {
+			return err
+		}
+
+		assignment.ReconfirmedAt = &now
+		if confirm {
+			return tx.Model(&assignment).Update("reconfirmed_at", now).Error
+		}
+
+		message := "Declined shift change"
+		if reason = strings.TrimSpace(reason); reason != "" {
+			message += ": " + reason
+		}
+		assignment.Status = "Cancelled"
+		assignment.CancelledAt = &now
+		assignment.CancellationReason = message
+		if err := tx.Model(&assignment).Updates(map[string]interface{}{
+			"status":              assignment.Status,
+			"cancelled_at":        now,
+			"cancellation_reason": message,
+			"hours_notice":        0,
+			"reconfirmed_at":      now,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Shift{}).
+			Where("id = ? AND assigned_volunteer_id = ?", shiftID, userID).
+			Update("assigned_volunteer_id", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := GetCacheService().InvalidateTags(CacheTagShifts, CacheTagVolunteers); err != nil {
+		log.Printf("Failed to invalidate shift caches after re-confirmation: %v", err)
+	}
+	return &assignment, nil
+}
+
+// notify tells a volunteer what changed on their shifts, asking them to re-confirm
+// when the time or place moved
+func (sss *ShiftSeriesService) notify(volunteer *NotifiedVolunteer, shiftsByID map[uint]models.Shift, changed []string, reason string, reconfirm bool) error {
+	lines := make([]string, 0, len(volunteer.ShiftIDs))
+	for _, shiftID := range volunteer.ShiftIDs {
+		shift := shiftsByID[shiftID]
+		lines = append(lines, fmt.Sprintf("%s %s-%s at %s", shift.Date.Format("Mon 2 Jan"),
+			shift.StartTime.Format("15:04"), shift.EndTime.Format("15:04"), shift.Location))
+	}
+
+	baseURL := os.Getenv("FRONTEND_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+	actionPath := "/volunteer/shifts/assigned"
+
+	title := "Your shift has changed"
+	if len(lines) > 1 {
+		title = fmt.Sprintf("%d of your shifts have changed", len(lines))
+	}
+	message := fmt.Sprintf("The %s of your shifts changed. They are now: %s.",
+		strings.ReplaceAll(strings.Join(changed, ", "), "_", " "), strings.Join(lines, "; "))
+	if reason != "" {
+		message += " Reason: " + reason + "."
+	}
+	if reconfirm {
+		message += fmt.Sprintf(" Please confirm you can still make it, or let us know you can't, at %s%s", baseURL, actionPath)
+	}
+
+	return GetGlobalRealtimeNotificationService().SendNotification(RealtimeNotificationData{
+		UserID:    volunteer.UserID,
+		Type:      "shift_changed",
+		Title:     title,
+		Message:   message,
+		Priority:  models.PriorityHigh,
+		Category:  "volunteer",
+		ActionURL: actionPath,
+		Channels:  []string{"websocket", "email"},
+		Data: map[string]interface{}{
+			"shift_ids":          volunteer.ShiftIDs,
+			"changed":            changed,
+			"reconfirm_required": reconfirm,
+		},
+	})
+}
+
+// shiftEditFields lists the fields a shift edit can change, in the order they are
+// reported
+var shiftEditFields = []string{"start_time", "end_time", "location", "description", "role", "required_skills", "max_volunteers"}
+
+// shiftEditNeedsReconfirm reports whether volunteers must re-confirm after these
+// changes, which is when the time or place of the shift moved
+func shiftEditNeedsReconfirm(changed []string) bool {
+	for _, field := range changed {
+		switch field {
+		case "start_time", "end_time", "location":
+			return true
+		}
+	}
+	return false
+}
+
+// applyShiftEdit copies the set fields of an edit onto a shift and returns the
+// fields whose values changed
+func applyShiftEdit(shift *models.Shift, edit ShiftEdit) ([]string, error) {
+	var changed []string
+	start, end := shift.StartTime, shift.EndTime
+	if edit.StartTime != nil {
+		start = *edit.StartTime
+	}
+	if edit.EndTime != nil {
+		end = *edit.EndTime
+	}
+	if edit.StartTime != nil || edit.EndTime != nil {
+		if err := validateShiftTimes(start, end); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrShiftEditInvalid, err)
+		}
+		if minuteOfDay(start) != minuteOfDay(shift.StartTime) {
+			shift.StartTime = start
+			changed = append(changed, "start_time")
+		}
+		if minuteOfDay(end) != minuteOfDay(shift.EndTime) {
+			shift.EndTime = end
+			changed = append(changed, "end_time")
+		}
+	}
+
+	setString := func(field string, target *string, value *string) error {
+		if value == nil {
+			return nil
+		}
+		trimmed := strings.TrimSpace(*value)
+		if trimmed == "" && (field == "location" || field == "description") {
+			return fmt.Errorf("%w: %s cannot be empty", ErrShiftEditInvalid, field)
+		}
+		if trimmed != *target {
+			*target = trimmed
+			changed = append(changed, field)
+		}
+		return nil
+	}
+	if err := setString("location", &shift.Location, edit.Location); err != nil {
+		return nil, err
+	}
+	if err := setString("description", &shift.Description, edit.Description); err != nil {
+		return nil, err
+	}
+	if err := setString("role", &shift.Role, edit.Role); err != nil {
+		return nil, err
+	}
+	if err := setString("required_skills", &shift.RequiredSkills, edit.RequiredSkills); err != nil {
+		return nil, err
+	}
+
+	if edit.MaxVolunteers != nil {
+		if *edit.MaxVolunteers < 1 || *edit.MaxVolunteers > 50 {
+			return nil, fmt.Errorf("%w: max volunteers must be between 1 and 50", ErrShiftEditInvalid)
+		}
+		if *edit.MaxVolunteers != shift.MaxVolunteers {
+			shift.MaxVolunteers = *edit.MaxVolunteers
+			changed = append(changed, "max_volunteers")
+		}
+	}
+	return changed, nil
+}
+
+// validateShiftTimes checks a shift ends after it starts and lasts between 30
+// minutes and 12 hours
+func validateShiftTimes(start, end time.Time) error {
+	duration := time.Duration(minuteOfDay(end)-minuteOfDay(start)) * time.Minute
+	switch {
+	case duration <= 0:
+		return errors.New("end time must be after start time")
+	case duration < 30*time.Minute:
+		return errors.New("a shift must be at least 30 minutes long")
+	case duration > 12*time.Hour:
+		return errors.New("a shift cannot be longer than 12 hours")
+	}
+	return nil
+}
+
+// shiftSeriesDates lists the dates of a series from its first date, stopping at
+// until or after occurrences shifts, whichever comes first
+func shiftSeriesDates(first time.Time, frequency string, until *time.Time, occurrences int) ([]time.Time, error) {
+	step := models.ShiftSeriesStep(frequency)
+	if step == 0 {
+		return nil, fmt.Errorf("%w: frequency must be daily, weekly or fortnightly", ErrShiftSeriesInvalid)
+	}
+	if until == nil && occurrences <= 0 {
+		return nil, fmt.Errorf("%w: give an end date or a number of shifts", ErrShiftSeriesInvalid)
+	}
+	if occurrences < 0 || occurrences > maxShiftSeriesShifts {
+		return nil, fmt.Errorf("%w: a series can have at most %d shifts", ErrShiftSeriesInvalid, maxShiftSeriesShifts)
+	}
+	if until != nil && until.Before(first) {
+		return nil, fmt.Errorf("%w: end date must be on or after the first date", ErrShiftSeriesInvalid)
+	}
+
+	var dates []time.Time
+	for date := first; ; date = date.AddDate(0, 0, step) {
+		if until != nil && date.After(*until) {
+			break
+		}
+		if occurrences > 0 && len(dates) == occurrences {
+			break
+		}
+		if len(dates) == maxShiftSeriesShifts {
+			return nil, fmt.Errorf("%w: a series can have at most %d shifts", ErrShiftSeriesInvalid, maxShiftSeriesShifts)
+		}
+		dates = append(dates, date)
+	}
+	return dates, nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestShiftSeriesDates(t *testing.T) {
+	first := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 11, 17, 0, 0, 0, 0, time.UTC)
+	beforeFirst := first.AddDate(0, 0, -1)
+	farAway := first.AddDate(5, 0, 0)
+
+	tests := []struct {
+		name        string
+		frequency   string
+		until       *time.Time
+		occurrences int
+		wantCount   int
+		wantLast    time.Time
+		wantErr     bool
+	}{
+		{name: "weekly until end date inclusive", frequency: models.ShiftSeriesWeekly, until: &until, wantCount: 5, wantLast: until},
+		{name: "fortnightly until end date", frequency: models.ShiftSeriesFortnightly, until: &until, wantCount: 3, wantLast: until},
+		{name: "daily by occurrences", frequency: models.ShiftSeriesDaily, occurrences: 3, wantCount: 3, wantLast: first.AddDate(0, 0, 2)},
+		{name: "occurrences stop before end date", frequency: models.ShiftSeriesWeekly, until: &until, occurrences: 2, wantCount: 2, wantLast: first.AddDate(0, 0, 7)},
+		{name: "unknown frequency", frequency: "monthly", occurrences: 3, wantErr: true},
+		{name: "no end", frequency: models.ShiftSeriesWeekly, wantErr: true},
+		{name: "end before start", frequency: models.ShiftSeriesWeekly, until: &beforeFirst, wantErr: true},
+		{name: "too many shifts", frequency: models.ShiftSeriesDaily, until: &farAway, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates, err := shiftSeriesDates(first, tt.frequency, tt.until, tt.occurrences)
+			if tt.wantErr {
+				if !errors.Is(err, ErrShiftSeriesInvalid) {
+					t.Fatalf("expected ErrShiftSeriesInvalid, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(dates) != tt.wantCount {
+				t.Fatalf("got %d dates, want %d", len(dates), tt.wantCount)
+			}
+			if !dates[0].Equal(first) || !dates[len(dates)-1].Equal(tt.wantLast) {
+				t.Errorf("dates run %s to %s, want %s to %s", dates[0], dates[len(dates)-1], first, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestApplyShiftEdit(t *testing.T) {
+	clock := func(value string) *time.Time {
+		parsed, _ := time.Parse("15:04", value)
+		return &parsed
+	}
+	text := func(value string) *string { return &value }
+	number := func(value int) *int { return &value }
+	base := models.Shift{
+		StartTime:     *clock("09:00"),
+		EndTime:       *clock("12:00"),
+		Location:      "Main Hall",
+		Description:   "Food bank",
+		Role:          "Packer",
+		MaxVolunteers: 4,
+	}
+
+	tests := []struct {
+		name          string
+		edit          ShiftEdit
+		wantChanged   []string
+		wantReconfirm bool
+		wantErr       bool
+	}{
+		{name: "new start time", edit: ShiftEdit{StartTime: clock("10:00")}, wantChanged: []string{"start_time"}, wantReconfirm: true},
+		{name: "new location", edit: ShiftEdit{Location: text(" Church Hall ")}, wantChanged: []string{"location"}, wantReconfirm: true},
+		{name: "description only", edit: ShiftEdit{Description: text("Food bank and advice")}, wantChanged: []string{"description"}},
+		{name: "same values", edit: ShiftEdit{StartTime: clock("09:00"), Location: text("Main Hall"), MaxVolunteers: number(4)}},
+		{name: "capacity", edit: ShiftEdit{MaxVolunteers: number(6)}, wantChanged: []string{"max_volunteers"}},
+		{name: "end before start", edit: ShiftEdit{EndTime: clock("08:00")}, wantErr: true},
+		{name: "too short", edit: ShiftEdit{EndTime: clock("09:15")}, wantErr: true},
+		{name: "empty location", edit: ShiftEdit{Location: text("  ")}, wantErr: true},
+		{name: "capacity out of range", edit: ShiftEdit{MaxVolunteers: number(0)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shift := base
+			changed, err := applyShiftEdit(&shift, tt.edit)
+			if tt.wantErr {
+				if !errors.Is(err, ErrShiftEditInvalid) {
+					t.Fatalf("expected ErrShiftEditInvalid, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(changed, tt.wantChanged) {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if got := shiftEditNeedsReconfirm(changed); got != tt.wantReconfirm {
+				t.Errorf("needs re-confirm = %v, want %v", got, tt.wantReconfirm)
+			}
+		})
+	}
+}
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of shift requirement a volunteer can be missing
+const (
+	ShiftGapRoleLevel = "role_level"
+	ShiftGapSkill     = "skill"
+	ShiftGapTraining  = "training"
+	ShiftGapDocument  = "document"
+)
+
+var ErrUnknownTrainingModule = errors.New("unknown or inactive training module")
+
+// volunteerRoleLevelRank orders role levels so a shift can require a minimum
+var volunteerRoleLevelRank = map[string]int{
+	models.VolunteerRoleGeneral:     0,
+	models.VolunteerRoleSpecialized: 1,
+	models.VolunteerRoleLead:        2,
+}
+
+// ShiftRequirementGap is one thing a volunteer needs before they can work a shift
+type ShiftRequirementGap struct {
+	Kind        string `json:"kind"`
+	Requirement string `json:"requirement"` // Role level, skill, training module name or document type
+	Message     string `json:"message"`
+}
+
+// AlmostEligibleShift is a shift a volunteer would qualify for with a few more
+// qualifications
+type AlmostEligibleShift struct {
+	Shift   models.Shift          `json:"shift"`
+	Missing []ShiftRequirementGap `json:"missing"`
+}
+
+// ShiftVisibilityService decides which shifts a volunteer may see and sign up for from
+// the shift's role level and skills and the training and documents its role needs
+type ShiftVisibilityService struct {
+	db *gorm.DB
+}
+
+// NewShiftVisibilityService creates a new shift visibility service
+func NewShiftVisibilityService() *ShiftVisibilityService {
+	return &ShiftVisibilityService{
+		db: db.DB,
+	}
+}
+
+// volunteerQualifications is what a volunteer holds. Expiry times are zero for
+// qualifications that do not expire.
+type volunteerQualifications struct {
+	roleLevel string
+	skills    map[string]bool
+	documents map[string]time.Time // Approved documents by type
+	trainings map[uint]time.Time   // Completed training by module
+}
+
+// shiftRoleRequirements are the documents and training each shift role needs
+type shiftRoleRequirements struct {
+	documents map[string][]string
+	trainings map[string][]models.TrainingModule
+}
+
+// almostEligibleMaxGaps is how many missing qualifications a shift may have and
+// still be listed as almost eligible
+func almostEligibleMaxGaps() int {
+	if n, err := strconv.Atoi(os.Getenv("SHIFT_ALMOST_ELIGIBLE_MAX_GAPS")); err == nil && n >= 0 {
+		return n
+	}
+	return 2
+}
+
+// Partition splits shifts into those the volunteer qualifies for and those they are
+// a few qualifications short of. Shifts missing more are left out.
+func (svs *ShiftVisibilityService) Partition(userID uint, shifts []models.Shift) ([]models.Shift, []AlmostEligibleShift, error) {
+	eligible := []models.Shift{}
+	almost := []AlmostEligibleShift{}
+	if len(shifts) == 0 {
+		return eligible, almost, nil
+	}
+
+	quals, err := svs.loadQualifications(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reqs, err := svs.loadRequirements()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	maxGaps := almostEligibleMaxGaps()
+	for _, shift := range shifts {
+		gaps := quals.gaps(shift, reqs)
+		switch {
+		case len(gaps) == 0:
+			eligible = append(eligible, shift)
+		case len(gaps) <= maxGaps:
+			almost = append(almost, AlmostEligibleShift{Shift: shift, Missing: gaps})
+		}
+	}
+	return eligible, almost, nil
+}
+
+// Check returns what the volunteer is missing to work a shift, or nothing when they
+// qualify
+func (svs *ShiftVisibilityService) Check(userID uint, shift models.Shift) ([]ShiftRequirementGap, error) {
+	quals, err := svs.loadQualifications(userID)
+	if err != nil {
+		return nil, err
+	}
+	reqs, err := svs.loadRequirements()
+	if err != nil {
+		return nil, err
+	}
+	return quals.gaps(shift, reqs), nil
+}
+
+// TrainingRequirements returns the training required for shift roles, optionally for one role
+func (svs *ShiftVisibilityService) TrainingRequirements(role string) ([]models.ShiftRoleTrainingRequirement, error) {
+	query := svs.db.Preload("TrainingModule")
+	if role = NormalizeShiftRole(role); role != "" {
+		query = query.Where("role = ?", role)
+	}
+
+	var requirements []models.ShiftRoleTrainingRequirement
+	err := query.Order("role ASC, training_module_id ASC").Find(&requirements).Error
+	return requirements, err
+}
+
+// SetTrainingRequirements replaces the training modules required for a shift role
+func (svs *ShiftVisibilityService) SetTrainingRequirements(role string, moduleIDs []uint, updatedBy uint) ([]models.ShiftRoleTrainingRequirement, error) {
+	role = NormalizeShiftRole(role)
+	seen := map[uint]bool{}
+	requirements := make([]models.ShiftRoleTrainingRequirement, 0, len(moduleIDs))
+	for _, moduleID := range moduleIDs {
+		if seen[moduleID] {
+			continue
+		}
+		seen[moduleID] = true
+		requirements = append(requirements, models.ShiftRoleTrainingRequirement{
+			Role:             role,
+			TrainingModuleID: moduleID,
+			CreatedBy:        updatedBy,
+		})
+	}
+
+	if len(seen) > 0 {
+		var active int64
+		ids := make([]uint, 0, len(seen))
+		for id := range seen {
+			ids = append(ids, id)
+		}
+		if err := svs.db.Model(&models.TrainingModule{}).Where("id IN ? AND active = ?", ids, true).Count(&active).Error; err != nil {
+			return nil, err
+		}
+		if int(active) != len(ids) {
+			return nil, ErrUnknownTrainingModule
+		}
+	}
+
+	err := svs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role = ?", role).Delete(&models.ShiftRoleTrainingRequirement{}).Error; err != nil {
+			return err
+		}
+		if len(requirements) == 0 {
+			return nil
+		}
+		return tx.Create(&requirements).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return svs.TrainingRequirements(role)
+}
+
+// loadQualifications reads a volunteer's role level, skills, approved documents and
+// completed training
+func (svs *ShiftVisibilityService) loadQualifications(userID uint) (*volunteerQualifications, error) {
+	quals := &volunteerQualifications{
+		roleLevel: models.VolunteerRoleGeneral,
+		skills:    map[string]bool{},
+		documents: map[string]time.Time{},
+		trainings: map[uint]time.Time{},
+	}
+
+	var profile models.VolunteerProfile
+	err := svs.db.Preload("Application").Where("user_id = ?", userID).First(&profile).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		if profile.RoleLevel != "" {
+			quals.roleLevel = profile.RoleLevel
+		}
+		skills := profile.Skills
+		if profile.Application != nil {
+			skills += "," + profile.Application.Skills
+		}
+		for _, skill := range splitSkills(skills) {
+			quals.skills[skill] = true
+		}
+	}
+
+	var documents []models.Document
+	if err := svs.db.Select("type", "expires_at").
+		Where("user_id = ? AND type IN ? AND status = ?", userID, models.VolunteerDocumentTypes, models.DocumentStatusApproved).
+		Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	for _, document := range documents {
+		_, held := quals.documents[document.Type]
+		quals.documents[document.Type] = laterExpiry(quals.documents[document.Type], document.ExpiresAt, held)
+	}
+
+	var trainings []models.UserTraining
+	if err := svs.db.Select("training_module_id", "expires_at").
+		Where("user_id = ? AND completed_at IS NOT NULL", userID).
+		Find(&trainings).Error; err != nil {
+		return nil, err
+	}
+	for _, training := range trainings {
+		_, held := quals.trainings[training.TrainingModuleID]
+		quals.trainings[training.TrainingModuleID] = laterExpiry(quals.trainings[training.TrainingModuleID], training.ExpiresAt, held)
+	}
+
+	return quals, nil
+}
+
+// loadRequirements reads the documents and training required for every shift role
+func (svs *ShiftVisibilityService) loadRequirements() (*shiftRoleRequirements, error) {
+	reqs := &shiftRoleRequirements{
+		documents: map[string][]string{},
+		trainings: map[string][]models.TrainingModule{},
+	}
+
+	var documents []models.ShiftRoleDocumentRequirement
+	if err := svs.db.Order("document_type ASC").Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	for _, requirement := range documents {
+		reqs.documents[requirement.Role] = append(reqs.documents[requirement.Role], requirement.DocumentType)
+	}
+
+	trainings, err := svs.TrainingRequirements("")
+	if err != nil {
+		return nil, err
+	}
+	for _, requirement := range trainings {
+		reqs.trainings[requirement.Role] = append(reqs.trainings[requirement.Role], requirement.TrainingModule)
+	}
+
+	return reqs, nil
+}
+
+// gaps lists what the volunteer is missing for a shift, in the order a coordinator
+// would usually resolve them
+func (q *volunteerQualifications) gaps(shift models.Shift, reqs *shiftRoleRequirements) []ShiftRequirementGap {
+	var gaps []ShiftRequirementGap
+
+	if need, ok := volunteerRoleLevelRank[shift.RoleLevel]; ok && volunteerRoleLevelRank[q.roleLevel] < need {
+		gaps = append(gaps, ShiftRequirementGap{
+			Kind:        ShiftGapRoleLevel,
+			Requirement: shift.RoleLevel,
+			Message:     fmt.Sprintf("Only open to %s volunteers", strings.ToLower(models.EnumLabel(shift.RoleLevel))),
+		})
+	}
+
+	for _, skill := range splitSkills(shift.RequiredSkills) {
+		if !q.skills[skill] {
+			gaps = append(gaps, ShiftRequirementGap{
+				Kind:        ShiftGapSkill,
+				Requirement: skill,
+				Message:     fmt.Sprintf("Needs the %s skill on your profile", skill),
+			})
+		}
+	}
+
+	role := NormalizeShiftRole(shift.Role)
+	if role == "" {
+		return gaps
+	}
+
+	for _, module := range reqs.trainings[role] {
+		expires, held := q.trainings[module.ID]
+		name := module.Title
+		if name == "" {
+			name = module.Name
+		}
+		switch {
+		case !held:
+			gaps = append(gaps, ShiftRequirementGap{
+				Kind:        ShiftGapTraining,
+				Requirement: name,
+				Message:     fmt.Sprintf("Complete the %s training", name),
+			})
+		case !expires.IsZero() && !expires.After(shift.Date):
+			gaps = append(gaps, ShiftRequirementGap{
+				Kind:        ShiftGapTraining,
+				Requirement: name,
+				Message:     fmt.Sprintf("Renew the %s training, which expires before this shift", name),
+			})
+		}
+	}
+
+	for _, documentType := range reqs.documents[role] {
+		expires, held := q.documents[documentType]
+		label := models.EnumLabel(documentType)
+		switch {
+		case !held:
+			gaps = append(gaps, ShiftRequirementGap{
+				Kind:        ShiftGapDocument,
+				Requirement: documentType,
+				Message:     fmt.Sprintf("Upload a %s for a coordinator to verify", label),
+			})
+		case !expires.IsZero() && !expires.After(shift.Date):
+			gaps = append(gaps, ShiftRequirementGap{
+				Kind:        ShiftGapDocument,
+				Requirement: documentType,
+				Message:     fmt.Sprintf("Your %s expires before this shift", label),
+			})
+		}
+	}
+
+	return gaps
+}
+
+// splitSkills parses a comma-separated skill list into lower-case skills
+func splitSkills(skills string) []string {
+	var parsed []string
+	for _, skill := range strings.Split(skills, ",") {
+		if skill = strings.ToLower(strings.TrimSpace(skill)); skill != "" {
+			parsed = append(parsed, skill)
+		}
+	}
+	return parsed
+}
+
+// laterExpiry keeps the longest-lasting of a held qualification and another copy of
+// it. A zero time means the qualification does not expire.
+func laterExpiry(current time.Time, expiresAt *time.Time, held bool) time.Time {
+	if expiresAt == nil {
+		return time.Time{}
+	}
+	if held && (current.IsZero() || current.After(*expiresAt)) {
+		return current
+	}
+	return *expiresAt
+}
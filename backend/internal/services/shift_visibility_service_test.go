@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestShiftGaps(t *testing.T) {
+	shiftDate := time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+	reqs := &shiftRoleRequirements{
+		documents: map[string][]string{"driver": {models.DocumentTypeDBSCheck}},
+		trainings: map[string][]models.TrainingModule{"driver": {{ID: 7, Title: "Safe lifting"}}},
+	}
+	shift := models.Shift{Date: shiftDate, Role: "Driver", RequiredSkills: "Driving, first aid", RoleLevel: models.VolunteerRoleSpecialized}
+
+	quals := &volunteerQualifications{
+		roleLevel: models.VolunteerRoleGeneral,
+		skills:    map[string]bool{"driving": true},
+		documents: map[string]time.Time{models.DocumentTypeDBSCheck: shiftDate.AddDate(0, 0, -1)},
+		trainings: map[uint]time.Time{},
+	}
+	gaps := quals.gaps(shift, reqs)
+	want := []string{ShiftGapRoleLevel, ShiftGapSkill, ShiftGapTraining, ShiftGapDocument}
+	if len(gaps) != len(want) {
+		t.Fatalf("got %d gaps %+v, want %v", len(gaps), gaps, want)
+	}
+	for i, kind := range want {
+		if gaps[i].Kind != kind {
+			t.Errorf("gap %d is %s, want %s", i, gaps[i].Kind, kind)
+		}
+	}
+	if gaps[1].Requirement != "first aid" {
+		t.Errorf("missing skill %q, want first aid", gaps[1].Requirement)
+	}
+
+	quals.roleLevel = models.VolunteerRoleLead
+	quals.skills["first aid"] = true
+	quals.trainings[7] = time.Time{}
+	quals.documents[models.DocumentTypeDBSCheck] = time.Time{}
+	if gaps := quals.gaps(shift, reqs); len(gaps) != 0 {
+		t.Fatalf("qualified volunteer has gaps %+v", gaps)
+	}
+}
+
+func TestLaterExpiry(t *testing.T) {
+	early := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := early.AddDate(1, 0, 0)
+
+	if got := laterExpiry(time.Time{}, &early, false); !got.Equal(early) {
+		t.Errorf("first copy: got %v", got)
+	}
+	if got := laterExpiry(early, &late, true); !got.Equal(late) {
+		t.Errorf("later copy: got %v", got)
+	}
+	if got := laterExpiry(late, &early, true); !got.Equal(late) {
+		t.Errorf("earlier copy: got %v", got)
+	}
+	if got := laterExpiry(time.Time{}, &late, true); !got.IsZero() {
+		t.Errorf("non-expiring copy held: got %v", got)
+	}
+	if got := laterExpiry(early, nil, true); !got.IsZero() {
+		t.Errorf("non-expiring copy: got %v", got)
+	}
+}
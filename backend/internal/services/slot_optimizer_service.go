@@ -0,0 +1,548 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxSlotPlanDays is the longest window the optimizer plans at once
+	maxSlotPlanDays = 28
+	// defaultLargeHouseholdSize is the household size that needs two places in a slot
+	defaultLargeHouseholdSize = 5
+	// slotDayPenalty weighs moving a visitor by one day against moving them by one
+	// minute within a day, so the preferred day always wins over the preferred time
+	slotDayPenalty = 24 * 60
+)
+
+var (
+	ErrSlotPlanInvalid = errors.New("invalid slot plan")
+	ErrSlotPlanChanged = errors.New("requests or capacity have changed since the preview; review the new plan before applying")
+)
+
+// Statuses of requests whose slots are fixed and not moved by the optimizer
+var fixedSlotStatuses = []string{
+	models.HelpRequestStatusTicketIssued, models.HelpRequestStatusCheckedIn, models.HelpRequestStatusCompleted,
+}
+
+// SlotPlanRequest chooses the approved requests and days the optimizer plans
+type SlotPlanRequest struct {
+	Category string
+	From     time.Time
+	Days     int
+}
+
+// SlotAssignment is a visit day and time the optimizer chose for a request
+type SlotAssignment struct {
+	RequestID     uint   `json:"request_id"`
+	Reference     string `json:"reference"`
+	VisitorID     uint   `json:"visitor_id"`
+	VisitorName   string `json:"visitor_name"`
+	Priority      string `json:"priority"`
+	PriorityScore int    `json:"priority_score"`
+	HouseholdSize int    `json:"household_size"`
+	PreferredDay  string `json:"preferred_day"`
+	PreferredSlot string `json:"preferred_slot"`
+	VisitDay      string `json:"visit_day"`
+	TimeSlot      string `json:"time_slot"`
+	Moved         bool   `json:"moved"` // The day or time differs from the visitor's preference
+}
+
+// SlotUnassigned is an approved request the optimizer could not place
+type SlotUnassigned struct {
+	RequestID     uint   `json:"request_id"`
+	Reference     string `json:"reference"`
+	PriorityScore int    `json:"priority_score"`
+	Reason        string `json:"reason"`
+}
+
+// SlotPlanDay is how much of a day the plan uses
+type SlotPlanDay struct {
+	Date      string `json:"date"`
+	Available int    `json:"available"` // Places before the plan
+	Assigned  int    `json:"assigned"`
+}
+
+// SlotPlan is a proposed assignment of approved requests to visit slots
+type SlotPlan struct {
+	PlanID        string           `json:"plan_id"` // Changes whenever the proposed assignments change
+	Category      string           `json:"category"`
+	From          string           `json:"from"`
+	To            string           `json:"to"`
+	Assignments   []SlotAssignment `json:"assignments"`
+	Unassigned    []SlotUnassigned `json:"unassigned"`
+	Days          []SlotPlanDay    `json:"days"`
+	PreferredKept int              `json:"preferred_kept"` // Assignments on the visitor's preferred day and time
+}
+
+// slotCandidate is an approved request waiting for a slot
+type slotCandidate struct {
+	ID            uint
+	Reference     string
+	VisitorID     uint
+	VisitorName   string
+	Priority      string
+	RequestDate   time.Time
+	HouseholdSize int
+	PreferredDay  string
+	PreferredSlot string
+	EarliestDay   string // First day allowed by the minimum gap between visits
+	Score         int
+}
+
+// slotTime is one bookable time on a day
+type slotTime struct {
+	Time      string
+	Minutes   int
+	Remaining int
+}
+
+// slotDay is one operating day in the plan
+type slotDay struct {
+	Date      string
+	Available int
+	Remaining int
+	Slots     []slotTime
+}
+
+// SlotOptimizerService assigns approved help requests to visit days and times,
+// respecting capacity, visitor preferences, priority and household rules
+type SlotOptimizerService struct {
+	db *gorm.DB
+}
+
+// NewSlotOptimizerService creates a new slot optimizer service
+func NewSlotOptimizerService() *SlotOptimizerService {
+	return &SlotOptimizerService{db: db.DB}
+}
+
+// largeHouseholdSize is the household size that takes two places in a slot
+func largeHouseholdSize() int {
+	if v, err := strconv.Atoi(os.Getenv("SLOT_OPTIMIZER_LARGE_HOUSEHOLD")); err == nil && v > 0 {
+		return v
+	}
+	return defaultLargeHouseholdSize
+}
+
+// slotPriorityScore ranks a request for a slot: urgency first, then how long the
+// visitor has waited, then household size
+func slotPriorityScore(priority string, requestDate time.Time, householdSize int, now time.Time) int {
+	score := 30
+	switch priority {
+	case models.PriorityUrgent, models.PriorityCritical:
+		score = 100
+	case models.PriorityHigh:
+		score = 60
+	case models.PriorityLow:
+		score = 10
+	}
+
+	waitingDays := int(now.Sub(requestDate).Hours() / 24)
+	if waitingDays > 14 {
+		waitingDays = 14
+	}
+	if waitingDays > 0 {
+		score += waitingDays * 2
+	}
+	if householdSize > 6 {
+		householdSize = 6
+	}
+	if householdSize > 1 {
+		score += householdSize - 1
+	}
+	return score
+}
+
+// Preview works out the best assignment of approved requests without saving it
+func (sos *SlotOptimizerService) Preview(req SlotPlanRequest) (*SlotPlan, error) {
+	if req.Category == "" {
+		return nil, fmt.Errorf("%w: category is required", ErrSlotPlanInvalid)
+	}
+	if req.Days <= 0 || req.Days > maxSlotPlanDays {
+		return nil, fmt.Errorf("%w: plan between 1 and %d days", ErrSlotPlanInvalid, maxSlotPlanDays)
+	}
+	category := strings.ToLower(strings.TrimSpace(req.Category))
+	from := time.Date(req.From.Year(), req.From.Month(), req.From.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, req.Days-1)
+
+	serviceType, err := NewServiceTypeService().GetByCode(category)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		serviceType = nil
+	}
+
+	days, err := sos.loadDays(category, serviceType, from, req.Days)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := sos.loadCandidates(category, serviceType, to.Format("2006-01-02"), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	plan := optimizeSlots(candidates, days, largeHouseholdSize())
+	plan.Category = category
+	plan.From = from.Format("2006-01-02")
+	plan.To = to.Format("2006-01-02")
+	return plan, nil
+}
+
+// Apply saves a previewed plan. The plan is worked out again and only saved when it
+// still matches the preview, so nobody applies assignments they have not seen.
+func (sos *SlotOptimizerService) Apply(req SlotPlanRequest, planID string) (*SlotPlan, error) {
+	plan, err := sos.Preview(req)
+	if err != nil {
+		return nil, err
+	}
+	if plan.PlanID != planID {
+		return plan, ErrSlotPlanChanged
+	}
+
+	err = sos.db.Transaction(func(tx *gorm.DB) error {
+		for _, assignment := range plan.Assignments {
+			result := tx.Model(&models.HelpRequest{}).
+				Where("id = ? AND status = ?", assignment.RequestID, models.HelpRequestStatusApproved).
+				Updates(map[string]interface{}{
+					"visit_day":  assignment.VisitDay,
+					"time_slot":  assignment.TimeSlot,
+					"updated_at": time.Now(),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrSlotPlanChanged
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, assignment := range plan.Assignments {
+		if assignment.Moved {
+			sos.notifyMoved(assignment)
+		}
+	}
+	return plan, nil
+}
+
+// loadDays returns the operating days in the window with their remaining places
+func (sos *SlotOptimizerService) loadDays(category string, serviceType *models.ServiceType, from time.Time, count int) ([]slotDay, error) {
+	open, closing := 10*60+30, 14*60+30
+	interval, perSlot := 10, 2
+	if serviceType != nil {
+		if o, c, err := serviceType.SlotWindow(); err == nil {
+			open, closing = o, c
+		}
+		if serviceType.SlotIntervalMinutes > 0 {
+			interval = serviceType.SlotIntervalMinutes
+		}
+		if serviceType.MaxVisitorsPerSlot > 0 {
+			perSlot = serviceType.MaxVisitorsPerSlot
+		}
+	}
+
+	today := time.Now().Format("2006-01-02")
+	var days []slotDay
+	for i := 0; i < count; i++ {
+		date := from.AddDate(0, 0, i)
+		dateStr := date.Format("2006-01-02")
+		if dateStr < today {
+			continue
+		}
+		if serviceType != nil && !serviceType.IsOperatingDay(date.Weekday()) {
+			continue
+		}
+		if serviceType == nil && (date.Weekday() < time.Tuesday || date.Weekday() > time.Thursday) {
+			continue
+		}
+
+		available, err := sos.dayCapacity(category, serviceType, date)
+		if err != nil {
+			return nil, err
+		}
+		if available <= 0 {
+			continue
+		}
+
+		var booked []struct {
+			TimeSlot string
+			Count    int
+		}
+		if err := sos.db.Model(&models.HelpRequest{}).
+			Select("time_slot, COUNT(*) AS count").
+			Where("LOWER(category) = ? AND visit_day = ? AND status IN ?", category, dateStr, fixedSlotStatuses).
+			Group("time_slot").Scan(&booked).Error; err != nil {
+			return nil, err
+		}
+		bookedBySlot := make(map[string]int, len(booked))
+		for _, b := range booked {
+			bookedBySlot[b.TimeSlot] = b.Count
+		}
+
+		day := slotDay{Date: dateStr, Available: available, Remaining: available}
+		for minutes := open; minutes < closing; minutes += interval {
+			label := fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+			if remaining := perSlot - bookedBySlot[label]; remaining > 0 {
+				day.Slots = append(day.Slots, slotTime{Time: label, Minutes: minutes, Remaining: remaining})
+			}
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// dayCapacity is how many more visits a category can take on a day once the tickets
+// already issued are counted
+func (sos *SlotOptimizerService) dayCapacity(category string, serviceType *models.ServiceType, date time.Time) (int, error) {
+	if category == models.CategoryFood || category == models.CategoryGeneral {
+		var capacity models.VisitCapacity
+		err := sos.db.Where("date = ?", date).First(&capacity).Error
+		if err == nil {
+			if !capacity.IsOperatingDay {
+				return 0, nil
+			}
+			return capacity.GetAvailableCapacity(category), nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, err
+		}
+		if category == models.CategoryFood {
+			return 50, nil
+		}
+		return 20, nil
+	}
+
+	daily := 10
+	if serviceType != nil {
+		daily = serviceType.DailyCapacity
+	}
+	var issued int64
+	if err := sos.db.Model(&models.HelpRequest{}).
+		Where("LOWER(category) = ? AND visit_day = ? AND status IN ?", category, date.Format("2006-01-02"), fixedSlotStatuses).
+		Count(&issued).Error; err != nil {
+		return 0, err
+	}
+	return daily - int(issued), nil
+}
+
+// loadCandidates returns approved requests that prefer a day up to the end of the
+// window, or have no usable day, with the first day each visitor may come
+func (sos *SlotOptimizerService) loadCandidates(category string, serviceType *models.ServiceType, to string, now time.Time) ([]slotCandidate, error) {
+	var requests []models.HelpRequest
+	if err := sos.db.Where("LOWER(category) = ? AND status = ? AND (visit_day IS NULL OR visit_day = '' OR visit_day <= ?)",
+		category, models.HelpRequestStatusApproved, to).
+		Order("id").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+
+	intervalDays := 7
+	if serviceType != nil {
+		intervalDays = serviceType.VisitIntervalDays
+	}
+
+	candidates := make([]slotCandidate, 0, len(requests))
+	for _, request := range requests {
+		candidate := slotCandidate{
+			ID:            request.ID,
+			Reference:     request.Reference,
+			VisitorID:     request.VisitorID,
+			VisitorName:   request.VisitorName,
+			Priority:      request.Priority,
+			RequestDate:   request.RequestDate,
+			HouseholdSize: request.HouseholdSize,
+			PreferredDay:  request.VisitDay,
+			PreferredSlot: request.TimeSlot,
+			Score:         slotPriorityScore(request.Priority, request.RequestDate, request.HouseholdSize, now),
+		}
+
+		if intervalDays > 0 {
+			var lastVisit string
+			if err := sos.db.Model(&models.HelpRequest{}).
+				Select("COALESCE(MAX(visit_day), '')").
+				Where("visitor_id = ? AND LOWER(category) = ? AND status IN ? AND visit_day <> '' AND visit_day <= ?",
+					request.VisitorID, category, fixedSlotStatuses, to).
+				Scan(&lastVisit).Error; err != nil {
+				return nil, err
+			}
+			if last, err := time.Parse("2006-01-02", lastVisit); err == nil {
+				candidate.EarliestDay = last.AddDate(0, 0, intervalDays).Format("2006-01-02")
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// optimizeSlots places candidates in priority order, giving each the free slot closest
+// to their preferred day and time. A visitor gets at most one slot per plan, cannot be
+// booked before their minimum gap between visits has passed, and a large household
+// takes two places in a slot.
+func optimizeSlots(candidates []slotCandidate, days []slotDay, largeHousehold int) *SlotPlan {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.RequestDate.Equal(b.RequestDate) {
+			return a.RequestDate.Before(b.RequestDate)
+		}
+		return a.ID < b.ID
+	})
+
+	plan := &SlotPlan{Assignments: []SlotAssignment{}, Unassigned: []SlotUnassigned{}, Days: []SlotPlanDay{}}
+	planned := map[uint]bool{}
+	for _, candidate := range candidates {
+		if planned[candidate.VisitorID] {
+			plan.Unassigned = append(plan.Unassigned, SlotUnassigned{
+				RequestID: candidate.ID, Reference: candidate.Reference, PriorityScore: candidate.Score,
+				Reason: "visitor already has a slot in this plan",
+			})
+			continue
+		}
+
+		places := 1
+		if largeHousehold > 0 && candidate.HouseholdSize >= largeHousehold {
+			places = 2
+		}
+		preferredDay, dayErr := time.Parse("2006-01-02", candidate.PreferredDay)
+		preferredMinutes := -1
+		if t, err := time.Parse("15:04", candidate.PreferredSlot); err == nil {
+			preferredMinutes = t.Hour()*60 + t.Minute()
+		}
+
+		bestDay, bestSlot, bestCost := -1, -1, 0
+		blockedByGap := false
+		for d := range days {
+			day := &days[d]
+			if day.Remaining < 1 {
+				continue
+			}
+			if candidate.EarliestDay != "" && day.Date < candidate.EarliestDay {
+				blockedByGap = true
+				continue
+			}
+			dayCost := 0
+			if dayErr == nil {
+				date, _ := time.Parse("2006-01-02", day.Date)
+				diff := int(date.Sub(preferredDay).Hours() / 24)
+				if diff < 0 {
+					diff = -diff
+				}
+				dayCost = diff * slotDayPenalty
+			}
+			for s := range day.Slots {
+				slot := day.Slots[s]
+				if slot.Remaining < places {
+					continue
+				}
+				cost := dayCost
+				if preferredMinutes >= 0 {
+					gap := slot.Minutes - preferredMinutes
+					if gap < 0 {
+						gap = -gap
+					}
+					cost += gap
+				}
+				if bestDay < 0 || cost < bestCost {
+					bestDay, bestSlot, bestCost = d, s, cost
+				}
+			}
+		}
+
+		if bestDay < 0 {
+			reason := "no free slot in the planning window"
+			if blockedByGap {
+				reason = "no free slot after the minimum gap since the last visit"
+			}
+			plan.Unassigned = append(plan.Unassigned, SlotUnassigned{
+				RequestID: candidate.ID, Reference: candidate.Reference, PriorityScore: candidate.Score, Reason: reason,
+			})
+			continue
+		}
+
+		day := &days[bestDay]
+		slot := &day.Slots[bestSlot]
+		day.Remaining--
+		slot.Remaining -= places
+		planned[candidate.VisitorID] = true
+
+		assignment := SlotAssignment{
+			RequestID:     candidate.ID,
+			Reference:     candidate.Reference,
+			VisitorID:     candidate.VisitorID,
+			VisitorName:   candidate.VisitorName,
+			Priority:      candidate.Priority,
+			PriorityScore: candidate.Score,
+			HouseholdSize: candidate.HouseholdSize,
+			PreferredDay:  candidate.PreferredDay,
+			PreferredSlot: candidate.PreferredSlot,
+			VisitDay:      day.Date,
+			TimeSlot:      slot.Time,
+		}
+		assignment.Moved = assignment.VisitDay != candidate.PreferredDay || assignment.TimeSlot != candidate.PreferredSlot
+		if !assignment.Moved {
+			plan.PreferredKept++
+		}
+		plan.Assignments = append(plan.Assignments, assignment)
+	}
+
+	for _, day := range days {
+		plan.Days = append(plan.Days, SlotPlanDay{Date: day.Date, Available: day.Available, Assigned: day.Available - day.Remaining})
+	}
+	plan.PlanID = slotPlanID(plan.Assignments)
+	return plan
+}
+
+// slotPlanID fingerprints a plan's assignments
+func slotPlanID(assignments []SlotAssignment) string {
+	sorted := append([]SlotAssignment(nil), assignments...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].RequestID < sorted[j].RequestID })
+
+	hash := sha256.New()
+	for _, a := range sorted {
+		fmt.Fprintf(hash, "%d:%s:%s;", a.RequestID, a.VisitDay, a.TimeSlot)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// notifyMoved tells a visitor their visit is on a different day or time than they asked
+func (sos *SlotOptimizerService) notifyMoved(assignment SlotAssignment) {
+	day := assignment.VisitDay
+	if date, err := time.Parse("2006-01-02", day); err == nil {
+		day = date.Format("Monday 2 January")
+	}
+	if err := GetGlobalRealtimeNotificationService().SendNotification(RealtimeNotificationData{
+		UserID:   assignment.VisitorID,
+		Type:     "help_request_rescheduled",
+		Title:    "Your Visit Time",
+		Message:  fmt.Sprintf("Your visit for request %s is booked for %s at %s. Please contact us if you can't make it.", assignment.Reference, day, assignment.TimeSlot),
+		Priority: "medium",
+		Category: "help_requests",
+		Channels: []string{"websocket", "push"},
+		Data: map[string]interface{}{
+			"help_request_id": assignment.RequestID,
+			"visit_day":       assignment.VisitDay,
+			"time_slot":       assignment.TimeSlot,
+		},
+	}); err != nil {
+		log.Printf("Failed to notify visitor %d of their visit slot: %v", assignment.VisitorID, err)
+	}
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestSlotPriorityScore(t *testing.T) {
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	cases := []struct {
+		priority  string
+		requested time.Time
+		household int
+		want      int
+	}{
+		{models.PriorityNormal, now, 1, 30},
+		{models.PriorityUrgent, now, 1, 100},
+		{models.PriorityLow, now.AddDate(0, 0, -3), 2, 17},
+		{models.PriorityHigh, now.AddDate(0, 0, -40), 10, 93}, // Waiting and household are capped
+	}
+	for _, tc := range cases {
+		if got := slotPriorityScore(tc.priority, tc.requested, tc.household, now); got != tc.want {
+			t.Errorf("slotPriorityScore(%s, %v, %d) = %d, want %d", tc.priority, tc.requested, tc.household, got, tc.want)
+		}
+	}
+}
+
+func testSlotDays() []slotDay {
+	return []slotDay{
+		{Date: "2026-03-10", Available: 2, Remaining: 2, Slots: []slotTime{
+			{Time: "10:30", Minutes: 630, Remaining: 2}, {Time: "10:40", Minutes: 640, Remaining: 1},
+		}},
+		{Date: "2026-03-11", Available: 3, Remaining: 3, Slots: []slotTime{
+			{Time: "10:30", Minutes: 630, Remaining: 2}, {Time: "10:40", Minutes: 640, Remaining: 2},
+		}},
+	}
+}
+
+func TestOptimizeSlots(t *testing.T) {
+	candidates := []slotCandidate{
+		{ID: 1, VisitorID: 11, HouseholdSize: 1, PreferredDay: "2026-03-10", PreferredSlot: "10:30", Score: 30},
+		{ID: 2, VisitorID: 12, HouseholdSize: 1, PreferredDay: "2026-03-10", PreferredSlot: "10:30", Score: 100},
+		{ID: 3, VisitorID: 13, HouseholdSize: 1, PreferredDay: "2026-03-10", PreferredSlot: "10:30", Score: 10},
+		{ID: 4, VisitorID: 12, HouseholdSize: 1, PreferredDay: "2026-03-11", PreferredSlot: "10:40", Score: 40},
+		{ID: 5, VisitorID: 15, HouseholdSize: 6, PreferredDay: "2026-03-11", PreferredSlot: "10:40", Score: 35},
+		{ID: 6, VisitorID: 16, HouseholdSize: 1, PreferredDay: "2026-03-10", EarliestDay: "2026-03-12", Score: 50},
+	}
+
+	plan := optimizeSlots(candidates, testSlotDays(), 5)
+
+	got := map[uint]string{}
+	for _, a := range plan.Assignments {
+		got[a.RequestID] = a.VisitDay + " " + a.TimeSlot
+	}
+	want := map[uint]string{
+		2: "2026-03-10 10:30", // Highest priority keeps their preference
+		5: "2026-03-11 10:40", // Large household takes both places
+		1: "2026-03-10 10:30",
+		3: "2026-03-11 10:30", // The preferred day is full
+	}
+	if len(got) != len(want) {
+		t.Fatalf("assignments = %v, want %v", got, want)
+	}
+	for id, slot := range want {
+		if got[id] != slot {
+			t.Errorf("request %d assigned %q, want %q", id, got[id], slot)
+		}
+	}
+
+	reasons := map[uint]string{}
+	for _, u := range plan.Unassigned {
+		reasons[u.RequestID] = u.Reason
+	}
+	if reasons[4] != "visitor already has a slot in this plan" {
+		t.Errorf("request 4 reason = %q", reasons[4])
+	}
+	if reasons[6] != "no free slot after the minimum gap since the last visit" {
+		t.Errorf("request 6 reason = %q", reasons[6])
+	}
+	if plan.PreferredKept != 3 {
+		t.Errorf("PreferredKept = %d, want 3", plan.PreferredKept)
+	}
+	if plan.Days[0].Assigned != 2 || plan.Days[1].Assigned != 2 {
+		t.Errorf("days = %+v", plan.Days)
+	}
+
+	again := optimizeSlots(candidates, testSlotDays(), 5)
+	if again.PlanID != plan.PlanID {
+		t.Errorf("PlanID changed between identical runs: %s, %s", plan.PlanID, again.PlanID)
+	}
+}
@@ -0,0 +1,534 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultStandbyCutoff      = "12:00"
+	defaultStandbyClaimWindow = 15 * time.Minute
+	defaultStandbyGrace       = 30 * time.Minute
+)
+
+var (
+	ErrStandbyNotOffered   = errors.New("there is no open offer for this standby entry")
+	ErrStandbyOfferExpired = errors.New("this offer has expired")
+)
+
+// StandbyService releases unused same-day capacity and offers it to visitors on the
+// standby list and to waitlisted help requests
+type StandbyService struct {
+	db          *gorm.DB
+	cutoff      string
+	claimWindow time.Duration
+	grace       time.Duration
+}
+
+// StandbyConversion summarises how many standby offers were claimed for a service
+type StandbyConversion struct {
+	Category       string  `json:"category"`
+	Offered        int64   `json:"offered"`
+	Claimed        int64   `json:"claimed"`
+	Expired        int64   `json:"expired"`
+	Declined       int64   `json:"declined"`
+	Outstanding    int64   `json:"outstanding"`
+	ConversionRate float64 `json:"conversion_rate"` // Claimed as a percentage of offers
+}
+
+// NewStandbyService creates a new standby service. The release cutoff (HH:MM), claim
+// window and no-show grace period can be set with STANDBY_RELEASE_CUTOFF,
+// STANDBY_CLAIM_WINDOW_MINUTES and STANDBY_RELEASE_GRACE_MINUTES.
+func NewStandbyService() *StandbyService {
+	ss := &StandbyService{
+		db:          db.DB,
+		cutoff:      defaultStandbyCutoff,
+		claimWindow: defaultStandbyClaimWindow,
+		grace:       defaultStandbyGrace,
+	}
+	if val := os.Getenv("STANDBY_RELEASE_CUTOFF"); val != "" {
+		if _, err := time.Parse("15:04", val); err == nil {
+			ss.cutoff = val
+		}
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("STANDBY_CLAIM_WINDOW_MINUTES")); err == nil && minutes > 0 {
+		ss.claimWindow = time.Duration(minutes) * time.Minute
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("STANDBY_RELEASE_GRACE_MINUTES")); err == nil && minutes >= 0 {
+		ss.grace = time.Duration(minutes) * time.Minute
+	}
+	return ss
+}
+
+// PastCutoff returns true once the release cutoff has passed for the day
+func (ss *StandbyService) PastCutoff(now time.Time) bool {
+	return now.Format("15:04") >= ss.cutoff
+}
+
+// RunRelease releases today's unused bookings once the cutoff has passed, moves pending
+// requests onto the standby list and offers the free capacity in priority order.
+// A log entry is recorded for every service where something changed.
+func (ss *StandbyService) RunRelease(now time.Time) ([]models.CapacityRelease, error) {
+	releases := []models.CapacityRelease{}
+	if !ss.PastCutoff(now) {
+		return releases, nil
+	}
+
+	today := now.Format("2006-01-02")
+	expired, err := ss.ExpireOffers(now)
+	if err != nil {
+		return nil, err
+	}
+
+	var serviceTypes []models.ServiceType
+	if err := ss.db.Where("is_active = ?", true).Order("sort_order ASC").Find(&serviceTypes).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range serviceTypes {
+		serviceType := &serviceTypes[i]
+		if !serviceType.IsOperatingDay(now.Weekday()) {
+			continue
+		}
+
+		release := models.CapacityRelease{
+			VisitDay:      today,
+			Category:      serviceType.Code,
+			OffersExpired: expired[serviceType.Code],
+		}
+
+		if release.ReleasedSlots, err = ss.releaseUnusedBookings(serviceType.Code, today, now); err != nil {
+			return nil, err
+		}
+		if release.WaitlistAdded, err = ss.enqueueWaitlist(serviceType.Code, today); err != nil {
+			return nil, err
+		}
+
+		offered, available, err := ss.offerCapacity(serviceType, today, now)
+		if err != nil {
+			return nil, err
+		}
+		release.OffersSent = offered
+		release.AvailableSlots = available
+
+		if release.ReleasedSlots == 0 && release.WaitlistAdded == 0 && release.OffersSent == 0 && release.OffersExpired == 0 {
+			continue
+		}
+		if err := ss.db.Create(&release).Error; err != nil {
+			log.Printf("Failed to record capacity release for %s: %v", serviceType.Code, err)
+		}
+		releases = append(releases, release)
+	}
+
+	return releases, nil
+}
+
+// ExpireOffers returns offers that were not claimed in time to the expired state,
+// counted by service
+func (ss *StandbyService) ExpireOffers(now time.Time) (map[string]int, error) {
+	var entries []models.StandbyEntry
+	if err := ss.db.Where("status = ? AND offer_expires_at <= ?", models.StandbyStatusOffered, now).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	expired := map[string]int{}
+	for _, entry := range entries {
+		if err := ss.db.Model(&entry).Update("status", models.StandbyStatusExpired).Error; err != nil {
+			return nil, err
+		}
+		expired[entry.Category]++
+	}
+	return expired, nil
+}
+
+// ClaimOffer issues a same-day ticket to the visitor holding an open offer.
+// Pass visitorID 0 when staff claim on the visitor's behalf at the front desk.
+func (ss *StandbyService) ClaimOffer(entryID, visitorID, claimedBy uint) (*models.StandbyEntry, *models.Ticket, error) {
+	var entry models.StandbyEntry
+	var ticket *models.Ticket
+	var helpRequest *models.HelpRequest
+	now := time.Now()
+
+	err := ss.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		if visitorID != 0 {
+			query = query.Where("visitor_id = ?", visitorID)
+		}
+		if err := query.First(&entry, entryID).Error; err != nil {
+			return err
+		}
+		if entry.Status != models.StandbyStatusOffered {
+			return ErrStandbyNotOffered
+		}
+		if entry.OfferExpiresAt != nil && entry.OfferExpiresAt.Before(now) {
+			return ErrStandbyOfferExpired
+		}
+
+		var visitor models.User
+		if err := tx.First(&visitor, entry.VisitorID).Error; err != nil {
+			return err
+		}
+
+		serviceType, err := NewServiceTypeService().GetByCode(entry.Category)
+		if err != nil {
+			serviceType = nil
+		}
+
+		if entry.HelpRequestID != nil {
+			helpRequest, ticket, err = ss.issueWaitlistTicket(tx, *entry.HelpRequestID, serviceType, claimedBy, now)
+		} else {
+			walkIns := &WalkInService{db: tx}
+			helpRequest, ticket, err = walkIns.issueSameDayTicket(tx, &visitor, WalkInInput{Notes: entry.Notes},
+				serviceType, entry.Category, claimedBy, now)
+		}
+		if err != nil {
+			return err
+		}
+
+		entry.Status = models.StandbyStatusClaimed
+		entry.ClaimedAt = &now
+		entry.HelpRequestID = &helpRequest.ID
+		return tx.Save(&entry).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var visitor models.User
+	if err := ss.db.First(&visitor, entry.VisitorID).Error; err == nil && visitor.Phone != "" {
+		if err := NewPhoneAuthService().SendTicketSMS(visitor, *helpRequest); err != nil {
+			log.Printf("Failed to send standby ticket SMS to visitor %d: %v", visitor.ID, err)
+		}
+	}
+
+	return &entry, ticket, nil
+}
+
+// DeclineOffer gives up an open offer and passes the place to the next visitor in line
+func (ss *StandbyService) DeclineOffer(entryID, visitorID uint) (*models.StandbyEntry, error) {
+	var entry models.StandbyEntry
+	if err := ss.db.Where("visitor_id = ?", visitorID).First(&entry, entryID).Error; err != nil {
+		return nil, err
+	}
+	if entry.Status != models.StandbyStatusOffered {
+		return nil, ErrStandbyNotOffered
+	}
+	if err := ss.db.Model(&entry).Update("status", models.StandbyStatusCancelled).Error; err != nil {
+		return nil, err
+	}
+
+	if serviceType, err := NewServiceTypeService().GetByCode(entry.Category); err == nil {
+		if _, _, err := ss.offerCapacity(serviceType, entry.VisitDay, time.Now()); err != nil {
+			log.Printf("Failed to pass declined standby place to the next visitor: %v", err)
+		}
+	}
+
+	return &entry, nil
+}
+
+// ConversionStats counts offers and claims for a day by service
+func (ss *StandbyService) ConversionStats(visitDay string) ([]StandbyConversion, error) {
+	var rows []struct {
+		Category string
+		Status   string
+		Count    int64
+	}
+	if err := ss.db.Model(&models.StandbyEntry{}).
+		Select("category, status, COUNT(*) AS count").
+		Where("visit_day = ? AND offered_at IS NOT NULL", visitDay).
+		Group("category, status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	byCategory := map[string]*StandbyConversion{}
+	categories := []string{}
+	for _, row := range rows {
+		stats, ok := byCategory[row.Category]
+		if !ok {
+			stats = &StandbyConversion{Category: row.Category}
+			byCategory[row.Category] = stats
+			categories = append(categories, row.Category)
+		}
+		stats.Offered += row.Count
+		switch row.Status {
+		case models.StandbyStatusClaimed:
+			stats.Claimed += row.Count
+		case models.StandbyStatusExpired:
+			stats.Expired += row.Count
+		case models.StandbyStatusCancelled:
+			stats.Declined += row.Count
+		case models.StandbyStatusOffered:
+			stats.Outstanding += row.Count
+		}
+	}
+
+	conversions := make([]StandbyConversion, 0, len(categories))
+	for _, category := range categories {
+		stats := byCategory[category]
+		if stats.Offered > 0 {
+			stats.ConversionRate = float64(stats.Claimed) / float64(stats.Offered) * 100
+		}
+		conversions = append(conversions, *stats)
+	}
+	return conversions, nil
+}
+
+// releaseUnusedBookings cancels today's bookings whose slot started more than the grace
+// period ago without the visitor checking in, freeing the capacity they held. Slots
+// that cannot be read as a time of day are never released.
+func (ss *StandbyService) releaseUnusedBookings(category, visitDay string, now time.Time) (int, error) {
+	cutoff := now.Add(-ss.grace)
+
+	var candidates []models.HelpRequest
+	if err := ss.db.Where("LOWER(category) = ? AND visit_day = ? AND status IN ? AND time_slot <> ''",
+		category, visitDay, []string{models.HelpRequestStatusApproved, models.HelpRequestStatusTicketIssued}).
+		Find(&candidates).Error; err != nil {
+		return 0, err
+	}
+
+	var requests []models.HelpRequest
+	for _, request := range candidates {
+		start, ok := parseSlotStart(request.TimeSlot, cutoff)
+		if ok && start.Before(cutoff) {
+			requests = append(requests, request)
+		}
+	}
+
+	released := 0
+	for _, request := range requests {
+		err := ss.db.Transaction(func(tx *gorm.DB) error {
+			var used int64
+			tx.Model(&models.Ticket{}).
+				Where("help_request_id = ? AND status = ?", request.ID, models.TicketStatusUsed).
+				Count(&used)
+			if used > 0 {
+				return nil
+			}
+
+			if err := tx.Model(&models.Ticket{}).
+				Where("help_request_id = ? AND status = ?", request.ID, models.TicketStatusActive).
+				Update("status", models.TicketStatusExpired).Error; err != nil {
+				return err
+			}
+			notes := strings.TrimSpace(request.Notes + fmt.Sprintf("\nReleased to standby at %s: slot %s not used", now.Format("15:04"), request.TimeSlot))
+			if err := tx.Model(&request).Updates(map[string]interface{}{
+				"status": models.HelpRequestStatusCancelled,
+				"notes":  notes,
+			}).Error; err != nil {
+				return err
+			}
+			released++
+			return nil
+		})
+		if err != nil {
+			return released, err
+		}
+	}
+	return released, nil
+}
+
+// enqueueWaitlist adds today's pending help requests to the standby list so they can be
+// offered released capacity alongside walk-ins
+func (ss *StandbyService) enqueueWaitlist(category, visitDay string) (int, error) {
+	var requests []models.HelpRequest
+	if err := ss.db.Where("LOWER(category) = ? AND visit_day = ? AND status = ?",
+		category, visitDay, models.HelpRequestStatusPending).
+		Where("id NOT IN (?)", ss.db.Model(&models.StandbyEntry{}).
+			Select("help_request_id").Where("help_request_id IS NOT NULL")).
+		Order("created_at ASC").
+		Find(&requests).Error; err != nil {
+		return 0, err
+	}
+
+	for _, request := range requests {
+		requestID := request.ID
+		entry := models.StandbyEntry{
+			VisitorID:     request.VisitorID,
+			HelpRequestID: &requestID,
+			Category:      category,
+			VisitDay:      visitDay,
+			Priority:      models.StandbyPriorityFor(request.Priority),
+			Source:        models.StandbySourceWaitlist,
+			Status:        models.StandbyStatusWaiting,
+		}
+		if err := ss.db.Create(&entry).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(requests), nil
+}
+
+// offerCapacity offers each free place, less any offers still open, to the next
+// waiting visitors in priority order. It returns the offers sent and the places left.
+// Runs for the same service and day are serialised so the release job and the claim
+// and decline handlers cannot offer the same place twice.
+func (ss *StandbyService) offerCapacity(serviceType *models.ServiceType, visitDay string, now time.Time) (int, int, error) {
+	var entries []models.StandbyEntry
+	available := 0
+	expiresAt := now.Add(ss.claimWindow)
+
+	err := ss.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "standby:"+serviceType.Code+":"+visitDay).Error; err != nil {
+			return err
+		}
+
+		var outstanding int64
+		if err := tx.Model(&models.StandbyEntry{}).
+			Where("visit_day = ? AND category = ? AND status = ?", visitDay, serviceType.Code, models.StandbyStatusOffered).
+			Count(&outstanding).Error; err != nil {
+			return err
+		}
+
+		available = NewServiceTypeService().RemainingCapacity(serviceType, visitDay) - int(outstanding)
+		if available <= 0 {
+			available = 0
+			return nil
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("visit_day = ? AND category = ? AND status = ?", visitDay, serviceType.Code, models.StandbyStatusWaiting).
+			Order("priority DESC, created_at ASC").
+			Limit(available).
+			Find(&entries).Error; err != nil {
+			return err
+		}
+
+		for i := range entries {
+			if err := tx.Model(&entries[i]).Updates(map[string]interface{}{
+				"status":           models.StandbyStatusOffered,
+				"offered_at":       now,
+				"offer_expires_at": expiresAt,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, available, err
+	}
+
+	// Notify once the offers are committed
+	for _, entry := range entries {
+		ss.notifyOffer(serviceType, entry, expiresAt)
+	}
+	return len(entries), available - len(entries), nil
+}
+
+// issueWaitlistTicket issues a same-day ticket against a waitlisted help request
+func (ss *StandbyService) issueWaitlistTicket(tx *gorm.DB, helpRequestID uint, serviceType *models.ServiceType, staffID uint, now time.Time) (*models.HelpRequest, *models.Ticket, error) {
+	var helpRequest models.HelpRequest
+	if err := tx.First(&helpRequest, helpRequestID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	ticketNumber := ""
+	if serviceType != nil {
+		ticketNumber = NewServiceTypeService().GenerateTicketNumber(serviceType)
+	} else {
+		suffix, err := randomHex(4)
+		if err != nil {
+			return nil, nil, err
+		}
+		ticketNumber = "TKT-" + strings.ToUpper(suffix)
+	}
+	endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
+
+	helpRequest.Status = models.HelpRequestStatusTicketIssued
+	helpRequest.TicketNumber = ticketNumber
+	helpRequest.QRCode = "QR_" + ticketNumber
+	helpRequest.TimeSlot = now.Format("15:04")
+	helpRequest.ApprovedAt = &now
+	if staffID != 0 {
+		helpRequest.ApprovedBy = &staffID
+	}
+	if err := tx.Save(&helpRequest).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to update help request: %w", err)
+	}
+
+	ticket := models.Ticket{
+		TicketNumber:  ticketNumber,
+		HelpRequestID: helpRequest.ID,
+		VisitorID:     helpRequest.VisitorID,
+		VisitorName:   helpRequest.VisitorName,
+		Category:      helpRequest.Category,
+		VisitDate:     now,
+		TimeSlot:      helpRequest.TimeSlot,
+		QRCode:        helpRequest.QRCode,
+		Status:        models.TicketStatusActive,
+		IssuedAt:      now,
+		ValidUntil:    endOfDay,
+		ExpiresAt:     endOfDay,
+	}
+	if err := tx.Create(&ticket).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to create ticket: %w", err)
+	}
+
+	return &helpRequest, &ticket, nil
+}
+
+// notifyOffer tells a visitor a place is available by push and SMS
+func (ss *StandbyService) notifyOffer(serviceType *models.ServiceType, entry models.StandbyEntry, expiresAt time.Time) {
+	message := fmt.Sprintf("A place for %s has become available today. Claim it by %s or it will be offered to the next person.",
+		serviceType.Name, expiresAt.Format("15:04"))
+
+	GetGlobalRealtimeNotificationService().SendNotification(RealtimeNotificationData{
+		UserID:    entry.VisitorID,
+		Type:      "standby_offer",
+		Title:     "A Place Is Available Today",
+		Message:   message,
+		Priority:  "high",
+		Category:  "standby",
+		ActionURL: "/visitor/standby",
+		Channels:  []string{"websocket", "push"},
+		Data: map[string]interface{}{
+			"standby_id": entry.ID,
+			"expires_at": expiresAt,
+		},
+	})
+
+	var visitor models.User
+	if err := ss.db.First(&visitor, entry.VisitorID).Error; err != nil || visitor.Phone == "" {
+		return
+	}
+	baseURL := os.Getenv("FRONTEND_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+	if err := sendUrgentSMS(visitor.Phone, fmt.Sprintf("Lewisham Charity: %s Claim here: %s/visitor/standby", message, baseURL)); err != nil {
+		log.Printf("Failed to send standby offer SMS to visitor %d: %v", visitor.ID, err)
+	}
+}
+
+// slotLayouts are the time formats accepted at the start of a booking's time slot
+var slotLayouts = []string{"15:04", "15.04", "3:04pm", "3.04pm", "3pm"}
+
+// parseSlotStart reads the start time of a slot such as "09:30", "9:30-10:00" or
+// "10am - 11am" as a time on the same day as day
+func parseSlotStart(slot string, day time.Time) (time.Time, bool) {
+	start := strings.ToLower(strings.TrimSpace(slot))
+	if i := strings.IndexAny(start, "-–"); i >= 0 {
+		start = strings.TrimSpace(start[:i])
+	}
+	start = strings.ReplaceAll(start, " ", "")
+
+	for _, layout := range slotLayouts {
+		t, err := time.Parse(layout, start)
+		if err == nil {
+			return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSlotStart(t *testing.T) {
+	day := time.Date(2026, 5, 4, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		slot   string
+		hour   int
+		minute int
+		ok     bool
+	}{
+		{"09:30", 9, 30, true},
+		{"9:30", 9, 30, true},
+		{"9:30-10:00", 9, 30, true},
+		{"13:00 - 14:00", 13, 0, true},
+		{"10am - 11am", 10, 0, true},
+		{"2:15 PM", 14, 15, true},
+		{"morning", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSlotStart(tt.slot, day)
+		if ok != tt.ok {
+			t.Errorf("%q: ok = %v, want %v", tt.slot, ok, tt.ok)
+			continue
+		}
+		if ok && (got.Hour() != tt.hour || got.Minute() != tt.minute || got.Day() != day.Day()) {
+			t.Errorf("%q: got %s", tt.slot, got)
+		}
+	}
+}
+
+func TestParseSlotStartComparesAsTimes(t *testing.T) {
+	day := time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC)
+	nine, _ := parseSlotStart("9:00", day)
+	ten, _ := parseSlotStart("10:00", day)
+	// As strings "9:00" sorts after "10:00"
+	if !nine.Before(ten) {
+		t.Fatal("9:00 should be before 10:00")
+	}
+}
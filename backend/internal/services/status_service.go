@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+)
+
+// Status page settings
+const (
+	statusCacheTTL              = 30 * time.Second
+	statusCheckTimeout          = 2 * time.Second
+	notificationFailureWindow   = time.Hour
+	notificationFailureMinCount = 5
+	notificationFailureRate     = 0.5
+)
+
+// componentRank orders component health from best to worst
+var componentRank = map[string]int{
+	models.ComponentOperational: 0,
+	models.ComponentDegraded:    1,
+	models.ComponentPartial:     2,
+	models.ComponentMajor:       3,
+}
+
+// statusComponentNames are the public names of the status page components
+var statusComponentNames = []struct {
+	ID   string
+	Name string
+}{
+	{models.StatusComponentAPI, "API"},
+	{models.StatusComponentBookings, "Bookings"},
+	{models.StatusComponentNotifications, "Notifications"},
+}
+
+// ServiceStatus is the public status page summary
+type ServiceStatus struct {
+	Status     string            `json:"status"`
+	Message    string            `json:"message"`
+	Components []ComponentStatus `json:"components"`
+	Incidents  []PublicIncident  `json:"incidents"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// ComponentStatus is the health of one component
+type ComponentStatus struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// PublicIncident is the part of an incident shown publicly
+type PublicIncident struct {
+	ID         uint      `json:"id"`
+	Title      string    `json:"title"`
+	Message    string    `json:"message"`
+	Severity   string    `json:"severity"`
+	Status     string    `json:"status"`
+	Components []string  `json:"components"`
+	StartedAt  time.Time `json:"started_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// StatusService works out component health for the public status page
+type StatusService struct {
+	db *gorm.DB
+}
+
+var (
+	statusCacheMu sync.Mutex
+	statusCache   *ServiceStatus
+)
+
+// NewStatusService creates a new status service
+func NewStatusService() *StatusService {
+	return &StatusService{
+		db: db.DB,
+	}
+}
+
+// CurrentStatus returns the status summary, reusing a recent result so the public
+// endpoint cannot be used to load the database
+func (ss *StatusService) CurrentStatus() ServiceStatus {
+	statusCacheMu.Lock()
+	defer statusCacheMu.Unlock()
+
+	if statusCache != nil && time.Since(statusCache.UpdatedAt) < statusCacheTTL {
+		return *statusCache
+	}
+
+	status := ss.buildStatus(time.Now())
+	statusCache = &status
+	return status
+}
+
+// InvalidateStatus clears the cached summary, e.g. after an incident changes
+func InvalidateStatus() {
+	statusCacheMu.Lock()
+	statusCache = nil
+	statusCacheMu.Unlock()
+}
+
+// buildStatus checks every component and applies any open incidents
+func (ss *StatusService) buildStatus(now time.Time) ServiceStatus {
+	health := map[string]string{
+		models.StatusComponentAPI:           models.ComponentOperational,
+		models.StatusComponentBookings:      models.ComponentOperational,
+		models.StatusComponentNotifications: models.ComponentOperational,
+	}
+
+	databaseUp := ss.databaseReachable()
+	if !databaseUp {
+		health[models.StatusComponentAPI] = models.ComponentPartial
+		health[models.StatusComponentBookings] = models.ComponentMajor
+		health[models.StatusComponentNotifications] = models.ComponentPartial
+	} else {
+		health[models.StatusComponentNotifications] = ss.notificationHealth(now)
+	}
+
+	incidents := []PublicIncident{}
+	if databaseUp {
+		var open []models.StatusIncident
+		ss.db.Where("status <> ?", models.IncidentStatusResolved).Order("started_at DESC").Find(&open)
+		incidents = applyStatusIncidents(health, open)
+	}
+
+	status := ServiceStatus{
+		Status:     models.ComponentOperational,
+		Components: make([]ComponentStatus, 0, len(statusComponentNames)),
+		Incidents:  incidents,
+		UpdatedAt:  now,
+	}
+	for _, component := range statusComponentNames {
+		status.Components = append(status.Components, ComponentStatus{
+			ID:     component.ID,
+			Name:   component.Name,
+			Status: health[component.ID],
+		})
+		status.Status = worseComponent(status.Status, health[component.ID])
+	}
+	status.Message = statusMessage(status.Status)
+
+	return status
+}
+
+// applyStatusIncidents worsens the health of each component affected by an open
+// incident and returns the incidents as shown publicly
+func applyStatusIncidents(health map[string]string, open []models.StatusIncident) []PublicIncident {
+	incidents := make([]PublicIncident, 0, len(open))
+	for i := range open {
+		incident := &open[i]
+		components := incident.AffectedComponents()
+		for _, component := range components {
+			if current, ok := health[component]; ok {
+				health[component] = worseComponent(current, incident.ComponentImpact())
+			}
+		}
+		if components == nil {
+			components = []string{}
+		}
+		incidents = append(incidents, PublicIncident{
+			ID:         incident.ID,
+			Title:      incident.Title,
+			Message:    incident.Message,
+			Severity:   incident.Severity,
+			Status:     incident.Status,
+			Components: components,
+			StartedAt:  incident.StartedAt,
+			UpdatedAt:  incident.UpdatedAt,
+		})
+	}
+	return incidents
+}
+
+// databaseReachable pings the database with a short timeout
+func (ss *StatusService) databaseReachable() bool {
+	if ss.db == nil {
+		return false
+	}
+	sqlDB, err := ss.db.DB()
+	if err != nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), statusCheckTimeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx) == nil
+}
+
+// notificationHealth looks at recent send failures and the SMS budget
+func (ss *StatusService) notificationHealth(now time.Time) string {
+	var total, failed int64
+	since := now.Add(-notificationFailureWindow)
+	ss.db.Model(&models.NotificationCost{}).Where("created_at >= ? AND status <> ?", since, models.NotificationCostBlocked).Count(&total)
+	ss.db.Model(&models.NotificationCost{}).Where("created_at >= ? AND status = ?", since, models.NotificationCostFailed).Count(&failed)
+
+	if total >= notificationFailureMinCount && float64(failed)/float64(total) >= notificationFailureRate {
+		if failed == total {
+			return models.ComponentPartial
+		}
+		return models.ComponentDegraded
+	}
+
+	// Non-urgent SMS are being sent by email instead
+	if notifications.GetSMSBudgetStatus(now).Blocked {
+		return models.ComponentDegraded
+	}
+
+	return models.ComponentOperational
+}
+
+// worseComponent returns whichever component health is worse
+func worseComponent(a, b string) string {
+	if componentRank[b] > componentRank[a] {
+		return b
+	}
+	return a
+}
+
+// statusMessage returns the headline shown for the overall status
+func statusMessage(status string) string {
+	switch status {
+	case models.ComponentMajor:
+		return "Major service outage"
+	case models.ComponentPartial:
+		return "Partial service outage"
+	case models.ComponentDegraded:
+		return "Some services are running slower than usual"
+	default:
+		return "All systems operational"
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestApplyStatusIncidents(t *testing.T) {
+	health := map[string]string{
+		models.StatusComponentAPI:           models.ComponentOperational,
+		models.StatusComponentBookings:      models.ComponentOperational,
+		models.StatusComponentNotifications: models.ComponentDegraded,
+	}
+	open := []models.StatusIncident{
+		{ID: 1, Title: "Slow bookings", Severity: models.IncidentSeverityMinor, Components: "bookings, api"},
+		{ID: 2, Title: "Bookings down", Severity: models.IncidentSeverityCritical, Components: "bookings"},
+		{ID: 3, Title: "Texts delayed", Severity: models.IncidentSeverityMinor, Components: "notifications,printing"},
+		{ID: 4, Title: "General notice", Severity: models.IncidentSeverityMajor},
+	}
+
+	incidents := applyStatusIncidents(health, open)
+	want := map[string]string{
+		models.StatusComponentAPI:           models.ComponentDegraded,
+		models.StatusComponentBookings:      models.ComponentMajor, // The worst incident wins
+		models.StatusComponentNotifications: models.ComponentDegraded,
+	}
+	for component, status := range want {
+		if health[component] != status {
+			t.Errorf("%s: got %s, want %s", component, health[component], status)
+		}
+	}
+	if _, added := health["printing"]; added {
+		t.Error("unknown component added to the status page")
+	}
+	if len(incidents) != 4 || len(incidents[0].Components) != 2 || incidents[3].Components == nil {
+		t.Errorf("got %+v", incidents)
+	}
+}
+
+func TestBuildStatusWithoutDatabase(t *testing.T) {
+	now := time.Date(2026, 5, 5, 9, 0, 0, 0, time.UTC)
+	status := (&StatusService{}).buildStatus(now)
+
+	if status.Status != models.ComponentMajor || status.Message != "Major service outage" {
+		t.Errorf("overall: got %s, %q", status.Status, status.Message)
+	}
+	if len(status.Components) != 3 || status.Components[1].ID != models.StatusComponentBookings ||
+		status.Components[1].Status != models.ComponentMajor {
+		t.Errorf("components: got %+v", status.Components)
+	}
+	if status.Incidents == nil || !status.UpdatedAt.Equal(now) {
+		t.Errorf("got %+v", status)
+	}
+}
+
+func TestWorseComponent(t *testing.T) {
+	tests := []struct{ a, b, want string }{
+		{models.ComponentOperational, models.ComponentDegraded, models.ComponentDegraded},
+		{models.ComponentMajor, models.ComponentPartial, models.ComponentMajor},
+		{models.ComponentPartial, models.ComponentPartial, models.ComponentPartial},
+	}
+	for _, tt := range tests {
+		if got := worseComponent(tt.a, tt.b); got != tt.want {
+			t.Errorf("worseComponent(%s, %s) = %s, want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
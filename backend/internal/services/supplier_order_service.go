@@ -0,0 +1,544 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/httpclient"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+)
+
+// supplierWebhookTimeout bounds each order posted to a supplier endpoint
+const supplierWebhookTimeout = 15 * time.Second
+
+// SupplierSignatureHeader carries the HMAC of webhook bodies in both directions
+const SupplierSignatureHeader = "X-Supplier-Signature"
+
+var (
+	ErrSupplierInactive            = errors.New("supplier is not active")
+	ErrSupplierNoWebhook           = errors.New("supplier has no webhook URL")
+	ErrSupplierNoEmail             = errors.New("supplier has no contact email")
+	ErrSupplierOrderNotSubmittable = errors.New("only draft or failed orders can be submitted")
+	ErrSupplierOrderClosed         = errors.New("order is no longer open")
+	ErrSupplierOrderNoLines        = errors.New("order has no lines")
+	ErrSupplierInvalidTransition   = errors.New("order cannot move to that status")
+	ErrSupplierBadSignature        = errors.New("invalid supplier signature")
+)
+
+// SupplierOrderService raises orders with suppliers when inventory runs low, sends them
+// and reconciles what is delivered against what was ordered
+type SupplierOrderService struct {
+	db     *gorm.DB
+	client *http.Client
+}
+
+// SupplierStatusUpdate is a status change reported by a supplier or entered by staff
+type SupplierStatusUpdate struct {
+	Status      string     `json:"status"`
+	ExternalRef string     `json:"external_ref"`
+	ExpectedAt  *time.Time `json:"expected_at"`
+	Notes       string     `json:"notes"`
+}
+
+// DeliveryLineResult compares what arrived for one line with what was ordered
+type DeliveryLineResult struct {
+	LineID     uint   `json:"line_id"`
+	ItemName   string `json:"item_name"`
+	Ordered    int    `json:"ordered"`
+	Received   int    `json:"received"`  // Total received so far
+	Delivered  int    `json:"delivered"` // Received in this delivery
+	Shortfall  int    `json:"shortfall"`
+	Over       int    `json:"over"`
+	StockAfter int    `json:"stock_after"`
+}
+
+// DeliveryReconciliation is the result of recording a delivery
+type DeliveryReconciliation struct {
+	OrderID  uint                 `json:"order_id"`
+	Status   string               `json:"status"`
+	Lines    []DeliveryLineResult `json:"lines"`
+	Complete bool                 `json:"complete"`
+}
+
+// NewSupplierOrderService creates a new supplier order service
+func NewSupplierOrderService() *SupplierOrderService {
+	return &SupplierOrderService{
+		db:     db.DB,
+		client: httpclient.New("supplier_webhook", httpclient.Options{Timeout: supplierWebhookTimeout}),
+	}
+}
+
+// GenerateSupplierOrderReference creates the reference quoted to suppliers
+func GenerateSupplierOrderReference() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("PO-%08d", time.Now().UnixNano()%100000000)
+	}
+	return "PO-" + strings.ToUpper(hex.EncodeToString(b))
+}
+
+// SignSupplierPayload returns the signature header value for a webhook body
+func SignSupplierPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySupplierSignature checks a supplier callback was signed with its secret
+func VerifySupplierSignature(supplier *models.Supplier, body []byte, signature string) error {
+	if supplier.WebhookSecret == "" || signature == "" {
+		return ErrSupplierBadSignature
+	}
+	if !hmac.Equal([]byte(SignSupplierPayload(supplier.WebhookSecret, body)), []byte(signature)) {
+		return ErrSupplierBadSignature
+	}
+	return nil
+}
+
+// GenerateSupplierSecret creates a webhook secret for a supplier
+func GenerateSupplierSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "sup_" + hex.EncodeToString(b), nil
+}
+
+// LoadOrder fetches an order with its supplier and lines
+func (sos *SupplierOrderService) LoadOrder(id uint) (*models.SupplierOrder, error) {
+	var order models.SupplierOrder
+	if err := sos.db.Preload("Supplier").Preload("Lines").First(&order, id).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// CheckReorders raises a low stock order for every active urgent need that has fallen
+// to its reorder level and is not already on an open order. Needs are grouped into
+// one order per supplier. Orders for suppliers set to auto submit are sent straight
+// away; the rest wait as drafts for review.
+func (sos *SupplierOrderService) CheckReorders(ctx context.Context) ([]models.SupplierOrder, error) {
+	var needs []models.UrgentNeed
+	if err := sos.db.Where("status = ? AND target_stock > 0", "active").Find(&needs).Error; err != nil {
+		return nil, err
+	}
+
+	var onOrder []uint
+	sos.db.Model(&models.SupplierOrderLine{}).
+		Joins("JOIN supplier_orders ON supplier_orders.id = supplier_order_lines.order_id AND supplier_orders.deleted_at IS NULL").
+		Where("supplier_orders.status IN ?", models.SupplierOrderOpenStatuses).
+		Distinct().
+		Pluck("supplier_order_lines.urgent_need_id", &onOrder)
+	open := make(map[uint]bool, len(onOrder))
+	for _, id := range onOrder {
+		open[id] = true
+	}
+
+	var suppliers []models.Supplier
+	if err := sos.db.Where("is_active = ?", true).Order("id ASC").Find(&suppliers).Error; err != nil {
+		return nil, err
+	}
+
+	bySupplier := map[uint][]models.SupplierOrderLine{}
+	var order []uint
+	for i := range needs {
+		need := &needs[i]
+		if open[need.ID] || !need.NeedsReorder() || need.GetQuantityNeeded() == 0 {
+			continue
+		}
+		supplier := supplierFor(need, suppliers)
+		if supplier == nil {
+			continue
+		}
+		if _, ok := bySupplier[supplier.ID]; !ok {
+			order = append(order, supplier.ID)
+		}
+		bySupplier[supplier.ID] = append(bySupplier[supplier.ID], models.SupplierOrderLine{
+			UrgentNeedID:    need.ID,
+			ItemName:        need.Name,
+			QuantityOrdered: need.GetQuantityNeeded(),
+		})
+	}
+
+	created := []models.SupplierOrder{}
+	for _, supplierID := range order {
+		draft, err := sos.CreateOrder(supplierID, bySupplier[supplierID], models.SupplierOrderTriggerLowStock, nil, "")
+		if err != nil {
+			log.Printf("Failed to raise low stock order for supplier %d: %v", supplierID, err)
+			continue
+		}
+		if draft.Supplier.AutoSubmit {
+			if err := sos.Submit(ctx, draft); err != nil {
+				log.Printf("Failed to submit low stock order %s: %v", draft.Reference, err)
+			}
+		}
+		if draft.Status != models.SupplierOrderSubmitted {
+			sos.notifyDraft(draft)
+		}
+		created = append(created, *draft)
+	}
+
+	return created, nil
+}
+
+// supplierFor picks the need's own supplier, or the first active supplier covering its
+// category
+func supplierFor(need *models.UrgentNeed, suppliers []models.Supplier) *models.Supplier {
+	if need.SupplierID != nil {
+		for i := range suppliers {
+			if suppliers[i].ID == *need.SupplierID {
+				return &suppliers[i]
+			}
+		}
+		return nil // Assigned supplier is inactive
+	}
+	for i := range suppliers {
+		if suppliers[i].Supplies(need.Category) {
+			return &suppliers[i]
+		}
+	}
+	return nil
+}
+
+// CreateOrder creates a draft order for a supplier
+func (sos *SupplierOrderService) CreateOrder(supplierID uint, lines []models.SupplierOrderLine, trigger string, createdBy *uint, notes string) (*models.SupplierOrder, error) {
+	if len(lines) == 0 {
+		return nil, ErrSupplierOrderNoLines
+	}
+
+	var supplier models.Supplier
+	if err := sos.db.First(&supplier, supplierID).Error; err != nil {
+		return nil, err
+	}
+	if !supplier.IsActive {
+		return nil, ErrSupplierInactive
+	}
+
+	order := models.SupplierOrder{
+		Reference:  GenerateSupplierOrderReference(),
+		SupplierID: supplier.ID,
+		Status:     models.SupplierOrderDraft,
+		Trigger:    trigger,
+		CreatedBy:  createdBy,
+		Notes:      notes,
+		Lines:      lines,
+	}
+	if err := sos.db.Create(&order).Error; err != nil {
+		return nil, err
+	}
+	order.Supplier = supplier
+	return &order, nil
+}
+
+// Submit sends an order to the supplier by webhook or email. A failed submission leaves
+// the order marked failed with the error so it can be sent again.
+func (sos *SupplierOrderService) Submit(ctx context.Context, order *models.SupplierOrder) error {
+	if order.Status != models.SupplierOrderDraft && order.Status != models.SupplierOrderFailed {
+		return ErrSupplierOrderNotSubmittable
+	}
+	if len(order.Lines) == 0 {
+		return ErrSupplierOrderNoLines
+	}
+	if !order.Supplier.IsActive {
+		return ErrSupplierInactive
+	}
+
+	now := time.Now()
+	if order.ExpectedAt == nil && order.Supplier.LeadTimeDays > 0 {
+		expected := now.AddDate(0, 0, order.Supplier.LeadTimeDays)
+		order.ExpectedAt = &expected
+	}
+
+	var err error
+	if order.Supplier.OrderMethod == models.SupplierOrderMethodWebhook {
+		err = sos.postOrder(ctx, order)
+	} else {
+		err = sos.emailOrder(order)
+	}
+
+	if err != nil {
+		order.Status = models.SupplierOrderFailed
+		order.LastError = err.Error()
+	} else {
+		order.Status = models.SupplierOrderSubmitted
+		order.SubmittedAt = &now
+		order.LastError = ""
+	}
+	if saveErr := sos.db.Omit("Supplier", "Lines").Save(order).Error; saveErr != nil {
+		return saveErr
+	}
+	return err
+}
+
+// supplierOrderPayload is the JSON order sent to supplier webhooks
+type supplierOrderPayload struct {
+	Event       string                     `json:"event"`
+	Reference   string                     `json:"reference"`
+	ExpectedBy  *time.Time                 `json:"expected_by,omitempty"`
+	Lines       []supplierOrderPayloadLine `json:"lines"`
+	Notes       string                     `json:"notes,omitempty"`
+	CallbackURL string                     `json:"callback_url"`
+	SentAt      time.Time                  `json:"sent_at"`
+}
+
+// supplierOrderPayloadLine is one line of a webhook order
+type supplierOrderPayloadLine struct {
+	LineID   uint   `json:"line_id"`
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+}
+
+// postOrder posts a signed order to the supplier's webhook. The supplier may reply with
+// its own order number and an expected delivery time.
+func (sos *SupplierOrderService) postOrder(ctx context.Context, order *models.SupplierOrder) error {
+	if order.Supplier.WebhookURL == "" {
+		return ErrSupplierNoWebhook
+	}
+
+	payload := supplierOrderPayload{
+		Event:       "order.created",
+		Reference:   order.Reference,
+		ExpectedBy:  order.ExpectedAt,
+		Notes:       order.Notes,
+		CallbackURL: fmt.Sprintf("%s/api/v1/webhooks/suppliers/%d", strings.TrimRight(os.Getenv("API_URL"), "/"), order.SupplierID),
+		SentAt:      time.Now(),
+	}
+	for _, line := range order.Lines {
+		payload.Lines = append(payload.Lines, supplierOrderPayloadLine{
+			LineID:   line.ID,
+			Item:     line.ItemName,
+			Quantity: line.QuantityOrdered,
+		})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, order.Supplier.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Order-Reference", order.Reference)
+	if order.Supplier.WebhookSecret != "" {
+		req.Header.Set(SupplierSignatureHeader, SignSupplierPayload(order.Supplier.WebhookSecret, body))
+	}
+
+	resp, err := sos.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	reply, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("supplier endpoint returned status %d", resp.StatusCode)
+	}
+
+	var ack struct {
+		ExternalRef string     `json:"external_ref"`
+		ExpectedAt  *time.Time `json:"expected_at"`
+	}
+	if json.Unmarshal(reply, &ack) == nil {
+		if ack.ExternalRef != "" {
+			order.ExternalRef = ack.ExternalRef
+		}
+		if ack.ExpectedAt != nil {
+			order.ExpectedAt = ack.ExpectedAt
+		}
+	}
+	return nil
+}
+
+// emailOrder emails the order form to the supplier's contact
+func (sos *SupplierOrderService) emailOrder(order *models.SupplierOrder) error {
+	if order.Supplier.ContactEmail == "" {
+		return ErrSupplierNoEmail
+	}
+	notificationService := notifications.GetService()
+	if notificationService == nil {
+		return errors.New("notification service is not available")
+	}
+
+	var b strings.Builder
+	greeting := order.Supplier.ContactName
+	if greeting == "" {
+		greeting = order.Supplier.Name
+	}
+	fmt.Fprintf(&b, "Dear %s,\n\nPlease supply the following items for order %s.\n\n", greeting, order.Reference)
+	for _, line := range order.Lines {
+		fmt.Fprintf(&b, "  %-40s %6d\n", line.ItemName, line.QuantityOrdered)
+	}
+	if order.ExpectedAt != nil {
+		fmt.Fprintf(&b, "\nWe would like to receive these by %s.\n", order.ExpectedAt.Format("Monday 2 January 2006"))
+	}
+	if order.Notes != "" {
+		fmt.Fprintf(&b, "\nNotes: %s\n", order.Notes)
+	}
+	b.WriteString("\nPlease quote the order reference on your delivery note or reply to confirm collection details.\n\nThank you,\nLewisham Charity")
+
+	return notificationService.SendEmail(order.Supplier.ContactEmail, "Order "+order.Reference, b.String())
+}
+
+// notifyDraft tells admins a low stock order is waiting for review
+func (sos *SupplierOrderService) notifyDraft(order *models.SupplierOrder) {
+	var adminIDs []uint
+	sos.db.Model(&models.User{}).
+		Where("role IN ? AND status = ?", []string{models.RoleAdmin, models.RoleSuperAdmin}, models.StatusActive).
+		Pluck("id", &adminIDs)
+
+	message := fmt.Sprintf("Stock is low on %d item(s). Order %s to %s is ready to review and send.",
+		len(order.Lines), order.Reference, order.Supplier.Name)
+	if order.Status == models.SupplierOrderFailed {
+		message = fmt.Sprintf("Order %s to %s could not be sent: %s", order.Reference, order.Supplier.Name, order.LastError)
+	}
+	for _, adminID := range adminIDs {
+		notification := models.InAppNotification{
+			UserID:    adminID,
+			Title:     "Supplier order needs review",
+			Message:   message,
+			Type:      "info",
+			Priority:  "normal",
+			ActionURL: fmt.Sprintf("/admin/supplier-orders/%d", order.ID),
+		}
+		if err := sos.db.Create(&notification).Error; err != nil {
+			log.Printf("Failed to notify admin %d of supplier order %s: %v", adminID, order.Reference, err)
+		}
+	}
+}
+
+// UpdateStatus applies a status change reported by the supplier or entered by staff
+func (sos *SupplierOrderService) UpdateStatus(order *models.SupplierOrder, update SupplierStatusUpdate) error {
+	if update.Status != "" && update.Status != order.Status {
+		allowed := false
+		for _, status := range models.SupplierOrderTransitions[order.Status] {
+			if status == update.Status {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return ErrSupplierInvalidTransition
+		}
+		order.Status = update.Status
+	}
+	if update.ExternalRef != "" {
+		order.ExternalRef = update.ExternalRef
+	}
+	if update.ExpectedAt != nil {
+		order.ExpectedAt = update.ExpectedAt
+	}
+	if update.Notes != "" {
+		if order.Notes != "" {
+			order.Notes += "\n"
+		}
+		order.Notes += update.Notes
+	}
+	return sos.db.Omit("Supplier", "Lines").Save(order).Error
+}
+
+// ReceiveDelivery records what arrived against each order line, adds it to the urgent
+// need's stock and reports any shortfall or over-delivery. Needs published by a stock
+// item are restocked through the item's movement ledger. Lines not listed are taken
+// as not delivered this time.
+func (sos *SupplierOrderService) ReceiveDelivery(order *models.SupplierOrder, received map[uint]int) (*DeliveryReconciliation, error) {
+	if !order.IsOpen() || order.Status == models.SupplierOrderDraft || order.Status == models.SupplierOrderFailed {
+		return nil, ErrSupplierOrderClosed
+	}
+
+	result := &DeliveryReconciliation{OrderID: order.ID, Lines: []DeliveryLineResult{}}
+	now := time.Now()
+
+	err := sos.db.Transaction(func(tx *gorm.DB) error {
+		complete := true
+		for i := range order.Lines {
+			line := &order.Lines[i]
+			delivered := received[line.ID]
+			if delivered < 0 {
+				delivered = 0
+			}
+
+			var need models.UrgentNeed
+			if err := tx.First(&need, line.UrgentNeedID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if delivered > 0 {
+				line.QuantityReceived += delivered
+				line.ReceivedAt = &now
+				if err := tx.Save(line).Error; err != nil {
+					return err
+				}
+				stocked := false
+				if need.ID != 0 {
+					var err error
+					if stocked, err = ReceiveForNeed(tx, need.ID, delivered, fmt.Sprintf("Supplier order %d", order.ID), now); err != nil {
+						return err
+					}
+				}
+				if stocked {
+					if err := tx.Select("current_stock").First(&need, need.ID).Error; err != nil {
+						return err
+					}
+				} else if need.ID != 0 {
+					need.CurrentStock += delivered
+					need.UpdateUrgencyFromStock()
+					if err := tx.Model(&need).Updates(map[string]interface{}{
+						"current_stock": need.CurrentStock,
+						"urgency":       need.Urgency,
+					}).Error; err != nil {
+						return err
+					}
+				}
+			}
+
+			row := DeliveryLineResult{
+				LineID:     line.ID,
+				ItemName:   line.ItemName,
+				Ordered:    line.QuantityOrdered,
+				Received:   line.QuantityReceived,
+				Delivered:  delivered,
+				Shortfall:  line.Outstanding(),
+				StockAfter: need.CurrentStock,
+			}
+			if line.QuantityReceived > line.QuantityOrdered {
+				row.Over = line.QuantityReceived - line.QuantityOrdered
+			}
+			if row.Shortfall > 0 {
+				complete = false
+			}
+			result.Lines = append(result.Lines, row)
+		}
+
+		order.Status = models.SupplierOrderPartiallyDelivered
+		if complete {
+			order.Status = models.SupplierOrderDelivered
+			order.DeliveredAt = &now
+		}
+		return tx.Omit("Supplier", "Lines").Save(order).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Status = order.Status
+	result.Complete = order.Status == models.SupplierOrderDelivered
+	return result, nil
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestSupplierSignature(t *testing.T) {
+	body := []byte(`{"status":"dispatched"}`)
+	supplier := &models.Supplier{WebhookSecret: "sup_secret"}
+	signature := SignSupplierPayload(supplier.WebhookSecret, body)
+
+	if err := VerifySupplierSignature(supplier, body, signature); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+	for name, check := range map[string]func() error{
+		"altered body":  func() error { return VerifySupplierSignature(supplier, []byte(`{"status":"delivered"}`), signature) },
+		"no signature":  func() error { return VerifySupplierSignature(supplier, body, "") },
+		"other secret":  func() error { return VerifySupplierSignature(supplier, body, SignSupplierPayload("other", body)) },
+		"no secret set": func() error { return VerifySupplierSignature(&models.Supplier{}, body, SignSupplierPayload("", body)) },
+	} {
+		if err := check(); !errors.Is(err, ErrSupplierBadSignature) {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+}
+
+func TestSupplierFor(t *testing.T) {
+	suppliers := []models.Supplier{
+		{ID: 1, Categories: "Tinned food, Pasta"},
+		{ID: 2, Categories: "toiletries,tinned food"},
+	}
+	assigned, inactive := uint(2), uint(9)
+
+	tests := []struct {
+		name string
+		need models.UrgentNeed
+		want uint // 0 for none
+	}{
+		{"first supplier covering the category", models.UrgentNeed{Category: "tinned food"}, 1},
+		{"assigned supplier", models.UrgentNeed{Category: "Tinned food", SupplierID: &assigned}, 2},
+		{"assigned supplier is inactive", models.UrgentNeed{Category: "Tinned food", SupplierID: &inactive}, 0},
+		{"nobody supplies it", models.UrgentNeed{Category: "Nappies"}, 0},
+	}
+	for _, tt := range tests {
+		got := supplierFor(&tt.need, suppliers)
+		if (got == nil && tt.want != 0) || (got != nil && got.ID != tt.want) {
+			t.Errorf("%s: got %+v, want supplier %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPostSupplierOrder(t *testing.T) {
+	t.Setenv("API_URL", "https://api.example.org/")
+	expected := time.Date(2026, 5, 8, 10, 0, 0, 0, time.UTC)
+
+	var posted supplierOrderPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(SupplierSignatureHeader) != SignSupplierPayload("sup_secret", body) || r.Header.Get("X-Order-Reference") != "PO-1234ABCD" {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		json.Unmarshal(body, &posted)
+		json.NewEncoder(w).Encode(map[string]interface{}{"external_ref": "WH-778", "expected_at": expected})
+	}))
+	defer server.Close()
+
+	order := &models.SupplierOrder{
+		SupplierID: 4,
+		Reference:  "PO-1234ABCD",
+		Supplier:   models.Supplier{WebhookURL: server.URL, WebhookSecret: "sup_secret"},
+		Lines:      []models.SupplierOrderLine{{ID: 11, ItemName: "Tinned tomatoes", QuantityOrdered: 48}},
+	}
+	sos := &SupplierOrderService{client: server.Client()}
+	if err := sos.postOrder(context.Background(), order); err != nil {
+		t.Fatal(err)
+	}
+	if order.ExternalRef != "WH-778" || order.ExpectedAt == nil || !order.ExpectedAt.Equal(expected) {
+		t.Errorf("acknowledgement not applied: %q %v", order.ExternalRef, order.ExpectedAt)
+	}
+	if posted.CallbackURL != "https://api.example.org/api/v1/webhooks/suppliers/4" || len(posted.Lines) != 1 || posted.Lines[0].Quantity != 48 {
+		t.Errorf("posted %+v", posted)
+	}
+
+	order.Supplier.WebhookSecret = "rotated"
+	if err := sos.postOrder(context.Background(), order); err == nil {
+		t.Error("a rejected order should fail")
+	}
+	order.Supplier.WebhookURL = ""
+	if err := sos.postOrder(context.Background(), order); !errors.Is(err, ErrSupplierNoWebhook) {
+		t.Errorf("no webhook: got %v", err)
+	}
+}
+
+func TestSupplierOrderTransitions(t *testing.T) {
+	sos := &SupplierOrderService{}
+	for _, tt := range []struct{ from, to string }{
+		{models.SupplierOrderDraft, models.SupplierOrderDispatched},
+		{models.SupplierOrderDispatched, models.SupplierOrderDelivered}, // Only by recording a delivery
+		{models.SupplierOrderDelivered, models.SupplierOrderCancelled},
+	} {
+		order := &models.SupplierOrder{Status: tt.from}
+		if err := sos.UpdateStatus(order, SupplierStatusUpdate{Status: tt.to}); !errors.Is(err, ErrSupplierInvalidTransition) || order.Status != tt.from {
+			t.Errorf("%s to %s: got %v, status %s", tt.from, tt.to, err, order.Status)
+		}
+	}
+
+	delivered := &models.SupplierOrder{Status: models.SupplierOrderDelivered}
+	if _, err := sos.ReceiveDelivery(delivered, map[uint]int{1: 5}); !errors.Is(err, ErrSupplierOrderClosed) {
+		t.Errorf("delivery against a delivered order: got %v", err)
+	}
+}
+
+func TestSupplierOrderLineOutstanding(t *testing.T) {
+	for _, tt := range []struct{ ordered, received, want int }{{48, 0, 48}, {48, 30, 18}, {48, 60, 0}} {
+		line := models.SupplierOrderLine{QuantityOrdered: tt.ordered, QuantityReceived: tt.received}
+		if got := line.Outstanding(); got != tt.want {
+			t.Errorf("ordered %d, received %d: outstanding %d, want %d", tt.ordered, tt.received, got, tt.want)
+		}
+	}
+}
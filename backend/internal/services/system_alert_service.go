@@ -0,0 +1,527 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/websocket"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Evaluator thresholds
+const (
+	alertHighRequestVolume     = 50 // Help requests in a day
+	alertLowCoveragePercent    = 80 // Share of today's shifts with a volunteer
+	alertPendingVerifications  = 10 // Documents waiting to be checked
+	maxAlertSnooze             = 30 * 24 * time.Hour
+	systemAlertTopic           = "admin_notifications"
+	resolvedAlertListLimit     = 200
+	alertSourceRequestVolume   = "high_request_volume"
+	alertSourceVolunteerCover  = "low_volunteer_coverage"
+	alertSourcePendingDocument = "pending_verifications"
+	alertSourceLowStock        = "low_stock"
+)
+
+var (
+	ErrAlertNotFound = errors.New("alert not found")
+	ErrAlertResolved = errors.New("alert has already been resolved")
+	ErrAlertInvalid  = errors.New("invalid alert")
+)
+
+// SystemAlertService raises, stores and manages the admin dashboard's system alerts
+type SystemAlertService struct {
+	db *gorm.DB
+}
+
+// SystemAlert is an alert with its current state
+type SystemAlert struct {
+	models.Alert
+	Status       string `json:"status"`
+	Acknowledged bool   `json:"acknowledged"`
+}
+
+// SystemAlertInput raises an alert by hand
+type SystemAlertInput struct {
+	Title       string
+	Message     string
+	Severity    string
+	ActionLabel string
+	ActionURL   string
+}
+
+// SystemAlertFilter narrows the alert list. An empty status lists open alerts that
+// are not snoozed; "all" lists everything.
+type SystemAlertFilter struct {
+	Status   string
+	Severity string
+	Source   string
+}
+
+// alertCondition is a problem the evaluator found
+type alertCondition struct {
+	Source      string
+	Severity    string
+	Title       string
+	Message     string
+	ActionLabel string
+	ActionURL   string
+}
+
+// alertPlan is what one evaluator run changes
+type alertPlan struct {
+	Create  []models.Alert
+	Refresh []models.Alert
+	Resolve []uint
+}
+
+// NewSystemAlertService creates a new system alert service
+func NewSystemAlertService() *SystemAlertService {
+	return &SystemAlertService{db: db.DB}
+}
+
+// List returns alerts. Open alerts come most severe and most recently seen first;
+// resolved alerts most recently resolved first.
+func (as *SystemAlertService) List(filter SystemAlertFilter, now time.Time) ([]SystemAlert, error) {
+	query := as.db.Model(&models.Alert{})
+	notSnoozed := "(snoozed_until IS NULL OR snoozed_until <= ?)"
+	switch filter.Status {
+	case "":
+		query = query.Where("resolved_at IS NULL").Where(notSnoozed, now)
+	case models.AlertStatusActive:
+		query = query.Where("resolved_at IS NULL AND acknowledged_at IS NULL").Where(notSnoozed, now)
+	case models.AlertStatusAcknowledged:
+		query = query.Where("resolved_at IS NULL AND acknowledged_at IS NOT NULL").Where(notSnoozed, now)
+	case models.AlertStatusSnoozed:
+		query = query.Where("resolved_at IS NULL AND snoozed_until > ?", now)
+	case models.AlertStatusResolved:
+		query = query.Where("resolved_at IS NOT NULL").Order("resolved_at DESC").Limit(resolvedAlertListLimit)
+	case "all":
+		query = query.Order("created_at DESC").Limit(resolvedAlertListLimit)
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrAlertInvalid, filter.Status)
+	}
+	if filter.Severity != "" {
+		query = query.Where("severity = ?", filter.Severity)
+	}
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+
+	var alerts []models.Alert
+	if err := query.Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	if filter.Status != models.AlertStatusResolved && filter.Status != "all" {
+		sort.SliceStable(alerts, func(i, j int) bool {
+			if rank := alertSeverityRank(alerts[i].Severity) - alertSeverityRank(alerts[j].Severity); rank != 0 {
+				return rank > 0
+			}
+			return alerts[i].LastSeenAt.After(alerts[j].LastSeenAt)
+		})
+	}
+
+	views := make([]SystemAlert, len(alerts))
+	for i := range alerts {
+		views[i] = systemAlertView(alerts[i], now)
+	}
+	return views, nil
+}
+
+// Get returns one alert
+func (as *SystemAlertService) Get(id uint) (*SystemAlert, error) {
+	var alert models.Alert
+	if err := as.db.First(&alert, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAlertNotFound
+		}
+		return nil, err
+	}
+	view := systemAlertView(alert, time.Now())
+	return &view, nil
+}
+
+// Create raises an alert by hand. Manual alerts stay until someone resolves them.
+func (as *SystemAlertService) Create(input SystemAlertInput, createdBy uint) (*SystemAlert, error) {
+	input.Title = strings.TrimSpace(input.Title)
+	if input.Title == "" {
+		return nil, fmt.Errorf("%w: title is required", ErrAlertInvalid)
+	}
+	if input.Severity == "" {
+		input.Severity = models.AlertSeverityMedium
+	}
+	if !models.ValidAlertSeverity(input.Severity) {
+		return nil, fmt.Errorf("%w: severity must be low, medium, high or critical", ErrAlertInvalid)
+	}
+
+	now := time.Now()
+	alert := models.Alert{
+		Source:      models.AlertSourceManual,
+		Severity:    input.Severity,
+		Type:        alertType(input.Severity),
+		Title:       input.Title,
+		Message:     strings.TrimSpace(input.Message),
+		ActionLabel: input.ActionLabel,
+		ActionURL:   input.ActionURL,
+		Occurrences: 1,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+		CreatedBy:   &createdBy,
+	}
+	if err := as.db.Create(&alert).Error; err != nil {
+		return nil, err
+	}
+	as.broadcast(alert)
+
+	view := systemAlertView(alert, now)
+	return &view, nil
+}
+
+// Acknowledge records that an admin has seen an alert. It stays on the dashboard,
+// marked as acknowledged, until it is resolved.
+func (as *SystemAlertService) Acknowledge(id, userID uint) (*SystemAlert, error) {
+	return as.update(id, func(alert *models.Alert, now time.Time) error {
+		if alert.AcknowledgedAt == nil {
+			alert.AcknowledgedAt = &now
+			alert.AcknowledgedBy = &userID
+		}
+		return nil
+	})
+}
+
+// Snooze hides an alert from the dashboard until the given time
+func (as *SystemAlertService) Snooze(id uint, until time.Time, userID uint) (*SystemAlert, error) {
+	return as.update(id, func(alert *models.Alert, now time.Time) error {
+		if !until.After(now) {
+			return fmt.Errorf("%w: snooze must end in the future", ErrAlertInvalid)
+		}
+		if until.Sub(now) > maxAlertSnooze {
+			return fmt.Errorf("%w: alerts can be snoozed for at most 30 days", ErrAlertInvalid)
+		}
+		alert.SnoozedUntil = &until
+		alert.SnoozedBy = &userID
+		return nil
+	})
+}
+
+// Resolve closes an alert. If the evaluator still finds the problem it raises a new
+// alert on its next run.
+func (as *SystemAlertService) Resolve(id, userID uint) (*SystemAlert, error) {
+	return as.update(id, func(alert *models.Alert, now time.Time) error {
+		alert.ResolvedAt = &now
+		alert.ResolvedBy = &userID
+		return nil
+	})
+}
+
+// Delete removes an alert
+func (as *SystemAlertService) Delete(id uint) error {
+	result := as.db.Delete(&models.Alert{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAlertNotFound
+	}
+	return nil
+}
+
+// Evaluate checks every alert rule, raising alerts for new problems, refreshing the
+// ones still present and resolving auto-resolving alerts whose problem has cleared.
+// It returns how many alerts were raised and resolved.
+func (as *SystemAlertService) Evaluate(now time.Time) (int, int, error) {
+	conditions, err := as.conditions(now)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var plan alertPlan
+	err = as.db.Transaction(func(tx *gorm.DB) error {
+		var open []models.Alert
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("resolved_at IS NULL AND source <> ?", models.AlertSourceManual).
+			Find(&open).Error; err != nil {
+			return err
+		}
+
+		plan = planAlerts(open, conditions, now)
+		if len(plan.Create) > 0 {
+			if err := tx.Create(&plan.Create).Error; err != nil {
+				return err
+			}
+		}
+		for i := range plan.Refresh {
+			if err := tx.Model(&plan.Refresh[i]).Updates(map[string]interface{}{
+				"severity":     plan.Refresh[i].Severity,
+				"type":         plan.Refresh[i].Type,
+				"message":      plan.Refresh[i].Message,
+				"occurrences":  plan.Refresh[i].Occurrences,
+				"last_seen_at": plan.Refresh[i].LastSeenAt,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if len(plan.Resolve) > 0 {
+			return tx.Model(&models.Alert{}).Where("id IN ?", plan.Resolve).Update("resolved_at", now).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, alert := range plan.Create {
+		as.broadcast(alert)
+	}
+	return len(plan.Create), len(plan.Resolve), nil
+}
+
+// raise records an alert for a one-off finding, such as a KPI anomaly. Unlike the
+// evaluator's alerts it stays open until an admin resolves it.
+func (as *SystemAlertService) raise(condition alertCondition, now time.Time) (*models.Alert, error) {
+	alert := models.Alert{
+		Source:      condition.Source,
+		Severity:    condition.Severity,
+		Type:        alertType(condition.Severity),
+		Title:       condition.Title,
+		Message:     condition.Message,
+		ActionLabel: condition.ActionLabel,
+		ActionURL:   condition.ActionURL,
+		Occurrences: 1,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	}
+	if err := as.db.Create(&alert).Error; err != nil {
+		return nil, err
+	}
+	as.broadcast(alert)
+	return &alert, nil
+}
+
+// update loads an open alert, applies a change and saves it
+func (as *SystemAlertService) update(id uint, change func(alert *models.Alert, now time.Time) error) (*SystemAlert, error) {
+	var alert models.Alert
+	now := time.Now()
+	err := as.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&alert, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAlertNotFound
+			}
+			return err
+		}
+		if alert.ResolvedAt != nil {
+			return ErrAlertResolved
+		}
+		if err := change(&alert, now); err != nil {
+			return err
+		}
+		return tx.Save(&alert).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	view := systemAlertView(alert, now)
+	return &view, nil
+}
+
+// conditions runs the alert rules
+func (as *SystemAlertService) conditions(now time.Time) ([]alertCondition, error) {
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	var conditions []alertCondition
+
+	var todayRequests int64
+	if err := as.db.Model(&models.HelpRequest{}).
+		Where("created_at >= ? AND created_at < ?", dayStart, dayEnd).
+		Count(&todayRequests).Error; err != nil {
+		return nil, err
+	}
+	if todayRequests > alertHighRequestVolume {
+		conditions = append(conditions, alertCondition{
+			Source:   alertSourceRequestVolume,
+			Severity: models.AlertSeverityMedium,
+			Title:    "High Request Volume",
+			Message:  fmt.Sprintf("High volume of requests today: %d", todayRequests),
+		})
+	}
+
+	var todayShifts, assignedShifts int64
+	if err := as.db.Model(&models.Shift{}).
+		Where("date >= ? AND date < ?", dayStart, dayEnd).
+		Count(&todayShifts).Error; err != nil {
+		return nil, err
+	}
+	if err := as.db.Model(&models.Shift{}).
+		Where("date >= ? AND date < ? AND assigned_volunteer_id IS NOT NULL", dayStart, dayEnd).
+		Count(&assignedShifts).Error; err != nil {
+		return nil, err
+	}
+	if todayShifts > 0 {
+		coverage := int(float64(assignedShifts) / float64(todayShifts) * 100)
+		if coverage < alertLowCoveragePercent {
+			conditions = append(conditions, alertCondition{
+				Source:      alertSourceVolunteerCover,
+				Severity:    models.AlertSeverityHigh,
+				Title:       "Low Volunteer Coverage",
+				Message:     fmt.Sprintf("Low volunteer coverage: %d%% (%d/%d shifts covered)", coverage, assignedShifts, todayShifts),
+				ActionLabel: "View Shifts",
+				ActionURL:   "/admin/shifts",
+			})
+		}
+	}
+
+	var pendingVerifications int64
+	if err := as.db.Model(&models.Document{}).
+		Where("status = ?", "pending_verification").
+		Count(&pendingVerifications).Error; err != nil {
+		return nil, err
+	}
+	if pendingVerifications > alertPendingVerifications {
+		conditions = append(conditions, alertCondition{
+			Source:      alertSourcePendingDocument,
+			Severity:    models.AlertSeverityLow,
+			Title:       "Pending Verifications",
+			Message:     fmt.Sprintf("%d document verifications pending", pendingVerifications),
+			ActionLabel: "Review Documents",
+			ActionURL:   "/admin/documents",
+		})
+	}
+
+	shortages, err := (&InventoryService{db: as.db}).Shortages()
+	if err != nil {
+		return nil, err
+	}
+	if condition, ok := lowStockCondition(shortages); ok {
+		conditions = append(conditions, condition)
+	}
+
+	return conditions, nil
+}
+
+// lowStockCondition raises one alert for every item at or below its minimum level,
+// high severity once any of them is critical
+func lowStockCondition(shortages []InventoryItemView) (alertCondition, bool) {
+	if len(shortages) == 0 {
+		return alertCondition{}, false
+	}
+
+	severity := models.AlertSeverityMedium
+	names := make([]string, 0, 3)
+	for _, item := range shortages {
+		if item.Urgency == StockUrgencyCritical {
+			severity = models.AlertSeverityHigh
+		}
+		if len(names) < 3 {
+			names = append(names, item.Name)
+		}
+	}
+	message := fmt.Sprintf("%d stock items are at or below their minimum level: %s", len(shortages), strings.Join(names, ", "))
+	if len(shortages) > len(names) {
+		message += fmt.Sprintf(" and %d more", len(shortages)-len(names))
+	}
+
+	return alertCondition{
+		Source:      alertSourceLowStock,
+		Severity:    severity,
+		Title:       "Low Stock",
+		Message:     message,
+		ActionLabel: "View Inventory",
+		ActionURL:   "/admin/inventory",
+	}, true
+}
+
+// broadcast tells connected admins about a new alert
+func (as *SystemAlertService) broadcast(alert models.Alert) {
+	if err := websocket.GetGlobalManager().BroadcastToTopic(systemAlertTopic, map[string]interface{}{
+		"type":  "system_alert",
+		"alert": systemAlertView(alert, time.Now()),
+	}); err != nil {
+		log.Printf("Failed to broadcast system alert %d: %v", alert.ID, err)
+	}
+}
+
+// planAlerts matches the evaluator's findings against its open alerts: each source
+// has at most one open alert, refreshed while the problem persists
+func planAlerts(open []models.Alert, conditions []alertCondition, now time.Time) alertPlan {
+	var plan alertPlan
+	bySource := map[string]models.Alert{}
+	for _, alert := range open {
+		bySource[alert.Source] = alert
+	}
+
+	found := map[string]bool{}
+	for _, condition := range conditions {
+		found[condition.Source] = true
+		if alert, ok := bySource[condition.Source]; ok {
+			alert.Severity = condition.Severity
+			alert.Type = alertType(condition.Severity)
+			alert.Message = condition.Message
+			alert.Occurrences++
+			alert.LastSeenAt = now
+			plan.Refresh = append(plan.Refresh, alert)
+			continue
+		}
+		plan.Create = append(plan.Create, models.Alert{
+			Source:      condition.Source,
+			Severity:    condition.Severity,
+			Type:        alertType(condition.Severity),
+			Title:       condition.Title,
+			Message:     condition.Message,
+			ActionLabel: condition.ActionLabel,
+			ActionURL:   condition.ActionURL,
+			AutoResolve: true,
+			Occurrences: 1,
+			FirstSeenAt: now,
+			LastSeenAt:  now,
+		})
+	}
+
+	for _, alert := range open {
+		if alert.AutoResolve && !found[alert.Source] {
+			plan.Resolve = append(plan.Resolve, alert.ID)
+		}
+	}
+	return plan
+}
+
+// systemAlertView adds an alert's state
+func systemAlertView(alert models.Alert, now time.Time) SystemAlert {
+	return SystemAlert{
+		Alert:        alert,
+		Status:       alert.Status(now),
+		Acknowledged: alert.AcknowledgedAt != nil,
+	}
+}
+
+// alertType is the dashboard style for a severity
+func alertType(severity string) string {
+	switch severity {
+	case models.AlertSeverityLow:
+		return "info"
+	case models.AlertSeverityMedium:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
+// alertSeverityRank orders severities, most severe highest
+func alertSeverityRank(severity string) int {
+	switch severity {
+	case models.AlertSeverityCritical:
+		return 3
+	case models.AlertSeverityHigh:
+		return 2
+	case models.AlertSeverityMedium:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestPlanAlerts(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	acknowledged := now.Add(-time.Hour)
+	open := []models.Alert{
+		{ID: 1, Source: alertSourceVolunteerCover, Severity: models.AlertSeverityHigh, Occurrences: 3, AutoResolve: true, AcknowledgedAt: &acknowledged},
+		{ID: 2, Source: alertSourcePendingDocument, Severity: models.AlertSeverityLow, Occurrences: 1, AutoResolve: true},
+		{ID: 3, Source: "legacy_rule", Severity: models.AlertSeverityLow, Occurrences: 1},
+	}
+	conditions := []alertCondition{
+		{Source: alertSourceVolunteerCover, Severity: models.AlertSeverityHigh, Title: "Low Volunteer Coverage", Message: "Low volunteer coverage: 50%"},
+		{Source: alertSourceRequestVolume, Severity: models.AlertSeverityMedium, Title: "High Request Volume", Message: "High volume of requests today: 60"},
+	}
+
+	plan := planAlerts(open, conditions, now)
+
+	if len(plan.Refresh) != 1 || plan.Refresh[0].ID != 1 {
+		t.Fatalf("expected the coverage alert to be refreshed, got %+v", plan.Refresh)
+	}
+	refreshed := plan.Refresh[0]
+	if refreshed.Occurrences != 4 || !refreshed.LastSeenAt.Equal(now) || refreshed.Message != "Low volunteer coverage: 50%" {
+		t.Errorf("refreshed alert not updated: %+v", refreshed)
+	}
+	if refreshed.AcknowledgedAt == nil {
+		t.Error("refreshing an alert should keep its acknowledgement")
+	}
+
+	if len(plan.Create) != 1 || plan.Create[0].Source != alertSourceRequestVolume {
+		t.Fatalf("expected a new request volume alert, got %+v", plan.Create)
+	}
+	if created := plan.Create[0]; !created.AutoResolve || created.Type != "warning" || created.Occurrences != 1 {
+		t.Errorf("new alert not set up for auto-resolve: %+v", created)
+	}
+
+	if len(plan.Resolve) != 1 || plan.Resolve[0] != 2 {
+		t.Errorf("expected only the cleared auto-resolving alert to resolve, got %v", plan.Resolve)
+	}
+}
+
+func TestAlertStatus(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		alert models.Alert
+		want  string
+	}{
+		{"new", models.Alert{}, models.AlertStatusActive},
+		{"acknowledged", models.Alert{AcknowledgedAt: &earlier}, models.AlertStatusAcknowledged},
+		{"snoozed", models.Alert{AcknowledgedAt: &earlier, SnoozedUntil: &later}, models.AlertStatusSnoozed},
+		{"snooze over", models.Alert{SnoozedUntil: &earlier}, models.AlertStatusActive},
+		{"resolved", models.Alert{SnoozedUntil: &later, ResolvedAt: &earlier}, models.AlertStatusResolved},
+	}
+	for _, tt := range tests {
+		if got := tt.alert.Status(now); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLowStockCondition(t *testing.T) {
+	if _, ok := lowStockCondition(nil); ok {
+		t.Fatal("no shortages should raise no alert")
+	}
+
+	shortages := []InventoryItemView{
+		{InventoryItem: models.InventoryItem{Name: "Nappies"}, Urgency: StockUrgencyHigh},
+		{InventoryItem: models.InventoryItem{Name: "Soap"}, Urgency: StockUrgencyHigh},
+	}
+	condition, ok := lowStockCondition(shortages)
+	if !ok || condition.Source != alertSourceLowStock || condition.Severity != models.AlertSeverityMedium {
+		t.Fatalf("unexpected condition for high shortages: %+v", condition)
+	}
+
+	shortages = append(shortages,
+		InventoryItemView{InventoryItem: models.InventoryItem{Name: "Rice"}, Urgency: StockUrgencyCritical},
+		InventoryItemView{InventoryItem: models.InventoryItem{Name: "Pasta"}, Urgency: StockUrgencyHigh},
+	)
+	condition, _ = lowStockCondition(shortages)
+	if condition.Severity != models.AlertSeverityHigh {
+		t.Errorf("a critical item should raise severity to high, got %s", condition.Severity)
+	}
+	if want := "4 stock items are at or below their minimum level: Nappies, Soap, Rice and 1 more"; condition.Message != want {
+		t.Errorf("message = %q, want %q", condition.Message, want)
+	}
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTagNotFound       = errors.New("tag not found")
+	ErrTagExists         = errors.New("a tag with this name already exists")
+	ErrTagInvalid        = errors.New("invalid tag")
+	ErrTagEntityNotFound = errors.New("nothing to tag with that type and ID")
+	ErrTagNotAssigned    = errors.New("tag is not attached to that")
+)
+
+var (
+	tagSlugInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+	tagColour      = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+)
+
+// tagCohortSQL selects the users in a tag's cohort: tagged visitors and volunteers,
+// visitors whose help requests are tagged and donors whose donations are tagged
+const tagCohortSQL = `SELECT tag_assignments.entity_id FROM tag_assignments
+	JOIN tags ON tags.id = tag_assignments.tag_id
+	WHERE tags.slug = @slug AND tag_assignments.entity_type IN ('visitor', 'volunteer')
+UNION SELECT help_requests.visitor_id FROM help_requests
+	JOIN tag_assignments ON tag_assignments.entity_type = 'help_request' AND tag_assignments.entity_id = help_requests.id
+	JOIN tags ON tags.id = tag_assignments.tag_id
+	WHERE tags.slug = @slug AND help_requests.deleted_at IS NULL
+UNION SELECT COALESCE(donations.user_id, donations.donor_id) FROM donations
+	JOIN tag_assignments ON tag_assignments.entity_type = 'donation' AND tag_assignments.entity_id = donations.id
+	JOIN tags ON tags.id = tag_assignments.tag_id
+	WHERE tags.slug = @slug AND donations.deleted_at IS NULL AND COALESCE(donations.user_id, donations.donor_id) IS NOT NULL`
+
+// TagInput creates or renames a tag
+type TagInput struct {
+	Name        string
+	Description string
+	Colour      string
+}
+
+// TagSummary is a tag with how many of each entity type carry it
+type TagSummary struct {
+	models.Tag
+	Counts map[string]int64 `json:"counts"`
+	Total  int64            `json:"total"`
+}
+
+// TagReport summarises everything carrying a tag and the cohort it defines
+type TagReport struct {
+	Tag                  models.Tag       `json:"tag"`
+	Counts               map[string]int64 `json:"counts"`
+	CohortSize           int64            `json:"cohort_size"` // Distinct people reached through the tag
+	Donations            int64            `json:"donations"`
+	MoneyDonated         float64          `json:"money_donated"` // Net of refunds
+	GoodsDonations       int64            `json:"goods_donations"`
+	HelpRequestsByStatus map[string]int64 `json:"help_requests_by_status"`
+}
+
+// TagService manages tags, what they are attached to and the cohorts they define
+type TagService struct {
+	db *gorm.DB
+}
+
+// NewTagService creates a new tag service
+func NewTagService() *TagService {
+	return &TagService{db: db.DB}
+}
+
+// List returns every tag with its usage, alphabetically
+func (ts *TagService) List() ([]TagSummary, error) {
+	var tags []models.Tag
+	if err := ts.db.Order("name ASC").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	type usage struct {
+		TagID      uint
+		EntityType string
+		Count      int64
+	}
+	var usages []usage
+	if err := ts.db.Model(&models.TagAssignment{}).
+		Select("tag_id, entity_type, COUNT(*) AS count").
+		Group("tag_id, entity_type").
+		Scan(&usages).Error; err != nil {
+		return nil, err
+	}
+
+	summaries := make([]TagSummary, len(tags))
+	index := map[uint]int{}
+	for i, tag := range tags {
+		summaries[i] = TagSummary{Tag: tag, Counts: map[string]int64{}}
+		index[tag.ID] = i
+	}
+	for _, u := range usages {
+		if i, ok := index[u.TagID]; ok {
+			summaries[i].Counts[u.EntityType] = u.Count
+			summaries[i].Total += u.Count
+		}
+	}
+	return summaries, nil
+}
+
+// Create adds a tag
+func (ts *TagService) Create(input TagInput, createdBy uint) (*models.Tag, error) {
+	tag := models.Tag{CreatedBy: createdBy}
+	if err := applyTagInput(&tag, input); err != nil {
+		return nil, err
+	}
+	if err := ts.checkSlugFree(tag.Slug, 0); err != nil {
+		return nil, err
+	}
+	if err := ts.db.Create(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// Update renames or recolours a tag. Campaigns using the old slug stop matching it.
+func (ts *TagService) Update(id uint, input TagInput) (*models.Tag, error) {
+	tag, err := ts.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyTagInput(tag, input); err != nil {
+		return nil, err
+	}
+	if err := ts.checkSlugFree(tag.Slug, tag.ID); err != nil {
+		return nil, err
+	}
+	if err := ts.db.Save(tag).Error; err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// Delete removes a tag and detaches it from everything
+func (ts *TagService) Delete(id uint) (*models.Tag, error) {
+	tag, err := ts.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	err = ts.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&models.TagAssignment{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(tag).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// Get returns a tag by ID
+func (ts *TagService) Get(id uint) (*models.Tag, error) {
+	var tag models.Tag
+	if err := ts.db.First(&tag, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// Find returns a tag by its ID or slug, as given in list filters
+func (ts *TagService) Find(ref string) (*models.Tag, error) {
+	if id, err := strconv.ParseUint(ref, 10, 32); err == nil {
+		return ts.Get(uint(id))
+	}
+	var tag models.Tag
+	if err := ts.db.Where("slug = ?", TagSlug(ref)).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// Assign attaches a tag to an entity. Tagging something twice has no effect.
+func (ts *TagService) Assign(tagID uint, entityType string, entityID, assignedBy uint) (*models.TagAssignment, error) {
+	if _, err := ts.Get(tagID); err != nil {
+		return nil, err
+	}
+	if err := ts.checkEntity(entityType, entityID); err != nil {
+		return nil, err
+	}
+
+	assignment := models.TagAssignment{TagID: tagID, EntityType: entityType, EntityID: entityID, AssignedBy: assignedBy}
+	if err := ts.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&assignment).Error; err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// Unassign detaches a tag from an entity
+func (ts *TagService) Unassign(tagID uint, entityType string, entityID uint) error {
+	result := ts.db.Where("tag_id = ? AND entity_type = ? AND entity_id = ?", tagID, entityType, entityID).
+		Delete(&models.TagAssignment{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTagNotAssigned
+	}
+	return nil
+}
+
+// TagsFor returns the tags on an entity
+func (ts *TagService) TagsFor(entityType string, entityID uint) ([]models.Tag, error) {
+	if !slices.Contains(models.TagEntityTypes, entityType) {
+		return nil, fmt.Errorf("%w: unknown entity type %q", ErrTagInvalid, entityType)
+	}
+	var tags []models.Tag
+	err := ts.db.Joins("JOIN tag_assignments ON tag_assignments.tag_id = tags.id").
+		Where("tag_assignments.entity_type = ? AND tag_assignments.entity_id = ?", entityType, entityID).
+		Order("tags.name ASC").
+		Find(&tags).Error
+	return tags, err
+}
+
+// Assignments returns what a tag is attached to, optionally of one entity type
+func (ts *TagService) Assignments(tagID uint, entityType string) ([]models.TagAssignment, error) {
+	if _, err := ts.Get(tagID); err != nil {
+		return nil, err
+	}
+	query := ts.db.Where("tag_id = ?", tagID)
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	var assignments []models.TagAssignment
+	err := query.Order("entity_type ASC, created_at DESC").Find(&assignments).Error
+	return assignments, err
+}
+
+// Filter narrows a list query to rows carrying a tag, given by ID or slug. Column is
+// the list's ID column, such as "help_requests.id"; users can be filtered by their
+// visitor and volunteer tags together.
+func (ts *TagService) Filter(query *gorm.DB, column, ref string, entityTypes ...string) (*gorm.DB, error) {
+	tag, err := ts.Find(ref)
+	if err != nil {
+		return nil, err
+	}
+	return query.Where(column+" IN (?)", ts.db.Model(&models.TagAssignment{}).
+		Select("entity_id").
+		Where("tag_id = ? AND entity_type IN ?", tag.ID, entityTypes)), nil
+}
+
+// Cohort returns a subquery selecting the IDs of the users in a tag's cohort, for
+// use as "users.id IN (?)"
+func (ts *TagService) Cohort(slug string) *gorm.DB {
+	return ts.db.Raw(tagCohortSQL, map[string]interface{}{"slug": slug})
+}
+
+// Report summarises a tag's visitors, volunteers, help requests and donations
+func (ts *TagService) Report(tagID uint) (*TagReport, error) {
+	tag, err := ts.Get(tagID)
+	if err != nil {
+		return nil, err
+	}
+	report := &TagReport{Tag: *tag, Counts: map[string]int64{}, HelpRequestsByStatus: map[string]int64{}}
+
+	type count struct {
+		Key   string
+		Count int64
+	}
+	var counts []count
+	if err := ts.db.Model(&models.TagAssignment{}).
+		Select("entity_type AS key, COUNT(*) AS count").
+		Where("tag_id = ?", tag.ID).
+		Group("entity_type").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range counts {
+		report.Counts[c.Key] = c.Count
+	}
+
+	if err := ts.db.Raw("SELECT COUNT(*) FROM ("+tagCohortSQL+") AS cohort", map[string]interface{}{"slug": tag.Slug}).
+		Scan(&report.CohortSize).Error; err != nil {
+		return nil, err
+	}
+
+	tagged := func(entityType string) *gorm.DB {
+		return ts.db.Model(&models.TagAssignment{}).Select("entity_id").Where("tag_id = ? AND entity_type = ?", tag.ID, entityType)
+	}
+
+	var donations struct {
+		Donations      int64
+		MoneyDonated   float64
+		GoodsDonations int64
+	}
+	if err := ts.db.Model(&models.Donation{}).
+		Select("COUNT(*) AS donations, "+
+			"COALESCE(SUM(CASE WHEN type = ? THEN amount - refunded_amount ELSE 0 END), 0) AS money_donated, "+
+			"COUNT(*) FILTER (WHERE type = ?) AS goods_donations", models.DonationTypeMoney, models.DonationTypeGoods).
+		Where("id IN (?)", tagged(models.TagEntityDonation)).
+		Scan(&donations).Error; err != nil {
+		return nil, err
+	}
+	report.Donations = donations.Donations
+	report.MoneyDonated = roundPence(donations.MoneyDonated)
+	report.GoodsDonations = donations.GoodsDonations
+
+	counts = nil
+	if err := ts.db.Model(&models.HelpRequest{}).
+		Select("status AS key, COUNT(*) AS count").
+		Where("id IN (?)", tagged(models.TagEntityHelpRequest)).
+		Group("status").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range counts {
+		report.HelpRequestsByStatus[c.Key] = c.Count
+	}
+
+	return report, nil
+}
+
+// checkSlugFree makes sure no other tag has the slug
+func (ts *TagService) checkSlugFree(slug string, exceptID uint) error {
+	var count int64
+	if err := ts.db.Model(&models.Tag{}).Where("slug = ? AND id <> ?", slug, exceptID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTagExists
+	}
+	return nil
+}
+
+// checkEntity makes sure the entity being tagged exists. Visitors and volunteers must
+// be users with that role.
+func (ts *TagService) checkEntity(entityType string, entityID uint) error {
+	var query *gorm.DB
+	switch entityType {
+	case models.TagEntityVisitor:
+		query = ts.db.Model(&models.User{}).Where("id = ? AND role = ?", entityID, models.RoleVisitor)
+	case models.TagEntityVolunteer:
+		query = ts.db.Model(&models.User{}).Where("id = ? AND role = ?", entityID, models.RoleVolunteer)
+	case models.TagEntityHelpRequest:
+		query = ts.db.Model(&models.HelpRequest{}).Where("id = ?", entityID)
+	case models.TagEntityDonation:
+		query = ts.db.Model(&models.Donation{}).Where("id = ?", entityID)
+	default:
+		return fmt.Errorf("%w: entity type must be one of %s", ErrTagInvalid, strings.Join(models.TagEntityTypes, ", "))
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrTagEntityNotFound
+	}
+	return nil
+}
+
+// applyTagInput validates a tag's name and colour and copies them onto the tag
+func applyTagInput(tag *models.Tag, input TagInput) error {
+	name := strings.Join(strings.Fields(input.Name), " ")
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrTagInvalid)
+	}
+	if len(name) > 60 {
+		return fmt.Errorf("%w: name must be 60 characters or fewer", ErrTagInvalid)
+	}
+	slug := TagSlug(name)
+	if slug == "" {
+		return fmt.Errorf("%w: name must contain letters or numbers", ErrTagInvalid)
+	}
+	if input.Colour != "" && !tagColour.MatchString(input.Colour) {
+		return fmt.Errorf("%w: colour must be a hex colour such as #2E7D32", ErrTagInvalid)
+	}
+
+	tag.Name = name
+	tag.Slug = slug
+	tag.Description = strings.TrimSpace(input.Description)
+	tag.Colour = input.Colour
+	return nil
+}
+
+// TagSlug turns a tag name into the lower-case form used in filters, e.g.
+// "Christmas Appeal 2026" becomes "christmas-appeal-2026"
+func TagSlug(name string) string {
+	return strings.Trim(tagSlugInvalid.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestTagSlug(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Christmas Appeal 2026", "christmas-appeal-2026"},
+		{"  School partnership ", "school-partnership"},
+		{"Food & Fuel", "food-fuel"},
+		{"--urgent--", "urgent"},
+		{"!!!", ""},
+	}
+
+	for _, tt := range tests {
+		if got := TagSlug(tt.name); got != tt.want {
+			t.Errorf("TagSlug(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplyTagInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   TagInput
+		wantErr bool
+		want    models.Tag
+	}{
+		{
+			name:  "valid tag",
+			input: TagInput{Name: "  Christmas   Appeal ", Description: " Seasonal donors ", Colour: "#2E7D32"},
+			want:  models.Tag{Name: "Christmas Appeal", Slug: "christmas-appeal", Description: "Seasonal donors", Colour: "#2E7D32"},
+		},
+		{
+			name:  "colour is optional",
+			input: TagInput{Name: "Referral"},
+			want:  models.Tag{Name: "Referral", Slug: "referral"},
+		},
+		{name: "missing name", input: TagInput{Name: "   "}, wantErr: true},
+		{name: "name too long", input: TagInput{Name: strings.Repeat("a", 61)}, wantErr: true},
+		{name: "name without letters", input: TagInput{Name: "???"}, wantErr: true},
+		{name: "bad colour", input: TagInput{Name: "Referral", Colour: "green"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tag models.Tag
+			err := applyTagInput(&tag, tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrTagInvalid) {
+					t.Fatalf("expected ErrTagInvalid, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tag != tt.want {
+				t.Errorf("got %+v, want %+v", tag, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+)
+
+// RenderTicket renders a visit ticket as a printable PDF for visitors without a
+// smartphone
+func RenderTicket(ticket models.Ticket) []byte {
+	return RenderTicketFor(ticket, notifications.CommsFormat{})
+}
+
+// RenderTicketFor renders a visit ticket in the format the visitor asked for: plain
+// language, large print, or both
+func RenderTicketFor(ticket models.Ticket, format notifications.CommsFormat) []byte {
+	organisation := BrandName("")
+	doc := BrandedPDF("Ticket "+ticket.TicketNumber, "")
+	if format.LargePrint {
+		doc.LargePrint()
+	}
+
+	if format.PlainLanguage {
+		doc.Heading(organisation+" - Your ticket").
+			Blank().
+			Field("Your ticket number", ticket.TicketNumber).
+			Field("Name", ticket.VisitorName).
+			Field("Help with", ticket.Category).
+			Field("Day", ticket.VisitDate.Format("Monday 2 January"))
+		if ticket.TimeSlot != "" {
+			doc.Field("Time", ticket.TimeSlot)
+		}
+		doc.Blank().
+			Subheading("What to do").
+			Line("1. Come 15 minutes early.").
+			Line("2. Show this ticket at the desk.").
+			Line("3. Bring your ID.").
+			Blank().
+			Line("If you cannot come, please tell us.")
+		return doc.Bytes()
+	}
+
+	doc.Heading(organisation+" - Visit Ticket").
+		Blank().
+		Field("Ticket number", ticket.TicketNumber).
+		Field("Name", ticket.VisitorName).
+		Field("Service", ticket.Category).
+		Field("Visit date", ticket.VisitDate.Format("Monday 2 January 2006"))
+	if ticket.TimeSlot != "" {
+		doc.Field("Time slot", ticket.TimeSlot)
+	}
+	doc.Field("Valid until", ticket.ValidUntil.Format("2 January 2006 15:04")).
+		Blank().
+		Subheading("On the day").
+		Line("Please arrive 15 minutes before your slot and show this ticket at the desk.").
+		Line("Staff can find your ticket using the ticket number above.")
+
+	return doc.Bytes()
+}
+
+// SendTicketIssuedEmail emails a visitor their ticket in the format they chose.
+// Visitors who asked for large print also get the ticket as a large-print PDF.
+func SendTicketIssuedEmail(user models.User, helpRequest models.HelpRequest) error {
+	service := notifications.GetService()
+	if service == nil {
+		return fmt.Errorf("notification service is not initialized")
+	}
+
+	data := notifications.NotificationData{
+		To:               user.Email,
+		Subject:          "Your Visit Ticket is Ready - " + helpRequest.TicketNumber,
+		TemplateType:     notifications.TicketIssued,
+		NotificationType: notifications.EmailNotification,
+		TemplateData: map[string]interface{}{
+			"Name":         user.FirstName + " " + user.LastName,
+			"TicketNumber": helpRequest.TicketNumber,
+			"Reference":    helpRequest.Reference,
+			"Category":     helpRequest.Category,
+			"VisitDay":     helpRequest.VisitDay,
+			"TimeSlot":     helpRequest.TimeSlot,
+			"QRCode":       helpRequest.QRCode,
+		},
+	}
+
+	if format := notifications.VisitorCommsFormat(user.ID); format.LargePrint {
+		data.Attachments = []notifications.EmailAttachment{{
+			Filename:    fmt.Sprintf("ticket-%s-large-print.pdf", helpRequest.TicketNumber),
+			ContentType: "application/pdf",
+			Content:     RenderTicketFor(ticketForHelpRequest(user, helpRequest), format),
+		}}
+	}
+	return service.SendNotification(data, user)
+}
+
+// ticketForHelpRequest returns the ticket issued for a help request, or one built
+// from the request when the ticket has not been saved yet
+func ticketForHelpRequest(user models.User, helpRequest models.HelpRequest) models.Ticket {
+	var ticket models.Ticket
+	if err := db.DB.Where("help_request_id = ?", helpRequest.ID).Order("id DESC").First(&ticket).Error; err == nil {
+		return ticket
+	}
+
+	visitDate, _ := time.ParseInLocation("2006-01-02", helpRequest.VisitDay, time.Local)
+	return models.Ticket{
+		TicketNumber:  helpRequest.TicketNumber,
+		HelpRequestID: helpRequest.ID,
+		VisitorID:     user.ID,
+		VisitorName:   user.FirstName + " " + user.LastName,
+		Category:      helpRequest.Category,
+		VisitDate:     visitDate,
+		TimeSlot:      helpRequest.TimeSlot,
+		ValidUntil:    visitDate.Add(24*time.Hour - time.Second),
+	}
+}
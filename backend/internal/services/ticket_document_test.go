@@ -0,0 +1,66 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+)
+
+// pdfContent returns a PDF's streams inflated, so the text drawn on its pages can
+// be searched
+func pdfContent(t *testing.T, pdf []byte) []byte {
+	t.Helper()
+	var content []byte
+	for _, stream := range regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllSubmatch(pdf, -1) {
+		r, err := zlib.NewReader(bytes.NewReader(stream[1]))
+		if err != nil {
+			content = append(content, stream[1]...)
+			continue
+		}
+		inflated, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("inflate: %v", err)
+		}
+		content = append(content, inflated...)
+	}
+	return content
+}
+
+func TestRenderTicketFor(t *testing.T) {
+	ticket := models.Ticket{
+		TicketNumber: "T-1001",
+		VisitorName:  "Jane Smith",
+		Category:     "food",
+		VisitDate:    time.Date(2026, 3, 10, 0, 0, 0, 0, time.Local),
+		TimeSlot:     "10:00",
+		ValidUntil:   time.Date(2026, 3, 10, 23, 59, 0, 0, time.Local),
+	}
+	cases := []struct {
+		format  notifications.CommsFormat
+		want    []string
+		notWant []string
+	}{
+		{notifications.CommsFormat{}, []string{"(Ticket number: T-1001)", " 11.00 Tf"}, []string{"What to do", " 18.00 Tf"}},
+		{notifications.CommsFormat{PlainLanguage: true}, []string{"(Your ticket number: T-1001)", "(What to do)"}, []string{"Valid until"}},
+		{notifications.CommsFormat{LargePrint: true}, []string{" 18.00 Tf", " 26.00 Tf", "(Ticket number: T-1001)"}, []string{" 11.00 Tf"}},
+	}
+	for _, tc := range cases {
+		pdf := pdfContent(t, RenderTicketFor(ticket, tc.format))
+		for _, want := range tc.want {
+			if !bytes.Contains(pdf, []byte(want)) {
+				t.Errorf("%+v: ticket is missing %q", tc.format, want)
+			}
+		}
+		for _, notWant := range tc.notWant {
+			if bytes.Contains(pdf, []byte(notWant)) {
+				t.Errorf("%+v: ticket should not contain %q", tc.format, notWant)
+			}
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/websocket"
+
+	"gorm.io/gorm"
+)
+
+// ticketFraudAlertTopic is the WebSocket topic check-in desks subscribe to
+const ticketFraudAlertTopic = "ticket_fraud_alerts"
+
+const (
+	// defaultTicketScanGraceMinutes is how far outside opening hours a ticket can be
+	// scanned before it is flagged
+	defaultTicketScanGraceMinutes = 60
+	// defaultTicketDeviceMaxVisitors is how many visitors one device can check in for
+	// on a day before it is flagged
+	defaultTicketDeviceMaxVisitors = 1
+	// issuedClockSkew allows for small clock differences between servers
+	issuedClockSkew = 2 * time.Minute
+)
+
+var (
+	ErrTicketFraudEventNotFound = errors.New("fraud event not found")
+	ErrTicketFraudReviewed      = errors.New("fraud event has already been reviewed")
+	ErrTicketFraudDecision      = errors.New("decision must be confirmed or dismissed")
+)
+
+// TicketScanContext describes where and how a ticket was presented
+type TicketScanContext struct {
+	Method      string
+	DeviceID    string
+	IPAddress   string
+	StaffID     *uint
+	SelfService bool
+}
+
+// TicketScanDevice identifies the device a scan came from: the X-Device-ID header when
+// the client sends one, otherwise a fingerprint of its address and browser
+func TicketScanDevice(deviceHeader, ipAddress, userAgent string) string {
+	if deviceHeader = strings.TrimSpace(deviceHeader); deviceHeader != "" {
+		if len(deviceHeader) > 100 {
+			deviceHeader = deviceHeader[:100]
+		}
+		return deviceHeader
+	}
+	sum := sha256.Sum256([]byte(ipAddress + "|" + userAgent))
+	return "fp:" + hex.EncodeToString(sum[:8])
+}
+
+// TicketFraudService checks ticket scans for duplicate use, impossible timing and
+// shared devices. Suspect check-ins are held and recorded for review instead of
+// being accepted.
+type TicketFraudService struct {
+	db *gorm.DB
+}
+
+// NewTicketFraudService creates a new ticket fraud service
+func NewTicketFraudService() *TicketFraudService {
+	return &TicketFraudService{db: db.DB}
+}
+
+// Inspect records a scan of a ticket and returns the fraud events it raised. The scan
+// should only go ahead when no events are returned.
+func (fs *TicketFraudService) Inspect(ticket *models.Ticket, scan TicketScanContext) ([]models.TicketFraudEvent, error) {
+	now := time.Now()
+	suspicions := map[string]string{}
+	if detail := fs.duplicateScan(ticket, scan, now); detail != "" {
+		suspicions[models.TicketFraudDuplicateScan] = detail
+	}
+	if detail := fs.impossibleTime(ticket, now); detail != "" {
+		suspicions[models.TicketFraudImpossibleTime] = detail
+	}
+	if scan.SelfService {
+		if detail := fs.sharedDevice(ticket, scan, now); detail != "" {
+			suspicions[models.TicketFraudSharedDevice] = detail
+		}
+	}
+
+	// Staff may already have looked into a reason for this ticket and cleared it
+	if len(suspicions) > 0 {
+		var cleared []string
+		fs.db.Model(&models.TicketFraudEvent{}).
+			Where("ticket_id = ? AND status = ?", ticket.ID, models.TicketFraudDismissed).
+			Distinct().Pluck("reason", &cleared)
+		clearDismissedSuspicions(suspicions, cleared, ticket.UsedAt != nil)
+	}
+
+	record := models.TicketScan{
+		TicketID:     ticket.ID,
+		TicketNumber: ticket.TicketNumber,
+		VisitorID:    ticket.VisitorID,
+		Method:       scan.Method,
+		DeviceID:     scan.DeviceID,
+		IPAddress:    scan.IPAddress,
+		StaffID:      scan.StaffID,
+		SelfService:  scan.SelfService,
+		Outcome:      models.TicketScanAccepted,
+		ScannedAt:    now,
+	}
+	if len(suspicions) > 0 {
+		record.Outcome = models.TicketScanFlagged
+	}
+
+	var events []models.TicketFraudEvent
+	err := fs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		for _, reason := range []string{models.TicketFraudDuplicateScan, models.TicketFraudImpossibleTime, models.TicketFraudSharedDevice} {
+			detail, ok := suspicions[reason]
+			if !ok {
+				continue
+			}
+			event := models.TicketFraudEvent{
+				TicketID:     ticket.ID,
+				TicketNumber: ticket.TicketNumber,
+				VisitorID:    ticket.VisitorID,
+				ScanID:       record.ID,
+				Reason:       reason,
+				Details:      detail,
+				DeviceID:     scan.DeviceID,
+				Status:       models.TicketFraudOpen,
+			}
+			if err := tx.Create(&event).Error; err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) > 0 {
+		fs.alertDesk(ticket, scan, events)
+	}
+	return events, nil
+}
+
+// clearDismissedSuspicions drops the suspicions staff have already dismissed for a
+// ticket. A ticket that has been used stays used.
+func clearDismissedSuspicions(suspicions map[string]string, cleared []string, used bool) {
+	for _, reason := range cleared {
+		if reason != models.TicketFraudDuplicateScan || !used {
+			delete(suspicions, reason)
+		}
+	}
+}
+
+// duplicateScan flags a ticket that has already been checked in, or that has been
+// presented on another device today, e.g. a shared screenshot of its QR code
+func (fs *TicketFraudService) duplicateScan(ticket *models.Ticket, scan TicketScanContext, now time.Time) string {
+	if ticket.UsedAt != nil || ticket.Status == models.TicketStatusUsed {
+		if ticket.UsedAt != nil {
+			return fmt.Sprintf("Ticket was already checked in at %s", ticket.UsedAt.Format("15:04 on 2 Jan"))
+		}
+		return "Ticket has already been used"
+	}
+
+	if scan.DeviceID == "" {
+		return ""
+	}
+	start, _ := dayRange(now)
+	query := fs.db.Where("ticket_id = ? AND device_id <> '' AND device_id <> ? AND scanned_at >= ?", ticket.ID, scan.DeviceID, start)
+	if !scan.SelfService {
+		// Desk scanners and staff browsers are different devices in normal use
+		query = query.Where("self_service = ?", true)
+	}
+	var other models.TicketScan
+	err := query.Order("scanned_at DESC").First(&other).Error
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("Ticket was also presented on another device at %s", other.ScannedAt.Format("15:04"))
+}
+
+// scanGrace returns how far outside opening hours a scan can be, set by
+// TICKET_SCAN_GRACE_MINUTES
+func scanGrace() time.Duration {
+	if minutes, err := strconv.Atoi(os.Getenv("TICKET_SCAN_GRACE_MINUTES")); err == nil && minutes >= 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultTicketScanGraceMinutes * time.Minute
+}
+
+// clockOn returns an HH:MM time on a day
+func clockOn(day time.Time, clock string) (time.Time, bool) {
+	parsed, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), parsed.Hour(), parsed.Minute(), 0, 0, day.Location()), true
+}
+
+// impossibleTime flags a scan before the ticket existed, or well outside the opening
+// hours of the ticket's queue
+func (fs *TicketFraudService) impossibleTime(ticket *models.Ticket, now time.Time) string {
+	if !ticket.IssuedAt.IsZero() && ticket.IssuedAt.After(now.Add(issuedClockSkew)) {
+		return fmt.Sprintf("Scanned at %s, before the ticket was issued at %s",
+			now.Format("15:04"), ticket.IssuedAt.Format("15:04 on 2 Jan"))
+	}
+
+	var settings models.QueueSettings
+	if err := fs.db.Where("category = ?", ticket.Category).First(&settings).Error; err != nil {
+		return ""
+	}
+	return outsideOpeningHours(now, ticket.Category, &settings, scanGrace())
+}
+
+// outsideOpeningHours describes a scan more than the grace period before a queue opens
+// or after it closes
+func outsideOpeningHours(now time.Time, category string, settings *models.QueueSettings, grace time.Duration) string {
+	if opens, ok := clockOn(now, settings.StartTime); ok && now.Before(opens.Add(-grace)) {
+		return fmt.Sprintf("Scanned at %s, before the %s queue opens at %s", now.Format("15:04"), category, settings.StartTime)
+	}
+	if closes, ok := clockOn(now, settings.EndTime); ok && now.After(closes.Add(grace)) {
+		return fmt.Sprintf("Scanned at %s, after the %s queue closed at %s", now.Format("15:04"), category, settings.EndTime)
+	}
+	return ""
+}
+
+// deviceMaxVisitors returns how many visitors one device can check in for on a day,
+// set by TICKET_DEVICE_MAX_VISITORS
+func deviceMaxVisitors() int64 {
+	if limit, err := strconv.Atoi(os.Getenv("TICKET_DEVICE_MAX_VISITORS")); err == nil && limit > 0 {
+		return int64(limit)
+	}
+	return defaultTicketDeviceMaxVisitors
+}
+
+// sharedDevice flags a visitor's device that has already checked in tickets for other
+// visitors today
+func (fs *TicketFraudService) sharedDevice(ticket *models.Ticket, scan TicketScanContext, now time.Time) string {
+	if scan.DeviceID == "" {
+		return ""
+	}
+	start, _ := dayRange(now)
+	var others int64
+	fs.db.Model(&models.TicketScan{}).
+		Where("device_id = ? AND self_service = ? AND outcome = ? AND scanned_at >= ? AND visitor_id <> ?",
+			scan.DeviceID, true, models.TicketScanAccepted, start, ticket.VisitorID).
+		Distinct("visitor_id").
+		Count(&others)
+	if others+1 <= deviceMaxVisitors() {
+		return ""
+	}
+	return fmt.Sprintf("This device has already checked in tickets for %d other visitor(s) today", others)
+}
+
+// alertDesk warns the check-in desk about a held scan
+func (fs *TicketFraudService) alertDesk(ticket *models.Ticket, scan TicketScanContext, events []models.TicketFraudEvent) {
+	reasons := make([]string, 0, len(events))
+	for _, event := range events {
+		reasons = append(reasons, event.Details)
+	}
+	title := "Check-in held: possible ticket misuse"
+	message := fmt.Sprintf("Ticket %s (%s): %s. Please check the visitor's ID before continuing.",
+		ticket.TicketNumber, ticket.VisitorName, strings.Join(reasons, "; "))
+
+	payload := map[string]interface{}{
+		"type":          "ticket_fraud_alert",
+		"ticket_id":     ticket.ID,
+		"ticket_number": ticket.TicketNumber,
+		"visitor_id":    ticket.VisitorID,
+		"events":        events,
+		"title":         title,
+		"message":       message,
+		"timestamp":     time.Now(),
+	}
+	manager := websocket.GetGlobalManager()
+	if err := manager.BroadcastToTopic(ticketFraudAlertTopic, payload); err != nil {
+		log.Printf("Failed to broadcast ticket fraud alert: %v", err)
+	}
+	for _, role := range []string{models.RoleStaff, models.RoleAdmin} {
+		if err := manager.BroadcastToRole(role, payload); err != nil {
+			log.Printf("Failed to broadcast ticket fraud alert to %s: %v", role, err)
+		}
+	}
+
+	if scan.StaffID != nil && *scan.StaffID > 0 {
+		NewRealtimeNotificationService().SendNotification(RealtimeNotificationData{
+			UserID:    *scan.StaffID,
+			Type:      "ticket_fraud_alert",
+			Title:     title,
+			Message:   message,
+			Priority:  "high",
+			Category:  "queue",
+			ActionURL: "/admin/checkin/fraud-events",
+			Channels:  []string{"websocket"},
+			Data: map[string]interface{}{
+				"ticket_id": ticket.ID,
+				"event_id":  events[0].ID,
+			},
+		})
+	}
+}
+
+// List returns fraud events, newest first, optionally filtered by status and reason
+func (fs *TicketFraudService) List(status, reason string, limit int) ([]models.TicketFraudEvent, error) {
+	query := fs.db.Model(&models.TicketFraudEvent{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+	var events []models.TicketFraudEvent
+	err := query.Order("created_at DESC").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// Review records a staff decision on a fraud event. Dismissing an event lets the
+// ticket be checked in again without the same reason holding it.
+func (fs *TicketFraudService) Review(id, reviewerID uint, decision, notes string) (*models.TicketFraudEvent, error) {
+	if decision != models.TicketFraudConfirmed && decision != models.TicketFraudDismissed {
+		return nil, ErrTicketFraudDecision
+	}
+
+	var event models.TicketFraudEvent
+	if err := fs.db.First(&event, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTicketFraudEventNotFound
+		}
+		return nil, err
+	}
+	if event.Status != models.TicketFraudOpen {
+		return nil, ErrTicketFraudReviewed
+	}
+
+	now := time.Now()
+	event.Status = decision
+	event.ReviewedBy = &reviewerID
+	event.ReviewedAt = &now
+	event.ReviewNotes = notes
+	if err := fs.db.Save(&event).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
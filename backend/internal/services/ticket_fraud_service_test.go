@@ -0,0 +1,121 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestTicketScanDevice(t *testing.T) {
+	if got := TicketScanDevice("  tablet-3 ", "10.0.0.1", "Safari"); got != "tablet-3" {
+		t.Errorf("header: got %q", got)
+	}
+	if got := TicketScanDevice(strings.Repeat("d", 150), "", ""); len(got) != 100 {
+		t.Errorf("long header kept %d characters", len(got))
+	}
+
+	phone := TicketScanDevice("", "10.0.0.1", "Safari")
+	if !strings.HasPrefix(phone, "fp:") || len(phone) != 19 {
+		t.Errorf("fingerprint %q", phone)
+	}
+	if phone != TicketScanDevice("", "10.0.0.1", "Safari") || phone == TicketScanDevice("", "10.0.0.2", "Safari") {
+		t.Error("fingerprint should be stable per address and browser")
+	}
+}
+
+func TestDuplicateScanWithoutLookup(t *testing.T) {
+	fs := &TicketFraudService{}
+	now := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	used := time.Date(2026, 5, 4, 9, 15, 0, 0, time.UTC)
+
+	if got := fs.duplicateScan(&models.Ticket{UsedAt: &used}, TicketScanContext{}, now); got != "Ticket was already checked in at 09:15 on 4 May" {
+		t.Errorf("used ticket: got %q", got)
+	}
+	if got := fs.duplicateScan(&models.Ticket{Status: models.TicketStatusUsed}, TicketScanContext{}, now); got != "Ticket has already been used" {
+		t.Errorf("used status: got %q", got)
+	}
+	// Without a device there is nothing to compare against
+	if got := fs.duplicateScan(&models.Ticket{}, TicketScanContext{}, now); got != "" {
+		t.Errorf("no device: got %q", got)
+	}
+}
+
+func TestImpossibleTimeBeforeIssue(t *testing.T) {
+	fs := &TicketFraudService{}
+	now := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	ticket := &models.Ticket{IssuedAt: now.Add(10 * time.Minute)}
+	if got := fs.impossibleTime(ticket, now); !strings.HasPrefix(got, "Scanned at 10:00, before the ticket was issued") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestOutsideOpeningHours(t *testing.T) {
+	settings := &models.QueueSettings{StartTime: "09:00", EndTime: "15:30"}
+	day := func(hour, minute int) time.Time { return time.Date(2026, 5, 4, hour, minute, 0, 0, time.UTC) }
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{day(7, 59), "Scanned at 07:59, before the food queue opens at 09:00"},
+		{day(8, 0), ""}, // Within the grace period
+		{day(12, 0), ""},
+		{day(16, 30), ""},
+		{day(16, 31), "Scanned at 16:31, after the food queue closed at 15:30"},
+	}
+	for _, tt := range tests {
+		if got := outsideOpeningHours(tt.at, "food", settings, time.Hour); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.at.Format("15:04"), got, tt.want)
+		}
+	}
+
+	// Times that cannot be read are not checked
+	if got := outsideOpeningHours(day(3, 0), "food", &models.QueueSettings{StartTime: "9am"}, 0); got != "" {
+		t.Errorf("unreadable opening time: got %q", got)
+	}
+}
+
+func TestClearDismissedSuspicions(t *testing.T) {
+	suspicions := func() map[string]string {
+		return map[string]string{
+			models.TicketFraudDuplicateScan:  "d",
+			models.TicketFraudImpossibleTime: "i",
+			models.TicketFraudSharedDevice:   "s",
+		}
+	}
+	cleared := []string{models.TicketFraudDuplicateScan, models.TicketFraudSharedDevice}
+
+	unused := suspicions()
+	clearDismissedSuspicions(unused, cleared, false)
+	if want := map[string]string{models.TicketFraudImpossibleTime: "i"}; !reflect.DeepEqual(unused, want) {
+		t.Errorf("unused ticket: got %v", unused)
+	}
+
+	used := suspicions()
+	clearDismissedSuspicions(used, cleared, true)
+	if want := map[string]string{models.TicketFraudDuplicateScan: "d", models.TicketFraudImpossibleTime: "i"}; !reflect.DeepEqual(used, want) {
+		t.Errorf("used ticket: got %v", used)
+	}
+}
+
+func TestTicketFraudLimits(t *testing.T) {
+	t.Setenv("TICKET_SCAN_GRACE_MINUTES", "0")
+	t.Setenv("TICKET_DEVICE_MAX_VISITORS", "3")
+	if scanGrace() != 0 || deviceMaxVisitors() != 3 {
+		t.Errorf("got %v and %d", scanGrace(), deviceMaxVisitors())
+	}
+	t.Setenv("TICKET_SCAN_GRACE_MINUTES", "-5")
+	t.Setenv("TICKET_DEVICE_MAX_VISITORS", "0")
+	if scanGrace() != time.Hour || deviceMaxVisitors() != 1 {
+		t.Errorf("defaults: got %v and %d", scanGrace(), deviceMaxVisitors())
+	}
+}
+
+func TestReviewTicketFraudDecision(t *testing.T) {
+	fs := &TicketFraudService{}
+	if _, err := fs.Review(1, 2, models.TicketFraudOpen, ""); err != ErrTicketFraudDecision {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// scheduledReleaseGrace is how late a slot is still released, such as after a
+	// restart; a slot found later than this is recorded as missed
+	scheduledReleaseGrace     = 2 * time.Hour
+	scheduledReleaseListLimit = 200
+)
+
+// Ticket release schedule errors
+var (
+	ErrInvalidReleaseSchedule   = errors.New("invalid ticket release schedule")
+	ErrTicketReleaseUnavailable = errors.New("ticket release is not available")
+)
+
+// TicketReleaser releases tickets for a visit day under the stored daily capacity and
+// returns the recorded release run's ID (0 when it could not be recorded) and the
+// tickets issued. The admin handlers, which own the release, set it.
+var TicketReleaser func(releaseDate string) (uint, int)
+
+// releaseWeekdays are the days tickets may be released on
+var releaseWeekdays = []time.Weekday{time.Tuesday, time.Wednesday, time.Thursday}
+
+// TicketReleaseScheduleInput changes the schedule. Fields left out are unchanged.
+type TicketReleaseScheduleInput struct {
+	Enabled   *bool    `json:"enabled"`
+	Weekdays  []string `json:"weekdays"`   // e.g. ["tuesday", "thursday"]
+	ReleaseAt *string  `json:"release_at"` // HH:MM
+}
+
+// TicketReleaseScheduleStatus is the schedule as admins see it
+type TicketReleaseScheduleStatus struct {
+	Enabled   bool       `json:"enabled"`
+	Weekdays  []string   `json:"weekdays"`
+	ReleaseAt string     `json:"release_at"`
+	NextRunAt *time.Time `json:"next_run_at"` // Empty while disabled
+	UpdatedBy *uint      `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// TicketReleaseScheduleService runs the ticket release automatically at its scheduled
+// slots and lets admins pause or move the schedule
+type TicketReleaseScheduleService struct {
+	db *gorm.DB
+}
+
+// NewTicketReleaseScheduleService creates a new ticket release schedule service
+func NewTicketReleaseScheduleService() *TicketReleaseScheduleService {
+	return &TicketReleaseScheduleService{db: db.DB}
+}
+
+// Schedule returns the stored schedule, or the default when none has been saved
+func (ts *TicketReleaseScheduleService) Schedule() (*models.TicketReleaseSchedule, error) {
+	var schedule models.TicketReleaseSchedule
+	if err := ts.db.Order("id").First(&schedule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			schedule = models.DefaultTicketReleaseSchedule()
+			return &schedule, nil
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// Status returns the schedule and when it next runs
+func (ts *TicketReleaseScheduleService) Status(now time.Time) (*TicketReleaseScheduleStatus, error) {
+	schedule, err := ts.Schedule()
+	if err != nil {
+		return nil, err
+	}
+	return releaseScheduleStatus(schedule, now), nil
+}
+
+// Update changes the schedule
+func (ts *TicketReleaseScheduleService) Update(input TicketReleaseScheduleInput, updatedBy uint, now time.Time) (*TicketReleaseScheduleStatus, error) {
+	schedule, err := ts.Schedule()
+	if err != nil {
+		return nil, err
+	}
+	if err := applyReleaseSchedule(schedule, input); err != nil {
+		return nil, err
+	}
+	schedule.UpdatedBy = &updatedBy
+	if err := ts.db.Save(schedule).Error; err != nil {
+		return nil, err
+	}
+	return releaseScheduleStatus(schedule, now), nil
+}
+
+// RunDue releases tickets when today's slot has come and nothing has handled it yet.
+// It returns the record of what it did, or nil when no slot was due.
+func (ts *TicketReleaseScheduleService) RunDue(now time.Time) (*models.ScheduledReleaseRun, error) {
+	schedule, err := ts.Schedule()
+	if err != nil {
+		return nil, err
+	}
+	if !schedule.Enabled {
+		return nil, nil
+	}
+	slot, ok := schedule.SlotOn(now)
+	if !ok || now.Before(slot) {
+		return nil, nil
+	}
+	// Leave the slot for a process that can release, rather than burn it
+	if TicketReleaser == nil {
+		return nil, ErrTicketReleaseUnavailable
+	}
+
+	// Claim the slot; another instance, or an earlier run, may already have it
+	run := &models.ScheduledReleaseRun{
+		ScheduledFor: slot,
+		ReleaseDate:  slot.Format("2006-01-02"),
+		Status:       models.ScheduledReleaseRunning,
+		StartedAt:    now,
+	}
+	result := ts.db.Clauses(clause.OnConflict{DoNothing: true}).Create(run)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	if now.After(slot.Add(scheduledReleaseGrace)) {
+		return run, ts.finish(run, models.ScheduledReleaseSkipped,
+			fmt.Sprintf("Missed: the scheduler was not running at %s", slot.Format("15:04")), now)
+	}
+
+	var released int64
+	if err := ts.db.Model(&models.ReleaseRun{}).Where("release_date = ?", run.ReleaseDate).Count(&released).Error; err != nil {
+		return run, ts.finish(run, models.ScheduledReleaseFailed, err.Error(), now)
+	}
+	if released > 0 {
+		return run, ts.finish(run, models.ScheduledReleaseSkipped, "Tickets for this day were already released by hand", now)
+	}
+
+	releaseRunID, issued := TicketReleaser(run.ReleaseDate)
+	run.TotalReleased = issued
+	reason := ""
+	if releaseRunID != 0 {
+		run.ReleaseRunID = &releaseRunID
+	} else {
+		reason = "Tickets were issued but the release could not be recorded"
+	}
+	return run, ts.finish(run, models.ScheduledReleaseReleased, reason, time.Now())
+}
+
+// Runs lists what the scheduler did at past slots, newest first
+func (ts *TicketReleaseScheduleService) Runs(limit int) ([]models.ScheduledReleaseRun, error) {
+	if limit <= 0 || limit > scheduledReleaseListLimit {
+		limit = scheduledReleaseListLimit
+	}
+	var runs []models.ScheduledReleaseRun
+	err := ts.db.Order("scheduled_for DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// finish records how a claimed slot ended
+func (ts *TicketReleaseScheduleService) finish(run *models.ScheduledReleaseRun, status, reason string, now time.Time) error {
+	run.Status = status
+	run.Reason = reason
+	run.CompletedAt = &now
+	return ts.db.Model(run).Updates(map[string]interface{}{
+		"status":         run.Status,
+		"reason":         run.Reason,
+		"release_run_id": run.ReleaseRunID,
+		"total_released": run.TotalReleased,
+		"completed_at":   run.CompletedAt,
+	}).Error
+}
+
+// applyReleaseSchedule checks a schedule change and applies it
+func applyReleaseSchedule(schedule *models.TicketReleaseSchedule, input TicketReleaseScheduleInput) error {
+	if input.Enabled != nil {
+		schedule.Enabled = *input.Enabled
+	}
+	if input.ReleaseAt != nil {
+		at, err := time.Parse("15:04", strings.TrimSpace(*input.ReleaseAt))
+		if err != nil {
+			return fmt.Errorf("%w: release_at must be HH:MM", ErrInvalidReleaseSchedule)
+		}
+		schedule.ReleaseAt = at.Format("15:04")
+	}
+	if input.Weekdays != nil {
+		if len(input.Weekdays) == 0 {
+			return fmt.Errorf("%w: choose at least one day", ErrInvalidReleaseSchedule)
+		}
+		for _, name := range input.Weekdays {
+			if !slices.ContainsFunc(releaseWeekdays, func(day time.Weekday) bool { return weekdayNamed(day, name) }) {
+				return fmt.Errorf("%w: tickets can only be released on Tuesday, Wednesday or Thursday, not %q", ErrInvalidReleaseSchedule, name)
+			}
+		}
+		days := []time.Weekday{}
+		for _, day := range releaseWeekdays {
+			if slices.ContainsFunc(input.Weekdays, func(name string) bool { return weekdayNamed(day, name) }) {
+				days = append(days, day)
+			}
+		}
+		schedule.SetDays(days)
+	}
+	return nil
+}
+
+// weekdayNamed reports whether a day name, in any case, is the weekday
+func weekdayNamed(day time.Weekday, name string) bool {
+	return strings.EqualFold(strings.TrimSpace(name), day.String())
+}
+
+// releaseScheduleStatus builds the admin view of a schedule
+func releaseScheduleStatus(schedule *models.TicketReleaseSchedule, now time.Time) *TicketReleaseScheduleStatus {
+	status := &TicketReleaseScheduleStatus{
+		Enabled:   schedule.Enabled,
+		Weekdays:  []string{},
+		ReleaseAt: schedule.ReleaseAt,
+		UpdatedBy: schedule.UpdatedBy,
+	}
+	for _, day := range schedule.Days() {
+		status.Weekdays = append(status.Weekdays, day.String())
+	}
+	if schedule.ID != 0 {
+		updatedAt := schedule.UpdatedAt
+		status.UpdatedAt = &updatedAt
+	}
+	if !schedule.Enabled {
+		return status
+	}
+	for offset := 0; offset <= 7; offset++ {
+		if slot, ok := schedule.SlotOn(now.AddDate(0, 0, offset)); ok && slot.After(now) {
+			status.NextRunAt = &slot
+			break
+		}
+	}
+	return status
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestApplyReleaseSchedule(t *testing.T) {
+	schedule := models.DefaultTicketReleaseSchedule()
+	disabled, at := false, "10:30"
+	err := applyReleaseSchedule(&schedule, TicketReleaseScheduleInput{
+		Enabled:   &disabled,
+		Weekdays:  []string{"thursday", "Tuesday"},
+		ReleaseAt: &at,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schedule.Enabled || schedule.ReleaseAt != "10:30" ||
+		!slices.Equal(schedule.Days(), []time.Weekday{time.Tuesday, time.Thursday}) {
+		t.Errorf("unexpected schedule %+v", schedule)
+	}
+
+	invalid := []TicketReleaseScheduleInput{
+		{Weekdays: []string{"monday"}},
+		{Weekdays: []string{}},
+		{ReleaseAt: func() *string { s := "9am"; return &s }()},
+	}
+	for _, input := range invalid {
+		if err := applyReleaseSchedule(&schedule, input); !errors.Is(err, ErrInvalidReleaseSchedule) {
+			t.Errorf("%+v: got %v, want ErrInvalidReleaseSchedule", input, err)
+		}
+	}
+}
+
+func TestReleaseScheduleNextRun(t *testing.T) {
+	schedule := models.DefaultTicketReleaseSchedule()
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		// Monday: the next release is Tuesday morning
+		{time.Date(2025, 10, 13, 12, 0, 0, 0, time.Local), time.Date(2025, 10, 14, 9, 0, 0, 0, time.Local)},
+		// Tuesday before the release
+		{time.Date(2025, 10, 14, 8, 59, 0, 0, time.Local), time.Date(2025, 10, 14, 9, 0, 0, 0, time.Local)},
+		// Thursday after the release: the next is the following Tuesday
+		{time.Date(2025, 10, 16, 9, 1, 0, 0, time.Local), time.Date(2025, 10, 21, 9, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		status := releaseScheduleStatus(&schedule, tt.now)
+		if status.NextRunAt == nil || !status.NextRunAt.Equal(tt.want) {
+			t.Errorf("at %s: next run %v, want %s", tt.now, status.NextRunAt, tt.want)
+		}
+	}
+
+	schedule.Enabled = false
+	if status := releaseScheduleStatus(&schedule, tests[0].now); status.NextRunAt != nil {
+		t.Errorf("disabled schedule has a next run at %s", status.NextRunAt)
+	}
+}
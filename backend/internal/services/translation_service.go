@@ -0,0 +1,233 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+)
+
+// Template translation errors
+var (
+	ErrTranslationNotFound = errors.New("translation not found")
+	ErrInvalidTranslation  = errors.New("invalid translation")
+)
+
+// LanguageCoverage is how much of the transactional template set is translated into
+// a language that recipients ask for
+type LanguageCoverage struct {
+	Language   string   `json:"language"`
+	Users      int64    `json:"users"`     // Users who chose this language
+	Locations  []string `json:"locations"` // Locations defaulting to it; "" is the organisation default
+	Translated int      `json:"translated"`
+	Missing    []string `json:"missing"`   // Template types that fall back to another language
+	Coverage   float64  `json:"coverage"`  // Percentage of templates translated
+	Messages   int64    `json:"messages"`  // Messages sent to recipients asking for it in the period
+	FellBack   int64    `json:"fell_back"` // Of those, how many were sent in another language
+}
+
+// TranslationCoverage reports untranslated templates for every language in use
+type TranslationCoverage struct {
+	Since     time.Time          `json:"since"`
+	Templates int                `json:"templates"`
+	Languages []LanguageCoverage `json:"languages"`
+}
+
+// TranslationService manages translations of the transactional notification
+// templates and reports where they are missing
+type TranslationService struct {
+	db *gorm.DB
+}
+
+// NewTranslationService creates a new translation service
+func NewTranslationService() *TranslationService {
+	return &TranslationService{db: db.DB}
+}
+
+// List returns template translations, optionally for one template type or language
+func (ts *TranslationService) List(templateType, language string) ([]models.NotificationTemplateTranslation, error) {
+	query := ts.db.Order("template_type ASC, language ASC")
+	if templateType != "" {
+		query = query.Where("template_type = ?", templateType)
+	}
+	if language != "" {
+		language, _ = notifications.NormalizeLanguage(language)
+		query = query.Where("language = ?", language)
+	}
+	var translations []models.NotificationTemplateTranslation
+	err := query.Find(&translations).Error
+	return translations, err
+}
+
+// Save adds or replaces a template's translation into a language
+func (ts *TranslationService) Save(templateType, language, subject, body string, updatedBy uint) (*models.NotificationTemplateTranslation, error) {
+	if !isTranslatableTemplate(templateType) {
+		return nil, fmt.Errorf("%w: unknown template %q", ErrInvalidTranslation, templateType)
+	}
+	language, ok := notifications.NormalizeLanguage(language)
+	if !ok || language == "" || language == models.DefaultLanguage {
+		return nil, fmt.Errorf("%w: language must be a language tag such as pl other than en", ErrInvalidTranslation)
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil, fmt.Errorf("%w: body is required", ErrInvalidTranslation)
+	}
+	if _, err := template.New("body").Parse(body); err != nil {
+		return nil, fmt.Errorf("%w: invalid body template: %v", ErrInvalidTranslation, err)
+	}
+	if _, err := template.New("subject").Parse(subject); err != nil {
+		return nil, fmt.Errorf("%w: invalid subject template: %v", ErrInvalidTranslation, err)
+	}
+
+	var translation models.NotificationTemplateTranslation
+	if err := ts.db.Where("template_type = ? AND language = ?", templateType, language).
+		Attrs(models.NotificationTemplateTranslation{TemplateType: templateType, Language: language}).
+		FirstOrInit(&translation).Error; err != nil {
+		return nil, err
+	}
+	translation.Subject = strings.TrimSpace(subject)
+	translation.Body = body
+	translation.UpdatedBy = &updatedBy
+	if err := ts.db.Save(&translation).Error; err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
+// Delete removes a template's translation, so recipients fall back along their chain
+func (ts *TranslationService) Delete(templateType, language string) error {
+	language, _ = notifications.NormalizeLanguage(language)
+	result := ts.db.Where("template_type = ? AND language = ?", templateType, language).
+		Delete(&models.NotificationTemplateTranslation{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTranslationNotFound
+	}
+	return nil
+}
+
+// Coverage lists, for every language users or locations ask for and every language
+// with translations, the templates still untranslated and how many messages fell
+// back to another language since the given time
+func (ts *TranslationService) Coverage(since time.Time) (*TranslationCoverage, error) {
+	templates := make([]string, 0)
+	for _, templateType := range notifications.TranslatableTemplates() {
+		templates = append(templates, templateType.String())
+	}
+	coverage := &TranslationCoverage{Since: since, Templates: len(templates), Languages: []LanguageCoverage{}}
+	languages := map[string]*LanguageCoverage{}
+	use := func(language string) *LanguageCoverage {
+		if entry, ok := languages[language]; ok {
+			return entry
+		}
+		entry := &LanguageCoverage{Language: language, Locations: []string{}}
+		languages[language] = entry
+		return entry
+	}
+
+	var userLanguages []struct {
+		Language string
+		Users    int64
+	}
+	if err := ts.db.Model(&models.User{}).Select("language, COUNT(*) AS users").
+		Where("language <> '' AND language <> ?", models.DefaultLanguage).
+		Group("language").Scan(&userLanguages).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range userLanguages {
+		use(row.Language).Users = row.Users
+	}
+
+	var profiles []models.BrandingProfile
+	if err := ts.db.Select("location", "language").
+		Where("language <> '' AND language <> ?", models.DefaultLanguage).
+		Order("location ASC").Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	for _, profile := range profiles {
+		entry := use(profile.Language)
+		entry.Locations = append(entry.Locations, profile.Location)
+	}
+
+	var translations []models.NotificationTemplateTranslation
+	if err := ts.db.Select("template_type", "language").Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	translated := map[string]map[string]bool{}
+	for _, translation := range translations {
+		if translated[translation.Language] == nil {
+			translated[translation.Language] = map[string]bool{}
+		}
+		translated[translation.Language][translation.TemplateType] = true
+		use(translation.Language)
+	}
+
+	var sent []struct {
+		Requested string
+		Messages  int64
+		FellBack  int64
+	}
+	if err := ts.db.Model(&models.NotificationLanguageLog{}).
+		Select("requested, COUNT(*) AS messages, COUNT(*) FILTER (WHERE fell_back) AS fell_back").
+		Where("created_at >= ? AND requested <> ?", since, models.DefaultLanguage).
+		Group("requested").Scan(&sent).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range sent {
+		entry := use(row.Requested)
+		entry.Messages = row.Messages
+		entry.FellBack = row.FellBack
+	}
+
+	for _, entry := range languages {
+		entry.Translated, entry.Missing = translationGaps(entry.Language, templates, translated)
+		if len(templates) > 0 {
+			entry.Coverage = math.Round(float64(entry.Translated)/float64(len(templates))*1000) / 10
+		}
+		coverage.Languages = append(coverage.Languages, *entry)
+	}
+	sort.Slice(coverage.Languages, func(i, j int) bool {
+		a, b := coverage.Languages[i], coverage.Languages[j]
+		if a.Users != b.Users {
+			return a.Users > b.Users
+		}
+		return a.Language < b.Language
+	})
+	return coverage, nil
+}
+
+// translationGaps counts the templates a recipient asking for language gets in
+// that language or its base language, and lists the rest
+func translationGaps(language string, templates []string, translated map[string]map[string]bool) (int, []string) {
+	base, _, _ := strings.Cut(language, "-")
+	count := 0
+	missing := []string{}
+	for _, templateType := range templates {
+		if translated[language][templateType] || translated[base][templateType] {
+			count++
+		} else {
+			missing = append(missing, templateType)
+		}
+	}
+	return count, missing
+}
+
+// isTranslatableTemplate reports whether templateType names a notification template
+func isTranslatableTemplate(templateType string) bool {
+	for _, known := range notifications.TranslatableTemplates() {
+		if known.String() == templateType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTranslationGaps(t *testing.T) {
+	templates := []string{"shift_reminder", "ticket_issued", "password_reset"}
+	translated := map[string]map[string]bool{
+		"pl":    {"shift_reminder": true, "ticket_issued": true, "password_reset": true},
+		"pt":    {"ticket_issued": true},
+		"pt-br": {"password_reset": true},
+	}
+	cases := []struct {
+		language string
+		count    int
+		missing  []string
+	}{
+		{"pl", 3, []string{}},
+		{"pt-br", 2, []string{"shift_reminder"}}, // The base language fills in
+		{"pt", 1, []string{"shift_reminder", "password_reset"}},
+		{"so", 0, []string{"shift_reminder", "ticket_issued", "password_reset"}},
+	}
+	for _, tc := range cases {
+		count, missing := translationGaps(tc.language, templates, translated)
+		if count != tc.count || !reflect.DeepEqual(missing, tc.missing) {
+			t.Errorf("translationGaps(%s) = %d %v, want %d %v", tc.language, count, missing, tc.count, tc.missing)
+		}
+	}
+}
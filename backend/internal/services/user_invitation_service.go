@@ -0,0 +1,385 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultInvitationExpiryHours is how long an invitation stays valid
+const defaultInvitationExpiryHours = 7 * 24
+
+var (
+	ErrInvitationNotFound   = errors.New("invitation not found")
+	ErrInvitationInvalid    = errors.New("invitation is invalid or has expired")
+	ErrInvitationClosed     = errors.New("invitation has already been accepted or revoked")
+	ErrInvitationPending    = errors.New("a pending invitation already exists for this email")
+	ErrInvitationEmailTaken = errors.New("an account with this email already exists")
+	ErrInvitationRole       = errors.New("invitations can only be sent for admin and staff accounts")
+	ErrInvitationScope      = errors.New("admin scopes can only be given to admin invitations")
+	ErrInvitationPhoneTaken = errors.New("phone number already in use")
+)
+
+// InvitationInput describes who to invite and to which role
+type InvitationInput struct {
+	Email      string
+	FirstName  string
+	LastName   string
+	Role       string
+	AdminScope string
+	Department string
+	Position   string
+}
+
+// AcceptInvitationInput is what the invitee fills in when accepting
+type AcceptInvitationInput struct {
+	Password  string
+	FirstName string
+	LastName  string
+	Phone     string
+	Address   string
+	City      string
+	Postcode  string
+}
+
+// UserInvitationService provisions staff and admin accounts through emailed invitations
+type UserInvitationService struct {
+	db *gorm.DB
+}
+
+// NewUserInvitationService creates a new invitation service
+func NewUserInvitationService() *UserInvitationService {
+	return &UserInvitationService{db: db.DB}
+}
+
+// expiry returns how long new invitations stay valid, set by INVITATION_EXPIRY_HOURS
+func (is *UserInvitationService) expiry() time.Duration {
+	if hours, err := strconv.Atoi(os.Getenv("INVITATION_EXPIRY_HOURS")); err == nil && hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultInvitationExpiryHours * time.Hour
+}
+
+// signInvitation returns the signature for an invitation token nonce
+func signInvitation(nonce string) (string, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return "", errors.New("JWT_SECRET is required to sign invitations")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("invitation:" + nonce))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// newInvitationToken creates a signed token and the hash stored for it
+func newInvitationToken() (token, hash string, err error) {
+	nonce, err := randomHex(24)
+	if err != nil {
+		return "", "", err
+	}
+	signature, err := signInvitation(nonce)
+	if err != nil {
+		return "", "", err
+	}
+	token = nonce + "." + signature
+	return token, hashInvitationToken(token), nil
+}
+
+// hashInvitationToken returns the stored form of a token
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyInvitationToken checks a token's signature before it is looked up
+func verifyInvitationToken(token string) bool {
+	nonce, signature, ok := strings.Cut(token, ".")
+	if !ok || nonce == "" {
+		return false
+	}
+	expected, err := signInvitation(nonce)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// List returns invitations, newest first, optionally filtered by status
+func (is *UserInvitationService) List(status string) ([]models.UserInvitation, error) {
+	now := time.Now()
+	query := is.db.Order("created_at DESC")
+	switch status {
+	case models.InvitationStatusPending:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", now)
+	case models.InvitationStatusAccepted:
+		query = query.Where("accepted_at IS NOT NULL")
+	case models.InvitationStatusRevoked:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NOT NULL")
+	case models.InvitationStatusExpired:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at <= ?", now)
+	}
+
+	var invitations []models.UserInvitation
+	err := query.Find(&invitations).Error
+	return invitations, err
+}
+
+// Create records an invitation and emails the signed link to the invitee
+func (is *UserInvitationService) Create(input InvitationInput, invitedBy uint) (*models.UserInvitation, error) {
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+	if input.Role != models.RoleAdmin && input.Role != models.RoleStaff {
+		return nil, ErrInvitationRole
+	}
+	if input.AdminScope != "" {
+		if _, ok := models.FindAdminScope(input.AdminScope); !ok || input.Role != models.RoleAdmin {
+			return nil, ErrInvitationScope
+		}
+	}
+
+	var users int64
+	is.db.Model(&models.User{}).Where("LOWER(email) = ?", input.Email).Count(&users)
+	if users > 0 {
+		return nil, ErrInvitationEmailTaken
+	}
+	var pending int64
+	is.db.Model(&models.UserInvitation{}).
+		Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", input.Email, time.Now()).
+		Count(&pending)
+	if pending > 0 {
+		return nil, ErrInvitationPending
+	}
+
+	token, hash, err := newInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invitation := models.UserInvitation{
+		Email:      input.Email,
+		FirstName:  input.FirstName,
+		LastName:   input.LastName,
+		Role:       input.Role,
+		AdminScope: input.AdminScope,
+		Department: input.Department,
+		Position:   input.Position,
+		TokenHash:  hash,
+		ExpiresAt:  now.Add(is.expiry()),
+		InvitedBy:  invitedBy,
+		SentCount:  1,
+		LastSentAt: now,
+	}
+	if err := is.db.Create(&invitation).Error; err != nil {
+		return nil, err
+	}
+
+	is.send(&invitation, token)
+	return &invitation, nil
+}
+
+// Resend issues a new token for an open invitation, extending its expiry. The old link
+// stops working.
+func (is *UserInvitationService) Resend(id uint) (*models.UserInvitation, error) {
+	invitation, err := is.load(id)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.AcceptedAt != nil || invitation.RevokedAt != nil {
+		return nil, ErrInvitationClosed
+	}
+
+	token, hash, err := newInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invitation.TokenHash = hash
+	invitation.ExpiresAt = now.Add(is.expiry())
+	invitation.SentCount++
+	invitation.LastSentAt = now
+	if err := is.db.Save(invitation).Error; err != nil {
+		return nil, err
+	}
+
+	is.send(invitation, token)
+	return invitation, nil
+}
+
+// Revoke cancels an invitation that has not been accepted
+func (is *UserInvitationService) Revoke(id, revokedBy uint) (*models.UserInvitation, error) {
+	invitation, err := is.load(id)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.AcceptedAt != nil || invitation.RevokedAt != nil {
+		return nil, ErrInvitationClosed
+	}
+
+	now := time.Now()
+	invitation.RevokedAt = &now
+	invitation.RevokedBy = &revokedBy
+	if err := is.db.Save(invitation).Error; err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// Lookup returns the open invitation for a token
+func (is *UserInvitationService) Lookup(token string) (*models.UserInvitation, error) {
+	return is.lookup(is.db, token)
+}
+
+// lookup finds an open invitation by token within a transaction
+func (is *UserInvitationService) lookup(tx *gorm.DB, token string) (*models.UserInvitation, error) {
+	if !verifyInvitationToken(token) {
+		return nil, ErrInvitationInvalid
+	}
+
+	var invitation models.UserInvitation
+	if err := tx.Where("token_hash = ?", hashInvitationToken(token)).First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationInvalid
+		}
+		return nil, err
+	}
+	if invitation.Status(time.Now()) != models.InvitationStatusPending {
+		return nil, ErrInvitationInvalid
+	}
+	return &invitation, nil
+}
+
+// Accept creates the invitee's account with their chosen password and profile details
+func (is *UserInvitationService) Accept(token string, input AcceptInvitationInput) (*models.User, *models.UserInvitation, error) {
+	var phone string
+	if input.Phone != "" {
+		normalized, err := models.NormalizePhone(input.Phone)
+		if err != nil {
+			return nil, nil, err
+		}
+		if NewPhoneAuthService().PhoneInUse(normalized, 0) {
+			return nil, nil, ErrInvitationPhoneTaken
+		}
+		phone = normalized
+	}
+
+	var user models.User
+	var invitation *models.UserInvitation
+	err := is.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		invitation, err = is.lookup(tx.Clauses(clause.Locking{Strength: "UPDATE"}), token)
+		if err != nil {
+			return err
+		}
+
+		var users int64
+		tx.Model(&models.User{}).Where("LOWER(email) = ?", invitation.Email).Count(&users)
+		if users > 0 {
+			return ErrInvitationEmailTaken
+		}
+
+		now := time.Now()
+		user = models.User{
+			FirstName:       firstNonEmpty(input.FirstName, invitation.FirstName),
+			LastName:        firstNonEmpty(input.LastName, invitation.LastName),
+			Email:           invitation.Email,
+			Phone:           phone,
+			Address:         input.Address,
+			City:            input.City,
+			Postcode:        input.Postcode,
+			Role:            invitation.Role,
+			AdminScope:      invitation.AdminScope,
+			Status:          models.StatusActive,
+			EmailVerified:   true, // They received the invitation at this address
+			EmailVerifiedAt: &now,
+		}
+		if err := user.HashPasswordWithValue(input.Password); err != nil {
+			return err
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		if invitation.Role == models.RoleStaff {
+			if err := tx.Create(&models.StaffProfile{
+				UserID:     user.ID,
+				EmployeeID: fmt.Sprintf("INV-%d", user.ID),
+				Department: firstNonEmpty(invitation.Department, models.DepartmentGeneral),
+				Position:   firstNonEmpty(invitation.Position, models.PositionStaffMember),
+				HireDate:   now,
+				Status:     models.StaffStatusActive,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		invitation.AcceptedAt = &now
+		invitation.AcceptedUserID = &user.ID
+		return tx.Save(invitation).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &user, invitation, nil
+}
+
+// load fetches an invitation by ID
+func (is *UserInvitationService) load(id uint) (*models.UserInvitation, error) {
+	var invitation models.UserInvitation
+	if err := is.db.First(&invitation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// send emails the invitation link
+func (is *UserInvitationService) send(invitation *models.UserInvitation, token string) {
+	baseURL := os.Getenv("FRONTEND_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+	link := fmt.Sprintf("%s/accept-invitation?token=%s", baseURL, token)
+
+	roleName := invitation.Role
+	if definition, ok := models.FindAdminScope(invitation.AdminScope); ok {
+		roleName = fmt.Sprintf("%s (%s)", invitation.Role, definition.Name)
+	}
+	name := invitation.FirstName
+	if name == "" {
+		name = "there"
+	}
+	body := fmt.Sprintf("Hello %s,\n\nYou have been invited to join Lewisham Charity as %s.\n\n"+
+		"Set your password and complete your profile to activate your account:\n%s\n\n"+
+		"This invitation expires on %s.",
+		name, roleName, link, invitation.ExpiresAt.Format("2 January 2006 15:04"))
+	if err := notifications.GetService().SendEmail(invitation.Email, "You're invited to Lewisham Charity", body); err != nil {
+		log.Printf("Failed to send invitation %d to %s: %v", invitation.ID, invitation.Email, err)
+	}
+}
+
+// firstNonEmpty returns the first value that is not blank
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
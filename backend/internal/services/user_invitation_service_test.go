@@ -0,0 +1,114 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestInvitationToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	token, hash, err := newInvitationToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != hashInvitationToken(token) || len(hash) != 64 {
+		t.Errorf("stored hash %q does not match the token", hash)
+	}
+	if !verifyInvitationToken(token) {
+		t.Error("fresh token rejected")
+	}
+
+	nonce := token[:len(token)-len(".")-64]
+	for name, forged := range map[string]string{
+		"unsigned":          nonce,
+		"empty nonce":       token[len(nonce):],
+		"changed nonce":     "x" + token[1:],
+		"changed signature": token[:len(token)-1] + "0",
+	} {
+		if forged != token && verifyInvitationToken(forged) {
+			t.Errorf("%s token accepted", name)
+		}
+	}
+
+	// A token signed under another secret is refused, as is signing with none
+	t.Setenv("JWT_SECRET", "rotated")
+	if verifyInvitationToken(token) {
+		t.Error("token accepted after the secret changed")
+	}
+	t.Setenv("JWT_SECRET", "")
+	if _, _, err := newInvitationToken(); err == nil {
+		t.Error("token issued without a secret")
+	}
+}
+
+func TestInvitationLookupRejectsForgedToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	is := &UserInvitationService{}
+	if _, err := is.Lookup("abc.def"); err != ErrInvitationInvalid {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestCreateInvitationRole(t *testing.T) {
+	is := &UserInvitationService{}
+	tests := []struct {
+		name  string
+		input InvitationInput
+		want  error
+	}{
+		{"volunteer", InvitationInput{Email: "a@example.org", Role: models.RoleVolunteer}, ErrInvitationRole},
+		{"visitor", InvitationInput{Email: "a@example.org", Role: models.RoleVisitor}, ErrInvitationRole},
+		{"unknown scope", InvitationInput{Email: "a@example.org", Role: models.RoleAdmin, AdminScope: "owner"}, ErrInvitationScope},
+		{"scoped staff", InvitationInput{Email: "a@example.org", Role: models.RoleStaff, AdminScope: models.AdminScopeFinanceOfficer}, ErrInvitationScope},
+	}
+	for _, tt := range tests {
+		if _, err := is.Create(tt.input, 1); err != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestInvitationExpiry(t *testing.T) {
+	is := &UserInvitationService{}
+	t.Setenv("INVITATION_EXPIRY_HOURS", "48")
+	if got := is.expiry(); got != 48*time.Hour {
+		t.Errorf("got %v", got)
+	}
+	for _, value := range []string{"", "0", "-3", "soon"} {
+		t.Setenv("INVITATION_EXPIRY_HOURS", value)
+		if got := is.expiry(); got != 7*24*time.Hour {
+			t.Errorf("%q: got %v, want a week", value, got)
+		}
+	}
+}
+
+func TestInvitationStatus(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	tests := []struct {
+		name       string
+		invitation models.UserInvitation
+		want       string
+	}{
+		{"open", models.UserInvitation{ExpiresAt: now.Add(time.Minute)}, models.InvitationStatusPending},
+		{"at expiry", models.UserInvitation{ExpiresAt: now}, models.InvitationStatusExpired},
+		{"revoked", models.UserInvitation{ExpiresAt: now.Add(time.Hour), RevokedAt: &earlier}, models.InvitationStatusRevoked},
+		{"accepted then expired", models.UserInvitation{ExpiresAt: earlier, AcceptedAt: &earlier}, models.InvitationStatusAccepted},
+	}
+	for _, tt := range tests {
+		if got := tt.invitation.Status(now); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFirstNonEmpty(t *testing.T) {
+	if got := firstNonEmpty("", "  ", "Sam", "Alex"); got != "Sam" {
+		t.Errorf("got %q", got)
+	}
+	if got := firstNonEmpty(" "); got != "" {
+		t.Errorf("got %q", got)
+	}
+}
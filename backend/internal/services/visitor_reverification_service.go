@@ -0,0 +1,465 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+)
+
+// Default re-verification policy, used when neither a system setting nor an env var
+// is set
+const (
+	defaultReverificationMonths    = 12
+	defaultReverificationGraceDays = 28
+)
+
+// Upper bounds accepted for the re-verification policy
+const (
+	maxReverificationMonths    = 60
+	maxReverificationGraceDays = 180
+)
+
+// Re-verification errors
+var (
+	ErrReverificationRequired      = errors.New("your documents need to be re-verified before you can book, please upload a current photo ID and proof of address")
+	ErrInvalidReverificationPolicy = errors.New("re-verification must be every 0 to 60 months (0 turns it off) with a grace period of 0 to 180 days")
+)
+
+// reverificationDocumentTypes are the documents a visitor refreshes
+var reverificationDocumentTypes = []string{models.DocumentTypeID, models.DocumentTypeProofAddress}
+
+// openReverificationStatuses are the statuses of a cycle still waiting on the visitor
+var openReverificationStatuses = []string{models.ReverificationDue, models.ReverificationRestricted}
+
+// reverificationAggregates summarises the cycles selected by a query
+const reverificationAggregates = `COUNT(*) AS flagged,
+	COUNT(*) FILTER (WHERE status = 'completed') AS completed,
+	COUNT(*) FILTER (WHERE status = 'completed' AND completed_at <= grace_ends_at) AS completed_on_time,
+	COUNT(*) FILTER (WHERE restricted_at IS NOT NULL) AS restricted,
+	COUNT(*) FILTER (WHERE status IN ('due', 'restricted')) AS outstanding,
+	COALESCE(AVG(EXTRACT(EPOCH FROM completed_at - flagged_at) / 86400) FILTER (WHERE status = 'completed'), 0) AS average_days_to_complete`
+
+// ReverificationPolicy is how often visitors refresh their documents and how long
+// they have to do it before new bookings are restricted. Months of 0 turns the
+// policy off.
+type ReverificationPolicy struct {
+	Months    int `json:"months"`
+	GraceDays int `json:"grace_days"`
+}
+
+// ReverificationRun counts what one pass of the re-verification job changed
+type ReverificationRun struct {
+	Flagged    int `json:"flagged"`
+	Restricted int `json:"restricted"`
+	Completed  int `json:"completed"`
+}
+
+// ReverificationStatus is a visitor's place in the re-verification cadence
+type ReverificationStatus struct {
+	Policy     ReverificationPolicy          `json:"policy"`
+	VerifiedAt *time.Time                    `json:"verified_at"` // Oldest of the latest photo ID and proof of address approvals
+	NextDueAt  *time.Time                    `json:"next_due_at"`
+	Cycle      *models.VisitorReverification `json:"cycle"` // Open cycle, if any
+	Restricted bool                          `json:"booking_restricted"`
+}
+
+// ReverificationMetrics summarises the cycles flagged in a period
+type ReverificationMetrics struct {
+	Period                string  `json:"period,omitempty"` // Month start, for trends
+	Flagged               int64   `json:"flagged"`
+	Completed             int64   `json:"completed"`
+	CompletedOnTime       int64   `json:"completed_on_time"` // Before new bookings were restricted
+	Restricted            int64   `json:"restricted"`        // Reached the end of the grace period
+	Outstanding           int64   `json:"outstanding"`
+	CompletionRate        float64 `json:"completion_rate"`          // Percentage of flagged cycles completed
+	OnTimeRate            float64 `json:"on_time_rate"`             // Percentage of flagged cycles completed within grace
+	AverageDaysToComplete float64 `json:"average_days_to_complete"` // From flag to documents re-approved
+}
+
+// ReverificationAnalytics reports re-verification completion over a date range
+type ReverificationAnalytics struct {
+	StartDate string                  `json:"start_date"`
+	EndDate   string                  `json:"end_date"`
+	Policy    ReverificationPolicy    `json:"policy"`
+	Summary   ReverificationMetrics   `json:"summary"`
+	Monthly   []ReverificationMetrics `json:"monthly"`
+}
+
+// VisitorReverificationService asks long-term visitors to refresh their identity and
+// address documents on a regular cadence
+type VisitorReverificationService struct {
+	db *gorm.DB
+}
+
+// NewVisitorReverificationService creates a new visitor re-verification service
+func NewVisitorReverificationService() *VisitorReverificationService {
+	return &VisitorReverificationService{db: db.DB}
+}
+
+// Policy returns the re-verification policy. System settings take priority over
+// VISITOR_REVERIFICATION_MONTHS and VISITOR_REVERIFICATION_GRACE_DAYS.
+func (vs *VisitorReverificationService) Policy() ReverificationPolicy {
+	return ReverificationPolicy{
+		Months:    vs.setting(models.ReverificationMonthsConfigKey, "VISITOR_REVERIFICATION_MONTHS", defaultReverificationMonths),
+		GraceDays: vs.setting(models.ReverificationGraceDaysConfigKey, "VISITOR_REVERIFICATION_GRACE_DAYS", defaultReverificationGraceDays),
+	}
+}
+
+// SetPolicy stores the re-verification policy as system settings
+func (vs *VisitorReverificationService) SetPolicy(policy ReverificationPolicy, updatedBy uint) error {
+	if policy.Months < 0 || policy.Months > maxReverificationMonths ||
+		policy.GraceDays < 0 || policy.GraceDays > maxReverificationGraceDays {
+		return ErrInvalidReverificationPolicy
+	}
+
+	return vs.db.Transaction(func(tx *gorm.DB) error {
+		settings := []struct {
+			key         string
+			value       int
+			description string
+		}{
+			{models.ReverificationMonthsConfigKey, policy.Months, "Months after which visitors must re-verify their photo ID and proof of address (0 turns it off)"},
+			{models.ReverificationGraceDaysConfigKey, policy.GraceDays, "Days visitors have to re-verify before new bookings are restricted"},
+		}
+		for _, setting := range settings {
+			var config models.SystemConfig
+			if err := tx.Where("key = ?", setting.key).
+				Attrs(models.SystemConfig{
+					Key:         setting.key,
+					Type:        models.ConfigTypeInt,
+					Category:    "verification",
+					Description: setting.description,
+				}).
+				FirstOrInit(&config).Error; err != nil {
+				return err
+			}
+			config.Value = strconv.Itoa(setting.value)
+			config.UpdatedBy = &updatedBy
+			if err := tx.Save(&config).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Run completes cycles whose documents have been re-approved, restricts visitors
+// whose grace period has ended and flags visitors whose verification is due
+func (vs *VisitorReverificationService) Run(now time.Time) (ReverificationRun, error) {
+	var run ReverificationRun
+	policy := vs.Policy()
+
+	completed, err := vs.completeVerified(0, now)
+	if err != nil {
+		return run, err
+	}
+	run.Completed = int(completed)
+	if policy.Months == 0 {
+		return run, nil
+	}
+
+	var expired []models.VisitorReverification
+	if err := vs.db.Preload("User").
+		Where("status = ? AND grace_ends_at <= ?", models.ReverificationDue, now).
+		Find(&expired).Error; err != nil {
+		return run, err
+	}
+	for i := range expired {
+		cycle := &expired[i]
+		result := vs.db.Model(&models.VisitorReverification{}).
+			Where("id = ? AND status = ?", cycle.ID, models.ReverificationDue).
+			Updates(map[string]interface{}{"status": models.ReverificationRestricted, "restricted_at": now})
+		if result.Error != nil {
+			return run, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		run.Restricted++
+		if cycle.User != nil {
+			vs.notifyVisitor(cycle.User, "Bookings paused until your documents are re-verified",
+				"We still need a current photo ID and proof of address from you. New bookings are paused until they have been checked.")
+		}
+	}
+
+	var due []struct {
+		UserID     uint
+		VerifiedAt time.Time
+	}
+	if err := vs.db.Raw(`SELECT latest.user_id, MIN(latest.verified_at) AS verified_at
+		FROM (SELECT user_id, type, MAX(verified_at) AS verified_at FROM documents
+			WHERE type IN ? AND status = ? AND verified_at IS NOT NULL AND deleted_at IS NULL
+			GROUP BY user_id, type) latest
+		JOIN users ON users.id = latest.user_id
+		WHERE users.role = ? AND users.status = ? AND users.deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM visitor_reverifications r WHERE r.user_id = latest.user_id AND r.status IN ?)
+		GROUP BY latest.user_id
+		HAVING COUNT(*) = ? AND MIN(latest.verified_at) <= ?`,
+		reverificationDocumentTypes, models.DocumentStatusApproved,
+		models.RoleVisitor, models.StatusActive, openReverificationStatuses,
+		len(reverificationDocumentTypes), now.AddDate(0, -policy.Months, 0)).
+		Scan(&due).Error; err != nil {
+		return run, err
+	}
+	for _, visitor := range due {
+		cycle := models.VisitorReverification{
+			UserID:      visitor.UserID,
+			VerifiedAt:  visitor.VerifiedAt,
+			DueAt:       reverificationDueAt(visitor.VerifiedAt, policy.Months),
+			FlaggedAt:   now,
+			GraceEndsAt: now.AddDate(0, 0, policy.GraceDays),
+			Status:      models.ReverificationDue,
+		}
+		if err := vs.db.Create(&cycle).Error; err != nil {
+			return run, err
+		}
+		run.Flagged++
+
+		var user models.User
+		if err := vs.db.First(&user, visitor.UserID).Error; err != nil {
+			log.Printf("Failed to load visitor %d for re-verification prompt: %v", visitor.UserID, err)
+			continue
+		}
+		vs.notifyVisitor(&user, "Time to re-verify your documents",
+			fmt.Sprintf("It has been %d months since we checked your documents. Please upload a current photo ID and proof of address by %s to keep booking visits.",
+				policy.Months, cycle.GraceEndsAt.Format("2 January 2006")))
+	}
+
+	return run, nil
+}
+
+// CheckBooking returns ErrReverificationRequired when the visitor's grace period has
+// ended without their documents being re-approved
+func (vs *VisitorReverificationService) CheckBooking(userID uint, now time.Time) error {
+	if vs.Policy().Months == 0 {
+		return nil
+	}
+	cycle, err := vs.openCycle(userID)
+	if err != nil || cycle == nil || !reverificationRestricts(cycle, now) {
+		return err
+	}
+
+	completed, err := vs.completeVerified(userID, now)
+	if err != nil {
+		return err
+	}
+	if completed > 0 {
+		return nil
+	}
+	return ErrReverificationRequired
+}
+
+// HasOpenCycle reports whether the visitor has been asked to re-verify and has not
+// finished yet
+func (vs *VisitorReverificationService) HasOpenCycle(userID uint) (bool, error) {
+	var open int64
+	err := vs.db.Model(&models.VisitorReverification{}).
+		Where("user_id = ? AND status IN ?", userID, openReverificationStatuses).
+		Count(&open).Error
+	return open > 0, err
+}
+
+// Status returns when the visitor was last verified, when they are next due and any
+// open cycle
+func (vs *VisitorReverificationService) Status(userID uint, now time.Time) (*ReverificationStatus, error) {
+	if _, err := vs.completeVerified(userID, now); err != nil {
+		return nil, err
+	}
+
+	status := &ReverificationStatus{Policy: vs.Policy()}
+	var verified struct {
+		Types      int
+		VerifiedAt *time.Time
+	}
+	if err := vs.db.Raw(`SELECT COUNT(*) AS types, MIN(verified_at) AS verified_at
+		FROM (SELECT type, MAX(verified_at) AS verified_at FROM documents
+			WHERE user_id = ? AND type IN ? AND status = ? AND verified_at IS NOT NULL AND deleted_at IS NULL
+			GROUP BY type) latest`,
+		userID, reverificationDocumentTypes, models.DocumentStatusApproved).
+		Scan(&verified).Error; err != nil {
+		return nil, err
+	}
+	if verified.Types == len(reverificationDocumentTypes) && verified.VerifiedAt != nil {
+		status.VerifiedAt = verified.VerifiedAt
+		if status.Policy.Months > 0 {
+			next := reverificationDueAt(*verified.VerifiedAt, status.Policy.Months)
+			status.NextDueAt = &next
+		}
+	}
+
+	cycle, err := vs.openCycle(userID)
+	if err != nil {
+		return nil, err
+	}
+	status.Cycle = cycle
+	status.Restricted = cycle != nil && status.Policy.Months > 0 && reverificationRestricts(cycle, now)
+	return status, nil
+}
+
+// Cycles lists re-verification cycles, most recently flagged first. Pass an empty
+// status for all of them.
+func (vs *VisitorReverificationService) Cycles(status string, limit int) ([]models.VisitorReverification, error) {
+	query := vs.db.Preload("User").Order("flagged_at DESC, id DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var cycles []models.VisitorReverification
+	err := query.Find(&cycles).Error
+	return cycles, err
+}
+
+// Analytics reports completion of the cycles flagged between start and end
+// (inclusive dates), overall and by month
+func (vs *VisitorReverificationService) Analytics(start, end time.Time) (*ReverificationAnalytics, error) {
+	to := end.AddDate(0, 0, 1)
+	analytics := &ReverificationAnalytics{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Policy:    vs.Policy(),
+		Monthly:   []ReverificationMetrics{},
+	}
+
+	if err := vs.db.Raw("SELECT "+reverificationAggregates+" FROM visitor_reverifications WHERE flagged_at >= ? AND flagged_at < ?",
+		start, to).Scan(&analytics.Summary).Error; err != nil {
+		return nil, err
+	}
+	reverificationRates(&analytics.Summary)
+
+	var rows []struct {
+		Month time.Time
+		ReverificationMetrics
+	}
+	if err := vs.db.Raw("SELECT date_trunc('month', flagged_at) AS month, "+reverificationAggregates+
+		" FROM visitor_reverifications WHERE flagged_at >= ? AND flagged_at < ? GROUP BY 1 ORDER BY 1",
+		start, to).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		metrics := row.ReverificationMetrics
+		metrics.Period = row.Month.Format("2006-01-02")
+		reverificationRates(&metrics)
+		analytics.Monthly = append(analytics.Monthly, metrics)
+	}
+	return analytics, nil
+}
+
+// completeVerified completes the open cycles, of one visitor or of everyone when
+// userID is 0, whose photo ID and proof of address have both been approved since the
+// cycle was flagged. The cycle completes when the last of them was approved.
+func (vs *VisitorReverificationService) completeVerified(userID uint, now time.Time) (int64, error) {
+	approvedSince := `FROM documents WHERE documents.user_id = visitor_reverifications.user_id
+		AND documents.type IN ? AND documents.status = ? AND documents.deleted_at IS NULL
+		AND documents.verified_at >= visitor_reverifications.flagged_at`
+	query := vs.db.Model(&models.VisitorReverification{}).
+		Where("status IN ?", openReverificationStatuses).
+		Where("(SELECT COUNT(DISTINCT documents.type) "+approvedSince+") = ?",
+			reverificationDocumentTypes, models.DocumentStatusApproved, len(reverificationDocumentTypes))
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	result := query.Updates(map[string]interface{}{
+		"status":       models.ReverificationCompleted,
+		"completed_at": gorm.Expr("COALESCE((SELECT MAX(documents.verified_at) "+approvedSince+"), ?)", reverificationDocumentTypes, models.DocumentStatusApproved, now),
+		"updated_at":   now,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// openCycle returns the visitor's due or restricted cycle, or nil
+func (vs *VisitorReverificationService) openCycle(userID uint) (*models.VisitorReverification, error) {
+	var cycle models.VisitorReverification
+	err := vs.db.Where("user_id = ? AND status IN ?", userID, openReverificationStatuses).
+		Order("flagged_at DESC").First(&cycle).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cycle, nil
+}
+
+// notifyVisitor sends an in-app notification and email asking the visitor to upload
+// their documents
+func (vs *VisitorReverificationService) notifyVisitor(user *models.User, title, message string) {
+	if err := GetGlobalRealtimeNotificationService().SendNotification(RealtimeNotificationData{
+		UserID:    user.ID,
+		Type:      "document_reverification",
+		Title:     title,
+		Message:   message,
+		Priority:  models.PriorityHigh,
+		Category:  "documents",
+		ActionURL: "/visitor/documents",
+		Channels:  []string{"websocket"},
+	}); err != nil {
+		log.Printf("Failed to notify visitor %d about re-verification: %v", user.ID, err)
+	}
+
+	if user.Email == "" {
+		return
+	}
+	baseURL := os.Getenv("FRONTEND_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+	body := fmt.Sprintf("<p>Hi %s,</p><p>%s</p><p><a href=\"%s/visitor/documents\">Upload your documents</a></p>",
+		html.EscapeString(user.FirstName), html.EscapeString(message), html.EscapeString(baseURL))
+	if err := notifications.GetService().SendEmail(user.Email, title, body); err != nil {
+		log.Printf("Failed to email visitor %d about re-verification: %v", user.ID, err)
+	}
+}
+
+// setting reads a non-negative integer policy setting from system config, then the
+// environment
+func (vs *VisitorReverificationService) setting(key, env string, fallback int) int {
+	var config models.SystemConfig
+	if err := vs.db.Where("key = ?", key).First(&config).Error; err == nil {
+		if value, err := strconv.Atoi(config.Value); err == nil && value >= 0 {
+			return value
+		}
+	}
+	if value, err := strconv.Atoi(os.Getenv(env)); err == nil && value >= 0 {
+		return value
+	}
+	return fallback
+}
+
+// reverificationDueAt returns when documents approved at verifiedAt must be refreshed
+func reverificationDueAt(verifiedAt time.Time, months int) time.Time {
+	return verifiedAt.AddDate(0, months, 0)
+}
+
+// reverificationRestricts reports whether an open cycle blocks new bookings: it has
+// been restricted, or its grace period ended before the job caught up with it
+func reverificationRestricts(cycle *models.VisitorReverification, now time.Time) bool {
+	switch cycle.Status {
+	case models.ReverificationRestricted:
+		return true
+	case models.ReverificationDue:
+		return !now.Before(cycle.GraceEndsAt)
+	default:
+		return false
+	}
+}
+
+// reverificationRates fills in the completion percentages and rounds the average
+func reverificationRates(metrics *ReverificationMetrics) {
+	metrics.AverageDaysToComplete = math.Round(metrics.AverageDaysToComplete*10) / 10
+	if metrics.Flagged == 0 {
+		metrics.CompletionRate, metrics.OnTimeRate = 0, 0
+		return
+	}
+	metrics.CompletionRate = math.Round(float64(metrics.Completed)/float64(metrics.Flagged)*1000) / 10
+	metrics.OnTimeRate = math.Round(float64(metrics.CompletedOnTime)/float64(metrics.Flagged)*1000) / 10
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestReverificationRestricts(t *testing.T) {
+	graceEnds := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		status string
+		now    time.Time
+		want   bool
+	}{
+		{models.ReverificationDue, graceEnds.Add(-time.Minute), false},
+		{models.ReverificationDue, graceEnds, true}, // Grace ended before the job restricted it
+		{models.ReverificationRestricted, graceEnds.Add(-time.Hour), true},
+		{models.ReverificationCompleted, graceEnds.AddDate(0, 1, 0), false},
+	}
+	for _, tc := range cases {
+		cycle := &models.VisitorReverification{Status: tc.status, GraceEndsAt: graceEnds}
+		if got := reverificationRestricts(cycle, tc.now); got != tc.want {
+			t.Errorf("reverificationRestricts(%s, %s) = %v, want %v", tc.status, tc.now, got, tc.want)
+		}
+	}
+}
+
+func TestReverificationDueAt(t *testing.T) {
+	verified := time.Date(2025, 8, 31, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		months int
+		want   time.Time
+	}{
+		{12, time.Date(2026, 8, 31, 10, 0, 0, 0, time.UTC)},
+		{6, time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC)}, // 31 February normalises into March
+	}
+	for _, tc := range cases {
+		if got := reverificationDueAt(verified, tc.months); !got.Equal(tc.want) {
+			t.Errorf("reverificationDueAt(%d months) = %s, want %s", tc.months, got, tc.want)
+		}
+	}
+}
+
+func TestReverificationRates(t *testing.T) {
+	cases := []struct {
+		name       string
+		metrics    ReverificationMetrics
+		completion float64
+		onTime     float64
+		avgDays    float64
+	}{
+		{"nothing flagged", ReverificationMetrics{}, 0, 0, 0},
+		{"partial", ReverificationMetrics{Flagged: 3, Completed: 2, CompletedOnTime: 1, AverageDaysToComplete: 12.345}, 66.7, 33.3, 12.3},
+		{"all on time", ReverificationMetrics{Flagged: 4, Completed: 4, CompletedOnTime: 4, AverageDaysToComplete: 5}, 100, 100, 5},
+	}
+	for _, tc := range cases {
+		metrics := tc.metrics
+		reverificationRates(&metrics)
+		if metrics.CompletionRate != tc.completion || metrics.OnTimeRate != tc.onTime || metrics.AverageDaysToComplete != tc.avgDays {
+			t.Errorf("%s: got completion %.1f, on time %.1f, avg %.1f; want %.1f, %.1f, %.1f", tc.name,
+				metrics.CompletionRate, metrics.OnTimeRate, metrics.AverageDaysToComplete, tc.completion, tc.onTime, tc.avgDays)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Away period errors
+var (
+	ErrAwayPeriodNotFound = errors.New("away period not found")
+	ErrAwayPeriodInvalid  = errors.New("away period must end on or after its start and not be in the past")
+	ErrAwayPeriodOverlap  = errors.New("away period overlaps an existing one")
+)
+
+// maxHeatmapDays limits how far ahead the availability heat map can look
+const maxHeatmapDays = 92
+
+// activeAssignmentStatuses are the assignment statuses that commit a volunteer to a shift
+var activeAssignmentStatuses = []string{"Confirmed", "Assigned"}
+
+// FlaggedAssignment is an upcoming assignment that needs a swap because the volunteer
+// is away
+type FlaggedAssignment struct {
+	AssignmentID  uint      `json:"assignment_id"`
+	ShiftID       uint      `json:"shift_id"`
+	UserID        uint      `json:"user_id"`
+	VolunteerName string    `json:"volunteer_name"`
+	Role          string    `json:"role"`
+	Location      string    `json:"location"`
+	Date          time.Time `json:"date"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	AwayPeriodID  *uint     `json:"away_period_id"`
+	FlaggedAt     time.Time `json:"flagged_at"`
+}
+
+// AvailabilityDay is one day of the coordinator availability heat map
+type AvailabilityDay struct {
+	Date             string  `json:"date"`
+	ActiveVolunteers int64   `json:"active_volunteers"`
+	Away             int     `json:"away"`
+	Available        int64   `json:"available"`
+	AvailableRate    float64 `json:"available_rate"` // Share of active volunteers not away, 0-1
+	Shifts           int     `json:"shifts"`
+	UnfilledShifts   int     `json:"unfilled_shifts"`
+	FlaggedForSwap   int     `json:"flagged_for_swap"`
+	OpenPlaces       int     `json:"open_places"` // Volunteer places still to fill, counting flagged ones
+}
+
+// VolunteerAwayService manages volunteer away periods ("away mode"). While away a
+// volunteer is left out of emergency call-outs, shift suggestions and reminders, and
+// shifts they were already committed to are flagged so a coordinator can arrange a swap.
+type VolunteerAwayService struct {
+	db *gorm.DB
+}
+
+// NewVolunteerAwayService creates a new volunteer away service
+func NewVolunteerAwayService() *VolunteerAwayService {
+	return &VolunteerAwayService{db: db.DB}
+}
+
+// List returns a volunteer's away periods, oldest first. Past periods are only
+// included when asked for.
+func (vas *VolunteerAwayService) List(userID uint, includePast bool) ([]models.VolunteerAwayPeriod, error) {
+	query := vas.db.Where("user_id = ?", userID)
+	if !includePast {
+		query = query.Where("ends_on >= ?::date", time.Now())
+	}
+
+	var periods []models.VolunteerAwayPeriod
+	err := query.Order("starts_on ASC").Find(&periods).Error
+	return periods, err
+}
+
+// Create records an away period and flags the volunteer's upcoming assignments that
+// fall inside it
+func (vas *VolunteerAwayService) Create(userID uint, startsOn, endsOn time.Time, reason string) (*models.VolunteerAwayPeriod, []FlaggedAssignment, error) {
+	today := time.Now().Format("2006-01-02")
+	if endsOn.Before(startsOn) || endsOn.Format("2006-01-02") < today {
+		return nil, nil, ErrAwayPeriodInvalid
+	}
+
+	period := models.VolunteerAwayPeriod{
+		UserID:   userID,
+		StartsOn: startsOn,
+		EndsOn:   endsOn,
+		Reason:   reason,
+	}
+	err := vas.db.Transaction(func(tx *gorm.DB) error {
+		var overlapping int64
+		if err := tx.Model(&models.VolunteerAwayPeriod{}).
+			Where("user_id = ? AND starts_on <= ?::date AND ends_on >= ?::date", userID, endsOn, startsOn).
+			Count(&overlapping).Error; err != nil {
+			return err
+		}
+		if overlapping > 0 {
+			return ErrAwayPeriodOverlap
+		}
+		if err := tx.Create(&period).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.ShiftAssignment{}).
+			Where("id IN (?)", vas.assignmentsDuring(tx, userID, startsOn, endsOn).Select("shift_assignments.id")).
+			Updates(map[string]interface{}{
+				"swap_flagged_at": time.Now(),
+				"away_period_id":  period.ID,
+			}).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	flagged, err := vas.flagged(vas.db.Where("shift_assignments.away_period_id = ?", period.ID))
+	if err != nil {
+		return nil, nil, err
+	}
+	return &period, flagged, nil
+}
+
+// Delete removes a volunteer's away period and clears the swap flags it raised
+func (vas *VolunteerAwayService) Delete(userID, periodID uint) error {
+	return vas.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", periodID, userID).Delete(&models.VolunteerAwayPeriod{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAwayPeriodNotFound
+		}
+
+		return tx.Model(&models.ShiftAssignment{}).
+			Where("away_period_id = ?", periodID).
+			Updates(map[string]interface{}{
+				"swap_flagged_at": nil,
+				"away_period_id":  nil,
+			}).Error
+	})
+}
+
+// AwayDays returns the days between from and to (inclusive) that a volunteer is away,
+// keyed by YYYY-MM-DD
+func (vas *VolunteerAwayService) AwayDays(userID uint, from, to time.Time) (map[string]bool, error) {
+	var periods []models.VolunteerAwayPeriod
+	if err := vas.db.Where("user_id = ? AND starts_on <= ?::date AND ends_on >= ?::date", userID, to, from).
+		Find(&periods).Error; err != nil {
+		return nil, err
+	}
+
+	days := map[string]bool{}
+	for _, period := range periods {
+		for day := period.StartsOn; !day.After(period.EndsOn); day = day.AddDate(0, 0, 1) {
+			days[day.Format("2006-01-02")] = true
+		}
+	}
+	return days, nil
+}
+
+// FlaggedAssignments lists upcoming assignments flagged for a swap, soonest first
+func (vas *VolunteerAwayService) FlaggedAssignments() ([]FlaggedAssignment, error) {
+	return vas.flagged(vas.db.Where("shift_assignments.swap_flagged_at IS NOT NULL AND shifts.start_time >= ?", time.Now()))
+}
+
+// Heatmap returns volunteer availability against shift demand for each day from start
+func (vas *VolunteerAwayService) Heatmap(start time.Time, days int) ([]AvailabilityDay, error) {
+	if days <= 0 || days > maxHeatmapDays {
+		days = maxHeatmapDays
+	}
+	end := start.AddDate(0, 0, days-1)
+
+	var active int64
+	if err := vas.activeVolunteers(vas.db).Count(&active).Error; err != nil {
+		return nil, err
+	}
+
+	var periods []models.VolunteerAwayPeriod
+	if err := vas.db.Where("starts_on <= ?::date AND ends_on >= ?::date", end, start).
+		Where("user_id IN (?)", vas.activeVolunteers(vas.db).Select("users.id")).
+		Find(&periods).Error; err != nil {
+		return nil, err
+	}
+
+	var shifts []heatmapShift
+	if err := vas.db.Model(&models.Shift{}).
+		Select(`shifts.date, shifts.max_volunteers,
+			COUNT(shift_assignments.id) AS filled,
+			COUNT(shift_assignments.swap_flagged_at) AS flagged`).
+		Joins("LEFT JOIN shift_assignments ON shift_assignments.shift_id = shifts.id AND shift_assignments.status IN ?", activeAssignmentStatuses).
+		Where("shifts.date::date BETWEEN ?::date AND ?::date", start, end).
+		Group("shifts.id, shifts.date, shifts.max_volunteers").
+		Scan(&shifts).Error; err != nil {
+		return nil, err
+	}
+	return buildAvailabilityHeatmap(start, days, active, periods, shifts), nil
+}
+
+// heatmapShift is a shift's places and assignments as read for the heat map
+type heatmapShift struct {
+	Date          time.Time
+	MaxVolunteers int
+	Filled        int
+	Flagged       int
+}
+
+// buildAvailabilityHeatmap counts volunteers away and places still to fill on each day
+func buildAvailabilityHeatmap(start time.Time, days int, active int64, periods []models.VolunteerAwayPeriod, shifts []heatmapShift) []AvailabilityDay {
+	heatmap := make([]AvailabilityDay, days)
+	byDate := map[string]*AvailabilityDay{}
+	for i := range heatmap {
+		day := start.AddDate(0, 0, i).Format("2006-01-02")
+		heatmap[i] = AvailabilityDay{Date: day, ActiveVolunteers: active}
+		byDate[day] = &heatmap[i]
+	}
+
+	awayByDate := map[string]map[uint]bool{}
+	for _, period := range periods {
+		for day := period.StartsOn; !day.After(period.EndsOn); day = day.AddDate(0, 0, 1) {
+			key := day.Format("2006-01-02")
+			if byDate[key] == nil {
+				continue
+			}
+			if awayByDate[key] == nil {
+				awayByDate[key] = map[uint]bool{}
+			}
+			awayByDate[key][period.UserID] = true
+		}
+	}
+
+	for _, shift := range shifts {
+		day := byDate[shift.Date.Format("2006-01-02")]
+		if day == nil {
+			continue
+		}
+		needed := shift.MaxVolunteers
+		if needed < 1 {
+			needed = 1
+		}
+		day.Shifts++
+		day.FlaggedForSwap += shift.Flagged
+		if open := needed - (shift.Filled - shift.Flagged); open > 0 {
+			day.UnfilledShifts++
+			day.OpenPlaces += open
+		}
+	}
+
+	for i := range heatmap {
+		day := &heatmap[i]
+		day.Away = len(awayByDate[day.Date])
+		day.Available = day.ActiveVolunteers - int64(day.Away)
+		if day.ActiveVolunteers > 0 {
+			day.AvailableRate = float64(day.Available) / float64(day.ActiveVolunteers)
+		}
+	}
+	return heatmap
+}
+
+// activeVolunteers selects the users who can be rostered
+func (vas *VolunteerAwayService) activeVolunteers(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&models.User{}).
+		Where("users.role IN ? AND users.status = ?", []string{models.RoleVolunteer, models.RoleVolunteerLegacy}, "active")
+}
+
+// assignmentsDuring selects a volunteer's upcoming committed assignments on shifts
+// between two dates
+func (vas *VolunteerAwayService) assignmentsDuring(tx *gorm.DB, userID uint, from, to time.Time) *gorm.DB {
+	return tx.Model(&models.ShiftAssignment{}).
+		Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id").
+		Where("shift_assignments.user_id = ? AND shift_assignments.status IN ?", userID, activeAssignmentStatuses).
+		Where("shifts.date::date BETWEEN ?::date AND ?::date AND shifts.start_time >= ?", from, to, time.Now())
+}
+
+// flagged loads flagged assignments matching a scope with their shift and volunteer
+func (vas *VolunteerAwayService) flagged(scope *gorm.DB) ([]FlaggedAssignment, error) {
+	var flagged []FlaggedAssignment
+	err := scope.Model(&models.ShiftAssignment{}).
+		Select(`shift_assignments.id AS assignment_id, shift_assignments.shift_id, shift_assignments.user_id,
+			CONCAT(users.first_name, ' ', users.last_name) AS volunteer_name,
+			shifts.role, shifts.location, shifts.date, shifts.start_time, shifts.end_time,
+			shift_assignments.away_period_id, shift_assignments.swap_flagged_at AS flagged_at`).
+		Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id").
+		Joins("JOIN users ON users.id = shift_assignments.user_id").
+		Where("shift_assignments.status IN ?", activeAssignmentStatuses).
+		Order("shifts.start_time ASC").
+		Scan(&flagged).Error
+	return flagged, err
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestCreateAwayPeriodDates(t *testing.T) {
+	vas := &VolunteerAwayService{}
+	today := time.Now().Truncate(24 * time.Hour)
+	tests := []struct {
+		name             string
+		startsOn, endsOn time.Time
+	}{
+		{"ends before it starts", today.AddDate(0, 0, 5), today.AddDate(0, 0, 4)},
+		{"entirely in the past", today.AddDate(0, 0, -10), today.AddDate(0, 0, -3)},
+	}
+	for _, tt := range tests {
+		if _, _, err := vas.Create(7, tt.startsOn, tt.endsOn, ""); err != ErrAwayPeriodInvalid {
+			t.Errorf("%s: got %v", tt.name, err)
+		}
+	}
+}
+
+func TestAwayPeriodCovers(t *testing.T) {
+	period := models.VolunteerAwayPeriod{
+		StartsOn: time.Date(2026, 8, 3, 0, 0, 0, 0, time.UTC),
+		EndsOn:   time.Date(2026, 8, 7, 0, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2026, 8, 2, 23, 59, 0, 0, time.UTC), false},
+		{time.Date(2026, 8, 3, 9, 0, 0, 0, time.UTC), true},
+		{time.Date(2026, 8, 7, 18, 0, 0, 0, time.UTC), true}, // The end day is included
+		{time.Date(2026, 8, 8, 0, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		if got := period.Covers(tt.at); got != tt.want {
+			t.Errorf("Covers(%s) = %v", tt.at, got)
+		}
+	}
+}
+
+func TestBuildAvailabilityHeatmap(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 8, d, 0, 0, 0, 0, time.UTC) }
+	periods := []models.VolunteerAwayPeriod{
+		{UserID: 1, StartsOn: day(1), EndsOn: day(4)}, // Starts before the heat map
+		{UserID: 2, StartsOn: day(4), EndsOn: day(4)},
+		{UserID: 2, StartsOn: day(5), EndsOn: day(9)}, // Runs past the end
+	}
+	shifts := []heatmapShift{
+		{Date: day(3), MaxVolunteers: 3, Filled: 3},
+		{Date: day(4), MaxVolunteers: 2, Filled: 2, Flagged: 1},
+		{Date: day(4), MaxVolunteers: 0}, // Needs at least one volunteer
+		{Date: day(8), MaxVolunteers: 2}, // Outside the heat map
+	}
+
+	heatmap := buildAvailabilityHeatmap(day(3), 3, 10, periods, shifts)
+	want := []AvailabilityDay{
+		{Date: "2026-08-03", ActiveVolunteers: 10, Away: 1, Available: 9, AvailableRate: 0.9, Shifts: 1},
+		{Date: "2026-08-04", ActiveVolunteers: 10, Away: 2, Available: 8, AvailableRate: 0.8, Shifts: 2, UnfilledShifts: 2, FlaggedForSwap: 1, OpenPlaces: 2},
+		{Date: "2026-08-05", ActiveVolunteers: 10, Away: 1, Available: 9, AvailableRate: 0.9},
+	}
+	if len(heatmap) != len(want) {
+		t.Fatalf("got %d days", len(heatmap))
+	}
+	for i := range want {
+		if heatmap[i] != want[i] {
+			t.Errorf("day %d: got %+v, want %+v", i, heatmap[i], want[i])
+		}
+	}
+
+	// No active volunteers leaves the rate at zero rather than dividing by it
+	if empty := buildAvailabilityHeatmap(day(3), 1, 0, nil, nil); empty[0].AvailableRate != 0 {
+		t.Errorf("got %+v", empty[0])
+	}
+}
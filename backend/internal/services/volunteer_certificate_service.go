@@ -0,0 +1,474 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Volunteer certificate errors
+var (
+	ErrCertificateNotFound   = errors.New("certificate not found")
+	ErrCertificateNoHours    = errors.New("no completed shifts to certify yet")
+	ErrCertificatePending    = errors.New("a certificate request is already waiting for approval")
+	ErrCertificateNotPending = errors.New("certificate has already been reviewed")
+	ErrCertificateNotIssued  = errors.New("certificate has not been approved")
+	ErrCertificateSigningKey = errors.New("CERTIFICATE_SIGNING_KEY or JWT_SECRET is required to sign certificates")
+)
+
+// CertificateRole is the time a volunteer spent in one role
+type CertificateRole struct {
+	Role   string  `json:"role"`
+	Shifts int64   `json:"shifts"`
+	Hours  float64 `json:"hours"`
+}
+
+// CertificateVerification is what an employer sees when checking a certificate. It
+// only repeats what is printed on the certificate itself.
+type CertificateVerification struct {
+	Valid           bool              `json:"valid"`
+	Status          string            `json:"status"`
+	Reason          string            `json:"reason,omitempty"` // Why the certificate is not valid
+	VolunteerName   string            `json:"volunteer_name,omitempty"`
+	TotalHours      float64           `json:"total_hours,omitempty"`
+	ShiftsCompleted int64             `json:"shifts_completed,omitempty"`
+	Roles           []CertificateRole `json:"roles,omitempty"`
+	FirstShiftOn    *time.Time        `json:"first_shift_on,omitempty"`
+	LastShiftOn     *time.Time        `json:"last_shift_on,omitempty"`
+	IssuedAt        *time.Time        `json:"issued_at,omitempty"`
+	CoordinatorName string            `json:"coordinator_name,omitempty"`
+}
+
+// VolunteerCertificateService issues certificates of volunteering hours. Volunteers
+// request one, a coordinator approves it, and the approved certificate is signed with
+// a verification code employers can check.
+type VolunteerCertificateService struct {
+	db *gorm.DB
+}
+
+// NewVolunteerCertificateService creates a new volunteer certificate service
+func NewVolunteerCertificateService() *VolunteerCertificateService {
+	return &VolunteerCertificateService{db: db.DB}
+}
+
+// Request records a volunteer's request for a certificate of their hours so far
+func (vcs *VolunteerCertificateService) Request(userID uint, addressedTo, purpose string) (*models.VolunteerCertificate, error) {
+	var user models.User
+	if err := vcs.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	var pending int64
+	if err := vcs.db.Model(&models.VolunteerCertificate{}).
+		Where("user_id = ? AND status = ?", userID, models.VolunteerCertificatePending).
+		Count(&pending).Error; err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, ErrCertificatePending
+	}
+
+	certificate := models.VolunteerCertificate{
+		UserID:        userID,
+		VolunteerName: strings.TrimSpace(user.FirstName + " " + user.LastName),
+		AddressedTo:   strings.TrimSpace(addressedTo),
+		Purpose:       strings.TrimSpace(purpose),
+		Status:        models.VolunteerCertificatePending,
+	}
+	if err := vcs.fillHours(&certificate); err != nil {
+		return nil, err
+	}
+	if certificate.ShiftsCompleted == 0 {
+		return nil, ErrCertificateNoHours
+	}
+
+	if err := vcs.db.Create(&certificate).Error; err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// fillHours totals a volunteer's completed shifts by role. Hours logged at check-out
+// are used where recorded, otherwise the shift's length.
+func (vcs *VolunteerCertificateService) fillHours(certificate *models.VolunteerCertificate) error {
+	var rows []certificateHoursRow
+	err := vcs.db.Table("shift_assignments").
+		Select(`COALESCE(NULLIF(shifts.role, ''), 'General volunteering') AS role,
+			COUNT(*) AS shifts,
+			COALESCE(SUM(`+assignmentHoursSQL+`), 0) AS hours,
+			MIN(shifts.date) AS first_on,
+			MAX(shifts.date) AS last_on`).
+		Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id AND shifts.deleted_at IS NULL").
+		Where("shift_assignments.user_id = ? AND LOWER(shift_assignments.status) = 'completed'", certificate.UserID).
+		Group("1").
+		Order("hours DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	return totalCertificateHours(certificate, rows)
+}
+
+// certificateHoursRow is a volunteer's completed shifts in one role
+type certificateHoursRow struct {
+	Role    string
+	Shifts  int64
+	Hours   float64
+	FirstOn *time.Time
+	LastOn  *time.Time
+}
+
+// totalCertificateHours records the hours per role on a certificate with the overall
+// total and the dates of the first and last shift
+func totalCertificateHours(certificate *models.VolunteerCertificate, rows []certificateHoursRow) error {
+	roles := make([]CertificateRole, 0, len(rows))
+	certificate.TotalHours = 0
+	certificate.ShiftsCompleted = 0
+	certificate.FirstShiftOn = nil
+	certificate.LastShiftOn = nil
+	for _, row := range rows {
+		hours := math.Round(row.Hours*10) / 10
+		roles = append(roles, CertificateRole{Role: row.Role, Shifts: row.Shifts, Hours: hours})
+		certificate.TotalHours += hours
+		certificate.ShiftsCompleted += row.Shifts
+		if row.FirstOn != nil && (certificate.FirstShiftOn == nil || row.FirstOn.Before(*certificate.FirstShiftOn)) {
+			certificate.FirstShiftOn = row.FirstOn
+		}
+		if row.LastOn != nil && (certificate.LastShiftOn == nil || row.LastOn.After(*certificate.LastShiftOn)) {
+			certificate.LastShiftOn = row.LastOn
+		}
+	}
+	certificate.TotalHours = math.Round(certificate.TotalHours*10) / 10
+
+	encoded, err := json.Marshal(roles)
+	if err != nil {
+		return err
+	}
+	certificate.Roles = string(encoded)
+	return nil
+}
+
+// ListForUser returns a volunteer's certificates, newest first
+func (vcs *VolunteerCertificateService) ListForUser(userID uint) ([]models.VolunteerCertificate, error) {
+	var certificates []models.VolunteerCertificate
+	err := vcs.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&certificates).Error
+	return certificates, err
+}
+
+// List returns certificates for coordinators, oldest pending first, optionally
+// filtered by status
+func (vcs *VolunteerCertificateService) List(status string, limit int) ([]models.VolunteerCertificate, error) {
+	query := vcs.db.Order("created_at ASC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var certificates []models.VolunteerCertificate
+	err := query.Find(&certificates).Error
+	return certificates, err
+}
+
+// Get returns a certificate. A non-zero userID limits it to that volunteer's own.
+func (vcs *VolunteerCertificateService) Get(id, userID uint) (*models.VolunteerCertificate, error) {
+	query := vcs.db.Where("id = ?", id)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var certificate models.VolunteerCertificate
+	if err := query.First(&certificate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCertificateNotFound
+		}
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// Approve signs a pending certificate. Hours are totalled again so the certificate
+// covers everything up to the day it is issued.
+func (vcs *VolunteerCertificateService) Approve(id, reviewerID uint, notes string) (*models.VolunteerCertificate, error) {
+	certificate, err := vcs.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := vcs.fillHours(certificate); err != nil {
+		return nil, err
+	}
+	if certificate.ShiftsCompleted == 0 {
+		return nil, ErrCertificateNoHours
+	}
+
+	var reviewer models.User
+	if err := vcs.db.First(&reviewer, reviewerID).Error; err == nil {
+		certificate.CoordinatorName = strings.TrimSpace(reviewer.FirstName + " " + reviewer.LastName)
+	}
+
+	code, err := newCertificateCode()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	certificate.Status = models.VolunteerCertificateApproved
+	certificate.ReviewedBy = &reviewerID
+	certificate.ReviewedAt = &now
+	certificate.ReviewNotes = notes
+	certificate.VerificationCode = &code
+	certificate.IssuedAt = &now
+	certificate.Signature, err = signCertificate(certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := vcs.db.Save(certificate).Error; err != nil {
+		return nil, err
+	}
+
+	vcs.notify(certificate, "Your volunteering certificate is ready",
+		fmt.Sprintf("Your certificate for %.1f hours of volunteering has been approved and can be downloaded.", certificate.TotalHours))
+	return certificate, nil
+}
+
+// Reject turns down a pending certificate request
+func (vcs *VolunteerCertificateService) Reject(id, reviewerID uint, notes string) (*models.VolunteerCertificate, error) {
+	certificate, err := vcs.pending(id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	certificate.Status = models.VolunteerCertificateRejected
+	certificate.ReviewedBy = &reviewerID
+	certificate.ReviewedAt = &now
+	certificate.ReviewNotes = notes
+	if err := vcs.db.Save(certificate).Error; err != nil {
+		return nil, err
+	}
+
+	message := "Your certificate request was not approved."
+	if notes != "" {
+		message += " " + notes
+	}
+	vcs.notify(certificate, "Certificate request not approved", message)
+	return certificate, nil
+}
+
+// Revoke withdraws an approved certificate. Its verification code then reports it as
+// no longer valid.
+func (vcs *VolunteerCertificateService) Revoke(id, reviewerID uint, notes string) (*models.VolunteerCertificate, error) {
+	certificate, err := vcs.Get(id, 0)
+	if err != nil {
+		return nil, err
+	}
+	if certificate.Status != models.VolunteerCertificateApproved {
+		return nil, ErrCertificateNotIssued
+	}
+
+	now := time.Now()
+	certificate.Status = models.VolunteerCertificateRevoked
+	certificate.RevokedAt = &now
+	certificate.ReviewedBy = &reviewerID
+	if notes != "" {
+		certificate.ReviewNotes = notes
+	}
+	if err := vcs.db.Save(certificate).Error; err != nil {
+		return nil, err
+	}
+	return certificate, nil
+}
+
+// pending loads a certificate that is still waiting for review
+func (vcs *VolunteerCertificateService) pending(id uint) (*models.VolunteerCertificate, error) {
+	certificate, err := vcs.Get(id, 0)
+	if err != nil {
+		return nil, err
+	}
+	if certificate.Status != models.VolunteerCertificatePending {
+		return nil, ErrCertificateNotPending
+	}
+	return certificate, nil
+}
+
+// Verify checks a verification code. A certificate is only valid when it is still
+// approved and its contents match the signature made when it was issued.
+func (vcs *VolunteerCertificateService) Verify(code string) (*CertificateVerification, error) {
+	var certificate models.VolunteerCertificate
+	err := vcs.db.Where("verification_code = ?", strings.ToUpper(strings.TrimSpace(code))).First(&certificate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCertificateNotFound
+		}
+		return nil, err
+	}
+	return checkCertificate(&certificate)
+}
+
+// checkCertificate returns what an employer is shown for a certificate
+func checkCertificate(certificate *models.VolunteerCertificate) (*CertificateVerification, error) {
+	result := &CertificateVerification{Status: certificate.Status}
+	switch certificate.Status {
+	case models.VolunteerCertificateApproved:
+		expected, err := signCertificate(certificate)
+		if err != nil {
+			return nil, err
+		}
+		if !hmac.Equal([]byte(expected), []byte(certificate.Signature)) {
+			log.Printf("Volunteer certificate %d failed signature check", certificate.ID)
+			result.Reason = "The certificate details do not match our records"
+			return result, nil
+		}
+	case models.VolunteerCertificateRevoked:
+		result.Reason = "This certificate has been withdrawn"
+		return result, nil
+	default:
+		result.Reason = "This certificate has not been issued"
+		return result, nil
+	}
+
+	result.Valid = true
+	result.VolunteerName = certificate.VolunteerName
+	result.TotalHours = certificate.TotalHours
+	result.ShiftsCompleted = certificate.ShiftsCompleted
+	result.Roles = CertificateRoles(certificate)
+	result.FirstShiftOn = certificate.FirstShiftOn
+	result.LastShiftOn = certificate.LastShiftOn
+	result.IssuedAt = certificate.IssuedAt
+	result.CoordinatorName = certificate.CoordinatorName
+	return result, nil
+}
+
+// notify tells the volunteer their request has been reviewed
+func (vcs *VolunteerCertificateService) notify(certificate *models.VolunteerCertificate, title, message string) {
+	notification := models.InAppNotification{
+		UserID:    certificate.UserID,
+		Title:     title,
+		Message:   message,
+		Type:      "info",
+		Priority:  models.PriorityNormal,
+		ActionURL: "/volunteer/certificates",
+	}
+	if err := vcs.db.Create(&notification).Error; err != nil {
+		log.Printf("Failed to notify user %d about certificate %d: %v", certificate.UserID, certificate.ID, err)
+	}
+}
+
+// CertificateRoles decodes the roles recorded on a certificate
+func CertificateRoles(certificate *models.VolunteerCertificate) []CertificateRole {
+	var roles []CertificateRole
+	if certificate.Roles != "" {
+		if err := json.Unmarshal([]byte(certificate.Roles), &roles); err != nil {
+			log.Printf("Failed to decode roles on certificate %d: %v", certificate.ID, err)
+		}
+	}
+	return roles
+}
+
+// CertificateVerifyURL is the page employers use to check a certificate
+func CertificateVerifyURL(code string) string {
+	baseURL := os.Getenv("CERTIFICATE_VERIFY_URL")
+	if baseURL == "" {
+		frontendURL := os.Getenv("FRONTEND_URL")
+		if frontendURL == "" {
+			frontendURL = "http://localhost:3000"
+		}
+		baseURL = frontendURL + "/certificates/verify"
+	}
+	return fmt.Sprintf("%s/%s", strings.TrimRight(baseURL, "/"), code)
+}
+
+// RenderVolunteerCertificate renders an approved certificate as a PDF letter
+func RenderVolunteerCertificate(certificate *models.VolunteerCertificate) ([]byte, error) {
+	if certificate.Status != models.VolunteerCertificateApproved || certificate.VerificationCode == nil {
+		return nil, ErrCertificateNotIssued
+	}
+
+	doc := BrandedPDF("Volunteering certificate - "+certificate.VolunteerName, "")
+	doc.Heading("Certificate of Volunteering").Blank()
+	if certificate.AddressedTo != "" {
+		doc.Linef("To %s,", certificate.AddressedTo).Blank()
+	}
+	doc.Line("This is to confirm that").
+		Blank().
+		Heading(certificate.VolunteerName).
+		Blank().
+		Linef("has volunteered with %s for %.1f hours over %d shifts,", BrandName(""), certificate.TotalHours, certificate.ShiftsCompleted)
+	if certificate.FirstShiftOn != nil && certificate.LastShiftOn != nil {
+		doc.Linef("between %s and %s.", certificate.FirstShiftOn.Format("2 January 2006"), certificate.LastShiftOn.Format("2 January 2006"))
+	}
+
+	doc.Blank().Subheading("Roles performed")
+	for _, role := range CertificateRoles(certificate) {
+		doc.Field(role.Role, fmt.Sprintf("%.1f hours, %d shifts", role.Hours, role.Shifts))
+	}
+
+	doc.Blank().
+		Line("We are grateful for their time and commitment to our community.").
+		Blank()
+	if certificate.CoordinatorName != "" {
+		doc.Field("Approved by", certificate.CoordinatorName+", Volunteer Coordinator")
+	}
+	doc.Field("Issued", certificate.IssuedAt.Format("2 January 2006")).
+		Blank().
+		Subheading("Verify this certificate").
+		Field("Verification code", *certificate.VerificationCode).
+		Line(CertificateVerifyURL(*certificate.VerificationCode)).
+		Field("Signature", certificate.Signature[:16])
+
+	return doc.Bytes(), nil
+}
+
+// newCertificateCode creates a verification code that is easy to read out over the
+// phone, e.g. VC-3F9A-12C4-B7E0
+func newCertificateCode() (string, error) {
+	raw, err := randomHex(6)
+	if err != nil {
+		return "", err
+	}
+	raw = strings.ToUpper(raw)
+	return fmt.Sprintf("VC-%s-%s-%s", raw[0:4], raw[4:8], raw[8:12]), nil
+}
+
+// signCertificate signs the details printed on a certificate so later edits to the
+// record are detected when it is verified
+func signCertificate(certificate *models.VolunteerCertificate) (string, error) {
+	secret := os.Getenv("CERTIFICATE_SIGNING_KEY")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	if secret == "" {
+		return "", ErrCertificateSigningKey
+	}
+
+	var code, issued, first, last string
+	if certificate.VerificationCode != nil {
+		code = *certificate.VerificationCode
+	}
+	if certificate.IssuedAt != nil {
+		issued = certificate.IssuedAt.UTC().Format(time.RFC3339)
+	}
+	if certificate.FirstShiftOn != nil {
+		first = certificate.FirstShiftOn.UTC().Format("2006-01-02")
+	}
+	if certificate.LastShiftOn != nil {
+		last = certificate.LastShiftOn.UTC().Format("2006-01-02")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "volunteer-certificate:%d|%d|%s|%s|%.1f|%d|%s|%s|%s|%s|%s",
+		certificate.ID, certificate.UserID, code, certificate.VolunteerName, certificate.TotalHours,
+		certificate.ShiftsCompleted, certificate.Roles, first, last, issued, certificate.CoordinatorName)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestTotalCertificateHours(t *testing.T) {
+	day := func(month time.Month, d int) *time.Time {
+		date := time.Date(2026, month, d, 0, 0, 0, 0, time.UTC)
+		return &date
+	}
+	certificate := &models.VolunteerCertificate{TotalHours: 99, ShiftsCompleted: 99} // Recounted from scratch
+	err := totalCertificateHours(certificate, []certificateHoursRow{
+		{Role: "Food bank", Shifts: 10, Hours: 31.26, FirstOn: day(3, 2), LastOn: day(5, 20)},
+		{Role: "Reception", Shifts: 3, Hours: 8.04, FirstOn: day(1, 15), LastOn: day(4, 1)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if certificate.TotalHours != 39.3 || certificate.ShiftsCompleted != 13 {
+		t.Errorf("got %v hours over %d shifts", certificate.TotalHours, certificate.ShiftsCompleted)
+	}
+	if !certificate.FirstShiftOn.Equal(*day(1, 15)) || !certificate.LastShiftOn.Equal(*day(5, 20)) {
+		t.Errorf("got %v to %v", certificate.FirstShiftOn, certificate.LastShiftOn)
+	}
+
+	roles := CertificateRoles(certificate)
+	if len(roles) != 2 || roles[0] != (CertificateRole{Role: "Food bank", Shifts: 10, Hours: 31.3}) || roles[1].Hours != 8 {
+		t.Errorf("roles %+v", roles)
+	}
+
+	empty := &models.VolunteerCertificate{FirstShiftOn: day(1, 1)}
+	if err := totalCertificateHours(empty, nil); err != nil || empty.ShiftsCompleted != 0 || empty.FirstShiftOn != nil || empty.Roles != "[]" {
+		t.Errorf("no shifts: got %+v, %v", empty, err)
+	}
+}
+
+func TestCertificateSignature(t *testing.T) {
+	t.Setenv("CERTIFICATE_SIGNING_KEY", "certificate-key")
+	code := "VC-3F9A-12C4-B7E0"
+	issued := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	certificate := func() *models.VolunteerCertificate {
+		return &models.VolunteerCertificate{
+			ID: 4, UserID: 9, VolunteerName: "Sam Taylor", TotalHours: 39.3, ShiftsCompleted: 13,
+			Status: models.VolunteerCertificateApproved, VerificationCode: &code, IssuedAt: &issued,
+			CoordinatorName: "Alex Morgan",
+		}
+	}
+	signed := certificate()
+	signature, err := signCertificate(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed.Signature = signature
+
+	result, err := checkCertificate(signed)
+	if err != nil || !result.Valid || result.VolunteerName != "Sam Taylor" || result.TotalHours != 39.3 {
+		t.Fatalf("signed certificate: got %+v, %v", result, err)
+	}
+
+	// Editing the hours after signing is caught
+	edited := certificate()
+	edited.Signature = signature
+	edited.TotalHours = 139.3
+	if result, _ := checkCertificate(edited); result.Valid || result.Reason == "" || result.VolunteerName != "" {
+		t.Errorf("edited certificate: got %+v", result)
+	}
+
+	// JWT_SECRET is used when no certificate key is set, and one of them is required
+	t.Setenv("CERTIFICATE_SIGNING_KEY", "")
+	t.Setenv("JWT_SECRET", "jwt-secret")
+	if fallback, err := signCertificate(certificate()); err != nil || fallback == signature {
+		t.Errorf("JWT_SECRET fallback: got %q, %v", fallback, err)
+	}
+	t.Setenv("JWT_SECRET", "")
+	if _, err := signCertificate(certificate()); err != ErrCertificateSigningKey {
+		t.Errorf("no key: got %v", err)
+	}
+}
+
+func TestCheckCertificateStatus(t *testing.T) {
+	for status, reason := range map[string]string{
+		models.VolunteerCertificateRevoked:  "This certificate has been withdrawn",
+		models.VolunteerCertificatePending:  "This certificate has not been issued",
+		models.VolunteerCertificateRejected: "This certificate has not been issued",
+	} {
+		result, err := checkCertificate(&models.VolunteerCertificate{Status: status, VolunteerName: "Sam"})
+		if err != nil || result.Valid || result.Reason != reason || result.VolunteerName != "" {
+			t.Errorf("%s: got %+v, %v", status, result, err)
+		}
+	}
+
+	if _, err := RenderVolunteerCertificate(&models.VolunteerCertificate{Status: models.VolunteerCertificatePending}); err != ErrCertificateNotIssued {
+		t.Errorf("render pending: got %v", err)
+	}
+}
+
+func TestCertificateCodeAndURL(t *testing.T) {
+	code, err := newCertificateCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^VC-[0-9A-F]{4}-[0-9A-F]{4}-[0-9A-F]{4}$`).MatchString(code) {
+		t.Errorf("code %q", code)
+	}
+
+	t.Setenv("CERTIFICATE_VERIFY_URL", "")
+	t.Setenv("FRONTEND_URL", "https://app.example.org")
+	if got := CertificateVerifyURL(code); got != "https://app.example.org/certificates/verify/"+code {
+		t.Errorf("got %q", got)
+	}
+	t.Setenv("CERTIFICATE_VERIFY_URL", "https://verify.example.org/")
+	if got := CertificateVerifyURL("VC-1"); got != "https://verify.example.org/VC-1" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,381 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+)
+
+// Volunteers are warned this long before an approved document expires
+const volunteerDocumentExpiryNotice = 30 * 24 * time.Hour
+
+var (
+	ErrDocumentNotPending      = errors.New("document is not awaiting verification")
+	ErrDocumentAlreadyExpired  = errors.New("expiry date has already passed")
+	ErrDocumentRejectionReason = errors.New("a rejection reason is required")
+	ErrUnknownDocumentType     = errors.New("unknown volunteer document type")
+)
+
+// VolunteerDocumentService handles volunteer documents, their verification and the
+// documents required for shift roles
+type VolunteerDocumentService struct {
+	db *gorm.DB
+}
+
+// VolunteerDocumentQueueItem is a document waiting for a coordinator to check it
+type VolunteerDocumentQueueItem struct {
+	Document       models.Document `json:"document"`
+	VolunteerName  string          `json:"volunteer_name"`
+	VolunteerEmail string          `json:"volunteer_email"`
+	WaitingHours   int             `json:"waiting_hours"`
+}
+
+// DocumentRequirementStatus is a volunteer's standing for one document type
+type DocumentRequirementStatus struct {
+	DocumentType string           `json:"document_type"`
+	Status       string           `json:"status"` // valid, expiring, pending, expired, rejected, missing
+	Roles        []string         `json:"roles"`  // Shift roles that need this document
+	Document     *models.Document `json:"document,omitempty"`
+}
+
+// NewVolunteerDocumentService creates a new volunteer document service
+func NewVolunteerDocumentService() *VolunteerDocumentService {
+	return &VolunteerDocumentService{
+		db: db.DB,
+	}
+}
+
+// NormalizeShiftRole returns the form shift roles are stored in for requirements
+func NormalizeShiftRole(role string) string {
+	return strings.ToLower(strings.TrimSpace(role))
+}
+
+// Requirements returns every shift role requirement, optionally for one role
+func (vds *VolunteerDocumentService) Requirements(role string) ([]models.ShiftRoleDocumentRequirement, error) {
+	query := vds.db.Model(&models.ShiftRoleDocumentRequirement{})
+	if role = NormalizeShiftRole(role); role != "" {
+		query = query.Where("role = ?", role)
+	}
+
+	var requirements []models.ShiftRoleDocumentRequirement
+	err := query.Order("role ASC, document_type ASC").Find(&requirements).Error
+	return requirements, err
+}
+
+// SetRequirements replaces the documents required for a shift role
+func (vds *VolunteerDocumentService) SetRequirements(role string, documentTypes []string, updatedBy uint) ([]models.ShiftRoleDocumentRequirement, error) {
+	role = NormalizeShiftRole(role)
+	seen := map[string]bool{}
+	requirements := make([]models.ShiftRoleDocumentRequirement, 0, len(documentTypes))
+	for _, documentType := range documentTypes {
+		if !models.IsVolunteerDocumentType(documentType) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownDocumentType, documentType)
+		}
+		if seen[documentType] {
+			continue
+		}
+		seen[documentType] = true
+		requirements = append(requirements, models.ShiftRoleDocumentRequirement{
+			Role:         role,
+			DocumentType: documentType,
+			CreatedBy:    updatedBy,
+		})
+	}
+
+	err := vds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role = ?", role).Delete(&models.ShiftRoleDocumentRequirement{}).Error; err != nil {
+			return err
+		}
+		if len(requirements) == 0 {
+			return nil
+		}
+		return tx.Create(&requirements).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return requirements, nil
+}
+
+// RequiredDocuments returns the document types needed to work a shift role
+func (vds *VolunteerDocumentService) RequiredDocuments(role string) ([]string, error) {
+	role = NormalizeShiftRole(role)
+	if role == "" {
+		return nil, nil
+	}
+
+	var documentTypes []string
+	err := vds.db.Model(&models.ShiftRoleDocumentRequirement{}).
+		Where("role = ?", role).
+		Order("document_type ASC").
+		Pluck("document_type", &documentTypes).Error
+	return documentTypes, err
+}
+
+// MissingDocuments returns the document types a volunteer still needs for a shift
+// role: those without an approved document that is valid on the shift date
+func (vds *VolunteerDocumentService) MissingDocuments(userID uint, role string, shiftDate time.Time) ([]string, error) {
+	required, err := vds.RequiredDocuments(role)
+	if err != nil || len(required) == 0 {
+		return nil, err
+	}
+
+	var valid []string
+	if err := vds.db.Model(&models.Document{}).
+		Where("user_id = ? AND type IN ? AND status = ?", userID, required, models.DocumentStatusApproved).
+		Where("expires_at IS NULL OR expires_at > ?", shiftDate).
+		Distinct().
+		Pluck("type", &valid).Error; err != nil {
+		return nil, err
+	}
+
+	have := map[string]bool{}
+	for _, documentType := range valid {
+		have[documentType] = true
+	}
+	var missing []string
+	for _, documentType := range required {
+		if !have[documentType] {
+			missing = append(missing, documentType)
+		}
+	}
+	return missing, nil
+}
+
+// RequirementStatus lists every document type required by a shift role with the
+// volunteer's current standing for it
+func (vds *VolunteerDocumentService) RequirementStatus(userID uint, now time.Time) ([]DocumentRequirementStatus, error) {
+	var requirements []models.ShiftRoleDocumentRequirement
+	if err := vds.db.Order("document_type ASC, role ASC").Find(&requirements).Error; err != nil {
+		return nil, err
+	}
+
+	var documents []models.Document
+	if err := vds.db.Where("user_id = ? AND type IN ?", userID, models.VolunteerDocumentTypes).
+		Order("uploaded_at DESC").
+		Find(&documents).Error; err != nil {
+		return nil, err
+	}
+
+	return documentRequirementStatuses(requirements, documents, now), nil
+}
+
+// documentRequirementStatuses groups shift role requirements by document type and
+// picks the volunteer's best document for each
+func documentRequirementStatuses(requirements []models.ShiftRoleDocumentRequirement, documents []models.Document, now time.Time) []DocumentRequirementStatus {
+	statuses := []DocumentRequirementStatus{}
+	index := map[string]int{}
+	for _, requirement := range requirements {
+		i, ok := index[requirement.DocumentType]
+		if !ok {
+			i = len(statuses)
+			index[requirement.DocumentType] = i
+			statuses = append(statuses, DocumentRequirementStatus{
+				DocumentType: requirement.DocumentType,
+				Status:       "missing",
+			})
+		}
+		statuses[i].Roles = append(statuses[i].Roles, requirement.Role)
+	}
+
+	for i := range statuses {
+		status := &statuses[i]
+		for j := range documents {
+			document := &documents[j]
+			if document.Type != status.DocumentType {
+				continue
+			}
+			state := documentState(document, now)
+			if status.Document == nil || documentStateRank[state] < documentStateRank[status.Status] {
+				status.Status = state
+				status.Document = document
+			}
+		}
+	}
+
+	return statuses
+}
+
+// documentStateRank orders document states from best to worst
+var documentStateRank = map[string]int{
+	"valid":    0,
+	"expiring": 1,
+	"pending":  2,
+	"expired":  3,
+	"rejected": 4,
+	"missing":  5,
+}
+
+// documentState describes a single document's standing at a point in time
+func documentState(document *models.Document, now time.Time) string {
+	switch document.Status {
+	case models.DocumentStatusPending:
+		return "pending"
+	case models.DocumentStatusRejected:
+		return "rejected"
+	}
+	if !document.IsValidOn(now) {
+		return "expired"
+	}
+	if document.ExpiresAt != nil && document.ExpiresAt.Before(now.Add(volunteerDocumentExpiryNotice)) {
+		return "expiring"
+	}
+	return "valid"
+}
+
+// VerificationQueue returns volunteer documents awaiting verification, oldest first
+func (vds *VolunteerDocumentService) VerificationQueue(documentType string, now time.Time) ([]VolunteerDocumentQueueItem, error) {
+	types := models.VolunteerDocumentTypes
+	if documentType != "" {
+		types = []string{documentType}
+	}
+
+	var documents []models.Document
+	if err := vds.db.Joins("JOIN users ON users.id = documents.user_id").
+		Where("users.role = ? AND documents.type IN ? AND documents.status = ?",
+			models.RoleVolunteer, types, models.DocumentStatusPending).
+		Preload("User").
+		Order("documents.uploaded_at ASC").
+		Limit(200).
+		Find(&documents).Error; err != nil {
+		return nil, err
+	}
+
+	queue := make([]VolunteerDocumentQueueItem, 0, len(documents))
+	for _, document := range documents {
+		queue = append(queue, VolunteerDocumentQueueItem{
+			Document:       document,
+			VolunteerName:  strings.TrimSpace(document.User.FirstName + " " + document.User.LastName),
+			VolunteerEmail: document.User.Email,
+			WaitingHours:   int(now.Sub(document.UploadedAt).Hours()),
+		})
+	}
+	return queue, nil
+}
+
+// Approve marks a pending volunteer document as verified. A non-nil expiresAt
+// replaces the expiry date given by the volunteer.
+func (vds *VolunteerDocumentService) Approve(document *models.Document, verifierID uint, expiresAt *time.Time, notes string) error {
+	if document.Status != models.DocumentStatusPending {
+		return ErrDocumentNotPending
+	}
+	now := time.Now()
+	if expiresAt != nil {
+		document.ExpiresAt = expiresAt
+	}
+	if document.ExpiresAt != nil && !document.ExpiresAt.After(now) {
+		return ErrDocumentAlreadyExpired
+	}
+
+	document.Status = models.DocumentStatusApproved
+	document.RejectionReason = ""
+	document.ExpiryNoticeAt = nil
+	return vds.recordVerification(document, verifierID, notes, now)
+}
+
+// Reject marks a pending volunteer document as rejected with a reason the volunteer
+// can act on
+func (vds *VolunteerDocumentService) Reject(document *models.Document, verifierID uint, reason string) error {
+	if document.Status != models.DocumentStatusPending {
+		return ErrDocumentNotPending
+	}
+	if strings.TrimSpace(reason) == "" {
+		return ErrDocumentRejectionReason
+	}
+
+	document.Status = models.DocumentStatusRejected
+	document.RejectionReason = reason
+	return vds.recordVerification(document, verifierID, "", time.Now())
+}
+
+// recordVerification saves a verification decision and its history entry
+func (vds *VolunteerDocumentService) recordVerification(document *models.Document, verifierID uint, notes string, now time.Time) error {
+	document.VerifiedBy = &verifierID
+	document.VerifiedAt = &now
+	if notes != "" {
+		document.Notes = notes
+	}
+
+	return vds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(document).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.DocumentVerificationResult{
+			DocumentID:      document.ID,
+			VerifiedBy:      verifierID,
+			Status:          document.Status,
+			Notes:           notes,
+			RejectionReason: document.RejectionReason,
+			VerifiedAt:      now,
+		}).Error
+	})
+}
+
+// ExpiringDocuments returns approved volunteer documents that expire before the
+// given time, soonest first
+func (vds *VolunteerDocumentService) ExpiringDocuments(before time.Time) ([]models.Document, error) {
+	var documents []models.Document
+	err := vds.db.Where("type IN ? AND status = ? AND expires_at IS NOT NULL AND expires_at < ?",
+		models.VolunteerDocumentTypes, models.DocumentStatusApproved, before).
+		Order("expires_at ASC").
+		Find(&documents).Error
+	return documents, err
+}
+
+// SendExpiryNotices warns volunteers once about approved documents that expire within
+// the notice period. It returns how many volunteers were warned.
+func (vds *VolunteerDocumentService) SendExpiryNotices(now time.Time) (int, error) {
+	var documents []models.Document
+	if err := vds.db.Joins("JOIN users ON users.id = documents.user_id").
+		Where("users.role = ? AND documents.type IN ? AND documents.status = ?",
+			models.RoleVolunteer, models.VolunteerDocumentTypes, models.DocumentStatusApproved).
+		Where("documents.expires_at IS NOT NULL AND documents.expires_at > ? AND documents.expires_at < ?",
+			now, now.Add(volunteerDocumentExpiryNotice)).
+		Where("documents.expiry_notice_at IS NULL").
+		Preload("User").
+		Find(&documents).Error; err != nil {
+		return 0, err
+	}
+
+	notificationService := notifications.GetService()
+	sent := 0
+	for i := range documents {
+		document := &documents[i]
+		label := strings.ReplaceAll(document.Type, "_", " ")
+		title := "Volunteer document expiring soon"
+		message := fmt.Sprintf("Your %s expires on %s. Please upload a renewed copy so you can keep signing up for shifts that need it.",
+			label, document.ExpiresAt.Format("2 January 2006"))
+
+		notification := models.InAppNotification{
+			UserID:    document.UserID,
+			Title:     title,
+			Message:   message,
+			Type:      "warning",
+			Priority:  "high",
+			ActionURL: "/volunteer/documents",
+		}
+		if err := vds.db.Create(&notification).Error; err != nil {
+			log.Printf("Failed to create document expiry notice for user %d: %v", document.UserID, err)
+			continue
+		}
+
+		if notificationService != nil && document.User.Email != "" {
+			if err := notificationService.SendEmail(document.User.Email, title, message); err != nil {
+				log.Printf("Failed to email document expiry notice to user %d: %v", document.UserID, err)
+			}
+		}
+
+		vds.db.Model(document).UpdateColumn("expiry_notice_at", now)
+		sent++
+	}
+
+	return sent, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestDocumentState(t *testing.T) {
+	now := time.Date(2026, 5, 5, 9, 0, 0, 0, time.UTC)
+	at := func(days int) *time.Time {
+		t := now.AddDate(0, 0, days)
+		return &t
+	}
+
+	tests := []struct {
+		name     string
+		document models.Document
+		want     string
+	}{
+		{"no expiry", models.Document{Status: models.DocumentStatusApproved}, "valid"},
+		{"expires in two months", models.Document{Status: models.DocumentStatusApproved, ExpiresAt: at(60)}, "valid"},
+		{"expires within the notice period", models.Document{Status: models.DocumentStatusApproved, ExpiresAt: at(29)}, "expiring"},
+		{"expired yesterday", models.Document{Status: models.DocumentStatusApproved, ExpiresAt: at(-1)}, "expired"},
+		{"awaiting a check", models.Document{Status: models.DocumentStatusPending, ExpiresAt: at(-1)}, "pending"},
+		{"rejected", models.Document{Status: models.DocumentStatusRejected}, "rejected"},
+	}
+	for _, tt := range tests {
+		if got := documentState(&tt.document, now); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDocumentRequirementStatuses(t *testing.T) {
+	now := time.Date(2026, 5, 5, 9, 0, 0, 0, time.UTC)
+	expired := now.AddDate(0, 0, -10)
+	requirements := []models.ShiftRoleDocumentRequirement{
+		{Role: "driver", DocumentType: models.DocumentTypeDBSCheck},
+		{Role: "youth_support", DocumentType: models.DocumentTypeDBSCheck},
+		{Role: "kitchen", DocumentType: models.DocumentTypeFoodHygiene},
+		{Role: "driver", DocumentType: models.DocumentTypeRightToWork},
+	}
+	documents := []models.Document{
+		{ID: 1, Type: models.DocumentTypeDBSCheck, Status: models.DocumentStatusApproved, ExpiresAt: &expired},
+		{ID: 2, Type: models.DocumentTypeDBSCheck, Status: models.DocumentStatusPending},
+		{ID: 3, Type: models.DocumentTypeFoodHygiene, Status: models.DocumentStatusRejected},
+		{ID: 4, Type: models.DocumentTypeTrainingCertificate, Status: models.DocumentStatusApproved},
+	}
+
+	statuses := documentRequirementStatuses(requirements, documents, now)
+	if len(statuses) != 3 {
+		t.Fatalf("got %+v", statuses)
+	}
+
+	// A renewal awaiting a check is better than the expired document it replaces
+	dbs := statuses[0]
+	if dbs.DocumentType != models.DocumentTypeDBSCheck || dbs.Status != "pending" || dbs.Document.ID != 2 ||
+		!reflect.DeepEqual(dbs.Roles, []string{"driver", "youth_support"}) {
+		t.Errorf("DBS: got %+v", dbs)
+	}
+	if hygiene := statuses[1]; hygiene.Status != "rejected" || hygiene.Document.ID != 3 {
+		t.Errorf("food hygiene: got %+v", hygiene)
+	}
+	if rightToWork := statuses[2]; rightToWork.Status != "missing" || rightToWork.Document != nil {
+		t.Errorf("right to work: got %+v", rightToWork)
+	}
+}
+
+func TestVolunteerDocumentDecisionChecks(t *testing.T) {
+	vds := &VolunteerDocumentService{}
+
+	approved := &models.Document{Status: models.DocumentStatusApproved}
+	if err := vds.Approve(approved, 1, nil, ""); !errors.Is(err, ErrDocumentNotPending) {
+		t.Errorf("approving an approved document: got %v", err)
+	}
+	if err := vds.Reject(approved, 1, "Blurred"); !errors.Is(err, ErrDocumentNotPending) {
+		t.Errorf("rejecting an approved document: got %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	if err := vds.Approve(&models.Document{Status: models.DocumentStatusPending}, 1, &past, ""); !errors.Is(err, ErrDocumentAlreadyExpired) {
+		t.Errorf("approving with a past expiry: got %v", err)
+	}
+	if err := vds.Reject(&models.Document{Status: models.DocumentStatusPending}, 1, "  "); !errors.Is(err, ErrDocumentRejectionReason) {
+		t.Errorf("rejecting without a reason: got %v", err)
+	}
+}
+
+func TestNormalizeShiftRole(t *testing.T) {
+	if got := NormalizeShiftRole("  Kitchen Lead "); got != "kitchen lead" {
+		t.Errorf("got %q", got)
+	}
+}
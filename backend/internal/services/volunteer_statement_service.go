@@ -0,0 +1,378 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/notifications"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Statements for the previous month are generated from this hour on the 1st
+const defaultVolunteerStatementHour = 8
+
+// assignmentHoursSQL is the hours a completed assignment counts for: the hours logged
+// at check-out where recorded, otherwise the shift's length
+const assignmentHoursSQL = `CASE
+	WHEN shift_assignments.hours_logged > 0 THEN shift_assignments.hours_logged
+	WHEN shift_assignments.duration > 0 THEN shift_assignments.duration
+	ELSE GREATEST(EXTRACT(EPOCH FROM (shifts.end_time - shifts.start_time)) / 3600, 0)
+END`
+
+// approvedAssignmentSQL matches the completed assignments whose hours count towards
+// a volunteer's statements and total: those whose hours were approved, or whose
+// attendance was confirmed by a team lead or coordinator
+const approvedAssignmentSQL = `LOWER(shift_assignments.status) = 'completed' AND
+	(shift_assignments.hours_approved_at IS NOT NULL OR shift_assignments.attendance_confirmed_at IS NOT NULL)`
+
+var (
+	ErrVolunteerStatementNotFound = errors.New("statement not found")
+	ErrVolunteerProfileNotFound   = errors.New("volunteer profile not found")
+)
+
+var (
+	// volunteerHourMilestones are lifetime hour totals celebrated on a statement
+	volunteerHourMilestones = []float64{10, 20, 50, 100, 250, 500, 1000}
+	// volunteerShiftMilestones are lifetime shift counts celebrated on a statement
+	volunteerShiftMilestones = []int64{1, 10, 25, 50, 100, 250}
+)
+
+// StatementShift is one completed shift on a statement
+type StatementShift struct {
+	ShiftID  uint    `json:"shift_id"`
+	Date     string  `json:"date"`
+	Role     string  `json:"role"`
+	Location string  `json:"location,omitempty"`
+	Hours    float64 `json:"hours"`
+}
+
+// StatementAchievement is a milestone reached or recognition received in the month
+type StatementAchievement struct {
+	Type        string `json:"type"` // hours, shifts or kudos
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// VolunteerStatementContent is the statement as stored and shown to the volunteer
+type VolunteerStatementContent struct {
+	VolunteerName string                 `json:"volunteer_name"`
+	Month         string                 `json:"month"`
+	From          string                 `json:"from"`
+	To            string                 `json:"to"`
+	Hours         float64                `json:"hours"`
+	ShiftCount    int64                  `json:"shift_count"`
+	LifetimeHours float64                `json:"lifetime_hours"`
+	KudosReceived int64                  `json:"kudos_received"`
+	Shifts        []StatementShift       `json:"shifts"`
+	Achievements  []StatementAchievement `json:"achievements"`
+}
+
+// VolunteerStatementService compiles monthly statements of volunteers' completed
+// hours. Generating a statement also corrects the total hours on the volunteer's
+// profile when it has drifted from their completed shifts.
+type VolunteerStatementService struct {
+	db *gorm.DB
+}
+
+// NewVolunteerStatementService creates a new volunteer statement service
+func NewVolunteerStatementService() *VolunteerStatementService {
+	return &VolunteerStatementService{db: db.DB}
+}
+
+// EnsureMonthlyStatements generates last month's statement for every active volunteer
+// who does not have one yet, once VOLUNTEER_STATEMENT_HOUR has passed on the 1st. It
+// returns how many were generated.
+func (vs *VolunteerStatementService) EnsureMonthlyStatements(now time.Time) (int, error) {
+	hour := defaultVolunteerStatementHour
+	if configured, err := strconv.Atoi(os.Getenv("VOLUNTEER_STATEMENT_HOUR")); err == nil && configured >= 0 && configured < 24 {
+		hour = configured
+	}
+	thisMonth := truncateSLAPeriod(now, slaTrendIntervalMonth)
+	if now.Before(thisMonth.Add(time.Duration(hour) * time.Hour)) {
+		return 0, nil
+	}
+	lastMonth := thisMonth.AddDate(0, -1, 0)
+
+	var volunteers []models.User
+	if err := vs.db.Where("role IN ? AND status = ?", []string{models.RoleVolunteer, models.RoleVolunteerLegacy}, models.StatusActive).
+		Where("NOT EXISTS (SELECT 1 FROM volunteer_statements WHERE volunteer_statements.user_id = users.id AND volunteer_statements.month = ?)",
+			lastMonth.Format("2006-01")).
+		Find(&volunteers).Error; err != nil {
+		return 0, err
+	}
+
+	generated := 0
+	for _, volunteer := range volunteers {
+		if _, err := vs.Generate(volunteer, lastMonth, now); err != nil {
+			log.Printf("Failed to generate the %s statement for volunteer %d: %v", lastMonth.Format("2006-01"), volunteer.ID, err)
+			continue
+		}
+		generated++
+	}
+	return generated, nil
+}
+
+// Generate compiles, stores and emails a volunteer's statement for the month starting
+// at monthStart. Volunteers with no shifts that month get a statement but no email.
+func (vs *VolunteerStatementService) Generate(volunteer models.User, monthStart time.Time, now time.Time) (*models.VolunteerStatement, error) {
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	content := VolunteerStatementContent{
+		VolunteerName: strings.TrimSpace(volunteer.FirstName + " " + volunteer.LastName),
+		Month:         monthStart.Format("2006-01"),
+		From:          monthStart.Format("2006-01-02"),
+		To:            monthEnd.AddDate(0, 0, -1).Format("2006-01-02"),
+		Shifts:        []StatementShift{},
+	}
+
+	var rows []struct {
+		ShiftID  uint
+		Date     time.Time
+		Role     string
+		Location string
+		Hours    float64
+	}
+	if err := vs.completedAssignments(volunteer.ID).
+		Select(`shifts.id AS shift_id, shifts.date, COALESCE(NULLIF(shifts.role, ''), 'General volunteering') AS role,
+			shifts.location, `+assignmentHoursSQL+` AS hours`).
+		Where("shifts.date >= ? AND shifts.date < ?", monthStart, monthEnd).
+		Order("shifts.date ASC, shifts.start_time ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		hours := math.Round(row.Hours*10) / 10
+		content.Shifts = append(content.Shifts, StatementShift{
+			ShiftID:  row.ShiftID,
+			Date:     row.Date.Format("2006-01-02"),
+			Role:     row.Role,
+			Location: row.Location,
+			Hours:    hours,
+		})
+		content.Hours += hours
+	}
+	content.Hours = math.Round(content.Hours*10) / 10
+	content.ShiftCount = int64(len(rows))
+
+	var upToMonthEnd struct {
+		Shifts int64
+		Hours  float64
+	}
+	if err := vs.completedAssignments(volunteer.ID).
+		Select("COUNT(*) AS shifts, COALESCE(SUM("+assignmentHoursSQL+"), 0) AS hours").
+		Where("shifts.date < ?", monthEnd).
+		Scan(&upToMonthEnd).Error; err != nil {
+		return nil, err
+	}
+	content.LifetimeHours = math.Round(upToMonthEnd.Hours*10) / 10
+
+	if err := vs.db.Model(&models.Kudos{}).
+		Where("recipient_id = ? AND status = ? AND created_at >= ? AND created_at < ?",
+			volunteer.ID, models.KudosStatusApproved, monthStart, monthEnd).
+		Count(&content.KudosReceived).Error; err != nil {
+		return nil, err
+	}
+	content.Achievements = statementAchievements(content.LifetimeHours, content.Hours,
+		upToMonthEnd.Shifts, content.ShiftCount, content.KudosReceived)
+
+	corrected, err := vs.reconcileTotalHours(volunteer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	statement := &models.VolunteerStatement{
+		UserID:         volunteer.ID,
+		Month:          content.Month,
+		Hours:          content.Hours,
+		Shifts:         content.ShiftCount,
+		LifetimeHours:  content.LifetimeHours,
+		HoursCorrected: corrected,
+		Content:        string(encoded),
+		GeneratedAt:    now,
+	}
+	if err := vs.db.Create(statement).Error; err != nil {
+		return nil, err
+	}
+
+	if content.ShiftCount > 0 && volunteer.Email != "" {
+		subject := fmt.Sprintf("Your volunteering statement for %s", monthStart.Format("January 2006"))
+		if err := notifications.GetService().SendEmail(volunteer.Email, subject, volunteerStatementEmailBody(&content)); err != nil {
+			log.Printf("Failed to email the %s statement to volunteer %d: %v", content.Month, volunteer.ID, err)
+		} else {
+			statement.EmailedAt = &now
+			vs.db.Model(statement).UpdateColumn("emailed_at", now)
+		}
+	}
+	return statement, nil
+}
+
+// completedAssignments selects a volunteer's completed shift assignments with
+// approved or confirmed hours, joined to their shifts
+func (vs *VolunteerStatementService) completedAssignments(userID uint) *gorm.DB {
+	return vs.db.Table("shift_assignments").
+		Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id AND shifts.deleted_at IS NULL").
+		Where("shift_assignments.user_id = ? AND "+approvedAssignmentSQL, userID)
+}
+
+// reconcileTotalHours sets the profile's total hours to the volunteer's approved
+// hours when they differ, returning the correction made
+func (vs *VolunteerStatementService) reconcileTotalHours(userID uint) (float64, error) {
+	var profile models.VolunteerProfile
+	if err := vs.db.Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	total, err := vs.completedHours(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	correction := math.Round((total-profile.TotalHours)*10) / 10
+	if correction == 0 {
+		return 0, nil
+	}
+	if err := vs.db.Model(&profile).UpdateColumn("total_hours", total).Error; err != nil {
+		return 0, err
+	}
+	log.Printf("Corrected volunteer %d's total hours from %.1f to %.1f", userID, profile.TotalHours, total)
+	return correction, nil
+}
+
+// completedHours returns a volunteer's approved shift hours to one decimal place
+func (vs *VolunteerStatementService) completedHours(userID uint) (float64, error) {
+	var total float64
+	if err := vs.completedAssignments(userID).
+		Select("COALESCE(SUM(" + assignmentHoursSQL + "), 0)").
+		Scan(&total).Error; err != nil {
+		return 0, err
+	}
+	return math.Round(total*10) / 10, nil
+}
+
+// RecomputeTotalHours rebuilds a volunteer's total hours from their approved shifts,
+// returning the totals before and after
+func (vs *VolunteerStatementService) RecomputeTotalHours(userID uint) (before, after float64, err error) {
+	err = vs.db.Transaction(func(tx *gorm.DB) error {
+		before, after, err = vs.RecomputeTotalHoursTx(tx, userID)
+		return err
+	})
+	return before, after, err
+}
+
+// RecomputeTotalHoursTx is RecomputeTotalHours within the caller's transaction, so
+// the change can commit together with the caller's own records such as an audit log
+func (vs *VolunteerStatementService) RecomputeTotalHoursTx(tx *gorm.DB, userID uint) (before, after float64, err error) {
+	var profile models.VolunteerProfile
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, 0, ErrVolunteerProfileNotFound
+		}
+		return 0, 0, err
+	}
+
+	total, err := (&VolunteerStatementService{db: tx}).completedHours(userID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := tx.Model(&profile).UpdateColumn("total_hours", total).Error; err != nil {
+		return 0, 0, err
+	}
+	return profile.TotalHours, total, nil
+}
+
+// ListForUser returns a volunteer's statements, newest first
+func (vs *VolunteerStatementService) ListForUser(userID uint) ([]models.VolunteerStatement, error) {
+	var statements []models.VolunteerStatement
+	err := vs.db.Omit("content").Where("user_id = ?", userID).Order("month DESC").Find(&statements).Error
+	return statements, err
+}
+
+// Get returns one of a volunteer's statements with its content
+func (vs *VolunteerStatementService) Get(userID uint, month string) (*models.VolunteerStatement, *VolunteerStatementContent, error) {
+	var statement models.VolunteerStatement
+	if err := vs.db.Where("user_id = ? AND month = ?", userID, month).First(&statement).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrVolunteerStatementNotFound
+		}
+		return nil, nil, err
+	}
+
+	var content VolunteerStatementContent
+	if err := json.Unmarshal([]byte(statement.Content), &content); err != nil {
+		return nil, nil, err
+	}
+	return &statement, &content, nil
+}
+
+// statementAchievements lists the milestones crossed during the month, given the
+// lifetime totals at the end of it and the month's own totals
+func statementAchievements(lifetimeHours, monthHours float64, lifetimeShifts, monthShifts, kudos int64) []StatementAchievement {
+	achievements := []StatementAchievement{}
+	for _, milestone := range volunteerHourMilestones {
+		if lifetimeHours-monthHours < milestone && lifetimeHours >= milestone {
+			achievements = append(achievements, StatementAchievement{
+				Type:        "hours",
+				Title:       fmt.Sprintf("%.0f hours", milestone),
+				Description: fmt.Sprintf("Passed %.0f hours of volunteering", milestone),
+			})
+		}
+	}
+	for _, milestone := range volunteerShiftMilestones {
+		if lifetimeShifts-monthShifts < milestone && lifetimeShifts >= milestone {
+			title := fmt.Sprintf("%d shifts", milestone)
+			description := fmt.Sprintf("Completed %d shifts", milestone)
+			if milestone == 1 {
+				title, description = "First shift", "Completed your first shift"
+			}
+			achievements = append(achievements, StatementAchievement{Type: "shifts", Title: title, Description: description})
+		}
+	}
+	if kudos > 0 {
+		description := "Received kudos from a fellow volunteer"
+		if kudos > 1 {
+			description = fmt.Sprintf("Received kudos from fellow volunteers %d times", kudos)
+		}
+		achievements = append(achievements, StatementAchievement{Type: "kudos", Title: "Kudos", Description: description})
+	}
+	return achievements
+}
+
+// volunteerStatementEmailBody writes the statement as plain text
+func volunteerStatementEmailBody(content *VolunteerStatementContent) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hello %s,\n\n", content.VolunteerName)
+	fmt.Fprintf(&b, "Thank you for volunteering with %s. Between %s and %s you completed %d shifts, %.1f hours in all. That brings your total to %.1f hours.\n\n",
+		BrandName(""), content.From, content.To, content.ShiftCount, content.Hours, content.LifetimeHours)
+
+	b.WriteString("Your shifts:\n")
+	for _, shift := range content.Shifts {
+		fmt.Fprintf(&b, "- %s %s: %.1f hours\n", shift.Date, shift.Role, shift.Hours)
+	}
+
+	if len(content.Achievements) > 0 {
+		b.WriteString("\nThis month:\n")
+		for _, achievement := range content.Achievements {
+			fmt.Fprintf(&b, "- %s\n", achievement.Description)
+		}
+	}
+
+	b.WriteString("\nIf anything looks wrong, please let your volunteer coordinator know. Past statements are in your volunteer dashboard.\n")
+	return b.String()
+}
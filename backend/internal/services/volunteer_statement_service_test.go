@@ -0,0 +1,34 @@
+package services
+
+import "testing"
+
+func TestStatementAchievementsOnlyCountsMilestonesCrossedThisMonth(t *testing.T) {
+	// 45 hours before the month, 60 after: only the 50 hour milestone was crossed
+	achievements := statementAchievements(60, 15, 12, 3, 0)
+	var titles []string
+	for _, achievement := range achievements {
+		titles = append(titles, achievement.Title)
+	}
+	if len(titles) != 2 || titles[0] != "50 hours" || titles[1] != "10 shifts" {
+		t.Fatalf("got %v, want [50 hours 10 shifts]", titles)
+	}
+}
+
+func TestStatementAchievementsFirstShiftAndKudos(t *testing.T) {
+	achievements := statementAchievements(4, 4, 1, 1, 2)
+	if len(achievements) != 2 {
+		t.Fatalf("got %d achievements, want 2: %+v", len(achievements), achievements)
+	}
+	if achievements[0].Title != "First shift" {
+		t.Errorf("got %q, want First shift", achievements[0].Title)
+	}
+	if achievements[1].Type != "kudos" || achievements[1].Description != "Received kudos from fellow volunteers 2 times" {
+		t.Errorf("got %+v", achievements[1])
+	}
+}
+
+func TestStatementAchievementsNoneWithoutShifts(t *testing.T) {
+	if achievements := statementAchievements(120, 0, 30, 0, 0); len(achievements) != 0 {
+		t.Fatalf("got %+v, want none", achievements)
+	}
+}
@@ -0,0 +1,587 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Volunteer team errors
+var (
+	ErrVolunteerTeamNotFound   = errors.New("volunteer team not found")
+	ErrVolunteerTeamInvalid    = errors.New("invalid volunteer team")
+	ErrVolunteerInAnotherTeam  = errors.New("volunteer is already in another team")
+	ErrVolunteerNotInTeam      = errors.New("volunteer is not in a team")
+	ErrNotTeamLead             = errors.New("you do not lead an active volunteer team")
+	ErrTeamPermission          = errors.New("your team is not allowed to do this")
+	ErrTeamAssignmentNotFound  = errors.New("shift assignment not found for your team")
+	ErrTeamAssignmentNotReady  = errors.New("shift assignment cannot be signed off in its current state")
+	ErrTeamLeadCannotBeRemoved = errors.New("choose a new team lead before removing the current one")
+)
+
+// teamTopContributors is how many members the team stats rank
+const teamTopContributors = 5
+
+// attendanceSignOffStatuses are the assignment statuses a team lead can confirm
+// attendance for
+var attendanceSignOffStatuses = []string{"Confirmed", "Assigned"}
+
+// TeamRosterEntry is one team member signed up for a shift
+type TeamRosterEntry struct {
+	AssignmentID          uint       `json:"assignment_id"`
+	UserID                uint       `json:"user_id"`
+	FirstName             string     `json:"first_name"`
+	LastName              string     `json:"last_name"`
+	Status                string     `json:"status"`
+	CheckedInAt           *time.Time `json:"checked_in_at"`
+	HoursLogged           float64    `json:"hours_logged"`
+	AttendanceConfirmedAt *time.Time `json:"attendance_confirmed_at"`
+	HoursApprovedAt       *time.Time `json:"hours_approved_at"`
+}
+
+// TeamShiftRoster is a shift and the team members signed up for it
+type TeamShiftRoster struct {
+	ShiftID   uint              `json:"shift_id"`
+	Date      time.Time         `json:"date"`
+	StartTime time.Time         `json:"start_time"`
+	EndTime   time.Time         `json:"end_time"`
+	Role      string            `json:"role"`
+	Location  string            `json:"location"`
+	Members   []TeamRosterEntry `json:"members"`
+}
+
+// TeamContributor is a member's hours in the team stats
+type TeamContributor struct {
+	UserID    uint    `json:"user_id"`
+	FirstName string  `json:"first_name"`
+	LastName  string  `json:"last_name"`
+	Hours     float64 `json:"hours"`
+}
+
+// VolunteerTeamStats are a team's figures for the current month
+type VolunteerTeamStats struct {
+	TeamID                uint              `json:"team_id"`
+	TeamName              string            `json:"team_name"`
+	LeadID                uint              `json:"lead_id"`
+	TotalMembers          int               `json:"total_members"`
+	ActiveMembers         int               `json:"active_members"`
+	TotalHoursThisMonth   float64           `json:"total_hours_this_month"`
+	AverageHoursPerMember float64           `json:"average_hours_per_member"` // Over active members
+	ShiftsThisMonth       int               `json:"shifts_this_month"`        // Completed by members
+	NoShowsThisMonth      int               `json:"no_shows_this_month"`
+	AttendanceRate        *float64          `json:"attendance_rate"` // Percentage of finished shifts attended
+	PendingApprovalHours  float64           `json:"pending_approval_hours"`
+	TopContributors       []TeamContributor `json:"top_contributors"`
+}
+
+// teamMemberMonth is one member's activity for the team stats
+type teamMemberMonth struct {
+	UserID        uint
+	FirstName     string
+	LastName      string
+	ProfileStatus string
+	Hours         float64
+	Shifts        int
+	NoShows       int
+	PendingHours  float64
+}
+
+// VolunteerTeamService manages volunteer teams and the sign-off their leads do for
+// their own members
+type VolunteerTeamService struct {
+	db *gorm.DB
+}
+
+// NewVolunteerTeamService creates a new volunteer team service
+func NewVolunteerTeamService() *VolunteerTeamService {
+	return &VolunteerTeamService{db: db.DB}
+}
+
+// teamPeople loads only the name and contact details of a team's lead or members
+func teamPeople(tx *gorm.DB) *gorm.DB {
+	return tx.Select("id", "first_name", "last_name", "email")
+}
+
+// Teams returns every team with its lead and members
+func (ts *VolunteerTeamService) Teams() ([]models.VolunteerTeam, error) {
+	var teams []models.VolunteerTeam
+	err := ts.db.Preload("Lead", teamPeople).Preload("Members.User", teamPeople).
+		Order("name ASC").Find(&teams).Error
+	return teams, err
+}
+
+// Team returns one team with its lead and members
+func (ts *VolunteerTeamService) Team(id uint) (*models.VolunteerTeam, error) {
+	var team models.VolunteerTeam
+	if err := ts.db.Preload("Lead", teamPeople).Preload("Members.User", teamPeople).First(&team, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVolunteerTeamNotFound
+		}
+		return nil, err
+	}
+	return &team, nil
+}
+
+// CreateTeam adds a team with its lead and the given volunteers as members
+func (ts *VolunteerTeamService) CreateTeam(team *models.VolunteerTeam, memberIDs []uint, createdBy uint, now time.Time) (*models.VolunteerTeam, error) {
+	if err := validateVolunteerTeam(team); err != nil {
+		return nil, err
+	}
+
+	err := ts.db.Transaction(func(tx *gorm.DB) error {
+		team.ID = 0
+		active := team.Active
+		if err := tx.Omit("Lead", "Members").Create(team).Error; err != nil {
+			return err
+		}
+		// The column defaults to true, so an inactive team is switched off after insert
+		if !active {
+			if err := tx.Model(team).Update("active", false).Error; err != nil {
+				return err
+			}
+		}
+		for _, userID := range append([]uint{team.LeadID}, memberIDs...) {
+			if err := addTeamMember(tx, team.ID, userID, createdBy, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ts.Team(team.ID)
+}
+
+// UpdateTeam changes a team's details, lead and permissions. A new lead joins the
+// team if they are not already in it.
+func (ts *VolunteerTeamService) UpdateTeam(id uint, changes *models.VolunteerTeam, updatedBy uint, now time.Time) (*models.VolunteerTeam, error) {
+	if err := validateVolunteerTeam(changes); err != nil {
+		return nil, err
+	}
+
+	err := ts.db.Transaction(func(tx *gorm.DB) error {
+		var team models.VolunteerTeam
+		if err := tx.First(&team, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrVolunteerTeamNotFound
+			}
+			return err
+		}
+
+		if err := addTeamMember(tx, team.ID, changes.LeadID, updatedBy, now); err != nil {
+			return err
+		}
+
+		team.Name = changes.Name
+		team.Description = changes.Description
+		team.LeadID = changes.LeadID
+		team.CanConfirmAttendance = changes.CanConfirmAttendance
+		team.CanApproveHours = changes.CanApproveHours
+		team.Active = changes.Active
+		return tx.Omit("Lead", "Members").Save(&team).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ts.Team(id)
+}
+
+// DeleteTeam removes a team and releases its members
+func (ts *VolunteerTeamService) DeleteTeam(id uint) error {
+	return ts.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ?", id).Delete(&models.VolunteerTeamMember{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.VolunteerTeam{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVolunteerTeamNotFound
+		}
+		return nil
+	})
+}
+
+// AddMember puts a volunteer in a team
+func (ts *VolunteerTeamService) AddMember(teamID, userID, addedBy uint, now time.Time) error {
+	return ts.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.VolunteerTeam{}, teamID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrVolunteerTeamNotFound
+			}
+			return err
+		}
+		return addTeamMember(tx, teamID, userID, addedBy, now)
+	})
+}
+
+// RemoveMember takes a volunteer out of a team. The lead stays until replaced.
+func (ts *VolunteerTeamService) RemoveMember(teamID, userID uint) error {
+	var team models.VolunteerTeam
+	if err := ts.db.First(&team, teamID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrVolunteerTeamNotFound
+		}
+		return err
+	}
+	if team.LeadID == userID {
+		return ErrTeamLeadCannotBeRemoved
+	}
+
+	result := ts.db.Where("team_id = ? AND user_id = ?", teamID, userID).Delete(&models.VolunteerTeamMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVolunteerNotInTeam
+	}
+	return nil
+}
+
+// validateVolunteerTeam checks a team's name and lead
+func validateVolunteerTeam(team *models.VolunteerTeam) error {
+	team.Name = strings.TrimSpace(team.Name)
+	if team.Name == "" {
+		return fmt.Errorf("%w: team name is required", ErrVolunteerTeamInvalid)
+	}
+	if team.LeadID == 0 {
+		return fmt.Errorf("%w: team lead is required", ErrVolunteerTeamInvalid)
+	}
+	return nil
+}
+
+// addTeamMember records a volunteer joining a team
+func addTeamMember(tx *gorm.DB, teamID, userID, addedBy uint, now time.Time) error {
+	var user models.User
+	if err := tx.Select("id", "role").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: user %d not found", ErrVolunteerTeamInvalid, userID)
+		}
+		return err
+	}
+	if user.Role != models.RoleVolunteer {
+		return fmt.Errorf("%w: user %d is not a volunteer", ErrVolunteerTeamInvalid, userID)
+	}
+
+	var existing models.VolunteerTeamMember
+	err := tx.Where("user_id = ?", userID).First(&existing).Error
+	if err == nil {
+		if existing.TeamID == teamID {
+			return nil
+		}
+		return ErrVolunteerInAnotherTeam
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return tx.Create(&models.VolunteerTeamMember{
+		TeamID:   teamID,
+		UserID:   userID,
+		JoinedAt: now,
+		AddedBy:  addedBy,
+	}).Error
+}
+
+// LedTeam returns the active team a volunteer leads
+func (ts *VolunteerTeamService) LedTeam(userID uint) (*models.VolunteerTeam, error) {
+	var team models.VolunteerTeam
+	if err := ts.db.Preload("Lead", teamPeople).Preload("Members.User", teamPeople).
+		Where("lead_id = ? AND active = ?", userID, true).First(&team).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotTeamLead
+		}
+		return nil, err
+	}
+	return &team, nil
+}
+
+// MemberTeam returns the team a volunteer belongs to
+func (ts *VolunteerTeamService) MemberTeam(userID uint) (*models.VolunteerTeam, error) {
+	var membership models.VolunteerTeamMember
+	if err := ts.db.Where("user_id = ?", userID).First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVolunteerNotInTeam
+		}
+		return nil, err
+	}
+	return ts.Team(membership.TeamID)
+}
+
+// Rosters returns the shifts between from and to that the team's members are signed
+// up for, with who is on each
+func (ts *VolunteerTeamService) Rosters(teamID uint, from, to time.Time) ([]TeamShiftRoster, error) {
+	var rows []struct {
+		TeamRosterEntry
+		ShiftID   uint
+		Date      time.Time
+		StartTime time.Time
+		EndTime   time.Time
+		Role      string
+		Location  string
+	}
+	err := ts.db.Table("shift_assignments").
+		Select(`shift_assignments.id AS assignment_id, shift_assignments.user_id, users.first_name, users.last_name,
+			shift_assignments.status, shift_assignments.checked_in_at, shift_assignments.hours_logged,
+			shift_assignments.attendance_confirmed_at, shift_assignments.hours_approved_at,
+			shifts.id AS shift_id, shifts.date, shifts.start_time, shifts.end_time, shifts.role, shifts.location`).
+		Joins("JOIN volunteer_team_members ON volunteer_team_members.user_id = shift_assignments.user_id").
+		Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id AND shifts.deleted_at IS NULL AND shifts.cancelled_at IS NULL").
+		Joins("JOIN users ON users.id = shift_assignments.user_id").
+		Where("volunteer_team_members.team_id = ? AND shifts.start_time >= ? AND shifts.start_time < ?", teamID, from, to).
+		Where("shift_assignments.status <> ?", "Cancelled").
+		Order("shifts.start_time ASC, users.first_name ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rosters := []TeamShiftRoster{}
+	for _, row := range rows {
+		if len(rosters) == 0 || rosters[len(rosters)-1].ShiftID != row.ShiftID {
+			rosters = append(rosters, TeamShiftRoster{
+				ShiftID:   row.ShiftID,
+				Date:      row.Date,
+				StartTime: row.StartTime,
+				EndTime:   row.EndTime,
+				Role:      row.Role,
+				Location:  row.Location,
+				Members:   []TeamRosterEntry{},
+			})
+		}
+		last := &rosters[len(rosters)-1]
+		last.Members = append(last.Members, row.TeamRosterEntry)
+	}
+	return rosters, nil
+}
+
+// PendingHours returns the team members' assignments waiting for hours approval
+func (ts *VolunteerTeamService) PendingHours(teamID uint) ([]models.ShiftAssignment, error) {
+	var assignments []models.ShiftAssignment
+	err := ts.db.Preload("Shift").Preload("User", teamPeople).
+		Joins("JOIN volunteer_team_members ON volunteer_team_members.user_id = shift_assignments.user_id").
+		Where("volunteer_team_members.team_id = ? AND shift_assignments.status = ?", teamID, models.ShiftAssignmentPendingApproval).
+		Order("shift_assignments.attendance_confirmed_at ASC").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+// ConfirmAttendance lets a team lead record whether a member turned up to a shift
+// that has started. Attended shifts wait for their hours to be approved; hours of
+// zero take the shift's length.
+func (ts *VolunteerTeamService) ConfirmAttendance(leadUserID, assignmentID uint, attended bool, hours float64, now time.Time) (*models.ShiftAssignment, error) {
+	team, err := ts.LedTeam(leadUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !team.CanConfirmAttendance {
+		return nil, ErrTeamPermission
+	}
+	if hours < 0 || hours > 24 {
+		return nil, fmt.Errorf("%w: hours must be between 0 and 24", ErrVolunteerTeamInvalid)
+	}
+
+	var assignment models.ShiftAssignment
+	err = ts.db.Transaction(func(tx *gorm.DB) error {
+		if err := ts.lockTeamAssignment(tx, team.ID, assignmentID, &assignment); err != nil {
+			return err
+		}
+		if assignment.AttendanceConfirmedAt != nil || !slices.Contains(attendanceSignOffStatuses, assignment.Status) ||
+			assignment.Shift.StartTime.After(now) {
+			return ErrTeamAssignmentNotReady
+		}
+
+		updates := map[string]interface{}{
+			"attendance_confirmed_by": leadUserID,
+			"attendance_confirmed_at": now,
+		}
+		if attended {
+			if hours == 0 {
+				hours = math.Round(assignment.Shift.EndTime.Sub(assignment.Shift.StartTime).Hours()*100) / 100
+			}
+			updates["status"] = models.ShiftAssignmentPendingApproval
+			updates["hours_logged"] = hours
+		} else {
+			updates["status"] = "NoShow"
+			updates["no_show_recorded"] = true
+			updates["no_show_recorded_by"] = leadUserID
+			updates["no_show_recorded_at"] = now
+			updates["no_show_reason"] = "Not attended, recorded by team lead"
+		}
+		return tx.Model(&assignment).Omit("Shift").Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// ApproveHours lets a team lead approve a member's hours for an attended shift,
+// optionally correcting them, and brings the member's total hours up to date
+func (ts *VolunteerTeamService) ApproveHours(leadUserID, assignmentID uint, hours *float64, now time.Time) (*models.ShiftAssignment, error) {
+	team, err := ts.LedTeam(leadUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !team.CanApproveHours {
+		return nil, ErrTeamPermission
+	}
+	if hours != nil && (*hours <= 0 || *hours > 24) {
+		return nil, fmt.Errorf("%w: hours must be more than 0 and at most 24", ErrVolunteerTeamInvalid)
+	}
+
+	var assignment models.ShiftAssignment
+	err = ts.db.Transaction(func(tx *gorm.DB) error {
+		if err := ts.lockTeamAssignment(tx, team.ID, assignmentID, &assignment); err != nil {
+			return err
+		}
+		if assignment.Status != models.ShiftAssignmentPendingApproval {
+			return ErrTeamAssignmentNotReady
+		}
+
+		updates := map[string]interface{}{
+			"status":            "Completed",
+			"hours_approved_by": leadUserID,
+			"hours_approved_at": now,
+		}
+		if hours != nil {
+			updates["hours_logged"] = *hours
+		}
+		return tx.Model(&assignment).Omit("Shift").Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err := (&VolunteerStatementService{db: ts.db}).RecomputeTotalHours(assignment.UserID); err != nil &&
+		!errors.Is(err, ErrVolunteerProfileNotFound) {
+		return &assignment, err
+	}
+	return &assignment, nil
+}
+
+// lockTeamAssignment loads and locks an assignment belonging to one of the team's
+// members, with its shift
+func (ts *VolunteerTeamService) lockTeamAssignment(tx *gorm.DB, teamID, assignmentID uint, assignment *models.ShiftAssignment) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "shift_assignments"}}).
+		Joins("JOIN volunteer_team_members ON volunteer_team_members.user_id = shift_assignments.user_id").
+		Where("shift_assignments.id = ? AND volunteer_team_members.team_id = ?", assignmentID, teamID).
+		First(assignment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTeamAssignmentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return tx.First(&assignment.Shift, assignment.ShiftID).Error
+}
+
+// Stats returns a team's figures for the month containing now
+func (ts *VolunteerTeamService) Stats(team *models.VolunteerTeam, now time.Time) (*VolunteerTeamStats, error) {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	var members []teamMemberMonth
+	if err := ts.db.Table("volunteer_team_members").
+		Select("users.id AS user_id, users.first_name, users.last_name, COALESCE(volunteer_profiles.status, '') AS profile_status").
+		Joins("JOIN users ON users.id = volunteer_team_members.user_id").
+		Joins("LEFT JOIN volunteer_profiles ON volunteer_profiles.user_id = users.id").
+		Where("volunteer_team_members.team_id = ?", team.ID).
+		Scan(&members).Error; err != nil {
+		return nil, err
+	}
+
+	var activity []struct {
+		UserID       uint
+		Hours        float64
+		Shifts       int
+		NoShows      int
+		PendingHours float64
+	}
+	if err := ts.db.Table("shift_assignments").
+		Select(`shift_assignments.user_id,
+			COALESCE(SUM(CASE WHEN LOWER(shift_assignments.status) = 'completed' THEN `+assignmentHoursSQL+` END), 0) AS hours,
+			COUNT(*) FILTER (WHERE LOWER(shift_assignments.status) = 'completed') AS shifts,
+			COUNT(*) FILTER (WHERE shift_assignments.status = 'NoShow') AS no_shows,
+			COALESCE(SUM(shift_assignments.hours_logged) FILTER (WHERE shift_assignments.status = ?), 0) AS pending_hours`,
+			models.ShiftAssignmentPendingApproval).
+		Joins("JOIN volunteer_team_members ON volunteer_team_members.user_id = shift_assignments.user_id").
+		Joins("JOIN shifts ON shifts.id = shift_assignments.shift_id AND shifts.deleted_at IS NULL").
+		Where("volunteer_team_members.team_id = ? AND shifts.start_time >= ? AND shifts.start_time < ?", team.ID, monthStart, monthEnd).
+		Group("shift_assignments.user_id").
+		Scan(&activity).Error; err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[uint]int, len(members))
+	for i, member := range members {
+		byUser[member.UserID] = i
+	}
+	for _, row := range activity {
+		if i, ok := byUser[row.UserID]; ok {
+			members[i].Hours, members[i].Shifts = row.Hours, row.Shifts
+			members[i].NoShows, members[i].PendingHours = row.NoShows, row.PendingHours
+		}
+	}
+
+	return buildTeamStats(*team, members), nil
+}
+
+// buildTeamStats totals a team's month from its members' activity
+func buildTeamStats(team models.VolunteerTeam, members []teamMemberMonth) *VolunteerTeamStats {
+	stats := &VolunteerTeamStats{
+		TeamID:          team.ID,
+		TeamName:        team.Name,
+		LeadID:          team.LeadID,
+		TotalMembers:    len(members),
+		TopContributors: []TeamContributor{},
+	}
+
+	for _, member := range members {
+		if strings.EqualFold(member.ProfileStatus, "active") {
+			stats.ActiveMembers++
+		}
+		stats.TotalHoursThisMonth += member.Hours
+		stats.ShiftsThisMonth += member.Shifts
+		stats.NoShowsThisMonth += member.NoShows
+		stats.PendingApprovalHours += member.PendingHours
+		if member.Hours > 0 {
+			stats.TopContributors = append(stats.TopContributors, TeamContributor{
+				UserID:    member.UserID,
+				FirstName: member.FirstName,
+				LastName:  member.LastName,
+				Hours:     math.Round(member.Hours*10) / 10,
+			})
+		}
+	}
+
+	stats.TotalHoursThisMonth = math.Round(stats.TotalHoursThisMonth*10) / 10
+	stats.PendingApprovalHours = math.Round(stats.PendingApprovalHours*10) / 10
+	if stats.ActiveMembers > 0 {
+		stats.AverageHoursPerMember = math.Round(stats.TotalHoursThisMonth/float64(stats.ActiveMembers)*10) / 10
+	}
+	if finished := stats.ShiftsThisMonth + stats.NoShowsThisMonth; finished > 0 {
+		rate := math.Round(float64(stats.ShiftsThisMonth)/float64(finished)*1000) / 10
+		stats.AttendanceRate = &rate
+	}
+
+	sort.SliceStable(stats.TopContributors, func(i, j int) bool {
+		return stats.TopContributors[i].Hours > stats.TopContributors[j].Hours
+	})
+	if len(stats.TopContributors) > teamTopContributors {
+		stats.TopContributors = stats.TopContributors[:teamTopContributors]
+	}
+	return stats
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestBuildTeamStats(t *testing.T) {
+	team := models.VolunteerTeam{ID: 3, Name: "Warehouse", LeadID: 10}
+	members := []teamMemberMonth{
+		{UserID: 10, FirstName: "Ada", ProfileStatus: "active", Hours: 12, Shifts: 3},
+		{UserID: 11, FirstName: "Ben", ProfileStatus: "Active", Hours: 4.25, Shifts: 1, NoShows: 1, PendingHours: 3},
+		{UserID: 12, FirstName: "Cy", ProfileStatus: "inactive"},
+		{UserID: 13, FirstName: "Di", ProfileStatus: "active", Hours: 20, Shifts: 4},
+	}
+
+	stats := buildTeamStats(team, members)
+
+	if stats.TeamID != 3 || stats.LeadID != 10 {
+		t.Errorf("team = %d lead = %d, want 3 and 10", stats.TeamID, stats.LeadID)
+	}
+	if stats.TotalMembers != 4 || stats.ActiveMembers != 3 {
+		t.Errorf("members = %d active = %d, want 4 and 3", stats.TotalMembers, stats.ActiveMembers)
+	}
+	if stats.TotalHoursThisMonth != 36.3 {
+		t.Errorf("total hours = %v, want 36.3", stats.TotalHoursThisMonth)
+	}
+	if stats.AverageHoursPerMember != 12.1 {
+		t.Errorf("average hours = %v, want 12.1", stats.AverageHoursPerMember)
+	}
+	if stats.ShiftsThisMonth != 8 || stats.NoShowsThisMonth != 1 || stats.PendingApprovalHours != 3 {
+		t.Errorf("shifts = %d no-shows = %d pending = %v, want 8, 1 and 3",
+			stats.ShiftsThisMonth, stats.NoShowsThisMonth, stats.PendingApprovalHours)
+	}
+	if stats.AttendanceRate == nil || *stats.AttendanceRate != 88.9 {
+		t.Errorf("attendance rate = %v, want 88.9", stats.AttendanceRate)
+	}
+
+	want := []uint{13, 10, 11}
+	if len(stats.TopContributors) != len(want) {
+		t.Fatalf("top contributors = %+v, want users %v", stats.TopContributors, want)
+	}
+	for i, userID := range want {
+		if stats.TopContributors[i].UserID != userID {
+			t.Errorf("contributor %d = user %d, want %d", i, stats.TopContributors[i].UserID, userID)
+		}
+	}
+}
+
+func TestBuildTeamStatsEmptyTeam(t *testing.T) {
+	stats := buildTeamStats(models.VolunteerTeam{ID: 1, Name: "New"}, nil)
+	if stats.AttendanceRate != nil {
+		t.Errorf("attendance rate = %v, want none before any shifts finish", *stats.AttendanceRate)
+	}
+	if stats.AverageHoursPerMember != 0 || stats.TopContributors == nil {
+		t.Errorf("stats = %+v, want zero average and an empty contributor list", stats)
+	}
+}
+
+func TestBuildTeamStatsTopContributorLimit(t *testing.T) {
+	var members []teamMemberMonth
+	for i := uint(1); i <= 7; i++ {
+		members = append(members, teamMemberMonth{UserID: i, Hours: float64(i)})
+	}
+	stats := buildTeamStats(models.VolunteerTeam{ID: 1}, members)
+	if len(stats.TopContributors) != teamTopContributors || stats.TopContributors[0].UserID != 7 {
+		t.Errorf("top contributors = %+v, want the %d with most hours", stats.TopContributors, teamTopContributors)
+	}
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/db"
+	"github.com/geoo115/charity-management-system/internal/models"
+	"gorm.io/gorm"
+)
+
+const defaultVisitIntervalDays = 7
+
+var (
+	ErrWalkInAlreadyMerged = errors.New("walk-in has already been merged")
+	ErrWalkInNotNewVisitor = errors.New("walk-in was matched to an existing account and does not need merging")
+	ErrMergeSameVisitor    = errors.New("cannot merge a visitor into the same account")
+	ErrMergeTargetInvalid  = errors.New("merge target must be an existing visitor account")
+)
+
+// WalkInService registers visitors who arrive at the front desk without a booking
+type WalkInService struct {
+	db *gorm.DB
+}
+
+// WalkInInput holds the minimal details collected at the front desk
+type WalkInInput struct {
+	FirstName           string
+	LastName            string
+	Phone               string
+	Postcode            string
+	Category            string
+	HouseholdSize       int
+	Notes               string
+	OverrideEligibility bool // Staff have checked eligibility in person
+}
+
+// EligibilityResult is the outcome of a quick eligibility check
+type EligibilityResult struct {
+	Eligible bool     `json:"eligible"`
+	Reasons  []string `json:"reasons"`
+}
+
+// WalkInResult describes what happened when a walk-in was registered
+type WalkInResult struct {
+	Registration       *models.WalkInRegistration `json:"registration"`
+	Visitor            *models.User               `json:"visitor"`
+	Eligibility        EligibilityResult          `json:"eligibility"`
+	Ticket             *models.Ticket             `json:"ticket,omitempty"`
+	Standby            *models.StandbyEntry       `json:"standby,omitempty"`
+	StandbyPosition    int                        `json:"standby_position,omitempty"`
+	PossibleDuplicates []models.User              `json:"possible_duplicates"`
+}
+
+// NewWalkInService creates a new walk-in service
+func NewWalkInService() *WalkInService {
+	return &WalkInService{
+		db: db.DB,
+	}
+}
+
+// RegisterWalkIn registers a walk-in visitor, checks eligibility and either issues a
+// same-day ticket or adds them to the standby list when today's capacity is used up
+func (ws *WalkInService) RegisterWalkIn(input WalkInInput, staffID uint) (*WalkInResult, error) {
+	now := time.Now()
+	today := now.Format("2006-01-02")
+
+	category := strings.ToLower(strings.TrimSpace(input.Category))
+	serviceTypeService := NewServiceTypeService()
+	serviceType, err := serviceTypeService.GetByCode(category)
+	if err == nil {
+		category = serviceType.Code
+	} else {
+		serviceType = nil
+	}
+
+	phone := ""
+	if strings.TrimSpace(input.Phone) != "" {
+		if phone, err = models.NormalizePhone(input.Phone); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &WalkInResult{PossibleDuplicates: []models.User{}}
+	newVisitor := true
+
+	// A known phone number identifies an existing visitor, so no new account is needed
+	if phone != "" {
+		existing, err := NewPhoneAuthService().FindUserByPhone(phone)
+		if errors.Is(err, ErrPhoneAmbiguous) {
+			// Registering another account would add to the duplicates
+			return nil, err
+		}
+		if err == nil {
+			result.Visitor = existing
+			newVisitor = false
+		}
+	}
+
+	err = ws.db.Transaction(func(tx *gorm.DB) error {
+		if newVisitor {
+			visitor, err := ws.createWalkInVisitor(tx, input, phone)
+			if err != nil {
+				return err
+			}
+			result.Visitor = visitor
+		}
+
+		result.Eligibility = ws.checkEligibility(tx, result.Visitor, input.Postcode, serviceType, category, now)
+		if !result.Eligibility.Eligible && input.OverrideEligibility {
+			result.Eligibility.Reasons = append(result.Eligibility.Reasons, "Eligibility overridden by staff")
+			result.Eligibility.Eligible = true
+		}
+
+		registration := models.WalkInRegistration{
+			VisitorID:        result.Visitor.ID,
+			Category:         category,
+			VisitDay:         today,
+			Outcome:          models.WalkInOutcomeIneligible,
+			EligibilityNotes: strings.Join(result.Eligibility.Reasons, "; "),
+			NewVisitor:       newVisitor,
+			RegisteredBy:     staffID,
+		}
+
+		if result.Eligibility.Eligible {
+			remaining := 1
+			if serviceType != nil {
+				remaining = serviceTypeService.RemainingCapacity(serviceType, today)
+			}
+
+			if remaining > 0 {
+				helpRequest, ticket, err := ws.issueSameDayTicket(tx, result.Visitor, input, serviceType, category, staffID, now)
+				if err != nil {
+					return err
+				}
+				registration.Outcome = models.WalkInOutcomeTicketIssued
+				registration.HelpRequestID = &helpRequest.ID
+				registration.TicketID = &ticket.ID
+				result.Ticket = ticket
+			} else {
+				standby := models.StandbyEntry{
+					VisitorID: result.Visitor.ID,
+					Category:  category,
+					VisitDay:  today,
+					Priority:  models.StandbyPriorityWalkIn,
+					Source:    models.StandbySourceWalkIn,
+					Status:    models.StandbyStatusWaiting,
+					Notes:     input.Notes,
+					AddedBy:   staffID,
+				}
+				if err := tx.Create(&standby).Error; err != nil {
+					return fmt.Errorf("failed to add to standby list: %w", err)
+				}
+
+				var ahead int64
+				tx.Model(&models.StandbyEntry{}).
+					Where("visit_day = ? AND category = ? AND status = ? AND (priority > ? OR (priority = ? AND id < ?))",
+						today, category, models.StandbyStatusWaiting, standby.Priority, standby.Priority, standby.ID).
+					Count(&ahead)
+
+				registration.Outcome = models.WalkInOutcomeStandby
+				registration.StandbyEntryID = &standby.ID
+				result.Standby = &standby
+				result.StandbyPosition = int(ahead) + 1
+			}
+		}
+
+		if err := tx.Create(&registration).Error; err != nil {
+			return fmt.Errorf("failed to record walk-in: %w", err)
+		}
+		result.Registration = &registration
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if newVisitor {
+		result.PossibleDuplicates = ws.FindPossibleDuplicates(result.Visitor)
+	}
+
+	return result, nil
+}
+
+// CheckEligibility runs the quick eligibility check for an existing visitor
+func (ws *WalkInService) CheckEligibility(visitor *models.User, category string) EligibilityResult {
+	category = strings.ToLower(strings.TrimSpace(category))
+	serviceType, err := NewServiceTypeService().GetByCode(category)
+	if err != nil {
+		serviceType = nil
+	}
+	return ws.checkEligibility(ws.db, visitor, visitor.Postcode, serviceType, category, time.Now())
+}
+
+// FindPossibleDuplicates returns other visitor accounts that may belong to the same person:
+// the same full name, or the same surname and postcode
+func (ws *WalkInService) FindPossibleDuplicates(visitor *models.User) []models.User {
+	duplicates := []models.User{}
+	postcode := normalizePostcode(visitor.Postcode)
+
+	query := ws.db.Select("id, first_name, last_name, email, phone, postcode, created_at").
+		Where("id <> ? AND role IN ?", visitor.ID, []string{models.RoleVisitor, models.RoleVisitorLegacy})
+	if postcode != "" {
+		query = query.Where("(LOWER(first_name) = ? AND LOWER(last_name) = ?) OR (LOWER(last_name) = ? AND UPPER(REPLACE(postcode, ' ', '')) = ?)",
+			strings.ToLower(visitor.FirstName), strings.ToLower(visitor.LastName), strings.ToLower(visitor.LastName), postcode)
+	} else {
+		query = query.Where("LOWER(first_name) = ? AND LOWER(last_name) = ?",
+			strings.ToLower(visitor.FirstName), strings.ToLower(visitor.LastName))
+	}
+
+	query.Order("created_at ASC").Limit(10).Find(&duplicates)
+	return duplicates
+}
+
+// MergeWalkIn moves everything recorded against a walk-in's new account onto the
+// visitor's existing account, then removes the walk-in account
+func (ws *WalkInService) MergeWalkIn(registrationID, targetUserID, staffID uint) (*models.WalkInRegistration, error) {
+	var registration models.WalkInRegistration
+	err := ws.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&registration, registrationID).Error; err != nil {
+			return err
+		}
+		if registration.MergedFromUserID != nil {
+			return ErrWalkInAlreadyMerged
+		}
+		if !registration.NewVisitor {
+			return ErrWalkInNotNewVisitor
+		}
+
+		sourceID := registration.VisitorID
+		if sourceID == targetUserID {
+			return ErrMergeSameVisitor
+		}
+
+		var source, target models.User
+		if err := tx.First(&source, sourceID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role IN ?", []string{models.RoleVisitor, models.RoleVisitorLegacy}).
+			First(&target, targetUserID).Error; err != nil {
+			return ErrMergeTargetInvalid
+		}
+
+		// Re-point the walk-in account's records at the existing account
+		for _, table := range []string{"help_requests", "tickets", "visits", "queue_entries", "standby_entries", "walk_in_registrations"} {
+			if err := tx.Table(table).Where("visitor_id = ?", sourceID).
+				Update("visitor_id", targetUserID).Error; err != nil {
+				return fmt.Errorf("failed to move %s: %w", table, err)
+			}
+		}
+
+		// Keep contact details the existing account is missing. The phone must be
+		// cleared from the walk-in account first because phone numbers are unique.
+		updates := map[string]interface{}{}
+		if target.Phone == "" && source.Phone != "" {
+			updates["phone"] = source.Phone
+		}
+		if target.Postcode == "" && source.Postcode != "" {
+			updates["postcode"] = source.Postcode
+		}
+		if err := tx.Model(&source).Update("phone", "").Error; err != nil {
+			return err
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&target).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("user_id = ?", sourceID).Delete(&models.VisitorProfile{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&source).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		registration.VisitorID = targetUserID
+		registration.MergedFromUserID = &sourceID
+		registration.MergedAt = &now
+		registration.MergedBy = &staffID
+		return tx.Save(&registration).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &registration, nil
+}
+
+// createWalkInVisitor creates a minimal visitor account for a walk-in
+func (ws *WalkInService) createWalkInVisitor(tx *gorm.DB, input WalkInInput, phone string) (*models.User, error) {
+	// Walk-ins can sign in later by SMS code, so the password is random and never shown
+	password, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	visitor := models.User{
+		FirstName:  strings.TrimSpace(input.FirstName),
+		LastName:   strings.TrimSpace(input.LastName),
+		Phone:      phone,
+		Postcode:   strings.ToUpper(strings.TrimSpace(input.Postcode)),
+		Password:   password + "Aa1!",
+		Role:       models.RoleVisitor,
+		Status:     "active",
+		FirstLogin: true,
+	}
+	if err := visitor.HashPassword(); err != nil {
+		return nil, err
+	}
+	if err := tx.Create(&visitor).Error; err != nil {
+		return nil, fmt.Errorf("failed to create visitor: %w", err)
+	}
+
+	householdSize := input.HouseholdSize
+	if householdSize < 1 {
+		householdSize = 1
+	}
+	if err := tx.Create(&models.VisitorProfile{UserID: visitor.ID, HouseholdSize: householdSize}).Error; err != nil {
+		return nil, fmt.Errorf("failed to create visitor profile: %w", err)
+	}
+
+	return &visitor, nil
+}
+
+// checkEligibility checks the postcode, that the service runs today and the minimum
+// interval since the visitor's last visit
+func (ws *WalkInService) checkEligibility(tx *gorm.DB, visitor *models.User, postcode string, serviceType *models.ServiceType, category string, now time.Time) EligibilityResult {
+	result := EligibilityResult{Eligible: true, Reasons: []string{}}
+	fail := func(reason string) {
+		result.Eligible = false
+		result.Reasons = append(result.Reasons, reason)
+	}
+
+	if postcode == "" {
+		postcode = visitor.Postcode
+	}
+	if postcode == "" {
+		fail("No postcode provided")
+	} else if !(&models.HelpRequest{Postcode: postcode}).IsEligible() {
+		fail(fmt.Sprintf("Postcode %s is outside the service area", strings.ToUpper(postcode)))
+	}
+
+	intervalDays := defaultVisitIntervalDays
+	if serviceType != nil {
+		if !serviceType.IsActive {
+			fail(fmt.Sprintf("%s is not currently available", serviceType.Name))
+		} else if !serviceType.IsOperatingDay(now.Weekday()) {
+			fail(fmt.Sprintf("%s does not run on %s", serviceType.Name, now.Weekday()))
+		} else if _, closing, err := serviceType.SlotWindow(); err == nil && now.Hour()*60+now.Minute() >= closing {
+			fail(fmt.Sprintf("%s has closed for today", serviceType.Name))
+		}
+		intervalDays = serviceType.VisitIntervalDays
+	}
+
+	if visitor.ID != 0 && intervalDays > 0 {
+		var lastVisit models.HelpRequest
+		since := now.AddDate(0, 0, -intervalDays).Format("2006-01-02")
+		if err := tx.Where("visitor_id = ? AND LOWER(category) = ? AND visit_day > ? AND status IN ?",
+			visitor.ID, category, since, []string{models.HelpRequestStatusTicketIssued,
+				models.HelpRequestStatusCheckedIn, models.HelpRequestStatusCompleted}).
+			Order("visit_day DESC").
+			First(&lastVisit).Error; err == nil {
+			fail(fmt.Sprintf("Already has a visit on %s; visits are limited to one every %d days", lastVisit.VisitDay, intervalDays))
+		}
+	}
+
+	return result
+}
+
+// issueSameDayTicket creates an approved help request and a ticket valid for the rest of today
+func (ws *WalkInService) issueSameDayTicket(tx *gorm.DB, visitor *models.User, input WalkInInput, serviceType *models.ServiceType, category string, staffID uint, now time.Time) (*models.HelpRequest, *models.Ticket, error) {
+	suffix, err := randomHex(4)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ticketNumber := "TKT-" + strings.ToUpper(suffix)
+	if serviceType != nil {
+		ticketNumber = NewServiceTypeService().GenerateTicketNumber(serviceType)
+	}
+	qrCode := "QR_" + ticketNumber
+	timeSlot := now.Format("15:04")
+	endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
+
+	householdSize := input.HouseholdSize
+	if householdSize < 1 {
+		householdSize = 1
+	}
+
+	helpRequest := models.HelpRequest{
+		VisitorID:        visitor.ID,
+		VisitorName:      visitor.FirstName + " " + visitor.LastName,
+		Email:            visitor.Email,
+		Phone:            visitor.Phone,
+		Postcode:         visitor.Postcode,
+		Category:         category,
+		Details:          "Walk-in registered at front desk",
+		HouseholdSize:    householdSize,
+		Notes:            input.Notes,
+		Status:           models.HelpRequestStatusTicketIssued,
+		RequestDate:      now,
+		ApprovedAt:       &now,
+		ApprovedBy:       &staffID,
+		EligibilityNotes: "Walk-in: eligibility checked at front desk",
+		TicketNumber:     ticketNumber,
+		QRCode:           qrCode,
+		Reference:        "WI-" + strings.ToUpper(suffix),
+		VisitDay:         now.Format("2006-01-02"),
+		TimeSlot:         timeSlot,
+	}
+	if err := tx.Create(&helpRequest).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to create help request: %w", err)
+	}
+
+	ticket := models.Ticket{
+		TicketNumber:  ticketNumber,
+		HelpRequestID: helpRequest.ID,
+		VisitorID:     visitor.ID,
+		VisitorName:   helpRequest.VisitorName,
+		Category:      category,
+		VisitDate:     now,
+		TimeSlot:      timeSlot,
+		QRCode:        qrCode,
+		Status:        models.TicketStatusActive,
+		IssuedAt:      now,
+		ValidUntil:    endOfDay,
+		ExpiresAt:     endOfDay,
+	}
+	if err := tx.Create(&ticket).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to create ticket: %w", err)
+	}
+
+	return &helpRequest, &ticket, nil
+}
+
+// normalizePostcode uppercases a postcode and removes spaces for comparison
+func normalizePostcode(postcode string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(postcode), " ", ""))
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
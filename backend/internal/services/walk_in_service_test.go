@@ -0,0 +1,77 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+)
+
+func TestWalkInEligibility(t *testing.T) {
+	// A Tuesday, before the food bank closes at 14:30
+	tuesday := time.Date(2026, 5, 5, 11, 0, 0, 0, time.UTC)
+	foodBank := &models.ServiceType{Name: "Food bank", IsActive: true, OperatingDays: "Tuesday,Thursday",
+		OpeningTime: "10:30", ClosingTime: "14:30", VisitIntervalDays: 7}
+	ws := &WalkInService{}
+
+	tests := []struct {
+		name     string
+		visitor  models.User
+		postcode string
+		service  func() *models.ServiceType
+		now      time.Time
+		reason   string // Empty when eligible
+	}{
+		{"eligible", models.User{}, "se13 5ab", nil, tuesday, ""},
+		{"postcode from the account", models.User{Postcode: "SE4 1AA"}, "", nil, tuesday, ""},
+		{"no postcode", models.User{}, "", nil, tuesday, "No postcode provided"},
+		{"outside the area", models.User{}, "n1 9gu", nil, tuesday, "Postcode N1 9GU is outside the service area"},
+		{"service paused", models.User{}, "SE13 5AB", func() *models.ServiceType {
+			paused := *foodBank
+			paused.IsActive = false
+			return &paused
+		}, tuesday, "Food bank is not currently available"},
+		{"not running today", models.User{}, "SE13 5AB", nil, tuesday.AddDate(0, 0, 1), "Food bank does not run on Wednesday"},
+		{"closed for the day", models.User{}, "SE13 5AB", nil, tuesday.Add(3*time.Hour + 30*time.Minute), "Food bank has closed for today"},
+	}
+	for _, tt := range tests {
+		service := foodBank
+		if tt.service != nil {
+			service = tt.service()
+		}
+		// New walk-ins have no account yet, so the visit interval is not checked
+		result := ws.checkEligibility(nil, &tt.visitor, tt.postcode, service, "food", tt.now)
+		if tt.reason == "" {
+			if !result.Eligible || len(result.Reasons) != 0 {
+				t.Errorf("%s: got %+v, want eligible", tt.name, result)
+			}
+			continue
+		}
+		if result.Eligible || strings.Join(result.Reasons, "; ") != tt.reason {
+			t.Errorf("%s: got %+v, want %q", tt.name, result, tt.reason)
+		}
+	}
+}
+
+func TestNormalizePostcode(t *testing.T) {
+	for _, in := range []string{"SE13 5AB", " se13 5ab ", "Se135Ab"} {
+		if got := normalizePostcode(in); got != "SE135AB" {
+			t.Errorf("normalizePostcode(%q) = %q", in, got)
+		}
+	}
+}
+
+func TestStandbyPriorityFor(t *testing.T) {
+	tests := map[string]int{
+		models.PriorityCritical: models.StandbyPriorityUrgent,
+		models.PriorityUrgent:   models.StandbyPriorityUrgent,
+		models.PriorityHigh:     models.StandbyPriorityHigh,
+		"":                      models.StandbyPriorityNormal,
+	}
+	for priority, want := range tests {
+		if got := models.StandbyPriorityFor(priority); got != want {
+			t.Errorf("StandbyPriorityFor(%q) = %d, want %d", priority, got, want)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // Logo formats accepted for branding
+	"image/png"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// PDF page geometry (A4 in points)
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMarginLeft   = 56
+	pdfMarginTop    = 64
+	pdfMarginBottom = 64
+	pdfLogoHeight   = 40 // Logos are scaled to this height, top right of each page
+	pdfLogoMaxWidth = 160
+	pdfLogoMaxBytes = 2 << 20 // Largest logo file decoded
+	pdfLogoMaxSide  = 4096    // Largest logo width or height in pixels
+	pdfFooterSize   = 8
+	pdfFooterLines  = 3  // Footer lines that fit in the bottom margin
+	pdfLargeBody    = 18 // Body size in large print; other sizes scale with it
+)
+
+// pdfLine is a single line of text placed on a page
+type pdfLine struct {
+	text      string
+	size      int
+	bold      bool
+	pageBreak bool
+}
+
+// PDFBranding is the letterhead applied to a document
+type PDFBranding struct {
+	Name        string   // Organisation name, recorded as the document producer
+	Color       string   // Hex colour for headings, e.g. #1D4ED8
+	FooterLines []string // Printed at the foot of every page
+	Logo        []byte   // PNG or JPEG, printed top right of every page
+}
+
+// pdfImage is a logo flattened onto white and re-encoded as an 8-bit PNG, a form
+// gofpdf always accepts
+type pdfImage struct {
+	width, height int
+	data          []byte
+}
+
+// PDFDocument builds simple text documents such as invoices, receipts and
+// certificates, rendered with gofpdf
+type PDFDocument struct {
+	title      string
+	lines      []pdfLine
+	branding   PDFBranding
+	logo       *pdfImage
+	largePrint bool
+}
+
+// NewPDFDocument creates a new PDF document with the given title
+func NewPDFDocument(title string) *PDFDocument {
+	return &PDFDocument{title: title}
+}
+
+// Brand applies an organisation's letterhead. A logo that cannot be decoded is left
+// out rather than failing the document.
+func (d *PDFDocument) Brand(branding PDFBranding) *PDFDocument {
+	d.branding = branding
+	d.logo = nil
+	if len(branding.Logo) > 0 {
+		if logo, err := decodePDFImage(branding.Logo); err == nil {
+			d.logo = logo
+		}
+	}
+	return d
+}
+
+// LargePrint sets the document in large print for readers with low vision. Text
+// is scaled up from 11 to 18 point.
+func (d *PDFDocument) LargePrint() *PDFDocument {
+	d.largePrint = true
+	return d
+}
+
+// Heading adds a bold heading line
+func (d *PDFDocument) Heading(text string) *PDFDocument {
+	d.lines = append(d.lines, pdfLine{text: text, size: 16, bold: true})
+	return d
+}
+
+// Subheading adds a bold line in the body font size
+func (d *PDFDocument) Subheading(text string) *PDFDocument {
+	d.lines = append(d.lines, pdfLine{text: text, size: 11, bold: true})
+	return d
+}
+
+// Line adds a regular body line
+func (d *PDFDocument) Line(text string) *PDFDocument {
+	d.lines = append(d.lines, pdfLine{text: text, size: 11})
+	return d
+}
+
+// Linef adds a formatted regular body line
+func (d *PDFDocument) Linef(format string, args ...interface{}) *PDFDocument {
+	return d.Line(fmt.Sprintf(format, args...))
+}
+
+// Field adds a "Label: value" line
+func (d *PDFDocument) Field(label, value string) *PDFDocument {
+	return d.Line(label + ": " + value)
+}
+
+// Blank adds an empty line
+func (d *PDFDocument) Blank() *PDFDocument {
+	d.lines = append(d.lines, pdfLine{size: 11})
+	return d
+}
+
+// PageBreak starts a new page, for example between certificates in one file
+func (d *PDFDocument) PageBreak() *PDFDocument {
+	d.lines = append(d.lines, pdfLine{pageBreak: true})
+	return d
+}
+
+// Bytes renders the document as a PDF file. It returns nil if the document could
+// not be rendered.
+func (d *PDFDocument) Bytes() []byte {
+	var buf bytes.Buffer
+	if err := d.render().Output(&buf); err != nil {
+		log.Printf("Failed to render PDF %q: %v", d.title, err)
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// render lays the document out with gofpdf. Lines longer than the page are wrapped
+// and pages break automatically above the footer.
+func (d *PDFDocument) render() *gofpdf.Fpdf {
+	producer := d.branding.Name
+	if producer == "" {
+		producer = "Lewisham Charity"
+	}
+
+	pdf := gofpdf.New("P", "pt", "A4", "")
+	pdf.SetTitle(d.title, true)
+	pdf.SetProducer(producer, true)
+	pdf.SetMargins(pdfMarginLeft, pdfMarginTop, pdfMarginLeft)
+	pdf.SetAutoPageBreak(true, pdfMarginBottom)
+	translate := pdf.UnicodeTranslatorFromDescriptor("") // UTF-8 to the core fonts' cp1252
+
+	if d.logo != nil {
+		pdf.RegisterImageOptionsReader("logo", gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(d.logo.data))
+		width, height := d.logoSize()
+		pdf.SetHeaderFunc(func() {
+			pdf.ImageOptions("logo", pdfPageWidth-pdfMarginLeft-width, pdfMarginTop-12-height, width, height,
+				false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+			pdf.SetXY(pdfMarginLeft, pdfMarginTop)
+		})
+	}
+
+	footer := d.branding.FooterLines
+	if len(footer) > pdfFooterLines {
+		footer = footer[:pdfFooterLines]
+	}
+	if len(footer) > 0 {
+		pdf.SetFooterFunc(func() {
+			pdf.SetFont("Helvetica", "", pdfFooterSize)
+			pdf.SetTextColor(107, 107, 107)
+			y := float64(pdfPageHeight - pdfMarginBottom + 20)
+			for _, text := range footer {
+				if text != "" {
+					pdf.Text(pdfMarginLeft, y, translate(text))
+				}
+				y += pdfFooterSize + 4
+			}
+		})
+	}
+
+	r, g, b, branded := pdfRGB(d.branding.Color)
+	width := float64(pdfPageWidth - 2*pdfMarginLeft)
+	pdf.AddPage()
+	for _, line := range d.lines {
+		if line.pageBreak {
+			// Consecutive breaks, or a break at the top, do not leave empty pages
+			if pdf.GetY() > pdfMarginTop {
+				pdf.AddPage()
+			}
+			continue
+		}
+
+		size := line.size
+		if d.largePrint {
+			size = size * pdfLargeBody / 11
+		}
+		style := ""
+		if line.bold {
+			style = "B"
+		}
+		pdf.SetFont("Helvetica", style, float64(size))
+		if branded && line.bold && line.size > 11 {
+			pdf.SetTextColor(r, g, b)
+		} else {
+			pdf.SetTextColor(0, 0, 0)
+		}
+
+		if line.text == "" {
+			pdf.Ln(float64(size + 6))
+			continue
+		}
+		pdf.MultiCell(width, float64(size+6), translate(line.text), "", "L", false)
+	}
+	return pdf
+}
+
+// logoSize returns the printed size of the logo: the logo height, narrowed to fit
+// the widest a logo may be
+func (d *PDFDocument) logoSize() (float64, float64) {
+	height := float64(pdfLogoHeight)
+	width := float64(d.logo.width) * height / float64(d.logo.height)
+	if width > pdfLogoMaxWidth {
+		width = pdfLogoMaxWidth
+		height = float64(d.logo.height) * width / float64(d.logo.width)
+	}
+	return width, height
+}
+
+// pdfRGB converts a hex colour such as #1D4ED8 to its red, green and blue values,
+// reporting false when it is not a valid colour
+func pdfRGB(hex string) (int, int, int, bool) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return int(value >> 16 & 0xFF), int(value >> 8 & 0xFF), int(value & 0xFF), true
+}
+
+// decodePDFImage decodes a PNG or JPEG and re-encodes it as an opaque PNG, blending
+// transparent areas onto white. The header is checked first so an oversized image
+// is refused before its pixels are allocated.
+func decodePDFImage(data []byte) (*pdfImage, error) {
+	if len(data) > pdfLogoMaxBytes {
+		return nil, fmt.Errorf("image is larger than %d bytes", pdfLogoMaxBytes)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width > pdfLogoMaxSide || config.Height > pdfLogoMaxSide {
+		return nil, fmt.Errorf("image is larger than %dx%d pixels", pdfLogoMaxSide, pdfLogoMaxSide)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return nil, fmt.Errorf("image is empty")
+	}
+
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, flat); err != nil {
+		return nil, err
+	}
+	return &pdfImage{width: bounds.Dx(), height: bounds.Dy(), data: encoded.Bytes()}, nil
+}
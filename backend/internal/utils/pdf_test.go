@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// renderPDF renders a document uncompressed so its text can be searched
+func renderPDF(t *testing.T, doc *PDFDocument) []byte {
+	t.Helper()
+	pdf := doc.render()
+	pdf.SetCompression(false)
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// testLogo encodes a small PNG with a transparent pixel
+func testLogo(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.NRGBA{R: 29, G: 78, B: 216, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPDFDocumentBytes(t *testing.T) {
+	pdf := NewPDFDocument("Invoice INV-2026-00001").
+		Heading("Lewisham Charity - Invoice").
+		Blank().
+		Field("Amount due", "£250.00").
+		Line("Pay (by transfer) to sort code 00-00-00").
+		Bytes()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) || !bytes.HasSuffix(bytes.TrimSpace(pdf), []byte("%%EOF")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+
+	text := renderPDF(t, NewPDFDocument("Invoice").
+		Field("Amount due", "£250.00").
+		Line("Pay (by transfer) to sort code 00-00-00"))
+	// The pound sign is written in the fonts' cp1252 encoding
+	if !bytes.Contains(text, []byte("(Amount due: \xa3250.00)")) || !bytes.Contains(text, []byte(`(Pay \(by transfer\) to sort code 00-00-00)`)) {
+		t.Error("body text not written as expected")
+	}
+}
+
+func TestPDFDocumentPages(t *testing.T) {
+	doc := NewPDFDocument("Pages")
+	for i := 0; i < 60; i++ {
+		doc.Linef("Line %d", i)
+	}
+	doc.PageBreak().PageBreak().Line("After the break")
+	if pages := doc.render().PageCount(); pages != 3 {
+		t.Errorf("got %d pages, want 60 lines over two pages and one for the break", pages)
+	}
+
+	// Large print needs more pages for the same lines
+	doc.LargePrint()
+	if pages := doc.render().PageCount(); pages <= 3 {
+		t.Errorf("large print has %d pages", pages)
+	}
+
+	// An empty document still has a page, and a break at the top adds none
+	if pages := NewPDFDocument("Empty").PageBreak().render().PageCount(); pages != 1 {
+		t.Errorf("empty document has %d pages", pages)
+	}
+}
+
+func TestPDFDocumentWrapsLongLines(t *testing.T) {
+	long := bytes.Repeat([]byte("word "), 60)
+	pdf := renderPDF(t, NewPDFDocument("Wrap").Line(string(long)))
+	if bytes.Contains(pdf, bytes.TrimSpace(long)) {
+		t.Error("a line wider than the page was not wrapped")
+	}
+}
+
+func TestPDFDocumentBranding(t *testing.T) {
+	doc := NewPDFDocument("Receipt").Brand(PDFBranding{
+		Name:        "Lewisham Food Bank",
+		Color:       "#1D4ED8",
+		FooterLines: []string{"Registered charity 123456", "", "Lewisham", "Dropped"},
+		Logo:        testLogo(t, 400, 50),
+	}).Heading("Receipt").Line("Thank you")
+	if doc.logo == nil {
+		t.Fatal("logo not decoded")
+	}
+	if width, height := doc.logoSize(); width != pdfLogoMaxWidth || height != 20 {
+		t.Errorf("wide logo printed at %vx%v, want it narrowed to %d wide", width, height, pdfLogoMaxWidth)
+	}
+
+	pdf := renderPDF(t, doc)
+	for _, want := range []string{
+		"/Subtype /Image",
+		"0.114 0.306 0.847 rg", // The heading in the brand colour
+		"(Registered charity 123456)",
+		"(Lewisham)",
+	} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if bytes.Contains(pdf, []byte("(Dropped)")) {
+		t.Errorf("printed more than %d footer lines", pdfFooterLines)
+	}
+
+	// A logo that cannot be decoded is left out, and an unknown colour ignored
+	doc.Brand(PDFBranding{Color: "blue", Logo: []byte("not an image")})
+	if doc.logo != nil || doc.Bytes() == nil {
+		t.Error("bad logo not left out")
+	}
+}
+
+func TestDecodePDFImage(t *testing.T) {
+	if _, err := decodePDFImage(testLogo(t, pdfLogoMaxSide+1, 1)); err == nil {
+		t.Error("accepted an oversized image")
+	}
+	if _, err := decodePDFImage(make([]byte, pdfLogoMaxBytes+1)); err == nil {
+		t.Error("accepted an oversized file")
+	}
+
+	logo, err := decodePDFImage(testLogo(t, 3, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(logo.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Transparent pixels are flattened onto white
+	if r, g, b, a := img.At(0, 1).RGBA(); r != 0xFFFF || g != 0xFFFF || b != 0xFFFF || a != 0xFFFF {
+		t.Errorf("transparent pixel became %v", img.At(0, 1))
+	}
+}
+
+func TestPDFRGB(t *testing.T) {
+	tests := []struct {
+		hex     string
+		r, g, b int
+		ok      bool
+	}{
+		{"#1D4ED8", 29, 78, 216, true},
+		{"ffffff", 255, 255, 255, true},
+		{"#FFF", 0, 0, 0, false},
+		{"#GGGGGG", 0, 0, 0, false},
+		{"", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		r, g, b, ok := pdfRGB(tt.hex)
+		if r != tt.r || g != tt.g || b != tt.b || ok != tt.ok {
+			t.Errorf("pdfRGB(%q) = %d, %d, %d, %v", tt.hex, r, g, b, ok)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package utils
+
+import "strings"
+
+// TruncateText shortens text to at most limit characters followed by "...". It
+// cuts on a character boundary, so multi-byte text is never split mid-character.
+func TruncateText(text string, limit int) string {
+	text = strings.TrimSpace(text)
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:limit])) + "..."
+}
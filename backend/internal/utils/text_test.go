@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  string
+	}{
+		{"  short  ", 10, "short"},
+		{"exactly ten", 11, "exactly ten"},
+		{"cut after the space", 10, "cut after..."},
+		{"café crème", 4, "café..."},
+		{"日本語のテキスト", 3, "日本語..."},
+		{"👋🏽 hello", 1, "👋..."},
+	}
+	for _, tt := range tests {
+		got := TruncateText(tt.text, tt.limit)
+		if got != tt.want {
+			t.Errorf("TruncateText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("TruncateText(%q, %d) split a character", tt.text, tt.limit)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/geoo115/charity-management-system/internal/models"
+	"github.com/geoo115/charity-management-system/internal/observability"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis keys shared by every replica
+const (
+	relayChannel      = "ws:relay"       // Pub/sub channel carrying broadcasts between replicas
+	instancesKey      = "ws:instances"   // Sorted set of replica ID by last heartbeat
+	instanceCountsKey = "ws:connections" // Hash of replica ID to open connection count
+	presenceKeyPrefix = "ws:presence:"   // Hash per user of replica ID to connection count
+)
+
+// Relay message kinds
+const (
+	relayBroadcast = "broadcast" // A topic, role or client broadcast
+	relayUser      = "user"      // A message for one user's connections
+)
+
+// relayEnvelope is a message published to the other replicas
+type relayEnvelope struct {
+	Origin    string            `json:"origin"`
+	Kind      string            `json:"kind"`
+	Broadcast *BroadcastMessage `json:"broadcast,omitempty"`
+	UserID    uint              `json:"user_id,omitempty"`
+	Category  string            `json:"category,omitempty"`
+	Message   json.RawMessage   `json:"message,omitempty"`
+}
+
+// redisRelay routes messages to users connected to any replica and tracks where
+// they are connected
+type redisRelay struct {
+	client      *redis.Client
+	instanceID  string
+	presenceTTL time.Duration
+}
+
+// hubCounters counts connections and messages on this replica
+type hubCounters struct {
+	opened          int64
+	closed          int64
+	dropped         int64
+	relayPublished  int64
+	relayReceived   int64
+	relayFailed     int64
+	subscribeDenied int64
+}
+
+// HubStats describes the hub across all replicas
+type HubStats struct {
+	Relay              string `json:"relay"` // "redis" or "local"
+	InstanceID         string `json:"instance_id"`
+	Instances          int    `json:"instances"`
+	ClusterConnections int    `json:"cluster_connections"`
+	ConnectionsOpened  int64  `json:"connections_opened"`
+	ConnectionsClosed  int64  `json:"connections_closed"`
+	DroppedMessages    int64  `json:"dropped_messages"`
+	RelayPublished     int64  `json:"relay_published"`
+	RelayReceived      int64  `json:"relay_received"`
+	RelayFailed        int64  `json:"relay_failed"`
+	SubscribeDenied    int64  `json:"subscribe_denied"`
+}
+
+// staffTopicPrefixes are topics only staff and admins may subscribe to
+var staffTopicPrefixes = []string{"admin_", "staff_", "document_verification", "service_time_alerts", "ticket_fraud_alerts", "queue_display"}
+
+// EnableRedis routes broadcasts through Redis so they reach users connected to any
+// replica, and records which replica each user is connected to. Without it the hub
+// only reaches connections on this replica.
+func (wsm *WebSocketManager) EnableRedis(client *redis.Client) {
+	if client == nil {
+		log.Println("WebSocket hub running without Redis, messages reach this replica only")
+		return
+	}
+
+	relay := &redisRelay{
+		client:      client,
+		instanceID:  hubInstanceID(),
+		presenceTTL: presenceTTL(),
+	}
+
+	wsm.mutex.Lock()
+	wsm.relay = relay
+	wsm.mutex.Unlock()
+
+	go wsm.receiveRelay(relay)
+	go wsm.heartbeat(relay)
+	log.Printf("WebSocket hub relaying through Redis as %s", relay.instanceID)
+}
+
+// UserOnline reports whether a user has a connection open on any replica
+func (wsm *WebSocketManager) UserOnline(userID uint) bool {
+	wsm.mutex.RLock()
+	local := len(wsm.userConnections[userID]) > 0
+	relay := wsm.relay
+	wsm.mutex.RUnlock()
+
+	if local || relay == nil {
+		return local
+	}
+
+	ctx, cancel := context.WithTimeout(wsm.ctx, 2*time.Second)
+	defer cancel()
+	presence, err := relay.client.HGetAll(ctx, presenceKeyPrefix+strconv.FormatUint(uint64(userID), 10)).Result()
+	if err != nil {
+		log.Printf("Failed to read WebSocket presence for user %d: %v", userID, err)
+		return false
+	}
+	live, err := relay.liveInstances(ctx)
+	if err != nil {
+		log.Printf("Failed to read WebSocket replicas: %v", err)
+		return false
+	}
+	return livePresence(presence, live) > 0
+}
+
+// GetHubStats returns connection and message counts for this replica and, when
+// relaying through Redis, the connections open across all replicas
+func (wsm *WebSocketManager) GetHubStats() HubStats {
+	wsm.mutex.RLock()
+	relay := wsm.relay
+	local := len(wsm.connections)
+	wsm.mutex.RUnlock()
+
+	stats := HubStats{
+		Relay:              "local",
+		Instances:          1,
+		ClusterConnections: local,
+		ConnectionsOpened:  atomic.LoadInt64(&wsm.counters.opened),
+		ConnectionsClosed:  atomic.LoadInt64(&wsm.counters.closed),
+		DroppedMessages:    atomic.LoadInt64(&wsm.counters.dropped),
+		RelayPublished:     atomic.LoadInt64(&wsm.counters.relayPublished),
+		RelayReceived:      atomic.LoadInt64(&wsm.counters.relayReceived),
+		RelayFailed:        atomic.LoadInt64(&wsm.counters.relayFailed),
+		SubscribeDenied:    atomic.LoadInt64(&wsm.counters.subscribeDenied),
+	}
+	if relay == nil {
+		return stats
+	}
+
+	stats.Relay = "redis"
+	stats.InstanceID = relay.instanceID
+	ctx, cancel := context.WithTimeout(wsm.ctx, 2*time.Second)
+	defer cancel()
+	live, err := relay.liveInstances(ctx)
+	if err != nil {
+		log.Printf("Failed to read WebSocket replicas: %v", err)
+		return stats
+	}
+	counts, err := relay.client.HGetAll(ctx, instanceCountsKey).Result()
+	if err != nil {
+		log.Printf("Failed to read WebSocket connection counts: %v", err)
+		return stats
+	}
+	stats.Instances = len(live)
+	stats.ClusterConnections = livePresence(counts, live)
+	return stats
+}
+
+// TopicAllowed reports whether a role may subscribe to a topic. Staff topics are
+// limited to staff and admins, and volunteer topics to volunteers and staff.
+func TopicAllowed(role, topic string) bool {
+	staff := isStaffRole(role)
+	for _, prefix := range staffTopicPrefixes {
+		if strings.HasPrefix(topic, prefix) {
+			return staff
+		}
+	}
+	if strings.HasPrefix(topic, "volunteer_") {
+		return staff || role == models.RoleVolunteer || role == models.RoleVolunteerLegacy
+	}
+	return topic != ""
+}
+
+// isStaffRole reports whether a role can see staff-only topics
+func isStaffRole(role string) bool {
+	switch role {
+	case models.RoleAdmin, models.RoleSuperAdmin, models.RoleStaff,
+		models.RoleAdminLegacy, models.RoleSuperAdminLegacy, models.RoleStaffLegacy:
+		return true
+	}
+	return false
+}
+
+// publishBroadcast sends a broadcast to the other replicas
+func (wsm *WebSocketManager) publishBroadcast(broadcast BroadcastMessage) {
+	wsm.publish(relayEnvelope{Kind: relayBroadcast, Broadcast: &broadcast})
+}
+
+// publishToUser sends a user's encoded message to the other replicas
+func (wsm *WebSocketManager) publishToUser(userID uint, category string, messageData []byte) {
+	wsm.publish(relayEnvelope{Kind: relayUser, UserID: userID, Category: category, Message: messageData})
+}
+
+// publish sends an envelope to the other replicas when relaying through Redis
+func (wsm *WebSocketManager) publish(envelope relayEnvelope) {
+	wsm.mutex.RLock()
+	relay := wsm.relay
+	wsm.mutex.RUnlock()
+	if relay == nil {
+		return
+	}
+
+	envelope.Origin = relay.instanceID
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		log.Printf("Failed to encode WebSocket relay message: %v", err)
+		wsm.recordRelay("failed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(wsm.ctx, 2*time.Second)
+	defer cancel()
+	if err := relay.client.Publish(ctx, relayChannel, data).Err(); err != nil {
+		log.Printf("Failed to relay WebSocket message through Redis: %v", err)
+		wsm.recordRelay("failed")
+		return
+	}
+	wsm.recordRelay("published")
+}
+
+// receiveRelay delivers messages other replicas published to connections on this one
+func (wsm *WebSocketManager) receiveRelay(relay *redisRelay) {
+	pubsub := relay.client.Subscribe(wsm.ctx, relayChannel)
+	defer pubsub.Close()
+
+	for {
+		select {
+		case <-wsm.ctx.Done():
+			return
+		case msg, ok := <-pubsub.Channel():
+			if !ok {
+				return
+			}
+			var envelope relayEnvelope
+			if err := json.Unmarshal([]byte(msg.Payload), &envelope); err != nil {
+				log.Printf("Ignoring malformed WebSocket relay message: %v", err)
+				wsm.recordRelay("failed")
+				continue
+			}
+			if envelope.Origin == relay.instanceID {
+				continue
+			}
+			wsm.recordRelay("received")
+			wsm.deliverRelayed(envelope)
+		}
+	}
+}
+
+// deliverRelayed delivers a relayed message to connections on this replica
+func (wsm *WebSocketManager) deliverRelayed(envelope relayEnvelope) {
+	switch envelope.Kind {
+	case relayBroadcast:
+		if envelope.Broadcast != nil {
+			wsm.processBroadcast(*envelope.Broadcast)
+		}
+	case relayUser:
+		if err := wsm.deliverToUser(envelope.UserID, envelope.Category, envelope.Message); err != nil && err != errUserNotConnected {
+			log.Printf("Failed to deliver relayed message to user %d: %v", envelope.UserID, err)
+		}
+	}
+}
+
+// heartbeat keeps this replica's presence and connection counts fresh in Redis and
+// publishes connection gauges
+func (wsm *WebSocketManager) heartbeat(relay *redisRelay) {
+	interval := relay.presenceTTL / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	wsm.writePresence(relay)
+	for {
+		select {
+		case <-wsm.ctx.Done():
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			relay.client.ZRem(ctx, instancesKey, relay.instanceID)
+			relay.client.HDel(ctx, instanceCountsKey, relay.instanceID)
+			cancel()
+			return
+		case <-ticker.C:
+			wsm.writePresence(relay)
+		}
+	}
+}
+
+// writePresence records this replica's heartbeat, its connection count and the
+// users connected to it
+func (wsm *WebSocketManager) writePresence(relay *redisRelay) {
+	wsm.mutex.RLock()
+	total := len(wsm.connections)
+	users := make(map[uint]int, len(wsm.userConnections))
+	for userID, conns := range wsm.userConnections {
+		users[userID] = len(conns)
+	}
+	wsm.mutex.RUnlock()
+
+	ctx, cancel := context.WithTimeout(wsm.ctx, 5*time.Second)
+	defer cancel()
+	now := time.Now()
+
+	pipe := relay.client.Pipeline()
+	pipe.ZAdd(ctx, instancesKey, &redis.Z{Score: float64(now.Unix()), Member: relay.instanceID})
+	pipe.ZRemRangeByScore(ctx, instancesKey, "-inf", strconv.FormatInt(now.Add(-relay.presenceTTL).Unix(), 10))
+	pipe.HSet(ctx, instanceCountsKey, relay.instanceID, total)
+	for userID, count := range users {
+		key := presenceKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+		pipe.HSet(ctx, key, relay.instanceID, count)
+		pipe.Expire(ctx, key, relay.presenceTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to write WebSocket presence to Redis: %v", err)
+	}
+}
+
+// setPresence updates a user's connection count on this replica as connections
+// open and close, so other replicas see them before the next heartbeat
+func (wsm *WebSocketManager) setPresence(relay *redisRelay, userID uint, count int) {
+	if relay == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		key := presenceKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+		var err error
+		if count > 0 {
+			pipe := relay.client.Pipeline()
+			pipe.HSet(ctx, key, relay.instanceID, count)
+			pipe.Expire(ctx, key, relay.presenceTTL)
+			_, err = pipe.Exec(ctx)
+		} else {
+			err = relay.client.HDel(ctx, key, relay.instanceID).Err()
+		}
+		if err != nil {
+			log.Printf("Failed to update WebSocket presence for user %d: %v", userID, err)
+		}
+	}()
+}
+
+// liveInstances returns the replicas that have sent a heartbeat within the
+// presence TTL
+func (r *redisRelay) liveInstances(ctx context.Context) (map[string]bool, error) {
+	cutoff := strconv.FormatInt(time.Now().Add(-r.presenceTTL).Unix(), 10)
+	ids, err := r.client.ZRangeByScore(ctx, instancesKey, &redis.ZRangeBy{Min: cutoff, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		live[id] = true
+	}
+	return live, nil
+}
+
+// livePresence totals per-replica connection counts, ignoring replicas that have
+// stopped sending heartbeats
+func livePresence(counts map[string]string, live map[string]bool) int {
+	total := 0
+	for instance, value := range counts {
+		if !live[instance] {
+			continue
+		}
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			total += n
+		}
+	}
+	return total
+}
+
+// recordDropped counts a message that could not be delivered to a connection
+func (wsm *WebSocketManager) recordDropped(reason string) {
+	atomic.AddInt64(&wsm.counters.dropped, 1)
+	observability.GetMetricsService().RecordWebSocketDropped(reason)
+}
+
+// recordRelay counts a message relayed through Redis
+func (wsm *WebSocketManager) recordRelay(result string) {
+	switch result {
+	case "published":
+		atomic.AddInt64(&wsm.counters.relayPublished, 1)
+	case "received":
+		atomic.AddInt64(&wsm.counters.relayReceived, 1)
+	default:
+		atomic.AddInt64(&wsm.counters.relayFailed, 1)
+	}
+	observability.GetMetricsService().RecordWebSocketRelay(result)
+}
+
+// publishConnectionMetrics sets the connection gauges by role and category
+func (wsm *WebSocketManager) publishConnectionMetrics(stats ConnectionStats) {
+	metrics := observability.GetMetricsService()
+	for role, count := range stats.ConnectionsByRole {
+		metrics.SetWebSocketConnections(role, "all", count)
+	}
+	for category, count := range stats.ConnectionsByCategory {
+		metrics.SetWebSocketConnections("all", category, count)
+	}
+}
+
+// hubInstanceID names this replica in Redis, from WEBSOCKET_INSTANCE_ID or the
+// host name and process ID
+func hubInstanceID() string {
+	if id := os.Getenv("WEBSOCKET_INSTANCE_ID"); id != "" {
+		return id
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "replica"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// presenceTTL is how long a replica's presence lasts without a heartbeat
+func presenceTTL() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("WEBSOCKET_PRESENCE_TTL_SECONDS"))
+	if err != nil || seconds < 15 {
+		seconds = 90
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,39 @@
+package websocket
+
+import "testing"
+
+func TestTopicAllowed(t *testing.T) {
+	cases := []struct {
+		role  string
+		topic string
+		want  bool
+	}{
+		{"visitor", "notifications", true},
+		{"visitor", "queue_updates", true},
+		{"visitor", "admin_notifications", false},
+		{"visitor", "ticket_fraud_alerts", false},
+		{"visitor", "volunteer_notifications", false},
+		{"volunteer", "volunteer_queue", true},
+		{"volunteer", "staff_updates", false},
+		{"staff", "document_verification", true},
+		{"admin", "volunteer_notifications", true},
+		{"SuperAdmin", "service_time_alerts", true},
+		{"admin", "", false},
+	}
+	for _, tc := range cases {
+		if got := TopicAllowed(tc.role, tc.topic); got != tc.want {
+			t.Errorf("TopicAllowed(%q, %q) = %v, want %v", tc.role, tc.topic, got, tc.want)
+		}
+	}
+}
+
+func TestLivePresence(t *testing.T) {
+	counts := map[string]string{"web-1": "2", "web-2": "3", "web-3": "4", "web-4": "bad"}
+	live := map[string]bool{"web-1": true, "web-2": true, "web-4": true}
+	if got := livePresence(counts, live); got != 5 {
+		t.Errorf("livePresence = %d, want 5 (replicas without a heartbeat are ignored)", got)
+	}
+	if got := livePresence(counts, map[string]bool{}); got != 0 {
+		t.Errorf("livePresence with no live replicas = %d, want 0", got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"log"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -25,8 +26,17 @@ type WebSocketManager struct {
 	maxConnections  int
 	messageBuffer   int
 	startTime       time.Time // Time when the manager was created
+	messageHandlers map[string]MessageHandler
+	relay           *redisRelay // Set when broadcasts are relayed through Redis
+	counters        hubCounters
 }
 
+// errUserNotConnected is returned when a user has no connection on this replica
+var errUserNotConnected = errors.New("user not connected")
+
+// MessageHandler handles a client message of a registered type
+type MessageHandler func(conn *ManagedConnection, msg map[string]interface{})
+
 // ManagedConnection represents a managed WebSocket connection
 type ManagedConnection struct {
 	ID           string
@@ -82,6 +92,7 @@ func NewWebSocketManager() *WebSocketManager {
 		maxConnections:  10000,      // Configurable limit
 		messageBuffer:   1000,       // Configurable buffer size
 		startTime:       time.Now(), // Initialize the start time
+		messageHandlers: make(map[string]MessageHandler),
 	}
 
 	// Start background processes
@@ -148,6 +159,9 @@ func (wsm *WebSocketManager) AddConnection(conn *websocket.Conn, userID uint, us
 		wsm.subscribeToTopic(connID, category, managedConn)
 	}
 
+	atomic.AddInt64(&wsm.counters.opened, 1)
+	wsm.setPresence(wsm.relay, userID, len(wsm.userConnections[userID]))
+
 	// Start connection handlers asynchronously
 	go wsm.handleConnection(managedConn)
 
@@ -187,6 +201,8 @@ func (wsm *WebSocketManager) RemoveConnection(connID string) {
 				delete(wsm.userConnections, managedConn.UserID)
 			}
 		}
+		atomic.AddInt64(&wsm.counters.closed, 1)
+		wsm.setPresence(wsm.relay, managedConn.UserID, len(wsm.userConnections[managedConn.UserID]))
 
 		// Close connection safely
 		if err := managedConn.Conn.Close(); err != nil {
@@ -203,6 +219,21 @@ func (wsm *WebSocketManager) RemoveConnection(connID string) {
 	}
 }
 
+// DisconnectUser closes every connection a user has open and returns how many closed
+func (wsm *WebSocketManager) DisconnectUser(userID uint) int {
+	wsm.mutex.RLock()
+	connIDs := make([]string, 0, len(wsm.userConnections[userID]))
+	for connID := range wsm.userConnections[userID] {
+		connIDs = append(connIDs, connID)
+	}
+	wsm.mutex.RUnlock()
+
+	for _, connID := range connIDs {
+		wsm.RemoveConnection(connID)
+	}
+	return len(connIDs)
+}
+
 // BroadcastToTopic sends a message to all connections subscribed to a topic
 func (wsm *WebSocketManager) BroadcastToTopic(topic string, message interface{}) error {
 	broadcast := BroadcastMessage{
@@ -212,37 +243,116 @@ func (wsm *WebSocketManager) BroadcastToTopic(topic string, message interface{})
 		Timestamp: time.Now(),
 	}
 
+	return wsm.enqueueBroadcast(broadcast)
+}
+
+// BroadcastToUser sends a message to all connections of a specific user
+func (wsm *WebSocketManager) BroadcastToUser(userID uint, message interface{}) error {
+	return wsm.broadcastToUser(userID, "", message)
+}
+
+// BroadcastToUserCategory sends a message to a user's connections in one category, such
+// as the notification connections on each of their devices
+func (wsm *WebSocketManager) BroadcastToUserCategory(userID uint, category string, message interface{}) error {
+	return wsm.broadcastToUser(userID, category, message)
+}
+
+// SendToConnection sends a message to one connection on this replica, such as the
+// current state when a client first connects
+func (wsm *WebSocketManager) SendToConnection(connID string, message interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("connection %s closed while sending: %v", connID, r)
+		}
+	}()
+
+	wsm.mutex.RLock()
+	conn, exists := wsm.connections[connID]
+	wsm.mutex.RUnlock()
+	if !exists || !conn.IsActive {
+		return errors.New("connection not found")
+	}
+
+	messageData, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	select {
+	case conn.SendChan <- messageData:
+		return nil
+	case <-time.After(2 * time.Second):
+		wsm.recordDropped("timeout")
+		return errors.New("send timeout")
+	case <-conn.Context.Done():
+		return errors.New("connection closed")
+	}
+}
+
+// RegisterMessageHandler routes client messages of a type to a handler
+func (wsm *WebSocketManager) RegisterMessageHandler(msgType string, handler MessageHandler) {
+	wsm.mutex.Lock()
+	defer wsm.mutex.Unlock()
+	wsm.messageHandlers[msgType] = handler
+}
+
+// HasCategory reports whether the connection was opened for a category
+func (mc *ManagedConnection) HasCategory(category string) bool {
+	for _, c := range mc.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// broadcastToUser sends a message to a user's connections, limited to a category when
+// one is given. When relaying through Redis the message also reaches the user's
+// connections on other replicas.
+func (wsm *WebSocketManager) broadcastToUser(userID uint, category string, message interface{}) error {
+	messageData, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	wsm.publishToUser(userID, category, messageData)
+	err = wsm.deliverToUser(userID, category, messageData)
+	if err == errUserNotConnected && wsm.UserOnline(userID) {
+		return nil
+	}
+	return err
+}
+
+// enqueueBroadcast queues a broadcast for this replica's connections and relays it
+// to the other replicas
+func (wsm *WebSocketManager) enqueueBroadcast(broadcast BroadcastMessage) error {
 	select {
 	case wsm.broadcastChan <- broadcast:
+		wsm.publishBroadcast(broadcast)
 		return nil
 	case <-time.After(5 * time.Second):
+		wsm.recordDropped("queue_full")
 		return errors.New("broadcast channel timeout")
 	}
 }
 
-// BroadcastToUser sends a message to all connections of a specific user
-func (wsm *WebSocketManager) BroadcastToUser(userID uint, message interface{}) error {
+// deliverToUser sends encoded message data to a user's connections on this replica
+func (wsm *WebSocketManager) deliverToUser(userID uint, category string, messageData []byte) error {
 	wsm.mutex.RLock()
 	userConnections, exists := wsm.userConnections[userID]
 	if !exists {
 		wsm.mutex.RUnlock()
-		return errors.New("user not connected")
+		return errUserNotConnected
 	}
 
 	// Create a copy to avoid holding the lock during message sending
 	targetConnections := make([]*ManagedConnection, 0, len(userConnections))
 	for _, conn := range userConnections {
-		if conn.IsActive {
+		if conn.IsActive && (category == "" || conn.HasCategory(category)) {
 			targetConnections = append(targetConnections, conn)
 		}
 	}
 	wsm.mutex.RUnlock()
 
-	messageData, err := json.Marshal(message)
-	if err != nil {
-		return err
-	}
-
 	// Send to all user connections concurrently
 	var wg sync.WaitGroup
 	for _, conn := range targetConnections {
@@ -269,6 +379,7 @@ func (wsm *WebSocketManager) BroadcastToUser(userID uint, message interface{}) e
 				// Message sent successfully
 			case <-time.After(2 * time.Second):
 				log.Printf("Timeout sending message to user %d connection %s", userID, c.ID)
+				wsm.recordDropped("timeout")
 			case <-c.Context.Done():
 				// Connection closed, that's normal
 			}
@@ -299,12 +410,7 @@ func (wsm *WebSocketManager) BroadcastToRole(role string, message interface{}) e
 		Timestamp: time.Now(),
 	}
 
-	select {
-	case wsm.broadcastChan <- broadcast:
-		return nil
-	case <-time.After(5 * time.Second):
-		return errors.New("broadcast channel timeout")
-	}
+	return wsm.enqueueBroadcast(broadcast)
 }
 
 // GetServerInfo returns basic information about the WebSocket server
@@ -591,6 +697,7 @@ func (wsm *WebSocketManager) processBroadcast(broadcast BroadcastMessage) {
 			case c.SendChan <- messageData:
 			case <-time.After(2 * time.Second):
 				log.Printf("Timeout sending broadcast to connection %s", c.ID)
+				wsm.recordDropped("timeout")
 			case <-c.Context.Done():
 				// Connection closed
 			}
@@ -628,6 +735,13 @@ func (wsm *WebSocketManager) processMessage(managedConn *ManagedConnection, mess
 	case "unsubscribe":
 		wsm.handleUnsubscribe(managedConn, msg)
 	default:
+		wsm.mutex.RLock()
+		handler, ok := wsm.messageHandlers[msgType]
+		wsm.mutex.RUnlock()
+		if ok {
+			handler(managedConn, msg)
+			return
+		}
 		log.Printf("Unknown message type '%s' from connection %s", msgType, managedConn.ID)
 	}
 }
@@ -731,6 +845,13 @@ func (wsm *WebSocketManager) handleSubscribe(managedConn *ManagedConnection, msg
 		return
 	}
 
+	if !TopicAllowed(managedConn.UserRole, topic) {
+		atomic.AddInt64(&wsm.counters.subscribeDenied, 1)
+		log.Printf("Connection %s (role %s) may not subscribe to topic: %s", managedConn.ID, managedConn.UserRole, topic)
+		wsm.sendSubscribeDenied(managedConn, topic)
+		return
+	}
+
 	wsm.mutex.Lock()
 	wsm.subscribeToTopic(managedConn.ID, topic, managedConn)
 	wsm.mutex.Unlock()
@@ -738,6 +859,24 @@ func (wsm *WebSocketManager) handleSubscribe(managedConn *ManagedConnection, msg
 	log.Printf("Connection %s subscribed to topic: %s", managedConn.ID, topic)
 }
 
+// sendSubscribeDenied tells a client it may not subscribe to a topic
+func (wsm *WebSocketManager) sendSubscribeDenied(managedConn *ManagedConnection, topic string) {
+	data, err := json.Marshal(map[string]interface{}{
+		"type":      "subscribe_denied",
+		"topic":     topic,
+		"timestamp": time.Now().Unix(),
+	})
+	if err != nil {
+		return
+	}
+	select {
+	case managedConn.SendChan <- data:
+	case <-time.After(2 * time.Second):
+		wsm.recordDropped("timeout")
+	case <-managedConn.Context.Done():
+	}
+}
+
 func (wsm *WebSocketManager) handleUnsubscribe(managedConn *ManagedConnection, msg map[string]interface{}) {
 	topic, ok := msg["topic"].(string)
 	if !ok {
@@ -787,6 +926,7 @@ func (wsm *WebSocketManager) monitorHealth() {
 			return
 		case <-ticker.C:
 			stats := wsm.GetConnectionStats()
+			wsm.publishConnectionMetrics(stats)
 			log.Printf("WebSocket Health: %d connections, %d subscriptions, %d broadcasts queued",
 				stats.TotalConnections, stats.SubscriptionCount, stats.ActiveBroadcasts)
 
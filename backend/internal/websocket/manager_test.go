@@ -0,0 +1,45 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBroadcastToUserCategory(t *testing.T) {
+	wsm := &WebSocketManager{userConnections: map[uint]map[string]*ManagedConnection{}}
+	connect := func(id string, userID uint, active bool, categories ...string) *ManagedConnection {
+		conn := &ManagedConnection{ID: id, UserID: userID, Categories: categories, IsActive: active, SendChan: make(chan []byte, 1), Context: context.Background()}
+		if wsm.userConnections[userID] == nil {
+			wsm.userConnections[userID] = map[string]*ManagedConnection{}
+		}
+		wsm.userConnections[userID][id] = conn
+		return conn
+	}
+	phone := connect("phone", 1, true, "notifications")
+	laptop := connect("laptop", 1, true, "queue_updates", "notifications")
+	queue := connect("queue", 1, true, "queue_updates")
+	closed := connect("closed", 1, false, "notifications")
+	other := connect("other", 2, true, "notifications")
+
+	if err := wsm.BroadcastToUserCategory(1, "notifications", map[string]string{"type": "notification"}); err != nil {
+		t.Fatal(err)
+	}
+	for conn, want := range map[*ManagedConnection]int{phone: 1, laptop: 1, queue: 0, closed: 0, other: 0} {
+		if len(conn.SendChan) != want {
+			t.Errorf("%s got %d messages, want %d", conn.ID, len(conn.SendChan), want)
+		}
+	}
+
+	// Without a category every active connection of the user gets the message
+	queue.SendChan, phone.SendChan, laptop.SendChan = make(chan []byte, 1), make(chan []byte, 1), make(chan []byte, 1)
+	if err := wsm.BroadcastToUser(1, map[string]string{"type": "logout"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(queue.SendChan) != 1 || len(phone.SendChan) != 1 || len(laptop.SendChan) != 1 {
+		t.Error("message not sent to every connection")
+	}
+
+	if err := wsm.BroadcastToUserCategory(3, "notifications", nil); err == nil {
+		t.Error("user with no connections: no error")
+	}
+}
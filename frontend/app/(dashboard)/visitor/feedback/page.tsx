@@ -1,6 +1,7 @@
 'use client';
 
 import React, { useState, useEffect } from 'react';
+import { useSearchParams } from 'next/navigation';
 import { useAuth } from '@/lib/auth/auth-context';
 import FeedbackForm from '@/components/visitor/feedback-form';
 import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card';
@@ -26,7 +27,10 @@ export default function FeedbackPage() {
   const [feedbackHistory, setFeedbackHistory] = useState<VisitorFeedback[]>([]);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState<string | null>(null);
-  const [showNewFeedbackForm, setShowNewFeedbackForm] = useState(false);
+  // Invitations sent after a visit link here with the visit to rate
+  const searchParams = useSearchParams();
+  const invitedVisitId = Number(searchParams.get('visit_id')) || 0;
+  const [showNewFeedbackForm, setShowNewFeedbackForm] = useState(invitedVisitId > 0);
   const { toast } = useToast();
 
   useEffect(() => {
@@ -171,7 +175,7 @@ export default function FeedbackPage() {
       {/* New Feedback Form */}
       {showNewFeedbackForm && (
         <FeedbackForm
-          visitId={0} // This would need to be selected from available visits
+          visitId={invitedVisitId}
           onSubmitSuccess={handleFeedbackSubmit}
           onCancel={() => setShowNewFeedbackForm(false)}
         />